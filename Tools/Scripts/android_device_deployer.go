@@ -0,0 +1,1080 @@
+// Android Device Deployer — Install, push content, launch, and stream logcat via adb.
+// Finds the newest APK/AAB under Build/ (or uses -apk), installs it to every connected
+// device, optionally pushes hot-update bundles into the app's persistentDataPath,
+// launches the app, and streams its logcat output until Ctrl+C.
+//
+// Build: go build android_device_deployer.go
+//
+// Usage: run from the Unity project root.
+//
+//	android_device_deployer                               # newest build, all devices
+//	android_device_deployer -apk Build/Android/Game.apk -device emulator-5554
+//	android_device_deployer -bundles Bundles/Android -bundles-dest yoo
+//	android_device_deployer -no-logcat --ci               # CI: install + launch only
+//	android_device_deployer -json                         # result document on stdout
+//	android_device_deployer -dry-run                      # list the adb/bundletool commands
+//
+// AAB files are installed through bundletool (set -bundletool or BUNDLETOOL_JAR).
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Directories searched (in order) for the newest APK/AAB when -apk is not given
+var buildSearchDirs = []string{
+	"Build",
+	filepath.Join("Build", "Android"),
+}
+
+// Default logcat tags shown when the app PID cannot be resolved
+var defaultLogcatTags = []string{"Unity", "CRASH", "AndroidRuntime", "DEBUG", "libc"}
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+// dryRun lists the commands that would change a device instead of running them
+var dryRun bool
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+type deployOptions struct {
+	artifact      string
+	packageName   string
+	devices       []string
+	bundlesDir    string
+	bundlesDest   string
+	bundletoolJar string
+	clearData     bool
+	launch        bool
+	logcat        bool
+	logcatFilter  string
+}
+
+type deviceResult struct {
+	serial   string
+	steps    []string
+	err      error
+	duration time.Duration
+}
+
+// ============================================================
+// Project Detection
+// ============================================================
+
+func isUnityProject(dir string) bool {
+	for _, marker := range []string{"Assets", "ProjectSettings"} {
+		info, err := os.Stat(filepath.Join(dir, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// readAndroidPackageName reads the Android applicationIdentifier from ProjectSettings.asset
+func readAndroidPackageName(projectRoot string) (string, error) {
+	path := filepath.Join(projectRoot, "ProjectSettings", "ProjectSettings.asset")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	inBlock := false
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "applicationIdentifier:") {
+			inBlock = true
+			continue
+		}
+		if inBlock {
+			if !strings.HasPrefix(line, "    ") {
+				break
+			}
+			if strings.HasPrefix(trimmed, "Android:") {
+				return strings.TrimSpace(strings.TrimPrefix(trimmed, "Android:")), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no Android applicationIdentifier found in %s", path)
+}
+
+// findNewestArtifact returns the most recently modified .apk or .aab in the search dirs
+func findNewestArtifact(projectRoot string) (string, error) {
+	var newest string
+	var newestTime time.Time
+	for _, dir := range buildSearchDirs {
+		filepath.Walk(filepath.Join(projectRoot, dir), func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			ext := strings.ToLower(filepath.Ext(path))
+			if ext != ".apk" && ext != ".aab" {
+				return nil
+			}
+			if info.ModTime().After(newestTime) {
+				newest = path
+				newestTime = info.ModTime()
+			}
+			return nil
+		})
+	}
+	if newest == "" {
+		return "", fmt.Errorf("no .apk or .aab found under %s", strings.Join(buildSearchDirs, ", "))
+	}
+	return newest, nil
+}
+
+// ============================================================
+// adb Helpers
+// ============================================================
+
+// adb runs a command that changes the device; skipped under -dry-run
+func adb(serial string, args ...string) (string, error) {
+	return adbCommand(runner.Run, serial, args)
+}
+
+// adbQuery runs a command that only reads from the device
+func adbQuery(serial string, args ...string) (string, error) {
+	return adbCommand(runner.Output, serial, args)
+}
+
+func adbCommand(run func(context.Context, string, ...string) ([]byte, []byte, error), serial string, args []string) (string, error) {
+	full := append([]string{"-s", serial}, args...)
+	stdout, stderr, err := run(context.Background(), "adb", full...)
+	text := strings.TrimSpace(string(stdout) + string(stderr))
+	if err != nil {
+		return text, fmt.Errorf("adb %s: %v\n%s", strings.Join(args, " "), err, text)
+	}
+	return text, nil
+}
+
+// listDevices returns serials of attached devices in the "device" state
+func listDevices() ([]string, error) {
+	output, _, err := runner.Output(context.Background(), "adb", "devices")
+	if err != nil {
+		return nil, err
+	}
+	var serials []string
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == "device" {
+			serials = append(serials, fields[0])
+		}
+	}
+	return serials, nil
+}
+
+// persistentDataPath mirrors Application.persistentDataPath on Android for external storage
+func persistentDataPath(packageName string) string {
+	return "/sdcard/Android/data/" + packageName + "/files"
+}
+
+// ============================================================
+// Deploy Steps
+// ============================================================
+
+func installArtifact(opts deployOptions, serial string) error {
+	if strings.EqualFold(filepath.Ext(opts.artifact), ".aab") {
+		return installBundle(opts, serial)
+	}
+	// -r: replace existing, -d: allow version downgrade (common when iterating)
+	output, err := adb(serial, "install", "-r", "-d", opts.artifact)
+	if err != nil {
+		return err
+	}
+	if !dryRun && !strings.Contains(output, "Success") {
+		return fmt.Errorf("install did not report success:\n%s", output)
+	}
+	return nil
+}
+
+// installBundle converts an .aab into a device-specific .apks set and installs it
+func installBundle(opts deployOptions, serial string) error {
+	if opts.bundletoolJar == "" {
+		return fmt.Errorf("installing .aab requires bundletool: pass -bundletool <path/to/bundletool.jar> or set BUNDLETOOL_JAR")
+	}
+	// One file per device; a fixed name keeps recorded command fixtures replayable
+	apksPath := filepath.Join(os.TempDir(), fmt.Sprintf("deploy_%s.apks", sanitizeSerial(serial)))
+	defer os.Remove(apksPath)
+
+	ctx := context.Background()
+	stdout, stderr, err := runner.Run(ctx, "java", "-jar", opts.bundletoolJar, "build-apks",
+		"--bundle="+opts.artifact, "--output="+apksPath, "--connected-device", "--device-id="+serial, "--overwrite")
+	if err != nil {
+		return fmt.Errorf("bundletool build-apks failed: %v\n%s", err, strings.TrimSpace(string(stdout)+string(stderr)))
+	}
+
+	stdout, stderr, err = runner.Run(ctx, "java", "-jar", opts.bundletoolJar, "install-apks",
+		"--apks="+apksPath, "--device-id="+serial)
+	if err != nil {
+		return fmt.Errorf("bundletool install-apks failed: %v\n%s", err, strings.TrimSpace(string(stdout)+string(stderr)))
+	}
+	return nil
+}
+
+func sanitizeSerial(serial string) string {
+	return regexp.MustCompile(`[^A-Za-z0-9_-]`).ReplaceAllString(serial, "_")
+}
+
+// pushBundles copies the local bundle folder into persistentDataPath/<dest>
+func pushBundles(opts deployOptions, serial string) error {
+	remote := persistentDataPath(opts.packageName)
+	if opts.bundlesDest != "" {
+		remote += "/" + strings.Trim(filepath.ToSlash(opts.bundlesDest), "/")
+	}
+	if _, err := adb(serial, "shell", "mkdir", "-p", remote); err != nil {
+		return err
+	}
+	// Trailing "/." pushes the folder contents rather than the folder itself
+	_, err := adb(serial, "push", filepath.Clean(opts.bundlesDir)+string(os.PathSeparator)+".", remote)
+	return err
+}
+
+func launchApp(opts deployOptions, serial string) error {
+	output, err := adb(serial, "shell", "monkey", "-p", opts.packageName, "-c", "android.intent.category.LAUNCHER", "1")
+	if err != nil {
+		return err
+	}
+	if strings.Contains(output, "No activities found") {
+		return fmt.Errorf("no launchable activity found for %s", opts.packageName)
+	}
+	return nil
+}
+
+func deployToDevice(opts deployOptions, serial string) (res deviceResult) {
+	res.serial = serial
+	deployStart := time.Now()
+	defer func() { res.duration = time.Since(deployStart) }()
+
+	if opts.clearData {
+		if _, err := adb(serial, "shell", "pm", "clear", opts.packageName); err != nil {
+			// Not fatal: the package may not be installed yet
+			res.steps = append(res.steps, tr("[--] clear data skipped (package not installed)"))
+		} else {
+			res.steps = append(res.steps, tr("[OK] cleared app data"))
+		}
+	}
+
+	start := time.Now()
+	if err := installArtifact(opts, serial); err != nil {
+		res.err = fmt.Errorf(tr("install failed: %v"), err)
+		return res
+	}
+	res.steps = append(res.steps, fmt.Sprintf(tr("[OK] installed %s (%s)"), filepath.Base(opts.artifact), time.Since(start).Round(time.Millisecond)))
+
+	if opts.bundlesDir != "" {
+		start = time.Now()
+		if err := pushBundles(opts, serial); err != nil {
+			res.err = fmt.Errorf(tr("push bundles failed: %v"), err)
+			return res
+		}
+		res.steps = append(res.steps, fmt.Sprintf(tr("[OK] pushed bundles to %s (%s)"), persistentDataPath(opts.packageName), time.Since(start).Round(time.Millisecond)))
+	}
+
+	if opts.launch {
+		if err := launchApp(opts, serial); err != nil {
+			res.err = fmt.Errorf(tr("launch failed: %v"), err)
+			return res
+		}
+		res.steps = append(res.steps, tr("[OK] launched ")+opts.packageName)
+	}
+
+	return res
+}
+
+// ============================================================
+// Logcat Streaming
+// ============================================================
+
+// streamLogcat follows the app's log output on one device until interrupted.
+// Filters by app PID when available so system noise stays out of the stream.
+func streamLogcat(opts deployOptions, serial string) error {
+	args := []string{"-s", serial, "logcat", "-v", "time"}
+
+	if opts.logcatFilter != "" {
+		args = append(args, "-s")
+		args = append(args, strings.Split(opts.logcatFilter, ",")...)
+	} else {
+		var pid string
+		// The process needs a moment to appear after launch
+		for attempt := 0; attempt < 10; attempt++ {
+			if out, err := adbQuery(serial, "shell", "pidof", opts.packageName); err == nil && out != "" {
+				pid = strings.Fields(out)[0]
+				break
+			}
+			time.Sleep(500 * time.Millisecond)
+		}
+		if pid != "" {
+			args = append(args, "--pid="+pid)
+		} else {
+			fmt.Println(tr("[WARNING] Could not resolve app PID, falling back to tag filter"))
+			args = append(args, "-s")
+			args = append(args, defaultLogcatTags...)
+		}
+	}
+
+	fmt.Printf(tr("\n--- logcat (%s) — press Ctrl+C to stop ---\n"), serial)
+	cmd := exec.Command("adb", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case <-interrupt:
+		cmd.Process.Kill()
+		<-done
+		fmt.Println(tr("\n--- logcat stopped ---"))
+		return nil
+	case err := <-done:
+		return err
+	}
+}
+
+// ============================================================
+// Commands
+// ============================================================
+
+// commandRunner starts every external program the tool uses. Output is for
+// commands that only read (an analysis pass, a device query) and always runs;
+// Run is for commands that change something and is only noted under -dry-run.
+//
+// Two environment variables swap the runner for tests and bug reports:
+//   - UNITYSTARTER_EXEC_RECORD=<file.json> runs commands as usual and saves each
+//     one with its output and exit code
+//   - UNITYSTARTER_EXEC_REPLAY=<file.json> starts nothing and answers every
+//     command from such a file, so a run can be repeated without the programs
+//
+// The project root and the temp folder are written as $ROOT and $TMP in fixtures
+// so they replay on any machine.
+type commandRunner interface {
+	Output(ctx context.Context, name string, args ...string) (stdout, stderr []byte, err error)
+	Run(ctx context.Context, name string, args ...string) (stdout, stderr []byte, err error)
+}
+
+var runner commandRunner = execRunner{}
+
+// replayingCommands is set when commands are answered from a fixture; lookPath
+// then accepts programs that are not installed
+var replayingCommands bool
+
+// setupRunner picks the runner for this run; the dry runner it returns (nil
+// without -dry-run) lists the skipped commands with printDryRunReport
+func setupRunner(root string, dryRun bool) (*dryRunner, error) {
+	var r commandRunner = execRunner{}
+	if path := os.Getenv("UNITYSTARTER_EXEC_REPLAY"); path != "" {
+		replay, err := loadReplayRunner(path, root)
+		if err != nil {
+			return nil, err
+		}
+		r = replay
+		replayingCommands = true
+	}
+	if path := os.Getenv("UNITYSTARTER_EXEC_RECORD"); path != "" {
+		r = &recordingRunner{inner: r, path: path, hide: fixturePaths(root, false)}
+	}
+	var dry *dryRunner
+	if dryRun {
+		dry = &dryRunner{inner: r}
+		r = dry
+	}
+	runner = r
+	return dry, nil
+}
+
+// lookPath reports whether a program can be started
+func lookPath(name string) error {
+	if replayingCommands {
+		return nil
+	}
+	_, err := exec.LookPath(name)
+	return err
+}
+
+// commandLine formats a command the way it could be pasted into a shell
+func commandLine(name string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, a := range append([]string{name}, args...) {
+		if a == "" || strings.ContainsAny(a, " \t\"'") {
+			a = strconv.Quote(a)
+		}
+		parts = append(parts, a)
+	}
+	return strings.Join(parts, " ")
+}
+
+// fixturePaths swaps the machine's paths for $ROOT and $TMP, or back with restore.
+// The longer path goes first so a project inside the temp folder stays $ROOT.
+func fixturePaths(root string, restore bool) *strings.Replacer {
+	paths := [][2]string{{root, "$ROOT"}, {filepath.Clean(os.TempDir()), "$TMP"}}
+	if len(paths[1][0]) > len(paths[0][0]) {
+		paths[0], paths[1] = paths[1], paths[0]
+	}
+	var pairs []string
+	for _, p := range paths {
+		if p[0] == "" || p[0] == "." {
+			continue
+		}
+		if restore {
+			pairs = append(pairs, p[1], p[0])
+		} else {
+			pairs = append(pairs, p[0], p[1])
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// execRunner starts the real programs
+type execRunner struct{}
+
+func (execRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+func (e execRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	return e.Output(ctx, name, args...)
+}
+
+// dryRunner passes queries through and keeps the commands that would change something
+type dryRunner struct {
+	inner   commandRunner
+	mu      sync.Mutex
+	planned []string
+}
+
+func (d *dryRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	return d.inner.Output(ctx, name, args...)
+}
+
+func (d *dryRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.planned = append(d.planned, commandLine(name, args))
+	return nil, nil, nil
+}
+
+// printDryRunReport lists the commands that were skipped, in the order they came
+func (d *dryRunner) printDryRunReport() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.planned) == 0 {
+		fmt.Println(tr("\n[Dry Run] No commands would be run."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d command(s) would be run; nothing was executed:\n"), len(d.planned))
+	for _, line := range d.planned {
+		fmt.Printf("  run     %s\n", line)
+		recordAction("run", line, "dry-run", "", 0)
+	}
+}
+
+// commandRecord is one command in a fixture; Exit is -1 when it did not start
+// or was killed, with the reason in Error
+type commandRecord struct {
+	Args   []string `json:"args"`
+	Stdout string   `json:"stdout,omitempty"`
+	Stderr string   `json:"stderr,omitempty"`
+	Exit   int      `json:"exit"`
+	Error  string   `json:"error,omitempty"`
+}
+
+type commandFixture struct {
+	Commands []commandRecord `json:"commands"`
+}
+
+// recordingRunner saves every command to path, rewriting the file after each
+// one so an interrupted run still leaves a usable fixture
+type recordingRunner struct {
+	inner   commandRunner
+	path    string
+	hide    *strings.Replacer
+	mu      sync.Mutex
+	fixture commandFixture
+}
+
+func (r *recordingRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	stdout, stderr, err := r.inner.Output(ctx, name, args...)
+	r.save(name, args, stdout, stderr, err)
+	return stdout, stderr, err
+}
+
+func (r *recordingRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	stdout, stderr, err := r.inner.Run(ctx, name, args...)
+	r.save(name, args, stdout, stderr, err)
+	return stdout, stderr, err
+}
+
+func (r *recordingRunner) save(name string, args []string, stdout, stderr []byte, err error) {
+	rec := commandRecord{Stdout: r.hide.Replace(string(stdout)), Stderr: r.hide.Replace(string(stderr))}
+	for _, a := range append([]string{name}, args...) {
+		rec.Args = append(rec.Args, r.hide.Replace(a))
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		rec.Exit = exitErr.ExitCode()
+	default:
+		rec.Exit = -1
+		rec.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Commands = append(r.fixture.Commands, rec)
+	data, _ := json.MarshalIndent(r.fixture, "", "  ")
+	os.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// replayRunner answers commands from a fixture. A command that appears several
+// times (the same device query, a retried step) gets its recordings in order,
+// the last one repeating once they run out.
+type replayRunner struct {
+	hide    *strings.Replacer
+	show    *strings.Replacer
+	mu      sync.Mutex
+	records map[string][]commandRecord
+}
+
+func loadReplayRunner(path, root string) (*replayRunner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture commandFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r := &replayRunner{hide: fixturePaths(root, false), show: fixturePaths(root, true), records: make(map[string][]commandRecord)}
+	for _, rec := range fixture.Commands {
+		key := strings.Join(rec.Args, "\x00")
+		r.records[key] = append(r.records[key], rec)
+	}
+	return r, nil
+}
+
+func (r *replayRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	var keyArgs []string
+	for _, a := range append([]string{name}, args...) {
+		keyArgs = append(keyArgs, r.hide.Replace(a))
+	}
+	key := strings.Join(keyArgs, "\x00")
+
+	r.mu.Lock()
+	queue := r.records[key]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, nil, fmt.Errorf("no recorded output for: %s", commandLine(name, args))
+	}
+	rec := queue[0]
+	if len(queue) > 1 {
+		r.records[key] = queue[1:]
+	}
+	r.mu.Unlock()
+
+	stdout, stderr := []byte(r.show.Replace(rec.Stdout)), []byte(r.show.Replace(rec.Stderr))
+	switch {
+	case rec.Error != "":
+		return stdout, stderr, errors.New(rec.Error)
+	case rec.Exit != 0:
+		return stdout, stderr, fmt.Errorf("exit status %d", rec.Exit)
+	}
+	return stdout, stderr, nil
+}
+
+func (r *replayRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	return r.Output(ctx, name, args...)
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to exit..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"[--] clear data skipped (package not installed)": "[--] 已跳过清除数据 (应用未安装)",
+	"[OK] cleared app data":                           "[OK] 已清除应用数据",
+	"install failed: %v":                              "安装失败: %v",
+	"[OK] installed %s (%s)":                          "[OK] 已安装 %s (%s)",
+	"push bundles failed: %v":                         "推送资源包失败: %v",
+	"[OK] pushed bundles to %s (%s)":                  "[OK] 已推送资源包到 %s (%s)",
+	"launch failed: %v":                               "启动失败: %v",
+	"[OK] launched ":                                  "[OK] 已启动 ",
+	"[WARNING] Could not resolve app PID, falling back to tag filter": "[WARNING] 无法获取应用 PID，改用标签过滤",
+	"\n--- logcat (%s) — press Ctrl+C to stop ---\n":                  "\n--- logcat (%s) — 按 Ctrl+C 停止 ---\n",
+	"\n--- logcat stopped ---":                                        "\n--- logcat 已停止 ---",
+	"\nPress Enter to exit...":                                        "\n按回车键退出...",
+
+	"  Android Device Deployer": "  Android 设备部署工具",
+	"[ERROR] adb not found. Install Android platform-tools and add it to PATH.":        "[ERROR] 未找到 adb。请安装 Android platform-tools 并将其加入 PATH。",
+	"[ERROR] Not in a Unity project root. Pass -apk <path> explicitly.":                "[ERROR] 当前不在 Unity 项目根目录。请显式传入 -apk <路径>。",
+	"[ERROR] Build artifact not found: %s\n":                                           "[ERROR] 未找到构建产物: %s\n",
+	"[ERROR] .aab requires bundletool. Pass -bundletool <path> or set BUNDLETOOL_JAR.": "[ERROR] .aab 需要 bundletool。请传入 -bundletool <路径> 或设置 BUNDLETOOL_JAR。",
+	"[ERROR] java not found in PATH (required by bundletool).":                         "[ERROR] PATH 中未找到 java (bundletool 需要)。",
+	"[ERROR] Not in a Unity project root. Pass -package <name> explicitly.":            "[ERROR] 当前不在 Unity 项目根目录。请显式传入 -package <包名>。",
+	"[ERROR] Bundles folder not found: %s\n":                                           "[ERROR] 未找到资源包目录: %s\n",
+	"[ERROR] Failed to list devices: %v\n":                                             "[ERROR] 获取设备列表失败: %v\n",
+	"[ERROR] Device not connected or unauthorized: %s\n":                               "[ERROR] 设备未连接或未授权: %s\n",
+	"[ERROR] No Android devices connected (check USB debugging authorization).":        "[ERROR] 没有已连接的 Android 设备 (请检查 USB 调试授权)。",
+	"  Artifact: %s\n":                     "  构建产物: %s\n",
+	"  Package:  %s\n":                     "  包名:     %s\n",
+	"  Devices:  %s\n":                     "  设备:     %s\n",
+	"  Bundles:  %s -> %s/%s\n":            "  资源包:   %s -> %s/%s\n",
+	"  DEPLOY COMPLETE":                    "  部署完成",
+	"  Devices: %d succeeded, %d failed\n": "  设备: %d 个成功，%d 个失败\n",
+	"  Time:    %s\n":                      "  耗时:    %s\n",
+	"\n[TIP] Streaming logcat from %s only. Use -device to pick another.\n": "\n[TIP] 仅输出 %s 的 logcat。可使用 -device 选择其他设备。\n",
+	"[ERROR] Failed to load the command fixture: %v\n":                      "[ERROR] 加载命令录制文件失败: %v\n",
+	"\n[Dry Run] No commands would be run.":                                 "\n[Dry Run] 不会运行任何命令。",
+	"\n[Dry Run] %d command(s) would be run; nothing was executed:\n":       "\n[Dry Run] 将会运行 %d 条命令，未执行任何命令:\n",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		artifact      string
+		packageName   string
+		deviceArg     string
+		bundlesDir    string
+		bundlesDest   string
+		bundletoolJar string
+		clearData     bool
+		noLaunch      bool
+		noLogcat      bool
+		logcatFilter  string
+		ciMode        bool
+	)
+
+	flag.StringVar(&artifact, "apk", "", "APK or AAB to install (default: newest under Build/)")
+	flag.StringVar(&packageName, "package", "", "Android package name (default: from ProjectSettings.asset)")
+	flag.StringVar(&deviceArg, "device", "", "Device serial(s), comma-separated (default: all connected)")
+	flag.StringVar(&bundlesDir, "bundles", "", "Local folder of hot-update bundles to push to persistentDataPath")
+	flag.StringVar(&bundlesDest, "bundles-dest", "", "Sub-folder under persistentDataPath for pushed bundles")
+	flag.StringVar(&bundletoolJar, "bundletool", os.Getenv("BUNDLETOOL_JAR"), "Path to bundletool.jar (required for .aab)")
+	flag.BoolVar(&clearData, "clear-data", false, "Clear app data before installing")
+	flag.BoolVar(&noLaunch, "no-launch", false, "Do not launch the app after install")
+	flag.BoolVar(&noLogcat, "no-logcat", false, "Do not stream logcat after launch")
+	flag.StringVar(&logcatFilter, "logcat-filter", "", "Logcat tag filters, comma-separated (e.g. Unity:V,*:S)")
+	flag.BoolVar(&dryRun, "dry-run", false, "List the adb and bundletool commands that would change the devices (implies -no-logcat)")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (implies -no-logcat)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("android_device_deployer")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  Android Device Deployer"))
+	printRule("=============================================")
+
+	projectRoot, _ := os.Getwd()
+	dry, err := setupRunner(projectRoot, dryRun)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Failed to load the command fixture: %v\n"), err)
+		recordError("failed to load the command fixture: %v", err)
+		exit(1)
+	}
+
+	if err := lookPath("adb"); err != nil {
+		fmt.Println(tr("[ERROR] adb not found. Install Android platform-tools and add it to PATH."))
+		recordError("adb not found. Install Android platform-tools and add it to PATH")
+		exit(1)
+	}
+
+	inProject := isUnityProject(projectRoot)
+
+	// Resolve artifact
+	if artifact == "" {
+		if !inProject {
+			fmt.Println(tr("[ERROR] Not in a Unity project root. Pass -apk <path> explicitly."))
+			recordError("not in a Unity project root. Pass -apk <path> explicitly")
+			exit(1)
+		}
+		found, err := findNewestArtifact(projectRoot)
+		if err != nil {
+			fmt.Printf("[ERROR] %v\n", err)
+			recordError("%v", err)
+			exit(1)
+		}
+		artifact = found
+	}
+	if _, err := os.Stat(artifact); err != nil {
+		fmt.Printf(tr("[ERROR] Build artifact not found: %s\n"), artifact)
+		recordError("build artifact not found: %s", artifact)
+		exit(1)
+	}
+	if strings.EqualFold(filepath.Ext(artifact), ".aab") {
+		if bundletoolJar == "" {
+			fmt.Println(tr("[ERROR] .aab requires bundletool. Pass -bundletool <path> or set BUNDLETOOL_JAR."))
+			recordError(".aab requires bundletool. Pass -bundletool <path> or set BUNDLETOOL_JAR")
+			exit(1)
+		}
+		if err := lookPath("java"); err != nil {
+			fmt.Println(tr("[ERROR] java not found in PATH (required by bundletool)."))
+			recordError("java not found in PATH (required by bundletool)")
+			exit(1)
+		}
+	}
+
+	// Resolve package name
+	if packageName == "" {
+		if !inProject {
+			fmt.Println(tr("[ERROR] Not in a Unity project root. Pass -package <name> explicitly."))
+			recordError("not in a Unity project root. Pass -package <name> explicitly")
+			exit(1)
+		}
+		name, err := readAndroidPackageName(projectRoot)
+		if err != nil {
+			fmt.Printf("[ERROR] %v\n", err)
+			recordError("%v", err)
+			exit(1)
+		}
+		packageName = name
+	}
+
+	if bundlesDir != "" {
+		if info, err := os.Stat(bundlesDir); err != nil || !info.IsDir() {
+			fmt.Printf(tr("[ERROR] Bundles folder not found: %s\n"), bundlesDir)
+			recordError("bundles folder not found: %s", bundlesDir)
+			exit(1)
+		}
+	}
+
+	// Resolve devices
+	connected, err := listDevices()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Failed to list devices: %v\n"), err)
+		recordError("failed to list devices: %v", err)
+		exit(1)
+	}
+	var devices []string
+	if deviceArg != "" {
+		connectedSet := make(map[string]bool, len(connected))
+		for _, s := range connected {
+			connectedSet[s] = true
+		}
+		for _, s := range strings.Split(deviceArg, ",") {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				continue
+			}
+			if !connectedSet[s] {
+				fmt.Printf(tr("[ERROR] Device not connected or unauthorized: %s\n"), s)
+				recordError("device not connected or unauthorized: %s", s)
+				exit(1)
+			}
+			devices = append(devices, s)
+		}
+	} else {
+		devices = connected
+	}
+	if len(devices) == 0 {
+		fmt.Println(tr("[ERROR] No Android devices connected (check USB debugging authorization)."))
+		recordError("no Android devices connected (check USB debugging authorization)")
+		exit(1)
+	}
+
+	opts := deployOptions{
+		artifact:      artifact,
+		packageName:   packageName,
+		devices:       devices,
+		bundlesDir:    bundlesDir,
+		bundlesDest:   bundlesDest,
+		bundletoolJar: bundletoolJar,
+		clearData:     clearData,
+		launch:        !noLaunch,
+		logcat:        !noLogcat && !noLaunch && !ciMode && !dryRun,
+		logcatFilter:  logcatFilter,
+	}
+
+	fmt.Printf(tr("  Artifact: %s\n"), opts.artifact)
+	fmt.Printf(tr("  Package:  %s\n"), opts.packageName)
+	fmt.Printf(tr("  Devices:  %s\n"), strings.Join(opts.devices, ", "))
+	if opts.bundlesDir != "" {
+		fmt.Printf(tr("  Bundles:  %s -> %s/%s\n"), opts.bundlesDir, persistentDataPath(opts.packageName), opts.bundlesDest)
+	}
+
+	// Deploy devices in parallel; output is collected and printed per device
+	startTime := time.Now()
+	results := make(chan deviceResult, len(devices))
+	for _, serial := range devices {
+		go func(s string) { results <- deployToDevice(opts, s) }(serial)
+	}
+
+	var failed int
+	ordered := make(map[string]deviceResult, len(devices))
+	for range devices {
+		r := <-results
+		ordered[r.serial] = r
+	}
+	fmt.Println()
+	for _, serial := range devices {
+		r := ordered[serial]
+		fmt.Printf("[%s]\n", serial)
+		for _, step := range r.steps {
+			fmt.Printf("  %s\n", step)
+		}
+		if r.err != nil {
+			fmt.Printf("  [FAIL] %v\n", r.err)
+			recordAction("deploy", serial, "failed", r.err.Error(), r.duration)
+			recordError("%s: %v", serial, r.err)
+			failed++
+		} else {
+			recordAction("deploy", serial, "ok", filepath.Base(opts.artifact), r.duration)
+		}
+	}
+
+	printRule("\n===========================================")
+	fmt.Println(tr("  DEPLOY COMPLETE"))
+	printRule("===========================================")
+	fmt.Printf(tr("  Devices: %d succeeded, %d failed\n"), len(devices)-failed, failed)
+	fmt.Printf(tr("  Time:    %s\n"), time.Since(startTime).Round(time.Millisecond))
+	if dry != nil {
+		dry.printDryRunReport()
+	}
+
+	if failed > 0 {
+		exit(1)
+	}
+
+	if opts.logcat {
+		first := devices[0]
+		if len(devices) > 1 {
+			fmt.Printf(tr("\n[TIP] Streaming logcat from %s only. Use -device to pick another.\n"), first)
+		}
+		if err := streamLogcat(opts, first); err != nil {
+			fmt.Printf("[ERROR] logcat: %v\n", err)
+			recordError("logcat: %v", err)
+			exit(1)
+		}
+		return
+	}
+
+	exit(0)
+}
@@ -4,21 +4,32 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"html/template"
+	"io"
 	"log"
 	"math"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
+	"unicode/utf8"
 )
 
 // --- CONFIGURATION PARAMETERS ---
@@ -92,163 +103,2889 @@ var ErrAlreadyNormalized = errors.New("file is already within the target loudnes
 type outputFormat struct {
 	name      string   // Display name
 	ext       string   // File extension (with dot)
-	ffmpegArgs []string // ffmpeg codec/quality arguments
+	encoders  []audioEncoder // ffmpeg encoders that can write it, best first
+	ffmpegArgs []string // ffmpeg codec/quality arguments of the encoder in use
 }
 
 var (
 	formatWAV = outputFormat{
-		name:      "WAV (Lossless PCM 16-bit)",
-		ext:       ".wav",
-		ffmpegArgs: []string{"-c:a", "pcm_s16le"},
+		name:     "WAV (Lossless PCM 16-bit)",
+		ext:      ".wav",
+		encoders: []audioEncoder{encoderPCM16},
 	}
 	formatOGG = outputFormat{
-		name:      "OGG (Vorbis VBR Quality 6)",
-		ext:       ".ogg",
-		ffmpegArgs: []string{"-c:a", "libvorbis", "-q:a", "6"},
+		name:     "OGG (Vorbis VBR Quality 6)",
+		ext:      ".ogg",
+		encoders: []audioEncoder{encoderLibVorbis, encoderVorbis},
 	}
+	formatFLAC = outputFormat{
+		name:     "FLAC (Lossless 16-bit)",
+		ext:      ".flac",
+		encoders: []audioEncoder{encoderFLAC16},
+	}
+)
+
+// selectedFormat is set during startup based on user choice.
+var selectedFormat outputFormat
+
+// dryRun runs the analysis passes but only lists the encodes (-dry-run).
+var dryRun bool
+
+type LoudnormInfo struct {
+	InputI       string `json:"input_i"`
+	InputTP      string `json:"input_tp"`
+	InputLRA     string `json:"input_lra"`
+	InputThresh  string `json:"input_thresh"`
+	TargetOffset string `json:"target_offset"`
+}
+
+type job struct {
+	path string
+}
+
+// NEW: result struct to hold processing outcome.
+type result struct {
+	path     string
+	err      error
+	duration time.Duration
+	level    *loudness
+}
+
+// NEW: This function displays the intro and asks for user confirmation.
+func displayIntroAndConfirm() bool {
+	scanner := bufio.NewScanner(os.Stdin)
+
+	fmt.Println(tr("--- LoudNorm: Game Audio Normalizer ---"))
+	fmt.Println(tr("\n[ About This Tool ]"))
+	fmt.Println(tr("This tool normalizes audio files for optimal game audio integration."))
+	fmt.Println(tr("It automatically detects audio category from folder names and applies"))
+	fmt.Println(tr("appropriate normalization strategies."))
+
+	fmt.Println(tr("\n[ Normalization Strategies ]"))
+	fmt.Println(tr("  Long audio (>= 3s): Two-pass LUFS loudness normalization (linear mode)"))
+	fmt.Println(tr("  Short audio (< 3s): Peak normalization (LUFS is unreliable for short SFX)"))
+
+	fmt.Println(tr("\n[ Category Targets (auto-detected from folder name) ]"))
+	fmt.Printf(tr("  Music/BGM:     %5.1f LUFS | Peak: %.1f dBTP\n"), categoryMusic.targetLUFS, categoryMusic.targetPeak)
+	fmt.Printf(tr("  Voice/Dialog:  %5.1f LUFS | Peak: %.1f dBTP\n"), categoryVoice.targetLUFS, categoryVoice.targetPeak)
+	fmt.Printf(tr("  SFX/SE:        %5.1f LUFS | Peak: %.1f dBTP\n"), categorySFX.targetLUFS, categorySFX.targetPeak)
+	fmt.Printf(tr("  Ambient/Env:   %5.1f LUFS | Peak: %.1f dBTP\n"), categoryAmbient.targetLUFS, categoryAmbient.targetPeak)
+	fmt.Printf(tr("  Default:       %5.1f LUFS | Peak: %.1f dBTP\n"), categoryDefault.targetLUFS, categoryDefault.targetPeak)
+
+	// --- Output Format Selection ---
+	fmt.Println(tr("\n[ Output Format ]"))
+	fmt.Println(tr("  1. WAV  - Lossless (recommended: let Unity handle final compression)"))
+	fmt.Println(tr("  2. OGG  - Vorbis VBR (smaller files, use when disk/memory matters)"))
+	fmt.Println(tr("  3. FLAC - Lossless masters kept outside Unity (Unity does not import .flac)"))
+	fmt.Print(tr("\nSelect output format (1, 2 or 3) [default: 1]: "))
+	scanner.Scan()
+	formatChoice := strings.TrimSpace(scanner.Text())
+	switch formatChoice {
+	case "2":
+		selectedFormat = formatOGG
+	case "3":
+		selectedFormat = formatFLAC
+	default:
+		selectedFormat = formatWAV
+	}
+	fmt.Printf(tr("  -> Selected: %s\n"), selectedFormat.name)
+
+	fmt.Println(tr("\n[ How It Works ]"))
+	fmt.Println(tr("1. It will recursively scan the current directory for audio files."))
+	fmt.Printf(tr("2. For each audio file, it will create a new '%s' file with the '%s' suffix.\n"), selectedFormat.ext, FILENAME_SUFFIX)
+	if selectedFormat.ext == ".wav" {
+		fmt.Println(tr("3. Output is lossless WAV to avoid double compression when Unity re-encodes on import."))
+	} else if selectedFormat.ext == ".flac" {
+		fmt.Println(tr("3. Output is lossless FLAC for masters; move the files out of Assets, Unity does not import them."))
+	} else {
+		fmt.Println(tr("3. Output is OGG Vorbis — smaller files, but may double-compress if Unity re-encodes."))
+	}
+	fmt.Printf(tr("4. Existing '%s%s' files will be overwritten.\n"), FILENAME_SUFFIX, selectedFormat.ext)
+	fmt.Println(tr("5. IMPORTANT: This tool requires FFmpeg to be installed and accessible in your system's PATH."))
+
+	fmt.Print(tr("\nDo you want to proceed? (Y/N): "))
+	scanner.Scan()
+	response := strings.TrimSpace(scanner.Text())
+
+	return strings.ToLower(response) == "y"
+}
+
+// waitForExit function pauses until the user presses Enter.
+func waitForExit() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to exit..."))
+	bufio.NewReader(os.Stdin).ReadBytes('\n')
+}
+
+// ============================================================
+// Progress
+// ============================================================
+
+// progressBoard shows the progress of work spread over goroutines: one line
+// per task, a bar when the task's total is known and a spinner when it is not.
+// Every method is safe to call from any goroutine; log lines printed through
+// printf appear above the bars instead of tearing them.
+//
+// How it draws depends on where stdout goes:
+//   - a terminal with ANSI support: every task on its own line, redrawn in place
+//   - the classic Windows console: one line redrawn with \r, showing the first
+//     running task and the item the first step is working on
+//   - a file, a pipe or -plain: nothing is redrawn; each bar prints a line per
+//     10%, and a task without a total prints a "still running" line every 15
+//     seconds in place of the spinner
+//
+// close stops drawing and leaves one line per task as the summary.
+type progressBoard struct {
+	mu    sync.Mutex
+	tasks []*progressTask
+	mode  int
+	drawn int // progressLines: lines drawn; progressLine: width of the line
+	frame int
+	stop  chan struct{}
+	wg    sync.WaitGroup
+	done  sync.Once // guards close
+}
+
+// progressTask is one line of the board. Steps are short-lived items (a file
+// being converted, a folder being deleted) shown while they run and left out
+// of the summary.
+type progressTask struct {
+	board   *progressBoard
+	name    string
+	current int64
+	total   int64 // 0 when unknown
+	bytes   bool  // current and total are sizes
+	failed  int
+	step    bool
+	started time.Time
+	ended   time.Time
+	logged  int       // progressLog: last 10% step printed
+	beat    time.Time // progressLog: last "still running" line
+}
+
+const (
+	progressLines = iota // ANSI terminal
+	progressLine         // terminal without cursor movement
+	progressLog          // no terminal, or plain mode
+)
+
+const (
+	progressRedraw    = 100 * time.Millisecond
+	progressHeartbeat = 15 * time.Second
+	progressBarWidth  = 30
+	progressNameWidth = 24
+	progressMaxSteps  = 8  // step lines shown at once; the rest are counted
+	progressLineWidth = 79 // progressLine: longer lines wrap and break \r
+)
+
+var progressSpinner = []string{"|", "/", "-", `\`}
+
+// detectProgressMode picks how the board draws on the current stdout
+func detectProgressMode() int {
+	if plainMode {
+		return progressLog
+	}
+	info, err := os.Stdout.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return progressLog
+	}
+	// Windows Terminal, ConEmu and terminals inside editors handle escapes;
+	// the classic console only does when a program switches it on
+	if runtime.GOOS != "windows" || os.Getenv("WT_SESSION") != "" || os.Getenv("TERM_PROGRAM") != "" || os.Getenv("ConEmuANSI") == "ON" {
+		return progressLines
+	}
+	return progressLine
+}
+
+func newProgressBoard() *progressBoard {
+	b := &progressBoard{mode: detectProgressMode(), stop: make(chan struct{})}
+	interval := progressRedraw
+	if b.mode == progressLog {
+		interval = time.Second
+	}
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-b.stop:
+				return
+			case <-ticker.C:
+				b.mu.Lock()
+				b.frame++
+				b.render()
+				b.mu.Unlock()
+			}
+		}
+	}()
+	return b
+}
+
+// add starts a task of total items; 0 draws a spinner until setTotal
+func (b *progressBoard) add(name string, total int64) *progressTask {
+	return b.addTask(&progressTask{name: name, total: total})
+}
+
+// addSize starts a task measured in bytes
+func (b *progressBoard) addSize(name string, total int64) *progressTask {
+	return b.addTask(&progressTask{name: name, total: total, bytes: true})
+}
+
+// addStep shows one item while it is being worked on; call finish when done
+func (b *progressBoard) addStep(name string) *progressTask {
+	return b.addTask(&progressTask{name: name, step: true})
+}
+
+// addSizeStep is a step that counts bytes, such as a folder being measured
+func (b *progressBoard) addSizeStep(name string) *progressTask {
+	return b.addTask(&progressTask{name: name, step: true, bytes: true})
+}
+
+func (b *progressBoard) addTask(t *progressTask) *progressTask {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t.board = b
+	t.started = time.Now()
+	t.beat = t.started
+	b.tasks = append(b.tasks, t)
+	return t
+}
+
+// printf prints a log line above the board
+func (b *progressBoard) printf(format string, a ...interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.erase()
+	fmt.Printf(format, a...)
+	b.render()
+}
+
+// close stops drawing and prints the final line of every task that is not a step.
+// Only the first call does anything; concurrent callers wait for it to finish.
+func (b *progressBoard) close() {
+	b.done.Do(func() {
+		close(b.stop)
+		b.wg.Wait()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.erase()
+		for _, t := range b.tasks {
+			if !t.step {
+				fmt.Println(t.line(b.frame, true))
+			}
+		}
+	})
+}
+
+func (t *progressTask) advance(n int64) {
+	if t == nil {
+		return
+	}
+	t.board.mu.Lock()
+	t.current += n
+	t.board.mu.Unlock()
+}
+
+func (t *progressTask) setTotal(total int64) {
+	if t == nil {
+		return
+	}
+	t.board.mu.Lock()
+	t.total = total
+	t.board.mu.Unlock()
+}
+
+// fail counts a failed item; failures show on the task's line
+func (t *progressTask) fail() {
+	if t == nil {
+		return
+	}
+	t.board.mu.Lock()
+	t.failed++
+	t.board.mu.Unlock()
+}
+
+func (t *progressTask) finish() {
+	if t == nil {
+		return
+	}
+	t.board.mu.Lock()
+	defer t.board.mu.Unlock()
+	if t.ended.IsZero() {
+		t.ended = time.Now()
+	}
+	if t.step {
+		b := t.board
+		for i, other := range b.tasks {
+			if other == t {
+				b.tasks = append(b.tasks[:i], b.tasks[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// amount is "12/40", "1.20 GB/3.00 GB" or, without a total, the count so far
+func (t *progressTask) amount() string {
+	format := func(n int64) string {
+		if t.bytes {
+			return formatSize(n)
+		}
+		return strconv.FormatInt(n, 10)
+	}
+	if t.total > 0 {
+		return format(t.current) + "/" + format(t.total)
+	}
+	return format(t.current)
+}
+
+// line renders the task; final is the summary form after close
+func (t *progressTask) line(frame int, final bool) string {
+	name := t.name
+	if r := []rune(name); len(r) > progressNameWidth {
+		name = "..." + string(r[len(r)-progressNameWidth+3:])
+	}
+	var sb strings.Builder
+	if t.board.mode == progressLog {
+		sb.WriteString(name + ": ")
+	} else {
+		fmt.Fprintf(&sb, "%-*s ", progressNameWidth, name)
+	}
+	end := t.ended
+	if end.IsZero() {
+		end = time.Now()
+	}
+	elapsed := end.Sub(t.started).Round(100 * time.Millisecond)
+	switch {
+	case t.step:
+		sb.WriteString(progressSpinner[frame%len(progressSpinner)])
+		if t.current > 0 {
+			sb.WriteString(" " + t.amount())
+		}
+		sb.WriteString(" " + elapsed.Round(time.Second).String())
+		return sb.String()
+	case t.total > 0 && t.board.mode == progressLog:
+		// Screen readers and log viewers get the numbers without the bar
+		fmt.Fprintf(&sb, "%s (%.0f%%)", t.amount(), math.Min(float64(t.current)/float64(t.total), 1)*100)
+	case t.total > 0:
+		percent := math.Min(float64(t.current)/float64(t.total), 1)
+		filled := int(percent * progressBarWidth)
+		fmt.Fprintf(&sb, "[%s%s] %3.0f%% (%s)", strings.Repeat("█", filled), strings.Repeat("-", progressBarWidth-filled), percent*100, t.amount())
+	case final || !t.ended.IsZero():
+		fmt.Fprintf(&sb, tr("done, %s"), t.amount())
+	default:
+		fmt.Fprintf(&sb, "%s %s", progressSpinner[frame%len(progressSpinner)], t.amount())
+	}
+	if t.failed > 0 {
+		fmt.Fprintf(&sb, tr(", %d failed"), t.failed)
+	}
+	if final || !t.ended.IsZero() {
+		fmt.Fprintf(&sb, tr(" in %s"), elapsed)
+	}
+	return sb.String()
+}
+
+// erase removes what the last render drew; called with mu held
+func (b *progressBoard) erase() {
+	switch b.mode {
+	case progressLines:
+		if b.drawn > 0 {
+			fmt.Printf("\x1b[%dA\x1b[J", b.drawn)
+		}
+	case progressLine:
+		if b.drawn > 0 {
+			fmt.Printf("\r%s\r", strings.Repeat(" ", b.drawn))
+		}
+	}
+	b.drawn = 0
+}
+
+// render draws the board; called with mu held
+func (b *progressBoard) render() {
+	select {
+	case <-b.stop:
+		return
+	default:
+	}
+	switch b.mode {
+	case progressLines:
+		var sb strings.Builder
+		if b.drawn > 0 {
+			fmt.Fprintf(&sb, "\x1b[%dA", b.drawn)
+		}
+		lines, steps := 0, 0
+		for _, t := range b.tasks {
+			if t.step {
+				if steps++; steps > progressMaxSteps {
+					continue
+				}
+			}
+			sb.WriteString("\r\x1b[2K" + t.line(b.frame, false) + "\n")
+			lines++
+		}
+		if steps > progressMaxSteps {
+			sb.WriteString("\r\x1b[2K" + fmt.Sprintf(tr("  ... and %d more"), steps-progressMaxSteps) + "\n")
+			lines++
+		}
+		sb.WriteString("\x1b[J")
+		fmt.Print(sb.String())
+		b.drawn = lines
+	case progressLine:
+		var main, step *progressTask
+		for _, t := range b.tasks {
+			if t.step && step == nil {
+				step = t
+			} else if !t.step && main == nil && t.ended.IsZero() {
+				main = t
+			}
+		}
+		var text string
+		if main != nil {
+			text = main.line(b.frame, false)
+		}
+		if step != nil {
+			text += "  " + progressSpinner[b.frame%len(progressSpinner)] + " " + step.name
+		}
+		if r := []rune(text); len(r) > progressLineWidth {
+			text = string(r[:progressLineWidth])
+		}
+		width := len([]rune(text))
+		pad := ""
+		if b.drawn > width {
+			pad = strings.Repeat(" ", b.drawn-width)
+		}
+		fmt.Printf("\r%s%s", text, pad)
+		b.drawn = width
+	case progressLog:
+		for _, t := range b.tasks {
+			switch {
+			case t.total > 0 && !t.step:
+				// 100% is left to the summary line of close
+				if s := int(t.current * 10 / t.total); s > t.logged && s < 10 {
+					t.logged = s
+					fmt.Println(t.line(b.frame, false))
+				}
+			case time.Since(t.beat) >= progressHeartbeat && t.ended.IsZero():
+				t.beat = time.Now()
+				detail := time.Since(t.started).Round(time.Second).String()
+				if t.current > 0 {
+					detail = t.amount() + ", " + detail
+				}
+				fmt.Printf(tr("%s: still running (%s)\n"), t.name, detail)
+			}
+		}
+	}
+}
+
+// ============================================================
+// Commands
+// ============================================================
+
+// commandRunner starts every external program the tool uses. Output is for
+// commands that only read (an analysis pass, a device query) and always runs;
+// Run is for commands that change something and is only noted under -dry-run.
+//
+// Two environment variables swap the runner for tests and bug reports:
+//   - UNITYSTARTER_EXEC_RECORD=<file.json> runs commands as usual and saves each
+//     one with its output and exit code
+//   - UNITYSTARTER_EXEC_REPLAY=<file.json> starts nothing and answers every
+//     command from such a file, so a run can be repeated without the programs
+//
+// The project root and the temp folder are written as $ROOT and $TMP in fixtures
+// so they replay on any machine.
+type commandRunner interface {
+	Output(ctx context.Context, name string, args ...string) (stdout, stderr []byte, err error)
+	Run(ctx context.Context, name string, args ...string) (stdout, stderr []byte, err error)
+}
+
+var runner commandRunner = execRunner{}
+
+// replayingCommands is set when commands are answered from a fixture; lookPath
+// then accepts programs that are not installed
+var replayingCommands bool
+
+// setupRunner picks the runner for this run; the dry runner it returns (nil
+// without -dry-run) lists the skipped commands with printDryRunReport
+func setupRunner(root string, dryRun bool) (*dryRunner, error) {
+	var r commandRunner = execRunner{}
+	if path := os.Getenv("UNITYSTARTER_EXEC_REPLAY"); path != "" {
+		replay, err := loadReplayRunner(path, root)
+		if err != nil {
+			return nil, err
+		}
+		r = replay
+		replayingCommands = true
+	}
+	if path := os.Getenv("UNITYSTARTER_EXEC_RECORD"); path != "" {
+		r = &recordingRunner{inner: r, path: path, hide: fixturePaths(root, false)}
+	}
+	var dry *dryRunner
+	if dryRun {
+		dry = &dryRunner{inner: r}
+		r = dry
+	}
+	runner = r
+	return dry, nil
+}
+
+// lookPath reports whether a program can be started
+func lookPath(name string) error {
+	if replayingCommands {
+		return nil
+	}
+	_, err := exec.LookPath(name)
+	return err
+}
+
+// commandLine formats a command the way it could be pasted into a shell
+func commandLine(name string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, a := range append([]string{name}, args...) {
+		if a == "" || strings.ContainsAny(a, " \t\"'") {
+			a = strconv.Quote(a)
+		}
+		parts = append(parts, a)
+	}
+	return strings.Join(parts, " ")
+}
+
+// fixturePaths swaps the machine's paths for $ROOT and $TMP, or back with restore.
+// The longer path goes first so a project inside the temp folder stays $ROOT.
+func fixturePaths(root string, restore bool) *strings.Replacer {
+	paths := [][2]string{{root, "$ROOT"}, {filepath.Clean(os.TempDir()), "$TMP"}}
+	if len(paths[1][0]) > len(paths[0][0]) {
+		paths[0], paths[1] = paths[1], paths[0]
+	}
+	var pairs []string
+	for _, p := range paths {
+		if p[0] == "" || p[0] == "." {
+			continue
+		}
+		if restore {
+			pairs = append(pairs, p[1], p[0])
+		} else {
+			pairs = append(pairs, p[0], p[1])
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// execRunner starts the real programs
+type execRunner struct{}
+
+func (execRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+func (e execRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	return e.Output(ctx, name, args...)
+}
+
+// dryRunner passes queries through and keeps the commands that would change something
+type dryRunner struct {
+	inner   commandRunner
+	mu      sync.Mutex
+	planned []string
+}
+
+func (d *dryRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	return d.inner.Output(ctx, name, args...)
+}
+
+func (d *dryRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.planned = append(d.planned, commandLine(name, args))
+	return nil, nil, nil
+}
+
+// printDryRunReport lists the commands that were skipped, in the order they came
+func (d *dryRunner) printDryRunReport() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.planned) == 0 {
+		fmt.Println(tr("\n[Dry Run] No commands would be run."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d command(s) would be run; nothing was executed:\n"), len(d.planned))
+	for _, line := range d.planned {
+		fmt.Printf("  run     %s\n", line)
+		recordAction("run", line, "dry-run", "", 0)
+	}
+}
+
+// commandRecord is one command in a fixture; Exit is -1 when it did not start
+// or was killed, with the reason in Error
+type commandRecord struct {
+	Args   []string `json:"args"`
+	Stdout string   `json:"stdout,omitempty"`
+	Stderr string   `json:"stderr,omitempty"`
+	Exit   int      `json:"exit"`
+	Error  string   `json:"error,omitempty"`
+}
+
+type commandFixture struct {
+	Commands []commandRecord `json:"commands"`
+}
+
+// recordingRunner saves every command to path, rewriting the file after each
+// one so an interrupted run still leaves a usable fixture
+type recordingRunner struct {
+	inner   commandRunner
+	path    string
+	hide    *strings.Replacer
+	mu      sync.Mutex
+	fixture commandFixture
+}
+
+func (r *recordingRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	stdout, stderr, err := r.inner.Output(ctx, name, args...)
+	r.save(name, args, stdout, stderr, err)
+	return stdout, stderr, err
+}
+
+func (r *recordingRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	stdout, stderr, err := r.inner.Run(ctx, name, args...)
+	r.save(name, args, stdout, stderr, err)
+	return stdout, stderr, err
+}
+
+func (r *recordingRunner) save(name string, args []string, stdout, stderr []byte, err error) {
+	rec := commandRecord{Stdout: r.hide.Replace(string(stdout)), Stderr: r.hide.Replace(string(stderr))}
+	for _, a := range append([]string{name}, args...) {
+		rec.Args = append(rec.Args, r.hide.Replace(a))
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		rec.Exit = exitErr.ExitCode()
+	default:
+		rec.Exit = -1
+		rec.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Commands = append(r.fixture.Commands, rec)
+	data, _ := json.MarshalIndent(r.fixture, "", "  ")
+	os.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// replayRunner answers commands from a fixture. A command that appears several
+// times (the same device query, a retried step) gets its recordings in order,
+// the last one repeating once they run out.
+type replayRunner struct {
+	hide    *strings.Replacer
+	show    *strings.Replacer
+	mu      sync.Mutex
+	records map[string][]commandRecord
+}
+
+func loadReplayRunner(path, root string) (*replayRunner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture commandFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r := &replayRunner{hide: fixturePaths(root, false), show: fixturePaths(root, true), records: make(map[string][]commandRecord)}
+	for _, rec := range fixture.Commands {
+		key := strings.Join(rec.Args, "\x00")
+		r.records[key] = append(r.records[key], rec)
+	}
+	return r, nil
+}
+
+func (r *replayRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	var keyArgs []string
+	for _, a := range append([]string{name}, args...) {
+		keyArgs = append(keyArgs, r.hide.Replace(a))
+	}
+	key := strings.Join(keyArgs, "\x00")
+
+	r.mu.Lock()
+	queue := r.records[key]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, nil, fmt.Errorf("no recorded output for: %s", commandLine(name, args))
+	}
+	rec := queue[0]
+	if len(queue) > 1 {
+		r.records[key] = queue[1:]
+	}
+	r.mu.Unlock()
+
+	stdout, stderr := []byte(r.show.Replace(rec.Stdout)), []byte(r.show.Replace(rec.Stderr))
+	switch {
+	case rec.Error != "":
+		return stdout, stderr, errors.New(rec.Error)
+	case rec.Exit != 0:
+		return stdout, stderr, fmt.Errorf("exit status %d", rec.Exit)
+	}
+	return stdout, stderr, nil
+}
+
+func (r *replayRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	return r.Output(ctx, name, args...)
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"--- LoudNorm: Game Audio Normalizer ---":                                                       "--- LoudNorm: 游戏音频响度标准化 ---",
+	"\n[ About This Tool ]":                                                                         "\n[ 关于本工具 ]",
+	"This tool normalizes audio files for optimal game audio integration.":                          "本工具对音频文件进行响度标准化，使其更适合在游戏中使用。",
+	"It automatically detects audio category from folder names and applies":                         "它会根据文件夹名称自动识别音频类别，并采用",
+	"appropriate normalization strategies.":                                                         "相应的标准化策略。",
+	"\n[ Normalization Strategies ]":                                                                "\n[ 标准化策略 ]",
+	"  Long audio (>= 3s): Two-pass LUFS loudness normalization (linear mode)":                      "  长音频 (>= 3s): 两遍 LUFS 响度标准化 (线性模式)",
+	"  Short audio (< 3s): Peak normalization (LUFS is unreliable for short SFX)":                   "  短音频 (< 3s): 峰值标准化 (LUFS 对短音效不可靠)",
+	"\n[ Category Targets (auto-detected from folder name) ]":                                       "\n[ 类别目标 (根据文件夹名称自动识别) ]",
+	"  Music/BGM:     %5.1f LUFS | Peak: %.1f dBTP\n":                                               "  音乐/BGM:      %5.1f LUFS | 峰值: %.1f dBTP\n",
+	"  Voice/Dialog:  %5.1f LUFS | Peak: %.1f dBTP\n":                                               "  语音/对白:     %5.1f LUFS | 峰值: %.1f dBTP\n",
+	"  SFX/SE:        %5.1f LUFS | Peak: %.1f dBTP\n":                                               "  音效/SE:       %5.1f LUFS | 峰值: %.1f dBTP\n",
+	"  Ambient/Env:   %5.1f LUFS | Peak: %.1f dBTP\n":                                               "  环境音:        %5.1f LUFS | 峰值: %.1f dBTP\n",
+	"  Default:       %5.1f LUFS | Peak: %.1f dBTP\n":                                               "  默认:          %5.1f LUFS | 峰值: %.1f dBTP\n",
+	"\n[ Output Format ]":                                                                           "\n[ 输出格式 ]",
+	"  1. WAV  - Lossless (recommended: let Unity handle final compression)":                        "  1. WAV  - 无损 (推荐: 由 Unity 负责最终压缩)",
+	"  2. OGG  - Vorbis VBR (smaller files, use when disk/memory matters)":                          "  2. OGG  - Vorbis VBR (文件更小，适用于磁盘/内存受限时)",
+	"\nSelect output format (1, 2 or 3) [default: 1]: ":                                             "\n选择输出格式 (1、2 或 3) [默认: 1]: ",
+	"  -> Selected: %s\n":                                                                           "  -> 已选择: %s\n",
+	"\n[ How It Works ]":                                                                            "\n[ 工作方式 ]",
+	"1. It will recursively scan the current directory for audio files.":                            "1. 递归扫描当前目录中的音频文件。",
+	"2. For each audio file, it will create a new '%s' file with the '%s' suffix.\n":                "2. 为每个音频文件生成一个带 '%[2]s' 后缀的新 '%[1]s' 文件。\n",
+	"3. Output is lossless WAV to avoid double compression when Unity re-encodes on import.":        "3. 输出为无损 WAV，避免 Unity 导入时重新编码造成二次压缩。",
+	"3. Output is OGG Vorbis — smaller files, but may double-compress if Unity re-encodes.":         "3. 输出为 OGG Vorbis — 文件更小，但 Unity 重新编码时可能造成二次压缩。",
+	"4. Existing '%s%s' files will be overwritten.\n":                                               "4. 已存在的 '%s%s' 文件将被覆盖。\n",
+	"5. IMPORTANT: This tool requires FFmpeg to be installed and accessible in your system's PATH.": "5. 重要: 本工具需要安装 FFmpeg 并将其加入系统 PATH。",
+	"\nDo you want to proceed? (Y/N): ":                                                             "\n是否继续？(Y/N): ",
+	"\nPress Enter to exit...":                                                                      "\n按回车键退出...",
+
+	"Operation cancelled by user.":          "用户已取消操作。",
+	"\nUser confirmed. Starting process...": "\n用户已确认，开始处理...",
+	"Error: Could not find ffmpeg. Please ensure FFmpeg is installed and added to your system's PATH.": "错误: 未找到 ffmpeg。请确认已安装 FFmpeg 并将其加入系统 PATH。",
+	"Failed to get current working directory: %v\n":                                                    "获取当前工作目录失败: %v\n",
+	"Scanning for audio files in [%s] and its subdirectories...\n":                                     "正在扫描 [%s] 及其子目录中的音频文件...\n",
+	"Error during initial file scan: %v\n":                                                             "初次扫描文件时出错: %v\n",
+	"No audio files found to process.":                                                                 "未找到需要处理的音频文件。",
+	"Found %d audio files to process.\n\n":                                                             "找到 %d 个待处理的音频文件。\n\n",
+	"Normalizing":                                                                                      "标准化",
+	"done, %s":                                                                                         "完成，%s",
+	", %d failed":                                                                                      "，%d 个失败",
+	" in %s":                                                                                           "，用时 %s",
+	"  ... and %d more":                                                                                "  ... 另有 %d 项",
+	"%s: still running (%s)\n":                                                                         "%s: 仍在运行 (%s)\n",
+	"\nAll tasks completed!":                                                                           "\n所有任务已完成！",
+	"\n--- Processing Summary ---":                                                                     "\n--- 处理摘要 ---",
+	"\nSuccessfully processed %d files:\n":                                                             "\n成功处理 %d 个文件:\n",
+	"  (None)":                                                                                         "  (无)",
+	"\nSkipped %d files (already normalized):\n":                                                       "\n跳过 %d 个文件 (已符合标准):\n",
+	"\nFailed to process %d files:\n":                                                                  "\n处理失败 %d 个文件:\n",
+	"  - %s\n    Error: %v\n":                                                                          "  - %s\n    错误: %v\n",
+
+	"[WARNING] Notifications are off: %v\n":                                                 "[WARNING] 通知已关闭: %v\n",
+	"[WARNING] Skipping webhook in %s: the URL is empty or not http(s) (unset variable?)\n": "[WARNING] 跳过 %s 中的 webhook: URL 为空或不是 http(s)（变量未设置？）\n",
+	"[WARNING] Skipping webhook %s: unknown format '%s' (use slack, discord or json)\n":     "[WARNING] 跳过 webhook %s: 未知格式 '%s'（可用 slack、discord 或 json）\n",
+	"[WARNING] Webhook %s failed: %v\n":                                                     "[WARNING] Webhook %s 发送失败: %v\n",
+	"[OK] Notified %s\n":                                                                    "[OK] 已通知 %s\n",
+	"Failed to load the command fixture: %v\n":                                              "加载命令录制文件失败: %v\n",
+	"\n[Dry Run] No commands would be run.":                                                 "\n[Dry Run] 不会运行任何命令。",
+	"\n[Dry Run] %d command(s) would be run; nothing was executed:\n":                       "\n[Dry Run] 将会运行 %d 条命令，未执行任何命令:\n",
+	"Cannot update asset references: %v\n":                                                  "无法更新资源引用: %v\n",
+	"\n--- Asset References ---":                                                            "\n--- 资源引用 ---",
+	"  [--] %s: no .meta yet, Unity has not imported it\n":                                  "  [--] %s: 还没有 .meta，Unity 尚未导入\n",
+	"  No imported files to swap.":                                                          "  没有可替换的已导入文件。",
+	"  [..] %s: %d reference(s) would point at the normalized files\n":                      "  [..] %s: 将有 %d 处引用指向标准化后的文件\n",
+	"  [OK] %s: %d reference(s) now point at the normalized files\n":                        "  [OK] %s: %d 处引用已指向标准化后的文件\n",
+	"  No references to the processed files were found.":                                    "  未找到对已处理文件的引用。",
+	"\n[!!] Could not write the loudness report: %v\n":                                      "\n[!!] 无法写入响度报告: %v\n",
+	"\n[OK] Loudness report: %s\n":                                                          "\n[OK] 响度报告: %s\n",
+	"Error: -plan and -apply are separate runs; review the plan in between.":                "错误: -plan 和 -apply 需分两次运行，中间请审阅计划。",
+	"Failed to read the plan: %v\n":                                                         "读取计划失败: %v\n",
+	"Nothing approved to normalize.":                                                        "没有已批准需要标准化的文件。",
+	"\n[!!] Could not write the plan: %v\n":                                                 "\n[!!] 无法写入计划: %v\n",
+	"\n[OK] Plan: %d file(s) to review in %s\n":                                             "\n[OK] 计划: %d 个文件待审阅，见 %s\n",
+	"     Set \"approve\" to false for the files to keep as they are, then run again with -apply %s\n": "     将需保持原样的文件的 \"approve\" 设为 false，然后使用 -apply %s 再次运行\n",
+	"  [!!] The plan was made for %s output, %s is selected now\n":                          "  [!!] 计划是按 %s 输出生成的，当前选择的是 %s\n",
+	"  [!!] %s: changed since the plan was written, run -plan again\n":                      "  [!!] %s: 计划写出后文件已更改，请重新运行 -plan\n",
+	"Plan %s: %d approved, %d not approved.\n":                                              "计划 %s: %d 个已批准，%d 个未批准。\n",
+	"Warning: could not list the ffmpeg encoders (%v); using the default for %s.\n":         "警告: 无法列出 ffmpeg 编码器（%v）；将使用 %s 的默认编码器。\n",
+	"Error: %v\n":                                                                           "错误: %v\n",
+	"Output: %s, encoder: %s\n":                                                                         "输出: %s，编码器: %s\n",
+	"  Note: %s\n":                                                                          "  注意: %s\n",
+	"FFmpeg's built-in Vorbis encoder: stereo output only, lower quality than libvorbis":    "FFmpeg 内置的 Vorbis 编码器：仅支持立体声输出，音质低于 libvorbis",
+	"  3. FLAC - Lossless masters kept outside Unity (Unity does not import .flac)":         "  3. FLAC - 无损母带，保存在 Unity 之外 (Unity 不导入 .flac)",
+	"3. Output is lossless FLAC for masters; move the files out of Assets, Unity does not import them.": "3. 输出为无损 FLAC 母带；请将文件移出 Assets，Unity 不会导入它们。",
+	"Error: Unity does not import FLAC, so -addressables and -remap need WAV or OGG output.":            "错误: Unity 不导入 FLAC，因此 -addressables 和 -remap 需要 WAV 或 OGG 输出。",
+	"\n--- Naming ---":                                                                                  "\n--- 命名 ---",
+	"  [OK] %s -> %s (kept its .meta)\n":                                                                "  [OK] %s -> %s (保留了其 .meta)\n",
+	"  No copies under earlier names to replace.":                                                       "  没有需要替换的旧名称副本。",
+
+	"[WARNING] Removing a stale %s (%s)\n":                      "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n": "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+	"  [!!] References not updated: %v\n":                       "  [!!] 未更新引用: %v\n",
+
+	"[WARNING] %s checkout failed for %s: %v %s\n": "[WARNING] %s 签出失败 %s: %v %s\n",
+	"[VCS] Checked out (%s): %s\n":                 "[VCS] 已签出 (%s): %s\n",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Notifications
+// ============================================================
+
+// Webhooks come from "notifications" in .unitystarter.json (UNITYSTARTER_CONFIG, or
+// the first file from the project root upward) and from UNITYSTARTER_WEBHOOK_URL.
+// The message is built from the recorded actions, errors and artifacts, so it says
+// the same as the -json document. A webhook that fails only prints a warning.
+const (
+	notifyConfigFileName = ".unitystarter.json"
+	notifyConfigEnvVar   = "UNITYSTARTER_CONFIG"
+	notifyWebhookEnvVar  = "UNITYSTARTER_WEBHOOK_URL"
+	notifyTimeout        = 15 * time.Second
+	notifyMaxLines       = 20 // errors, log lines and artifacts per message
+)
+
+// notifyConfig is the "notifications" object of .unitystarter.json
+type notifyConfig struct {
+	Webhooks        []webhookConfig `json:"webhooks"`
+	ArtifactBaseURL string          `json:"artifactBaseUrl"` // project-relative artifact paths are appended
+}
+
+// webhookConfig is one target. The URL may use ${VAR} so the secret stays in the
+// CI environment instead of the repository.
+type webhookConfig struct {
+	URL    string   `json:"url"`
+	Format string   `json:"format"` // slack, discord, json (default: from the URL host)
+	On     []string `json:"on"`     // success, failure (default: both)
+	Tools  []string `json:"tools"`  // tool names (default: every tool)
+}
+
+// notifyExcerpt is a titled block of log lines shown under the errors
+type notifyExcerpt struct {
+	Title string   `json:"title"`
+	Lines []string `json:"lines"`
+}
+
+// notification is the body posted to "json" webhooks
+type notification struct {
+	Tool       string          `json:"tool"`
+	Project    string          `json:"project"`
+	Host       string          `json:"host"`
+	Success    bool            `json:"success"`
+	ExitCode   int             `json:"exitCode"`
+	DurationMs int64           `json:"durationMs"`
+	Summary    string          `json:"summary"`
+	Counts     map[string]int  `json:"counts"` // actions per status
+	Errors     []string        `json:"errors"`
+	Logs       []notifyExcerpt `json:"logs"`
+	Artifacts  []string        `json:"artifacts"` // links with artifactBaseUrl, else paths
+	RunURL     string          `json:"runUrl,omitempty"`
+}
+
+var (
+	notifyHooks       []webhookConfig
+	notifyTool        string
+	notifyBasePath    string
+	notifyArtifactURL string
+	notifySummary     string
+	notifyExcerpts    []notifyExcerpt
+	notifySent        bool
+)
+
+// loadNotifyConfig reads the "notifications" object the same way loadSharedConfig
+// finds .unitystarter.json. Returns an empty config when no file exists.
+func loadNotifyConfig(projectRoot string) (*notifyConfig, string, error) {
+	path := os.Getenv(notifyConfigEnvVar)
+	if path == "" {
+		abs, err := filepath.Abs(projectRoot)
+		if err != nil {
+			return &notifyConfig{}, "", nil
+		}
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			candidate := filepath.Join(dir, notifyConfigFileName)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				return &notifyConfig{}, "", nil
+			}
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, path, err
+	}
+	var file struct {
+		Notifications notifyConfig `json:"notifications"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, path, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	return &file.Notifications, path, nil
+}
+
+// enableNotifications loads the webhooks of this tool. Call it once the real work
+// starts, so listings, dry runs and cancelled prompts never post.
+func enableNotifications(basePath, tool string) {
+	cfg, path, err := loadNotifyConfig(basePath)
+	if err != nil {
+		fmt.Printf(tr("[WARNING] Notifications are off: %v\n"), err)
+		return
+	}
+	hooks := cfg.Webhooks
+	if u := os.Getenv(notifyWebhookEnvVar); u != "" {
+		hooks = append(hooks, webhookConfig{URL: u})
+	}
+	notifyBasePath = basePath
+	notifyArtifactURL = cfg.ArtifactBaseURL
+	for _, h := range hooks {
+		if len(h.Tools) > 0 && !containsFold(h.Tools, tool) {
+			continue
+		}
+		h.URL = strings.TrimSpace(os.ExpandEnv(h.URL))
+		if u, err := url.Parse(h.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			fmt.Printf(tr("[WARNING] Skipping webhook in %s: the URL is empty or not http(s) (unset variable?)\n"), path)
+			continue
+		}
+		h.Format = webhookFormat(h)
+		if h.Format != "slack" && h.Format != "discord" && h.Format != "json" {
+			fmt.Printf(tr("[WARNING] Skipping webhook %s: unknown format '%s' (use slack, discord or json)\n"), webhookHost(h.URL), h.Format)
+			continue
+		}
+		notifyHooks = append(notifyHooks, h)
+	}
+	notifyTool = tool
+}
+
+// setNotifySummary replaces the default "3 ok, 1 failed" line of the message
+func setNotifySummary(format string, args ...interface{}) {
+	notifySummary = fmt.Sprintf(format, args...)
+}
+
+// addNotifyExcerpt attaches log lines of a failure to the message
+func addNotifyExcerpt(title string, lines []string) {
+	if len(lines) > 0 {
+		notifyExcerpts = append(notifyExcerpts, notifyExcerpt{Title: title, Lines: lines})
+	}
+}
+
+// sendNotifications posts the result to every webhook that wants it. Call it
+// before waiting for a key press, so the message does not wait for the user.
+func sendNotifications(exitCode int) {
+	if notifySent || len(notifyHooks) == 0 {
+		return
+	}
+	notifySent = true
+	n := buildNotification(exitCode)
+	event := "success"
+	if !n.Success {
+		event = "failure"
+	}
+	for _, h := range notifyHooks {
+		if len(h.On) > 0 && !containsFold(h.On, event) {
+			continue
+		}
+		if err := postWebhook(h, n); err != nil {
+			fmt.Printf(tr("[WARNING] Webhook %s failed: %v\n"), webhookHost(h.URL), err)
+			continue
+		}
+		fmt.Printf(tr("[OK] Notified %s\n"), webhookHost(h.URL))
+	}
+}
+
+func buildNotification(exitCode int) *notification {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	host, _ := os.Hostname()
+	n := &notification{
+		Tool:       notifyTool,
+		Project:    filepath.Base(notifyBasePath),
+		Host:       host,
+		Success:    exitCode == 0 && len(jsonDoc.Errors) == 0,
+		ExitCode:   exitCode,
+		DurationMs: time.Since(jsonStart).Milliseconds(),
+		Summary:    notifySummary,
+		Counts:     make(map[string]int),
+		Errors:     append([]string{}, jsonDoc.Errors...),
+		Logs:       append([]notifyExcerpt{}, notifyExcerpts...),
+		Artifacts:  []string{},
+		RunURL:     ciRunURL(),
+	}
+	for _, a := range jsonDoc.Actions {
+		n.Counts[a.Status]++
+	}
+	if n.Summary == "" {
+		var parts []string
+		for _, status := range []string{"ok", "failed", "skipped"} {
+			if n.Counts[status] > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", n.Counts[status], status))
+			}
+		}
+		n.Summary = strings.Join(parts, ", ")
+	}
+	for _, a := range jsonDoc.Artifacts {
+		n.Artifacts = append(n.Artifacts, artifactLink(a))
+	}
+	return n
+}
+
+// artifactLink turns a path into a link below artifactBaseUrl; paths outside the
+// project, or without a base URL, are kept as they are
+func artifactLink(p string) string {
+	rel := filepath.ToSlash(p)
+	if filepath.IsAbs(p) {
+		r, err := filepath.Rel(notifyBasePath, p)
+		if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+			return rel
+		}
+		rel = filepath.ToSlash(r)
+	}
+	if notifyArtifactURL == "" {
+		return rel
+	}
+	parts := strings.Split(rel, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.TrimSuffix(notifyArtifactURL, "/") + "/" + strings.Join(parts, "/")
+}
+
+// ciRunURL links the CI job the tool runs in, when the CI exposes one
+func ciRunURL() string {
+	if server, repo, run := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"); server != "" && repo != "" && run != "" {
+		return server + "/" + repo + "/actions/runs/" + run
+	}
+	for _, name := range []string{"CI_JOB_URL", "BUILD_URL"} { // GitLab, Jenkins
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// webhookFormat returns the configured format, else guesses it from the host.
+// Mattermost and Rocket.Chat accept "slack".
+func webhookFormat(h webhookConfig) string {
+	if h.Format != "" {
+		return strings.ToLower(h.Format)
+	}
+	host := webhookHost(h.URL)
+	switch {
+	case host == "hooks.slack.com":
+		return "slack"
+	case host == "discord.com" || host == "discordapp.com" || strings.HasSuffix(host, ".discord.com"):
+		return "discord"
+	}
+	return "json"
+}
+
+// webhookHost is what the output shows of a webhook: the path holds the secret
+func webhookHost(raw string) string {
+	if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		return strings.ToLower(u.Hostname())
+	}
+	return "webhook"
+}
+
+// notificationText renders the message as Slack mrkdwn or Discord markdown,
+// cut to limit characters
+func notificationText(n *notification, slack bool, limit int) string {
+	esc := func(s string) string { return s }
+	bold := func(s string) string { return "**" + s + "**" }
+	link := func(u, text string) string { return text + ": " + u }
+	if slack {
+		esc = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+		bold = func(s string) string { return "*" + s + "*" }
+		link = func(u, text string) string { return "<" + u + "|" + esc(text) + ">" }
+	}
+
+	status := "✅ " + bold(esc(n.Tool)) + " succeeded"
+	if !n.Success {
+		status = "❌ " + bold(esc(n.Tool)) + fmt.Sprintf(" failed (exit code %d)", n.ExitCode)
+	}
+	lines := []string{fmt.Sprintf("%s: %s on %s, %s", status, esc(n.Project), esc(n.Host), (time.Duration(n.DurationMs) * time.Millisecond).Round(time.Second))}
+	if n.Summary != "" {
+		lines = append(lines, esc(n.Summary))
+	}
+	if n.RunURL != "" {
+		lines = append(lines, link(n.RunURL, "CI run"))
+	}
+	if len(n.Errors) > 0 {
+		lines = append(lines, "", bold("Errors"))
+		for i, e := range n.Errors {
+			if i == notifyMaxLines {
+				lines = append(lines, fmt.Sprintf("… %d more", len(n.Errors)-i))
+				break
+			}
+			lines = append(lines, "• "+esc(e))
+		}
+	}
+	for _, x := range n.Logs {
+		excerpt := x.Lines
+		if len(excerpt) > notifyMaxLines {
+			excerpt = excerpt[len(excerpt)-notifyMaxLines:]
+		}
+		// A stray fence inside the log would end the code block early
+		block := strings.ReplaceAll(strings.Join(excerpt, "\n"), "```", "'''")
+		lines = append(lines, "", bold(esc(x.Title)), "```", esc(block), "```")
+	}
+	if len(n.Artifacts) > 0 {
+		lines = append(lines, "", bold("Artifacts"))
+		for i, a := range n.Artifacts {
+			if i == notifyMaxLines {
+				lines = append(lines, fmt.Sprintf("… %d more", len(n.Artifacts)-i))
+				break
+			}
+			if strings.HasPrefix(a, "http://") || strings.HasPrefix(a, "https://") {
+				lines = append(lines, "• "+link(a, path.Base(a)))
+			} else {
+				lines = append(lines, "• "+esc(a))
+			}
+		}
+	}
+
+	text := strings.Join(lines, "\n")
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	text = string(runes[:limit-8])
+	if strings.Count(text, "```")%2 == 1 {
+		text += "\n```"
+	}
+	return text + "\n…"
+}
+
+// postWebhook sends one message; a 429 or 5xx answer is retried once
+func postWebhook(h webhookConfig, n *notification) error {
+	var payload interface{} = n
+	switch h.Format {
+	case "slack":
+		payload = map[string]string{"text": notificationText(n, true, 3900)}
+	case "discord":
+		payload = map[string]string{"content": notificationText(n, false, 2000)}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: notifyTimeout}
+	for attempt := 0; ; attempt++ {
+		resp, err := client.Post(h.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			// The error text repeats the URL, secret included
+			if ue, ok := err.(*url.Error); ok {
+				err = ue.Err
+			}
+			return err
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+		if resp.StatusCode < 300 {
+			return nil
+		}
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !retry || attempt > 0 {
+			return fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		wait := 2 * time.Second
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 && s <= 30 {
+			wait = time.Duration(s) * time.Second
+		}
+		time.Sleep(wait)
+	}
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Encoders
+// ============================================================
+
+// Which encoders an ffmpeg build has depends on how it was compiled: libvorbis is
+// missing from some minimal and distro builds, which only have FFmpeg's own
+// stereo-only Vorbis encoder. The build is asked with `ffmpeg -encoders` once at
+// startup and the best encoder it has for the chosen format is used; -encoder picks
+// one by name. Audio encoding always runs on the CPU (ffmpeg's hardware encoders are
+// video only), so the workers are what make a batch fast.
+var encoderFlag string
+
+// audioEncoder is one ffmpeg encoder that can write an output format
+type audioEncoder struct {
+	name string
+	args []string // codec and quality arguments
+	note string   // shown when this encoder is used instead of the best one
+}
+
+var (
+	encoderPCM16     = audioEncoder{name: "pcm_s16le", args: []string{"-c:a", "pcm_s16le"}}
+	encoderFLAC16    = audioEncoder{name: "flac", args: []string{"-c:a", "flac", "-sample_fmt", "s16"}}
+	encoderLibVorbis = audioEncoder{name: "libvorbis", args: []string{"-c:a", "libvorbis", "-q:a", "6"}}
+	encoderVorbis    = audioEncoder{
+		name: "vorbis",
+		args: []string{"-c:a", "vorbis", "-strict", "experimental", "-ac", "2", "-q:a", "6"},
+		note: "FFmpeg's built-in Vorbis encoder: stereo output only, lower quality than libvorbis",
+	}
+)
+
+// Regex to read an audio encoder line of `ffmpeg -encoders`, e.g. " A....D libvorbis  libvorbis".
+var encoderLineRegex = regexp.MustCompile(`(?m)^\s*A[A-Z.]{5}\s+(\S+)`)
+
+// probeEncoders lists the audio encoders of the local ffmpeg build
+func probeEncoders() (map[string]bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	stdout, _, err := runner.Output(ctx, "ffmpeg", "-hide_banner", "-encoders")
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool)
+	for _, m := range encoderLineRegex.FindAllStringSubmatch(string(stdout), -1) {
+		found[m[1]] = true
+	}
+	if len(found) == 0 {
+		return nil, errors.New("no audio encoders in the output of ffmpeg -encoders")
+	}
+	return found, nil
+}
+
+func encoderNames(encoders []audioEncoder) string {
+	var names []string
+	for _, e := range encoders {
+		names = append(names, e.name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// selectEncoder picks the encoder for format: the one named by override, or the
+// first one of format.encoders the build has. available is nil when the probe
+// failed; the choice is then made without checking.
+func selectEncoder(format outputFormat, override string, available map[string]bool) (audioEncoder, error) {
+	candidates := format.encoders
+	if override != "" {
+		candidates = nil
+		for _, e := range format.encoders {
+			if e.name == override {
+				candidates = []audioEncoder{e}
+			}
+		}
+		if candidates == nil {
+			return audioEncoder{}, fmt.Errorf("encoder %q cannot write %s output (use one of: %s)", override, format.ext, encoderNames(format.encoders))
+		}
+	}
+	if available == nil {
+		return candidates[0], nil
+	}
+	for _, e := range candidates {
+		if available[e.name] {
+			return e, nil
+		}
+	}
+	var present []audioEncoder
+	for _, e := range format.encoders {
+		if available[e.name] {
+			present = append(present, e)
+		}
+	}
+	if override != "" && len(present) > 0 {
+		return audioEncoder{}, fmt.Errorf("encoder %q is not in this ffmpeg build (it has: %s)", override, encoderNames(present))
+	}
+	return audioEncoder{}, fmt.Errorf("this ffmpeg build has no encoder for %s output (needs one of: %s); install a full build from https://ffmpeg.org/download.html or choose another format", format.ext, encoderNames(format.encoders))
+}
+
+// ============================================================
+// Bit Depth
+// ============================================================
+
+// -bit-depth sets the sample format of lossless output so normalized masters can be
+// kept at the depth they were recorded at and compressed per platform later. -dither
+// adds noise shaped by the chosen method when the level change is rounded to 16-bit
+// samples; at 24 bits and above the rounding error is far below audibility.
+var (
+	bitDepthFlag string
+	ditherFlag   string
+)
+
+// sampleDepth is one -bit-depth choice
+type sampleDepth struct {
+	label    string // as given to -bit-depth
+	name     string // as shown in the format name
+	wavCodec string
+	flacFmt  string // sample format for the flac encoder; empty when FLAC cannot hold it
+}
+
+var sampleDepths = []sampleDepth{
+	{label: "16", name: "16-bit", wavCodec: "pcm_s16le", flacFmt: "s16"},
+	{label: "24", name: "24-bit", wavCodec: "pcm_s24le", flacFmt: "s32"},
+	{label: "32f", name: "32-bit float", wavCodec: "pcm_f32le"},
+}
+
+// ditherMethods are the swresample dither methods -dither accepts
+var ditherMethods = []string{"none", "triangular", "triangular_hp", "lipshitz", "shibata"}
+
+// checkDepthFlags validates -bit-depth and -dither before anything is asked
+func checkDepthFlags() (sampleDepth, error) {
+	var depth sampleDepth
+	var labels []string
+	for _, d := range sampleDepths {
+		labels = append(labels, d.label)
+		if d.label == bitDepthFlag {
+			depth = d
+		}
+	}
+	if depth.label == "" {
+		return depth, fmt.Errorf("unknown -bit-depth %q (use %s)", bitDepthFlag, strings.Join(labels, ", "))
+	}
+	if !containsFold(ditherMethods, ditherFlag) {
+		return depth, fmt.Errorf("unknown -dither %q (use %s)", ditherFlag, strings.Join(ditherMethods, ", "))
+	}
+	ditherFlag = strings.ToLower(ditherFlag)
+	if ditherFlag != "none" && depth.label != "16" {
+		return depth, fmt.Errorf("-dither only applies to 16-bit output")
+	}
+	return depth, nil
+}
+
+// applyBitDepth sets the encoder of a lossless format to write depth
+func applyBitDepth(format outputFormat, depth sampleDepth) (outputFormat, error) {
+	switch format.ext {
+	case ".wav":
+		format.encoders = []audioEncoder{{name: depth.wavCodec, args: []string{"-c:a", depth.wavCodec}}}
+		format.name = fmt.Sprintf("WAV (Lossless PCM %s)", depth.name)
+	case ".flac":
+		if depth.flacFmt == "" {
+			return format, fmt.Errorf("FLAC cannot hold %s samples (use 16 or 24)", depth.name)
+		}
+		format.encoders = []audioEncoder{{name: "flac", args: []string{"-c:a", "flac", "-sample_fmt", depth.flacFmt}}}
+		format.name = fmt.Sprintf("FLAC (Lossless %s)", depth.name)
+	default:
+		if depth.label != "16" || ditherFlag != "none" {
+			return format, fmt.Errorf("-bit-depth and -dither apply to WAV and FLAC output, not %s", format.ext)
+		}
+	}
+	return format, nil
+}
+
+// outputFilter ends an -af chain with the conversion to the output rate, dithered
+// to 16 bits when -dither asks for it; without dithering ffmpeg converts as before
+func outputFilter(filter string, sampleRate int) string {
+	if ditherFlag == "" || ditherFlag == "none" {
+		return filter
+	}
+	return fmt.Sprintf("%s,aresample=%d:osf=s16:dither_method=%s", filter, sampleRate, ditherFlag)
+}
+
+// ============================================================
+// Naming
+// ============================================================
+
+// -name-case and -name-prefix apply a naming convention to the normalized files,
+// so the copies Unity imports need no renaming by hand: "Gun Shot-01.wav" in SFX
+// becomes sfx_gun_shot_01_normalized.wav with both. The _normalized suffix stays so
+// later runs still skip the copies. An output a run without the convention wrote
+// (gun Shot-01_normalized.wav) is replaced by the new one and hands over its .meta,
+// so the references Unity already has to it stay intact.
+var (
+	nameCaseFlag   string
+	namePrefixFlag string
+	// categoryPrefixes maps a lower-case category name to its prefix under -name-prefix
+	categoryPrefixes map[string]string
+)
+
+// defaultCategoryPrefixes is what -name-prefix auto uses
+var defaultCategoryPrefixes = map[string]string{
+	"music":   "mus_",
+	"voice":   "vo_",
+	"sfx":     "sfx_",
+	"ambient": "amb_",
+}
+
+var (
+	// Regex to find a lower-case letter or digit followed by an upper-case letter (camelCase).
+	camelBoundaryRegex = regexp.MustCompile(`([\p{Ll}\d])(\p{Lu})`)
+	// Regex to find runs of characters that separate words in a file name.
+	nameSeparatorRegex = regexp.MustCompile(`[^\p{L}\d]+`)
+)
+
+// parseNamingFlags checks -name-case and reads -name-prefix: "auto" for the
+// built-in prefixes or "Category=prefix,..." for a map of its own
+func parseNamingFlags() error {
+	switch nameCaseFlag {
+	case "keep", "lower", "snake":
+	default:
+		return fmt.Errorf("unknown -name-case %q (use keep, lower or snake)", nameCaseFlag)
+	}
+	switch strings.TrimSpace(namePrefixFlag) {
+	case "":
+		return nil
+	case "auto":
+		categoryPrefixes = defaultCategoryPrefixes
+		return nil
+	}
+	known := map[string]bool{}
+	for _, c := range []audioCategory{categoryMusic, categoryVoice, categorySFX, categoryAmbient, categoryDefault} {
+		known[strings.ToLower(c.name)] = true
+	}
+	categoryPrefixes = make(map[string]string)
+	for _, pair := range strings.Split(namePrefixFlag, ",") {
+		category, prefix, ok := strings.Cut(pair, "=")
+		category = strings.ToLower(strings.TrimSpace(category))
+		if !ok || !known[category] {
+			return fmt.Errorf("invalid -name-prefix entry %q (use auto or Music=mus_,SFX=sfx_,... with Music, Voice, SFX, Ambient, Default)", pair)
+		}
+		categoryPrefixes[category] = strings.TrimSpace(prefix)
+	}
+	return nil
+}
+
+// applyNamingConvention returns the base name (without extension) a normalized
+// copy of a file named name in category gets
+func applyNamingConvention(name string, cat audioCategory) string {
+	switch nameCaseFlag {
+	case "lower":
+		name = strings.ToLower(name)
+	case "snake":
+		name = camelBoundaryRegex.ReplaceAllString(name, "${1}_${2}")
+		name = strings.Trim(nameSeparatorRegex.ReplaceAllString(name, "_"), "_")
+		name = strings.ToLower(name)
+	}
+	if prefix := categoryPrefixes[strings.ToLower(cat.name)]; prefix != "" && !strings.HasPrefix(strings.ToLower(name), strings.ToLower(prefix)) {
+		name = prefix + name
+	}
+	return name
+}
+
+// normalizedPath is where the normalized copy of source is written
+func normalizedPath(source string) string {
+	dir, base := filepath.Split(source)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+	return filepath.Join(dir, applyNamingConvention(name, detectCategory(source))+FILENAME_SUFFIX+selectedFormat.ext)
+}
+
+// legacyNormalizedPath is where a run without a naming convention writes the copy
+func legacyNormalizedPath(source string) string {
+	return strings.TrimSuffix(source, filepath.Ext(source)) + FILENAME_SUFFIX + selectedFormat.ext
+}
+
+// adoptLegacyOutputs removes the copies written under the old names of the files
+// normalized in this run, moving their .meta to the new copy when it has none
+func adoptLegacyOutputs(rootDir string, sources []string) {
+	rel := func(p string) string {
+		if r, err := filepath.Rel(rootDir, p); err == nil {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	found := 0
+	for _, source := range sources {
+		legacy, output := legacyNormalizedPath(source), normalizedPath(source)
+		if legacy == output {
+			continue
+		}
+		if _, err := os.Stat(legacy); err != nil {
+			continue
+		}
+		_, metaErr := os.Stat(legacy + ".meta")
+		_, newMetaErr := os.Stat(output + ".meta")
+		moveMeta := metaErr == nil && errors.Is(newMetaErr, os.ErrNotExist)
+		found++
+		if dryRun {
+			fmt.Printf(tr("  [..] %s -> %s\n"), rel(legacy), rel(output))
+			recordAction("rename", legacy, "dry-run", output, 0)
+			continue
+		}
+		if moveMeta {
+			if err := os.Rename(legacy+".meta", output+".meta"); err != nil {
+				fmt.Printf(tr("  [!!] %s: %v\n"), rel(legacy), err)
+				recordError("%s: %v", legacy, err)
+				continue
+			}
+		}
+		if err := os.Remove(legacy); err != nil {
+			fmt.Printf(tr("  [!!] %s: %v\n"), rel(legacy), err)
+			recordError("%s: %v", legacy, err)
+			continue
+		}
+		// Without a .meta of its own the old copy's .meta would be an orphan
+		os.Remove(legacy + ".meta")
+		if moveMeta {
+			fmt.Printf(tr("  [OK] %s -> %s (kept its .meta)\n"), rel(legacy), rel(output))
+		} else {
+			fmt.Printf(tr("  [OK] %s -> %s\n"), rel(legacy), rel(output))
+		}
+		recordAction("rename", legacy, "ok", output, 0)
+	}
+	if found == 0 {
+		fmt.Println(tr("  No copies under earlier names to replace."))
+	}
+}
+
+// ============================================================
+// Approval Plan
+// ============================================================
+
+// -plan runs the analysis only and writes the gain each file would get to a JSON
+// file; -apply normalizes only the entries still marked "approve": true, each to
+// the target in the plan. In between, the audio lead unticks the tracks that must
+// keep their dynamics or moves a target. A file that changed after the plan was
+// written is left alone until it is planned again.
+var (
+	planPath  string
+	applyPath string
+	// approvedFiles maps the absolute path of every approved file to its entry under -apply
+	approvedFiles map[string]*planEntry
+)
+
+// planEntry is one file in the plan. File is relative to the folder the tool runs
+// in; Measured and Target are LUFS or dB peak as Strategy says; Size and Modified
+// tell -apply whether the file is still the one that was measured.
+type planEntry struct {
+	File     string  `json:"file"`
+	Category string  `json:"category"`
+	Strategy string  `json:"strategy"`
+	Measured float64 `json:"measured"`
+	Target   float64 `json:"target"`
+	Gain     float64 `json:"gain"`
+	Approve  bool    `json:"approve"`
+	Size     int64   `json:"size"`
+	Modified string  `json:"modified"`
+}
+
+type normalizePlan struct {
+	Format  string       `json:"format"`
+	Entries []*planEntry `json:"entries"`
+}
+
+// fileStamp is what a plan entry remembers to notice a file changing
+func fileStamp(path string) (int64, string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, "", err
+	}
+	return info.Size(), info.ModTime().UTC().Format(time.RFC3339Nano), nil
+}
+
+// writePlan writes one approved entry per file that would be normalized
+func writePlan(p, rootDir string, results []result) (int, error) {
+	plan := &normalizePlan{Format: selectedFormat.ext}
+	sort.Slice(results, func(i, j int) bool { return results[i].path < results[j].path })
+	for _, res := range results {
+		if res.err != nil || res.level == nil || !res.level.known {
+			continue
+		}
+		name, err := filepath.Rel(rootDir, res.path)
+		if err != nil {
+			return 0, err
+		}
+		size, modified, err := fileStamp(res.path)
+		if err != nil {
+			return 0, err
+		}
+		lv := res.level
+		plan.Entries = append(plan.Entries, &planEntry{
+			File:     filepath.ToSlash(name),
+			Category: detectCategory(res.path).name,
+			Strategy: lv.strategy,
+			Measured: math.Round(lv.before*10) / 10,
+			Target:   lv.target,
+			Gain:     math.Round((lv.target-lv.before)*10) / 10,
+			Approve:  true,
+			Size:     size,
+			Modified: modified,
+		})
+	}
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if dir := filepath.Dir(p); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return 0, err
+		}
+	}
+	return len(plan.Entries), os.WriteFile(p, append(data, '\n'), 0644)
+}
+
+// loadApproved reads a plan and keeps the approved entries whose files are
+// unchanged; the others are listed with the reason they are left out.
+func loadApproved(p, rootDir string) (map[string]*planEntry, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var plan normalizePlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	if plan.Format != "" && plan.Format != selectedFormat.ext {
+		fmt.Printf(tr("  [!!] The plan was made for %s output, %s is selected now\n"), plan.Format, selectedFormat.ext)
+	}
+	approved := make(map[string]*planEntry)
+	held := 0
+	for _, e := range plan.Entries {
+		path := filepath.Join(rootDir, filepath.FromSlash(e.File))
+		if !e.Approve {
+			held++
+			recordAction("normalize", path, "skipped", "not approved", 0)
+			continue
+		}
+		if e.Strategy != strategyLUFS && e.Strategy != strategyPeak {
+			return nil, fmt.Errorf("%s: unknown strategy %q (use %s or %s)", e.File, e.Strategy, strategyLUFS, strategyPeak)
+		}
+		size, modified, err := fileStamp(path)
+		if err != nil {
+			fmt.Printf(tr("  [!!] %s: %v\n"), e.File, err)
+			recordError("%s: %v", path, err)
+			continue
+		}
+		if size != e.Size || modified != e.Modified {
+			fmt.Printf(tr("  [!!] %s: changed since the plan was written, run -plan again\n"), e.File)
+			recordAction("normalize", path, "skipped", "changed since the plan was written", 0)
+			continue
+		}
+		approved[path] = e
+	}
+	fmt.Printf(tr("Plan %s: %d approved, %d not approved.\n"), p, len(approved), held)
+	return approved, nil
+}
+
+// inScope reports whether path is normalized in this run: every file, or under
+// -apply only the approved ones
+func inScope(path string) bool {
+	if approvedFiles == nil {
+		return true
+	}
+	return approvedFiles[path] != nil
+}
+
+// ============================================================
+// HTML Report
+// ============================================================
+
+// -html-report writes one page an audio lead can sign a batch off from: a bar per
+// file with its level before and after against the category target, a histogram
+// of how far the files sit from their targets before and after, and links to the
+// source and normalized files. Levels are LUFS for long audio and dB peak for short
+// SFX, which is why the histogram counts distance from target rather than level.
+var htmlReportPath string
+
+const (
+	strategyLUFS = "LUFS"
+	strategyPeak = "Peak"
+
+	reportFloorDB  = -48.0 // level at the left end of the bars
+	reportBinWidth = 2.0   // histogram bin in dB/LU from target
+	reportBinLimit = 10.0  // offsets beyond +/- this share the outer bins
+)
+
+// loudness is a file's level in its strategy's unit; after is the target until the
+// written file has been measured again.
+type loudness struct {
+	strategy string
+	target   float64
+	before   float64
+	after    float64
+	known    bool // before was measured (not silent, parsed)
+	measured bool // after was measured on the written file
+}
+
+// measureOutput analyzes the written file again so the report shows the level it
+// really ended up at rather than the target.
+func measureOutput(path string, lv *loudness) {
+	ctx, cancel := context.WithTimeout(context.Background(), FFMPEG_TIMEOUT)
+	defer cancel()
+	if lv.strategy == strategyPeak {
+		_, stderr, _ := runner.Output(ctx, "ffmpeg", "-i", path, "-af", "volumedetect", "-f", "null", "-")
+		if m := maxVolRegex.FindStringSubmatch(string(stderr)); len(m) == 2 {
+			if v, err := strconv.ParseFloat(m[1], 64); err == nil {
+				lv.after, lv.measured = v, true
+			}
+		}
+		return
+	}
+	_, stderr, _ := runner.Output(ctx, "ffmpeg", "-i", path, "-af", "loudnorm=print_format=json", "-f", "null", "-")
+	if info, err := extractLoudnormInfo(string(stderr)); err == nil {
+		if v, err := strconv.ParseFloat(info.InputI, 64); err == nil && !math.IsInf(v, 0) {
+			lv.after, lv.measured = v, true
+		}
+	}
+}
+
+// reportRow is one file in the HTML report
+type reportRow struct {
+	Name     string
+	Category string
+	Status   string // normalized, planned, skipped, failed
+	Error    string
+	Source   string // links relative to the report
+	Output   string
+	Strategy string
+	Target   float64
+	Before   float64
+	After    float64
+	Levels   bool
+	Measured bool
+}
+
+// reportBin is one histogram column; heights are percentages of the tallest column
+type reportBin struct {
+	Label        string
+	Before       int
+	After        int
+	BeforeHeight float64
+	AfterHeight  float64
+}
+
+type reportData struct {
+	Folder      string
+	GeneratedAt string
+	Format      string
+	DryRun      bool
+	Normalized  int
+	Skipped     int
+	Failed      int
+	Rows        []reportRow
+	Bins        []reportBin
+}
+
+var htmlReport = template.Must(template.New("loudness").Funcs(template.FuncMap{
+	"level": func(v float64) string { return fmt.Sprintf("%.1f", v) },
+	"offset": func(v float64) string {
+		return fmt.Sprintf("%+.1f", v)
+	},
+	"bar": func(v float64) string {
+		return fmt.Sprintf("%.1f%%", math.Max(0, math.Min(100, (v-reportFloorDB)/-reportFloorDB*100)))
+	},
+	"sub": func(a, b float64) float64 { return a - b },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Loudness Report — {{.Folder}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; font-size: 14px; vertical-align: middle; }
+th { background: #f0f0f0; }
+td.num { text-align: right; white-space: nowrap; }
+.normalized { color: #1a7f37; font-weight: bold; }
+.planned, .skipped { color: #9a6700; font-weight: bold; }
+.failed { color: #cf222e; font-weight: bold; }
+.meter { position: relative; width: 320px; }
+.meter div { height: 8px; margin: 2px 0; }
+.meter .before { background: #afb8c1; }
+.meter .after { background: #0969da; }
+.meter .target { position: absolute; top: 0; bottom: 0; border-left: 2px dashed #cf222e; }
+.histogram { display: flex; align-items: flex-end; gap: 6px; height: 160px; margin-bottom: 0.5em; }
+.histogram .bin { flex: 1; display: flex; align-items: flex-end; gap: 2px; height: 100%; }
+.histogram .bin div { flex: 1; min-height: 1px; }
+.histogram .before { background: #afb8c1; }
+.histogram .after { background: #0969da; }
+.labels { display: flex; gap: 6px; font-size: 12px; color: #555; }
+.labels span { flex: 1; text-align: center; }
+.key span { display: inline-block; width: 12px; height: 12px; vertical-align: middle; }
+.note { color: #555; font-size: 12px; }
+</style>
+</head>
+<body>
+<h1>Loudness Report — {{.Folder}}</h1>
+<p>{{.GeneratedAt}} · {{.Format}}{{if .DryRun}} · dry run, after levels are the targets{{end}}</p>
+<p><span class="normalized">{{.Normalized}} {{if .DryRun}}to normalize{{else}}normalized{{end}}</span>, <span class="skipped">{{.Skipped}} already normalized</span>, <span class="failed">{{.Failed}} failed</span></p>
+<h2>Distance From Target</h2>
+<p class="key"><span style="background: #afb8c1"></span> before &nbsp; <span style="background: #0969da"></span> after &nbsp; (dB/LU, files per bin)</p>
+<div class="histogram">{{range .Bins}}<div class="bin" title="{{.Label}}: {{.Before}} before, {{.After}} after"><div class="before" style="height: {{.BeforeHeight}}%"></div><div class="after" style="height: {{.AfterHeight}}%"></div></div>{{end}}</div>
+<div class="labels">{{range .Bins}}<span>{{.Label}}</span>{{end}}</div>
+<h2>Files</h2>
+<table>
+<tr><th>File</th><th>Category</th><th>Status</th><th>Unit</th><th>Target</th><th>Before</th><th>After</th><th>Change</th><th>Levels</th></tr>
+{{range .Rows}}<tr>
+<td><a href="{{.Source}}">{{.Name}}</a>{{if .Output}} → <a href="{{.Output}}">normalized</a>{{end}}</td>
+<td>{{.Category}}</td><td class="{{.Status}}">{{.Status}}</td>
+{{if .Levels}}<td>{{.Strategy}}</td><td class="num">{{level .Target}}</td><td class="num">{{level .Before}}</td>
+<td class="num">{{level .After}}{{if not .Measured}} <span class="note">target</span>{{end}}</td><td class="num">{{offset (sub .After .Before)}}</td>
+<td><div class="meter"><div class="before" style="width: {{bar .Before}}"></div><div class="after" style="width: {{bar .After}}"></div><span class="target" style="left: {{bar .Target}}"></span></div></td>
+{{else}}<td colspan="6">{{.Error}}</td>{{end}}
+</tr>{{end}}
+</table>
+<p class="note">Bars run from -48 dB on the left to 0 dB on the right; the dashed line is the category target.</p>
+</body>
+</html>
+`))
+
+// reportBins counts the files by distance from their target, before and after
+func reportBins(rows []reportRow) []reportBin {
+	n := int(2*reportBinLimit/reportBinWidth) + 2
+	bins := make([]reportBin, n)
+	for i := range bins {
+		lo := -reportBinLimit + float64(i-1)*reportBinWidth
+		switch i {
+		case 0:
+			bins[i].Label = fmt.Sprintf("< %+.0f", -reportBinLimit)
+		case n - 1:
+			bins[i].Label = fmt.Sprintf("≥ %+.0f", reportBinLimit)
+		default:
+			bins[i].Label = fmt.Sprintf("%+.0f", lo)
+		}
+	}
+	index := func(offset float64) int {
+		i := int(math.Floor((offset+reportBinLimit)/reportBinWidth)) + 1
+		return int(math.Max(0, math.Min(float64(n-1), float64(i))))
+	}
+	tallest := 1
+	for _, r := range rows {
+		if !r.Levels {
+			continue
+		}
+		b, a := &bins[index(r.Before-r.Target)], &bins[index(r.After-r.Target)]
+		b.Before++
+		a.After++
+		if b.Before > tallest {
+			tallest = b.Before
+		}
+		if a.After > tallest {
+			tallest = a.After
+		}
+	}
+	for i := range bins {
+		bins[i].BeforeHeight = float64(bins[i].Before) * 100 / float64(tallest)
+		bins[i].AfterHeight = float64(bins[i].After) * 100 / float64(tallest)
+	}
+	return bins
+}
+
+// writeHTMLReport writes the report to p with file links relative to it
+func writeHTMLReport(p, rootDir string, results []result) error {
+	if abs, err := filepath.Abs(p); err == nil {
+		p = abs
+	}
+	rel := func(target string) string {
+		r, err := filepath.Rel(filepath.Dir(p), target)
+		if err != nil {
+			return filepath.ToSlash(target)
+		}
+		return filepath.ToSlash(r)
+	}
+	data := &reportData{
+		Folder:      filepath.Base(rootDir),
+		GeneratedAt: time.Now().Format("2006-01-02 15:04"),
+		Format:      selectedFormat.name,
+		DryRun:      dryRun,
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].path < results[j].path })
+	for _, res := range results {
+		name, err := filepath.Rel(rootDir, res.path)
+		if err != nil {
+			name = res.path
+		}
+		row := reportRow{Name: filepath.ToSlash(name), Category: detectCategory(res.path).name, Source: rel(res.path)}
+		switch {
+		case res.err == nil:
+			row.Status = "normalized"
+			data.Normalized++
+			if dryRun {
+				row.Status = "planned"
+			} else {
+				row.Output = rel(normalizedPath(res.path))
+			}
+		case errors.Is(res.err, ErrAlreadyNormalized):
+			row.Status = "skipped"
+			data.Skipped++
+		default:
+			row.Status = "failed"
+			row.Error = res.err.Error()
+			data.Failed++
+		}
+		if lv := res.level; lv != nil && lv.known && row.Status != "failed" {
+			row.Levels = true
+			row.Strategy, row.Target, row.Before, row.After, row.Measured = lv.strategy, lv.target, lv.before, lv.after, lv.measured
+			if row.Status == "skipped" {
+				row.After, row.Measured = lv.before, true
+			}
+		}
+		data.Rows = append(data.Rows, row)
+	}
+	data.Bins = reportBins(data.Rows)
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if err := htmlReport.Execute(f, data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ============================================================
+// Version Control Checkout
+// ============================================================
+
+// Perforce and Plastic SCM keep files read-only until they are checked out. Writing
+// them directly (even after clearing the flag) leaves changes the server does not know
+// about, so files are opened for edit first. Git and plain folders need nothing.
+const (
+	vcsNone     = "none"
+	vcsPerforce = "p4"
+	vcsPlastic  = "plastic"
 )
 
-// selectedFormat is set during startup based on user choice.
-var selectedFormat outputFormat
+// vcsEnvVar overrides detection for every tool (e.g. on build agents)
+const vcsEnvVar = "UNITYSTARTER_VCS"
 
-type LoudnormInfo struct {
-	InputI       string `json:"input_i"`
-	InputTP      string `json:"input_tp"`
-	InputLRA     string `json:"input_lra"`
-	InputThresh  string `json:"input_thresh"`
-	TargetOffset string `json:"target_offset"`
+// vcsClient issues checkouts for files about to be modified
+type vcsClient struct {
+	kind       string
+	source     string // how kind was chosen, for the startup message
+	checkedOut map[string]bool
 }
 
-type job struct {
-	path string
+// activeVCS is set up in main; nil means no checkouts
+var activeVCS *vcsClient
+
+// detectVCS picks the checkout provider. Priority: -vcs flag > UNITYSTARTER_VCS >
+// Unity's VersionControlSettings.asset mode > workspace markers (.plastic, P4CONFIG).
+func detectVCS(projectRoot, override string) (*vcsClient, error) {
+	normalize := func(v string) (string, bool) {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "p4", "perforce":
+			return vcsPerforce, true
+		case "plastic", "plasticscm", "cm", "unityvcs":
+			return vcsPlastic, true
+		case "none", "git", "off":
+			return vcsNone, true
+		}
+		return "", false
+	}
+	newClient := func(kind, source string) *vcsClient {
+		return &vcsClient{kind: kind, source: source, checkedOut: make(map[string]bool)}
+	}
+
+	if override != "" && override != "auto" {
+		kind, ok := normalize(override)
+		if !ok {
+			return nil, fmt.Errorf("unknown -vcs value '%s' (use auto, none, p4 or plastic)", override)
+		}
+		return newClient(kind, "-vcs flag"), nil
+	}
+	if env := os.Getenv(vcsEnvVar); env != "" {
+		if kind, ok := normalize(env); ok {
+			return newClient(kind, vcsEnvVar), nil
+		}
+	}
+
+	// Unity stores the project's integrated VCS in m_Mode ("Perforce", "PlasticSCM", ...)
+	if data, err := os.ReadFile(filepath.Join(projectRoot, "ProjectSettings", "VersionControlSettings.asset")); err == nil {
+		if m := regexp.MustCompile(`(?m)^\s*m_Mode:\s*(.+?)\s*$`).FindSubmatch(data); m != nil {
+			switch strings.ToLower(string(m[1])) {
+			case "perforce":
+				return newClient(vcsPerforce, "VersionControlSettings.asset"), nil
+			case "plasticscm", "plastic scm", "unity version control":
+				return newClient(vcsPlastic, "VersionControlSettings.asset"), nil
+			}
+		}
+	}
+
+	// Workspace markers, searched upward from the project root
+	p4config := os.Getenv("P4CONFIG")
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info != nil {
+				return newClient(vcsNone, "git repository"), nil
+			}
+			if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+				return newClient(vcsPlastic, ".plastic workspace"), nil
+			}
+			if p4config != "" {
+				if _, err := os.Stat(filepath.Join(dir, p4config)); err == nil {
+					return newClient(vcsPerforce, "P4CONFIG"), nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if os.Getenv("P4CLIENT") != "" {
+		return newClient(vcsPerforce, "P4CLIENT"), nil
+	}
+	return newClient(vcsNone, "no Perforce/Plastic workspace found"), nil
 }
 
-// NEW: result struct to hold processing outcome.
-type result struct {
-	path string
-	err  error
+// checkout opens an existing file for edit. New files need no checkout, and files
+// outside the depot/workspace only produce a warning: the write still goes ahead.
+func (v *vcsClient) checkout(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || v.checkedOut[abs] {
+		return
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return
+	}
+	v.checkedOut[abs] = true
+
+	var cmd *exec.Cmd
+	switch v.kind {
+	case vcsPerforce:
+		cmd = exec.Command("p4", "edit", abs)
+	case vcsPlastic:
+		cmd = exec.Command("cm", "checkout", abs)
+	default:
+		return
+	}
+	// Run from the file's folder so P4CONFIG / the Plastic workspace is resolved
+	cmd.Dir = filepath.Dir(abs)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		fmt.Printf(tr("[WARNING] %s checkout failed for %s: %v %s\n"), v.kind, filepath.Base(abs), err, strings.TrimSpace(string(out)))
+		return
+	}
+	fmt.Printf(tr("[VCS] Checked out (%s): %s\n"), v.kind, filepath.Base(abs))
 }
 
-// NEW: This function displays the intro and asks for user confirmation.
-func displayIntroAndConfirm() bool {
-	scanner := bufio.NewScanner(os.Stdin)
+// ============================================================
+// Safe File Writes
+// ============================================================
 
-	fmt.Println("--- LoudNorm: Game Audio Normalizer ---")
-	fmt.Println("\n[ About This Tool ]")
-	fmt.Println("This tool normalizes audio files for optimal game audio integration.")
-	fmt.Println("It automatically detects audio category from folder names and applies")
-	fmt.Println("appropriate normalization strategies.")
+// fsys is where writeFileAtomic writes in a dry run. The tools with an in-memory
+// dry-run overlay swap it; here -dry-run returns before the .meta and asset
+// rewrites, so it is always the disk.
+var fsys fileSystem = osFS{}
 
-	fmt.Println("\n[ Normalization Strategies ]")
-	fmt.Println("  Long audio (>= 3s): Two-pass LUFS loudness normalization (linear mode)")
-	fmt.Println("  Short audio (< 3s): Peak normalization (LUFS is unreliable for short SFX)")
+type fileSystem interface {
+	WriteFile(name string, data []byte, perm os.FileMode) error
+}
 
-	fmt.Println("\n[ Category Targets (auto-detected from folder name) ]")
-	fmt.Printf("  Music/BGM:     %5.1f LUFS | Peak: %.1f dBTP\n", categoryMusic.targetLUFS, categoryMusic.targetPeak)
-	fmt.Printf("  Voice/Dialog:  %5.1f LUFS | Peak: %.1f dBTP\n", categoryVoice.targetLUFS, categoryVoice.targetPeak)
-	fmt.Printf("  SFX/SE:        %5.1f LUFS | Peak: %.1f dBTP\n", categorySFX.targetLUFS, categorySFX.targetPeak)
-	fmt.Printf("  Ambient/Env:   %5.1f LUFS | Peak: %.1f dBTP\n", categoryAmbient.targetLUFS, categoryAmbient.targetPeak)
-	fmt.Printf("  Default:       %5.1f LUFS | Peak: %.1f dBTP\n", categoryDefault.targetLUFS, categoryDefault.targetPeak)
+type osFS struct{}
 
-	// --- Output Format Selection ---
-	fmt.Println("\n[ Output Format ]")
-	fmt.Println("  1. WAV  - Lossless (recommended: let Unity handle final compression)")
-	fmt.Println("  2. OGG  - Vorbis VBR (smaller files, use when disk/memory matters)")
-	fmt.Printf("\nSelect output format (1 or 2) [default: 1]: ")
-	scanner.Scan()
-	formatChoice := strings.TrimSpace(scanner.Text())
-	switch formatChoice {
-	case "2":
-		selectedFormat = formatOGG
-	default:
-		selectedFormat = formatWAV
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// isDryRun reports whether writes go to a dry-run overlay, which this tool has not
+func isDryRun() bool {
+	return false
+}
+
+// writeFileAtomic replaces path with data without ever leaving a half-written file:
+// data goes to a temp file in the same directory which is then renamed over the target.
+// The target's permission bits are kept, and a read-only flag (files under Perforce or
+// Plastic SCM that were not checked out) is cleared so the write does not fail.
+func writeFileAtomic(path string, data []byte) error {
+	// A dry run writes straight into the overlay: no checkout, no temp file
+	if isDryRun() {
+		return fsys.WriteFile(path, data, 0644)
+	}
+	activeVCS.checkout(path)
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if mode&0200 == 0 {
+			mode |= 0200
+			if err := os.Chmod(path, mode); err != nil {
+				return fmt.Errorf("cannot clear read-only flag on %s: %v", path, err)
+			}
+		}
 	}
-	fmt.Printf("  -> Selected: %s\n", selectedFormat.name)
 
-	fmt.Println("\n[ How It Works ]")
-	fmt.Println("1. It will recursively scan the current directory for audio files.")
-	fmt.Printf("2. For each audio file, it will create a new '%s' file with the '%s' suffix.\n", selectedFormat.ext, FILENAME_SUFFIX)
-	if selectedFormat.ext == ".wav" {
-		fmt.Println("3. Output is lossless WAV to avoid double compression when Unity re-encodes on import.")
-	} else {
-		fmt.Println("3. Output is OGG Vorbis — smaller files, but may double-compress if Unity re-encodes.")
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return err
 	}
-	fmt.Printf("4. Existing '%s%s' files will be overwritten.\n", FILENAME_SUFFIX, selectedFormat.ext)
-	fmt.Println("5. IMPORTANT: This tool requires FFmpeg to be installed and accessible in your system's PATH.")
 
-	fmt.Printf("\nDo you want to proceed? (Y/N): ")
-	scanner.Scan()
-	response := strings.TrimSpace(scanner.Text())
+	// Retry briefly: editors, indexers and antivirus can hold the target open on Windows
+	for attempt := 0; ; attempt++ {
+		err = os.Rename(tmpPath, path)
+		if err == nil || attempt == 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
 
-	return strings.ToLower(response) == "y"
+// ============================================================
+// Asset References
+// ============================================================
+
+// -addressables and -remap finish the swap inside Unity. Each normalized file gets
+// a .meta of its own, copied from the source's so it keeps the same import
+// settings but with a new GUID, and the references to the source GUID are then
+// pointed at it: -addressables edits the Addressables group assets, -remap the
+// listed assets (a ScriptableObject that maps sound IDs to clips, a prefab).
+// Unity picks the edits up on the next refresh. A group entry for a whole folder
+// has no per-file GUID, so it cannot be swapped this way.
+var (
+	updateAddressables bool
+	remapFlag          string
+	remapTargets       []string
+	forceLock          bool
+	vcsMode            string
+)
+
+var (
+	// Regex to find the GUID line of a .meta file.
+	metaGUIDRegex = regexp.MustCompile(`(?m)^guid:[ \t]*([0-9a-fA-F]{32})[ \t]*\r?$`)
+	// Regex to find a GUID reference: a group entry, an object reference or an AssetReference.
+	referenceGUIDRegex = regexp.MustCompile(`\b((?:guid|m_GUID|m_AssetGUID):[ \t]*)([0-9a-fA-F]{32})\b`)
+)
+
+// findUnityProjectRoot walks up from dir to the folder that holds Assets and ProjectSettings.
+func findUnityProjectRoot(dir string) (string, error) {
+	for d := dir; ; {
+		if isDir(filepath.Join(d, "Assets")) && isDir(filepath.Join(d, "ProjectSettings")) {
+			return d, nil
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return "", fmt.Errorf("%s is not inside a Unity project", dir)
+		}
+		d = parent
+	}
 }
 
-// waitForExit function pauses until the user presses Enter.
-func waitForExit() {
-	fmt.Println("\nPress Enter to exit...")
-	bufio.NewReader(os.Stdin).ReadBytes('\n')
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// resolveRemapTargets checks the -remap assets up front so a typo fails before any encoding.
+// Paths are relative to the project root, as Unity shows them.
+func resolveRemapTargets(projectRoot, list string) ([]string, error) {
+	var targets []string
+	for _, p := range strings.Split(list, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(projectRoot, filepath.FromSlash(p))
+		}
+		if info, err := os.Stat(p); err != nil || info.IsDir() {
+			return nil, fmt.Errorf("remap asset not found: %s", p)
+		}
+		targets = append(targets, p)
+	}
+	return targets, nil
+}
+
+// findAddressableGroups lists the group assets next to AddressableAssetSettings.asset.
+func findAddressableGroups(projectRoot string) ([]string, error) {
+	var settings string
+	filepath.Walk(filepath.Join(projectRoot, "Assets"), func(path string, info os.FileInfo, err error) error {
+		if err != nil || settings != "" {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && info.Name() == "AddressableAssetSettings.asset" {
+			settings = path
+		}
+		return nil
+	})
+	if settings == "" {
+		return nil, errors.New("AddressableAssetSettings.asset not found under Assets")
+	}
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(settings), "AssetGroups", "*.asset"))
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no group assets in %s", filepath.Join(filepath.Dir(settings), "AssetGroups"))
+	}
+	return matches, nil
+}
+
+// newAssetGUID returns a random GUID in the form Unity writes to .meta files.
+func newAssetGUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// outputMetaGUID returns the GUID of source and the one its normalized output will
+// have. The output keeps its .meta from an earlier run; otherwise one is written from
+// the source's (nothing is written under -dry-run). oldGUID is empty when Unity has
+// not imported the source yet.
+func outputMetaGUID(source, output string) (oldGUID, newGUID string, err error) {
+	data, err := os.ReadFile(source + ".meta")
+	if errors.Is(err, os.ErrNotExist) {
+		return "", "", nil
+	} else if err != nil {
+		return "", "", err
+	}
+	m := metaGUIDRegex.FindSubmatch(data)
+	if m == nil {
+		return "", "", fmt.Errorf("no guid in %s.meta", filepath.Base(source))
+	}
+	oldGUID = strings.ToLower(string(m[1]))
+
+	if existing, err := os.ReadFile(output + ".meta"); err == nil {
+		if m := metaGUIDRegex.FindSubmatch(existing); m != nil {
+			return oldGUID, strings.ToLower(string(m[1])), nil
+		}
+	}
+	if newGUID, err = newAssetGUID(); err != nil {
+		return "", "", err
+	}
+	if dryRun {
+		return oldGUID, newGUID, nil
+	}
+	meta := metaGUIDRegex.ReplaceAll(data, []byte("guid: "+newGUID))
+	if err := writeFileAtomic(output+".meta", meta); err != nil {
+		return "", "", err
+	}
+	return oldGUID, newGUID, nil
+}
+
+// rewriteGUIDReferences points the references in path at the normalized files and
+// returns how many it changed. The file is only written when something changed.
+func rewriteGUIDReferences(path string, swaps map[string]string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	text := referenceGUIDRegex.ReplaceAllStringFunc(string(data), func(ref string) string {
+		m := referenceGUIDRegex.FindStringSubmatch(ref)
+		if guid, ok := swaps[strings.ToLower(m[2])]; ok {
+			count++
+			return m[1] + guid
+		}
+		return ref
+	})
+	if count == 0 || dryRun {
+		return count, nil
+	}
+	if err := writeFileAtomic(path, []byte(text)); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// updateAssetReferences swaps the normalized files in for their sources in the
+// Addressables groups and the -remap assets.
+func updateAssetReferences(projectRoot string, sources []string) {
+	fmt.Println(tr("\n--- Asset References ---"))
+	// The .meta files and the edited assets are project files: hold the lock while writing them
+	if !dryRun {
+		if err := acquireProjectLock(projectRoot, "audio_volume_normalizer", "remap", forceLock); err != nil {
+			fmt.Printf(tr("  [!!] References not updated: %v\n"), err)
+			recordError("%v", err)
+			return
+		}
+		defer releaseProjectLock()
+	}
+	rel := func(p string) string {
+		if r, err := filepath.Rel(projectRoot, p); err == nil {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+
+	swaps := make(map[string]string)
+	for _, source := range sources {
+		output := normalizedPath(source)
+		oldGUID, newGUID, err := outputMetaGUID(source, output)
+		if err != nil {
+			fmt.Printf(tr("  [!!] %s: %v\n"), rel(output), err)
+			recordError("%s: %v", output, err)
+			continue
+		}
+		if oldGUID == "" {
+			fmt.Printf(tr("  [--] %s: no .meta yet, Unity has not imported it\n"), rel(source))
+			continue
+		}
+		swaps[oldGUID] = newGUID
+	}
+	if len(swaps) == 0 {
+		fmt.Println(tr("  No imported files to swap."))
+		return
+	}
+
+	targets := remapTargets
+	if updateAddressables {
+		groups, err := findAddressableGroups(projectRoot)
+		if err != nil {
+			fmt.Printf(tr("  [!!] Addressables: %v\n"), err)
+			recordError("addressables: %v", err)
+		}
+		targets = append(groups, targets...)
+	}
+	seen := make(map[string]bool)
+	total := 0
+	for _, target := range targets {
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+		n, err := rewriteGUIDReferences(target, swaps)
+		if err != nil {
+			fmt.Printf(tr("  [!!] %s: %v\n"), rel(target), err)
+			recordError("%s: %v", target, err)
+			continue
+		}
+		if n == 0 {
+			continue
+		}
+		total += n
+		if dryRun {
+			fmt.Printf(tr("  [..] %s: %d reference(s) would point at the normalized files\n"), rel(target), n)
+			recordAction("remap", target, "dry-run", fmt.Sprintf("%d reference(s)", n), 0)
+		} else {
+			fmt.Printf(tr("  [OK] %s: %d reference(s) now point at the normalized files\n"), rel(target), n)
+			recordAction("remap", target, "ok", fmt.Sprintf("%d reference(s)", n), 0)
+			recordArtifact(target)
+		}
+	}
+	if total == 0 {
+		fmt.Println(tr("  No references to the processed files were found."))
+	}
 }
 
 func main() {
+	var noNotify bool
+	flag.BoolVar(&noNotify, "no-notify", false, "Do not post the result to the webhooks in .unitystarter.json")
+	flag.BoolVar(&dryRun, "dry-run", false, "Analyze every file and list the ffmpeg commands that would write output, without running them")
+	flag.BoolVar(&updateAddressables, "addressables", false, "Point Addressables group entries at the normalized files")
+	flag.StringVar(&encoderFlag, "encoder", "", "ffmpeg encoder to use instead of the best one the build has (ogg: libvorbis, vorbis; wav/flac follow -bit-depth)")
+	flag.StringVar(&bitDepthFlag, "bit-depth", "16", "Sample format of WAV/FLAC output: 16, 24 or 32f (32-bit float, WAV only)")
+	flag.StringVar(&ditherFlag, "dither", "none", "Dither when rounding to 16-bit: none, triangular, triangular_hp, lipshitz, shibata")
+	flag.StringVar(&nameCaseFlag, "name-case", "keep", "Case of the normalized file names: keep, lower or snake (snake_case)")
+	flag.StringVar(&namePrefixFlag, "name-prefix", "", "Category prefix for the normalized file names: auto, or Music=mus_,SFX=sfx_,...")
+	flag.StringVar(&planPath, "plan", "", "Analyze only and write the proposed gain per file to this JSON plan for review")
+	flag.StringVar(&applyPath, "apply", "", "Normalize only the approved entries of a plan written by -plan, to the targets in it")
+	flag.StringVar(&htmlReportPath, "html-report", "", "Write an HTML page with before/after loudness per file and a histogram to this path")
+	flag.StringVar(&remapFlag, "remap", "", "Comma-separated assets (e.g. a sound bank ScriptableObject) whose references move to the normalized files")
+	flag.StringVar(&vcsMode, "vcs", "auto", "Checkout before overwriting outputs, .meta files and assets: auto, none, p4, plastic")
+	flag.BoolVar(&forceLock, "force", false, "-addressables/-remap: run even if '.unitystarter.lock' says another tool is working on the project")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (prompts go to stderr)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("audio_volume_normalizer")
+	}
+	depth, err := checkDepthFlags()
+	if err == nil {
+		err = parseNamingFlags()
+	}
+	if err != nil {
+		log.Printf(tr("Error: %v\n"), err)
+		recordError("%v", err)
+		return
+	}
+	if planPath != "" && applyPath != "" {
+		log.Println(tr("Error: -plan and -apply are separate runs; review the plan in between."))
+		recordError("-plan and -apply cannot be combined")
+		return
+	}
+	if planPath != "" {
+		// Planning is a dry run that keeps the measurements
+		dryRun = true
+	}
+
 	// NEW: Display the introduction and wait for confirmation before doing anything else.
 	if !displayIntroAndConfirm() {
-		fmt.Println("Operation cancelled by user.")
+		fmt.Println(tr("Operation cancelled by user."))
+		recordError("operation cancelled by user")
 		waitForExit()
 		return
 	}
 
-	fmt.Println("\nUser confirmed. Starting process...")
+	fmt.Println(tr("\nUser confirmed. Starting process..."))
+
+	// Get the current working directory to start the scan.
+	rootDir, err := os.Getwd()
+	if err != nil {
+		log.Printf(tr("Failed to get current working directory: %v\n"), err)
+		recordError("failed to get current working directory: %v", err)
+		waitForExit()
+		return
+	}
+	var projectRoot string
+	if updateAddressables || remapFlag != "" {
+		if projectRoot, err = findUnityProjectRoot(rootDir); err == nil {
+			remapTargets, err = resolveRemapTargets(projectRoot, remapFlag)
+		}
+		if err != nil {
+			log.Printf(tr("Cannot update asset references: %v\n"), err)
+			recordError("cannot update asset references: %v", err)
+			waitForExit()
+			return
+		}
+	}
+	vcsRoot := rootDir
+	if projectRoot != "" {
+		vcsRoot = projectRoot
+	}
+	if activeVCS, err = detectVCS(vcsRoot, vcsMode); err != nil {
+		log.Printf(tr("Error: %v\n"), err)
+		recordError("%v", err)
+		waitForExit()
+		return
+	}
+	if applyPath != "" {
+		if approvedFiles, err = loadApproved(applyPath, rootDir); err != nil {
+			log.Printf(tr("Failed to read the plan: %v\n"), err)
+			recordError("failed to read the plan: %v", err)
+			waitForExit()
+			return
+		}
+		if len(approvedFiles) == 0 {
+			fmt.Println(tr("Nothing approved to normalize."))
+			waitForExit()
+			return
+		}
+	}
+	dry, err := setupRunner(rootDir, dryRun)
+	if err != nil {
+		log.Printf(tr("Failed to load the command fixture: %v\n"), err)
+		recordError("failed to load the command fixture: %v", err)
+		waitForExit()
+		return
+	}
 
 	// Verify that ffmpeg is available in the system's PATH.
 	if !commandExists("ffmpeg") {
-		log.Println("Error: Could not find ffmpeg. Please ensure FFmpeg is installed and added to your system's PATH.")
+		log.Println(tr("Error: Could not find ffmpeg. Please ensure FFmpeg is installed and added to your system's PATH."))
+		recordError("ffmpeg not found in PATH")
 		waitForExit()
 		return
 	}
-
-	// Get the current working directory to start the scan.
-	rootDir, err := os.Getwd()
+	if selectedFormat, err = applyBitDepth(selectedFormat, depth); err != nil {
+		log.Printf(tr("Error: %v\n"), err)
+		recordError("%v", err)
+		waitForExit()
+		return
+	}
+	if projectRoot != "" && selectedFormat.ext == ".flac" {
+		log.Println(tr("Error: Unity does not import FLAC, so -addressables and -remap need WAV or OGG output."))
+		recordError("-addressables and -remap need WAV or OGG output")
+		waitForExit()
+		return
+	}
+	available, err := probeEncoders()
+	if err != nil {
+		fmt.Printf(tr("Warning: could not list the ffmpeg encoders (%v); using the default for %s.\n"), err, selectedFormat.ext)
+	}
+	encoder, err := selectEncoder(selectedFormat, encoderFlag, available)
 	if err != nil {
-		log.Printf("Failed to get current working directory: %v\n", err)
+		log.Printf(tr("Error: %v\n"), err)
+		recordError("%v", err)
 		waitForExit()
 		return
 	}
-	fmt.Printf("Scanning for audio files in [%s] and its subdirectories...\n", rootDir)
+	selectedFormat.ffmpegArgs = encoder.args
+	fmt.Printf(tr("Output: %s, encoder: %s\n"), selectedFormat.name, encoder.name)
+	if encoder.name != selectedFormat.encoders[0].name {
+		if encoder.note != "" {
+			fmt.Printf(tr("  Note: %s\n"), tr(encoder.note))
+		}
+		recordAction("encoder", encoder.name, "ok", "fallback from "+selectedFormat.encoders[0].name, 0)
+	}
+	fmt.Printf(tr("Scanning for audio files in [%s] and its subdirectories...\n"), rootDir)
 
 	// --- NEW: First pass to count files for the progress bar ---
 	var totalFiles int32
+	// Two sources written to one file (hit.wav and hit.mp3, or names the naming
+	// convention makes equal) would overwrite each other
+	outputs := make(map[string]string)
 	err = filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() && isAudioFile(path) && !strings.HasSuffix(strings.TrimSuffix(path, filepath.Ext(path)), FILENAME_SUFFIX) {
+		if !info.IsDir() && isAudioFile(path) && !strings.HasSuffix(strings.TrimSuffix(path, filepath.Ext(path)), FILENAME_SUFFIX) && inScope(path) {
+			output := normalizedPath(path)
+			if other, ok := outputs[strings.ToLower(output)]; ok {
+				return fmt.Errorf("%s and %s would both be written to %s", other, path, filepath.Base(output))
+			}
+			outputs[strings.ToLower(output)] = path
 			atomic.AddInt32(&totalFiles, 1)
 		}
 		return nil
 	})
 	if err != nil {
-		log.Printf("Error during initial file scan: %v\n", err)
+		log.Printf(tr("Error during initial file scan: %v\n"), err)
+		recordError("error during initial file scan: %v", err)
 		waitForExit()
 		return
 	}
 	if totalFiles == 0 {
-		fmt.Println("No audio files found to process.")
+		fmt.Println(tr("No audio files found to process."))
 		waitForExit()
 		return
 	}
-	fmt.Printf("Found %d audio files to process.\n\n", totalFiles)
+	fmt.Printf(tr("Found %d audio files to process.\n\n"), totalFiles)
+	if !noNotify && !dryRun {
+		enableNotifications(rootDir, "audio_volume_normalizer")
+	}
 	// --- End of file counting ---
 
 	// Set up a concurrent processing pool.
 	var wg sync.WaitGroup
 	jobs := make(chan job)
 	results := make(chan result)
-	var processedFiles int32
+
+	// Each worker shows the file it is on below the overall bar
+	board := newProgressBoard()
+	overall := board.add(tr("Normalizing"), int64(totalFiles))
 
 	// Start the worker goroutines.
 	for i := 0; i < WORKER_COUNT; i++ {
 		wg.Add(1)
-		go worker(i+1, &wg, jobs, results)
+		go worker(i+1, &wg, board, jobs, results)
 	}
 
 	// Start a goroutine to walk the directory and dispatch jobs.
@@ -258,7 +2995,7 @@ func main() {
 			if err != nil {
 				return err
 			}
-			if !info.IsDir() && isAudioFile(path) && !strings.HasSuffix(strings.TrimSuffix(path, filepath.Ext(path)), FILENAME_SUFFIX) {
+			if !info.IsDir() && isAudioFile(path) && !strings.HasSuffix(strings.TrimSuffix(path, filepath.Ext(path)), FILENAME_SUFFIX) && inScope(path) {
 				jobs <- job{path: path}
 			}
 			return nil
@@ -275,59 +3012,102 @@ func main() {
 	var successfulFiles []string
 	var failedFiles []result
 	var skippedFiles []string
+	var allResults []result
 	for res := range results {
-		atomic.AddInt32(&processedFiles, 1)
+		allResults = append(allResults, res)
 		if res.err == nil {
 			successfulFiles = append(successfulFiles, res.path)
+			recordAction("normalize", res.path, "ok", selectedFormat.ext, res.duration)
+			if !dryRun {
+				recordArtifact(normalizedPath(res.path))
+			}
 		} else if errors.Is(res.err, ErrAlreadyNormalized) {
 			skippedFiles = append(skippedFiles, res.path)
+			recordAction("normalize", res.path, "skipped", "already normalized", res.duration)
 		} else {
 			failedFiles = append(failedFiles, res)
+			recordAction("normalize", res.path, "failed", res.err.Error(), res.duration)
+			recordError("%s: %v", res.path, res.err)
+			overall.fail()
 		}
-		// --- NEW: Progress Bar ---
-		printProgressBar(atomic.LoadInt32(&processedFiles), totalFiles)
+		overall.advance(1)
 	}
+	board.close()
 
-	fmt.Println("\nAll tasks completed!")
+	fmt.Println(tr("\nAll tasks completed!"))
 
 	// --- NEW: Print Processing Summary ---
-	fmt.Println("\n--- Processing Summary ---")
-	fmt.Printf("\nSuccessfully processed %d files:\n", len(successfulFiles))
+	fmt.Println(tr("\n--- Processing Summary ---"))
+	fmt.Printf(tr("\nSuccessfully processed %d files:\n"), len(successfulFiles))
 	if len(successfulFiles) > 0 {
 		for _, file := range successfulFiles {
 			fmt.Printf("  - %s\n", file)
 		}
 	} else {
-		fmt.Println("  (None)")
+		fmt.Println(tr("  (None)"))
 	}
 
-	fmt.Printf("\nSkipped %d files (already normalized):\n", len(skippedFiles))
+	fmt.Printf(tr("\nSkipped %d files (already normalized):\n"), len(skippedFiles))
 	if len(skippedFiles) > 0 {
 		for _, file := range skippedFiles {
 			fmt.Printf("  - %s\n", file)
 		}
 	} else {
-		fmt.Println("  (None)")
+		fmt.Println(tr("  (None)"))
 	}
 
-	fmt.Printf("\nFailed to process %d files:\n", len(failedFiles))
+	fmt.Printf(tr("\nFailed to process %d files:\n"), len(failedFiles))
 	if len(failedFiles) > 0 {
 		for _, f := range failedFiles {
-			fmt.Printf("  - %s\n    Error: %v\n", f.path, f.err)
+			fmt.Printf(tr("  - %s\n    Error: %v\n"), f.path, f.err)
 		}
 	} else {
-		fmt.Println("  (None)")
+		fmt.Println(tr("  (None)"))
+	}
+	if htmlReportPath != "" {
+		if err := writeHTMLReport(htmlReportPath, rootDir, allResults); err != nil {
+			fmt.Printf(tr("\n[!!] Could not write the loudness report: %v\n"), err)
+			recordError("could not write the loudness report: %v", err)
+		} else {
+			fmt.Printf(tr("\n[OK] Loudness report: %s\n"), htmlReportPath)
+			recordArtifact(htmlReportPath)
+		}
+	}
+	if nameCaseFlag != "keep" || categoryPrefixes != nil {
+		fmt.Println(tr("\n--- Naming ---"))
+		adoptLegacyOutputs(rootDir, successfulFiles)
+	}
+	if projectRoot != "" {
+		updateAssetReferences(projectRoot, successfulFiles)
+	}
+	if planPath != "" {
+		if n, err := writePlan(planPath, rootDir, allResults); err != nil {
+			fmt.Printf(tr("\n[!!] Could not write the plan: %v\n"), err)
+			recordError("could not write the plan: %v", err)
+		} else {
+			fmt.Printf(tr("\n[OK] Plan: %d file(s) to review in %s\n"), n, planPath)
+			fmt.Printf(tr("     Set \"approve\" to false for the files to keep as they are, then run again with -apply %s\n"), planPath)
+			recordArtifact(planPath)
+		}
+	} else if dry != nil {
+		dry.printDryRunReport()
 	}
 
+	setNotifySummary("%d normalized, %d already normalized, %d failed", len(successfulFiles), len(skippedFiles), len(failedFiles))
+	sendNotifications(0)
 	waitForExit()
 }
 
 // worker is a concurrent processor for handling normalization jobs.
-func worker(id int, wg *sync.WaitGroup, jobs <-chan job, results chan<- result) {
+func worker(id int, wg *sync.WaitGroup, board *progressBoard, jobs <-chan job, results chan<- result) {
 	defer wg.Done()
 	for j := range jobs {
-		err := processFile(j.path)
-		results <- result{path: j.path, err: err}
+		start := time.Now()
+		step := board.addStep(filepath.Base(j.path))
+		level := &loudness{}
+		err := processFile(j.path, level)
+		step.finish()
+		results <- result{path: j.path, err: err, duration: time.Since(start), level: level}
 	}
 }
 
@@ -359,22 +3139,29 @@ func parseDuration(ffmpegOutput string) float64 {
 }
 
 // processFile normalizes a single audio file using the appropriate strategy.
-func processFile(filePath string) error {
+// The levels it measured go to lv for the report.
+func processFile(filePath string, lv *loudness) error {
 	cat := detectCategory(filePath)
+	if e := approvedFiles[filePath]; e != nil {
+		// The target may have been moved in the plan
+		if e.Strategy == strategyPeak {
+			cat.targetPeak = e.Target
+		} else {
+			cat.targetLUFS = e.Target
+		}
+	}
 
 	// --- Step 1: FFmpeg First Pass (Analysis) ---
 	loudnormFilterPass1 := fmt.Sprintf("loudnorm=I=%.1f:TP=%.1f:LRA=11:print_format=json", cat.targetLUFS, cat.targetTP)
 	ctx1, cancel1 := context.WithTimeout(context.Background(), FFMPEG_TIMEOUT)
 	defer cancel1()
-	cmdPass1 := exec.CommandContext(ctx1, "ffmpeg", "-i", filePath, "-af", loudnormFilterPass1, "-f", "null", "-")
-	var pass1Stderr bytes.Buffer
-	cmdPass1.Stderr = &pass1Stderr
-	if err := cmdPass1.Run(); err != nil {
+	_, pass1Stderr, err := runner.Output(ctx1, "ffmpeg", "-i", filePath, "-af", loudnormFilterPass1, "-f", "null", "-")
+	if err != nil {
 		if ctx1.Err() == context.DeadlineExceeded {
 			return fmt.Errorf("ffmpeg analysis timed out after %v", FFMPEG_TIMEOUT)
 		}
 	}
-	pass1Output := pass1Stderr.String()
+	pass1Output := string(pass1Stderr)
 
 	// Determine audio duration to choose normalization strategy.
 	duration := parseDuration(pass1Output)
@@ -390,30 +3177,36 @@ func processFile(filePath string) error {
 		targetSampleRate = MAX_SAMPLERATE
 	}
 
-	outputFilePath := strings.TrimSuffix(filePath, filepath.Ext(filePath)) + FILENAME_SUFFIX + selectedFormat.ext
+	outputFilePath := normalizedPath(filePath)
 
 	// --- Choose Strategy ---
 	if duration >= 0 && duration < SHORT_AUDIO_THRESHOLD_SEC {
-		return processShortAudio(filePath, outputFilePath, pass1Output, cat, targetSampleRate)
+		lv.strategy, lv.target = strategyPeak, cat.targetPeak
+		err = processShortAudio(filePath, outputFilePath, pass1Output, cat, targetSampleRate, lv)
+	} else {
+		lv.strategy, lv.target = strategyLUFS, cat.targetLUFS
+		err = processLongAudio(filePath, outputFilePath, pass1Output, cat, targetSampleRate, lv)
 	}
-	return processLongAudio(filePath, outputFilePath, pass1Output, cat, targetSampleRate)
+	lv.after = lv.target
+	if err == nil && htmlReportPath != "" && !dryRun {
+		measureOutput(outputFilePath, lv)
+	}
+	return err
 }
 
 // processShortAudio uses peak normalization for short sound effects.
 // LUFS measurement is unreliable for audio < 400ms and not ideal for short SFX in general.
-func processShortAudio(filePath, outputFilePath, ffmpegOutput string, cat audioCategory, targetSampleRate int) error {
+func processShortAudio(filePath, outputFilePath, ffmpegOutput string, cat audioCategory, targetSampleRate int, lv *loudness) error {
 	// Use ffmpeg's volumedetect to get peak level.
 	ctx, cancel := context.WithTimeout(context.Background(), FFMPEG_TIMEOUT)
 	defer cancel()
-	cmdDetect := exec.CommandContext(ctx, "ffmpeg", "-i", filePath, "-af", "volumedetect", "-f", "null", "-")
-	var detectStderr bytes.Buffer
-	cmdDetect.Stderr = &detectStderr
-	if err := cmdDetect.Run(); err != nil {
+	_, detectStderr, err := runner.Output(ctx, "ffmpeg", "-i", filePath, "-af", "volumedetect", "-f", "null", "-")
+	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
 			return fmt.Errorf("ffmpeg volumedetect timed out after %v", FFMPEG_TIMEOUT)
 		}
 	}
-	detectOutput := detectStderr.String()
+	detectOutput := string(detectStderr)
 
 	// Extract max_volume from volumedetect output.
 	match := maxVolRegex.FindStringSubmatch(detectOutput)
@@ -432,6 +3225,7 @@ func processShortAudio(filePath, outputFilePath, ffmpegOutput string, cat audioC
 	if math.IsInf(maxVolume, -1) {
 		return fmt.Errorf("file appears to be silent, skipping")
 	}
+	lv.before, lv.known = maxVolume, true
 
 	// Skip if already close enough.
 	if math.Abs(gainNeeded) <= PEAK_TOLERANCE {
@@ -446,23 +3240,25 @@ func processShortAudio(filePath, outputFilePath, ffmpegOutput string, cat audioC
 	volumeFilter := fmt.Sprintf("volume=%.2fdB", gainNeeded)
 	ctx2, cancel2 := context.WithTimeout(context.Background(), FFMPEG_TIMEOUT)
 	defer cancel2()
-	args := []string{"-y", "-i", filePath, "-map_metadata", "-1", "-af", volumeFilter}
+	args := []string{"-y", "-i", filePath, "-map_metadata", "-1", "-af", outputFilter(volumeFilter, targetSampleRate)}
 	args = append(args, selectedFormat.ffmpegArgs...)
-	args = append(args, "-ar", strconv.Itoa(targetSampleRate), outputFilePath)
-	cmdApply := exec.CommandContext(ctx2, "ffmpeg", args...)
-	var applyStderr bytes.Buffer
-	cmdApply.Stderr = &applyStderr
-	if err := cmdApply.Run(); err != nil {
+	tempOutput := tempOutputPath(outputFilePath)
+	args = append(args, "-ar", strconv.Itoa(targetSampleRate), tempOutput)
+	if _, applyStderr, err := runner.Run(ctx2, "ffmpeg", args...); err != nil {
+		os.Remove(tempOutput)
 		if ctx2.Err() == context.DeadlineExceeded {
 			return fmt.Errorf("ffmpeg peak normalize timed out after %v", FFMPEG_TIMEOUT)
 		}
-		return fmt.Errorf("ffmpeg peak normalization failed: %w\nOutput:\n%s", err, applyStderr.String())
+		return fmt.Errorf("ffmpeg peak normalization failed: %w\nOutput:\n%s", err, applyStderr)
 	}
-	return nil
+	if dryRun {
+		return nil
+	}
+	return replaceFileAtomic(tempOutput, outputFilePath)
 }
 
 // processLongAudio uses two-pass LUFS normalization with linear mode.
-func processLongAudio(filePath, outputFilePath, pass1Output string, cat audioCategory, targetSampleRate int) error {
+func processLongAudio(filePath, outputFilePath, pass1Output string, cat audioCategory, targetSampleRate int, lv *loudness) error {
 	lnInfo, err := extractLoudnormInfo(pass1Output)
 	if err != nil {
 		return fmt.Errorf("failed to extract loudness info: %w", err)
@@ -474,6 +3270,7 @@ func processLongAudio(filePath, outputFilePath, pass1Output string, cat audioCat
 		if math.IsInf(measuredLufs, -1) {
 			return fmt.Errorf("file appears to be silent (measured LUFS: -inf), skipping")
 		}
+		lv.before, lv.known = measuredLufs, true
 		if math.Abs(measuredLufs-cat.targetLUFS) <= LOUDNESS_TOLERANCE {
 			return ErrAlreadyNormalized
 		}
@@ -488,20 +3285,22 @@ func processLongAudio(filePath, outputFilePath, pass1Output string, cat audioCat
 
 	ctx2, cancel2 := context.WithTimeout(context.Background(), FFMPEG_TIMEOUT)
 	defer cancel2()
-	args := []string{"-y", "-i", filePath, "-map_metadata", "-1", "-af", loudnormFilterPass2}
+	args := []string{"-y", "-i", filePath, "-map_metadata", "-1", "-af", outputFilter(loudnormFilterPass2, targetSampleRate)}
 	args = append(args, selectedFormat.ffmpegArgs...)
-	args = append(args, "-ar", strconv.Itoa(targetSampleRate), outputFilePath)
-	cmdPass2 := exec.CommandContext(ctx2, "ffmpeg", args...)
-	var pass2Stderr bytes.Buffer
-	cmdPass2.Stderr = &pass2Stderr
-	if err := cmdPass2.Run(); err != nil {
+	tempOutput := tempOutputPath(outputFilePath)
+	args = append(args, "-ar", strconv.Itoa(targetSampleRate), tempOutput)
+	if _, pass2Stderr, err := runner.Run(ctx2, "ffmpeg", args...); err != nil {
+		os.Remove(tempOutput)
 		if ctx2.Err() == context.DeadlineExceeded {
 			return fmt.Errorf("ffmpeg second pass timed out after %v", FFMPEG_TIMEOUT)
 		}
-		return fmt.Errorf("ffmpeg second pass failed: %w\nOutput:\n%s", err, pass2Stderr.String())
+		return fmt.Errorf("ffmpeg second pass failed: %w\nOutput:\n%s", err, pass2Stderr)
+	}
+	if dryRun {
+		return nil
 	}
 
-	return nil
+	return replaceFileAtomic(tempOutput, outputFilePath)
 }
 
 func extractLoudnormInfo(stderr string) (*LoudnormInfo, error) {
@@ -534,25 +3333,69 @@ func extractLoudnormInfo(stderr string) (*LoudnormInfo, error) {
 	return &lnInfo, nil
 }
 
+// tempOutputPath returns a hidden sibling of outputPath for ffmpeg to encode into.
+// The leading dot keeps Unity from importing the partial file; the extension is kept
+// so ffmpeg still picks the right container.
+func tempOutputPath(outputPath string) string {
+	dir, base := filepath.Split(outputPath)
+	ext := filepath.Ext(base)
+	return filepath.Join(dir, "."+strings.TrimSuffix(base, ext)+".tmp"+ext)
+}
+
+// replaceFileAtomic moves a finished encode over outputPath in one rename, so a
+// cancelled or failed run never leaves a truncated file (and its .meta) behind.
+// An existing output is checked out first and keeps its permissions; a read-only
+// flag left by a failed Perforce/Plastic SCM checkout is cleared.
+func replaceFileAtomic(tempPath, outputPath string) error {
+	activeVCS.checkout(outputPath)
+	if info, err := os.Stat(outputPath); err == nil {
+		mode := info.Mode().Perm() | 0200
+		if err := os.Chmod(outputPath, mode); err != nil {
+			os.Remove(tempPath)
+			return fmt.Errorf("cannot clear read-only flag on %s: %w", outputPath, err)
+		}
+		os.Chmod(tempPath, mode)
+	}
+
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		if err = os.Rename(tempPath, outputPath); err == nil {
+			return nil
+		}
+		// Unity's importer or an audio preview may still have the old file open
+		time.Sleep(100 * time.Millisecond)
+	}
+	os.Remove(tempPath)
+	return fmt.Errorf("failed to replace %s: %w", outputPath, err)
+}
+
 func isAudioFile(path string) bool {
+	// Hidden files include leftover temp encodes from an interrupted run
+	if strings.HasPrefix(filepath.Base(path), ".") {
+		return false
+	}
 	ext := strings.ToLower(filepath.Ext(path))
 	return audioExtensions[ext]
 }
 
 func commandExists(cmd string) bool {
-	_, err := exec.LookPath(cmd)
-	return err == nil
+	return lookPath(cmd) == nil
 }
 
-// NEW: printProgressBar function to draw the progress bar.
-func printProgressBar(current, total int32) {
-	barLength := 40
-	percent := float64(current) / float64(total)
-	filledLength := int(float64(barLength) * percent)
-
-	bar := strings.Repeat("█", filledLength) + strings.Repeat("-", barLength-filledLength)
-	fmt.Printf("\r[%s] %.0f%% (%d/%d)", bar, percent*100, current, total)
-	if current == total {
-		fmt.Println() // Newline at the end
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
 	}
 }
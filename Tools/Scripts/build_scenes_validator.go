@@ -0,0 +1,1349 @@
+// Build Scenes Validator — Check EditorBuildSettings.asset scene entries against the project.
+// Every entry in m_Scenes must point to an existing .unity file whose .meta GUID matches the
+// GUID stored in the build settings. Scenes moved outside the editor (manually, by a VCS
+// merge, or by rename_project) leave stale entries that Unity silently drops from builds.
+//
+// Build: go build build_scenes_validator.go
+//
+// Usage: run from the Unity project root.
+//
+//	build_scenes_validator              # report only (exit 1 on problems)
+//	build_scenes_validator -fix         # repair stale GUIDs and moved scene paths
+//	build_scenes_validator -fix -dry-run
+//	build_scenes_validator -json        # result document on stdout
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+var (
+	sceneEnabledRegex = regexp.MustCompile(`^(\s*)- enabled: (\d)`)
+	scenePathRegex    = regexp.MustCompile(`^(\s*)path: (.*)$`)
+	sceneGUIDRegex    = regexp.MustCompile(`^(\s*)guid: ([0-9a-fA-F]*)\s*$`)
+	metaGUIDRegex     = regexp.MustCompile(`(?m)^guid: ([0-9a-fA-F]{32})\s*$`)
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// sceneEntry is one m_Scenes item, with the line indexes needed to patch it in place
+type sceneEntry struct {
+	index    int // position in the build list (build index for enabled scenes)
+	enabled  bool
+	path     string
+	guid     string
+	pathLine int
+	guidLine int
+}
+
+// sceneIssue describes a problem with an entry and, if resolvable, its fix
+type sceneIssue struct {
+	entry   sceneEntry
+	problem string
+	newPath string // "" = unchanged
+	newGUID string // "" = unchanged
+}
+
+func (i sceneIssue) fixable() bool {
+	return i.newPath != "" || i.newGUID != ""
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Parsing
+// ============================================================
+
+// parseScenes reads the m_Scenes list. Parsing is line based so the file can be
+// patched without re-serializing the YAML (Unity's formatting is preserved).
+func parseScenes(lines []string) []sceneEntry {
+	var entries []sceneEntry
+	inScenes := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "m_Scenes:" {
+			inScenes = true
+			continue
+		}
+		if trimmed == "m_Scenes: []" {
+			return nil
+		}
+		if !inScenes {
+			continue
+		}
+		// Next top-level key of EditorBuildSettings ends the list
+		if strings.HasPrefix(line, "  m_") {
+			break
+		}
+		if m := sceneEnabledRegex.FindStringSubmatch(line); m != nil {
+			entries = append(entries, sceneEntry{index: len(entries), enabled: m[2] == "1", pathLine: -1, guidLine: -1})
+			continue
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		cur := &entries[len(entries)-1]
+		if m := scenePathRegex.FindStringSubmatch(line); m != nil {
+			cur.path = strings.TrimSpace(m[2])
+			cur.pathLine = i
+		} else if m := sceneGUIDRegex.FindStringSubmatch(line); m != nil {
+			cur.guid = strings.ToLower(m[2])
+			cur.guidLine = i
+		}
+	}
+	return entries
+}
+
+// readMetaGUID returns the guid stored in <assetPath>.meta
+func readMetaGUID(basePath, assetPath string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(assetPath)) + ".meta")
+	if err != nil {
+		return "", err
+	}
+	m := metaGUIDRegex.FindSubmatch(data)
+	if m == nil {
+		return "", fmt.Errorf("no guid in %s.meta", assetPath)
+	}
+	return strings.ToLower(string(m[1])), nil
+}
+
+// indexScenes maps every scene in Assets/ by GUID and by file name
+func indexScenes(basePath string) (byGUID map[string]string, byName map[string][]string) {
+	byGUID = make(map[string]string)
+	byName = make(map[string][]string)
+	filepath.Walk(filepath.Join(basePath, "Assets"), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".unity") {
+			return nil
+		}
+		rel, _ := filepath.Rel(basePath, path)
+		rel = filepath.ToSlash(rel)
+		if guid, err := readMetaGUID(basePath, rel); err == nil {
+			byGUID[guid] = rel
+		}
+		name := filepath.Base(rel)
+		byName[name] = append(byName[name], rel)
+		return nil
+	})
+	return byGUID, byName
+}
+
+// ============================================================
+// Validation
+// ============================================================
+
+func validate(basePath string, entries []sceneEntry) []sceneIssue {
+	byGUID, byName := indexScenes(basePath)
+	var issues []sceneIssue
+
+	for _, e := range entries {
+		if e.path == "" {
+			issue := sceneIssue{entry: e, problem: tr("entry has no path")}
+			if p, ok := byGUID[e.guid]; ok {
+				issue.newPath = p
+			}
+			issues = append(issues, issue)
+			continue
+		}
+
+		if _, err := os.Stat(filepath.Join(basePath, filepath.FromSlash(e.path))); err == nil {
+			metaGUID, err := readMetaGUID(basePath, e.path)
+			if err != nil {
+				issues = append(issues, sceneIssue{entry: e, problem: fmt.Sprintf(tr("cannot read .meta: %v"), err)})
+				continue
+			}
+			if metaGUID != e.guid {
+				issues = append(issues, sceneIssue{
+					entry:   e,
+					problem: fmt.Sprintf(tr("GUID mismatch (settings: %s, .meta: %s)"), e.guid, metaGUID),
+					newGUID: metaGUID,
+				})
+			}
+			continue
+		}
+
+		// Scene file is gone: the GUID is the reliable way to find where it moved
+		issue := sceneIssue{entry: e, problem: tr("scene file not found")}
+		if p, ok := byGUID[e.guid]; ok {
+			issue.newPath = p
+			issue.problem += tr(" (moved, found by GUID)")
+		} else if candidates := byName[filepath.Base(e.path)]; len(candidates) == 1 {
+			// .meta was regenerated after the move: match by file name if unambiguous
+			issue.newPath = candidates[0]
+			if g, err := readMetaGUID(basePath, candidates[0]); err == nil && g != e.guid {
+				issue.newGUID = g
+			}
+			issue.problem += tr(" (found by file name, GUID differs)")
+		} else if len(candidates) > 1 {
+			issue.problem += fmt.Sprintf(tr(" (%d scenes named %s, cannot choose)"), len(candidates), filepath.Base(e.path))
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues
+}
+
+// applyFixes patches the path/guid lines in place, keeping indentation
+func applyFixes(lines []string, issues []sceneIssue) int {
+	fixed := 0
+	for _, issue := range issues {
+		if !issue.fixable() {
+			continue
+		}
+		e := issue.entry
+		if issue.newPath != "" && e.pathLine >= 0 {
+			m := scenePathRegex.FindStringSubmatch(lines[e.pathLine])
+			lines[e.pathLine] = m[1] + "path: " + issue.newPath
+		}
+		if issue.newGUID != "" && e.guidLine >= 0 {
+			m := sceneGUIDRegex.FindStringSubmatch(lines[e.guidLine])
+			lines[e.guidLine] = m[1] + "guid: " + issue.newGUID
+		}
+		fixed++
+	}
+	return fixed
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Filesystem
+// ============================================================
+
+// fileSystem is every filesystem call that can change the project. The real
+// run uses osFS; -dry-run swaps in an overlayFS so the same code path runs
+// and the overlay reports what it would have changed.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Walk(root string, fn filepath.WalkFunc) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Chmod(name string, mode os.FileMode) error
+}
+
+var fsys fileSystem = osFS{}
+
+// isDryRun reports whether changes are going to the in-memory overlay
+func isDryRun() bool {
+	_, ok := fsys.(*overlayFS)
+	return ok
+}
+
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)         { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) Walk(root string, fn filepath.WalkFunc) error { return filepath.Walk(root, fn) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// fsChange is one change the overlay absorbed instead of the disk
+type fsChange struct {
+	op     string // "write", "create", "mkdir", "rename", "delete"
+	path   string
+	detail string
+}
+
+// overlayFS reads through to the disk and keeps every write, rename and
+// delete in memory. Later reads see those changes, so multi-step operations
+// (rename a folder, then rewrite files inside it) behave as they would for real.
+type overlayFS struct {
+	mu      sync.Mutex
+	files   map[string][]byte // files written during the run
+	dirs    map[string]bool   // directories created during the run
+	moved   map[string]string // renamed path -> where its content is on disk
+	removed map[string]bool   // deleted paths; hides everything below them
+	changes []fsChange
+}
+
+func newOverlayFS() *overlayFS {
+	return &overlayFS{
+		files:   map[string][]byte{},
+		dirs:    map[string]bool{},
+		moved:   map[string]string{},
+		removed: map[string]bool{},
+	}
+}
+
+func overlayKey(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// isUnder reports whether p is base or inside it
+func isUnder(p, base string) bool {
+	return p == base || strings.HasPrefix(p, strings.TrimSuffix(base, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// locate finds where the content of p lives: in memory (data / memDir) or
+// on disk (disk, after following renames). ok is false if p was deleted.
+func (o *overlayFS) locate(p string) (data []byte, memFile, memDir bool, disk string, ok bool) {
+	if d, found := o.files[p]; found {
+		return d, true, false, "", true
+	}
+	if o.dirs[p] {
+		return nil, false, true, "", true
+	}
+	for q := p; ; {
+		if src, found := o.moved[q]; found {
+			rel, _ := filepath.Rel(q, p)
+			return nil, false, false, filepath.Join(src, rel), true
+		}
+		if o.removed[q] {
+			return nil, false, false, "", false
+		}
+		parent := filepath.Dir(q)
+		if parent == q {
+			break
+		}
+		q = parent
+	}
+	return nil, false, false, p, true
+}
+
+func (o *overlayFS) stat(p string) (os.FileInfo, error) {
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	case memFile:
+		return memFileInfo{name: filepath.Base(p), size: int64(len(data))}, nil
+	case memDir:
+		return memFileInfo{name: filepath.Base(p), dir: true}, nil
+	}
+	info, err := os.Stat(disk)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	if disk != p {
+		return renamedFileInfo{info, filepath.Base(p)}, nil
+	}
+	return info, nil
+}
+
+func (o *overlayFS) Stat(name string) (os.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stat(overlayKey(name))
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case memDir:
+		return nil, &os.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	case memFile:
+		return append([]byte(nil), data...), nil
+	}
+	return os.ReadFile(disk)
+}
+
+func (o *overlayFS) readDir(p string) ([]os.DirEntry, error) {
+	_, memFile, memDir, disk, ok := o.locate(p)
+	if !ok || memFile {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+	names := map[string]bool{}
+	if !memDir {
+		entries, err := os.ReadDir(disk)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			names[e.Name()] = true
+		}
+	}
+	for k := range o.dirs {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.files {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.moved {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	var result []os.DirEntry
+	for name := range names {
+		if info, err := o.stat(filepath.Join(p, name)); err == nil {
+			result = append(result, fs.FileInfoToDirEntry(info))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (o *overlayFS) ReadDir(name string) ([]os.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readDir(overlayKey(name))
+}
+
+// Walk follows filepath.Walk: lexical order, SkipDir and SkipAll honored.
+// The lock is released while fn runs so the callback can change the overlay.
+func (o *overlayFS) Walk(root string, fn filepath.WalkFunc) error {
+	info, err := o.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = o.walk(root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (o *overlayFS) walk(path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	entries, err := o.ReadDir(path)
+	if err1 := fn(path, info, err); err != nil || err1 != nil {
+		return err1
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		childInfo, err := o.Stat(child)
+		if err != nil {
+			if err := fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := o.walk(child, childInfo, fn); err != nil {
+			if err == filepath.SkipDir && childInfo.IsDir() {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *overlayFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	op := "create"
+	if info, err := o.stat(p); err == nil {
+		if info.IsDir() {
+			return &os.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+		}
+		op = "write"
+	}
+	if info, err := o.stat(filepath.Dir(p)); err != nil || !info.IsDir() {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	o.files[p] = append([]byte(nil), data...)
+	o.changes = append(o.changes, fsChange{op, p, fmt.Sprintf("%d bytes", len(data))})
+	return nil
+}
+
+func (o *overlayFS) MkdirAll(path string, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	created := false
+	for q := p; ; q = filepath.Dir(q) {
+		if info, err := o.stat(q); err == nil {
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: q, Err: errors.New("not a directory")}
+			}
+			break
+		}
+		o.dirs[q] = true
+		created = true
+		if filepath.Dir(q) == q {
+			break
+		}
+	}
+	if created {
+		o.changes = append(o.changes, fsChange{"mkdir", p, ""})
+	}
+	return nil
+}
+
+// shift moves every overlay entry under from to the same place under to
+func (o *overlayFS) shift(from, to string) {
+	for k, v := range o.files {
+		if isUnder(k, from) {
+			delete(o.files, k)
+			o.files[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, from) {
+			delete(o.dirs, k)
+			o.dirs[to+strings.TrimPrefix(k, from)] = true
+		}
+	}
+	for k, v := range o.moved {
+		if isUnder(k, from) {
+			delete(o.moved, k)
+			o.moved[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+}
+
+func (o *overlayFS) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	from, to := overlayKey(oldpath), overlayKey(newpath)
+	_, _, _, disk, ok := o.locate(from)
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if from == to {
+		return nil
+	}
+	if info, err := o.stat(filepath.Dir(to)); err != nil || !info.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	o.drop(to)
+	o.shift(from, to)
+	if disk != "" {
+		if _, err := os.Stat(disk); err == nil {
+			o.moved[to] = disk
+		}
+	}
+	o.removed[from] = true
+	o.changes = append(o.changes, fsChange{"rename", from, to})
+	return nil
+}
+
+// drop forgets p and everything below it and hides it from the disk
+func (o *overlayFS) drop(p string) {
+	for k := range o.files {
+		if isUnder(k, p) {
+			delete(o.files, k)
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, p) {
+			delete(o.dirs, k)
+		}
+	}
+	for k := range o.moved {
+		if isUnder(k, p) {
+			delete(o.moved, k)
+		}
+	}
+	o.removed[p] = true
+}
+
+// treeSize is the byte size of p as the overlay currently sees it
+func (o *overlayFS) treeSize(p string) int64 {
+	info, err := o.stat(p)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var size int64
+	entries, _ := o.readDir(p)
+	for _, e := range entries {
+		size += o.treeSize(filepath.Join(p, e.Name()))
+	}
+	return size
+}
+
+func (o *overlayFS) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	info, err := o.stat(p)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	if info.IsDir() {
+		if entries, _ := o.readDir(p); len(entries) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", info.Size())})
+	return nil
+}
+
+func (o *overlayFS) RemoveAll(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	if _, err := o.stat(p); err != nil {
+		return nil
+	}
+	size := o.treeSize(p)
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", size)})
+	return nil
+}
+
+func (o *overlayFS) Chmod(name string, mode os.FileMode) error {
+	if _, err := o.Stat(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+// printDryRunReport lists what the overlay absorbed, relative to base
+func (o *overlayFS) printDryRunReport(base string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if abs, err := filepath.Abs(base); err == nil {
+		base = abs
+	}
+	if len(o.changes) == 0 {
+		fmt.Println(tr("\n[Dry Run] No changes would be made."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d change(s) would be made; nothing was written:\n"), len(o.changes))
+	rel := func(p string) string {
+		if r, err := filepath.Rel(base, p); err == nil && !strings.HasPrefix(r, "..") {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	for _, c := range o.changes {
+		switch c.op {
+		case "rename":
+			fmt.Printf("  %-7s %s -> %s\n", c.op, rel(c.path), rel(c.detail))
+		case "mkdir":
+			fmt.Printf("  %-7s %s\n", c.op, rel(c.path))
+		default:
+			fmt.Printf("  %-7s %s (%s)\n", c.op, rel(c.path), c.detail)
+		}
+	}
+}
+
+// memFileInfo describes a file or directory that only exists in the overlay
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (m memFileInfo) Name() string       { return m.name }
+func (m memFileInfo) Size() int64        { return m.size }
+func (m memFileInfo) ModTime() time.Time { return time.Time{} }
+func (m memFileInfo) IsDir() bool        { return m.dir }
+func (m memFileInfo) Sys() interface{}   { return nil }
+func (m memFileInfo) Mode() os.FileMode {
+	if m.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// renamedFileInfo is a disk entry seen under the name it was renamed to
+type renamedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (r renamedFileInfo) Name() string { return r.name }
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"entry has no path":                       "条目没有路径",
+	"cannot read .meta: %v":                   "无法读取 .meta: %v",
+	"GUID mismatch (settings: %s, .meta: %s)": "GUID 不匹配 (构建设置: %s, .meta: %s)",
+	"scene file not found":                    "场景文件不存在",
+	" (moved, found by GUID)":                 " (已移动，通过 GUID 找到)",
+	" (found by file name, GUID differs)":     " (通过文件名找到，GUID 不同)",
+	" (%d scenes named %s, cannot choose)":    " (有 %d 个名为 %s 的场景，无法确定)",
+	"\nPress Enter to continue...":            "\n按回车键继续...",
+
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"[ERROR] Cannot read EditorBuildSettings.asset: %v\n":              "[ERROR] 无法读取 EditorBuildSettings.asset: %v\n",
+	"Checking %d scene(s) in EditorBuildSettings.asset\n\n":            "正在检查 EditorBuildSettings.asset 中的 %d 个场景\n\n",
+	" (disabled)":                                             " (已禁用)",
+	"          path -> %s\n":                                  "          路径 -> %s\n",
+	"\nAll build scenes are valid.":                           "\n所有构建场景均有效。",
+	"\n%d problem(s) found":                                   "\n发现 %d 个问题",
+	", %d repairable with -fix":                               "，其中 %d 个可用 -fix 修复",
+	"\n[Dry Run] EditorBuildSettings.asset was not modified.": "\n[Dry Run] 未修改 EditorBuildSettings.asset。",
+	"\nApply repairs? (y/N): ":                                "\n是否应用修复？(y/N): ",
+	"Operation cancelled.":                                    "操作已取消。",
+	"[ERROR] Cannot write EditorBuildSettings.asset: %v\n":    "[ERROR] 无法写入 EditorBuildSettings.asset: %v\n",
+	"\n[OK] Repaired entries: %d\n":                           "\n[OK] 已修复条目: %d\n",
+	"[WARNING] Unresolved entries: %d. Fix them in File > Build Settings.\n": "[WARNING] 未解决条目: %d。请在 File > Build Settings 中修复。\n",
+
+	"\n[Dry Run] No changes would be made.":                          "\n[Dry Run] 不会做任何更改。",
+	"\n[Dry Run] %d change(s) would be made; nothing was written:\n": "\n[Dry Run] 将会进行 %d 项更改，未写入任何内容:\n",
+	"[WARNING] Removing a stale %s (%s)\n":                           "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n":      "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	if status == "ok" && isDryRun() {
+		status = "planned"
+	}
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// CI Annotations
+// ============================================================
+
+// With -annotate, errors and warnings are printed once more as GitHub Actions
+// workflow commands or TeamCity service messages, so the CI lists them on the run
+// page and, when they point at a file, next to that file in the diff.
+var (
+	annotateMode  string              // "", "github" or "teamcity"
+	annotateTool  string              // TeamCity inspection category
+	annotateBase  string              // project root; relative files start here
+	annotateRoot  string              // repository root; CI file paths are relative to it
+	annotateTypes = map[string]bool{} // TeamCity inspection types already declared
+)
+
+// setAnnotateMode checks the -annotate value; "auto" picks the CI the tool runs in
+func setAnnotateMode(mode, basePath, tool string) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "none":
+		annotateMode = ""
+	case "github", "teamcity":
+		annotateMode = strings.ToLower(strings.TrimSpace(mode))
+	case "auto":
+		annotateMode = ""
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			annotateMode = "github"
+		} else if os.Getenv("TEAMCITY_VERSION") != "" {
+			annotateMode = "teamcity"
+		}
+	default:
+		return fmt.Errorf("unknown -annotate mode '%s' (use github, teamcity or auto)", mode)
+	}
+	annotateTool = tool
+	annotateBase, _ = filepath.Abs(basePath)
+	annotateRoot = repositoryRoot(annotateBase)
+	return nil
+}
+
+// repositoryRoot is GITHUB_WORKSPACE, else the nearest folder with a .git entry
+// (a folder, or a file in worktrees and submodules), else the project itself
+func repositoryRoot(basePath string) string {
+	if ws := os.Getenv("GITHUB_WORKSPACE"); ws != "" && annotateMode == "github" {
+		return ws
+	}
+	for dir := basePath; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return basePath
+		}
+	}
+}
+
+// annotate prints one annotation. level is "error" or "warning"; file is absolute
+// or relative to the project root, and may be empty like line and col.
+func annotate(level, file string, line, col int, title, message string) {
+	if annotateMode == "" {
+		return
+	}
+	if file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(annotateBase, filepath.FromSlash(file))
+		}
+		if rel, err := filepath.Rel(annotateRoot, file); err == nil {
+			file = rel
+		}
+		file = filepath.ToSlash(file)
+	}
+
+	if annotateMode == "github" {
+		// Property values also escape ':' and ','; the message only '%' and line breaks
+		data := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+		prop := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+		var props []string
+		if file != "" {
+			props = append(props, "file="+prop.Replace(file))
+			if line > 0 {
+				props = append(props, "line="+strconv.Itoa(line))
+			}
+			if col > 0 {
+				props = append(props, "col="+strconv.Itoa(col))
+			}
+		}
+		if title != "" {
+			props = append(props, "title="+prop.Replace(title))
+		}
+		fmt.Printf("::%s %s::%s\n", level, strings.Join(props, ","), data.Replace(message))
+		return
+	}
+
+	esc := strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]").Replace
+	status := "WARNING"
+	if level == "error" {
+		status = "ERROR"
+	}
+	if file == "" {
+		text := message
+		if title != "" {
+			text = title + ": " + message
+		}
+		fmt.Printf("##teamcity[message text='%s' status='%s']\n", esc(text), status)
+		return
+	}
+	typeID := annotateTool + "." + strings.ReplaceAll(strings.ToLower(title), " ", "-")
+	if !annotateTypes[typeID] {
+		annotateTypes[typeID] = true
+		fmt.Printf("##teamcity[inspectionType id='%s' name='%s' category='%s' description='%s']\n", esc(typeID), esc(title), esc(annotateTool), esc(title))
+	}
+	lineAttr := ""
+	if line > 0 {
+		lineAttr = fmt.Sprintf(" line='%d'", line)
+	}
+	fmt.Printf("##teamcity[inspection typeId='%s' message='%s' file='%s'%s SEVERITY='%s']\n", esc(typeID), esc(message), esc(file), lineAttr, status)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode bool
+	var dryRun bool
+	var fix bool
+	var force bool
+	var annotateFlag string
+
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no confirmation prompts)")
+	flag.BoolVar(&fix, "fix", false, "Repair stale GUIDs and paths of moved scenes")
+	flag.BoolVar(&dryRun, "dry-run", false, "With -fix: show the repairs without writing")
+	flag.BoolVar(&force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
+	flag.StringVar(&annotateFlag, "annotate", "", "Also print problems as CI annotations: github, teamcity, auto")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("build_scenes_validator")
+		ciMode = true
+	}
+	if dryRun {
+		fsys = newOverlayFS()
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if err := setAnnotateMode(annotateFlag, basePath, "build_scenes_validator"); err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(2)
+	}
+	// -fix reads and writes EditorBuildSettings.asset; a rename must not change it meanwhile
+	if fix && !dryRun {
+		if err := acquireProjectLock(basePath, "build_scenes_validator", "fix", force); err != nil {
+			fmt.Printf(tr("[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exit(1)
+		}
+		defer releaseProjectLock()
+	}
+
+	settingsPath := filepath.Join(basePath, "ProjectSettings", "EditorBuildSettings.asset")
+	content, err := os.ReadFile(settingsPath)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot read EditorBuildSettings.asset: %v\n"), err)
+		recordError("cannot read EditorBuildSettings.asset: %v", err)
+		exit(1)
+	}
+
+	// Keep the original line ending style when writing back
+	text := string(content)
+	newline := "\n"
+	if strings.Contains(text, "\r\n") {
+		newline = "\r\n"
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+	}
+	lines := strings.Split(text, "\n")
+
+	entries := parseScenes(lines)
+	fmt.Printf(tr("Checking %d scene(s) in EditorBuildSettings.asset\n\n"), len(entries))
+
+	issues := validate(basePath, entries)
+	bad := make(map[int]bool)
+	for _, issue := range issues {
+		bad[issue.entry.index] = true
+	}
+	for _, e := range entries {
+		if !bad[e.index] {
+			state := ""
+			if !e.enabled {
+				state = tr(" (disabled)")
+			}
+			fmt.Printf("[OK]      #%d %s%s\n", e.index, e.path, state)
+			recordAction("validate", e.path, "ok", strings.TrimSpace(state), 0)
+		}
+	}
+	for _, issue := range issues {
+		fmt.Printf("[ERROR]   #%d %s: %s\n", issue.entry.index, issue.entry.path, issue.problem)
+		recordAction("validate", issue.entry.path, "failed", issue.problem, 0)
+		if issue.newPath != "" {
+			fmt.Printf(tr("          path -> %s\n"), issue.newPath)
+		}
+		if issue.newGUID != "" {
+			fmt.Printf("          guid -> %s\n", issue.newGUID)
+		}
+		// Entries -fix repairs in this run are only worth a warning
+		level, message := "error", issue.problem
+		if fix && issue.fixable() {
+			level, message = "warning", issue.problem+"; repaired by -fix"
+		}
+		annotate(level, "ProjectSettings/EditorBuildSettings.asset", issue.entry.pathLine+1, 0, fmt.Sprintf("Build scene #%d", issue.entry.index), issue.entry.path+": "+message)
+	}
+
+	if len(issues) == 0 {
+		fmt.Println(tr("\nAll build scenes are valid."))
+		exit(0)
+	}
+
+	unfixable := 0
+	for _, issue := range issues {
+		if !issue.fixable() {
+			unfixable++
+		}
+	}
+
+	if !fix {
+		fmt.Printf(tr("\n%d problem(s) found"), len(issues))
+		if unfixable < len(issues) {
+			fmt.Printf(tr(", %d repairable with -fix"), len(issues)-unfixable)
+		}
+		fmt.Println(".")
+		exit(1)
+	}
+
+	if !ciMode && !dryRun {
+		fmt.Print(tr("\nApply repairs? (y/N): "))
+		confirm, _ := stdinReader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+			fmt.Println(tr("Operation cancelled."))
+			exit(0)
+		}
+	}
+
+	fixed := applyFixes(lines, issues)
+	if fixed > 0 {
+		if err := fsys.WriteFile(settingsPath, []byte(strings.Join(lines, newline)), 0644); err != nil {
+			fmt.Printf(tr("[ERROR] Cannot write EditorBuildSettings.asset: %v\n"), err)
+			recordError("cannot write EditorBuildSettings.asset: %v", err)
+			exit(1)
+		}
+	}
+
+	fmt.Printf(tr("\n[OK] Repaired entries: %d\n"), fixed)
+	for _, issue := range issues {
+		if issue.fixable() {
+			recordAction("repair", issue.entry.path, "ok", issue.problem, 0)
+		}
+	}
+	if fixed > 0 {
+		recordArtifact(settingsPath)
+	}
+	if overlay, ok := fsys.(*overlayFS); ok {
+		overlay.printDryRunReport(basePath)
+		fmt.Println(tr("\n[Dry Run] EditorBuildSettings.asset was not modified."))
+	}
+	if unfixable > 0 {
+		fmt.Printf(tr("[WARNING] Unresolved entries: %d. Fix them in File > Build Settings.\n"), unfixable)
+		exit(1)
+	}
+	exit(0)
+}
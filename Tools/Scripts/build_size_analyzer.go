@@ -0,0 +1,911 @@
+// Build Size Analyzer — Size breakdown of a Unity build for regression tracking.
+// Parses the "Build Report" section of the Editor log (or scans a build output folder),
+// prints a breakdown by asset type and the top-N largest assets, and diffs against a
+// previously saved breakdown.
+//
+// Build: go build build_size_analyzer.go
+//
+// Usage:
+//
+//	build_size_analyzer                                  # last Build Report in Editor.log
+//	build_size_analyzer -log ci_build.log -top 30
+//	build_size_analyzer -dir Build/Android               # scan output folder instead
+//	build_size_analyzer -save size.json                  # store breakdown for later
+//	build_size_analyzer -compare size_prev.json -o report.md
+//	build_size_analyzer -json -o report.md                # result document on stdout
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Asset type classification by file extension (used for per-asset grouping)
+var assetTypes = map[string]string{
+	".png": "Textures", ".jpg": "Textures", ".jpeg": "Textures", ".tga": "Textures",
+	".psd": "Textures", ".exr": "Textures", ".hdr": "Textures", ".tif": "Textures",
+	".tiff": "Textures", ".bmp": "Textures", ".gif": "Textures", ".spriteatlas": "Textures",
+	".spriteatlasv2": "Textures", ".rendertexture": "Textures",
+	".fbx": "Meshes", ".obj": "Meshes", ".blend": "Meshes", ".mesh": "Meshes",
+	".anim": "Animations", ".controller": "Animations", ".overridecontroller": "Animations",
+	".wav": "Sounds", ".ogg": "Sounds", ".mp3": "Sounds", ".aif": "Sounds", ".aiff": "Sounds", ".flac": "Sounds",
+	".shader": "Shaders", ".shadergraph": "Shaders", ".compute": "Shaders", ".hlsl": "Shaders", ".cginc": "Shaders",
+	".shadervariants": "Shaders",
+	".cs":             "Scripts", ".dll": "Scripts", ".asmdef": "Scripts",
+	".unity":  "Levels",
+	".prefab": "Prefabs",
+	".mat":    "Materials",
+	".ttf":    "Fonts", ".otf": "Fonts", ".fontsettings": "Fonts",
+	".mp4": "Videos", ".webm": "Videos", ".mov": "Videos",
+	// Build output folder types
+	".so": "Native Libraries", ".a": "Native Libraries", ".dylib": "Native Libraries",
+	".bundle": "Asset Bundles", ".ab": "Asset Bundles",
+	".apk": "Packages", ".aab": "Packages", ".ipa": "Packages", ".obb": "Packages",
+	".wasm": "WebAssembly", ".data": "Data", ".resource": "Data", ".ress": "Data",
+	".assets": "Data",
+}
+
+// Editor.log section markers
+const (
+	reportHeader   = "Build Report"
+	categoryHeader = "Uncompressed usage by category"
+	assetsHeader   = "Used Assets and files from the Resources folder"
+	separatorLine  = "-------------------------------------------------------------------------------"
+)
+
+var (
+	// "Textures               12.3 mb	 45.2% "
+	categoryLineRegex = regexp.MustCompile(`^\s*([A-Za-z][A-Za-z ]*?)\s+([\d.]+)\s*(b|kb|mb|gb)\b`)
+	// " 5.3 mb	 19.5% Assets/Textures/foo.png"
+	assetLineRegex = regexp.MustCompile(`^\s*([\d.]+)\s*(b|kb|mb|gb)\s+([\d.<]+)%\s+(.+?)\s*$`)
+	// "Complete build size    150.3 mb"
+	completeSizeRegex = regexp.MustCompile(`^\s*Complete build size\s+([\d.]+)\s*(b|kb|mb|gb)`)
+)
+
+// ============================================================
+// Types
+// ============================================================
+
+// breakdown is the saved/compared document. Keep field names stable:
+// CI pipelines store these files between builds.
+type breakdown struct {
+	Source       string           `json:"source"`
+	Mode         string           `json:"mode"` // "editor-log" or "folder"
+	GeneratedAt  string           `json:"generatedAt"`
+	TotalBytes   int64            `json:"totalBytes"`
+	CompleteSize int64            `json:"completeBuildSize,omitempty"`
+	Categories   map[string]int64 `json:"categories"`
+	Assets       []assetEntry     `json:"assets"`
+}
+
+type assetEntry struct {
+	Path  string `json:"path"`
+	Type  string `json:"type"`
+	Bytes int64  `json:"bytes"`
+}
+
+type assetDelta struct {
+	Path   string
+	Type   string
+	Before int64
+	After  int64
+}
+
+// ============================================================
+// Editor Log Parsing
+// ============================================================
+
+// defaultEditorLogPath returns the Editor.log location for the current OS
+func defaultEditorLogPath() string {
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		if local := os.Getenv("LOCALAPPDATA"); local != "" {
+			return filepath.Join(local, "Unity", "Editor", "Editor.log")
+		}
+		return filepath.Join(home, "AppData", "Local", "Unity", "Editor", "Editor.log")
+	case "darwin":
+		return filepath.Join(home, "Library", "Logs", "Unity", "Editor.log")
+	default:
+		return filepath.Join(home, ".config", "unity3d", "Editor.log")
+	}
+}
+
+// parseUnitySize converts "12.3 mb" style values to bytes (Unity uses 1024-based units)
+func parseUnitySize(value, unit string) int64 {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	switch strings.ToLower(unit) {
+	case "kb":
+		v *= 1024
+	case "mb":
+		v *= 1024 * 1024
+	case "gb":
+		v *= 1024 * 1024 * 1024
+	}
+	return int64(v)
+}
+
+// parseEditorLog extracts the LAST Build Report section from an Editor log.
+// Earlier reports in the same log belong to previous builds in that session.
+func parseEditorLog(logPath string) (*breakdown, error) {
+	f, err := os.Open(logPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	var reportStart = -1
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == reportHeader {
+			// Start a fresh capture; only the latest report is kept
+			lines = lines[:0]
+			reportStart = 0
+		}
+		if reportStart >= 0 {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if reportStart < 0 {
+		return nil, fmt.Errorf("no \"%s\" section found in %s (was a player build run in this session?)", reportHeader, logPath)
+	}
+
+	bd := &breakdown{
+		Source:      logPath,
+		Mode:        "editor-log",
+		GeneratedAt: time.Now().Format(time.RFC3339),
+		Categories:  make(map[string]int64),
+	}
+
+	section := ""
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, categoryHeader):
+			section = "categories"
+			continue
+		case strings.HasPrefix(trimmed, assetsHeader):
+			section = "assets"
+			continue
+		case strings.HasPrefix(trimmed, separatorLine):
+			if section == "assets" {
+				section = "done"
+			}
+			continue
+		}
+		if section == "done" {
+			break
+		}
+
+		if m := completeSizeRegex.FindStringSubmatch(line); m != nil {
+			bd.CompleteSize = parseUnitySize(m[1], m[2])
+			continue
+		}
+
+		switch section {
+		case "categories":
+			m := categoryLineRegex.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			name := strings.TrimSpace(m[1])
+			size := parseUnitySize(m[2], m[3])
+			if name == "Total User Assets" {
+				bd.TotalBytes = size
+				continue
+			}
+			bd.Categories[name] = size
+		case "assets":
+			m := assetLineRegex.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			path := m[4]
+			bd.Assets = append(bd.Assets, assetEntry{
+				Path:  path,
+				Type:  classifyAsset(path),
+				Bytes: parseUnitySize(m[1], m[2]),
+			})
+		}
+	}
+
+	if bd.TotalBytes == 0 {
+		for _, size := range bd.Categories {
+			bd.TotalBytes += size
+		}
+	}
+	return bd, nil
+}
+
+// ============================================================
+// Folder Scanning
+// ============================================================
+
+// scanBuildFolder groups every file in a build output folder by type
+func scanBuildFolder(dir string) (*breakdown, error) {
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("build folder not found: %s", dir)
+	}
+
+	bd := &breakdown{
+		Source:      dir,
+		Mode:        "folder",
+		GeneratedAt: time.Now().Format(time.RFC3339),
+		Categories:  make(map[string]int64),
+	}
+
+	err = filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		rel = filepath.ToSlash(rel)
+		t := classifyAsset(rel)
+		bd.Categories[t] += fi.Size()
+		bd.TotalBytes += fi.Size()
+		bd.Assets = append(bd.Assets, assetEntry{Path: rel, Type: t, Bytes: fi.Size()})
+		return nil
+	})
+	bd.CompleteSize = bd.TotalBytes
+	return bd, err
+}
+
+// classifyAsset maps a file path to a display type. Compression suffixes
+// (WebGL .br/.gz/.unityweb) are stripped so Build.wasm.br counts as WebAssembly.
+func classifyAsset(path string) string {
+	name := strings.ToLower(path)
+	for _, suffix := range []string{".br", ".gz", ".unityweb"} {
+		if strings.HasSuffix(name, suffix) && strings.Count(filepath.Base(name), ".") > 1 {
+			name = strings.TrimSuffix(name, suffix)
+		}
+	}
+	if t, ok := assetTypes[filepath.Ext(name)]; ok {
+		return t
+	}
+	if strings.Contains(name, "built-in") || strings.HasPrefix(name, "resources/unity_builtin_extra") {
+		return "Built-in"
+	}
+	return "Other"
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+type kv struct {
+	key   string
+	value int64
+}
+
+func sortedCategories(m map[string]int64) []kv {
+	var out []kv
+	for k, v := range m {
+		out = append(out, kv{k, v})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].value != out[j].value {
+			return out[i].value > out[j].value
+		}
+		return out[i].key < out[j].key
+	})
+	return out
+}
+
+func percent(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(total)
+}
+
+func formatDelta(delta int64) string {
+	if delta > 0 {
+		return "+" + formatSize(delta)
+	}
+	if delta < 0 {
+		return "-" + formatSize(-delta)
+	}
+	return "0 B"
+}
+
+func renderReport(bd *breakdown, prev *breakdown, topN int) string {
+	var sb strings.Builder
+
+	sb.WriteString(tr("# Build Size Report\n\n"))
+	sb.WriteString(fmt.Sprintf(tr("- **Source**: `%s` (%s)\n"), bd.Source, bd.Mode))
+	sb.WriteString(fmt.Sprintf(tr("- **Generated**: %s\n"), bd.GeneratedAt))
+	sb.WriteString(fmt.Sprintf(tr("- **Total assets**: %s\n"), formatSize(bd.TotalBytes)))
+	if bd.CompleteSize > 0 && bd.Mode == "editor-log" {
+		sb.WriteString(fmt.Sprintf(tr("- **Complete build size**: %s\n"), formatSize(bd.CompleteSize)))
+	}
+	if prev != nil {
+		sb.WriteString(fmt.Sprintf(tr("- **Compared with**: `%s` (%s)\n"), prev.Source, prev.GeneratedAt))
+	}
+
+	// Category breakdown
+	sb.WriteString(tr("\n## By Type\n\n"))
+	if prev != nil {
+		sb.WriteString(tr("| Type | Size | % | Previous | Delta |\n|------|-----:|--:|---------:|------:|\n"))
+	} else {
+		sb.WriteString(tr("| Type | Size | % |\n|------|-----:|--:|\n"))
+	}
+	seen := make(map[string]bool)
+	for _, c := range sortedCategories(bd.Categories) {
+		seen[c.key] = true
+		if prev != nil {
+			before := prev.Categories[c.key]
+			sb.WriteString(fmt.Sprintf("| %s | %s | %.1f%% | %s | %s |\n", c.key, formatSize(c.value), percent(c.value, bd.TotalBytes), formatSize(before), formatDelta(c.value-before)))
+		} else {
+			sb.WriteString(fmt.Sprintf("| %s | %s | %.1f%% |\n", c.key, formatSize(c.value), percent(c.value, bd.TotalBytes)))
+		}
+	}
+	if prev != nil {
+		for _, c := range sortedCategories(prev.Categories) {
+			if !seen[c.key] {
+				sb.WriteString(fmt.Sprintf("| %s | 0 B | 0.0%% | %s | %s |\n", c.key, formatSize(c.value), formatDelta(-c.value)))
+			}
+		}
+		prevTotal := prev.TotalBytes
+		sb.WriteString(fmt.Sprintf(tr("| **Total** | **%s** | | **%s** | **%s** |\n"), formatSize(bd.TotalBytes), formatSize(prevTotal), formatDelta(bd.TotalBytes-prevTotal)))
+	}
+
+	// Top-N assets
+	assets := append([]assetEntry(nil), bd.Assets...)
+	sort.Slice(assets, func(i, j int) bool { return assets[i].Bytes > assets[j].Bytes })
+	if len(assets) > topN {
+		assets = assets[:topN]
+	}
+	sb.WriteString(fmt.Sprintf(tr("\n## Top %d Largest Assets\n\n"), len(assets)))
+	sb.WriteString(tr("| # | Size | Type | Path |\n|--:|-----:|------|------|\n"))
+	for i, a := range assets {
+		sb.WriteString(fmt.Sprintf("| %d | %s | %s | `%s` |\n", i+1, formatSize(a.Bytes), a.Type, a.Path))
+	}
+
+	// Asset-level diff
+	if prev != nil {
+		added, removed, changed := diffAssets(prev, bd)
+		writeDeltaTable(&sb, tr("Grown / Shrunk Assets"), changed, topN)
+		writeDeltaTable(&sb, tr("New Assets"), added, topN)
+		writeDeltaTable(&sb, tr("Removed Assets"), removed, topN)
+	}
+
+	return sb.String()
+}
+
+func diffAssets(prev, cur *breakdown) (added, removed, changed []assetDelta) {
+	before := make(map[string]assetEntry, len(prev.Assets))
+	for _, a := range prev.Assets {
+		before[a.Path] = a
+	}
+	after := make(map[string]bool, len(cur.Assets))
+	for _, a := range cur.Assets {
+		after[a.Path] = true
+		old, ok := before[a.Path]
+		switch {
+		case !ok:
+			added = append(added, assetDelta{Path: a.Path, Type: a.Type, After: a.Bytes})
+		case old.Bytes != a.Bytes:
+			changed = append(changed, assetDelta{Path: a.Path, Type: a.Type, Before: old.Bytes, After: a.Bytes})
+		}
+	}
+	for _, a := range prev.Assets {
+		if !after[a.Path] {
+			removed = append(removed, assetDelta{Path: a.Path, Type: a.Type, Before: a.Bytes})
+		}
+	}
+	bySize := func(list []assetDelta) {
+		sort.Slice(list, func(i, j int) bool {
+			di := list[i].After - list[i].Before
+			dj := list[j].After - list[j].Before
+			if di < 0 {
+				di = -di
+			}
+			if dj < 0 {
+				dj = -dj
+			}
+			return di > dj
+		})
+	}
+	bySize(added)
+	bySize(removed)
+	bySize(changed)
+	return
+}
+
+func writeDeltaTable(sb *strings.Builder, title string, list []assetDelta, topN int) {
+	sb.WriteString(fmt.Sprintf("\n## %s (%d)\n\n", title, len(list)))
+	if len(list) == 0 {
+		sb.WriteString(tr("_None_\n"))
+		return
+	}
+	sb.WriteString(tr("| Delta | Before | After | Type | Path |\n|------:|-------:|------:|------|------|\n"))
+	for i, d := range list {
+		if i >= topN {
+			sb.WriteString(fmt.Sprintf(tr("\n_...and %d more_\n"), len(list)-topN))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | `%s` |\n", formatDelta(d.After-d.Before), formatSize(d.Before), formatSize(d.After), d.Type, d.Path))
+	}
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+func loadBreakdown(path string) (*breakdown, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var bd breakdown
+	if err := json.Unmarshal(data, &bd); err != nil {
+		return nil, fmt.Errorf("invalid breakdown file %s: %v", path, err)
+	}
+	return &bd, nil
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"# Build Size Report\n\n":          "# 构建体积报告\n\n",
+	"- **Source**: `%s` (%s)\n":        "- **来源**: `%s` (%s)\n",
+	"- **Generated**: %s\n":            "- **生成时间**: %s\n",
+	"- **Total assets**: %s\n":         "- **资源总大小**: %s\n",
+	"- **Complete build size**: %s\n":  "- **完整构建大小**: %s\n",
+	"- **Compared with**: `%s` (%s)\n": "- **对比基准**: `%s` (%s)\n",
+	"\n## By Type\n\n":                 "\n## 按类型\n\n",
+	"| Type | Size | % | Previous | Delta |\n|------|-----:|--:|---------:|------:|\n": "| 类型 | 大小 | % | 上次 | 变化 |\n|------|-----:|--:|---------:|------:|\n",
+	"| Type | Size | % |\n|------|-----:|--:|\n":                                       "| 类型 | 大小 | % |\n|------|-----:|--:|\n",
+	"| **Total** | **%s** | | **%s** | **%s** |\n":                                     "| **合计** | **%s** | | **%s** | **%s** |\n",
+	"\n## Top %d Largest Assets\n\n":                                                   "\n## 体积最大的 %d 个资源\n\n",
+	"| # | Size | Type | Path |\n|--:|-----:|------|------|\n":                         "| # | 大小 | 类型 | 路径 |\n|--:|-----:|------|------|\n",
+	"Grown / Shrunk Assets":                                                            "变大 / 变小的资源",
+	"New Assets":                                                                       "新增资源",
+	"Removed Assets":                                                                   "移除的资源",
+	"_None_\n":                                                                         "_无_\n",
+	"| Delta | Before | After | Type | Path |\n|------:|-------:|------:|------|------|\n": "| 变化 | 之前 | 之后 | 类型 | 路径 |\n|------:|-------:|------:|------|------|\n",
+	"\n_...and %d more_\n": "\n_……另有 %d 项_\n",
+
+	"[ERROR] Cannot write report: %v\n":       "[ERROR] 无法写入报告: %v\n",
+	"[OK] Report written to %s\n":             "[OK] 报告已写入 %s\n",
+	"[ERROR] Cannot save breakdown: %v\n":     "[ERROR] 无法保存体积明细: %v\n",
+	"[OK] Breakdown saved to %s\n":            "[OK] 体积明细已保存到 %s\n",
+	"[ERROR] Invalid -max-growth value: %s\n": "[ERROR] 无效的 -max-growth 值: %s\n",
+	"[FAIL] Build grew by %s (limit: %s)\n":   "[FAIL] 构建增大了 %s (上限: %s)\n",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// CI Annotations
+// ============================================================
+
+// With -annotate, errors and warnings are printed once more as GitHub Actions
+// workflow commands or TeamCity service messages, so the CI lists them on the run
+// page and, when they point at a file, next to that file in the diff.
+var (
+	annotateMode  string              // "", "github" or "teamcity"
+	annotateTool  string              // TeamCity inspection category
+	annotateBase  string              // project root; relative files start here
+	annotateRoot  string              // repository root; CI file paths are relative to it
+	annotateTypes = map[string]bool{} // TeamCity inspection types already declared
+)
+
+// setAnnotateMode checks the -annotate value; "auto" picks the CI the tool runs in
+func setAnnotateMode(mode, basePath, tool string) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "none":
+		annotateMode = ""
+	case "github", "teamcity":
+		annotateMode = strings.ToLower(strings.TrimSpace(mode))
+	case "auto":
+		annotateMode = ""
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			annotateMode = "github"
+		} else if os.Getenv("TEAMCITY_VERSION") != "" {
+			annotateMode = "teamcity"
+		}
+	default:
+		return fmt.Errorf("unknown -annotate mode '%s' (use github, teamcity or auto)", mode)
+	}
+	annotateTool = tool
+	annotateBase, _ = filepath.Abs(basePath)
+	annotateRoot = repositoryRoot(annotateBase)
+	return nil
+}
+
+// repositoryRoot is GITHUB_WORKSPACE, else the nearest folder with a .git entry
+// (a folder, or a file in worktrees and submodules), else the project itself
+func repositoryRoot(basePath string) string {
+	if ws := os.Getenv("GITHUB_WORKSPACE"); ws != "" && annotateMode == "github" {
+		return ws
+	}
+	for dir := basePath; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return basePath
+		}
+	}
+}
+
+// annotate prints one annotation. level is "error" or "warning"; file is absolute
+// or relative to the project root, and may be empty like line and col.
+func annotate(level, file string, line, col int, title, message string) {
+	if annotateMode == "" {
+		return
+	}
+	if file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(annotateBase, filepath.FromSlash(file))
+		}
+		if rel, err := filepath.Rel(annotateRoot, file); err == nil {
+			file = rel
+		}
+		file = filepath.ToSlash(file)
+	}
+
+	if annotateMode == "github" {
+		// Property values also escape ':' and ','; the message only '%' and line breaks
+		data := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+		prop := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+		var props []string
+		if file != "" {
+			props = append(props, "file="+prop.Replace(file))
+			if line > 0 {
+				props = append(props, "line="+strconv.Itoa(line))
+			}
+			if col > 0 {
+				props = append(props, "col="+strconv.Itoa(col))
+			}
+		}
+		if title != "" {
+			props = append(props, "title="+prop.Replace(title))
+		}
+		fmt.Printf("::%s %s::%s\n", level, strings.Join(props, ","), data.Replace(message))
+		return
+	}
+
+	esc := strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]").Replace
+	status := "WARNING"
+	if level == "error" {
+		status = "ERROR"
+	}
+	if file == "" {
+		text := message
+		if title != "" {
+			text = title + ": " + message
+		}
+		fmt.Printf("##teamcity[message text='%s' status='%s']\n", esc(text), status)
+		return
+	}
+	typeID := annotateTool + "." + strings.ReplaceAll(strings.ToLower(title), " ", "-")
+	if !annotateTypes[typeID] {
+		annotateTypes[typeID] = true
+		fmt.Printf("##teamcity[inspectionType id='%s' name='%s' category='%s' description='%s']\n", esc(typeID), esc(title), esc(annotateTool), esc(title))
+	}
+	lineAttr := ""
+	if line > 0 {
+		lineAttr = fmt.Sprintf(" line='%d'", line)
+	}
+	fmt.Printf("##teamcity[inspection typeId='%s' message='%s' file='%s'%s SEVERITY='%s']\n", esc(typeID), esc(message), esc(file), lineAttr, status)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		logPath      string
+		dirPath      string
+		outputPath   string
+		savePath     string
+		comparePath  string
+		topN         int
+		maxGrowth    string
+		annotateFlag string
+	)
+
+	flag.StringVar(&logPath, "log", "", "Editor log to parse (default: platform Editor.log)")
+	flag.StringVar(&dirPath, "dir", "", "Scan a build output folder instead of the Editor log")
+	flag.StringVar(&outputPath, "o", "", "Write the Markdown report to a file (default: stdout)")
+	flag.StringVar(&savePath, "save", "", "Save the breakdown as JSON for future comparisons")
+	flag.StringVar(&comparePath, "compare", "", "Compare against a previously saved breakdown JSON")
+	flag.IntVar(&topN, "top", 20, "Number of largest assets / deltas to list")
+	flag.StringVar(&maxGrowth, "max-growth", "", "Fail (exit 2) if total size grew more than this (e.g. 5MB or 3%)")
+	flag.StringVar(&annotateFlag, "annotate", "", "Also print a failed size gate as a CI annotation: github, teamcity, auto")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (report goes to stderr or -o)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("build_size_analyzer")
+	}
+	wd, _ := os.Getwd()
+	if err := setAnnotateMode(annotateFlag, wd, "build_size_analyzer"); err != nil {
+		fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
+		recordError("%v", err)
+		exitTool(1)
+	}
+
+	var bd *breakdown
+	var err error
+	if dirPath != "" {
+		bd, err = scanBuildFolder(dirPath)
+	} else {
+		if logPath == "" {
+			logPath = defaultEditorLogPath()
+		}
+		bd, err = parseEditorLog(logPath)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
+		recordError("%v", err)
+		exitTool(1)
+	}
+	recordAction("analyze", bd.Source, "ok", formatSize(bd.TotalBytes), time.Since(jsonStart))
+
+	var prev *breakdown
+	if comparePath != "" {
+		prev, err = loadBreakdown(comparePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
+			recordError("%v", err)
+			exitTool(1)
+		}
+	}
+
+	report := renderReport(bd, prev, topN)
+	if outputPath != "" {
+		if err := os.WriteFile(outputPath, []byte(report), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, tr("[ERROR] Cannot write report: %v\n"), err)
+			recordError("cannot write report: %v", err)
+			exitTool(1)
+		}
+		fmt.Printf(tr("[OK] Report written to %s\n"), outputPath)
+		recordArtifact(outputPath)
+	} else {
+		fmt.Print(report)
+	}
+
+	if savePath != "" {
+		data, _ := json.MarshalIndent(bd, "", "  ")
+		if err := os.WriteFile(savePath, data, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, tr("[ERROR] Cannot save breakdown: %v\n"), err)
+			recordError("cannot save breakdown: %v", err)
+			exitTool(1)
+		}
+		fmt.Fprintf(os.Stderr, tr("[OK] Breakdown saved to %s\n"), savePath)
+		recordArtifact(savePath)
+	}
+
+	// Regression gate
+	if prev != nil && maxGrowth != "" {
+		growth := bd.TotalBytes - prev.TotalBytes
+		limit := maxGrowth
+		exceeded := false
+		if strings.HasSuffix(limit, "%") {
+			pct, perr := strconv.ParseFloat(strings.TrimSuffix(limit, "%"), 64)
+			if perr == nil && prev.TotalBytes > 0 {
+				exceeded = percent(growth, prev.TotalBytes) > pct
+			}
+		} else {
+			m := regexp.MustCompile(`(?i)^([\d.]+)\s*(b|kb|mb|gb)?$`).FindStringSubmatch(strings.TrimSpace(limit))
+			if m == nil {
+				fmt.Fprintf(os.Stderr, tr("[ERROR] Invalid -max-growth value: %s\n"), limit)
+				recordError("invalid -max-growth value: %s", limit)
+				exitTool(1)
+			}
+			unit := m[2]
+			if unit == "" {
+				unit = "b"
+			}
+			exceeded = growth > parseUnitySize(m[1], unit)
+		}
+		if exceeded {
+			fmt.Fprintf(os.Stderr, tr("[FAIL] Build grew by %s (limit: %s)\n"), formatDelta(growth), limit)
+			annotate("error", "", 0, 0, "Build size", fmt.Sprintf("%s grew by %s (limit: %s)", bd.Source, formatDelta(growth), limit))
+			recordAction("size-gate", prev.Source, "failed", fmt.Sprintf("grew by %s (limit: %s)", formatDelta(growth), limit), 0)
+			exitTool(2)
+		}
+		recordAction("size-gate", prev.Source, "ok", fmt.Sprintf("grew by %s (limit: %s)", formatDelta(growth), limit), 0)
+	}
+}
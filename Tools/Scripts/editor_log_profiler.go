@@ -0,0 +1,1186 @@
+// Editor Log Profiler — Domain reload, script compilation and asset import timings from Editor.log.
+// Parses the timings Unity writes to the Editor log (domain reload profiling, script
+// compilation, asset pipeline refreshes and per-asset imports), reports totals per
+// importer and the slowest assets and reload phases, and keeps a local history so
+// iteration-time regressions show up as a trend instead of a feeling.
+//
+// Build: go build editor_log_profiler.go
+//
+// Usage:
+//
+//	editor_log_profiler                                  # current Editor.log
+//	editor_log_profiler -log Editor-prev.log -top 30
+//	editor_log_profiler -o timings.md                    # write the report to a file
+//	editor_log_profiler -max-regression 20%              # exit 2 if reloads got slower
+//	editor_log_profiler -history timings.json -no-record # compare without recording
+//	editor_log_profiler -json -o timings.md              # result document on stdout
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Default history location, relative to the Unity project root. Library/ is
+// per-machine and never versioned, which is what a local timing history wants.
+const defaultHistoryFile = "Library/UnityStarter/EditorTimings.json"
+
+// Runs kept in the history file, and how many earlier runs the regression
+// gate takes the median of
+const (
+	maxHistoryRuns  = 100
+	baselineRuns    = 10
+	trendRowsInTail = 10
+)
+
+// The log only carries an importer ID, so the importer is inferred from the
+// file extension; anything else is reported as its extension
+var importerTypes = map[string]string{
+	".png": "TextureImporter", ".jpg": "TextureImporter", ".jpeg": "TextureImporter", ".tga": "TextureImporter",
+	".psd": "TextureImporter", ".exr": "TextureImporter", ".hdr": "TextureImporter", ".tif": "TextureImporter",
+	".tiff": "TextureImporter", ".bmp": "TextureImporter", ".gif": "TextureImporter",
+	".fbx": "ModelImporter", ".obj": "ModelImporter", ".blend": "ModelImporter", ".dae": "ModelImporter",
+	".wav": "AudioImporter", ".ogg": "AudioImporter", ".mp3": "AudioImporter", ".aif": "AudioImporter",
+	".aiff": "AudioImporter", ".flac": "AudioImporter",
+	".mp4": "VideoClipImporter", ".webm": "VideoClipImporter", ".mov": "VideoClipImporter",
+	".ttf": "TrueTypeFontImporter", ".otf": "TrueTypeFontImporter",
+	".cs":  "MonoImporter",
+	".dll": "PluginImporter", ".so": "PluginImporter", ".a": "PluginImporter", ".aar": "PluginImporter", ".jar": "PluginImporter",
+	".shader": "ShaderImporter", ".compute": "ComputeShaderImporter", ".hlsl": "ShaderIncludeImporter", ".cginc": "ShaderIncludeImporter",
+	".shadergraph": "ShaderGraphImporter", ".shadersubgraph": "ShaderSubGraphImporter",
+	".asmdef": "AssemblyDefinitionImporter", ".asmref": "AssemblyDefinitionReferenceImporter",
+	".unity":  "SceneImporter",
+	".prefab": "PrefabImporter",
+	".mat":    "NativeFormatImporter", ".asset": "NativeFormatImporter", ".anim": "NativeFormatImporter",
+	".controller": "NativeFormatImporter", ".overridecontroller": "NativeFormatImporter", ".physicmaterial": "NativeFormatImporter",
+	".mask": "NativeFormatImporter", ".rendertexture": "NativeFormatImporter", ".spriteatlas": "NativeFormatImporter",
+	".spriteatlasv2": "SpriteAtlasImporter", ".inputactions": "InputActionImporter", ".uxml": "UIElementsViewImporter",
+	".uss": "StyleSheetImporter", ".json": "TextScriptImporter", ".txt": "TextScriptImporter", ".xml": "TextScriptImporter",
+	".bytes": "TextScriptImporter", ".csv": "TextScriptImporter", ".md": "TextScriptImporter",
+}
+
+var (
+	// Unity's -timestamps prefix: "2024-03-01T10:15:02.1234567Z|0x1a2b|"
+	timestampPrefixRegex = regexp.MustCompile(`^\[?\d{4}-\d{2}-\d{2}T[\d:.]+Z?[|\]]([^|]*\|)?\s*`)
+	// "Domain Reload Profiling: 1393ms" (2021.2+)
+	reloadProfilingRegex = regexp.MustCompile(`^Domain Reload Profiling:\s*(\d+)\s*ms`)
+	// "\tFinalizeReload (922ms)" lines under the profiling header
+	reloadPhaseRegex = regexp.MustCompile(`^([\t ]+)(\w+) \((\d+)\s*ms\)\s*$`)
+	// "- Completed reload, in  1.234 seconds" (older editors)
+	completedReloadRegex = regexp.MustCompile(`^-\s*Completed reload, in\s+([\d.]+) seconds`)
+	// "*** Tundra build success (3.45 seconds), 12 items updated, 456 evaluated"
+	tundraRegex = regexp.MustCompile(`^\*\*\* Tundra build (success|failed) \(([\d.]+) seconds\)`)
+	// "- Finished compile Library/ScriptAssemblies/Game.dll in 2.3 seconds" (older editors)
+	finishedCompileRegex = regexp.MustCompile(`^-\s*Finished compile (.+?) in ([\d.]+) seconds`)
+	// "Asset Pipeline Refresh (id=2c9a...): Total: 2.345 seconds - Initiated by RefreshV2(...)"
+	refreshRegex = regexp.MustCompile(`^Asset Pipeline Refresh(?: \(id=[0-9a-f]+\))?: Total: ([\d.]+) seconds(?: - Initiated by (\w+))?`)
+	// "Start importing Assets/Foo.png using Guid(...) Importer(...)"; the
+	// duration "-> (artifact id: '...') in 0.0519 seconds" is on the same or next line
+	startImportRegex = regexp.MustCompile(`^Start importing (.+?) using Guid\(([0-9a-f]*)\)`)
+	importDoneRegex  = regexp.MustCompile(`->\s*\(artifact id: '[0-9a-f]*'\) in ([\d.]+) seconds`)
+)
+
+// ============================================================
+// Types
+// ============================================================
+
+// timings is everything parsed from one log
+type timings struct {
+	Reloads     []reloadEvent
+	Compiles    []float64 // milliseconds per script compilation (Bee/Tundra run)
+	Assemblies  map[string][]float64
+	Refreshes   []float64
+	Imports     []importEvent
+	FailedBuild int
+}
+
+type reloadEvent struct {
+	Ms     float64
+	Phases map[string]float64 // "FinalizeReload/SetupLoadedEditorAssemblies" -> ms
+}
+
+type importEvent struct {
+	Path     string
+	Importer string
+	Ms       float64
+}
+
+// historyRun is one entry in the history file. Keep field names stable:
+// the file accumulates across tool versions.
+type historyRun struct {
+	RecordedAt   string  `json:"recordedAt"`
+	Log          string  `json:"log"`
+	LogSize      int64   `json:"logSize"`
+	LogModified  string  `json:"logModified"`
+	Reloads      int     `json:"reloads"`
+	ReloadAvgMs  float64 `json:"reloadAvgMs"`
+	ReloadMaxMs  float64 `json:"reloadMaxMs"`
+	Compiles     int     `json:"compiles"`
+	CompileAvgMs float64 `json:"compileAvgMs"`
+	Refreshes    int     `json:"refreshes"`
+	RefreshAvgMs float64 `json:"refreshAvgMs"`
+	Imports      int     `json:"imports"`
+	ImportMs     float64 `json:"importTotalMs"`
+}
+
+type timingHistory struct {
+	Runs []historyRun `json:"runs"`
+}
+
+type importerStat struct {
+	Name  string
+	Count int
+	Total float64
+	Max   float64
+}
+
+type phaseStat struct {
+	Path  string
+	Count int
+	Total float64
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Editor Log Parsing
+// ============================================================
+
+// defaultEditorLogPath returns the Editor.log location for the current OS
+func defaultEditorLogPath() string {
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		if local := os.Getenv("LOCALAPPDATA"); local != "" {
+			return filepath.Join(local, "Unity", "Editor", "Editor.log")
+		}
+		return filepath.Join(home, "AppData", "Local", "Unity", "Editor", "Editor.log")
+	case "darwin":
+		return filepath.Join(home, "Library", "Logs", "Unity", "Editor.log")
+	default:
+		return filepath.Join(home, ".config", "unity3d", "Editor.log")
+	}
+}
+
+// parseEditorLog collects every timing in the log. Editors that print
+// "Domain Reload Profiling" also print the older "Completed reload" line for
+// the same reload, so the latter only counts when the former never appears.
+func parseEditorLog(logPath string) (*timings, error) {
+	f, err := os.Open(logPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t := &timings{Assemblies: make(map[string][]float64)}
+	var legacyReloads []float64
+	var reload *reloadEvent
+	var phaseStack []string
+	pending := -1 // index in t.Imports still waiting for its duration
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		line = timestampPrefixRegex.ReplaceAllString(line, "")
+
+		// Phase lines directly follow the profiling header
+		if reload != nil {
+			if m := reloadPhaseRegex.FindStringSubmatch(line); m != nil {
+				depth := indentDepth(m[1])
+				if depth > len(phaseStack)+1 {
+					depth = len(phaseStack) + 1
+				}
+				phaseStack = append(phaseStack[:depth-1], m[2])
+				ms, _ := strconv.ParseFloat(m[3], 64)
+				reload.Phases[strings.Join(phaseStack, "/")] += ms
+				continue
+			}
+			reload = nil
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if m := reloadProfilingRegex.FindStringSubmatch(trimmed); m != nil {
+			ms, _ := strconv.ParseFloat(m[1], 64)
+			t.Reloads = append(t.Reloads, reloadEvent{Ms: ms, Phases: make(map[string]float64)})
+			reload = &t.Reloads[len(t.Reloads)-1]
+			phaseStack = phaseStack[:0]
+			continue
+		}
+		if m := completedReloadRegex.FindStringSubmatch(trimmed); m != nil {
+			legacyReloads = append(legacyReloads, seconds(m[1]))
+			continue
+		}
+		if m := tundraRegex.FindStringSubmatch(trimmed); m != nil {
+			t.Compiles = append(t.Compiles, seconds(m[2]))
+			if m[1] == "failed" {
+				t.FailedBuild++
+			}
+			continue
+		}
+		if m := finishedCompileRegex.FindStringSubmatch(trimmed); m != nil {
+			name := filepath.Base(filepath.FromSlash(m[1]))
+			t.Assemblies[name] = append(t.Assemblies[name], seconds(m[2]))
+			continue
+		}
+		if m := refreshRegex.FindStringSubmatch(trimmed); m != nil {
+			t.Refreshes = append(t.Refreshes, seconds(m[1]))
+			continue
+		}
+		if m := startImportRegex.FindStringSubmatch(trimmed); m != nil {
+			path := strings.TrimSpace(m[1])
+			t.Imports = append(t.Imports, importEvent{Path: path, Importer: importerFor(path), Ms: -1})
+			pending = len(t.Imports) - 1
+		}
+		if pending >= 0 {
+			if m := importDoneRegex.FindStringSubmatch(trimmed); m != nil {
+				t.Imports[pending].Ms = seconds(m[1])
+				pending = -1
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(t.Reloads) == 0 {
+		for _, ms := range legacyReloads {
+			t.Reloads = append(t.Reloads, reloadEvent{Ms: ms})
+		}
+	}
+	// Imports whose duration never showed up (editor closed mid-import) are dropped
+	imports := t.Imports[:0]
+	for _, imp := range t.Imports {
+		if imp.Ms >= 0 {
+			imports = append(imports, imp)
+		}
+	}
+	t.Imports = imports
+	return t, nil
+}
+
+// indentDepth counts tabs, or groups of two spaces for editors that indent with spaces
+func indentDepth(indent string) int {
+	depth := strings.Count(indent, "\t") + strings.Count(indent, " ")/2
+	if depth < 1 {
+		depth = 1
+	}
+	return depth
+}
+
+// seconds converts a logged "1.234" seconds value to milliseconds
+func seconds(value string) float64 {
+	v, _ := strconv.ParseFloat(value, 64)
+	return v * 1000
+}
+
+func importerFor(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if name, ok := importerTypes[ext]; ok {
+		return name
+	}
+	if ext == "" {
+		return "(no extension)"
+	}
+	return ext
+}
+
+// ============================================================
+// Aggregation
+// ============================================================
+
+// summarize turns the parsed timings into the history entry for this run
+func summarize(t *timings, logPath string, info os.FileInfo) historyRun {
+	if abs, err := filepath.Abs(logPath); err == nil {
+		logPath = abs
+	}
+	run := historyRun{
+		RecordedAt: time.Now().Format(time.RFC3339),
+		Log:        filepath.ToSlash(logPath),
+		Reloads:    len(t.Reloads),
+		Compiles:   len(t.Compiles),
+		Refreshes:  len(t.Refreshes),
+		Imports:    len(t.Imports),
+	}
+	if info != nil {
+		run.LogSize = info.Size()
+		run.LogModified = info.ModTime().Format(time.RFC3339)
+	}
+	var reloads []float64
+	for _, r := range t.Reloads {
+		reloads = append(reloads, r.Ms)
+		if r.Ms > run.ReloadMaxMs {
+			run.ReloadMaxMs = r.Ms
+		}
+	}
+	run.ReloadAvgMs = average(reloads)
+	run.CompileAvgMs = average(t.Compiles)
+	run.RefreshAvgMs = average(t.Refreshes)
+	for _, imp := range t.Imports {
+		run.ImportMs += imp.Ms
+	}
+	return run
+}
+
+func importerStats(imports []importEvent) []importerStat {
+	byName := make(map[string]*importerStat)
+	for _, imp := range imports {
+		s := byName[imp.Importer]
+		if s == nil {
+			s = &importerStat{Name: imp.Importer}
+			byName[imp.Importer] = s
+		}
+		s.Count++
+		s.Total += imp.Ms
+		if imp.Ms > s.Max {
+			s.Max = imp.Ms
+		}
+	}
+	var out []importerStat
+	for _, s := range byName {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total != out[j].Total {
+			return out[i].Total > out[j].Total
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// slowestImports keeps the longest import of each asset; an asset imported
+// five times in a session is one slow asset, not five
+func slowestImports(imports []importEvent, scriptsOnly bool) []importEvent {
+	longest := make(map[string]importEvent)
+	for _, imp := range imports {
+		if (imp.Importer == "MonoImporter") != scriptsOnly {
+			continue
+		}
+		if prev, ok := longest[imp.Path]; !ok || imp.Ms > prev.Ms {
+			longest[imp.Path] = imp
+		}
+	}
+	var out []importEvent
+	for _, imp := range longest {
+		out = append(out, imp)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Ms != out[j].Ms {
+			return out[i].Ms > out[j].Ms
+		}
+		return out[i].Path < out[j].Path
+	})
+	return out
+}
+
+// phaseStats averages each reload phase over the reloads that reported it
+func phaseStats(reloads []reloadEvent) []phaseStat {
+	byPath := make(map[string]*phaseStat)
+	for _, r := range reloads {
+		for path, ms := range r.Phases {
+			s := byPath[path]
+			if s == nil {
+				s = &phaseStat{Path: path}
+				byPath[path] = s
+			}
+			s.Count++
+			s.Total += ms
+		}
+	}
+	var out []phaseStat
+	for _, s := range byPath {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		ai, aj := out[i].Total/float64(out[i].Count), out[j].Total/float64(out[j].Count)
+		if ai != aj {
+			return ai > aj
+		}
+		return out[i].Path < out[j].Path
+	})
+	return out
+}
+
+func assemblyStats(assemblies map[string][]float64) []importerStat {
+	var out []importerStat
+	for name, list := range assemblies {
+		s := importerStat{Name: name, Count: len(list)}
+		for _, ms := range list {
+			s.Total += ms
+			if ms > s.Max {
+				s.Max = ms
+			}
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Max != out[j].Max {
+			return out[i].Max > out[j].Max
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// ============================================================
+// History
+// ============================================================
+
+func loadHistory(path string) (*timingHistory, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &timingHistory{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var h timingHistory
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("invalid history file %s: %v", path, err)
+	}
+	return &h, nil
+}
+
+// record appends the run unless the same log (path, size and time) was the
+// last one recorded, so running the tool twice does not skew the baseline
+func (h *timingHistory) record(run historyRun) bool {
+	if n := len(h.Runs); n > 0 {
+		last := h.Runs[n-1]
+		if last.Log == run.Log && last.LogSize == run.LogSize && last.LogModified == run.LogModified {
+			return false
+		}
+	}
+	h.Runs = append(h.Runs, run)
+	if len(h.Runs) > maxHistoryRuns {
+		h.Runs = h.Runs[len(h.Runs)-maxHistoryRuns:]
+	}
+	return true
+}
+
+func saveHistory(path string, h *timingHistory) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, _ := json.MarshalIndent(h, "", "  ")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// baseline is the median of up to baselineRuns runs before the current one,
+// skipping runs without any data for the metric
+func baseline(runs []historyRun, metric func(historyRun) float64) (float64, int) {
+	var values []float64
+	for i := len(runs) - 1; i >= 0 && len(values) < baselineRuns; i-- {
+		if v := metric(runs[i]); v > 0 {
+			values = append(values, v)
+		}
+	}
+	return median(values), len(values)
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+func renderReport(t *timings, run historyRun, previous []historyRun, topN int) string {
+	var sb strings.Builder
+
+	sb.WriteString(tr("# Editor Timing Report\n\n"))
+	sb.WriteString(fmt.Sprintf(tr("- **Log**: `%s`\n"), run.Log))
+	sb.WriteString(fmt.Sprintf(tr("- **Generated**: %s\n"), run.RecordedAt))
+	if t.FailedBuild > 0 {
+		sb.WriteString(fmt.Sprintf(tr("- **Failed script compilations**: %d\n"), t.FailedBuild))
+	}
+
+	var compileMax, refreshMax float64
+	for _, ms := range t.Compiles {
+		compileMax = maxFloat(compileMax, ms)
+	}
+	for _, ms := range t.Refreshes {
+		refreshMax = maxFloat(refreshMax, ms)
+	}
+	sb.WriteString(tr("\n## Summary\n\n"))
+	sb.WriteString(tr("| Event | Count | Average | Max | Total |\n|-------|------:|--------:|----:|------:|\n"))
+	sb.WriteString(fmt.Sprintf(tr("| Domain reload | %d | %s | %s | %s |\n"), run.Reloads, formatMs(run.ReloadAvgMs), formatMs(run.ReloadMaxMs), formatMs(run.ReloadAvgMs*float64(run.Reloads))))
+	sb.WriteString(fmt.Sprintf(tr("| Script compilation | %d | %s | %s | %s |\n"), run.Compiles, formatMs(run.CompileAvgMs), formatMs(compileMax), formatMs(run.CompileAvgMs*float64(run.Compiles))))
+	sb.WriteString(fmt.Sprintf(tr("| Asset refresh | %d | %s | %s | %s |\n"), run.Refreshes, formatMs(run.RefreshAvgMs), formatMs(refreshMax), formatMs(run.RefreshAvgMs*float64(run.Refreshes))))
+	importAvg := 0.0
+	if run.Imports > 0 {
+		importAvg = run.ImportMs / float64(run.Imports)
+	}
+	var importMax float64
+	for _, imp := range t.Imports {
+		importMax = maxFloat(importMax, imp.Ms)
+	}
+	sb.WriteString(fmt.Sprintf(tr("| Asset import | %d | %s | %s | %s |\n"), run.Imports, formatMs(importAvg), formatMs(importMax), formatMs(run.ImportMs)))
+
+	// Reload phases
+	if phases := phaseStats(t.Reloads); len(phases) > 0 {
+		if len(phases) > topN {
+			phases = phases[:topN]
+		}
+		sb.WriteString(fmt.Sprintf(tr("\n## Slowest Domain Reload Phases (%d)\n\n"), len(phases)))
+		sb.WriteString(tr("| Average | Share | Phase |\n|--------:|------:|-------|\n"))
+		for _, p := range phases {
+			avg := p.Total / float64(p.Count)
+			sb.WriteString(fmt.Sprintf("| %s | %.1f%% | `%s` |\n", formatMs(avg), share(avg, run.ReloadAvgMs), p.Path))
+		}
+	}
+
+	// Compiled assemblies (editors that log per-assembly compiles)
+	if assemblies := assemblyStats(t.Assemblies); len(assemblies) > 0 {
+		if len(assemblies) > topN {
+			assemblies = assemblies[:topN]
+		}
+		sb.WriteString(fmt.Sprintf(tr("\n## Slowest Assemblies (%d)\n\n"), len(assemblies)))
+		sb.WriteString(tr("| Max | Compiles | Total | Assembly |\n|----:|---------:|------:|----------|\n"))
+		for _, a := range assemblies {
+			sb.WriteString(fmt.Sprintf("| %s | %d | %s | `%s` |\n", formatMs(a.Max), a.Count, formatMs(a.Total), a.Name))
+		}
+	}
+
+	// Importers
+	sb.WriteString(tr("\n## Import Time by Importer\n\n"))
+	stats := importerStats(t.Imports)
+	if len(stats) == 0 {
+		sb.WriteString(tr("_None_\n"))
+	} else {
+		sb.WriteString(tr("| Importer | Imports | Total | % | Average | Max |\n|----------|--------:|------:|--:|--------:|----:|\n"))
+		for _, s := range stats {
+			sb.WriteString(fmt.Sprintf("| %s | %d | %s | %.1f%% | %s | %s |\n", s.Name, s.Count, formatMs(s.Total), share(s.Total, run.ImportMs), formatMs(s.Total/float64(s.Count)), formatMs(s.Max)))
+		}
+	}
+
+	writeImportTable(&sb, tr("Slowest Assets"), slowestImports(t.Imports, false), topN)
+	writeImportTable(&sb, tr("Slowest Scripts"), slowestImports(t.Imports, true), topN)
+
+	// Trend
+	if len(previous) > 0 {
+		writeTrend(&sb, run, previous)
+	}
+	return sb.String()
+}
+
+func writeImportTable(sb *strings.Builder, title string, list []importEvent, topN int) {
+	if len(list) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\n## %s (%d)\n\n", title, len(list)))
+	sb.WriteString(tr("| # | Time | Importer | Path |\n|--:|-----:|----------|------|\n"))
+	for i, imp := range list {
+		if i >= topN {
+			sb.WriteString(fmt.Sprintf(tr("\n_...and %d more_\n"), len(list)-topN))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("| %d | %s | %s | `%s` |\n", i+1, formatMs(imp.Ms), imp.Importer, imp.Path))
+	}
+}
+
+// writeTrend compares this run with the median of the earlier ones and lists
+// the most recent runs
+func writeTrend(sb *strings.Builder, run historyRun, previous []historyRun) {
+	sb.WriteString(tr("\n## Trend\n\n"))
+	sb.WriteString(tr("| Metric | This run | Baseline | Change |\n|--------|---------:|---------:|-------:|\n"))
+	for _, m := range trendMetrics {
+		cur := m.value(run)
+		base, n := baseline(previous, m.value)
+		if n == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", tr(m.name), formatMs(cur), formatMs(base), formatChange(cur, base)))
+	}
+	sb.WriteString(fmt.Sprintf(tr("\n_Baseline: median of the last %d recorded run(s)._\n"), minInt(len(previous), baselineRuns)))
+
+	tail := append(append([]historyRun(nil), previous...), run)
+	if len(tail) > trendRowsInTail {
+		tail = tail[len(tail)-trendRowsInTail:]
+	}
+	sb.WriteString(tr("\n| Recorded | Reloads | Reload avg | Compile avg | Refresh avg | Import total |\n|----------|--------:|-----------:|------------:|------------:|-------------:|\n"))
+	for _, r := range tail {
+		sb.WriteString(fmt.Sprintf("| %s | %d | %s | %s | %s | %s |\n", r.RecordedAt, r.Reloads, formatMs(r.ReloadAvgMs), formatMs(r.CompileAvgMs), formatMs(r.RefreshAvgMs), formatMs(r.ImportMs)))
+	}
+}
+
+// trendMetrics are the per-run values the trend table and the regression
+// gate look at. Import totals depend on what changed, so they are shown but
+// never gated.
+var trendMetrics = []struct {
+	name  string
+	gated bool
+	value func(historyRun) float64
+}{
+	{"Domain reload (avg)", true, func(r historyRun) float64 { return r.ReloadAvgMs }},
+	{"Script compilation (avg)", true, func(r historyRun) float64 { return r.CompileAvgMs }},
+	{"Asset refresh (avg)", true, func(r historyRun) float64 { return r.RefreshAvgMs }},
+	{"Asset import (total)", false, func(r historyRun) float64 { return r.ImportMs }},
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func formatMs(ms float64) string {
+	switch {
+	case ms >= 60000:
+		return fmt.Sprintf("%dm %04.1fs", int(ms/60000), (ms-float64(int(ms/60000))*60000)/1000)
+	case ms >= 1000:
+		return fmt.Sprintf("%.2f s", ms/1000)
+	default:
+		return fmt.Sprintf("%.0f ms", ms)
+	}
+}
+
+func formatChange(cur, base float64) string {
+	if base == 0 {
+		return "-"
+	}
+	pct := (cur - base) * 100 / base
+	if pct > 0 {
+		return fmt.Sprintf("+%.1f%%", pct)
+	}
+	return fmt.Sprintf("%.1f%%", pct)
+}
+
+func share(part, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return part * 100 / total
+}
+
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func maxFloat(a, b float64) float64 {
+	if b > a {
+		return b
+	}
+	return a
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"# Editor Timing Report\n\n":             "# 编辑器耗时报告\n\n",
+	"- **Log**: `%s`\n":                      "- **日志**: `%s`\n",
+	"- **Generated**: %s\n":                  "- **生成时间**: %s\n",
+	"- **Failed script compilations**: %d\n": "- **脚本编译失败次数**: %d\n",
+	"\n## Summary\n\n":                       "\n## 概览\n\n",
+	"| Event | Count | Average | Max | Total |\n|-------|------:|--------:|----:|------:|\n":                   "| 事件 | 次数 | 平均 | 最长 | 合计 |\n|-------|------:|--------:|----:|------:|\n",
+	"| Domain reload | %d | %s | %s | %s |\n":                                                                  "| 域重载 | %d | %s | %s | %s |\n",
+	"| Script compilation | %d | %s | %s | %s |\n":                                                             "| 脚本编译 | %d | %s | %s | %s |\n",
+	"| Asset refresh | %d | %s | %s | %s |\n":                                                                  "| 资源刷新 | %d | %s | %s | %s |\n",
+	"| Asset import | %d | %s | %s | %s |\n":                                                                   "| 资源导入 | %d | %s | %s | %s |\n",
+	"\n## Slowest Domain Reload Phases (%d)\n\n":                                                               "\n## 最慢的域重载阶段 (%d)\n\n",
+	"| Average | Share | Phase |\n|--------:|------:|-------|\n":                                               "| 平均 | 占比 | 阶段 |\n|--------:|------:|-------|\n",
+	"\n## Slowest Assemblies (%d)\n\n":                                                                         "\n## 编译最慢的程序集 (%d)\n\n",
+	"| Max | Compiles | Total | Assembly |\n|----:|---------:|------:|----------|\n":                           "| 最长 | 编译次数 | 合计 | 程序集 |\n|----:|---------:|------:|----------|\n",
+	"\n## Import Time by Importer\n\n":                                                                         "\n## 按导入器统计导入耗时\n\n",
+	"| Importer | Imports | Total | % | Average | Max |\n|----------|--------:|------:|--:|--------:|----:|\n": "| 导入器 | 导入次数 | 合计 | % | 平均 | 最长 |\n|----------|--------:|------:|--:|--------:|----:|\n",
+	"_None_\n":        "_无_\n",
+	"Slowest Assets":  "导入最慢的资源",
+	"Slowest Scripts": "导入最慢的脚本",
+	"| # | Time | Importer | Path |\n|--:|-----:|----------|------|\n": "| # | 耗时 | 导入器 | 路径 |\n|--:|-----:|----------|------|\n",
+	"\n_...and %d more_\n": "\n_……另有 %d 项_\n",
+	"\n## Trend\n\n":       "\n## 趋势\n\n",
+	"| Metric | This run | Baseline | Change |\n|--------|---------:|---------:|-------:|\n":                                                                             "| 指标 | 本次 | 基线 | 变化 |\n|--------|---------:|---------:|-------:|\n",
+	"\n_Baseline: median of the last %d recorded run(s)._\n":                                                                                                             "\n_基线：最近 %d 次记录的中位数。_\n",
+	"\n| Recorded | Reloads | Reload avg | Compile avg | Refresh avg | Import total |\n|----------|--------:|-----------:|------------:|------------:|-------------:|\n": "\n| 记录时间 | 重载次数 | 平均重载 | 平均编译 | 平均刷新 | 导入合计 |\n|----------|--------:|-----------:|------------:|------------:|-------------:|\n",
+	"Domain reload (avg)":      "域重载（平均）",
+	"Script compilation (avg)": "脚本编译（平均）",
+	"Asset refresh (avg)":      "资源刷新（平均）",
+	"Asset import (total)":     "资源导入（合计）",
+
+	"[ERROR] Invalid -max-regression value: %s\n":                                         "[ERROR] 无效的 -max-regression 值: %s\n",
+	"[WARNING] No reload, compile or import timings found in %s\n":                        "[WARNING] %s 中没有找到重载、编译或导入耗时\n",
+	"[ERROR] Cannot write report: %v\n":                                                   "[ERROR] 无法写入报告: %v\n",
+	"[OK] Report written to %s\n":                                                         "[OK] 报告已写入 %s\n",
+	"[TIP] Run from the Unity project root or pass -history to track trends across runs.": "[TIP] 在 Unity 项目根目录运行或传入 -history，即可跨次记录趋势。",
+	"[ERROR] Cannot save history: %v\n":                                                   "[ERROR] 无法保存历史记录: %v\n",
+	"[OK] Run recorded in %s (%d run(s))\n":                                               "[OK] 本次结果已记录到 %s (共 %d 次)\n",
+	"[--] This log is unchanged since the last recorded run; history not updated.":        "[--] 日志自上次记录后没有变化，未更新历史记录。",
+	"[WARNING] No earlier runs in the history; regression gate skipped.":                  "[WARNING] 历史记录中没有更早的结果，跳过回归检查。",
+	"[FAIL] %s got slower: %s vs baseline %s (%s, limit: +%g%%)\n":                        "[FAIL] %s 变慢了: %s，基线 %s (%s，上限: +%g%%)\n",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// CI Annotations
+// ============================================================
+
+// With -annotate, errors and warnings are printed once more as GitHub Actions
+// workflow commands or TeamCity service messages, so the CI lists them on the run
+// page and, when they point at a file, next to that file in the diff.
+var (
+	annotateMode  string              // "", "github" or "teamcity"
+	annotateTool  string              // TeamCity inspection category
+	annotateBase  string              // project root; relative files start here
+	annotateRoot  string              // repository root; CI file paths are relative to it
+	annotateTypes = map[string]bool{} // TeamCity inspection types already declared
+)
+
+// setAnnotateMode checks the -annotate value; "auto" picks the CI the tool runs in
+func setAnnotateMode(mode, basePath, tool string) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "none":
+		annotateMode = ""
+	case "github", "teamcity":
+		annotateMode = strings.ToLower(strings.TrimSpace(mode))
+	case "auto":
+		annotateMode = ""
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			annotateMode = "github"
+		} else if os.Getenv("TEAMCITY_VERSION") != "" {
+			annotateMode = "teamcity"
+		}
+	default:
+		return fmt.Errorf("unknown -annotate mode '%s' (use github, teamcity or auto)", mode)
+	}
+	annotateTool = tool
+	annotateBase, _ = filepath.Abs(basePath)
+	annotateRoot = repositoryRoot(annotateBase)
+	return nil
+}
+
+// repositoryRoot is GITHUB_WORKSPACE, else the nearest folder with a .git entry
+// (a folder, or a file in worktrees and submodules), else the project itself
+func repositoryRoot(basePath string) string {
+	if ws := os.Getenv("GITHUB_WORKSPACE"); ws != "" && annotateMode == "github" {
+		return ws
+	}
+	for dir := basePath; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return basePath
+		}
+	}
+}
+
+// annotate prints one annotation. level is "error" or "warning"; file is absolute
+// or relative to the project root, and may be empty like line and col.
+func annotate(level, file string, line, col int, title, message string) {
+	if annotateMode == "" {
+		return
+	}
+	if file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(annotateBase, filepath.FromSlash(file))
+		}
+		if rel, err := filepath.Rel(annotateRoot, file); err == nil {
+			file = rel
+		}
+		file = filepath.ToSlash(file)
+	}
+
+	if annotateMode == "github" {
+		// Property values also escape ':' and ','; the message only '%' and line breaks
+		data := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+		prop := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+		var props []string
+		if file != "" {
+			props = append(props, "file="+prop.Replace(file))
+			if line > 0 {
+				props = append(props, "line="+strconv.Itoa(line))
+			}
+			if col > 0 {
+				props = append(props, "col="+strconv.Itoa(col))
+			}
+		}
+		if title != "" {
+			props = append(props, "title="+prop.Replace(title))
+		}
+		fmt.Printf("::%s %s::%s\n", level, strings.Join(props, ","), data.Replace(message))
+		return
+	}
+
+	esc := strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]").Replace
+	status := "WARNING"
+	if level == "error" {
+		status = "ERROR"
+	}
+	if file == "" {
+		text := message
+		if title != "" {
+			text = title + ": " + message
+		}
+		fmt.Printf("##teamcity[message text='%s' status='%s']\n", esc(text), status)
+		return
+	}
+	typeID := annotateTool + "." + strings.ReplaceAll(strings.ToLower(title), " ", "-")
+	if !annotateTypes[typeID] {
+		annotateTypes[typeID] = true
+		fmt.Printf("##teamcity[inspectionType id='%s' name='%s' category='%s' description='%s']\n", esc(typeID), esc(title), esc(annotateTool), esc(title))
+	}
+	lineAttr := ""
+	if line > 0 {
+		lineAttr = fmt.Sprintf(" line='%d'", line)
+	}
+	fmt.Printf("##teamcity[inspection typeId='%s' message='%s' file='%s'%s SEVERITY='%s']\n", esc(typeID), esc(message), esc(file), lineAttr, status)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		logPath       string
+		outputPath    string
+		historyPath   string
+		noRecord      bool
+		topN          int
+		maxRegression string
+		annotateFlag  string
+	)
+
+	flag.StringVar(&logPath, "log", "", "Editor log to parse (default: platform Editor.log)")
+	flag.StringVar(&outputPath, "o", "", "Write the Markdown report to a file (default: stdout)")
+	flag.StringVar(&historyPath, "history", "", "History file for trends (default: "+defaultHistoryFile+" when run from a Unity project)")
+	flag.BoolVar(&noRecord, "no-record", false, "Compare with the history without adding this run to it")
+	flag.IntVar(&topN, "top", 15, "Number of slowest assets / scripts / phases to list")
+	flag.StringVar(&maxRegression, "max-regression", "", "Fail (exit 2) if an average got slower than the history baseline by more than this (e.g. 20%)")
+	flag.StringVar(&annotateFlag, "annotate", "", "Also print gate failures and warnings as CI annotations: github, teamcity, auto")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (report goes to stderr or -o)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("editor_log_profiler")
+	}
+	wd, _ := os.Getwd()
+	if err := setAnnotateMode(annotateFlag, wd, "editor_log_profiler"); err != nil {
+		fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
+		recordError("%v", err)
+		exitTool(1)
+	}
+
+	limit := -1.0
+	if maxRegression != "" {
+		v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(maxRegression), "%"), 64)
+		if err != nil || v < 0 {
+			fmt.Fprintf(os.Stderr, tr("[ERROR] Invalid -max-regression value: %s\n"), maxRegression)
+			recordError("invalid -max-regression value: %s", maxRegression)
+			exitTool(1)
+		}
+		limit = v
+	}
+
+	if logPath == "" {
+		logPath = defaultEditorLogPath()
+	}
+	t, err := parseEditorLog(logPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
+		recordError("%v", err)
+		exitTool(1)
+	}
+	info, _ := os.Stat(logPath)
+	run := summarize(t, logPath, info)
+	recordAction("analyze", logPath, "ok", fmt.Sprintf("%d reloads, %d compiles, %d imports", run.Reloads, run.Compiles, run.Imports), time.Since(jsonStart))
+	empty := run.Reloads == 0 && run.Compiles == 0 && run.Refreshes == 0 && run.Imports == 0
+	if empty {
+		fmt.Fprintf(os.Stderr, tr("[WARNING] No reload, compile or import timings found in %s\n"), logPath)
+		annotate("warning", "", 0, 0, "Editor timings", "no reload, compile or import timings found in "+logPath)
+	}
+
+	// History: the default lives in the project's Library folder
+	if historyPath == "" {
+		if wd, err := os.Getwd(); err == nil && isUnityProject(wd) {
+			historyPath = filepath.Join(wd, filepath.FromSlash(defaultHistoryFile))
+		}
+	}
+	var history *timingHistory
+	var previous []historyRun
+	if historyPath != "" {
+		history, err = loadHistory(historyPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] %v\n", err)
+			recordError("%v", err)
+			exitTool(1)
+		}
+		previous = history.Runs
+		// The same unchanged log was already recorded; compare with what came before it
+		if n := len(previous); n > 0 && previous[n-1].Log == run.Log && previous[n-1].LogSize == run.LogSize && previous[n-1].LogModified == run.LogModified {
+			previous = previous[:n-1]
+		}
+	}
+
+	report := renderReport(t, run, previous, topN)
+	if outputPath != "" {
+		if err := os.WriteFile(outputPath, []byte(report), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, tr("[ERROR] Cannot write report: %v\n"), err)
+			recordError("cannot write report: %v", err)
+			exitTool(1)
+		}
+		fmt.Printf(tr("[OK] Report written to %s\n"), outputPath)
+		recordArtifact(outputPath)
+	} else {
+		fmt.Print(report)
+	}
+
+	if historyPath == "" {
+		fmt.Fprintln(os.Stderr, tr("[TIP] Run from the Unity project root or pass -history to track trends across runs."))
+	} else if !noRecord && !empty {
+		if history.record(run) {
+			if err := saveHistory(historyPath, history); err != nil {
+				fmt.Fprintf(os.Stderr, tr("[ERROR] Cannot save history: %v\n"), err)
+				recordError("cannot save history: %v", err)
+				exitTool(1)
+			}
+			fmt.Fprintf(os.Stderr, tr("[OK] Run recorded in %s (%d run(s))\n"), historyPath, len(history.Runs))
+			recordArtifact(historyPath)
+		} else {
+			fmt.Fprintln(os.Stderr, tr("[--] This log is unchanged since the last recorded run; history not updated."))
+		}
+	}
+
+	// Regression gate
+	if limit >= 0 {
+		if len(previous) == 0 {
+			fmt.Fprintln(os.Stderr, tr("[WARNING] No earlier runs in the history; regression gate skipped."))
+			annotate("warning", "", 0, 0, "Editor timings", "no earlier runs in the history; regression gate skipped")
+			recordAction("time-gate", historyPath, "skipped", "no baseline", 0)
+			return
+		}
+		failed := false
+		for _, m := range trendMetrics {
+			if !m.gated {
+				continue
+			}
+			cur := m.value(run)
+			base, n := baseline(previous, m.value)
+			if n == 0 || cur == 0 {
+				continue
+			}
+			change := formatChange(cur, base)
+			detail := fmt.Sprintf("%s vs %s (%s, limit: +%g%%)", formatMs(cur), formatMs(base), change, limit)
+			if (cur-base)*100/base > limit {
+				fmt.Fprintf(os.Stderr, tr("[FAIL] %s got slower: %s vs baseline %s (%s, limit: +%g%%)\n"), tr(m.name), formatMs(cur), formatMs(base), change, limit)
+				annotate("error", "", 0, 0, "Editor timings", fmt.Sprintf("%s got slower: %s", m.name, detail))
+				recordAction("time-gate", m.name, "failed", detail, 0)
+				failed = true
+				continue
+			}
+			recordAction("time-gate", m.name, "ok", detail, 0)
+		}
+		if failed {
+			exitTool(2)
+		}
+	}
+}
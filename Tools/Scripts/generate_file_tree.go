@@ -12,13 +12,18 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"math"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -45,6 +50,7 @@ type stats struct {
 	dirs      int
 	files     int
 	totalSize int64
+	progress  *progressTask // entries read so far
 }
 
 type profile struct {
@@ -104,7 +110,7 @@ var defaultIgnoreDirs = []string{
 	".git", ".vs", ".idea", ".vscode", ".utmp",
 	"node_modules", "obj", "Logs", "Temp",
 	"Library", "SceneBackups", "MemoryCaptures",
-	"UserSettings", "Packages",
+	"UserSettings", "Packages", ".unitystarter_snapshots",
 }
 
 var defaultIgnoreExts = []string{
@@ -191,11 +197,26 @@ func (c *config) matchesFilter(name string) bool {
 // Tree Traversal
 // ============================================================
 
+// Tree connectors; plain mode swaps them for ASCII so screen readers don't
+// spell out box-drawing characters
+var (
+	treeBranch = "├── "
+	treeLast   = "└── "
+	treePipe   = "│   "
+)
+
+func useASCIITree() {
+	treeBranch = "|-- "
+	treeLast = "`-- "
+	treePipe = "|   "
+}
+
 func traverseDir(cfg *config, buf *bytes.Buffer, st *stats, dirPath, prefix string, depth int) {
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
 		return
 	}
+	st.progress.advance(int64(len(entries)))
 
 	var visibleDirs, visibleFiles []os.DirEntry
 	filteredCount := 0
@@ -243,9 +264,9 @@ func traverseDir(cfg *config, buf *bytes.Buffer, st *stats, dirPath, prefix stri
 	if len(visible) == 0 {
 		if hasEllipsis {
 			if cfg.showCount {
-				buf.WriteString(fmt.Sprintf("%s└── ... (%d items)\n", prefix, filteredCount))
+				buf.WriteString(fmt.Sprintf(tr("%s%s... (%d items)\n"), prefix, treeLast, filteredCount))
 			} else {
-				buf.WriteString(fmt.Sprintf("%s└── ...\n", prefix))
+				buf.WriteString(fmt.Sprintf("%s%s...\n", prefix, treeLast))
 			}
 		}
 		return
@@ -254,11 +275,11 @@ func traverseDir(cfg *config, buf *bytes.Buffer, st *stats, dirPath, prefix stri
 	// Render entries
 	for i, entry := range visible {
 		isLast := i == len(visible)-1 && !hasEllipsis
-		connector := "├── "
+		connector := treeBranch
 		if isLast {
-			connector = "└── "
+			connector = treeLast
 		}
-		childPrefix := prefix + "│   "
+		childPrefix := prefix + treePipe
 		if isLast {
 			childPrefix = prefix + "    "
 		}
@@ -268,7 +289,12 @@ func traverseDir(cfg *config, buf *bytes.Buffer, st *stats, dirPath, prefix stri
 		if entry.IsDir() {
 			st.dirs++
 			buf.WriteString(fmt.Sprintf("%s%s%s\n", prefix, connector, name))
+			var step *progressTask
+			if depth == 0 && st.progress != nil {
+				step = st.progress.board.addStep(name)
+			}
 			traverseDir(cfg, buf, st, filepath.Join(dirPath, name), childPrefix, depth+1)
+			step.finish()
 		} else {
 			st.files++
 			display := name
@@ -285,9 +311,9 @@ func traverseDir(cfg *config, buf *bytes.Buffer, st *stats, dirPath, prefix stri
 	// Trailing ellipsis for filtered items
 	if hasEllipsis {
 		if cfg.showCount {
-			buf.WriteString(fmt.Sprintf("%s└── ... (%d items)\n", prefix, filteredCount))
+			buf.WriteString(fmt.Sprintf(tr("%s%s... (%d items)\n"), prefix, treeLast, filteredCount))
 		} else {
-			buf.WriteString(fmt.Sprintf("%s└── ...\n", prefix))
+			buf.WriteString(fmt.Sprintf("%s%s...\n", prefix, treeLast))
 		}
 	}
 }
@@ -412,19 +438,23 @@ func generate(cfg *config, profileName string) (string, stats) {
 	}
 
 	// Header
-	buf.WriteString("# Directory Structure\n\n")
-	buf.WriteString(fmt.Sprintf("- **Generated**: %s\n", time.Now().Format("2006-01-02 15:04:05")))
-	buf.WriteString(fmt.Sprintf("- **Profile**: %s\n", profileName))
+	buf.WriteString(tr("# Directory Structure\n\n"))
+	buf.WriteString(fmt.Sprintf(tr("- **Generated**: %s\n"), time.Now().Format("2006-01-02 15:04:05")))
+	buf.WriteString(fmt.Sprintf(tr("- **Profile**: %s\n"), profileName))
 	if cfg.maxDepth > 0 {
-		buf.WriteString(fmt.Sprintf("- **Depth**: %d\n", cfg.maxDepth))
+		buf.WriteString(fmt.Sprintf(tr("- **Depth**: %d\n"), cfg.maxDepth))
 	}
 	buf.WriteString("\n```\n")
 
 	// Root
 	buf.WriteString(rootName + "/\n")
 
-	// Traverse
+	// Traverse; the board shows the entries read and the top-level folder being read
+	board := newProgressBoard()
+	st.progress = board.add(tr("Reading"), 0)
 	traverseDir(cfg, &buf, &st, cfg.targetDir, "", 0)
+	st.progress.finish()
+	board.close()
 
 	buf.WriteString("```\n")
 	return buf.String(), st
@@ -449,22 +479,22 @@ func writeOutput(content, outputPath string) error {
 // ============================================================
 
 func runInteractive() {
-	fmt.Println("==============================================")
-	fmt.Println("  Generate File Tree")
-	fmt.Println("  Create Markdown directory structure")
-	fmt.Println("==============================================")
+	printRule("==============================================")
+	fmt.Println(tr("  Generate File Tree"))
+	fmt.Println(tr("  Create Markdown directory structure"))
+	printRule("==============================================")
 
 	targetDir, _ := os.Getwd()
-	fmt.Printf("\nTarget: %s\n", targetDir)
+	fmt.Printf(tr("\nTarget: %s\n"), targetDir)
 
 	// Profile selection
-	fmt.Println("\nSelect profile:")
+	fmt.Println(tr("\nSelect profile:"))
 	for i, p := range profiles {
 		def := ""
 		if i == 1 {
-			def = " (default)"
+			def = tr(" (default)")
 		}
-		fmt.Printf("  [%d] %-10s — %s%s\n", i+1, p.name, p.description, def)
+		fmt.Printf("  [%d] %-10s — %s%s\n", i+1, p.name, tr(p.description), def)
 	}
 
 	fmt.Print("\n> ")
@@ -475,14 +505,14 @@ func runInteractive() {
 		profileIdx = n - 1
 	}
 	p := profiles[profileIdx]
-	fmt.Printf("Using profile: %s\n", p.name)
+	fmt.Printf(tr("Using profile: %s\n"), p.name)
 
 	// Optional depth
 	depthDefault := "unlimited"
 	if p.maxDepth > 0 {
 		depthDefault = strconv.Itoa(p.maxDepth)
 	}
-	fmt.Printf("\nMax depth (0=unlimited, default: %s): ", depthDefault)
+	fmt.Printf(tr("\nMax depth (0=unlimited, default: %s): "), depthDefault)
 	depthStr, _ := stdinReader.ReadString('\n')
 	depthStr = strings.TrimSpace(depthStr)
 	maxDepth := -1 // use profile default
@@ -493,7 +523,7 @@ func runInteractive() {
 	}
 
 	// Output file
-	fmt.Print("Output file (default: directory_structure.md): ")
+	fmt.Print(tr("Output file (default: directory_structure.md): "))
 	outStr, _ := stdinReader.ReadString('\n')
 	outStr = strings.TrimSpace(outStr)
 	if outStr == "" {
@@ -502,7 +532,7 @@ func runInteractive() {
 
 	cfg := buildConfig(p, targetDir, outStr, maxDepth, false, false, false, false, "", "")
 
-	fmt.Println("\nGenerating...")
+	fmt.Println(tr("\nGenerating..."))
 	startTime := time.Now()
 
 	content, st := generate(cfg, p.name)
@@ -540,28 +570,600 @@ func formatSize(bytes int64) string {
 }
 
 func printSummary(outputFile, profileName string, st stats, duration time.Duration, cfg *config) {
-	fmt.Println("\n===========================================")
-	fmt.Println("  GENERATION COMPLETE")
-	fmt.Println("===========================================")
-	fmt.Printf("  Output:      %s\n", outputFile)
-	fmt.Printf("  Directories: %d\n", st.dirs)
+	printRule("\n===========================================")
+	fmt.Println(tr("  GENERATION COMPLETE"))
+	printRule("===========================================")
+	fmt.Printf(tr("  Output:      %s\n"), outputFile)
+	fmt.Printf(tr("  Directories: %d\n"), st.dirs)
 	sizeStr := ""
 	if cfg.showSize && st.totalSize > 0 {
 		sizeStr = fmt.Sprintf(" (%s)", formatSize(st.totalSize))
 	}
-	fmt.Printf("  Files:       %d%s\n", st.files, sizeStr)
-	fmt.Printf("  Profile:     %s\n", profileName)
+	fmt.Printf(tr("  Files:       %d%s\n"), st.files, sizeStr)
+	fmt.Printf(tr("  Profile:     %s\n"), profileName)
 	if cfg.maxDepth > 0 {
-		fmt.Printf("  Depth limit: %d\n", cfg.maxDepth)
+		fmt.Printf(tr("  Depth limit: %d\n"), cfg.maxDepth)
 	}
-	fmt.Printf("  Time:        %s\n", duration.Round(time.Millisecond))
+	fmt.Printf(tr("  Time:        %s\n"), duration.Round(time.Millisecond))
 }
 
 func waitForKeyPress() {
-	fmt.Println("\nPress Enter to exit...")
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to exit..."))
 	stdinReader.ReadBytes('\n')
 }
 
+// ============================================================
+// Progress
+// ============================================================
+
+// progressBoard shows the progress of work spread over goroutines: one line
+// per task, a bar when the task's total is known and a spinner when it is not.
+// Every method is safe to call from any goroutine; log lines printed through
+// printf appear above the bars instead of tearing them.
+//
+// How it draws depends on where stdout goes:
+//   - a terminal with ANSI support: every task on its own line, redrawn in place
+//   - the classic Windows console: one line redrawn with \r, showing the first
+//     running task and the item the first step is working on
+//   - a file, a pipe or -plain: nothing is redrawn; each bar prints a line per
+//     10%, and a task without a total prints a "still running" line every 15
+//     seconds in place of the spinner
+//
+// close stops drawing and leaves one line per task as the summary.
+type progressBoard struct {
+	mu    sync.Mutex
+	tasks []*progressTask
+	mode  int
+	drawn int // progressLines: lines drawn; progressLine: width of the line
+	frame int
+	stop  chan struct{}
+	wg    sync.WaitGroup
+	done  sync.Once // guards close
+}
+
+// progressTask is one line of the board. Steps are short-lived items (a file
+// being converted, a folder being deleted) shown while they run and left out
+// of the summary.
+type progressTask struct {
+	board   *progressBoard
+	name    string
+	current int64
+	total   int64 // 0 when unknown
+	bytes   bool  // current and total are sizes
+	failed  int
+	step    bool
+	started time.Time
+	ended   time.Time
+	logged  int       // progressLog: last 10% step printed
+	beat    time.Time // progressLog: last "still running" line
+}
+
+const (
+	progressLines = iota // ANSI terminal
+	progressLine         // terminal without cursor movement
+	progressLog          // no terminal, or plain mode
+)
+
+const (
+	progressRedraw    = 100 * time.Millisecond
+	progressHeartbeat = 15 * time.Second
+	progressBarWidth  = 30
+	progressNameWidth = 24
+	progressMaxSteps  = 8  // step lines shown at once; the rest are counted
+	progressLineWidth = 79 // progressLine: longer lines wrap and break \r
+)
+
+var progressSpinner = []string{"|", "/", "-", `\`}
+
+// detectProgressMode picks how the board draws on the current stdout
+func detectProgressMode() int {
+	if plainMode {
+		return progressLog
+	}
+	info, err := os.Stdout.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return progressLog
+	}
+	// Windows Terminal, ConEmu and terminals inside editors handle escapes;
+	// the classic console only does when a program switches it on
+	if runtime.GOOS != "windows" || os.Getenv("WT_SESSION") != "" || os.Getenv("TERM_PROGRAM") != "" || os.Getenv("ConEmuANSI") == "ON" {
+		return progressLines
+	}
+	return progressLine
+}
+
+func newProgressBoard() *progressBoard {
+	b := &progressBoard{mode: detectProgressMode(), stop: make(chan struct{})}
+	interval := progressRedraw
+	if b.mode == progressLog {
+		interval = time.Second
+	}
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-b.stop:
+				return
+			case <-ticker.C:
+				b.mu.Lock()
+				b.frame++
+				b.render()
+				b.mu.Unlock()
+			}
+		}
+	}()
+	return b
+}
+
+// add starts a task of total items; 0 draws a spinner until setTotal
+func (b *progressBoard) add(name string, total int64) *progressTask {
+	return b.addTask(&progressTask{name: name, total: total})
+}
+
+// addSize starts a task measured in bytes
+func (b *progressBoard) addSize(name string, total int64) *progressTask {
+	return b.addTask(&progressTask{name: name, total: total, bytes: true})
+}
+
+// addStep shows one item while it is being worked on; call finish when done
+func (b *progressBoard) addStep(name string) *progressTask {
+	return b.addTask(&progressTask{name: name, step: true})
+}
+
+// addSizeStep is a step that counts bytes, such as a folder being measured
+func (b *progressBoard) addSizeStep(name string) *progressTask {
+	return b.addTask(&progressTask{name: name, step: true, bytes: true})
+}
+
+func (b *progressBoard) addTask(t *progressTask) *progressTask {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t.board = b
+	t.started = time.Now()
+	t.beat = t.started
+	b.tasks = append(b.tasks, t)
+	return t
+}
+
+// printf prints a log line above the board
+func (b *progressBoard) printf(format string, a ...interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.erase()
+	fmt.Printf(format, a...)
+	b.render()
+}
+
+// close stops drawing and prints the final line of every task that is not a step.
+// Only the first call does anything; concurrent callers wait for it to finish.
+func (b *progressBoard) close() {
+	b.done.Do(func() {
+		close(b.stop)
+		b.wg.Wait()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.erase()
+		for _, t := range b.tasks {
+			if !t.step {
+				fmt.Println(t.line(b.frame, true))
+			}
+		}
+	})
+}
+
+func (t *progressTask) advance(n int64) {
+	if t == nil {
+		return
+	}
+	t.board.mu.Lock()
+	t.current += n
+	t.board.mu.Unlock()
+}
+
+func (t *progressTask) setTotal(total int64) {
+	if t == nil {
+		return
+	}
+	t.board.mu.Lock()
+	t.total = total
+	t.board.mu.Unlock()
+}
+
+// fail counts a failed item; failures show on the task's line
+func (t *progressTask) fail() {
+	if t == nil {
+		return
+	}
+	t.board.mu.Lock()
+	t.failed++
+	t.board.mu.Unlock()
+}
+
+func (t *progressTask) finish() {
+	if t == nil {
+		return
+	}
+	t.board.mu.Lock()
+	defer t.board.mu.Unlock()
+	if t.ended.IsZero() {
+		t.ended = time.Now()
+	}
+	if t.step {
+		b := t.board
+		for i, other := range b.tasks {
+			if other == t {
+				b.tasks = append(b.tasks[:i], b.tasks[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// amount is "12/40", "1.20 GB/3.00 GB" or, without a total, the count so far
+func (t *progressTask) amount() string {
+	format := func(n int64) string {
+		if t.bytes {
+			return formatSize(n)
+		}
+		return strconv.FormatInt(n, 10)
+	}
+	if t.total > 0 {
+		return format(t.current) + "/" + format(t.total)
+	}
+	return format(t.current)
+}
+
+// line renders the task; final is the summary form after close
+func (t *progressTask) line(frame int, final bool) string {
+	name := t.name
+	if r := []rune(name); len(r) > progressNameWidth {
+		name = "..." + string(r[len(r)-progressNameWidth+3:])
+	}
+	var sb strings.Builder
+	if t.board.mode == progressLog {
+		sb.WriteString(name + ": ")
+	} else {
+		fmt.Fprintf(&sb, "%-*s ", progressNameWidth, name)
+	}
+	end := t.ended
+	if end.IsZero() {
+		end = time.Now()
+	}
+	elapsed := end.Sub(t.started).Round(100 * time.Millisecond)
+	switch {
+	case t.step:
+		sb.WriteString(progressSpinner[frame%len(progressSpinner)])
+		if t.current > 0 {
+			sb.WriteString(" " + t.amount())
+		}
+		sb.WriteString(" " + elapsed.Round(time.Second).String())
+		return sb.String()
+	case t.total > 0 && t.board.mode == progressLog:
+		// Screen readers and log viewers get the numbers without the bar
+		fmt.Fprintf(&sb, "%s (%.0f%%)", t.amount(), math.Min(float64(t.current)/float64(t.total), 1)*100)
+	case t.total > 0:
+		percent := math.Min(float64(t.current)/float64(t.total), 1)
+		filled := int(percent * progressBarWidth)
+		fmt.Fprintf(&sb, "[%s%s] %3.0f%% (%s)", strings.Repeat("█", filled), strings.Repeat("-", progressBarWidth-filled), percent*100, t.amount())
+	case final || !t.ended.IsZero():
+		fmt.Fprintf(&sb, tr("done, %s"), t.amount())
+	default:
+		fmt.Fprintf(&sb, "%s %s", progressSpinner[frame%len(progressSpinner)], t.amount())
+	}
+	if t.failed > 0 {
+		fmt.Fprintf(&sb, tr(", %d failed"), t.failed)
+	}
+	if final || !t.ended.IsZero() {
+		fmt.Fprintf(&sb, tr(" in %s"), elapsed)
+	}
+	return sb.String()
+}
+
+// erase removes what the last render drew; called with mu held
+func (b *progressBoard) erase() {
+	switch b.mode {
+	case progressLines:
+		if b.drawn > 0 {
+			fmt.Printf("\x1b[%dA\x1b[J", b.drawn)
+		}
+	case progressLine:
+		if b.drawn > 0 {
+			fmt.Printf("\r%s\r", strings.Repeat(" ", b.drawn))
+		}
+	}
+	b.drawn = 0
+}
+
+// render draws the board; called with mu held
+func (b *progressBoard) render() {
+	select {
+	case <-b.stop:
+		return
+	default:
+	}
+	switch b.mode {
+	case progressLines:
+		var sb strings.Builder
+		if b.drawn > 0 {
+			fmt.Fprintf(&sb, "\x1b[%dA", b.drawn)
+		}
+		lines, steps := 0, 0
+		for _, t := range b.tasks {
+			if t.step {
+				if steps++; steps > progressMaxSteps {
+					continue
+				}
+			}
+			sb.WriteString("\r\x1b[2K" + t.line(b.frame, false) + "\n")
+			lines++
+		}
+		if steps > progressMaxSteps {
+			sb.WriteString("\r\x1b[2K" + fmt.Sprintf(tr("  ... and %d more"), steps-progressMaxSteps) + "\n")
+			lines++
+		}
+		sb.WriteString("\x1b[J")
+		fmt.Print(sb.String())
+		b.drawn = lines
+	case progressLine:
+		var main, step *progressTask
+		for _, t := range b.tasks {
+			if t.step && step == nil {
+				step = t
+			} else if !t.step && main == nil && t.ended.IsZero() {
+				main = t
+			}
+		}
+		var text string
+		if main != nil {
+			text = main.line(b.frame, false)
+		}
+		if step != nil {
+			text += "  " + progressSpinner[b.frame%len(progressSpinner)] + " " + step.name
+		}
+		if r := []rune(text); len(r) > progressLineWidth {
+			text = string(r[:progressLineWidth])
+		}
+		width := len([]rune(text))
+		pad := ""
+		if b.drawn > width {
+			pad = strings.Repeat(" ", b.drawn-width)
+		}
+		fmt.Printf("\r%s%s", text, pad)
+		b.drawn = width
+	case progressLog:
+		for _, t := range b.tasks {
+			switch {
+			case t.total > 0 && !t.step:
+				// 100% is left to the summary line of close
+				if s := int(t.current * 10 / t.total); s > t.logged && s < 10 {
+					t.logged = s
+					fmt.Println(t.line(b.frame, false))
+				}
+			case time.Since(t.beat) >= progressHeartbeat && t.ended.IsZero():
+				t.beat = time.Now()
+				detail := time.Since(t.started).Round(time.Second).String()
+				if t.current > 0 {
+					detail = t.amount() + ", " + detail
+				}
+				fmt.Printf(tr("%s: still running (%s)\n"), t.name, detail)
+			}
+		}
+	}
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"Folders only, max depth 3 — quick overview": "仅目录，最大深度 3 — 快速概览",
+	"Code & doc files, unlimited depth":          "代码与文档文件，不限深度",
+	"Code, config, and Unity asset types":        "代码、配置与 Unity 资源类型",
+	"All files, no filtering":                    "所有文件，不做过滤",
+
+	"%s%s... (%d items)\n":      "%s%s... (%d 项)\n",
+	"# Directory Structure\n\n": "# 目录结构\n\n",
+	"- **Generated**: %s\n":     "- **生成时间**: %s\n",
+	"- **Profile**: %s\n":       "- **配置**: %s\n",
+	"- **Depth**: %d\n":         "- **深度**: %d\n",
+
+	"  Generate File Tree":                            "  生成文件树",
+	"  Create Markdown directory structure":           "  生成 Markdown 目录结构",
+	"\nTarget: %s\n":                                  "\n目标: %s\n",
+	"\nSelect profile:":                               "\n选择配置:",
+	" (default)":                                      " (默认)",
+	"Using profile: %s\n":                             "使用配置: %s\n",
+	"\nMax depth (0=unlimited, default: %s): ":        "\n最大深度 (0=不限，默认: %s): ",
+	"Output file (default: directory_structure.md): ": "输出文件 (默认: directory_structure.md): ",
+	"\nGenerating...":                                 "\n正在生成...",
+	"Reading":                                         "读取",
+	"done, %s":                                        "完成，%s",
+	", %d failed":                                     "，%d 个失败",
+	" in %s":                                          "，用时 %s",
+	"  ... and %d more":                               "  ... 另有 %d 项",
+	"%s: still running (%s)\n":                        "%s: 仍在运行 (%s)\n",
+	"  GENERATION COMPLETE":                           "  生成完成",
+	"  Output:      %s\n":                             "  输出:        %s\n",
+	"  Directories: %d\n":                             "  目录:        %d\n",
+	"  Files:       %d%s\n":                           "  文件:        %d%s\n",
+	"  Profile:     %s\n":                             "  配置:        %s\n",
+	"  Depth limit: %d\n":                             "  深度限制:    %d\n",
+	"  Time:        %s\n":                             "  耗时:        %s\n",
+	"\nPress Enter to exit...":                        "\n按回车键退出...",
+
+	"[ERROR] Cannot get current directory: %v\n":            "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Target is not a valid directory: %s\n":         "[ERROR] 目标不是有效的目录: %s\n",
+	"[ERROR] Unknown profile: %s\n":                         "[ERROR] 未知配置: %s\n",
+	"Available profiles: minimal, standard, detailed, full": "可用配置: minimal, standard, detailed, full",
+	"Target: %s\n":                           "目标: %s\n",
+	"Profile: %s\n":                          "配置: %s\n",
+	"Generating...":                          "正在生成...",
+	"[ERROR] Cannot write output file: %v\n": "[ERROR] 无法写入输出文件: %v\n",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
 // ============================================================
 // Entry Point
 // ============================================================
@@ -592,7 +1194,22 @@ func main() {
 	flag.BoolVar(&showCount, "show-count", false, "Show hidden item counts in ...")
 	flag.BoolVar(&ciMode, "ci", false, "CI mode (non-interactive, no prompts)")
 	flag.BoolVar(&interactive, "i", false, "Interactive mode with profile selection")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
 	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+	if plainMode {
+		useASCIITree()
+	}
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("generate_file_tree")
+		ciMode = true
+	}
 
 	// Interactive mode
 	if interactive {
@@ -608,8 +1225,9 @@ func main() {
 			var err error
 			targetDir, err = os.Getwd()
 			if err != nil {
-				fmt.Printf("[ERROR] Cannot get current directory: %v\n", err)
-				os.Exit(1)
+				fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+				recordError("cannot get current directory: %v", err)
+				exitTool(1)
 			}
 		}
 	}
@@ -617,11 +1235,12 @@ func main() {
 	// Validate target
 	info, err := os.Stat(targetDir)
 	if err != nil || !info.IsDir() {
-		fmt.Printf("[ERROR] Target is not a valid directory: %s\n", targetDir)
+		fmt.Printf(tr("[ERROR] Target is not a valid directory: %s\n"), targetDir)
+		recordError("target is not a valid directory: %s", targetDir)
 		if !ciMode {
 			waitForKeyPress()
 		}
-		os.Exit(1)
+		exitTool(1)
 	}
 
 	// Resolve absolute path
@@ -642,12 +1261,13 @@ func main() {
 	}
 	p, ok := findProfile(profileName)
 	if !ok {
-		fmt.Printf("[ERROR] Unknown profile: %s\n", profileName)
-		fmt.Println("Available profiles: minimal, standard, detailed, full")
+		fmt.Printf(tr("[ERROR] Unknown profile: %s\n"), profileName)
+		recordError("unknown profile: %s", profileName)
+		fmt.Println(tr("Available profiles: minimal, standard, detailed, full"))
 		if !ciMode {
 			waitForKeyPress()
 		}
-		os.Exit(1)
+		exitTool(1)
 	}
 
 	// Build config
@@ -655,23 +1275,27 @@ func main() {
 
 	// Generate
 	if !ciMode {
-		fmt.Printf("Target: %s\n", targetDir)
-		fmt.Printf("Profile: %s\n", profileName)
-		fmt.Println("Generating...")
+		fmt.Printf(tr("Target: %s\n"), targetDir)
+		fmt.Printf(tr("Profile: %s\n"), profileName)
+		fmt.Println(tr("Generating..."))
 	}
 
 	startTime := time.Now()
 	content, st := generate(cfg, profileName)
 
 	if err := writeOutput(content, cfg.outputFile); err != nil {
-		fmt.Printf("[ERROR] Cannot write output file: %v\n", err)
+		fmt.Printf(tr("[ERROR] Cannot write output file: %v\n"), err)
+		recordAction("write", cfg.outputFile, "failed", err.Error(), time.Since(startTime))
+		recordError("cannot write output file: %v", err)
 		if !ciMode {
 			waitForKeyPress()
 		}
-		os.Exit(1)
+		exitTool(1)
 	}
 
 	duration := time.Since(startTime)
+	recordAction("write", cfg.outputFile, "ok", fmt.Sprintf("%d directories, %d files", st.dirs, st.files), duration)
+	recordArtifact(cfg.outputFile)
 	printSummary(cfg.outputFile, profileName, st, duration, cfg)
 
 	if !ciMode {
@@ -0,0 +1,130 @@
+package main
+
+// Helpers for the golden tests of the tools. Each tool builds on its own, so this
+// file is passed next to the tool and its tests:
+//
+//	go test rename_project.go golden_helpers_test.go rename_project_test.go
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "Rewrite testdata/*.golden from the current output")
+
+// setupRun prepares a run of the tool inside the test: English plain output and
+// input for the prompts. fsys (a dry run swaps in an overlay) and the prompt
+// reader are restored when the test ends.
+func setupRun(t *testing.T, input string) {
+	t.Helper()
+	savedFS, savedReader := fsys, stdinReader
+	t.Cleanup(func() { fsys, stdinReader = savedFS, savedReader })
+	setLanguage("en")
+	plainMode = true
+	stdinReader = bufio.NewReader(strings.NewReader(input))
+}
+
+// copyFixture copies testdata/<name> into a temporary directory
+func copyFixture(t *testing.T, name string) string {
+	t.Helper()
+	src := filepath.Join("testdata", name)
+	dst := t.TempDir()
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), data, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
+
+// Dates and times in file names and contents change on every run
+var goldenTimestamp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[ _]\d{2}:?\d{2}:?\d{2}`)
+
+// assertGoldenTree lists every file under dir with its contents and compares the
+// listing to the golden file. Files under the volatile prefixes are listed
+// without contents. Run with -update to rewrite the golden file.
+func assertGoldenTree(t *testing.T, dir, golden string, volatile ...string) {
+	t.Helper()
+	var b strings.Builder
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		rel = filepath.ToSlash(rel)
+		for _, prefix := range volatile {
+			if strings.HasPrefix(rel, prefix) {
+				b.WriteString("== " + rel + " (not compared) ==\n")
+				return nil
+			}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		b.WriteString("== " + rel + " ==\n")
+		b.Write(data)
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			b.WriteString("\n")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := goldenTimestamp.ReplaceAllString(b.String(), "<time>")
+
+	if *updateGolden {
+		if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("tree differs from %s (run with -update to accept):\n--- got ---\n%s--- want ---\n%s", golden, got, want)
+	}
+}
+
+func writeTestFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertFileBytes(t *testing.T, path string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s:\ngot  %q\nwant %q", filepath.Base(path), got, want)
+	}
+}
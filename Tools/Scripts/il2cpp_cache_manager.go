@@ -0,0 +1,1478 @@
+// IL2CPP Build Cache Manager — Measure, prune, and relocate incremental player build caches.
+// Unlike unity_project_full_clean (which removes Library/ entirely), this tool only touches the
+// player build caches (Library/Bee, Library/Il2cppBuildCache, Library/PlayerDataCache) and
+// removes individual per-target entries by age/size policy, so the next build of the active
+// target stays incremental while disk use on build agents stays bounded.
+//
+// Build: go build il2cpp_cache_manager.go
+//
+// Usage: run from the Unity project root.
+//
+//	il2cpp_cache_manager                                  # measure only
+//	il2cpp_cache_manager -prune -max-age 14d              # drop entries unused for 14 days
+//	il2cpp_cache_manager -prune -max-size 30GB -ci        # LRU-trim to 30 GB total
+//	il2cpp_cache_manager -relocate D:/UnityCaches         # move caches, leave links behind
+//	il2cpp_cache_manager -prune -max-age 14d -json        # result document on stdout
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// cacheRoot describes a build cache folder and how it is split into prunable entries
+type cacheRoot struct {
+	name     string // display name
+	path     string // relative to project root
+	entryDir string // subfolder whose children are the prunable entries ("" = children of path)
+}
+
+// Player build caches. Each child folder is one build target / configuration,
+// e.g. Library/Bee/artifacts/Android, Library/Il2cppBuildCache/iOS.
+var cacheRoots = []cacheRoot{
+	{name: "Bee", path: filepath.Join("Library", "Bee"), entryDir: "artifacts"},
+	{name: "Il2cppBuildCache", path: filepath.Join("Library", "Il2cppBuildCache")},
+	{name: "PlayerDataCache", path: filepath.Join("Library", "PlayerDataCache")},
+}
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// EditorInstance represents the structure of Library/EditorInstance.json
+type EditorInstance struct {
+	ProcessID int `json:"process_id"`
+}
+
+// cacheEntry is one prunable unit inside a cache root
+type cacheEntry struct {
+	root     string    // cache root display name
+	path     string    // relative to project root
+	size     int64     // bytes
+	lastUsed time.Time // newest modification time found inside the entry
+	reason   string    // why it is selected for pruning ("" = kept)
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// checkUnityRunning checks if Unity Editor is running for this project
+// via Library/EditorInstance.json and a liveness check on the recorded PID.
+func checkUnityRunning(basePath string) (bool, int) {
+	data, err := os.ReadFile(filepath.Join(basePath, "Library", "EditorInstance.json"))
+	if err != nil {
+		return false, 0
+	}
+	var instance EditorInstance
+	if err := json.Unmarshal(data, &instance); err != nil || instance.ProcessID <= 0 {
+		return false, 0
+	}
+	if isProcessRunning(instance.ProcessID) {
+		return true, instance.ProcessID
+	}
+	return false, 0
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// ============================================================
+// Measurement
+// ============================================================
+
+// measureDir returns the total size and newest modification time under path.
+// Symlinks/junctions created by -relocate are followed at the top level only.
+func measureDir(path string) (int64, time.Time) {
+	var size int64
+	var newest time.Time
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, newest
+}
+
+// collectEntries lists every prunable entry of every cache root that exists
+func collectEntries(basePath string) []cacheEntry {
+	var entries []cacheEntry
+	for _, root := range cacheRoots {
+		dir := filepath.Join(basePath, root.path, root.entryDir)
+		children, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, child := range children {
+			if !child.IsDir() {
+				continue
+			}
+			rel := filepath.Join(root.path, root.entryDir, child.Name())
+			size, lastUsed := measureDir(filepath.Join(basePath, rel))
+			entries = append(entries, cacheEntry{root: root.name, path: rel, size: size, lastUsed: lastUsed})
+		}
+	}
+	return entries
+}
+
+// totalSize sums the size of all entries not yet selected for pruning
+func totalSize(entries []cacheEntry) int64 {
+	var total int64
+	for _, e := range entries {
+		if e.reason == "" {
+			total += e.size
+		}
+	}
+	return total
+}
+
+// ============================================================
+// Prune Policy
+// ============================================================
+
+// applyPolicy marks entries for removal. Entries older than maxAge go first;
+// then, while the remaining total exceeds maxSize, the least recently used
+// entries are removed. The `keep` most recently used entries per cache root
+// are never touched so the active build target stays incremental.
+func applyPolicy(entries []cacheEntry, maxAge time.Duration, maxSize int64, keep int) {
+	// Most recently used first
+	sort.Slice(entries, func(i, j int) bool { return entries[i].lastUsed.After(entries[j].lastUsed) })
+
+	protected := make(map[int]bool)
+	perRoot := make(map[string]int)
+	for i, e := range entries {
+		if perRoot[e.root] < keep {
+			protected[i] = true
+			perRoot[e.root]++
+		}
+	}
+
+	if maxAge > 0 {
+		cutoff := time.Now().Add(-maxAge)
+		for i := range entries {
+			if !protected[i] && entries[i].lastUsed.Before(cutoff) {
+				entries[i].reason = fmt.Sprintf(tr("unused for %s"), formatAge(entries[i].lastUsed))
+			}
+		}
+	}
+
+	if maxSize > 0 {
+		for i := len(entries) - 1; i >= 0 && totalSize(entries) > maxSize; i-- {
+			if !protected[i] && entries[i].reason == "" {
+				entries[i].reason = tr("over size budget (LRU)")
+			}
+		}
+	}
+}
+
+// ============================================================
+// Relocation
+// ============================================================
+
+// isLink reports whether path is a symlink or (on Windows) a directory junction
+func isLink(path string) bool {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return true
+	}
+	// Junctions report as irregular/reparse points on Windows
+	if runtime.GOOS == "windows" {
+		target, err := os.Readlink(path)
+		return err == nil && target != ""
+	}
+	return false
+}
+
+// createLink points linkPath at target. Junctions are used on Windows so
+// no administrator rights or Developer Mode are required.
+func createLink(target, linkPath string) error {
+	if overlay, ok := fsys.(*overlayFS); ok {
+		if runtime.GOOS == "windows" {
+			overlay.noteCommand("mklink", "/J", linkPath, target)
+		} else {
+			overlay.noteCommand("ln", "-s", target, linkPath)
+		}
+		return nil
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("cmd", "/c", "mklink", "/J", linkPath, target).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("mklink failed: %v (%s)", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	return os.Symlink(target, linkPath)
+}
+
+// moveDir renames src to dst, falling back to copy + delete across volumes
+func moveDir(src, dst string) error {
+	if err := fsys.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := fsys.Rename(src, dst); err == nil {
+		return nil
+	}
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		out := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(out, info.Mode().Perm()|0700)
+		}
+		return copyFile(path, out, info.Mode())
+	})
+	if err != nil {
+		return fmt.Errorf("copy to %s failed: %v", dst, err)
+	}
+	return fsys.RemoveAll(src)
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm()|0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// relocateCaches moves each cache root to dest/<project>/<name> and links it back
+func relocateCaches(basePath, dest string) (moved int, failed int) {
+	projectKey := filepath.Base(basePath)
+	for _, root := range cacheRoots {
+		src := filepath.Join(basePath, root.path)
+		if _, err := os.Stat(src); err != nil {
+			fmt.Printf(tr("[--]   %-18s not present, skipped\n"), root.name)
+			continue
+		}
+		if isLink(src) {
+			target, _ := os.Readlink(src)
+			fmt.Printf(tr("[--]   %-18s already relocated -> %s\n"), root.name, target)
+			continue
+		}
+		dst := filepath.Join(dest, projectKey, root.name)
+		if _, err := fsys.Stat(dst); err == nil {
+			fmt.Printf(tr("[FAIL] %-18s destination already exists: %s\n"), root.name, dst)
+			recordAction("relocate", root.path, "failed", "destination already exists: "+dst, 0)
+			failed++
+			continue
+		}
+		started := time.Now()
+		if err := moveDir(src, dst); err != nil {
+			fmt.Printf("[FAIL] %-18s %v\n", root.name, err)
+			recordAction("relocate", root.path, "failed", err.Error(), time.Since(started))
+			failed++
+			continue
+		}
+		if err := createLink(dst, src); err != nil {
+			// Put the cache back rather than leaving the project without it
+			moveDir(dst, src)
+			fmt.Printf(tr("[FAIL] %-18s %v (cache restored)\n"), root.name, err)
+			recordAction("relocate", root.path, "failed", err.Error()+" (cache restored)", time.Since(started))
+			failed++
+			continue
+		}
+		fmt.Printf("[OK]   %-18s %s -> %s\n", root.name, root.path, dst)
+		recordAction("relocate", root.path, "ok", dst, time.Since(started))
+		moved++
+	}
+	return moved, failed
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+// formatAge renders the time since t as "3d 4h" / "5h 12m"
+func formatAge(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	d := time.Since(t)
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh", days, hours)
+	}
+	return fmt.Sprintf("%dh %dm", hours, int(d.Minutes())%60)
+}
+
+// parseAge accepts Go durations plus a "d" (days) suffix, e.g. "14d", "36h"
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age: %s", s)
+		}
+		return time.Duration(days * 24 * float64(time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+var sizeRegex = regexp.MustCompile(`(?i)^([\d.]+)\s*(b|kb|mb|gb|tb)?$`)
+
+// parseSize accepts "500MB", "30GB", "1.5tb" or plain bytes
+func parseSize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	m := sizeRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+	v, _ := strconv.ParseFloat(m[1], 64)
+	switch strings.ToLower(m[2]) {
+	case "kb":
+		v *= 1 << 10
+	case "mb":
+		v *= 1 << 20
+	case "gb":
+		v *= 1 << 30
+	case "tb":
+		v *= 1 << 40
+	}
+	return int64(v), nil
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Filesystem
+// ============================================================
+
+// fileSystem is every filesystem call that can change the project. The real
+// run uses osFS; -dry-run swaps in an overlayFS so the same code path runs
+// and the overlay reports what it would have changed.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Walk(root string, fn filepath.WalkFunc) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Chmod(name string, mode os.FileMode) error
+}
+
+var fsys fileSystem = osFS{}
+
+// isDryRun reports whether changes are going to the in-memory overlay
+func isDryRun() bool {
+	_, ok := fsys.(*overlayFS)
+	return ok
+}
+
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)         { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) Walk(root string, fn filepath.WalkFunc) error { return filepath.Walk(root, fn) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// fsChange is one change the overlay absorbed instead of the disk
+type fsChange struct {
+	op     string // "write", "create", "mkdir", "rename", "delete"
+	path   string
+	detail string
+}
+
+// overlayFS reads through to the disk and keeps every write, rename and
+// delete in memory. Later reads see those changes, so multi-step operations
+// (rename a folder, then rewrite files inside it) behave as they would for real.
+type overlayFS struct {
+	mu      sync.Mutex
+	files   map[string][]byte // files written during the run
+	dirs    map[string]bool   // directories created during the run
+	moved   map[string]string // renamed path -> where its content is on disk
+	removed map[string]bool   // deleted paths; hides everything below them
+	changes []fsChange
+}
+
+func newOverlayFS() *overlayFS {
+	return &overlayFS{
+		files:   map[string][]byte{},
+		dirs:    map[string]bool{},
+		moved:   map[string]string{},
+		removed: map[string]bool{},
+	}
+}
+
+func overlayKey(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// isUnder reports whether p is base or inside it
+func isUnder(p, base string) bool {
+	return p == base || strings.HasPrefix(p, strings.TrimSuffix(base, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// locate finds where the content of p lives: in memory (data / memDir) or
+// on disk (disk, after following renames). ok is false if p was deleted.
+func (o *overlayFS) locate(p string) (data []byte, memFile, memDir bool, disk string, ok bool) {
+	if d, found := o.files[p]; found {
+		return d, true, false, "", true
+	}
+	if o.dirs[p] {
+		return nil, false, true, "", true
+	}
+	for q := p; ; {
+		if src, found := o.moved[q]; found {
+			rel, _ := filepath.Rel(q, p)
+			return nil, false, false, filepath.Join(src, rel), true
+		}
+		if o.removed[q] {
+			return nil, false, false, "", false
+		}
+		parent := filepath.Dir(q)
+		if parent == q {
+			break
+		}
+		q = parent
+	}
+	return nil, false, false, p, true
+}
+
+func (o *overlayFS) stat(p string) (os.FileInfo, error) {
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	case memFile:
+		return memFileInfo{name: filepath.Base(p), size: int64(len(data))}, nil
+	case memDir:
+		return memFileInfo{name: filepath.Base(p), dir: true}, nil
+	}
+	info, err := os.Stat(disk)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	if disk != p {
+		return renamedFileInfo{info, filepath.Base(p)}, nil
+	}
+	return info, nil
+}
+
+func (o *overlayFS) Stat(name string) (os.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stat(overlayKey(name))
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case memDir:
+		return nil, &os.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	case memFile:
+		return append([]byte(nil), data...), nil
+	}
+	return os.ReadFile(disk)
+}
+
+func (o *overlayFS) readDir(p string) ([]os.DirEntry, error) {
+	_, memFile, memDir, disk, ok := o.locate(p)
+	if !ok || memFile {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+	names := map[string]bool{}
+	if !memDir {
+		entries, err := os.ReadDir(disk)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			names[e.Name()] = true
+		}
+	}
+	for k := range o.dirs {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.files {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.moved {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	var result []os.DirEntry
+	for name := range names {
+		if info, err := o.stat(filepath.Join(p, name)); err == nil {
+			result = append(result, fs.FileInfoToDirEntry(info))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (o *overlayFS) ReadDir(name string) ([]os.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readDir(overlayKey(name))
+}
+
+// Walk follows filepath.Walk: lexical order, SkipDir and SkipAll honored.
+// The lock is released while fn runs so the callback can change the overlay.
+func (o *overlayFS) Walk(root string, fn filepath.WalkFunc) error {
+	info, err := o.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = o.walk(root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (o *overlayFS) walk(path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	entries, err := o.ReadDir(path)
+	if err1 := fn(path, info, err); err != nil || err1 != nil {
+		return err1
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		childInfo, err := o.Stat(child)
+		if err != nil {
+			if err := fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := o.walk(child, childInfo, fn); err != nil {
+			if err == filepath.SkipDir && childInfo.IsDir() {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *overlayFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	op := "create"
+	if info, err := o.stat(p); err == nil {
+		if info.IsDir() {
+			return &os.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+		}
+		op = "write"
+	}
+	if info, err := o.stat(filepath.Dir(p)); err != nil || !info.IsDir() {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	o.files[p] = append([]byte(nil), data...)
+	o.changes = append(o.changes, fsChange{op, p, fmt.Sprintf("%d bytes", len(data))})
+	return nil
+}
+
+func (o *overlayFS) MkdirAll(path string, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	created := false
+	for q := p; ; q = filepath.Dir(q) {
+		if info, err := o.stat(q); err == nil {
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: q, Err: errors.New("not a directory")}
+			}
+			break
+		}
+		o.dirs[q] = true
+		created = true
+		if filepath.Dir(q) == q {
+			break
+		}
+	}
+	if created {
+		o.changes = append(o.changes, fsChange{"mkdir", p, ""})
+	}
+	return nil
+}
+
+// shift moves every overlay entry under from to the same place under to
+func (o *overlayFS) shift(from, to string) {
+	for k, v := range o.files {
+		if isUnder(k, from) {
+			delete(o.files, k)
+			o.files[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, from) {
+			delete(o.dirs, k)
+			o.dirs[to+strings.TrimPrefix(k, from)] = true
+		}
+	}
+	for k, v := range o.moved {
+		if isUnder(k, from) {
+			delete(o.moved, k)
+			o.moved[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+}
+
+func (o *overlayFS) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	from, to := overlayKey(oldpath), overlayKey(newpath)
+	_, _, _, disk, ok := o.locate(from)
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if from == to {
+		return nil
+	}
+	if info, err := o.stat(filepath.Dir(to)); err != nil || !info.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	o.drop(to)
+	o.shift(from, to)
+	if disk != "" {
+		if _, err := os.Stat(disk); err == nil {
+			o.moved[to] = disk
+		}
+	}
+	o.removed[from] = true
+	o.changes = append(o.changes, fsChange{"rename", from, to})
+	return nil
+}
+
+// drop forgets p and everything below it and hides it from the disk
+func (o *overlayFS) drop(p string) {
+	for k := range o.files {
+		if isUnder(k, p) {
+			delete(o.files, k)
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, p) {
+			delete(o.dirs, k)
+		}
+	}
+	for k := range o.moved {
+		if isUnder(k, p) {
+			delete(o.moved, k)
+		}
+	}
+	o.removed[p] = true
+}
+
+// treeSize is the byte size of p as the overlay currently sees it
+func (o *overlayFS) treeSize(p string) int64 {
+	info, err := o.stat(p)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var size int64
+	entries, _ := o.readDir(p)
+	for _, e := range entries {
+		size += o.treeSize(filepath.Join(p, e.Name()))
+	}
+	return size
+}
+
+func (o *overlayFS) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	info, err := o.stat(p)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	if info.IsDir() {
+		if entries, _ := o.readDir(p); len(entries) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", info.Size())})
+	return nil
+}
+
+func (o *overlayFS) RemoveAll(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	if _, err := o.stat(p); err != nil {
+		return nil
+	}
+	size := o.treeSize(p)
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", size)})
+	return nil
+}
+
+func (o *overlayFS) Chmod(name string, mode os.FileMode) error {
+	if _, err := o.Stat(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+// noteCommand lists a step the overlay cannot simulate, such as creating a
+// symlink or junction
+func (o *overlayFS) noteCommand(args ...string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.changes = append(o.changes, fsChange{"run", strings.Join(args, " "), ""})
+}
+
+// printDryRunReport lists what the overlay absorbed, relative to base
+func (o *overlayFS) printDryRunReport(base string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if abs, err := filepath.Abs(base); err == nil {
+		base = abs
+	}
+	if len(o.changes) == 0 {
+		fmt.Println(tr("\n[Dry Run] No changes would be made."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d change(s) would be made; nothing was written:\n"), len(o.changes))
+	rel := func(p string) string {
+		if r, err := filepath.Rel(base, p); err == nil && !strings.HasPrefix(r, "..") {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	for _, c := range o.changes {
+		switch c.op {
+		case "rename":
+			fmt.Printf("  %-7s %s -> %s\n", c.op, rel(c.path), rel(c.detail))
+		case "mkdir", "run":
+			fmt.Printf("  %-7s %s\n", c.op, rel(c.path))
+		default:
+			fmt.Printf("  %-7s %s (%s)\n", c.op, rel(c.path), c.detail)
+		}
+	}
+}
+
+// memFileInfo describes a file or directory that only exists in the overlay
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (m memFileInfo) Name() string       { return m.name }
+func (m memFileInfo) Size() int64        { return m.size }
+func (m memFileInfo) ModTime() time.Time { return time.Time{} }
+func (m memFileInfo) IsDir() bool        { return m.dir }
+func (m memFileInfo) Sys() interface{}   { return nil }
+func (m memFileInfo) Mode() os.FileMode {
+	if m.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// renamedFileInfo is a disk entry seen under the name it was renamed to
+type renamedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (r renamedFileInfo) Name() string { return r.name }
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"unused for %s":                                 "已 %s 未使用",
+	"over size budget (LRU)":                        "超出容量预算 (LRU)",
+	"[--]   %-18s not present, skipped\n":           "[--]   %-18s 不存在，已跳过\n",
+	"[--]   %-18s already relocated -> %s\n":        "[--]   %-18s 已迁移 -> %s\n",
+	"[FAIL] %-18s destination already exists: %s\n": "[FAIL] %-18s 目标已存在: %s\n",
+	"[FAIL] %-18s %v (cache restored)\n":            "[FAIL] %-18s %v (缓存已还原)\n",
+	"\nPress Enter to continue...":                  "\n按回车键继续...",
+
+	"[ERROR] -prune needs -max-age and/or -max-size.":                                              "[ERROR] -prune 需要配合 -max-age 和/或 -max-size。",
+	"[ERROR] Cannot get current directory: %v\n":                                                   "[ERROR] 无法获取当前目录: %v\n",
+	"  IL2CPP Build Cache Manager":                                                                 "  IL2CPP 构建缓存管理",
+	"Target: %s\n":                                                                                 "目标: %s\n",
+	"[Dry Run] Nothing will be changed":                                                            "[Dry Run] 不会做任何修改",
+	"\n[ERROR] Current directory does not appear to be a Unity project.":                           "\n[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                                       "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"\n[ERROR] Unity Editor is running (PID: %d). Close it before pruning or relocating caches.\n": "\n[ERROR] Unity 编辑器正在运行 (PID: %d)。请先关闭编辑器再清理或迁移缓存。\n",
+	"\nScanning build caches...":                                                                   "\n正在扫描构建缓存...",
+	"\nNo player build caches found. Nothing to do.":                                               "\n未找到播放器构建缓存，无需处理。",
+	"ENTRY":                                  "条目",
+	"SIZE":                                   "大小",
+	"LAST USED":                              "最近使用",
+	"  %-7s %-51s %12s  %s ago":              "  %-7s %-51s %12s  %s 前",
+	"\nTotal cache size: %s in %d entries\n": "\n缓存总大小: %s，共 %d 个条目\n",
+	"  %s is relocated -> %s\n":              "  %s 已迁移 -> %s\n",
+	"Selected for pruning: %d entries, %s (remaining: %s)\n": "待清理: %d 个条目，%s (剩余: %s)\n",
+	"\nProceed with pruning? (y/N): ":                        "\n是否继续清理？(y/N): ",
+	"Operation cancelled.":                                   "操作已取消。",
+	"[OK]   Pruned %s (%s)\n":                                "[OK]   已清理 %s (%s)\n",
+	"  PRUNE COMPLETE":                                       "  清理完成",
+	"  Freed:     %s\n":                                      "  释放空间:  %s\n",
+	"  Remaining: %s\n":                                      "  剩余:      %s\n",
+	"  Failed:    %d entries\n":                              "  失败:      %d 个条目\n",
+	"[ERROR] Invalid -relocate path: %v\n":                   "[ERROR] 无效的 -relocate 路径: %v\n",
+	"\nRelocating caches to %s\n":                            "\n正在将缓存迁移到 %s\n",
+	"Proceed? (y/N): ":                                       "是否继续？(y/N): ",
+	"\nRelocated %d cache(s)":                                "\n已迁移 %d 个缓存",
+	", %d failed":                                            "，%d 个失败",
+
+	"\n[Dry Run] No changes would be made.":                          "\n[Dry Run] 不会做任何更改。",
+	"\n[Dry Run] %d change(s) would be made; nothing was written:\n": "\n[Dry Run] 将会进行 %d 项更改，未写入任何内容:\n",
+
+	"[WARNING] Removing a stale %s (%s)\n":                      "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n": "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	if status == "ok" && isDryRun() {
+		status = "planned"
+	}
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode   bool
+		dryRun   bool
+		force    bool
+		prune    bool
+		maxAgeS  string
+		maxSizeS string
+		keep     int
+		relocate string
+	)
+
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no confirmation prompts)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Show what would be pruned/moved without changing anything")
+	flag.BoolVar(&force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
+	flag.BoolVar(&prune, "prune", false, "Remove cache entries selected by -max-age / -max-size")
+	flag.StringVar(&maxAgeS, "max-age", "", "Prune entries not used within this period (e.g. 14d, 72h)")
+	flag.StringVar(&maxSizeS, "max-size", "", "Keep total cache size under this budget (e.g. 30GB), LRU first")
+	flag.IntVar(&keep, "keep", 1, "Always keep this many most recently used entries per cache")
+	flag.StringVar(&relocate, "relocate", "", "Move caches to this directory and link them back into Library/")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("il2cpp_cache_manager")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if overlay, ok := fsys.(*overlayFS); ok {
+			cwd, _ := os.Getwd()
+			overlay.printDryRunReport(cwd)
+		}
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+
+	maxAge, err := parseAge(maxAgeS)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		recordError("%v", err)
+		exit(1)
+	}
+	maxSize, err := parseSize(maxSizeS)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		recordError("%v", err)
+		exit(1)
+	}
+	if prune && maxAge == 0 && maxSize == 0 {
+		fmt.Println(tr("[ERROR] -prune needs -max-age and/or -max-size."))
+		recordError("-prune needs -max-age and/or -max-size")
+		exit(1)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  IL2CPP Build Cache Manager"))
+	printRule("=============================================")
+	fmt.Printf(tr("Target: %s\n"), basePath)
+	if dryRun {
+		fmt.Println(tr("[Dry Run] Nothing will be changed"))
+		fsys = newOverlayFS()
+	}
+
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("\n[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+
+	modifying := (prune || relocate != "") && !dryRun
+	if modifying {
+		if isRunning, pid := checkUnityRunning(basePath); isRunning {
+			fmt.Printf(tr("\n[ERROR] Unity Editor is running (PID: %d). Close it before pruning or relocating caches.\n"), pid)
+			recordError("Unity Editor is running (PID: %d). Close it before pruning or relocating caches", pid)
+			exit(1)
+		}
+		operation := "prune"
+		if relocate != "" {
+			operation = "relocate"
+		}
+		if err := acquireProjectLock(basePath, "il2cpp_cache_manager", operation, force); err != nil {
+			fmt.Printf(tr("\n[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exit(1)
+		}
+		defer releaseProjectLock()
+	}
+
+	// Measure
+	fmt.Println(tr("\nScanning build caches..."))
+	entries := collectEntries(basePath)
+	if len(entries) == 0 {
+		fmt.Println(tr("\nNo player build caches found. Nothing to do."))
+		if relocate == "" {
+			exit(0)
+		}
+	}
+
+	if prune {
+		applyPolicy(entries, maxAge, maxSize, keep)
+	} else {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].lastUsed.After(entries[j].lastUsed) })
+	}
+
+	var total, selected int64
+	fmt.Println()
+	fmt.Printf("  %-7s %-51s %12s  %s\n", "", tr("ENTRY"), tr("SIZE"), tr("LAST USED"))
+	for _, e := range entries {
+		mark := "[KEEP]"
+		if e.reason != "" {
+			mark = "[PRUNE]"
+			selected += e.size
+		}
+		if !prune {
+			mark = ""
+		}
+		fmt.Printf(tr("  %-7s %-51s %12s  %s ago"), mark, filepath.ToSlash(e.path), formatSize(e.size), formatAge(e.lastUsed))
+		if e.reason != "" {
+			fmt.Printf("  (%s)", e.reason)
+		}
+		fmt.Println()
+		total += e.size
+	}
+	fmt.Printf(tr("\nTotal cache size: %s in %d entries\n"), formatSize(total), len(entries))
+	for _, root := range cacheRoots {
+		if p := filepath.Join(basePath, root.path); isLink(p) {
+			target, _ := os.Readlink(p)
+			fmt.Printf(tr("  %s is relocated -> %s\n"), root.name, target)
+		}
+	}
+
+	// Prune
+	if prune {
+		var targets []cacheEntry
+		for _, e := range entries {
+			if e.reason != "" {
+				targets = append(targets, e)
+			}
+		}
+		fmt.Printf(tr("Selected for pruning: %d entries, %s (remaining: %s)\n"), len(targets), formatSize(selected), formatSize(total-selected))
+		if len(targets) > 0 {
+			if !ciMode && !dryRun {
+				fmt.Print(tr("\nProceed with pruning? (y/N): "))
+				confirm, _ := stdinReader.ReadString('\n')
+				if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+					fmt.Println(tr("Operation cancelled."))
+					exit(0)
+				}
+			}
+			var freed int64
+			var failed int
+			for _, t := range targets {
+				started := time.Now()
+				if err := fsys.RemoveAll(filepath.Join(basePath, t.path)); err != nil {
+					fmt.Printf("[FAIL] %s: %v\n", filepath.ToSlash(t.path), err)
+					recordAction("prune", filepath.ToSlash(t.path), "failed", err.Error(), time.Since(started))
+					failed++
+					continue
+				}
+				fmt.Printf(tr("[OK]   Pruned %s (%s)\n"), filepath.ToSlash(t.path), formatSize(t.size))
+				recordAction("prune", filepath.ToSlash(t.path), "ok", formatSize(t.size), time.Since(started))
+				freed += t.size
+			}
+
+			printRule("\n===========================================")
+			fmt.Println(tr("  PRUNE COMPLETE"))
+			printRule("===========================================")
+			fmt.Printf(tr("  Freed:     %s\n"), formatSize(freed))
+			fmt.Printf(tr("  Remaining: %s\n"), formatSize(total-freed))
+			if failed > 0 {
+				fmt.Printf(tr("  Failed:    %d entries\n"), failed)
+				exit(1)
+			}
+		}
+	}
+
+	// Relocate
+	if relocate != "" {
+		dest, err := filepath.Abs(relocate)
+		if err != nil {
+			fmt.Printf(tr("[ERROR] Invalid -relocate path: %v\n"), err)
+			recordError("invalid -relocate path: %v", err)
+			exit(1)
+		}
+		fmt.Printf(tr("\nRelocating caches to %s\n"), dest)
+		if !dryRun && !ciMode {
+			fmt.Print(tr("Proceed? (y/N): "))
+			confirm, _ := stdinReader.ReadString('\n')
+			if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+				fmt.Println(tr("Operation cancelled."))
+				exit(0)
+			}
+		}
+		moved, failed := relocateCaches(basePath, dest)
+		fmt.Printf(tr("\nRelocated %d cache(s)"), moved)
+		if failed > 0 {
+			fmt.Printf(tr(", %d failed"), failed)
+		}
+		fmt.Println()
+		if failed > 0 {
+			exit(1)
+		}
+	}
+
+	exit(0)
+}
@@ -0,0 +1,1788 @@
+// Lighting Cache Manager — Measure and clean the GI cache and baked lighting data.
+// unity_project_full_clean removes Library/ as a whole, which throws away far more than
+// lighting iteration needs. This tool only touches lighting: the editor's GI cache
+// (shared by every project on the machine), the project's Library/BakedLighting, and
+// the baked data next to each scene (LightingData.asset, lightmaps, reflection probes),
+// reporting the size of each and keeping the bakes of the scenes you name.
+//
+// Build: go build lighting_cache_manager.go
+//
+// Usage: run from the Unity project root.
+//
+//	lighting_cache_manager                                     # measure only
+//	lighting_cache_manager -clean-gicache                      # empty the GI cache
+//	lighting_cache_manager -gicache-max-size 20GB              # trim the GI cache, oldest first
+//	lighting_cache_manager -clean-bakes -keep-scenes "Assets/Scenes/Main.unity"
+//	lighting_cache_manager -clean-bakes -scenes "Assets/Levels/**" -dry-run
+//	lighting_cache_manager -orphans                            # bakes no scene uses any more
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Project-local bake intermediates; regenerated by the next bake
+var bakedLightingCache = filepath.Join("Library", "BakedLighting")
+
+// Files Unity writes into a scene's lighting folder when baking. Only these are
+// removed; other assets a team keeps in the folder stay.
+var bakeFileRegex = regexp.MustCompile(`(?i)^(.*LightingData\.asset|Lightmap-\d+_comp_[a-z]+\.(exr|png|tga|hdr)|ReflectionProbe-\d+\.(exr|png|hdr))$`)
+
+// The scene's reference to its lighting data, in the LightmapSettings object
+var lightingDataRefRegex = regexp.MustCompile(`(m_LightingDataAsset:\s*)\{fileID:\s*-?\d+,\s*guid:\s*([0-9a-fA-F]{32}),\s*type:\s*\d+\}`)
+
+var metaGUIDRegex = regexp.MustCompile(`(?m)^guid:\s*([0-9a-fA-F]{32})`)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// EditorInstance represents the structure of Library/EditorInstance.json
+type EditorInstance struct {
+	ProcessID int `json:"process_id"`
+}
+
+// bakeSet is the baked lighting in one folder: a LightingData.asset and the
+// lightmaps and probes baked with it. Multi-scene bakes share one set.
+type bakeSet struct {
+	folder    string    // relative to project root, slash-separated
+	dataAsset string    // the LightingData.asset
+	guid      string    // of dataAsset, as referenced by the scenes
+	scenes    []string  // scenes referencing dataAsset; empty = orphaned
+	files     []string  // bake files with their .meta, relative to project root
+	size      int64     // bytes
+	baked     time.Time // modification time of dataAsset
+	reason    string    // why it is selected for cleaning ("" = kept)
+}
+
+// cacheFile is one file of the GI cache, for the size budget
+type cacheFile struct {
+	path string
+	size int64
+	used time.Time
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// checkUnityRunning checks if Unity Editor is running for this project
+// via Library/EditorInstance.json and a liveness check on the recorded PID.
+func checkUnityRunning(basePath string) (bool, int) {
+	data, err := os.ReadFile(filepath.Join(basePath, "Library", "EditorInstance.json"))
+	if err != nil {
+		return false, 0
+	}
+	var instance EditorInstance
+	if err := json.Unmarshal(data, &instance); err != nil || instance.ProcessID <= 0 {
+		return false, 0
+	}
+	if isProcessRunning(instance.ProcessID) {
+		return true, instance.ProcessID
+	}
+	return false, 0
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// ============================================================
+// GI Cache
+// ============================================================
+
+// defaultGICachePath returns the editor's default GI cache folder for the
+// current OS. A custom location set in Preferences > GI Cache is passed with -gicache.
+func defaultGICachePath() string {
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		if local := os.Getenv("LOCALAPPDATA"); local != "" {
+			return filepath.Join(local, "Unity", "Caches", "GiCache")
+		}
+		return filepath.Join(home, "AppData", "Local", "Unity", "Caches", "GiCache")
+	case "darwin":
+		return filepath.Join(home, "Library", "Caches", "com.unity3d.UnityEditor", "GiCache")
+	default:
+		return filepath.Join(home, ".cache", "unity3d", "GiCache")
+	}
+}
+
+// listCacheFiles returns every file below dir, least recently used first
+func listCacheFiles(dir string) []cacheFile {
+	var files []cacheFile
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		files = append(files, cacheFile{path: path, size: info.Size(), used: info.ModTime()})
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].used.Before(files[j].used) })
+	return files
+}
+
+// measureDir returns the total size and file count under path
+func measureDir(path string) (int64, int) {
+	var size int64
+	var count int
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+			count++
+		}
+		return nil
+	})
+	return size, count
+}
+
+// trimCache selects the oldest files until the rest fits maxSize; maxSize 0
+// selects everything
+func trimCache(files []cacheFile, maxSize int64) ([]cacheFile, int64) {
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	var selected []cacheFile
+	var freed int64
+	for _, f := range files {
+		if maxSize > 0 && total-freed <= maxSize {
+			break
+		}
+		selected = append(selected, f)
+		freed += f.size
+	}
+	return selected, freed
+}
+
+// removeCacheFiles deletes the selected GI cache files, then the folders
+// left empty (the cache root itself stays)
+func removeCacheFiles(root string, files []cacheFile) (int64, int) {
+	var freed int64
+	var failed int
+	dirs := make(map[string]bool)
+	for _, f := range files {
+		if err := fsys.Remove(f.path); err != nil {
+			failed++
+			continue
+		}
+		freed += f.size
+		for dir := filepath.Dir(f.path); isUnder(dir, root) && dir != root; dir = filepath.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+	// Deepest first, so parents are empty by the time they come up
+	sorted := make([]string, 0, len(dirs))
+	for d := range dirs {
+		sorted = append(sorted, d)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, d := range sorted {
+		if entries, err := fsys.ReadDir(d); err == nil && len(entries) == 0 {
+			fsys.Remove(d)
+		}
+	}
+	return freed, failed
+}
+
+// ============================================================
+// Baked Lighting
+// ============================================================
+
+// readMetaGUID returns the guid stored in <path>.meta
+func readMetaGUID(path string) (string, error) {
+	data, err := os.ReadFile(path + ".meta")
+	if err != nil {
+		return "", err
+	}
+	m := metaGUIDRegex.FindSubmatch(data)
+	if m == nil {
+		return "", fmt.Errorf("no guid in %s.meta", filepath.Base(path))
+	}
+	return strings.ToLower(string(m[1])), nil
+}
+
+// collectBakeSets finds every LightingData.asset under Assets/, the bake files
+// next to it, and the scenes that reference it
+func collectBakeSets(basePath string) []*bakeSet {
+	byFolder := make(map[string]*bakeSet)
+	byGUID := make(map[string]*bakeSet)
+	var scenes []string
+	filepath.Walk(filepath.Join(basePath, "Assets"), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(basePath, path)
+		rel = filepath.ToSlash(rel)
+		if strings.HasSuffix(rel, ".unity") {
+			scenes = append(scenes, rel)
+			return nil
+		}
+		if !strings.HasSuffix(rel, "LightingData.asset") {
+			return nil
+		}
+		folder := filepath.ToSlash(filepath.Dir(rel))
+		if _, seen := byFolder[folder]; seen {
+			return nil
+		}
+		set := &bakeSet{folder: folder, dataAsset: rel, baked: info.ModTime()}
+		set.guid, _ = readMetaGUID(path)
+		byFolder[folder] = set
+		if set.guid != "" {
+			byGUID[set.guid] = set
+		}
+		return nil
+	})
+
+	for _, set := range byFolder {
+		entries, err := os.ReadDir(filepath.Join(basePath, filepath.FromSlash(set.folder)))
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() || !bakeFileRegex.MatchString(strings.TrimSuffix(e.Name(), ".meta")) {
+				continue
+			}
+			if info, err := e.Info(); err == nil {
+				set.files = append(set.files, set.folder+"/"+e.Name())
+				set.size += info.Size()
+			}
+		}
+	}
+
+	for _, scene := range scenes {
+		data, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(scene)))
+		if err != nil {
+			continue
+		}
+		if m := lightingDataRefRegex.FindSubmatch(data); m != nil {
+			if set := byGUID[strings.ToLower(string(m[2]))]; set != nil {
+				set.scenes = append(set.scenes, scene)
+			}
+		}
+	}
+
+	sets := make([]*bakeSet, 0, len(byFolder))
+	for _, set := range byFolder {
+		sets = append(sets, set)
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].folder < sets[j].folder })
+	return sets
+}
+
+// selectBakeSets marks the sets to clean. With orphansOnly only sets no scene
+// references are picked; otherwise the sets of the scenes matching targets (all
+// scenes, plus orphans, when targets is empty). A set used by a scene matching
+// keep is never picked, even when another of its scenes is targeted.
+func selectBakeSets(sets []*bakeSet, targets, keep []*regexp.Regexp, orphansOnly bool) {
+	for _, set := range sets {
+		if matchesAnyScene(keep, set.scenes) {
+			continue
+		}
+		switch {
+		case len(set.scenes) == 0:
+			if orphansOnly || len(targets) == 0 {
+				set.reason = tr("no scene uses it")
+			}
+		case orphansOnly:
+		case len(targets) == 0 || matchesAnyScene(targets, set.scenes):
+			set.reason = tr("scene selected")
+		}
+	}
+}
+
+func matchesAnyScene(globs []*regexp.Regexp, scenes []string) bool {
+	for _, s := range scenes {
+		if matchesAny(globs, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanBakeSet deletes the bake files of a set, the folder if nothing else is
+// left in it, and clears the reference in each scene so Unity shows the scene
+// as not baked instead of a missing asset
+func cleanBakeSet(basePath string, set *bakeSet) error {
+	for _, rel := range set.files {
+		path := filepath.Join(basePath, filepath.FromSlash(rel))
+		activeVCS.checkout(path)
+		if err := fsys.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	folder := filepath.Join(basePath, filepath.FromSlash(set.folder))
+	if entries, err := fsys.ReadDir(folder); err == nil && len(entries) == 0 {
+		if err := fsys.Remove(folder); err == nil {
+			activeVCS.checkout(folder + ".meta")
+			fsys.Remove(folder + ".meta")
+		}
+	}
+
+	for _, scene := range set.scenes {
+		path := filepath.Join(basePath, filepath.FromSlash(scene))
+		data, err := fsys.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		updated := lightingDataRefRegex.ReplaceAllFunc(data, func(m []byte) []byte {
+			if !strings.EqualFold(string(lightingDataRefRegex.FindSubmatch(m)[2]), set.guid) {
+				return m
+			}
+			return lightingDataRefRegex.ReplaceAll(m, []byte("${1}{fileID: 0}"))
+		})
+		if string(updated) == string(data) {
+			continue
+		}
+		activeVCS.checkout(path)
+		info, err := fsys.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := fsys.WriteFile(path, updated, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("cannot update %s: %v", scene, err)
+		}
+	}
+	return nil
+}
+
+// ============================================================
+// Path Filters
+// ============================================================
+
+// globToRegexp converts a glob (*, ?, **) on slash-separated project paths. A
+// pattern without a slash matches the file name in any folder.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compileGlobs parses a comma-separated glob list
+func compileGlobs(list string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := globToRegexp(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, rel string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Version Control Checkout
+// ============================================================
+
+// Perforce and Plastic SCM keep files read-only until they are checked out. Writing
+// them directly (even after clearing the flag) leaves changes the server does not know
+// about, so files are opened for edit first. Git and plain folders need nothing.
+const (
+	vcsNone     = "none"
+	vcsPerforce = "p4"
+	vcsPlastic  = "plastic"
+)
+
+// vcsEnvVar overrides detection for every tool (e.g. on build agents)
+const vcsEnvVar = "UNITYSTARTER_VCS"
+
+// vcsClient issues checkouts for files about to be modified
+type vcsClient struct {
+	kind       string
+	source     string // how kind was chosen, for the startup message
+	checkedOut map[string]bool
+}
+
+// activeVCS is set up in main; nil means no checkouts
+var activeVCS *vcsClient
+
+// detectVCS picks the checkout provider. Priority: -vcs flag > UNITYSTARTER_VCS >
+// Unity's VersionControlSettings.asset mode > workspace markers (.plastic, P4CONFIG).
+func detectVCS(projectRoot, override string) (*vcsClient, error) {
+	normalize := func(v string) (string, bool) {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "p4", "perforce":
+			return vcsPerforce, true
+		case "plastic", "plasticscm", "cm", "unityvcs":
+			return vcsPlastic, true
+		case "none", "git", "off":
+			return vcsNone, true
+		}
+		return "", false
+	}
+	newClient := func(kind, source string) *vcsClient {
+		return &vcsClient{kind: kind, source: source, checkedOut: make(map[string]bool)}
+	}
+
+	if override != "" && override != "auto" {
+		kind, ok := normalize(override)
+		if !ok {
+			return nil, fmt.Errorf("unknown -vcs value '%s' (use auto, none, p4 or plastic)", override)
+		}
+		return newClient(kind, "-vcs flag"), nil
+	}
+	if env := os.Getenv(vcsEnvVar); env != "" {
+		if kind, ok := normalize(env); ok {
+			return newClient(kind, vcsEnvVar), nil
+		}
+	}
+
+	// Unity stores the project's integrated VCS in m_Mode ("Perforce", "PlasticSCM", ...)
+	if data, err := os.ReadFile(filepath.Join(projectRoot, "ProjectSettings", "VersionControlSettings.asset")); err == nil {
+		if m := regexp.MustCompile(`(?m)^\s*m_Mode:\s*(.+?)\s*$`).FindSubmatch(data); m != nil {
+			switch strings.ToLower(string(m[1])) {
+			case "perforce":
+				return newClient(vcsPerforce, "VersionControlSettings.asset"), nil
+			case "plasticscm", "plastic scm", "unity version control":
+				return newClient(vcsPlastic, "VersionControlSettings.asset"), nil
+			}
+		}
+	}
+
+	// Workspace markers, searched upward from the project root
+	p4config := os.Getenv("P4CONFIG")
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info != nil {
+				return newClient(vcsNone, "git repository"), nil
+			}
+			if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+				return newClient(vcsPlastic, ".plastic workspace"), nil
+			}
+			if p4config != "" {
+				if _, err := os.Stat(filepath.Join(dir, p4config)); err == nil {
+					return newClient(vcsPerforce, "P4CONFIG"), nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if os.Getenv("P4CLIENT") != "" {
+		return newClient(vcsPerforce, "P4CLIENT"), nil
+	}
+	return newClient(vcsNone, "no Perforce/Plastic workspace found"), nil
+}
+
+// checkout opens an existing file for edit. New files need no checkout, and files
+// outside the depot/workspace only produce a warning: the write still goes ahead.
+func (v *vcsClient) checkout(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || v.checkedOut[abs] {
+		return
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return
+	}
+	v.checkedOut[abs] = true
+
+	var cmd *exec.Cmd
+	switch v.kind {
+	case vcsPerforce:
+		cmd = exec.Command("p4", "edit", abs)
+	case vcsPlastic:
+		cmd = exec.Command("cm", "checkout", abs)
+	default:
+		return
+	}
+	// Run from the file's folder so P4CONFIG / the Plastic workspace is resolved
+	cmd.Dir = filepath.Dir(abs)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		fmt.Printf(tr("[WARNING] %s checkout failed for %s: %v %s\n"), v.kind, filepath.Base(abs), err, strings.TrimSpace(string(out)))
+		return
+	}
+	fmt.Printf(tr("[VCS] Checked out (%s): %s\n"), v.kind, filepath.Base(abs))
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+// formatAge renders the time since t as "3d 4h" / "5h 12m"
+func formatAge(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	d := time.Since(t)
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh", days, hours)
+	}
+	return fmt.Sprintf("%dh %dm", hours, int(d.Minutes())%60)
+}
+
+var sizeRegex = regexp.MustCompile(`(?i)^([\d.]+)\s*(b|kb|mb|gb|tb)?$`)
+
+// parseSize accepts "500MB", "30GB", "1.5tb" or plain bytes
+func parseSize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	m := sizeRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+	v, _ := strconv.ParseFloat(m[1], 64)
+	switch strings.ToLower(m[2]) {
+	case "kb":
+		v *= 1 << 10
+	case "mb":
+		v *= 1 << 20
+	case "gb":
+		v *= 1 << 30
+	case "tb":
+		v *= 1 << 40
+	}
+	return int64(v), nil
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Filesystem
+// ============================================================
+
+// fileSystem is every filesystem call that can change the project. The real
+// run uses osFS; -dry-run swaps in an overlayFS so the same code path runs
+// and the overlay reports what it would have changed.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Walk(root string, fn filepath.WalkFunc) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Chmod(name string, mode os.FileMode) error
+}
+
+var fsys fileSystem = osFS{}
+
+// isDryRun reports whether changes are going to the in-memory overlay
+func isDryRun() bool {
+	_, ok := fsys.(*overlayFS)
+	return ok
+}
+
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)         { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) Walk(root string, fn filepath.WalkFunc) error { return filepath.Walk(root, fn) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// fsChange is one change the overlay absorbed instead of the disk
+type fsChange struct {
+	op     string // "write", "create", "mkdir", "rename", "delete"
+	path   string
+	detail string
+}
+
+// overlayFS reads through to the disk and keeps every write, rename and
+// delete in memory. Later reads see those changes, so multi-step operations
+// (rename a folder, then rewrite files inside it) behave as they would for real.
+type overlayFS struct {
+	mu      sync.Mutex
+	files   map[string][]byte // files written during the run
+	dirs    map[string]bool   // directories created during the run
+	moved   map[string]string // renamed path -> where its content is on disk
+	removed map[string]bool   // deleted paths; hides everything below them
+	changes []fsChange
+}
+
+func newOverlayFS() *overlayFS {
+	return &overlayFS{
+		files:   map[string][]byte{},
+		dirs:    map[string]bool{},
+		moved:   map[string]string{},
+		removed: map[string]bool{},
+	}
+}
+
+func overlayKey(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// isUnder reports whether p is base or inside it
+func isUnder(p, base string) bool {
+	return p == base || strings.HasPrefix(p, strings.TrimSuffix(base, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// locate finds where the content of p lives: in memory (data / memDir) or
+// on disk (disk, after following renames). ok is false if p was deleted.
+func (o *overlayFS) locate(p string) (data []byte, memFile, memDir bool, disk string, ok bool) {
+	if d, found := o.files[p]; found {
+		return d, true, false, "", true
+	}
+	if o.dirs[p] {
+		return nil, false, true, "", true
+	}
+	for q := p; ; {
+		if src, found := o.moved[q]; found {
+			rel, _ := filepath.Rel(q, p)
+			return nil, false, false, filepath.Join(src, rel), true
+		}
+		if o.removed[q] {
+			return nil, false, false, "", false
+		}
+		parent := filepath.Dir(q)
+		if parent == q {
+			break
+		}
+		q = parent
+	}
+	return nil, false, false, p, true
+}
+
+func (o *overlayFS) stat(p string) (os.FileInfo, error) {
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	case memFile:
+		return memFileInfo{name: filepath.Base(p), size: int64(len(data))}, nil
+	case memDir:
+		return memFileInfo{name: filepath.Base(p), dir: true}, nil
+	}
+	info, err := os.Stat(disk)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	if disk != p {
+		return renamedFileInfo{info, filepath.Base(p)}, nil
+	}
+	return info, nil
+}
+
+func (o *overlayFS) Stat(name string) (os.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stat(overlayKey(name))
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case memDir:
+		return nil, &os.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	case memFile:
+		return append([]byte(nil), data...), nil
+	}
+	return os.ReadFile(disk)
+}
+
+func (o *overlayFS) readDir(p string) ([]os.DirEntry, error) {
+	_, memFile, memDir, disk, ok := o.locate(p)
+	if !ok || memFile {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+	names := map[string]bool{}
+	if !memDir {
+		entries, err := os.ReadDir(disk)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			names[e.Name()] = true
+		}
+	}
+	for k := range o.dirs {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.files {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.moved {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	var result []os.DirEntry
+	for name := range names {
+		if info, err := o.stat(filepath.Join(p, name)); err == nil {
+			result = append(result, fs.FileInfoToDirEntry(info))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (o *overlayFS) ReadDir(name string) ([]os.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readDir(overlayKey(name))
+}
+
+// Walk follows filepath.Walk: lexical order, SkipDir and SkipAll honored.
+// The lock is released while fn runs so the callback can change the overlay.
+func (o *overlayFS) Walk(root string, fn filepath.WalkFunc) error {
+	info, err := o.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = o.walk(root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (o *overlayFS) walk(path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	entries, err := o.ReadDir(path)
+	if err1 := fn(path, info, err); err != nil || err1 != nil {
+		return err1
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		childInfo, err := o.Stat(child)
+		if err != nil {
+			if err := fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := o.walk(child, childInfo, fn); err != nil {
+			if err == filepath.SkipDir && childInfo.IsDir() {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *overlayFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	op := "create"
+	if info, err := o.stat(p); err == nil {
+		if info.IsDir() {
+			return &os.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+		}
+		op = "write"
+	}
+	if info, err := o.stat(filepath.Dir(p)); err != nil || !info.IsDir() {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	o.files[p] = append([]byte(nil), data...)
+	o.changes = append(o.changes, fsChange{op, p, fmt.Sprintf("%d bytes", len(data))})
+	return nil
+}
+
+func (o *overlayFS) MkdirAll(path string, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	created := false
+	for q := p; ; q = filepath.Dir(q) {
+		if info, err := o.stat(q); err == nil {
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: q, Err: errors.New("not a directory")}
+			}
+			break
+		}
+		o.dirs[q] = true
+		created = true
+		if filepath.Dir(q) == q {
+			break
+		}
+	}
+	if created {
+		o.changes = append(o.changes, fsChange{"mkdir", p, ""})
+	}
+	return nil
+}
+
+// shift moves every overlay entry under from to the same place under to
+func (o *overlayFS) shift(from, to string) {
+	for k, v := range o.files {
+		if isUnder(k, from) {
+			delete(o.files, k)
+			o.files[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, from) {
+			delete(o.dirs, k)
+			o.dirs[to+strings.TrimPrefix(k, from)] = true
+		}
+	}
+	for k, v := range o.moved {
+		if isUnder(k, from) {
+			delete(o.moved, k)
+			o.moved[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+}
+
+func (o *overlayFS) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	from, to := overlayKey(oldpath), overlayKey(newpath)
+	_, _, _, disk, ok := o.locate(from)
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if from == to {
+		return nil
+	}
+	if info, err := o.stat(filepath.Dir(to)); err != nil || !info.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	o.drop(to)
+	o.shift(from, to)
+	if disk != "" {
+		if _, err := os.Stat(disk); err == nil {
+			o.moved[to] = disk
+		}
+	}
+	o.removed[from] = true
+	o.changes = append(o.changes, fsChange{"rename", from, to})
+	return nil
+}
+
+// drop forgets p and everything below it and hides it from the disk
+func (o *overlayFS) drop(p string) {
+	for k := range o.files {
+		if isUnder(k, p) {
+			delete(o.files, k)
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, p) {
+			delete(o.dirs, k)
+		}
+	}
+	for k := range o.moved {
+		if isUnder(k, p) {
+			delete(o.moved, k)
+		}
+	}
+	o.removed[p] = true
+}
+
+// treeSize is the byte size of p as the overlay currently sees it
+func (o *overlayFS) treeSize(p string) int64 {
+	info, err := o.stat(p)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var size int64
+	entries, _ := o.readDir(p)
+	for _, e := range entries {
+		size += o.treeSize(filepath.Join(p, e.Name()))
+	}
+	return size
+}
+
+func (o *overlayFS) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	info, err := o.stat(p)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	if info.IsDir() {
+		if entries, _ := o.readDir(p); len(entries) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", info.Size())})
+	return nil
+}
+
+func (o *overlayFS) RemoveAll(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	if _, err := o.stat(p); err != nil {
+		return nil
+	}
+	size := o.treeSize(p)
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", size)})
+	return nil
+}
+
+func (o *overlayFS) Chmod(name string, mode os.FileMode) error {
+	if _, err := o.Stat(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+// noteCommand lists a step the overlay cannot simulate, such as creating a
+// symlink or junction
+func (o *overlayFS) noteCommand(args ...string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.changes = append(o.changes, fsChange{"run", strings.Join(args, " "), ""})
+}
+
+// printDryRunReport lists what the overlay absorbed, relative to base
+func (o *overlayFS) printDryRunReport(base string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if abs, err := filepath.Abs(base); err == nil {
+		base = abs
+	}
+	if len(o.changes) == 0 {
+		fmt.Println(tr("\n[Dry Run] No changes would be made."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d change(s) would be made; nothing was written:\n"), len(o.changes))
+	rel := func(p string) string {
+		if r, err := filepath.Rel(base, p); err == nil && !strings.HasPrefix(r, "..") {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	for _, c := range o.changes {
+		switch c.op {
+		case "rename":
+			fmt.Printf("  %-7s %s -> %s\n", c.op, rel(c.path), rel(c.detail))
+		case "mkdir", "run":
+			fmt.Printf("  %-7s %s\n", c.op, rel(c.path))
+		default:
+			fmt.Printf("  %-7s %s (%s)\n", c.op, rel(c.path), c.detail)
+		}
+	}
+}
+
+// memFileInfo describes a file or directory that only exists in the overlay
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (m memFileInfo) Name() string       { return m.name }
+func (m memFileInfo) Size() int64        { return m.size }
+func (m memFileInfo) ModTime() time.Time { return time.Time{} }
+func (m memFileInfo) IsDir() bool        { return m.dir }
+func (m memFileInfo) Sys() interface{}   { return nil }
+func (m memFileInfo) Mode() os.FileMode {
+	if m.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// renamedFileInfo is a disk entry seen under the name it was renamed to
+type renamedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (r renamedFileInfo) Name() string { return r.name }
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"no scene uses it":                             "没有场景使用",
+	"scene selected":                               "场景已选中",
+	"\nPress Enter to continue...":                 "\n按回车键继续...",
+	"[WARNING] %s checkout failed for %s: %v %s\n": "[WARNING] %s 签出失败 %s: %v %s\n",
+	"[VCS] Checked out (%s): %s\n":                 "[VCS] 已签出 (%s): %s\n",
+
+	"[ERROR] -gicache-max-size and -clean-gicache cannot be combined.":                       "[ERROR] -gicache-max-size 和 -clean-gicache 不能同时使用。",
+	"[ERROR] -orphans and -clean-bakes cannot be combined.":                                  "[ERROR] -orphans 和 -clean-bakes 不能同时使用。",
+	"[ERROR] Cannot get current directory: %v\n":                                             "[ERROR] 无法获取当前目录: %v\n",
+	"  Lighting Cache Manager":                                                               "  光照缓存管理",
+	"Target: %s\n":                                                                           "目标: %s\n",
+	"[Dry Run] Nothing will be changed":                                                      "[Dry Run] 不会做任何修改",
+	"\n[ERROR] Current directory does not appear to be a Unity project.":                     "\n[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                                 "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"\n[ERROR] Unity Editor is running (PID: %d). Close it before cleaning lighting data.\n": "\n[ERROR] Unity 编辑器正在运行 (PID: %d)。请先关闭编辑器再清理光照数据。\n",
+	"[VCS] Using %s (%s)\n":                                                                  "[VCS] 使用 %s (%s)\n",
+	"\nGI CACHE":                                                                             "\nGI 缓存",
+	"  %-22s %12s  %d file(s)\n":                                                             "  %-22s %12s  %d 个文件\n",
+	"  %-22s not present\n":                                                                  "  %-22s 不存在\n",
+	"  Shared by every project and editor on this machine.":                                  "  本机所有项目和编辑器共用。",
+	"\nBAKED LIGHTING":                                                                       "\n烘焙光照",
+	"  No LightingData.asset found under Assets/.":                                           "  Assets/ 下未找到 LightingData.asset。",
+	"SCENE":      "场景",
+	"FILES":      "文件",
+	"SIZE":       "大小",
+	"BAKED":      "烘焙于",
+	"(no scene)": "(无场景)",
+	"%s ago":     "%s 前",
+	"\nTotal baked lighting: %s in %d set(s)\n": "\n烘焙光照总大小: %s，共 %d 组\n",
+	"\nSelected: %s\n":                          "\n待清理: %s\n",
+	"GI cache: %d file(s), %s (remaining: %s)":  "GI 缓存: %d 个文件，%s (剩余: %s)",
+	"bakes: %d set(s), %s":                      "烘焙数据: %d 组，%s",
+	"\nNothing to clean.":                       "\n没有需要清理的内容。",
+	"\n[WARNING] The GI cache is shared; editors open on other projects rebuild what they need.": "\n[WARNING] GI 缓存是共用的；其他项目中打开的编辑器会重新生成所需内容。",
+	"\nProceed? (y/N): ":                                           "\n是否继续？(y/N): ",
+	"Operation cancelled.":                                         "操作已取消。",
+	"[OK]   Cleaned the GI cache (%s)\n":                           "[OK]   已清理 GI 缓存 (%s)\n",
+	"[FAIL] GI cache: %d item(s) could not be removed (in use?)\n": "[FAIL] GI 缓存: %d 项无法删除（正在使用？）\n",
+	"[OK]   Removed %s (%s)\n":                                     "[OK]   已删除 %s (%s)\n",
+	"[FAIL] %s: %v\n":                                              "[FAIL] %s: %v\n",
+	"[OK]   Cleaned %s (%s)\n":                                     "[OK]   已清理 %s (%s)\n",
+	"  CLEAN COMPLETE":                                             "  清理完成",
+	"  Freed:  %s\n":                                               "  释放:   %s\n",
+	"  Failed: %d\n":                                               "  失败:   %d\n",
+	"[TIP] Scenes whose bakes were removed show as not baked; bake them again in the Lighting window.": "[TIP] 烘焙数据被删除的场景会显示为未烘焙；请在 Lighting 窗口中重新烘焙。",
+
+	"\n[Dry Run] No changes would be made.":                          "\n[Dry Run] 不会做任何更改。",
+	"\n[Dry Run] %d change(s) would be made; nothing was written:\n": "\n[Dry Run] 将会进行 %d 项更改，未写入任何内容:\n",
+
+	"[WARNING] Removing a stale %s (%s)\n":                      "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n": "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	if status == "ok" && isDryRun() {
+		status = "planned"
+	}
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode     bool
+		dryRun     bool
+		force      bool
+		cleanGI    bool
+		giMaxSizeS string
+		giPath     string
+		cleanBakes bool
+		orphans    bool
+		scenesFlag string
+		keepFlag   string
+		vcsMode    string
+	)
+
+	flag.BoolVar(&cleanGI, "clean-gicache", false, "Empty the GI cache and remove Library/BakedLighting")
+	flag.StringVar(&giMaxSizeS, "gicache-max-size", "", "Trim the GI cache to this size (e.g. 20GB), least recently used first")
+	flag.StringVar(&giPath, "gicache", "", "GI cache folder, if moved in Preferences > GI Cache (default: the editor's default location)")
+	flag.BoolVar(&cleanBakes, "clean-bakes", false, "Remove the baked lighting of the scenes matched by -scenes (default: all)")
+	flag.BoolVar(&orphans, "orphans", false, "Remove only baked lighting that no scene references")
+	flag.StringVar(&scenesFlag, "scenes", "", "Comma-separated globs of scenes whose bakes -clean-bakes removes")
+	flag.StringVar(&keepFlag, "keep-scenes", "", "Comma-separated globs of scenes whose bakes are always kept")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no confirmation prompts)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Show what would be removed without changing anything")
+	flag.BoolVar(&force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
+	flag.StringVar(&vcsMode, "vcs", "auto", "Checkout provider for scene and bake files: auto, none, p4, plastic")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("lighting_cache_manager")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if overlay, ok := fsys.(*overlayFS); ok {
+			cwd, _ := os.Getwd()
+			overlay.printDryRunReport(cwd)
+		}
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+
+	giMaxSize, err := parseSize(giMaxSizeS)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		recordError("%v", err)
+		exit(1)
+	}
+	if cleanGI && giMaxSize > 0 {
+		fmt.Println(tr("[ERROR] -gicache-max-size and -clean-gicache cannot be combined."))
+		recordError("-gicache-max-size and -clean-gicache cannot be combined")
+		exit(1)
+	}
+	if orphans && cleanBakes {
+		fmt.Println(tr("[ERROR] -orphans and -clean-bakes cannot be combined."))
+		recordError("-orphans and -clean-bakes cannot be combined")
+		exit(1)
+	}
+	targets, err := compileGlobs(scenesFlag)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		recordError("%v", err)
+		exit(1)
+	}
+	keep, err := compileGlobs(keepFlag)
+	if err != nil {
+		fmt.Printf("[ERROR] %v\n", err)
+		recordError("%v", err)
+		exit(1)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if giPath == "" {
+		giPath = defaultGICachePath()
+	}
+	if abs, err := filepath.Abs(giPath); err == nil {
+		giPath = abs
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  Lighting Cache Manager"))
+	printRule("=============================================")
+	fmt.Printf(tr("Target: %s\n"), basePath)
+	if dryRun {
+		fmt.Println(tr("[Dry Run] Nothing will be changed"))
+		fsys = newOverlayFS()
+	}
+
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("\n[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+
+	trimGI := cleanGI || giMaxSize > 0
+	modifying := (trimGI || cleanBakes || orphans) && !dryRun
+	if modifying {
+		if isRunning, pid := checkUnityRunning(basePath); isRunning {
+			fmt.Printf(tr("\n[ERROR] Unity Editor is running (PID: %d). Close it before cleaning lighting data.\n"), pid)
+			recordError("Unity Editor is running (PID: %d). Close it before cleaning lighting data", pid)
+			exit(1)
+		}
+		if err := acquireProjectLock(basePath, "lighting_cache_manager", "clean lighting", force); err != nil {
+			fmt.Printf(tr("\n[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exit(1)
+		}
+		defer releaseProjectLock()
+		if cleanBakes || orphans {
+			if activeVCS, err = detectVCS(basePath, vcsMode); err != nil {
+				fmt.Printf("[ERROR] %v\n", err)
+				recordError("%v", err)
+				exit(1)
+			}
+			if activeVCS.kind != vcsNone {
+				fmt.Printf(tr("[VCS] Using %s (%s)\n"), activeVCS.kind, activeVCS.source)
+			}
+		}
+	}
+
+	// GI cache
+	fmt.Println(tr("\nGI CACHE"))
+	giFiles := listCacheFiles(giPath)
+	var giTotal int64
+	for _, f := range giFiles {
+		giTotal += f.size
+	}
+	fmt.Printf("  %-22s %s\n", "GiCache", giPath)
+	fmt.Printf(tr("  %-22s %12s  %d file(s)\n"), "", formatSize(giTotal), len(giFiles))
+	fmt.Println(tr("  Shared by every project and editor on this machine."))
+	bakedPath := filepath.Join(basePath, bakedLightingCache)
+	bakedSize, bakedCount := measureDir(bakedPath)
+	if _, err := os.Stat(bakedPath); err == nil {
+		fmt.Printf(tr("  %-22s %12s  %d file(s)\n"), filepath.ToSlash(bakedLightingCache), formatSize(bakedSize), bakedCount)
+	} else {
+		fmt.Printf(tr("  %-22s not present\n"), filepath.ToSlash(bakedLightingCache))
+	}
+
+	// Baked lighting per scene
+	fmt.Println(tr("\nBAKED LIGHTING"))
+	sets := collectBakeSets(basePath)
+	if cleanBakes || orphans {
+		selectBakeSets(sets, targets, keep, orphans)
+	}
+	var bakeTotal int64
+	if len(sets) == 0 {
+		fmt.Println(tr("  No LightingData.asset found under Assets/."))
+	} else {
+		fmt.Printf("  %-7s %-50s %6s %12s  %s\n", "", tr("SCENE"), tr("FILES"), tr("SIZE"), tr("BAKED"))
+	}
+	for _, set := range sets {
+		mark := ""
+		if cleanBakes || orphans {
+			mark = "[KEEP]"
+			if set.reason != "" {
+				mark = "[CLEAN]"
+			}
+		}
+		scene := tr("(no scene)") + " " + set.folder
+		if len(set.scenes) > 0 {
+			scene = strings.Join(set.scenes, ", ")
+		}
+		fmt.Printf("  %-7s %-50s %6d %12s  %s", mark, scene, len(set.files), formatSize(set.size), fmt.Sprintf(tr("%s ago"), formatAge(set.baked)))
+		if set.reason != "" {
+			fmt.Printf("  (%s)", set.reason)
+		}
+		fmt.Println()
+		bakeTotal += set.size
+	}
+	if len(sets) > 0 {
+		fmt.Printf(tr("\nTotal baked lighting: %s in %d set(s)\n"), formatSize(bakeTotal), len(sets))
+	}
+
+	if !trimGI && !cleanBakes && !orphans {
+		exit(0)
+	}
+
+	// Selection
+	var giSelected []cacheFile
+	var giFreed int64
+	if trimGI {
+		giSelected, giFreed = trimCache(giFiles, giMaxSize)
+	}
+	cleanBaked := cleanGI && bakedCount > 0
+	var bakeSelected []*bakeSet
+	var bakeFreed int64
+	for _, set := range sets {
+		if set.reason != "" {
+			bakeSelected = append(bakeSelected, set)
+			bakeFreed += set.size
+		}
+	}
+
+	var parts []string
+	if trimGI {
+		parts = append(parts, fmt.Sprintf(tr("GI cache: %d file(s), %s (remaining: %s)"), len(giSelected), formatSize(giFreed), formatSize(giTotal-giFreed)))
+	}
+	if cleanBaked {
+		parts = append(parts, fmt.Sprintf("%s: %s", filepath.ToSlash(bakedLightingCache), formatSize(bakedSize)))
+	}
+	if cleanBakes || orphans {
+		parts = append(parts, fmt.Sprintf(tr("bakes: %d set(s), %s"), len(bakeSelected), formatSize(bakeFreed)))
+	}
+	fmt.Printf(tr("\nSelected: %s\n"), strings.Join(parts, "; "))
+	if len(giSelected) == 0 && !cleanBaked && len(bakeSelected) == 0 {
+		fmt.Println(tr("\nNothing to clean."))
+		exit(0)
+	}
+	if len(giSelected) > 0 {
+		fmt.Println(tr("\n[WARNING] The GI cache is shared; editors open on other projects rebuild what they need."))
+	}
+	if !ciMode && !dryRun {
+		fmt.Print(tr("\nProceed? (y/N): "))
+		confirm, _ := stdinReader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+			fmt.Println(tr("Operation cancelled."))
+			exit(0)
+		}
+	}
+	fmt.Println()
+
+	var freed int64
+	var failed int
+	if len(giSelected) > 0 {
+		started := time.Now()
+		if cleanGI {
+			// Emptying the whole cache: one delete per top-level entry instead of per file
+			entries, _ := fsys.ReadDir(giPath)
+			for _, e := range entries {
+				if err := fsys.RemoveAll(filepath.Join(giPath, e.Name())); err != nil {
+					failed++
+				}
+			}
+			if failed == 0 {
+				freed += giFreed
+			} else {
+				remaining, _ := measureDir(giPath)
+				freed += giTotal - remaining
+			}
+		} else {
+			n, fails := removeCacheFiles(giPath, giSelected)
+			freed += n
+			failed += fails
+		}
+		if failed > 0 {
+			fmt.Printf(tr("[FAIL] GI cache: %d item(s) could not be removed (in use?)\n"), failed)
+			recordAction("clean", giPath, "failed", fmt.Sprintf("%d items could not be removed", failed), time.Since(started))
+		} else {
+			fmt.Printf(tr("[OK]   Cleaned the GI cache (%s)\n"), formatSize(giFreed))
+			recordAction("clean", giPath, "ok", formatSize(giFreed), time.Since(started))
+		}
+	}
+	if cleanBaked {
+		started := time.Now()
+		if err := fsys.RemoveAll(bakedPath); err != nil {
+			fmt.Printf(tr("[FAIL] %s: %v\n"), filepath.ToSlash(bakedLightingCache), err)
+			recordAction("clean", filepath.ToSlash(bakedLightingCache), "failed", err.Error(), time.Since(started))
+			failed++
+		} else {
+			fmt.Printf(tr("[OK]   Removed %s (%s)\n"), filepath.ToSlash(bakedLightingCache), formatSize(bakedSize))
+			recordAction("clean", filepath.ToSlash(bakedLightingCache), "ok", formatSize(bakedSize), time.Since(started))
+			freed += bakedSize
+		}
+	}
+	for _, set := range bakeSelected {
+		started := time.Now()
+		if err := cleanBakeSet(basePath, set); err != nil {
+			fmt.Printf(tr("[FAIL] %s: %v\n"), set.folder, err)
+			recordAction("clean", set.folder, "failed", err.Error(), time.Since(started))
+			failed++
+			continue
+		}
+		fmt.Printf(tr("[OK]   Cleaned %s (%s)\n"), set.folder, formatSize(set.size))
+		recordAction("clean", set.folder, "ok", formatSize(set.size), time.Since(started))
+		freed += set.size
+	}
+
+	printRule("\n===========================================")
+	fmt.Println(tr("  CLEAN COMPLETE"))
+	printRule("===========================================")
+	fmt.Printf(tr("  Freed:  %s\n"), formatSize(freed))
+	if failed > 0 {
+		fmt.Printf(tr("  Failed: %d\n"), failed)
+	}
+	for _, set := range bakeSelected {
+		if len(set.scenes) > 0 {
+			fmt.Println(tr("[TIP] Scenes whose bakes were removed show as not baked; bake them again in the Lighting window."))
+			break
+		}
+	}
+	if failed > 0 {
+		exit(1)
+	}
+	exit(0)
+}
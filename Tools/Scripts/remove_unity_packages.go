@@ -8,14 +8,26 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -171,18 +183,277 @@ func removeDependencyLine(content, pkg string) string {
 // ============================================================
 
 func createBackup(manifestPath string) (string, error) {
-	data, err := os.ReadFile(manifestPath)
+	data, err := fsys.ReadFile(manifestPath)
 	if err != nil {
 		return "", err
 	}
 
 	backupPath := manifestPath + ".bak"
-	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+	if err := writeFileAtomic(backupPath, data); err != nil {
 		return "", err
 	}
 	return backupPath, nil
 }
 
+// ============================================================
+// Shared Policy
+// ============================================================
+
+// A studio keeps its policy (clean lists, package categories, naming rules) in one
+// JSON document that "policy" in .unitystarter.json points at: an HTTP(S) URL, a
+// file in a git repository at a pinned revision, or a local path. Fetched copies
+// are cached in the user cache folder; a pinned commit or content hash is fetched
+// once, anything else again after "refresh". When a fetch fails the cached copy is
+// used with a warning, so tools keep working offline.
+const (
+	policyConfigFileName = ".unitystarter.json"
+	policyConfigEnvVar   = "UNITYSTARTER_CONFIG"
+	policyOfflineEnvVar  = "UNITYSTARTER_POLICY_OFFLINE" // "1": only use the cached copy
+	policyDefaultFile    = "unitystarter-policy.json"    // git: path in the repository
+	policyDefaultRefresh = time.Hour
+	policyTimeout        = 30 * time.Second
+)
+
+// policySource is the "policy" object of .unitystarter.json
+type policySource struct {
+	Source   string `json:"source"`   // https://..., git+https://....git, git@host:repo.git or a path
+	Revision string `json:"revision"` // git: commit, tag or branch (default: HEAD)
+	File     string `json:"file"`     // git: path of the document in the repository
+	SHA256   string `json:"sha256"`   // http: expected hash of the document; pins it
+	Refresh  string `json:"refresh"`  // how long an unpinned copy is used, e.g. "30m" (default: 1h)
+}
+
+// sharedPolicy is the policy document. The schema is shared by all tools in this
+// folder; each one reads the parts it applies and ignores the rest.
+type sharedPolicy struct {
+	Clean *struct {
+		Directories []string `json:"directories"` // replaces the built-in top-level folders
+		Extensions  []string `json:"extensions"`  // replaces the built-in top-level file extensions
+	} `json:"clean"`
+	Packages *struct {
+		Categories []struct {
+			Name     string   `json:"name"`
+			Packages []string `json:"packages"`
+		} `json:"categories"` // replaces the built-in removal categories
+	} `json:"packages"`
+	Naming *struct {
+		Scripts  string `json:"scripts"`  // regex new script names must match
+		Packages string `json:"packages"` // regex new package names must match
+	} `json:"naming"`
+}
+
+var gitCommitRegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// loadPolicy reads "policy" from .unitystarter.json (found like loadSharedConfig)
+// and returns the document with a description of where it came from. Returns nil
+// when no policy is configured.
+func loadPolicy(projectRoot string) (*sharedPolicy, string, error) {
+	path := os.Getenv(policyConfigEnvVar)
+	if path == "" {
+		abs, err := filepath.Abs(projectRoot)
+		if err != nil {
+			return nil, "", nil
+		}
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			candidate := filepath.Join(dir, policyConfigFileName)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				return nil, "", nil
+			}
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	var file struct {
+		Policy *policySource `json:"policy"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, "", fmt.Errorf("invalid %s: %v", path, err)
+	}
+	if file.Policy == nil || strings.TrimSpace(file.Policy.Source) == "" {
+		return nil, "", nil
+	}
+
+	doc, origin, err := fetchPolicy(file.Policy, filepath.Dir(path))
+	if err != nil {
+		return nil, origin, fmt.Errorf("policy %s: %v", origin, err)
+	}
+	var policy sharedPolicy
+	if err := json.Unmarshal(doc, &policy); err != nil {
+		return nil, origin, fmt.Errorf("invalid policy %s: %v", origin, err)
+	}
+	return &policy, origin, nil
+}
+
+// isGitSource reports whether a policy source names a git repository
+func isGitSource(source string) bool {
+	return strings.HasPrefix(source, "git+") || strings.HasPrefix(source, "git@") ||
+		strings.HasPrefix(source, "ssh://") || strings.HasSuffix(source, ".git")
+}
+
+// fetchPolicy returns the policy document, from the cache when it is pinned,
+// fresh enough or offline, and from the source otherwise
+func fetchPolicy(src *policySource, configDir string) ([]byte, string, error) {
+	source := strings.TrimSpace(src.Source)
+	isGit := isGitSource(source)
+	isHTTP := !isGit && (strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://"))
+	if !isGit && !isHTTP {
+		p := source
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(configDir, filepath.FromSlash(p))
+		}
+		data, err := os.ReadFile(p)
+		return data, p, err
+	}
+
+	revision, file := src.Revision, src.File
+	if isGit {
+		if revision == "" {
+			revision = "HEAD"
+		}
+		if file == "" {
+			file = policyDefaultFile
+		}
+	}
+	origin := source
+	if isGit {
+		origin = source + "@" + revision + ":" + file
+	}
+	refresh := policyDefaultRefresh
+	if src.Refresh != "" {
+		d, err := time.ParseDuration(src.Refresh)
+		if err != nil {
+			return nil, origin, fmt.Errorf("invalid refresh '%s': %v", src.Refresh, err)
+		}
+		refresh = d
+	}
+	pinned := (isGit && gitCommitRegex.MatchString(strings.ToLower(revision))) || (isHTTP && src.SHA256 != "")
+	matchesPin := func(data []byte) bool {
+		if src.SHA256 == "" {
+			return true
+		}
+		sum := sha256.Sum256(data)
+		return strings.EqualFold(hex.EncodeToString(sum[:]), strings.TrimSpace(src.SHA256))
+	}
+
+	// One cache file per source, revision and path
+	key := sha256.Sum256([]byte(source + "\n" + revision + "\n" + file))
+	cachePath := ""
+	if dir, err := os.UserCacheDir(); err == nil {
+		cachePath = filepath.Join(dir, "UnityStarter", "policy", hex.EncodeToString(key[:8])+".json")
+	}
+	var cached []byte
+	var cachedAt time.Time
+	if cachePath != "" {
+		if info, err := os.Stat(cachePath); err == nil {
+			if data, err := os.ReadFile(cachePath); err == nil && matchesPin(data) {
+				cached, cachedAt = data, info.ModTime()
+			}
+		}
+	}
+	if cached != nil && (pinned || time.Since(cachedAt) < refresh) {
+		return cached, origin, nil
+	}
+	if os.Getenv(policyOfflineEnvVar) == "1" {
+		if cached != nil {
+			return cached, origin, nil
+		}
+		return nil, origin, fmt.Errorf("%s is set and there is no cached copy", policyOfflineEnvVar)
+	}
+
+	var data []byte
+	var err error
+	if isGit {
+		data, err = fetchGitPolicy(strings.TrimPrefix(source, "git+"), revision, file)
+	} else {
+		data, err = fetchHTTPPolicy(source)
+	}
+	if err == nil && !matchesPin(data) {
+		err = fmt.Errorf("the document does not match the pinned sha256")
+	}
+	if err != nil {
+		if cached != nil {
+			fmt.Printf(tr("[WARNING] Cannot refresh the policy, using the copy cached %s: %v\n"), cachedAt.Format("2006-01-02 15:04"), err)
+			return cached, origin, nil
+		}
+		return nil, origin, err
+	}
+	if cachePath != "" {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err == nil {
+			tmp := cachePath + ".tmp"
+			if os.WriteFile(tmp, data, 0644) == nil {
+				os.Rename(tmp, cachePath)
+			}
+		}
+	}
+	return data, origin, nil
+}
+
+func fetchHTTPPolicy(source string) ([]byte, error) {
+	client := &http.Client{Timeout: policyTimeout}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+}
+
+// fetchGitPolicy reads one file at a revision without a full clone: a shallow
+// fetch of just that revision into a scratch repository
+func fetchGitPolicy(repo, revision, file string) ([]byte, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git is not installed")
+	}
+	tmp, err := os.MkdirTemp("", "unitystarter-policy-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	run := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("git", append([]string{"-C", tmp}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("git %s: %v %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return out, nil
+	}
+	if _, err := run("init", "-q"); err != nil {
+		return nil, err
+	}
+	if _, err := run("fetch", "-q", "--depth", "1", repo, revision); err != nil {
+		return nil, err
+	}
+	return run("show", "FETCH_HEAD:"+strings.TrimPrefix(filepath.ToSlash(file), "/"))
+}
+
+// applyPackagePolicy replaces the built-in removal categories with the policy's
+func applyPackagePolicy(p *sharedPolicy) error {
+	if p == nil || p.Packages == nil || len(p.Packages.Categories) == 0 {
+		return nil
+	}
+	var list []packageCategory
+	for _, c := range p.Packages.Categories {
+		if strings.TrimSpace(c.Name) == "" || len(c.Packages) == 0 {
+			return fmt.Errorf("policy package categories need a name and at least one package")
+		}
+		list = append(list, packageCategory{name: c.Name, packages: c.Packages})
+	}
+	categories = list
+	return nil
+}
+
 // ============================================================
 // Package Selection
 // ============================================================
@@ -200,10 +471,10 @@ func buildFullRemoveSet() map[string]bool {
 
 // selectInteractive lets the user select categories interactively.
 func selectInteractive(existingPkgs map[string]bool) map[string]bool {
-	fmt.Println("\n=============================================")
-	fmt.Println("  SELECT CATEGORIES TO REMOVE")
-	fmt.Println("=============================================")
-	fmt.Println("  [0] All categories (default)")
+	printRule("\n=============================================")
+	fmt.Println(tr("  SELECT CATEGORIES TO REMOVE"))
+	printRule("=============================================")
+	fmt.Println(tr("  [0] All categories (default)"))
 
 	for i, cat := range categories {
 		// Count how many packages in this category exist in manifest
@@ -213,11 +484,11 @@ func selectInteractive(existingPkgs map[string]bool) map[string]bool {
 				count++
 			}
 		}
-		status := fmt.Sprintf("%d/%d in manifest", count, len(cat.packages))
+		status := fmt.Sprintf(tr("%d/%d in manifest"), count, len(cat.packages))
 		fmt.Printf("  [%d] %-30s  (%s)\n", i+1, cat.name, status)
 	}
 
-	fmt.Print("\nEnter numbers separated by commas (e.g. 1,3,5), or Enter for all: ")
+	fmt.Print(tr("\nEnter numbers separated by commas (e.g. 1,3,5), or Enter for all: "))
 	input, _ := stdinReader.ReadString('\n')
 	input = strings.TrimSpace(input)
 
@@ -245,12 +516,12 @@ func selectInteractive(existingPkgs map[string]bool) map[string]bool {
 // ============================================================
 
 func printPreview(toRemove []string, kept []string) {
-	fmt.Println("\n=============================================")
-	fmt.Println("  PACKAGES TO REMOVE")
-	fmt.Println("=============================================")
+	printRule("\n=============================================")
+	fmt.Println(tr("  PACKAGES TO REMOVE"))
+	printRule("=============================================")
 
 	if len(toRemove) == 0 {
-		fmt.Println("  (none — all listed packages are already absent)")
+		fmt.Println(tr("  (none — all listed packages are already absent)"))
 		return
 	}
 
@@ -272,8 +543,303 @@ func printPreview(toRemove []string, kept []string) {
 		fmt.Printf("    - %s\n", pkg)
 	}
 
-	fmt.Printf("\n  Total to remove: %d\n", len(toRemove))
-	fmt.Printf("  Remaining after: %d packages\n", len(kept))
+	fmt.Printf(tr("\n  Total to remove: %d\n"), len(toRemove))
+	fmt.Printf(tr("  Remaining after: %d packages\n"), len(kept))
+}
+
+// ============================================================
+// Version Control Checkout
+// ============================================================
+
+// Under Perforce or Plastic SCM, manifest.json must be checked out before it is
+// rewritten; otherwise the change is invisible to the server (and the file is read-only).
+const (
+	vcsNone     = "none"
+	vcsPerforce = "p4"
+	vcsPlastic  = "plastic"
+)
+
+// vcsEnvVar overrides detection (same variable as rename_project)
+const vcsEnvVar = "UNITYSTARTER_VCS"
+
+// vcsClient issues checkouts for files about to be modified
+type vcsClient struct {
+	kind       string
+	source     string // how kind was chosen, for the startup message
+	checkedOut map[string]bool
+}
+
+// activeVCS is set up in main; nil means no checkouts
+var activeVCS *vcsClient
+
+// detectVCS picks the checkout provider. Priority: -vcs flag > UNITYSTARTER_VCS >
+// Unity's VersionControlSettings.asset mode > workspace markers (.plastic, P4CONFIG).
+func detectVCS(projectRoot, override string) (*vcsClient, error) {
+	normalize := func(v string) (string, bool) {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "p4", "perforce":
+			return vcsPerforce, true
+		case "plastic", "plasticscm", "cm", "unityvcs":
+			return vcsPlastic, true
+		case "none", "git", "off":
+			return vcsNone, true
+		}
+		return "", false
+	}
+	newClient := func(kind, source string) *vcsClient {
+		return &vcsClient{kind: kind, source: source, checkedOut: make(map[string]bool)}
+	}
+
+	if override != "" && override != "auto" {
+		kind, ok := normalize(override)
+		if !ok {
+			return nil, fmt.Errorf("unknown -vcs value '%s' (use auto, none, p4 or plastic)", override)
+		}
+		return newClient(kind, "-vcs flag"), nil
+	}
+	if env := os.Getenv(vcsEnvVar); env != "" {
+		if kind, ok := normalize(env); ok {
+			return newClient(kind, vcsEnvVar), nil
+		}
+	}
+
+	// Unity stores the project's integrated VCS in m_Mode ("Perforce", "PlasticSCM", ...)
+	if data, err := os.ReadFile(filepath.Join(projectRoot, "ProjectSettings", "VersionControlSettings.asset")); err == nil {
+		if m := regexp.MustCompile(`(?m)^\s*m_Mode:\s*(.+?)\s*$`).FindSubmatch(data); m != nil {
+			switch strings.ToLower(string(m[1])) {
+			case "perforce":
+				return newClient(vcsPerforce, "VersionControlSettings.asset"), nil
+			case "plasticscm", "plastic scm", "unity version control":
+				return newClient(vcsPlastic, "VersionControlSettings.asset"), nil
+			}
+		}
+	}
+
+	// Workspace markers, searched upward from the project root
+	p4config := os.Getenv("P4CONFIG")
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info != nil {
+				return newClient(vcsNone, "git repository"), nil
+			}
+			if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+				return newClient(vcsPlastic, ".plastic workspace"), nil
+			}
+			if p4config != "" {
+				if _, err := os.Stat(filepath.Join(dir, p4config)); err == nil {
+					return newClient(vcsPerforce, "P4CONFIG"), nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if os.Getenv("P4CLIENT") != "" {
+		return newClient(vcsPerforce, "P4CLIENT"), nil
+	}
+	return newClient(vcsNone, "no Perforce/Plastic workspace found"), nil
+}
+
+// checkout opens an existing file for edit. New files need no checkout, and files
+// outside the depot/workspace only produce a warning: the write still goes ahead.
+func (v *vcsClient) checkout(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || v.checkedOut[abs] {
+		return
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return
+	}
+	v.checkedOut[abs] = true
+
+	var cmd *exec.Cmd
+	switch v.kind {
+	case vcsPerforce:
+		cmd = exec.Command("p4", "edit", abs)
+	case vcsPlastic:
+		cmd = exec.Command("cm", "checkout", abs)
+	default:
+		return
+	}
+	// Run from the file's folder so P4CONFIG / the Plastic workspace is resolved
+	cmd.Dir = filepath.Dir(abs)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		fmt.Printf(tr("[WARNING] %s checkout failed for %s: %v %s\n"), v.kind, filepath.Base(abs), err, strings.TrimSpace(string(out)))
+		return
+	}
+	fmt.Printf(tr("[VCS] Checked out (%s): %s\n"), v.kind, filepath.Base(abs))
+}
+
+// ============================================================
+// Safe File Writes
+// ============================================================
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// writeFileAtomic replaces path with data without ever leaving a half-written file:
+// data goes to a temp file in the same directory which is then renamed over the target.
+// The target's permission bits are kept, and a read-only flag (files under Perforce or
+// Plastic SCM that were not checked out) is cleared so the write does not fail.
+func writeFileAtomic(path string, data []byte) error {
+	// A dry run writes straight into the overlay: no checkout, no temp file
+	if isDryRun() {
+		return fsys.WriteFile(path, data, 0644)
+	}
+	activeVCS.checkout(path)
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if mode&0200 == 0 {
+			mode |= 0200
+			if err := os.Chmod(path, mode); err != nil {
+				return fmt.Errorf("cannot clear read-only flag on %s: %v", path, err)
+			}
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Retry briefly: editors, indexers and antivirus can hold the target open on Windows
+	for attempt := 0; ; attempt++ {
+		err = os.Rename(tmpPath, path)
+		if err == nil || attempt == 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// textFormat is the BOM / line-ending style of manifest.json. The regex edits above
+// assume LF-only text, so the manifest is decoded first and re-encoded on write.
+type textFormat struct {
+	bom  bool
+	crlf bool // ending of most lines, and of the lines a rewrite adds
+	// Mixed files only: the text as read (LF) and the ending of each of its line
+	// breaks, 'r' (CRLF) or 'n' (LF). Strings keep textFormat comparable.
+	orig string
+	eols string
+}
+
+// decodeText strips a UTF-8 BOM and normalizes line endings to LF. A file counts as
+// CRLF when most of its line breaks are CRLF; a file with both keeps the ending of
+// every line, so a rewrite only changes the endings of the lines it edits.
+func decodeText(data []byte) (string, textFormat) {
+	var format textFormat
+	if bytes.HasPrefix(data, utf8BOM) {
+		format.bom = true
+		data = data[len(utf8BOM):]
+	}
+	crlfCount := bytes.Count(data, []byte("\r\n"))
+	lfCount := bytes.Count(data, []byte("\n")) - crlfCount
+	format.crlf = crlfCount > 0 && crlfCount >= lfCount
+	if crlfCount == 0 {
+		return string(data), format
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if lfCount > 0 {
+		eols := make([]byte, 0, crlfCount+lfCount)
+		for i, c := range data {
+			if c == '\n' {
+				if i > 0 && data[i-1] == '\r' {
+					eols = append(eols, 'r')
+				} else {
+					eols = append(eols, 'n')
+				}
+			}
+		}
+		format.orig, format.eols = text, string(eols)
+	}
+	return text, format
+}
+
+// encode converts LF text back to the recorded format
+func (f textFormat) encode(text string) []byte {
+	switch {
+	case f.eols != "":
+		text = f.mixedEndings(text)
+	case f.crlf:
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	if f.bom {
+		return append(append([]byte{}, utf8BOM...), text...)
+	}
+	return []byte(text)
+}
+
+// mixedEndings gives the lines of a rewritten mixed file the endings they had. The
+// unchanged lines before and after the edited region keep theirs; edited lines keep
+// the ending of the line they replace when the line count did not change, and get
+// the majority ending otherwise.
+func (f textFormat) mixedEndings(text string) string {
+	old := strings.Split(f.orig, "\n")
+	lines := strings.Split(text, "\n")
+	prefix := 0
+	for prefix < len(old) && prefix < len(lines) && old[prefix] == lines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(lines)-prefix && old[len(old)-1-suffix] == lines[len(lines)-1-suffix] {
+		suffix++
+	}
+	crlfAt := func(j int) bool {
+		if j >= 0 && j < len(f.eols) {
+			return f.eols[j] == 'r'
+		}
+		return f.crlf
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		b.WriteString(line)
+		if i == len(lines)-1 {
+			break
+		}
+		crlf := f.crlf
+		switch {
+		case i < prefix || len(lines) == len(old):
+			crlf = crlfAt(i)
+		case i >= len(lines)-suffix:
+			crlf = crlfAt(i - len(lines) + len(old))
+		}
+		if crlf {
+			b.WriteByte('\r')
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
 }
 
 // ============================================================
@@ -281,10 +847,838 @@ func printPreview(toRemove []string, kept []string) {
 // ============================================================
 
 func waitForKeyPress() {
-	fmt.Println("\nPress Enter to exit...")
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to exit..."))
 	stdinReader.ReadBytes('\n')
 }
 
+// ============================================================
+// Filesystem
+// ============================================================
+
+// fileSystem is every filesystem call that can change the project. The real
+// run uses osFS; -dry-run swaps in an overlayFS so the same code path runs
+// and the overlay reports what it would have changed.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Walk(root string, fn filepath.WalkFunc) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Chmod(name string, mode os.FileMode) error
+}
+
+var fsys fileSystem = osFS{}
+
+// isDryRun reports whether changes are going to the in-memory overlay
+func isDryRun() bool {
+	_, ok := fsys.(*overlayFS)
+	return ok
+}
+
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)         { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) Walk(root string, fn filepath.WalkFunc) error { return filepath.Walk(root, fn) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// fsChange is one change the overlay absorbed instead of the disk
+type fsChange struct {
+	op     string // "write", "create", "mkdir", "rename", "delete"
+	path   string
+	detail string
+}
+
+// overlayFS reads through to the disk and keeps every write, rename and
+// delete in memory. Later reads see those changes, so multi-step operations
+// (rename a folder, then rewrite files inside it) behave as they would for real.
+type overlayFS struct {
+	mu      sync.Mutex
+	files   map[string][]byte // files written during the run
+	dirs    map[string]bool   // directories created during the run
+	moved   map[string]string // renamed path -> where its content is on disk
+	removed map[string]bool   // deleted paths; hides everything below them
+	changes []fsChange
+}
+
+func newOverlayFS() *overlayFS {
+	return &overlayFS{
+		files:   map[string][]byte{},
+		dirs:    map[string]bool{},
+		moved:   map[string]string{},
+		removed: map[string]bool{},
+	}
+}
+
+func overlayKey(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// isUnder reports whether p is base or inside it
+func isUnder(p, base string) bool {
+	return p == base || strings.HasPrefix(p, strings.TrimSuffix(base, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// locate finds where the content of p lives: in memory (data / memDir) or
+// on disk (disk, after following renames). ok is false if p was deleted.
+func (o *overlayFS) locate(p string) (data []byte, memFile, memDir bool, disk string, ok bool) {
+	if d, found := o.files[p]; found {
+		return d, true, false, "", true
+	}
+	if o.dirs[p] {
+		return nil, false, true, "", true
+	}
+	for q := p; ; {
+		if src, found := o.moved[q]; found {
+			rel, _ := filepath.Rel(q, p)
+			return nil, false, false, filepath.Join(src, rel), true
+		}
+		if o.removed[q] {
+			return nil, false, false, "", false
+		}
+		parent := filepath.Dir(q)
+		if parent == q {
+			break
+		}
+		q = parent
+	}
+	return nil, false, false, p, true
+}
+
+func (o *overlayFS) stat(p string) (os.FileInfo, error) {
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	case memFile:
+		return memFileInfo{name: filepath.Base(p), size: int64(len(data))}, nil
+	case memDir:
+		return memFileInfo{name: filepath.Base(p), dir: true}, nil
+	}
+	info, err := os.Stat(disk)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	if disk != p {
+		return renamedFileInfo{info, filepath.Base(p)}, nil
+	}
+	return info, nil
+}
+
+func (o *overlayFS) Stat(name string) (os.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stat(overlayKey(name))
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case memDir:
+		return nil, &os.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	case memFile:
+		return append([]byte(nil), data...), nil
+	}
+	return os.ReadFile(disk)
+}
+
+func (o *overlayFS) readDir(p string) ([]os.DirEntry, error) {
+	_, memFile, memDir, disk, ok := o.locate(p)
+	if !ok || memFile {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+	names := map[string]bool{}
+	if !memDir {
+		entries, err := os.ReadDir(disk)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			names[e.Name()] = true
+		}
+	}
+	for k := range o.dirs {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.files {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.moved {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	var result []os.DirEntry
+	for name := range names {
+		if info, err := o.stat(filepath.Join(p, name)); err == nil {
+			result = append(result, fs.FileInfoToDirEntry(info))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (o *overlayFS) ReadDir(name string) ([]os.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readDir(overlayKey(name))
+}
+
+// Walk follows filepath.Walk: lexical order, SkipDir and SkipAll honored.
+// The lock is released while fn runs so the callback can change the overlay.
+func (o *overlayFS) Walk(root string, fn filepath.WalkFunc) error {
+	info, err := o.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = o.walk(root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (o *overlayFS) walk(path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	entries, err := o.ReadDir(path)
+	if err1 := fn(path, info, err); err != nil || err1 != nil {
+		return err1
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		childInfo, err := o.Stat(child)
+		if err != nil {
+			if err := fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := o.walk(child, childInfo, fn); err != nil {
+			if err == filepath.SkipDir && childInfo.IsDir() {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *overlayFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	op := "create"
+	if info, err := o.stat(p); err == nil {
+		if info.IsDir() {
+			return &os.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+		}
+		op = "write"
+	}
+	if info, err := o.stat(filepath.Dir(p)); err != nil || !info.IsDir() {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	o.files[p] = append([]byte(nil), data...)
+	o.changes = append(o.changes, fsChange{op, p, fmt.Sprintf("%d bytes", len(data))})
+	return nil
+}
+
+func (o *overlayFS) MkdirAll(path string, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	created := false
+	for q := p; ; q = filepath.Dir(q) {
+		if info, err := o.stat(q); err == nil {
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: q, Err: errors.New("not a directory")}
+			}
+			break
+		}
+		o.dirs[q] = true
+		created = true
+		if filepath.Dir(q) == q {
+			break
+		}
+	}
+	if created {
+		o.changes = append(o.changes, fsChange{"mkdir", p, ""})
+	}
+	return nil
+}
+
+// shift moves every overlay entry under from to the same place under to
+func (o *overlayFS) shift(from, to string) {
+	for k, v := range o.files {
+		if isUnder(k, from) {
+			delete(o.files, k)
+			o.files[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, from) {
+			delete(o.dirs, k)
+			o.dirs[to+strings.TrimPrefix(k, from)] = true
+		}
+	}
+	for k, v := range o.moved {
+		if isUnder(k, from) {
+			delete(o.moved, k)
+			o.moved[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+}
+
+func (o *overlayFS) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	from, to := overlayKey(oldpath), overlayKey(newpath)
+	_, _, _, disk, ok := o.locate(from)
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if from == to {
+		return nil
+	}
+	if info, err := o.stat(filepath.Dir(to)); err != nil || !info.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	o.drop(to)
+	o.shift(from, to)
+	if disk != "" {
+		if _, err := os.Stat(disk); err == nil {
+			o.moved[to] = disk
+		}
+	}
+	o.removed[from] = true
+	o.changes = append(o.changes, fsChange{"rename", from, to})
+	return nil
+}
+
+// drop forgets p and everything below it and hides it from the disk
+func (o *overlayFS) drop(p string) {
+	for k := range o.files {
+		if isUnder(k, p) {
+			delete(o.files, k)
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, p) {
+			delete(o.dirs, k)
+		}
+	}
+	for k := range o.moved {
+		if isUnder(k, p) {
+			delete(o.moved, k)
+		}
+	}
+	o.removed[p] = true
+}
+
+// treeSize is the byte size of p as the overlay currently sees it
+func (o *overlayFS) treeSize(p string) int64 {
+	info, err := o.stat(p)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var size int64
+	entries, _ := o.readDir(p)
+	for _, e := range entries {
+		size += o.treeSize(filepath.Join(p, e.Name()))
+	}
+	return size
+}
+
+func (o *overlayFS) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	info, err := o.stat(p)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	if info.IsDir() {
+		if entries, _ := o.readDir(p); len(entries) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", info.Size())})
+	return nil
+}
+
+func (o *overlayFS) RemoveAll(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	if _, err := o.stat(p); err != nil {
+		return nil
+	}
+	size := o.treeSize(p)
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", size)})
+	return nil
+}
+
+func (o *overlayFS) Chmod(name string, mode os.FileMode) error {
+	if _, err := o.Stat(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+// printDryRunReport lists what the overlay absorbed, relative to base
+func (o *overlayFS) printDryRunReport(base string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if abs, err := filepath.Abs(base); err == nil {
+		base = abs
+	}
+	if len(o.changes) == 0 {
+		fmt.Println(tr("\n[Dry Run] No changes would be made."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d change(s) would be made; nothing was written:\n"), len(o.changes))
+	rel := func(p string) string {
+		if r, err := filepath.Rel(base, p); err == nil && !strings.HasPrefix(r, "..") {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	for _, c := range o.changes {
+		switch c.op {
+		case "rename":
+			fmt.Printf("  %-7s %s -> %s\n", c.op, rel(c.path), rel(c.detail))
+		case "mkdir":
+			fmt.Printf("  %-7s %s\n", c.op, rel(c.path))
+		default:
+			fmt.Printf("  %-7s %s (%s)\n", c.op, rel(c.path), c.detail)
+		}
+	}
+}
+
+// memFileInfo describes a file or directory that only exists in the overlay
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (m memFileInfo) Name() string       { return m.name }
+func (m memFileInfo) Size() int64        { return m.size }
+func (m memFileInfo) ModTime() time.Time { return time.Time{} }
+func (m memFileInfo) IsDir() bool        { return m.dir }
+func (m memFileInfo) Sys() interface{}   { return nil }
+func (m memFileInfo) Mode() os.FileMode {
+	if m.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// renamedFileInfo is a disk entry seen under the name it was renamed to
+type renamedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (r renamedFileInfo) Name() string { return r.name }
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"  SELECT CATEGORIES TO REMOVE":  "  选择要移除的类别",
+	"  [0] All categories (default)": "  [0] 全部类别 (默认)",
+	"%d/%d in manifest":              "清单中有 %d/%d 个",
+	"\nEnter numbers separated by commas (e.g. 1,3,5), or Enter for all: ": "\n输入以逗号分隔的编号 (例如 1,3,5)，直接回车选择全部: ",
+	"  PACKAGES TO REMOVE":                              "  待移除的包",
+	"  (none — all listed packages are already absent)": "  (无 — 列出的包均已不存在)",
+	"\n  Total to remove: %d\n":                         "\n  待移除合计: %d\n",
+	"  Remaining after: %d packages\n":                  "  移除后剩余: %d 个包\n",
+	"[WARNING] %s checkout failed for %s: %v %s\n":      "[WARNING] %s 签出失败 (%s): %v %s\n",
+	"[VCS] Checked out (%s): %s\n":                      "[VCS] 已签出 (%s): %s\n",
+	"\nPress Enter to exit...":                          "\n按回车键退出...",
+
+	"[ERROR] Cannot get current directory: %v\n":                          "[ERROR] 无法获取当前目录: %v\n",
+	"  Remove Unity Packages":                                             "  移除 Unity 包",
+	"Target: %s\n":                                                        "目标: %s\n",
+	"[Dry Run] No files will be modified":                                 "[Dry Run] 不会修改任何文件",
+	"\nRemovable packages by category:\n":                                 "\n按类别列出可移除的包:\n",
+	"\n[ERROR] Current directory does not appear to be a Unity project.":  "\n[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":              "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"Please run this tool from the Unity project root.":                   "请在 Unity 项目根目录中运行此工具。",
+	"Version control: %s (%s)\n":                                          "版本控制: %s (%s)\n",
+	"\n[ERROR] Cannot read %s: %v\n":                                      "\n[ERROR] 无法读取 %s: %v\n",
+	"\nFound %d packages in manifest\n":                                   "\n清单中共有 %d 个包\n",
+	"\nNothing to remove.":                                                "\n没有需要移除的包。",
+	"\n[Dry Run] No files were modified.":                                 "\n[Dry Run] 未修改任何文件。",
+	"\nProceed with removal? (y/N): ":                                     "\n是否继续移除？(y/N): ",
+	"Operation cancelled.":                                                "操作已取消。",
+	"[WARNING] Failed to create backup: %v\n":                             "[WARNING] 创建备份失败: %v\n",
+	"Continue without backup? (y/N): ":                                    "不备份继续吗？(y/N): ",
+	"[OK] Backup: %s\n":                                                   "[OK] 备份: %s\n",
+	"  [OK] Removed: %s\n":                                                "  [OK] 已移除: %s\n",
+	"  [--] Not found in dependencies block: %s\n":                        "  [--] dependencies 中未找到: %s\n",
+	"\n[ERROR] Failed to write manifest: %v\n":                            "\n[ERROR] 写入清单失败: %v\n",
+	"  REMOVAL COMPLETE":                                                  "  移除完成",
+	"  Removed:   %d packages\n":                                          "  已移除:   %d 个包\n",
+	"  Remaining: %d packages\n":                                          "  剩余:     %d 个包\n",
+	"  Backup:    %s\n":                                                   "  备份:     %s\n",
+	"  Time:      %s\n":                                                   "  耗时:     %s\n",
+	"\n  [TIP] packages-lock.json exists.":                                "\n  [TIP] 存在 packages-lock.json。",
+	"  Unity will regenerate it automatically when you open the project.": "  打开项目时 Unity 会自动重新生成它。",
+	"\n  Please open Unity to let it resolve the updated manifest.":       "\n  请打开 Unity 以解析更新后的清单。",
+
+	"\n[Dry Run] No changes would be made.":                          "\n[Dry Run] 不会做任何更改。",
+	"\n[Dry Run] %d change(s) would be made; nothing was written:\n": "\n[Dry Run] 将会进行 %d 项更改，未写入任何内容:\n",
+
+	"[WARNING] Removing a stale %s (%s)\n":                      "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n": "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+
+	"Categories: %s\n": "类别: %s\n",
+	"[WARNING] Cannot refresh the policy, using the copy cached %s: %v\n": "[WARNING] 无法刷新策略，使用 %s 缓存的副本: %v\n",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	if status == "ok" && isDryRun() {
+		status = "planned"
+	}
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
 // ============================================================
 // Entry Point
 // ============================================================
@@ -292,16 +1686,32 @@ func waitForKeyPress() {
 func main() {
 	var (
 		dryRun      bool
+		force       bool
 		ciMode      bool
 		interactive bool
 		listMode    bool
+		vcsMode     string
 	)
 
 	flag.BoolVar(&dryRun, "dry-run", false, "Preview changes without modifying files")
+	flag.BoolVar(&force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
 	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no prompts, removes all)")
 	flag.BoolVar(&interactive, "i", false, "Interactive mode: select categories to remove")
 	flag.BoolVar(&listMode, "list", false, "List all removable packages and exit")
+	flag.StringVar(&vcsMode, "vcs", "auto", "Checkout before writing: auto, none, p4, plastic")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
 	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("remove_unity_packages")
+		ciMode = true
+	}
 
 	// Also support legacy DRY_RUN env var
 	if strings.EqualFold(os.Getenv("DRY_RUN"), "1") {
@@ -310,25 +1720,44 @@ func main() {
 
 	basePath, err := os.Getwd()
 	if err != nil {
-		fmt.Printf("[ERROR] Cannot get current directory: %v\n", err)
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
 		if !ciMode {
 			waitForKeyPress()
 		}
-		os.Exit(1)
+		exitTool(1)
 	}
 
-	fmt.Println("=============================================")
-	fmt.Println("  Remove Unity Packages")
-	fmt.Println("=============================================")
-	fmt.Printf("Target: %s\n", basePath)
+	printRule("=============================================")
+	fmt.Println(tr("  Remove Unity Packages"))
+	printRule("=============================================")
+	fmt.Printf(tr("Target: %s\n"), basePath)
+
+	// A studio policy can replace the categories
+	policy, policyOrigin, err := loadPolicy(basePath)
+	if err == nil {
+		err = applyPackagePolicy(policy)
+	}
+	if err != nil {
+		fmt.Printf(tr("\n[ERROR] %v\n"), err)
+		recordError("%v", err)
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(1)
+	}
+	if policy != nil && policy.Packages != nil && len(policy.Packages.Categories) > 0 {
+		fmt.Printf(tr("Categories: %s\n"), policyOrigin)
+	}
 
 	if dryRun {
-		fmt.Println("[Dry Run] No files will be modified")
+		fmt.Println(tr("[Dry Run] No files will be modified"))
+		fsys = newOverlayFS()
 	}
 
 	// List mode
 	if listMode {
-		fmt.Println("\nRemovable packages by category:\n")
+		fmt.Println(tr("\nRemovable packages by category:\n"))
 		for _, cat := range categories {
 			fmt.Printf("[%s]\n", cat.name)
 			for _, pkg := range cat.packages {
@@ -344,33 +1773,61 @@ func main() {
 
 	// Validate Unity project
 	if !isUnityProject(basePath) {
-		fmt.Println("\n[ERROR] Current directory does not appear to be a Unity project.")
-		fmt.Println("Expected 'Assets/' and 'ProjectSettings/' directories.")
-		fmt.Println("Please run this tool from the Unity project root.")
+		fmt.Println(tr("\n[ERROR] Current directory does not appear to be a Unity project."))
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		fmt.Println(tr("Please run this tool from the Unity project root."))
+		recordError("not a Unity project: %s", basePath)
 		if !ciMode {
 			waitForKeyPress()
 		}
-		os.Exit(1)
+		exitTool(1)
+	}
+
+	// Perforce / Plastic SCM checkout for files that will be rewritten
+	activeVCS, err = detectVCS(basePath, vcsMode)
+	if err != nil {
+		fmt.Printf("\n[ERROR] %v\n", err)
+		recordError("%v", err)
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(1)
+	}
+	if activeVCS.kind != vcsNone {
+		fmt.Printf(tr("Version control: %s (%s)\n"), activeVCS.kind, activeVCS.source)
+	}
+	if !dryRun {
+		if err := acquireProjectLock(basePath, "remove_unity_packages", "remove packages", force); err != nil {
+			fmt.Printf("\n[ERROR] %v\n", err)
+			recordError("%v", err)
+			if !ciMode {
+				waitForKeyPress()
+			}
+			exitTool(1)
+		}
+		defer releaseProjectLock()
 	}
 
 	// Read manifest
 	manifestPath := filepath.Join(basePath, "Packages", "manifest.json")
 	content, err := os.ReadFile(manifestPath)
 	if err != nil {
-		fmt.Printf("\n[ERROR] Cannot read %s: %v\n", manifestPath, err)
+		fmt.Printf(tr("\n[ERROR] Cannot read %s: %v\n"), manifestPath, err)
+		recordError("cannot read %s: %v", manifestPath, err)
 		if !ciMode {
 			waitForKeyPress()
 		}
-		os.Exit(1)
+		exitTool(1)
 	}
 
 	// Parse existing packages
-	existingPackages := readDependencies(string(content))
+	manifestText, manifestFormat := decodeText(content)
+	existingPackages := readDependencies(manifestText)
 	existingSet := make(map[string]bool)
 	for _, pkg := range existingPackages {
 		existingSet[pkg] = true
 	}
-	fmt.Printf("\nFound %d packages in manifest\n", len(existingPackages))
+	fmt.Printf(tr("\nFound %d packages in manifest\n"), len(existingPackages))
 
 	// Determine which packages to remove
 	var removeSet map[string]bool
@@ -401,29 +1858,20 @@ func main() {
 	printPreview(toRemove, kept)
 
 	if len(toRemove) == 0 {
-		fmt.Println("\nNothing to remove.")
-		if !ciMode {
-			waitForKeyPress()
-		}
-		return
-	}
-
-	// Dry-run stops here
-	if dryRun {
-		fmt.Println("\n[Dry Run] No files were modified.")
+		fmt.Println(tr("\nNothing to remove."))
 		if !ciMode {
 			waitForKeyPress()
 		}
 		return
 	}
 
-	// Confirmation
-	if !ciMode {
-		fmt.Print("\nProceed with removal? (y/N): ")
+	// Confirmation; a dry run goes straight to the overlay
+	if !ciMode && !dryRun {
+		fmt.Print(tr("\nProceed with removal? (y/N): "))
 		confirm, _ := stdinReader.ReadString('\n')
 		confirm = strings.TrimSpace(strings.ToLower(confirm))
 		if confirm != "y" {
-			fmt.Println("Operation cancelled.")
+			fmt.Println(tr("Operation cancelled."))
 			waitForKeyPress()
 			return
 		}
@@ -432,49 +1880,59 @@ func main() {
 	// Create backup
 	backupPath, backupErr := createBackup(manifestPath)
 	if backupErr != nil {
-		fmt.Printf("[WARNING] Failed to create backup: %v\n", backupErr)
+		fmt.Printf(tr("[WARNING] Failed to create backup: %v\n"), backupErr)
+		recordAction("backup", manifestPath+".bak", "failed", backupErr.Error(), 0)
 		if !ciMode {
-			fmt.Print("Continue without backup? (y/N): ")
+			fmt.Print(tr("Continue without backup? (y/N): "))
 			cont, _ := stdinReader.ReadString('\n')
 			cont = strings.TrimSpace(strings.ToLower(cont))
 			if cont != "y" {
-				fmt.Println("Operation cancelled.")
+				fmt.Println(tr("Operation cancelled."))
 				waitForKeyPress()
 				return
 			}
 		}
 	} else {
-		fmt.Printf("[OK] Backup: %s\n", backupPath)
+		fmt.Printf(tr("[OK] Backup: %s\n"), backupPath)
+		recordAction("backup", backupPath, "ok", "", 0)
+		recordArtifact(backupPath)
 	}
 
 	// Remove packages using text-based replacement (preserves key order)
 	startTime := time.Now()
-	text := string(content)
+	text := manifestText
 	removedCount := 0
 
 	for _, pkg := range toRemove {
 		before := text
 		text = removeDependencyLine(text, pkg)
 		if text != before {
-			fmt.Printf("  [OK] Removed: %s\n", pkg)
+			fmt.Printf(tr("  [OK] Removed: %s\n"), pkg)
+			recordAction("remove-package", pkg, "ok", "", 0)
 			removedCount++
 		} else {
-			fmt.Printf("  [--] Not found in dependencies block: %s\n", pkg)
+			fmt.Printf(tr("  [--] Not found in dependencies block: %s\n"), pkg)
+			recordAction("remove-package", pkg, "skipped", "not found in dependencies block", 0)
 		}
 	}
 
 	// Write updated manifest
 	if removedCount > 0 {
-		if err := os.WriteFile(manifestPath, []byte(text), 0644); err != nil {
-			fmt.Printf("\n[ERROR] Failed to write manifest: %v\n", err)
+		if err := writeFileAtomic(manifestPath, manifestFormat.encode(text)); err != nil {
+			fmt.Printf(tr("\n[ERROR] Failed to write manifest: %v\n"), err)
+			recordError("failed to write manifest: %v", err)
 			if !ciMode {
 				waitForKeyPress()
 			}
-			os.Exit(1)
+			exitTool(1)
 		}
 	}
 
 	duration := time.Since(startTime)
+	if removedCount > 0 {
+		recordAction("write", manifestPath, "ok", fmt.Sprintf("%d packages removed", removedCount), duration)
+		recordArtifact(manifestPath)
+	}
 
 	// Check for packages-lock.json
 	lockPath := filepath.Join(basePath, "Packages", "packages-lock.json")
@@ -484,20 +1942,25 @@ func main() {
 	}
 
 	// Summary
-	fmt.Println("\n===========================================")
-	fmt.Println("  REMOVAL COMPLETE")
-	fmt.Println("===========================================")
-	fmt.Printf("  Removed:   %d packages\n", removedCount)
-	fmt.Printf("  Remaining: %d packages\n", len(kept))
-	fmt.Printf("  Backup:    %s\n", backupPath)
-	fmt.Printf("  Time:      %s\n", duration.Round(time.Millisecond))
+	printRule("\n===========================================")
+	fmt.Println(tr("  REMOVAL COMPLETE"))
+	printRule("===========================================")
+	fmt.Printf(tr("  Removed:   %d packages\n"), removedCount)
+	fmt.Printf(tr("  Remaining: %d packages\n"), len(kept))
+	fmt.Printf(tr("  Backup:    %s\n"), backupPath)
+	fmt.Printf(tr("  Time:      %s\n"), duration.Round(time.Millisecond))
 
 	if hasLock {
-		fmt.Println("\n  [TIP] packages-lock.json exists.")
-		fmt.Println("  Unity will regenerate it automatically when you open the project.")
+		fmt.Println(tr("\n  [TIP] packages-lock.json exists."))
+		fmt.Println(tr("  Unity will regenerate it automatically when you open the project."))
 	}
 
-	fmt.Println("\n  Please open Unity to let it resolve the updated manifest.")
+	fmt.Println(tr("\n  Please open Unity to let it resolve the updated manifest."))
+
+	if overlay, ok := fsys.(*overlayFS); ok {
+		overlay.printDryRunReport(basePath)
+		fmt.Println(tr("\n[Dry Run] No files were modified."))
+	}
 
 	if !ciMode {
 		waitForKeyPress()
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestManifestFormatRoundTrip removes a package from a manifest in each
+// encoding and checks the BOM and line endings survive the rewrite
+func TestManifestFormatRoundTrip(t *testing.T) {
+	const manifest = "{\n  \"dependencies\": {\n    \"com.unity.ads\": \"4.4.2\",\n    \"com.unity.ugui\": \"2.0.0\"\n  }\n}\n"
+	const want = "{\n  \"dependencies\": {\n    \"com.unity.ugui\": \"2.0.0\"\n  }\n}\n"
+	cases := []struct {
+		name   string
+		format textFormat
+	}{
+		{"LF", textFormat{}},
+		{"CRLF", textFormat{crlf: true}},
+		{"BOM+LF", textFormat{bom: true}},
+		{"BOM+CRLF", textFormat{bom: true, crlf: true}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := tc.format.encode(manifest)
+			text, format := decodeText(data)
+			if format != tc.format {
+				t.Fatalf("decodeText format = %+v, want %+v", format, tc.format)
+			}
+			if got := format.encode(text); !bytes.Equal(got, data) {
+				t.Fatalf("encode(decodeText(x)) = %q, want %q", got, data)
+			}
+			got := format.encode(removeDependencyLine(text, "com.unity.ads"))
+			if wantBytes := tc.format.encode(want); !bytes.Equal(got, wantBytes) {
+				t.Errorf("got  %q\nwant %q", got, wantBytes)
+			}
+		})
+	}
+}
+
+// TestManifestMixedLineEndings removes a package from a manifest whose lines
+// end in both CRLF and LF; every remaining line keeps its own ending
+func TestManifestMixedLineEndings(t *testing.T) {
+	const manifest = "{\r\n  \"dependencies\": {\n    \"com.unity.ads\": \"4.4.2\",\r\n    \"com.unity.ugui\": \"2.0.0\"\n  }\r\n}\n"
+	const want = "{\r\n  \"dependencies\": {\n    \"com.unity.ugui\": \"2.0.0\"\n  }\r\n}\n"
+	text, format := decodeText([]byte(manifest))
+	if got := format.encode(text); string(got) != manifest {
+		t.Fatalf("encode(decodeText(x)) = %q, want %q", got, manifest)
+	}
+	if got := format.encode(removeDependencyLine(text, "com.unity.ads")); string(got) != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
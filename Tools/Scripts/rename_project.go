@@ -1,17 +1,25 @@
 package main
 
 import (
+	"archive/zip"
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -23,6 +31,14 @@ const (
 	stateFileName  = ".rename_project.json"
 	backupDirName  = ".rename_backup"
 	maxBackupCount = 5
+
+	// Written into every backup archive next to the files
+	backupManifestName = "backup.json"
+
+	// Shared, company-wide values (author, license, year); the env var points at an
+	// explicit file, otherwise the nearest one above the project root wins
+	sharedConfigFileName = ".unitystarter.json"
+	sharedConfigEnvVar   = "UNITYSTARTER_CONFIG"
 )
 
 var namePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
@@ -61,6 +77,17 @@ var excludedDirs = map[string]bool{
 	"DOTween":      true,
 }
 
+// Folders that commonly hold scenes directly under Assets/ but are never the project folder
+var genericSceneDirs = map[string]bool{
+	"Scenes": true,
+	"Levels": true,
+	"Maps":   true,
+}
+
+// Runner-up score (as a percentage of the best score) at which folder detection
+// stops guessing and asks the user to choose
+const ambiguousScorePercent = 80
+
 // Global stdin reader to avoid multiple buffered readers competing for stdin
 var stdinReader *bufio.Reader
 
@@ -79,6 +106,50 @@ type RenameState struct {
 	CompanyName   string `json:"companyName"`
 	AppName       string `json:"appName"`
 	RenamedAt     string `json:"renamedAt"`
+
+	// Assets whose text shows the names to players; edited by hand, kept on save
+	DisplayNameAssets []DisplayNameAsset `json:"displayNameAssets,omitempty"`
+}
+
+// DisplayNameAsset names a prefab, scene or ScriptableObject with the studio or
+// app name in its serialized text (title screen, about box, credits)
+type DisplayNameAsset struct {
+	Asset  string   `json:"asset"`            // "Assets/.../MainMenu.prefab" or the asset's GUID
+	Fields []string `json:"fields,omitempty"` // serialized field names; default m_Text and m_text
+}
+
+// SharedConfig holds the values used to fill #AUTHOR#, #YEAR# and #LICENSE#
+// placeholders. Read from .unitystarter.json; flags override each field.
+type SharedConfig struct {
+	Author        string `json:"author"`
+	License       string `json:"license"`       // SPDX identifier, e.g. "MIT"
+	CopyrightYear string `json:"copyrightYear"` // defaults to the current year
+}
+
+// RenameConfig is a rename.json or rename.yaml given with -config: the new names
+// (empty keeps the current one) and bundle IDs for single platforms, keyed by
+// Unity's platform name once loaded
+type RenameConfig struct {
+	Project   string            `json:"project"`
+	Company   string            `json:"company"`
+	App       string            `json:"app"`
+	BundleIDs map[string]string `json:"bundleIds"`
+}
+
+// backupManifest is saved as backup.json in each backup archive, so a restore by
+// hand knows which rename the files came before. Keep field names stable:
+// archives outlive the tool version that wrote them.
+type backupManifest struct {
+	CreatedAt string      `json:"createdAt"`
+	From      renameNames `json:"from"`
+	To        renameNames `json:"to"`
+	Files     []string    `json:"files"` // paths in the archive; _parent/ is the folder above the project
+}
+
+type renameNames struct {
+	ProjectFolder string `json:"projectFolder"`
+	CompanyName   string `json:"companyName"`
+	AppName       string `json:"appName"`
 }
 
 // FileChange describes a planned modification for dry-run preview
@@ -101,7 +172,7 @@ type Logger struct {
 func NewLogger(logPath string) *Logger {
 	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
-		fmt.Printf("Warning: could not create log file %s: %v\n", logPath, err)
+		fmt.Printf(tr("Warning: could not create log file %s: %v\n"), logPath, err)
 		return &Logger{writer: os.Stdout}
 	}
 	return &Logger{file: f, writer: io.MultiWriter(os.Stdout, f)}
@@ -115,6 +186,17 @@ func (l *Logger) Println(args ...interface{}) {
 	fmt.Fprintln(l.writer, args...)
 }
 
+// Rule writes a separator line; plain mode keeps only its leading blank line
+func (l *Logger) Rule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Fprintln(l.writer)
+		}
+		return
+	}
+	fmt.Fprintln(l.writer, line)
+}
+
 func (l *Logger) Close() {
 	if l.file != nil {
 		l.file.Close()
@@ -127,12 +209,14 @@ func (l *Logger) Close() {
 
 func loadState(projectRoot string) (*RenameState, error) {
 	statePath := filepath.Join(projectRoot, stateFileName)
-	data, err := os.ReadFile(statePath)
+	data, err := fsys.ReadFile(statePath)
 	if err != nil {
 		return nil, err
 	}
+	// Tolerate a BOM added by editors such as Notepad
+	text, _ := decodeText(data)
 	var state RenameState
-	if err := json.Unmarshal(data, &state); err != nil {
+	if err := json.Unmarshal([]byte(text), &state); err != nil {
 		return nil, fmt.Errorf("invalid state file %s: %v", statePath, err)
 	}
 	return &state, nil
@@ -144,30 +228,31 @@ func saveState(projectRoot string, state *RenameState) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(statePath, data, 0644)
+	return writeJournaledFile(statePath, data)
 }
 
 // ============================================================
 // Project Detection
 // ============================================================
 
-// findProjectRoot scans for a Unity project root directory in the current or immediate subdirectories.
-func findProjectRoot() (string, error) {
-	if _, err := os.Stat("./Assets"); err == nil {
-		if _, err := os.Stat("./ProjectSettings"); err == nil {
-			return ".", nil
+// findProjectRoot scans for a Unity project root directory in dir or its immediate subdirectories.
+func findProjectRoot(dir string) (string, error) {
+	if _, err := fsys.Stat(filepath.Join(dir, "Assets")); err == nil {
+		if _, err := fsys.Stat(filepath.Join(dir, "ProjectSettings")); err == nil {
+			return dir, nil
 		}
 	}
 
-	entries, err := os.ReadDir(".")
+	entries, err := fsys.ReadDir(dir)
 	if err != nil {
 		return "", err
 	}
 	for _, entry := range entries {
 		if entry.IsDir() {
-			if _, err := os.Stat(filepath.Join(entry.Name(), "Assets")); err == nil {
-				if _, err := os.Stat(filepath.Join(entry.Name(), "ProjectSettings")); err == nil {
-					return entry.Name(), nil
+			sub := filepath.Join(dir, entry.Name())
+			if _, err := fsys.Stat(filepath.Join(sub, "Assets")); err == nil {
+				if _, err := fsys.Stat(filepath.Join(sub, "ProjectSettings")); err == nil {
+					return sub, nil
 				}
 			}
 		}
@@ -179,7 +264,7 @@ func findProjectRoot() (string, error) {
 // It uses multiple heuristics to identify the correct folder.
 func findMainProjectFolder(projectRoot, productName string) (string, error) {
 	assetsPath := filepath.Join(projectRoot, "Assets")
-	entries, err := os.ReadDir(assetsPath)
+	entries, err := fsys.ReadDir(assetsPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read Assets directory: %v", err)
 	}
@@ -215,7 +300,7 @@ func findMainProjectFolder(projectRoot, productName string) (string, error) {
 			reasons = append(reasons, "matches productName")
 		}
 
-		subEntries, err := os.ReadDir(dirPath)
+		subEntries, err := fsys.ReadDir(dirPath)
 		if err != nil {
 			continue
 		}
@@ -250,33 +335,33 @@ func findMainProjectFolder(projectRoot, productName string) (string, error) {
 
 		if hasAsmdef {
 			score += 30
-			reasons = append(reasons, "contains asmdef")
+			reasons = append(reasons, tr("contains asmdef"))
 		}
 		if hasEditor {
 			score += 20
-			reasons = append(reasons, "has Editor folder")
+			reasons = append(reasons, tr("has Editor folder"))
 		}
 		if hasScripts {
 			score += 15
-			reasons = append(reasons, "has Scripts folder")
+			reasons = append(reasons, tr("has Scripts folder"))
 		}
 		if hasBuiltIn {
 			score += 25
-			reasons = append(reasons, "has BuiltIn folder")
+			reasons = append(reasons, tr("has BuiltIn folder"))
 		}
 		if hasLiveContent {
 			score += 25
-			reasons = append(reasons, "has LiveContent folder")
+			reasons = append(reasons, tr("has LiveContent folder"))
 		}
 		if hasScenes {
 			score += 20
-			reasons = append(reasons, "has Scenes folder")
+			reasons = append(reasons, tr("has Scenes folder"))
 		}
 
 		asmdefCount := countAsmdefFiles(dirPath)
 		if asmdefCount > 0 {
 			score += asmdefCount * 10
-			reasons = append(reasons, fmt.Sprintf("contains %d asmdef files", asmdefCount))
+			reasons = append(reasons, fmt.Sprintf(tr("contains %d asmdef files"), asmdefCount))
 		}
 
 		if score > 0 {
@@ -292,21 +377,91 @@ func findMainProjectFolder(projectRoot, productName string) (string, error) {
 		return "", fmt.Errorf("could not find main project folder in Assets directory")
 	}
 
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
 	best := candidates[0]
-	for _, c := range candidates[1:] {
-		if c.score > best.score {
-			best = c
+
+	// A close runner-up means the heuristic cannot tell the folders apart
+	// (e.g. scenes or asmdefs live outside the project folder). Ask instead of guessing.
+	ambiguous := len(candidates) > 1 &&
+		!strings.EqualFold(best.name, productName) &&
+		candidates[1].score*100 >= best.score*ambiguousScorePercent
+	if !ambiguous {
+		fmt.Printf(tr("Detected main project folder: %s (score: %d, reason: %s)\n"), best.name, best.score, best.reason)
+		return best.name, nil
+	}
+
+	fmt.Println(tr("\nSeveral folders in Assets/ look like the main project folder:"))
+	for i, c := range candidates {
+		fmt.Printf(tr("  [%d] %s (score: %d, reason: %s)\n"), i+1, c.name, c.score, c.reason)
+	}
+	fmt.Println(tr("Tip: pass -assets-folder <Name> or -assets-glob <pattern> to skip this question."))
+	for {
+		fmt.Printf(tr("Select the project folder [1-%d] (Enter = 1): "), len(candidates))
+		input, _ := stdinReader.ReadString('\n')
+		input = strings.TrimSpace(input)
+		if input == "" {
+			return best.name, nil
+		}
+		if n, err := strconv.Atoi(input); err == nil && n >= 1 && n <= len(candidates) {
+			return candidates[n-1].name, nil
+		}
+		fmt.Printf(tr("Invalid selection: '%s'\n"), input)
+	}
+}
+
+// resolveProjectFolderOption turns -assets-folder / -assets-glob into a folder name
+// directly under Assets/. Returns "" when neither option was given.
+func resolveProjectFolderOption(projectRoot, assetsFolder, assetsGlob string) (string, error) {
+	assetsPath := filepath.Join(projectRoot, "Assets")
+
+	if assetsFolder != "" {
+		name := strings.Trim(filepath.ToSlash(assetsFolder), "/")
+		name = strings.TrimPrefix(name, "Assets/")
+		if name == "" || strings.Contains(name, "/") {
+			return "", fmt.Errorf("-assets-folder must name a folder directly under Assets/ (got '%s')", assetsFolder)
+		}
+		info, err := fsys.Stat(filepath.Join(assetsPath, name))
+		if err != nil || !info.IsDir() {
+			return "", fmt.Errorf("-assets-folder: Assets/%s does not exist", name)
+		}
+		return name, nil
+	}
+
+	if assetsGlob != "" {
+		pattern := strings.TrimPrefix(strings.Trim(filepath.ToSlash(assetsGlob), "/"), "Assets/")
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return "", fmt.Errorf("-assets-glob: invalid pattern '%s': %v", assetsGlob, err)
+		}
+		entries, err := fsys.ReadDir(assetsPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read Assets directory: %v", err)
+		}
+		var matches []string
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			if ok, _ := filepath.Match(pattern, entry.Name()); ok {
+				matches = append(matches, entry.Name())
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return "", fmt.Errorf("-assets-glob: no folder in Assets/ matches '%s'", pattern)
+		case 1:
+			return matches[0], nil
+		default:
+			return "", fmt.Errorf("-assets-glob: '%s' matches %d folders (%s), make the pattern more specific", pattern, len(matches), strings.Join(matches, ", "))
 		}
 	}
 
-	fmt.Printf("Detected main project folder: %s (score: %d, reason: %s)\n", best.name, best.score, best.reason)
-	return best.name, nil
+	return "", nil
 }
 
 // countAsmdefFiles counts .asmdef files recursively in a directory
 func countAsmdefFiles(dir string) int {
 	count := 0
-	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	fsys.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil
 		}
@@ -319,22 +474,35 @@ func countAsmdefFiles(dir string) int {
 }
 
 // getCurrentProjectInfo reads the current project settings.
-// Priority: state file > auto-detection > EditorBuildSettings fallback.
-func getCurrentProjectInfo(projectRoot string) (string, string, string, error) {
+// Priority for the project folder: -assets-folder/-assets-glob > state file >
+// auto-detection > EditorBuildSettings fallback.
+func getCurrentProjectInfo(projectRoot, assetsFolder, assetsGlob string) (string, string, string, error) {
+	// Priority 0: Explicit folder selection from the command line
+	override, err := resolveProjectFolderOption(projectRoot, assetsFolder, assetsGlob)
+	if err != nil {
+		return "", "", "", err
+	}
+
 	// Priority 1: Read from state file (reliable for re-runs)
 	state, err := loadState(projectRoot)
 	if err == nil && state.ProjectFolder != "" {
+		if override != "" {
+			if override != state.ProjectFolder {
+				fmt.Printf(tr("Using project folder '%s' from command line (state file says '%s')\n"), override, state.ProjectFolder)
+			}
+			return override, state.CompanyName, state.AppName, nil
+		}
 		folderPath := filepath.Join(projectRoot, "Assets", state.ProjectFolder)
-		if _, statErr := os.Stat(folderPath); statErr == nil {
-			fmt.Printf("Loaded project info from state file (%s)\n", stateFileName)
+		if _, statErr := fsys.Stat(folderPath); statErr == nil {
+			fmt.Printf(tr("Loaded project info from state file (%s)\n"), stateFileName)
 			return state.ProjectFolder, state.CompanyName, state.AppName, nil
 		}
-		fmt.Printf("Warning: state file references non-existent folder '%s', falling back to auto-detection\n", state.ProjectFolder)
+		fmt.Printf(tr("Warning: state file references non-existent folder '%s', falling back to auto-detection\n"), state.ProjectFolder)
 	}
 
 	// Priority 2: Auto-detect from ProjectSettings
 	projectSettingsPath := filepath.Join(projectRoot, "ProjectSettings", "ProjectSettings.asset")
-	projectSettingsBytes, err := os.ReadFile(projectSettingsPath)
+	projectSettingsBytes, err := fsys.ReadFile(projectSettingsPath)
 	if err != nil {
 		return "", "", "", fmt.Errorf("failed to read %s: %v", projectSettingsPath, err)
 	}
@@ -355,24 +523,37 @@ func getCurrentProjectInfo(projectRoot string) (string, string, string, error) {
 	}
 	appName := strings.TrimSpace(productNameMatches[1])
 
+	if override != "" {
+		fmt.Printf(tr("Using project folder from command line: %s\n"), override)
+		return override, companyName, appName, nil
+	}
+
 	// Use intelligent detection to find the main project folder
 	projectName, err := findMainProjectFolder(projectRoot, appName)
 	if err != nil {
 		// Fallback: try to extract from EditorBuildSettings
 		editorBuildSettingsPath := filepath.Join(projectRoot, "ProjectSettings", "EditorBuildSettings.asset")
-		editorBuildSettingsBytes, readErr := os.ReadFile(editorBuildSettingsPath)
+		editorBuildSettingsBytes, readErr := fsys.ReadFile(editorBuildSettingsPath)
 		if readErr != nil {
 			return "", "", "", fmt.Errorf("auto-detection failed and fallback read error: %v", readErr)
 		}
 		editorBuildSettingsContent := string(editorBuildSettingsBytes)
 
+		// Scenes may live in a shared folder (Assets/Scenes/...) rather than
+		// Assets/<Project>/Scenes/; only accept a folder that can be a project folder.
 		projectNameRegex := regexp.MustCompile(`path: Assets/([^/]+)/`)
-		projectNameMatches := projectNameRegex.FindStringSubmatch(editorBuildSettingsContent)
-		if len(projectNameMatches) < 2 {
-			return "", "", "", fmt.Errorf("could not detect project folder: %v", err)
+		projectName = ""
+		for _, m := range projectNameRegex.FindAllStringSubmatch(editorBuildSettingsContent, -1) {
+			name := strings.TrimSpace(m[1])
+			if !excludedDirs[name] && !genericSceneDirs[name] {
+				projectName = name
+				break
+			}
+		}
+		if projectName == "" {
+			return "", "", "", fmt.Errorf("could not detect project folder: %v (use -assets-folder to specify it)", err)
 		}
-		projectName = strings.TrimSpace(projectNameMatches[1])
-		fmt.Printf("Using fallback detection: %s\n", projectName)
+		fmt.Printf(tr("Using fallback detection: %s\n"), projectName)
 	}
 
 	return projectName, companyName, appName, nil
@@ -387,10 +568,10 @@ func getCurrentProjectInfo(projectRoot string) (string, string, string, error) {
 func promptValidatedInput(stepNum int, label, description, currentValue string) string {
 	for {
 		clearScreen()
-		fmt.Printf("Step %d: Enter the New %s\n", stepNum, label)
+		fmt.Printf(tr("Step %d: Enter the New %s\n"), stepNum, label)
 		fmt.Println(description)
-		fmt.Printf("\nCurrent value: %s\n", currentValue)
-		fmt.Print("Enter new value (press Enter to keep current): ")
+		fmt.Printf(tr("\nCurrent value: %s\n"), currentValue)
+		fmt.Print(tr("Enter new value (press Enter to keep current): "))
 
 		input, _ := stdinReader.ReadString('\n')
 		input = strings.TrimSpace(input)
@@ -400,9 +581,9 @@ func promptValidatedInput(stepNum int, label, description, currentValue string)
 		}
 
 		if !namePattern.MatchString(input) {
-			fmt.Printf("\nInvalid input: '%s'\n", input)
-			fmt.Println("Must only contain letters, numbers, underscores (_), and dashes (-).")
-			fmt.Println("Cannot start with a number or dash.")
+			fmt.Printf(tr("\nInvalid input: '%s'\n"), input)
+			fmt.Println(tr("Must only contain letters, numbers, underscores (_), and dashes (-)."))
+			fmt.Println(tr("Cannot start with a number or dash."))
 			waitForKeyPress()
 			continue
 		}
@@ -417,10 +598,10 @@ func promptValidatedInput(stepNum int, label, description, currentValue string)
 
 // collectFilesToBackup returns the list of files that will be modified.
 // Does NOT include the project folder itself (folder rename is easily reversible).
-func collectFilesToBackup(projectRoot, oldName string) []string {
+func collectFilesToBackup(projectRoot, oldName string, asm *assemblyRewriter) []string {
 	var files []string
 	addIfExists := func(path string) {
-		if _, err := os.Stat(path); err == nil {
+		if _, err := fsys.Stat(path); err == nil {
 			files = append(files, path)
 		}
 	}
@@ -428,32 +609,13 @@ func collectFilesToBackup(projectRoot, oldName string) []string {
 	// Project folder meta file
 	addIfExists(filepath.Join(projectRoot, "Assets", oldName+".meta"))
 
-	// Asmdef files in project folder
-	projectFolderPath := filepath.Join(projectRoot, "Assets", oldName)
-	filepath.Walk(projectFolderPath, func(path string, info os.FileInfo, err error) error {
-		if err == nil && !info.IsDir() && strings.HasSuffix(info.Name(), ".asmdef") {
-			files = append(files, path)
+	// Asmdef, asmref and script files that name a renamed assembly
+	files = append(files, asm.files...)
+	for _, path := range asm.files {
+		if asm.moves[path] != "" {
 			addIfExists(path + ".meta")
 		}
-		return nil
-	})
-
-	// Asmdef files outside project folder that reference old name
-	wordRegex := regexp.MustCompile(`\b` + regexp.QuoteMeta(oldName) + `\b`)
-	assetsPath := filepath.Join(projectRoot, "Assets")
-	filepath.Walk(assetsPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() || !strings.HasSuffix(info.Name(), ".asmdef") {
-			return nil
-		}
-		if strings.HasPrefix(path, projectFolderPath+string(os.PathSeparator)) {
-			return nil // already handled above
-		}
-		content, rErr := os.ReadFile(path)
-		if rErr == nil && wordRegex.Match(content) {
-			files = append(files, path)
-		}
-		return nil
-	})
+	}
 
 	// Config files
 	addIfExists(filepath.Join(projectRoot, "Assets", "Build", "Editor", "BuildPipeline", "BuildScript.cs"))
@@ -463,61 +625,97 @@ func collectFilesToBackup(projectRoot, oldName string) []string {
 	return files
 }
 
-func createBackup(projectRoot string, files []string) (string, error) {
+// createBackup zips the files a rename is about to change into a timestamped
+// archive under .rename_backup/, with a backup.json saying which rename it was for.
+// Returns the archive's path.
+func createBackup(projectRoot string, files []string, from, to renameNames) (string, error) {
 	if len(files) == 0 {
 		return "", nil
 	}
 
-	timestamp := time.Now().Format("2006-01-02_150405")
-	backupDir := filepath.Join(projectRoot, backupDirName, timestamp)
-	if err := os.MkdirAll(backupDir, 0755); err != nil {
+	now := time.Now()
+	backupDir := filepath.Join(projectRoot, backupDirName)
+	if err := fsys.MkdirAll(backupDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create backup directory: %v", err)
 	}
 
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	manifest := backupManifest{CreatedAt: now.Format("2006-01-02 15:04:05"), From: from, To: to}
+	seen := make(map[string]bool)
 	for _, filePath := range files {
 		relPath, err := filepath.Rel(projectRoot, filePath)
 		if err != nil {
 			relPath = filepath.Base(filePath)
 		}
-		destPath := filepath.Join(backupDir, relPath)
-		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-			return "", fmt.Errorf("failed to create backup subdirectory for %s: %v", relPath, err)
+		// Docs and pipelines above the Unity project (-docs, -ci) go under _parent/ inside the backup
+		for relPath == ".." || strings.HasPrefix(relPath, ".."+string(os.PathSeparator)) {
+			relPath = "_parent" + relPath[2:]
+		}
+		relPath = filepath.ToSlash(relPath)
+		if seen[relPath] {
+			continue
+		}
+		seen[relPath] = true
+
+		info, err := fsys.Stat(filePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to backup %s: %v", relPath, err)
+		}
+		data, err := fsys.ReadFile(filePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to backup %s: %v", relPath, err)
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: relPath, Method: zip.Deflate, Modified: info.ModTime()})
+		if err == nil {
+			_, err = w.Write(data)
 		}
-		if err := copyFile(filePath, destPath); err != nil {
+		if err != nil {
 			return "", fmt.Errorf("failed to backup %s: %v", relPath, err)
 		}
+		manifest.Files = append(manifest.Files, relPath)
 	}
 
-	cleanupOldBackups(filepath.Join(projectRoot, backupDirName))
-	return backupDir, nil
-}
-
-func copyFile(src, dst string) error {
-	data, err := os.ReadFile(src)
+	data, _ := json.MarshalIndent(manifest, "", "    ")
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: backupManifestName, Method: zip.Deflate, Modified: now})
+	if err == nil {
+		_, err = w.Write(append(data, '\n'))
+	}
+	if err == nil {
+		err = zw.Close()
+	}
 	if err != nil {
-		return err
+		return "", fmt.Errorf("failed to write backup archive: %v", err)
 	}
-	return os.WriteFile(dst, data, 0644)
+
+	archivePath := filepath.Join(backupDir, now.Format("2006-01-02_150405")+".zip")
+	if err := writeFileAtomic(archivePath, buf.Bytes()); err != nil {
+		return "", fmt.Errorf("failed to write backup archive: %v", err)
+	}
+
+	cleanupOldBackups(backupDir)
+	return archivePath, nil
 }
 
-// cleanupOldBackups keeps only the most recent backups
+// cleanupOldBackups keeps only the most recent backups: archives, and the
+// folders older versions of the tool copied files into
 func cleanupOldBackups(backupBaseDir string) {
-	entries, err := os.ReadDir(backupBaseDir)
+	entries, err := fsys.ReadDir(backupBaseDir)
 	if err != nil {
 		return
 	}
-	var dirs []string
+	var backups []string
 	for _, entry := range entries {
-		if entry.IsDir() {
-			dirs = append(dirs, entry.Name())
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".zip") {
+			backups = append(backups, entry.Name())
 		}
 	}
-	if len(dirs) <= maxBackupCount {
+	if len(backups) <= maxBackupCount {
 		return
 	}
-	sort.Strings(dirs)
-	for _, dir := range dirs[:len(dirs)-maxBackupCount] {
-		os.RemoveAll(filepath.Join(backupBaseDir, dir))
+	sort.Strings(backups)
+	for _, name := range backups[:len(backups)-maxBackupCount] {
+		fsys.RemoveAll(filepath.Join(backupBaseDir, name))
 	}
 }
 
@@ -525,74 +723,49 @@ func cleanupOldBackups(backupBaseDir string) {
 // Change Preview (Dry-Run)
 // ============================================================
 
-func previewChanges(projectRoot, oldName, newName, oldCompanyName, newCompanyName, oldAppName, newAppName string) []FileChange {
+func previewChanges(projectRoot, oldName, newName, oldCompanyName, newCompanyName, oldAppName, newAppName string, addrFiles []string, addr *addressablesRewriter, asm *assemblyRewriter, pkgs *packageRewriter, displayTargets []displayNameTarget, display *displayNameRewriter, ciFiles []string, ci *ciRewriter, nsFiles []string, ns *namespaceRewriter, docFiles []string, docs *docRewriter, templateFiles []string, vars map[string]string, storePlan []storeChange, bundleNotes []string) []FileChange {
 	var changes []FileChange
-	wordRegex := regexp.MustCompile(`\b` + regexp.QuoteMeta(oldName) + `\b`)
-
 	// 1. Folder rename
 	if oldName != newName {
 		changes = append(changes, FileChange{
 			Path:   filepath.Join("Assets", oldName),
 			Action: "rename",
 			Details: []string{
-				fmt.Sprintf("Rename folder: Assets/%s -> Assets/%s", oldName, newName),
-				fmt.Sprintf("Rename meta:   Assets/%s.meta -> Assets/%s.meta", oldName, newName),
+				fmt.Sprintf(tr("Rename folder: Assets/%s -> Assets/%s"), oldName, newName),
+				fmt.Sprintf(tr("Rename meta:   Assets/%s.meta -> Assets/%s.meta"), oldName, newName),
 			},
 		})
 	}
 
-	// 2. Asmdef files in project folder
-	projectFolderPath := filepath.Join(projectRoot, "Assets", oldName)
-	filepath.Walk(projectFolderPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() || !strings.HasSuffix(info.Name(), ".asmdef") {
-			return nil
-		}
+	// 2-3. Assembly names and the references to them; asmdef file renames
+	for _, path := range asm.files {
 		relPath, _ := filepath.Rel(projectRoot, path)
-		content, rErr := os.ReadFile(path)
+		text, _, rErr := readTextFile(path)
 		if rErr != nil {
-			return nil
-		}
-		var details []string
-		if wordRegex.MatchString(string(content)) {
-			details = append(details, "Update name/references containing '"+oldName+"'")
-		}
-		newFileName := wordRegex.ReplaceAllString(info.Name(), newName)
-		if newFileName != info.Name() {
-			details = append(details, fmt.Sprintf("Rename file: %s -> %s", info.Name(), newFileName))
+			continue
 		}
-		if len(details) > 0 {
-			changes = append(changes, FileChange{Path: relPath, Action: "modify", Details: details})
+		_, details := asm.rewrite(path, text)
+		if newPath := asm.moves[path]; newPath != "" {
+			details = append(details, fmt.Sprintf(tr("Rename file: %s -> %s"), filepath.Base(path), filepath.Base(newPath)))
 		}
-		return nil
-	})
+		changes = append(changes, FileChange{Path: relPath, Action: "modify", Details: details})
+	}
 
-	// 3. Asmdef files outside project folder
-	assetsPath := filepath.Join(projectRoot, "Assets")
-	filepath.Walk(assetsPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() || !strings.HasSuffix(info.Name(), ".asmdef") {
-			return nil
-		}
-		if strings.HasPrefix(path, projectFolderPath+string(os.PathSeparator)) {
-			return nil
-		}
-		content, rErr := os.ReadFile(path)
-		if rErr != nil {
-			return nil
+	// 4. Embedded package names and the references to them
+	for _, path := range pkgs.files {
+		text, _, err := readTextFile(path)
+		if err != nil {
+			continue
 		}
-		if wordRegex.MatchString(string(content)) {
+		if _, changed := pkgs.rewrite(path, text); len(changed) > 0 {
 			relPath, _ := filepath.Rel(projectRoot, path)
-			changes = append(changes, FileChange{
-				Path:    relPath,
-				Action:  "modify",
-				Details: []string{"Update references containing '" + oldName + "'"},
-			})
+			changes = append(changes, FileChange{Path: relPath, Action: "modify", Details: changed})
 		}
-		return nil
-	})
+	}
 
-	// 4. BuildScript.cs
+	// 5. BuildScript.cs
 	buildScriptPath := filepath.Join(projectRoot, "Assets", "Build", "Editor", "BuildPipeline", "BuildScript.cs")
-	if _, statErr := os.Stat(buildScriptPath); statErr == nil {
+	if _, statErr := fsys.Stat(buildScriptPath); statErr == nil {
 		var details []string
 		if oldCompanyName != newCompanyName {
 			details = append(details, fmt.Sprintf("CompanyName: \"%s\" -> \"%s\"", oldCompanyName, newCompanyName))
@@ -601,9 +774,9 @@ func previewChanges(projectRoot, oldName, newName, oldCompanyName, newCompanyNam
 			details = append(details, fmt.Sprintf("ApplicationName: \"%s\" -> \"%s\"", oldAppName, newAppName))
 		}
 		if oldName != newName {
-			details = append(details, fmt.Sprintf("Asset paths: Assets/%s/ -> Assets/%s/", oldName, newName))
+			details = append(details, fmt.Sprintf(tr("Asset paths: Assets/%s/ -> Assets/%s/"), oldName, newName))
 		}
-		if len(details) > 0 {
+		if len(details) > 0 && renameFilter.allows(buildScriptPath) {
 			changes = append(changes, FileChange{
 				Path:    filepath.Join("Assets", "Build", "Editor", "BuildPipeline", "BuildScript.cs"),
 				Action:  "modify",
@@ -612,7 +785,7 @@ func previewChanges(projectRoot, oldName, newName, oldCompanyName, newCompanyNam
 		}
 	}
 
-	// 5. ProjectSettings.asset
+	// 6. ProjectSettings.asset
 	{
 		var details []string
 		if oldCompanyName != newCompanyName {
@@ -626,7 +799,8 @@ func previewChanges(projectRoot, oldName, newName, oldCompanyName, newCompanyNam
 			newID := "com." + newCompanyName + "." + newAppName
 			details = append(details, fmt.Sprintf("applicationIdentifier: %s -> %s", oldID, newID))
 		}
-		if len(details) > 0 {
+		details = append(details, bundleNotes...)
+		if len(details) > 0 && renameFilter.allows(filepath.Join(projectRoot, "ProjectSettings", "ProjectSettings.asset")) {
 			changes = append(changes, FileChange{
 				Path:    filepath.Join("ProjectSettings", "ProjectSettings.asset"),
 				Action:  "modify",
@@ -635,32 +809,130 @@ func previewChanges(projectRoot, oldName, newName, oldCompanyName, newCompanyNam
 		}
 	}
 
-	// 6. EditorBuildSettings.asset
+	// 7. EditorBuildSettings.asset
 	if oldName != newName {
 		editorBuildSettingsPath := filepath.Join(projectRoot, "ProjectSettings", "EditorBuildSettings.asset")
-		if content, err := os.ReadFile(editorBuildSettingsPath); err == nil {
-			if strings.Contains(string(content), "Assets/"+oldName+"/") {
+		if content, err := fsys.ReadFile(editorBuildSettingsPath); err == nil {
+			if strings.Contains(string(content), "Assets/"+oldName+"/") && renameFilter.allows(editorBuildSettingsPath) {
 				changes = append(changes, FileChange{
 					Path:   filepath.Join("ProjectSettings", "EditorBuildSettings.asset"),
 					Action: "modify",
 					Details: []string{
-						fmt.Sprintf("Scene paths: Assets/%s/... -> Assets/%s/...", oldName, newName),
+						fmt.Sprintf(tr("Scene paths: Assets/%s/... -> Assets/%s/..."), oldName, newName),
 					},
 				})
 			}
 		}
 	}
 
+	// 8. Addressables profile values and build/load paths
+	for _, path := range addrFiles {
+		text, _, err := readTextFile(path)
+		if err != nil {
+			continue
+		}
+		if _, changed := addr.rewrite(text); len(changed) > 0 {
+			relPath, _ := filepath.Rel(projectRoot, path)
+			changes = append(changes, FileChange{Path: relPath, Action: "modify", Details: changed})
+		}
+	}
+
+	// 9. Display names in the assets listed in the state file
+	for _, t := range displayTargets {
+		text, _, err := readTextFile(t.path)
+		if err != nil {
+			continue
+		}
+		if _, changed := display.rewrite(text, t.fields); len(changed) > 0 {
+			relPath, _ := filepath.Rel(projectRoot, t.path)
+			changes = append(changes, FileChange{Path: relPath, Action: "modify", Details: changed})
+		}
+	}
+
+	// 10. CI pipeline definitions (-ci)
+	for _, path := range ciFiles {
+		text, _, err := readTextFile(path)
+		if err != nil {
+			continue
+		}
+		if _, changed := ci.rewrite(text); len(changed) > 0 {
+			relPath, _ := filepath.Rel(projectRoot, path)
+			changes = append(changes, FileChange{Path: relPath, Action: "modify", Details: changed})
+		}
+	}
+
+	// 11. C# namespace declarations and using directives (-namespaces)
+	for _, path := range nsFiles {
+		text, _, err := readTextFile(path)
+		if err != nil {
+			continue
+		}
+		if _, changed := ns.rewrite(text); len(changed) > 0 {
+			relPath, _ := filepath.Rel(projectRoot, path)
+			changes = append(changes, FileChange{Path: relPath, Action: "modify", Details: changed})
+		}
+	}
+
+	// 12. Markdown docs (-docs)
+	for _, path := range docFiles {
+		text, _, err := readTextFile(path)
+		if err != nil {
+			continue
+		}
+		if _, n := docs.rewrite(text); n > 0 {
+			relPath, _ := filepath.Rel(projectRoot, path)
+			changes = append(changes, FileChange{
+				Path:    relPath,
+				Action:  "modify",
+				Details: []string{fmt.Sprintf(tr("Update %d name/path reference(s)"), n)},
+			})
+		}
+	}
+
+	// 13. Template placeholders and SPDX license lines
+	for _, path := range templateFiles {
+		text, _, err := readTextFile(path)
+		if err != nil {
+			continue
+		}
+		if _, n := fillTemplateText(text, vars, ownsSPDX(projectRoot, path)); n > 0 {
+			relPath, _ := filepath.Rel(projectRoot, path)
+			changes = append(changes, FileChange{
+				Path:    relPath,
+				Action:  "modify",
+				Details: []string{fmt.Sprintf(tr("Fill %d template value(s) (author/year/license)"), n)},
+			})
+		}
+	}
+
+	// 14. Store metadata scaffold (-store)
+	if len(storePlan) > 0 {
+		var details []string
+		created := 0
+		for _, c := range storePlan {
+			if c.action == "update" {
+				rel, _ := filepath.Rel(filepath.Join(projectRoot, storeDirName), c.path)
+				details = append(details, fmt.Sprintf(tr("Update to the new names: %s"), filepath.ToSlash(rel)))
+			} else {
+				created++
+			}
+		}
+		if created > 0 {
+			details = append([]string{fmt.Sprintf(tr("Create %d fastlane metadata file(s)"), created)}, details...)
+		}
+		changes = append(changes, FileChange{Path: storeDirName, Action: "scaffold", Details: details})
+	}
+
 	return changes
 }
 
 func printPreview(log *Logger, changes []FileChange) {
-	log.Println("\n=============================================")
-	log.Println("  CHANGE PREVIEW")
-	log.Println("=============================================")
+	log.Rule("\n=============================================")
+	log.Println(tr("  CHANGE PREVIEW"))
+	log.Rule("=============================================")
 
 	if len(changes) == 0 {
-		log.Println("\nNo changes needed.")
+		log.Println(tr("\nNo changes needed."))
 		return
 	}
 
@@ -671,7 +943,122 @@ func printPreview(log *Logger, changes []FileChange) {
 		}
 	}
 
-	log.Printf("\nTotal: %d file(s) will be affected.\n", len(changes))
+	log.Printf(tr("\nTotal: %d file(s) will be affected.\n"), len(changes))
+}
+
+// ============================================================
+// Safety Checks
+// ============================================================
+
+// Old names this short, or ordinary words, also appear in text that has nothing to
+// do with the project, and every pass that matches words would replace them there
+const minDistinctNameLength = 4
+
+var commonWordNames = map[string]bool{
+	"app": true, "application": true, "assets": true, "client": true, "common": true,
+	"company": true, "core": true, "data": true, "default": true, "demo": true,
+	"editor": true, "example": true, "game": true, "main": true, "player": true,
+	"project": true, "runtime": true, "sample": true, "scripts": true, "server": true,
+	"template": true, "test": true, "tests": true, "tools": true, "ui": true,
+	"unity": true,
+}
+
+// Matching lines shown per risky name
+const riskSampleCount = 5
+
+// renameRisk is a case the user must confirm explicitly; samples are
+// "path:line: text" lines the passes will change
+type renameRisk struct {
+	message string
+	samples []string
+}
+
+// isCaseInsensitiveFS reports whether the project lives on a file system that
+// ignores case, where Assets and ASSETS are the same folder
+func isCaseInsensitiveFS(projectRoot string) bool {
+	return sameDiskEntry(filepath.Join(projectRoot, "Assets"), filepath.Join(projectRoot, "ASSETS"))
+}
+
+// findFolderCollision returns the folder under Assets/ (other than the project
+// folder) whose name equals newName, ignoring case; empty when there is none
+func findFolderCollision(projectRoot, oldName, newName string) string {
+	if oldName == newName {
+		return ""
+	}
+	entries, err := fsys.ReadDir(filepath.Join(projectRoot, "Assets"))
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != oldName && strings.EqualFold(entry.Name(), newName) {
+			return entry.Name()
+		}
+	}
+	return ""
+}
+
+// checkRenameRisks looks for renames that are easy to get wrong: a new folder name
+// that only differs in case from another folder, names that only change case, and
+// old names likely to match unrelated text in the files listed in the preview
+func checkRenameRisks(projectRoot string, names [][3]string, changes []FileChange) []renameRisk {
+	var risks []renameRisk
+	caseInsensitive := isCaseInsensitiveFS(projectRoot)
+
+	for _, n := range names {
+		label, oldValue, newValue := n[0], n[1], n[2]
+		if oldValue == newValue {
+			continue
+		}
+		if strings.EqualFold(oldValue, newValue) {
+			msg := fmt.Sprintf(tr("%s only changes case (%s -> %s): version control may not record it"), label, oldValue, newValue)
+			if caseInsensitive {
+				msg += tr(", and this file system ignores case, so other tools may keep the old spelling")
+			}
+			risks = append(risks, renameRisk{message: msg})
+			continue
+		}
+		if len(oldValue) >= minDistinctNameLength && !commonWordNames[strings.ToLower(oldValue)] {
+			continue
+		}
+		word := regexp.MustCompile(`\b` + regexp.QuoteMeta(oldValue) + `\b`)
+		risk := renameRisk{message: fmt.Sprintf(tr("%s '%s' is short or a common word, so unrelated text may be replaced too; matches in the files the rename edits:"), label, oldValue)}
+		for _, c := range changes {
+			if c.Action != "modify" || len(risk.samples) >= riskSampleCount {
+				continue
+			}
+			text, _, err := readTextFile(filepath.Join(projectRoot, c.Path))
+			if err != nil {
+				continue
+			}
+			for i, line := range strings.Split(text, "\n") {
+				if word.MatchString(line) {
+					line = strings.TrimSpace(line)
+					if r := []rune(line); len(r) > 100 {
+						line = string(r[:100]) + "..."
+					}
+					risk.samples = append(risk.samples, fmt.Sprintf("%s:%d: %s", filepath.ToSlash(c.Path), i+1, line))
+					if len(risk.samples) >= riskSampleCount {
+						break
+					}
+				}
+			}
+		}
+		risks = append(risks, risk)
+	}
+	return risks
+}
+
+func printRisks(log *Logger, risks []renameRisk) {
+	log.Rule("\n=============================================")
+	log.Println(tr("  SAFETY WARNINGS"))
+	log.Rule("=============================================")
+	for _, r := range risks {
+		log.Printf("\n[!] %s\n", r.message)
+		for _, sample := range r.samples {
+			log.Printf("    %s\n", sample)
+		}
+		recordAction("warn", r.message, "risk", strings.Join(r.samples, "\n"), 0)
+	}
 }
 
 // ============================================================
@@ -688,24 +1075,24 @@ func renameFolderAndMeta(oldFolderPath, newFolderPath string) error {
 		return fmt.Errorf("safety check failed: target folder is not in the same parent directory")
 	}
 
-	if _, err := os.Stat(newFolderPath); err == nil {
+	if _, err := fsys.Stat(newFolderPath); err == nil && !sameDiskEntry(oldFolderPath, newFolderPath) {
 		return fmt.Errorf(
 			"target folder already exists: %s\n"+
 				"  If this is from a failed previous run, please remove it manually and retry.\n"+
 				"  Path: %s", filepath.Base(newFolderPath), newFolderPath)
 	}
 
-	if err := os.Rename(oldFolderPath, newFolderPath); err != nil {
+	if err := moveEntry(oldFolderPath, newFolderPath); err != nil {
 		return fmt.Errorf("failed to rename folder: %v", err)
 	}
 
 	oldMetaPath := oldFolderPath + ".meta"
 	newMetaPath := newFolderPath + ".meta"
-	if _, err := os.Stat(oldMetaPath); err == nil {
-		if _, err := os.Stat(newMetaPath); err == nil {
+	if _, err := fsys.Stat(oldMetaPath); err == nil {
+		if _, err := fsys.Stat(newMetaPath); err == nil && !sameDiskEntry(oldMetaPath, newMetaPath) {
 			return fmt.Errorf("target meta file already exists: %s", newMetaPath)
 		}
-		if err := os.Rename(oldMetaPath, newMetaPath); err != nil {
+		if err := moveEntry(oldMetaPath, newMetaPath); err != nil {
 			return fmt.Errorf("failed to rename meta file (folder was already renamed): %v", err)
 		}
 	}
@@ -713,125 +1100,442 @@ func renameFolderAndMeta(oldFolderPath, newFolderPath string) error {
 	return nil
 }
 
-// ============================================================
-// Update Operations
-// ============================================================
+// sameDiskEntry reports whether two paths name one file or folder, as a case-only
+// rename does on a case-insensitive file system (Windows, macOS)
+func sameDiskEntry(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
 
-// updateAsmdefFilesInFolder updates all .asmdef files within the project folder.
-// Uses word-boundary regex for precise replacement, preserving JSON formatting.
-func updateAsmdefFilesInFolder(log *Logger, folderPath, oldProjectName, newProjectName string) error {
-	if oldProjectName == newProjectName {
+// moveEntry renames a file or folder. A rename that only changes case goes through
+// a temporary name, since a case-insensitive file system may treat it as a no-op.
+func moveEntry(oldPath, newPath string) error {
+	if oldPath == newPath || !strings.EqualFold(oldPath, newPath) {
+		if err := fsys.Rename(oldPath, newPath); err != nil {
+			return err
+		}
+		journal.recordMove(oldPath, newPath)
 		return nil
 	}
-	wordRegex := regexp.MustCompile(`\b` + regexp.QuoteMeta(oldProjectName) + `\b`)
-	var errors []string
+	tmpPath := oldPath + ".renaming"
+	if err := fsys.Rename(oldPath, tmpPath); err != nil {
+		return err
+	}
+	if err := fsys.Rename(tmpPath, newPath); err != nil {
+		fsys.Rename(tmpPath, oldPath)
+		return err
+	}
+	journal.recordMove(oldPath, newPath)
+	return nil
+}
 
-	filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() || !strings.HasSuffix(info.Name(), ".asmdef") {
-			return nil
-		}
+// ============================================================
+// Assembly Definitions
+// ============================================================
 
-		content, readErr := os.ReadFile(path)
-		if readErr != nil {
-			errors = append(errors, fmt.Sprintf("failed to read %s: %v", path, readErr))
-			return nil
-		}
+// assemblyRewriter renames the assemblies named after the project and rewires
+// what points at them by name: "references" in .asmdef files, "reference" in
+// .asmref files and InternalsVisibleTo in scripts ("GUID:" references need
+// nothing). Names are matched exactly, so a reference to an assembly that is
+// not renamed stays as it is. Assemblies are renamed under Assets/ only; the
+// references are also fixed in embedded packages (Packages/<folder>).
+type assemblyRewriter struct {
+	names        map[string]string // old assembly name -> new
+	moves        map[string]string // asmdef path -> new path, for files named after the project
+	rootNs       *regexp.Regexp    // "rootNamespace" values starting with the old namespace
+	oldNamespace string
+	newNamespace string
+	files        []string // .asmdef, .asmref and .cs files whose text changes
+}
 
-		contentStr := string(content)
-		newContent := wordRegex.ReplaceAllString(contentStr, newProjectName)
-		newFileName := wordRegex.ReplaceAllString(info.Name(), newProjectName)
+var (
+	jsonStringPattern         = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+	internalsVisibleToPattern = regexp.MustCompile(`(InternalsVisibleTo\(\s*")([^"]+)(")`)
+)
 
-		if newContent == contentStr && newFileName == info.Name() {
-			return nil // nothing to do
-		}
+// newAssemblyRewriter scans the project as it is on disk; after the folder
+// rename it is built again so the paths are current
+func newAssemblyRewriter(projectRoot, oldName, newName string) *assemblyRewriter {
+	rw := &assemblyRewriter{names: map[string]string{}, moves: map[string]string{}}
+	if oldName == newName {
+		return rw
+	}
+	wordRegex := regexp.MustCompile(`\b` + regexp.QuoteMeta(oldName) + `\b`)
+	if oldNamespace, newNamespace := namespaceName(oldName), namespaceName(newName); oldNamespace != newNamespace {
+		rw.rootNs = regexp.MustCompile(`("rootNamespace"\s*:\s*")` + regexp.QuoteMeta(oldNamespace) + `((?:\.[^"\\]*)?)"`)
+		rw.oldNamespace, rw.newNamespace = oldNamespace, newNamespace
+	}
 
-		// Validate the result is still valid JSON
-		var jsonCheck interface{}
-		if jsonErr := json.Unmarshal([]byte(newContent), &jsonCheck); jsonErr != nil {
-			errors = append(errors, fmt.Sprintf("JSON validation failed after update for %s: %v", path, jsonErr))
+	// Assemblies named after the project
+	assetsPath := filepath.Join(projectRoot, "Assets")
+	fsys.Walk(assetsPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.EqualFold(filepath.Ext(path), ".asmdef") || !renameFilter.allows(path) {
 			return nil
 		}
-
-		dir := filepath.Dir(path)
-		newPath := filepath.Join(dir, newFileName)
-
-		if err := os.WriteFile(newPath, []byte(newContent), 0644); err != nil {
-			errors = append(errors, fmt.Sprintf("failed to write %s: %v", newPath, err))
+		text, _, rErr := readTextFile(path)
+		if rErr != nil {
 			return nil
 		}
-
-		log.Printf("[OK] Updated asmdef: %s", filepath.Base(path))
-		if newFileName != info.Name() {
-			log.Printf(" -> %s", newFileName)
+		var asmdef struct {
+			Name string `json:"name"`
 		}
-		log.Println()
-
-		// Clean up old file and rename meta if filename changed
-		if newFileName != info.Name() && path != newPath {
-			os.Remove(path)
-			oldMetaPath := path + ".meta"
-			newMetaPath := newPath + ".meta"
-			if _, statErr := os.Stat(oldMetaPath); statErr == nil {
-				if renameErr := os.Rename(oldMetaPath, newMetaPath); renameErr != nil {
-					errors = append(errors, fmt.Sprintf("failed to rename meta: %s: %v", oldMetaPath, renameErr))
-				}
+		if json.Unmarshal([]byte(text), &asmdef) == nil && asmdef.Name != "" {
+			if name := wordRegex.ReplaceAllString(asmdef.Name, newName); name != asmdef.Name {
+				rw.names[asmdef.Name] = name
 			}
 		}
-
+		if fileName := wordRegex.ReplaceAllString(info.Name(), newName); fileName != info.Name() {
+			rw.moves[path] = filepath.Join(filepath.Dir(path), fileName)
+		}
 		return nil
 	})
 
-	if len(errors) > 0 {
-		return fmt.Errorf("encountered %d error(s): %s", len(errors), strings.Join(errors, "; "))
-	}
-	return nil
-}
-
-// updateAsmdefReferencesGlobally updates references in ALL .asmdef files across Assets/,
-// excluding the project folder (which is handled by updateAsmdefFilesInFolder).
-func updateAsmdefReferencesGlobally(log *Logger, assetsPath, projectFolderPath, oldProjectName, newProjectName string) error {
-	if oldProjectName == newProjectName {
-		return nil
+	// Every file that names one of them
+	for _, root := range assemblyRoots(projectRoot) {
+		fsys.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".asmdef", ".asmref", ".cs":
+			default:
+				return nil
+			}
+			text, _, rErr := readTextFile(path)
+			if rErr != nil {
+				return nil
+			}
+			_, changed := rw.rewrite(path, text)
+			if (len(changed) > 0 || rw.moves[path] != "") && renameFilter.allows(path) {
+				rw.files = append(rw.files, path)
+			}
+			return nil
+		})
+	}
+	return rw
+}
+
+// assemblyRoots returns Assets/ and the embedded packages
+func assemblyRoots(projectRoot string) []string {
+	roots := []string{filepath.Join(projectRoot, "Assets")}
+	entries, _ := fsys.ReadDir(filepath.Join(projectRoot, "Packages"))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			roots = append(roots, filepath.Join(projectRoot, "Packages", entry.Name()))
+		}
+	}
+	return roots
+}
+
+// rewrite returns the new text and an "old -> new" entry for every changed value
+func (rw *assemblyRewriter) rewrite(path, text string) (string, []string) {
+	var changed []string
+	if strings.EqualFold(filepath.Ext(path), ".cs") {
+		if len(rw.names) == 0 || !strings.Contains(text, "InternalsVisibleTo") {
+			return text, nil
+		}
+		text = internalsVisibleToPattern.ReplaceAllStringFunc(text, func(m string) string {
+			sub := internalsVisibleToPattern.FindStringSubmatch(m)
+			newName, ok := rw.names[sub[2]]
+			if !ok {
+				return m
+			}
+			changed = append(changed, fmt.Sprintf("InternalsVisibleTo(\"%s\") -> InternalsVisibleTo(\"%s\")", sub[2], newName))
+			return sub[1] + newName + sub[3]
+		})
+		return text, changed
+	}
+
+	// "name", "references" and "reference" hold whole names, so only string
+	// values that are exactly an old assembly name are replaced
+	text = jsonStringPattern.ReplaceAllStringFunc(text, func(m string) string {
+		newName, ok := rw.names[m[1:len(m)-1]]
+		if !ok {
+			return m
+		}
+		changed = append(changed, fmt.Sprintf("%s -> \"%s\"", m, newName))
+		return `"` + newName + `"`
+	})
+	if rw.rootNs != nil && strings.EqualFold(filepath.Ext(path), ".asmdef") {
+		text = rw.rootNs.ReplaceAllStringFunc(text, func(m string) string {
+			sub := rw.rootNs.FindStringSubmatch(m)
+			changed = append(changed, fmt.Sprintf("rootNamespace: %s%s -> %s%s", rw.oldNamespace, sub[2], rw.newNamespace, sub[2]))
+			return sub[1] + rw.newNamespace + sub[2] + `"`
+		})
 	}
-	wordRegex := regexp.MustCompile(`\b` + regexp.QuoteMeta(oldProjectName) + `\b`)
+	return text, changed
+}
+
+// updateAssemblies writes the new assembly names and references. The files are
+// renamed afterwards by renameAsmdefFiles.
+func updateAssemblies(log *Logger, projectRoot string, rw *assemblyRewriter) error {
 	var errors []string
+	for _, path := range rw.files {
+		relPath, _ := filepath.Rel(projectRoot, path)
+		text, format, err := readTextFile(path)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("failed to read %s: %v", relPath, err))
+			continue
+		}
+		newText, changed := rw.rewrite(path, text)
+		if len(changed) == 0 {
+			continue
+		}
+		if !strings.EqualFold(filepath.Ext(path), ".cs") {
+			var jsonCheck interface{}
+			if jsonErr := json.Unmarshal([]byte(newText), &jsonCheck); jsonErr != nil {
+				errors = append(errors, fmt.Sprintf("JSON validation failed after update for %s: %v", relPath, jsonErr))
+				continue
+			}
+		}
+		if err := writeTextFileAtomic(path, newText, format); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to write %s: %v", relPath, err))
+			recordAction("modify", path, "failed", err.Error(), 0)
+			continue
+		}
+		log.Printf(tr("[OK] Updated assembly names: %s (%d change(s))\n"), relPath, len(changed))
+		recordAction("modify", path, "ok", strings.Join(changed, "\n"), 0)
+	}
 
-	filepath.Walk(assetsPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() || !strings.HasSuffix(info.Name(), ".asmdef") {
-			return nil
+	if len(errors) > 0 {
+		return fmt.Errorf("encountered %d error(s): %s", len(errors), strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// renameAsmdefFiles gives the asmdef files named after the project their new
+// name, together with their .meta. Refuses to overwrite an existing file.
+func renameAsmdefFiles(log *Logger, projectRoot string, rw *assemblyRewriter) error {
+	var paths []string
+	for path := range rw.moves {
+		if renameFilter.allows(path) {
+			paths = append(paths, path)
 		}
-		// Skip files inside the project folder (already handled)
-		if strings.HasPrefix(path, projectFolderPath+string(os.PathSeparator)) {
-			return nil
+	}
+	sort.Strings(paths)
+
+	var errors []string
+	for _, path := range paths {
+		newPath := rw.moves[path]
+		relPath, _ := filepath.Rel(projectRoot, path)
+		if _, err := fsys.Stat(newPath); err == nil && !sameDiskEntry(path, newPath) {
+			errors = append(errors, fmt.Sprintf("target file already exists: %s", newPath))
+			continue
+		}
+		if err := moveEntry(path, newPath); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to rename %s: %v", relPath, err))
+			continue
 		}
+		if _, err := fsys.Stat(path + ".meta"); err == nil {
+			if err := moveEntry(path+".meta", newPath+".meta"); err != nil {
+				errors = append(errors, fmt.Sprintf("failed to rename meta: %s.meta: %v", relPath, err))
+			}
+		}
+		log.Printf(tr("[OK] Renamed asmdef: %s -> %s\n"), relPath, filepath.Base(newPath))
+		recordAction("rename", path, "ok", "-> "+filepath.Base(newPath), 0)
+	}
 
-		content, readErr := os.ReadFile(path)
-		if readErr != nil {
-			return nil
+	if len(errors) > 0 {
+		return fmt.Errorf("encountered %d error(s): %s", len(errors), strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// ============================================================
+// Embedded Packages
+// ============================================================
+
+// packageRewriter renames the embedded packages (Packages/<folder>/package.json)
+// whose name is made of the old names: a dot- or dash-separated word equal to the
+// old company, app or project name (lowercased, as UPM names are) is replaced, so
+// com.oldcompany.oldgame.tools becomes com.newcompany.newgame.tools. Their
+// displayName follows the old names word by word. Every JSON string that is
+// exactly a renamed package name is replaced as well: the dependencies in
+// manifest.json, packages-lock.json and the other packages, "testables", and
+// the versionDefines of asmdef files. Packages from anyone else keep their name.
+type packageRewriter struct {
+	ids     map[string]string // old package name -> new
+	words   map[string]string // old name word -> new, lowercased
+	display []docRule         // displayName of the renamed packages
+	owned   map[string]bool   // package.json of the renamed packages
+	files   []string          // files whose text changes
+}
+
+var packageDisplayNamePattern = regexp.MustCompile(`("displayName"\s*:\s*")((?:[^"\\]|\\.)*)(")`)
+
+// packageWord is a name as it can appear in a package name: lowercase, without
+// the characters UPM does not allow
+func packageWord(name string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(name) {
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' {
+			b.WriteRune(c)
 		}
+	}
+	return b.String()
+}
 
-		contentStr := string(content)
-		newContent := wordRegex.ReplaceAllString(contentStr, newProjectName)
-		if newContent == contentStr {
-			return nil
+// newPackageRewriter scans the project as it is on disk; after the folder rename
+// it is built again so the asmdef paths are current
+func newPackageRewriter(projectRoot, oldName, newName, oldCompanyName, newCompanyName, oldAppName, newAppName string) *packageRewriter {
+	rw := &packageRewriter{ids: map[string]string{}, words: map[string]string{}, owned: map[string]bool{}}
+	word := func(name string) *regexp.Regexp { return regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`) }
+	for _, pair := range [][2]string{{oldAppName, newAppName}, {oldName, newName}, {oldCompanyName, newCompanyName}} {
+		from, to := packageWord(pair[0]), packageWord(pair[1])
+		if from == "" || to == "" || from == to || rw.words[from] != "" {
+			continue
+		}
+		rw.words[from] = to
+		if pair[0] != pair[1] {
+			rw.display = append(rw.display, docRule{re: word(pair[0]), replacement: pair[1]})
 		}
+	}
+	if len(rw.words) == 0 {
+		return rw
+	}
 
-		// Validate JSON
-		var jsonCheck interface{}
-		if jsonErr := json.Unmarshal([]byte(newContent), &jsonCheck); jsonErr != nil {
-			errors = append(errors, fmt.Sprintf("JSON validation failed for %s: %v", path, jsonErr))
-			return nil
+	// Packages named after the project
+	packagesPath := filepath.Join(projectRoot, "Packages")
+	entries, _ := fsys.ReadDir(packagesPath)
+	existing := map[string]bool{}
+	renamed := map[string]string{} // package.json path -> old package name
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(packagesPath, entry.Name(), "package.json")
+		text, _, err := readTextFile(path)
+		if err != nil {
+			continue
+		}
+		var pkg struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal([]byte(text), &pkg) != nil || pkg.Name == "" {
+			continue
+		}
+		existing[pkg.Name] = true
+		if rw.renameID(pkg.Name) != pkg.Name && renameFilter.allows(path) {
+			renamed[path] = pkg.Name
 		}
+	}
+	for path, oldID := range renamed {
+		// Two packages cannot share a name; that one is left as it is
+		if newID := rw.renameID(oldID); !existing[newID] {
+			rw.ids[oldID] = newID
+			rw.owned[path] = true
+		}
+	}
+	if len(rw.ids) == 0 {
+		return rw
+	}
 
-		if writeErr := os.WriteFile(path, []byte(newContent), 0644); writeErr != nil {
-			errors = append(errors, fmt.Sprintf("failed to write %s: %v", path, writeErr))
+	// Every file that names one of them
+	candidates := []string{filepath.Join(packagesPath, "manifest.json"), filepath.Join(packagesPath, "packages-lock.json")}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			candidates = append(candidates, filepath.Join(packagesPath, entry.Name(), "package.json"))
+		}
+	}
+	for _, root := range assemblyRoots(projectRoot) {
+		fsys.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && strings.EqualFold(filepath.Ext(path), ".asmdef") {
+				candidates = append(candidates, path)
+			}
 			return nil
+		})
+	}
+	for _, path := range candidates {
+		text, _, err := readTextFile(path)
+		if err != nil {
+			continue
+		}
+		if _, changed := rw.rewrite(path, text); len(changed) > 0 && renameFilter.allows(path) {
+			rw.files = append(rw.files, path)
+		}
+	}
+	return rw
+}
+
+// renameID replaces the words of a package name that are an old name. The first
+// part (com, io, ...) is never one.
+func (rw *packageRewriter) renameID(id string) string {
+	parts := strings.Split(id, ".")
+	for i := 1; i < len(parts); i++ {
+		words := strings.Split(parts[i], "-")
+		for j, w := range words {
+			if to, ok := rw.words[w]; ok {
+				words[j] = to
+			}
 		}
+		parts[i] = strings.Join(words, "-")
+	}
+	return strings.Join(parts, ".")
+}
 
-		relPath, _ := filepath.Rel(assetsPath, path)
-		log.Printf("[OK] Updated external asmdef references: %s\n", relPath)
-		return nil
+// rewrite returns the new text and an "old -> new" entry for every changed value
+func (rw *packageRewriter) rewrite(path, text string) (string, []string) {
+	if len(rw.ids) == 0 {
+		return text, nil
+	}
+	// An asmdef names packages only in its versionDefines
+	if strings.EqualFold(filepath.Ext(path), ".asmdef") && !strings.Contains(text, "versionDefines") {
+		return text, nil
+	}
+	var changed []string
+	text = jsonStringPattern.ReplaceAllStringFunc(text, func(m string) string {
+		newID, ok := rw.ids[m[1:len(m)-1]]
+		if !ok {
+			return m
+		}
+		changed = append(changed, fmt.Sprintf("%s -> \"%s\"", m, newID))
+		return `"` + newID + `"`
 	})
+	if rw.owned[path] {
+		text = packageDisplayNamePattern.ReplaceAllStringFunc(text, func(m string) string {
+			sub := packageDisplayNamePattern.FindStringSubmatch(m)
+			value := sub[2]
+			for _, r := range rw.display {
+				value = r.re.ReplaceAllString(value, r.replacement)
+			}
+			if value == sub[2] {
+				return m
+			}
+			changed = append(changed, fmt.Sprintf("displayName: %s -> %s", sub[2], value))
+			return sub[1] + value + sub[3]
+		})
+	}
+	return text, changed
+}
+
+// updatePackages writes the new package names, display names and references
+func updatePackages(log *Logger, projectRoot string, rw *packageRewriter) error {
+	var errors []string
+	for _, path := range rw.files {
+		relPath, _ := filepath.Rel(projectRoot, path)
+		text, format, err := readTextFile(path)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("failed to read %s: %v", relPath, err))
+			continue
+		}
+		newText, changed := rw.rewrite(path, text)
+		if len(changed) == 0 {
+			continue
+		}
+		var jsonCheck interface{}
+		if jsonErr := json.Unmarshal([]byte(newText), &jsonCheck); jsonErr != nil {
+			errors = append(errors, fmt.Sprintf("JSON validation failed after update for %s: %v", relPath, jsonErr))
+			continue
+		}
+		if err := writeTextFileAtomic(path, newText, format); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to write %s: %v", relPath, err))
+			recordAction("modify", path, "failed", err.Error(), 0)
+			continue
+		}
+		log.Printf(tr("[OK] Updated package names: %s (%d change(s))\n"), relPath, len(changed))
+		recordAction("modify", path, "ok", strings.Join(changed, "\n"), 0)
+	}
 
 	if len(errors) > 0 {
 		return fmt.Errorf("encountered %d error(s): %s", len(errors), strings.Join(errors, "; "))
@@ -839,15 +1543,18 @@ func updateAsmdefReferencesGlobally(log *Logger, assetsPath, projectFolderPath,
 	return nil
 }
 
+// ============================================================
+// Update Operations
+// ============================================================
+
 // updateBuildScript updates BuildScript.cs using precise regex matching on const declarations.
 // Only modifies specific const string lines and asset path references.
 func updateBuildScript(log *Logger, filePath, oldFolderName, newFolderName, oldCompanyName, newCompanyName, oldAppName, newAppName string) error {
-	content, err := os.ReadFile(filePath)
+	text, format, err := readTextFile(filePath)
 	if err != nil {
 		return err
 	}
 
-	text := string(content)
 	modified := false
 
 	// Precisely replace const CompanyName declaration
@@ -857,7 +1564,7 @@ func updateBuildScript(log *Logger, filePath, oldFolderName, newFolderName, oldC
 		if newText != text {
 			text = newText
 			modified = true
-			log.Println("[OK] Updated BuildScript.cs: CompanyName")
+			log.Println(tr("[OK] Updated BuildScript.cs: CompanyName"))
 		}
 	}
 
@@ -868,7 +1575,7 @@ func updateBuildScript(log *Logger, filePath, oldFolderName, newFolderName, oldC
 		if newText != text {
 			text = newText
 			modified = true
-			log.Println("[OK] Updated BuildScript.cs: ApplicationName")
+			log.Println(tr("[OK] Updated BuildScript.cs: ApplicationName"))
 		}
 	}
 
@@ -879,28 +1586,29 @@ func updateBuildScript(log *Logger, filePath, oldFolderName, newFolderName, oldC
 		if strings.Contains(text, oldPath) {
 			text = strings.ReplaceAll(text, oldPath, newPath)
 			modified = true
-			log.Println("[OK] Updated BuildScript.cs: asset paths")
+			log.Println(tr("[OK] Updated BuildScript.cs: asset paths"))
 		}
 	}
 
 	if !modified {
-		log.Println("[--] BuildScript.cs: no changes needed")
+		log.Println(tr("[--] BuildScript.cs: no changes needed"))
 		return nil
 	}
+	recordAction("modify", filePath, "ok", "BuildScript.cs constants/paths", 0)
 
-	return os.WriteFile(filePath, []byte(text), 0644)
+	return writeTextFileAtomic(filePath, text, format)
 }
 
 // updateProjectSettings updates ProjectSettings.asset using exact value matching.
 // Replaces companyName, productName, applicationIdentifier (by exact bundle ID),
-// metroPackageName, and metroApplicationDescription.
-func updateProjectSettings(log *Logger, filePath, oldCompanyName, newCompanyName, oldAppName, newAppName string) error {
-	content, err := os.ReadFile(filePath)
+// metroPackageName, and metroApplicationDescription, then sets the per-platform
+// bundle IDs of a -config file.
+func updateProjectSettings(log *Logger, filePath, oldCompanyName, newCompanyName, oldAppName, newAppName string, bundleIDs map[string]string) error {
+	text, format, err := readTextFile(filePath)
 	if err != nil {
 		return err
 	}
 
-	text := string(content)
 	modified := false
 
 	// Replace companyName precisely (exact old value match)
@@ -933,6 +1641,12 @@ func updateProjectSettings(log *Logger, filePath, oldCompanyName, newCompanyName
 		}
 	}
 
+	// Platforms with their own bundle ID override the shared one
+	if t, notes := setBundleIDs(text, bundleIDs); len(notes) > 0 {
+		text = t
+		modified = true
+	}
+
 	// Replace metroPackageName precisely
 	if oldAppName != newAppName {
 		old := "metroPackageName: " + oldAppName
@@ -954,250 +1668,3567 @@ func updateProjectSettings(log *Logger, filePath, oldCompanyName, newCompanyName
 	}
 
 	if !modified {
-		log.Println("[--] ProjectSettings.asset: no changes needed")
+		log.Println(tr("[--] ProjectSettings.asset: no changes needed"))
 		return nil
 	}
 
-	return os.WriteFile(filePath, []byte(text), 0644)
+	return writeTextFileAtomic(filePath, text, format)
 }
 
 // updateEditorBuildSettings updates scene paths in EditorBuildSettings.asset
 func updateEditorBuildSettings(log *Logger, filePath, oldProjectName, newProjectName string) error {
 	if oldProjectName == newProjectName {
-		log.Println("[--] EditorBuildSettings.asset: no changes needed")
+		log.Println(tr("[--] EditorBuildSettings.asset: no changes needed"))
 		return nil
 	}
 
-	content, err := os.ReadFile(filePath)
+	text, format, err := readTextFile(filePath)
 	if err != nil {
 		return err
 	}
 
-	text := string(content)
 	oldPathPrefix := "Assets/" + oldProjectName + "/"
 	newPathPrefix := "Assets/" + newProjectName + "/"
 
 	if !strings.Contains(text, oldPathPrefix) {
-		log.Println("[--] EditorBuildSettings.asset: no changes needed")
+		log.Println(tr("[--] EditorBuildSettings.asset: no changes needed"))
 		return nil
 	}
 
 	text = strings.ReplaceAll(text, oldPathPrefix, newPathPrefix)
-	return os.WriteFile(filePath, []byte(text), 0644)
+	return writeTextFileAtomic(filePath, text, format)
 }
 
 // ============================================================
-// Utilities
+// Documentation (-docs)
 // ============================================================
 
-func waitForKeyPress() {
-	fmt.Println("\nPress Enter to continue...")
-	stdinReader.ReadBytes('\n')
+// Folders (matched case-insensitively) whose Markdown files are part of the docs pass
+var docsDirNames = map[string]bool{
+	"docs":          true,
+	"doc":           true,
+	"documentation": true,
 }
 
-func clearScreen() {
-	var cmd *exec.Cmd
-	switch runtime.GOOS {
-	case "windows":
-		cmd = exec.Command("cmd", "/c", "cls")
-	case "linux", "darwin":
-		cmd = exec.Command("clear")
-	default:
-		return
-	}
-	cmd.Stdout = os.Stdout
-	cmd.Run()
+// Markdown files that record history; old names in them are left as written
+var docsHistoryFiles = map[string]bool{
+	"changelog.md": true,
+	"changes.md":   true,
+	"history.md":   true,
 }
 
-// ============================================================
-// Entry Point
-// ============================================================
-
-func main() {
-	projectRoot, err := findProjectRoot()
-	if err != nil {
-		fmt.Println("Error:", err)
-		waitForKeyPress()
-		return
-	}
-	fmt.Printf("Found Unity project root at: %s\n", projectRoot)
-
-	// Initialize logger
-	logPath := filepath.Join(projectRoot, "rename_project.log")
-	log := NewLogger(logPath)
-	defer log.Close()
-	log.Printf("=== Rename Project Tool started at %s ===\n", time.Now().Format("2006-01-02 15:04:05"))
-
-	// Get current project info (prefers state file for reliable re-runs)
-	oldName, oldCompanyName, oldAppName, err := getCurrentProjectInfo(projectRoot)
-	if err != nil {
-		log.Printf("Error getting current project info: %v\n", err)
-		waitForKeyPress()
-		return
-	}
-
-	log.Println("\nCurrent project settings:")
-	log.Printf("  Project Folder: %s\n", oldName)
-	log.Printf("  Company Name:   %s\n", oldCompanyName)
-	log.Printf("  App Name:       %s\n", oldAppName)
-	waitForKeyPress()
+// Absolute URLs point at remote resources (the hosted repository, wikis, stores)
+// that keep their names after a local rename, so they are left as written. Static
+// shields.io badges are the exception: their label is just text.
+var docURLPattern = regexp.MustCompile(`https?://[^\s)\]"'<>]+`)
 
-	// Collect new names with immediate validation (press Enter to keep current)
-	newProjectName := promptValidatedInput(1, "Project Name",
-		"The folder name (Assets\\PROJECT_NAME) should only contain letters, numbers,\nunderscores (_), and dashes (-). It cannot start with a number or dash.",
-		oldName)
+const docBadgeURLPrefix = "img.shields.io/badge/"
 
-	newCompanyName := promptValidatedInput(2, "Company Name",
-		"The name should only contain letters, numbers, underscores (_), and dashes (-).\nIt cannot start with a number or dash.",
-		oldCompanyName)
+// docRule is one textual replacement applied to Markdown files
+type docRule struct {
+	re          *regexp.Regexp
+	replacement string
+}
 
-	newAppName := promptValidatedInput(3, "Application Name",
-		"The name should only contain letters, numbers, underscores (_), and dashes (-).\nIt cannot start with a number or dash.",
-		oldAppName)
+// docRewriter holds the -docs replacements and the spans they must not touch
+type docRewriter struct {
+	rules []docRule
+	// Matches directory names on disk (the Unity project folder and its parents)
+	// used as path segments; group 1 is set when the match is really Assets/<name>/
+	dirPattern *regexp.Regexp
+}
 
-	// Check if anything actually changed
-	if newProjectName == oldName && newCompanyName == oldCompanyName && newAppName == oldAppName {
-		clearScreen()
-		log.Println("\nNo changes needed — all values are the same as current settings.")
-		waitForKeyPress()
-		return
+// newDocRewriter builds the replacements in the order they must be applied:
+// Assets/ paths first (they carry the folder name), then the app name in prose and
+// badge text, then remaining mentions of the folder name, then "#anchor" links
+// so the table of contents follows the renamed headings.
+func newDocRewriter(projectRoot, oldName, newName, oldAppName, newAppName string) *docRewriter {
+	rw := &docRewriter{}
+	word := func(name string) string { return `\b` + regexp.QuoteMeta(name) + `\b` }
+	anchor := func(oldText, newText string) {
+		oldText, newText = strings.ToLower(oldText), strings.ToLower(newText)
+		rw.rules = append(rw.rules, docRule{
+			re:          regexp.MustCompile(`(\]\(#[^)\s]*?)` + word(oldText)),
+			replacement: "${1}" + newText,
+		})
 	}
 
-	// Preview all changes before execution
-	clearScreen()
-	changes := previewChanges(projectRoot, oldName, newProjectName, oldCompanyName, newCompanyName, oldAppName, newAppName)
-	printPreview(log, changes)
-
-	if len(changes) == 0 {
-		waitForKeyPress()
-		return
+	if oldName != newName {
+		rw.rules = append(rw.rules, docRule{
+			re:          regexp.MustCompile(`\bAssets([/\\])` + word(oldName)),
+			replacement: "Assets${1}" + newName,
+		})
 	}
-
-	// Final confirmation
-	fmt.Print("\nProceed with these changes? (y/N): ")
-	confirm, _ := stdinReader.ReadString('\n')
-	confirm = strings.TrimSpace(strings.ToLower(confirm))
-	if confirm != "y" {
-		log.Println("\nOperation cancelled by user.")
-		waitForKeyPress()
-		return
+	if oldAppName != newAppName {
+		rw.rules = append(rw.rules, docRule{re: regexp.MustCompile(word(oldAppName)), replacement: newAppName})
 	}
-
-	// Create backup of all affected files
-	log.Println("\nCreating backup...")
-	filesToBackup := collectFilesToBackup(projectRoot, oldName)
-	backupDir, backupErr := createBackup(projectRoot, filesToBackup)
-	if backupErr != nil {
-		log.Printf("Warning: backup failed: %v\n", backupErr)
-		fmt.Print("Continue without backup? (y/N): ")
-		cont, _ := stdinReader.ReadString('\n')
-		cont = strings.TrimSpace(strings.ToLower(cont))
-		if cont != "y" {
-			log.Println("Operation cancelled.")
-			waitForKeyPress()
-			return
-		}
-	} else if backupDir != "" {
-		log.Printf("[OK] Backup created at: %s\n", backupDir)
+	if oldName != newName && oldName != oldAppName {
+		rw.rules = append(rw.rules, docRule{re: regexp.MustCompile(word(oldName)), replacement: newName})
+	}
+	if oldAppName != newAppName {
+		anchor(oldAppName, newAppName)
+	}
+	if oldName != newName && oldName != oldAppName {
+		anchor(oldName, newName)
 	}
 
-	log.Println("\nExecuting changes...")
-
-	// 1. Rename project folder + meta
-	if oldName != newProjectName {
-		oldFolderPath := filepath.Join(projectRoot, "Assets", oldName)
-		newFolderPath := filepath.Join(projectRoot, "Assets", newProjectName)
-		if err := renameFolderAndMeta(oldFolderPath, newFolderPath); err != nil {
-			log.Printf("Error renaming folder: %v\n", err)
-			waitForKeyPress()
-			return
-		}
-		log.Printf("[OK] Renamed folder: Assets/%s -> Assets/%s\n", oldName, newProjectName)
-
-		// Save partial state checkpoint: folder renamed, but company/app not yet updated.
-		// This ensures re-runs can find the correct folder even if subsequent steps fail.
-		partialState := &RenameState{
-			ProjectFolder: newProjectName,
-			CompanyName:   oldCompanyName,
-			AppName:       oldAppName,
-			RenamedAt:     time.Now().Format("2006-01-02 15:04:05"),
+	// The rename never touches the folders above Assets/, so paths through them stay
+	roots := findDocsRoots(projectRoot)
+	var dirNames []string
+	for i, root := range roots {
+		if i > 0 && i == len(roots)-1 {
+			break // the workspace root itself is never part of a relative path
 		}
-		if sErr := saveState(projectRoot, partialState); sErr != nil {
-			log.Printf("Warning: failed to save state checkpoint: %v\n", sErr)
+		if abs, err := filepath.Abs(root); err == nil {
+			dirNames = append(dirNames, regexp.QuoteMeta(filepath.Base(abs)))
 		}
 	}
+	if len(dirNames) > 0 {
+		rw.dirPattern = regexp.MustCompile(`(Assets[/\\])?\b(?:` + strings.Join(dirNames, "|") + `)[/\\]`)
+	}
+	return rw
+}
 
-	// 2. Update asmdef files in the project folder
-	if oldName != newProjectName {
-		newFolderPath := filepath.Join(projectRoot, "Assets", newProjectName)
-		if err := updateAsmdefFilesInFolder(log, newFolderPath, oldName, newProjectName); err != nil {
-			log.Printf("Warning: %v\n", err)
-		}
-
-		// 3. Update asmdef references globally (outside the project folder)
-		assetsPath := filepath.Join(projectRoot, "Assets")
-		if err := updateAsmdefReferencesGlobally(log, assetsPath, newFolderPath, oldName, newProjectName); err != nil {
-			log.Printf("Warning: %v\n", err)
+// keepSpans returns the sorted [start, end) ranges that are copied unchanged
+func (rw *docRewriter) keepSpans(text string) [][]int {
+	var spans [][]int
+	for _, loc := range docURLPattern.FindAllStringIndex(text, -1) {
+		if !strings.Contains(text[loc[0]:loc[1]], docBadgeURLPrefix) {
+			spans = append(spans, loc)
 		}
 	}
-
-	// 4. Update BuildScript.cs (if exists)
-	buildScriptPath := filepath.Join(projectRoot, "Assets", "Build", "Editor", "BuildPipeline", "BuildScript.cs")
-	if _, statErr := os.Stat(buildScriptPath); statErr == nil {
-		if err := updateBuildScript(log, buildScriptPath, oldName, newProjectName, oldCompanyName, newCompanyName, oldAppName, newAppName); err != nil {
-			log.Printf("Warning: Error updating BuildScript.cs: %v\n", err)
+	if rw.dirPattern != nil {
+		for _, m := range rw.dirPattern.FindAllStringSubmatchIndex(text, -1) {
+			if m[2] < 0 {
+				spans = append(spans, m[:2])
+			}
 		}
 	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	return spans
+}
 
-	// 5. Update ProjectSettings.asset
-	projectSettingsPath := filepath.Join(projectRoot, "ProjectSettings", "ProjectSettings.asset")
-	if err := updateProjectSettings(log, projectSettingsPath, oldCompanyName, newCompanyName, oldAppName, newAppName); err != nil {
-		log.Printf("Error updating ProjectSettings.asset: %v\n", err)
-		waitForKeyPress()
-		return
+// rewrite applies the rules outside the kept spans and returns the new text
+// together with the number of replacements made.
+func (rw *docRewriter) rewrite(text string) (string, int) {
+	var b strings.Builder
+	count := 0
+	apply := func(s string) {
+		for _, r := range rw.rules {
+			if n := len(r.re.FindAllStringIndex(s, -1)); n > 0 {
+				s = r.re.ReplaceAllString(s, r.replacement)
+				count += n
+			}
+		}
+		b.WriteString(s)
 	}
-	log.Println("[OK] Updated ProjectSettings.asset")
 
-	// 6. Update EditorBuildSettings.asset
-	editorBuildSettingsPath := filepath.Join(projectRoot, "ProjectSettings", "EditorBuildSettings.asset")
-	if err := updateEditorBuildSettings(log, editorBuildSettingsPath, oldName, newProjectName); err != nil {
-		log.Printf("Error updating EditorBuildSettings.asset: %v\n", err)
-		waitForKeyPress()
-		return
+	last := 0
+	for _, span := range rw.keepSpans(text) {
+		if span[1] <= last {
+			continue
+		}
+		if span[0] > last {
+			apply(text[last:span[0]])
+			last = span[0]
+		}
+		b.WriteString(text[last:span[1]])
+		last = span[1]
 	}
-	log.Println("[OK] Updated EditorBuildSettings.asset")
+	apply(text[last:])
+	return b.String(), count
+}
 
-	// 7. Save final state file for future re-runs
-	finalState := &RenameState{
-		ProjectFolder: newProjectName,
-		CompanyName:   newCompanyName,
-		AppName:       newAppName,
-		RenamedAt:     time.Now().Format("2006-01-02 15:04:05"),
+// findDocsRoots returns the Unity project root plus every parent up to the
+// workspace root (.git, .plastic or P4CONFIG), so a repository README that sits
+// above the Unity project is included.
+func findDocsRoots(projectRoot string) []string {
+	abs, err := filepath.Abs(projectRoot)
+	if err != nil {
+		return []string{projectRoot}
 	}
-	if err := saveState(projectRoot, finalState); err != nil {
-		log.Printf("Warning: failed to save state file: %v\n", err)
-	} else {
-		log.Printf("[OK] Saved state file: %s\n", stateFileName)
+	p4config := os.Getenv("P4CONFIG")
+	isWorkspaceRoot := func(dir string) bool {
+		for _, marker := range []string{".git", ".plastic", p4config} {
+			if marker == "" {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return true
+			}
+		}
+		return false
 	}
 
-	// Summary
-	log.Println("\n===========================================")
-	log.Println("  Project successfully renamed!")
-	log.Println("===========================================")
-	log.Println("\nSummary:")
-	if oldName != newProjectName {
-		log.Printf("  Folder:  Assets/%s -> Assets/%s\n", oldName, newProjectName)
+	// Roots keep the form of projectRoot (relative stays relative) for readable output
+	var roots []string
+	root := projectRoot
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		roots = append(roots, root)
+		if isWorkspaceRoot(dir) {
+			return roots
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+		root = filepath.Join(root, "..")
+	}
+	// No workspace marker: stay inside the Unity project
+	return roots[:1]
+}
+
+// collectDocFiles returns the Markdown files (top-level *.md and everything under
+// docs/) that mention one of the old names.
+func collectDocFiles(projectRoot string, docs *docRewriter) []string {
+	if len(docs.rules) == 0 {
+		return nil
+	}
+	var files []string
+	addIfReferenced := func(path string) {
+		if docsHistoryFiles[strings.ToLower(filepath.Base(path))] {
+			return
+		}
+		text, _, err := readTextFile(path)
+		if err != nil {
+			return
+		}
+		if _, n := docs.rewrite(text); n > 0 && renameFilter.allows(path) {
+			files = append(files, path)
+		}
+	}
+
+	for _, root := range findDocsRoots(projectRoot) {
+		entries, err := fsys.ReadDir(root)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			path := filepath.Join(root, entry.Name())
+			if !entry.IsDir() {
+				if strings.EqualFold(filepath.Ext(entry.Name()), ".md") {
+					addIfReferenced(path)
+				}
+				continue
+			}
+			if !docsDirNames[strings.ToLower(entry.Name())] {
+				continue
+			}
+			fsys.Walk(path, func(p string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() && strings.EqualFold(filepath.Ext(p), ".md") {
+					addIfReferenced(p)
+				}
+				return nil
+			})
+		}
+	}
+	return files
+}
+
+// updateDocs rewrites the collected Markdown files
+func updateDocs(log *Logger, projectRoot string, files []string, docs *docRewriter) error {
+	var errors []string
+	for _, path := range files {
+		relPath, _ := filepath.Rel(projectRoot, path)
+		text, format, err := readTextFile(path)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("failed to read %s: %v", relPath, err))
+			continue
+		}
+		newText, n := docs.rewrite(text)
+		if n == 0 {
+			continue
+		}
+		if err := writeTextFileAtomic(path, newText, format); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to write %s: %v", relPath, err))
+			recordAction("modify", path, "failed", err.Error(), 0)
+			continue
+		}
+		log.Printf(tr("[OK] Updated docs: %s (%d reference(s))\n"), relPath, n)
+		recordAction("modify", path, "ok", fmt.Sprintf("%d references", n), 0)
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("encountered %d error(s): %s", len(errors), strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// ============================================================
+// Addressables
+// ============================================================
+
+// Addressables keeps its settings asset in AddressableAssetsData/ unless the
+// project moved it; the groups and their schemas live in the folder below it
+const addressablesSettingsName = "AddressableAssetSettings.asset"
+
+// Serialized fields that hold a path or URL: profile values (m_Value), the path
+// references of the settings and the group schemas, which hold a profile variable
+// id or a custom path (m_Id), and the content state folder
+var addressablesValuePattern = regexp.MustCompile(`^(\s*(?:-\s+)?(?:m_Value|m_Id|m_ContentStateBuildPath):[ \t]*)(\S.*)$`)
+
+// Text Addressables evaluates is left as written: [UnityEditor.PlayerSettings.productName]
+// follows the new names by itself, and {Game.Config.CdnRoot} names a C# member.
+// The host of a URL is a server name, not part of the folder layout.
+var addressablesKeepPattern = regexp.MustCompile(`\[[^\]]*\]|\{[^}]*\}|[A-Za-z][A-Za-z0-9+.-]*://[^/\s'"]*`)
+
+// addressablesRewriter replaces the old company and product names (and the bundle
+// ID made of them) in Addressables path values, so remote load paths and build
+// paths keep following the CDN folder layout after a rename
+type addressablesRewriter struct {
+	rules []docRule
+}
+
+func newAddressablesRewriter(oldCompanyName, newCompanyName, oldAppName, newAppName string) *addressablesRewriter {
+	rw := &addressablesRewriter{}
+	word := func(name string) *regexp.Regexp { return regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`) }
+	if oldCompanyName != newCompanyName || oldAppName != newAppName {
+		rw.rules = append(rw.rules, docRule{
+			re:          word("com." + oldCompanyName + "." + oldAppName),
+			replacement: "com." + newCompanyName + "." + newAppName,
+		})
+	}
+	if oldAppName != newAppName {
+		rw.rules = append(rw.rules, docRule{re: word(oldAppName), replacement: newAppName})
+	}
+	if oldCompanyName != newCompanyName && oldCompanyName != oldAppName {
+		rw.rules = append(rw.rules, docRule{re: word(oldCompanyName), replacement: newCompanyName})
+	}
+	return rw
+}
+
+// rewriteValue applies the rules outside the evaluated spans of one value
+func (rw *addressablesRewriter) rewriteValue(value string) string {
+	var b strings.Builder
+	apply := func(s string) {
+		for _, r := range rw.rules {
+			s = r.re.ReplaceAllString(s, r.replacement)
+		}
+		b.WriteString(s)
+	}
+	last := 0
+	for _, span := range addressablesKeepPattern.FindAllStringIndex(value, -1) {
+		apply(value[last:span[0]])
+		b.WriteString(value[span[0]:span[1]])
+		last = span[1]
+	}
+	apply(value[last:])
+	return b.String()
+}
+
+// rewrite returns the new text and an "old -> new" line for every changed value
+func (rw *addressablesRewriter) rewrite(text string) (string, []string) {
+	if len(rw.rules) == 0 {
+		return text, nil
+	}
+	var changed []string
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		m := addressablesValuePattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if value := rw.rewriteValue(m[2]); value != m[2] {
+			lines[i] = m[1] + value
+			changed = append(changed, m[2]+" -> "+value)
+		}
+	}
+	return strings.Join(lines, "\n"), changed
+}
+
+// collectAddressablesFiles returns the Addressables assets with a value to rewrite:
+// every settings asset under Assets/ and the .asset files in the folder below it
+func collectAddressablesFiles(projectRoot string, rw *addressablesRewriter) []string {
+	if len(rw.rules) == 0 {
+		return nil
+	}
+	var dataDirs []string
+	fsys.Walk(filepath.Join(projectRoot, "Assets"), func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && info.Name() == addressablesSettingsName {
+			dataDirs = append(dataDirs, filepath.Dir(path))
+		}
+		return nil
+	})
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, dir := range dataDirs {
+		fsys.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || !strings.HasSuffix(info.Name(), ".asset") || seen[path] {
+				return nil
+			}
+			seen[path] = true
+			if text, _, rErr := readTextFile(path); rErr == nil {
+				if _, changed := rw.rewrite(text); len(changed) > 0 && renameFilter.allows(path) {
+					files = append(files, path)
+				}
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// updateAddressables rewrites the profile values and paths of the collected assets
+func updateAddressables(log *Logger, projectRoot string, files []string, rw *addressablesRewriter) error {
+	var errors []string
+	for _, path := range files {
+		relPath, _ := filepath.Rel(projectRoot, path)
+		text, format, err := readTextFile(path)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("failed to read %s: %v", relPath, err))
+			continue
+		}
+		newText, changed := rw.rewrite(text)
+		if len(changed) == 0 {
+			continue
+		}
+		if err := writeTextFileAtomic(path, newText, format); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to write %s: %v", relPath, err))
+			recordAction("modify", path, "failed", err.Error(), 0)
+			continue
+		}
+		log.Printf(tr("[OK] Updated Addressables paths: %s (%d value(s))\n"), relPath, len(changed))
+		recordAction("modify", path, "ok", fmt.Sprintf("%d Addressables values", len(changed)), 0)
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("encountered %d error(s): %s", len(errors), strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// ============================================================
+// Display Names
+// ============================================================
+
+// UGUI Text and TextMeshPro keep what they show in these fields
+var displayNameDefaultFields = []string{"m_Text", "m_text"}
+
+var (
+	assetGUIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+	metaGUIDPattern  = regexp.MustCompile(`(?m)^guid:[ \t]*([0-9a-f]{32})`)
+
+	// A field with a scalar value; group 1 is its indentation (including "- ")
+	serializedFieldPattern = regexp.MustCompile(`^([ \t]*(?:-[ \t]+)?)([A-Za-z_][A-Za-z0-9_]*):[ \t]+(\S.*)$`)
+)
+
+// displayNameTarget is a DisplayNameAsset found on disk
+type displayNameTarget struct {
+	path   string
+	fields map[string]bool
+}
+
+// displayNameRewriter replaces the old app and company names in the configured
+// fields of the assets listed under displayNameAssets in the state file
+type displayNameRewriter struct {
+	rules []docRule
+}
+
+func newDisplayNameRewriter(oldCompanyName, newCompanyName, oldAppName, newAppName string) *displayNameRewriter {
+	rw := &displayNameRewriter{}
+	word := func(name string) *regexp.Regexp { return regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`) }
+	if oldAppName != newAppName {
+		rw.rules = append(rw.rules, docRule{re: word(oldAppName), replacement: newAppName})
+	}
+	if oldCompanyName != newCompanyName && oldCompanyName != oldAppName {
+		rw.rules = append(rw.rules, docRule{re: word(oldCompanyName), replacement: newCompanyName})
+	}
+	return rw
+}
+
+func (rw *displayNameRewriter) replace(s string) string {
+	for _, r := range rw.rules {
+		s = r.re.ReplaceAllString(s, r.replacement)
+	}
+	return s
+}
+
+// rewrite returns the new text and a "field: old -> new" line for every changed
+// value. Unity wraps long strings onto the following lines, indented deeper than
+// the field; those lines belong to the value.
+func (rw *displayNameRewriter) rewrite(text string, fields map[string]bool) (string, []string) {
+	if len(rw.rules) == 0 {
+		return text, nil
+	}
+	indent := func(line string) int { return len(line) - len(strings.TrimLeft(line, " \t")) }
+	var changed []string
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		m := serializedFieldPattern.FindStringSubmatch(line)
+		if m == nil || !fields[m[2]] {
+			continue
+		}
+		oldParts := []string{m[3]}
+		newParts := []string{rw.replace(m[3])}
+		lines[i] = line[:len(line)-len(m[3])] + newParts[0] + lines[i][len(line):]
+		for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" && indent(lines[i+1]) > len(m[1]) {
+			i++
+			oldParts = append(oldParts, strings.TrimSpace(lines[i]))
+			lines[i] = rw.replace(lines[i])
+			newParts = append(newParts, strings.TrimSpace(lines[i]))
+		}
+		if oldValue, newValue := strings.Join(oldParts, " "), strings.Join(newParts, " "); oldValue != newValue {
+			changed = append(changed, fmt.Sprintf("%s: %s -> %s", m[2], oldValue, newValue))
+		}
+	}
+	return strings.Join(lines, "\n"), changed
+}
+
+// resolveDisplayNameAssets finds the configured assets. GUIDs are looked up in
+// the .meta files under Assets/; a path into the old project folder also matches
+// the renamed folder, so the list resolves before and after the folder rename.
+func resolveDisplayNameAssets(projectRoot, oldName, newName string, assets []DisplayNameAsset) ([]displayNameTarget, []string) {
+	var guids map[string]string
+	lookupGUID := func(guid string) string {
+		if guids == nil {
+			guids = make(map[string]string)
+			fsys.Walk(filepath.Join(projectRoot, "Assets"), func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() || !strings.HasSuffix(path, ".meta") {
+					return nil
+				}
+				if data, rErr := fsys.ReadFile(path); rErr == nil {
+					if m := metaGUIDPattern.FindSubmatch(data); m != nil {
+						guids[string(m[1])] = strings.TrimSuffix(path, ".meta")
+					}
+				}
+				return nil
+			})
+		}
+		return guids[guid]
+	}
+
+	var targets []displayNameTarget
+	var missing []string
+	for _, a := range assets {
+		var path string
+		if id := strings.ToLower(strings.TrimSpace(a.Asset)); assetGUIDPattern.MatchString(id) {
+			path = lookupGUID(id)
+		} else {
+			rel := filepath.ToSlash(strings.TrimSpace(a.Asset))
+			path = filepath.Join(projectRoot, filepath.FromSlash(rel))
+			oldPrefix := "Assets/" + oldName + "/"
+			if _, err := fsys.Stat(path); err != nil && strings.HasPrefix(rel, oldPrefix) {
+				path = filepath.Join(projectRoot, "Assets", newName, filepath.FromSlash(rel[len(oldPrefix):]))
+			}
+		}
+		if info, err := fsys.Stat(path); path == "" || err != nil || info.IsDir() {
+			missing = append(missing, a.Asset)
+			continue
+		}
+		if !renameFilter.allows(path) {
+			continue
+		}
+		fields := make(map[string]bool)
+		names := a.Fields
+		if len(names) == 0 {
+			names = displayNameDefaultFields
+		}
+		for _, f := range names {
+			fields[f] = true
+		}
+		targets = append(targets, displayNameTarget{path: path, fields: fields})
+	}
+	return targets, missing
+}
+
+// moveDisplayNameAssets points the paths into the old project folder at the
+// renamed one, so the state file keeps resolving on the next rename
+func moveDisplayNameAssets(assets []DisplayNameAsset, oldName, newName string) []DisplayNameAsset {
+	oldPrefix := "Assets/" + oldName + "/"
+	moved := make([]DisplayNameAsset, len(assets))
+	for i, a := range assets {
+		moved[i] = a
+		if rel := filepath.ToSlash(strings.TrimSpace(a.Asset)); strings.HasPrefix(rel, oldPrefix) {
+			moved[i].Asset = "Assets/" + newName + "/" + rel[len(oldPrefix):]
+		}
+	}
+	return moved
+}
+
+// updateDisplayNames rewrites the configured fields of the resolved assets
+func updateDisplayNames(log *Logger, projectRoot string, targets []displayNameTarget, rw *displayNameRewriter) error {
+	var errors []string
+	for _, t := range targets {
+		relPath, _ := filepath.Rel(projectRoot, t.path)
+		text, format, err := readTextFile(t.path)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("failed to read %s: %v", relPath, err))
+			continue
+		}
+		newText, changed := rw.rewrite(text, t.fields)
+		if len(changed) == 0 {
+			continue
+		}
+		if err := writeTextFileAtomic(t.path, newText, format); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to write %s: %v", relPath, err))
+			recordAction("modify", t.path, "failed", err.Error(), 0)
+			continue
+		}
+		log.Printf(tr("[OK] Updated display names: %s (%d value(s))\n"), relPath, len(changed))
+		recordAction("modify", t.path, "ok", fmt.Sprintf("%d display names", len(changed)), 0)
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("encountered %d error(s): %s", len(errors), strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// ============================================================
+// CI Pipelines (-ci)
+// ============================================================
+
+// Pipeline definitions looked for in the Unity project root and every parent up
+// to the workspace root; -ci-glob replaces the list
+const defaultCIGlobs = ".github/workflows/*.yml,.github/workflows/*.yaml,.gitlab-ci.yml,azure-pipelines.yml,bitbucket-pipelines.yml,.circleci/config.yml,codemagic.yaml,unity-cloud-build*.yml"
+
+// Expressions the CI service evaluates (${{ secrets.X }}, $(Build.ArtifactName))
+// name secrets and variables set elsewhere, and URLs name remote resources, so
+// both are left as written
+var ciKeepPattern = regexp.MustCompile(`\$\{\{.*?\}\}|\$\([^)]*\)|https?://[^\s"'<>]+`)
+
+// ciRule replaces one old name where it is not part of a longer word; unlike
+// the docs pass, "_" separates words too, so MyGame_Win64.zip is an artifact of MyGame
+type ciRule struct {
+	from, to string
+}
+
+// ciRewriter holds the -ci replacements: the bundle ID first (it contains the
+// other names), then Assets/ paths, then the product, folder and company names
+type ciRewriter struct {
+	rules []ciRule
+}
+
+func newCIRewriter(oldName, newName, oldCompanyName, newCompanyName, oldAppName, newAppName string) *ciRewriter {
+	rw := &ciRewriter{}
+	add := func(oldText, newText string) {
+		for _, r := range rw.rules {
+			if r.from == oldText {
+				return
+			}
+		}
+		if oldText != newText {
+			rw.rules = append(rw.rules, ciRule{from: oldText, to: newText})
+		}
+	}
+	oldID := "com." + oldCompanyName + "." + oldAppName
+	newID := "com." + newCompanyName + "." + newAppName
+	add(oldID, newID)
+	add(strings.ToLower(oldID), strings.ToLower(newID))
+	add("Assets/"+oldName, "Assets/"+newName)
+	add(oldAppName, newAppName)
+	add(oldName, newName)
+	add(oldCompanyName, newCompanyName)
+	return rw
+}
+
+func isCIWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// replaceCIWord replaces from in s where no letter or digit touches it
+func replaceCIWord(s, from, to string) string {
+	var b strings.Builder
+	last := 0
+	for i := 0; ; {
+		j := strings.Index(s[i:], from)
+		if j < 0 {
+			break
+		}
+		start, end := i+j, i+j+len(from)
+		if (start == 0 || !isCIWordByte(s[start-1])) && (end == len(s) || !isCIWordByte(s[end])) {
+			b.WriteString(s[last:start])
+			b.WriteString(to)
+			last = end
+			i = end
+		} else {
+			i = start + 1
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// rewriteLine applies the rules outside the evaluated expressions and URLs
+func (rw *ciRewriter) rewriteLine(line string) string {
+	var b strings.Builder
+	apply := func(s string) {
+		for _, r := range rw.rules {
+			s = replaceCIWord(s, r.from, r.to)
+		}
+		b.WriteString(s)
+	}
+	last := 0
+	for _, span := range ciKeepPattern.FindAllStringIndex(line, -1) {
+		apply(line[last:span[0]])
+		b.WriteString(line[span[0]:span[1]])
+		last = span[1]
+	}
+	apply(line[last:])
+	return b.String()
+}
+
+// rewrite returns the new text and an "old -> new" entry for every changed line
+func (rw *ciRewriter) rewrite(text string) (string, []string) {
+	if len(rw.rules) == 0 {
+		return text, nil
+	}
+	var changed []string
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if newLine := rw.rewriteLine(line); newLine != line {
+			lines[i] = newLine
+			changed = append(changed, strings.TrimSpace(line)+" -> "+strings.TrimSpace(newLine))
+		}
+	}
+	return strings.Join(lines, "\n"), changed
+}
+
+// collectCIFiles returns the pipeline files matched by the comma-separated globs
+// that mention an old name. Patterns are relative to each root from findDocsRoots,
+// so a workflow in the repository root above the Unity project is found.
+func collectCIFiles(projectRoot, globs string, rw *ciRewriter) ([]string, error) {
+	var patterns []string
+	for _, g := range strings.Split(globs, ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			if _, err := filepath.Match(filepath.FromSlash(g), ""); err != nil {
+				return nil, fmt.Errorf("invalid -ci-glob pattern '%s': %v", g, err)
+			}
+			patterns = append(patterns, filepath.FromSlash(g))
+		}
+	}
+	if len(rw.rules) == 0 {
+		return nil, nil
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, root := range findDocsRoots(projectRoot) {
+		for _, pattern := range patterns {
+			matches, _ := filepath.Glob(filepath.Join(root, pattern))
+			for _, path := range matches {
+				abs, err := filepath.Abs(path)
+				if err != nil || seen[abs] {
+					continue
+				}
+				seen[abs] = true
+				info, err := fsys.Stat(path)
+				if err != nil || info.IsDir() {
+					continue
+				}
+				if text, _, rErr := readTextFile(path); rErr == nil {
+					if _, changed := rw.rewrite(text); len(changed) > 0 && renameFilter.allows(path) {
+						files = append(files, path)
+					}
+				}
+			}
+		}
+	}
+	return files, nil
+}
+
+// updateCIFiles rewrites the collected pipeline files
+func updateCIFiles(log *Logger, projectRoot string, files []string, rw *ciRewriter) error {
+	var errors []string
+	for _, path := range files {
+		relPath, _ := filepath.Rel(projectRoot, path)
+		text, format, err := readTextFile(path)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("failed to read %s: %v", relPath, err))
+			continue
+		}
+		newText, changed := rw.rewrite(text)
+		if len(changed) == 0 {
+			continue
+		}
+		if err := writeTextFileAtomic(path, newText, format); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to write %s: %v", relPath, err))
+			recordAction("modify", path, "failed", err.Error(), 0)
+			continue
+		}
+		log.Printf(tr("[OK] Updated CI pipeline: %s (%d line(s))\n"), relPath, len(changed))
+		recordAction("modify", path, "ok", fmt.Sprintf("%d lines", len(changed)), 0)
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("encountered %d error(s): %s", len(errors), strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// ============================================================
+// C# Namespaces (-namespaces)
+// ============================================================
+
+// namespaceRewriter renames the root namespace in namespace declarations and
+// using directives (plain, static, alias and global ones). Only those lines are
+// touched; a fully qualified OldName.Type in code is left for the compiler to
+// point out rather than guessed at.
+type namespaceRewriter struct {
+	pattern *regexp.Regexp // nil when the namespace does not change
+	newName string
+}
+
+// namespaceName turns a project folder name into the identifier scripts use
+// for it: folder names may contain '-', C# identifiers may not
+func namespaceName(folderName string) string {
+	return strings.ReplaceAll(folderName, "-", "_")
+}
+
+func newNamespaceRewriter(oldName, newName string) *namespaceRewriter {
+	rw := &namespaceRewriter{newName: namespaceName(newName)}
+	oldNamespace := namespaceName(oldName)
+	if oldNamespace == rw.newName {
+		return rw
+	}
+	// Group 1 is everything before the root namespace; group 2 makes sure the whole
+	// first segment matched (OldName.Core, OldName; or OldName { but not OldNameTools)
+	// and that "using OldName = ..." declares an alias rather than naming a namespace
+	rw.pattern = regexp.MustCompile(`^([ \t]*(?:global[ \t]+)?(?:using[ \t]+(?:static[ \t]+)?(?:[A-Za-z_][A-Za-z0-9_]*[ \t]*=[ \t]*)?(?:global::)?|namespace[ \t]+))` +
+		regexp.QuoteMeta(oldNamespace) + `([ \t]*[.;{]|[ \t]*\r?$)`)
+	return rw
+}
+
+// rewrite returns the new text and an "old -> new" entry for every changed line
+func (rw *namespaceRewriter) rewrite(text string) (string, []string) {
+	if rw.pattern == nil {
+		return text, nil
+	}
+	var changed []string
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if newLine := rw.pattern.ReplaceAllString(line, "${1}"+rw.newName+"${2}"); newLine != line {
+			lines[i] = newLine
+			changed = append(changed, strings.TrimSpace(line)+" -> "+strings.TrimSpace(newLine))
+		}
+	}
+	return strings.Join(lines, "\n"), changed
+}
+
+// collectNamespaceFiles returns the .cs files under Assets/ that declare or
+// import the old root namespace
+func collectNamespaceFiles(projectRoot string, rw *namespaceRewriter) []string {
+	if rw.pattern == nil {
+		return nil
+	}
+	var files []string
+	fsys.Walk(filepath.Join(projectRoot, "Assets"), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.EqualFold(filepath.Ext(path), ".cs") {
+			return nil
+		}
+		text, _, rErr := readTextFile(path)
+		if rErr != nil {
+			return nil
+		}
+		if _, changed := rw.rewrite(text); len(changed) > 0 && renameFilter.allows(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files
+}
+
+// updateNamespaces rewrites the collected scripts
+func updateNamespaces(log *Logger, projectRoot string, files []string, rw *namespaceRewriter) error {
+	var errors []string
+	for _, path := range files {
+		relPath, _ := filepath.Rel(projectRoot, path)
+		text, format, err := readTextFile(path)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("failed to read %s: %v", relPath, err))
+			continue
+		}
+		newText, changed := rw.rewrite(text)
+		if len(changed) == 0 {
+			continue
+		}
+		if err := writeTextFileAtomic(path, newText, format); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to write %s: %v", relPath, err))
+			recordAction("modify", path, "failed", err.Error(), 0)
+			continue
+		}
+		log.Printf(tr("[OK] Updated namespaces: %s (%d line(s))\n"), relPath, len(changed))
+		recordAction("modify", path, "ok", fmt.Sprintf("%d lines", len(changed)), 0)
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("encountered %d error(s): %s", len(errors), strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// ============================================================
+// Store Metadata (-store)
+// ============================================================
+
+// The scaffold follows the folder layout fastlane's supply (Google Play) and
+// deliver (App Store) upload, so store/ can be used as their metadata_path
+const storeDirName = "store"
+
+var storeLocalePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// storeFile is one file of the scaffold
+type storeFile struct {
+	path string // slash-separated, relative to store/
+	text string
+}
+
+// storeChange is a planned write: "create" for a missing file, "update" for a
+// file that is still exactly what the scaffold wrote for the old names
+type storeChange struct {
+	path   string
+	text   string
+	action string
+}
+
+func storeScaffold(locales []string, companyName, appName, year string) []storeFile {
+	bundleID := "com." + companyName + "." + appName
+	files := []storeFile{
+		{path: "Appfile", text: fmt.Sprintf("# fastlane Appfile; copy it into fastlane/ or merge it with the existing one\napp_identifier(\"%s\") # App Store\npackage_name(\"%s\") # Google Play\n", bundleID, bundleID)},
+		{path: "ios/copyright.txt", text: year + " " + companyName + "\n"},
+	}
+	for _, locale := range locales {
+		android := "android/" + locale + "/"
+		ios := "ios/" + locale + "/"
+		files = append(files,
+			storeFile{path: android + "title.txt", text: appName + "\n"},
+			storeFile{path: android + "short_description.txt", text: "TODO: one-line pitch for " + appName + " (up to 80 characters)\n"},
+			storeFile{path: android + "full_description.txt", text: "TODO: full description of " + appName + " (up to 4000 characters)\n"},
+			storeFile{path: android + "changelogs/default.txt", text: "TODO: what's new in this version (up to 500 characters)\n"},
+			storeFile{path: ios + "name.txt", text: appName + "\n"},
+			storeFile{path: ios + "subtitle.txt", text: "TODO: subtitle (up to 30 characters)\n"},
+			storeFile{path: ios + "description.txt", text: "TODO: description of " + appName + " (up to 4000 characters)\n"},
+			storeFile{path: ios + "keywords.txt", text: "TODO: comma-separated keywords (up to 100 characters)\n"},
+			storeFile{path: ios + "release_notes.txt", text: "TODO: what's new in this version (up to 4000 characters)\n"},
+			storeFile{path: ios + "support_url.txt", text: "TODO: https://\n"},
+			storeFile{path: ios + "privacy_url.txt", text: "TODO: https://\n"},
+		)
+	}
+	return files
+}
+
+// parseStoreLocales splits -store-locales into fastlane locale codes (en-US, zh-Hans)
+func parseStoreLocales(value string) ([]string, error) {
+	var locales []string
+	for _, locale := range strings.Split(value, ",") {
+		if locale = strings.TrimSpace(locale); locale == "" {
+			continue
+		}
+		if !storeLocalePattern.MatchString(locale) {
+			return nil, fmt.Errorf("invalid store locale '%s' (expected codes like en-US or zh-Hans)", locale)
+		}
+		locales = append(locales, locale)
+	}
+	if len(locales) == 0 {
+		return nil, fmt.Errorf("-store-locales lists no locale")
+	}
+	return locales, nil
+}
+
+// planStoreScaffold compares the scaffold with store/ on disk. Files that exist are
+// kept as written, unless they still hold the scaffold of the old names (oldFiles,
+// in the same order): those were never edited and follow the rename.
+func planStoreScaffold(projectRoot string, oldFiles, newFiles []storeFile) []storeChange {
+	var plan []storeChange
+	for i, f := range newFiles {
+		path := filepath.Join(projectRoot, storeDirName, filepath.FromSlash(f.path))
+		text, _, err := readTextFile(path)
+		switch {
+		case err != nil:
+			plan = append(plan, storeChange{path: path, text: f.text, action: "create"})
+		case text != f.text && text == oldFiles[i].text:
+			plan = append(plan, storeChange{path: path, text: f.text, action: "update"})
+		}
+	}
+	return plan
+}
+
+// writeStoreScaffold creates the missing files and updates the stale app names
+func writeStoreScaffold(log *Logger, projectRoot string, plan []storeChange) error {
+	var errors []string
+	created := 0
+	for _, c := range plan {
+		relPath, _ := filepath.Rel(projectRoot, c.path)
+		journal.recordMkdir(filepath.Dir(c.path))
+		if err := fsys.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to create folder for %s: %v", relPath, err))
+			continue
+		}
+		if err := writeJournaledFile(c.path, []byte(c.text)); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to write %s: %v", relPath, err))
+			recordAction(c.action, c.path, "failed", err.Error(), 0)
+			continue
+		}
+		if c.action == "update" {
+			log.Printf(tr("[OK] Updated store metadata: %s\n"), relPath)
+		} else {
+			created++
+		}
+		recordAction(c.action, c.path, "ok", "", 0)
+	}
+	if created > 0 {
+		log.Printf(tr("[OK] Created %d store metadata file(s) in %s/\n"), created, storeDirName)
+		recordArtifact(filepath.Join(projectRoot, storeDirName))
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("encountered %d error(s): %s", len(errors), strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// ============================================================
+// Path Filters (-rename-include / -rename-exclude)
+// ============================================================
+
+// globToRegexp converts a glob to a regexp: ** spans folders, * and ? stay within
+// one path segment. Patterns without a slash match the file name alone.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compileGlobs parses a comma-separated glob list
+func compileGlobs(list string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := globToRegexp(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, rel string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathFilter limits the files the replacement passes may write. Without globs
+// every pass keeps its own fixed set of files; the globs can only narrow it.
+type pathFilter struct {
+	root     string
+	includes []*regexp.Regexp
+	excludes []*regexp.Regexp
+	skipped  map[string]bool // project-relative paths a pass would have changed
+}
+
+var renameFilter = &pathFilter{}
+
+func newPathFilter(includeList, excludeList string) (*pathFilter, error) {
+	includes, err := compileGlobs(includeList)
+	if err != nil {
+		return nil, fmt.Errorf("-rename-include: %v", err)
+	}
+	excludes, err := compileGlobs(excludeList)
+	if err != nil {
+		return nil, fmt.Errorf("-rename-exclude: %v", err)
+	}
+	return &pathFilter{includes: includes, excludes: excludes, skipped: make(map[string]bool)}, nil
+}
+
+// allows reports whether a pass may change the file. Paths are matched relative
+// to the Unity project root with forward slashes; files above it start with ../
+func (f *pathFilter) allows(path string) bool {
+	if len(f.includes) == 0 && len(f.excludes) == 0 {
+		return true
+	}
+	rel, err := filepath.Rel(f.root, path)
+	if err != nil {
+		rel = path
+	}
+	rel = filepath.ToSlash(rel)
+	if (len(f.includes) > 0 && !matchesAny(f.includes, rel)) || matchesAny(f.excludes, rel) {
+		f.skipped[rel] = true
+		return false
+	}
+	return true
+}
+
+// printSkipped lists the files left alone because of the globs
+func (f *pathFilter) printSkipped(log *Logger) {
+	if len(f.skipped) == 0 {
+		return
+	}
+	paths := make([]string, 0, len(f.skipped))
+	for rel := range f.skipped {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+	log.Printf(tr("\nExcluded by -rename-include/-rename-exclude (%d file(s), left unchanged):\n"), len(paths))
+	for _, rel := range paths {
+		log.Printf("    %s\n", rel)
+		recordAction("skip", rel, "excluded", "", 0)
+	}
+}
+
+// ============================================================
+// Template Variables
+// ============================================================
+
+// Unity-style placeholders (like #SCRIPTNAME# in script templates) that the rename
+// fills in. #COMPANY# and #PRODUCT# take the new company and application names.
+const (
+	placeholderAuthor  = "#AUTHOR#"
+	placeholderYear    = "#YEAR#"
+	placeholderLicense = "#LICENSE#"
+	placeholderCompany = "#COMPANY#"
+	placeholderProduct = "#PRODUCT#"
+)
+
+// Text files that may carry file headers or license text
+var templateFileExts = map[string]bool{
+	".cs":     true,
+	".md":     true,
+	".txt":    true,
+	".shader": true,
+	".hlsl":   true,
+	".cginc":  true,
+	".uss":    true,
+	".uxml":   true,
+}
+
+// Vendored code keeps its own headers and license
+var templateSkipDirs = map[string]bool{
+	"ThirdParty": true,
+	"Plugins":    true,
+}
+
+var (
+	spdxPattern    = regexp.MustCompile(`(SPDX-License-Identifier:[ \t]*)([A-Za-z0-9.+()\-]+(?:[ \t]+(?:AND|OR|WITH)[ \t]+[A-Za-z0-9.+()\-]+)*)`)
+	licenseIDRegex = regexp.MustCompile(`^[A-Za-z0-9.+()\-]+(?: (?:AND|OR|WITH) [A-Za-z0-9.+()\-]+)*$`)
+	yearRegex      = regexp.MustCompile(`^\d{4}(-\d{4})?$`)
+)
+
+// loadSharedConfig reads UNITYSTARTER_CONFIG, or else the first .unitystarter.json
+// found from the project root upward, so one file in a parent folder can serve every
+// project of a company. Returns an empty config when none exists.
+func loadSharedConfig(projectRoot string) (*SharedConfig, string, error) {
+	path := os.Getenv(sharedConfigEnvVar)
+	if path == "" {
+		abs, err := filepath.Abs(projectRoot)
+		if err != nil {
+			return &SharedConfig{}, "", nil
+		}
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			candidate := filepath.Join(dir, sharedConfigFileName)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				return &SharedConfig{}, "", nil
+			}
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, path, err
+	}
+	var cfg SharedConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, path, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	return &cfg, path, nil
+}
+
+// validateSharedConfig rejects values that would produce broken headers
+func validateSharedConfig(cfg *SharedConfig) error {
+	if strings.ContainsAny(cfg.Author, "\r\n") {
+		return fmt.Errorf("author must be a single line")
+	}
+	if cfg.License != "" && !licenseIDRegex.MatchString(cfg.License) {
+		return fmt.Errorf("license '%s' is not an SPDX identifier (e.g. MIT, Apache-2.0)", cfg.License)
+	}
+	if cfg.CopyrightYear != "" && !yearRegex.MatchString(cfg.CopyrightYear) {
+		return fmt.Errorf("copyright year '%s' must look like 2025 or 2020-2025", cfg.CopyrightYear)
+	}
+	return nil
+}
+
+// templateVars maps each placeholder to its value; unknown values stay unfilled
+func templateVars(cfg *SharedConfig, newCompanyName, newAppName string) map[string]string {
+	vars := map[string]string{
+		placeholderYear:    cfg.CopyrightYear,
+		placeholderCompany: newCompanyName,
+		placeholderProduct: newAppName,
+	}
+	if vars[placeholderYear] == "" {
+		vars[placeholderYear] = strconv.Itoa(time.Now().Year())
+	}
+	if cfg.Author != "" {
+		vars[placeholderAuthor] = cfg.Author
+	}
+	if cfg.License != "" {
+		vars[placeholderLicense] = cfg.License
+	}
+	return vars
+}
+
+// fillTemplateText replaces the placeholders and, with updateSPDX and a configured
+// license, existing SPDX-License-Identifier lines. Returns the new text and the
+// number of replacements.
+func fillTemplateText(text string, vars map[string]string, updateSPDX bool) (string, int) {
+	count := 0
+	for placeholder, value := range vars {
+		if value == "" {
+			continue
+		}
+		if n := strings.Count(text, placeholder); n > 0 {
+			text = strings.ReplaceAll(text, placeholder, value)
+			count += n
+		}
+	}
+	if license := vars[placeholderLicense]; updateSPDX && license != "" {
+		text = spdxPattern.ReplaceAllStringFunc(text, func(m string) string {
+			sub := spdxPattern.FindStringSubmatch(m)
+			if sub[2] == license {
+				return m
+			}
+			count++
+			return sub[1] + license
+		})
+	}
+	return text, count
+}
+
+// isLicenseFile matches LICENSE, LICENSE.md, COPYING.txt, NOTICE and the like
+func isLicenseFile(name string) bool {
+	base := strings.ToUpper(strings.TrimSuffix(name, filepath.Ext(name)))
+	return base == "LICENSE" || base == "LICENCE" || base == "COPYING" || base == "NOTICE"
+}
+
+// ownsSPDX reports whether SPDX lines in the file follow the configured license:
+// only project code under Assets/; embedded packages may be vendored.
+func ownsSPDX(projectRoot, path string) bool {
+	return strings.HasPrefix(path, filepath.Join(projectRoot, "Assets")+string(os.PathSeparator))
+}
+
+// collectTemplateFiles returns the files under Assets/ and Packages/ plus the license
+// files in the project and repository roots that have something to fill in.
+func collectTemplateFiles(projectRoot string, vars map[string]string) []string {
+	var files []string
+	addIfTemplated := func(path string) {
+		text, _, err := readTextFile(path)
+		if err != nil {
+			return
+		}
+		if _, n := fillTemplateText(text, vars, ownsSPDX(projectRoot, path)); n > 0 && renameFilter.allows(path) {
+			files = append(files, path)
+		}
+	}
+
+	for _, dir := range []string{"Assets", "Packages"} {
+		fsys.Walk(filepath.Join(projectRoot, dir), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if templateSkipDirs[info.Name()] {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if templateFileExts[strings.ToLower(filepath.Ext(path))] || isLicenseFile(info.Name()) {
+				addIfTemplated(path)
+			}
+			return nil
+		})
+	}
+	for _, root := range findDocsRoots(projectRoot) {
+		entries, err := fsys.ReadDir(root)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && isLicenseFile(entry.Name()) {
+				addIfTemplated(filepath.Join(root, entry.Name()))
+			}
+		}
+	}
+	return files
+}
+
+// updateTemplateFiles fills in the collected files
+func updateTemplateFiles(log *Logger, projectRoot string, files []string, vars map[string]string) error {
+	var errors []string
+	for _, path := range files {
+		relPath, _ := filepath.Rel(projectRoot, path)
+		text, format, err := readTextFile(path)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("failed to read %s: %v", relPath, err))
+			continue
+		}
+		newText, n := fillTemplateText(text, vars, ownsSPDX(projectRoot, path))
+		if n == 0 {
+			continue
+		}
+		if err := writeTextFileAtomic(path, newText, format); err != nil {
+			errors = append(errors, fmt.Sprintf("failed to write %s: %v", relPath, err))
+			recordAction("modify", path, "failed", err.Error(), 0)
+			continue
+		}
+		log.Printf(tr("[OK] Filled template values: %s (%d)\n"), relPath, n)
+		recordAction("modify", path, "ok", fmt.Sprintf("%d template values", n), 0)
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("encountered %d error(s): %s", len(errors), strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// ============================================================
+// Rename Config (-config)
+// ============================================================
+
+// Unity's keys in the applicationIdentifier map, by the lower-case names a
+// rename config may use
+var bundleIDPlatforms = map[string]string{
+	"android":          "Android",
+	"ios":              "iPhone",
+	"iphone":           "iPhone",
+	"standalone":       "Standalone",
+	"tvos":             "tvOS",
+	"visionos":         "VisionOS",
+	"webgl":            "WebGL",
+	"switch":           "Switch",
+	"ps4":              "PS4",
+	"ps5":              "PS5",
+	"xboxone":          "XboxOne",
+	"gamecorexboxone":  "GameCoreXboxOne",
+	"gamecorescarlett": "GameCoreScarlett",
+}
+
+var bundleIDPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*(\.[A-Za-z][A-Za-z0-9_-]*)+$`)
+
+// loadRenameConfig reads a rename config, JSON or YAML by its extension, and
+// checks every value before anything is touched
+func loadRenameConfig(path string) (*RenameConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg RenameConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&cfg)
+	case ".yaml", ".yml":
+		err = parseRenameYAML(string(data), &cfg)
+	default:
+		return nil, fmt.Errorf("%s: a rename config must be .json, .yaml or .yml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", path, err)
+	}
+
+	for _, f := range []struct{ key, value string }{{"project", cfg.Project}, {"company", cfg.Company}, {"app", cfg.App}} {
+		if f.value != "" && !namePattern.MatchString(f.value) {
+			return nil, fmt.Errorf("%s: %s '%s' may only contain letters, numbers, _ and -, and cannot start with a number or dash", path, f.key, f.value)
+		}
+	}
+	ids := make(map[string]string, len(cfg.BundleIDs))
+	for platform, id := range cfg.BundleIDs {
+		key, ok := bundleIDPlatforms[strings.ToLower(platform)]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown bundle ID platform '%s'", path, platform)
+		}
+		if _, dup := ids[key]; dup {
+			return nil, fmt.Errorf("%s: more than one bundle ID for %s", path, key)
+		}
+		if !bundleIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("%s: bundle ID '%s' for %s must look like com.company.app", path, id, platform)
+		}
+		ids[key] = id
+	}
+	cfg.BundleIDs = ids
+	return &cfg, nil
+}
+
+// parseRenameYAML reads the YAML a rename config needs: top-level "key: value"
+// lines and the bundleIds map indented under its key. Names and bundle IDs
+// never contain '#', so everything after one is a comment.
+func parseRenameYAML(text string, cfg *RenameConfig) error {
+	fields := map[string]*string{"project": &cfg.Project, "company": &cfg.Company, "app": &cfg.App}
+	inBundleIDs := false
+	for n, line := range strings.Split(text, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimRight(line, " \t\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			return fmt.Errorf("line %d: expected 'key: value'", n+1)
+		}
+		key, value := strings.TrimSpace(line[:i]), yamlUnquote(strings.TrimSpace(line[i+1:]))
+		if line[0] == ' ' || line[0] == '\t' {
+			if !inBundleIDs {
+				return fmt.Errorf("line %d: unexpected indentation", n+1)
+			}
+			if cfg.BundleIDs == nil {
+				cfg.BundleIDs = make(map[string]string)
+			}
+			cfg.BundleIDs[key] = value
+			continue
+		}
+		inBundleIDs = key == "bundleIds" && value == ""
+		if inBundleIDs {
+			continue
+		}
+		field, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("line %d: unknown key '%s'", n+1, key)
+		}
+		*field = value
+	}
+	return nil
+}
+
+func yamlUnquote(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// setBundleIDs gives platforms in ProjectSettings.asset's applicationIdentifier
+// map their own bundle ID, adding the ones the map lacks. Returns the new text
+// and a line per platform that changed.
+func setBundleIDs(text string, ids map[string]string) (string, []string) {
+	if len(ids) == 0 {
+		return text, nil
+	}
+	lines := strings.Split(text, "\n")
+	start := -1
+	for i, l := range lines {
+		if l == "  applicationIdentifier:" || l == "  applicationIdentifier: {}" {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return text, nil
+	}
+
+	var notes []string
+	seen := make(map[string]bool)
+	end := start + 1
+	for ; end < len(lines) && strings.HasPrefix(lines[end], "    "); end++ {
+		entry := strings.TrimSpace(lines[end])
+		i := strings.Index(entry, ":")
+		if i < 0 {
+			continue
+		}
+		platform, old := entry[:i], strings.TrimSpace(entry[i+1:])
+		id, ok := ids[platform]
+		if !ok {
+			continue
+		}
+		seen[platform] = true
+		if old != id {
+			lines[end] = "    " + platform + ": " + id
+			notes = append(notes, fmt.Sprintf("applicationIdentifier.%s: %s -> %s", platform, old, id))
+		}
+	}
+
+	var platforms []string
+	for platform := range ids {
+		if !seen[platform] {
+			platforms = append(platforms, platform)
+		}
+	}
+	sort.Strings(platforms)
+	var added []string
+	for _, platform := range platforms {
+		added = append(added, "    "+platform+": "+ids[platform])
+		notes = append(notes, fmt.Sprintf(tr("applicationIdentifier.%s: (none) -> %s"), platform, ids[platform]))
+	}
+	if len(notes) == 0 {
+		return text, nil
+	}
+	lines[start] = "  applicationIdentifier:"
+	lines = append(lines[:end], append(added, lines[end:]...)...)
+	return strings.Join(lines, "\n"), notes
+}
+
+// bundleIDChanges previews setBundleIDs on ProjectSettings.asset as it will be
+// after the shared com.<Company>.<App> ID has been replaced
+func bundleIDChanges(projectRoot, oldID, newID string, ids map[string]string) []string {
+	if len(ids) == 0 {
+		return nil
+	}
+	text, _, err := readTextFile(filepath.Join(projectRoot, "ProjectSettings", "ProjectSettings.asset"))
+	if err != nil {
+		return nil
+	}
+	if oldID != newID {
+		text = strings.ReplaceAll(text, oldID, newID)
+	}
+	_, notes := setBundleIDs(text, ids)
+	return notes
+}
+
+// ============================================================
+// Version Control Checkout
+// ============================================================
+
+// Perforce and Plastic SCM keep files read-only until they are checked out. Writing
+// them directly (even after clearing the flag) leaves changes the server does not know
+// about, so files are opened for edit first. Git and plain folders need nothing.
+const (
+	vcsNone     = "none"
+	vcsPerforce = "p4"
+	vcsPlastic  = "plastic"
+)
+
+// vcsEnvVar overrides detection for every tool (e.g. on build agents)
+const vcsEnvVar = "UNITYSTARTER_VCS"
+
+// vcsClient issues checkouts for files about to be modified
+type vcsClient struct {
+	kind       string
+	source     string // how kind was chosen, for the startup message
+	checkedOut map[string]bool
+}
+
+// activeVCS is set up in main; nil means no checkouts
+var activeVCS *vcsClient
+
+// detectVCS picks the checkout provider. Priority: -vcs flag > UNITYSTARTER_VCS >
+// Unity's VersionControlSettings.asset mode > workspace markers (.plastic, P4CONFIG).
+func detectVCS(projectRoot, override string) (*vcsClient, error) {
+	normalize := func(v string) (string, bool) {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "p4", "perforce":
+			return vcsPerforce, true
+		case "plastic", "plasticscm", "cm", "unityvcs":
+			return vcsPlastic, true
+		case "none", "git", "off":
+			return vcsNone, true
+		}
+		return "", false
+	}
+	newClient := func(kind, source string) *vcsClient {
+		return &vcsClient{kind: kind, source: source, checkedOut: make(map[string]bool)}
+	}
+
+	if override != "" && override != "auto" {
+		kind, ok := normalize(override)
+		if !ok {
+			return nil, fmt.Errorf("unknown -vcs value '%s' (use auto, none, p4 or plastic)", override)
+		}
+		return newClient(kind, "-vcs flag"), nil
+	}
+	if env := os.Getenv(vcsEnvVar); env != "" {
+		if kind, ok := normalize(env); ok {
+			return newClient(kind, vcsEnvVar), nil
+		}
+	}
+
+	// Unity stores the project's integrated VCS in m_Mode ("Perforce", "PlasticSCM", ...)
+	if data, err := os.ReadFile(filepath.Join(projectRoot, "ProjectSettings", "VersionControlSettings.asset")); err == nil {
+		if m := regexp.MustCompile(`(?m)^\s*m_Mode:\s*(.+?)\s*$`).FindSubmatch(data); m != nil {
+			switch strings.ToLower(string(m[1])) {
+			case "perforce":
+				return newClient(vcsPerforce, "VersionControlSettings.asset"), nil
+			case "plasticscm", "plastic scm", "unity version control":
+				return newClient(vcsPlastic, "VersionControlSettings.asset"), nil
+			}
+		}
+	}
+
+	// Workspace markers, searched upward from the project root
+	p4config := os.Getenv("P4CONFIG")
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info != nil {
+				return newClient(vcsNone, "git repository"), nil
+			}
+			if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+				return newClient(vcsPlastic, ".plastic workspace"), nil
+			}
+			if p4config != "" {
+				if _, err := os.Stat(filepath.Join(dir, p4config)); err == nil {
+					return newClient(vcsPerforce, "P4CONFIG"), nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if os.Getenv("P4CLIENT") != "" {
+		return newClient(vcsPerforce, "P4CLIENT"), nil
+	}
+	return newClient(vcsNone, "no Perforce/Plastic workspace found"), nil
+}
+
+// checkout opens an existing file for edit. New files need no checkout, and files
+// outside the depot/workspace only produce a warning: the write still goes ahead.
+func (v *vcsClient) checkout(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || v.checkedOut[abs] {
+		return
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return
+	}
+	v.checkedOut[abs] = true
+
+	var cmd *exec.Cmd
+	switch v.kind {
+	case vcsPerforce:
+		cmd = exec.Command("p4", "edit", abs)
+	case vcsPlastic:
+		cmd = exec.Command("cm", "checkout", abs)
+	default:
+		return
+	}
+	// Run from the file's folder so P4CONFIG / the Plastic workspace is resolved
+	cmd.Dir = filepath.Dir(abs)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		fmt.Printf(tr("[WARNING] %s checkout failed for %s: %v %s\n"), v.kind, filepath.Base(abs), err, strings.TrimSpace(string(out)))
+		return
+	}
+	fmt.Printf(tr("[VCS] Checked out (%s): %s\n"), v.kind, filepath.Base(abs))
+}
+
+// ============================================================
+// Rollback Journal
+// ============================================================
+
+// journalEntry is one change a rename made: a file written (with its content from
+// before the rename touched it) or created, a folder created, or an entry moved
+type journalEntry struct {
+	kind string // "write", "create", "mkdir", "move"
+	path string // the file or folder changed; for a move, where it went
+	from string // move: where it came from
+	data []byte // write: the original content
+}
+
+// renameJournal lists the changes of a running rename in order so a failing step
+// can undo all of them. It is nil outside the execution phase (dry runs, the
+// backup), which makes every method a no-op.
+type renameJournal struct {
+	entries []journalEntry
+	seen    map[string]bool
+}
+
+var journal *renameJournal
+
+func newRenameJournal() *renameJournal {
+	return &renameJournal{seen: make(map[string]bool)}
+}
+
+// keepWrite reads what path holds before its first write in this run. The entry
+// is only journaled by recordWrite once the write went through: a write that
+// failed changed nothing, and undoing it would fail the same way.
+func (j *renameJournal) keepWrite(path string) (*journalEntry, error) {
+	if j == nil || j.seen[path] {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		return &journalEntry{kind: "create", path: path}, nil
+	case err != nil:
+		return nil, fmt.Errorf("cannot keep %s for rollback: %v", path, err)
+	}
+	return &journalEntry{kind: "write", path: path, data: data}, nil
+}
+
+// recordWrite journals an entry from keepWrite after its write succeeded
+func (j *renameJournal) recordWrite(e *journalEntry) {
+	if j == nil || e == nil {
+		return
+	}
+	j.entries = append(j.entries, *e)
+	j.seen[e.path] = true
+}
+
+// recordMkdir notes the folders that creating dir with MkdirAll will add
+func (j *renameJournal) recordMkdir(dir string) {
+	if j == nil {
+		return
+	}
+	var missing []string
+	for d := dir; filepath.Dir(d) != d; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		}
+		missing = append(missing, d)
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		j.entries = append(j.entries, journalEntry{kind: "mkdir", path: missing[i]})
+	}
+}
+
+func (j *renameJournal) recordMove(from, to string) {
+	if j != nil {
+		j.entries = append(j.entries, journalEntry{kind: "move", path: to, from: from})
+	}
+}
+
+// rollback undoes the journaled changes, newest first, and ends the journal.
+// Whatever cannot be undone is listed; the backup still has those files.
+func (j *renameJournal) rollback(log *Logger) {
+	if j == nil || len(j.entries) == 0 {
+		return
+	}
+	journal = nil // the undo steps are not journaled themselves
+	log.Println(tr("\nRolling back the changes made so far..."))
+	failed := 0
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		e := j.entries[i]
+		target := e.path
+		var err error
+		switch e.kind {
+		case "write":
+			err = writeFileAtomic(e.path, e.data)
+		case "create", "mkdir":
+			err = os.Remove(e.path)
+		case "move":
+			target = e.from
+			err = moveEntry(e.path, e.from)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			failed++
+			log.Printf(tr("[FAIL] Could not restore %s: %v\n"), target, err)
+			recordAction("rollback", target, "failed", err.Error(), 0)
+			continue
+		}
+		recordAction("rollback", target, "ok", e.kind, 0)
+	}
+	if failed > 0 {
+		log.Printf(tr("[!!] %d change(s) could not be undone; restore them from the backup.\n"), failed)
+		return
+	}
+	log.Printf(tr("[OK] Rolled back %d change(s); the project is as it was before the rename.\n"), len(j.entries))
+}
+
+// ============================================================
+// Safe File Writes
+// ============================================================
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// writeFileAtomic replaces path with data without ever leaving a half-written file:
+// data goes to a temp file in the same directory which is then renamed over the target.
+// The target's permission bits are kept, and a read-only flag (files under Perforce or
+// Plastic SCM that were not checked out) is cleared so the write does not fail.
+func writeFileAtomic(path string, data []byte) error {
+	// A dry run writes straight into the overlay: no checkout, no temp file
+	if isDryRun() {
+		return fsys.WriteFile(path, data, 0644)
+	}
+	activeVCS.checkout(path)
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if mode&0200 == 0 {
+			mode |= 0200
+			if err := os.Chmod(path, mode); err != nil {
+				return fmt.Errorf("cannot clear read-only flag on %s: %v", path, err)
+			}
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Retry briefly: editors, indexers and antivirus can hold the target open on Windows
+	for attempt := 0; ; attempt++ {
+		err = os.Rename(tmpPath, path)
+		if err == nil || attempt == 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// writeJournaledFile is writeFileAtomic for the rename steps: the original is kept
+// so a failing step can roll it back, and journaled only once the write succeeded
+func writeJournaledFile(path string, data []byte) error {
+	undo, err := journal.keepWrite(path)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return err
+	}
+	journal.recordWrite(undo)
+	return nil
+}
+
+// textFormat records the BOM and line-ending style of a text file. Rewrites work on
+// BOM-less, LF-only text; encode restores the original format when writing back so
+// CRLF files (common for files edited on Windows) and UTF-8 BOM files stay intact.
+type textFormat struct {
+	bom  bool
+	crlf bool // ending of most lines, and of the lines a rewrite adds
+	// Mixed files only: the text as read (LF) and the ending of each of its line
+	// breaks, 'r' (CRLF) or 'n' (LF). Strings keep textFormat comparable.
+	orig string
+	eols string
+}
+
+// decodeText strips a UTF-8 BOM and normalizes line endings to LF. A file counts as
+// CRLF when most of its line breaks are CRLF; a file with both keeps the ending of
+// every line, so a rewrite only changes the endings of the lines it edits.
+func decodeText(data []byte) (string, textFormat) {
+	var format textFormat
+	if bytes.HasPrefix(data, utf8BOM) {
+		format.bom = true
+		data = data[len(utf8BOM):]
+	}
+	crlfCount := bytes.Count(data, []byte("\r\n"))
+	lfCount := bytes.Count(data, []byte("\n")) - crlfCount
+	format.crlf = crlfCount > 0 && crlfCount >= lfCount
+	if crlfCount == 0 {
+		return string(data), format
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if lfCount > 0 {
+		eols := make([]byte, 0, crlfCount+lfCount)
+		for i, c := range data {
+			if c == '\n' {
+				if i > 0 && data[i-1] == '\r' {
+					eols = append(eols, 'r')
+				} else {
+					eols = append(eols, 'n')
+				}
+			}
+		}
+		format.orig, format.eols = text, string(eols)
+	}
+	return text, format
+}
+
+// encode converts LF text back to the recorded format
+func (f textFormat) encode(text string) []byte {
+	switch {
+	case f.eols != "":
+		text = f.mixedEndings(text)
+	case f.crlf:
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	if f.bom {
+		return append(append([]byte{}, utf8BOM...), text...)
+	}
+	return []byte(text)
+}
+
+// mixedEndings gives the lines of a rewritten mixed file the endings they had. The
+// unchanged lines before and after the edited region keep theirs; edited lines keep
+// the ending of the line they replace when the line count did not change, and get
+// the majority ending otherwise.
+func (f textFormat) mixedEndings(text string) string {
+	old := strings.Split(f.orig, "\n")
+	lines := strings.Split(text, "\n")
+	prefix := 0
+	for prefix < len(old) && prefix < len(lines) && old[prefix] == lines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(lines)-prefix && old[len(old)-1-suffix] == lines[len(lines)-1-suffix] {
+		suffix++
+	}
+	crlfAt := func(j int) bool {
+		if j >= 0 && j < len(f.eols) {
+			return f.eols[j] == 'r'
+		}
+		return f.crlf
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		b.WriteString(line)
+		if i == len(lines)-1 {
+			break
+		}
+		crlf := f.crlf
+		switch {
+		case i < prefix || len(lines) == len(old):
+			crlf = crlfAt(i)
+		case i >= len(lines)-suffix:
+			crlf = crlfAt(i - len(lines) + len(old))
+		}
+		if crlf {
+			b.WriteByte('\r')
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// readTextFile reads path as LF text and reports its original format
+func readTextFile(path string) (string, textFormat, error) {
+	data, err := fsys.ReadFile(path)
+	if err != nil {
+		return "", textFormat{}, err
+	}
+	text, format := decodeText(data)
+	return text, format, nil
+}
+
+// writeTextFileAtomic writes LF text in the given format via writeJournaledFile
+func writeTextFileAtomic(path, text string, format textFormat) error {
+	return writeJournaledFile(path, format.encode(text))
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+func clearScreen() {
+	// Clearing wipes what a screen reader or CI log has already captured
+	if plainMode {
+		fmt.Println()
+		return
+	}
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "cls")
+	case "linux", "darwin":
+		cmd = exec.Command("clear")
+	default:
+		return
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Run()
+}
+
+// ============================================================
+// Filesystem
+// ============================================================
+
+// fileSystem is every filesystem call that can change the project. The real
+// run uses osFS; -dry-run swaps in an overlayFS so the same code path runs
+// and the overlay reports what it would have changed.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Walk(root string, fn filepath.WalkFunc) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Chmod(name string, mode os.FileMode) error
+}
+
+var fsys fileSystem = osFS{}
+
+// isDryRun reports whether changes are going to the in-memory overlay
+func isDryRun() bool {
+	_, ok := fsys.(*overlayFS)
+	return ok
+}
+
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)         { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) Walk(root string, fn filepath.WalkFunc) error { return filepath.Walk(root, fn) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// fsChange is one change the overlay absorbed instead of the disk
+type fsChange struct {
+	op     string // "write", "create", "mkdir", "rename", "delete"
+	path   string
+	detail string
+}
+
+// overlayFS reads through to the disk and keeps every write, rename and
+// delete in memory. Later reads see those changes, so multi-step operations
+// (rename a folder, then rewrite files inside it) behave as they would for real.
+type overlayFS struct {
+	mu      sync.Mutex
+	files   map[string][]byte // files written during the run
+	dirs    map[string]bool   // directories created during the run
+	moved   map[string]string // renamed path -> where its content is on disk
+	removed map[string]bool   // deleted paths; hides everything below them
+	changes []fsChange
+}
+
+func newOverlayFS() *overlayFS {
+	return &overlayFS{
+		files:   map[string][]byte{},
+		dirs:    map[string]bool{},
+		moved:   map[string]string{},
+		removed: map[string]bool{},
+	}
+}
+
+func overlayKey(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// isUnder reports whether p is base or inside it
+func isUnder(p, base string) bool {
+	return p == base || strings.HasPrefix(p, strings.TrimSuffix(base, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// locate finds where the content of p lives: in memory (data / memDir) or
+// on disk (disk, after following renames). ok is false if p was deleted.
+func (o *overlayFS) locate(p string) (data []byte, memFile, memDir bool, disk string, ok bool) {
+	if d, found := o.files[p]; found {
+		return d, true, false, "", true
+	}
+	if o.dirs[p] {
+		return nil, false, true, "", true
+	}
+	for q := p; ; {
+		if src, found := o.moved[q]; found {
+			rel, _ := filepath.Rel(q, p)
+			return nil, false, false, filepath.Join(src, rel), true
+		}
+		if o.removed[q] {
+			return nil, false, false, "", false
+		}
+		parent := filepath.Dir(q)
+		if parent == q {
+			break
+		}
+		q = parent
+	}
+	return nil, false, false, p, true
+}
+
+func (o *overlayFS) stat(p string) (os.FileInfo, error) {
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	case memFile:
+		return memFileInfo{name: filepath.Base(p), size: int64(len(data))}, nil
+	case memDir:
+		return memFileInfo{name: filepath.Base(p), dir: true}, nil
+	}
+	info, err := os.Stat(disk)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	if disk != p {
+		return renamedFileInfo{info, filepath.Base(p)}, nil
+	}
+	return info, nil
+}
+
+func (o *overlayFS) Stat(name string) (os.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stat(overlayKey(name))
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case memDir:
+		return nil, &os.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	case memFile:
+		return append([]byte(nil), data...), nil
+	}
+	return os.ReadFile(disk)
+}
+
+func (o *overlayFS) readDir(p string) ([]os.DirEntry, error) {
+	_, memFile, memDir, disk, ok := o.locate(p)
+	if !ok || memFile {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+	names := map[string]bool{}
+	if !memDir {
+		entries, err := os.ReadDir(disk)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			names[e.Name()] = true
+		}
+	}
+	for k := range o.dirs {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.files {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.moved {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	var result []os.DirEntry
+	for name := range names {
+		if info, err := o.stat(filepath.Join(p, name)); err == nil {
+			result = append(result, fs.FileInfoToDirEntry(info))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (o *overlayFS) ReadDir(name string) ([]os.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readDir(overlayKey(name))
+}
+
+// Walk follows filepath.Walk: lexical order, SkipDir and SkipAll honored.
+// The lock is released while fn runs so the callback can change the overlay.
+func (o *overlayFS) Walk(root string, fn filepath.WalkFunc) error {
+	info, err := o.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = o.walk(root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (o *overlayFS) walk(path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	entries, err := o.ReadDir(path)
+	if err1 := fn(path, info, err); err != nil || err1 != nil {
+		return err1
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		childInfo, err := o.Stat(child)
+		if err != nil {
+			if err := fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := o.walk(child, childInfo, fn); err != nil {
+			if err == filepath.SkipDir && childInfo.IsDir() {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *overlayFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	op := "create"
+	if info, err := o.stat(p); err == nil {
+		if info.IsDir() {
+			return &os.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+		}
+		op = "write"
+	}
+	if info, err := o.stat(filepath.Dir(p)); err != nil || !info.IsDir() {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	o.files[p] = append([]byte(nil), data...)
+	o.changes = append(o.changes, fsChange{op, p, fmt.Sprintf("%d bytes", len(data))})
+	return nil
+}
+
+func (o *overlayFS) MkdirAll(path string, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	created := false
+	for q := p; ; q = filepath.Dir(q) {
+		if info, err := o.stat(q); err == nil {
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: q, Err: errors.New("not a directory")}
+			}
+			break
+		}
+		o.dirs[q] = true
+		created = true
+		if filepath.Dir(q) == q {
+			break
+		}
+	}
+	if created {
+		o.changes = append(o.changes, fsChange{"mkdir", p, ""})
+	}
+	return nil
+}
+
+// shift moves every overlay entry under from to the same place under to
+func (o *overlayFS) shift(from, to string) {
+	for k, v := range o.files {
+		if isUnder(k, from) {
+			delete(o.files, k)
+			o.files[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, from) {
+			delete(o.dirs, k)
+			o.dirs[to+strings.TrimPrefix(k, from)] = true
+		}
+	}
+	for k, v := range o.moved {
+		if isUnder(k, from) {
+			delete(o.moved, k)
+			o.moved[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+}
+
+func (o *overlayFS) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	from, to := overlayKey(oldpath), overlayKey(newpath)
+	_, _, _, disk, ok := o.locate(from)
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if from == to {
+		return nil
+	}
+	if info, err := o.stat(filepath.Dir(to)); err != nil || !info.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	o.drop(to)
+	o.shift(from, to)
+	if disk != "" {
+		if _, err := os.Stat(disk); err == nil {
+			o.moved[to] = disk
+		}
+	}
+	o.removed[from] = true
+	o.changes = append(o.changes, fsChange{"rename", from, to})
+	return nil
+}
+
+// drop forgets p and everything below it and hides it from the disk
+func (o *overlayFS) drop(p string) {
+	for k := range o.files {
+		if isUnder(k, p) {
+			delete(o.files, k)
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, p) {
+			delete(o.dirs, k)
+		}
+	}
+	for k := range o.moved {
+		if isUnder(k, p) {
+			delete(o.moved, k)
+		}
+	}
+	o.removed[p] = true
+}
+
+// treeSize is the byte size of p as the overlay currently sees it
+func (o *overlayFS) treeSize(p string) int64 {
+	info, err := o.stat(p)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var size int64
+	entries, _ := o.readDir(p)
+	for _, e := range entries {
+		size += o.treeSize(filepath.Join(p, e.Name()))
+	}
+	return size
+}
+
+func (o *overlayFS) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	info, err := o.stat(p)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	if info.IsDir() {
+		if entries, _ := o.readDir(p); len(entries) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", info.Size())})
+	return nil
+}
+
+func (o *overlayFS) RemoveAll(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	if _, err := o.stat(p); err != nil {
+		return nil
+	}
+	size := o.treeSize(p)
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", size)})
+	return nil
+}
+
+func (o *overlayFS) Chmod(name string, mode os.FileMode) error {
+	if _, err := o.Stat(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+// printDryRunReport lists what the overlay absorbed, relative to base
+func (o *overlayFS) printDryRunReport(base string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if abs, err := filepath.Abs(base); err == nil {
+		base = abs
+	}
+	if len(o.changes) == 0 {
+		fmt.Println(tr("\n[Dry Run] No changes would be made."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d change(s) would be made; nothing was written:\n"), len(o.changes))
+	rel := func(p string) string {
+		if r, err := filepath.Rel(base, p); err == nil && !strings.HasPrefix(r, "..") {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	for _, c := range o.changes {
+		switch c.op {
+		case "rename":
+			fmt.Printf("  %-7s %s -> %s\n", c.op, rel(c.path), rel(c.detail))
+		case "mkdir":
+			fmt.Printf("  %-7s %s\n", c.op, rel(c.path))
+		default:
+			fmt.Printf("  %-7s %s (%s)\n", c.op, rel(c.path), c.detail)
+		}
+	}
+}
+
+// memFileInfo describes a file or directory that only exists in the overlay
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (m memFileInfo) Name() string       { return m.name }
+func (m memFileInfo) Size() int64        { return m.size }
+func (m memFileInfo) ModTime() time.Time { return time.Time{} }
+func (m memFileInfo) IsDir() bool        { return m.dir }
+func (m memFileInfo) Sys() interface{}   { return nil }
+func (m memFileInfo) Mode() os.FileMode {
+	if m.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// renamedFileInfo is a disk entry seen under the name it was renamed to
+type renamedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (r renamedFileInfo) Name() string { return r.name }
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"Warning: could not create log file %s: %v\n": "警告: 无法创建日志文件 %s: %v\n",
+	"contains asmdef":          "包含 asmdef",
+	"has Editor folder":        "有 Editor 文件夹",
+	"has Scripts folder":       "有 Scripts 文件夹",
+	"has BuiltIn folder":       "有 BuiltIn 文件夹",
+	"has LiveContent folder":   "有 LiveContent 文件夹",
+	"has Scenes folder":        "有 Scenes 文件夹",
+	"contains %d asmdef files": "包含 %d 个 asmdef 文件",
+	"Detected main project folder: %s (score: %d, reason: %s)\n":                                "检测到主项目文件夹: %s (得分: %d, 原因: %s)\n",
+	"\nSeveral folders in Assets/ look like the main project folder:":                           "\nAssets/ 中有多个文件夹看起来像主项目文件夹:",
+	"  [%d] %s (score: %d, reason: %s)\n":                                                       "  [%d] %s (得分: %d, 原因: %s)\n",
+	"Tip: pass -assets-folder <Name> or -assets-glob <pattern> to skip this question.":          "提示: 传入 -assets-folder <名称> 或 -assets-glob <模式> 可跳过此问题。",
+	"Select the project folder [1-%d] (Enter = 1): ":                                            "选择项目文件夹 [1-%d] (回车 = 1): ",
+	"Invalid selection: '%s'\n":                                                                 "无效的选择: '%s'\n",
+	"Using project folder '%s' from command line (state file says '%s')\n":                      "使用命令行指定的项目文件夹 '%s' (状态文件记录为 '%s')\n",
+	"Loaded project info from state file (%s)\n":                                                "已从状态文件加载项目信息 (%s)\n",
+	"Warning: state file references non-existent folder '%s', falling back to auto-detection\n": "警告: 状态文件引用了不存在的文件夹 '%s'，改用自动检测\n",
+	"Using project folder from command line: %s\n":                                              "使用命令行指定的项目文件夹: %s\n",
+	"Using fallback detection: %s\n":                                                            "使用后备检测结果: %s\n",
+
+	"Step %d: Enter the New %s\n":                                          "第 %d 步: 输入新的%s\n",
+	"\nCurrent value: %s\n":                                                "\n当前值: %s\n",
+	"Enter new value (press Enter to keep current): ":                      "输入新值 (直接回车保留当前值): ",
+	"\nInvalid input: '%s'\n":                                              "\n无效的输入: '%s'\n",
+	"Must only contain letters, numbers, underscores (_), and dashes (-).": "只能包含字母、数字、下划线 (_) 和短横线 (-)。",
+	"Cannot start with a number or dash.":                                  "不能以数字或短横线开头。",
+	"Project Name":                                                         "项目名称",
+	"Company Name":                                                         "公司名称",
+	"Application Name":                                                     "应用名称",
+	"The folder name (Assets\\PROJECT_NAME) should only contain letters, numbers,\nunderscores (_), and dashes (-). It cannot start with a number or dash.": "文件夹名称 (Assets\\PROJECT_NAME) 只能包含字母、数字、\n下划线 (_) 和短横线 (-)，且不能以数字或短横线开头。",
+	"The name should only contain letters, numbers, underscores (_), and dashes (-).\nIt cannot start with a number or dash.":                               "名称只能包含字母、数字、下划线 (_) 和短横线 (-)，\n且不能以数字或短横线开头。",
+
+	"Rename folder: Assets/%s -> Assets/%s":           "重命名文件夹: Assets/%s -> Assets/%s",
+	"Rename meta:   Assets/%s.meta -> Assets/%s.meta": "重命名 meta:  Assets/%s.meta -> Assets/%s.meta",
+	"Rename file: %s -> %s":                           "重命名文件: %s -> %s",
+	"Asset paths: Assets/%s/ -> Assets/%s/":           "资源路径: Assets/%s/ -> Assets/%s/",
+	"Scene paths: Assets/%s/... -> Assets/%s/...":     "场景路径: Assets/%s/... -> Assets/%s/...",
+	"  CHANGE PREVIEW":                                "  变更预览",
+	"\nNo changes needed.":                            "\n无需任何更改。",
+	"\nTotal: %d file(s) will be affected.\n":         "\n合计: 将影响 %d 个文件。\n",
+
+	"[OK] Updated BuildScript.cs: CompanyName":          "[OK] 已更新 BuildScript.cs: CompanyName",
+	"[OK] Updated BuildScript.cs: ApplicationName":      "[OK] 已更新 BuildScript.cs: ApplicationName",
+	"[OK] Updated BuildScript.cs: asset paths":          "[OK] 已更新 BuildScript.cs: 资源路径",
+	"[--] BuildScript.cs: no changes needed":            "[--] BuildScript.cs: 无需更改",
+	"[--] ProjectSettings.asset: no changes needed":     "[--] ProjectSettings.asset: 无需更改",
+	"[--] EditorBuildSettings.asset: no changes needed": "[--] EditorBuildSettings.asset: 无需更改",
+	"[WARNING] %s checkout failed for %s: %v %s\n":      "[WARNING] %s 签出失败 %s: %v %s\n",
+	"[VCS] Checked out (%s): %s\n":                      "[VCS] 已签出 (%s): %s\n",
+	"\nPress Enter to continue...":                      "\n按回车键继续...",
+
+	"Error: -assets-folder and -assets-glob cannot be combined": "错误: -assets-folder 与 -assets-glob 不能同时使用",
+	"Error:":                            "错误:",
+	"Found Unity project root at: %s\n": "找到 Unity 项目根目录: %s\n",
+	"Version control: %s (%s), files are checked out before writing\n":   "版本控制: %s (%s)，写入前会先签出文件\n",
+	"=== Rename Project Tool started at %s ===\n":                        "=== 项目重命名工具启动于 %s ===\n",
+	"Error getting current project info: %v\n":                           "获取当前项目信息失败: %v\n",
+	"\nCurrent project settings:":                                        "\n当前项目设置:",
+	"  Project Folder: %s\n":                                             "  项目文件夹: %s\n",
+	"  Company Name:   %s\n":                                             "  公司名称:   %s\n",
+	"  App Name:       %s\n":                                             "  应用名称:   %s\n",
+	"\nNo changes needed — all values are the same as current settings.": "\n无需更改 — 所有值都与当前设置相同。",
+	"\nProceed with these changes? (y/N): ":                              "\n是否执行这些更改? (y/N): ",
+	"\nOperation cancelled by user.":                                     "\n用户已取消操作。",
+	"\nCreating backup...":                                               "\n正在创建备份...",
+	"Warning: backup failed: %v\n":                                       "警告: 备份失败: %v\n",
+	"Continue without backup? (y/N): ":                                   "不备份继续? (y/N): ",
+	"Operation cancelled.":                                               "操作已取消。",
+	"[OK] Backup created at: %s\n":                                       "[OK] 备份已创建: %s\n",
+	"%d files":                                                           "%d 个文件",
+	"\nExecuting changes...":                                             "\n正在执行更改...",
+	"Error renaming folder: %v\n":                                        "重命名文件夹出错: %v\n",
+	"[OK] Renamed folder: Assets/%s -> Assets/%s\n":                      "[OK] 已重命名文件夹: Assets/%s -> Assets/%s\n",
+	"Warning: failed to save state checkpoint: %v\n":                     "警告: 保存状态检查点失败: %v\n",
+	"Error updating BuildScript.cs: %v\n":                                "更新 BuildScript.cs 出错: %v\n",
+	"Error updating ProjectSettings.asset: %v\n":                         "更新 ProjectSettings.asset 出错: %v\n",
+	"[OK] Updated ProjectSettings.asset":                                 "[OK] 已更新 ProjectSettings.asset",
+	"Error updating EditorBuildSettings.asset: %v\n":                     "更新 EditorBuildSettings.asset 出错: %v\n",
+	"[OK] Updated EditorBuildSettings.asset":                             "[OK] 已更新 EditorBuildSettings.asset",
+	"Error: failed to save state file: %v\n":                             "错误: 保存状态文件失败: %v\n",
+	"[OK] Saved state file: %s\n":                                        "[OK] 已保存状态文件: %s\n",
+
+	"  Project successfully renamed!":     "  项目重命名成功!",
+	"\nSummary:":                          "\n摘要:",
+	"  Folder:  Assets/%s -> Assets/%s\n": "  文件夹:  Assets/%s -> Assets/%s\n",
+	"  Company: %s -> %s\n":               "  公司:    %s -> %s\n",
+	"  App:     %s -> %s\n":               "  应用:    %s -> %s\n",
+	"  Backup:  %s\n":                     "  备份:    %s\n",
+	"  Log:     %s\n":                     "  日志:    %s\n",
+	"\n[TIP] Run 'p4 reconcile Assets/...' to record the folder move (Assets/%s -> Assets/%s).\n":           "\n[TIP] 运行 'p4 reconcile Assets/...' 记录文件夹移动 (Assets/%s -> Assets/%s)。\n",
+	"\n[TIP] Review the folder move in Plastic SCM Pending Changes (moved items are detected on check-in).": "\n[TIP] 请在 Plastic SCM 的 Pending Changes 中检查文件夹移动 (签入时会检测移动项)。",
+	"\nPlease verify the changes in Unity Editor.":                                                          "\n请在 Unity Editor 中确认这些更改。",
+
+	"\n[Dry Run] No changes would be made.":                                 "\n[Dry Run] 不会做任何更改。",
+	"\n[Dry Run] %d change(s) would be made; nothing was written:\n":        "\n[Dry Run] 将会进行 %d 项更改，未写入任何内容:\n",
+	"[Dry Run] Changes go to an in-memory copy; nothing is written to disk": "[Dry Run] 所有更改只写入内存副本，不会写入磁盘",
+	"\n[Dry Run] No files were changed.":                                    "\n[Dry Run] 未修改任何文件。",
+
+	"Update %d name/path reference(s)":          "更新 %d 处名称/路径引用",
+	"[OK] Updated docs: %s (%d reference(s))\n": "[OK] 已更新文档: %s (%d 处引用)\n",
+
+	"Fill %d template value(s) (author/year/license)": "填充 %d 个模板值 (作者/年份/许可证)",
+	"[OK] Filled template values: %s (%d)\n":          "[OK] 已填充模板值: %s (%d)\n",
+	"Error reading template values: %v\n":             "读取模板值出错: %v\n",
+	"  Template values from: %s\n":                    "  模板值来源: %s\n",
+	"  Author:         %s\n":                          "  作者:       %s\n",
+	"  License:        %s\n":                          "  许可证:     %s\n",
+
+	"[WARNING] Removing a stale %s (%s)\n":                                                                             "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n":                                                        "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+	"[OK] Updated Addressables paths: %s (%d value(s))\n":                                                              "[OK] 已更新 Addressables 路径: %s (%d 个值)\n",
+	"[OK] Updated CI pipeline: %s (%d line(s))\n":                                                                      "[OK] 已更新 CI 流水线: %s (%d 行)\n",
+	"[OK] Updated store metadata: %s\n":                                                                                "[OK] 已更新商店元数据: %s\n",
+	"[OK] Created %d store metadata file(s) in %s/\n":                                                                  "[OK] 已在 %[2]s/ 中创建 %[1]d 个商店元数据文件\n",
+	"Create %d fastlane metadata file(s)":                                                                              "创建 %d 个 fastlane 元数据文件",
+	"Update to the new names: %s":                                                                                      "更新为新名称: %s",
+	"[OK] Updated display names: %s (%d value(s))\n":                                                                   "[OK] 已更新显示名称: %s (%d 个值)\n",
+	"Warning: display name asset not found: %s\n":                                                                      "警告: 未找到显示名称资源: %s\n",
+	"\nExcluded by -rename-include/-rename-exclude (%d file(s), left unchanged):\n":                                    "\n被 -rename-include/-rename-exclude 排除（%d 个文件，保持不变）:\n",
+	"[--] ProjectSettings.asset: excluded, left unchanged":                                                             "[--] ProjectSettings.asset: 已排除，保持不变",
+	"[--] EditorBuildSettings.asset: excluded, left unchanged":                                                         "[--] EditorBuildSettings.asset: 已排除，保持不变",
+	"%s only changes case (%s -> %s): version control may not record it":                                               "%s 只改变了大小写（%s -> %s）: 版本控制可能不会记录此变更",
+	", and this file system ignores case, so other tools may keep the old spelling":                                    "，且当前文件系统不区分大小写，其他工具可能仍保留旧的写法",
+	"%s '%s' is short or a common word, so unrelated text may be replaced too; matches in the files the rename edits:": "%s '%s' 过短或是常用词，无关文本也可能被替换; 重命名会修改的文件中的匹配如下:",
+	"  SAFETY WARNINGS": "  安全警告",
+	"\nError: Assets/%s already exists and is not the project folder; choose another name or move that folder first.\n":     "\n错误: Assets/%s 已存在且不是项目文件夹; 请换一个名称，或先移走该文件夹。\n",
+	"\nError: Assets/%s differs from the new name '%s' only by case; on Windows and macOS both would be the same folder.\n": "\n错误: Assets/%s 与新名称 '%s' 仅大小写不同; 在 Windows 和 macOS 上二者是同一个文件夹。\n",
+	"Project folder":   "项目文件夹",
+	"Company name":     "公司名称",
+	"Application name": "应用名称",
+	"\nType 'yes' to proceed despite the warnings above: ":                         "\n尽管有上述警告仍要继续，请输入 'yes': ",
+	"  New names from: %s\n":                                                       "  新名称来源: %s\n",
+	"applicationIdentifier.%s: (none) -> %s":                                       "applicationIdentifier.%s: (无) -> %s",
+	"\nRolling back the changes made so far...":                                    "\n正在回滚已做的更改...",
+	"[FAIL] Could not restore %s: %v\n":                                            "[FAIL] 无法恢复 %s: %v\n",
+	"[!!] %d change(s) could not be undone; restore them from the backup.\n":       "[!!] %d 项更改无法撤销，请从备份中恢复。\n",
+	"[OK] Rolled back %d change(s); the project is as it was before the rename.\n": "[OK] 已回滚 %d 项更改，项目已恢复到重命名之前的状态。\n",
+	"[OK] Updated namespaces: %s (%d line(s))\n":                                   "[OK] 已更新命名空间: %s (%d 行)\n",
+	"[OK] Updated assembly names: %s (%d change(s))\n":                             "[OK] 已更新程序集名称: %s (%d 处)\n",
+	"[OK] Renamed asmdef: %s -> %s\n":                                              "[OK] 已重命名 asmdef: %s -> %s\n",
+	"Error: snapshot failed, nothing was changed: %v\n":                            "错误: 创建快照失败，未做任何更改: %v\n",
+	"\n[OK] Snapshot taken: %s (undo with: unity_snapshot restore %s)\n":           "\n[OK] 已创建快照: %s（撤销命令: unity_snapshot restore %s）\n",
+	"[OK] Updated package names: %s (%d change(s))\n":                              "[OK] 已更新包名: %s (%d 处)\n",
+}
+
+// ============================================================
+// Snapshot (-snapshot)
+// ============================================================
+
+// takeSnapshot runs "unity_snapshot create" on the project, looking the tool up
+// next to this executable, then on PATH, and returns the new snapshot's ID
+func takeSnapshot(basePath, label string) (string, error) {
+	file := "unity_snapshot"
+	if runtime.GOOS == "windows" {
+		file += ".exe"
+	}
+	exe := ""
+	if self, err := os.Executable(); err == nil {
+		candidate := filepath.Join(filepath.Dir(self), file)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			exe = candidate
+		}
+	}
+	if exe == "" {
+		p, err := exec.LookPath(file)
+		if err != nil {
+			return "", fmt.Errorf("%s not found next to this tool or on PATH", file)
+		}
+		exe = p
+	}
+
+	cmd := exec.Command(exe, "create", "-json", "-label", label)
+	cmd.Dir = basePath
+	out, runErr := cmd.Output()
+	var result struct {
+		Success   bool     `json:"success"`
+		Errors    []string `json:"errors"`
+		Artifacts []string `json:"artifacts"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil || !result.Success || len(result.Artifacts) == 0 {
+		switch {
+		case len(result.Errors) > 0:
+			return "", fmt.Errorf("%s", strings.Join(result.Errors, "; "))
+		case runErr != nil:
+			return "", fmt.Errorf("%s: %v", file, runErr)
+		}
+		return "", fmt.Errorf("%s did not report a snapshot", file)
+	}
+	return filepath.Base(filepath.FromSlash(result.Artifacts[0])), nil
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	if status == "ok" && isDryRun() {
+		status = "planned"
+	}
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Rename
+// ============================================================
+
+// renameOptions are the command-line flags of a rename
+type renameOptions struct {
+	assetsFolder string
+	assetsGlob   string
+	vcsMode      string
+	dryRun       bool
+	force        bool
+	docs         bool
+	ci           bool
+	ciGlobs      string
+	namespaces   bool
+	snapshot     bool
+	store        bool
+	storeLocales string
+	include      string // -rename-include
+	exclude      string // -rename-exclude
+	author       string
+	license      string
+	year         string
+	configPath   string
+}
+
+// runRename renames the Unity project in dir or one of its immediate subfolders
+// and returns the exit code: 1 when it failed or was cancelled, 2 for invalid
+// options. Files go through fsys and the prompts read stdinReader, so tests can
+// run it on a fixture without the executable.
+func runRename(dir string, opts renameOptions) int {
+	if opts.assetsFolder != "" && opts.assetsGlob != "" {
+		fmt.Println(tr("Error: -assets-folder and -assets-glob cannot be combined"))
+		recordError("-assets-folder and -assets-glob cannot be combined")
+		return 2
+	}
+
+	filter, err := newPathFilter(opts.include, opts.exclude)
+	if err != nil {
+		fmt.Println(tr("Error:"), err)
+		recordError("%v", err)
+		return 2
+	}
+	renameFilter = filter
+
+	var locales []string
+	if opts.store {
+		var err error
+		if locales, err = parseStoreLocales(opts.storeLocales); err != nil {
+			fmt.Println(tr("Error:"), err)
+			recordError("%v", err)
+			return 2
+		}
+	}
+
+	var renameConfig *RenameConfig
+	if opts.configPath != "" {
+		if renameConfig, err = loadRenameConfig(opts.configPath); err != nil {
+			fmt.Println(tr("Error:"), err)
+			recordError("%v", err)
+			return 1
+		}
+	}
+
+	projectRoot, err := findProjectRoot(dir)
+	renameFilter.root = projectRoot
+	if err != nil {
+		fmt.Println(tr("Error:"), err)
+		recordError("%v", err)
+		return 1
+	}
+	fmt.Printf(tr("Found Unity project root at: %s\n"), projectRoot)
+
+	activeVCS, err = detectVCS(projectRoot, opts.vcsMode)
+	if err != nil {
+		fmt.Println(tr("Error:"), err)
+		recordError("%v", err)
+		return 1
+	}
+	if activeVCS.kind != vcsNone {
+		fmt.Printf(tr("Version control: %s (%s), files are checked out before writing\n"), activeVCS.kind, activeVCS.source)
+	}
+	if !opts.dryRun {
+		if err := acquireProjectLock(projectRoot, "rename_project", "rename", opts.force); err != nil {
+			fmt.Println(tr("Error:"), err)
+			recordError("%v", err)
+			return 1
+		}
+		defer releaseProjectLock()
+	}
+
+	// Initialize logger; a dry run only logs to the console
+	logPath := filepath.Join(projectRoot, "rename_project.log")
+	log := &Logger{writer: os.Stdout}
+	if opts.dryRun {
+		fmt.Println(tr("[Dry Run] Changes go to an in-memory copy; nothing is written to disk"))
+		fsys = newOverlayFS()
+	} else {
+		log = NewLogger(logPath)
+	}
+	defer log.Close()
+	log.Printf(tr("=== Rename Project Tool started at %s ===\n"), time.Now().Format("2006-01-02 15:04:05"))
+
+	// Get current project info (prefers state file for reliable re-runs)
+	oldName, oldCompanyName, oldAppName, err := getCurrentProjectInfo(projectRoot, opts.assetsFolder, opts.assetsGlob)
+	if err != nil {
+		log.Printf(tr("Error getting current project info: %v\n"), err)
+		recordError("error getting current project info: %v", err)
+		return 1
+	}
+
+	log.Println(tr("\nCurrent project settings:"))
+	log.Printf(tr("  Project Folder: %s\n"), oldName)
+	log.Printf(tr("  Company Name:   %s\n"), oldCompanyName)
+	log.Printf(tr("  App Name:       %s\n"), oldAppName)
+
+	// Shared template values (author, license, year); flags win over the config file
+	sharedConfig, sharedConfigPath, err := loadSharedConfig(projectRoot)
+	if err == nil {
+		for _, o := range []struct{ value, field *string }{
+			{&opts.author, &sharedConfig.Author},
+			{&opts.license, &sharedConfig.License},
+			{&opts.year, &sharedConfig.CopyrightYear},
+		} {
+			if *o.value != "" {
+				*o.field = *o.value
+			}
+		}
+		err = validateSharedConfig(sharedConfig)
+	}
+	if err != nil {
+		log.Printf(tr("Error reading template values: %v\n"), err)
+		recordError("error reading template values: %v", err)
+		return 1
+	}
+	if sharedConfigPath != "" {
+		log.Printf(tr("  Template values from: %s\n"), sharedConfigPath)
+	}
+	if sharedConfig.Author != "" {
+		log.Printf(tr("  Author:         %s\n"), sharedConfig.Author)
+	}
+	if sharedConfig.License != "" {
+		log.Printf(tr("  License:        %s\n"), sharedConfig.License)
+	}
+
+	// Collect new names from -config, or with immediate validation (press Enter to keep current)
+	newProjectName, newCompanyName, newAppName := oldName, oldCompanyName, oldAppName
+	var bundleIDs map[string]string
+	if renameConfig != nil {
+		log.Printf(tr("  New names from: %s\n"), opts.configPath)
+		for _, f := range []struct{ value, field *string }{
+			{&renameConfig.Project, &newProjectName},
+			{&renameConfig.Company, &newCompanyName},
+			{&renameConfig.App, &newAppName},
+		} {
+			if *f.value != "" {
+				*f.field = *f.value
+			}
+		}
+		bundleIDs = renameConfig.BundleIDs
+	} else {
+		waitForKeyPress()
+
+		newProjectName = promptValidatedInput(1, tr("Project Name"),
+			tr("The folder name (Assets\\PROJECT_NAME) should only contain letters, numbers,\nunderscores (_), and dashes (-). It cannot start with a number or dash."),
+			oldName)
+
+		newCompanyName = promptValidatedInput(2, tr("Company Name"),
+			tr("The name should only contain letters, numbers, underscores (_), and dashes (-).\nIt cannot start with a number or dash."),
+			oldCompanyName)
+
+		newAppName = promptValidatedInput(3, tr("Application Name"),
+			tr("The name should only contain letters, numbers, underscores (_), and dashes (-).\nIt cannot start with a number or dash."),
+			oldAppName)
+	}
+	var bundleNotes []string
+	if renameFilter.allows(filepath.Join(projectRoot, "ProjectSettings", "ProjectSettings.asset")) {
+		bundleNotes = bundleIDChanges(projectRoot, "com."+oldCompanyName+"."+oldAppName, "com."+newCompanyName+"."+newAppName, bundleIDs)
+	}
+
+	// Files with #AUTHOR#/#YEAR#/... placeholders are worth a run even without a rename
+	vars := templateVars(sharedConfig, newCompanyName, newAppName)
+	templateFiles := collectTemplateFiles(projectRoot, vars)
+
+	// So is a store scaffold that is missing files
+	var storePlan []storeChange
+	if opts.store {
+		year := vars[placeholderYear]
+		storePlan = planStoreScaffold(projectRoot,
+			storeScaffold(locales, oldCompanyName, oldAppName, year),
+			storeScaffold(locales, newCompanyName, newAppName, year))
+	}
+
+	// A folder with the new name would stop the rename halfway through
+	if other := findFolderCollision(projectRoot, oldName, newProjectName); other != "" {
+		if other == newProjectName {
+			log.Printf(tr("\nError: Assets/%s already exists and is not the project folder; choose another name or move that folder first.\n"), other)
+		} else {
+			log.Printf(tr("\nError: Assets/%s differs from the new name '%s' only by case; on Windows and macOS both would be the same folder.\n"), other, newProjectName)
+		}
+		recordError("project folder name collides with Assets/%s", other)
+		return 1
+	}
+
+	// Check if anything actually changed
+	if newProjectName == oldName && newCompanyName == oldCompanyName && newAppName == oldAppName && len(templateFiles) == 0 && len(storePlan) == 0 && len(bundleNotes) == 0 {
+		clearScreen()
+		log.Println(tr("\nNo changes needed — all values are the same as current settings."))
+		return 0
+	}
+
+	// Preview all changes before execution
+	clearScreen()
+	var docs *docRewriter
+	var docFiles []string
+	if opts.docs {
+		docs = newDocRewriter(projectRoot, oldName, newProjectName, oldAppName, newAppName)
+		docFiles = collectDocFiles(projectRoot, docs)
+	}
+	addr := newAddressablesRewriter(oldCompanyName, newCompanyName, oldAppName, newAppName)
+	addrFiles := collectAddressablesFiles(projectRoot, addr)
+	asm := newAssemblyRewriter(projectRoot, oldName, newProjectName)
+	pkgs := newPackageRewriter(projectRoot, oldName, newProjectName, oldCompanyName, newCompanyName, oldAppName, newAppName)
+	display := newDisplayNameRewriter(oldCompanyName, newCompanyName, oldAppName, newAppName)
+	var displayAssets []DisplayNameAsset
+	var displayTargets []displayNameTarget
+	if state, err := loadState(projectRoot); err == nil {
+		displayAssets = state.DisplayNameAssets
+		var missing []string
+		displayTargets, missing = resolveDisplayNameAssets(projectRoot, oldName, newProjectName, displayAssets)
+		for _, asset := range missing {
+			log.Printf(tr("Warning: display name asset not found: %s\n"), asset)
+		}
+	}
+	var ci *ciRewriter
+	var ciFiles []string
+	if opts.ci {
+		ci = newCIRewriter(oldName, newProjectName, oldCompanyName, newCompanyName, oldAppName, newAppName)
+		if ciFiles, err = collectCIFiles(projectRoot, opts.ciGlobs, ci); err != nil {
+			log.Println(tr("Error:"), err)
+			recordError("%v", err)
+			return 1
+		}
+	}
+	var ns *namespaceRewriter
+	var nsFiles []string
+	if opts.namespaces {
+		ns = newNamespaceRewriter(oldName, newProjectName)
+		nsFiles = collectNamespaceFiles(projectRoot, ns)
+	}
+	changes := previewChanges(projectRoot, oldName, newProjectName, oldCompanyName, newCompanyName, oldAppName, newAppName, addrFiles, addr, asm, pkgs, displayTargets, display, ciFiles, ci, nsFiles, ns, docFiles, docs, templateFiles, vars, storePlan, bundleNotes)
+	printPreview(log, changes)
+	renameFilter.printSkipped(log)
+	risks := checkRenameRisks(projectRoot, [][3]string{
+		{tr("Project folder"), oldName, newProjectName},
+		{tr("Company name"), oldCompanyName, newCompanyName},
+		{tr("Application name"), oldAppName, newAppName},
+	}, changes)
+	if len(risks) > 0 {
+		printRisks(log, risks)
+	}
+
+	if len(changes) == 0 {
+		return 0
+	}
+
+	// Final confirmation
+	if !opts.dryRun {
+		// Warnings need the word typed out, so a habitual "y" does not get past them
+		want := "y"
+		if len(risks) > 0 {
+			want = "yes"
+			fmt.Print(tr("\nType 'yes' to proceed despite the warnings above: "))
+		} else {
+			fmt.Print(tr("\nProceed with these changes? (y/N): "))
+		}
+		confirm, _ := stdinReader.ReadString('\n')
+		confirm = strings.TrimSpace(strings.ToLower(confirm))
+		if confirm != want {
+			log.Println(tr("\nOperation cancelled by user."))
+			recordError("operation cancelled by user")
+			return 1
+		}
+	}
+
+	// A restore point of the whole project, on top of the backup of the changed files
+	if opts.snapshot && !opts.dryRun {
+		id, err := takeSnapshot(projectRoot, fmt.Sprintf("before rename_project %s -> %s", oldName, newProjectName))
+		if err != nil {
+			log.Printf(tr("Error: snapshot failed, nothing was changed: %v\n"), err)
+			recordError("snapshot failed: %v", err)
+			return 1
+		}
+		log.Printf(tr("\n[OK] Snapshot taken: %s (undo with: unity_snapshot restore %s)\n"), id, id)
+		recordAction("snapshot", id, "ok", "", 0)
+	}
+
+	// Create backup of all affected files
+	log.Println(tr("\nCreating backup..."))
+	filesToBackup := append(collectFilesToBackup(projectRoot, oldName, asm), addrFiles...)
+	filesToBackup = append(filesToBackup, pkgs.files...)
+	for _, t := range displayTargets {
+		filesToBackup = append(filesToBackup, t.path)
+	}
+	filesToBackup = append(filesToBackup, ciFiles...)
+	filesToBackup = append(filesToBackup, nsFiles...)
+	filesToBackup = append(filesToBackup, docFiles...)
+	filesToBackup = append(filesToBackup, templateFiles...)
+	for _, c := range storePlan {
+		if c.action == "update" {
+			filesToBackup = append(filesToBackup, c.path)
+		}
+	}
+	backupDir, backupErr := createBackup(projectRoot, filesToBackup,
+		renameNames{ProjectFolder: oldName, CompanyName: oldCompanyName, AppName: oldAppName},
+		renameNames{ProjectFolder: newProjectName, CompanyName: newCompanyName, AppName: newAppName})
+	if backupErr != nil {
+		log.Printf(tr("Warning: backup failed: %v\n"), backupErr)
+		recordAction("backup", backupDirName, "failed", backupErr.Error(), 0)
+		fmt.Print(tr("Continue without backup? (y/N): "))
+		cont, _ := stdinReader.ReadString('\n')
+		cont = strings.TrimSpace(strings.ToLower(cont))
+		if cont != "y" {
+			log.Println(tr("Operation cancelled."))
+			recordError("operation cancelled: backup failed")
+			return 1
+		}
+	} else if backupDir != "" {
+		log.Printf(tr("[OK] Backup created at: %s\n"), backupDir)
+		recordAction("backup", backupDir, "ok", fmt.Sprintf(tr("%d files"), len(filesToBackup)), 0)
+		recordArtifact(backupDir)
+	}
+
+	// Every change from here on is journaled; a failing step undoes all of them
+	if !opts.dryRun {
+		journal = newRenameJournal()
+	}
+	abort := func() int {
+		journal.rollback(log)
+		return 1
+	}
+
+	log.Println(tr("\nExecuting changes..."))
+	savedDisplayAssets := displayAssets
+
+	// 1. Rename project folder + meta
+	if oldName != newProjectName {
+		oldFolderPath := filepath.Join(projectRoot, "Assets", oldName)
+		newFolderPath := filepath.Join(projectRoot, "Assets", newProjectName)
+		if err := renameFolderAndMeta(oldFolderPath, newFolderPath); err != nil {
+			log.Printf(tr("Error renaming folder: %v\n"), err)
+			recordAction("rename", "Assets/"+oldName, "failed", err.Error(), 0)
+			recordError("error renaming folder: %v", err)
+			return abort()
+		}
+		log.Printf(tr("[OK] Renamed folder: Assets/%s -> Assets/%s\n"), oldName, newProjectName)
+		recordAction("rename", "Assets/"+oldName, "ok", "-> Assets/"+newProjectName, 0)
+		savedDisplayAssets = moveDisplayNameAssets(displayAssets, oldName, newProjectName)
+
+		// Save partial state checkpoint: folder renamed, but company/app not yet updated.
+		// This ensures re-runs can find the correct folder even if subsequent steps fail.
+		partialState := &RenameState{
+			ProjectFolder:     newProjectName,
+			CompanyName:       oldCompanyName,
+			AppName:           oldAppName,
+			RenamedAt:         time.Now().Format("2006-01-02 15:04:05"),
+			DisplayNameAssets: savedDisplayAssets,
+		}
+		if sErr := saveState(projectRoot, partialState); sErr != nil {
+			log.Printf(tr("Warning: failed to save state checkpoint: %v\n"), sErr)
+		}
+	}
+
+	// 2. Update assembly names and the references to them (collected again:
+	// the folder has moved)
+	if oldName != newProjectName {
+		asm = newAssemblyRewriter(projectRoot, oldName, newProjectName)
+		if err := updateAssemblies(log, projectRoot, asm); err != nil {
+			log.Println(tr("Error:"), err)
+			recordError("%v", err)
+			return abort()
+		}
+
+		// 3. Rename the asmdef files named after the project
+		if err := renameAsmdefFiles(log, projectRoot, asm); err != nil {
+			log.Println(tr("Error:"), err)
+			recordError("%v", err)
+			return abort()
+		}
+	}
+
+	// 4. Update embedded package names and the references to them; collected
+	// again because asmdef files may have moved
+	if len(pkgs.files) > 0 {
+		pkgs = newPackageRewriter(projectRoot, oldName, newProjectName, oldCompanyName, newCompanyName, oldAppName, newAppName)
+		if err := updatePackages(log, projectRoot, pkgs); err != nil {
+			log.Println(tr("Error:"), err)
+			recordError("%v", err)
+			return abort()
+		}
+	}
+
+	// 5. Update BuildScript.cs (if exists)
+	buildScriptPath := filepath.Join(projectRoot, "Assets", "Build", "Editor", "BuildPipeline", "BuildScript.cs")
+	if _, statErr := os.Stat(buildScriptPath); statErr == nil && renameFilter.allows(buildScriptPath) {
+		if err := updateBuildScript(log, buildScriptPath, oldName, newProjectName, oldCompanyName, newCompanyName, oldAppName, newAppName); err != nil {
+			log.Printf(tr("Error updating BuildScript.cs: %v\n"), err)
+			recordError("error updating BuildScript.cs: %v", err)
+			return abort()
+		}
+	}
+
+	// 6. Update ProjectSettings.asset
+	projectSettingsPath := filepath.Join(projectRoot, "ProjectSettings", "ProjectSettings.asset")
+	if !renameFilter.allows(projectSettingsPath) {
+		log.Println(tr("[--] ProjectSettings.asset: excluded, left unchanged"))
+	} else {
+		if err := updateProjectSettings(log, projectSettingsPath, oldCompanyName, newCompanyName, oldAppName, newAppName, bundleIDs); err != nil {
+			log.Printf(tr("Error updating ProjectSettings.asset: %v\n"), err)
+			recordError("error updating ProjectSettings.asset: %v", err)
+			return abort()
+		}
+		log.Println(tr("[OK] Updated ProjectSettings.asset"))
+		recordAction("modify", "ProjectSettings/ProjectSettings.asset", "ok", "", 0)
+	}
+
+	// 7. Update EditorBuildSettings.asset
+	editorBuildSettingsPath := filepath.Join(projectRoot, "ProjectSettings", "EditorBuildSettings.asset")
+	if !renameFilter.allows(editorBuildSettingsPath) {
+		log.Println(tr("[--] EditorBuildSettings.asset: excluded, left unchanged"))
+	} else {
+		if err := updateEditorBuildSettings(log, editorBuildSettingsPath, oldName, newProjectName); err != nil {
+			log.Printf(tr("Error updating EditorBuildSettings.asset: %v\n"), err)
+			recordError("error updating EditorBuildSettings.asset: %v", err)
+			return abort()
+		}
+		log.Println(tr("[OK] Updated EditorBuildSettings.asset"))
+		recordAction("modify", "ProjectSettings/EditorBuildSettings.asset", "ok", "", 0)
+	}
+
+	// 8. Update Addressables profiles and paths; collected again because the folder may have moved
+	if len(addrFiles) > 0 {
+		if err := updateAddressables(log, projectRoot, collectAddressablesFiles(projectRoot, addr), addr); err != nil {
+			log.Println(tr("Error:"), err)
+			recordError("%v", err)
+			return abort()
+		}
+	}
+
+	// 9. Update display names; resolved again because the folder may have moved
+	if len(displayTargets) > 0 {
+		targets, _ := resolveDisplayNameAssets(projectRoot, oldName, newProjectName, displayAssets)
+		if err := updateDisplayNames(log, projectRoot, targets, display); err != nil {
+			log.Println(tr("Error:"), err)
+			recordError("%v", err)
+			return abort()
+		}
+	}
+
+	// 10. Update CI pipeline definitions (-ci)
+	if len(ciFiles) > 0 {
+		if err := updateCIFiles(log, projectRoot, ciFiles, ci); err != nil {
+			log.Println(tr("Error:"), err)
+			recordError("%v", err)
+			return abort()
+		}
+	}
+
+	// 11. Update C# namespaces (-namespaces); collected again because the folder may have moved
+	if len(nsFiles) > 0 {
+		if err := updateNamespaces(log, projectRoot, collectNamespaceFiles(projectRoot, ns), ns); err != nil {
+			log.Println(tr("Error:"), err)
+			recordError("%v", err)
+			return abort()
+		}
+	}
+
+	// 12. Update Markdown docs (-docs)
+	if len(docFiles) > 0 {
+		if err := updateDocs(log, projectRoot, docFiles, docs); err != nil {
+			log.Println(tr("Error:"), err)
+			recordError("%v", err)
+			return abort()
+		}
+	}
+
+	// 13. Fill template placeholders; collected again because the folder may have moved
+	if len(templateFiles) > 0 {
+		if err := updateTemplateFiles(log, projectRoot, collectTemplateFiles(projectRoot, vars), vars); err != nil {
+			log.Println(tr("Error:"), err)
+			recordError("%v", err)
+			return abort()
+		}
+	}
+
+	// 14. Create the store metadata scaffold (-store)
+	if len(storePlan) > 0 {
+		if err := writeStoreScaffold(log, projectRoot, storePlan); err != nil {
+			log.Println(tr("Error:"), err)
+			recordError("%v", err)
+			return abort()
+		}
+	}
+
+	// 15. Save final state file for future re-runs
+	finalState := &RenameState{
+		ProjectFolder:     newProjectName,
+		CompanyName:       newCompanyName,
+		AppName:           newAppName,
+		RenamedAt:         time.Now().Format("2006-01-02 15:04:05"),
+		DisplayNameAssets: savedDisplayAssets,
+	}
+	if err := saveState(projectRoot, finalState); err != nil {
+		log.Printf(tr("Error: failed to save state file: %v\n"), err)
+		recordError("failed to save state file: %v", err)
+		return abort()
+	}
+	log.Printf(tr("[OK] Saved state file: %s\n"), stateFileName)
+	recordArtifact(filepath.Join(projectRoot, stateFileName))
+	journal = nil
+
+	// Summary
+	log.Rule("\n===========================================")
+	log.Println(tr("  Project successfully renamed!"))
+	log.Rule("===========================================")
+	log.Println(tr("\nSummary:"))
+	if oldName != newProjectName {
+		log.Printf(tr("  Folder:  Assets/%s -> Assets/%s\n"), oldName, newProjectName)
 	}
 	if oldCompanyName != newCompanyName {
-		log.Printf("  Company: %s -> %s\n", oldCompanyName, newCompanyName)
+		log.Printf(tr("  Company: %s -> %s\n"), oldCompanyName, newCompanyName)
 	}
 	if oldAppName != newAppName {
-		log.Printf("  App:     %s -> %s\n", oldAppName, newAppName)
+		log.Printf(tr("  App:     %s -> %s\n"), oldAppName, newAppName)
 	}
 	if backupDir != "" {
-		log.Printf("  Backup:  %s\n", backupDir)
+		log.Printf(tr("  Backup:  %s\n"), backupDir)
+	}
+	if !opts.dryRun {
+		log.Printf(tr("  Log:     %s\n"), logPath)
+	}
+	if oldName != newProjectName {
+		switch activeVCS.kind {
+		case vcsPerforce:
+			log.Printf(tr("\n[TIP] Run 'p4 reconcile Assets/...' to record the folder move (Assets/%s -> Assets/%s).\n"), oldName, newProjectName)
+		case vcsPlastic:
+			log.Println(tr("\n[TIP] Review the folder move in Plastic SCM Pending Changes (moved items are detected on check-in)."))
+		}
+	}
+	if overlay, ok := fsys.(*overlayFS); ok {
+		overlay.printDryRunReport(projectRoot)
+		log.Println(tr("\n[Dry Run] No files were changed."))
+	} else {
+		log.Println(tr("\nPlease verify the changes in Unity Editor."))
+	}
+	return 0
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var opts renameOptions
+	flag.StringVar(&opts.assetsFolder, "assets-folder", "", "Main project folder under Assets/ (skips auto-detection)")
+	flag.StringVar(&opts.assetsGlob, "assets-glob", "", "Glob matching exactly one folder under Assets/, e.g. \"_Game*\"")
+	flag.StringVar(&opts.vcsMode, "vcs", "auto", "Checkout before writing: auto, none, p4, plastic")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "Run the rename against an in-memory copy and list what would change")
+	flag.BoolVar(&opts.force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
+	flag.BoolVar(&opts.docs, "docs", false, "Also rewrite the old names in Markdown docs (top-level *.md and docs/)")
+	flag.BoolVar(&opts.ci, "ci", false, "Also rewrite the old names, bundle ID and artifact names in CI pipeline YAML files")
+	flag.StringVar(&opts.ciGlobs, "ci-glob", defaultCIGlobs, "Comma-separated globs of the files -ci updates, relative to the project or repository root")
+	flag.BoolVar(&opts.snapshot, "snapshot", false, "Also take a unity_snapshot restore point of Assets/, Packages/ and ProjectSettings/ before writing")
+	flag.BoolVar(&opts.namespaces, "namespaces", false, "Also rename the root namespace (the project folder name) in namespace and using lines of every .cs file under Assets/")
+	flag.BoolVar(&opts.store, "store", false, "Also create a fastlane-style store/ metadata scaffold filled with the new names")
+	flag.StringVar(&opts.storeLocales, "store-locales", "en-US", "Comma-separated locales of the -store scaffold, e.g. \"en-US,zh-Hans\"")
+	flag.StringVar(&opts.include, "rename-include", "", "Comma-separated globs; the replacement passes only change matching files (e.g. \"Assets/**,ProjectSettings/**\")")
+	flag.StringVar(&opts.exclude, "rename-exclude", "", "Comma-separated globs the replacement passes never change (e.g. \"Assets/ThirdParty/**,**/Plugins/**\")")
+	flag.StringVar(&opts.author, "author", "", "Value for #AUTHOR# placeholders (overrides .unitystarter.json)")
+	flag.StringVar(&opts.license, "license", "", "SPDX license for #LICENSE# and SPDX-License-Identifier lines (overrides .unitystarter.json)")
+	flag.StringVar(&opts.year, "year", "", "Value for #YEAR# placeholders (default: .unitystarter.json, then the current year)")
+	flag.StringVar(&opts.configPath, "config", "", "rename.json or rename.yaml with the new names and per-platform bundle IDs; replaces the prompts")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (prompts go to stderr)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	if jsonMode {
+		enableJSONOutput("rename_project")
 	}
-	log.Printf("  Log:     %s\n", logPath)
-	log.Println("\nPlease verify the changes in Unity Editor.")
+	code := runRename(".", opts)
 	waitForKeyPress()
+	exitTool(code)
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+// textFormatCases are the encodings Unity projects are seen with: LF, CRLF
+// (edited on Windows) and either with a UTF-8 BOM
+var textFormatCases = []struct {
+	name   string
+	format textFormat
+}{
+	{"LF", textFormat{}},
+	{"CRLF", textFormat{crlf: true}},
+	{"BOM+LF", textFormat{bom: true}},
+	{"BOM+CRLF", textFormat{bom: true, crlf: true}},
+}
+
+// TestTextFormatRoundTrip runs each encoding through the rewrite path the
+// settings updates use and compares the bytes written
+func TestTextFormatRoundTrip(t *testing.T) {
+	const settings = "PlayerSettings:\n  companyName: Acme\n  productName: MyGame\n  applicationIdentifier:\n    Android: com.Acme.MyGame\n  metroPackageName: MyGame\n"
+	const wantSettings = "PlayerSettings:\n  companyName: Nova\n  productName: NewGame\n  applicationIdentifier:\n    Android: com.Nova.NewGame\n  metroPackageName: NewGame\n"
+	const scenes = "EditorBuildSettings:\n  m_Scenes:\n  - enabled: 1\n    path: Assets/MyGame/Scenes/Main.unity\n"
+	const wantScenes = "EditorBuildSettings:\n  m_Scenes:\n  - enabled: 1\n    path: Assets/NewGame/Scenes/Main.unity\n"
+	log := &Logger{writer: io.Discard}
+
+	for _, tc := range textFormatCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Reading and writing back unchanged gives the same bytes
+			data := tc.format.encode(settings)
+			text, format := decodeText(data)
+			if text != settings || format != tc.format {
+				t.Fatalf("decodeText = %q, %+v; want %q, %+v", text, format, settings, tc.format)
+			}
+			if got := format.encode(text); !bytes.Equal(got, data) {
+				t.Fatalf("encode(decodeText(x)) = %q, want %q", got, data)
+			}
+
+			dir := t.TempDir()
+			settingsPath := filepath.Join(dir, "ProjectSettings.asset")
+			scenesPath := filepath.Join(dir, "EditorBuildSettings.asset")
+			writeTestFile(t, settingsPath, tc.format.encode(settings))
+			writeTestFile(t, scenesPath, tc.format.encode(scenes))
+
+			if err := updateProjectSettings(log, settingsPath, "Acme", "Nova", "MyGame", "NewGame", nil); err != nil {
+				t.Fatal(err)
+			}
+			if err := updateEditorBuildSettings(log, scenesPath, "MyGame", "NewGame"); err != nil {
+				t.Fatal(err)
+			}
+			assertFileBytes(t, settingsPath, tc.format.encode(wantSettings))
+			assertFileBytes(t, scenesPath, tc.format.encode(wantScenes))
+		})
+	}
+}
+
+// TestMixedLineEndings rewrites a settings file whose lines end in both CRLF
+// and LF; the edited lines keep their own ending and the others are untouched
+func TestMixedLineEndings(t *testing.T) {
+	const settings = "PlayerSettings:\r\n  companyName: Acme\n  productName: MyGame\r\n  applicationIdentifier:\n    Android: com.Acme.MyGame\r\n  metroPackageName: MyGame\n"
+	const want = "PlayerSettings:\r\n  companyName: Nova\n  productName: NewGame\r\n  applicationIdentifier:\n    Android: com.Nova.NewGame\r\n  metroPackageName: NewGame\n"
+	log := &Logger{writer: io.Discard}
+
+	text, format := decodeText([]byte(settings))
+	if got := format.encode(text); string(got) != settings {
+		t.Fatalf("encode(decodeText(x)) = %q, want %q", got, settings)
+	}
+
+	path := filepath.Join(t.TempDir(), "ProjectSettings.asset")
+	writeTestFile(t, path, []byte(settings))
+	if err := updateProjectSettings(log, path, "Acme", "Nova", "MyGame", "NewGame", nil); err != nil {
+		t.Fatal(err)
+	}
+	assertFileBytes(t, path, []byte(want))
+}
+
+// TestRenameGolden renames testdata/rename_project (MyGame by Acme) with its
+// rename.json and compares the resulting tree to testdata/rename_project.golden
+func TestRenameGolden(t *testing.T) {
+	dir := copyFixture(t, "rename_project")
+	setupRun(t, "y\n")
+	opts := renameOptions{vcsMode: "none", namespaces: true, configPath: filepath.Join(dir, "rename.json")}
+	if code := runRename(dir, opts); code != 0 {
+		t.Fatalf("runRename = %d, want 0", code)
+	}
+	// The backup archive and the log differ on every run; only their names are compared
+	assertGoldenTree(t, dir, "testdata/rename_project.golden", ".rename_backup/", "rename_project.log")
+}
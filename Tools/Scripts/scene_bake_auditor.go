@@ -0,0 +1,1653 @@
+// Scene Bake Auditor — Find scenes with stale or missing NavMesh and occlusion data, and re-bake them.
+// A scene that was edited after its NavMesh or occlusion culling data was baked
+// ships with data that no longer matches its geometry, and a scene with navigation
+// or occluder static objects (or an unbaked NavMeshSurface) but no data ships
+// without it. The audit compares each scene with the data assets it references;
+// "rebake" opens the affected scenes in batch mode with the installed editor and
+// bakes them again through SceneDataBaker from "unity_build_hooks install".
+//
+// Build: go build scene_bake_auditor.go
+//
+// Usage: run from the Unity project root.
+//
+//	scene_bake_auditor                                  # audit the enabled Build Settings scenes
+//	scene_bake_auditor -all                             # every scene under Assets/
+//	scene_bake_auditor -scenes "Assets/Levels/**" -kinds navmesh
+//	scene_bake_auditor rebake                           # re-bake what the audit reports
+//	scene_bake_auditor rebake -dry-run                  # show the Unity command line
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	bakeExecuteMethod = "Build.Hooks.Editor.SceneDataBaker.BakeFromCommandLine"
+	bakeLogRel        = "Library/UnityStarter/SceneBake.log"
+
+	// Overrides the editor picked from ProjectVersion.txt and the Unity Hub folders
+	envUnityPath = "UNITYSTARTER_UNITY"
+)
+
+// Contract with the editor hooks; keep in sync with unity_build_hooks.go
+const (
+	envBakeScenes        = "UNITYSTARTER_BAKE_SCENES"
+	envBakeReport        = "UNITYSTARTER_BAKE_REPORT"
+	defaultBakeReportRel = "Library/UnityStarter/LastBake.json"
+
+	hooksManifestFile = "UnityStarterBuildHooks.json" // in ProjectSettings/
+	bakeHooksMin      = 3                             // first hooks version with SceneDataBaker
+)
+
+// Bake kinds, as passed to SceneDataBaker
+const (
+	kindNavMesh   = "navmesh"
+	kindOcclusion = "occlusion"
+)
+
+// StaticEditorFlags bits that make a scene need baked data
+const (
+	occluderStaticFlag   = 2
+	navigationStaticFlag = 8
+	occludeeStaticFlag   = 16
+)
+
+var (
+	// "--- !u!196 &4": class ID and file ID of the next YAML document
+	yamlDocRegex    = regexp.MustCompile(`^--- !u!(\d+) &-?\d+( stripped)?`)
+	dataRefRegex    = regexp.MustCompile(`^\s*(m_NavMeshData|m_OcclusionCullingData):\s*\{fileID:\s*(-?\d+)(?:,\s*guid:\s*([0-9a-fA-F]{32}))?`)
+	staticFlagRegex = regexp.MustCompile(`^\s*m_StaticEditorFlags:\s*(\d+)`)
+	metaGUIDRegex   = regexp.MustCompile(`(?m)^guid:\s*([0-9a-fA-F]{32})`)
+
+	// EditorBuildSettings m_Scenes entries
+	sceneEnabledRegex = regexp.MustCompile(`^(\s*)- enabled: (\d)`)
+	scenePathRegex    = regexp.MustCompile(`^(\s*)path: (.*)$`)
+
+	projectVersionRegex = regexp.MustCompile(`(?m)^m_EditorVersion:\s*(\S+)`)
+
+	// Log lines worth showing when the bake fails
+	logErrorRegex = regexp.MustCompile(`(?i)(error CS\d+|\[SceneDataBaker\].*:|\[Error\]|Exception:|error:)`)
+)
+
+// Unity class IDs of the scene objects the audit reads
+const (
+	classGameObject        = "1"
+	classOcclusionSettings = "29"
+	classMonoBehaviour     = "114"
+	classNavMeshSettings   = "196"
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// EditorInstance represents the structure of Library/EditorInstance.json
+type EditorInstance struct {
+	ProcessID int `json:"process_id"`
+}
+
+// sceneData is what a scene file says about its baked data
+type sceneData struct {
+	navMeshRef    string   // guid of the scene NavMesh (Navigation window); "" = none
+	surfaceRefs   []string // guid per NavMeshSurface; "" = surface not baked
+	occlusionRef  string   // guid of the occlusion culling data; "" = none
+	navStatic     int      // GameObjects marked Navigation Static
+	occlusionObjs int      // GameObjects marked Occluder or Occludee Static
+}
+
+// bakeStatus is the state of one kind of data in one scene
+type bakeStatus struct {
+	state  string    // statusOK, statusStale, statusMissing or "" when the scene needs none
+	detail string    // why it is stale or missing
+	baked  time.Time // oldest data asset; zero when none exists
+	assets []string  // data assets the scene references, relative to the project root
+}
+
+// Data states
+const (
+	statusOK      = "ok"
+	statusStale   = "stale"
+	statusMissing = "missing"
+)
+
+// sceneAudit is the audit result of one scene
+type sceneAudit struct {
+	path      string // relative to project root, slash-separated
+	modified  time.Time
+	navMesh   bakeStatus
+	occlusion bakeStatus
+}
+
+// dataAsset is an .asset file of the project, indexed by guid
+type dataAsset struct {
+	path     string
+	modified time.Time
+}
+
+// bakeReport is the LastBake.json SceneDataBaker writes
+type bakeReport struct {
+	ContractVersion int    `json:"contractVersion"`
+	UnityVersion    string `json:"unityVersion"`
+	FinishedAt      string `json:"finishedAt"`
+	Scenes          []struct {
+		Scene     string `json:"scene"`
+		Kinds     string `json:"kinds"`
+		NavMesh   bool   `json:"navMesh"`
+		Surfaces  int    `json:"surfaces"`
+		Occlusion bool   `json:"occlusion"`
+		Error     string `json:"error"`
+	} `json:"scenes"`
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// checkUnityRunning checks if Unity Editor is running for this project
+// via Library/EditorInstance.json and a liveness check on the recorded PID.
+func checkUnityRunning(basePath string) (bool, int) {
+	data, err := os.ReadFile(filepath.Join(basePath, "Library", "EditorInstance.json"))
+	if err != nil {
+		return false, 0
+	}
+	var instance EditorInstance
+	if err := json.Unmarshal(data, &instance); err != nil || instance.ProcessID <= 0 {
+		return false, 0
+	}
+	if isProcessRunning(instance.ProcessID) {
+		return true, instance.ProcessID
+	}
+	return false, 0
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// readUnityVersion returns the editor version from ProjectSettings/ProjectVersion.txt
+func readUnityVersion(basePath string) string {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectVersion.txt"))
+	if err != nil {
+		return ""
+	}
+	if m := projectVersionRegex.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// installedHooksVersion reads the hooks manifest; 0 when the hooks are not installed
+func installedHooksVersion(basePath string) int {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", hooksManifestFile))
+	if err != nil {
+		return 0
+	}
+	var m struct {
+		HooksVersion int `json:"hooksVersion"`
+	}
+	json.Unmarshal(data, &m)
+	return m.HooksVersion
+}
+
+// ============================================================
+// Scene Selection
+// ============================================================
+
+// buildSettingsScenes returns the enabled scenes of EditorBuildSettings.asset
+func buildSettingsScenes(basePath string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "EditorBuildSettings.asset"))
+	if err != nil {
+		return nil, err
+	}
+	var scenes []string
+	inScenes, enabled := false, false
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "m_Scenes:" {
+			inScenes = true
+			continue
+		}
+		if !inScenes {
+			continue
+		}
+		// Next top-level key of EditorBuildSettings ends the list
+		if strings.HasPrefix(line, "  m_") {
+			break
+		}
+		if m := sceneEnabledRegex.FindStringSubmatch(line); m != nil {
+			enabled = m[2] == "1"
+		} else if m := scenePathRegex.FindStringSubmatch(line); m != nil && enabled {
+			if p := strings.TrimSpace(m[2]); p != "" {
+				scenes = append(scenes, p)
+			}
+		}
+	}
+	return scenes, nil
+}
+
+// projectScenes returns every scene under Assets/, filtered by globs when given
+func projectScenes(basePath string, globs []*regexp.Regexp) []string {
+	var scenes []string
+	filepath.Walk(filepath.Join(basePath, "Assets"), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(info.Name(), ".unity") {
+			return nil
+		}
+		rel, _ := filepath.Rel(basePath, path)
+		rel = filepath.ToSlash(rel)
+		if len(globs) == 0 || matchesAny(globs, rel) {
+			scenes = append(scenes, rel)
+		}
+		return nil
+	})
+	sort.Strings(scenes)
+	return scenes
+}
+
+// ============================================================
+// Scene Data
+// ============================================================
+
+// indexDataAssets maps the guid of every .asset under Assets/ to its path and
+// modification time. NavMesh and occlusion data are always separate assets.
+func indexDataAssets(basePath string) map[string]dataAsset {
+	index := make(map[string]dataAsset)
+	filepath.Walk(filepath.Join(basePath, "Assets"), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(info.Name(), ".asset.meta") {
+			return nil
+		}
+		meta, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		m := metaGUIDRegex.FindSubmatch(meta)
+		if m == nil {
+			return nil
+		}
+		assetPath := strings.TrimSuffix(path, ".meta")
+		asset, err := os.Stat(assetPath)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(basePath, assetPath)
+		index[strings.ToLower(string(m[1]))] = dataAsset{path: filepath.ToSlash(rel), modified: asset.ModTime()}
+		return nil
+	})
+	return index
+}
+
+// parseSceneData reads the data references and static flags of a scene file.
+// Objects inside prefab instances are not in the scene file and are not counted.
+func parseSceneData(content string) *sceneData {
+	data := &sceneData{}
+	class, stripped := "", false
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if m := yamlDocRegex.FindStringSubmatch(line); m != nil {
+			class, stripped = m[1], m[2] != ""
+			continue
+		}
+		if stripped {
+			continue
+		}
+		if m := staticFlagRegex.FindStringSubmatch(line); m != nil && class == classGameObject {
+			flags, _ := strconv.ParseUint(m[1], 10, 64)
+			if flags&navigationStaticFlag != 0 {
+				data.navStatic++
+			}
+			if flags&(occluderStaticFlag|occludeeStaticFlag) != 0 {
+				data.occlusionObjs++
+			}
+			continue
+		}
+		m := dataRefRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		guid := ""
+		if m[2] != "0" {
+			guid = strings.ToLower(m[3])
+		}
+		switch {
+		case m[1] == "m_NavMeshData" && class == classNavMeshSettings:
+			data.navMeshRef = guid
+		case m[1] == "m_NavMeshData" && class == classMonoBehaviour:
+			// Only NavMeshSurface serializes m_NavMeshData in a MonoBehaviour
+			data.surfaceRefs = append(data.surfaceRefs, guid)
+		case m[1] == "m_OcclusionCullingData" && class == classOcclusionSettings:
+			data.occlusionRef = guid
+		}
+	}
+	return data
+}
+
+// dataStatus compares the data assets a scene references with the scene file. A
+// bake saves the data first and the scene afterwards, so the scene counts as
+// edited after the bake only when it is newer than the data by more than grace.
+func dataStatus(refs []string, index map[string]dataAsset, sceneModified time.Time, grace time.Duration) bakeStatus {
+	var st bakeStatus
+	unbaked, missing := 0, 0
+	for _, guid := range refs {
+		if guid == "" {
+			unbaked++
+			continue
+		}
+		asset, ok := index[guid]
+		if !ok {
+			missing++
+			continue
+		}
+		st.assets = append(st.assets, asset.path)
+		if st.baked.IsZero() || asset.modified.Before(st.baked) {
+			st.baked = asset.modified
+		}
+	}
+	switch {
+	case unbaked > 0 && len(refs) > 1:
+		st.state, st.detail = statusMissing, fmt.Sprintf(tr("%d of %d NavMeshSurface(s) not baked"), unbaked, len(refs))
+	case unbaked > 0:
+		st.state, st.detail = statusMissing, tr("not baked")
+	case missing > 0:
+		st.state, st.detail = statusMissing, tr("referenced data asset not found")
+	case sceneModified.Sub(st.baked) > grace:
+		st.state, st.detail = statusStale, fmt.Sprintf(tr("scene saved %s after the bake"), formatDuration(sceneModified.Sub(st.baked)))
+	default:
+		st.state = statusOK
+	}
+	return st
+}
+
+// auditScene works out the NavMesh and occlusion status of one scene
+func auditScene(basePath, rel string, index map[string]dataAsset, grace time.Duration) (*sceneAudit, error) {
+	path := filepath.Join(basePath, filepath.FromSlash(rel))
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, errors.New("scene file not found")
+	} else if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data := parseSceneData(string(content))
+	audit := &sceneAudit{path: rel, modified: info.ModTime()}
+
+	// NavMeshSurface components replace the scene NavMesh when present
+	switch {
+	case len(data.surfaceRefs) > 0:
+		audit.navMesh = dataStatus(data.surfaceRefs, index, audit.modified, grace)
+	case data.navMeshRef != "" || data.navStatic > 0:
+		audit.navMesh = dataStatus([]string{data.navMeshRef}, index, audit.modified, grace)
+		if data.navMeshRef == "" {
+			audit.navMesh.detail = fmt.Sprintf(tr("%d Navigation Static object(s), no NavMesh"), data.navStatic)
+		}
+	}
+	if data.occlusionRef != "" || data.occlusionObjs > 0 {
+		audit.occlusion = dataStatus([]string{data.occlusionRef}, index, audit.modified, grace)
+		if data.occlusionRef == "" {
+			audit.occlusion.detail = fmt.Sprintf(tr("%d occluder/occludee object(s), no occlusion data"), data.occlusionObjs)
+		}
+	}
+	return audit, nil
+}
+
+// status returns the bake status of one kind
+func (a *sceneAudit) status(kind string) *bakeStatus {
+	if kind == kindNavMesh {
+		return &a.navMesh
+	}
+	return &a.occlusion
+}
+
+// bakeKinds returns the kinds of the scene to bake: those stale or missing, or
+// every kind the scene uses when all is set
+func (a *sceneAudit) bakeKinds(kinds []string, all bool) []string {
+	var res []string
+	for _, kind := range kinds {
+		st := a.status(kind)
+		if st.state == statusStale || st.state == statusMissing || (all && st.state == statusOK) {
+			res = append(res, kind)
+		}
+	}
+	return res
+}
+
+// parseKinds checks the -kinds list
+func parseKinds(list string) ([]string, error) {
+	var kinds []string
+	for _, k := range strings.Split(list, ",") {
+		switch k = strings.ToLower(strings.TrimSpace(k)); k {
+		case "":
+		case kindNavMesh, kindOcclusion:
+			if !containsString(kinds, k) {
+				kinds = append(kinds, k)
+			}
+		default:
+			return nil, fmt.Errorf("unknown kind '%s' (use navmesh, occlusion)", k)
+		}
+	}
+	if len(kinds) == 0 {
+		return nil, errors.New("-kinds is empty")
+	}
+	return kinds, nil
+}
+
+// ============================================================
+// Installed Editors
+// ============================================================
+
+// hubEditorFolders returns the folders Unity Hub installs editors into: the
+// default location plus the custom one from secondaryInstallPath.json
+func hubEditorFolders() []string {
+	home, _ := os.UserHomeDir()
+	var folders []string
+	var hubConfig string
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramW6432"} {
+			if pf := os.Getenv(env); pf != "" {
+				folders = append(folders, filepath.Join(pf, "Unity", "Hub", "Editor"))
+			}
+		}
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			hubConfig = filepath.Join(appData, "UnityHub")
+		}
+	case "darwin":
+		folders = append(folders, "/Applications/Unity/Hub/Editor")
+		hubConfig = filepath.Join(home, "Library", "Application Support", "UnityHub")
+	default:
+		folders = append(folders, filepath.Join(home, "Unity", "Hub", "Editor"))
+		hubConfig = filepath.Join(home, ".config", "UnityHub")
+	}
+	if hubConfig != "" {
+		// The file holds a single JSON string; empty when no custom location is set
+		if data, err := os.ReadFile(filepath.Join(hubConfig, "secondaryInstallPath.json")); err == nil {
+			var custom string
+			if json.Unmarshal(data, &custom) == nil && custom != "" {
+				folders = append(folders, custom)
+			}
+		}
+	}
+	return folders
+}
+
+// editorExecutable is the Unity binary inside an editor version folder
+func editorExecutable(dir string) string {
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(dir, "Editor", "Unity.exe")
+	case "darwin":
+		return filepath.Join(dir, "Unity.app", "Contents", "MacOS", "Unity")
+	default:
+		return filepath.Join(dir, "Editor", "Unity")
+	}
+}
+
+// findUnity picks the editor: -unity, UNITYSTARTER_UNITY, then the Hub install
+// of the project's exact version. Another patch version is never picked
+// silently; it would upgrade the project.
+func findUnity(basePath, override string) (string, error) {
+	for _, candidate := range []string{override, os.Getenv(envUnityPath)} {
+		if candidate == "" {
+			continue
+		}
+		// A version folder works as well as the binary itself
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			candidate = editorExecutable(candidate)
+		}
+		if _, err := os.Stat(candidate); err != nil {
+			return "", fmt.Errorf("Unity editor not found: %s", candidate)
+		}
+		return candidate, nil
+	}
+	version := readUnityVersion(basePath)
+	if version == "" {
+		return "", errors.New("cannot read the Unity version from ProjectSettings/ProjectVersion.txt; pass -unity")
+	}
+	for _, folder := range hubEditorFolders() {
+		exe := editorExecutable(filepath.Join(folder, version))
+		if _, err := os.Stat(exe); err == nil {
+			return exe, nil
+		}
+	}
+	return "", fmt.Errorf("Unity %s is not installed in the Unity Hub folders; install it or pass -unity", version)
+}
+
+// ============================================================
+// Rebake
+// ============================================================
+
+// bakeCommand returns the Unity arguments and the extra environment for a bake
+// of the given scenes ("Assets/A.unity=navmesh,occlusion" entries)
+func bakeCommand(basePath string, entries []string) ([]string, []string) {
+	args := []string{
+		"-batchmode", "-quit",
+		"-projectPath", basePath,
+		"-executeMethod", bakeExecuteMethod,
+		"-logFile", filepath.Join(basePath, filepath.FromSlash(bakeLogRel)),
+	}
+	env := []string{
+		envBakeScenes + "=" + strings.Join(entries, ";"),
+		envBakeReport + "=" + filepath.Join(basePath, filepath.FromSlash(defaultBakeReportRel)),
+	}
+	return args, env
+}
+
+// runBake starts Unity and returns the report SceneDataBaker wrote. Unity exits
+// with 1 when a scene failed, so the report is read in that case too.
+func runBake(basePath, unity string, entries []string, timeout time.Duration) (*bakeReport, error) {
+	reportPath := filepath.Join(basePath, filepath.FromSlash(defaultBakeReportRel))
+	logPath := filepath.Join(basePath, filepath.FromSlash(bakeLogRel))
+	// Leftovers of the previous run must not pass for this run's results
+	os.Remove(reportPath)
+	os.Remove(logPath)
+	if err := os.MkdirAll(filepath.Dir(reportPath), 0755); err != nil {
+		return nil, err
+	}
+
+	args, env := bakeCommand(basePath, entries)
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, unity, args...)
+	cmd.Dir = basePath
+	cmd.Env = append(os.Environ(), env...)
+	runErr := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("Unity failed (%v) and wrote no bake report", runErr)
+		}
+		return nil, errors.New("no bake report was written (hooks missing or the project did not compile)")
+	}
+	var report bakeReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("cannot read %s: %v", defaultBakeReportRel, err)
+	}
+	return &report, nil
+}
+
+// logExcerpt returns the last error lines of a log, or its last lines when no
+// line looks like an error
+func logExcerpt(logPath string, max int) []string {
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	var errorLines []string
+	for _, line := range lines {
+		if logErrorRegex.MatchString(line) {
+			errorLines = append(errorLines, strings.TrimSpace(line))
+		}
+	}
+	if len(errorLines) == 0 {
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		errorLines = lines
+	}
+	if len(errorLines) > max {
+		errorLines = errorLines[len(errorLines)-max:]
+	}
+	return errorLines
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+// printAudit prints one line per scene with the state of each kind, and the
+// reason under each stale or missing one. Returns the scenes with issues.
+func printAudit(audits []*sceneAudit, kinds []string) int {
+	width := len(tr("SCENE"))
+	for _, a := range audits {
+		if len(a.path) > width {
+			width = len(a.path)
+		}
+	}
+	fmt.Printf("%-*s", width+2, tr("SCENE"))
+	for _, kind := range kinds {
+		fmt.Printf("%-12s", tr(strings.ToUpper(kind)))
+	}
+	fmt.Println()
+
+	issues := 0
+	for _, a := range audits {
+		fmt.Printf("%-*s", width+2, a.path)
+		var notes []string
+		for _, kind := range kinds {
+			st := a.status(kind)
+			label := "-"
+			if st.state != "" {
+				label = tr(st.state)
+			}
+			fmt.Printf("%-12s", label)
+			if st.state == statusStale || st.state == statusMissing {
+				notes = append(notes, fmt.Sprintf("%s: %s", tr(strings.ToUpper(kind)), st.detail))
+			}
+		}
+		fmt.Println()
+		for _, note := range notes {
+			fmt.Printf("    %s\n", note)
+		}
+		if len(notes) > 0 {
+			issues++
+		}
+	}
+	return issues
+}
+
+// ============================================================
+// Path Filters
+// ============================================================
+
+// globToRegexp converts a glob (*, ?, **) on slash-separated project paths. A
+// pattern without a slash matches the file name in any folder.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compileGlobs parses a comma-separated glob list
+func compileGlobs(list string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := globToRegexp(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, rel string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Version Control Checkout
+// ============================================================
+
+// Perforce and Plastic SCM keep files read-only until they are checked out. Writing
+// them directly (even after clearing the flag) leaves changes the server does not know
+// about, so files are opened for edit first. Git and plain folders need nothing.
+const (
+	vcsNone     = "none"
+	vcsPerforce = "p4"
+	vcsPlastic  = "plastic"
+)
+
+// vcsEnvVar overrides detection for every tool (e.g. on build agents)
+const vcsEnvVar = "UNITYSTARTER_VCS"
+
+// vcsClient issues checkouts for files about to be modified
+type vcsClient struct {
+	kind       string
+	source     string // how kind was chosen, for the startup message
+	checkedOut map[string]bool
+}
+
+// activeVCS is set up in main; nil means no checkouts
+var activeVCS *vcsClient
+
+// detectVCS picks the checkout provider. Priority: -vcs flag > UNITYSTARTER_VCS >
+// Unity's VersionControlSettings.asset mode > workspace markers (.plastic, P4CONFIG).
+func detectVCS(projectRoot, override string) (*vcsClient, error) {
+	normalize := func(v string) (string, bool) {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "p4", "perforce":
+			return vcsPerforce, true
+		case "plastic", "plasticscm", "cm", "unityvcs":
+			return vcsPlastic, true
+		case "none", "git", "off":
+			return vcsNone, true
+		}
+		return "", false
+	}
+	newClient := func(kind, source string) *vcsClient {
+		return &vcsClient{kind: kind, source: source, checkedOut: make(map[string]bool)}
+	}
+
+	if override != "" && override != "auto" {
+		kind, ok := normalize(override)
+		if !ok {
+			return nil, fmt.Errorf("unknown -vcs value '%s' (use auto, none, p4 or plastic)", override)
+		}
+		return newClient(kind, "-vcs flag"), nil
+	}
+	if env := os.Getenv(vcsEnvVar); env != "" {
+		if kind, ok := normalize(env); ok {
+			return newClient(kind, vcsEnvVar), nil
+		}
+	}
+
+	// Unity stores the project's integrated VCS in m_Mode ("Perforce", "PlasticSCM", ...)
+	if data, err := os.ReadFile(filepath.Join(projectRoot, "ProjectSettings", "VersionControlSettings.asset")); err == nil {
+		if m := regexp.MustCompile(`(?m)^\s*m_Mode:\s*(.+?)\s*$`).FindSubmatch(data); m != nil {
+			switch strings.ToLower(string(m[1])) {
+			case "perforce":
+				return newClient(vcsPerforce, "VersionControlSettings.asset"), nil
+			case "plasticscm", "plastic scm", "unity version control":
+				return newClient(vcsPlastic, "VersionControlSettings.asset"), nil
+			}
+		}
+	}
+
+	// Workspace markers, searched upward from the project root
+	p4config := os.Getenv("P4CONFIG")
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info != nil {
+				return newClient(vcsNone, "git repository"), nil
+			}
+			if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+				return newClient(vcsPlastic, ".plastic workspace"), nil
+			}
+			if p4config != "" {
+				if _, err := os.Stat(filepath.Join(dir, p4config)); err == nil {
+					return newClient(vcsPerforce, "P4CONFIG"), nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if os.Getenv("P4CLIENT") != "" {
+		return newClient(vcsPerforce, "P4CLIENT"), nil
+	}
+	return newClient(vcsNone, "no Perforce/Plastic workspace found"), nil
+}
+
+// checkout opens an existing file for edit. New files need no checkout, and files
+// outside the depot/workspace only produce a warning: the write still goes ahead.
+func (v *vcsClient) checkout(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || v.checkedOut[abs] {
+		return
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return
+	}
+	v.checkedOut[abs] = true
+
+	var cmd *exec.Cmd
+	switch v.kind {
+	case vcsPerforce:
+		cmd = exec.Command("p4", "edit", abs)
+	case vcsPlastic:
+		cmd = exec.Command("cm", "checkout", abs)
+	default:
+		return
+	}
+	// Run from the file's folder so P4CONFIG / the Plastic workspace is resolved
+	cmd.Dir = filepath.Dir(abs)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		fmt.Printf(tr("[WARNING] %s checkout failed for %s: %v %s\n"), v.kind, filepath.Base(abs), err, strings.TrimSpace(string(out)))
+		return
+	}
+	fmt.Printf(tr("[VCS] Checked out (%s): %s\n"), v.kind, filepath.Base(abs))
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+// formatDuration renders d as "3d 4h" / "5h 12m" / "40s"
+func formatDuration(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh %dm", hours, int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...":                 "\n按回车键继续...",
+	"[WARNING] %s checkout failed for %s: %v %s\n": "[WARNING] %s 签出失败 %s: %v %s\n",
+	"[VCS] Checked out (%s): %s\n":                 "[VCS] 已签出 (%s): %s\n",
+
+	"Usage: scene_bake_auditor [flags] [audit|rebake]": "用法: scene_bake_auditor [参数] [audit|rebake]",
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  SCENE BAKE AUDIT": "  场景烘焙数据检查",
+	"  Project: %s\n":    "  项目:   %s\n",
+	"  Kinds:   %s\n":    "  类型:   %s\n",
+	"[--] No scenes to audit. Enable scenes in the Build Settings, or pass -all or -scenes.": "[--] 没有要检查的场景。请在 Build Settings 中启用场景，或使用 -all / -scenes。",
+	"[WARNING] %s: %v\n":                   "[WARNING] %s: %v\n",
+	"SCENE":                                "场景",
+	"NAVMESH":                              "NAVMESH",
+	"OCCLUSION":                            "遮挡剔除",
+	"ok":                                   "正常",
+	"stale":                                "过期",
+	"missing":                              "缺失",
+	"not baked":                            "未烘焙",
+	"referenced data asset not found":      "找不到引用的数据资源",
+	"scene saved %s after the bake":        "场景在烘焙 %s 后又被保存",
+	"%d of %d NavMeshSurface(s) not baked": "%d/%d 个 NavMeshSurface 未烘焙",
+	"%d Navigation Static object(s), no NavMesh":                                            "%d 个 Navigation Static 对象，但没有 NavMesh",
+	"%d occluder/occludee object(s), no occlusion data":                                     "%d 个遮挡/被遮挡静态对象，但没有遮挡数据",
+	"\n%d scene(s) audited, %d with stale or missing data\n":                                "\n已检查 %d 个场景，其中 %d 个数据过期或缺失\n",
+	"[TIP] Run \"scene_bake_auditor rebake\" to bake them again with the installed editor.": "[TIP] 运行 \"scene_bake_auditor rebake\" 用已安装的编辑器重新烘焙。",
+	"\nNothing to re-bake.":                                                                 "\n没有需要重新烘焙的场景。",
+	"[WARNING] %v\n":                                                                        "[WARNING] %v\n",
+	"\n[Dry Run] Unity was not started.":                                                    "\n[Dry Run] 未启动 Unity。",
+	"\n[ERROR] Unity Editor is running (PID: %d). Close it before re-baking; batch mode cannot open a project that is already open.\n": "\n[ERROR] Unity 编辑器正在运行 (PID: %d)。请先关闭编辑器再重新烘焙；批处理模式无法打开已打开的项目。\n",
+	"\n[ERROR] %v\n":                            "\n[ERROR] %v\n",
+	"[VCS] Using %s (%s)\n":                     "[VCS] 使用 %s (%s)\n",
+	"\nRe-baking %d scene(s) with %s ...\n":     "\n正在用 %[2]s 重新烘焙 %[1]d 个场景...\n",
+	"[FAIL] %v\n":                               "[FAIL] %v\n",
+	"       Log: %s\n":                          "       日志: %s\n",
+	"[FAIL] %s: %s\n":                           "[FAIL] %s: %s\n",
+	"[OK]   %s (%s)\n":                          "[OK]   %s (%s)\n",
+	"\nBake finished in %s: %d ok, %d failed\n": "\n烘焙完成，耗时 %s: %d 个成功，%d 个失败\n",
+	"Log: %s\n":                                 "日志: %s\n",
+	"[WARNING] %s still has stale or missing data after the bake\n":                  "[WARNING] 烘焙后 %s 的数据仍然过期或缺失\n",
+	"[TIP] Commit the scenes together with their NavMesh and occlusion data assets.": "[TIP] 请将场景与其 NavMesh 和遮挡数据资源一起提交。",
+
+	"[WARNING] Removing a stale %s (%s)\n":                      "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n": "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// CI Annotations
+// ============================================================
+
+// With -annotate, errors and warnings are printed once more as GitHub Actions
+// workflow commands or TeamCity service messages, so the CI lists them on the run
+// page and, when they point at a file, next to that file in the diff.
+var (
+	annotateMode  string              // "", "github" or "teamcity"
+	annotateTool  string              // TeamCity inspection category
+	annotateBase  string              // project root; relative files start here
+	annotateRoot  string              // repository root; CI file paths are relative to it
+	annotateTypes = map[string]bool{} // TeamCity inspection types already declared
+)
+
+// setAnnotateMode checks the -annotate value; "auto" picks the CI the tool runs in
+func setAnnotateMode(mode, basePath, tool string) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "none":
+		annotateMode = ""
+	case "github", "teamcity":
+		annotateMode = strings.ToLower(strings.TrimSpace(mode))
+	case "auto":
+		annotateMode = ""
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			annotateMode = "github"
+		} else if os.Getenv("TEAMCITY_VERSION") != "" {
+			annotateMode = "teamcity"
+		}
+	default:
+		return fmt.Errorf("unknown -annotate mode '%s' (use github, teamcity or auto)", mode)
+	}
+	annotateTool = tool
+	annotateBase, _ = filepath.Abs(basePath)
+	annotateRoot = repositoryRoot(annotateBase)
+	return nil
+}
+
+// repositoryRoot is GITHUB_WORKSPACE, else the nearest folder with a .git entry
+// (a folder, or a file in worktrees and submodules), else the project itself
+func repositoryRoot(basePath string) string {
+	if ws := os.Getenv("GITHUB_WORKSPACE"); ws != "" && annotateMode == "github" {
+		return ws
+	}
+	for dir := basePath; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return basePath
+		}
+	}
+}
+
+// annotate prints one annotation. level is "error" or "warning"; file is absolute
+// or relative to the project root, and may be empty like line and col.
+func annotate(level, file string, line, col int, title, message string) {
+	if annotateMode == "" {
+		return
+	}
+	if file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(annotateBase, filepath.FromSlash(file))
+		}
+		if rel, err := filepath.Rel(annotateRoot, file); err == nil {
+			file = rel
+		}
+		file = filepath.ToSlash(file)
+	}
+
+	if annotateMode == "github" {
+		// Property values also escape ':' and ','; the message only '%' and line breaks
+		data := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+		prop := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+		var props []string
+		if file != "" {
+			props = append(props, "file="+prop.Replace(file))
+			if line > 0 {
+				props = append(props, "line="+strconv.Itoa(line))
+			}
+			if col > 0 {
+				props = append(props, "col="+strconv.Itoa(col))
+			}
+		}
+		if title != "" {
+			props = append(props, "title="+prop.Replace(title))
+		}
+		fmt.Printf("::%s %s::%s\n", level, strings.Join(props, ","), data.Replace(message))
+		return
+	}
+
+	esc := strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]").Replace
+	status := "WARNING"
+	if level == "error" {
+		status = "ERROR"
+	}
+	if file == "" {
+		text := message
+		if title != "" {
+			text = title + ": " + message
+		}
+		fmt.Printf("##teamcity[message text='%s' status='%s']\n", esc(text), status)
+		return
+	}
+	typeID := annotateTool + "." + strings.ReplaceAll(strings.ToLower(title), " ", "-")
+	if !annotateTypes[typeID] {
+		annotateTypes[typeID] = true
+		fmt.Printf("##teamcity[inspectionType id='%s' name='%s' category='%s' description='%s']\n", esc(typeID), esc(title), esc(annotateTool), esc(title))
+	}
+	lineAttr := ""
+	if line > 0 {
+		lineAttr = fmt.Sprintf(" line='%d'", line)
+	}
+	fmt.Printf("##teamcity[inspection typeId='%s' message='%s' file='%s'%s SEVERITY='%s']\n", esc(typeID), esc(message), esc(file), lineAttr, status)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode     bool
+		dryRun     bool
+		force      bool
+		allScenes  bool
+		includeOK  bool
+		scenesFlag string
+		kindsFlag  string
+		unityFlag  string
+		vcsMode    string
+		annotateFl string
+		grace      time.Duration
+		timeout    time.Duration
+	)
+
+	flag.BoolVar(&allScenes, "all", false, "Audit every scene under Assets/ instead of the enabled Build Settings scenes")
+	flag.StringVar(&scenesFlag, "scenes", "", "Comma-separated globs of scenes under Assets/ to audit (e.g. \"Assets/Levels/**\")")
+	flag.StringVar(&kindsFlag, "kinds", "navmesh,occlusion", "Data to check and bake: navmesh, occlusion")
+	flag.DurationVar(&grace, "grace", 10*time.Minute, "How much newer than its data a scene may be before the data counts as stale")
+	flag.BoolVar(&includeOK, "include-ok", false, "rebake: also bake scenes whose data is up to date")
+	flag.StringVar(&unityFlag, "unity", "", "Unity editor binary or version folder (default: UNITYSTARTER_UNITY, then the Hub install of the project's version)")
+	flag.DurationVar(&timeout, "timeout", 0, "rebake: abort Unity after this long (e.g. 60m; default: none)")
+	flag.StringVar(&annotateFl, "annotate", "", "Also print stale and missing data as CI annotations: github, teamcity, auto")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&dryRun, "dry-run", false, "rebake: print the Unity command line without running it")
+	flag.BoolVar(&force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
+	flag.StringVar(&vcsMode, "vcs", "auto", "Checkout provider for the scenes and data assets rebake changes: auto, none, p4, plastic")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the command ("rebake -dry-run")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("scene_bake_auditor")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	command := "audit"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	if len(args) > 1 || (command != "audit" && command != "rebake") {
+		fmt.Println(tr("Usage: scene_bake_auditor [flags] [audit|rebake]"))
+		recordError("unknown command")
+		exit(2)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if err := setAnnotateMode(annotateFl, basePath, "scene_bake_auditor"); err != nil {
+		fail(2, "%v", err)
+	}
+	kinds, err := parseKinds(kindsFlag)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+	globs, err := compileGlobs(scenesFlag)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+
+	var scenes []string
+	if allScenes || len(globs) > 0 {
+		scenes = projectScenes(basePath, globs)
+	} else if scenes, err = buildSettingsScenes(basePath); err != nil {
+		fail(1, "cannot read ProjectSettings/EditorBuildSettings.asset: %v", err)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  SCENE BAKE AUDIT"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project: %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Kinds:   %s\n"), strings.Join(kinds, ", "))
+	fmt.Println()
+	if len(scenes) == 0 {
+		fmt.Println(tr("[--] No scenes to audit. Enable scenes in the Build Settings, or pass -all or -scenes."))
+		exit(0)
+	}
+
+	index := indexDataAssets(basePath)
+	var audits []*sceneAudit
+	for _, rel := range scenes {
+		a, err := auditScene(basePath, rel, index, grace)
+		if err != nil {
+			// Build Settings may still list a deleted scene; build_scenes_validator reports those
+			fmt.Printf(tr("[WARNING] %s: %v\n"), rel, err)
+			recordAction("audit", rel, "failed", err.Error(), 0)
+			continue
+		}
+		audits = append(audits, a)
+	}
+
+	issues := printAudit(audits, kinds)
+	for _, a := range audits {
+		for _, kind := range kinds {
+			st := a.status(kind)
+			if st.state == "" {
+				continue
+			}
+			recordAction(kind, a.path, st.state, st.detail, 0)
+			if st.state != statusOK {
+				annotate("warning", a.path, 0, 0, "Scene bake data", fmt.Sprintf("%s data is %s: %s", kind, st.state, st.detail))
+			}
+		}
+	}
+	fmt.Printf(tr("\n%d scene(s) audited, %d with stale or missing data\n"), len(audits), issues)
+
+	if command == "audit" {
+		if issues > 0 {
+			fmt.Println(tr("[TIP] Run \"scene_bake_auditor rebake\" to bake them again with the installed editor."))
+			exit(1)
+		}
+		exit(0)
+	}
+
+	// Rebake
+	var entries []string
+	var targets []*sceneAudit
+	for _, a := range audits {
+		if k := a.bakeKinds(kinds, includeOK); len(k) > 0 {
+			entries = append(entries, a.path+"="+strings.Join(k, ","))
+			targets = append(targets, a)
+		}
+	}
+	if len(entries) == 0 {
+		fmt.Println(tr("\nNothing to re-bake."))
+		exit(0)
+	}
+
+	if hooks := installedHooksVersion(basePath); hooks < bakeHooksMin {
+		fail(1, "rebake needs build hooks v%d or newer (installed: v%d); run: unity_build_hooks install", bakeHooksMin, hooks)
+	}
+	unity, err := findUnity(basePath, unityFlag)
+	if err != nil {
+		if !dryRun {
+			fail(1, "%v", err)
+		}
+		// The dry run still shows the command line
+		fmt.Printf(tr("[WARNING] %v\n"), err)
+		unity = "Unity"
+	}
+
+	if dryRun {
+		bakeArgs, env := bakeCommand(basePath, entries)
+		fmt.Printf("\n  %s %s\n", unity, strings.Join(bakeArgs, " "))
+		for _, e := range env {
+			fmt.Printf("  %s\n", e)
+		}
+		for i, a := range targets {
+			recordAction("rebake", a.path, "planned", strings.TrimPrefix(entries[i], a.path+"="), 0)
+		}
+		fmt.Println(tr("\n[Dry Run] Unity was not started."))
+		exit(0)
+	}
+
+	if isRunning, pid := checkUnityRunning(basePath); isRunning {
+		fmt.Printf(tr("\n[ERROR] Unity Editor is running (PID: %d). Close it before re-baking; batch mode cannot open a project that is already open.\n"), pid)
+		recordError("Unity Editor is running (PID: %d). Close it before re-baking", pid)
+		exit(1)
+	}
+	if err := acquireProjectLock(basePath, "scene_bake_auditor", "rebake", force); err != nil {
+		fmt.Printf(tr("\n[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(1)
+	}
+	defer releaseProjectLock()
+	if activeVCS, err = detectVCS(basePath, vcsMode); err != nil {
+		fail(1, "%v", err)
+	}
+	if activeVCS.kind != vcsNone {
+		fmt.Printf(tr("[VCS] Using %s (%s)\n"), activeVCS.kind, activeVCS.source)
+	}
+	// The bake saves every scene and replaces the data assets it already has
+	for _, a := range targets {
+		activeVCS.checkout(filepath.Join(basePath, filepath.FromSlash(a.path)))
+		for _, kind := range kinds {
+			for _, asset := range a.status(kind).assets {
+				activeVCS.checkout(filepath.Join(basePath, filepath.FromSlash(asset)))
+				activeVCS.checkout(filepath.Join(basePath, filepath.FromSlash(asset)) + ".meta")
+			}
+		}
+	}
+
+	fmt.Printf(tr("\nRe-baking %d scene(s) with %s ...\n"), len(entries), unity)
+	start := time.Now()
+	report, err := runBake(basePath, unity, entries, timeout)
+	logPath := filepath.Join(basePath, filepath.FromSlash(bakeLogRel))
+	if err != nil {
+		fmt.Printf(tr("[FAIL] %v\n"), err)
+		for _, line := range logExcerpt(logPath, 20) {
+			fmt.Printf("       %s\n", line)
+		}
+		fmt.Printf(tr("       Log: %s\n"), logPath)
+		recordError("%v", err)
+		annotate("error", "", 0, 0, "Scene bake failed", err.Error())
+		exit(1)
+	}
+	recordArtifact(logPath)
+
+	failed := 0
+	for _, s := range report.Scenes {
+		if s.Error != "" {
+			failed++
+			fmt.Printf(tr("[FAIL] %s: %s\n"), s.Scene, s.Error)
+			recordAction("rebake", s.Scene, "failed", s.Error, 0)
+			recordError("%s: %s", s.Scene, s.Error)
+			annotate("error", s.Scene, 0, 0, "Scene bake failed", s.Error)
+			continue
+		}
+		fmt.Printf(tr("[OK]   %s (%s)\n"), s.Scene, s.Kinds)
+		recordAction("rebake", s.Scene, "ok", s.Kinds, 0)
+	}
+	fmt.Printf(tr("\nBake finished in %s: %d ok, %d failed\n"), time.Since(start).Round(time.Second), len(report.Scenes)-failed, failed)
+	if failed > 0 {
+		fmt.Printf(tr("Log: %s\n"), logPath)
+		exit(1)
+	}
+
+	// Audit the baked scenes again: a scene the bake could not produce data for
+	// (no navigation or occluder geometry found) still shows up
+	index = indexDataAssets(basePath)
+	remaining := 0
+	for _, a := range targets {
+		after, err := auditScene(basePath, a.path, index, grace)
+		if err == nil && len(after.bakeKinds(kinds, false)) == 0 {
+			continue
+		}
+		remaining++
+		fmt.Printf(tr("[WARNING] %s still has stale or missing data after the bake\n"), a.path)
+		recordError("%s still has stale or missing data after the bake", a.path)
+	}
+	if remaining > 0 {
+		exit(1)
+	}
+	fmt.Println(tr("[TIP] Commit the scenes together with their NavMesh and occlusion data assets."))
+	exit(0)
+}
@@ -0,0 +1,1315 @@
+// StreamingAssets Sync — Incremental copy of generated content into StreamingAssets.
+// Mirrors build output (AssetBundles, videos, localization tables...) into
+// Assets/StreamingAssets by comparing SHA-256 hashes: only new and changed files
+// are copied, files that no longer exist in the source are removed, and unchanged
+// files are never touched, so their .meta files (and GUIDs) stay as they are and
+// Unity does not reimport them. Hashing and copying run in parallel.
+//
+// Build: go build streaming_assets_sync.go
+//
+// Usage: run from the Unity project root. Each source is copied into a folder of
+// the same name under Assets/StreamingAssets, or into the folder after '='.
+//
+//	streaming_assets_sync Build/Bundles
+//	streaming_assets_sync Build/Bundles=AB/Android Localization/Out=Localization
+//	streaming_assets_sync -dry-run -exclude "*.manifest" Build/Bundles
+//	streaming_assets_sync -keep-stale ../Videos=Videos     # only add and update
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Default destination root, relative to the project root
+const defaultStreamingRoot = "Assets/StreamingAssets"
+
+// Hashes of files that did not change (same size and modification time) are
+// reused from this cache, so large video folders are not read on every run
+const hashCacheFile = "Library/UnityStarter/StreamingAssetsHashes.json"
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// syncMapping is one source folder and the folder it is mirrored into
+type syncMapping struct {
+	source string // absolute
+	dest   string // absolute, under Assets/
+	label  string // "Build/Bundles -> Assets/StreamingAssets/Bundles"
+}
+
+// Operation kinds
+const (
+	opAdd    = "add"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+// syncOp is one planned change. rel is slash-separated, relative to the mapping's folders.
+type syncOp struct {
+	kind    string
+	mapping *syncMapping
+	rel     string
+	size    int64
+	srcHash string // add/update: verified again after copying
+	err     error
+}
+
+// syncPlan is the outcome of comparing every mapping
+type syncPlan struct {
+	ops       []*syncOp
+	unchanged int
+	metaKept  int      // unchanged or updated files whose .meta stays untouched
+	staleDirs []string // absolute; removed when empty after the deletes
+}
+
+// cachedHash is keyed by absolute path in the hash cache
+type cachedHash struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"modTime"` // UnixNano
+	SHA256  string `json:"sha256"`
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks for Assets/ and ProjectSettings/ in the given directory
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// isUnder reports whether p is base or inside it
+func isUnder(p, base string) bool {
+	rel, err := filepath.Rel(base, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// parseMapping turns "src" or "src=dest" into absolute folders. dest is relative
+// to the StreamingAssets root and defaults to the source folder's name.
+func parseMapping(basePath, streamingRoot, arg string) (*syncMapping, error) {
+	src, dst := arg, ""
+	if i := strings.LastIndex(arg, "="); i >= 0 {
+		src, dst = arg[:i], arg[i+1:]
+	}
+	if strings.TrimSpace(src) == "" {
+		return nil, fmt.Errorf("empty source in '%s'", arg)
+	}
+	if !filepath.IsAbs(src) {
+		src = filepath.Join(basePath, src)
+	}
+	src = filepath.Clean(src)
+	info, err := os.Stat(src)
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("source folder not found: %s", src)
+	}
+	if dst == "" {
+		dst = filepath.Base(src)
+	}
+	if filepath.IsAbs(dst) {
+		return nil, fmt.Errorf("destination must be relative to %s: %s", streamingRoot, dst)
+	}
+	dest := filepath.Clean(filepath.Join(basePath, filepath.FromSlash(streamingRoot), filepath.FromSlash(dst)))
+
+	assets := filepath.Join(basePath, "Assets")
+	if !isUnder(dest, assets) || dest == assets {
+		return nil, fmt.Errorf("destination must be inside Assets/: %s", dest)
+	}
+	if isUnder(dest, src) || isUnder(src, dest) {
+		return nil, fmt.Errorf("source and destination overlap: %s, %s", src, dest)
+	}
+	relSrc, err := filepath.Rel(basePath, src)
+	if err != nil || strings.HasPrefix(relSrc, "..") {
+		relSrc = src
+	}
+	relDest, _ := filepath.Rel(basePath, dest)
+	return &syncMapping{
+		source: src,
+		dest:   dest,
+		label:  filepath.ToSlash(relSrc) + " -> " + filepath.ToSlash(relDest),
+	}, nil
+}
+
+// ============================================================
+// Path Filters
+// ============================================================
+
+// globToRegexp converts a glob to a regexp: ** spans folders, * and ? stay within
+// one path segment. Patterns without a slash match the file name alone.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compileGlobs parses a comma-separated glob list
+func compileGlobs(list string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := globToRegexp(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, rel string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Hashing
+// ============================================================
+
+// hashCache remembers file hashes between runs. Entries are only trusted while
+// the file's size and modification time are unchanged.
+type hashCache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]cachedHash
+	used    map[string]bool
+	dirty   bool
+}
+
+func loadHashCache(path string, enabled bool) *hashCache {
+	c := &hashCache{path: path, entries: make(map[string]cachedHash), used: make(map[string]bool)}
+	if !enabled {
+		return c
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &c.entries)
+	}
+	return c
+}
+
+// hash returns the SHA-256 of p, from the cache when info still matches
+func (c *hashCache) hash(p string, info os.FileInfo) (string, error) {
+	c.mu.Lock()
+	e, ok := c.entries[p]
+	c.used[p] = true
+	c.mu.Unlock()
+	if ok && e.Size == info.Size() && e.ModTime == info.ModTime().UnixNano() {
+		return e.SHA256, nil
+	}
+	sum, err := hashFile(p)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.entries[p] = cachedHash{Size: info.Size(), ModTime: info.ModTime().UnixNano(), SHA256: sum}
+	c.dirty = true
+	c.mu.Unlock()
+	return sum, nil
+}
+
+// forget drops a path whose file was replaced or deleted
+func (c *hashCache) forget(p string) {
+	c.mu.Lock()
+	delete(c.entries, p)
+	c.dirty = true
+	c.mu.Unlock()
+}
+
+// save keeps entries seen in this run plus those of folders that were not
+// part of it, so syncing one mapping does not throw away another's hashes
+func (c *hashCache) save(roots []string) error {
+	if !c.dirty && len(c.used) == len(c.entries) {
+		return nil
+	}
+	for p := range c.entries {
+		if c.used[p] {
+			continue
+		}
+		for _, root := range roots {
+			if isUnder(p, root) {
+				delete(c.entries, p)
+				break
+			}
+		}
+	}
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+func hashFile(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// parallel runs fn for 0..n-1 on up to jobs goroutines, calling progress after
+// each item from one goroutine at a time
+func parallel(n, jobs int, fn func(i int), progress func(done int)) {
+	if jobs < 1 {
+		jobs = 1
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+				mu.Lock()
+				done++
+				if progress != nil {
+					progress(done)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// ============================================================
+// Planning
+// ============================================================
+
+// listFiles returns the regular files under root by slash-separated relative
+// path, leaving out .meta files and paths matching the excludes. dirs collects
+// every folder below root.
+func listFiles(root string, excludes []*regexp.Regexp) (map[string]os.FileInfo, map[string]bool, error) {
+	files := make(map[string]os.FileInfo)
+	dirs := make(map[string]bool)
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return files, dirs, nil
+	}
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		if matchesAny(excludes, rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			dirs[rel] = true
+			return nil
+		}
+		if strings.HasSuffix(strings.ToLower(rel), ".meta") || !info.Mode().IsRegular() {
+			return nil
+		}
+		files[rel] = info
+		return nil
+	})
+	return files, dirs, err
+}
+
+// planMapping compares one source with its destination. Files of equal size are
+// hashed on both sides; a size difference alone already means "changed".
+func planMapping(m *syncMapping, excludes []*regexp.Regexp, keepStale bool, cache *hashCache, jobs int, plan *syncPlan) error {
+	srcFiles, srcDirs, err := listFiles(m.source, excludes)
+	if err != nil {
+		return err
+	}
+	// The excludes apply to the source only: an excluded file already in the
+	// destination is left alone rather than deleted as stale
+	dstFiles, dstDirs, err := listFiles(m.dest, nil)
+	if err != nil {
+		return err
+	}
+
+	rels := make([]string, 0, len(srcFiles))
+	for rel := range srcFiles {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+
+	ops := make([]*syncOp, len(rels))
+	var compare []int
+	for i, rel := range rels {
+		ops[i] = &syncOp{kind: opAdd, mapping: m, rel: rel, size: srcFiles[rel].Size()}
+		if dst, ok := dstFiles[rel]; ok {
+			ops[i].kind = opUpdate
+			if dst.Size() == srcFiles[rel].Size() {
+				compare = append(compare, i)
+			}
+		}
+	}
+
+	var mu sync.Mutex
+	var firstErr error
+	fmt.Printf(tr("  Hashing %d source file(s), %d with a same-size copy to compare...\n"), len(rels), len(compare))
+	parallel(len(rels), jobs, func(i int) {
+		op := ops[i]
+		srcPath := filepath.Join(m.source, filepath.FromSlash(op.rel))
+		sum, err := cache.hash(srcPath, srcFiles[op.rel])
+		if err == nil {
+			op.srcHash = sum
+		}
+		if err == nil && op.kind == opUpdate && dstFiles[op.rel].Size() == op.size {
+			var dstSum string
+			dstSum, err = cache.hash(filepath.Join(m.dest, filepath.FromSlash(op.rel)), dstFiles[op.rel])
+			if err == nil && dstSum == sum {
+				op.kind = ""
+			}
+		}
+		if err != nil {
+			mu.Lock()
+			if firstErr == nil {
+				firstErr = err
+			}
+			mu.Unlock()
+		}
+	}, func(done int) {
+		printProgressBar(done, len(rels))
+	})
+	if firstErr != nil {
+		return firstErr
+	}
+
+	for _, op := range ops {
+		if op.kind == "" {
+			plan.unchanged++
+		} else {
+			plan.ops = append(plan.ops, op)
+		}
+		if op.kind != opAdd {
+			if _, err := os.Stat(filepath.Join(m.dest, filepath.FromSlash(op.rel)) + ".meta"); err == nil {
+				plan.metaKept++
+			}
+		}
+	}
+
+	if keepStale {
+		return nil
+	}
+	var stale []string
+	for rel := range dstFiles {
+		if _, ok := srcFiles[rel]; !ok && !matchesAny(excludes, rel) {
+			stale = append(stale, rel)
+		}
+	}
+	sort.Strings(stale)
+	for _, rel := range stale {
+		plan.ops = append(plan.ops, &syncOp{kind: opDelete, mapping: m, rel: rel, size: dstFiles[rel].Size()})
+	}
+	for rel := range dstDirs {
+		if !srcDirs[rel] {
+			plan.staleDirs = append(plan.staleDirs, filepath.Join(m.dest, filepath.FromSlash(rel)))
+		}
+	}
+	return nil
+}
+
+// ============================================================
+// Sync
+// ============================================================
+
+// copyFile replaces target with src through a temp file in the target folder
+// and checks the copy against the hash taken while planning. An existing
+// target's .meta file is not touched, so Unity keeps its GUID and import settings.
+func copyFile(src, target, wantHash string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), in)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && wantHash != "" && hex.EncodeToString(h.Sum(nil)) != wantHash {
+		err = fmt.Errorf("%s changed while copying", filepath.Base(src))
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		if info, statErr := os.Stat(target); statErr == nil {
+			activeVCS.checkout(target)
+			// Clear a read-only flag left by Perforce/Plastic so the rename succeeds
+			os.Chmod(target, info.Mode().Perm()|0200)
+		}
+		err = os.Rename(tmp.Name(), target)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// removeWithMeta deletes a file and the .meta file Unity created for it
+func removeWithMeta(p string) error {
+	activeVCS.checkout(p)
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(p + ".meta"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// applyPlan runs the deletes first (a file replaced by a folder of the same
+// name needs the space), then the copies in parallel, then drops stale folders
+// that ended up empty, deepest first.
+func applyPlan(plan *syncPlan, cache *hashCache, jobs int) (copied, deleted, failed int) {
+	var copies []*syncOp
+	for _, op := range plan.ops {
+		if op.kind != opDelete {
+			copies = append(copies, op)
+			continue
+		}
+		p := filepath.Join(op.mapping.dest, filepath.FromSlash(op.rel))
+		if op.err = removeWithMeta(p); op.err != nil {
+			failed++
+		} else {
+			deleted++
+		}
+		cache.forget(p)
+	}
+
+	if len(copies) > 0 {
+		var mu sync.Mutex
+		parallel(len(copies), jobs, func(i int) {
+			op := copies[i]
+			target := filepath.Join(op.mapping.dest, filepath.FromSlash(op.rel))
+			op.err = copyFile(filepath.Join(op.mapping.source, filepath.FromSlash(op.rel)), target, op.srcHash)
+			cache.forget(target)
+			mu.Lock()
+			if op.err != nil {
+				failed++
+			} else {
+				copied++
+			}
+			mu.Unlock()
+		}, func(done int) {
+			printProgressBar(done, len(copies))
+		})
+	}
+
+	sort.Slice(plan.staleDirs, func(i, j int) bool { return len(plan.staleDirs[i]) > len(plan.staleDirs[j]) })
+	for _, dir := range plan.staleDirs {
+		if entries, err := os.ReadDir(dir); err == nil && len(entries) == 0 {
+			if os.Remove(dir) == nil {
+				os.Remove(dir + ".meta")
+			}
+		}
+	}
+	return copied, deleted, failed
+}
+
+// ============================================================
+// Version Control Checkout
+// ============================================================
+
+// Perforce and Plastic SCM keep files read-only until they are checked out. Writing
+// them directly (even after clearing the flag) leaves changes the server does not know
+// about, so files are opened for edit first. Git and plain folders need nothing.
+const (
+	vcsNone     = "none"
+	vcsPerforce = "p4"
+	vcsPlastic  = "plastic"
+)
+
+// vcsEnvVar overrides detection for every tool (e.g. on build agents)
+const vcsEnvVar = "UNITYSTARTER_VCS"
+
+// vcsClient issues checkouts for files about to be modified
+type vcsClient struct {
+	kind       string
+	source     string // how kind was chosen, for the startup message
+	checkedOut map[string]bool
+}
+
+// activeVCS is set up in main; nil means no checkouts
+var activeVCS *vcsClient
+
+// detectVCS picks the checkout provider. Priority: -vcs flag > UNITYSTARTER_VCS >
+// Unity's VersionControlSettings.asset mode > workspace markers (.plastic, P4CONFIG).
+func detectVCS(projectRoot, override string) (*vcsClient, error) {
+	normalize := func(v string) (string, bool) {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "p4", "perforce":
+			return vcsPerforce, true
+		case "plastic", "plasticscm", "cm", "unityvcs":
+			return vcsPlastic, true
+		case "none", "git", "off":
+			return vcsNone, true
+		}
+		return "", false
+	}
+	newClient := func(kind, source string) *vcsClient {
+		return &vcsClient{kind: kind, source: source, checkedOut: make(map[string]bool)}
+	}
+
+	if override != "" && override != "auto" {
+		kind, ok := normalize(override)
+		if !ok {
+			return nil, fmt.Errorf("unknown -vcs value '%s' (use auto, none, p4 or plastic)", override)
+		}
+		return newClient(kind, "-vcs flag"), nil
+	}
+	if env := os.Getenv(vcsEnvVar); env != "" {
+		if kind, ok := normalize(env); ok {
+			return newClient(kind, vcsEnvVar), nil
+		}
+	}
+
+	// Unity stores the project's integrated VCS in m_Mode ("Perforce", "PlasticSCM", ...)
+	if data, err := os.ReadFile(filepath.Join(projectRoot, "ProjectSettings", "VersionControlSettings.asset")); err == nil {
+		if m := regexp.MustCompile(`(?m)^\s*m_Mode:\s*(.+?)\s*$`).FindSubmatch(data); m != nil {
+			switch strings.ToLower(string(m[1])) {
+			case "perforce":
+				return newClient(vcsPerforce, "VersionControlSettings.asset"), nil
+			case "plasticscm", "plastic scm", "unity version control":
+				return newClient(vcsPlastic, "VersionControlSettings.asset"), nil
+			}
+		}
+	}
+
+	// Workspace markers, searched upward from the project root
+	p4config := os.Getenv("P4CONFIG")
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info != nil {
+				return newClient(vcsNone, "git repository"), nil
+			}
+			if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+				return newClient(vcsPlastic, ".plastic workspace"), nil
+			}
+			if p4config != "" {
+				if _, err := os.Stat(filepath.Join(dir, p4config)); err == nil {
+					return newClient(vcsPerforce, "P4CONFIG"), nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if os.Getenv("P4CLIENT") != "" {
+		return newClient(vcsPerforce, "P4CLIENT"), nil
+	}
+	return newClient(vcsNone, "no Perforce/Plastic workspace found"), nil
+}
+
+// checkout opens an existing file for edit. New files need no checkout, and files
+// outside the depot/workspace only produce a warning: the write still goes ahead.
+func (v *vcsClient) checkout(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || v.checkedOut[abs] {
+		return
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return
+	}
+	v.checkedOut[abs] = true
+
+	var cmd *exec.Cmd
+	switch v.kind {
+	case vcsPerforce:
+		cmd = exec.Command("p4", "edit", abs)
+	case vcsPlastic:
+		cmd = exec.Command("cm", "checkout", abs)
+	default:
+		return
+	}
+	// Run from the file's folder so P4CONFIG / the Plastic workspace is resolved
+	cmd.Dir = filepath.Dir(abs)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		fmt.Printf(tr("[WARNING] %s checkout failed for %s: %v %s\n"), v.kind, filepath.Base(abs), err, strings.TrimSpace(string(out)))
+		return
+	}
+	fmt.Printf(tr("[VCS] Checked out (%s): %s\n"), v.kind, filepath.Base(abs))
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+func printProgressBar(current, total int) {
+	percent := float64(current) / float64(total)
+	if plainMode || jsonMode {
+		// One line per 10% step instead of redrawing the bar with \r
+		step := int(percent * 10)
+		if current == total || step > int(float64(current-1)/float64(total)*10) {
+			fmt.Printf(tr("Progress: %d/%d (%.0f%%)\n"), current, total, percent*100)
+		}
+		return
+	}
+	barLength := 40
+	filledLength := int(float64(barLength) * percent)
+
+	bar := strings.Repeat("█", filledLength) + strings.Repeat("-", barLength-filledLength)
+	fmt.Printf("\r[%s] %.0f%% (%d/%d)", bar, percent*100, current, total)
+	if current == total {
+		fmt.Println()
+	}
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"Usage: streaming_assets_sync [flags] <source>[=<folder>] ...": "用法: streaming_assets_sync [参数] <源文件夹>[=<目标文件夹>] ...",
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                            "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.":      "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"[ERROR] Destinations overlap: %s, %s\n":                                "[ERROR] 目标文件夹重叠: %s, %s\n",
+	"  STREAMINGASSETS SYNC":                                                "  STREAMINGASSETS 同步",
+	"  Hashing %d source file(s), %d with a same-size copy to compare...\n": "  正在计算 %d 个源文件的哈希，其中 %d 个需与同大小的目标文件比较...\n",
+	"[ERROR] Cannot compare %s: %v\n":                                       "[ERROR] 无法比较 %s: %v\n",
+	"  Summary":                                                             "  摘要",
+	"  New:           %d\n":                                                 "  新增:          %d\n",
+	"  Changed:       %d\n":                                                 "  已更改:        %d\n",
+	"  Stale:         %d\n":                                                 "  过期:          %d\n",
+	"  Unchanged:     %d\n":                                                 "  未更改:        %d\n",
+	"  .meta kept:    %d\n":                                                 "  保留的 .meta:  %d\n",
+	"  To copy:       %s\n":                                                 "  待复制:        %s\n",
+	"\n[OK] StreamingAssets is up to date.":                                 "\n[OK] StreamingAssets 已是最新。",
+	"\n[Dry Run] Nothing was copied or deleted.":                            "\n[Dry Run] 未复制或删除任何文件。",
+	"\nApply %d change(s)? (y/N): ":                                         "\n是否应用 %d 项更改？(y/N): ",
+	"Operation cancelled.":                                                  "操作已取消。",
+	"[ERROR] %s: %v\n":                                                      "[ERROR] %s: %v\n",
+	"[WARNING] Cannot write the hash cache: %v\n":                           "[WARNING] 无法写入哈希缓存: %v\n",
+	"\n[OK] Copied %d and deleted %d file(s) in %.1fs\n":                    "\n[OK] 已复制 %d 个、删除 %d 个文件，用时 %.1f 秒\n",
+	"[ERROR] %d file(s) failed.\n":                                          "[ERROR] %d 个文件失败。\n",
+	"[TIP] Unity picks the changes up on the next refresh; unchanged files are not reimported.": "[TIP] Unity 会在下次刷新时识别这些更改；未更改的文件不会重新导入。",
+	"Progress: %d/%d (%.0f%%)\n": "进度: %d/%d (%.0f%%)\n",
+
+	"[WARNING] %s checkout failed for %s: %v %s\n": "[WARNING] %s 签出失败 %s: %v %s\n",
+	"[VCS] Checked out (%s): %s\n":                 "[VCS] 已签出 (%s): %s\n",
+	"\nPress Enter to continue...":                 "\n按回车键继续...",
+
+	"[WARNING] Removing a stale %s (%s)\n":                      "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n": "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode, dryRun, keepStale, rehash, force bool
+	var rootFlag, excludeFlag, vcsMode string
+	var jobs int
+
+	flag.StringVar(&rootFlag, "root", defaultStreamingRoot, "Folder the destinations are relative to (must be inside Assets/)")
+	flag.StringVar(&excludeFlag, "exclude", "", "Comma-separated globs of source files to leave out (e.g. \"*.manifest,**/Debug/**\")")
+	flag.BoolVar(&keepStale, "keep-stale", false, "Do not delete destination files that are missing from the source")
+	flag.IntVar(&jobs, "jobs", runtime.NumCPU(), "Files hashed and copied in parallel")
+	flag.BoolVar(&rehash, "rehash", false, "Ignore the hash cache and read every file again")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode: apply without asking")
+	flag.BoolVar(&dryRun, "dry-run", false, "List the copies and deletes without touching disk")
+	flag.BoolVar(&force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
+	flag.StringVar(&vcsMode, "vcs", "auto", "Checkout before writing: auto, none, p4, plastic")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the sources ("Build/Bundles -dry-run")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("streaming_assets_sync")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+
+	if len(args) == 0 {
+		fmt.Println(tr("Usage: streaming_assets_sync [flags] <source>[=<folder>] ..."))
+		recordError("expected at least one source folder")
+		exit(2)
+	}
+	excludes, err := compileGlobs(excludeFlag)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(2)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	activeVCS, err = detectVCS(basePath, vcsMode)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(1)
+	}
+	if !dryRun {
+		if err := acquireProjectLock(basePath, "streaming_assets_sync", "sync", force); err != nil {
+			fmt.Printf(tr("[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exit(1)
+		}
+		defer releaseProjectLock()
+	}
+
+	var mappings []*syncMapping
+	var destRoots []string
+	for _, arg := range args {
+		m, err := parseMapping(basePath, rootFlag, arg)
+		if err != nil {
+			fmt.Printf(tr("[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exit(2)
+		}
+		// Nested destinations would delete each other's files as stale
+		for _, other := range mappings {
+			if isUnder(m.dest, other.dest) || isUnder(other.dest, m.dest) {
+				fmt.Printf(tr("[ERROR] Destinations overlap: %s, %s\n"), other.label, m.label)
+				recordError("destinations overlap: %s, %s", other.label, m.label)
+				exit(2)
+			}
+		}
+		mappings = append(mappings, m)
+		destRoots = append(destRoots, m.source, m.dest)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  STREAMINGASSETS SYNC"))
+	printRule("=============================================")
+
+	cache := loadHashCache(filepath.Join(basePath, filepath.FromSlash(hashCacheFile)), !rehash)
+	plan := &syncPlan{}
+	var copyBytes int64
+	counts := make(map[string]int)
+	for _, m := range mappings {
+		fmt.Printf("\n%s\n", m.label)
+		first := len(plan.ops)
+		if err := planMapping(m, excludes, keepStale, cache, jobs, plan); err != nil {
+			fmt.Printf(tr("[ERROR] Cannot compare %s: %v\n"), m.label, err)
+			recordError("cannot compare %s: %v", m.label, err)
+			exit(1)
+		}
+		for _, op := range plan.ops[first:] {
+			mark := map[string]string{opAdd: "[+]", opUpdate: "[~]", opDelete: "[-]"}[op.kind]
+			fmt.Printf("  %s %s (%s)\n", mark, op.rel, formatSize(op.size))
+			counts[op.kind]++
+			if op.kind != opDelete {
+				copyBytes += op.size
+			}
+		}
+	}
+
+	printRule("\n=============================================")
+	fmt.Println(tr("  Summary"))
+	printRule("=============================================")
+	fmt.Printf(tr("  New:           %d\n"), counts[opAdd])
+	fmt.Printf(tr("  Changed:       %d\n"), counts[opUpdate])
+	fmt.Printf(tr("  Stale:         %d\n"), counts[opDelete])
+	fmt.Printf(tr("  Unchanged:     %d\n"), plan.unchanged)
+	fmt.Printf(tr("  .meta kept:    %d\n"), plan.metaKept)
+	fmt.Printf(tr("  To copy:       %s\n"), formatSize(copyBytes))
+
+	if len(plan.ops) == 0 {
+		if !dryRun {
+			cache.save(destRoots)
+		}
+		fmt.Println(tr("\n[OK] StreamingAssets is up to date."))
+		exit(0)
+	}
+	targetOf := func(op *syncOp) string {
+		rel, _ := filepath.Rel(basePath, filepath.Join(op.mapping.dest, filepath.FromSlash(op.rel)))
+		return filepath.ToSlash(rel)
+	}
+	if dryRun {
+		for _, op := range plan.ops {
+			recordAction(op.kind, targetOf(op), "planned", formatSize(op.size), 0)
+		}
+		fmt.Println(tr("\n[Dry Run] Nothing was copied or deleted."))
+		exit(0)
+	}
+	if !ciMode {
+		fmt.Printf(tr("\nApply %d change(s)? (y/N): "), len(plan.ops))
+		confirm, _ := stdinReader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+			fmt.Println(tr("Operation cancelled."))
+			exit(0)
+		}
+	}
+
+	fmt.Println()
+	start := time.Now()
+	copied, deleted, failed := applyPlan(plan, cache, jobs)
+	for _, op := range plan.ops {
+		target := targetOf(op)
+		if op.err != nil {
+			fmt.Printf(tr("[ERROR] %s: %v\n"), target, op.err)
+			recordError("%s: %v", target, op.err)
+			recordAction(op.kind, target, "failed", op.err.Error(), 0)
+		} else {
+			recordAction(op.kind, target, "ok", formatSize(op.size), 0)
+		}
+	}
+	if err := cache.save(destRoots); err != nil {
+		fmt.Printf(tr("[WARNING] Cannot write the hash cache: %v\n"), err)
+	}
+
+	fmt.Printf(tr("\n[OK] Copied %d and deleted %d file(s) in %.1fs\n"), copied, deleted, time.Since(start).Seconds())
+	if failed > 0 {
+		fmt.Printf(tr("[ERROR] %d file(s) failed.\n"), failed)
+		exit(1)
+	}
+	fmt.Println(tr("[TIP] Unity picks the changes up on the next refresh; unchanged files are not reimported."))
+	exit(0)
+}
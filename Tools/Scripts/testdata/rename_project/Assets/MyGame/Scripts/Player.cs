@@ -0,0 +1,8 @@
+using UnityEngine;
+
+namespace MyGame.Gameplay
+{
+    public class Player : MonoBehaviour
+    {
+    }
+}
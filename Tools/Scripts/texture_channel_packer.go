@@ -9,18 +9,25 @@
 package main
 
 import (
+	_ "image/jpeg"
 	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"image"
 	"image/draw"
-	_ "image/jpeg"
 	"image/png"
+	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -328,9 +335,9 @@ func detectOutputSize(sources [4]channelSource) (int, int, error) {
 // ============================================================
 
 func printPreview(sources [4]channelSource, outW, outH int, outPath string, labels [4]string) {
-	fmt.Println("\n=============================================")
-	fmt.Println("  CHANNEL PACK PREVIEW")
-	fmt.Println("=============================================")
+	printRule("\n=============================================")
+	fmt.Println(tr("  CHANNEL PACK PREVIEW"))
+	printRule("=============================================")
 	for ci := 0; ci < 4; ci++ {
 		src := sources[ci]
 		label := ""
@@ -343,7 +350,7 @@ func printPreview(sources [4]channelSource, outW, outH int, outPath string, labe
 			fmt.Printf("  %s%s ← %s : %s\n", channelLetters[ci], label, filepath.Base(src.FilePath), src.Channel)
 		}
 	}
-	fmt.Printf("\n  Output: %s (%dx%d, PNG)\n", outPath, outW, outH)
+	fmt.Printf(tr("\n  Output: %s (%dx%d, PNG)\n"), outPath, outW, outH)
 }
 
 // ============================================================
@@ -351,12 +358,14 @@ func printPreview(sources [4]channelSource, outW, outH int, outPath string, labe
 // ============================================================
 
 func executePack(sources [4]channelSource, outW, outH int, outPath string) {
-	fmt.Println("\nPacking channels...")
+	fmt.Println(tr("\nPacking channels..."))
 	startTime := time.Now()
 
 	out, log, err := packChannels(outW, outH, sources)
 	if err != nil {
 		fmt.Printf("\n[ERROR] %v\n", err)
+		recordAction("pack", outPath, "failed", err.Error(), time.Since(startTime))
+		recordError("%v", err)
 		return
 	}
 
@@ -364,48 +373,40 @@ func executePack(sources [4]channelSource, outW, outH int, outPath string) {
 		fmt.Println(msg)
 	}
 
-	// Encode output PNG with buffered writer
-	fmt.Print("\nEncoding PNG...")
-	f, err := os.Create(outPath)
-	if err != nil {
-		fmt.Printf("\n[ERROR] Cannot create output file: %v\n", err)
-		return
-	}
-
-	writer := bufio.NewWriterSize(f, 256*1024)
+	// Encode in memory, then write in one go so a failed encode leaves no partial file
+	fmt.Print(tr("\nEncoding PNG..."))
+	var buf bytes.Buffer
 	encoder := &png.Encoder{CompressionLevel: png.BestSpeed}
-	if err := encoder.Encode(writer, out); err != nil {
-		f.Close()
-		os.Remove(outPath) // clean up partial file
-		fmt.Printf("\n[ERROR] PNG encode failed: %v\n", err)
+	if err := encoder.Encode(&buf, out); err != nil {
+		fmt.Printf(tr("\n[ERROR] PNG encode failed: %v\n"), err)
+		recordAction("pack", outPath, "failed", err.Error(), time.Since(startTime))
+		recordError("PNG encode failed: %v", err)
 		return
 	}
-	if err := writer.Flush(); err != nil {
-		f.Close()
-		os.Remove(outPath)
-		fmt.Printf("\n[ERROR] Write failed: %v\n", err)
-		return
-	}
-	f.Close()
 	out = nil
 	runtime.GC()
 
-	// Report
-	info, _ := os.Stat(outPath)
-	var outSize int64
-	if info != nil {
-		outSize = info.Size()
+	if err := fsys.WriteFile(outPath, buf.Bytes(), 0644); err != nil {
+		fmt.Printf(tr("\n[ERROR] Cannot create output file: %v\n"), err)
+		recordAction("pack", outPath, "failed", err.Error(), time.Since(startTime))
+		recordError("cannot create output file: %v", err)
+		return
 	}
-	duration := time.Since(startTime)
 
-	fmt.Println(" done")
-	fmt.Println("\n===========================================")
-	fmt.Println("  PACK COMPLETE")
-	fmt.Println("===========================================")
-	fmt.Printf("  Output:     %s\n", outPath)
-	fmt.Printf("  Resolution: %dx%d\n", outW, outH)
-	fmt.Printf("  File size:  %s\n", formatSize(outSize))
-	fmt.Printf("  Time:       %s\n", duration.Round(time.Millisecond))
+	// Report
+	outSize := int64(buf.Len())
+	duration := time.Since(startTime)
+	recordAction("pack", outPath, "ok", fmt.Sprintf("%dx%d", outW, outH), duration)
+	recordArtifact(outPath)
+
+	fmt.Println(tr(" done"))
+	printRule("\n===========================================")
+	fmt.Println(tr("  PACK COMPLETE"))
+	printRule("===========================================")
+	fmt.Printf(tr("  Output:     %s\n"), outPath)
+	fmt.Printf(tr("  Resolution: %dx%d\n"), outW, outH)
+	fmt.Printf(tr("  File size:  %s\n"), formatSize(outSize))
+	fmt.Printf(tr("  Time:       %s\n"), duration.Round(time.Millisecond))
 }
 
 // ============================================================
@@ -413,16 +414,16 @@ func executePack(sources [4]channelSource, outW, outH int, outPath string) {
 // ============================================================
 
 func runInteractive() {
-	fmt.Println("==============================================")
-	fmt.Println("  Texture Channel Packer")
-	fmt.Println("  Pack images into RGBA channels of a texture")
-	fmt.Println("==============================================")
-	fmt.Println("\nSupported input formats: PNG, JPEG")
-	fmt.Println("Output format: PNG (lossless)")
+	printRule("==============================================")
+	fmt.Println(tr("  Texture Channel Packer"))
+	fmt.Println(tr("  Pack images into RGBA channels of a texture"))
+	printRule("==============================================")
+	fmt.Println(tr("\nSupported input formats: PNG, JPEG"))
+	fmt.Println(tr("Output format: PNG (lossless)"))
 
 	// Select mode
-	fmt.Println("\nSelect packing mode:")
-	fmt.Println("  [1] Custom channel packing")
+	fmt.Println(tr("\nSelect packing mode:"))
+	fmt.Println(tr("  [1] Custom channel packing"))
 	for i, p := range presets {
 		fmt.Printf("  [%d] %s (%s)\n", i+2, p.description, formatPresetLabels(p.labels))
 	}
@@ -438,25 +439,25 @@ func runInteractive() {
 	if mode > 1 {
 		p := presets[mode-2]
 		labels = p.labels
-		fmt.Printf("\nUsing preset: %s\n", p.description)
+		fmt.Printf(tr("\nUsing preset: %s\n"), p.description)
 	}
 
 	// Collect channel sources
 	var sources [4]channelSource
-	fmt.Println("\nFor each channel, drag an image file or type its path.")
-	fmt.Println("Type a number (0-255) for a constant fill value.")
-	fmt.Println("Press Enter to use the default fill value.\n")
+	fmt.Println(tr("\nFor each channel, drag an image file or type its path."))
+	fmt.Println(tr("Type a number (0-255) for a constant fill value."))
+	fmt.Println(tr("Press Enter to use the default fill value.\n"))
 
 	for ci := 0; ci < 4; ci++ {
 		fillDefault := defaultFills[ci]
 		label := labels[ci]
 		if label != channelLabels[ci] {
-			fmt.Printf("--- %s Channel (%s) --- [default fill: %d]\n", channelLabels[ci], label, fillDefault)
+			fmt.Printf(tr("--- %s Channel (%s) --- [default fill: %d]\n"), channelLabels[ci], label, fillDefault)
 		} else {
-			fmt.Printf("--- %s Channel --- [default fill: %d]\n", channelLabels[ci], fillDefault)
+			fmt.Printf(tr("--- %s Channel --- [default fill: %d]\n"), channelLabels[ci], fillDefault)
 		}
 
-		fmt.Print("Source: ")
+		fmt.Print(tr("Source: "))
 		input, _ := stdinReader.ReadString('\n')
 		input = strings.TrimSpace(input)
 
@@ -476,14 +477,14 @@ func runInteractive() {
 		// Treat as file path
 		filePath := normalizePath(input)
 		if _, err := os.Stat(filePath); err != nil {
-			fmt.Printf("  [WARNING] File not found: %s\n", filePath)
-			fmt.Printf("  Using fill(%d) instead.\n\n", fillDefault)
+			fmt.Printf(tr("  [WARNING] File not found: %s\n"), filePath)
+			fmt.Printf(tr("  Using fill(%d) instead.\n\n"), fillDefault)
 			sources[ci] = channelSource{Fill: fillDefault}
 			continue
 		}
 
 		// Ask which channel to extract
-		fmt.Print("  Extract channel [R/G/B/A/Gray] (default: Gray): ")
+		fmt.Print(tr("  Extract channel [R/G/B/A/Gray] (default: Gray): "))
 		chStr, _ := stdinReader.ReadString('\n')
 		chStr = strings.TrimSpace(chStr)
 		ch := "Gray"
@@ -496,7 +497,7 @@ func runInteractive() {
 	}
 
 	// Output path
-	fmt.Print("Output file path (default: packed.png): ")
+	fmt.Print(tr("Output file path (default: packed.png): "))
 	outPath, _ := stdinReader.ReadString('\n')
 	outPath = strings.TrimSpace(outPath)
 	if outPath == "" {
@@ -511,7 +512,7 @@ func runInteractive() {
 	outW, outH, err := detectOutputSize(sources)
 	if err != nil {
 		fmt.Printf("\n[ERROR] %v\n", err)
-		fmt.Println("At least one channel must have a source image.")
+		fmt.Println(tr("At least one channel must have a source image."))
 		waitForKeyPress()
 		return
 	}
@@ -520,11 +521,11 @@ func runInteractive() {
 	printPreview(sources, outW, outH, outPath, labels)
 
 	// Confirm
-	fmt.Print("\nProceed? (Y/n): ")
+	fmt.Print(tr("\nProceed? (Y/n): "))
 	confirm, _ := stdinReader.ReadString('\n')
 	confirm = strings.TrimSpace(strings.ToLower(confirm))
 	if confirm == "n" || confirm == "no" {
-		fmt.Println("Operation cancelled.")
+		fmt.Println(tr("Operation cancelled."))
 		waitForKeyPress()
 		return
 	}
@@ -561,10 +562,686 @@ func formatPresetLabels(labels [4]string) string {
 }
 
 func waitForKeyPress() {
-	fmt.Println("\nPress Enter to exit...")
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to exit..."))
 	stdinReader.ReadBytes('\n')
 }
 
+// ============================================================
+// Filesystem
+// ============================================================
+
+// fileSystem is every filesystem call that can change the project. The real
+// run uses osFS; -dry-run swaps in an overlayFS so the same code path runs
+// and the overlay reports what it would have changed.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Walk(root string, fn filepath.WalkFunc) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Chmod(name string, mode os.FileMode) error
+}
+
+var fsys fileSystem = osFS{}
+
+// isDryRun reports whether changes are going to the in-memory overlay
+func isDryRun() bool {
+	_, ok := fsys.(*overlayFS)
+	return ok
+}
+
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)         { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) Walk(root string, fn filepath.WalkFunc) error { return filepath.Walk(root, fn) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// fsChange is one change the overlay absorbed instead of the disk
+type fsChange struct {
+	op     string // "write", "create", "mkdir", "rename", "delete"
+	path   string
+	detail string
+}
+
+// overlayFS reads through to the disk and keeps every write, rename and
+// delete in memory. Later reads see those changes, so multi-step operations
+// (rename a folder, then rewrite files inside it) behave as they would for real.
+type overlayFS struct {
+	mu      sync.Mutex
+	files   map[string][]byte // files written during the run
+	dirs    map[string]bool   // directories created during the run
+	moved   map[string]string // renamed path -> where its content is on disk
+	removed map[string]bool   // deleted paths; hides everything below them
+	changes []fsChange
+}
+
+func newOverlayFS() *overlayFS {
+	return &overlayFS{
+		files:   map[string][]byte{},
+		dirs:    map[string]bool{},
+		moved:   map[string]string{},
+		removed: map[string]bool{},
+	}
+}
+
+func overlayKey(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// isUnder reports whether p is base or inside it
+func isUnder(p, base string) bool {
+	return p == base || strings.HasPrefix(p, strings.TrimSuffix(base, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// locate finds where the content of p lives: in memory (data / memDir) or
+// on disk (disk, after following renames). ok is false if p was deleted.
+func (o *overlayFS) locate(p string) (data []byte, memFile, memDir bool, disk string, ok bool) {
+	if d, found := o.files[p]; found {
+		return d, true, false, "", true
+	}
+	if o.dirs[p] {
+		return nil, false, true, "", true
+	}
+	for q := p; ; {
+		if src, found := o.moved[q]; found {
+			rel, _ := filepath.Rel(q, p)
+			return nil, false, false, filepath.Join(src, rel), true
+		}
+		if o.removed[q] {
+			return nil, false, false, "", false
+		}
+		parent := filepath.Dir(q)
+		if parent == q {
+			break
+		}
+		q = parent
+	}
+	return nil, false, false, p, true
+}
+
+func (o *overlayFS) stat(p string) (os.FileInfo, error) {
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	case memFile:
+		return memFileInfo{name: filepath.Base(p), size: int64(len(data))}, nil
+	case memDir:
+		return memFileInfo{name: filepath.Base(p), dir: true}, nil
+	}
+	info, err := os.Stat(disk)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	if disk != p {
+		return renamedFileInfo{info, filepath.Base(p)}, nil
+	}
+	return info, nil
+}
+
+func (o *overlayFS) Stat(name string) (os.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stat(overlayKey(name))
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case memDir:
+		return nil, &os.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	case memFile:
+		return append([]byte(nil), data...), nil
+	}
+	return os.ReadFile(disk)
+}
+
+func (o *overlayFS) readDir(p string) ([]os.DirEntry, error) {
+	_, memFile, memDir, disk, ok := o.locate(p)
+	if !ok || memFile {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+	names := map[string]bool{}
+	if !memDir {
+		entries, err := os.ReadDir(disk)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			names[e.Name()] = true
+		}
+	}
+	for k := range o.dirs {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.files {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.moved {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	var result []os.DirEntry
+	for name := range names {
+		if info, err := o.stat(filepath.Join(p, name)); err == nil {
+			result = append(result, fs.FileInfoToDirEntry(info))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (o *overlayFS) ReadDir(name string) ([]os.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readDir(overlayKey(name))
+}
+
+// Walk follows filepath.Walk: lexical order, SkipDir and SkipAll honored.
+// The lock is released while fn runs so the callback can change the overlay.
+func (o *overlayFS) Walk(root string, fn filepath.WalkFunc) error {
+	info, err := o.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = o.walk(root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (o *overlayFS) walk(path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	entries, err := o.ReadDir(path)
+	if err1 := fn(path, info, err); err != nil || err1 != nil {
+		return err1
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		childInfo, err := o.Stat(child)
+		if err != nil {
+			if err := fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := o.walk(child, childInfo, fn); err != nil {
+			if err == filepath.SkipDir && childInfo.IsDir() {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *overlayFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	op := "create"
+	if info, err := o.stat(p); err == nil {
+		if info.IsDir() {
+			return &os.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+		}
+		op = "write"
+	}
+	if info, err := o.stat(filepath.Dir(p)); err != nil || !info.IsDir() {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	o.files[p] = append([]byte(nil), data...)
+	o.changes = append(o.changes, fsChange{op, p, fmt.Sprintf("%d bytes", len(data))})
+	return nil
+}
+
+func (o *overlayFS) MkdirAll(path string, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	created := false
+	for q := p; ; q = filepath.Dir(q) {
+		if info, err := o.stat(q); err == nil {
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: q, Err: errors.New("not a directory")}
+			}
+			break
+		}
+		o.dirs[q] = true
+		created = true
+		if filepath.Dir(q) == q {
+			break
+		}
+	}
+	if created {
+		o.changes = append(o.changes, fsChange{"mkdir", p, ""})
+	}
+	return nil
+}
+
+// shift moves every overlay entry under from to the same place under to
+func (o *overlayFS) shift(from, to string) {
+	for k, v := range o.files {
+		if isUnder(k, from) {
+			delete(o.files, k)
+			o.files[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, from) {
+			delete(o.dirs, k)
+			o.dirs[to+strings.TrimPrefix(k, from)] = true
+		}
+	}
+	for k, v := range o.moved {
+		if isUnder(k, from) {
+			delete(o.moved, k)
+			o.moved[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+}
+
+func (o *overlayFS) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	from, to := overlayKey(oldpath), overlayKey(newpath)
+	_, _, _, disk, ok := o.locate(from)
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if from == to {
+		return nil
+	}
+	if info, err := o.stat(filepath.Dir(to)); err != nil || !info.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	o.drop(to)
+	o.shift(from, to)
+	if disk != "" {
+		if _, err := os.Stat(disk); err == nil {
+			o.moved[to] = disk
+		}
+	}
+	o.removed[from] = true
+	o.changes = append(o.changes, fsChange{"rename", from, to})
+	return nil
+}
+
+// drop forgets p and everything below it and hides it from the disk
+func (o *overlayFS) drop(p string) {
+	for k := range o.files {
+		if isUnder(k, p) {
+			delete(o.files, k)
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, p) {
+			delete(o.dirs, k)
+		}
+	}
+	for k := range o.moved {
+		if isUnder(k, p) {
+			delete(o.moved, k)
+		}
+	}
+	o.removed[p] = true
+}
+
+// treeSize is the byte size of p as the overlay currently sees it
+func (o *overlayFS) treeSize(p string) int64 {
+	info, err := o.stat(p)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var size int64
+	entries, _ := o.readDir(p)
+	for _, e := range entries {
+		size += o.treeSize(filepath.Join(p, e.Name()))
+	}
+	return size
+}
+
+func (o *overlayFS) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	info, err := o.stat(p)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	if info.IsDir() {
+		if entries, _ := o.readDir(p); len(entries) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", info.Size())})
+	return nil
+}
+
+func (o *overlayFS) RemoveAll(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	if _, err := o.stat(p); err != nil {
+		return nil
+	}
+	size := o.treeSize(p)
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", size)})
+	return nil
+}
+
+func (o *overlayFS) Chmod(name string, mode os.FileMode) error {
+	if _, err := o.Stat(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+// printDryRunReport lists what the overlay absorbed, relative to base
+func (o *overlayFS) printDryRunReport(base string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if abs, err := filepath.Abs(base); err == nil {
+		base = abs
+	}
+	if len(o.changes) == 0 {
+		fmt.Println(tr("\n[Dry Run] No changes would be made."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d change(s) would be made; nothing was written:\n"), len(o.changes))
+	rel := func(p string) string {
+		if r, err := filepath.Rel(base, p); err == nil && !strings.HasPrefix(r, "..") {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	for _, c := range o.changes {
+		switch c.op {
+		case "rename":
+			fmt.Printf("  %-7s %s -> %s\n", c.op, rel(c.path), rel(c.detail))
+		case "mkdir":
+			fmt.Printf("  %-7s %s\n", c.op, rel(c.path))
+		default:
+			fmt.Printf("  %-7s %s (%s)\n", c.op, rel(c.path), c.detail)
+		}
+	}
+}
+
+// memFileInfo describes a file or directory that only exists in the overlay
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (m memFileInfo) Name() string       { return m.name }
+func (m memFileInfo) Size() int64        { return m.size }
+func (m memFileInfo) ModTime() time.Time { return time.Time{} }
+func (m memFileInfo) IsDir() bool        { return m.dir }
+func (m memFileInfo) Sys() interface{}   { return nil }
+func (m memFileInfo) Mode() os.FileMode {
+	if m.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// renamedFileInfo is a disk entry seen under the name it was renamed to
+type renamedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (r renamedFileInfo) Name() string { return r.name }
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"  CHANNEL PACK PREVIEW":                    "  通道打包预览",
+	"\n  Output: %s (%dx%d, PNG)\n":             "\n  输出: %s (%dx%d, PNG)\n",
+	"\nPacking channels...":                     "\n正在打包通道...",
+	"\nEncoding PNG...":                         "\n正在编码 PNG...",
+	"\n[ERROR] Cannot create output file: %v\n": "\n[ERROR] 无法创建输出文件: %v\n",
+	"\n[ERROR] PNG encode failed: %v\n":         "\n[ERROR] PNG 编码失败: %v\n",
+	" done":                                     " 完成",
+	"  PACK COMPLETE":                           "  打包完成",
+	"  Output:     %s\n":                        "  输出:       %s\n",
+	"  Resolution: %dx%d\n":                     "  分辨率:     %dx%d\n",
+	"  File size:  %s\n":                        "  文件大小:   %s\n",
+	"  Time:       %s\n":                        "  耗时:       %s\n",
+
+	"  Texture Channel Packer":                                 "  纹理通道打包",
+	"  Pack images into RGBA channels of a texture":            "  将多张图片打包到一张纹理的 RGBA 通道",
+	"\nSupported input formats: PNG, JPEG":                     "\n支持的输入格式: PNG, JPEG",
+	"Output format: PNG (lossless)":                            "输出格式: PNG (无损)",
+	"\nSelect packing mode:":                                   "\n选择打包模式:",
+	"  [1] Custom channel packing":                             "  [1] 自定义通道打包",
+	"\nUsing preset: %s\n":                                     "\n使用预设: %s\n",
+	"\nFor each channel, drag an image file or type its path.": "\n为每个通道拖入图片文件或输入其路径。",
+	"Type a number (0-255) for a constant fill value.":         "输入数字 (0-255) 作为固定填充值。",
+	"Press Enter to use the default fill value.\n":             "直接按回车使用默认填充值。\n",
+	"--- %s Channel (%s) --- [default fill: %d]\n":             "--- %s 通道 (%s) --- [默认填充: %d]\n",
+	"--- %s Channel --- [default fill: %d]\n":                  "--- %s 通道 --- [默认填充: %d]\n",
+	"Source: ":                                           "来源: ",
+	"  [WARNING] File not found: %s\n":                   "  [WARNING] 文件不存在: %s\n",
+	"  Using fill(%d) instead.\n\n":                      "  改用 fill(%d)。\n\n",
+	"  Extract channel [R/G/B/A/Gray] (default: Gray): ": "  提取通道 [R/G/B/A/Gray] (默认: Gray): ",
+	"Output file path (default: packed.png): ":           "输出文件路径 (默认: packed.png): ",
+	"At least one channel must have a source image.":     "至少需要为一个通道指定来源图片。",
+	"\nProceed? (Y/n): ":                                 "\n是否继续？(Y/n): ",
+	"Operation cancelled.":                               "操作已取消。",
+	"\nPress Enter to exit...":                           "\n按回车键退出...",
+
+	"[ERROR] %s channel: file not found: %s\n":                                         "[ERROR] %s 通道: 文件不存在: %s\n",
+	"[ERROR] Invalid size format. Use WxH (e.g. 2048x2048)":                            "[ERROR] 尺寸格式无效。请使用 WxH (例如 2048x2048)",
+	"[ERROR] Invalid size values. Width and height must be positive integers.":         "[ERROR] 尺寸值无效。宽和高必须是正整数。",
+	"[WARNING] Texture dimensions exceed 16384. This will require significant memory.": "[WARNING] 纹理尺寸超过 16384，将占用大量内存。",
+	"[ERROR] %v\nUse -size WxH to specify output dimensions.\n":                        "[ERROR] %v\n请使用 -size WxH 指定输出尺寸。\n",
+	"\n[Dry Run] No output file written.":                                              "\n[Dry Run] 未写入输出文件。",
+
+	"\n[Dry Run] No changes would be made.":                          "\n[Dry Run] 不会做任何更改。",
+	"\n[Dry Run] %d change(s) would be made; nothing was written:\n": "\n[Dry Run] 将会进行 %d 项更改，未写入任何内容:\n",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	if status == "ok" && isDryRun() {
+		status = "planned"
+	}
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
 // ============================================================
 // Entry Point
 // ============================================================
@@ -591,7 +1268,19 @@ func main() {
 	flag.StringVar(&presetName, "preset", "", "Use preset labels (hdrp-mask, urp-mask)")
 	flag.BoolVar(&ciMode, "ci", false, "CI mode (non-interactive, no prompts)")
 	flag.BoolVar(&dryRun, "dry-run", false, "Preview only, don't write output")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
 	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("texture_channel_packer")
+		ciMode = true
+	}
 
 	// If no channel flags provided, run interactive mode
 	if redSpec == "" && greenSpec == "" && blueSpec == "" && alphaSpec == "" && !ciMode {
@@ -611,8 +1300,9 @@ func main() {
 	for ci := 0; ci < 4; ci++ {
 		if sources[ci].FilePath != "" {
 			if _, err := os.Stat(sources[ci].FilePath); err != nil {
-				fmt.Printf("[ERROR] %s channel: file not found: %s\n", channelLetters[ci], sources[ci].FilePath)
-				os.Exit(1)
+				fmt.Printf(tr("[ERROR] %s channel: file not found: %s\n"), channelLetters[ci], sources[ci].FilePath)
+				recordError("%s channel: file not found: %s", channelLetters[ci], sources[ci].FilePath)
+				exitTool(1)
 			}
 		}
 	}
@@ -622,24 +1312,27 @@ func main() {
 	if sizeSpec != "" {
 		parts := strings.SplitN(strings.ToLower(sizeSpec), "x", 2)
 		if len(parts) != 2 {
-			fmt.Println("[ERROR] Invalid size format. Use WxH (e.g. 2048x2048)")
-			os.Exit(1)
+			fmt.Println(tr("[ERROR] Invalid size format. Use WxH (e.g. 2048x2048)"))
+			recordError("invalid size format: %s", sizeSpec)
+			exitTool(1)
 		}
 		w, err1 := strconv.Atoi(parts[0])
 		h, err2 := strconv.Atoi(parts[1])
 		if err1 != nil || err2 != nil || w <= 0 || h <= 0 {
-			fmt.Println("[ERROR] Invalid size values. Width and height must be positive integers.")
-			os.Exit(1)
+			fmt.Println(tr("[ERROR] Invalid size values. Width and height must be positive integers."))
+			recordError("invalid size values: %s", sizeSpec)
+			exitTool(1)
 		}
 		if w > 16384 || h > 16384 {
-			fmt.Println("[WARNING] Texture dimensions exceed 16384. This will require significant memory.")
+			fmt.Println(tr("[WARNING] Texture dimensions exceed 16384. This will require significant memory."))
 		}
 		outW, outH = w, h
 	} else {
 		w, h, err := detectOutputSize(sources)
 		if err != nil {
-			fmt.Printf("[ERROR] %v\nUse -size WxH to specify output dimensions.\n", err)
-			os.Exit(1)
+			fmt.Printf(tr("[ERROR] %v\nUse -size WxH to specify output dimensions.\n"), err)
+			recordError("%v", err)
+			exitTool(1)
 		}
 		outW, outH = w, h
 	}
@@ -661,21 +1354,24 @@ func main() {
 	// Preview
 	printPreview(sources, outW, outH, outPath, labels)
 
+	// Confirm in non-CI mode; a dry run packs into the overlay
 	if dryRun {
-		fmt.Println("\n[Dry Run] No output file written.")
-		return
-	}
-
-	// Confirm in non-CI mode
-	if !ciMode {
-		fmt.Print("\nProceed? (Y/n): ")
+		fsys = newOverlayFS()
+	} else if !ciMode {
+		fmt.Print(tr("\nProceed? (Y/n): "))
 		confirm, _ := stdinReader.ReadString('\n')
 		confirm = strings.TrimSpace(strings.ToLower(confirm))
 		if confirm == "n" || confirm == "no" {
-			fmt.Println("Operation cancelled.")
+			fmt.Println(tr("Operation cancelled."))
 			return
 		}
 	}
 
 	executePack(sources, outW, outH, outPath)
+
+	if overlay, ok := fsys.(*overlayFS); ok {
+		cwd, _ := os.Getwd()
+		overlay.printDryRunReport(cwd)
+		fmt.Println(tr("\n[Dry Run] No output file written."))
+	}
 }
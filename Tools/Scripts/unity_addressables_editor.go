@@ -0,0 +1,1854 @@
+// Unity Addressables Editor — List and bulk-edit Addressables addresses, labels and groups.
+// Reads the AddressableAssetSettings asset and its group assets as text, resolves
+// every entry to its asset path and edits them by path-based rules instead of
+// clicking through the Groups window: move assets to a group, give them addresses
+// built from their path, add and remove labels, make whole folders addressable, or
+// rename a label across the project. Every change is printed as a diff first and
+// -dry-run stops there.
+//
+// Build: go build unity_addressables_editor.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_addressables_editor                                  # list groups and entries
+//	unity_addressables_editor list -labels level               # only entries with a label
+//	unity_addressables_editor apply -dry-run                   # preview AddressablesRules.json
+//	unity_addressables_editor apply                            # apply the rules
+//	unity_addressables_editor apply -paths "Assets/Game/Levels/**" -group Levels -address "levels/{relname}" -add
+//	unity_addressables_editor rename-label enemy enemies       # rename a label everywhere
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	defaultRulesFile    = "AddressablesRules.json" // in the project root
+	defaultSettingsPath = "Assets/AddressableAssetsData/AddressableAssetSettings.asset"
+)
+
+// Assets the Addressables package refuses as entries (AddressableAssetUtility.IsPathValidForEntry)
+var invalidEntryExtensions = map[string]bool{
+	".cs": true, ".js": true, ".boo": true, ".exe": true, ".dll": true,
+	".meta": true, ".preset": true, ".asmdef": true, ".asmref": true,
+}
+
+// Placeholders of a rule's address template
+var addressPlaceholders = []string{"{path}", "{file}", "{name}", "{ext}", "{folder}", "{dir}", "{rel}", "{relname}"}
+
+var (
+	metaGUIDRegex      = regexp.MustCompile(`(?m)^guid:[ \t]*([0-9a-fA-F]{32})[ \t]*\r?$`)
+	settingsRefRegex   = regexp.MustCompile(`com\.unity\.addressableassets:\s*\{[^}]*guid:\s*([0-9a-fA-F]{32})`)
+	groupAssetRegex    = regexp.MustCompile(`^\s*- \{fileID: -?\d+, guid: ([0-9a-fA-F]{32}), type: \d+\}`)
+	placeholderRegex   = regexp.MustCompile(`\{[^{}]*\}`)
+	invalidLabelRegex  = regexp.MustCompile(`[\[\]]`)
+	hexGUIDRegex       = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+	groupReadOnlyRegex = regexp.MustCompile(`^m_ReadOnly:\s*1\s*$`)
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// EditorInstance represents the structure of Library/EditorInstance.json
+type EditorInstance struct {
+	ProcessID int `json:"process_id"`
+}
+
+// rulesFile is AddressablesRules.json. Rules run in order: a later rule's group
+// and address win, labels add up.
+type rulesFile struct {
+	Rules []*addressRule `json:"rules"`
+}
+
+// addressRule edits the entries whose asset path matches one of Paths
+type addressRule struct {
+	Paths        []string `json:"paths"`             // globs: ** spans folders
+	Exclude      []string `json:"exclude,omitempty"` // globs left alone
+	Group        string   `json:"group,omitempty"`   // existing group to move the entries to
+	Address      string   `json:"address,omitempty"` // template, e.g. "levels/{relname}"
+	Lowercase    bool     `json:"lowercase,omitempty"`
+	Labels       []string `json:"labels,omitempty"`
+	RemoveLabels []string `json:"removeLabels,omitempty"` // "*" removes every label
+	Add          bool     `json:"add,omitempty"`          // also make matching assets addressable
+
+	paths    []*regexp.Regexp
+	prefixes []string // static part of each path glob, the base of {rel}
+	exclude  []*regexp.Regexp
+}
+
+// addrEntry is one item of a group's m_SerializeEntries
+type addrEntry struct {
+	guid     string
+	address  string
+	labels   []string
+	readOnly bool
+	asset    string   // resolved path, "" when the asset is missing
+	raw      []string // serialized lines without the group's indent; nil for new entries
+	group    *addrGroup
+
+	// State before any rule ran, for the diff
+	origGroup   *addrGroup
+	origAddress string
+	origLabels  []string
+	isNew       bool
+}
+
+// addrGroup is one AddressableAssetGroup asset
+type addrGroup struct {
+	name     string
+	guid     string
+	path     string // project-relative
+	readOnly bool   // Built In Data and other groups the package manages
+	lines    []string
+	start    int // entries block is lines[start:end]
+	end      int
+	indent   string
+	crlf     bool
+	sorted   bool // entries were serialized in GUID order (Addressables 1.16+)
+	entries  []*addrEntry
+	dirty    bool
+}
+
+// addrSettings is the AddressableAssetSettings asset
+type addrSettings struct {
+	path         string
+	lines        []string
+	crlf         bool
+	defaultGroup string
+	groups       []*addrGroup
+	labels       []string
+	labelStart   int // label list is lines[labelStart:labelEnd]
+	labelEnd     int
+	labelIndent  string
+	dirty        bool
+}
+
+// assetDB maps the GUIDs of Assets/ and the embedded packages to paths
+type assetDB struct {
+	paths   map[string]string // guid -> path
+	folders map[string]bool
+	assets  []string // files that can become entries, sorted
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// checkUnityRunning checks if Unity Editor is running for this project
+// via Library/EditorInstance.json and a liveness check on the recorded PID.
+func checkUnityRunning(basePath string) (bool, int) {
+	data, err := os.ReadFile(filepath.Join(basePath, "Library", "EditorInstance.json"))
+	if err != nil {
+		return false, 0
+	}
+	var instance EditorInstance
+	if err := json.Unmarshal(data, &instance); err != nil || instance.ProcessID <= 0 {
+		return false, 0
+	}
+	if isProcessRunning(instance.ProcessID) {
+		return true, instance.ProcessID
+	}
+	return false, 0
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// ============================================================
+// Asset Database
+// ============================================================
+
+// scanRoots returns Assets/ and the embedded packages: folders under Packages/
+// with a package.json
+func scanRoots(basePath string) []string {
+	roots := []string{"Assets"}
+	entries, _ := os.ReadDir(filepath.Join(basePath, "Packages"))
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(basePath, "Packages", e.Name(), "package.json")); err == nil {
+			roots = append(roots, "Packages/"+e.Name())
+		}
+	}
+	return roots
+}
+
+// scanAssets reads the GUID of every .meta file. Folders starting with "." or
+// ending with "~" are hidden from Unity and skipped, as Unity skips them.
+func scanAssets(basePath string) *assetDB {
+	db := &assetDB{paths: make(map[string]string), folders: make(map[string]bool)}
+	for _, root := range scanRoots(basePath) {
+		rootPath := filepath.Join(basePath, filepath.FromSlash(root))
+		filepath.Walk(rootPath, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if name := info.Name(); p != rootPath && (strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(p, ".meta") {
+				return nil
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return nil
+			}
+			m := metaGUIDRegex.FindSubmatch(data)
+			if m == nil {
+				return nil
+			}
+			asset := strings.TrimSuffix(p, ".meta")
+			rel, _ := filepath.Rel(basePath, asset)
+			rel = filepath.ToSlash(rel)
+			db.paths[strings.ToLower(string(m[1]))] = rel
+			if st, err := os.Stat(asset); err == nil && st.IsDir() {
+				db.folders[rel] = true
+			} else if err == nil && isValidEntryPath(rel) {
+				db.assets = append(db.assets, rel)
+			}
+			return nil
+		})
+	}
+	sort.Strings(db.assets)
+	return db
+}
+
+// isValidEntryPath follows the package: no scripts or plugins, nothing in an
+// Editor folder and not the Addressables data itself
+func isValidEntryPath(rel string) bool {
+	if invalidEntryExtensions[strings.ToLower(path.Ext(rel))] {
+		return false
+	}
+	for _, seg := range strings.Split(path.Dir(rel), "/") {
+		if strings.EqualFold(seg, "Editor") {
+			return false
+		}
+	}
+	return !within(rel, "Assets/AddressableAssetsData")
+}
+
+// within reports whether rel is root or inside it
+func within(rel, root string) bool {
+	return rel == root || strings.HasPrefix(rel, root+"/")
+}
+
+// ============================================================
+// Addressables Settings
+// ============================================================
+
+// readLines splits a text asset into lines; crlf reports Windows line endings
+func readLines(p string) ([]string, bool, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, false, err
+	}
+	if !bytes.HasPrefix(data, []byte("%YAML")) {
+		return nil, false, fmt.Errorf("%s is not serialized as text; set Asset Serialization to Force Text", filepath.Base(p))
+	}
+	crlf := bytes.Contains(data, []byte("\r\n"))
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n"), crlf, nil
+}
+
+// writeLines writes a text asset back with its line endings; checked out first
+func writeLines(basePath, rel string, lines []string, crlf bool) error {
+	p := filepath.Join(basePath, filepath.FromSlash(rel))
+	eol := "\n"
+	if crlf {
+		eol = "\r\n"
+	}
+	activeVCS.checkout(p)
+	info, err := os.Stat(p)
+	if err != nil {
+		return err
+	}
+	tmp := p + ".addrtmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(lines, eol)+eol), info.Mode().Perm()); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// locateSettings finds the settings asset through the config object the package
+// registers in EditorBuildSettings, else at the default location
+func locateSettings(basePath string, db *assetDB) (string, error) {
+	data, _ := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "EditorBuildSettings.asset"))
+	if m := settingsRefRegex.FindSubmatch(data); m != nil {
+		if rel, ok := db.paths[strings.ToLower(string(m[1]))]; ok {
+			return rel, nil
+		}
+	}
+	if _, err := os.Stat(filepath.Join(basePath, filepath.FromSlash(defaultSettingsPath))); err == nil {
+		return defaultSettingsPath, nil
+	}
+	return "", fmt.Errorf("Addressables is not set up in this project (no AddressableAssetSettings asset); create it in Window > Asset Management > Addressables > Groups")
+}
+
+// yamlValue returns the scalar after "key:" on a line, unquoted
+func yamlValue(line, key string) (string, bool) {
+	t := strings.TrimSpace(line)
+	if !strings.HasPrefix(t, key+":") {
+		return "", false
+	}
+	return yamlUnquote(strings.TrimSpace(t[len(key)+1:])), true
+}
+
+func yamlUnquote(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// yamlQuote quotes a scalar the way Unity does when it would not read back plain
+func yamlQuote(s string) string {
+	if s == "" || strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") ||
+		strings.TrimSpace(s) != s || strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+	return s
+}
+
+// yamlList reads the "- item" lines that follow a "key:" line at the same indent.
+// It returns the items and the index after the list.
+func yamlList(lines []string, i int, indent string) ([]string, int) {
+	if strings.HasSuffix(strings.TrimSpace(lines[i]), "[]") {
+		return nil, i + 1
+	}
+	var items []string
+	j := i + 1
+	for ; j < len(lines) && strings.HasPrefix(lines[j], indent+"- "); j++ {
+		items = append(items, yamlUnquote(strings.TrimSpace(lines[j][len(indent)+2:])))
+	}
+	return items, j
+}
+
+// renderList writes "key:" and its items, or "key: []"
+func renderList(indent, key string, items []string) []string {
+	if len(items) == 0 {
+		return []string{indent + key + ": []"}
+	}
+	out := []string{indent + key + ":"}
+	for _, it := range items {
+		out = append(out, indent+"- "+yamlQuote(it))
+	}
+	return out
+}
+
+func leadingSpace(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " "))]
+}
+
+// loadSettings reads the settings asset and every group it lists
+func loadSettings(basePath string, db *assetDB) (*addrSettings, []string, error) {
+	rel, err := locateSettings(basePath, db)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines, crlf, err := readLines(filepath.Join(basePath, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, nil, err
+	}
+	s := &addrSettings{path: rel, lines: lines, crlf: crlf, labelStart: -1}
+	var groupGUIDs []string
+	for i := 0; i < len(lines); i++ {
+		t := strings.TrimSpace(lines[i])
+		switch {
+		case strings.HasPrefix(t, "m_DefaultGroup:"):
+			s.defaultGroup, _ = yamlValue(t, "m_DefaultGroup")
+		case strings.HasPrefix(t, "m_LabelNames:"):
+			s.labelIndent = leadingSpace(lines[i])
+			s.labelStart = i
+			s.labels, s.labelEnd = yamlList(lines, i, s.labelIndent)
+		case t == "m_GroupAssets:":
+			ind := leadingSpace(lines[i])
+			for j := i + 1; j < len(lines) && strings.HasPrefix(lines[j], ind+"- "); j++ {
+				if m := groupAssetRegex.FindStringSubmatch(lines[j]); m != nil {
+					groupGUIDs = append(groupGUIDs, strings.ToLower(m[1]))
+				}
+			}
+		}
+	}
+	if s.labelStart < 0 {
+		return nil, nil, fmt.Errorf("%s has no label table; open the project once with the Addressables package installed", rel)
+	}
+
+	var warnings []string
+	for _, guid := range groupGUIDs {
+		groupPath, ok := db.paths[guid]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("group asset %s listed in %s is missing", guid, path.Base(rel)))
+			continue
+		}
+		g, err := loadGroup(basePath, groupPath, db)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", groupPath, err))
+			continue
+		}
+		g.guid = guid
+		s.groups = append(s.groups, g)
+	}
+	return s, warnings, nil
+}
+
+// loadGroup reads m_SerializeEntries of one group asset
+func loadGroup(basePath, rel string, db *assetDB) (*addrGroup, error) {
+	lines, crlf, err := readLines(filepath.Join(basePath, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil, err
+	}
+	g := &addrGroup{path: rel, lines: lines, crlf: crlf, start: -1}
+	for i := 0; i < len(lines); i++ {
+		t := strings.TrimSpace(lines[i])
+		if v, ok := yamlValue(lines[i], "m_GroupName"); ok && g.name == "" {
+			g.name = v
+		}
+		if !strings.HasPrefix(t, "m_SerializeEntries:") || g.start >= 0 {
+			continue
+		}
+		g.indent = leadingSpace(lines[i])
+		g.start = i
+		j := i + 1
+		if !strings.HasSuffix(t, "[]") {
+			for j < len(lines) && strings.HasPrefix(lines[j], g.indent+"- ") {
+				e := &addrEntry{group: g}
+				e.raw = append(e.raw, lines[j][len(g.indent):])
+				e.guid, _ = yamlValue(strings.TrimPrefix(lines[j][len(g.indent):], "- "), "m_GUID")
+				for j++; j < len(lines) && strings.HasPrefix(lines[j], g.indent+"  "); j++ {
+					e.raw = append(e.raw, lines[j][len(g.indent):])
+				}
+				e.parseFields()
+				g.entries = append(g.entries, e)
+			}
+		}
+		g.end = j
+		i = j - 1
+	}
+	if g.start < 0 {
+		return nil, fmt.Errorf("not an Addressables group (no m_SerializeEntries)")
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(line, g.indent) && groupReadOnlyRegex.MatchString(line[len(g.indent):]) {
+			g.readOnly = true
+		}
+	}
+	g.sorted = sort.SliceIsSorted(g.entries, func(i, j int) bool { return g.entries[i].guid < g.entries[j].guid })
+	for _, e := range g.entries {
+		e.asset = db.paths[strings.ToLower(e.guid)]
+		e.origGroup = g
+		e.origAddress = e.address
+		e.origLabels = append([]string(nil), e.labels...)
+	}
+	return g, nil
+}
+
+// parseFields reads address, labels and read-only state from the raw lines
+func (e *addrEntry) parseFields() {
+	for i := 1; i < len(e.raw); i++ {
+		if v, ok := yamlValue(e.raw[i], "m_Address"); ok {
+			e.address = v
+		}
+		if groupReadOnlyRegex.MatchString(strings.TrimSpace(e.raw[i])) {
+			e.readOnly = true
+		}
+		if strings.HasPrefix(strings.TrimSpace(e.raw[i]), "m_SerializedLabels:") {
+			e.labels, _ = yamlList(e.raw, i, "  ")
+		}
+	}
+	// Built In Data lists the scene list and Resources under pseudo GUIDs
+	if !hexGUIDRegex.MatchString(e.guid) {
+		e.readOnly = true
+	}
+}
+
+// render serializes the entry at the group's indent. Unchanged fields keep
+// their original lines.
+func (e *addrEntry) render(indent string) []string {
+	if e.raw == nil {
+		out := []string{
+			indent + "- m_GUID: " + e.guid,
+			indent + "  m_Address: " + yamlQuote(e.address),
+			indent + "  m_ReadOnly: 0",
+		}
+		out = append(out, renderList(indent+"  ", "m_SerializedLabels", e.labels)...)
+		return append(out, indent+"  FlaggedDuringContentUpdateRestriction: 0")
+	}
+	var out []string
+	for i := 0; i < len(e.raw); i++ {
+		t := strings.TrimSpace(e.raw[i])
+		switch {
+		case strings.HasPrefix(t, "m_Address:") && e.address != e.origAddress:
+			out = append(out, indent+"  m_Address: "+yamlQuote(e.address))
+		case strings.HasPrefix(t, "m_SerializedLabels:") && !sameLabels(e.labels, e.origLabels):
+			out = append(out, renderList(indent+"  ", "m_SerializedLabels", e.labels)...)
+			_, next := yamlList(e.raw, i, "  ")
+			i = next - 1
+		default:
+			out = append(out, indent+e.raw[i])
+		}
+	}
+	return out
+}
+
+// render rebuilds the group asset with its current entries
+func (g *addrGroup) render() []string {
+	out := append([]string(nil), g.lines[:g.start]...)
+	if len(g.entries) == 0 {
+		out = append(out, g.indent+"m_SerializeEntries: []")
+	} else {
+		out = append(out, g.indent+"m_SerializeEntries:")
+		for _, e := range g.entries {
+			out = append(out, e.render(g.indent)...)
+		}
+	}
+	return append(out, g.lines[g.end:]...)
+}
+
+// render rebuilds the settings asset with the current label table
+func (s *addrSettings) render() []string {
+	out := append([]string(nil), s.lines[:s.labelStart]...)
+	out = append(out, renderList(s.labelIndent, "m_LabelNames", s.labels)...)
+	return append(out, s.lines[s.labelEnd:]...)
+}
+
+func (s *addrSettings) findGroup(name string) *addrGroup {
+	for _, g := range s.groups {
+		if g.name == name {
+			return g
+		}
+	}
+	return nil
+}
+
+// addLabel registers a label in the settings' label table
+func (s *addrSettings) addLabel(label string) {
+	for _, l := range s.labels {
+		if l == label {
+			return
+		}
+	}
+	s.labels = append(s.labels, label)
+	s.dirty = true
+}
+
+// allEntries returns the entries of every group in group order
+func (s *addrSettings) allEntries() []*addrEntry {
+	var list []*addrEntry
+	for _, g := range s.groups {
+		list = append(list, g.entries...)
+	}
+	return list
+}
+
+// moveEntry takes the entry out of its group and into g, in GUID order when g
+// keeps its entries sorted
+func moveEntry(e *addrEntry, g *addrGroup) {
+	if e.group == g {
+		return
+	}
+	if from := e.group; from != nil {
+		for i, x := range from.entries {
+			if x == e {
+				from.entries = append(from.entries[:i], from.entries[i+1:]...)
+				break
+			}
+		}
+		from.dirty = true
+	}
+	e.group = g
+	g.dirty = true
+	i := len(g.entries)
+	if g.sorted {
+		i = sort.Search(len(g.entries), func(k int) bool { return g.entries[k].guid > e.guid })
+	}
+	g.entries = append(g.entries, nil)
+	copy(g.entries[i+1:], g.entries[i:])
+	g.entries[i] = e
+}
+
+func sameLabels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Rules
+// ============================================================
+
+// globToRegexp converts a glob to a regexp: ** spans folders, * and ? stay within
+// one path segment. Patterns without a slash match the file name alone.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// globPrefix is the folder part of a glob before its first wildcard:
+// "Assets/Game/Levels/**/*.unity" gives "Assets/Game/Levels/"
+func globPrefix(pattern string) string {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	if !strings.Contains(pattern, "/") {
+		return ""
+	}
+	segs := strings.Split(pattern, "/")
+	n := len(segs) - 1 // the last segment is the file
+	for i, seg := range segs[:n] {
+		if strings.ContainsAny(seg, "*?") {
+			n = i
+			break
+		}
+	}
+	if n == 0 {
+		return ""
+	}
+	return strings.Join(segs[:n], "/") + "/"
+}
+
+// splitList parses a comma-separated flag value
+func splitList(list string) []string {
+	var res []string
+	for _, p := range strings.Split(list, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// compile checks a rule and prepares its globs
+func (r *addressRule) compile(index int) error {
+	where := fmt.Sprintf("rule %d", index+1)
+	if len(r.Paths) == 0 {
+		return fmt.Errorf("%s has no paths", where)
+	}
+	if r.Group == "" && r.Address == "" && len(r.Labels) == 0 && len(r.RemoveLabels) == 0 && !r.Add {
+		return fmt.Errorf("%s changes nothing; give it a group, address, labels, removeLabels or add", where)
+	}
+	for _, p := range r.Paths {
+		re, err := globToRegexp(p)
+		if err != nil {
+			return fmt.Errorf("%s: invalid glob '%s': %v", where, p, err)
+		}
+		r.paths = append(r.paths, re)
+		r.prefixes = append(r.prefixes, globPrefix(p))
+	}
+	for _, p := range r.Exclude {
+		re, err := globToRegexp(p)
+		if err != nil {
+			return fmt.Errorf("%s: invalid glob '%s': %v", where, p, err)
+		}
+		r.exclude = append(r.exclude, re)
+	}
+	for _, ph := range placeholderRegex.FindAllString(r.Address, -1) {
+		known := false
+		for _, k := range addressPlaceholders {
+			known = known || ph == k
+		}
+		if !known {
+			return fmt.Errorf("%s: unknown placeholder %s in address (use %s)", where, ph, strings.Join(addressPlaceholders, " "))
+		}
+	}
+	for _, l := range append(append([]string(nil), r.Labels...), r.RemoveLabels...) {
+		if strings.TrimSpace(l) == "" || invalidLabelRegex.MatchString(l) {
+			return fmt.Errorf("%s: invalid label '%s'", where, l)
+		}
+	}
+	return nil
+}
+
+// match returns the {rel} base of the first path glob that takes the asset
+func (r *addressRule) match(asset string) (string, bool) {
+	for _, re := range r.exclude {
+		if re.MatchString(asset) {
+			return "", false
+		}
+	}
+	for i, re := range r.paths {
+		if re.MatchString(asset) {
+			return r.prefixes[i], true
+		}
+	}
+	return "", false
+}
+
+// expandAddress fills in an address template for one asset
+func expandAddress(tmpl, asset, prefix string, lower bool) string {
+	file := path.Base(asset)
+	ext := path.Ext(file)
+	rel := strings.TrimPrefix(asset, prefix)
+	r := strings.NewReplacer(
+		"{path}", asset,
+		"{file}", file,
+		"{name}", strings.TrimSuffix(file, ext),
+		"{ext}", strings.TrimPrefix(ext, "."),
+		"{folder}", path.Base(path.Dir(asset)),
+		"{dir}", path.Dir(asset),
+		"{rel}", rel,
+		"{relname}", strings.TrimSuffix(rel, path.Ext(rel)),
+	)
+	addr := r.Replace(tmpl)
+	if lower {
+		addr = strings.ToLower(addr)
+	}
+	return addr
+}
+
+// loadRules reads a rules file
+func loadRules(p string) ([]*addressRule, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var f rulesFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", filepath.Base(p), err)
+	}
+	if len(f.Rules) == 0 {
+		return nil, fmt.Errorf("%s lists no rules", filepath.Base(p))
+	}
+	return f.Rules, nil
+}
+
+// insideFolderEntry reports whether one of the asset's folders is an entry
+// already; its contents are addressable through it
+func insideFolderEntry(asset string, folders map[string]bool) bool {
+	for d := path.Dir(asset); strings.Contains(d, "/"); d = path.Dir(d) {
+		if folders[d] {
+			return true
+		}
+	}
+	return false
+}
+
+// applyRules runs the rules over the entries, and with add over every asset,
+// and returns the entries that changed
+func applyRules(s *addrSettings, db *assetDB, rules []*addressRule) ([]*addrEntry, error) {
+	byGUID := make(map[string]*addrEntry)
+	folders := make(map[string]bool)
+	for _, e := range s.allEntries() {
+		byGUID[strings.ToLower(e.guid)] = e
+		if db.folders[e.asset] {
+			folders[e.asset] = true
+		}
+	}
+	guidOf := make(map[string]string, len(db.paths))
+	for guid, p := range db.paths {
+		guidOf[p] = guid
+	}
+	var defaultGroup *addrGroup
+	for _, g := range s.groups {
+		if g.guid == strings.ToLower(s.defaultGroup) {
+			defaultGroup = g
+		}
+	}
+
+	for i, r := range rules {
+		var target *addrGroup
+		if r.Group != "" {
+			if target = s.findGroup(r.Group); target == nil {
+				return nil, fmt.Errorf("rule %d: group '%s' does not exist; create it in the Addressables Groups window", i+1, r.Group)
+			}
+			if target.readOnly {
+				return nil, fmt.Errorf("rule %d: group '%s' is read-only", i+1, r.Group)
+			}
+		}
+
+		if r.Add {
+			for _, asset := range db.assets {
+				guid := guidOf[asset]
+				if byGUID[guid] != nil || insideFolderEntry(asset, folders) {
+					continue
+				}
+				if _, ok := r.match(asset); !ok {
+					continue
+				}
+				g := target
+				if g == nil {
+					g = defaultGroup
+				}
+				if g == nil {
+					return nil, fmt.Errorf("rule %d adds assets but names no group and the settings have no default group", i+1)
+				}
+				e := &addrEntry{guid: guid, address: asset, asset: asset, isNew: true}
+				moveEntry(e, g)
+				byGUID[guid] = e
+			}
+		}
+
+		for _, e := range s.allEntries() {
+			if e.readOnly || e.group.readOnly || e.asset == "" {
+				continue
+			}
+			prefix, ok := r.match(e.asset)
+			if !ok {
+				continue
+			}
+			if target != nil {
+				moveEntry(e, target)
+			}
+			if r.Address != "" {
+				e.address = expandAddress(r.Address, e.asset, prefix, r.Lowercase)
+			}
+			for _, l := range r.RemoveLabels {
+				kept := e.labels[:0:0]
+				for _, x := range e.labels {
+					if l != "*" && x != l {
+						kept = append(kept, x)
+					}
+				}
+				e.labels = kept
+			}
+			for _, l := range r.Labels {
+				if !containsString(e.labels, l) {
+					e.labels = append(e.labels, l)
+				}
+				s.addLabel(l)
+			}
+		}
+	}
+	return changedEntries(s), nil
+}
+
+// renameLabel replaces a label on every entry and in the label table
+func renameLabel(s *addrSettings, from, to string) ([]*addrEntry, error) {
+	if !containsString(s.labels, from) {
+		found := false
+		for _, e := range s.allEntries() {
+			found = found || containsString(e.labels, from)
+		}
+		if !found {
+			return nil, fmt.Errorf("label '%s' is not used in this project", from)
+		}
+	}
+	var labels []string
+	for _, l := range s.labels {
+		if l == from {
+			l = to
+		}
+		if !containsString(labels, l) {
+			labels = append(labels, l)
+		}
+	}
+	if !containsString(labels, to) {
+		labels = append(labels, to)
+	}
+	s.labels = labels
+	s.dirty = true
+	for _, e := range s.allEntries() {
+		if !containsString(e.labels, from) {
+			continue
+		}
+		var next []string
+		for _, l := range e.labels {
+			if l == from {
+				l = to
+			}
+			if !containsString(next, l) {
+				next = append(next, l)
+			}
+		}
+		e.labels = next
+	}
+	return changedEntries(s), nil
+}
+
+// changedEntries lists the entries that differ from the files, in path order,
+// and marks their groups for writing
+func changedEntries(s *addrSettings) []*addrEntry {
+	var list []*addrEntry
+	for _, e := range s.allEntries() {
+		if e.isNew || e.group != e.origGroup || e.address != e.origAddress || !sameLabels(e.labels, e.origLabels) {
+			e.group.dirty = true
+			list = append(list, e)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].asset < list[j].asset })
+	return list
+}
+
+// duplicateAddresses lists addresses that now load more than one asset and
+// that one of the changes introduced
+func duplicateAddresses(s *addrSettings, changed []*addrEntry) map[string][]string {
+	byAddress := make(map[string][]string)
+	for _, e := range s.allEntries() {
+		byAddress[e.address] = append(byAddress[e.address], e.asset)
+	}
+	dups := make(map[string][]string)
+	for _, e := range changed {
+		if e.isNew || e.address != e.origAddress {
+			if len(byAddress[e.address]) > 1 {
+				dups[e.address] = byAddress[e.address]
+			}
+		}
+	}
+	return dups
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+// printEntries lists the groups and the entries that pass the filters
+func printEntries(s *addrSettings, groupFilter string, labelFilter []string, pathFilter []*regexp.Regexp) int {
+	shown := 0
+	missing := 0
+	for _, g := range s.groups {
+		if groupFilter != "" && g.name != groupFilter {
+			continue
+		}
+		var list []*addrEntry
+		for _, e := range g.entries {
+			if len(labelFilter) > 0 {
+				hit := false
+				for _, l := range labelFilter {
+					hit = hit || containsString(e.labels, l)
+				}
+				if !hit {
+					continue
+				}
+			}
+			if len(pathFilter) > 0 {
+				hit := false
+				for _, re := range pathFilter {
+					hit = hit || re.MatchString(e.asset)
+				}
+				if !hit {
+					continue
+				}
+			}
+			list = append(list, e)
+		}
+		if len(list) == 0 && (len(labelFilter) > 0 || len(pathFilter) > 0) {
+			continue
+		}
+		flags := ""
+		if g.readOnly {
+			flags = tr(" (read-only)")
+		}
+		fmt.Printf(tr("\n  %s%s: %d entr(ies)\n"), g.name, flags, len(list))
+		for _, e := range list {
+			labels := ""
+			if len(e.labels) > 0 {
+				labels = "  [" + strings.Join(e.labels, ", ") + "]"
+			}
+			asset := e.asset
+			switch {
+			case asset == "" && e.readOnly:
+				asset = e.address
+			case asset == "":
+				asset = tr("(missing asset)")
+				missing++
+			}
+			if asset == e.address {
+				fmt.Printf("    %s%s\n", e.address, labels)
+			} else {
+				fmt.Printf("    %s%s\n        %s\n", e.address, labels, asset)
+			}
+			recordAction("entry", e.address, "ok", fmt.Sprintf("group=%s; asset=%s; labels=%s", g.name, e.asset, strings.Join(e.labels, ",")), 0)
+			shown++
+		}
+	}
+	if missing > 0 {
+		fmt.Printf(tr("\n[WARNING] %d entr(ies) point at assets that no longer exist; Addressables drops them on the next build\n"), missing)
+	}
+	return shown
+}
+
+// labelDiff renders added and removed labels as "+a -b"
+func labelDiff(from, to []string) string {
+	var parts []string
+	for _, l := range to {
+		if !containsString(from, l) {
+			parts = append(parts, "+"+l)
+		}
+	}
+	for _, l := range from {
+		if !containsString(to, l) {
+			parts = append(parts, "-"+l)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// describeChange is the one-line summary of a change for the JSON document
+func describeChange(e *addrEntry) string {
+	var parts []string
+	if e.isNew {
+		parts = append(parts, "new entry in "+e.group.name)
+	} else if e.group != e.origGroup {
+		parts = append(parts, "group "+e.origGroup.name+" -> "+e.group.name)
+	}
+	if !e.isNew && e.address != e.origAddress {
+		parts = append(parts, "address "+e.origAddress+" -> "+e.address)
+	} else if e.isNew {
+		parts = append(parts, "address "+e.address)
+	}
+	if d := labelDiff(e.origLabels, e.labels); d != "" {
+		parts = append(parts, "labels "+d)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// printChanges shows the diff of every changed entry
+func printChanges(changed []*addrEntry) {
+	for _, e := range changed {
+		fmt.Printf("\n  %s\n", e.asset)
+		if e.isNew {
+			fmt.Printf(tr("      + new entry in %s\n"), e.group.name)
+		} else if e.group != e.origGroup {
+			fmt.Printf(tr("      group:   %s -> %s\n"), e.origGroup.name, e.group.name)
+		}
+		if e.isNew {
+			fmt.Printf(tr("      address: %s\n"), e.address)
+		} else if e.address != e.origAddress {
+			fmt.Printf(tr("      address: %s -> %s\n"), e.origAddress, e.address)
+		}
+		if d := labelDiff(e.origLabels, e.labels); d != "" {
+			fmt.Printf(tr("      labels:  %s\n"), d)
+		}
+	}
+}
+
+// dirtyFiles lists the assets that have to be written
+func dirtyFiles(s *addrSettings) []string {
+	var files []string
+	if s.dirty {
+		files = append(files, s.path)
+	}
+	for _, g := range s.groups {
+		if g.dirty {
+			files = append(files, g.path)
+		}
+	}
+	return files
+}
+
+// saveSettings writes the settings and every changed group
+func saveSettings(basePath string, s *addrSettings) map[string]error {
+	errs := make(map[string]error)
+	if s.dirty {
+		if err := writeLines(basePath, s.path, s.render(), s.crlf); err != nil {
+			errs[s.path] = err
+		}
+	}
+	for _, g := range s.groups {
+		if !g.dirty {
+			continue
+		}
+		if err := writeLines(basePath, g.path, g.render(), g.crlf); err != nil {
+			errs[g.path] = err
+		}
+	}
+	return errs
+}
+
+// ============================================================
+// Version Control Checkout
+// ============================================================
+
+// Perforce and Plastic SCM keep files read-only until they are checked out. Writing
+// them directly (even after clearing the flag) leaves changes the server does not know
+// about, so files are opened for edit first. Git and plain folders need nothing.
+const (
+	vcsNone     = "none"
+	vcsPerforce = "p4"
+	vcsPlastic  = "plastic"
+)
+
+// vcsEnvVar overrides detection for every tool (e.g. on build agents)
+const vcsEnvVar = "UNITYSTARTER_VCS"
+
+// vcsClient issues checkouts for files about to be modified
+type vcsClient struct {
+	kind       string
+	source     string // how kind was chosen, for the startup message
+	checkedOut map[string]bool
+}
+
+// activeVCS is set up in main; nil means no checkouts
+var activeVCS *vcsClient
+
+// detectVCS picks the checkout provider. Priority: -vcs flag > UNITYSTARTER_VCS >
+// Unity's VersionControlSettings.asset mode > workspace markers (.plastic, P4CONFIG).
+func detectVCS(projectRoot, override string) (*vcsClient, error) {
+	normalize := func(v string) (string, bool) {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "p4", "perforce":
+			return vcsPerforce, true
+		case "plastic", "plasticscm", "cm", "unityvcs":
+			return vcsPlastic, true
+		case "none", "git", "off":
+			return vcsNone, true
+		}
+		return "", false
+	}
+	newClient := func(kind, source string) *vcsClient {
+		return &vcsClient{kind: kind, source: source, checkedOut: make(map[string]bool)}
+	}
+
+	if override != "" && override != "auto" {
+		kind, ok := normalize(override)
+		if !ok {
+			return nil, fmt.Errorf("unknown -vcs value '%s' (use auto, none, p4 or plastic)", override)
+		}
+		return newClient(kind, "-vcs flag"), nil
+	}
+	if env := os.Getenv(vcsEnvVar); env != "" {
+		if kind, ok := normalize(env); ok {
+			return newClient(kind, vcsEnvVar), nil
+		}
+	}
+
+	// Unity stores the project's integrated VCS in m_Mode ("Perforce", "PlasticSCM", ...)
+	if data, err := os.ReadFile(filepath.Join(projectRoot, "ProjectSettings", "VersionControlSettings.asset")); err == nil {
+		if m := regexp.MustCompile(`(?m)^\s*m_Mode:\s*(.+?)\s*$`).FindSubmatch(data); m != nil {
+			switch strings.ToLower(string(m[1])) {
+			case "perforce":
+				return newClient(vcsPerforce, "VersionControlSettings.asset"), nil
+			case "plasticscm", "plastic scm", "unity version control":
+				return newClient(vcsPlastic, "VersionControlSettings.asset"), nil
+			}
+		}
+	}
+
+	// Workspace markers, searched upward from the project root
+	p4config := os.Getenv("P4CONFIG")
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info != nil {
+				return newClient(vcsNone, "git repository"), nil
+			}
+			if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+				return newClient(vcsPlastic, ".plastic workspace"), nil
+			}
+			if p4config != "" {
+				if _, err := os.Stat(filepath.Join(dir, p4config)); err == nil {
+					return newClient(vcsPerforce, "P4CONFIG"), nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if os.Getenv("P4CLIENT") != "" {
+		return newClient(vcsPerforce, "P4CLIENT"), nil
+	}
+	return newClient(vcsNone, "no Perforce/Plastic workspace found"), nil
+}
+
+// checkout opens an existing file for edit. New files need no checkout, and files
+// outside the depot/workspace only produce a warning: the write still goes ahead.
+func (v *vcsClient) checkout(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || v.checkedOut[abs] {
+		return
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return
+	}
+	v.checkedOut[abs] = true
+
+	var cmd *exec.Cmd
+	switch v.kind {
+	case vcsPerforce:
+		cmd = exec.Command("p4", "edit", abs)
+	case vcsPlastic:
+		cmd = exec.Command("cm", "checkout", abs)
+	default:
+		return
+	}
+	// Run from the file's folder so P4CONFIG / the Plastic workspace is resolved
+	cmd.Dir = filepath.Dir(abs)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		fmt.Printf(tr("[WARNING] %s checkout failed for %s: %v %s\n"), v.kind, filepath.Base(abs), err, strings.TrimSpace(string(out)))
+		return
+	}
+	fmt.Printf(tr("[VCS] Checked out (%s): %s\n"), v.kind, filepath.Base(abs))
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...":                 "\n按回车键继续...",
+	"[WARNING] %s checkout failed for %s: %v %s\n": "[WARNING] %s 签出失败 %s: %v %s\n",
+	"[VCS] Checked out (%s): %s\n":                 "[VCS] 已签出 (%s): %s\n",
+
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                                                  "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.":                            "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                                      "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"Usage: unity_addressables_editor [flags] [list|apply [rules.json]|rename-label <old> <new>]": "用法: unity_addressables_editor [参数] [list|apply [rules.json]|rename-label <旧标签> <新标签>]",
+	"  ADDRESSABLES EDITOR":                       "  ADDRESSABLES 编辑器",
+	"  Project:  %s\n":                            "  项目:     %s\n",
+	"  Settings: %s\n":                            "  设置:     %s\n",
+	"  Groups:   %d, %d entr(ies), %d label(s)\n": "  分组:     %d 个，%d 个条目，%d 个标签\n",
+	"[WARNING] %s\n":                              "[WARNING] %s\n",
+	" (read-only)":                                "（只读）",
+	"\n  %s%s: %d entr(ies)\n":                    "\n  %s%s: %d 个条目\n",
+	"(missing asset)":                             "（资源缺失）",
+	"\n[WARNING] %d entr(ies) point at assets that no longer exist; Addressables drops them on the next build\n": "\n[WARNING] %d 个条目指向已不存在的资源；Addressables 会在下次构建时移除它们\n",
+	"      + new entry in %s\n":                         "      + 新条目，位于 %s\n",
+	"      group:   %s -> %s\n":                         "      分组:   %s -> %s\n",
+	"      address: %s\n":                               "      地址:   %s\n",
+	"      address: %s -> %s\n":                         "      地址:   %s -> %s\n",
+	"      labels:  %s\n":                               "      标签:   %s\n",
+	"\n[OK] Nothing to change.":                         "\n[OK] 无需更改。",
+	"[WARNING] Address '%s' would load %d assets: %s\n": "[WARNING] 地址 '%s' 将对应 %d 个资源: %s\n",
+	"\n  %d entr(ies) change in %d file(s)\n":           "\n  %d 个条目将更改，涉及 %d 个文件\n",
+	"\n[Dry Run] No files were changed.":                "\n[Dry Run] 未修改任何文件。",
+	"\n[ERROR] Unity Editor is running (PID: %d). Close it first; the editor keeps the groups in memory and saves them over these changes.\n": "\n[ERROR] Unity 编辑器正在运行 (PID: %d)。请先关闭；编辑器在内存中保存着分组，保存时会覆盖这些更改。\n",
+	"\nWrite %d file(s)? (y/N): ": "\n写入 %d 个文件？(y/N): ",
+	"Operation cancelled.":        "操作已取消。",
+	"[VCS] Using %s (%s)\n":       "[VCS] 使用 %s (%s)\n",
+	"[FAIL] %s: %v\n":             "[FAIL] %s: %v\n",
+	"[OK]   %s\n":                 "[OK]   %s\n",
+	"\n[TIP] Build the Addressables content again; the built catalog still has the old addresses and labels.": "\n[TIP] 请重新构建 Addressables 内容；已构建的目录中仍是旧的地址和标签。",
+
+	"[WARNING] Removing a stale %s (%s)\n":                      "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n": "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode, dryRun, force, addFlag, lowerFlag bool
+	var vcsMode, pathsFlag, excludeFlag, groupFlag, addressFlag, labelsFlag, removeFlag string
+
+	flag.StringVar(&pathsFlag, "paths", "", "Comma-separated globs of the entries to list, or to edit in one rule instead of the rules file")
+	flag.StringVar(&excludeFlag, "exclude", "", "apply -paths: comma-separated globs to leave alone")
+	flag.StringVar(&groupFlag, "group", "", "list: only this group; apply -paths: move the entries to this group")
+	flag.StringVar(&addressFlag, "address", "", "apply -paths: address template ({path} {file} {name} {ext} {folder} {dir} {rel} {relname})")
+	flag.BoolVar(&lowerFlag, "lowercase", false, "apply -paths: lower-case the addresses")
+	flag.StringVar(&labelsFlag, "labels", "", "list: only entries with one of these labels; apply -paths: labels to add")
+	flag.StringVar(&removeFlag, "remove-labels", "", "apply -paths: labels to remove (\"*\" for all)")
+	flag.BoolVar(&addFlag, "add", false, "apply -paths: also make matching assets addressable")
+	flag.BoolVar(&dryRun, "dry-run", false, "Show the changes without writing")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no confirmation prompts)")
+	flag.BoolVar(&force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
+	flag.StringVar(&vcsMode, "vcs", "auto", "Checkout before writing: auto, none, p4, plastic")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the command ("apply -dry-run")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_addressables_editor")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	command := "list"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	valid := false
+	switch command {
+	case "list":
+		valid = len(args) <= 1
+	case "apply":
+		valid = len(args) <= 2 && !(len(args) == 2 && pathsFlag != "")
+	case "rename-label":
+		valid = len(args) == 3
+	}
+	if !valid {
+		fmt.Println(tr("Usage: unity_addressables_editor [flags] [list|apply [rules.json]|rename-label <old> <new>]"))
+		recordError("unknown command")
+		exit(2)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+
+	// Rules come from -paths or the rules file
+	var rules []*addressRule
+	if command == "apply" {
+		if pathsFlag != "" {
+			rules = []*addressRule{{
+				Paths: splitList(pathsFlag), Exclude: splitList(excludeFlag), Group: groupFlag, Address: addressFlag,
+				Lowercase: lowerFlag, Labels: splitList(labelsFlag), RemoveLabels: splitList(removeFlag), Add: addFlag,
+			}}
+		} else {
+			rulesPath := filepath.Join(basePath, defaultRulesFile)
+			if len(args) == 2 {
+				rulesPath = args[1]
+			}
+			if rules, err = loadRules(rulesPath); err != nil {
+				fail(2, "%v", err)
+			}
+		}
+		for i, r := range rules {
+			if err := r.compile(i); err != nil {
+				fail(2, "%v", err)
+			}
+		}
+	}
+	if command == "rename-label" && (invalidLabelRegex.MatchString(args[2]) || strings.TrimSpace(args[2]) == "") {
+		fail(2, "invalid label '%s'", args[2])
+	}
+	var pathFilter []*regexp.Regexp
+	if command == "list" {
+		for _, p := range splitList(pathsFlag) {
+			re, err := globToRegexp(p)
+			if err != nil {
+				fail(2, "invalid glob '%s': %v", p, err)
+			}
+			pathFilter = append(pathFilter, re)
+		}
+	}
+
+	db := scanAssets(basePath)
+	settings, warnings, err := loadSettings(basePath, db)
+	if err != nil {
+		fail(1, "%v", err)
+	}
+	entries := 0
+	for _, g := range settings.groups {
+		entries += len(g.entries)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  ADDRESSABLES EDITOR"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:  %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Settings: %s\n"), settings.path)
+	fmt.Printf(tr("  Groups:   %d, %d entr(ies), %d label(s)\n"), len(settings.groups), entries, len(settings.labels))
+	for _, w := range warnings {
+		fmt.Printf(tr("[WARNING] %s\n"), w)
+		recordError("%s", w)
+	}
+
+	if command == "list" {
+		if groupFlag != "" && settings.findGroup(groupFlag) == nil {
+			fail(1, "group '%s' does not exist", groupFlag)
+		}
+		printEntries(settings, groupFlag, splitList(labelsFlag), pathFilter)
+		exit(0)
+	}
+
+	var changed []*addrEntry
+	if command == "apply" {
+		changed, err = applyRules(settings, db, rules)
+	} else {
+		changed, err = renameLabel(settings, args[1], args[2])
+	}
+	if err != nil {
+		fail(1, "%v", err)
+	}
+	files := dirtyFiles(settings)
+	if len(files) == 0 {
+		fmt.Println(tr("\n[OK] Nothing to change."))
+		exit(0)
+	}
+	printChanges(changed)
+	dups := duplicateAddresses(settings, changed)
+	var addresses []string
+	for a := range dups {
+		addresses = append(addresses, a)
+	}
+	sort.Strings(addresses)
+	if len(addresses) > 0 {
+		fmt.Println()
+	}
+	for _, a := range addresses {
+		fmt.Printf(tr("[WARNING] Address '%s' would load %d assets: %s\n"), a, len(dups[a]), strings.Join(dups[a], ", "))
+	}
+	fmt.Printf(tr("\n  %d entr(ies) change in %d file(s)\n"), len(changed), len(files))
+
+	if dryRun {
+		for _, e := range changed {
+			recordAction("update", e.asset, "planned", describeChange(e), 0)
+		}
+		fmt.Println(tr("\n[Dry Run] No files were changed."))
+		exit(0)
+	}
+	if isRunning, pid := checkUnityRunning(basePath); isRunning {
+		fmt.Printf(tr("\n[ERROR] Unity Editor is running (PID: %d). Close it first; the editor keeps the groups in memory and saves them over these changes.\n"), pid)
+		recordError("Unity Editor is running (PID: %d)", pid)
+		exit(1)
+	}
+	if !ciMode {
+		fmt.Printf(tr("\nWrite %d file(s)? (y/N): "), len(files))
+		confirm, _ := stdinReader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+			fmt.Println(tr("Operation cancelled."))
+			exit(0)
+		}
+	}
+	if err := acquireProjectLock(basePath, "unity_addressables_editor", command, force); err != nil {
+		fail(1, "%v", err)
+	}
+	defer releaseProjectLock()
+	if activeVCS, err = detectVCS(basePath, vcsMode); err != nil {
+		fail(1, "%v", err)
+	}
+	if activeVCS.kind != vcsNone {
+		fmt.Printf(tr("[VCS] Using %s (%s)\n"), activeVCS.kind, activeVCS.source)
+	}
+
+	fmt.Println()
+	errs := saveSettings(basePath, settings)
+	for _, f := range files {
+		if err := errs[f]; err != nil {
+			fmt.Printf(tr("[FAIL] %s: %v\n"), f, err)
+			recordAction("write", f, "failed", err.Error(), 0)
+			recordError("%s: %v", f, err)
+			continue
+		}
+		fmt.Printf(tr("[OK]   %s\n"), f)
+		recordAction("write", f, "ok", "", 0)
+	}
+	if len(errs) > 0 {
+		exit(1)
+	}
+	for _, e := range changed {
+		recordAction("update", e.asset, "ok", describeChange(e), 0)
+	}
+	fmt.Println(tr("\n[TIP] Build the Addressables content again; the built catalog still has the old addresses and labels."))
+	exit(0)
+}
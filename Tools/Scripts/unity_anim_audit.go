@@ -0,0 +1,1212 @@
+// Unity Anim Audit — Find unused animator states and clips, missing clip references and over-dense keyframes.
+// Reads the AnimatorController, AnimatorOverrideController and .anim YAML of the
+// project without Unity. In every controller layer it follows the entry, the
+// default states, Any State and every transition, and reports the states nothing
+// enters; states named in Animator.Play / CrossFade calls in C# count as entered.
+// It reports .anim clips no controller, override controller, Timeline or other
+// asset uses, motion references to clips that were deleted, and clips whose
+// curves carry more keys per second than hand-keyed motion needs (baked or
+// recorded) or hold one value on every key. -out writes the findings as a
+// Markdown or CSV report for the animators.
+//
+// Build: go build unity_anim_audit.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_anim_audit                                    # every controller and clip under Assets
+//	unity_anim_audit Assets/Characters                  # one folder
+//	unity_anim_audit -max-density 20                    # keys per second per curve
+//	unity_anim_audit -out Docs/AnimAudit.md             # report for the animators (.md or .csv)
+//	unity_anim_audit -strict -ci                        # CI: warnings fail too
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Class IDs of the objects read from controllers and clips
+const (
+	classAnimationClip      = "74"
+	classAnimatorController = "91"
+	classBlendTree          = "206"
+	classStateTransition    = "1101"
+	classState              = "1102"
+	classStateMachine       = "1107"
+	classTransition         = "1109"
+)
+
+// Text assets whose GUID references mark a clip as used
+var referenceExtensions = map[string]bool{
+	".controller": true, ".overrideController": true, ".unity": true, ".prefab": true,
+	".asset": true, ".playable": true,
+}
+
+// Curves an AnimationClip plays; m_EditorCurves repeats them for the editor, and
+// m_CompressedRotationCurves holds packed keys that cannot be counted
+var curveSections = map[string]bool{
+	"m_RotationCurves": true, "m_EulerCurves": true, "m_PositionCurves": true,
+	"m_ScaleCurves": true, "m_FloatCurves": true, "m_PPtrCurves": true,
+}
+
+// Keys per second per curve above which a clip is reported as dense. Hand-keyed
+// motion rarely needs more; baked or recorded clips carry one key per frame.
+const defaultMaxDensity = 30
+
+// Finding kinds, in report order
+const (
+	findMissing  = "missing"
+	findState    = "state"
+	findClip     = "clip"
+	findDense    = "dense"
+	findConstant = "constant"
+)
+
+var findingSections = []struct {
+	kind  string
+	title string
+	tag   string
+}{
+	{findMissing, "Missing clip references", "[MISSING] "},
+	{findState, "Unused states", "[UNUSED]  "},
+	{findClip, "Unused clips", "[UNUSED]  "},
+	{findDense, "Dense keyframes", "[DENSE]   "},
+	{findConstant, "Constant curves", "[CONSTANT]"},
+}
+
+var (
+	metaGUIDRegex = regexp.MustCompile(`(?m)^guid: ([0-9a-fA-F]{32})`)
+	guidRefRegex  = regexp.MustCompile(`(?i)guid: ([0-9a-f]{32})`)
+	docRegex      = regexp.MustCompile(`(?m)^--- !u!(\d+) &(-?\d+)`)
+	fileIDRegex   = regexp.MustCompile(`fileID: (-?\d+)`)
+	// m_Motion of states and blend trees, and the clips and controller of an override controller
+	motionRefRegex = regexp.MustCompile(`(m_Motion|m_OriginalClip|m_OverrideClip|m_Controller): \{fileID: (-?\d+), guid: ([0-9a-fA-F]{32})`)
+	// Animator.Play("Jump"), CrossFade("Base Layer.Jump", 0.1f), Animator.StringToHash("Jump")
+	stateCallRegex = regexp.MustCompile(`\b(?:Play|PlayInFixedTime|CrossFade|CrossFadeInFixedTime|HasState|StringToHash)\s*\(\s*"((?:[^"\\\n]|\\.)*)"`)
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// yamlDoc is one object of a Unity YAML file
+type yamlDoc struct {
+	class string
+	id    string
+	line  int
+	body  string
+}
+
+// clip is one .anim file and what its curves hold
+type clip struct {
+	rel       string
+	guid      string
+	size      int64
+	duration  float64
+	curves    int
+	keys      int
+	constant  int // curves holding one value on every key
+	redundant int // keys the constant curves could drop
+	usedBy    []string
+}
+
+// finding is one problem, kept as an English format so the report and JSON stay English
+type finding struct {
+	kind   string
+	file   string
+	line   int
+	format string
+	args   []interface{}
+}
+
+func (f finding) message() string {
+	return fmt.Sprintf(f.format, f.args...)
+}
+
+// audit is everything the scan found
+type audit struct {
+	basePath    string
+	roots       []string
+	guids       map[string]string // every GUID in the project -> asset path
+	clips       map[string]*clip  // GUID -> .anim clip under the roots
+	codeStates  map[string]bool   // state names C# plays by name
+	controllers int
+	layers      int
+	states      int
+	findings    []finding
+}
+
+// ============================================================
+// Project Index
+// ============================================================
+
+// Folders indexed for GUIDs, so references into packages are not reported as missing
+var indexRoots = []string{"Assets", "Packages", filepath.Join("Library", "PackageCache")}
+
+// indexGUIDs maps the GUID of every asset in the project and its packages to its path
+func indexGUIDs(basePath string) map[string]string {
+	guids := make(map[string]string)
+	for _, root := range indexRoots {
+		filepath.Walk(filepath.Join(basePath, root), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if strings.HasPrefix(info.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(info.Name(), ".meta") {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			if m := metaGUIDRegex.FindSubmatch(data); m != nil {
+				guids[strings.ToLower(string(m[1]))] = relPath(basePath, strings.TrimSuffix(path, ".meta"))
+			}
+			return nil
+		})
+	}
+	return guids
+}
+
+// projectFiles lists the assets and scripts of Assets/ and Packages/ the audit reads
+func projectFiles(basePath string, excludes []*regexp.Regexp) []string {
+	var files []string
+	for _, root := range []string{"Assets", "Packages"} {
+		filepath.Walk(filepath.Join(basePath, root), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if strings.HasPrefix(info.Name(), ".") || strings.HasSuffix(info.Name(), "~") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			rel := relPath(basePath, path)
+			ext := filepath.Ext(path)
+			if (ext == ".anim" || ext == ".cs" || referenceExtensions[ext]) && !matchesAny(excludes, rel) {
+				files = append(files, rel)
+			}
+			return nil
+		})
+	}
+	sort.Strings(files)
+	return files
+}
+
+// inRoots reports whether a project file is under one of the audited folders
+func (a *audit) inRoots(rel string) bool {
+	for _, r := range a.roots {
+		if rel == r || strings.HasPrefix(rel, r+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// metaGUID reads the GUID from the .meta file next to path
+func metaGUID(path string) string {
+	data, err := os.ReadFile(path + ".meta")
+	if err != nil {
+		return ""
+	}
+	if m := metaGUIDRegex.FindSubmatch(data); m != nil {
+		return strings.ToLower(string(m[1]))
+	}
+	return ""
+}
+
+func (a *audit) add(kind, file string, line int, format string, args ...interface{}) {
+	a.findings = append(a.findings, finding{kind: kind, file: file, line: line, format: format, args: args})
+}
+
+// ============================================================
+// Unity YAML
+// ============================================================
+
+// splitDocs splits a Unity YAML file into its objects
+func splitDocs(data []byte) []yamlDoc {
+	locs := docRegex.FindAllSubmatchIndex(data, -1)
+	docs := make([]yamlDoc, 0, len(locs))
+	line := 1
+	prev := 0
+	for i, m := range locs {
+		end := len(data)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		line += bytes.Count(data[prev:m[0]], []byte("\n"))
+		prev = m[0]
+		docs = append(docs, yamlDoc{
+			class: string(data[m[2]:m[3]]),
+			id:    string(data[m[4]:m[5]]),
+			line:  line,
+			body:  string(data[m[1]:end]),
+		})
+	}
+	return docs
+}
+
+// field returns the value of a top-level field of an object ("  m_Name: Idle")
+func (d yamlDoc) field(key string) string {
+	prefix := "  " + key + ":"
+	for _, l := range strings.Split(d.body, "\n") {
+		if strings.HasPrefix(l, prefix) {
+			return strings.TrimSpace(strings.TrimRight(l[len(prefix):], "\r"))
+		}
+	}
+	return ""
+}
+
+// ref returns the fileID of a top-level reference field, "" when it is empty
+func (d yamlDoc) ref(key string) string {
+	if m := fileIDRegex.FindStringSubmatch(d.field(key)); m != nil && m[1] != "0" {
+		return m[1]
+	}
+	return ""
+}
+
+// block returns the lines of a top-level field that spans several lines: its
+// list items at the same indent and everything indented deeper
+func (d yamlDoc) block(key string) []string {
+	var lines []string
+	in := false
+	for _, l := range strings.Split(d.body, "\n") {
+		l = strings.TrimRight(l, "\r")
+		if !in {
+			in = l == "  "+key+":"
+			continue
+		}
+		if !strings.HasPrefix(l, "   ") && !strings.HasPrefix(l, "  - ") {
+			break
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+// refs returns every fileID a top-level field lists
+func (d yamlDoc) refs(key string) []string {
+	var ids []string
+	for _, l := range d.block(key) {
+		for _, m := range fileIDRegex.FindAllStringSubmatch(l, -1) {
+			if m[1] != "0" {
+				ids = append(ids, m[1])
+			}
+		}
+	}
+	return ids
+}
+
+// ============================================================
+// Controllers
+// ============================================================
+
+// auditController reports the states of a controller nothing enters and its
+// references to deleted clips
+func (a *audit) auditController(rel string, data []byte) {
+	docs := splitDocs(data)
+	byID := make(map[string]yamlDoc, len(docs))
+	for _, d := range docs {
+		byID[d.id] = d
+	}
+
+	// State machine of every state and sub-state machine, for the state paths
+	parent := make(map[string]string)
+	for _, d := range docs {
+		if d.class != classStateMachine {
+			continue
+		}
+		for _, id := range d.refs("m_ChildStates") {
+			parent[id] = d.id
+		}
+		for _, id := range d.refs("m_ChildStateMachines") {
+			parent[id] = d.id
+		}
+	}
+	path := func(id string) string {
+		parts := []string{byID[id].field("m_Name")}
+		for p, ok := parent[id]; ok; p, ok = parent[p] {
+			parts = append([]string{byID[p].field("m_Name")}, parts...)
+		}
+		if _, ok := parent[id]; !ok {
+			return parts[0]
+		}
+		return strings.Join(parts, "/")
+	}
+
+	// Follow every layer from its root state machine
+	reached := make(map[string]bool)
+	var enter, visit, follow func(id string)
+	enter = func(sm string) {
+		d, ok := byID[sm]
+		if !ok || reached[sm] {
+			return
+		}
+		reached[sm] = true
+		if s := d.ref("m_DefaultState"); s != "" {
+			visit(s)
+		}
+		for _, key := range []string{"m_EntryTransitions", "m_AnyStateTransitions", "m_StateMachineTransitions"} {
+			for _, id := range d.refs(key) {
+				if byID[id].class == classStateMachine {
+					continue // the "first" of a state machine transition names its source
+				}
+				follow(id)
+			}
+		}
+	}
+	visit = func(state string) {
+		if reached[state] {
+			return
+		}
+		reached[state] = true
+		for _, t := range byID[state].refs("m_Transitions") {
+			follow(t)
+		}
+	}
+	follow = func(id string) {
+		t, ok := byID[id]
+		if !ok || (t.class != classStateTransition && t.class != classTransition) {
+			return
+		}
+		if s := t.ref("m_DstState"); s != "" {
+			visit(s)
+		}
+		if sm := t.ref("m_DstStateMachine"); sm != "" {
+			enter(sm)
+		}
+	}
+	for _, d := range docs {
+		if d.class != classAnimatorController {
+			continue
+		}
+		for _, l := range d.block("m_AnimatorLayers") {
+			if t := strings.TrimSpace(l); strings.HasPrefix(t, "m_StateMachine:") {
+				if m := fileIDRegex.FindStringSubmatch(t); m != nil && m[1] != "0" {
+					a.layers++
+					enter(m[1])
+				}
+			}
+		}
+	}
+
+	for _, d := range docs {
+		if d.class != classState {
+			continue
+		}
+		a.states++
+		name := d.field("m_Name")
+		if !reached[d.id] && !a.codeStates[name] {
+			a.add(findState, rel, d.line, "state \"%s\" is never entered", path(d.id))
+		}
+	}
+	a.checkMotions(rel, docs)
+}
+
+// checkMotions marks the clips a controller or override controller uses and
+// reports its references to assets that no longer exist
+func (a *audit) checkMotions(rel string, docs []yamlDoc) {
+	for _, d := range docs {
+		for _, m := range motionRefRegex.FindAllStringSubmatchIndex(d.body, -1) {
+			key := d.body[m[2]:m[3]]
+			guid := strings.ToLower(d.body[m[6]:m[7]])
+			if c := a.clips[guid]; c != nil {
+				c.use(rel)
+				continue
+			}
+			if _, ok := a.guids[guid]; ok {
+				continue
+			}
+			line := d.line + strings.Count(d.body[:m[0]], "\n")
+			switch {
+			case d.class == classState:
+				a.add(findMissing, rel, line, "state \"%s\": clip %s no longer exists", d.field("m_Name"), guid)
+			case d.class == classBlendTree:
+				a.add(findMissing, rel, line, "blend tree \"%s\": clip %s no longer exists", d.field("m_Name"), guid)
+			case key == "m_Controller":
+				a.add(findMissing, rel, line, "controller %s no longer exists", guid)
+			case key == "m_OriginalClip":
+				a.add(findMissing, rel, line, "original clip %s no longer exists", guid)
+			case key == "m_OverrideClip":
+				a.add(findMissing, rel, line, "override clip %s no longer exists", guid)
+			default:
+				a.add(findMissing, rel, line, "clip %s no longer exists", guid)
+			}
+		}
+	}
+}
+
+// use records an asset that references the clip, once
+func (c *clip) use(rel string) {
+	if !containsString(c.usedBy, rel) {
+		c.usedBy = append(c.usedBy, rel)
+	}
+}
+
+// ============================================================
+// Clips
+// ============================================================
+
+// curve collects the keys of one curve while a clip is read
+type curve struct {
+	keys   int
+	values map[string]bool
+	moving bool // a key has a slope, so the curve is not flat between equal values
+}
+
+// read counts the curves and keys of an AnimationClip and finds the ones
+// holding one value on every key
+func (c *clip) read(data []byte) {
+	for _, d := range splitDocs(data) {
+		if d.class != classAnimationClip {
+			continue
+		}
+		var start, stop float64
+		var cur *curve
+		finish := func() {
+			if cur == nil {
+				return
+			}
+			c.curves++
+			c.keys += cur.keys
+			if cur.keys > 2 && len(cur.values) == 1 && !cur.moving {
+				c.constant++
+				c.redundant += cur.keys - 2
+			}
+			cur = nil
+		}
+		inCurves := false
+		for _, l := range strings.Split(d.body, "\n") {
+			l = strings.TrimRight(l, "\r")
+			t := strings.TrimSpace(l)
+			if strings.HasPrefix(l, "  ") && !strings.HasPrefix(l, "   ") && !strings.HasPrefix(l, "  - ") {
+				finish()
+				key := strings.TrimSuffix(strings.Fields(t + " ")[0], ":")
+				inCurves = curveSections[key]
+				continue
+			}
+			switch {
+			case strings.HasPrefix(t, "m_StartTime:"):
+				start, _ = strconv.ParseFloat(strings.TrimSpace(t[len("m_StartTime:"):]), 64)
+			case strings.HasPrefix(t, "m_StopTime:"):
+				stop, _ = strconv.ParseFloat(strings.TrimSpace(t[len("m_StopTime:"):]), 64)
+			}
+			if !inCurves {
+				continue
+			}
+			if strings.HasPrefix(l, "  - ") {
+				finish()
+				cur = &curve{values: make(map[string]bool)}
+				continue
+			}
+			if cur == nil {
+				continue
+			}
+			t = strings.TrimPrefix(t, "- ")
+			switch {
+			case strings.HasPrefix(t, "time:"):
+				cur.keys++
+			case strings.HasPrefix(t, "value:"):
+				cur.values[strings.TrimSpace(t[len("value:"):])] = true
+			case strings.HasPrefix(t, "inSlope:"), strings.HasPrefix(t, "outSlope:"):
+				if !isZero(t[strings.Index(t, ":")+1:]) {
+					cur.moving = true
+				}
+			}
+		}
+		finish()
+		c.duration = stop - start
+	}
+}
+
+// isZero reports whether a YAML number or vector ({x: 0, y: 0, z: 0}) is zero everywhere
+func isZero(s string) bool {
+	s = strings.Trim(strings.TrimSpace(s), "{}")
+	for _, part := range strings.Split(s, ",") {
+		if i := strings.Index(part, ":"); i >= 0 {
+			part = part[i+1:]
+		}
+		if v, err := strconv.ParseFloat(strings.TrimSpace(part), 64); err != nil || v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// density is the average number of keys per second on each curve, counting the
+// intervals between keys so a two-key curve over a long clip is not dense
+func (c *clip) density() float64 {
+	if c.curves == 0 || c.duration <= 0 || c.keys <= 2*c.curves {
+		return 0
+	}
+	return float64(c.keys-c.curves) / float64(c.curves) / c.duration
+}
+
+// ============================================================
+// Code References
+// ============================================================
+
+// scanCode collects the state names C# plays by name. "Base Layer.Jump" counts
+// for every state called Jump.
+func (a *audit) scanCode(basePath string, files []string) {
+	for _, rel := range files {
+		if !strings.HasSuffix(rel, ".cs") {
+			continue
+		}
+		src, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(rel)))
+		if err != nil {
+			continue
+		}
+		for _, m := range stateCallRegex.FindAllSubmatch(src, -1) {
+			name := string(m[1])
+			if i := strings.LastIndex(name, "."); i >= 0 {
+				name = name[i+1:]
+			}
+			a.codeStates[name] = true
+		}
+	}
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+// mdEscape keeps "|" from splitting a Markdown table cell
+func mdEscape(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// writeReport writes the findings as CSV for a .csv path, else as Markdown
+func writeReport(path string, a *audit) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		buf.WriteString("\xEF\xBB\xBF")
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"kind", "file", "line", "finding"})
+		for _, f := range a.findings {
+			w.Write([]string{f.kind, f.file, strconv.Itoa(f.line), f.message()})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		return os.WriteFile(path, buf.Bytes(), 0644)
+	}
+
+	fmt.Fprintf(&buf, "# Animation Audit\n\n%s, %s: %d controller(s) with %d state(s), %d clip(s).\n",
+		filepath.Base(a.basePath), strings.Join(a.roots, ", "), a.controllers, a.states, len(a.clips))
+	if len(a.findings) == 0 {
+		buf.WriteString("\nNo findings.\n")
+	}
+	for _, s := range findingSections {
+		var rows []finding
+		for _, f := range a.findings {
+			if f.kind == s.kind {
+				rows = append(rows, f)
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "\n## %s (%d)\n\n| File | Line | Finding |\n| --- | --- | --- |\n", s.title, len(rows))
+		for _, f := range rows {
+			line := ""
+			if f.line > 0 {
+				line = strconv.Itoa(f.line)
+			}
+			fmt.Fprintf(&buf, "| `%s` | %s | %s |\n", f.file, line, mdEscape(f.message()))
+		}
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode      bool
+		strict      bool
+		maxDensity  float64
+		outFlag     string
+		excludeFlag string
+	)
+
+	flag.Float64Var(&maxDensity, "max-density", defaultMaxDensity, "Keys per second per curve above which a clip is reported as dense (0 = off)")
+	flag.StringVar(&outFlag, "out", "", "Also write the findings to this file: Markdown, or CSV for a .csv path")
+	flag.StringVar(&excludeFlag, "exclude", "", "Comma-separated globs of files left out")
+	flag.BoolVar(&strict, "strict", false, "Warnings (unused states and clips, dense or constant curves) fail the check too")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the folders ("Assets/Characters -ci")
+	flag.Parse()
+	var roots []string
+	for flag.NArg() > 0 {
+		roots = append(roots, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_anim_audit")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	excludes, err := compileGlobs(excludeFlag)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if len(roots) == 0 {
+		roots = []string{"Assets"}
+	}
+	for i, r := range roots {
+		abs := r
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(basePath, filepath.FromSlash(r))
+		}
+		if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+			fail(2, "folder %s does not exist", r)
+		}
+		roots[i] = relPath(basePath, abs)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  ANIMATION AUDIT"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:  %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Scanning: %s\n"), strings.Join(roots, ", "))
+
+	a := &audit{
+		basePath:   basePath,
+		roots:      roots,
+		guids:      indexGUIDs(basePath),
+		clips:      make(map[string]*clip),
+		codeStates: make(map[string]bool),
+	}
+	files := projectFiles(basePath, excludes)
+
+	for _, rel := range files {
+		if filepath.Ext(rel) != ".anim" || !a.inRoots(rel) {
+			continue
+		}
+		path := filepath.Join(basePath, filepath.FromSlash(rel))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf(tr("[WARNING] %s: %v\n"), rel, err)
+			continue
+		}
+		c := &clip{rel: rel, guid: metaGUID(path), size: int64(len(data))}
+		c.read(data)
+		if c.guid == "" {
+			c.guid = rel // no .meta: nothing can reference it
+		}
+		a.clips[c.guid] = c
+	}
+	a.scanCode(basePath, files)
+
+	for _, rel := range files {
+		ext := filepath.Ext(rel)
+		if !referenceExtensions[ext] {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(rel)))
+		if err != nil {
+			fmt.Printf(tr("[WARNING] %s: %v\n"), rel, err)
+			continue
+		}
+		if a.inRoots(rel) {
+			switch ext {
+			case ".controller":
+				a.controllers++
+				a.auditController(rel, data)
+			case ".overrideController":
+				a.checkMotions(rel, splitDocs(data))
+			}
+		}
+		for _, m := range guidRefRegex.FindAllSubmatch(data, -1) {
+			if c := a.clips[strings.ToLower(string(m[1]))]; c != nil {
+				c.use(rel)
+			}
+		}
+	}
+	fmt.Printf(tr("  Controllers: %d (%d layer(s), %d state(s))\n"), a.controllers, a.layers, a.states)
+	fmt.Printf(tr("  Clips:       %d\n"), len(a.clips))
+
+	clips := make([]*clip, 0, len(a.clips))
+	for _, c := range a.clips {
+		clips = append(clips, c)
+	}
+	sort.Slice(clips, func(i, j int) bool { return clips[i].rel < clips[j].rel })
+	for _, c := range clips {
+		if len(c.usedBy) == 0 {
+			a.add(findClip, c.rel, 0, "used by no controller, Timeline or other asset (%s)", formatSize(c.size))
+		}
+		if d := c.density(); maxDensity > 0 && d > maxDensity {
+			a.add(findDense, c.rel, 0, "%d keys on %d curve(s) over %.2fs: %.0f keys per second per curve (limit %g)", c.keys, c.curves, c.duration, d, maxDensity)
+		}
+		if c.constant > 0 {
+			a.add(findConstant, c.rel, 0, "%d of %d curve(s) hold one value on every key; %d key(s) could go", c.constant, c.curves, c.redundant)
+		}
+		recordAction("clip", c.rel, "ok", fmt.Sprintf("%d curves, %d keys, %.2fs, used by %d", c.curves, c.keys, c.duration, len(c.usedBy)), 0)
+	}
+
+	order := make(map[string]int)
+	for i, s := range findingSections {
+		order[s.kind] = i
+	}
+	sort.SliceStable(a.findings, func(i, j int) bool {
+		fi, fj := a.findings[i], a.findings[j]
+		if fi.kind != fj.kind {
+			return order[fi.kind] < order[fj.kind]
+		}
+		if fi.file != fj.file {
+			return fi.file < fj.file
+		}
+		return fi.line < fj.line
+	})
+
+	errors, warnings := 0, 0
+	for _, s := range findingSections {
+		count := 0
+		for _, f := range a.findings {
+			if f.kind != s.kind {
+				continue
+			}
+			if count == 0 {
+				fmt.Printf("\n%s:\n", tr(s.title))
+			}
+			count++
+			where := f.file
+			if f.line > 0 {
+				where = fmt.Sprintf("%s:%d", f.file, f.line)
+			}
+			fmt.Printf("%s %s: %s\n", s.tag, where, fmt.Sprintf(tr(f.format), f.args...))
+			status := "warning"
+			if f.kind == findMissing || strict {
+				status = "failed"
+				recordError("%s: %s", where, f.message())
+			}
+			recordAction(f.kind, where, status, f.message(), 0)
+			if f.kind == findMissing {
+				errors++
+			} else {
+				warnings++
+			}
+		}
+	}
+
+	fmt.Println()
+	switch {
+	case errors > 0:
+		fmt.Printf(tr("[ERROR] %d missing clip reference(s), %d warning(s).\n"), errors, warnings)
+	case warnings > 0 && strict:
+		fmt.Printf(tr("[ERROR] %d warning(s) (-strict).\n"), warnings)
+	case warnings > 0:
+		fmt.Printf(tr("[WARNING] %d warning(s); no missing clip references.\n"), warnings)
+	default:
+		fmt.Println(tr("[OK] Every state is entered, every clip is used and no curve is over-dense."))
+	}
+
+	if outFlag != "" {
+		out := outFlag
+		if !filepath.IsAbs(out) {
+			out = filepath.Join(basePath, filepath.FromSlash(out))
+		}
+		if err := writeReport(out, a); err != nil {
+			fail(1, "cannot write %s: %v", outFlag, err)
+		}
+		recordArtifact(out)
+		fmt.Printf(tr("[OK] Wrote %s\n"), relPath(basePath, out))
+	}
+	if warnings > 0 {
+		fmt.Println(tr("[TIP] States entered only from code with a hash, and clips loaded from Resources, Addressables or bundles, show as unused; check before deleting."))
+	}
+	if errors > 0 || (strict && warnings > 0) {
+		exit(1)
+	}
+	exit(0)
+}
+
+// ============================================================
+// Path Filters
+// ============================================================
+
+// globToRegexp converts a glob (*, ?, **) on slash-separated project paths. A
+// pattern without a slash matches the file name in any folder.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compileGlobs parses a comma-separated glob list
+func compileGlobs(list string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := globToRegexp(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, rel string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+// relPath shows a path relative to the project root, with forward slashes
+func relPath(basePath, p string) string {
+	if rel, err := filepath.Rel(basePath, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return p
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...":                                     "\n按回车键继续...",
+	"[ERROR] %v\n":                                                     "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  ANIMATION AUDIT":                                                "  动画审计",
+	"  Project:  %s\n":                                                 "  项目:     %s\n",
+	"  Scanning: %s\n":                                                 "  扫描:     %s\n",
+	"[WARNING] %s: %v\n":                                               "[WARNING] %s: %v\n",
+	"  Controllers: %d (%d layer(s), %d state(s))\n":                   "  控制器:   %d 个（%d 个层，%d 个状态）\n",
+	"  Clips:       %d\n":                                              "  动画片段: %d 个\n",
+	"Missing clip references":                                          "缺失的片段引用",
+	"Unused states":                                                    "未使用的状态",
+	"Unused clips":                                                     "未使用的片段",
+	"Dense keyframes":                                                  "关键帧过密",
+	"Constant curves":                                                  "恒定曲线",
+	"state \"%s\" is never entered":                                    "状态 \"%s\" 永远不会进入",
+	"state \"%s\": clip %s no longer exists":                           "状态 \"%s\": 片段 %s 已不存在",
+	"blend tree \"%s\": clip %s no longer exists":                      "混合树 \"%s\": 片段 %s 已不存在",
+	"controller %s no longer exists":                                   "控制器 %s 已不存在",
+	"original clip %s no longer exists":                                "原始片段 %s 已不存在",
+	"override clip %s no longer exists":                                "覆盖片段 %s 已不存在",
+	"clip %s no longer exists":                                         "片段 %s 已不存在",
+	"used by no controller, Timeline or other asset (%s)":              "没有任何控制器、Timeline 或其他资源使用 (%s)",
+	"%d keys on %d curve(s) over %.2fs: %.0f keys per second per curve (limit %g)": "%.2[3]f 秒内 %[2]d 条曲线共 %[1]d 个关键帧: 每条曲线每秒 %.0[4]f 个（上限 %[5]g）",
+	"%d of %d curve(s) hold one value on every key; %d key(s) could go":            "%[2]d 条曲线中有 %[1]d 条所有关键帧的值相同，可删去 %[3]d 个关键帧",
+	"[ERROR] %d missing clip reference(s), %d warning(s).\n":                       "[ERROR] %d 个缺失的片段引用，%d 个警告。\n",
+	"[ERROR] %d warning(s) (-strict).\n":                                           "[ERROR] %d 个警告 (-strict)。\n",
+	"[WARNING] %d warning(s); no missing clip references.\n":                       "[WARNING] %d 个警告；没有缺失的片段引用。\n",
+	"[OK] Every state is entered, every clip is used and no curve is over-dense.":  "[OK] 所有状态都会进入，所有片段都在使用，没有过密的曲线。",
+	"[OK] Wrote %s\n": "[OK] 已写入 %s\n",
+	"[TIP] States entered only from code with a hash, and clips loaded from Resources, Addressables or bundles, show as unused; check before deleting.": "[TIP] 仅在代码中通过哈希进入的状态，以及从 Resources、Addressables 或资源包加载的片段，会显示为未使用，删除前请确认。",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
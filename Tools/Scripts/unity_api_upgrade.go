@@ -0,0 +1,1086 @@
+// Unity API Upgrade — Find scripting APIs that are obsolete or removed in a target Unity version before upgrading.
+// Scans the C# of the project for APIs a table of known Unity API changes marks
+// obsolete or removed by the target editor version (-target, default: the newest
+// version the table knows), and writes an upgrade readiness report: each API
+// with the version it went obsolete and was removed in, its replacement, and
+// every use with its file and line. Uses of removed APIs stop the project from
+// compiling after the upgrade and fail the check; obsolete ones are warnings.
+// Code behind #if UNITY_<version>_OR_NEWER guards that the target does not
+// compile is skipped, as are comments. ApiUpgrades.json in the project root adds
+// rules to the built-in table or replaces them by id. -out writes the report as
+// Markdown or CSV.
+//
+// Build: go build unity_api_upgrade.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_api_upgrade                                   # to the newest version the table knows
+//	unity_api_upgrade -target 2023.2                    # to one version
+//	unity_api_upgrade Assets/Game -exclude "ThirdParty/**"
+//	unity_api_upgrade -out Docs/Upgrade.md              # readiness report (.md or .csv)
+//	unity_api_upgrade -strict -ci                       # CI: obsolete APIs fail too
+//
+// ApiUpgrades.json lists rules like the built-in ones; "disabled": true turns a
+// built-in rule off:
+//
+//	{"rules": [{"id": "legacy-input", "api": "Input.GetAxis", "pattern": "\\bInput\\.GetAxis\\b",
+//	            "obsolete": "6000.0", "replacement": "the Input System package"},
+//	           {"id": "www", "disabled": true}]}
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const defaultTableFile = "ApiUpgrades.json" // in the project root
+
+// Uses listed per API on the console; the report lists all
+const consoleUsesPerAPI = 10
+
+// Known Unity scripting API changes. Patterns match one line of C# with comments
+// and string literals removed. Where only the member name tells an API apart
+// (Rigidbody.velocity from NavMeshAgent.velocity), the pattern goes by the
+// variable name and can miss uses or match others.
+var builtinRules = []apiRule{
+	{ID: "find-object-of-type", API: "Object.FindObjectOfType / FindObjectsOfType", Pattern: `\bFindObjectsOfType\s*[<(]|\bFindObjectOfType\s*[<(]`,
+		Obsolete: "2023.1", Replacement: "FindFirstObjectByType, FindAnyObjectByType, FindObjectsByType"},
+	{ID: "rigidbody-velocity", API: "Rigidbody(2D).velocity / drag / angularDrag", Pattern: `(?i)(?:\b\w*(?:rigidbody|rb)\w*|<Rigidbody(?:2D)?>\(\))\.(?:velocity|drag|angularDrag)\b`,
+		Obsolete: "6000.0", Replacement: "linearVelocity, linearDamping, angularDamping"},
+	{ID: "physic-material", API: "PhysicMaterial", Pattern: `\bPhysicMaterial(?:Combine)?\b`,
+		Obsolete: "6000.0", Replacement: "PhysicsMaterial, PhysicsMaterialCombine"},
+	{ID: "render-pass-execute", API: "ScriptableRenderPass.Execute / OnCameraSetup / Configure", Pattern: `\boverride\s+void\s+(?:Execute\s*\(\s*ScriptableRenderContext|OnCameraSetup\s*\(|Configure\s*\(\s*CommandBuffer)`,
+		Obsolete: "6000.0", Replacement: "ScriptableRenderPass.RecordRenderGraph", Note: "URP 17 runs passes through the Render Graph; Execute only runs in Compatibility Mode"},
+	{ID: "render-target-handle", API: "RenderTargetHandle, cameraColorTarget, cameraDepthTarget", Pattern: `\bRenderTargetHandle\b|\bcameraColorTarget\b|\bcameraDepthTarget\b`,
+		Obsolete: "2022.1", Replacement: "RTHandle, cameraColorTargetHandle, cameraDepthTargetHandle"},
+	{ID: "define-symbols-for-group", API: "PlayerSettings.Get/SetScriptingDefineSymbolsForGroup", Pattern: `\bPlayerSettings\.(?:Get|Set)ScriptingDefineSymbolsForGroup\b`,
+		Obsolete: "2023.1", Replacement: "PlayerSettings.Get/SetScriptingDefineSymbols(NamedBuildTarget)"},
+	{ID: "build-target-group", API: "PlayerSettings methods taking a BuildTargetGroup", Pattern: `\bPlayerSettings\.(?:Get|Set)(?:ScriptingBackend|ApplicationIdentifier|ManagedStrippingLevel|Il2CppCompilerConfiguration|ApiCompatibilityLevel)\s*\(\s*BuildTargetGroup\.`,
+		Obsolete: "2023.1", Replacement: "the NamedBuildTarget overloads"},
+	{ID: "physics-auto-simulation", API: "Physics.autoSimulation", Pattern: `\bPhysics\.autoSimulation\b`,
+		Obsolete: "2022.2", Replacement: "Physics.simulationMode"},
+	{ID: "texture-resize", API: "Texture2D.Resize", Pattern: `(?i)\b\w*tex\w*\.Resize\s*\(`,
+		Obsolete: "2021.2", Replacement: "Texture2D.Reinitialize"},
+	{ID: "web-request-errors", API: "UnityWebRequest.isNetworkError / isHttpError", Pattern: `\.(?:isNetworkError|isHttpError)\b`,
+		Obsolete: "2020.2", Replacement: "UnityWebRequest.result"},
+	{ID: "builtin-vr", API: "PlayerSettings.virtualRealitySupported", Pattern: `\bPlayerSettings\.virtualRealitySupported\b`,
+		Obsolete: "2019.3", Removed: "2020.1", Replacement: "XR Plug-in Management"},
+	{ID: "guitext", API: "GUIText, GUITexture", Pattern: `\bGUIText\b|\bGUITexture\b|\bguiText\b|\bguiTexture\b`,
+		Obsolete: "2017.2", Removed: "2019.3", Replacement: "UI Text or TextMeshPro, UI Image"},
+	{ID: "build-target-linux32", API: "BuildTarget.StandaloneLinux / StandaloneLinuxUniversal", Pattern: `\bBuildTarget\.StandaloneLinux(?:Universal)?\b`,
+		Obsolete: "2019.2", Removed: "2019.2", Replacement: "BuildTarget.StandaloneLinux64"},
+	{ID: "scene-gui-delegate", API: "SceneView.onSceneGUIDelegate", Pattern: `\bSceneView\.onSceneGUIDelegate\b`,
+		Obsolete: "2019.1", Replacement: "SceneView.duringSceneGui"},
+	{ID: "experimental-uielements", API: "UnityEngine/UnityEditor.Experimental.UIElements", Pattern: `\bUnity(?:Engine|Editor)\.Experimental\.UIElements\b`,
+		Obsolete: "2019.1", Replacement: "UnityEngine.UIElements, UnityEditor.UIElements"},
+	{ID: "lwrp", API: "Lightweight Render Pipeline", Pattern: `\bUnityEngine\.(?:Experimental\.)?Rendering\.LWRP\b|\bLightweightRenderPipeline\w*`,
+		Obsolete: "2019.3", Replacement: "URP (UnityEngine.Rendering.Universal)"},
+	{ID: "www", API: "WWW", Pattern: `\bnew\s+WWW\s*\(|\bWWW\.\w|\bWWW\s+\w+\s*[=;]`,
+		Obsolete: "2018.3", Replacement: "UnityWebRequest"},
+	{ID: "prefab-utility", API: "PrefabUtility before the 2018.3 prefab system", Pattern: `\bPrefabUtility\.(?:CreatePrefab|ReplacePrefab|CreateEmptyPrefab|GetPrefabType|GetPrefabParent|GetPrefabObject|DisconnectPrefabInstance|ConnectGameObjectToPrefab|FindPrefabRoot|FindRootGameObjectWithSameParentPrefab|ReconnectToLastPrefab)\b|\bPrefabType\b`,
+		Obsolete: "2018.3", Replacement: "PrefabUtility.SaveAsPrefabAsset and the 2018.3 prefab API"},
+	{ID: "terrain-splat", API: "TerrainData.splatPrototypes", Pattern: `\bsplatPrototypes\b|\bSplatPrototype\b`,
+		Obsolete: "2018.3", Replacement: "TerrainData.terrainLayers, TerrainLayer"},
+	{ID: "movie-texture", API: "MovieTexture", Pattern: `\bMovieTexture\b`,
+		Obsolete: "2018.2", Replacement: "VideoPlayer"},
+	{ID: "external-eval", API: "Application.ExternalCall / ExternalEval", Pattern: `\bApplication\.(?:ExternalCall|ExternalEval)\b`,
+		Obsolete: "2018.2", Replacement: "a .jslib plug-in"},
+	{ID: "legacy-networking", API: "Legacy networking (NetworkView, Network, MasterServer)", Pattern: `\bNetworkView\b|\bMasterServer\.|\bNetwork\.(?:Instantiate|Connect|InitializeServer|Disconnect|isServer|isClient|peerType)\b|\[RPC\]`,
+		Obsolete: "5.1", Removed: "2018.2", Replacement: "Netcode for GameObjects or another networking library"},
+	{ID: "build-callbacks", API: "IPreprocessBuild, IPostprocessBuild, IProcessScene", Pattern: `\b(?:IPreprocessBuild|IPostprocessBuild|IProcessScene)\b`,
+		Obsolete: "2018.1", Replacement: "IPreprocessBuildWithReport, IPostprocessBuildWithReport, IProcessSceneWithReport"},
+	{ID: "hierarchy-changed", API: "EditorApplication.hierarchyWindowChanged / projectWindowChanged", Pattern: `\bEditorApplication\.(?:hierarchyWindowChanged|projectWindowChanged)\b`,
+		Obsolete: "2018.1", Replacement: "EditorApplication.hierarchyChanged, projectChanged"},
+	{ID: "build-target-macos", API: "BuildTarget.StandaloneOSXIntel / Intel64 / Universal", Pattern: `\bBuildTarget\.StandaloneOSX(?:Intel|Intel64|Universal)\b`,
+		Obsolete: "2017.3", Replacement: "BuildTarget.StandaloneOSX"},
+	{ID: "playmode-changed", API: "EditorApplication.playmodeStateChanged", Pattern: `\bEditorApplication\.playmodeStateChanged\b`,
+		Obsolete: "2017.2", Replacement: "EditorApplication.playModeStateChanged, pauseStateChanged"},
+	{ID: "vr-namespace", API: "UnityEngine.VR, VRSettings, VRDevice", Pattern: `\bUnityEngine\.VR\b|\bVRSettings\b|\bVRDevice\b`,
+		Obsolete: "2017.2", Replacement: "UnityEngine.XR, XRSettings, XRDevice"},
+	{ID: "web-request-send", API: "UnityWebRequest.Send", Pattern: `(?i)\b\w*(?:request|uwr|req)\w*\.Send\s*\(\s*\)`,
+		Obsolete: "2017.2", Replacement: "UnityWebRequest.SendWebRequest"},
+	{ID: "capture-screenshot", API: "Application.CaptureScreenshot", Pattern: `\bApplication\.CaptureScreenshot\b`,
+		Obsolete: "2017.1", Replacement: "ScreenCapture.CaptureScreenshot"},
+	{ID: "handles-caps", API: "Handles.CubeCap, SphereCap... and DrawCapFunction", Pattern: `\bHandles\.(?:CubeCap|SphereCap|ConeCap|CylinderCap|CircleCap|RectangleCap|DotCap|ArrowCap|SelectionFrame|DrawCapFunction)\b`,
+		Obsolete: "5.6", Replacement: "Handles.CubeHandleCap... and Handles.CapFunction"},
+	{ID: "bundle-identifier", API: "PlayerSettings.bundleIdentifier", Pattern: `\bPlayerSettings\.(?:bundleIdentifier|iPhoneBundleIdentifier)\b`,
+		Obsolete: "5.6", Replacement: "PlayerSettings.applicationIdentifier, SetApplicationIdentifier"},
+	{ID: "navmesh-stop", API: "NavMeshAgent.Stop / Resume", Pattern: `(?i)\b\w*agent\w*\.(?:Stop|Resume)\s*\(\s*\)`,
+		Obsolete: "5.6", Replacement: "NavMeshAgent.isStopped"},
+	{ID: "camera-hdr", API: "Camera.hdr", Pattern: `(?i)\b\w*cam\w*\.hdr\b`,
+		Obsolete: "5.6", Replacement: "Camera.allowHDR"},
+	{ID: "particle-legacy", API: "ParticleSystem.startSpeed, emissionRate...", Pattern: `(?i)\b\w*(?:particle|ps)\w*\.(?:emissionRate|enableEmission|startLifetime|startSpeed|startSize|startColor|startRotation|startDelay|maxParticles|gravityModifier|playbackSpeed|simulationSpace)\b`,
+		Obsolete: "5.5", Replacement: "the main and emission modules (ps.main.startSpeed...)"},
+	{ID: "unload-scene", API: "SceneManager.UnloadScene", Pattern: `\bSceneManager\.UnloadScene\s*\(`,
+		Obsolete: "5.5", Replacement: "SceneManager.UnloadSceneAsync"},
+	{ID: "texture-importer-format", API: "TextureImporterFormat.AutomaticCompressed...", Pattern: `\bTextureImporterFormat\.Automatic(?:Compressed|16bit|Truecolor|Crunched)\b`,
+		Obsolete: "5.5", Replacement: "TextureImporter.textureCompression"},
+	{ID: "build-target-webplayer", API: "BuildTarget.WebPlayer", Pattern: `\bBuildTarget(?:Group)?\.WebPlayer\w*`,
+		Obsolete: "5.4", Removed: "5.4", Replacement: "WebGL"},
+	{ID: "random-seed", API: "Random.seed", Pattern: `\bRandom\.seed\b`,
+		Obsolete: "5.4", Replacement: "Random.InitState, Random.state"},
+	{ID: "application-loadlevel", API: "Application.LoadLevel, loadedLevel...", Pattern: `\bApplication\.(?:LoadLevel\w*|loadedLevel\w*|levelCount|isLoadingLevel|UnloadLevel)\b`,
+		Obsolete: "5.3", Replacement: "SceneManager"},
+	{ID: "editor-scene", API: "EditorApplication.OpenScene, SaveScene, currentScene...", Pattern: `\bEditorApplication\.(?:OpenScene\w*|NewScene|NewEmptyScene|SaveScene|SaveCurrentSceneIfUserWantsTo|currentScene|isSceneDirty|MarkSceneDirty)\b`,
+		Obsolete: "5.3", Replacement: "EditorSceneManager"},
+	{ID: "assetbundle-create", API: "AssetBundle.CreateFromFile / CreateFromMemory", Pattern: `\bAssetBundle\.(?:CreateFromFile|CreateFromMemory\w*)\b`,
+		Obsolete: "5.3", Replacement: "AssetBundle.LoadFromFile, LoadFromMemory(Async)"},
+	{ID: "build-asset-bundle", API: "BuildPipeline.BuildAssetBundle", Pattern: `\bBuildPipeline\.(?:BuildAssetBundle|BuildAssetBundleExplicitAssetNames|BuildStreamedSceneAssetBundle)\b`,
+		Obsolete: "5.0", Replacement: "BuildPipeline.BuildAssetBundles"},
+	{ID: "screen-cursor", API: "Screen.lockCursor / showCursor", Pattern: `\bScreen\.(?:lockCursor|showCursor)\b`,
+		Obsolete: "5.0", Replacement: "Cursor.lockState, Cursor.visible"},
+	{ID: "log-callback", API: "Application.RegisterLogCallback", Pattern: `\bApplication\.RegisterLogCallback\w*\b`,
+		Obsolete: "5.0", Replacement: "Application.logMessageReceived(Threaded)"},
+	{ID: "component-shortcuts", API: "gameObject.rigidbody, renderer, collider...", Pattern: `\bgameObject\.(?:rigidbody|rigidbody2D|renderer|collider|collider2D|audio|camera|light|animation|hingeJoint|particleSystem|constantForce)\b`,
+		Obsolete: "5.0", Removed: "5.0", Replacement: "GetComponent<T>()"},
+	{ID: "gameobject-active", API: "GameObject.active", Pattern: `\bgameObject\.active\b`,
+		Obsolete: "4.0", Replacement: "SetActive, activeSelf, activeInHierarchy"},
+}
+
+// Finding kinds, in report order
+const (
+	statusRemoved  = "removed"
+	statusObsolete = "obsolete"
+)
+
+var (
+	projectVersionRegex = regexp.MustCompile(`(?m)^m_EditorVersion:\s*(\S+)`)
+	releaseRegex        = regexp.MustCompile(`^(\d+)\.(\d+)`)
+	stringRegex         = regexp.MustCompile(`@"(?:[^"]|"")*"|"(?:[^"\\\n]|\\.)*"|'(?:[^'\\\n]|\\.)'`)
+	// #if UNITY_2023_1_OR_NEWER, #if !UNITY_6000_0_OR_NEWER
+	guardRegex = regexp.MustCompile(`^#\s*(if|elif|else|endif)\b(.*)$`)
+	newerRegex = regexp.MustCompile(`(!?)\s*UNITY_(\d+)_(\d+)_OR_NEWER\b`)
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// apiRule is one API change of the table
+type apiRule struct {
+	ID          string `json:"id"`
+	API         string `json:"api"`
+	Pattern     string `json:"pattern"`
+	Obsolete    string `json:"obsolete"`
+	Removed     string `json:"removed,omitempty"`
+	Replacement string `json:"replacement"`
+	Note        string `json:"note,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+
+	regex *regexp.Regexp
+}
+
+// tableFile is ApiUpgrades.json
+type tableFile struct {
+	Rules []apiRule `json:"rules"`
+}
+
+// hit is one use of an API
+type hit struct {
+	rule *apiRule
+	file string
+	line int
+	code string
+}
+
+// guard is one open #if of a script: the version of its UNITY_X_Y_OR_NEWER
+// symbol, whether it was negated, and whether the #else branch is being read
+type guard struct {
+	version string
+	negated bool
+	inElse  bool
+}
+
+// ============================================================
+// Rule Table
+// ============================================================
+
+// loadRules returns the built-in rules with those of a table file added, or
+// replacing the built-in ones with the same id
+func loadRules(path string) ([]*apiRule, error) {
+	var extra []apiRule
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var table tableFile
+		if err := json.Unmarshal(data, &table); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", filepath.Base(path), err)
+		}
+		extra = table.Rules
+	}
+	var rules []*apiRule
+	index := make(map[string]int)
+	for _, list := range [][]apiRule{builtinRules, extra} {
+		for i := range list {
+			r := list[i]
+			if r.ID == "" {
+				return nil, fmt.Errorf("%s: rule %d has no id", filepath.Base(path), i+1)
+			}
+			if at, ok := index[r.ID]; ok {
+				rules[at] = &r
+			} else {
+				index[r.ID] = len(rules)
+				rules = append(rules, &r)
+			}
+		}
+	}
+	var enabled []*apiRule
+	for _, r := range rules {
+		if r.Disabled {
+			continue
+		}
+		if r.API == "" {
+			r.API = r.ID
+		}
+		if releaseKey(r.Obsolete) == nil {
+			return nil, fmt.Errorf("rule %s: obsolete version \"%s\" is not like 2023.1", r.ID, r.Obsolete)
+		}
+		if r.Removed != "" && releaseKey(r.Removed) == nil {
+			return nil, fmt.Errorf("rule %s: removed version \"%s\" is not like 2023.1", r.ID, r.Removed)
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil || r.Pattern == "" {
+			return nil, fmt.Errorf("rule %s: invalid pattern: %v", r.ID, err)
+		}
+		r.regex = re
+		enabled = append(enabled, r)
+	}
+	return enabled, nil
+}
+
+// newestRelease is the latest version the rules name
+func newestRelease(rules []*apiRule) string {
+	newest := "0.0"
+	for _, r := range rules {
+		for _, v := range []string{r.Obsolete, r.Removed} {
+			if v != "" && releaseLess(newest, v) {
+				newest = v
+			}
+		}
+	}
+	return newest
+}
+
+// status tells what the target version does with a rule's API: removed,
+// obsolete, or "" when it is still fine there
+func (r *apiRule) status(target string) string {
+	switch {
+	case r.Removed != "" && !releaseLess(target, r.Removed):
+		return statusRemoved
+	case !releaseLess(target, r.Obsolete):
+		return statusObsolete
+	}
+	return ""
+}
+
+// since is the version the status of a rule starts with
+func (r *apiRule) since(status string) string {
+	if status == statusRemoved {
+		return r.Removed
+	}
+	return r.Obsolete
+}
+
+// ============================================================
+// Versions
+// ============================================================
+
+// readUnityVersion returns the editor version from ProjectSettings/ProjectVersion.txt
+func readUnityVersion(basePath string) string {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectVersion.txt"))
+	if err != nil {
+		return ""
+	}
+	if m := projectVersionRegex.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// releaseKey is the major and minor number of a version ("2022.3.62f3" -> 2022, 3)
+func releaseKey(v string) []int {
+	m := releaseRegex.FindStringSubmatch(v)
+	if m == nil {
+		return nil
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	return []int{major, minor}
+}
+
+func releaseLess(a, b string) bool {
+	ka, kb := releaseKey(a), releaseKey(b)
+	if ka == nil || kb == nil {
+		return a < b
+	}
+	if ka[0] != kb[0] {
+		return ka[0] < kb[0]
+	}
+	return ka[1] < kb[1]
+}
+
+// release cuts a version to its major and minor number
+func release(v string) string {
+	if k := releaseKey(v); k != nil {
+		return fmt.Sprintf("%d.%d", k[0], k[1])
+	}
+	return v
+}
+
+// ============================================================
+// Scripts
+// ============================================================
+
+// projectScripts lists the C# files under the roots
+func projectScripts(basePath string, roots []string, excludes []*regexp.Regexp) []string {
+	var files []string
+	for _, root := range roots {
+		filepath.Walk(filepath.Join(basePath, filepath.FromSlash(root)), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if strings.HasPrefix(info.Name(), ".") || strings.HasSuffix(info.Name(), "~") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			rel := relPath(basePath, path)
+			if filepath.Ext(path) == ".cs" && !matchesAny(excludes, rel) {
+				files = append(files, rel)
+			}
+			return nil
+		})
+	}
+	sort.Strings(files)
+	return files
+}
+
+// scanScript returns the uses of the rules' APIs in a script, and how many
+// lines were skipped as guarded for older versions than target
+func scanScript(rel string, data []byte, rules []*apiRule, target string) ([]hit, int) {
+	var hits []hit
+	var guards []guard
+	guarded := 0
+	inComment := false
+	for i, raw := range strings.Split(string(data), "\n") {
+		l := strings.TrimSpace(strings.TrimRight(raw, "\r"))
+		if m := guardRegex.FindStringSubmatch(l); m != nil {
+			switch m[1] {
+			case "if":
+				g := guard{}
+				if v := newerRegex.FindStringSubmatch(m[2]); v != nil {
+					g.version, g.negated = v[2]+"."+v[3], v[1] == "!"
+				}
+				guards = append(guards, g)
+			case "elif", "else":
+				if len(guards) > 0 {
+					guards[len(guards)-1].inElse = true
+				}
+			case "endif":
+				if len(guards) > 0 {
+					guards = guards[:len(guards)-1]
+				}
+			}
+			continue
+		}
+		code := stripComments(stringRegex.ReplaceAllString(l, `""`), &inComment)
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+		dead := false
+		for _, g := range guards {
+			// The branch the target version does not compile
+			if g.version != "" && !releaseLess(target, g.version) && g.negated != g.inElse {
+				dead = true
+			}
+		}
+		for _, r := range rules {
+			if !r.regex.MatchString(code) {
+				continue
+			}
+			if dead {
+				guarded++
+				continue
+			}
+			hits = append(hits, hit{rule: r, file: rel, line: i + 1, code: l})
+		}
+	}
+	return hits, guarded
+}
+
+// stripComments removes // and /* */ comments from a line; inComment carries an
+// open block comment to the next line
+func stripComments(l string, inComment *bool) string {
+	var b strings.Builder
+	for i := 0; i < len(l); i++ {
+		switch {
+		case *inComment:
+			if strings.HasPrefix(l[i:], "*/") {
+				*inComment = false
+				i++
+			}
+		case strings.HasPrefix(l[i:], "//"):
+			return b.String()
+		case strings.HasPrefix(l[i:], "/*"):
+			*inComment = true
+			i++
+		default:
+			b.WriteByte(l[i])
+		}
+	}
+	return b.String()
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+// apiGroup is the uses of one API
+type apiGroup struct {
+	rule   *apiRule
+	status string
+	hits   []hit
+	files  int
+}
+
+// groupHits puts the uses together per API: removed APIs first, then by the
+// version they changed in, newest first
+func groupHits(hits []hit, target string) []*apiGroup {
+	byRule := make(map[*apiRule]*apiGroup)
+	var groups []*apiGroup
+	for _, h := range hits {
+		g := byRule[h.rule]
+		if g == nil {
+			g = &apiGroup{rule: h.rule, status: h.rule.status(target)}
+			byRule[h.rule] = g
+			groups = append(groups, g)
+		}
+		g.hits = append(g.hits, h)
+	}
+	for _, g := range groups {
+		files := make(map[string]bool)
+		for _, h := range g.hits {
+			files[h.file] = true
+		}
+		g.files = len(files)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if a.status != b.status {
+			return a.status == statusRemoved
+		}
+		if sa, sb := a.rule.since(a.status), b.rule.since(b.status); sa != sb {
+			return releaseLess(sb, sa)
+		}
+		return a.rule.ID < b.rule.ID
+	})
+	return groups
+}
+
+// mdEscape keeps "|" from splitting a Markdown table cell
+func mdEscape(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// writeReport writes the uses as CSV for a .csv path, else as a Markdown readiness report
+func writeReport(path, project, current, target string, groups []*apiGroup) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		buf.WriteString("\xEF\xBB\xBF")
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"status", "since", "api", "replacement", "file", "line", "code"})
+		for _, g := range groups {
+			for _, h := range g.hits {
+				w.Write([]string{g.status, g.rule.since(g.status), g.rule.API, g.rule.Replacement, h.file, strconv.Itoa(h.line), h.code})
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		return os.WriteFile(path, buf.Bytes(), 0644)
+	}
+
+	fmt.Fprintf(&buf, "# Upgrade Readiness: %s → %s\n\n%s. ", current, target, project)
+	removed, obsolete := 0, 0
+	for _, g := range groups {
+		if g.status == statusRemoved {
+			removed += len(g.hits)
+		} else {
+			obsolete += len(g.hits)
+		}
+	}
+	switch {
+	case removed > 0:
+		fmt.Fprintf(&buf, "**Not ready**: %d use(s) of removed APIs stop the project from compiling in %s; %d use(s) of obsolete APIs.\n", removed, target, obsolete)
+	case obsolete > 0:
+		fmt.Fprintf(&buf, "**Ready with warnings**: %d use(s) of APIs obsolete in %s.\n", obsolete, target)
+	default:
+		fmt.Fprintf(&buf, "**Ready**: no use of an API the table marks obsolete or removed in %s.\n", target)
+	}
+	if len(groups) == 0 {
+		return os.WriteFile(path, buf.Bytes(), 0644)
+	}
+	buf.WriteString("\n| API | Status | Since | Replacement | Uses | Files |\n| --- | --- | --- | --- | ---: | ---: |\n")
+	for _, g := range groups {
+		fmt.Fprintf(&buf, "| %s | %s | %s | %s | %d | %d |\n", mdEscape(g.rule.API), g.status, g.rule.since(g.status), mdEscape(g.rule.Replacement), len(g.hits), g.files)
+	}
+	for _, g := range groups {
+		verb := "Obsolete"
+		if g.status == statusRemoved {
+			verb = "Removed"
+		}
+		fmt.Fprintf(&buf, "\n## %s\n\n%s in %s; use %s.", g.rule.API, verb, g.rule.since(g.status), g.rule.Replacement)
+		if g.rule.Note != "" {
+			buf.WriteString(" " + g.rule.Note + ".")
+		}
+		buf.WriteString("\n\n| File | Line | Code |\n| --- | ---: | --- |\n")
+		for _, h := range g.hits {
+			fmt.Fprintf(&buf, "| `%s` | %d | `%s` |\n", h.file, h.line, mdEscape(strings.ReplaceAll(h.code, "`", "'")))
+		}
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode      bool
+		strict      bool
+		targetFlag  string
+		tableFlag   string
+		outFlag     string
+		excludeFlag string
+	)
+
+	flag.StringVar(&targetFlag, "target", "", "Unity version to upgrade to, e.g. 6000.0 (default: the newest version the table knows)")
+	flag.StringVar(&tableFlag, "table", "", "Rules added to the built-in table (default: "+defaultTableFile+" if present)")
+	flag.StringVar(&outFlag, "out", "", "Also write the report to this file: Markdown, or CSV for a .csv path")
+	flag.StringVar(&excludeFlag, "exclude", "", "Comma-separated globs of files left out")
+	flag.BoolVar(&strict, "strict", false, "Obsolete APIs fail the check too, not only removed ones")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the folders ("Assets/Game -ci")
+	flag.Parse()
+	var roots []string
+	for flag.NArg() > 0 {
+		roots = append(roots, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_api_upgrade")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	excludes, err := compileGlobs(excludeFlag)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+	if targetFlag != "" && releaseKey(targetFlag) == nil {
+		fail(2, "-target \"%s\" is not a Unity version like 6000.0", targetFlag)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if len(roots) == 0 {
+		roots = []string{"Assets"}
+		if info, err := os.Stat(filepath.Join(basePath, "Packages")); err == nil && info.IsDir() {
+			roots = append(roots, "Packages")
+		}
+	}
+	for i, r := range roots {
+		abs := r
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(basePath, filepath.FromSlash(r))
+		}
+		if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+			fail(2, "folder %s does not exist", r)
+		}
+		roots[i] = relPath(basePath, abs)
+	}
+
+	tablePath := tableFlag
+	if tablePath == "" {
+		tablePath = defaultTableFile
+		if _, err := os.Stat(filepath.Join(basePath, tablePath)); err != nil {
+			tablePath = ""
+		}
+	}
+	if tablePath != "" && !filepath.IsAbs(tablePath) {
+		tablePath = filepath.Join(basePath, filepath.FromSlash(tablePath))
+	}
+	rules, err := loadRules(tablePath)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+	target := release(targetFlag)
+	if target == "" {
+		target = newestRelease(rules)
+	}
+	current := readUnityVersion(basePath)
+	if current == "" {
+		current = "?"
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  API UPGRADE READINESS"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:  %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Upgrade:  %s -> %s\n"), current, target)
+	fmt.Printf(tr("  Scanning: %s\n"), strings.Join(roots, ", "))
+	if tablePath != "" {
+		fmt.Printf(tr("  Table:    built-in + %s\n"), relPath(basePath, tablePath))
+	}
+	if releaseKey(current) != nil && releaseLess(target, current) {
+		fmt.Printf(tr("[WARNING] The target %s is older than the project's editor %s.\n"), target, current)
+	}
+
+	var active []*apiRule
+	for _, r := range rules {
+		if r.status(target) != "" {
+			active = append(active, r)
+		}
+	}
+	var hits []hit
+	scripts, guarded := 0, 0
+	for _, rel := range projectScripts(basePath, roots, excludes) {
+		data, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(rel)))
+		if err != nil {
+			fmt.Printf(tr("[WARNING] %s: %v\n"), rel, err)
+			continue
+		}
+		scripts++
+		h, g := scanScript(rel, data, active, target)
+		hits = append(hits, h...)
+		guarded += g
+	}
+	fmt.Printf(tr("  Checked:  %d script(s) against %d API change(s) up to %s\n"), scripts, len(active), target)
+	if guarded > 0 {
+		fmt.Printf(tr("  Skipped:  %d use(s) behind #if guards %s does not compile\n"), guarded, target)
+	}
+
+	groups := groupHits(hits, target)
+	removed, obsolete, upcoming := 0, 0, 0
+	for _, g := range groups {
+		tag := "[OBSOLETE]"
+		if g.status == statusRemoved {
+			tag = "[REMOVED] "
+			removed += len(g.hits)
+		} else {
+			obsolete += len(g.hits)
+		}
+		since := g.rule.since(g.status)
+		if releaseKey(current) == nil || releaseLess(current, since) {
+			upcoming += len(g.hits)
+		}
+		fmt.Printf("\n%s %s (%s %s)\n", tag, g.rule.API, tr(g.status), since)
+		fmt.Printf(tr("  Use %s. %d use(s) in %d file(s).\n"), g.rule.Replacement, len(g.hits), g.files)
+		if g.rule.Note != "" {
+			fmt.Printf("  %s.\n", g.rule.Note)
+		}
+		for i, h := range g.hits {
+			if i == consoleUsesPerAPI {
+				fmt.Printf(tr("    ... and %d more\n"), len(g.hits)-i)
+				break
+			}
+			code := []rune(h.code)
+			if len(code) > 90 {
+				code = append(code[:89], '…')
+			}
+			fmt.Printf("    %s:%d  %s\n", h.file, h.line, string(code))
+		}
+		status := "warning"
+		if g.status == statusRemoved || strict {
+			status = "failed"
+		}
+		for _, h := range g.hits {
+			recordAction(g.status, fmt.Sprintf("%s:%d", h.file, h.line), status,
+				fmt.Sprintf("%s (%s %s): use %s", g.rule.API, g.status, since, g.rule.Replacement), 0)
+		}
+		if status == "failed" {
+			recordError("%s: %d use(s), %s in %s", g.rule.API, len(g.hits), g.status, since)
+		}
+	}
+
+	fmt.Println()
+	switch {
+	case removed > 0:
+		fmt.Printf(tr("[ERROR] Not ready: %d use(s) of APIs removed by %s will not compile; %d use(s) of obsolete APIs.\n"), removed, target, obsolete)
+	case obsolete > 0 && strict:
+		fmt.Printf(tr("[ERROR] %d use(s) of APIs obsolete in %s (-strict).\n"), obsolete, target)
+	case obsolete > 0:
+		fmt.Printf(tr("[WARNING] Ready with warnings: %d use(s) of APIs obsolete in %s.\n"), obsolete, target)
+	default:
+		fmt.Printf(tr("[OK] Ready: no use of an API the table marks obsolete or removed in %s.\n"), target)
+	}
+	if len(groups) > 0 && current != "?" {
+		fmt.Printf(tr("  %d of them change with the upgrade; the others are already obsolete in %s.\n"), upcoming, release(current))
+	}
+
+	if outFlag != "" {
+		out := outFlag
+		if !filepath.IsAbs(out) {
+			out = filepath.Join(basePath, filepath.FromSlash(out))
+		}
+		if err := writeReport(out, filepath.Base(basePath), release(current), target, groups); err != nil {
+			fail(1, "cannot write %s: %v", outFlag, err)
+		}
+		recordArtifact(out)
+		fmt.Printf(tr("[OK] Wrote %s\n"), relPath(basePath, out))
+	}
+	if len(groups) > 0 {
+		fmt.Println(tr("[TIP] The table covers common API changes, not every one; the Unity upgrade guides and the compiler after the upgrade have the full list."))
+	}
+	if removed > 0 || (strict && obsolete > 0) {
+		exit(1)
+	}
+	exit(0)
+}
+
+// ============================================================
+// Path Filters
+// ============================================================
+
+// globToRegexp converts a glob (*, ?, **) on slash-separated project paths. A
+// pattern without a slash matches the file name in any folder.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compileGlobs parses a comma-separated glob list
+func compileGlobs(list string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := globToRegexp(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, rel string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+// relPath shows a path relative to the project root, with forward slashes
+func relPath(basePath, p string) string {
+	if rel, err := filepath.Rel(basePath, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return p
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...":                                     "\n按回车键继续...",
+	"[ERROR] %v\n":                                                     "[错误] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                       "[错误] 无法获取当前目录：%v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[错误] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  API UPGRADE READINESS":                                          "  API 升级就绪检查",
+	"  Project:  %s\n":                                                 "  项目：    %s\n",
+	"  Upgrade:  %s -> %s\n":                                           "  升级：    %s -> %s\n",
+	"  Scanning: %s\n":                                                 "  扫描：    %s\n",
+	"  Table:    built-in + %s\n":                                      "  规则表：  内置 + %s\n",
+	"[WARNING] The target %s is older than the project's editor %s.\n": "[警告] 目标版本 %s 比项目的编辑器版本 %s 更旧。\n",
+	"[WARNING] %s: %v\n":                                               "[警告] %s：%v\n",
+	"  Checked:  %d script(s) against %d API change(s) up to %s\n":     "  已检查：  %d 个脚本，对照截至 %[3]s 的 %[2]d 项 API 变更\n",
+	"  Skipped:  %d use(s) behind #if guards %s does not compile\n":    "  已跳过：  %d 处用法位于 %s 不编译的 #if 分支中\n",
+	"  Use %s. %d use(s) in %d file(s).\n":                             "  请改用 %s。%d 处用法，涉及 %d 个文件。\n",
+	"    ... and %d more\n":                                            "    ……另有 %d 处\n",
+	"[ERROR] Not ready: %d use(s) of APIs removed by %s will not compile; %d use(s) of obsolete APIs.\n": "[错误] 尚未就绪：%[1]d 处使用了 %[2]s 中已移除的 API，将无法编译；%[3]d 处使用了已过时的 API。\n",
+	"[ERROR] %d use(s) of APIs obsolete in %s (-strict).\n":                                              "[错误] %d 处使用了 %s 中已过时的 API（-strict）。\n",
+	"[WARNING] Ready with warnings: %d use(s) of APIs obsolete in %s.\n":                                 "[警告] 可以升级但有警告：%d 处使用了 %s 中已过时的 API。\n",
+	"[OK] Ready: no use of an API the table marks obsolete or removed in %s.\n":                          "[完成] 已就绪：未使用规则表中标记为在 %s 已过时或已移除的 API。\n",
+	"  %d of them change with the upgrade; the others are already obsolete in %s.\n":                     "  其中 %d 处随本次升级变化；其余在 %s 中已经过时。\n",
+	"[OK] Wrote %s\n": "[完成] 已写入 %s\n",
+	"[TIP] The table covers common API changes, not every one; the Unity upgrade guides and the compiler after the upgrade have the full list.": "[提示] 规则表覆盖常见的 API 变更而非全部；完整列表请参阅 Unity 升级指南及升级后的编译器输出。",
+	"removed":  "已移除",
+	"obsolete": "已过时",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
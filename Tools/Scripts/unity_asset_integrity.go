@@ -0,0 +1,1677 @@
+// Unity Asset Integrity Checker — Find truncated and corrupted binary assets.
+// Reads the structure of the common binary formats under Assets/ (PNG chunks and
+// CRCs, JPEG markers, WAV and Ogg containers, binary and ASCII FBX) and reports
+// files a bad copy, an interrupted transfer or a failed checkout left broken,
+// before Unity imports them as pink textures or silent clips. Git LFS pointer
+// files checked out in place of the real asset (a clone made without Git LFS)
+// are found for every file type, and -pull fetches just those files. Exits with
+// 1 when a file is broken, so it can run as a CI check.
+//
+// Build: go build unity_asset_integrity.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_asset_integrity                          # Assets/ and embedded packages
+//	unity_asset_integrity Assets/Art/Textures      # only these folders or files
+//	unity_asset_integrity -types png,wav -jobs 8
+//	unity_asset_integrity -ci -annotate github
+//	unity_asset_integrity -pull                    # git lfs pull the pointer files
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Checked formats by extension
+var formatByExt = map[string]string{
+	".png":  "png",
+	".jpg":  "jpg",
+	".jpeg": "jpg",
+	".wav":  "wav",
+	".ogg":  "ogg",
+	".fbx":  "fbx",
+}
+
+var (
+	pngSignature       = []byte("\x89PNG\r\n\x1a\n")
+	fbxBinarySignature = []byte("Kaydara FBX Binary  \x00")
+)
+
+// Git LFS pointer files start with this line and are never larger than
+// lfsPointerMaxSize (https://github.com/git-lfs/git-lfs/blob/main/docs/spec.md)
+const (
+	lfsPointerPrefix  = "version https://git-lfs.github.com/spec/"
+	lfsPointerMaxSize = 1024
+)
+
+// Files that are text by nature and never stored in Git LFS; not opened when
+// looking for pointers
+var textExts = map[string]bool{
+	".meta": true, ".cs": true, ".asmdef": true, ".asmref": true, ".json": true,
+	".txt": true, ".md": true, ".shader": true, ".hlsl": true, ".cginc": true,
+	".uss": true, ".uxml": true, ".xml": true,
+}
+
+// Include patterns passed to one "git lfs pull", so the command line stays well
+// below the Windows limit
+const lfsPullBatchChars = 8000
+
+// How far from the end of a JPEG the end-of-image marker is looked for; some
+// cameras and editors append metadata after it
+const jpegTailScan = 64 * 1024
+
+// Problems listed per file in the report before "...and N more"
+const maxListed = 200
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// issue is one problem found in a file. Errors mean the file is broken; warnings
+// mean it is readable but unusual (data after the end marker, size mismatches).
+type issue struct {
+	level   string // "error" or "warning"
+	message string
+}
+
+// checkedFile is the result for one asset
+type checkedFile struct {
+	path       string // project-relative, forward slashes
+	format     string // "" for files only checked for being an LFS pointer
+	size       int64
+	lfsPointer bool
+	issues     []issue
+}
+
+// fileReader is what the format checks read from: sequential reads with the
+// offset tracked, and random access for trailers
+type fileReader struct {
+	f    *os.File
+	r    *bufio.Reader
+	size int64
+	pos  int64
+}
+
+func (fr *fileReader) Read(p []byte) (int, error) {
+	n, err := fr.r.Read(p)
+	fr.pos += int64(n)
+	return n, err
+}
+
+// readFull reads exactly len(p) bytes; io.ErrUnexpectedEOF or io.EOF at the end
+func (fr *fileReader) readFull(p []byte) error {
+	_, err := io.ReadFull(fr, p)
+	return err
+}
+
+// skip moves n bytes forward, feeding them to w when it is not nil
+func (fr *fileReader) skip(n int64, w io.Writer) error {
+	if w == nil {
+		w = io.Discard
+	}
+	copied, err := io.CopyN(w, fr, n)
+	if err == nil && copied < n {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// seek jumps to an absolute offset
+func (fr *fileReader) seek(offset int64) error {
+	if _, err := fr.f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	fr.r.Reset(fr.f)
+	fr.pos = offset
+	return nil
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Scanning
+// ============================================================
+
+// findFiles lists the checked files under the given roots (project-relative
+// folders or files). Hidden folders and folders ending in "~" are skipped, as
+// Unity does not import them. With pointers, small files of any other binary
+// type are listed too, to be checked for being an LFS pointer.
+func findFiles(basePath string, roots []string, formats map[string]bool, pointers bool) []string {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(p string, size int64) {
+		ext := strings.ToLower(filepath.Ext(p))
+		format := formatByExt[ext]
+		if format == "" {
+			if !pointers || textExts[ext] || size > lfsPointerMaxSize {
+				return
+			}
+		} else if len(formats) > 0 && !formats[format] {
+			return
+		}
+		rel, err := filepath.Rel(basePath, p)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			rel = p
+		}
+		rel = filepath.ToSlash(rel)
+		if !seen[rel] {
+			seen[rel] = true
+			files = append(files, rel)
+		}
+	}
+	for _, root := range roots {
+		rootPath := root
+		if !filepath.IsAbs(rootPath) {
+			rootPath = filepath.Join(basePath, filepath.FromSlash(root))
+		}
+		filepath.Walk(rootPath, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if name := info.Name(); p != rootPath && (strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.Mode().IsRegular() {
+				add(p, info.Size())
+			}
+			return nil
+		})
+	}
+	sort.Strings(files)
+	return files
+}
+
+// defaultRoots is Assets/ plus every embedded package
+func defaultRoots(basePath string) []string {
+	roots := []string{"Assets"}
+	entries, _ := os.ReadDir(filepath.Join(basePath, "Packages"))
+	for _, e := range entries {
+		if _, err := os.Stat(filepath.Join(basePath, "Packages", e.Name(), "package.json")); e.IsDir() && err == nil {
+			roots = append(roots, "Packages/"+e.Name())
+		}
+	}
+	return roots
+}
+
+// parallel runs fn for 0..n-1 on up to jobs goroutines, calling progress after
+// each item from one goroutine at a time
+func parallel(n, jobs int, fn func(i int), progress func(done int)) {
+	if jobs < 1 {
+		jobs = 1
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+				mu.Lock()
+				done++
+				if progress != nil {
+					progress(done)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// checkFile opens one file and runs the check for its format
+func checkFile(basePath, rel string) *checkedFile {
+	c := &checkedFile{path: rel, format: formatByExt[strings.ToLower(filepath.Ext(rel))]}
+	p := rel
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(basePath, filepath.FromSlash(rel))
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		c.issues = append(c.issues, issue{"error", fmt.Sprintf("cannot read: %v", err)})
+		return c
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		c.issues = append(c.issues, issue{"error", fmt.Sprintf("cannot read: %v", err)})
+		return c
+	}
+	c.size = info.Size()
+	if c.size == 0 {
+		c.issues = append(c.issues, issue{"error", "empty file"})
+		return c
+	}
+	fr := &fileReader{f: f, r: bufio.NewReaderSize(f, 64*1024), size: c.size}
+	if c.size <= lfsPointerMaxSize {
+		if pointer, ok := readLFSPointer(fr); ok {
+			c.lfsPointer = true
+			c.issues = append(c.issues, errorf("Git LFS pointer instead of the file (%s)", pointer))
+			return c
+		}
+		fr.seek(0)
+	}
+	switch c.format {
+	case "png":
+		c.issues = checkPNG(fr)
+	case "jpg":
+		c.issues = checkJPEG(fr)
+	case "wav":
+		c.issues = checkWAV(fr)
+	case "ogg":
+		c.issues = checkOgg(fr)
+	case "fbx":
+		c.issues = checkFBX(fr)
+	}
+	return c
+}
+
+// ============================================================
+// Commands
+// ============================================================
+
+// commandRunner starts every external program the tool uses. Output is for
+// commands that only read (rev-parse, lfs version); Run is for commands that
+// change something (lfs pull).
+//
+// Two environment variables swap the runner for tests and bug reports:
+//   - UNITYSTARTER_EXEC_RECORD=<file.json> runs commands as usual and saves each
+//     one with its output and exit code
+//   - UNITYSTARTER_EXEC_REPLAY=<file.json> starts nothing and answers every
+//     command from such a file, so a run can be repeated without the programs
+//
+// The project root and the temp folder are written as $ROOT and $TMP in fixtures
+// so they replay on any machine.
+type commandRunner interface {
+	Output(ctx context.Context, name string, args ...string) (stdout, stderr []byte, err error)
+	Run(ctx context.Context, name string, args ...string) (stdout, stderr []byte, err error)
+}
+
+var runner commandRunner = execRunner{}
+
+// replayingCommands is set when commands are answered from a fixture; lookPath
+// then accepts programs that are not installed
+var replayingCommands bool
+
+// setupRunner picks the runner for this run
+func setupRunner(root string) error {
+	var r commandRunner = execRunner{}
+	if path := os.Getenv("UNITYSTARTER_EXEC_REPLAY"); path != "" {
+		replay, err := loadReplayRunner(path, root)
+		if err != nil {
+			return err
+		}
+		r = replay
+		replayingCommands = true
+	}
+	if path := os.Getenv("UNITYSTARTER_EXEC_RECORD"); path != "" {
+		r = &recordingRunner{inner: r, path: path, hide: fixturePaths(root, false)}
+	}
+	runner = r
+	return nil
+}
+
+// lookPath reports whether a program can be started
+func lookPath(name string) error {
+	if replayingCommands {
+		return nil
+	}
+	_, err := exec.LookPath(name)
+	return err
+}
+
+// commandLine formats a command the way it could be pasted into a shell
+func commandLine(name string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, a := range append([]string{name}, args...) {
+		if a == "" || strings.ContainsAny(a, " \t\"'") {
+			a = strconv.Quote(a)
+		}
+		parts = append(parts, a)
+	}
+	return strings.Join(parts, " ")
+}
+
+// fixturePaths swaps the machine's paths for $ROOT and $TMP, or back with restore.
+// The longer path goes first so a project inside the temp folder stays $ROOT.
+func fixturePaths(root string, restore bool) *strings.Replacer {
+	paths := [][2]string{{root, "$ROOT"}, {filepath.Clean(os.TempDir()), "$TMP"}}
+	if len(paths[1][0]) > len(paths[0][0]) {
+		paths[0], paths[1] = paths[1], paths[0]
+	}
+	var pairs []string
+	for _, p := range paths {
+		if p[0] == "" || p[0] == "." {
+			continue
+		}
+		if restore {
+			pairs = append(pairs, p[1], p[0])
+		} else {
+			pairs = append(pairs, p[0], p[1])
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// execRunner starts the real programs
+type execRunner struct{}
+
+func (execRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+func (e execRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	return e.Output(ctx, name, args...)
+}
+
+// commandRecord is one command in a fixture; Exit is -1 when it did not start
+// or was killed, with the reason in Error
+type commandRecord struct {
+	Args   []string `json:"args"`
+	Stdout string   `json:"stdout,omitempty"`
+	Stderr string   `json:"stderr,omitempty"`
+	Exit   int      `json:"exit"`
+	Error  string   `json:"error,omitempty"`
+}
+
+type commandFixture struct {
+	Commands []commandRecord `json:"commands"`
+}
+
+// recordingRunner saves every command to path, rewriting the file after each
+// one so an interrupted run still leaves a usable fixture
+type recordingRunner struct {
+	inner   commandRunner
+	path    string
+	hide    *strings.Replacer
+	mu      sync.Mutex
+	fixture commandFixture
+}
+
+func (r *recordingRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	stdout, stderr, err := r.inner.Output(ctx, name, args...)
+	r.save(name, args, stdout, stderr, err)
+	return stdout, stderr, err
+}
+
+func (r *recordingRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	stdout, stderr, err := r.inner.Run(ctx, name, args...)
+	r.save(name, args, stdout, stderr, err)
+	return stdout, stderr, err
+}
+
+func (r *recordingRunner) save(name string, args []string, stdout, stderr []byte, err error) {
+	rec := commandRecord{Stdout: r.hide.Replace(string(stdout)), Stderr: r.hide.Replace(string(stderr))}
+	for _, a := range append([]string{name}, args...) {
+		rec.Args = append(rec.Args, r.hide.Replace(a))
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		rec.Exit = exitErr.ExitCode()
+	default:
+		rec.Exit = -1
+		rec.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Commands = append(r.fixture.Commands, rec)
+	data, _ := json.MarshalIndent(r.fixture, "", "  ")
+	os.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// replayRunner answers commands from a fixture. A command that appears several
+// times (the same query, a retried step) gets its recordings in order,
+// the last one repeating once they run out.
+type replayRunner struct {
+	hide    *strings.Replacer
+	show    *strings.Replacer
+	mu      sync.Mutex
+	records map[string][]commandRecord
+}
+
+func loadReplayRunner(path, root string) (*replayRunner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture commandFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r := &replayRunner{hide: fixturePaths(root, false), show: fixturePaths(root, true), records: make(map[string][]commandRecord)}
+	for _, rec := range fixture.Commands {
+		key := strings.Join(rec.Args, "\x00")
+		r.records[key] = append(r.records[key], rec)
+	}
+	return r, nil
+}
+
+func (r *replayRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	var keyArgs []string
+	for _, a := range append([]string{name}, args...) {
+		keyArgs = append(keyArgs, r.hide.Replace(a))
+	}
+	key := strings.Join(keyArgs, "\x00")
+
+	r.mu.Lock()
+	queue := r.records[key]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, nil, fmt.Errorf("no recorded output for: %s", commandLine(name, args))
+	}
+	rec := queue[0]
+	if len(queue) > 1 {
+		r.records[key] = queue[1:]
+	}
+	r.mu.Unlock()
+
+	stdout, stderr := []byte(r.show.Replace(rec.Stdout)), []byte(r.show.Replace(rec.Stderr))
+	switch {
+	case rec.Error != "":
+		return stdout, stderr, errors.New(rec.Error)
+	case rec.Exit != 0:
+		return stdout, stderr, fmt.Errorf("exit status %d", rec.Exit)
+	}
+	return stdout, stderr, nil
+}
+
+func (r *replayRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	return r.Output(ctx, name, args...)
+}
+
+// ============================================================
+// Git LFS Pointers
+// ============================================================
+
+// readLFSPointer reports whether a file is a Git LFS pointer, and describes the
+// object it points at ("oid sha256:1f2e3d4c..., 4.20 MB")
+func readLFSPointer(fr *fileReader) (string, bool) {
+	data, err := io.ReadAll(io.LimitReader(fr, lfsPointerMaxSize+1))
+	if err != nil || !bytes.HasPrefix(data, []byte(lfsPointerPrefix)) {
+		return "", false
+	}
+	var oid, size string
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "oid ") {
+			oid = strings.TrimPrefix(line, "oid ")
+		} else if strings.HasPrefix(line, "size ") {
+			size = strings.TrimPrefix(line, "size ")
+		}
+	}
+	if len(oid) > 19 {
+		oid = oid[:19] + "..."
+	}
+	if n, err := strconv.ParseInt(strings.TrimSpace(size), 10, 64); err == nil {
+		return fmt.Sprintf("oid %s, %s", oid, formatSize(n)), true
+	}
+	return "oid " + oid, true
+}
+
+// gitOutput runs git in dir and returns its trimmed standard output
+func gitOutput(dir string, args ...string) (string, error) {
+	stdout, stderr, err := runner.Output(context.Background(), "git", append([]string{"-C", dir}, args...)...)
+	if err != nil {
+		if msg := strings.TrimSpace(string(stderr)); msg != "" {
+			return "", fmt.Errorf("%s", msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(stdout)), nil
+}
+
+// lfsPull downloads the given project-relative files with "git lfs pull",
+// a few hundred paths per call. The paths are passed to --include relative to
+// the repository root, with glob characters escaped.
+func lfsPull(basePath string, files []string) error {
+	if err := lookPath("git"); err != nil {
+		return fmt.Errorf("git is not installed")
+	}
+	root, err := gitOutput(basePath, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return fmt.Errorf("not a git repository: %v", err)
+	}
+	if _, err := gitOutput(root, "lfs", "version"); err != nil {
+		return fmt.Errorf("Git LFS is not installed; install it from https://git-lfs.com and run 'git lfs install'")
+	}
+	absBase, _ := filepath.Abs(basePath)
+	escape := strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, ",", `\,`)
+	var batch []string
+	length := 0
+	pull := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		stdout, stderr, err := runner.Run(context.Background(), "git", "-C", root, "lfs", "pull", "--include="+strings.Join(batch, ","), "--exclude=")
+		os.Stdout.Write(stdout)
+		os.Stderr.Write(stderr)
+		batch, length = nil, 0
+		return err
+	}
+	for _, f := range files {
+		rel, err := filepath.Rel(root, filepath.Join(absBase, filepath.FromSlash(f)))
+		if err != nil {
+			return err
+		}
+		pattern := escape.Replace(filepath.ToSlash(rel))
+		if length+len(pattern) > lfsPullBatchChars {
+			if err := pull(); err != nil {
+				return fmt.Errorf("git lfs pull: %v", err)
+			}
+		}
+		batch = append(batch, pattern)
+		length += len(pattern) + 1
+	}
+	if err := pull(); err != nil {
+		return fmt.Errorf("git lfs pull: %v", err)
+	}
+	return nil
+}
+
+// ============================================================
+// Format Checks
+// ============================================================
+
+func errorf(format string, a ...interface{}) issue {
+	return issue{"error", fmt.Sprintf(format, a...)}
+}
+
+func warningf(format string, a ...interface{}) issue {
+	return issue{"warning", fmt.Sprintf(format, a...)}
+}
+
+// notFormat describes a file that does not start like its extension says,
+// quoting the start when it is text
+func notFormat(fr *fileReader, name string) issue {
+	head := make([]byte, 48)
+	fr.seek(0)
+	n, _ := io.ReadFull(fr, head)
+	head = head[:n]
+	if line := bytes.SplitN(head, []byte("\n"), 2)[0]; len(line) > 0 && isPrintable(line) {
+		return errorf("not a %s file (starts with \"%s\")", name, strings.TrimSpace(string(line)))
+	}
+	return errorf("not a %s file", name)
+}
+
+func isPrintable(b []byte) bool {
+	for _, c := range b {
+		if (c < 0x20 || c > 0x7e) && c != '\t' && c != '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+// checkPNG walks the chunks and verifies each CRC. The first chunk must be IHDR
+// and the last IEND; a file that ends before IEND was cut off.
+func checkPNG(fr *fileReader) []issue {
+	sig := make([]byte, len(pngSignature))
+	if err := fr.readFull(sig); err != nil || !bytes.Equal(sig, pngSignature) {
+		return []issue{notFormat(fr, "PNG")}
+	}
+	header := make([]byte, 8)
+	stored := make([]byte, 4)
+	for first := true; ; first = false {
+		offset := fr.pos
+		if err := fr.readFull(header); err != nil {
+			return []issue{errorf("truncated at %d of %d bytes: no IEND chunk", offset, fr.size)}
+		}
+		length := int64(binary.BigEndian.Uint32(header[:4]))
+		chunk := string(header[4:])
+		if first && chunk != "IHDR" {
+			return []issue{errorf("first chunk is '%s', not IHDR", printableName(header[4:]))}
+		}
+		if !isChunkName(header[4:]) {
+			return []issue{errorf("corrupt chunk header at offset %d", offset)}
+		}
+		if offset+12+length > fr.size {
+			return []issue{errorf("truncated: chunk %s at offset %d needs %d bytes, the file ends after %d", chunk, offset, length, fr.size-offset-8)}
+		}
+		crc := crc32.NewIEEE()
+		crc.Write(header[4:])
+		if err := fr.skip(length, crc); err != nil {
+			return []issue{errorf("truncated in chunk %s at offset %d", chunk, offset)}
+		}
+		if err := fr.readFull(stored); err != nil {
+			return []issue{errorf("truncated in chunk %s at offset %d", chunk, offset)}
+		}
+		if binary.BigEndian.Uint32(stored) != crc.Sum32() {
+			return []issue{errorf("chunk %s at offset %d is corrupt (CRC mismatch)", chunk, offset)}
+		}
+		if chunk == "IEND" {
+			if rest := fr.size - fr.pos; rest > 0 {
+				return []issue{warningf("%d byte(s) after the IEND chunk", rest)}
+			}
+			return nil
+		}
+	}
+}
+
+func isChunkName(b []byte) bool {
+	for _, c := range b {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+func printableName(b []byte) string {
+	if isPrintable(b) {
+		return string(b)
+	}
+	return fmt.Sprintf("%x", b)
+}
+
+// checkJPEG walks the header segments up to the first scan, then looks for the
+// end-of-image marker near the end of the file
+func checkJPEG(fr *fileReader) []issue {
+	soi := make([]byte, 2)
+	if err := fr.readFull(soi); err != nil || soi[0] != 0xFF || soi[1] != 0xD8 {
+		return []issue{notFormat(fr, "JPEG")}
+	}
+	b := make([]byte, 2)
+	for {
+		offset := fr.pos
+		if err := fr.readFull(b[:1]); err != nil {
+			return []issue{errorf("truncated at %d of %d bytes, before the image data", offset, fr.size)}
+		}
+		if b[0] != 0xFF {
+			return []issue{errorf("corrupt marker at offset %d", offset)}
+		}
+		marker := byte(0xFF)
+		for marker == 0xFF {
+			if err := fr.readFull(b[:1]); err != nil {
+				return []issue{errorf("truncated at %d of %d bytes, before the image data", fr.pos, fr.size)}
+			}
+			marker = b[0]
+		}
+		switch {
+		case marker == 0xD9:
+			return []issue{errorf("the image ends at offset %d before any image data", offset)}
+		case marker == 0x01 || marker >= 0xD0 && marker <= 0xD7:
+			continue // no length
+		}
+		if err := fr.readFull(b); err != nil {
+			return []issue{errorf("truncated in the segment at offset %d", offset)}
+		}
+		length := int64(binary.BigEndian.Uint16(b))
+		if length < 2 || offset+2+length > fr.size {
+			return []issue{errorf("truncated: the segment at offset %d needs %d bytes, the file ends after %d", offset, length, fr.size-offset-2)}
+		}
+		if err := fr.skip(length-2, nil); err != nil {
+			return []issue{errorf("truncated in the segment at offset %d", offset)}
+		}
+		if marker == 0xDA { // start of scan: entropy-coded data follows
+			break
+		}
+	}
+
+	// End of image, ignoring the zero or 0xFF padding some encoders add
+	start := fr.size - jpegTailScan
+	if start < fr.pos {
+		start = fr.pos
+	}
+	tail := make([]byte, fr.size-start)
+	if err := fr.seek(start); err != nil || fr.readFull(tail) != nil {
+		return []issue{errorf("cannot read the end of the file")}
+	}
+	end := len(tail)
+	for end > 0 && (tail[end-1] == 0x00 || tail[end-1] == 0xFF && (end < 2 || tail[end-2] != 0xFF)) {
+		end--
+	}
+	if end >= 2 && tail[end-2] == 0xFF && tail[end-1] == 0xD9 {
+		return nil
+	}
+	if i := bytes.LastIndex(tail, []byte{0xFF, 0xD9}); i >= 0 {
+		return []issue{warningf("%d byte(s) after the end-of-image marker", len(tail)-i-2)}
+	}
+	return []issue{errorf("truncated: no end-of-image marker, the image data is cut off")}
+}
+
+// checkWAV walks the RIFF chunks: the sizes must fit the file, and there must be
+// a fmt chunk before the data chunk
+func checkWAV(fr *fileReader) []issue {
+	header := make([]byte, 12)
+	if err := fr.readFull(header); err != nil || string(header[8:12]) != "WAVE" {
+		return []issue{notFormat(fr, "WAV")}
+	}
+	switch string(header[:4]) {
+	case "RF64", "BW64":
+		return nil // 64-bit sizes live in the ds64 chunk; the header says nothing
+	case "RIFF":
+	default:
+		return []issue{notFormat(fr, "WAV")}
+	}
+	var issues []issue
+	riffEnd := 8 + int64(binary.LittleEndian.Uint32(header[4:8]))
+	if riffEnd > fr.size {
+		issues = append(issues, errorf("truncated: the RIFF header says %d bytes, the file has %d", riffEnd, fr.size))
+	} else if riffEnd < fr.size {
+		issues = append(issues, warningf("%d byte(s) after the RIFF data", fr.size-riffEnd))
+	}
+
+	var haveFmt, haveData bool
+	var blockAlign uint16
+	chunk := make([]byte, 8)
+	for fr.pos+8 <= fr.size && fr.pos+8 <= riffEnd {
+		offset := fr.pos
+		if err := fr.readFull(chunk); err != nil {
+			break
+		}
+		id := printableName(chunk[:4])
+		length := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		if offset+8+length > fr.size {
+			if riffEnd > fr.size {
+				return issues // already reported from the header
+			}
+			return append(issues, errorf("truncated: chunk '%s' at offset %d needs %d bytes, the file ends after %d", id, offset, length, fr.size-offset-8))
+		}
+		switch id {
+		case "fmt ":
+			if length < 16 {
+				return append(issues, errorf("the fmt chunk is too short (%d bytes)", length))
+			}
+			fmtData := make([]byte, 16)
+			if err := fr.readFull(fmtData); err != nil {
+				return append(issues, errorf("truncated in the fmt chunk"))
+			}
+			channels := binary.LittleEndian.Uint16(fmtData[2:4])
+			rate := binary.LittleEndian.Uint32(fmtData[4:8])
+			blockAlign = binary.LittleEndian.Uint16(fmtData[12:14])
+			if channels == 0 || rate == 0 || blockAlign == 0 {
+				return append(issues, errorf("the fmt chunk is corrupt (%d channel(s), %d Hz, block size %d)", channels, rate, blockAlign))
+			}
+			haveFmt = true
+			length -= 16
+		case "data":
+			if !haveFmt {
+				return append(issues, errorf("data chunk before the fmt chunk"))
+			}
+			haveData = true
+			if length%int64(blockAlign) != 0 {
+				issues = append(issues, warningf("the data chunk (%d bytes) does not end on a whole sample frame", length))
+			}
+		}
+		if err := fr.skip(length+length%2, nil); err != nil && offset+8+length < fr.size {
+			return append(issues, errorf("truncated in chunk '%s' at offset %d", id, offset))
+		}
+	}
+	if !haveFmt {
+		return append(issues, errorf("no fmt chunk"))
+	}
+	if !haveData {
+		return append(issues, errorf("no data chunk"))
+	}
+	return issues
+}
+
+// Ogg pages are checked with the CRC the format defines: polynomial 0x04c11db7,
+// not bit-reflected, zero start value, computed with the CRC field zeroed
+var oggCRCTable = func() *[256]uint32 {
+	var t [256]uint32
+	for i := range t {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		t[i] = r
+	}
+	return &t
+}()
+
+func oggCRC(crc uint32, data []byte) uint32 {
+	for _, b := range data {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+// checkOgg reads every page: capture pattern, CRC, and the page sequence of each
+// logical stream. The last page of each stream must carry end-of-stream.
+func checkOgg(fr *fileReader) []issue {
+	header := make([]byte, 27)
+	lastSeq := make(map[uint32]uint32)
+	ended := make(map[uint32]bool)
+	var serials []uint32
+	for page := 0; fr.pos < fr.size; page++ {
+		offset := fr.pos
+		if err := fr.readFull(header); err != nil {
+			return []issue{errorf("truncated: page %d at offset %d is cut off", page, offset)}
+		}
+		if string(header[:4]) != "OggS" {
+			if page == 0 {
+				return []issue{notFormat(fr, "Ogg")}
+			}
+			return []issue{errorf("corrupt page header at offset %d", offset)}
+		}
+		flags := header[5]
+		serial := binary.LittleEndian.Uint32(header[14:18])
+		seq := binary.LittleEndian.Uint32(header[18:22])
+		stored := binary.LittleEndian.Uint32(header[22:26])
+		segments := make([]byte, header[26])
+		if err := fr.readFull(segments); err != nil {
+			return []issue{errorf("truncated: page %d at offset %d is cut off", page, offset)}
+		}
+		bodyLen := 0
+		for _, s := range segments {
+			bodyLen += int(s)
+		}
+		body := make([]byte, bodyLen)
+		if err := fr.readFull(body); err != nil {
+			return []issue{errorf("truncated: page %d at offset %d is cut off", page, offset)}
+		}
+
+		copy(header[22:26], []byte{0, 0, 0, 0})
+		crc := oggCRC(oggCRC(oggCRC(0, header), segments), body)
+		if crc != stored {
+			return []issue{errorf("page %d at offset %d is corrupt (CRC mismatch)", page, offset)}
+		}
+		if last, ok := lastSeq[serial]; ok {
+			if seq != last+1 {
+				return []issue{errorf("page %d at offset %d: %d page(s) of the stream are missing", page, offset, int64(seq)-int64(last)-1)}
+			}
+		} else {
+			if flags&0x02 == 0 {
+				return []issue{errorf("page %d at offset %d starts a stream without the beginning-of-stream flag", page, offset)}
+			}
+			serials = append(serials, serial)
+		}
+		lastSeq[serial] = seq
+		if flags&0x04 != 0 {
+			ended[serial] = true
+		}
+	}
+	for _, s := range serials {
+		if !ended[s] {
+			return []issue{errorf("truncated: the stream has no end-of-stream page")}
+		}
+	}
+	return nil
+}
+
+// checkFBX follows the top-level node records of a binary FBX to the null record
+// that closes them; in an ASCII FBX the braces must balance
+func checkFBX(fr *fileReader) []issue {
+	head := make([]byte, 27)
+	n, _ := io.ReadFull(fr, head)
+	head = head[:n]
+	if !bytes.HasPrefix(head, fbxBinarySignature) {
+		fr.seek(0)
+		return checkASCIIFBX(fr)
+	}
+	if n < 27 {
+		return []issue{errorf("truncated: the header is cut off")}
+	}
+	version := binary.LittleEndian.Uint32(head[23:27])
+	wide := version >= 7500 // 64-bit record offsets
+	recordLen := 13
+	if wide {
+		recordLen = 25
+	}
+	record := make([]byte, recordLen)
+	for {
+		offset := fr.pos
+		if err := fr.readFull(record); err != nil {
+			return []issue{errorf("truncated at %d of %d bytes: the node list is not closed", offset, fr.size)}
+		}
+		var end int64
+		var nameLen int
+		if wide {
+			end = int64(binary.LittleEndian.Uint64(record[0:8]))
+			nameLen = int(record[24])
+		} else {
+			end = int64(binary.LittleEndian.Uint32(record[0:4]))
+			nameLen = int(record[12])
+		}
+		if end == 0 && nameLen == 0 {
+			return nil // null record: the top-level list is complete
+		}
+		name := make([]byte, nameLen)
+		if err := fr.readFull(name); err != nil {
+			return []issue{errorf("truncated in the node at offset %d", offset)}
+		}
+		if end <= offset || end > fr.size {
+			return []issue{errorf("truncated or corrupt: node '%s' at offset %d ends at %d, the file has %d bytes", printableName(name), offset, end, fr.size)}
+		}
+		if err := fr.seek(end); err != nil {
+			return []issue{errorf("cannot read node '%s' at offset %d", printableName(name), offset)}
+		}
+	}
+}
+
+// checkASCIIFBX counts braces outside strings and comments
+func checkASCIIFBX(fr *fileReader) []issue {
+	head := make([]byte, 1024)
+	n, _ := io.ReadFull(fr, head)
+	if !bytes.Contains(head[:n], []byte("FBX")) || !isPrintable(bytes.TrimRight(bytes.ReplaceAll(head[:n], []byte("\n"), nil), "\x00")) {
+		return []issue{notFormat(fr, "FBX")}
+	}
+	fr.seek(0)
+	depth := 0
+	inString, inComment := false, false
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := fr.Read(buf)
+		for _, c := range buf[:n] {
+			switch {
+			case inComment:
+				inComment = c != '\n'
+			case inString:
+				inString = c != '"'
+			case c == '"':
+				inString = true
+			case c == ';':
+				inComment = true
+			case c == '{':
+				depth++
+			case c == '}':
+				depth--
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	if depth > 0 {
+		return []issue{errorf("truncated: %d unclosed block(s)", depth)}
+	}
+	if depth < 0 {
+		return []issue{errorf("corrupt: %d unmatched closing brace(s)", -depth)}
+	}
+	return nil
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+// printReport lists every file with problems, errors first, and returns the
+// number of broken files and of files with only warnings
+func printReport(results []*checkedFile) (broken, warned int) {
+	var listed []*checkedFile
+	for _, c := range results {
+		hasError, hasWarning := false, false
+		for _, is := range c.issues {
+			if is.level == "error" {
+				hasError = true
+			} else {
+				hasWarning = true
+			}
+		}
+		if hasError {
+			broken++
+		} else if hasWarning {
+			warned++
+		}
+		if hasError || hasWarning {
+			listed = append(listed, c)
+		}
+	}
+	sort.SliceStable(listed, func(i, j int) bool {
+		return listed[i].issues[0].level == "error" && listed[j].issues[0].level != "error"
+	})
+	if len(listed) == 0 {
+		return broken, warned
+	}
+	fmt.Println(tr("\nPROBLEMS"))
+	for i, c := range listed {
+		if i == maxListed {
+			fmt.Printf(tr("  ...and %d more\n"), len(listed)-maxListed)
+			break
+		}
+		for _, is := range c.issues {
+			tag := "[ERROR]  "
+			if is.level == "warning" {
+				tag = "[WARNING]"
+			}
+			fmt.Printf("  %s %s (%s): %s\n", tag, c.path, formatSize(c.size), is.message)
+		}
+	}
+	return broken, warned
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+func printProgressBar(current, total int) {
+	percent := float64(current) / float64(total)
+	if plainMode || jsonMode {
+		// One line per 10% step instead of redrawing the bar with \r
+		step := int(percent * 10)
+		if current == total || step > int(float64(current-1)/float64(total)*10) {
+			fmt.Printf(tr("Progress: %d/%d (%.0f%%)\n"), current, total, percent*100)
+		}
+		return
+	}
+	barLength := 40
+	filledLength := int(float64(barLength) * percent)
+
+	bar := strings.Repeat("█", filledLength) + strings.Repeat("-", barLength-filledLength)
+	fmt.Printf("\r[%s] %.0f%% (%d/%d)", bar, percent*100, current, total)
+	if current == total {
+		fmt.Println()
+	}
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...":                                     "\n按回车键继续...",
+	"Progress: %d/%d (%.0f%%)\n":                                       "进度: %d/%d (%.0f%%)\n",
+	"[ERROR] %v\n":                                                     "[错误] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                       "[错误] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[错误] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  UNITY ASSET INTEGRITY CHECK":                                    "  Unity 资源完整性检查",
+	"  Project: %s\n":                                                  "  项目: %s\n",
+	"  Folders: %s\n":                                                  "  目录: %s\n",
+	"[--] No PNG, JPEG, WAV, Ogg or FBX files found.":                  "[--] 未找到 PNG、JPEG、WAV、Ogg 或 FBX 文件。",
+	"Checking %d file(s)...\n":                                         "正在检查 %d 个文件...\n",
+	"\nPROBLEMS":                                                       "\n问题",
+	"  ...and %d more\n":                                               "  ...以及另外 %d 项\n",
+	"  SUMMARY":                                                        "  摘要",
+	"  Checked:  %d file(s), %s (%s)\n":                                "  已检查:  %d 个文件, %s (%s)\n",
+	"  Broken:   %d\n":                                                 "  已损坏:   %d\n",
+	"  Warnings: %d\n":                                                 "  警告:     %d\n",
+	"  Took:     %v\n":                                                 "  耗时:     %v\n",
+	"\n[TIP] Restore broken files from version control or re-export them from the source application.": "\n[提示] 请从版本控制中恢复损坏的文件，或从源应用程序重新导出。",
+	"\n[OK] All checked assets are intact.":  "\n[成功] 所有已检查的资源均完好。",
+	"\nPulling %d file(s) with Git LFS...\n": "\n正在使用 Git LFS 拉取 %d 个文件...\n",
+	"[OK] Pulled: %s\n":                      "[成功] 已拉取: %s\n",
+	"[ERROR] Still a pointer: %s\n":          "[错误] 仍为指针文件: %s\n",
+	"Pulled %d of %d file(s)\n":              "已拉取 %d/%d 个文件\n",
+	"  Pointers: %d\n":                       "  LFS 指针: %d\n",
+	"\n[TIP] %d file(s) are Git LFS pointers: the repository was cloned or updated without Git LFS. Install it, run 'git lfs install', then run this tool again with -pull.\n": "\n[提示] %d 个文件是 Git LFS 指针文件：仓库在克隆或更新时未安装 Git LFS。请安装 Git LFS 并运行 'git lfs install'，然后使用 -pull 再次运行本工具。\n",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// CI Annotations
+// ============================================================
+
+// With -annotate, errors and warnings are printed once more as GitHub Actions
+// workflow commands or TeamCity service messages, so the CI lists them on the run
+// page and, when they point at a file, next to that file in the diff.
+var (
+	annotateMode  string              // "", "github" or "teamcity"
+	annotateTool  string              // TeamCity inspection category
+	annotateBase  string              // project root; relative files start here
+	annotateRoot  string              // repository root; CI file paths are relative to it
+	annotateTypes = map[string]bool{} // TeamCity inspection types already declared
+)
+
+// setAnnotateMode checks the -annotate value; "auto" picks the CI the tool runs in
+func setAnnotateMode(mode, basePath, tool string) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "none":
+		annotateMode = ""
+	case "github", "teamcity":
+		annotateMode = strings.ToLower(strings.TrimSpace(mode))
+	case "auto":
+		annotateMode = ""
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			annotateMode = "github"
+		} else if os.Getenv("TEAMCITY_VERSION") != "" {
+			annotateMode = "teamcity"
+		}
+	default:
+		return fmt.Errorf("unknown -annotate mode '%s' (use github, teamcity or auto)", mode)
+	}
+	annotateTool = tool
+	annotateBase, _ = filepath.Abs(basePath)
+	annotateRoot = repositoryRoot(annotateBase)
+	return nil
+}
+
+// repositoryRoot is GITHUB_WORKSPACE, else the nearest folder with a .git entry
+// (a folder, or a file in worktrees and submodules), else the project itself
+func repositoryRoot(basePath string) string {
+	if ws := os.Getenv("GITHUB_WORKSPACE"); ws != "" && annotateMode == "github" {
+		return ws
+	}
+	for dir := basePath; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return basePath
+		}
+	}
+}
+
+// annotate prints one annotation. level is "error" or "warning"; file is absolute
+// or relative to the project root, and may be empty like line and col.
+func annotate(level, file string, line, col int, title, message string) {
+	if annotateMode == "" {
+		return
+	}
+	if file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(annotateBase, filepath.FromSlash(file))
+		}
+		if rel, err := filepath.Rel(annotateRoot, file); err == nil {
+			file = rel
+		}
+		file = filepath.ToSlash(file)
+	}
+
+	if annotateMode == "github" {
+		// Property values also escape ':' and ','; the message only '%' and line breaks
+		data := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+		prop := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+		var props []string
+		if file != "" {
+			props = append(props, "file="+prop.Replace(file))
+			if line > 0 {
+				props = append(props, "line="+strconv.Itoa(line))
+			}
+			if col > 0 {
+				props = append(props, "col="+strconv.Itoa(col))
+			}
+		}
+		if title != "" {
+			props = append(props, "title="+prop.Replace(title))
+		}
+		fmt.Printf("::%s %s::%s\n", level, strings.Join(props, ","), data.Replace(message))
+		return
+	}
+
+	esc := strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]").Replace
+	status := "WARNING"
+	if level == "error" {
+		status = "ERROR"
+	}
+	if file == "" {
+		text := message
+		if title != "" {
+			text = title + ": " + message
+		}
+		fmt.Printf("##teamcity[message text='%s' status='%s']\n", esc(text), status)
+		return
+	}
+	typeID := annotateTool + "." + strings.ReplaceAll(strings.ToLower(title), " ", "-")
+	if !annotateTypes[typeID] {
+		annotateTypes[typeID] = true
+		fmt.Printf("##teamcity[inspectionType id='%s' name='%s' category='%s' description='%s']\n", esc(typeID), esc(title), esc(annotateTool), esc(title))
+	}
+	lineAttr := ""
+	if line > 0 {
+		lineAttr = fmt.Sprintf(" line='%d'", line)
+	}
+	fmt.Printf("##teamcity[inspection typeId='%s' message='%s' file='%s'%s SEVERITY='%s']\n", esc(typeID), esc(message), esc(file), lineAttr, status)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode     bool
+		strict     bool
+		pull       bool
+		jobs       int
+		typesFlag  string
+		annotateFl string
+	)
+
+	flag.StringVar(&typesFlag, "types", "", "Comma-separated formats to check: png, jpg, wav, ogg, fbx (default: all, and LFS pointers of any type)")
+	flag.IntVar(&jobs, "jobs", runtime.NumCPU(), "Files checked in parallel")
+	flag.BoolVar(&strict, "strict", false, "Also exit with 1 when a file only has warnings")
+	flag.BoolVar(&pull, "pull", false, "Download Git LFS pointer files with 'git lfs pull' and check them again")
+	flag.StringVar(&annotateFl, "annotate", "", "Also print broken files as CI annotations: github, teamcity, auto")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the folders ("Assets/Audio -types wav")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_asset_integrity")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if err := setupRunner(basePath); err != nil {
+		fail(1, "failed to load the command fixture: %v", err)
+	}
+	if err := setAnnotateMode(annotateFl, basePath, "unity_asset_integrity"); err != nil {
+		fail(2, "%v", err)
+	}
+
+	formats := make(map[string]bool)
+	for _, t := range strings.Split(typesFlag, ",") {
+		t = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(t), "."))
+		if t == "" {
+			continue
+		}
+		if t == "jpeg" {
+			t = "jpg"
+		}
+		if formatByExt["."+t] == "" {
+			fail(2, "unknown format '%s' (use png, jpg, wav, ogg, fbx)", t)
+		}
+		formats[t] = true
+	}
+	roots := args
+	if len(roots) == 0 {
+		roots = defaultRoots(basePath)
+	}
+	for _, root := range roots {
+		p := root
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(basePath, filepath.FromSlash(root))
+		}
+		if _, err := os.Stat(p); err != nil {
+			fail(2, "cannot read %s: %v", root, err)
+		}
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  UNITY ASSET INTEGRITY CHECK"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project: %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Folders: %s\n"), strings.Join(roots, ", "))
+	fmt.Println()
+
+	files := findFiles(basePath, roots, formats, len(formats) == 0)
+	if len(files) == 0 {
+		fmt.Println(tr("[--] No PNG, JPEG, WAV, Ogg or FBX files found."))
+		exit(0)
+	}
+	fmt.Printf(tr("Checking %d file(s)...\n"), len(files))
+	start := time.Now()
+	checked := make([]*checkedFile, len(files))
+	parallel(len(files), jobs, func(i int) {
+		checked[i] = checkFile(basePath, files[i])
+	}, func(done int) {
+		printProgressBar(done, len(files))
+	})
+	if !plainMode {
+		fmt.Println()
+	}
+	var results []*checkedFile
+	var pointers []int
+	for _, c := range checked {
+		if c.format == "" && !c.lfsPointer {
+			continue // not a pointer, and not a format that is checked
+		}
+		if c.lfsPointer {
+			pointers = append(pointers, len(results))
+		}
+		results = append(results, c)
+	}
+
+	// Download the real files and check them again
+	if pull && len(pointers) > 0 {
+		paths := make([]string, len(pointers))
+		for i, r := range pointers {
+			paths[i] = results[r].path
+		}
+		fmt.Printf(tr("\nPulling %d file(s) with Git LFS...\n"), len(paths))
+		if err := lfsPull(basePath, paths); err != nil {
+			fail(1, "%v", err)
+		}
+		pulled := 0
+		for _, r := range pointers {
+			c := checkFile(basePath, results[r].path)
+			if !c.lfsPointer {
+				pulled++
+				fmt.Printf(tr("[OK] Pulled: %s\n"), c.path)
+				recordAction("pull", c.path, "ok", "", 0)
+			} else {
+				fmt.Printf(tr("[ERROR] Still a pointer: %s\n"), c.path)
+				recordAction("pull", c.path, "failed", "the object is not on the LFS server or not included by git lfs fetch settings", 0)
+			}
+			results[r] = c
+		}
+		fmt.Printf(tr("Pulled %d of %d file(s)\n"), pulled, len(pointers))
+	}
+
+	var total int64
+	lfsPointers := 0
+	byFormat := make(map[string]int)
+	for _, c := range results {
+		total += c.size
+		if c.lfsPointer {
+			lfsPointers++
+		}
+		if c.format != "" {
+			byFormat[c.format]++
+		}
+		status, detail := "ok", ""
+		for _, is := range c.issues {
+			if is.level == "error" {
+				status = "failed"
+			} else if status == "ok" {
+				status = "warning"
+			}
+			if detail != "" {
+				detail += "; "
+			}
+			detail += is.message
+			title := "Broken asset"
+			if c.lfsPointer {
+				title = "Git LFS pointer"
+			}
+			annotate(is.level, c.path, 0, 0, title, is.message)
+		}
+		recordAction("check", c.path, status, detail, 0)
+	}
+	broken, warned := printReport(results)
+
+	var counts []string
+	for _, f := range []string{"png", "jpg", "wav", "ogg", "fbx"} {
+		if byFormat[f] > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", byFormat[f], strings.ToUpper(f)))
+		}
+	}
+	fmt.Println()
+	printRule("=============================================")
+	fmt.Println(tr("  SUMMARY"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Checked:  %d file(s), %s (%s)\n"), len(results), formatSize(total), strings.Join(counts, ", "))
+	fmt.Printf(tr("  Broken:   %d\n"), broken-lfsPointers)
+	fmt.Printf(tr("  Pointers: %d\n"), lfsPointers)
+	fmt.Printf(tr("  Warnings: %d\n"), warned)
+	fmt.Printf(tr("  Took:     %v\n"), time.Since(start).Round(time.Millisecond))
+
+	if lfsPointers > 0 && !pull {
+		fmt.Printf(tr("\n[TIP] %d file(s) are Git LFS pointers: the repository was cloned or updated without Git LFS. Install it, run 'git lfs install', then run this tool again with -pull.\n"), lfsPointers)
+	}
+	if broken > lfsPointers {
+		fmt.Println(tr("\n[TIP] Restore broken files from version control or re-export them from the source application."))
+	}
+	if broken > 0 {
+		exit(1)
+	}
+	if strict && warned > 0 {
+		exit(1)
+	}
+	fmt.Println(tr("\n[OK] All checked assets are intact."))
+	exit(0)
+}
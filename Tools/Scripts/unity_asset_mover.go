@@ -0,0 +1,1879 @@
+// Unity Asset Mover — Move or rename asset folders and files without the editor.
+// Each asset moves together with its .meta file, so GUIDs (and every scene, prefab and
+// material reference that uses them) survive. Path-based references that GUIDs do not
+// cover are rewritten: "Assets/..." strings in code, JSON and YAML assets (Addressables
+// addresses, build settings, string tables) and Resources.Load paths.
+//
+// Build: go build unity_asset_mover.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_asset_mover Assets/Game/UI Assets/Game/Interface
+//	unity_asset_mover -dry-run Assets/Art/hero.png Assets/Art/Characters/hero.png
+//	unity_asset_mover -plan moves.txt -ci      # one "from -> to" per line
+//	unity_asset_mover -snapshot -plan moves.txt
+//	unity_asset_mover -json -plan moves.txt
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Text files that can hold path strings. .asset files are only touched when they
+// are serialized as text (Asset Serialization: Force Text).
+var referenceFileExts = map[string]bool{
+	".cs":    true,
+	".json":  true,
+	".txt":   true,
+	".csv":   true,
+	".tsv":   true,
+	".xml":   true,
+	".yaml":  true,
+	".yml":   true,
+	".asset": true,
+}
+
+// Folders scanned for references, relative to the project root
+var referenceRoots = []string{"Assets", "ProjectSettings"}
+
+// Folder .meta written for destination parents that do not exist yet
+const folderMetaTemplate = `fileFormatVersion: 2
+guid: %s
+folderAsset: yes
+DefaultImporter:
+  externalObjects: {}
+  userData:
+  assetBundleName:
+  assetBundleVariant:
+`
+
+var planLineRegex = regexp.MustCompile(`^(.+?)\s*(?:->|=>|\t)\s*(.+)$`)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// assetMove is one planned move, with project-relative forward-slash paths
+type assetMove struct {
+	from  string
+	to    string
+	isDir bool
+	// Resources.Load paths of every asset in the move, old -> new ("" when the
+	// destination is outside any Resources folder)
+	resources map[string]string
+}
+
+// refRule is one textual replacement applied to reference files
+type refRule struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// refChange is a file whose references change
+type refChange struct {
+	path  string
+	count int
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Move Plan
+// ============================================================
+
+// normalizeAssetPath turns user input into a clean "Assets/..." path
+func normalizeAssetPath(p string) string {
+	p = strings.TrimSpace(strings.Trim(strings.TrimSpace(p), `"'`))
+	p = path.Clean(filepath.ToSlash(p))
+	return strings.TrimPrefix(p, "./")
+}
+
+// readPlan parses a plan file: one "from -> to" (or "=>", or tab separated) per
+// line; blank lines and lines starting with # are ignored.
+func readPlan(planPath string) ([][2]string, error) {
+	data, err := os.ReadFile(planPath)
+	if err != nil {
+		return nil, err
+	}
+	var pairs [][2]string
+	for i, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m := planLineRegex.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("%s:%d: expected 'from -> to'", planPath, i+1)
+		}
+		pairs = append(pairs, [2]string{m[1], m[2]})
+	}
+	return pairs, nil
+}
+
+// planMove validates one move against the project as it will look after the
+// earlier moves of the plan (the overlay or disk already reflects them at run time,
+// so validation runs right before each move).
+func planMove(basePath, from, to string) (*assetMove, error) {
+	from, to = normalizeAssetPath(from), normalizeAssetPath(to)
+	for _, p := range []string{from, to} {
+		if p != "Assets" && !strings.HasPrefix(p, "Assets/") {
+			return nil, fmt.Errorf("'%s' is not under Assets/", p)
+		}
+		if strings.HasSuffix(p, ".meta") {
+			return nil, fmt.Errorf("'%s': move the asset, its .meta follows", p)
+		}
+	}
+	if from == "Assets" || to == "Assets" {
+		return nil, fmt.Errorf("the Assets folder itself cannot be moved")
+	}
+	if from == to {
+		return nil, fmt.Errorf("'%s': source and destination are the same", from)
+	}
+	if strings.HasPrefix(to+"/", from+"/") {
+		return nil, fmt.Errorf("cannot move '%s' into itself", from)
+	}
+
+	info, err := fsys.Stat(filepath.Join(basePath, filepath.FromSlash(from)))
+	if err != nil {
+		return nil, fmt.Errorf("'%s' not found", from)
+	}
+	// Case-only renames are allowed: on Windows/macOS the destination "exists" already
+	if _, err := fsys.Stat(filepath.Join(basePath, filepath.FromSlash(to))); err == nil && !strings.EqualFold(from, to) {
+		return nil, fmt.Errorf("'%s' already exists", to)
+	}
+
+	move := &assetMove{from: from, to: to, isDir: info.IsDir(), resources: make(map[string]string)}
+	addResource := func(oldAsset string) {
+		if oldRes, ok := resourcesPath(oldAsset); ok {
+			newAsset := to + strings.TrimPrefix(oldAsset, from)
+			newRes, _ := resourcesPath(newAsset)
+			move.resources[oldRes] = newRes
+		}
+	}
+	if !move.isDir {
+		addResource(from)
+		return move, nil
+	}
+	fsys.Walk(filepath.Join(basePath, filepath.FromSlash(from)), func(p string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() || strings.HasSuffix(p, ".meta") {
+			return nil
+		}
+		if rel, rErr := filepath.Rel(basePath, p); rErr == nil {
+			addResource(filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return move, nil
+}
+
+// resourcesPath returns the Resources.Load path of an asset: the part after the
+// last Resources/ folder, without extension.
+func resourcesPath(assetPath string) (string, bool) {
+	i := strings.LastIndex(assetPath, "/Resources/")
+	if i < 0 {
+		return "", false
+	}
+	rel := assetPath[i+len("/Resources/"):]
+	return strings.TrimSuffix(rel, path.Ext(rel)), true
+}
+
+// ============================================================
+// Reference Rewriting
+// ============================================================
+
+// buildRefRules returns the replacements for one move. Asset paths match only as a
+// whole path segment ("Assets/UI" never touches "Assets/UIKit"). Resources paths
+// match only as a complete quoted string or YAML scalar value, since short paths
+// like "Icons/Sword" are too generic to replace anywhere else.
+func buildRefRules(move *assetMove) []refRule {
+	rules := []refRule{{
+		re:          regexp.MustCompile(`(?m)\b` + regexp.QuoteMeta(move.from) + `([/"'\s,;)\]]|$)`),
+		replacement: move.to + "${1}",
+	}}
+
+	var oldPaths []string
+	for oldRes, newRes := range move.resources {
+		if newRes != "" && newRes != oldRes {
+			oldPaths = append(oldPaths, oldRes)
+		}
+	}
+	// Longest first so "UI/Icons/Sword" is not shadowed by a shorter path
+	sort.Slice(oldPaths, func(i, j int) bool { return len(oldPaths[i]) > len(oldPaths[j]) })
+	for _, oldRes := range oldPaths {
+		rules = append(rules, refRule{
+			re:          regexp.MustCompile(`(?m)(["']|:[ \t]+)` + regexp.QuoteMeta(oldRes) + `(["']|[ \t]*$)`),
+			replacement: "${1}" + move.resources[oldRes] + "${2}",
+		})
+	}
+	return rules
+}
+
+// brokenResourcesRule matches references to assets that leave every Resources folder
+func brokenResourcesRule(move *assetMove) *regexp.Regexp {
+	var lost []string
+	for oldRes, newRes := range move.resources {
+		if newRes == "" {
+			lost = append(lost, regexp.QuoteMeta(oldRes))
+		}
+	}
+	if len(lost) == 0 {
+		return nil
+	}
+	sort.Strings(lost)
+	return regexp.MustCompile(`(?m)(["']|:[ \t]+)(?:` + strings.Join(lost, "|") + `)(["']|[ \t]*$)`)
+}
+
+// isReferenceFile reports whether path is a text file worth scanning
+func isReferenceFile(path string, data []byte) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if !referenceFileExts[ext] {
+		return false
+	}
+	if ext == ".asset" {
+		return bytes.HasPrefix(data, []byte("%YAML"))
+	}
+	return !bytes.Contains(data, []byte{0})
+}
+
+// applyRules rewrites text and returns it with the number of changed lines.
+// Lines rather than matches: chained moves rewrite the same spot more than once.
+func applyRules(text string, rules []refRule) (string, int) {
+	newText := text
+	for _, r := range rules {
+		newText = r.re.ReplaceAllString(newText, r.replacement)
+	}
+	if newText == text {
+		return text, 0
+	}
+	oldLines, newLines := strings.Split(text, "\n"), strings.Split(newText, "\n")
+	count := 0
+	for i := range oldLines {
+		if i < len(newLines) && oldLines[i] != newLines[i] {
+			count++
+		}
+	}
+	return newText, count
+}
+
+// walkReferenceFiles calls fn for every reference file's path and text
+func walkReferenceFiles(basePath string, fn func(path, text string, format textFormat)) {
+	for _, root := range referenceRoots {
+		fsys.Walk(filepath.Join(basePath, root), func(p string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || !referenceFileExts[strings.ToLower(filepath.Ext(p))] {
+				return nil
+			}
+			data, rErr := fsys.ReadFile(p)
+			if rErr != nil || !isReferenceFile(p, data) {
+				return nil
+			}
+			text, format := decodeText(data)
+			fn(p, text, format)
+			return nil
+		})
+	}
+}
+
+// scanReferences lists the files the rules would change, without writing
+func scanReferences(basePath string, rules []refRule) []refChange {
+	var changes []refChange
+	walkReferenceFiles(basePath, func(p, text string, _ textFormat) {
+		if _, n := applyRules(text, rules); n > 0 {
+			changes = append(changes, refChange{path: p, count: n})
+		}
+	})
+	return changes
+}
+
+// rewriteReferences applies the rules to every reference file
+func rewriteReferences(basePath string, rules []refRule) ([]refChange, error) {
+	var changes []refChange
+	var errs []string
+	walkReferenceFiles(basePath, func(p, text string, format textFormat) {
+		newText, n := applyRules(text, rules)
+		if n == 0 {
+			return
+		}
+		rel, _ := filepath.Rel(basePath, p)
+		if err := writeTextFileAtomic(p, newText, format); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", filepath.ToSlash(rel), err))
+			recordAction("modify", filepath.ToSlash(rel), "failed", err.Error(), 0)
+			return
+		}
+		changes = append(changes, refChange{path: p, count: n})
+		recordAction("modify", filepath.ToSlash(rel), "ok", fmt.Sprintf("%d lines", n), 0)
+	})
+	if len(errs) > 0 {
+		return changes, fmt.Errorf("%d file(s) could not be written: %s", len(errs), strings.Join(errs, "; "))
+	}
+	return changes, nil
+}
+
+// countMatches counts how many reference files match re, for warnings
+func countMatches(basePath string, re *regexp.Regexp) int {
+	count := 0
+	walkReferenceFiles(basePath, func(_, text string, _ textFormat) {
+		count += len(re.FindAllStringIndex(text, -1))
+	})
+	return count
+}
+
+// ============================================================
+// Moving
+// ============================================================
+
+// newGUID returns a random 32-digit hex GUID in Unity's .meta format
+func newGUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ensureParentFolders creates the missing folders above dst, each with a .meta so
+// the new folders get stable GUIDs instead of ones Unity invents on the next import
+func ensureParentFolders(basePath, dst string) ([]string, error) {
+	var created []string
+	var missing []string
+	for dir := path.Dir(dst); dir != "Assets" && dir != "."; dir = path.Dir(dir) {
+		if _, err := fsys.Stat(filepath.Join(basePath, filepath.FromSlash(dir))); err == nil {
+			break
+		}
+		missing = append(missing, dir)
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		dir := filepath.Join(basePath, filepath.FromSlash(missing[i]))
+		if err := fsys.MkdirAll(dir, 0755); err != nil {
+			return created, err
+		}
+		meta := fmt.Sprintf(folderMetaTemplate, newGUID())
+		if err := writeFileAtomic(dir+".meta", []byte(meta)); err != nil {
+			return created, err
+		}
+		activeVCS.add(dir + ".meta")
+		created = append(created, missing[i])
+	}
+	return created, nil
+}
+
+// moveAsset moves the asset and its .meta. The .meta is what keeps the GUID, so a
+// missing one is reported: Unity will assign a new GUID and break references.
+func moveAsset(basePath string, move *assetMove) (metaMoved bool, err error) {
+	src := filepath.Join(basePath, filepath.FromSlash(move.from))
+	dst := filepath.Join(basePath, filepath.FromSlash(move.to))
+	if err := activeVCS.move(src, dst, move.isDir); err != nil {
+		return false, err
+	}
+	if _, err := fsys.Stat(src + ".meta"); err != nil {
+		return false, nil
+	}
+	if err := activeVCS.move(src+".meta", dst+".meta", false); err != nil {
+		return false, fmt.Errorf("moved %s but not its .meta: %v", move.from, err)
+	}
+	return true, nil
+}
+
+// ============================================================
+// Version Control
+// ============================================================
+
+// Perforce and Plastic SCM track moves themselves (p4 move, cm move), which keeps
+// file history; a plain rename would show up as a delete plus an add. Git detects
+// renames on its own, so the files are simply renamed there.
+const (
+	vcsNone     = "none"
+	vcsPerforce = "p4"
+	vcsPlastic  = "plastic"
+)
+
+// vcsEnvVar overrides detection for every tool (e.g. on build agents)
+const vcsEnvVar = "UNITYSTARTER_VCS"
+
+// vcsClient runs checkouts, adds and moves for files under version control
+type vcsClient struct {
+	kind       string
+	source     string // how kind was chosen, for the startup message
+	checkedOut map[string]bool
+}
+
+// activeVCS is set up in main; nil means no version control commands
+var activeVCS *vcsClient
+
+// detectVCS picks the provider. Priority: -vcs flag > UNITYSTARTER_VCS >
+// Unity's VersionControlSettings.asset mode > workspace markers (.plastic, P4CONFIG).
+func detectVCS(projectRoot, override string) (*vcsClient, error) {
+	normalize := func(v string) (string, bool) {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "p4", "perforce":
+			return vcsPerforce, true
+		case "plastic", "plasticscm", "cm", "unityvcs":
+			return vcsPlastic, true
+		case "none", "git", "off":
+			return vcsNone, true
+		}
+		return "", false
+	}
+	newClient := func(kind, source string) *vcsClient {
+		return &vcsClient{kind: kind, source: source, checkedOut: make(map[string]bool)}
+	}
+
+	if override != "" && override != "auto" {
+		kind, ok := normalize(override)
+		if !ok {
+			return nil, fmt.Errorf("unknown -vcs value '%s' (use auto, none, p4 or plastic)", override)
+		}
+		return newClient(kind, "-vcs flag"), nil
+	}
+	if env := os.Getenv(vcsEnvVar); env != "" {
+		if kind, ok := normalize(env); ok {
+			return newClient(kind, vcsEnvVar), nil
+		}
+	}
+
+	// Unity stores the project's integrated VCS in m_Mode ("Perforce", "PlasticSCM", ...)
+	if data, err := os.ReadFile(filepath.Join(projectRoot, "ProjectSettings", "VersionControlSettings.asset")); err == nil {
+		if m := regexp.MustCompile(`(?m)^\s*m_Mode:\s*(.+?)\s*$`).FindSubmatch(data); m != nil {
+			switch strings.ToLower(string(m[1])) {
+			case "perforce":
+				return newClient(vcsPerforce, "VersionControlSettings.asset"), nil
+			case "plasticscm", "plastic scm", "unity version control":
+				return newClient(vcsPlastic, "VersionControlSettings.asset"), nil
+			}
+		}
+	}
+
+	// Workspace markers, searched upward from the project root
+	p4config := os.Getenv("P4CONFIG")
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info != nil {
+				return newClient(vcsNone, "git repository"), nil
+			}
+			if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+				return newClient(vcsPlastic, ".plastic workspace"), nil
+			}
+			if p4config != "" {
+				if _, err := os.Stat(filepath.Join(dir, p4config)); err == nil {
+					return newClient(vcsPerforce, "P4CONFIG"), nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if os.Getenv("P4CLIENT") != "" {
+		return newClient(vcsPerforce, "P4CLIENT"), nil
+	}
+	return newClient(vcsNone, "no Perforce/Plastic workspace found"), nil
+}
+
+// run executes a VCS command from dir; a dry run only lists it
+func (v *vcsClient) run(dir string, args ...string) error {
+	if overlay, ok := fsys.(*overlayFS); ok {
+		overlay.noteCommand(args...)
+		return nil
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	// Run from the file's folder so P4CONFIG / the Plastic workspace is resolved
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %v %s", args[0], args[1], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// checkout opens an existing file for edit. Files outside the depot/workspace only
+// produce a warning: the write still goes ahead.
+func (v *vcsClient) checkout(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || v.checkedOut[abs] {
+		return
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return
+	}
+	v.checkedOut[abs] = true
+
+	var err2 error
+	switch v.kind {
+	case vcsPerforce:
+		err2 = v.run(filepath.Dir(abs), "p4", "edit", abs)
+	case vcsPlastic:
+		err2 = v.run(filepath.Dir(abs), "cm", "checkout", abs)
+	}
+	if err2 != nil {
+		fmt.Printf(tr("[WARNING] %s checkout failed for %s: %v\n"), v.kind, filepath.Base(abs), err2)
+		return
+	}
+	fmt.Printf(tr("[VCS] Checked out (%s): %s\n"), v.kind, filepath.Base(abs))
+}
+
+// add marks a newly created file for add
+func (v *vcsClient) add(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	var err2 error
+	switch v.kind {
+	case vcsPerforce:
+		err2 = v.run(filepath.Dir(abs), "p4", "add", abs)
+	case vcsPlastic:
+		err2 = v.run(filepath.Dir(abs), "cm", "add", abs)
+	}
+	if err2 != nil {
+		fmt.Printf(tr("[WARNING] %s add failed for %s: %v\n"), v.kind, filepath.Base(abs), err2)
+	}
+}
+
+// move renames src to dst through the VCS when there is one. Items the VCS does
+// not know (new, unversioned) fail there and are renamed on disk instead.
+func (v *vcsClient) move(src, dst string, isDir bool) error {
+	if v != nil && v.kind != vcsNone {
+		absSrc, err1 := filepath.Abs(src)
+		absDst, err2 := filepath.Abs(dst)
+		if err1 == nil && err2 == nil {
+			var err error
+			switch v.kind {
+			case vcsPerforce:
+				from, to := absSrc, absDst
+				if isDir {
+					from, to = filepath.Join(absSrc, "..."), filepath.Join(absDst, "...")
+				}
+				if err = v.run(filepath.Dir(absSrc), "p4", "edit", from); err == nil {
+					err = v.run(filepath.Dir(absSrc), "p4", "move", from, to)
+				}
+			case vcsPlastic:
+				err = v.run(filepath.Dir(absSrc), "cm", "move", absSrc, absDst)
+			}
+			if err == nil {
+				if isDryRun() {
+					return fsys.Rename(src, dst)
+				}
+				return nil
+			}
+			fmt.Printf(tr("[WARNING] %s move failed for %s, renaming on disk: %v\n"), v.kind, filepath.Base(src), err)
+		}
+	}
+	return fsys.Rename(src, dst)
+}
+
+// ============================================================
+// Safe File Writes
+// ============================================================
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// writeFileAtomic replaces path with data without ever leaving a half-written file:
+// data goes to a temp file in the same directory which is then renamed over the target.
+// The target's permission bits are kept, and a read-only flag (files under Perforce or
+// Plastic SCM that were not checked out) is cleared so the write does not fail.
+func writeFileAtomic(path string, data []byte) error {
+	// A dry run writes straight into the overlay: no checkout, no temp file
+	if isDryRun() {
+		return fsys.WriteFile(path, data, 0644)
+	}
+	activeVCS.checkout(path)
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if mode&0200 == 0 {
+			mode |= 0200
+			if err := os.Chmod(path, mode); err != nil {
+				return fmt.Errorf("cannot clear read-only flag on %s: %v", path, err)
+			}
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Retry briefly: editors, indexers and antivirus can hold the target open on Windows
+	for attempt := 0; ; attempt++ {
+		err = os.Rename(tmpPath, path)
+		if err == nil || attempt == 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// textFormat records the BOM and line-ending style of a text file so rewrites keep it
+type textFormat struct {
+	bom  bool
+	crlf bool // ending of most lines, and of the lines a rewrite adds
+	// Mixed files only: the text as read (LF) and the ending of each of its line
+	// breaks, 'r' (CRLF) or 'n' (LF). Strings keep textFormat comparable.
+	orig string
+	eols string
+}
+
+// decodeText strips a UTF-8 BOM and normalizes line endings to LF. A file counts as
+// CRLF when most of its line breaks are CRLF; a file with both keeps the ending of
+// every line, so a rewrite only changes the endings of the lines it edits.
+func decodeText(data []byte) (string, textFormat) {
+	var format textFormat
+	if bytes.HasPrefix(data, utf8BOM) {
+		format.bom = true
+		data = data[len(utf8BOM):]
+	}
+	crlfCount := bytes.Count(data, []byte("\r\n"))
+	lfCount := bytes.Count(data, []byte("\n")) - crlfCount
+	format.crlf = crlfCount > 0 && crlfCount >= lfCount
+	if crlfCount == 0 {
+		return string(data), format
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if lfCount > 0 {
+		eols := make([]byte, 0, crlfCount+lfCount)
+		for i, c := range data {
+			if c == '\n' {
+				if i > 0 && data[i-1] == '\r' {
+					eols = append(eols, 'r')
+				} else {
+					eols = append(eols, 'n')
+				}
+			}
+		}
+		format.orig, format.eols = text, string(eols)
+	}
+	return text, format
+}
+
+// encode converts LF text back to the recorded format
+func (f textFormat) encode(text string) []byte {
+	switch {
+	case f.eols != "":
+		text = f.mixedEndings(text)
+	case f.crlf:
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	if f.bom {
+		return append(append([]byte{}, utf8BOM...), text...)
+	}
+	return []byte(text)
+}
+
+// mixedEndings gives the lines of a rewritten mixed file the endings they had. The
+// unchanged lines before and after the edited region keep theirs; edited lines keep
+// the ending of the line they replace when the line count did not change, and get
+// the majority ending otherwise.
+func (f textFormat) mixedEndings(text string) string {
+	old := strings.Split(f.orig, "\n")
+	lines := strings.Split(text, "\n")
+	prefix := 0
+	for prefix < len(old) && prefix < len(lines) && old[prefix] == lines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(lines)-prefix && old[len(old)-1-suffix] == lines[len(lines)-1-suffix] {
+		suffix++
+	}
+	crlfAt := func(j int) bool {
+		if j >= 0 && j < len(f.eols) {
+			return f.eols[j] == 'r'
+		}
+		return f.crlf
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		b.WriteString(line)
+		if i == len(lines)-1 {
+			break
+		}
+		crlf := f.crlf
+		switch {
+		case i < prefix || len(lines) == len(old):
+			crlf = crlfAt(i)
+		case i >= len(lines)-suffix:
+			crlf = crlfAt(i - len(lines) + len(old))
+		}
+		if crlf {
+			b.WriteByte('\r')
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// writeTextFileAtomic writes LF text in the given format via writeFileAtomic
+func writeTextFileAtomic(path, text string, format textFormat) error {
+	return writeFileAtomic(path, format.encode(text))
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Filesystem
+// ============================================================
+
+// fileSystem is every filesystem call that can change the project. The real
+// run uses osFS; -dry-run swaps in an overlayFS so the same code path runs
+// and the overlay reports what it would have changed.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Walk(root string, fn filepath.WalkFunc) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Chmod(name string, mode os.FileMode) error
+}
+
+var fsys fileSystem = osFS{}
+
+// isDryRun reports whether changes are going to the in-memory overlay
+func isDryRun() bool {
+	_, ok := fsys.(*overlayFS)
+	return ok
+}
+
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)         { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) Walk(root string, fn filepath.WalkFunc) error { return filepath.Walk(root, fn) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// fsChange is one change the overlay absorbed instead of the disk
+type fsChange struct {
+	op     string // "write", "create", "mkdir", "rename", "delete"
+	path   string
+	detail string
+}
+
+// overlayFS reads through to the disk and keeps every write, rename and
+// delete in memory. Later reads see those changes, so multi-step operations
+// (rename a folder, then rewrite files inside it) behave as they would for real.
+type overlayFS struct {
+	mu      sync.Mutex
+	files   map[string][]byte // files written during the run
+	dirs    map[string]bool   // directories created during the run
+	moved   map[string]string // renamed path -> where its content is on disk
+	removed map[string]bool   // deleted paths; hides everything below them
+	changes []fsChange
+}
+
+func newOverlayFS() *overlayFS {
+	return &overlayFS{
+		files:   map[string][]byte{},
+		dirs:    map[string]bool{},
+		moved:   map[string]string{},
+		removed: map[string]bool{},
+	}
+}
+
+func overlayKey(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// isUnder reports whether p is base or inside it
+func isUnder(p, base string) bool {
+	return p == base || strings.HasPrefix(p, strings.TrimSuffix(base, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// locate finds where the content of p lives: in memory (data / memDir) or
+// on disk (disk, after following renames). ok is false if p was deleted.
+func (o *overlayFS) locate(p string) (data []byte, memFile, memDir bool, disk string, ok bool) {
+	if d, found := o.files[p]; found {
+		return d, true, false, "", true
+	}
+	if o.dirs[p] {
+		return nil, false, true, "", true
+	}
+	for q := p; ; {
+		if src, found := o.moved[q]; found {
+			rel, _ := filepath.Rel(q, p)
+			return nil, false, false, filepath.Join(src, rel), true
+		}
+		if o.removed[q] {
+			return nil, false, false, "", false
+		}
+		parent := filepath.Dir(q)
+		if parent == q {
+			break
+		}
+		q = parent
+	}
+	return nil, false, false, p, true
+}
+
+func (o *overlayFS) stat(p string) (os.FileInfo, error) {
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	case memFile:
+		return memFileInfo{name: filepath.Base(p), size: int64(len(data))}, nil
+	case memDir:
+		return memFileInfo{name: filepath.Base(p), dir: true}, nil
+	}
+	info, err := os.Stat(disk)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	if disk != p {
+		return renamedFileInfo{info, filepath.Base(p)}, nil
+	}
+	return info, nil
+}
+
+func (o *overlayFS) Stat(name string) (os.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stat(overlayKey(name))
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case memDir:
+		return nil, &os.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	case memFile:
+		return append([]byte(nil), data...), nil
+	}
+	return os.ReadFile(disk)
+}
+
+func (o *overlayFS) readDir(p string) ([]os.DirEntry, error) {
+	_, memFile, memDir, disk, ok := o.locate(p)
+	if !ok || memFile {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+	names := map[string]bool{}
+	if !memDir {
+		entries, err := os.ReadDir(disk)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			names[e.Name()] = true
+		}
+	}
+	for k := range o.dirs {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.files {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.moved {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	var result []os.DirEntry
+	for name := range names {
+		if info, err := o.stat(filepath.Join(p, name)); err == nil {
+			result = append(result, fs.FileInfoToDirEntry(info))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (o *overlayFS) ReadDir(name string) ([]os.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readDir(overlayKey(name))
+}
+
+// Walk follows filepath.Walk: lexical order, SkipDir and SkipAll honored.
+// The lock is released while fn runs so the callback can change the overlay.
+func (o *overlayFS) Walk(root string, fn filepath.WalkFunc) error {
+	info, err := o.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = o.walk(root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (o *overlayFS) walk(path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	entries, err := o.ReadDir(path)
+	if err1 := fn(path, info, err); err != nil || err1 != nil {
+		return err1
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		childInfo, err := o.Stat(child)
+		if err != nil {
+			if err := fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := o.walk(child, childInfo, fn); err != nil {
+			if err == filepath.SkipDir && childInfo.IsDir() {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *overlayFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	op := "create"
+	if info, err := o.stat(p); err == nil {
+		if info.IsDir() {
+			return &os.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+		}
+		op = "write"
+	}
+	if info, err := o.stat(filepath.Dir(p)); err != nil || !info.IsDir() {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	o.files[p] = append([]byte(nil), data...)
+	o.changes = append(o.changes, fsChange{op, p, fmt.Sprintf("%d bytes", len(data))})
+	return nil
+}
+
+func (o *overlayFS) MkdirAll(path string, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	created := false
+	for q := p; ; q = filepath.Dir(q) {
+		if info, err := o.stat(q); err == nil {
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: q, Err: errors.New("not a directory")}
+			}
+			break
+		}
+		o.dirs[q] = true
+		created = true
+		if filepath.Dir(q) == q {
+			break
+		}
+	}
+	if created {
+		o.changes = append(o.changes, fsChange{"mkdir", p, ""})
+	}
+	return nil
+}
+
+// shift moves every overlay entry under from to the same place under to
+func (o *overlayFS) shift(from, to string) {
+	for k, v := range o.files {
+		if isUnder(k, from) {
+			delete(o.files, k)
+			o.files[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, from) {
+			delete(o.dirs, k)
+			o.dirs[to+strings.TrimPrefix(k, from)] = true
+		}
+	}
+	for k, v := range o.moved {
+		if isUnder(k, from) {
+			delete(o.moved, k)
+			o.moved[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+}
+
+func (o *overlayFS) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	from, to := overlayKey(oldpath), overlayKey(newpath)
+	_, _, _, disk, ok := o.locate(from)
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if from == to {
+		return nil
+	}
+	if info, err := o.stat(filepath.Dir(to)); err != nil || !info.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	o.drop(to)
+	o.shift(from, to)
+	if disk != "" {
+		if _, err := os.Stat(disk); err == nil {
+			o.moved[to] = disk
+		}
+	}
+	o.removed[from] = true
+	o.changes = append(o.changes, fsChange{"rename", from, to})
+	return nil
+}
+
+// drop forgets p and everything below it and hides it from the disk
+func (o *overlayFS) drop(p string) {
+	for k := range o.files {
+		if isUnder(k, p) {
+			delete(o.files, k)
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, p) {
+			delete(o.dirs, k)
+		}
+	}
+	for k := range o.moved {
+		if isUnder(k, p) {
+			delete(o.moved, k)
+		}
+	}
+	o.removed[p] = true
+}
+
+// treeSize is the byte size of p as the overlay currently sees it
+func (o *overlayFS) treeSize(p string) int64 {
+	info, err := o.stat(p)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var size int64
+	entries, _ := o.readDir(p)
+	for _, e := range entries {
+		size += o.treeSize(filepath.Join(p, e.Name()))
+	}
+	return size
+}
+
+func (o *overlayFS) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	info, err := o.stat(p)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	if info.IsDir() {
+		if entries, _ := o.readDir(p); len(entries) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", info.Size())})
+	return nil
+}
+
+func (o *overlayFS) RemoveAll(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	if _, err := o.stat(p); err != nil {
+		return nil
+	}
+	size := o.treeSize(p)
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", size)})
+	return nil
+}
+
+func (o *overlayFS) Chmod(name string, mode os.FileMode) error {
+	if _, err := o.Stat(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+// noteCommand lists a step the overlay cannot simulate, such as a p4 or cm
+// command
+func (o *overlayFS) noteCommand(args ...string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.changes = append(o.changes, fsChange{"run", strings.Join(args, " "), ""})
+}
+
+// printDryRunReport lists what the overlay absorbed, relative to base
+func (o *overlayFS) printDryRunReport(base string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if abs, err := filepath.Abs(base); err == nil {
+		base = abs
+	}
+	if len(o.changes) == 0 {
+		fmt.Println(tr("\n[Dry Run] No changes would be made."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d change(s) would be made; nothing was written:\n"), len(o.changes))
+	rel := func(p string) string {
+		if r, err := filepath.Rel(base, p); err == nil && !strings.HasPrefix(r, "..") {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	for _, c := range o.changes {
+		switch c.op {
+		case "rename":
+			fmt.Printf("  %-7s %s -> %s\n", c.op, rel(c.path), rel(c.detail))
+		case "mkdir", "run":
+			fmt.Printf("  %-7s %s\n", c.op, rel(c.path))
+		default:
+			fmt.Printf("  %-7s %s (%s)\n", c.op, rel(c.path), c.detail)
+		}
+	}
+}
+
+// memFileInfo describes a file or directory that only exists in the overlay
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (m memFileInfo) Name() string       { return m.name }
+func (m memFileInfo) Size() int64        { return m.size }
+func (m memFileInfo) ModTime() time.Time { return time.Time{} }
+func (m memFileInfo) IsDir() bool        { return m.dir }
+func (m memFileInfo) Sys() interface{}   { return nil }
+func (m memFileInfo) Mode() os.FileMode {
+	if m.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// renamedFileInfo is a disk entry seen under the name it was renamed to
+type renamedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (r renamedFileInfo) Name() string { return r.name }
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"[ERROR] Cannot read plan: %v\n":                                   "[ERROR] 无法读取移动计划: %v\n",
+	"Usage: unity_asset_mover [flags] <from> <to>":                     "用法: unity_asset_mover [参数] <源路径> <目标路径>",
+	"       unity_asset_mover [flags] -plan moves.txt":                 "      unity_asset_mover [参数] -plan moves.txt",
+	"[--] The plan contains no moves.":                                 "[--] 移动计划中没有任何移动项。",
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"Version control: %s (%s), moves go through %s\n": "版本控制: %s (%s)，移动操作通过 %s 执行\n",
+	"  MOVE PLAN": "  移动计划",
+	"\nMove these assets and rewrite path references? (y/N): ": "\n是否移动这些资源并改写路径引用？(y/N): ",
+	"Operation cancelled.":  "操作已取消。",
+	"[ERROR] Move %d: %v\n": "[ERROR] 第 %d 项移动: %v\n",
+	"[WARNING] Earlier moves were applied; path references were not rewritten yet.": "[WARNING] 之前的移动已执行，但路径引用尚未改写。",
+	"[OK] Created folder: %s (with .meta)\n":                                        "[OK] 已创建文件夹: %s (含 .meta)\n",
+	"[ERROR] Cannot create %s: %v\n":                                                "[ERROR] 无法创建 %s: %v\n",
+	"[ERROR] Cannot move %s: %v\n":                                                  "[ERROR] 无法移动 %s: %v\n",
+	"[OK] Moved: %s -> %s\n":                                                        "[OK] 已移动: %s -> %s\n",
+	"[WARNING] %s has no .meta file; Unity will assign a new GUID.\n":               "[WARNING] %s 没有 .meta 文件，Unity 会分配新的 GUID。\n",
+	"\nRewriting path references...":                                                "\n正在改写路径引用...",
+	"[OK] Updated references: %s (%d)\n":                                            "[OK] 已更新引用: %s (%d)\n",
+	"[--] No path references found.":                                                "[--] 未找到路径引用。",
+	"[WARNING] %d Resources.Load path(s) point at assets moved out of Resources/; they will no longer load.\n": "[WARNING] 有 %d 处 Resources.Load 路径指向已移出 Resources/ 的资源，这些资源将无法再加载。\n",
+	"  Summary":                        "  摘要",
+	"  Moves:               %d\n":      "  移动:                %d\n",
+	"  Folders created:     %d\n":      "  新建文件夹:          %d\n",
+	"  Files updated:       %d\n":      "  更新的文件:          %d\n",
+	"  Lines updated:       %d\n":      "  更新的行数:          %d\n",
+	"\n[Dry Run] No files were moved.": "\n[Dry Run] 未移动任何文件。",
+	"\n[Dry Run] Changes go to an in-memory copy; nothing is written to disk": "\n[Dry Run] 所有更改只写入内存副本，不会写入磁盘",
+
+	"[WARNING] %s checkout failed for %s: %v\n":               "[WARNING] %s 签出 %s 失败: %v\n",
+	"[VCS] Checked out (%s): %s\n":                            "[VCS] 已签出 (%s): %s\n",
+	"[WARNING] %s add failed for %s: %v\n":                    "[WARNING] %s 添加 %s 失败: %v\n",
+	"[WARNING] %s move failed for %s, renaming on disk: %v\n": "[WARNING] %s 移动 %s 失败，改为直接在磁盘上重命名: %v\n",
+	"\nPress Enter to continue...":                            "\n按回车键继续...",
+
+	"\n[Dry Run] No changes would be made.":                          "\n[Dry Run] 不会做任何更改。",
+	"\n[Dry Run] %d change(s) would be made; nothing was written:\n": "\n[Dry Run] 将会进行 %d 项更改，未写入任何内容:\n",
+
+	"[WARNING] Removing a stale %s (%s)\n":                      "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n": "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+
+	"[ERROR] Snapshot failed, nothing was moved: %v\n":                 "[ERROR] 创建快照失败，未移动任何文件: %v\n",
+	"[OK] Snapshot taken: %s (undo with: unity_snapshot restore %s)\n": "[OK] 已创建快照: %s（撤销命令: unity_snapshot restore %s）\n",
+}
+
+// ============================================================
+// Snapshot (-snapshot)
+// ============================================================
+
+// takeSnapshot runs "unity_snapshot create" on the project, looking the tool up
+// next to this executable, then on PATH, and returns the new snapshot's ID
+func takeSnapshot(basePath, label string) (string, error) {
+	file := "unity_snapshot"
+	if runtime.GOOS == "windows" {
+		file += ".exe"
+	}
+	exe := ""
+	if self, err := os.Executable(); err == nil {
+		candidate := filepath.Join(filepath.Dir(self), file)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			exe = candidate
+		}
+	}
+	if exe == "" {
+		p, err := exec.LookPath(file)
+		if err != nil {
+			return "", fmt.Errorf("%s not found next to this tool or on PATH", file)
+		}
+		exe = p
+	}
+
+	cmd := exec.Command(exe, "create", "-json", "-label", label)
+	cmd.Dir = basePath
+	out, runErr := cmd.Output()
+	var result struct {
+		Success   bool     `json:"success"`
+		Errors    []string `json:"errors"`
+		Artifacts []string `json:"artifacts"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil || !result.Success || len(result.Artifacts) == 0 {
+		switch {
+		case len(result.Errors) > 0:
+			return "", fmt.Errorf("%s", strings.Join(result.Errors, "; "))
+		case runErr != nil:
+			return "", fmt.Errorf("%s: %v", file, runErr)
+		}
+		return "", fmt.Errorf("%s did not report a snapshot", file)
+	}
+	return filepath.Base(filepath.FromSlash(result.Artifacts[0])), nil
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	if status == "ok" && isDryRun() {
+		status = "planned"
+	}
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode bool
+	var dryRun bool
+	var force bool
+	var snapshot bool
+	var planPath string
+	var vcsMode string
+
+	flag.StringVar(&planPath, "plan", "", "File with one \"from -> to\" move per line")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no confirmation prompts)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Run the moves against an in-memory copy and list what would change")
+	flag.BoolVar(&force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
+	flag.BoolVar(&snapshot, "snapshot", false, "Take a unity_snapshot restore point of Assets/, Packages/ and ProjectSettings/ before moving")
+	flag.StringVar(&vcsMode, "vcs", "auto", "Move through version control: auto, none, p4, plastic")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_asset_mover")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+
+	var pairs [][2]string
+	switch {
+	case planPath != "" && flag.NArg() == 0:
+		var err error
+		if pairs, err = readPlan(planPath); err != nil {
+			fmt.Printf(tr("[ERROR] Cannot read plan: %v\n"), err)
+			recordError("cannot read plan: %v", err)
+			exit(1)
+		}
+	case planPath == "" && flag.NArg() == 2:
+		pairs = [][2]string{{flag.Arg(0), flag.Arg(1)}}
+	default:
+		fmt.Println(tr("Usage: unity_asset_mover [flags] <from> <to>"))
+		fmt.Println(tr("       unity_asset_mover [flags] -plan moves.txt"))
+		recordError("expected <from> <to> or -plan")
+		exit(2)
+	}
+	if len(pairs) == 0 {
+		fmt.Println(tr("[--] The plan contains no moves."))
+		exit(0)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+
+	activeVCS, err = detectVCS(basePath, vcsMode)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(1)
+	}
+	if activeVCS.kind != vcsNone {
+		fmt.Printf(tr("Version control: %s (%s), moves go through %s\n"), activeVCS.kind, activeVCS.source, activeVCS.kind)
+	}
+	if !dryRun {
+		if err := acquireProjectLock(basePath, "unity_asset_mover", "move", force); err != nil {
+			fmt.Printf(tr("[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exit(1)
+		}
+		defer releaseProjectLock()
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  MOVE PLAN"))
+	printRule("=============================================")
+	for i, p := range pairs {
+		fmt.Printf("  [%d] %s -> %s\n", i+1, normalizeAssetPath(p[0]), normalizeAssetPath(p[1]))
+	}
+
+	if dryRun {
+		fmt.Println(tr("\n[Dry Run] Changes go to an in-memory copy; nothing is written to disk"))
+		fsys = newOverlayFS()
+	} else if !ciMode {
+		fmt.Print(tr("\nMove these assets and rewrite path references? (y/N): "))
+		confirm, _ := stdinReader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+			fmt.Println(tr("Operation cancelled."))
+			exit(0)
+		}
+	}
+	if snapshot && !dryRun {
+		id, err := takeSnapshot(basePath, fmt.Sprintf("before unity_asset_mover (%d move(s))", len(pairs)))
+		if err != nil {
+			fmt.Printf(tr("[ERROR] Snapshot failed, nothing was moved: %v\n"), err)
+			recordError("snapshot failed: %v", err)
+			exit(1)
+		}
+		fmt.Printf(tr("[OK] Snapshot taken: %s (undo with: unity_snapshot restore %s)\n"), id, id)
+		recordAction("snapshot", id, "ok", "", 0)
+	}
+
+	// Moves run in order, each validated against the result of the previous ones
+	fmt.Println()
+	var rules []refRule
+	var broken []*regexp.Regexp
+	foldersCreated := 0
+	for i, p := range pairs {
+		move, err := planMove(basePath, p[0], p[1])
+		if err != nil {
+			fmt.Printf(tr("[ERROR] Move %d: %v\n"), i+1, err)
+			recordAction("move", normalizeAssetPath(p[0]), "failed", err.Error(), 0)
+			recordError("move %d: %v", i+1, err)
+			if i > 0 {
+				fmt.Println(tr("[WARNING] Earlier moves were applied; path references were not rewritten yet."))
+			}
+			exit(1)
+		}
+
+		created, err := ensureParentFolders(basePath, move.to)
+		for _, dir := range created {
+			fmt.Printf(tr("[OK] Created folder: %s (with .meta)\n"), dir)
+			recordAction("create", dir, "ok", "folder + .meta", 0)
+		}
+		foldersCreated += len(created)
+		if err != nil {
+			fmt.Printf(tr("[ERROR] Cannot create %s: %v\n"), path.Dir(move.to), err)
+			recordError("cannot create %s: %v", path.Dir(move.to), err)
+			exit(1)
+		}
+
+		metaMoved, err := moveAsset(basePath, move)
+		if err != nil {
+			fmt.Printf(tr("[ERROR] Cannot move %s: %v\n"), move.from, err)
+			recordAction("move", move.from, "failed", err.Error(), 0)
+			recordError("cannot move %s: %v", move.from, err)
+			exit(1)
+		}
+		fmt.Printf(tr("[OK] Moved: %s -> %s\n"), move.from, move.to)
+		recordAction("move", move.from, "ok", "-> "+move.to, 0)
+		if !metaMoved {
+			fmt.Printf(tr("[WARNING] %s has no .meta file; Unity will assign a new GUID.\n"), move.from)
+		}
+
+		rules = append(rules, buildRefRules(move)...)
+		if re := brokenResourcesRule(move); re != nil {
+			broken = append(broken, re)
+		}
+	}
+
+	// One pass over the project applies every move's rules in plan order, so
+	// chained moves (A -> B, then B -> C) end up pointing at C
+	fmt.Println(tr("\nRewriting path references..."))
+	changes, rewriteErr := rewriteReferences(basePath, rules)
+	lines := 0
+	for _, c := range changes {
+		rel, _ := filepath.Rel(basePath, c.path)
+		fmt.Printf(tr("[OK] Updated references: %s (%d)\n"), filepath.ToSlash(rel), c.count)
+		lines += c.count
+	}
+	if len(changes) == 0 {
+		fmt.Println(tr("[--] No path references found."))
+	}
+	if rewriteErr != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), rewriteErr)
+		recordError("%v", rewriteErr)
+	}
+	for _, re := range broken {
+		if n := countMatches(basePath, re); n > 0 {
+			fmt.Printf(tr("[WARNING] %d Resources.Load path(s) point at assets moved out of Resources/; they will no longer load.\n"), n)
+			recordError("%d Resources.Load path(s) point at assets moved out of Resources/", n)
+		}
+	}
+
+	printRule("\n=============================================")
+	fmt.Println(tr("  Summary"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Moves:               %d\n"), len(pairs))
+	fmt.Printf(tr("  Folders created:     %d\n"), foldersCreated)
+	fmt.Printf(tr("  Files updated:       %d\n"), len(changes))
+	fmt.Printf(tr("  Lines updated:       %d\n"), lines)
+
+	if overlay, ok := fsys.(*overlayFS); ok {
+		overlay.printDryRunReport(basePath)
+		fmt.Println(tr("\n[Dry Run] No files were moved."))
+	}
+	if rewriteErr != nil || len(jsonDoc.Errors) > 0 {
+		exit(1)
+	}
+	exit(0)
+}
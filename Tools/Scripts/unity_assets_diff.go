@@ -0,0 +1,1203 @@
+// Unity Assets Diff — List the assets that changed between two git refs.
+// Compares Assets/, Packages/ and ProjectSettings/ between two commits, branches
+// or tags and lists the added, removed, modified and renamed assets grouped by
+// type (textures, models, audio, prefabs, scenes...), with the size of each and
+// the size delta of each group. An asset and its .meta count as one change;
+// changes to a .meta alone (import settings, labels, a new GUID) are listed
+// apart with the settings that changed, so art-heavy pull requests can be
+// reviewed from the terminal.
+//
+// Build: go build unity_assets_diff.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_assets_diff main..feature      # what feature changes since main
+//	unity_assets_diff main...feature     # since the branches split (like a pull request)
+//	unity_assets_diff v1.2               # v1.2 against HEAD
+//	unity_assets_diff main feature -type textures,audio
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Folders of the project that are compared
+var assetRoots = []string{"Assets", "Packages", "ProjectSettings"}
+
+// Asset groups, in report order, by extension
+var assetGroups = []struct {
+	name string
+	exts []string
+}{
+	{"Scenes", []string{"unity"}},
+	{"Prefabs", []string{"prefab"}},
+	{"Textures", []string{"png", "jpg", "jpeg", "tga", "psd", "psb", "tif", "tiff", "exr", "hdr", "gif", "bmp", "iff", "pict", "ktx", "ktx2", "basis", "svg"}},
+	{"Models", []string{"fbx", "obj", "blend", "max", "ma", "mb", "3ds", "dae", "c4d", "abc", "usdz", "glb", "gltf"}},
+	{"Audio", []string{"wav", "mp3", "ogg", "aif", "aiff", "flac", "mod", "it", "s3m", "xm", "bank"}},
+	{"Video", []string{"mp4", "mov", "webm", "avi", "m4v", "ogv"}},
+	{"Materials", []string{"mat", "physicmaterial", "physicsmaterial2d", "terrainlayer"}},
+	{"Animation", []string{"anim", "controller", "overridecontroller", "mask", "playable", "signal"}},
+	{"Shaders", []string{"shader", "shadergraph", "shadersubgraph", "hlsl", "cginc", "compute", "raytrace", "vfx", "shadervariants"}},
+	{"Scripts", []string{"cs", "asmdef", "asmref", "rsp", "dll"}},
+	{"UI", []string{"uxml", "uss", "tss", "spriteatlas", "spriteatlasv2", "guiskin"}},
+	{"Fonts", []string{"ttf", "otf", "fontsettings"}},
+	{"Data", []string{"asset", "json", "xml", "txt", "bytes", "csv", "yaml", "yml", "inputactions", "mixer", "lighting", "preset"}},
+}
+
+const (
+	groupOther    = "Other"
+	groupSettings = "Project Settings"
+	groupPackages = "Packages" // the manifest and embedded packages outside Assets/
+	groupFolders  = "Folders"  // a .meta added or removed without a file: a folder
+)
+
+// Settings listed per meta-only change before "+N more"
+const maxKeysListed = 4
+
+var zeroSHA = strings.Repeat("0", 40)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// rawChange is one entry of "git diff --raw"
+type rawChange struct {
+	status           byte // A, M, D or R
+	path, oldPath    string
+	oldHash, newHash string
+}
+
+// assetChange is a changed asset, with its .meta when that changed as well
+type assetChange struct {
+	status           byte // A, M, D, R; 'm' for a change to the .meta alone
+	path, oldPath    string
+	oldSize, newSize int64
+	group            string
+	keys             []string // meta-only: the settings that changed
+}
+
+func (c *assetChange) delta() int64 {
+	return c.newSize - c.oldSize
+}
+
+// groupSummary counts the changes of one group
+type groupSummary struct {
+	name                            string
+	changes                         []*assetChange
+	added, modified, removed, moved int
+	delta                           int64
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Commands
+// ============================================================
+
+// commandRunner starts every external program the tool uses. The tool only
+// reads (diff, show, cat-file), so there is no Run for commands that change
+// something. stdin, when not empty, is written to the command's standard input.
+//
+// Two environment variables swap the runner for tests and bug reports:
+//   - UNITYSTARTER_EXEC_RECORD=<file.json> runs commands as usual and saves each
+//     one with its output and exit code
+//   - UNITYSTARTER_EXEC_REPLAY=<file.json> starts nothing and answers every
+//     command from such a file, so a run can be repeated without the programs
+//
+// The project root and the temp folder are written as $ROOT and $TMP in fixtures
+// so they replay on any machine.
+type commandRunner interface {
+	Output(ctx context.Context, stdin, name string, args ...string) (stdout, stderr []byte, err error)
+}
+
+var runner commandRunner = execRunner{}
+
+// replayingCommands is set when commands are answered from a fixture; lookPath
+// then accepts programs that are not installed
+var replayingCommands bool
+
+// setupRunner picks the runner for this run
+func setupRunner(root string) error {
+	var r commandRunner = execRunner{}
+	if path := os.Getenv("UNITYSTARTER_EXEC_REPLAY"); path != "" {
+		replay, err := loadReplayRunner(path, root)
+		if err != nil {
+			return err
+		}
+		r = replay
+		replayingCommands = true
+	}
+	if path := os.Getenv("UNITYSTARTER_EXEC_RECORD"); path != "" {
+		r = &recordingRunner{inner: r, path: path, hide: fixturePaths(root, false)}
+	}
+	runner = r
+	return nil
+}
+
+// lookPath reports whether a program can be started
+func lookPath(name string) error {
+	if replayingCommands {
+		return nil
+	}
+	_, err := exec.LookPath(name)
+	return err
+}
+
+// commandLine formats a command the way it could be pasted into a shell
+func commandLine(name string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, a := range append([]string{name}, args...) {
+		if a == "" || strings.ContainsAny(a, " \t\"'") {
+			a = strconv.Quote(a)
+		}
+		parts = append(parts, a)
+	}
+	return strings.Join(parts, " ")
+}
+
+// fixturePaths swaps the machine's paths for $ROOT and $TMP, or back with restore.
+// The longer path goes first so a project inside the temp folder stays $ROOT.
+func fixturePaths(root string, restore bool) *strings.Replacer {
+	paths := [][2]string{{root, "$ROOT"}, {filepath.Clean(os.TempDir()), "$TMP"}}
+	if len(paths[1][0]) > len(paths[0][0]) {
+		paths[0], paths[1] = paths[1], paths[0]
+	}
+	var pairs []string
+	for _, p := range paths {
+		if p[0] == "" || p[0] == "." {
+			continue
+		}
+		if restore {
+			pairs = append(pairs, p[1], p[0])
+		} else {
+			pairs = append(pairs, p[0], p[1])
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// execRunner starts the real programs
+type execRunner struct{}
+
+func (execRunner) Output(ctx context.Context, stdin, name string, args ...string) ([]byte, []byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+// commandRecord is one command in a fixture; Exit is -1 when it did not start
+// or was killed, with the reason in Error
+type commandRecord struct {
+	Args   []string `json:"args"`
+	Stdin  string   `json:"stdin,omitempty"`
+	Stdout string   `json:"stdout,omitempty"`
+	Stderr string   `json:"stderr,omitempty"`
+	Exit   int      `json:"exit"`
+	Error  string   `json:"error,omitempty"`
+}
+
+type commandFixture struct {
+	Commands []commandRecord `json:"commands"`
+}
+
+// recordingRunner saves every command to path, rewriting the file after each
+// one so an interrupted run still leaves a usable fixture
+type recordingRunner struct {
+	inner   commandRunner
+	path    string
+	hide    *strings.Replacer
+	mu      sync.Mutex
+	fixture commandFixture
+}
+
+func (r *recordingRunner) Output(ctx context.Context, stdin, name string, args ...string) ([]byte, []byte, error) {
+	stdout, stderr, err := r.inner.Output(ctx, stdin, name, args...)
+	r.save(stdin, name, args, stdout, stderr, err)
+	return stdout, stderr, err
+}
+
+func (r *recordingRunner) save(stdin, name string, args []string, stdout, stderr []byte, err error) {
+	rec := commandRecord{Stdin: r.hide.Replace(stdin), Stdout: r.hide.Replace(string(stdout)), Stderr: r.hide.Replace(string(stderr))}
+	for _, a := range append([]string{name}, args...) {
+		rec.Args = append(rec.Args, r.hide.Replace(a))
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		rec.Exit = exitErr.ExitCode()
+	default:
+		rec.Exit = -1
+		rec.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Commands = append(r.fixture.Commands, rec)
+	data, _ := json.MarshalIndent(r.fixture, "", "  ")
+	os.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// replayRunner answers commands from a fixture. A command that appears several
+// times (the same query, a retried step) gets its recordings in order,
+// the last one repeating once they run out.
+type replayRunner struct {
+	hide    *strings.Replacer
+	show    *strings.Replacer
+	mu      sync.Mutex
+	records map[string][]commandRecord
+}
+
+func loadReplayRunner(path, root string) (*replayRunner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture commandFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r := &replayRunner{hide: fixturePaths(root, false), show: fixturePaths(root, true), records: make(map[string][]commandRecord)}
+	for _, rec := range fixture.Commands {
+		key := strings.Join(append(rec.Args, rec.Stdin), "\x00")
+		r.records[key] = append(r.records[key], rec)
+	}
+	return r, nil
+}
+
+func (r *replayRunner) Output(ctx context.Context, stdin, name string, args ...string) ([]byte, []byte, error) {
+	var keyArgs []string
+	for _, a := range append([]string{name}, args...) {
+		keyArgs = append(keyArgs, r.hide.Replace(a))
+	}
+	key := strings.Join(append(keyArgs, r.hide.Replace(stdin)), "\x00")
+
+	r.mu.Lock()
+	queue := r.records[key]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, nil, fmt.Errorf("no recorded output for: %s", commandLine(name, args))
+	}
+	rec := queue[0]
+	if len(queue) > 1 {
+		r.records[key] = queue[1:]
+	}
+	r.mu.Unlock()
+
+	stdout, stderr := []byte(r.show.Replace(rec.Stdout)), []byte(r.show.Replace(rec.Stderr))
+	switch {
+	case rec.Error != "":
+		return stdout, stderr, errors.New(rec.Error)
+	case rec.Exit != 0:
+		return stdout, stderr, fmt.Errorf("exit status %d", rec.Exit)
+	}
+	return stdout, stderr, nil
+}
+
+// ============================================================
+// Git
+// ============================================================
+
+func gitOutput(dir string, args ...string) (string, error) {
+	return gitInput(dir, "", args...)
+}
+
+// gitInput runs git in dir with input on stdin
+func gitInput(dir, input string, args ...string) (string, error) {
+	stdout, stderr, err := runner.Output(context.Background(), input, "git", append([]string{"-C", dir}, args...)...)
+	if err != nil {
+		if msg := strings.TrimSpace(string(stderr)); msg != "" {
+			return "", fmt.Errorf("%s", msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(stdout)), nil
+}
+
+// splitNUL splits -z output, dropping the empty field after the last NUL
+func splitNUL(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\x00"), "\x00")
+}
+
+// resolveRange turns "A..B", "A...B", "A B" or "A" into the two commits to
+// compare. "A...B" compares B with the commit it branched from A at, like a pull
+// request; "A" alone compares A with HEAD.
+func resolveRange(basePath string, args []string) (from, to, label string, err error) {
+	var a, b string
+	mergeBase := false
+	switch {
+	case len(args) == 2:
+		a, b = args[0], args[1]
+	case strings.Contains(args[0], "..."):
+		i := strings.Index(args[0], "...")
+		a, b, mergeBase = args[0][:i], args[0][i+3:], true
+	case strings.Contains(args[0], ".."):
+		i := strings.Index(args[0], "..")
+		a, b = args[0][:i], args[0][i+2:]
+	default:
+		a = args[0]
+	}
+	// Like git, an empty side is HEAD
+	if a == "" {
+		a = "HEAD"
+	}
+	if b == "" {
+		b = "HEAD"
+	}
+	if from, err = gitOutput(basePath, "rev-parse", "--verify", "--quiet", a+"^{commit}"); err != nil || from == "" {
+		return "", "", "", fmt.Errorf("unknown revision '%s'", a)
+	}
+	if to, err = gitOutput(basePath, "rev-parse", "--verify", "--quiet", b+"^{commit}"); err != nil || to == "" {
+		return "", "", "", fmt.Errorf("unknown revision '%s'", b)
+	}
+	label = a + ".." + b
+	if mergeBase {
+		if from, err = gitOutput(basePath, "merge-base", from, to); err != nil || from == "" {
+			return "", "", "", fmt.Errorf("'%s' and '%s' have no common commit", a, b)
+		}
+		label = a + "..." + b
+	}
+	return from, to, label, nil
+}
+
+// diffRaw lists the files that differ between the commits below the asset
+// roots, relative to the project root, with renames detected
+func diffRaw(basePath, from, to string) ([]rawChange, error) {
+	args := []string{"diff", "--raw", "-z", "--no-abbrev", "-M", "--relative", from, to, "--"}
+	out, err := gitOutput(basePath, append(args, assetRoots...)...)
+	if err != nil {
+		return nil, err
+	}
+	var changes []rawChange
+	fields := splitNUL(out)
+	for i := 0; i < len(fields); i++ {
+		// :<old mode> <new mode> <old hash> <new hash> <status>, then the path(s)
+		meta := strings.Fields(strings.TrimPrefix(fields[i], ":"))
+		if len(meta) != 5 || i+1 >= len(fields) {
+			continue
+		}
+		c := rawChange{status: meta[4][0], oldHash: meta[2], newHash: meta[3]}
+		c.path = fields[i+1]
+		i++
+		if c.status == 'R' || c.status == 'C' {
+			if i+1 >= len(fields) {
+				break
+			}
+			c.oldPath, c.path = c.path, fields[i+1]
+			i++
+		}
+		switch c.status {
+		case 'C':
+			c.status, c.oldPath, c.oldHash = 'A', "", zeroSHA
+		case 'T':
+			c.status = 'M'
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// blobSizes looks up the sizes of the blobs in one git call
+func blobSizes(basePath string, hashes []string) (map[string]int64, error) {
+	var input strings.Builder
+	for _, h := range hashes {
+		if h != zeroSHA {
+			input.WriteString(h + "\n")
+		}
+	}
+	sizes := make(map[string]int64)
+	if input.Len() == 0 {
+		return sizes, nil
+	}
+	out, err := gitInput(basePath, input.String(), "cat-file", "--batch-check=%(objectname) %(objectsize)")
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if f := strings.Fields(line); len(f) == 2 {
+			sizes[f[0]], _ = strconv.ParseInt(f[1], 10, 64)
+		}
+	}
+	return sizes, nil
+}
+
+// ============================================================
+// Comparison
+// ============================================================
+
+// groupOf returns the report group of a project-relative path
+func groupOf(p string) string {
+	switch {
+	case strings.HasPrefix(p, "ProjectSettings/"):
+		return groupSettings
+	case strings.HasPrefix(p, "Packages/") && !strings.Contains(strings.TrimPrefix(p, "Packages/"), "/"):
+		return groupPackages
+	}
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(p), "."))
+	for _, g := range assetGroups {
+		if containsString(g.exts, ext) {
+			return g.name
+		}
+	}
+	return groupOther
+}
+
+// pairChanges folds each .meta into the change of its asset. A .meta that
+// changed on its own is a meta-only change; one added or removed without an
+// asset belongs to a folder.
+func pairChanges(raw []rawChange, sizes map[string]int64) []*assetChange {
+	// Rename detection can pair two unrelated .meta files that look alike; a
+	// .meta is only renamed along with its asset
+	renamed := make(map[string]string)
+	for _, r := range raw {
+		if r.status == 'R' && !strings.HasSuffix(r.path, ".meta") {
+			renamed[r.oldPath] = r.path
+		}
+	}
+	var split []rawChange
+	for _, r := range raw {
+		if r.status == 'R' && strings.HasSuffix(r.path, ".meta") &&
+			renamed[strings.TrimSuffix(r.oldPath, ".meta")] != strings.TrimSuffix(r.path, ".meta") {
+			split = append(split,
+				rawChange{status: 'D', path: r.oldPath, oldHash: r.oldHash, newHash: zeroSHA},
+				rawChange{status: 'A', path: r.path, oldHash: zeroSHA, newHash: r.newHash})
+			continue
+		}
+		split = append(split, r)
+	}
+	raw = split
+
+	metas := make(map[string]*rawChange)
+	for i := range raw {
+		if strings.HasSuffix(raw[i].path, ".meta") {
+			metas[raw[i].path] = &raw[i]
+		}
+	}
+	used := make(map[*rawChange]bool)
+	var changes []*assetChange
+	for i := range raw {
+		r := &raw[i]
+		if strings.HasSuffix(r.path, ".meta") {
+			continue
+		}
+		c := &assetChange{status: r.status, path: r.path, oldPath: r.oldPath,
+			oldSize: sizes[r.oldHash], newSize: sizes[r.newHash], group: groupOf(r.path)}
+		if m := metas[r.path+".meta"]; m != nil {
+			used[m] = true
+		}
+		if r.oldPath != "" {
+			if m := metas[r.oldPath+".meta"]; m != nil {
+				used[m] = true
+			}
+		}
+		changes = append(changes, c)
+	}
+	for i := range raw {
+		r := &raw[i]
+		if !strings.HasSuffix(r.path, ".meta") || used[r] {
+			continue
+		}
+		c := &assetChange{status: r.status, path: strings.TrimSuffix(r.path, ".meta"),
+			oldPath: strings.TrimSuffix(r.oldPath, ".meta"), group: groupFolders}
+		if r.status == 'M' {
+			c.status, c.path, c.group = 'm', r.path, groupOf(strings.TrimSuffix(r.path, ".meta"))
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// metaSettings flattens a .meta into "path: value" entries; the path follows the
+// indentation, and list items are numbered ("platformSettings[1].maxTextureSize")
+func metaSettings(text string) map[string]string {
+	type level struct {
+		indent int
+		key    string
+		items  int
+	}
+	settings := make(map[string]string)
+	var stack []level
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(trimmed)
+		item := strings.HasPrefix(trimmed, "- ")
+		if item {
+			trimmed = strings.TrimPrefix(trimmed, "- ")
+		}
+		for len(stack) > 0 && (stack[len(stack)-1].indent > indent || (stack[len(stack)-1].indent == indent && !item)) {
+			stack = stack[:len(stack)-1]
+		}
+		prefix := ""
+		if len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			if item {
+				top.items++
+			}
+			prefix = top.key
+			if top.items > 0 {
+				prefix += "[" + strconv.Itoa(top.items) + "]"
+			}
+			prefix += "."
+		}
+		i := strings.Index(trimmed, ":")
+		if i < 0 {
+			settings[prefix] += trimmed
+			continue
+		}
+		key, value := prefix+trimmed[:i], strings.TrimSpace(trimmed[i+1:])
+		settings[key] = value
+		// A list item's keys sit two columns right of its dash
+		childIndent := indent
+		if item {
+			childIndent += 2
+		}
+		stack = append(stack, level{indent: childIndent, key: key})
+	}
+	return settings
+}
+
+// changedSettings returns the last part of each setting that differs between
+// the two versions of a .meta, in file order without repeats
+func changedSettings(basePath, oldHash, newHash string) []string {
+	oldText, err1 := gitOutput(basePath, "cat-file", "blob", oldHash)
+	newText, err2 := gitOutput(basePath, "cat-file", "blob", newHash)
+	if err1 != nil || err2 != nil {
+		return nil
+	}
+	before, after := metaSettings(oldText), metaSettings(newText)
+	var keys []string
+	add := func(k string) {
+		name := k
+		if i := strings.LastIndex(k, "."); i >= 0 {
+			name = k[i+1:]
+		}
+		if name != "" && !containsString(keys, name) {
+			keys = append(keys, name)
+		}
+	}
+	var all []string
+	for k := range after {
+		all = append(all, k)
+	}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			all = append(all, k)
+		}
+	}
+	sort.Strings(all)
+	for _, k := range all {
+		if before[k] != after[k] {
+			add(k)
+		}
+	}
+	return keys
+}
+
+// summarize groups the changes in report order, the largest size change first
+func summarize(changes []*assetChange) []*groupSummary {
+	order := []string{}
+	for _, g := range assetGroups {
+		order = append(order, g.name)
+	}
+	order = append(order, groupOther, groupFolders, groupPackages, groupSettings)
+	byName := make(map[string]*groupSummary)
+	for _, c := range changes {
+		if c.status == 'm' {
+			continue
+		}
+		g := byName[c.group]
+		if g == nil {
+			g = &groupSummary{name: c.group}
+			byName[c.group] = g
+		}
+		g.changes = append(g.changes, c)
+		g.delta += c.delta()
+		switch c.status {
+		case 'A':
+			g.added++
+		case 'D':
+			g.removed++
+		case 'R':
+			g.moved++
+		default:
+			g.modified++
+		}
+	}
+	var groups []*groupSummary
+	for _, name := range order {
+		if g := byName[name]; g != nil {
+			sort.SliceStable(g.changes, func(i, j int) bool {
+				return abs64(g.changes[i].delta()) > abs64(g.changes[j].delta())
+			})
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+// formatDelta is a signed size; zero is "±0 B"
+func formatDelta(d int64) string {
+	switch {
+	case d > 0:
+		return "+" + formatSize(d)
+	case d < 0:
+		return "-" + formatSize(-d)
+	}
+	return "±0 B"
+}
+
+// counts is "+3 ~2 -1 >1" for the non-zero counts
+func counts(added, modified, removed, moved int) string {
+	var parts []string
+	for _, c := range []struct {
+		sign string
+		n    int
+	}{{"+", added}, {"~", modified}, {"-", removed}, {">", moved}} {
+		if c.n > 0 {
+			parts = append(parts, c.sign+strconv.Itoa(c.n))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func printChange(c *assetChange) {
+	switch {
+	case c.group == groupFolders && c.status == 'R':
+		fmt.Printf("  > %s -> %s\n", c.oldPath, c.path)
+		return
+	case c.group == groupFolders && c.status == 'A':
+		fmt.Printf("  + %s/\n", c.path)
+		return
+	case c.group == groupFolders:
+		fmt.Printf("  - %s/\n", c.path)
+		return
+	}
+	switch c.status {
+	case 'A':
+		fmt.Printf("  + %s  (%s)\n", c.path, formatSize(c.newSize))
+	case 'D':
+		fmt.Printf("  - %s  (%s)\n", c.path, formatSize(c.oldSize))
+	case 'R':
+		if c.oldSize != c.newSize {
+			fmt.Printf("  > %s -> %s  (%s)\n", c.oldPath, c.path, formatDelta(c.delta()))
+		} else {
+			fmt.Printf("  > %s -> %s\n", c.oldPath, c.path)
+		}
+	case 'm':
+		more := ""
+		keys := c.keys
+		if len(keys) > maxKeysListed {
+			more = fmt.Sprintf(tr(" +%d more"), len(keys)-maxKeysListed)
+			keys = keys[:maxKeysListed]
+		}
+		if len(keys) == 0 {
+			fmt.Printf("  ~ %s\n", c.path)
+		} else {
+			fmt.Printf("  ~ %s  (%s%s)\n", c.path, strings.Join(keys, ", "), more)
+		}
+	default:
+		fmt.Printf("  ~ %s  (%s -> %s, %s)\n", c.path, formatSize(c.oldSize), formatSize(c.newSize), formatDelta(c.delta()))
+	}
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...":                "\n按回车键继续...",
+	"  Added:     %d\n":                           "  新增:      %d\n",
+	"  Compared: %s (%s..%s)\n":                   "  比较:     %s (%s..%s)\n",
+	"  Meta-only: %d\n":                           "  仅 meta:   %d\n",
+	"  Modified:  %d\n":                           "  修改:      %d\n",
+	"  Project:  %s\n":                            "  项目:     %s\n",
+	"  Removed:   %d\n":                           "  删除:      %d\n",
+	"  Renamed:   %d\n":                           "  重命名:    %d\n",
+	"  SUMMARY":                                   "  摘要",
+	"  Size:      %s (changed files: %s -> %s)\n": "  大小:      %s (变更文件: %s -> %s)\n",
+	"  UNITY ASSETS DIFF":                         "  UNITY 资源差异",
+	" +%d more":                                   " 另有 %d 项",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                        "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"Usage: unity_assets_diff [flags] <from>..<to> | <from>...<to> | <from> [<to>]": "用法: unity_assets_diff [选项] <from>..<to> | <from>...<to> | <from> [<to>]",
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"\nMETA-ONLY CHANGES  %d\n":                                        "\n仅 META 变更  %d\n",
+	"\n[--] No asset changes.":                                         "\n[--] 没有资源变更。",
+
+	// Group names
+	"Scenes":           "场景",
+	"Prefabs":          "预制体",
+	"Textures":         "纹理",
+	"Models":           "模型",
+	"Audio":            "音频",
+	"Video":            "视频",
+	"Materials":        "材质",
+	"Animation":        "动画",
+	"Shaders":          "着色器",
+	"Scripts":          "脚本",
+	"UI":               "UI",
+	"Fonts":            "字体",
+	"Data":             "数据",
+	"Other":            "其他",
+	"Folders":          "文件夹",
+	"Packages":         "包",
+	"Project Settings": "项目设置",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode   bool
+		typeFlag string
+		noMeta   bool
+	)
+
+	flag.StringVar(&typeFlag, "type", "", "Comma-separated groups to list, e.g. textures,audio,prefabs (default: all)")
+	flag.BoolVar(&noMeta, "no-meta", false, "Leave out changes to .meta files alone")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the refs ("main..feature -type textures")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_assets_diff")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	if len(args) == 0 || len(args) > 2 {
+		fmt.Println(tr("Usage: unity_assets_diff [flags] <from>..<to> | <from>...<to> | <from> [<to>]"))
+		recordError("no refs given")
+		exit(2)
+	}
+	types := make(map[string]bool)
+	for _, t := range strings.Split(typeFlag, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t == "" {
+			continue
+		}
+		known := t == strings.ToLower(groupOther) || t == strings.ToLower(groupFolders) ||
+			t == strings.ToLower(groupPackages) || t == "settings"
+		for _, g := range assetGroups {
+			known = known || t == strings.ToLower(g.name)
+		}
+		if !known {
+			fail(2, "unknown -type '%s'", t)
+		}
+		if t == "settings" {
+			t = strings.ToLower(groupSettings)
+		}
+		types[t] = true
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if err := setupRunner(basePath); err != nil {
+		fail(1, "failed to load the command fixture: %v", err)
+	}
+	if err := lookPath("git"); err != nil {
+		fail(1, "git is not installed")
+	}
+	if _, err := gitOutput(basePath, "rev-parse", "--git-dir"); err != nil {
+		fail(1, "the project is not in a git repository")
+	}
+	from, to, label, err := resolveRange(basePath, args)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  UNITY ASSETS DIFF"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:  %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Compared: %s (%s..%s)\n"), label, from[:10], to[:10])
+
+	raw, err := diffRaw(basePath, from, to)
+	if err != nil {
+		fail(1, "git diff: %v", err)
+	}
+	var hashes []string
+	for _, r := range raw {
+		hashes = append(hashes, r.oldHash, r.newHash)
+	}
+	sizes, err := blobSizes(basePath, hashes)
+	if err != nil {
+		fail(1, "cannot read blob sizes: %v", err)
+	}
+	var changes []*assetChange
+	for _, c := range pairChanges(raw, sizes) {
+		if len(types) > 0 && !types[strings.ToLower(c.group)] {
+			continue
+		}
+		if c.status == 'm' && noMeta {
+			continue
+		}
+		changes = append(changes, c)
+	}
+	if len(changes) == 0 {
+		fmt.Println(tr("\n[--] No asset changes."))
+		exit(0)
+	}
+
+	groups := summarize(changes)
+	var added, modified, removed, moved int
+	var total, oldTotal, newTotal int64
+	for _, g := range groups {
+		fmt.Printf("\n%s  %s, %s\n", strings.ToUpper(tr(g.name)), counts(g.added, g.modified, g.removed, g.moved), formatDelta(g.delta))
+		for _, c := range g.changes {
+			printChange(c)
+		}
+		added, modified, removed, moved = added+g.added, modified+g.modified, removed+g.removed, moved+g.moved
+		total += g.delta
+	}
+
+	var metaOnly []*assetChange
+	for _, c := range changes {
+		oldTotal += c.oldSize
+		newTotal += c.newSize
+		if c.status == 'm' {
+			metaOnly = append(metaOnly, c)
+		}
+	}
+	if len(metaOnly) > 0 {
+		// Each .meta is read at both commits to name the settings that changed
+		byPath := make(map[string]rawChange)
+		for _, r := range raw {
+			byPath[r.path] = r
+		}
+		fmt.Printf(tr("\nMETA-ONLY CHANGES  %d\n"), len(metaOnly))
+		for _, c := range metaOnly {
+			r := byPath[c.path]
+			c.keys = changedSettings(basePath, r.oldHash, r.newHash)
+			printChange(c)
+		}
+	}
+
+	// One record per change in the JSON document
+	for _, c := range changes {
+		action, detail := "modify", c.group
+		switch c.status {
+		case 'A':
+			action = "add"
+		case 'D':
+			action = "delete"
+		case 'R':
+			action, detail = "rename", c.group+", from "+c.oldPath
+		case 'm':
+			action, detail = "meta", c.group+", "+strings.Join(c.keys, ", ")
+		}
+		if c.status != 'm' {
+			detail += ", " + formatDelta(c.delta())
+		}
+		recordAction(action, c.path, "ok", detail, 0)
+	}
+
+	fmt.Println()
+	printRule("=============================================")
+	fmt.Println(tr("  SUMMARY"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Added:     %d\n"), added)
+	fmt.Printf(tr("  Modified:  %d\n"), modified)
+	fmt.Printf(tr("  Removed:   %d\n"), removed)
+	fmt.Printf(tr("  Renamed:   %d\n"), moved)
+	fmt.Printf(tr("  Meta-only: %d\n"), len(metaOnly))
+	fmt.Printf(tr("  Size:      %s (changed files: %s -> %s)\n"), formatDelta(total), formatSize(oldTotal), formatSize(newTotal))
+	exit(0)
+}
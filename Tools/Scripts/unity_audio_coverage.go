@@ -0,0 +1,1164 @@
+// Unity Audio Coverage — Find audio files nothing uses, and audio references that point at nothing.
+// Indexes the audio files under the audio folders (Assets/ by default) and looks
+// for every way a game reaches one: GUID references in scenes, prefabs, Timeline
+// assets and other assets, CycloneGames.Audio sound banks and AudioClipReference
+// assets (by GUID, file path, StreamingAssets path or Addressables address),
+// Addressables entries, and string constants in C# that name a file, its path,
+// its Resources path or its address. Files no one reaches are orphans. References
+// to deleted clips, Resources.Load<AudioClip> paths with no file, clip references
+// that resolve to nothing and PlayEvent names no bank defines are missing
+// references, which fail the check. Run it before and after
+// audio_volume_normalizer to see which of the _normalized copies are in use.
+//
+// Build: go build unity_audio_coverage.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_audio_coverage                                # orphans and missing references
+//	unity_audio_coverage -audio Assets/Audio,Assets/Music
+//	unity_audio_coverage -all                           # also where each file is used
+//	unity_audio_coverage -strict -ci                    # CI: orphans fail too
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Audio files Unity imports, and the formats audio_volume_normalizer reads
+var audioExtensions = map[string]bool{
+	".wav": true, ".mp3": true, ".ogg": true, ".aif": true, ".aiff": true, ".flac": true,
+	".m4a": true, ".aac": true, ".wma": true, ".opus": true,
+	".mod": true, ".it": true, ".s3m": true, ".xm": true,
+}
+
+// Text assets that can hold a reference to an audio clip
+var referenceExtensions = map[string]string{
+	".unity":              "scene",
+	".prefab":             "prefab",
+	".asset":              "asset",
+	".playable":           "timeline",
+	".controller":         "asset",
+	".overrideController": "asset",
+	".anim":               "asset",
+	".preset":             "asset",
+}
+
+// Reference kinds besides the file kinds above
+const (
+	kindSoundBank     = "sound bank"
+	kindClipReference = "clip reference"
+	kindAddressables  = "addressables"
+	kindCode          = "code"
+)
+
+// Scripts of CycloneGames.Audio whose assets are read, found by file name and namespace
+var audioScripts = map[string]string{
+	"AudioBank.cs":          "bank",
+	"AudioEvent.cs":         "event",
+	"AudioClipReference.cs": "clipref",
+}
+
+const audioNamespace = "namespace CycloneGames.Audio"
+
+// Suffix audio_volume_normalizer gives the files it writes
+const normalizedSuffix = "_normalized"
+
+// Class ID of AudioClip in a reference: {fileID: 8300000, guid: ..., type: 3}
+const audioClipFileID = "8300000"
+
+// AudioLocationKind of AudioClipReference
+const (
+	locationFilePath        = "0"
+	locationStreamingAssets = "1"
+	locationAssetAddress    = "4"
+)
+
+// Folders indexed for GUIDs, so references into packages are not reported as missing
+var indexRoots = []string{"Assets", "Packages", filepath.Join("Library", "PackageCache")}
+
+var (
+	metaGUIDRegex = regexp.MustCompile(`(?m)^guid: ([0-9a-fA-F]{32})`)
+	// guid: ... in an object reference, and m_GUID: ... in clip references and Addressables entries
+	guidRefRegex = regexp.MustCompile(`(?i)guid: ([0-9a-f]{32})`)
+	clipRefRegex = regexp.MustCompile(`\{fileID: (\d+), guid: ([0-9a-fA-F]{32})`)
+	scriptRegex  = regexp.MustCompile(`m_Script: \{fileID: 11500000, guid: ([0-9a-fA-F]{32})`)
+	docRegex     = regexp.MustCompile(`(?m)^--- !u!\d+ &-?\d+`)
+
+	csStringRegex = regexp.MustCompile(`@?"((?:[^"\\\n]|\\.)*)"`)
+	// Resources.Load<AudioClip>("Sfx/Jump"), Resources.Load("Sfx/Jump", typeof(AudioClip))
+	resourcesLoadRegex = regexp.MustCompile(`Resources\.Load(?:Async)?\s*(<\s*AudioClip\s*>)?\s*\(\s*"((?:[^"\\\n]|\\.)*)"\s*(,\s*typeof\s*\(\s*AudioClip\s*\))?`)
+	// AudioManager.PlayEvent("Jump_SFX", ...) and the other lookups by event name
+	eventCallRegex = regexp.MustCompile(`\b(?:PlayEvent|PlayEventScheduled|StopAll|IsEventPlaying)\s*\(\s*"((?:[^"\\\n]|\\.)*)"`)
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// audioFile is one audio file under the audio folders
+type audioFile struct {
+	rel  string
+	guid string
+	size int64
+	refs []reference
+}
+
+// reference is one place that reaches an audio file
+type reference struct {
+	kind string
+	file string
+	line int
+}
+
+// missingRef is a reference that reaches no file or event
+type missingRef struct {
+	file   string
+	line   int
+	format string // English, translated when printed
+	args   []interface{}
+}
+
+// coverage is everything the scan found
+type coverage struct {
+	basePath string
+	files    []*audioFile
+	byGUID   map[string]*audioFile
+	byPath   map[string]*audioFile // lower-case lookups of the names code may use
+	byName   map[string][]*audioFile
+	guids    map[string]string // every GUID in the project -> asset path
+	scripts  map[string]string // script GUID -> audioScripts kind
+	address  map[string]string // Addressables address -> GUID
+	events   map[string]bool   // AudioEvent names of every bank
+	missing  []missingRef
+}
+
+// ============================================================
+// Project Index
+// ============================================================
+
+// indexGUIDs maps the GUID of every asset in the project and its packages to its path
+func indexGUIDs(basePath string) map[string]string {
+	guids := make(map[string]string)
+	for _, root := range indexRoots {
+		filepath.Walk(filepath.Join(basePath, root), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if strings.HasPrefix(info.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(info.Name(), ".meta") {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			if m := metaGUIDRegex.FindSubmatch(data); m != nil {
+				guids[strings.ToLower(string(m[1]))] = relPath(basePath, strings.TrimSuffix(path, ".meta"))
+			}
+			return nil
+		})
+	}
+	return guids
+}
+
+// findAudioScripts picks the CycloneGames.Audio scripts out of the GUID index
+func findAudioScripts(basePath string, guids map[string]string) map[string]string {
+	scripts := make(map[string]string)
+	for guid, rel := range guids {
+		kind, ok := audioScripts[filepath.Base(rel)]
+		if !ok {
+			continue
+		}
+		src, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(rel)))
+		if err == nil && bytes.Contains(src, []byte(audioNamespace)) {
+			scripts[guid] = kind
+		}
+	}
+	return scripts
+}
+
+// findAudio lists the audio files under the audio folders
+func (c *coverage) findAudio(roots []string, excludes []*regexp.Regexp) error {
+	for _, root := range roots {
+		dir := filepath.Join(c.basePath, filepath.FromSlash(root))
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("audio folder %s does not exist", root)
+		}
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if skipFolder(info.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			rel := relPath(c.basePath, path)
+			if !audioExtensions[strings.ToLower(filepath.Ext(path))] || matchesAny(excludes, rel) || c.byPath[strings.ToLower(rel)] != nil {
+				return nil
+			}
+			f := &audioFile{rel: rel, size: info.Size(), guid: metaGUID(path)}
+			c.files = append(c.files, f)
+			if f.guid != "" {
+				c.byGUID[f.guid] = f
+			}
+			c.addLookups(f)
+			return nil
+		})
+	}
+	sort.Slice(c.files, func(i, j int) bool { return c.files[i].rel < c.files[j].rel })
+	return nil
+}
+
+// addLookups registers the strings code may use for a file: its path with and
+// without extension, its Resources and StreamingAssets paths, and its file name
+func (c *coverage) addLookups(f *audioFile) {
+	lower := strings.ToLower(f.rel)
+	noExt := strings.TrimSuffix(lower, filepath.Ext(lower))
+	keys := []string{lower, noExt}
+	if i := strings.LastIndex(lower, "/resources/"); i >= 0 {
+		keys = append(keys, noExt[i+len("/resources/"):], lower[i+len("/resources/"):])
+	}
+	if strings.HasPrefix(lower, "assets/streamingassets/") {
+		keys = append(keys, lower[len("assets/streamingassets/"):])
+	}
+	for _, k := range keys {
+		if c.byPath[k] == nil {
+			c.byPath[k] = f
+		}
+	}
+	base := filepath.Base(lower)
+	for _, n := range []string{base, strings.TrimSuffix(base, filepath.Ext(base))} {
+		c.byName[n] = append(c.byName[n], f)
+	}
+}
+
+// resourcesPath is the path Resources.Load takes for a file, or "" outside Resources
+func resourcesPath(rel string) string {
+	lower := strings.ToLower(rel)
+	i := strings.LastIndex(lower, "/resources/")
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimSuffix(lower[i+len("/resources/"):], filepath.Ext(lower))
+}
+
+// metaGUID reads the GUID from the .meta file next to path
+func metaGUID(path string) string {
+	data, err := os.ReadFile(path + ".meta")
+	if err != nil {
+		return ""
+	}
+	if m := metaGUIDRegex.FindSubmatch(data); m != nil {
+		return strings.ToLower(string(m[1]))
+	}
+	return ""
+}
+
+// skipFolder reports folders Unity does not import: hidden ones and those ending in ~
+func skipFolder(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~")
+}
+
+// addRef records a reference, once per file and kind
+func (f *audioFile) addRef(kind, file string, line int) {
+	for _, r := range f.refs {
+		if r.kind == kind && r.file == file {
+			return
+		}
+	}
+	f.refs = append(f.refs, reference{kind: kind, file: file, line: line})
+}
+
+// lineCounter turns increasing byte offsets into line numbers without rescanning
+type lineCounter struct {
+	data   []byte
+	offset int
+	line   int
+}
+
+func (lc *lineCounter) at(offset int) int {
+	if offset < lc.offset {
+		lc.offset, lc.line = 0, 1
+	}
+	lc.line += bytes.Count(lc.data[lc.offset:offset], []byte("\n"))
+	lc.offset = offset
+	return lc.line
+}
+
+// ============================================================
+// Asset References
+// ============================================================
+
+// pendingClipRef is an AudioClipReference without a GUID, resolved once every
+// Addressables address is known
+type pendingClipRef struct {
+	file     string
+	line     int
+	kind     string
+	location string
+}
+
+// scanAssets reads every scene, prefab and asset for references to audio files
+func (c *coverage) scanAssets(excludes []*regexp.Regexp) {
+	var pending []pendingClipRef
+	for _, root := range []string{"Assets", "Packages"} {
+		filepath.Walk(filepath.Join(c.basePath, root), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if skipFolder(info.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			kind, ok := referenceExtensions[filepath.Ext(path)]
+			rel := relPath(c.basePath, path)
+			if !ok || matchesAny(excludes, rel) {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil || !bytes.HasPrefix(data, []byte("%YAML")) {
+				return nil
+			}
+			pending = append(pending, c.scanAsset(data, rel, kind)...)
+			return nil
+		})
+	}
+
+	// An address also names the file in code
+	for addr, guid := range c.address {
+		if f := c.byGUID[guid]; f != nil && c.byPath[strings.ToLower(addr)] == nil {
+			c.byPath[strings.ToLower(addr)] = f
+		}
+	}
+	for _, p := range pending {
+		c.resolveClipRef(p)
+	}
+}
+
+func (c *coverage) scanAsset(data []byte, rel, kind string) []pendingClipRef {
+	for _, m := range scriptRegex.FindAllSubmatch(data, -1) {
+		switch c.scripts[strings.ToLower(string(m[1]))] {
+		case "bank", "event":
+			kind = kindSoundBank
+		case "clipref":
+			if kind != kindSoundBank {
+				kind = kindClipReference
+			}
+		}
+	}
+	if bytes.Contains(data, []byte("m_SerializeEntries:")) {
+		kind = kindAddressables
+		c.readAddresses(data)
+	}
+
+	lc := &lineCounter{data: data, line: 1}
+	for _, m := range guidRefRegex.FindAllSubmatchIndex(data, -1) {
+		if f := c.byGUID[strings.ToLower(string(data[m[2]:m[3]]))]; f != nil {
+			f.addRef(kind, rel, lc.at(m[0]))
+		}
+	}
+	lc = &lineCounter{data: data, line: 1}
+	for _, m := range clipRefRegex.FindAllSubmatchIndex(data, -1) {
+		guid := strings.ToLower(string(data[m[4]:m[5]]))
+		if string(data[m[2]:m[3]]) == audioClipFileID && c.guids[guid] == "" && !strings.HasPrefix(guid, "0000000000000000") {
+			c.addMissing(rel, lc.at(m[0]), "AudioClip %s no longer exists", guid)
+		}
+	}
+
+	// Events and clip references are objects of their own in the file
+	var pending []pendingClipRef
+	starts := docRegex.FindAllIndex(data, -1)
+	lc = &lineCounter{data: data, line: 1}
+	for i, s := range starts {
+		end := len(data)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+		doc := data[s[0]:end]
+		m := scriptRegex.FindSubmatch(doc)
+		if m == nil {
+			continue
+		}
+		fields := docFields(doc)
+		switch c.scripts[strings.ToLower(string(m[1]))] {
+		case "event":
+			if name := fields["m_Name"]; name != "" {
+				c.events[name] = true
+			}
+		case "clipref":
+			line := lc.at(s[0])
+			guid := strings.ToLower(fields["m_GUID"])
+			switch {
+			case guid != "" && c.guids[guid] == "":
+				c.addMissing(rel, line, "AudioClipReference %s: GUID %s no longer exists", fields["m_Name"], guid)
+			case guid == "" && fields["m_Location"] != "":
+				pending = append(pending, pendingClipRef{file: rel, line: line, kind: fields["locationKind"], location: fields["m_Location"]})
+			}
+		}
+	}
+	return pending
+}
+
+// docFields reads the top-level scalar fields of one object
+func docFields(doc []byte) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(string(doc), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if !strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "   ") {
+			continue
+		}
+		if i := strings.Index(line, ":"); i > 0 {
+			key := strings.TrimSpace(line[:i])
+			if _, seen := fields[key]; !seen {
+				fields[key] = yamlUnquote(strings.TrimSpace(line[i+1:]))
+			}
+		}
+	}
+	return fields
+}
+
+// yamlValue returns the scalar after "key:" on a line, unquoted
+func yamlValue(line, key string) (string, bool) {
+	t := strings.TrimSpace(line)
+	if !strings.HasPrefix(t, key+":") {
+		return "", false
+	}
+	return yamlUnquote(strings.TrimSpace(t[len(key)+1:])), true
+}
+
+func yamlUnquote(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// readAddresses reads the GUID and address of every entry of an Addressables group
+func (c *coverage) readAddresses(data []byte) {
+	guid := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		t := strings.TrimPrefix(strings.TrimSpace(line), "- ")
+		if v, ok := yamlValue(t, "m_GUID"); ok {
+			guid = strings.ToLower(v)
+			continue
+		}
+		if v, ok := yamlValue(t, "m_Address"); ok && guid != "" {
+			c.address[v] = guid
+			guid = ""
+		}
+	}
+}
+
+// resolveClipRef finds the file an AudioClipReference without a GUID loads
+func (c *coverage) resolveClipRef(p pendingClipRef) {
+	loc := strings.TrimPrefix(filepath.ToSlash(p.location), "./")
+	switch p.kind {
+	case locationFilePath:
+		if f := c.byPath[strings.ToLower(loc)]; f != nil {
+			f.addRef(kindClipReference, p.file, p.line)
+		} else if strings.HasPrefix(strings.ToLower(loc), "assets/") {
+			c.addMissing(p.file, p.line, "AudioClipReference file %s does not exist", loc)
+		}
+	case locationStreamingAssets:
+		if f := c.byPath[strings.ToLower("assets/streamingassets/"+strings.TrimPrefix(loc, "/"))]; f != nil {
+			f.addRef(kindClipReference, p.file, p.line)
+		} else {
+			c.addMissing(p.file, p.line, "AudioClipReference StreamingAssets/%s does not exist", loc)
+		}
+	case locationAssetAddress:
+		if guid, ok := c.address[p.location]; ok {
+			if f := c.byGUID[guid]; f != nil {
+				f.addRef(kindClipReference, p.file, p.line)
+			}
+		} else {
+			c.addMissing(p.file, p.line, "AudioClipReference address %s is in no Addressables group", p.location)
+		}
+	}
+	// Persistent data paths and URLs are filled at runtime and cannot be checked
+}
+
+func (c *coverage) addMissing(file string, line int, format string, args ...interface{}) {
+	c.missing = append(c.missing, missingRef{file: file, line: line, format: format, args: args})
+}
+
+// ============================================================
+// Code References
+// ============================================================
+
+// scanCode reads every C# file for strings that name an audio file, audio loads
+// from Resources and event names
+func (c *coverage) scanCode(excludes []*regexp.Regexp) {
+	resources := make(map[string]bool)
+	for _, f := range c.files {
+		if p := resourcesPath(f.rel); p != "" {
+			resources[p] = true
+		}
+	}
+	checkEvents := false
+	for _, kind := range c.scripts {
+		checkEvents = checkEvents || kind == "event"
+	}
+
+	for _, root := range []string{"Assets", "Packages"} {
+		filepath.Walk(filepath.Join(c.basePath, root), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if skipFolder(info.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			rel := relPath(c.basePath, path)
+			if !strings.HasSuffix(path, ".cs") || matchesAny(excludes, rel) {
+				return nil
+			}
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			lc := &lineCounter{data: src, line: 1}
+			for _, m := range csStringRegex.FindAllSubmatchIndex(src, -1) {
+				s := csharpUnquote(string(src[m[2]:m[3]]), src[m[0]] == '@')
+				key := strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(strings.ReplaceAll(s, `\`, "/"), "./"), "/"))
+				if key == "" {
+					continue
+				}
+				if f := c.byPath[key]; f != nil {
+					f.addRef(kindCode, rel, lc.at(m[0]))
+				}
+				for _, f := range c.byName[key] {
+					f.addRef(kindCode, rel, lc.at(m[0]))
+				}
+			}
+
+			lc = &lineCounter{data: src, line: 1}
+			for _, m := range resourcesLoadRegex.FindAllSubmatchIndex(src, -1) {
+				typed := m[2] >= 0 || m[6] >= 0
+				p := csharpUnquote(string(src[m[4]:m[5]]), false)
+				if typed && !resources[strings.ToLower(p)] {
+					c.addMissing(rel, lc.at(m[0]), "Resources.Load<AudioClip>(\"%s\"): no audio file in a Resources folder", p)
+				}
+			}
+			if checkEvents {
+				lc = &lineCounter{data: src, line: 1}
+				for _, m := range eventCallRegex.FindAllSubmatchIndex(src, -1) {
+					name := csharpUnquote(string(src[m[2]:m[3]]), false)
+					if !c.events[name] {
+						c.addMissing(rel, lc.at(m[0]), "event \"%s\" is in no sound bank", name)
+					}
+				}
+			}
+			return nil
+		})
+	}
+}
+
+func csharpUnquote(s string, verbatim bool) string {
+	if verbatim {
+		return strings.ReplaceAll(s, `""`, `"`)
+	}
+	if u, err := strconv.Unquote(`"` + s + `"`); err == nil {
+		return u
+	}
+	return s
+}
+
+// normalizedNote explains an orphan that has a normalized counterpart: an English
+// format and the counterpart's name, or "" when there is none
+func (c *coverage) normalizedNote(f *audioFile) (string, string) {
+	ext := filepath.Ext(f.rel)
+	stem := strings.TrimSuffix(f.rel, ext)
+	if strings.HasSuffix(stem, normalizedSuffix) {
+		original := strings.TrimSuffix(stem, normalizedSuffix)
+		for _, o := range c.files {
+			if strings.TrimSuffix(o.rel, filepath.Ext(o.rel)) == original && len(o.refs) > 0 {
+				return "normalized copy of %s, which is still the one in use", filepath.Base(o.rel)
+			}
+		}
+		return "", ""
+	}
+	for _, o := range c.files {
+		if strings.TrimSuffix(o.rel, filepath.Ext(o.rel)) == stem+normalizedSuffix && len(o.refs) > 0 {
+			return "replaced by %s", filepath.Base(o.rel)
+		}
+	}
+	return "", ""
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode      bool
+		strict      bool
+		showAll     bool
+		audioFlag   string
+		excludeFlag string
+	)
+
+	flag.StringVar(&audioFlag, "audio", "Assets", "Comma-separated folders whose audio files are checked")
+	flag.StringVar(&excludeFlag, "exclude", "", "Comma-separated globs of files left out, both audio and references")
+	flag.BoolVar(&strict, "strict", false, "Orphaned audio files fail the check too")
+	flag.BoolVar(&showAll, "all", false, "Also list the files in use and where they are used")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_audio_coverage")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	if flag.NArg() > 0 {
+		fmt.Println(tr("Usage: unity_audio_coverage [-audio <folders>] [-exclude <globs>] [-all] [-strict] [-ci] [-json]"))
+		recordError("unexpected argument '%s'", flag.Arg(0))
+		exit(2)
+	}
+	excludes, err := compileGlobs(excludeFlag)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+
+	var roots []string
+	for _, r := range strings.Split(audioFlag, ",") {
+		if r = strings.Trim(filepath.ToSlash(strings.TrimSpace(r)), "/"); r != "" {
+			roots = append(roots, r)
+		}
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  AUDIO COVERAGE"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project: %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Audio:   %s\n"), strings.Join(roots, ", "))
+
+	c := &coverage{
+		basePath: basePath,
+		byGUID:   make(map[string]*audioFile),
+		byPath:   make(map[string]*audioFile),
+		byName:   make(map[string][]*audioFile),
+		address:  make(map[string]string),
+		events:   make(map[string]bool),
+	}
+	if err := c.findAudio(roots, excludes); err != nil {
+		fail(2, "%v", err)
+	}
+	var total int64
+	for _, f := range c.files {
+		total += f.size
+	}
+	fmt.Printf(tr("  Files:   %d (%s)\n"), len(c.files), formatSize(total))
+
+	c.guids = indexGUIDs(basePath)
+	c.scripts = findAudioScripts(basePath, c.guids)
+	c.scanAssets(excludes)
+	c.scanCode(excludes)
+
+	// Files in use, by the kind of their references
+	var orphans []*audioFile
+	byKind := make(map[string]int)
+	for _, f := range c.files {
+		if len(f.refs) == 0 {
+			orphans = append(orphans, f)
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, r := range f.refs {
+			if !seen[r.kind] {
+				seen[r.kind] = true
+				byKind[r.kind]++
+			}
+		}
+		places := make([]string, 0, len(f.refs))
+		for _, r := range f.refs {
+			places = append(places, fmt.Sprintf("%s %s:%d", r.kind, r.file, r.line))
+		}
+		recordAction("used", f.rel, "ok", strings.Join(places, ", "), 0)
+	}
+	fmt.Printf(tr("\nIn use: %d of %d file(s)\n"), len(c.files)-len(orphans), len(c.files))
+	kinds := make([]string, 0, len(byKind))
+	for k := range byKind {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	for _, k := range kinds {
+		fmt.Printf(tr("  %-15s %d file(s)\n"), tr(k), byKind[k])
+	}
+
+	if showAll {
+		for _, f := range c.files {
+			if len(f.refs) == 0 {
+				continue
+			}
+			fmt.Printf("\n%s\n", f.rel)
+			for i, r := range f.refs {
+				if i == 5 {
+					fmt.Printf(tr("  ...and %d more\n"), len(f.refs)-5)
+					break
+				}
+				fmt.Printf("  %-15s %s:%d\n", tr(r.kind), r.file, r.line)
+			}
+		}
+	}
+
+	if len(orphans) > 0 {
+		var orphanSize int64
+		for _, f := range orphans {
+			orphanSize += f.size
+		}
+		fmt.Printf(tr("\nOrphaned: %d file(s), %s\n"), len(orphans), formatSize(orphanSize))
+		for _, f := range orphans {
+			detail, shown := formatSize(f.size), formatSize(f.size)
+			if note, other := c.normalizedNote(f); note != "" {
+				detail += "; " + fmt.Sprintf(note, other)
+				shown += "; " + fmt.Sprintf(tr(note), other)
+			}
+			fmt.Printf(tr("[ORPHAN] %s (%s)\n"), f.rel, shown)
+			status := "warning"
+			if strict {
+				status = "failed"
+				recordError("%s is not used", f.rel)
+			}
+			recordAction("orphan", f.rel, status, detail, 0)
+		}
+	}
+
+	if len(c.missing) > 0 {
+		sort.SliceStable(c.missing, func(i, j int) bool {
+			if c.missing[i].file != c.missing[j].file {
+				return c.missing[i].file < c.missing[j].file
+			}
+			return c.missing[i].line < c.missing[j].line
+		})
+		fmt.Printf(tr("\nMissing references: %d\n"), len(c.missing))
+		for _, m := range c.missing {
+			detail := fmt.Sprintf(m.format, m.args...)
+			fmt.Printf(tr("[MISSING] %s:%d: %s\n"), m.file, m.line, fmt.Sprintf(tr(m.format), m.args...))
+			recordAction("missing", fmt.Sprintf("%s:%d", m.file, m.line), "failed", detail, 0)
+			recordError("%s:%d: %s", m.file, m.line, detail)
+		}
+	}
+
+	fmt.Println()
+	switch {
+	case len(c.missing) > 0:
+		fmt.Printf(tr("[ERROR] %d missing reference(s), %d orphaned file(s).\n"), len(c.missing), len(orphans))
+	case len(orphans) > 0 && strict:
+		fmt.Printf(tr("[ERROR] %d orphaned file(s) (-strict).\n"), len(orphans))
+	case len(orphans) > 0:
+		fmt.Printf(tr("[WARNING] %d orphaned file(s); no missing references.\n"), len(orphans))
+	default:
+		fmt.Println(tr("[OK] Every audio file is used and every reference resolves."))
+	}
+	if len(orphans) > 0 {
+		fmt.Println(tr("[TIP] Files loaded from outside the project (downloads, bundles built elsewhere, runtime paths) show as orphans; check before deleting."))
+	}
+	if len(c.files) > len(orphans) {
+		fmt.Println(tr("[TIP] Level the files in use with audio_volume_normalizer, run from their folder."))
+	}
+	if len(c.missing) > 0 || (strict && len(orphans) > 0) {
+		exit(1)
+	}
+	exit(0)
+}
+
+// ============================================================
+// Path Filters
+// ============================================================
+
+// globToRegexp converts a glob (*, ?, **) on slash-separated project paths. A
+// pattern without a slash matches the file name in any folder.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compileGlobs parses a comma-separated glob list
+func compileGlobs(list string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := globToRegexp(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, rel string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+// relPath shows a path relative to the project root, with forward slashes
+func relPath(basePath, p string) string {
+	if rel, err := filepath.Rel(basePath, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return p
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...":                         "\n按回车键继续...",
+	"normalized copy of %s, which is still the one in use": "%s 的归一化副本，但仍在使用原文件",
+	"replaced by %s": "已被 %s 取代",
+	"[ERROR] %v\n":   "[ERROR] %v\n",
+	"Usage: unity_audio_coverage [-audio <folders>] [-exclude <globs>] [-all] [-strict] [-ci] [-json]": "用法: unity_audio_coverage [-audio <目录>] [-exclude <glob>] [-all] [-strict] [-ci] [-json]",
+	"[ERROR] Cannot get current directory: %v\n":                                                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.":                                 "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                                           "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  AUDIO COVERAGE":             "  音频引用覆盖检查",
+	"  Project: %s\n":              "  项目: %s\n",
+	"  Audio:   %s\n":              "  音频目录: %s\n",
+	"  Files:   %d (%s)\n":         "  文件:     %d 个 (%s)\n",
+	"\nIn use: %d of %d file(s)\n": "\n在用: %d / %d 个文件\n",
+	"  %-15s %d file(s)\n":         "  %-15s %d 个文件\n",
+	"  ...and %d more\n":           "  ...另有 %d 处\n",
+	"\nOrphaned: %d file(s), %s\n": "\n未使用: %d 个文件，%s\n",
+	"[ORPHAN] %s (%s)\n":           "[ORPHAN] %s (%s)\n",
+	"\nMissing references: %d\n":   "\n缺失的引用: %d 个\n",
+	"[MISSING] %s:%d: %s\n":        "[MISSING] %s:%d: %s\n",
+	"[ERROR] %d missing reference(s), %d orphaned file(s).\n":     "[ERROR] %d 个缺失的引用，%d 个未使用的文件。\n",
+	"[ERROR] %d orphaned file(s) (-strict).\n":                    "[ERROR] %d 个未使用的文件 (-strict)。\n",
+	"[WARNING] %d orphaned file(s); no missing references.\n":     "[WARNING] %d 个未使用的文件；没有缺失的引用。\n",
+	"[OK] Every audio file is used and every reference resolves.": "[OK] 所有音频文件都在使用，所有引用都能解析。",
+	"[TIP] Files loaded from outside the project (downloads, bundles built elsewhere, runtime paths) show as orphans; check before deleting.": "[TIP] 从项目外部加载的文件（下载、在别处构建的资源包、运行时路径）会显示为未使用，删除前请确认。",
+	"[TIP] Level the files in use with audio_volume_normalizer, run from their folder.":                                                       "[TIP] 可在这些文件所在目录运行 audio_volume_normalizer 统一在用文件的响度。",
+	"scene":                         "场景",
+	"prefab":                        "预制体",
+	"asset":                         "资源",
+	"timeline":                      "Timeline",
+	"sound bank":                    "音频库",
+	"clip reference":                "片段引用",
+	"addressables":                  "Addressables",
+	"code":                          "代码",
+	"AudioClip %s no longer exists": "AudioClip %s 已不存在",
+	"AudioClipReference %s: GUID %s no longer exists":                        "AudioClipReference %s: GUID %s 已不存在",
+	"AudioClipReference file %s does not exist":                              "AudioClipReference 的文件 %s 不存在",
+	"AudioClipReference StreamingAssets/%s does not exist":                   "AudioClipReference 的 StreamingAssets/%s 不存在",
+	"AudioClipReference address %s is in no Addressables group":              "AudioClipReference 的地址 %s 不在任何 Addressables 组中",
+	"Resources.Load<AudioClip>(\"%s\"): no audio file in a Resources folder": "Resources.Load<AudioClip>(\"%s\"): Resources 目录中没有该音频文件",
+	"event \"%s\" is in no sound bank":                                       "事件 \"%s\" 不在任何音频库中",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
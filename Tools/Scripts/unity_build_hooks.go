@@ -0,0 +1,2883 @@
+// Unity Build Hooks — Install and update the editor scripts the build tools rely on.
+// The C# sources are embedded here: a contract class with the environment variables
+// the Go tools set before starting a build, a version stamping preprocessor, an
+// Addressables content build hook, a JSON build report writer, a batch mode
+// NavMesh / occlusion re-baker, and exporters for Profiler captures and Memory
+// Profiler snapshots. A manifest in ProjectSettings records what was installed,
+// so updates replace only files that were not edited locally.
+//
+// Build: go build unity_build_hooks.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_build_hooks                    # status: missing, outdated or edited hooks
+//	unity_build_hooks install            # install / update (edited files are kept)
+//	unity_build_hooks install -force     # also overwrite edited files
+//	unity_build_hooks remove
+//	unity_build_hooks -json status
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	// Bump when the embedded sources or the contract below change
+	hooksVersion = 5
+
+	defaultHooksDir  = "Assets/Build/Editor/Hooks"
+	manifestFileName = "UnityStarterBuildHooks.json" // in ProjectSettings/
+)
+
+// Contract between the Go tools and the installed hooks. Tools that run Unity set
+// these variables; the C# side reads them through BuildHooksContract, which is
+// generated from these values so both sides always agree.
+const (
+	envBuildVersion          = "UNITYSTARTER_BUILD_VERSION"
+	envBuildNumber           = "UNITYSTARTER_BUILD_NUMBER"
+	envBuildCommit           = "UNITYSTARTER_BUILD_COMMIT"
+	envBuildAddressables     = "UNITYSTARTER_BUILD_ADDRESSABLES"
+	envBuildDefines          = "UNITYSTARTER_BUILD_DEFINES"
+	envBuildReport           = "UNITYSTARTER_BUILD_REPORT"
+	defaultBuildReportRel    = "Library/UnityStarter/LastBuild.json"
+	envBakeScenes            = "UNITYSTARTER_BAKE_SCENES"
+	envBakeReport            = "UNITYSTARTER_BAKE_REPORT"
+	defaultBakeReportRel     = "Library/UnityStarter/LastBake.json"
+	envProfileCaptures       = "UNITYSTARTER_PROFILE_CAPTURES"
+	envProfileReport         = "UNITYSTARTER_PROFILE_REPORT"
+	defaultProfileReportRel  = "Library/UnityStarter/LastProfileExport.json"
+	envSnapshotExports       = "UNITYSTARTER_SNAPSHOT_EXPORTS"
+	envSnapshotReport        = "UNITYSTARTER_SNAPSHOT_REPORT"
+	defaultSnapshotReportRel = "Library/UnityStarter/LastSnapshotExport.json"
+)
+
+// hookFile is one embedded source, installed as <dir>/<name>
+type hookFile struct {
+	name    string
+	content string
+}
+
+// hookFiles returns the embedded sources with the contract values filled in
+func hookFiles() []hookFile {
+	fill := strings.NewReplacer(
+		"{{VERSION}}", strconv.Itoa(hooksVersion),
+		"{{ENV_VERSION}}", envBuildVersion,
+		"{{ENV_BUILD_NUMBER}}", envBuildNumber,
+		"{{ENV_COMMIT}}", envBuildCommit,
+		"{{ENV_ADDRESSABLES}}", envBuildAddressables,
+		"{{ENV_DEFINES}}", envBuildDefines,
+		"{{ENV_REPORT}}", envBuildReport,
+		"{{DEFAULT_REPORT}}", defaultBuildReportRel,
+		"{{ENV_BAKE_SCENES}}", envBakeScenes,
+		"{{ENV_BAKE_REPORT}}", envBakeReport,
+		"{{DEFAULT_BAKE_REPORT}}", defaultBakeReportRel,
+		"{{ENV_PROFILE_CAPTURES}}", envProfileCaptures,
+		"{{ENV_PROFILE_REPORT}}", envProfileReport,
+		"{{DEFAULT_PROFILE_REPORT}}", defaultProfileReportRel,
+		"{{ENV_SNAPSHOT_EXPORTS}}", envSnapshotExports,
+		"{{ENV_SNAPSHOT_REPORT}}", envSnapshotReport,
+		"{{DEFAULT_SNAPSHOT_REPORT}}", defaultSnapshotReportRel,
+	)
+	var files []hookFile
+	for _, f := range []hookFile{
+		{"Build.Hooks.Editor.asmdef", asmdefSource},
+		{"BuildHooksContract.cs", contractSource},
+		{"VersionStampPreprocessor.cs", versionStampSource},
+		{"AddressablesBuildHook.cs", addressablesHookSource},
+		{"ExtraDefinesBuildHook.cs", extraDefinesHookSource},
+		{"BuildReportWriter.cs", reportWriterSource},
+		{"SceneDataBaker.cs", sceneDataBakerSource},
+		{"ProfilerDataExporter.cs", profilerExporterSource},
+		{"MemorySnapshotExporter.cs", snapshotExporterSource},
+	} {
+		files = append(files, hookFile{f.name, fill.Replace(f.content)})
+	}
+	return files
+}
+
+const asmdefSource = `{
+    "name": "Build.Hooks.Editor",
+    "rootNamespace": "",
+    "references": [],
+    "includePlatforms": [
+        "Editor"
+    ],
+    "excludePlatforms": [],
+    "allowUnsafeCode": false,
+    "overrideReferences": false,
+    "precompiledReferences": [],
+    "autoReferenced": true,
+    "defineConstraints": [],
+    "versionDefines": [],
+    "noEngineReferences": false
+}
+`
+
+const contractSource = `// Installed by unity_build_hooks (hooks v{{VERSION}}). Run "unity_build_hooks install"
+// to update; files edited locally are reported and kept.
+using System;
+
+namespace Build.Hooks.Editor
+{
+    /// <summary>
+    /// Names shared with the UnityStarter command line tools. The tools set these
+    /// environment variables before starting Unity and read the report afterwards.
+    /// </summary>
+    public static class BuildHooksContract
+    {
+        public const int Version = {{VERSION}};
+
+        public const string EnvVersion = "{{ENV_VERSION}}";
+        public const string EnvBuildNumber = "{{ENV_BUILD_NUMBER}}";
+        public const string EnvCommit = "{{ENV_COMMIT}}";
+        public const string EnvBuildAddressables = "{{ENV_ADDRESSABLES}}";
+        public const string EnvDefines = "{{ENV_DEFINES}}";
+        public const string EnvReportPath = "{{ENV_REPORT}}";
+
+        public const string DefaultReportPath = "{{DEFAULT_REPORT}}";
+
+        public const string EnvBakeScenes = "{{ENV_BAKE_SCENES}}";
+        public const string EnvBakeReportPath = "{{ENV_BAKE_REPORT}}";
+        public const string DefaultBakeReportPath = "{{DEFAULT_BAKE_REPORT}}";
+
+        public const string EnvProfileCaptures = "{{ENV_PROFILE_CAPTURES}}";
+        public const string EnvProfileReportPath = "{{ENV_PROFILE_REPORT}}";
+        public const string DefaultProfileReportPath = "{{DEFAULT_PROFILE_REPORT}}";
+
+        public const string EnvSnapshotExports = "{{ENV_SNAPSHOT_EXPORTS}}";
+        public const string EnvSnapshotReportPath = "{{ENV_SNAPSHOT_REPORT}}";
+        public const string DefaultSnapshotReportPath = "{{DEFAULT_SNAPSHOT_REPORT}}";
+
+        /// <summary>
+        /// Value of an environment variable, or null when it is unset or blank.
+        /// </summary>
+        public static string Get(string name)
+        {
+            string value = Environment.GetEnvironmentVariable(name);
+            return string.IsNullOrWhiteSpace(value) ? null : value.Trim();
+        }
+
+        /// <summary>
+        /// True for "1" or "true".
+        /// </summary>
+        public static bool IsSet(string name)
+        {
+            string value = Get(name);
+            return value == "1" || string.Equals(value, "true", StringComparison.OrdinalIgnoreCase);
+        }
+
+        public static string ReportPath()
+        {
+            return Get(EnvReportPath) ?? DefaultReportPath;
+        }
+    }
+}
+`
+
+const versionStampSource = `// Installed by unity_build_hooks (hooks v{{VERSION}}). Run "unity_build_hooks install"
+// to update; files edited locally are reported and kept.
+using UnityEditor;
+using UnityEditor.Build;
+using UnityEditor.Build.Reporting;
+using UnityEngine;
+
+namespace Build.Hooks.Editor
+{
+    /// <summary>
+    /// Applies {{ENV_VERSION}} and {{ENV_BUILD_NUMBER}} to PlayerSettings before the build.
+    /// Without them the project settings are used unchanged.
+    /// </summary>
+    public class VersionStampPreprocessor : IPreprocessBuildWithReport
+    {
+        private const string DEBUG_FLAG = "[VersionStamp]";
+
+        // Early, so later processors (and the Addressables build) see the stamped version
+        public int callbackOrder => -100;
+
+        public void OnPreprocessBuild(BuildReport report)
+        {
+            string version = BuildHooksContract.Get(BuildHooksContract.EnvVersion);
+            if (version != null && version != PlayerSettings.bundleVersion)
+            {
+                Debug.Log($"{DEBUG_FLAG} bundleVersion: {PlayerSettings.bundleVersion} -> {version}");
+                PlayerSettings.bundleVersion = version;
+            }
+
+            string buildNumber = BuildHooksContract.Get(BuildHooksContract.EnvBuildNumber);
+            if (buildNumber == null)
+            {
+                return;
+            }
+            if (!int.TryParse(buildNumber, out int number) || number <= 0)
+            {
+                throw new BuildFailedException($"{DEBUG_FLAG} {BuildHooksContract.EnvBuildNumber} must be a positive integer, got '{buildNumber}'");
+            }
+
+            Debug.Log($"{DEBUG_FLAG} Build number: {number}");
+            PlayerSettings.Android.bundleVersionCode = number;
+            PlayerSettings.iOS.buildNumber = buildNumber;
+            PlayerSettings.macOS.buildNumber = buildNumber;
+        }
+    }
+}
+`
+
+const addressablesHookSource = `// Installed by unity_build_hooks (hooks v{{VERSION}}). Run "unity_build_hooks install"
+// to update; files edited locally are reported and kept.
+using System;
+using System.Reflection;
+using UnityEditor;
+using UnityEditor.Build;
+using UnityEngine;
+
+namespace Build.Hooks.Editor
+{
+    /// <summary>
+    /// Builds Addressables content before the player when {{ENV_ADDRESSABLES}}=1.
+    /// Reflection keeps the hook compiling in projects without the Addressables package.
+    /// Nothing happens when Addressables is already set to build with the player.
+    /// </summary>
+    public class AddressablesBuildHook : BuildPlayerProcessor
+    {
+        private const string DEBUG_FLAG = "[AddressablesBuildHook]";
+
+        // Before Unity's AddressablesPlayerBuildProcessor (callbackOrder = 1)
+        public override int callbackOrder => 0;
+
+        public override void PrepareForBuild(BuildPlayerContext buildPlayerContext)
+        {
+            if (!BuildHooksContract.IsSet(BuildHooksContract.EnvBuildAddressables))
+            {
+                return;
+            }
+
+            Type settingsType = FindType("UnityEditor.AddressableAssets.Settings.AddressableAssetSettings");
+            Type defaultObjectType = FindType("UnityEditor.AddressableAssets.AddressableAssetSettingsDefaultObject");
+            if (settingsType == null || defaultObjectType == null)
+            {
+                throw new BuildFailedException($"{DEBUG_FLAG} {BuildHooksContract.EnvBuildAddressables} is set but the Addressables package is not installed.");
+            }
+
+            object settings = defaultObjectType.GetProperty("Settings", BindingFlags.Public | BindingFlags.Static)?.GetValue(null);
+            if (settings == null)
+            {
+                throw new BuildFailedException($"{DEBUG_FLAG} Addressables settings not found. Create them in Window > Asset Management > Addressables > Groups.");
+            }
+
+            if (BuildsWithPlayer(settings))
+            {
+                Debug.Log($"{DEBUG_FLAG} Addressables is set to build with the player; skipping.");
+                return;
+            }
+
+            MethodInfo build = null;
+            foreach (MethodInfo method in settingsType.GetMethods(BindingFlags.Public | BindingFlags.Static))
+            {
+                ParameterInfo[] parameters = method.GetParameters();
+                if (method.Name == "BuildPlayerContent" && parameters.Length == 1 && parameters[0].IsOut)
+                {
+                    build = method;
+                    break;
+                }
+            }
+            if (build == null)
+            {
+                throw new BuildFailedException($"{DEBUG_FLAG} AddressableAssetSettings.BuildPlayerContent not found.");
+            }
+
+            Debug.Log($"{DEBUG_FLAG} Building Addressables content...");
+            object[] args = { null };
+            build.Invoke(null, args);
+            string error = args[0]?.GetType().GetProperty("Error")?.GetValue(args[0]) as string;
+            if (!string.IsNullOrEmpty(error))
+            {
+                throw new BuildFailedException($"{DEBUG_FLAG} Addressables build failed: {error}");
+            }
+            Debug.Log($"{DEBUG_FLAG} Addressables content built.");
+        }
+
+        // BuildAddressablesWithPlayerBuild: PreferencesValue, BuildWithPlayer or DoNotBuildWithPlayer
+        private static bool BuildsWithPlayer(object settings)
+        {
+            object option = settings.GetType().GetProperty("BuildAddressablesWithPlayerBuild")?.GetValue(settings);
+            switch (option?.ToString())
+            {
+                case "BuildWithPlayer":
+                    return true;
+                case "DoNotBuildWithPlayer":
+                    return false;
+                case null:
+                    return false;
+                default:
+                    return EditorPrefs.GetBool("Addressables.BuildAddressablesWithPlayerBuild", true);
+            }
+        }
+
+        private static Type FindType(string fullName)
+        {
+            foreach (Assembly assembly in AppDomain.CurrentDomain.GetAssemblies())
+            {
+                Type type = assembly.GetType(fullName);
+                if (type != null)
+                {
+                    return type;
+                }
+            }
+            return null;
+        }
+    }
+}
+`
+
+const extraDefinesHookSource = `// Installed by unity_build_hooks (hooks v{{VERSION}}). Run "unity_build_hooks install"
+// to update; files edited locally are reported and kept.
+using System;
+using System.Collections.Generic;
+using UnityEditor;
+using UnityEditor.Build;
+using UnityEngine;
+
+namespace Build.Hooks.Editor
+{
+    /// <summary>
+    /// Adds the scripting defines listed in {{ENV_DEFINES}} (separated by ';' or ',')
+    /// to this build only. They go into BuildPlayerOptions.extraScriptingDefines, so
+    /// the Player Settings define list is never changed.
+    /// </summary>
+    public class ExtraDefinesBuildHook : BuildPlayerProcessor
+    {
+        private const string DEBUG_FLAG = "[ExtraDefinesBuildHook]";
+
+        // Before the Addressables hook, so content built with the player sees the same defines
+        public override int callbackOrder => -10;
+
+        public override void PrepareForBuild(BuildPlayerContext buildPlayerContext)
+        {
+            string value = BuildHooksContract.Get(BuildHooksContract.EnvDefines);
+            if (value == null)
+            {
+                return;
+            }
+
+            BuildPlayerOptions options = buildPlayerContext.BuildPlayerOptions;
+            var defines = new List<string>(options.extraScriptingDefines ?? Array.Empty<string>());
+            foreach (string part in value.Split(new[] { ';', ',' }, StringSplitOptions.RemoveEmptyEntries))
+            {
+                string define = part.Trim();
+                if (define.Length > 0 && !defines.Contains(define))
+                {
+                    defines.Add(define);
+                }
+            }
+
+            options.extraScriptingDefines = defines.ToArray();
+            buildPlayerContext.BuildPlayerOptions = options;
+            Debug.Log($"{DEBUG_FLAG} Extra defines: {string.Join(";", defines)}");
+        }
+    }
+}
+`
+
+const reportWriterSource = `// Installed by unity_build_hooks (hooks v{{VERSION}}). Run "unity_build_hooks install"
+// to update; files edited locally are reported and kept.
+using System;
+using System.IO;
+using UnityEditor;
+using UnityEditor.Build;
+using UnityEditor.Build.Reporting;
+using UnityEngine;
+
+namespace Build.Hooks.Editor
+{
+    /// <summary>
+    /// Writes a JSON summary of each successful build to {{ENV_REPORT}}
+    /// (default: {{DEFAULT_REPORT}}) for the command line tools. The previous
+    /// report is deleted when a build starts, so a missing report means the build failed.
+    /// </summary>
+    public class BuildReportWriter : IPreprocessBuildWithReport, IPostprocessBuildWithReport
+    {
+        private const string DEBUG_FLAG = "[BuildReportWriter]";
+
+        // Last, so the report sees the output of every other post-processor
+        public int callbackOrder => 1000;
+
+        [Serializable]
+        private class Report
+        {
+            public int contractVersion;
+            public string result;
+            public string platform;
+            public string outputPath;
+            public long totalSizeBytes;
+            public double durationSeconds;
+            public int warnings;
+            public int errors;
+            public string bundleVersion;
+            public string buildNumber;
+            public string commit;
+            public string defines;
+            public string unityVersion;
+            public string startedAt;
+            public string finishedAt;
+        }
+
+        public void OnPreprocessBuild(BuildReport report)
+        {
+            string path = BuildHooksContract.ReportPath();
+            if (File.Exists(path))
+            {
+                File.Delete(path);
+            }
+        }
+
+        public void OnPostprocessBuild(BuildReport report)
+        {
+            BuildSummary summary = report.summary;
+            DateTime started = summary.buildStartedAt.ToUniversalTime();
+            DateTime finished = DateTime.UtcNow;
+            var data = new Report
+            {
+                contractVersion = BuildHooksContract.Version,
+                // Post-processors only run for builds that succeeded
+                result = "Succeeded",
+                platform = summary.platform.ToString(),
+                outputPath = summary.outputPath,
+                totalSizeBytes = OutputSize(summary.outputPath),
+                durationSeconds = Math.Round((finished - started).TotalSeconds, 1),
+                warnings = summary.totalWarnings,
+                errors = summary.totalErrors,
+                bundleVersion = PlayerSettings.bundleVersion,
+                buildNumber = BuildHooksContract.Get(BuildHooksContract.EnvBuildNumber) ?? "",
+                commit = BuildHooksContract.Get(BuildHooksContract.EnvCommit) ?? "",
+                defines = BuildHooksContract.Get(BuildHooksContract.EnvDefines) ?? "",
+                unityVersion = Application.unityVersion,
+                startedAt = started.ToString("o"),
+                finishedAt = finished.ToString("o"),
+            };
+
+            string path = BuildHooksContract.ReportPath();
+            try
+            {
+                Directory.CreateDirectory(Path.GetDirectoryName(Path.GetFullPath(path)));
+                File.WriteAllText(path, JsonUtility.ToJson(data, true));
+                Debug.Log($"{DEBUG_FLAG} Wrote {path}");
+            }
+            catch (Exception ex)
+            {
+                // A missing report must not fail a build that succeeded
+                Debug.LogWarning($"{DEBUG_FLAG} Cannot write {path}: {ex.Message}");
+            }
+        }
+
+        // Size of the build output: the folder, or the file plus its <name>_Data
+        // folder for Windows/Linux players
+        private static long OutputSize(string outputPath)
+        {
+            if (Directory.Exists(outputPath))
+            {
+                return DirectorySize(outputPath);
+            }
+            if (!File.Exists(outputPath))
+            {
+                return 0;
+            }
+            long size = new FileInfo(outputPath).Length;
+            string dataDir = Path.Combine(Path.GetDirectoryName(outputPath) ?? "", Path.GetFileNameWithoutExtension(outputPath) + "_Data");
+            if (Directory.Exists(dataDir))
+            {
+                size += DirectorySize(dataDir);
+            }
+            return size;
+        }
+
+        private static long DirectorySize(string dir)
+        {
+            long size = 0;
+            foreach (string file in Directory.GetFiles(dir, "*", SearchOption.AllDirectories))
+            {
+                size += new FileInfo(file).Length;
+            }
+            return size;
+        }
+    }
+}
+`
+
+const sceneDataBakerSource = `// Installed by unity_build_hooks (hooks v{{VERSION}}). Run "unity_build_hooks install"
+// to update; files edited locally are reported and kept.
+using System;
+using System.Collections.Generic;
+using System.IO;
+using System.Reflection;
+using UnityEditor;
+using UnityEditor.SceneManagement;
+using UnityEngine;
+using UnityEngine.SceneManagement;
+
+namespace Build.Hooks.Editor
+{
+    /// <summary>
+    /// Re-bakes NavMesh and occlusion culling data in batch mode:
+    /// -executeMethod Build.Hooks.Editor.SceneDataBaker.BakeFromCommandLine.
+    /// {{ENV_BAKE_SCENES}} lists "Assets/Scene.unity=navmesh,occlusion" entries
+    /// separated by ';'. Results go to {{ENV_BAKE_REPORT}} (default:
+    /// {{DEFAULT_BAKE_REPORT}}) and the editor exits with 1 when a scene failed.
+    /// NavMeshSurface components of the AI Navigation package are baked through
+    /// reflection, so the hooks compile without the package.
+    /// </summary>
+    public static class SceneDataBaker
+    {
+        private const string DEBUG_FLAG = "[SceneDataBaker]";
+
+        [Serializable]
+        private class SceneResult
+        {
+            public string scene;
+            public string kinds;
+            public bool navMesh;
+            public int surfaces;
+            public bool occlusion;
+            public string error;
+        }
+
+        [Serializable]
+        private class Report
+        {
+            public int contractVersion;
+            public string unityVersion;
+            public string finishedAt;
+            public List<SceneResult> scenes = new List<SceneResult>();
+        }
+
+        public static void BakeFromCommandLine()
+        {
+            var report = new Report { contractVersion = BuildHooksContract.Version, unityVersion = Application.unityVersion };
+            string value = BuildHooksContract.Get(BuildHooksContract.EnvBakeScenes);
+            if (value == null)
+            {
+                Debug.LogError($"{DEBUG_FLAG} {BuildHooksContract.EnvBakeScenes} is not set; nothing to bake.");
+                EditorApplication.Exit(1);
+                return;
+            }
+
+            int failed = 0;
+            foreach (string entry in value.Split(new[] { ';' }, StringSplitOptions.RemoveEmptyEntries))
+            {
+                int separator = entry.LastIndexOf('=');
+                var result = new SceneResult
+                {
+                    scene = (separator < 0 ? entry : entry.Substring(0, separator)).Trim(),
+                    kinds = separator < 0 ? "navmesh,occlusion" : entry.Substring(separator + 1).Trim().ToLowerInvariant(),
+                };
+                report.scenes.Add(result);
+                try
+                {
+                    Bake(result);
+                    Debug.Log($"{DEBUG_FLAG} Baked {result.scene} ({result.kinds})");
+                }
+                catch (Exception ex)
+                {
+                    failed++;
+                    result.error = ex.Message;
+                    Debug.LogError($"{DEBUG_FLAG} {result.scene}: {ex}");
+                }
+            }
+
+            report.finishedAt = DateTime.UtcNow.ToString("o");
+            string path = BuildHooksContract.Get(BuildHooksContract.EnvBakeReportPath) ?? BuildHooksContract.DefaultBakeReportPath;
+            try
+            {
+                Directory.CreateDirectory(Path.GetDirectoryName(Path.GetFullPath(path)));
+                File.WriteAllText(path, JsonUtility.ToJson(report, true));
+            }
+            catch (Exception ex)
+            {
+                failed++;
+                Debug.LogError($"{DEBUG_FLAG} Cannot write {path}: {ex.Message}");
+            }
+            EditorApplication.Exit(failed > 0 ? 1 : 0);
+        }
+
+        private static void Bake(SceneResult result)
+        {
+            Scene scene = EditorSceneManager.OpenScene(result.scene, OpenSceneMode.Single);
+            if (!scene.IsValid())
+            {
+                throw new InvalidOperationException("the scene cannot be opened");
+            }
+
+            if (result.kinds.Contains("navmesh"))
+            {
+                result.surfaces = BakeSurfaces(scene);
+                if (result.surfaces == 0)
+                {
+                    // Scenes without NavMeshSurface use the NavMesh of the Navigation window
+#pragma warning disable 618
+                    UnityEditor.AI.NavMeshBuilder.BuildNavMesh();
+#pragma warning restore 618
+                }
+                result.navMesh = true;
+            }
+            if (result.kinds.Contains("occlusion"))
+            {
+                if (!StaticOcclusionCulling.Compute())
+                {
+                    throw new InvalidOperationException("occlusion culling bake failed");
+                }
+                result.occlusion = true;
+            }
+
+            EditorSceneManager.MarkSceneDirty(scene);
+            if (!EditorSceneManager.SaveScene(scene))
+            {
+                throw new InvalidOperationException("the scene cannot be saved");
+            }
+        }
+
+        // Bakes every NavMeshSurface of the scene. The new data replaces the asset the
+        // surface had; surfaces baked for the first time get NavMesh-<name>.asset in a
+        // folder named after the scene, as the NavMeshSurface inspector does.
+        private static int BakeSurfaces(Scene scene)
+        {
+            Type surfaceType = FindType("Unity.AI.Navigation.NavMeshSurface") ?? FindType("UnityEngine.AI.NavMeshSurface");
+            if (surfaceType == null)
+            {
+                return 0;
+            }
+            MethodInfo build = surfaceType.GetMethod("BuildNavMesh", Type.EmptyTypes);
+            PropertyInfo data = surfaceType.GetProperty("navMeshData");
+            if (build == null || data == null)
+            {
+                throw new InvalidOperationException($"{surfaceType.FullName} has no BuildNavMesh() / navMeshData");
+            }
+
+            var surfaces = new List<Component>();
+            foreach (GameObject root in scene.GetRootGameObjects())
+            {
+                surfaces.AddRange(root.GetComponentsInChildren(surfaceType, true));
+            }
+
+            string sceneFolder = scene.path.Substring(0, scene.path.LastIndexOf('/'));
+            foreach (Component surface in surfaces)
+            {
+                string previousPath = AssetDatabase.GetAssetPath(data.GetValue(surface) as UnityEngine.Object);
+                build.Invoke(surface, null);
+                var baked = data.GetValue(surface) as UnityEngine.Object;
+                if (baked == null)
+                {
+                    continue;
+                }
+
+                string assetPath = previousPath;
+                if (string.IsNullOrEmpty(assetPath))
+                {
+                    string folder = sceneFolder + "/" + scene.name;
+                    if (!AssetDatabase.IsValidFolder(folder))
+                    {
+                        AssetDatabase.CreateFolder(sceneFolder, scene.name);
+                    }
+                    assetPath = AssetDatabase.GenerateUniqueAssetPath($"{folder}/NavMesh-{surface.name}.asset");
+                }
+                else
+                {
+                    AssetDatabase.DeleteAsset(assetPath);
+                }
+                AssetDatabase.CreateAsset(baked, assetPath);
+                EditorUtility.SetDirty(surface);
+            }
+            return surfaces.Count;
+        }
+
+        private static Type FindType(string fullName)
+        {
+            foreach (Assembly assembly in AppDomain.CurrentDomain.GetAssemblies())
+            {
+                Type type = assembly.GetType(fullName);
+                if (type != null)
+                {
+                    return type;
+                }
+            }
+            return null;
+        }
+    }
+}
+`
+
+const profilerExporterSource = `// Installed by unity_build_hooks (hooks v{{VERSION}}). Run "unity_build_hooks install"
+// to update; files edited locally are reported and kept.
+using System;
+using System.Collections.Generic;
+using System.Globalization;
+using System.IO;
+using System.Text;
+using UnityEditor;
+using UnityEngine;
+#if UNITY_2020_2_OR_NEWER
+using UnityEditor.Profiling;
+using UnityEditorInternal;
+#endif
+
+namespace Build.Hooks.Editor
+{
+    /// <summary>
+    /// Converts Profiler captures (.data saved from the Profiler window, .raw written
+    /// with -profiler-log-file) into CSV in batch mode:
+    /// -executeMethod Build.Hooks.Editor.ProfilerDataExporter.ExportFromCommandLine.
+    /// {{ENV_PROFILE_CAPTURES}} lists "capture=csv" pairs separated by ';'. Each CSV has
+    /// one row per frame with the main thread frame time, the GC allocations and the
+    /// render counters; a counter the capture did not record stays empty. Results go
+    /// to {{ENV_PROFILE_REPORT}} (default: {{DEFAULT_PROFILE_REPORT}}) and the editor
+    /// exits with 1 when a capture failed. Reading counters needs Unity 2020.2 or newer.
+    /// </summary>
+    public static class ProfilerDataExporter
+    {
+        private const string DEBUG_FLAG = "[ProfilerDataExporter]";
+
+        // CSV column and the Profiler counter it is read from
+        private static readonly string[,] Counters =
+        {
+            { "gcAlloc", "GC Allocated In Frame" },
+            { "gcAllocCount", "GC Allocation In Frame Count" },
+            { "drawCalls", "Draw Calls Count" },
+            { "batches", "Batches Count" },
+            { "setPassCalls", "SetPass Calls Count" },
+            { "triangles", "Triangles Count" },
+            { "vertices", "Vertices Count" },
+            { "usedMemory", "Total Used Memory" },
+        };
+
+        [Serializable]
+        private class CaptureResult
+        {
+            public string capture;
+            public string csv;
+            public int frames;
+            public string error;
+        }
+
+        [Serializable]
+        private class Report
+        {
+            public int contractVersion;
+            public string unityVersion;
+            public string finishedAt;
+            public List<CaptureResult> captures = new List<CaptureResult>();
+        }
+
+        public static void ExportFromCommandLine()
+        {
+            var report = new Report { contractVersion = BuildHooksContract.Version, unityVersion = Application.unityVersion };
+            string value = BuildHooksContract.Get(BuildHooksContract.EnvProfileCaptures);
+            if (value == null)
+            {
+                Debug.LogError($"{DEBUG_FLAG} {BuildHooksContract.EnvProfileCaptures} is not set; nothing to export.");
+                EditorApplication.Exit(1);
+                return;
+            }
+
+            int failed = 0;
+            foreach (string entry in value.Split(new[] { ';' }, StringSplitOptions.RemoveEmptyEntries))
+            {
+                int separator = entry.LastIndexOf('=');
+                var result = new CaptureResult
+                {
+                    capture = (separator < 0 ? entry : entry.Substring(0, separator)).Trim(),
+                    csv = separator < 0 ? Path.ChangeExtension(entry.Trim(), ".csv") : entry.Substring(separator + 1).Trim(),
+                };
+                report.captures.Add(result);
+                try
+                {
+                    result.frames = Export(result.capture, result.csv);
+                    Debug.Log($"{DEBUG_FLAG} Exported {result.frames} frame(s) of {result.capture} to {result.csv}");
+                }
+                catch (Exception ex)
+                {
+                    failed++;
+                    result.error = ex.Message;
+                    Debug.LogError($"{DEBUG_FLAG} {result.capture}: {ex}");
+                }
+            }
+
+            report.finishedAt = DateTime.UtcNow.ToString("o");
+            string path = BuildHooksContract.Get(BuildHooksContract.EnvProfileReportPath) ?? BuildHooksContract.DefaultProfileReportPath;
+            try
+            {
+                Directory.CreateDirectory(Path.GetDirectoryName(Path.GetFullPath(path)));
+                File.WriteAllText(path, JsonUtility.ToJson(report, true));
+            }
+            catch (Exception ex)
+            {
+                failed++;
+                Debug.LogError($"{DEBUG_FLAG} Cannot write {path}: {ex.Message}");
+            }
+            EditorApplication.Exit(failed > 0 ? 1 : 0);
+        }
+
+        private static int Export(string capture, string csv)
+        {
+#if UNITY_2020_2_OR_NEWER
+            if (!File.Exists(capture))
+            {
+                throw new FileNotFoundException("the capture does not exist", capture);
+            }
+            ProfilerDriver.ClearAllFrames();
+            if (!ProfilerDriver.LoadProfile(capture, false))
+            {
+                throw new InvalidOperationException("the capture cannot be loaded");
+            }
+            int first = ProfilerDriver.firstFrameIndex;
+            int last = ProfilerDriver.lastFrameIndex;
+            if (first < 0 || last < first)
+            {
+                throw new InvalidOperationException("the capture has no frames");
+            }
+
+            var builder = new StringBuilder("frame,frameTime");
+            for (int c = 0; c < Counters.GetLength(0); c++)
+            {
+                builder.Append(',').Append(Counters[c, 0]);
+            }
+            builder.Append('\n');
+
+            int frames = 0;
+            for (int frame = first; frame <= last; frame++)
+            {
+                // Thread 0 is the main thread, which records the frame counters
+                using (RawFrameDataView view = ProfilerDriver.GetRawFrameDataView(frame, 0))
+                {
+                    if (view == null || !view.valid)
+                    {
+                        continue;
+                    }
+                    builder.Append(frame.ToString(CultureInfo.InvariantCulture));
+                    builder.Append(',').Append(view.frameTimeMs.ToString("0.###", CultureInfo.InvariantCulture));
+                    for (int c = 0; c < Counters.GetLength(0); c++)
+                    {
+                        builder.Append(',');
+                        int marker = view.GetMarkerId(Counters[c, 1]);
+                        if (marker != FrameDataView.invalidMarkerId)
+                        {
+                            builder.Append(view.GetCounterValueAsLong(marker).ToString(CultureInfo.InvariantCulture));
+                        }
+                    }
+                    builder.Append('\n');
+                    frames++;
+                }
+            }
+            ProfilerDriver.ClearAllFrames();
+
+            Directory.CreateDirectory(Path.GetDirectoryName(Path.GetFullPath(csv)));
+            File.WriteAllText(csv, builder.ToString());
+            return frames;
+#else
+            throw new NotSupportedException("reading Profiler counters needs Unity 2020.2 or newer; export the capture to CSV instead");
+#endif
+        }
+    }
+}
+`
+
+const snapshotExporterSource = `// Installed by unity_build_hooks (hooks v{{VERSION}}). Run "unity_build_hooks install"
+// to update; files edited locally are reported and kept.
+using System;
+using System.Collections.Generic;
+using System.IO;
+using System.Reflection;
+using UnityEditor;
+using UnityEngine;
+
+namespace Build.Hooks.Editor
+{
+    /// <summary>
+    /// Exports Memory Profiler snapshots (.snap) to JSON in batch mode:
+    /// -executeMethod Build.Hooks.Editor.MemorySnapshotExporter.ExportFromCommandLine.
+    /// {{ENV_SNAPSHOT_EXPORTS}} lists "snapshot=json" pairs separated by ';'. Each JSON
+    /// file groups the native objects of the snapshot by type, name and whether they
+    /// are assets, with their count and size, plus the size of the managed heap.
+    /// Results go to {{ENV_SNAPSHOT_REPORT}} (default: {{DEFAULT_SNAPSHOT_REPORT}}) and
+    /// the editor exits with 1 when a snapshot failed. Snapshots are read through
+    /// PackedMemorySnapshot via reflection, so the hooks compile on editors without it.
+    /// </summary>
+    public static class MemorySnapshotExporter
+    {
+        private const string DEBUG_FLAG = "[MemorySnapshotExporter]";
+        private const string SnapshotTypeName = "UnityEditor.Profiling.Memory.Experimental.PackedMemorySnapshot";
+        private const int PersistentFlag = 2; // ObjectFlags.IsPersistent: loaded from an asset
+
+        [Serializable]
+        private class ObjectGroup
+        {
+            public string type;
+            public string name;
+            public bool asset;
+            public int count;
+            public long size;
+        }
+
+        [Serializable]
+        private class SnapshotExport
+        {
+            public int contractVersion;
+            public string snapshot;
+            public string recordDate;
+            public string platform;
+            public int nativeObjects;
+            public long nativeObjectBytes;
+            public long managedHeapBytes;
+            public List<ObjectGroup> objects = new List<ObjectGroup>();
+        }
+
+        [Serializable]
+        private class SnapshotResult
+        {
+            public string snapshot;
+            public string json;
+            public int objects;
+            public string error;
+        }
+
+        [Serializable]
+        private class Report
+        {
+            public int contractVersion;
+            public string unityVersion;
+            public string finishedAt;
+            public List<SnapshotResult> snapshots = new List<SnapshotResult>();
+        }
+
+        public static void ExportFromCommandLine()
+        {
+            var report = new Report { contractVersion = BuildHooksContract.Version, unityVersion = Application.unityVersion };
+            string value = BuildHooksContract.Get(BuildHooksContract.EnvSnapshotExports);
+            if (value == null)
+            {
+                Debug.LogError($"{DEBUG_FLAG} {BuildHooksContract.EnvSnapshotExports} is not set; nothing to export.");
+                EditorApplication.Exit(1);
+                return;
+            }
+
+            int failed = 0;
+            foreach (string entry in value.Split(new[] { ';' }, StringSplitOptions.RemoveEmptyEntries))
+            {
+                int separator = entry.LastIndexOf('=');
+                var result = new SnapshotResult
+                {
+                    snapshot = (separator < 0 ? entry : entry.Substring(0, separator)).Trim(),
+                    json = separator < 0 ? Path.ChangeExtension(entry.Trim(), ".json") : entry.Substring(separator + 1).Trim(),
+                };
+                report.snapshots.Add(result);
+                try
+                {
+                    result.objects = Export(result.snapshot, result.json);
+                    Debug.Log($"{DEBUG_FLAG} Exported {result.objects} native object(s) of {result.snapshot} to {result.json}");
+                }
+                catch (Exception ex)
+                {
+                    failed++;
+                    result.error = (ex is TargetInvocationException && ex.InnerException != null ? ex.InnerException : ex).Message;
+                    Debug.LogError($"{DEBUG_FLAG} {result.snapshot}: {ex}");
+                }
+            }
+
+            report.finishedAt = DateTime.UtcNow.ToString("o");
+            string path = BuildHooksContract.Get(BuildHooksContract.EnvSnapshotReportPath) ?? BuildHooksContract.DefaultSnapshotReportPath;
+            try
+            {
+                Directory.CreateDirectory(Path.GetDirectoryName(Path.GetFullPath(path)));
+                File.WriteAllText(path, JsonUtility.ToJson(report, true));
+            }
+            catch (Exception ex)
+            {
+                failed++;
+                Debug.LogError($"{DEBUG_FLAG} Cannot write {path}: {ex.Message}");
+            }
+            EditorApplication.Exit(failed > 0 ? 1 : 0);
+        }
+
+        private static int Export(string snapshotPath, string jsonPath)
+        {
+            if (!File.Exists(snapshotPath))
+            {
+                throw new FileNotFoundException("the snapshot does not exist", snapshotPath);
+            }
+            Type snapshotType = FindType(SnapshotTypeName);
+            MethodInfo load = snapshotType == null ? null : snapshotType.GetMethod("Load", BindingFlags.Public | BindingFlags.Static, null, new[] { typeof(string) }, null);
+            if (load == null)
+            {
+                throw new NotSupportedException("this editor has no PackedMemorySnapshot API to read snapshots with");
+            }
+            object snapshot = load.Invoke(null, new object[] { snapshotPath });
+            if (snapshot == null)
+            {
+                throw new InvalidOperationException("the snapshot cannot be loaded");
+            }
+
+            try
+            {
+                var export = new SnapshotExport { contractVersion = BuildHooksContract.Version, snapshot = snapshotPath };
+                object recorded = Member(snapshot, "recordDate");
+                if (recorded is DateTime)
+                {
+                    export.recordDate = ((DateTime)recorded).ToUniversalTime().ToString("o");
+                }
+                object metadata = Member(snapshot, "metadata");
+                if (metadata != null)
+                {
+                    export.platform = Member(metadata, "platform") as string;
+                }
+
+                string[] typeNames = (string[])Column(Member(snapshot, "nativeTypes"), "typeName", true);
+                object objects = Member(snapshot, "nativeObjects");
+                string[] names = (string[])Column(objects, "objectName", true);
+                int[] typeIndexes = (int[])Column(objects, "nativeTypeArrayIndex", true);
+                ulong[] sizes = (ulong[])Column(objects, "size", true);
+                Array flags = Column(objects, "flags", false);
+
+                var groups = new Dictionary<string, ObjectGroup>();
+                for (int i = 0; i < names.Length; i++)
+                {
+                    int typeIndex = typeIndexes[i];
+                    string type = typeIndex >= 0 && typeIndex < typeNames.Length ? typeNames[typeIndex] : "(unknown)";
+                    bool asset = flags != null && (Convert.ToInt32(flags.GetValue(i)) & PersistentFlag) != 0;
+                    string key = type + "\n" + names[i] + "\n" + asset;
+                    ObjectGroup group;
+                    if (!groups.TryGetValue(key, out group))
+                    {
+                        group = new ObjectGroup { type = type, name = names[i], asset = asset };
+                        groups.Add(key, group);
+                        export.objects.Add(group);
+                    }
+                    group.count++;
+                    group.size += (long)sizes[i];
+                    export.nativeObjectBytes += (long)sizes[i];
+                }
+                export.nativeObjects = names.Length;
+
+                Array sections = Column(Member(snapshot, "managedHeapSections"), "bytes", false);
+                if (sections != null)
+                {
+                    foreach (object section in sections)
+                    {
+                        var bytes = section as byte[];
+                        export.managedHeapBytes += bytes == null ? 0 : bytes.LongLength;
+                    }
+                }
+
+                Directory.CreateDirectory(Path.GetDirectoryName(Path.GetFullPath(jsonPath)));
+                File.WriteAllText(jsonPath, JsonUtility.ToJson(export));
+                return names.Length;
+            }
+            finally
+            {
+                var disposable = snapshot as IDisposable;
+                if (disposable != null)
+                {
+                    disposable.Dispose();
+                }
+            }
+        }
+
+        // A public property or field of the snapshot API; null when this editor has none
+        private static object Member(object target, string name)
+        {
+            if (target == null)
+            {
+                return null;
+            }
+            const BindingFlags flags = BindingFlags.Public | BindingFlags.Instance;
+            PropertyInfo property = target.GetType().GetProperty(name, flags);
+            if (property != null)
+            {
+                return property.GetValue(target, null);
+            }
+            FieldInfo field = target.GetType().GetField(name, flags);
+            return field == null ? null : field.GetValue(target);
+        }
+
+        // Reads a whole ArrayEntries<T> column of a snapshot table
+        private static Array Column(object table, string name, bool required)
+        {
+            object entries = Member(table, name);
+            MethodInfo count = entries == null ? null : entries.GetType().GetMethod("GetNumEntries", Type.EmptyTypes);
+            MethodInfo get = entries == null ? null : entries.GetType().GetMethod("GetEntries");
+            if (count == null || get == null || get.GetParameters().Length != 3)
+            {
+                if (required)
+                {
+                    throw new NotSupportedException($"the snapshot API of this editor has no {name} column");
+                }
+                return null;
+            }
+            int n = Convert.ToInt32(count.Invoke(entries, null));
+            // GetEntries(uint start, uint count, ref T[] data)
+            Type element = get.GetParameters()[2].ParameterType.GetElementType().GetElementType();
+            var args = new object[] { 0u, (uint)n, Array.CreateInstance(element, n) };
+            get.Invoke(entries, args);
+            return (Array)args[2];
+        }
+
+        private static Type FindType(string fullName)
+        {
+            foreach (Assembly assembly in AppDomain.CurrentDomain.GetAssemblies())
+            {
+                Type type = assembly.GetType(fullName);
+                if (type != null)
+                {
+                    return type;
+                }
+            }
+            return null;
+        }
+    }
+}
+`
+
+// .meta files written for new sources, folders and the assembly definition
+const scriptMetaTemplate = `fileFormatVersion: 2
+guid: %s
+MonoImporter:
+  externalObjects: {}
+  serializedVersion: 2
+  defaultReferences: []
+  executionOrder: 0
+  icon: {instanceID: 0}
+  userData:
+  assetBundleName:
+  assetBundleVariant:
+`
+
+const asmdefMetaTemplate = `fileFormatVersion: 2
+guid: %s
+AssemblyDefinitionImporter:
+  externalObjects: {}
+  userData:
+  assetBundleName:
+  assetBundleVariant:
+`
+
+const folderMetaTemplate = `fileFormatVersion: 2
+guid: %s
+folderAsset: yes
+DefaultImporter:
+  externalObjects: {}
+  userData:
+  assetBundleName:
+  assetBundleVariant:
+`
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// hooksManifest is ProjectSettings/UnityStarterBuildHooks.json: what was installed
+// where, with the hash of each file as written
+type hooksManifest struct {
+	HooksVersion int               `json:"hooksVersion"`
+	Dir          string            `json:"dir"`
+	Files        map[string]string `json:"files"` // name -> sha256 of the installed text
+}
+
+// Per-file states reported by status
+const (
+	stateCurrent  = "current"
+	stateMissing  = "missing"
+	stateOutdated = "outdated" // an older version, unedited: install replaces it
+	stateModified = "modified" // edited locally (or not installed by this tool): kept without -force
+)
+
+// hookStatus is one embedded file compared with the project
+type hookStatus struct {
+	file  hookFile
+	path  string // project-relative
+	state string
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Manifest & Status
+// ============================================================
+
+func manifestPath(basePath string) string {
+	return filepath.Join(basePath, "ProjectSettings", manifestFileName)
+}
+
+// loadManifest returns the manifest, or nil when the hooks were never installed
+func loadManifest(basePath string) (*hooksManifest, error) {
+	data, err := fsys.ReadFile(manifestPath(basePath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m hooksManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", manifestFileName, err)
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]string)
+	}
+	return &m, nil
+}
+
+func saveManifest(basePath string, m *hooksManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, statErr := fsys.Stat(manifestPath(basePath))
+	if err := writeFileAtomic(manifestPath(basePath), append(data, '\n')); err != nil {
+		return err
+	}
+	if statErr != nil {
+		activeVCS.add(manifestPath(basePath))
+	}
+	return nil
+}
+
+// textHash hashes text with line endings and BOM normalized, so a checkout that
+// converts to CRLF does not count as an edit
+func textHash(data []byte) string {
+	text, _ := decodeText(data)
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkStatus compares every embedded file with the project
+func checkStatus(basePath, dir string, manifest *hooksManifest) []hookStatus {
+	var result []hookStatus
+	for _, f := range hookFiles() {
+		st := hookStatus{file: f, path: dir + "/" + f.name}
+		data, err := fsys.ReadFile(filepath.Join(basePath, filepath.FromSlash(st.path)))
+		switch {
+		case err != nil:
+			st.state = stateMissing
+		case textHash(data) == textHash([]byte(f.content)):
+			st.state = stateCurrent
+		case manifest != nil && manifest.Files[f.name] == textHash(data):
+			st.state = stateOutdated
+		default:
+			st.state = stateModified
+		}
+		result = append(result, st)
+	}
+	return result
+}
+
+// ============================================================
+// Install & Remove
+// ============================================================
+
+// newGUID returns a random 32-digit hex GUID in Unity's .meta format
+func newGUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ensureFolders creates dir and its missing parents, each with a .meta so the new
+// folders get stable GUIDs instead of ones Unity invents on the next import
+func ensureFolders(basePath, dir string) ([]string, error) {
+	var created []string
+	var missing []string
+	for d := dir; d != "Assets" && d != "." && d != "/"; d = path.Dir(d) {
+		if _, err := fsys.Stat(filepath.Join(basePath, filepath.FromSlash(d))); err == nil {
+			break
+		}
+		missing = append(missing, d)
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		abs := filepath.Join(basePath, filepath.FromSlash(missing[i]))
+		if err := fsys.MkdirAll(abs, 0755); err != nil {
+			return created, err
+		}
+		meta := fmt.Sprintf(folderMetaTemplate, newGUID())
+		if err := writeFileAtomic(abs+".meta", []byte(meta)); err != nil {
+			return created, err
+		}
+		activeVCS.add(abs + ".meta")
+		created = append(created, missing[i])
+	}
+	return created, nil
+}
+
+// installFile writes one hook. An existing file keeps its .meta (and GUID) and its
+// line endings; a new one gets a fresh .meta and is marked for add.
+func installFile(basePath string, st hookStatus) error {
+	abs := filepath.Join(basePath, filepath.FromSlash(st.path))
+	format := textFormat{}
+	if data, err := fsys.ReadFile(abs); err == nil {
+		_, format = decodeText(data)
+	}
+	if err := writeTextFileAtomic(abs, st.file.content, format); err != nil {
+		return err
+	}
+	if st.state == stateMissing {
+		activeVCS.add(abs)
+	}
+	if _, err := fsys.Stat(abs + ".meta"); err == nil {
+		return nil
+	}
+	metaTemplate := scriptMetaTemplate
+	if strings.HasSuffix(st.file.name, ".asmdef") {
+		metaTemplate = asmdefMetaTemplate
+	}
+	if err := writeFileAtomic(abs+".meta", []byte(fmt.Sprintf(metaTemplate, newGUID()))); err != nil {
+		return fmt.Errorf("wrote %s but not its .meta: %v", st.path, err)
+	}
+	activeVCS.add(abs + ".meta")
+	return nil
+}
+
+// removeFile deletes one hook and its .meta
+func removeFile(basePath, rel string) error {
+	abs := filepath.Join(basePath, filepath.FromSlash(rel))
+	if err := activeVCS.remove(abs); err != nil {
+		return err
+	}
+	if _, err := fsys.Stat(abs + ".meta"); err == nil {
+		return activeVCS.remove(abs + ".meta")
+	}
+	return nil
+}
+
+// removeEmptyFolder deletes dir (and its .meta) once the hooks are gone and
+// nothing else was put there
+func removeEmptyFolder(basePath, dir string) bool {
+	abs := filepath.Join(basePath, filepath.FromSlash(dir))
+	entries, err := fsys.ReadDir(abs)
+	if err != nil || len(entries) > 0 {
+		return false
+	}
+	if err := fsys.Remove(abs); err != nil {
+		return false
+	}
+	if _, err := fsys.Stat(abs + ".meta"); err == nil {
+		activeVCS.remove(abs + ".meta")
+	}
+	return true
+}
+
+// normalizeAssetPath turns user input into a clean "Assets/..." path
+func normalizeAssetPath(p string) string {
+	p = strings.TrimSpace(strings.Trim(strings.TrimSpace(p), `"'`))
+	p = path.Clean(filepath.ToSlash(p))
+	return strings.TrimSuffix(strings.TrimPrefix(p, "./"), "/")
+}
+
+// ============================================================
+// Version Control
+// ============================================================
+
+// Hooks are added (p4 add, cm add) when installed, checked out before an update and
+// deleted through the VCS on removal, so the change lands in one changelist /
+// changeset. Git needs nothing: the files simply show up as changed.
+const (
+	vcsNone     = "none"
+	vcsPerforce = "p4"
+	vcsPlastic  = "plastic"
+)
+
+// vcsEnvVar overrides detection for every tool (e.g. on build agents)
+const vcsEnvVar = "UNITYSTARTER_VCS"
+
+// vcsClient runs checkouts, adds and deletes for files under version control
+type vcsClient struct {
+	kind       string
+	source     string // how kind was chosen, for the startup message
+	checkedOut map[string]bool
+}
+
+// activeVCS is set up in main; nil means no version control commands
+var activeVCS *vcsClient
+
+// detectVCS picks the provider. Priority: -vcs flag > UNITYSTARTER_VCS >
+// Unity's VersionControlSettings.asset mode > workspace markers (.plastic, P4CONFIG).
+func detectVCS(projectRoot, override string) (*vcsClient, error) {
+	normalize := func(v string) (string, bool) {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "p4", "perforce":
+			return vcsPerforce, true
+		case "plastic", "plasticscm", "cm", "unityvcs":
+			return vcsPlastic, true
+		case "none", "git", "off":
+			return vcsNone, true
+		}
+		return "", false
+	}
+	newClient := func(kind, source string) *vcsClient {
+		return &vcsClient{kind: kind, source: source, checkedOut: make(map[string]bool)}
+	}
+
+	if override != "" && override != "auto" {
+		kind, ok := normalize(override)
+		if !ok {
+			return nil, fmt.Errorf("unknown -vcs value '%s' (use auto, none, p4 or plastic)", override)
+		}
+		return newClient(kind, "-vcs flag"), nil
+	}
+	if env := os.Getenv(vcsEnvVar); env != "" {
+		if kind, ok := normalize(env); ok {
+			return newClient(kind, vcsEnvVar), nil
+		}
+	}
+
+	// Unity stores the project's integrated VCS in m_Mode ("Perforce", "PlasticSCM", ...)
+	if data, err := os.ReadFile(filepath.Join(projectRoot, "ProjectSettings", "VersionControlSettings.asset")); err == nil {
+		if m := regexp.MustCompile(`(?m)^\s*m_Mode:\s*(.+?)\s*$`).FindSubmatch(data); m != nil {
+			switch strings.ToLower(string(m[1])) {
+			case "perforce":
+				return newClient(vcsPerforce, "VersionControlSettings.asset"), nil
+			case "plasticscm", "plastic scm", "unity version control":
+				return newClient(vcsPlastic, "VersionControlSettings.asset"), nil
+			}
+		}
+	}
+
+	// Workspace markers, searched upward from the project root
+	p4config := os.Getenv("P4CONFIG")
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info != nil {
+				return newClient(vcsNone, "git repository"), nil
+			}
+			if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+				return newClient(vcsPlastic, ".plastic workspace"), nil
+			}
+			if p4config != "" {
+				if _, err := os.Stat(filepath.Join(dir, p4config)); err == nil {
+					return newClient(vcsPerforce, "P4CONFIG"), nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if os.Getenv("P4CLIENT") != "" {
+		return newClient(vcsPerforce, "P4CLIENT"), nil
+	}
+	return newClient(vcsNone, "no Perforce/Plastic workspace found"), nil
+}
+
+// run executes a VCS command from dir; a dry run only lists it
+func (v *vcsClient) run(dir string, args ...string) error {
+	if overlay, ok := fsys.(*overlayFS); ok {
+		overlay.noteCommand(args...)
+		return nil
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	// Run from the file's folder so P4CONFIG / the Plastic workspace is resolved
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %v %s", args[0], args[1], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// checkout opens an existing file for edit. Files outside the depot/workspace only
+// produce a warning: the write still goes ahead.
+func (v *vcsClient) checkout(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || v.checkedOut[abs] {
+		return
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return
+	}
+	v.checkedOut[abs] = true
+
+	var err2 error
+	switch v.kind {
+	case vcsPerforce:
+		err2 = v.run(filepath.Dir(abs), "p4", "edit", abs)
+	case vcsPlastic:
+		err2 = v.run(filepath.Dir(abs), "cm", "checkout", abs)
+	}
+	if err2 != nil {
+		fmt.Printf(tr("[WARNING] %s checkout failed for %s: %v\n"), v.kind, filepath.Base(abs), err2)
+		return
+	}
+	fmt.Printf(tr("[VCS] Checked out (%s): %s\n"), v.kind, filepath.Base(abs))
+}
+
+// add marks a newly created file for add
+func (v *vcsClient) add(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	var err2 error
+	switch v.kind {
+	case vcsPerforce:
+		err2 = v.run(filepath.Dir(abs), "p4", "add", abs)
+	case vcsPlastic:
+		err2 = v.run(filepath.Dir(abs), "cm", "add", abs)
+	}
+	if err2 != nil {
+		fmt.Printf(tr("[WARNING] %s add failed for %s: %v\n"), v.kind, filepath.Base(abs), err2)
+	}
+}
+
+// remove deletes a file through the VCS when there is one (p4 delete, cm remove).
+// Files the VCS does not know, and files it leaves on disk, are deleted directly.
+func (v *vcsClient) remove(path string) error {
+	if v != nil && v.kind != vcsNone {
+		if abs, err := filepath.Abs(path); err == nil {
+			switch v.kind {
+			case vcsPerforce:
+				err = v.run(filepath.Dir(abs), "p4", "delete", abs)
+			case vcsPlastic:
+				err = v.run(filepath.Dir(abs), "cm", "remove", abs)
+			}
+			if err != nil {
+				fmt.Printf(tr("[WARNING] %s delete failed for %s, deleting on disk: %v\n"), v.kind, filepath.Base(abs), err)
+			}
+		}
+	}
+	if _, err := fsys.Stat(path); err != nil {
+		return nil
+	}
+	return fsys.Remove(path)
+}
+
+// ============================================================
+// Safe File Writes
+// ============================================================
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// writeFileAtomic replaces path with data without ever leaving a half-written file:
+// data goes to a temp file in the same directory which is then renamed over the target.
+// The target's permission bits are kept, and a read-only flag (files under Perforce or
+// Plastic SCM that were not checked out) is cleared so the write does not fail.
+func writeFileAtomic(path string, data []byte) error {
+	// A dry run writes straight into the overlay: no checkout, no temp file
+	if isDryRun() {
+		return fsys.WriteFile(path, data, 0644)
+	}
+	activeVCS.checkout(path)
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if mode&0200 == 0 {
+			mode |= 0200
+			if err := os.Chmod(path, mode); err != nil {
+				return fmt.Errorf("cannot clear read-only flag on %s: %v", path, err)
+			}
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Retry briefly: editors, indexers and antivirus can hold the target open on Windows
+	for attempt := 0; ; attempt++ {
+		err = os.Rename(tmpPath, path)
+		if err == nil || attempt == 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// textFormat records the BOM and line-ending style of a text file so rewrites keep it
+type textFormat struct {
+	bom  bool
+	crlf bool // ending of most lines, and of the lines a rewrite adds
+	// Mixed files only: the text as read (LF) and the ending of each of its line
+	// breaks, 'r' (CRLF) or 'n' (LF). Strings keep textFormat comparable.
+	orig string
+	eols string
+}
+
+// decodeText strips a UTF-8 BOM and normalizes line endings to LF. A file counts as
+// CRLF when most of its line breaks are CRLF; a file with both keeps the ending of
+// every line, so a rewrite only changes the endings of the lines it edits.
+func decodeText(data []byte) (string, textFormat) {
+	var format textFormat
+	if bytes.HasPrefix(data, utf8BOM) {
+		format.bom = true
+		data = data[len(utf8BOM):]
+	}
+	crlfCount := bytes.Count(data, []byte("\r\n"))
+	lfCount := bytes.Count(data, []byte("\n")) - crlfCount
+	format.crlf = crlfCount > 0 && crlfCount >= lfCount
+	if crlfCount == 0 {
+		return string(data), format
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if lfCount > 0 {
+		eols := make([]byte, 0, crlfCount+lfCount)
+		for i, c := range data {
+			if c == '\n' {
+				if i > 0 && data[i-1] == '\r' {
+					eols = append(eols, 'r')
+				} else {
+					eols = append(eols, 'n')
+				}
+			}
+		}
+		format.orig, format.eols = text, string(eols)
+	}
+	return text, format
+}
+
+// encode converts LF text back to the recorded format
+func (f textFormat) encode(text string) []byte {
+	switch {
+	case f.eols != "":
+		text = f.mixedEndings(text)
+	case f.crlf:
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	if f.bom {
+		return append(append([]byte{}, utf8BOM...), text...)
+	}
+	return []byte(text)
+}
+
+// mixedEndings gives the lines of a rewritten mixed file the endings they had. The
+// unchanged lines before and after the edited region keep theirs; edited lines keep
+// the ending of the line they replace when the line count did not change, and get
+// the majority ending otherwise.
+func (f textFormat) mixedEndings(text string) string {
+	old := strings.Split(f.orig, "\n")
+	lines := strings.Split(text, "\n")
+	prefix := 0
+	for prefix < len(old) && prefix < len(lines) && old[prefix] == lines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(lines)-prefix && old[len(old)-1-suffix] == lines[len(lines)-1-suffix] {
+		suffix++
+	}
+	crlfAt := func(j int) bool {
+		if j >= 0 && j < len(f.eols) {
+			return f.eols[j] == 'r'
+		}
+		return f.crlf
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		b.WriteString(line)
+		if i == len(lines)-1 {
+			break
+		}
+		crlf := f.crlf
+		switch {
+		case i < prefix || len(lines) == len(old):
+			crlf = crlfAt(i)
+		case i >= len(lines)-suffix:
+			crlf = crlfAt(i - len(lines) + len(old))
+		}
+		if crlf {
+			b.WriteByte('\r')
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// writeTextFileAtomic writes LF text in the given format via writeFileAtomic
+func writeTextFileAtomic(path, text string, format textFormat) error {
+	return writeFileAtomic(path, format.encode(text))
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Filesystem
+// ============================================================
+
+// fileSystem is every filesystem call that can change the project. The real
+// run uses osFS; -dry-run swaps in an overlayFS so the same code path runs
+// and the overlay reports what it would have changed.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Walk(root string, fn filepath.WalkFunc) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Chmod(name string, mode os.FileMode) error
+}
+
+var fsys fileSystem = osFS{}
+
+// isDryRun reports whether changes are going to the in-memory overlay
+func isDryRun() bool {
+	_, ok := fsys.(*overlayFS)
+	return ok
+}
+
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)         { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) Walk(root string, fn filepath.WalkFunc) error { return filepath.Walk(root, fn) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// fsChange is one change the overlay absorbed instead of the disk
+type fsChange struct {
+	op     string // "write", "create", "mkdir", "rename", "delete"
+	path   string
+	detail string
+}
+
+// overlayFS reads through to the disk and keeps every write, rename and
+// delete in memory. Later reads see those changes, so multi-step operations
+// (rename a folder, then rewrite files inside it) behave as they would for real.
+type overlayFS struct {
+	mu      sync.Mutex
+	files   map[string][]byte // files written during the run
+	dirs    map[string]bool   // directories created during the run
+	moved   map[string]string // renamed path -> where its content is on disk
+	removed map[string]bool   // deleted paths; hides everything below them
+	changes []fsChange
+}
+
+func newOverlayFS() *overlayFS {
+	return &overlayFS{
+		files:   map[string][]byte{},
+		dirs:    map[string]bool{},
+		moved:   map[string]string{},
+		removed: map[string]bool{},
+	}
+}
+
+func overlayKey(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// isUnder reports whether p is base or inside it
+func isUnder(p, base string) bool {
+	return p == base || strings.HasPrefix(p, strings.TrimSuffix(base, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// locate finds where the content of p lives: in memory (data / memDir) or
+// on disk (disk, after following renames). ok is false if p was deleted.
+func (o *overlayFS) locate(p string) (data []byte, memFile, memDir bool, disk string, ok bool) {
+	if d, found := o.files[p]; found {
+		return d, true, false, "", true
+	}
+	if o.dirs[p] {
+		return nil, false, true, "", true
+	}
+	for q := p; ; {
+		if src, found := o.moved[q]; found {
+			rel, _ := filepath.Rel(q, p)
+			return nil, false, false, filepath.Join(src, rel), true
+		}
+		if o.removed[q] {
+			return nil, false, false, "", false
+		}
+		parent := filepath.Dir(q)
+		if parent == q {
+			break
+		}
+		q = parent
+	}
+	return nil, false, false, p, true
+}
+
+func (o *overlayFS) stat(p string) (os.FileInfo, error) {
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	case memFile:
+		return memFileInfo{name: filepath.Base(p), size: int64(len(data))}, nil
+	case memDir:
+		return memFileInfo{name: filepath.Base(p), dir: true}, nil
+	}
+	info, err := os.Stat(disk)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	if disk != p {
+		return renamedFileInfo{info, filepath.Base(p)}, nil
+	}
+	return info, nil
+}
+
+func (o *overlayFS) Stat(name string) (os.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stat(overlayKey(name))
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case memDir:
+		return nil, &os.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	case memFile:
+		return append([]byte(nil), data...), nil
+	}
+	return os.ReadFile(disk)
+}
+
+func (o *overlayFS) readDir(p string) ([]os.DirEntry, error) {
+	_, memFile, memDir, disk, ok := o.locate(p)
+	if !ok || memFile {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+	names := map[string]bool{}
+	if !memDir {
+		entries, err := os.ReadDir(disk)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			names[e.Name()] = true
+		}
+	}
+	for k := range o.dirs {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.files {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.moved {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	var result []os.DirEntry
+	for name := range names {
+		if info, err := o.stat(filepath.Join(p, name)); err == nil {
+			result = append(result, fs.FileInfoToDirEntry(info))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (o *overlayFS) ReadDir(name string) ([]os.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readDir(overlayKey(name))
+}
+
+// Walk follows filepath.Walk: lexical order, SkipDir and SkipAll honored.
+// The lock is released while fn runs so the callback can change the overlay.
+func (o *overlayFS) Walk(root string, fn filepath.WalkFunc) error {
+	info, err := o.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = o.walk(root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (o *overlayFS) walk(path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	entries, err := o.ReadDir(path)
+	if err1 := fn(path, info, err); err != nil || err1 != nil {
+		return err1
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		childInfo, err := o.Stat(child)
+		if err != nil {
+			if err := fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := o.walk(child, childInfo, fn); err != nil {
+			if err == filepath.SkipDir && childInfo.IsDir() {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *overlayFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	op := "create"
+	if info, err := o.stat(p); err == nil {
+		if info.IsDir() {
+			return &os.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+		}
+		op = "write"
+	}
+	if info, err := o.stat(filepath.Dir(p)); err != nil || !info.IsDir() {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	o.files[p] = append([]byte(nil), data...)
+	o.changes = append(o.changes, fsChange{op, p, fmt.Sprintf("%d bytes", len(data))})
+	return nil
+}
+
+func (o *overlayFS) MkdirAll(path string, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	created := false
+	for q := p; ; q = filepath.Dir(q) {
+		if info, err := o.stat(q); err == nil {
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: q, Err: errors.New("not a directory")}
+			}
+			break
+		}
+		o.dirs[q] = true
+		created = true
+		if filepath.Dir(q) == q {
+			break
+		}
+	}
+	if created {
+		o.changes = append(o.changes, fsChange{"mkdir", p, ""})
+	}
+	return nil
+}
+
+// shift moves every overlay entry under from to the same place under to
+func (o *overlayFS) shift(from, to string) {
+	for k, v := range o.files {
+		if isUnder(k, from) {
+			delete(o.files, k)
+			o.files[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, from) {
+			delete(o.dirs, k)
+			o.dirs[to+strings.TrimPrefix(k, from)] = true
+		}
+	}
+	for k, v := range o.moved {
+		if isUnder(k, from) {
+			delete(o.moved, k)
+			o.moved[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+}
+
+func (o *overlayFS) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	from, to := overlayKey(oldpath), overlayKey(newpath)
+	_, _, _, disk, ok := o.locate(from)
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if from == to {
+		return nil
+	}
+	if info, err := o.stat(filepath.Dir(to)); err != nil || !info.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	o.drop(to)
+	o.shift(from, to)
+	if disk != "" {
+		if _, err := os.Stat(disk); err == nil {
+			o.moved[to] = disk
+		}
+	}
+	o.removed[from] = true
+	o.changes = append(o.changes, fsChange{"rename", from, to})
+	return nil
+}
+
+// drop forgets p and everything below it and hides it from the disk
+func (o *overlayFS) drop(p string) {
+	for k := range o.files {
+		if isUnder(k, p) {
+			delete(o.files, k)
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, p) {
+			delete(o.dirs, k)
+		}
+	}
+	for k := range o.moved {
+		if isUnder(k, p) {
+			delete(o.moved, k)
+		}
+	}
+	o.removed[p] = true
+}
+
+// treeSize is the byte size of p as the overlay currently sees it
+func (o *overlayFS) treeSize(p string) int64 {
+	info, err := o.stat(p)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var size int64
+	entries, _ := o.readDir(p)
+	for _, e := range entries {
+		size += o.treeSize(filepath.Join(p, e.Name()))
+	}
+	return size
+}
+
+func (o *overlayFS) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	info, err := o.stat(p)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	if info.IsDir() {
+		if entries, _ := o.readDir(p); len(entries) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", info.Size())})
+	return nil
+}
+
+func (o *overlayFS) RemoveAll(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	if _, err := o.stat(p); err != nil {
+		return nil
+	}
+	size := o.treeSize(p)
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", size)})
+	return nil
+}
+
+func (o *overlayFS) Chmod(name string, mode os.FileMode) error {
+	if _, err := o.Stat(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+// noteCommand lists a step the overlay cannot simulate, such as a p4 or cm
+// command
+func (o *overlayFS) noteCommand(args ...string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.changes = append(o.changes, fsChange{"run", strings.Join(args, " "), ""})
+}
+
+// printDryRunReport lists what the overlay absorbed, relative to base
+func (o *overlayFS) printDryRunReport(base string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if abs, err := filepath.Abs(base); err == nil {
+		base = abs
+	}
+	if len(o.changes) == 0 {
+		fmt.Println(tr("\n[Dry Run] No changes would be made."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d change(s) would be made; nothing was written:\n"), len(o.changes))
+	rel := func(p string) string {
+		if r, err := filepath.Rel(base, p); err == nil && !strings.HasPrefix(r, "..") {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	for _, c := range o.changes {
+		switch c.op {
+		case "rename":
+			fmt.Printf("  %-7s %s -> %s\n", c.op, rel(c.path), rel(c.detail))
+		case "mkdir", "run":
+			fmt.Printf("  %-7s %s\n", c.op, rel(c.path))
+		default:
+			fmt.Printf("  %-7s %s (%s)\n", c.op, rel(c.path), c.detail)
+		}
+	}
+}
+
+// memFileInfo describes a file or directory that only exists in the overlay
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (m memFileInfo) Name() string       { return m.name }
+func (m memFileInfo) Size() int64        { return m.size }
+func (m memFileInfo) ModTime() time.Time { return time.Time{} }
+func (m memFileInfo) IsDir() bool        { return m.dir }
+func (m memFileInfo) Sys() interface{}   { return nil }
+func (m memFileInfo) Mode() os.FileMode {
+	if m.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// renamedFileInfo is a disk entry seen under the name it was renamed to
+type renamedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (r renamedFileInfo) Name() string { return r.name }
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"Usage: unity_build_hooks [flags] [status|install|remove]":         "用法: unity_build_hooks [参数] [status|install|remove]",
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"[ERROR] -dir must be inside Assets/: %s\n":               "[ERROR] -dir 必须位于 Assets/ 内: %s\n",
+	"[WARNING] The hooks were installed in %s; checking %s\n": "[WARNING] 构建钩子安装在 %s；正在检查 %s\n",
+	"  BUILD HOOKS":             "  构建钩子",
+	"  Folder:            %s\n": "  文件夹:            %s\n",
+	"  Installed version: %s\n": "  已安装版本:        %s\n",
+	"  Bundled version:   %d\n": "  内置版本:          %d\n",
+	"[OK] Up to date":           "[OK] 已是最新",
+	"[--] Missing":              "[--] 缺失",
+	"[WARNING] Outdated":        "[WARNING] 已过期",
+	"[WARNING] Edited locally":  "[WARNING] 已在本地修改",
+	"\nRun 'unity_build_hooks install' to install or update the hooks.":       "\n运行 'unity_build_hooks install' 安装或更新构建钩子。",
+	"\n[OK] All build hooks are up to date.":                                  "\n[OK] 所有构建钩子均为最新。",
+	"\nThe build tools set these environment variables:":                      "\n构建工具会设置以下环境变量:",
+	"\n[WARNING] %d edited file(s) are kept; use -force to replace them.\n":   "\n[WARNING] 保留了 %d 个本地修改过的文件；使用 -force 可替换它们。\n",
+	"\n[--] Nothing to install.":                                              "\n[--] 没有需要安装的内容。",
+	"\n[--] Nothing to remove.":                                               "\n[--] 没有需要移除的内容。",
+	"\nVersion control: %s (%s)\n":                                            "\n版本控制: %s (%s)\n",
+	"\n[Dry Run] Changes go to an in-memory copy; nothing is written to disk": "\n[Dry Run] 所有更改只写入内存副本，不会写入磁盘",
+	"\nInstall or update %d hook file(s) in %s? (y/N): ":                      "\n是否在 %[2]s 中安装或更新 %[1]d 个钩子文件？(y/N): ",
+	"\nRemove %d hook file(s) from %s? (y/N): ":                               "\n是否从 %[2]s 中移除 %[1]d 个钩子文件？(y/N): ",
+	"Operation cancelled.":                                                    "操作已取消。",
+	"[OK] Created folder: %s (with .meta)\n":                                  "[OK] 已创建文件夹: %s (含 .meta)\n",
+	"[ERROR] Cannot create %s: %v\n":                                          "[ERROR] 无法创建 %s: %v\n",
+	"[ERROR] Cannot write %s: %v\n":                                           "[ERROR] 无法写入 %s: %v\n",
+	"[OK] Installed: %s\n":                                                    "[OK] 已安装: %s\n",
+	"[OK] Updated: %s\n":                                                      "[OK] 已更新: %s\n",
+	"[ERROR] Cannot remove %s: %v\n":                                          "[ERROR] 无法移除 %s: %v\n",
+	"[OK] Removed: %s\n":                                                      "[OK] 已移除: %s\n",
+	"[OK] Removed empty folder: %s\n":                                         "[OK] 已移除空文件夹: %s\n",
+	"\n[Dry Run] No files were written.":                                      "\n[Dry Run] 未写入任何文件。",
+
+	"[WARNING] %s checkout failed for %s: %v\n":                 "[WARNING] %s 签出 %s 失败: %v\n",
+	"[VCS] Checked out (%s): %s\n":                              "[VCS] 已签出 (%s): %s\n",
+	"[WARNING] %s add failed for %s: %v\n":                      "[WARNING] %s 添加 %s 失败: %v\n",
+	"[WARNING] %s delete failed for %s, deleting on disk: %v\n": "[WARNING] %s 删除 %s 失败，改为直接在磁盘上删除: %v\n",
+	"\nPress Enter to continue...":                              "\n按回车键继续...",
+
+	"\n[Dry Run] No changes would be made.":                          "\n[Dry Run] 不会做任何更改。",
+	"\n[Dry Run] %d change(s) would be made; nothing was written:\n": "\n[Dry Run] 将会进行 %d 项更改，未写入任何内容:\n",
+	"[WARNING] Removing a stale %s (%s)\n":                           "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n":      "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	if status == "ok" && isDryRun() {
+		status = "planned"
+	}
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode, dryRun, force bool
+	var dir, vcsMode string
+
+	flag.StringVar(&dir, "dir", "", "Folder for the hook scripts (default: from the manifest, else "+defaultHooksDir+")")
+	flag.BoolVar(&force, "force", false, "Also overwrite or remove hook files that were edited locally")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no confirmation prompts)")
+	flag.BoolVar(&dryRun, "dry-run", false, "List what install/remove would change without touching disk")
+	flag.StringVar(&vcsMode, "vcs", "auto", "Add/checkout/delete through version control: auto, none, p4, plastic")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the command ("install -force")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_build_hooks")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+
+	command := "status"
+	if len(args) > 0 {
+		command = strings.ToLower(args[0])
+	}
+	if len(args) > 1 || (command != "status" && command != "install" && command != "remove") {
+		fmt.Println(tr("Usage: unity_build_hooks [flags] [status|install|remove]"))
+		recordError("expected status, install or remove")
+		exit(2)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+
+	manifest, err := loadManifest(basePath)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(1)
+	}
+	if dir == "" && manifest != nil && manifest.Dir != "" {
+		dir = manifest.Dir
+	}
+	if dir == "" {
+		dir = defaultHooksDir
+	}
+	dir = normalizeAssetPath(dir)
+	if !strings.HasPrefix(dir, "Assets/") {
+		fmt.Printf(tr("[ERROR] -dir must be inside Assets/: %s\n"), dir)
+		recordError("-dir must be inside Assets/: %s", dir)
+		exit(2)
+	}
+	if manifest != nil && manifest.Dir != "" && manifest.Dir != dir {
+		fmt.Printf(tr("[WARNING] The hooks were installed in %s; checking %s\n"), manifest.Dir, dir)
+	}
+
+	statuses := checkStatus(basePath, dir, manifest)
+	installed := "-"
+	if manifest != nil {
+		installed = strconv.Itoa(manifest.HooksVersion)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  BUILD HOOKS"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Folder:            %s\n"), dir)
+	fmt.Printf(tr("  Installed version: %s\n"), installed)
+	fmt.Printf(tr("  Bundled version:   %d\n"), hooksVersion)
+	fmt.Println()
+	labels := map[string]string{
+		stateCurrent:  tr("[OK] Up to date"),
+		stateMissing:  tr("[--] Missing"),
+		stateOutdated: tr("[WARNING] Outdated"),
+		stateModified: tr("[WARNING] Edited locally"),
+	}
+	pending := 0
+	for _, st := range statuses {
+		fmt.Printf("  %-28s %s\n", st.file.name, labels[st.state])
+		if st.state != stateCurrent {
+			pending++
+		}
+	}
+
+	if command == "status" {
+		for _, st := range statuses {
+			recordAction("check", st.path, st.state, "", 0)
+		}
+		if pending > 0 {
+			fmt.Println(tr("\nRun 'unity_build_hooks install' to install or update the hooks."))
+			exit(1)
+		}
+		fmt.Println(tr("\n[OK] All build hooks are up to date."))
+		fmt.Println(tr("\nThe build tools set these environment variables:"))
+		for _, env := range []string{envBuildVersion, envBuildNumber, envBuildCommit, envBuildAddressables, envBuildReport} {
+			fmt.Printf("  %s\n", env)
+		}
+		exit(0)
+	}
+
+	// Decide what changes before asking
+	var work []hookStatus
+	kept := 0
+	for _, st := range statuses {
+		switch {
+		case command == "install" && st.state == stateCurrent:
+		case command == "remove" && st.state == stateMissing:
+		case st.state == stateModified && !force:
+			kept++
+		default:
+			work = append(work, st)
+		}
+	}
+	if kept > 0 {
+		fmt.Printf(tr("\n[WARNING] %d edited file(s) are kept; use -force to replace them.\n"), kept)
+	}
+	if len(work) == 0 {
+		if command == "install" {
+			fmt.Println(tr("\n[--] Nothing to install."))
+		} else {
+			fmt.Println(tr("\n[--] Nothing to remove."))
+		}
+		exit(0)
+	}
+
+	activeVCS, err = detectVCS(basePath, vcsMode)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(1)
+	}
+	if activeVCS.kind != vcsNone {
+		fmt.Printf(tr("\nVersion control: %s (%s)\n"), activeVCS.kind, activeVCS.source)
+	}
+
+	if dryRun {
+		fmt.Println(tr("\n[Dry Run] Changes go to an in-memory copy; nothing is written to disk"))
+		fsys = newOverlayFS()
+	} else if !ciMode {
+		question := tr("\nInstall or update %d hook file(s) in %s? (y/N): ")
+		if command == "remove" {
+			question = tr("\nRemove %d hook file(s) from %s? (y/N): ")
+		}
+		fmt.Printf(question, len(work), dir)
+		confirm, _ := stdinReader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+			fmt.Println(tr("Operation cancelled."))
+			exit(0)
+		}
+	}
+	// -force is about edited hook files here, so it does not take over the lock
+	if !dryRun {
+		if err := acquireProjectLock(basePath, "unity_build_hooks", command, false); err != nil {
+			fmt.Printf(tr("[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exit(1)
+		}
+		defer releaseProjectLock()
+	}
+
+	fmt.Println()
+	failed := 0
+	if command == "install" {
+		created, err := ensureFolders(basePath, dir)
+		for _, d := range created {
+			fmt.Printf(tr("[OK] Created folder: %s (with .meta)\n"), d)
+			recordAction("create", d, "ok", "folder + .meta", 0)
+		}
+		if err != nil {
+			fmt.Printf(tr("[ERROR] Cannot create %s: %v\n"), dir, err)
+			recordError("cannot create %s: %v", dir, err)
+			exit(1)
+		}
+
+		if manifest == nil {
+			manifest = &hooksManifest{Files: make(map[string]string)}
+		}
+		for _, st := range work {
+			if err := installFile(basePath, st); err != nil {
+				fmt.Printf(tr("[ERROR] Cannot write %s: %v\n"), st.path, err)
+				recordAction("install", st.path, "failed", err.Error(), 0)
+				recordError("cannot write %s: %v", st.path, err)
+				failed++
+				continue
+			}
+			verb := tr("[OK] Installed: %s\n")
+			if st.state != stateMissing {
+				verb = tr("[OK] Updated: %s\n")
+			}
+			fmt.Printf(verb, st.path)
+			recordAction("install", st.path, "ok", st.state, 0)
+			manifest.Files[st.file.name] = textHash([]byte(st.file.content))
+		}
+		manifest.HooksVersion = hooksVersion
+		manifest.Dir = dir
+		if err := saveManifest(basePath, manifest); err != nil {
+			fmt.Printf(tr("[ERROR] Cannot write %s: %v\n"), manifestFileName, err)
+			recordError("cannot write %s: %v", manifestFileName, err)
+			failed++
+		}
+	} else {
+		for _, st := range work {
+			if err := removeFile(basePath, st.path); err != nil {
+				fmt.Printf(tr("[ERROR] Cannot remove %s: %v\n"), st.path, err)
+				recordAction("delete", st.path, "failed", err.Error(), 0)
+				recordError("cannot remove %s: %v", st.path, err)
+				failed++
+				continue
+			}
+			fmt.Printf(tr("[OK] Removed: %s\n"), st.path)
+			recordAction("delete", st.path, "ok", "", 0)
+			if manifest != nil {
+				delete(manifest.Files, st.file.name)
+			}
+		}
+		if removeEmptyFolder(basePath, dir) {
+			fmt.Printf(tr("[OK] Removed empty folder: %s\n"), dir)
+		}
+		// Edited files that stay behind keep their manifest entries
+		if manifest != nil && len(manifest.Files) == 0 {
+			if err := activeVCS.remove(manifestPath(basePath)); err != nil {
+				fmt.Printf(tr("[ERROR] Cannot remove %s: %v\n"), manifestFileName, err)
+				recordError("cannot remove %s: %v", manifestFileName, err)
+				failed++
+			}
+		} else if manifest != nil {
+			if err := saveManifest(basePath, manifest); err != nil {
+				fmt.Printf(tr("[ERROR] Cannot write %s: %v\n"), manifestFileName, err)
+				recordError("cannot write %s: %v", manifestFileName, err)
+				failed++
+			}
+		}
+	}
+
+	if overlay, ok := fsys.(*overlayFS); ok {
+		overlay.printDryRunReport(basePath)
+		fmt.Println(tr("\n[Dry Run] No files were written."))
+	}
+	if failed > 0 {
+		exit(1)
+	}
+	exit(0)
+}
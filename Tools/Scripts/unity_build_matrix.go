@@ -0,0 +1,1773 @@
+// Unity Build Matrix — Run player builds for every platform × configuration × define set.
+// Reads a matrix definition (BuildMatrix.json), expands it into cells and builds
+// each cell in batch mode through BuildScript.PerformBuild_CI, each into its own
+// folder with its own log. Cells can be split into shards so several CI agents
+// build parts of the matrix; "report" merges the results of all agents into one
+// JSON and HTML report with artifacts, logs and an excerpt of every failure.
+//
+// Define sets need the ExtraDefinesBuildHook and success is read from the report
+// BuildReportWriter leaves behind; install both with "unity_build_hooks install".
+//
+// Build: go build unity_build_matrix.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_build_matrix list                       # show the cells
+//	unity_build_matrix                            # build every cell in sequence
+//	unity_build_matrix -cells "Android-*"         # only some cells
+//	unity_build_matrix -shard 2/3 -ci             # this agent's third of the matrix
+//	unity_build_matrix report                     # merge cell results into matrix.json/html
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	defaultMatrixFile    = "BuildMatrix.json" // in the project root
+	defaultExecuteMethod = "Build.Pipeline.Editor.BuildScript.PerformBuild_CI"
+	defaultMatrixOut     = "Build/Matrix"
+
+	cellResultName = "cell.json"   // per cell folder, read back by "report"
+	reportJSONName = "matrix.json" // in the output folder
+	reportHTMLName = "matrix.html"
+
+	maxCellAnnotations = 50 // compiler messages annotated per cell with -annotate
+
+	// Overrides the editor picked from ProjectVersion.txt and the Unity Hub folders
+	envUnityPath = "UNITYSTARTER_UNITY"
+)
+
+// Contract with the editor hooks; keep in sync with unity_build_hooks.go
+const (
+	envBuildVersion      = "UNITYSTARTER_BUILD_VERSION"
+	envBuildNumber       = "UNITYSTARTER_BUILD_NUMBER"
+	envBuildCommit       = "UNITYSTARTER_BUILD_COMMIT"
+	envBuildAddressables = "UNITYSTARTER_BUILD_ADDRESSABLES"
+	envBuildDefines      = "UNITYSTARTER_BUILD_DEFINES"
+	envBuildReport       = "UNITYSTARTER_BUILD_REPORT"
+
+	hooksManifestFile = "UnityStarterBuildHooks.json" // in ProjectSettings/
+	definesHooksMin   = 2                             // first hooks version with ExtraDefinesBuildHook
+)
+
+// Log lines worth showing when a cell fails
+var logErrorRegex = regexp.MustCompile(`(?i)(error CS\d+|BuildFailedException|Build Failed|Error building|\[Error\]|Exception:|error:)`)
+
+// compilerMessageRegex matches "Assets/Foo.cs(12,5): warning CS0618: ..." lines
+var compilerMessageRegex = regexp.MustCompile(`^\s*(.+?)\((\d+),(\d+)\):\s*(error|warning)\s+(\w+):\s*(.*?)\s*$`)
+
+var projectVersionRegex = regexp.MustCompile(`(?m)^m_EditorVersion:\s*(\S+)`)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// matrixFile is BuildMatrix.json
+type matrixFile struct {
+	ExecuteMethod  string                `json:"executeMethod,omitempty"`
+	Output         string                `json:"output,omitempty"`
+	Platforms      []string              `json:"platforms"`      // BuildTarget names: Android, StandaloneWindows64, WebGL...
+	Configurations []matrixConfiguration `json:"configurations"` // default: one "Release" without arguments
+	DefineSets     []matrixDefineSet     `json:"defineSets"`     // default: one "Default" without defines
+	Exclude        []matrixExclude       `json:"exclude,omitempty"`
+	Args           []string              `json:"args,omitempty"` // passed to every cell
+	Addressables   bool                  `json:"addressables,omitempty"`
+	TimeoutMinutes int                   `json:"timeoutMinutes,omitempty"`
+}
+
+// matrixConfiguration adds PerformBuild_CI arguments, e.g. ["-debug"] or ["-fast", "-clean"]
+type matrixConfiguration struct {
+	Name string   `json:"name"`
+	Args []string `json:"args,omitempty"`
+}
+
+type matrixDefineSet struct {
+	Name    string   `json:"name"`
+	Defines []string `json:"defines,omitempty"`
+}
+
+// matrixExclude drops the cells matching every non-empty field
+type matrixExclude struct {
+	Platform      string `json:"platform,omitempty"`
+	Configuration string `json:"configuration,omitempty"`
+	DefineSet     string `json:"defineSet,omitempty"`
+}
+
+// matrixCell is one build of the expanded matrix
+type matrixCell struct {
+	ID            string   `json:"id"` // Platform-Configuration-DefineSet
+	Platform      string   `json:"platform"`
+	Configuration string   `json:"configuration"`
+	DefineSet     string   `json:"defineSet"`
+	Defines       []string `json:"defines,omitempty"`
+	Args          []string `json:"args,omitempty"`
+}
+
+// buildReport is what BuildReportWriter writes after a successful build
+type buildReport struct {
+	Result          string  `json:"result"`
+	Platform        string  `json:"platform"`
+	OutputPath      string  `json:"outputPath"`
+	TotalSizeBytes  int64   `json:"totalSizeBytes"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	Warnings        int     `json:"warnings"`
+	Errors          int     `json:"errors"`
+	BundleVersion   string  `json:"bundleVersion"`
+	UnityVersion    string  `json:"unityVersion"`
+}
+
+// Cell states
+const (
+	cellOK      = "ok"
+	cellFailed  = "failed"
+	cellSkipped = "skipped" // not run after -fail-fast stopped the shard
+)
+
+// cellResult is <out>/<cell>/cell.json. Paths are relative to the cell folder so
+// results copied from other agents still resolve.
+type cellResult struct {
+	Cell            matrixCell   `json:"cell"`
+	Status          string       `json:"status"`
+	ExitCode        int          `json:"exitCode"`
+	Error           string       `json:"error,omitempty"`
+	StartedAt       string       `json:"startedAt"`
+	DurationSeconds float64      `json:"durationSeconds"`
+	Host            string       `json:"host,omitempty"`
+	Shard           string       `json:"shard,omitempty"`
+	Log             string       `json:"log"`
+	Report          *buildReport `json:"report,omitempty"`
+	LogExcerpt      []string     `json:"logExcerpt,omitempty"`
+
+	dir string // absolute cell folder, set when loaded
+}
+
+// matrixReport is matrix.json
+type matrixReport struct {
+	GeneratedAt string        `json:"generatedAt"`
+	Project     string        `json:"project"`
+	Total       int           `json:"total"`   // cells in the matrix
+	OK          int           `json:"ok"`      // cells with a result of each state
+	Failed      int           `json:"failed"`  //
+	Skipped     int           `json:"skipped"` //
+	Missing     []string      `json:"missing"` // cells without any result yet
+	Cells       []*cellResult `json:"cells"`
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks for Assets/ and ProjectSettings/ in the given directory
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// readUnityVersion returns the editor version from ProjectSettings/ProjectVersion.txt
+func readUnityVersion(basePath string) string {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectVersion.txt"))
+	if err != nil {
+		return ""
+	}
+	if m := projectVersionRegex.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// isUnder reports whether p is base or inside it
+func isUnder(p, base string) bool {
+	rel, err := filepath.Rel(base, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// installedHooksVersion reads the hooks manifest; 0 when the hooks are not installed
+func installedHooksVersion(basePath string) int {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", hooksManifestFile))
+	if err != nil {
+		return 0
+	}
+	var m struct {
+		HooksVersion int `json:"hooksVersion"`
+	}
+	json.Unmarshal(data, &m)
+	return m.HooksVersion
+}
+
+// ============================================================
+// Matrix Definition
+// ============================================================
+
+func loadMatrix(p string) (*matrixFile, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var m matrixFile
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", filepath.Base(p), err)
+	}
+	if len(m.Platforms) == 0 {
+		return nil, fmt.Errorf("%s lists no platforms", filepath.Base(p))
+	}
+	if len(m.Configurations) == 0 {
+		m.Configurations = []matrixConfiguration{{Name: "Release"}}
+	}
+	if len(m.DefineSets) == 0 {
+		m.DefineSets = []matrixDefineSet{{Name: "Default"}}
+	}
+	if m.ExecuteMethod == "" {
+		m.ExecuteMethod = defaultExecuteMethod
+	}
+	if m.Output == "" {
+		m.Output = defaultMatrixOut
+	}
+	for _, name := range append(configNames(m.Configurations), defineSetNames(m.DefineSets)...) {
+		if name == "" || strings.ContainsAny(name, `-/\ `) {
+			return nil, fmt.Errorf("invalid name '%s': names are part of the cell ID and cannot be empty or contain '-', '/', '\\' or spaces", name)
+		}
+	}
+	return &m, nil
+}
+
+func configNames(list []matrixConfiguration) []string {
+	var names []string
+	for _, c := range list {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func defineSetNames(list []matrixDefineSet) []string {
+	var names []string
+	for _, d := range list {
+		names = append(names, d.Name)
+	}
+	return names
+}
+
+// expandCells returns the cells in definition order: platforms outermost
+func expandCells(m *matrixFile) []matrixCell {
+	var cells []matrixCell
+	for _, platform := range m.Platforms {
+		for _, conf := range m.Configurations {
+			for _, set := range m.DefineSets {
+				if excluded(m.Exclude, platform, conf.Name, set.Name) {
+					continue
+				}
+				args := append(append([]string{}, conf.Args...), m.Args...)
+				cells = append(cells, matrixCell{
+					ID:            platform + "-" + conf.Name + "-" + set.Name,
+					Platform:      platform,
+					Configuration: conf.Name,
+					DefineSet:     set.Name,
+					Defines:       set.Defines,
+					Args:          args,
+				})
+			}
+		}
+	}
+	return cells
+}
+
+func excluded(rules []matrixExclude, platform, conf, set string) bool {
+	for _, r := range rules {
+		if (r.Platform == "" || strings.EqualFold(r.Platform, platform)) &&
+			(r.Configuration == "" || strings.EqualFold(r.Configuration, conf)) &&
+			(r.DefineSet == "" || strings.EqualFold(r.DefineSet, set)) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterCells keeps the cells whose ID matches one of the comma-separated patterns
+func filterCells(cells []matrixCell, patterns string) ([]matrixCell, error) {
+	if strings.TrimSpace(patterns) == "" {
+		return cells, nil
+	}
+	var res []matrixCell
+	for _, c := range cells {
+		for _, p := range strings.Split(patterns, ",") {
+			ok, err := path.Match(strings.TrimSpace(p), c.ID)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern '%s': %v", p, err)
+			}
+			if ok {
+				res = append(res, c)
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// parseShard turns "2/3" into index 1 of 3
+func parseShard(s string) (int, int, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) == 2 {
+		i, err1 := strconv.Atoi(parts[0])
+		n, err2 := strconv.Atoi(parts[1])
+		if err1 == nil && err2 == nil && n > 0 && i >= 1 && i <= n {
+			return i - 1, n, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid -shard '%s' (use i/n, e.g. 2/3)", s)
+}
+
+// shardCells deals the cells round-robin, so each shard gets a mix of platforms
+func shardCells(cells []matrixCell, index, count int) []matrixCell {
+	var res []matrixCell
+	for i, c := range cells {
+		if i%count == index {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// ============================================================
+// Installed Editors
+// ============================================================
+
+// hubEditorFolders returns the folders Unity Hub installs editors into: the
+// default location plus the custom one from secondaryInstallPath.json
+func hubEditorFolders() []string {
+	home, _ := os.UserHomeDir()
+	var folders []string
+	var hubConfig string
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramW6432"} {
+			if pf := os.Getenv(env); pf != "" {
+				folders = append(folders, filepath.Join(pf, "Unity", "Hub", "Editor"))
+			}
+		}
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			hubConfig = filepath.Join(appData, "UnityHub")
+		}
+	case "darwin":
+		folders = append(folders, "/Applications/Unity/Hub/Editor")
+		hubConfig = filepath.Join(home, "Library", "Application Support", "UnityHub")
+	default:
+		folders = append(folders, filepath.Join(home, "Unity", "Hub", "Editor"))
+		hubConfig = filepath.Join(home, ".config", "UnityHub")
+	}
+	if hubConfig != "" {
+		// The file holds a single JSON string; empty when no custom location is set
+		if data, err := os.ReadFile(filepath.Join(hubConfig, "secondaryInstallPath.json")); err == nil {
+			var custom string
+			if json.Unmarshal(data, &custom) == nil && custom != "" {
+				folders = append(folders, custom)
+			}
+		}
+	}
+	return folders
+}
+
+// editorExecutable is the Unity binary inside an editor version folder
+func editorExecutable(dir string) string {
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(dir, "Editor", "Unity.exe")
+	case "darwin":
+		return filepath.Join(dir, "Unity.app", "Contents", "MacOS", "Unity")
+	default:
+		return filepath.Join(dir, "Editor", "Unity")
+	}
+}
+
+// findUnity picks the editor: -unity, UNITYSTARTER_UNITY, then the Hub install
+// of the project's exact version. Another patch version is never picked
+// silently; it would upgrade the project on every agent.
+func findUnity(basePath, override string) (string, error) {
+	for _, candidate := range []string{override, os.Getenv(envUnityPath)} {
+		if candidate == "" {
+			continue
+		}
+		// A version folder works as well as the binary itself
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			candidate = editorExecutable(candidate)
+		}
+		if _, err := os.Stat(candidate); err != nil {
+			return "", fmt.Errorf("Unity editor not found: %s", candidate)
+		}
+		return candidate, nil
+	}
+	version := readUnityVersion(basePath)
+	if version == "" {
+		return "", errors.New("cannot read the Unity version from ProjectSettings/ProjectVersion.txt; pass -unity")
+	}
+	for _, folder := range hubEditorFolders() {
+		exe := editorExecutable(filepath.Join(folder, version))
+		if _, err := os.Stat(exe); err == nil {
+			return exe, nil
+		}
+	}
+	return "", fmt.Errorf("Unity %s is not installed in the Unity Hub folders; install it or pass -unity", version)
+}
+
+// ============================================================
+// Cell Runner
+// ============================================================
+
+// runOptions are the settings shared by every cell of a run
+type runOptions struct {
+	basePath      string
+	unity         string
+	executeMethod string
+	outDir        string // absolute
+	addressables  bool
+	version       string
+	buildNumber   string
+	commit        string
+	shard         string
+	timeout       time.Duration
+}
+
+// cellCommand returns the Unity arguments and the extra environment for one cell
+func cellCommand(opts *runOptions, c matrixCell, cellDir string) ([]string, []string) {
+	args := []string{
+		"-batchmode", "-quit",
+		"-projectPath", opts.basePath,
+		"-buildTarget", c.Platform,
+		"-executeMethod", opts.executeMethod,
+		"-outputBasePath", filepath.Join(cellDir, "Output"),
+		"-logFile", filepath.Join(cellDir, "build.log"),
+	}
+	args = append(args, c.Args...)
+	env := []string{envBuildReport + "=" + filepath.Join(cellDir, "report.json")}
+	if len(c.Defines) > 0 {
+		env = append(env, envBuildDefines+"="+strings.Join(c.Defines, ";"))
+	}
+	if opts.addressables {
+		env = append(env, envBuildAddressables+"=1")
+	}
+	if opts.version != "" {
+		env = append(env, envBuildVersion+"="+opts.version)
+	}
+	if opts.buildNumber != "" {
+		env = append(env, envBuildNumber+"="+opts.buildNumber)
+	}
+	if opts.commit != "" {
+		env = append(env, envBuildCommit+"="+opts.commit)
+	}
+	return args, env
+}
+
+// runCell builds one cell and writes its cell.json. A cell succeeded when Unity
+// exited with 0 and BuildReportWriter left a report: PerformBuild_CI only logs
+// errors, so batch mode can quit with 0 after a build never started.
+func runCell(opts *runOptions, c matrixCell) *cellResult {
+	cellDir := filepath.Join(opts.outDir, c.ID)
+	host, _ := os.Hostname()
+	res := &cellResult{Cell: c, Host: host, Shard: opts.shard, Log: "build.log", StartedAt: time.Now().UTC().Format(time.RFC3339), dir: cellDir}
+
+	// Leftovers of the previous run must not pass for this run's results
+	for _, name := range []string{cellResultName, "report.json", "build.log"} {
+		os.Remove(filepath.Join(cellDir, name))
+	}
+	os.RemoveAll(filepath.Join(cellDir, "Output"))
+	if err := os.MkdirAll(cellDir, 0755); err != nil {
+		res.Status, res.Error = cellFailed, err.Error()
+		return res
+	}
+
+	args, env := cellCommand(opts, c, cellDir)
+	ctx := context.Background()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, opts.unity, args...)
+	cmd.Dir = opts.basePath
+	cmd.Env = append(os.Environ(), env...)
+
+	start := time.Now()
+	err := cmd.Run()
+	res.DurationSeconds = time.Since(start).Seconds()
+	if cmd.ProcessState != nil {
+		res.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	if data, rErr := os.ReadFile(filepath.Join(cellDir, "report.json")); rErr == nil {
+		var report buildReport
+		if json.Unmarshal(data, &report) == nil {
+			res.Report = &report
+		}
+	}
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		res.Status, res.Error = cellFailed, fmt.Sprintf("timed out after %s", opts.timeout)
+	case err != nil && res.ExitCode == 0:
+		res.Status, res.Error = cellFailed, err.Error()
+	case res.ExitCode != 0:
+		res.Status, res.Error = cellFailed, fmt.Sprintf("Unity exited with code %d", res.ExitCode)
+	case res.Report == nil:
+		res.Status, res.Error = cellFailed, "no build report was written (build failed or hooks missing)"
+	default:
+		res.Status = cellOK
+	}
+	if res.Status == cellFailed {
+		res.LogExcerpt = logExcerpt(filepath.Join(cellDir, "build.log"), 20)
+	}
+	saveCellResult(res)
+	return res
+}
+
+// logExcerpt returns the last error lines of a log, or its last lines when no
+// line looks like an error
+func logExcerpt(logPath string, max int) []string {
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	var errorLines []string
+	for _, line := range lines {
+		if logErrorRegex.MatchString(line) {
+			errorLines = append(errorLines, strings.TrimSpace(line))
+		}
+	}
+	if len(errorLines) == 0 {
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		errorLines = lines
+	}
+	if len(errorLines) > max {
+		errorLines = errorLines[len(errorLines)-max:]
+	}
+	return errorLines
+}
+
+func saveCellResult(res *cellResult) error {
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(res.dir, cellResultName), append(data, '\n'), 0644)
+}
+
+// annotateBuildLog turns the compiler errors and warnings of a cell's log into CI
+// annotations. Unity prints every compiler message several times, so repeats
+// are dropped, and at most max are printed per cell.
+func annotateBuildLog(cellID, logPath string, max int) {
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		return
+	}
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		m := compilerMessageRegex.FindStringSubmatch(line)
+		if m == nil || seen[m[0]] {
+			continue
+		}
+		seen[m[0]] = true
+		if len(seen) > max {
+			return
+		}
+		lineNo, _ := strconv.Atoi(m[2])
+		col, _ := strconv.Atoi(m[3])
+		annotate(m[4], m[1], lineNo, col, m[5], cellID+": "+m[6])
+	}
+}
+
+// readCommit returns HEAD of the project's git repository, or "" outside one
+func readCommit(basePath string) string {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = basePath
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+// collectResults reads every cell.json under outDir, including ones copied in
+// from other agents, and lists the matrix cells without a result
+func collectResults(outDir string, cells []matrixCell) *matrixReport {
+	report := &matrixReport{GeneratedAt: time.Now().UTC().Format(time.RFC3339), Total: len(cells), Missing: []string{}, Cells: []*cellResult{}}
+	byID := make(map[string]*cellResult)
+	entries, _ := os.ReadDir(outDir)
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(outDir, e.Name())
+		data, err := os.ReadFile(filepath.Join(dir, cellResultName))
+		if err != nil {
+			continue
+		}
+		var res cellResult
+		if json.Unmarshal(data, &res) != nil || res.Cell.ID == "" {
+			continue
+		}
+		res.dir = dir
+		byID[res.Cell.ID] = &res
+	}
+	// Matrix order first; results of cells no longer in the matrix are left out
+	for _, c := range cells {
+		res, ok := byID[c.ID]
+		if !ok {
+			report.Missing = append(report.Missing, c.ID)
+			continue
+		}
+		report.Cells = append(report.Cells, res)
+		switch res.Status {
+		case cellOK:
+			report.OK++
+		case cellFailed:
+			report.Failed++
+		default:
+			report.Skipped++
+		}
+	}
+	return report
+}
+
+func writeMatrixJSON(p string, report *matrixReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, append(data, '\n'), 0644)
+}
+
+var htmlReport = template.Must(template.New("matrix").Funcs(template.FuncMap{
+	"size":     func(b int64) string { return formatSize(b) },
+	"duration": func(s float64) string { return (time.Duration(s) * time.Second).String() },
+	"join":     strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Build Matrix — {{.Project}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; font-size: 14px; }
+th { background: #f0f0f0; }
+.ok { color: #1a7f37; font-weight: bold; }
+.failed { color: #cf222e; font-weight: bold; }
+.skipped, .missing { color: #777; }
+pre { background: #f6f8fa; padding: 8px; overflow-x: auto; font-size: 12px; }
+</style>
+</head>
+<body>
+<h1>Build Matrix — {{.Project}}</h1>
+<p>{{.GeneratedAt}} · {{.Total}} cell(s): <span class="ok">{{.OK}} ok</span>, <span class="failed">{{.Failed}} failed</span>, {{.Skipped}} skipped, {{len .Missing}} without result</p>
+<table>
+<tr><th>Cell</th><th>Platform</th><th>Configuration</th><th>Define set</th><th>Status</th><th>Duration</th><th>Size</th><th>Warnings / errors</th><th>Host</th><th>Log</th><th>Artifact</th></tr>
+{{range .Cells}}<tr>
+<td>{{.Cell.ID}}</td><td>{{.Cell.Platform}}</td><td>{{.Cell.Configuration}}</td><td>{{.Cell.DefineSet}}{{if .Cell.Defines}} ({{join .Cell.Defines ";"}}){{end}}</td>
+<td class="{{.Status}}">{{.Status}}</td><td>{{duration .DurationSeconds}}</td>
+<td>{{if .Report}}{{size .Report.TotalSizeBytes}}{{end}}</td>
+<td>{{if .Report}}{{.Report.Warnings}} / {{.Report.Errors}}{{end}}</td>
+<td>{{.Host}}</td>
+<td><a href="{{.Cell.ID}}/{{.Log}}">{{.Log}}</a></td>
+<td>{{if .Report}}<a href="{{.Cell.ID}}/Output/">{{.Report.OutputPath}}</a>{{end}}</td>
+</tr>{{end}}
+{{range .Missing}}<tr><td>{{.}}</td><td colspan="10" class="missing">no result</td></tr>{{end}}
+</table>
+{{range .Cells}}{{if eq .Status "failed"}}
+<h2 class="failed">{{.Cell.ID}}</h2>
+<p>{{.Error}}</p>
+{{if .LogExcerpt}}<pre>{{range .LogExcerpt}}{{.}}
+{{end}}</pre>{{end}}
+{{end}}{{end}}
+</body>
+</html>
+`))
+
+func writeMatrixHTML(p string, report *matrixReport) error {
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if err := htmlReport.Execute(f, report); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// printReport lists every cell of the merged report
+func printReport(report *matrixReport) {
+	printRule("\n=============================================")
+	fmt.Println(tr("  Matrix Results"))
+	printRule("=============================================")
+	for _, res := range report.Cells {
+		mark := map[string]string{cellOK: "[OK]  ", cellFailed: "[FAIL]", cellSkipped: "[--]  "}[res.Status]
+		size := ""
+		if res.Report != nil {
+			size = formatSize(res.Report.TotalSizeBytes)
+		}
+		fmt.Printf("  %s %-44s %8s  %10s\n", mark, res.Cell.ID, (time.Duration(res.DurationSeconds) * time.Second).String(), size)
+	}
+	for _, id := range report.Missing {
+		fmt.Printf(tr("  [--]   %-44s no result\n"), id)
+	}
+	fmt.Printf(tr("\n  %d cell(s): %d ok, %d failed, %d skipped, %d without result\n"), report.Total, report.OK, report.Failed, report.Skipped, len(report.Missing))
+}
+
+// writeReports merges the results and writes matrix.json and matrix.html
+func writeReports(outDir, project string, cells []matrixCell) (*matrixReport, error) {
+	report := collectResults(outDir, cells)
+	report.Project = project
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return report, err
+	}
+	if err := writeMatrixJSON(filepath.Join(outDir, reportJSONName), report); err != nil {
+		return report, err
+	}
+	if err := writeMatrixHTML(filepath.Join(outDir, reportHTMLName), report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"Usage: unity_build_matrix [flags] [run|list|report]":              "用法: unity_build_matrix [参数] [run|list|report]",
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"\n%d of %d cell(s)\n":                                             "\n共 %[2]d 个单元，选中 %[1]d 个\n",
+	"\n[OK] Report: %s\n":                                              "\n[OK] 报告: %s\n",
+	"[--] No cells to build.":                                          "[--] 没有需要构建的单元。",
+	"[WARNING] The build hooks are not installed: no cell can report success. Run: unity_build_hooks install": "[WARNING] 未安装构建钩子：任何单元都无法报告成功。请运行: unity_build_hooks install",
+	"[WARNING] %v\n":                          "[WARNING] %v\n",
+	"  BUILD MATRIX":                          "  构建矩阵",
+	"  Project:  %s\n":                        "  项目:     %s\n",
+	"  Unity:    %s\n":                        "  Unity:    %s\n",
+	"  Output:   %s\n":                        "  输出:     %s\n",
+	"  Shard:    %s (%d of %d cell(s))\n":     "  分片:     %s（共 %[3]d 个单元中的 %[2]d 个）\n",
+	"  Cells:    %d of %d\n":                  "  单元:     %d / %d\n",
+	"\n[Dry Run] No build was started.":       "\n[Dry Run] 未启动任何构建。",
+	"[%d/%d] [--] %s skipped (-fail-fast)\n":  "[%d/%d] [--] 已跳过 %s (-fail-fast)\n",
+	"[%d/%d] Building %s ...\n":               "[%d/%d] 正在构建 %s ...\n",
+	"[%d/%d] [OK] %s (%s, %s)\n":              "[%d/%d] [OK] %s (%s，%s)\n",
+	"[%d/%d] [FAIL] %s: %s\n":                 "[%d/%d] [FAIL] %s: %s\n",
+	"         Log: %s\n":                      "         日志: %s\n",
+	"[WARNING] Cannot write the report: %v\n": "[WARNING] 无法写入报告: %v\n",
+	"[TIP] Copy the cell folders of every shard into one folder and run \"unity_build_matrix report\" there.": "[TIP] 将所有分片的单元文件夹复制到同一文件夹，然后在那里运行 \"unity_build_matrix report\"。",
+	"  Matrix Results":           "  矩阵结果",
+	"  [--]   %-44s no result\n": "  [--]   %-44s 无结果\n",
+	"\n  %d cell(s): %d ok, %d failed, %d skipped, %d without result\n": "\n  %d 个单元: %d 个成功，%d 个失败，%d 个已跳过，%d 个无结果\n",
+
+	"[WARNING] Notifications are off: %v\n":                                                 "[WARNING] 通知已关闭: %v\n",
+	"[WARNING] Skipping webhook in %s: the URL is empty or not http(s) (unset variable?)\n": "[WARNING] 跳过 %s 中的 webhook: URL 为空或不是 http(s)（变量未设置？）\n",
+	"[WARNING] Skipping webhook %s: unknown format '%s' (use slack, discord or json)\n":     "[WARNING] 跳过 webhook %s: 未知格式 '%s'（可用 slack、discord 或 json）\n",
+	"[WARNING] Webhook %s failed: %v\n":                                                     "[WARNING] Webhook %s 发送失败: %v\n",
+	"[OK] Notified %s\n":                                                                    "[OK] 已通知 %s\n",
+
+	"\nPress Enter to continue...": "\n按回车键继续...",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Notifications
+// ============================================================
+
+// Webhooks come from "notifications" in .unitystarter.json (UNITYSTARTER_CONFIG, or
+// the first file from the project root upward) and from UNITYSTARTER_WEBHOOK_URL.
+// The message is built from the recorded actions, errors and artifacts, so it says
+// the same as the -json document. A webhook that fails only prints a warning.
+const (
+	notifyConfigFileName = ".unitystarter.json"
+	notifyConfigEnvVar   = "UNITYSTARTER_CONFIG"
+	notifyWebhookEnvVar  = "UNITYSTARTER_WEBHOOK_URL"
+	notifyTimeout        = 15 * time.Second
+	notifyMaxLines       = 20 // errors, log lines and artifacts per message
+)
+
+// notifyConfig is the "notifications" object of .unitystarter.json
+type notifyConfig struct {
+	Webhooks        []webhookConfig `json:"webhooks"`
+	ArtifactBaseURL string          `json:"artifactBaseUrl"` // project-relative artifact paths are appended
+}
+
+// webhookConfig is one target. The URL may use ${VAR} so the secret stays in the
+// CI environment instead of the repository.
+type webhookConfig struct {
+	URL    string   `json:"url"`
+	Format string   `json:"format"` // slack, discord, json (default: from the URL host)
+	On     []string `json:"on"`     // success, failure (default: both)
+	Tools  []string `json:"tools"`  // tool names (default: every tool)
+}
+
+// notifyExcerpt is a titled block of log lines shown under the errors
+type notifyExcerpt struct {
+	Title string   `json:"title"`
+	Lines []string `json:"lines"`
+}
+
+// notification is the body posted to "json" webhooks
+type notification struct {
+	Tool       string          `json:"tool"`
+	Project    string          `json:"project"`
+	Host       string          `json:"host"`
+	Success    bool            `json:"success"`
+	ExitCode   int             `json:"exitCode"`
+	DurationMs int64           `json:"durationMs"`
+	Summary    string          `json:"summary"`
+	Counts     map[string]int  `json:"counts"` // actions per status
+	Errors     []string        `json:"errors"`
+	Logs       []notifyExcerpt `json:"logs"`
+	Artifacts  []string        `json:"artifacts"` // links with artifactBaseUrl, else paths
+	RunURL     string          `json:"runUrl,omitempty"`
+}
+
+var (
+	notifyHooks       []webhookConfig
+	notifyTool        string
+	notifyBasePath    string
+	notifyArtifactURL string
+	notifySummary     string
+	notifyExcerpts    []notifyExcerpt
+	notifySent        bool
+)
+
+// loadNotifyConfig reads the "notifications" object the same way loadSharedConfig
+// finds .unitystarter.json. Returns an empty config when no file exists.
+func loadNotifyConfig(projectRoot string) (*notifyConfig, string, error) {
+	path := os.Getenv(notifyConfigEnvVar)
+	if path == "" {
+		abs, err := filepath.Abs(projectRoot)
+		if err != nil {
+			return &notifyConfig{}, "", nil
+		}
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			candidate := filepath.Join(dir, notifyConfigFileName)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				return &notifyConfig{}, "", nil
+			}
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, path, err
+	}
+	var file struct {
+		Notifications notifyConfig `json:"notifications"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, path, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	return &file.Notifications, path, nil
+}
+
+// enableNotifications loads the webhooks of this tool. Call it once the real work
+// starts, so listings, dry runs and cancelled prompts never post.
+func enableNotifications(basePath, tool string) {
+	cfg, path, err := loadNotifyConfig(basePath)
+	if err != nil {
+		fmt.Printf(tr("[WARNING] Notifications are off: %v\n"), err)
+		return
+	}
+	hooks := cfg.Webhooks
+	if u := os.Getenv(notifyWebhookEnvVar); u != "" {
+		hooks = append(hooks, webhookConfig{URL: u})
+	}
+	notifyBasePath = basePath
+	notifyArtifactURL = cfg.ArtifactBaseURL
+	for _, h := range hooks {
+		if len(h.Tools) > 0 && !containsFold(h.Tools, tool) {
+			continue
+		}
+		h.URL = strings.TrimSpace(os.ExpandEnv(h.URL))
+		if u, err := url.Parse(h.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			fmt.Printf(tr("[WARNING] Skipping webhook in %s: the URL is empty or not http(s) (unset variable?)\n"), path)
+			continue
+		}
+		h.Format = webhookFormat(h)
+		if h.Format != "slack" && h.Format != "discord" && h.Format != "json" {
+			fmt.Printf(tr("[WARNING] Skipping webhook %s: unknown format '%s' (use slack, discord or json)\n"), webhookHost(h.URL), h.Format)
+			continue
+		}
+		notifyHooks = append(notifyHooks, h)
+	}
+	notifyTool = tool
+}
+
+// setNotifySummary replaces the default "3 ok, 1 failed" line of the message
+func setNotifySummary(format string, args ...interface{}) {
+	notifySummary = fmt.Sprintf(format, args...)
+}
+
+// addNotifyExcerpt attaches log lines of a failure to the message
+func addNotifyExcerpt(title string, lines []string) {
+	if len(lines) > 0 {
+		notifyExcerpts = append(notifyExcerpts, notifyExcerpt{Title: title, Lines: lines})
+	}
+}
+
+// sendNotifications posts the result to every webhook that wants it. Call it
+// before waiting for a key press, so the message does not wait for the user.
+func sendNotifications(exitCode int) {
+	if notifySent || len(notifyHooks) == 0 {
+		return
+	}
+	notifySent = true
+	n := buildNotification(exitCode)
+	event := "success"
+	if !n.Success {
+		event = "failure"
+	}
+	for _, h := range notifyHooks {
+		if len(h.On) > 0 && !containsFold(h.On, event) {
+			continue
+		}
+		if err := postWebhook(h, n); err != nil {
+			fmt.Printf(tr("[WARNING] Webhook %s failed: %v\n"), webhookHost(h.URL), err)
+			continue
+		}
+		fmt.Printf(tr("[OK] Notified %s\n"), webhookHost(h.URL))
+	}
+}
+
+func buildNotification(exitCode int) *notification {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	host, _ := os.Hostname()
+	n := &notification{
+		Tool:       notifyTool,
+		Project:    filepath.Base(notifyBasePath),
+		Host:       host,
+		Success:    exitCode == 0 && len(jsonDoc.Errors) == 0,
+		ExitCode:   exitCode,
+		DurationMs: time.Since(jsonStart).Milliseconds(),
+		Summary:    notifySummary,
+		Counts:     make(map[string]int),
+		Errors:     append([]string{}, jsonDoc.Errors...),
+		Logs:       append([]notifyExcerpt{}, notifyExcerpts...),
+		Artifacts:  []string{},
+		RunURL:     ciRunURL(),
+	}
+	for _, a := range jsonDoc.Actions {
+		n.Counts[a.Status]++
+	}
+	if n.Summary == "" {
+		var parts []string
+		for _, status := range []string{"ok", "failed", "skipped"} {
+			if n.Counts[status] > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", n.Counts[status], status))
+			}
+		}
+		n.Summary = strings.Join(parts, ", ")
+	}
+	for _, a := range jsonDoc.Artifacts {
+		n.Artifacts = append(n.Artifacts, artifactLink(a))
+	}
+	return n
+}
+
+// artifactLink turns a path into a link below artifactBaseUrl; paths outside the
+// project, or without a base URL, are kept as they are
+func artifactLink(p string) string {
+	rel := filepath.ToSlash(p)
+	if filepath.IsAbs(p) {
+		r, err := filepath.Rel(notifyBasePath, p)
+		if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+			return rel
+		}
+		rel = filepath.ToSlash(r)
+	}
+	if notifyArtifactURL == "" {
+		return rel
+	}
+	parts := strings.Split(rel, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.TrimSuffix(notifyArtifactURL, "/") + "/" + strings.Join(parts, "/")
+}
+
+// ciRunURL links the CI job the tool runs in, when the CI exposes one
+func ciRunURL() string {
+	if server, repo, run := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"); server != "" && repo != "" && run != "" {
+		return server + "/" + repo + "/actions/runs/" + run
+	}
+	for _, name := range []string{"CI_JOB_URL", "BUILD_URL"} { // GitLab, Jenkins
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// webhookFormat returns the configured format, else guesses it from the host.
+// Mattermost and Rocket.Chat accept "slack".
+func webhookFormat(h webhookConfig) string {
+	if h.Format != "" {
+		return strings.ToLower(h.Format)
+	}
+	host := webhookHost(h.URL)
+	switch {
+	case host == "hooks.slack.com":
+		return "slack"
+	case host == "discord.com" || host == "discordapp.com" || strings.HasSuffix(host, ".discord.com"):
+		return "discord"
+	}
+	return "json"
+}
+
+// webhookHost is what the output shows of a webhook: the path holds the secret
+func webhookHost(raw string) string {
+	if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		return strings.ToLower(u.Hostname())
+	}
+	return "webhook"
+}
+
+// notificationText renders the message as Slack mrkdwn or Discord markdown,
+// cut to limit characters
+func notificationText(n *notification, slack bool, limit int) string {
+	esc := func(s string) string { return s }
+	bold := func(s string) string { return "**" + s + "**" }
+	link := func(u, text string) string { return text + ": " + u }
+	if slack {
+		esc = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+		bold = func(s string) string { return "*" + s + "*" }
+		link = func(u, text string) string { return "<" + u + "|" + esc(text) + ">" }
+	}
+
+	status := "✅ " + bold(esc(n.Tool)) + " succeeded"
+	if !n.Success {
+		status = "❌ " + bold(esc(n.Tool)) + fmt.Sprintf(" failed (exit code %d)", n.ExitCode)
+	}
+	lines := []string{fmt.Sprintf("%s: %s on %s, %s", status, esc(n.Project), esc(n.Host), (time.Duration(n.DurationMs) * time.Millisecond).Round(time.Second))}
+	if n.Summary != "" {
+		lines = append(lines, esc(n.Summary))
+	}
+	if n.RunURL != "" {
+		lines = append(lines, link(n.RunURL, "CI run"))
+	}
+	if len(n.Errors) > 0 {
+		lines = append(lines, "", bold("Errors"))
+		for i, e := range n.Errors {
+			if i == notifyMaxLines {
+				lines = append(lines, fmt.Sprintf("… %d more", len(n.Errors)-i))
+				break
+			}
+			lines = append(lines, "• "+esc(e))
+		}
+	}
+	for _, x := range n.Logs {
+		excerpt := x.Lines
+		if len(excerpt) > notifyMaxLines {
+			excerpt = excerpt[len(excerpt)-notifyMaxLines:]
+		}
+		// A stray fence inside the log would end the code block early
+		block := strings.ReplaceAll(strings.Join(excerpt, "\n"), "```", "'''")
+		lines = append(lines, "", bold(esc(x.Title)), "```", esc(block), "```")
+	}
+	if len(n.Artifacts) > 0 {
+		lines = append(lines, "", bold("Artifacts"))
+		for i, a := range n.Artifacts {
+			if i == notifyMaxLines {
+				lines = append(lines, fmt.Sprintf("… %d more", len(n.Artifacts)-i))
+				break
+			}
+			if strings.HasPrefix(a, "http://") || strings.HasPrefix(a, "https://") {
+				lines = append(lines, "• "+link(a, path.Base(a)))
+			} else {
+				lines = append(lines, "• "+esc(a))
+			}
+		}
+	}
+
+	text := strings.Join(lines, "\n")
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	text = string(runes[:limit-8])
+	if strings.Count(text, "```")%2 == 1 {
+		text += "\n```"
+	}
+	return text + "\n…"
+}
+
+// postWebhook sends one message; a 429 or 5xx answer is retried once
+func postWebhook(h webhookConfig, n *notification) error {
+	var payload interface{} = n
+	switch h.Format {
+	case "slack":
+		payload = map[string]string{"text": notificationText(n, true, 3900)}
+	case "discord":
+		payload = map[string]string{"content": notificationText(n, false, 2000)}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: notifyTimeout}
+	for attempt := 0; ; attempt++ {
+		resp, err := client.Post(h.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			// The error text repeats the URL, secret included
+			if ue, ok := err.(*url.Error); ok {
+				err = ue.Err
+			}
+			return err
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+		if resp.StatusCode < 300 {
+			return nil
+		}
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !retry || attempt > 0 {
+			return fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		wait := 2 * time.Second
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 && s <= 30 {
+			wait = time.Duration(s) * time.Second
+		}
+		time.Sleep(wait)
+	}
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// CI Annotations
+// ============================================================
+
+// With -annotate, errors and warnings are printed once more as GitHub Actions
+// workflow commands or TeamCity service messages, so the CI lists them on the run
+// page and, when they point at a file, next to that file in the diff.
+var (
+	annotateMode  string              // "", "github" or "teamcity"
+	annotateTool  string              // TeamCity inspection category
+	annotateBase  string              // project root; relative files start here
+	annotateRoot  string              // repository root; CI file paths are relative to it
+	annotateTypes = map[string]bool{} // TeamCity inspection types already declared
+)
+
+// setAnnotateMode checks the -annotate value; "auto" picks the CI the tool runs in
+func setAnnotateMode(mode, basePath, tool string) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "none":
+		annotateMode = ""
+	case "github", "teamcity":
+		annotateMode = strings.ToLower(strings.TrimSpace(mode))
+	case "auto":
+		annotateMode = ""
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			annotateMode = "github"
+		} else if os.Getenv("TEAMCITY_VERSION") != "" {
+			annotateMode = "teamcity"
+		}
+	default:
+		return fmt.Errorf("unknown -annotate mode '%s' (use github, teamcity or auto)", mode)
+	}
+	annotateTool = tool
+	annotateBase, _ = filepath.Abs(basePath)
+	annotateRoot = repositoryRoot(annotateBase)
+	return nil
+}
+
+// repositoryRoot is GITHUB_WORKSPACE, else the nearest folder with a .git entry
+// (a folder, or a file in worktrees and submodules), else the project itself
+func repositoryRoot(basePath string) string {
+	if ws := os.Getenv("GITHUB_WORKSPACE"); ws != "" && annotateMode == "github" {
+		return ws
+	}
+	for dir := basePath; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return basePath
+		}
+	}
+}
+
+// annotate prints one annotation. level is "error" or "warning"; file is absolute
+// or relative to the project root, and may be empty like line and col.
+func annotate(level, file string, line, col int, title, message string) {
+	if annotateMode == "" {
+		return
+	}
+	if file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(annotateBase, filepath.FromSlash(file))
+		}
+		if rel, err := filepath.Rel(annotateRoot, file); err == nil {
+			file = rel
+		}
+		file = filepath.ToSlash(file)
+	}
+
+	if annotateMode == "github" {
+		// Property values also escape ':' and ','; the message only '%' and line breaks
+		data := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+		prop := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+		var props []string
+		if file != "" {
+			props = append(props, "file="+prop.Replace(file))
+			if line > 0 {
+				props = append(props, "line="+strconv.Itoa(line))
+			}
+			if col > 0 {
+				props = append(props, "col="+strconv.Itoa(col))
+			}
+		}
+		if title != "" {
+			props = append(props, "title="+prop.Replace(title))
+		}
+		fmt.Printf("::%s %s::%s\n", level, strings.Join(props, ","), data.Replace(message))
+		return
+	}
+
+	esc := strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]").Replace
+	status := "WARNING"
+	if level == "error" {
+		status = "ERROR"
+	}
+	if file == "" {
+		text := message
+		if title != "" {
+			text = title + ": " + message
+		}
+		fmt.Printf("##teamcity[message text='%s' status='%s']\n", esc(text), status)
+		return
+	}
+	typeID := annotateTool + "." + strings.ReplaceAll(strings.ToLower(title), " ", "-")
+	if !annotateTypes[typeID] {
+		annotateTypes[typeID] = true
+		fmt.Printf("##teamcity[inspectionType id='%s' name='%s' category='%s' description='%s']\n", esc(typeID), esc(title), esc(annotateTool), esc(title))
+	}
+	lineAttr := ""
+	if line > 0 {
+		lineAttr = fmt.Sprintf(" line='%d'", line)
+	}
+	fmt.Printf("##teamcity[inspection typeId='%s' message='%s' file='%s'%s SEVERITY='%s']\n", esc(typeID), esc(message), esc(file), lineAttr, status)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode, dryRun, failFast, noNotify bool
+	var matrixFlag, outFlag, cellsFlag, shardFlag, unityFlag, versionFlag, buildNumber, annotateFlag string
+	var timeoutFlag time.Duration
+
+	flag.StringVar(&matrixFlag, "matrix", defaultMatrixFile, "Matrix definition file")
+	flag.StringVar(&outFlag, "out", "", "Output folder for cell builds, logs and reports (default: \"output\" in the matrix, else Build/Matrix)")
+	flag.StringVar(&cellsFlag, "cells", "", "Comma-separated cell ID patterns to run (e.g. \"Android-*,*-Debug-*\")")
+	flag.StringVar(&shardFlag, "shard", "", "Run only shard i of n (e.g. 2/3) when the matrix is split across agents")
+	flag.StringVar(&unityFlag, "unity", "", "Unity editor binary or version folder (default: UNITYSTARTER_UNITY, then the Hub install of the project's version)")
+	flag.StringVar(&versionFlag, "version", "", "bundleVersion for every cell (sets UNITYSTARTER_BUILD_VERSION)")
+	flag.StringVar(&buildNumber, "build-number", "", "Build number for every cell (sets UNITYSTARTER_BUILD_NUMBER)")
+	flag.DurationVar(&timeoutFlag, "timeout", 0, "Abort a cell after this long (e.g. 90m; default: \"timeoutMinutes\" in the matrix, else none)")
+	flag.BoolVar(&failFast, "fail-fast", false, "Stop after the first failed cell")
+	flag.StringVar(&annotateFlag, "annotate", "", "Also print build errors and warnings as CI annotations: github, teamcity, auto")
+	flag.BoolVar(&noNotify, "no-notify", false, "Do not post the result to the webhooks in .unitystarter.json")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Print the Unity command line of every cell without running it")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the command ("list -cells Android-*")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_build_matrix")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		sendNotifications(code)
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	command := "run"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	if len(args) > 1 || (command != "run" && command != "list" && command != "report") {
+		fmt.Println(tr("Usage: unity_build_matrix [flags] [run|list|report]"))
+		recordError("unknown command")
+		exit(2)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if err := setAnnotateMode(annotateFlag, basePath, "unity_build_matrix"); err != nil {
+		fail(2, "%v", err)
+	}
+
+	matrixPath := matrixFlag
+	if !filepath.IsAbs(matrixPath) {
+		matrixPath = filepath.Join(basePath, matrixPath)
+	}
+	matrix, err := loadMatrix(matrixPath)
+	if err != nil {
+		fail(1, "%v", err)
+	}
+	all := expandCells(matrix)
+	cells, err := filterCells(all, cellsFlag)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+	if shardFlag != "" {
+		index, count, err := parseShard(shardFlag)
+		if err != nil {
+			fail(2, "%v", err)
+		}
+		cells = shardCells(cells, index, count)
+	}
+
+	outDir := outFlag
+	if outDir == "" {
+		outDir = matrix.Output
+	}
+	if !filepath.IsAbs(outDir) {
+		outDir = filepath.Join(basePath, filepath.FromSlash(outDir))
+	}
+	if isUnder(outDir, filepath.Join(basePath, "Assets")) {
+		fail(2, "the output folder cannot be inside Assets/: %s", outDir)
+	}
+	project := filepath.Base(basePath)
+
+	switch command {
+	case "list":
+		for _, c := range cells {
+			defines := strings.Join(c.Defines, ";")
+			fmt.Printf("%-44s %-22s %-12s %-12s %s\n", c.ID, c.Platform, c.Configuration, c.DefineSet, defines)
+			recordAction("cell", c.ID, "planned", strings.TrimSpace(strings.Join(c.Args, " ")+" "+defines), 0)
+		}
+		fmt.Printf(tr("\n%d of %d cell(s)\n"), len(cells), len(all))
+		exit(0)
+	case "report":
+		if !noNotify {
+			enableNotifications(basePath, "unity_build_matrix")
+		}
+		report, err := writeReports(outDir, project, all)
+		if err != nil {
+			fail(1, "cannot write the report: %v", err)
+		}
+		printReport(report)
+		setNotifySummary("Merged report: %d of %d cell(s) ok, %d failed, %d skipped, %d without result", report.OK, report.Total, report.Failed, report.Skipped, len(report.Missing))
+		for _, res := range report.Cells {
+			if res.Status == cellFailed {
+				recordError("%s: %s", res.Cell.ID, res.Error)
+				addNotifyExcerpt(res.Cell.ID, res.LogExcerpt)
+				annotate("error", "", 0, 0, res.Cell.ID, res.Error)
+			}
+			annotateBuildLog(res.Cell.ID, filepath.Join(res.dir, res.Log), maxCellAnnotations)
+		}
+		fmt.Printf(tr("\n[OK] Report: %s\n"), filepath.Join(outDir, reportHTMLName))
+		recordArtifact(filepath.Join(outDir, reportJSONName))
+		recordArtifact(filepath.Join(outDir, reportHTMLName))
+		if report.Failed > 0 {
+			exit(1)
+		}
+		exit(0)
+	}
+
+	if len(cells) == 0 {
+		fmt.Println(tr("[--] No cells to build."))
+		exit(0)
+	}
+
+	hooks := installedHooksVersion(basePath)
+	if hooks == 0 {
+		fmt.Println(tr("[WARNING] The build hooks are not installed: no cell can report success. Run: unity_build_hooks install"))
+	}
+	for _, c := range cells {
+		if len(c.Defines) > 0 && hooks < definesHooksMin {
+			fail(1, "define set '%s' needs build hooks v%d or newer (installed: v%d); run: unity_build_hooks install", c.DefineSet, definesHooksMin, hooks)
+		}
+	}
+
+	unity, err := findUnity(basePath, unityFlag)
+	if err != nil {
+		if !dryRun {
+			fail(1, "%v", err)
+		}
+		// The dry run still shows the command lines
+		fmt.Printf(tr("[WARNING] %v\n"), err)
+		unity = "Unity"
+	}
+	timeout := timeoutFlag
+	if timeout == 0 && matrix.TimeoutMinutes > 0 {
+		timeout = time.Duration(matrix.TimeoutMinutes) * time.Minute
+	}
+	opts := &runOptions{
+		basePath:      basePath,
+		unity:         unity,
+		executeMethod: matrix.ExecuteMethod,
+		outDir:        outDir,
+		addressables:  matrix.Addressables,
+		version:       versionFlag,
+		buildNumber:   buildNumber,
+		commit:        os.Getenv(envBuildCommit),
+		shard:         shardFlag,
+		timeout:       timeout,
+	}
+	if opts.commit == "" {
+		opts.commit = readCommit(basePath)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  BUILD MATRIX"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:  %s\n"), project)
+	fmt.Printf(tr("  Unity:    %s\n"), unity)
+	fmt.Printf(tr("  Output:   %s\n"), outDir)
+	if shardFlag != "" {
+		fmt.Printf(tr("  Shard:    %s (%d of %d cell(s))\n"), shardFlag, len(cells), len(all))
+	} else {
+		fmt.Printf(tr("  Cells:    %d of %d\n"), len(cells), len(all))
+	}
+	fmt.Println()
+
+	if dryRun {
+		for _, c := range cells {
+			cellArgs, env := cellCommand(opts, c, filepath.Join(outDir, c.ID))
+			fmt.Printf("[%s]\n  %s %s\n", c.ID, unity, strings.Join(cellArgs, " "))
+			for _, e := range env {
+				fmt.Printf("  %s\n", e)
+			}
+			recordAction("build", c.ID, "planned", strings.Join(env, " "), 0)
+		}
+		fmt.Println(tr("\n[Dry Run] No build was started."))
+		exit(0)
+	}
+
+	if !noNotify {
+		enableNotifications(basePath, "unity_build_matrix")
+	}
+	failed, skipped := 0, 0
+	for i, c := range cells {
+		if failFast && failed > 0 {
+			res := &cellResult{Cell: c, Status: cellSkipped, Shard: shardFlag, Log: "build.log", dir: filepath.Join(outDir, c.ID)}
+			if os.MkdirAll(res.dir, 0755) == nil {
+				saveCellResult(res)
+			}
+			fmt.Printf(tr("[%d/%d] [--] %s skipped (-fail-fast)\n"), i+1, len(cells), c.ID)
+			recordAction("build", c.ID, "skipped", "fail-fast", 0)
+			skipped++
+			continue
+		}
+		fmt.Printf(tr("[%d/%d] Building %s ...\n"), i+1, len(cells), c.ID)
+		res := runCell(opts, c)
+		duration := time.Duration(res.DurationSeconds * float64(time.Second))
+		annotateBuildLog(c.ID, filepath.Join(res.dir, res.Log), maxCellAnnotations)
+		if res.Status == cellOK {
+			fmt.Printf(tr("[%d/%d] [OK] %s (%s, %s)\n"), i+1, len(cells), c.ID, duration.Round(time.Second), formatSize(res.Report.TotalSizeBytes))
+			recordAction("build", c.ID, "ok", res.Report.OutputPath, duration)
+			continue
+		}
+		failed++
+		fmt.Printf(tr("[%d/%d] [FAIL] %s: %s\n"), i+1, len(cells), c.ID, res.Error)
+		for _, line := range res.LogExcerpt {
+			fmt.Printf("         %s\n", line)
+		}
+		fmt.Printf(tr("         Log: %s\n"), filepath.Join(res.dir, res.Log))
+		recordAction("build", c.ID, "failed", res.Error, duration)
+		recordError("%s: %s", c.ID, res.Error)
+		addNotifyExcerpt(c.ID, res.LogExcerpt)
+		annotate("error", "", 0, 0, c.ID, res.Error)
+	}
+
+	report, err := writeReports(outDir, project, all)
+	if err != nil {
+		fmt.Printf(tr("[WARNING] Cannot write the report: %v\n"), err)
+	} else {
+		printReport(report)
+		fmt.Printf(tr("\n[OK] Report: %s\n"), filepath.Join(outDir, reportHTMLName))
+		recordArtifact(filepath.Join(outDir, reportJSONName))
+		recordArtifact(filepath.Join(outDir, reportHTMLName))
+	}
+	setNotifySummary("%d of %d cell(s) ok, %d failed, %d skipped", len(cells)-failed-skipped, len(cells), failed, skipped)
+	if shardFlag != "" {
+		fmt.Println(tr("[TIP] Copy the cell folders of every shard into one folder and run \"unity_build_matrix report\" there."))
+	}
+	if failed > 0 {
+		exit(1)
+	}
+	exit(0)
+}
@@ -0,0 +1,1336 @@
+// Unity CDN Verify — Check that the hot-update content on a CDN is complete and intact.
+// Downloads the live catalog (a YooAsset .version file or package manifest, an
+// Addressables catalog_*.json, or a content trust manifest) and checks every
+// bundle it lists on the CDN in parallel: a HEAD request for availability and
+// size, then ranged GETs of its first and last bytes compared with the local
+// build, or with -full a download of the whole file checked against the hash in
+// the catalog. The live catalog is also compared with the locally built one, so
+// an upload that stopped halfway, a bundle a CDN edge still serves from an older
+// build, or a catalog uploaded before its bundles shows up before players
+// download it. Exits with 1 when something is missing or differs.
+//
+// Build: go build unity_cdn_verify.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_cdn_verify https://cdn.example.com/game/Android/DefaultPackage/PackageManifest_DefaultPackage.version
+//	unity_cdn_verify https://cdn.example.com/game/Android/catalog_1.0.json -local ServerData/Android
+//	unity_cdn_verify https://cdn.example.com/live/ContentTrust.json -full    # download and hash every bundle
+//	unity_cdn_verify -url https://cdn.example.com/... -jobs 16 -no-cache -ci
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Where the build pipeline puts hot-update content (YooAssetBuilder and
+// AddressablesBuilder DEFAULT_BUILD_OUTPUT_DIR), then the default output roots
+// of YooAsset and Addressables; searched for the local copy of the catalog
+var defaultContentDirs = []string{"Build/HotUpdateBundle", "Build/AddressablesContent", "Bundles", "ServerData"}
+
+const (
+	// Value of the Authorization header, e.g. "Bearer ..." for a private bucket;
+	// read from the environment so it stays out of the CI log
+	envCDNAuth = "UNITYSTARTER_CDN_AUTH"
+
+	maxCatalogBytes = 256 << 20 // a catalog larger than this is not a catalog
+	userAgent       = "UnityStarter-unity_cdn_verify"
+)
+
+// Catalog formats
+const (
+	formatYooAsset     = "YooAsset"
+	formatAddressables = "Addressables"
+	formatTrust        = "Content trust"
+)
+
+// Outcome of one bundle
+const (
+	statusOK      = "ok"
+	statusMissing = "missing"
+	statusPartial = "partial" // size differs from the catalog or the local build
+	statusCorrupt = "corrupt" // bytes or hash differ
+	statusError   = "error"   // could not be checked (network, HTTP errors)
+)
+
+// OutputNameStyle of a YooAsset manifest (EFileNameStyle)
+const (
+	yooHashName           = 0
+	yooBundleName         = 1
+	yooBundleNameHashName = 2
+)
+
+// Problems listed per kind before "...and N more"
+const maxListed = 50
+
+var (
+	// Content-Range: bytes 0-65535/1048576
+	contentRangeRegex = regexp.MustCompile(`^bytes \d+-\d+/(\d+)$`)
+
+	// PackageManifest_<package>.version
+	yooVersionFileRegex = regexp.MustCompile(`^PackageManifest_(.+)\.version$`)
+
+	sizeRegex = regexp.MustCompile(`(?i)^([\d.]+)\s*(b|kb|mb|gb|tb)?$`)
+)
+
+var httpClient = &http.Client{Timeout: 2 * time.Minute}
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// catalogEntry is one file the catalog lists
+type catalogEntry struct {
+	location string // relative to the catalog folder, or an absolute URL
+	size     int64  // -1 when the catalog does not record it
+	hashAlg  string // "sha256", "md5", or "" when the catalog has no usable hash
+	hash     string // lower-case hex
+}
+
+// catalog is a parsed catalog file
+type catalog struct {
+	format  string
+	name    string // file name
+	version string
+	root    string // content root below the catalog folder (content trust manifests)
+	raw     []byte
+	entries []*catalogEntry
+}
+
+// bundleCheck is one bundle and what the CDN returned for it
+type bundleCheck struct {
+	entry    *catalogEntry
+	url      string
+	local    string // the file in the local build, "" when there is none
+	expected int64  // size from the catalog, else from the local build; -1 when unknown
+	status   string
+	detail   string
+	duration time.Duration
+}
+
+// fetcher sends the requests; network errors, 5xx and 429 responses are retried
+type fetcher struct {
+	auth    string
+	noCache bool
+	retries int
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// HTTP
+// ============================================================
+
+func (f *fetcher) request(method, u, byteRange string) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; attempt <= f.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+		req, err := http.NewRequest(method, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", userAgent)
+		if f.auth != "" {
+			req.Header.Set("Authorization", f.auth)
+		}
+		if f.noCache {
+			req.Header.Set("Cache-Control", "no-cache")
+		}
+		if byteRange != "" {
+			req.Header.Set("Range", byteRange)
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("%s %s: %s", method, u, resp.Status)
+			continue
+		}
+		return resp, nil
+	}
+	return nil, lastErr
+}
+
+// fetch downloads a small file; a missing file returns errNotFound
+func (f *fetcher) fetch(u string) ([]byte, error) {
+	resp, err := f.request("GET", u, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if isNotFound(resp.StatusCode) {
+		return nil, errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCatalogBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %v", u, err)
+	}
+	if len(data) > maxCatalogBytes {
+		return nil, fmt.Errorf("GET %s: larger than %s", u, formatSize(maxCatalogBytes))
+	}
+	return data, nil
+}
+
+var errNotFound = errors.New("not found")
+
+// S3 and most buckets answer 403 for keys that do not exist
+func isNotFound(code int) bool {
+	return code == http.StatusNotFound || code == http.StatusForbidden || code == http.StatusGone
+}
+
+// resolveURL joins a catalog location to the folder of the catalog
+func resolveURL(base *url.URL, location string) string {
+	if strings.Contains(location, "://") {
+		return location
+	}
+	ref := &url.URL{Path: location}
+	return base.ResolveReference(ref).String()
+}
+
+// ============================================================
+// Catalogs
+// ============================================================
+
+// parseCatalog reads a YooAsset package manifest, an Addressables catalog or a
+// content trust manifest (schema 2, CycloneGames.AssetManagement)
+func parseCatalog(name string, data []byte) (*catalog, error) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("%s is not a JSON catalog: %v", name, err)
+	}
+	c := &catalog{name: name, raw: data}
+	switch {
+	case keys["BundleList"] != nil:
+		var doc struct {
+			PackageName     string
+			PackageVersion  string
+			OutputNameStyle int
+			BundleList      []struct {
+				BundleName string
+				FileHash   string
+				FileSize   int64
+			}
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		c.format, c.version = formatYooAsset, doc.PackageVersion
+		for _, b := range doc.BundleList {
+			c.entries = append(c.entries, &catalogEntry{
+				location: yooBundleFileName(doc.OutputNameStyle, b.BundleName, b.FileHash),
+				size:     b.FileSize,
+				hashAlg:  "md5",
+				hash:     strings.ToLower(b.FileHash),
+			})
+		}
+	case keys["m_InternalIds"] != nil:
+		var doc struct {
+			InternalIds []string `json:"m_InternalIds"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		c.format = formatAddressables
+		c.version = strings.TrimSuffix(strings.TrimPrefix(name, "catalog_"), path.Ext(name))
+		seen := make(map[string]bool)
+		for _, id := range doc.InternalIds {
+			// Bundles in the player ({UnityEngine.AddressableAssets.Addressables.RuntimePath}/...)
+			// are not on the CDN; remote ones are usually absolute after the build
+			if i := strings.IndexAny(id, "?#"); i >= 0 {
+				id = id[:i]
+			}
+			if strings.HasPrefix(id, "{") || !strings.HasSuffix(strings.ToLower(id), ".bundle") || seen[id] {
+				continue
+			}
+			seen[id] = true
+			c.entries = append(c.entries, &catalogEntry{location: id, size: -1})
+		}
+	case keys["schemaVersion"] != nil && keys["entries"] != nil:
+		var doc struct {
+			SchemaVersion int    `json:"schemaVersion"`
+			Version       string `json:"version"`
+			ContentRoot   string `json:"contentRoot"`
+			Entries       []struct {
+				Location        string `json:"location"`
+				SizeBytes       int64  `json:"sizeBytes"`
+				HashAlgorithm   string `json:"hashAlgorithm"`
+				ExpectedHashHex string `json:"expectedHashHex"`
+			} `json:"entries"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		if doc.SchemaVersion != 2 {
+			return nil, fmt.Errorf("%s: content trust manifest schema %d is not supported (expected 2)", name, doc.SchemaVersion)
+		}
+		c.format, c.version, c.root = formatTrust, doc.Version, doc.ContentRoot
+		for _, e := range doc.Entries {
+			entry := &catalogEntry{location: e.Location, size: e.SizeBytes}
+			// XxHash64 entries are compared with the local build instead
+			if strings.EqualFold(e.HashAlgorithm, "Sha256") {
+				entry.hashAlg, entry.hash = "sha256", strings.ToLower(e.ExpectedHashHex)
+			}
+			c.entries = append(c.entries, entry)
+		}
+	default:
+		return nil, fmt.Errorf("%s is not a YooAsset manifest, an Addressables catalog or a content trust manifest", name)
+	}
+	return c, nil
+}
+
+// yooBundleFileName is the name YooAsset uploads a bundle under
+// (ManifestTools.GetRemoteBundleFileName)
+func yooBundleFileName(style int, bundleName, fileHash string) string {
+	ext := path.Ext(bundleName)
+	switch style {
+	case yooHashName:
+		return fileHash + ext
+	case yooBundleNameHashName:
+		return strings.TrimSuffix(bundleName, ext) + "_" + fileHash + ext
+	default: // yooBundleName
+		return bundleName
+	}
+}
+
+// entryPath is the location of an entry below the catalog folder
+func (c *catalog) entryPath(e *catalogEntry) string {
+	if c.root == "" || c.root == "." {
+		return e.location
+	}
+	return strings.TrimSuffix(c.root, "/") + "/" + e.location
+}
+
+// diffCatalogs lists the entries that were added, removed or changed between
+// the local and the live catalog
+func diffCatalogs(local, live *catalog) (added, removed, changed []string) {
+	byLocation := make(map[string]*catalogEntry)
+	for _, e := range local.entries {
+		byLocation[local.entryPath(e)] = e
+	}
+	seen := make(map[string]bool)
+	for _, e := range live.entries {
+		p := live.entryPath(e)
+		seen[p] = true
+		l, ok := byLocation[p]
+		switch {
+		case !ok:
+			added = append(added, p)
+		case l.size != e.size || l.hash != e.hash:
+			changed = append(changed, p)
+		}
+	}
+	for _, e := range local.entries {
+		if p := local.entryPath(e); !seen[p] {
+			removed = append(removed, p)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
+
+// ============================================================
+// Local Build
+// ============================================================
+
+// findLocal looks for a file named name below the content folders. With several
+// (one per platform or version), the one whose folders end like the folders of
+// the catalog URL wins, e.g. .../Android/DefaultPackage/ for a catalog in
+// https://cdn/game/Android/DefaultPackage/.
+func findLocal(roots []string, name string, catalogURL *url.URL) (string, error) {
+	var found []string
+	for _, root := range roots {
+		filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && info.Name() == name {
+				found = append(found, p)
+			}
+			return nil
+		})
+	}
+	if len(found) <= 1 {
+		if len(found) == 0 {
+			return "", nil
+		}
+		return found[0], nil
+	}
+	urlDirs := strings.Split(strings.Trim(path.Dir(catalogURL.Path), "/"), "/")
+	best, bestScore, tie := "", -1, false
+	for _, p := range found {
+		dirs := strings.Split(filepath.ToSlash(filepath.Dir(p)), "/")
+		score := 0
+		for score < len(dirs) && score < len(urlDirs) && strings.EqualFold(dirs[len(dirs)-1-score], urlDirs[len(urlDirs)-1-score]) {
+			score++
+		}
+		switch {
+		case score > bestScore:
+			best, bestScore, tie = p, score, false
+		case score == bestScore:
+			tie = true
+		}
+	}
+	if tie {
+		sort.Strings(found)
+		return "", fmt.Errorf("%d local builds have %s; pick one with -local:\n  %s", len(found), name, strings.Join(found, "\n  "))
+	}
+	return best, nil
+}
+
+// localFiles indexes the files below a local build folder by name, for catalog
+// entries with absolute URLs
+func localFiles(dir string) map[string]string {
+	files := make(map[string]string)
+	filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			if _, ok := files[info.Name()]; !ok {
+				files[info.Name()] = p
+			}
+		}
+		return nil
+	})
+	return files
+}
+
+// ============================================================
+// Verification
+// ============================================================
+
+// verifyBundle checks one bundle: HEAD for availability and size, then
+// either the whole file (-full, or small files) or its first and last bytes
+func verifyBundle(f *fetcher, c *bundleCheck, rangeBytes int64, full bool) {
+	start := time.Now()
+	defer func() { c.duration = time.Since(start) }()
+	c.status = statusOK
+
+	resp, err := f.request("HEAD", c.url, "")
+	if err != nil {
+		c.status, c.detail = statusError, err.Error()
+		return
+	}
+	resp.Body.Close()
+	switch {
+	case isNotFound(resp.StatusCode):
+		c.status, c.detail = statusMissing, fmt.Sprintf("HTTP %d", resp.StatusCode)
+		return
+	case resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented:
+		// No HEAD; the GET requests below still tell
+	case resp.StatusCode != http.StatusOK:
+		c.status, c.detail = statusError, "HEAD: "+resp.Status
+		return
+	case resp.ContentLength >= 0 && c.expected >= 0 && resp.ContentLength != c.expected:
+		c.status = statusPartial
+		c.detail = fmt.Sprintf(tr("%s on the CDN, %s expected"), formatSize(resp.ContentLength), formatSize(c.expected))
+		return
+	}
+
+	switch {
+	case full || (c.expected >= 0 && c.expected <= 2*rangeBytes):
+		verifyWhole(f, c)
+	case c.expected < 0:
+		// Nothing to compare with; without HEAD, the first byte shows it is there
+		if resp.StatusCode != http.StatusOK {
+			verifyRange(f, c, 0, 1)
+		}
+	default:
+		if verifyRange(f, c, 0, rangeBytes) && c.status == statusOK {
+			verifyRange(f, c, c.expected-rangeBytes, rangeBytes)
+		}
+	}
+}
+
+// verifyWhole downloads the bundle and checks its size and hash; without a hash
+// in the catalog it is compared with the local build
+func verifyWhole(f *fetcher, c *bundleCheck) {
+	resp, err := f.request("GET", c.url, "")
+	if err != nil {
+		c.status, c.detail = statusError, err.Error()
+		return
+	}
+	defer resp.Body.Close()
+	if isNotFound(resp.StatusCode) {
+		c.status, c.detail = statusMissing, fmt.Sprintf("HTTP %d", resp.StatusCode)
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		c.status, c.detail = statusError, "GET: "+resp.Status
+		return
+	}
+
+	alg, want := c.entry.hashAlg, c.entry.hash
+	if alg == "" && c.local != "" {
+		localHash, err := fileHash(c.local, "sha256")
+		if err == nil {
+			alg, want = "sha256", localHash
+		}
+	}
+	h := newHash(alg)
+	var w io.Writer = io.Discard
+	if h != nil {
+		w = h
+	}
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		c.status, c.detail = statusError, fmt.Sprintf(tr("download stopped after %s: %v"), formatSize(n), err)
+		return
+	}
+	if c.expected >= 0 && n != c.expected {
+		c.status = statusPartial
+		c.detail = fmt.Sprintf(tr("%s on the CDN, %s expected"), formatSize(n), formatSize(c.expected))
+		return
+	}
+	if h == nil {
+		return
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		c.status = statusCorrupt
+		c.detail = fmt.Sprintf(tr("%s %s, expected %s"), strings.ToUpper(alg), got, want)
+	}
+}
+
+// verifyRange fetches n bytes at offset and compares them with the local build.
+// It returns false when the check is finished: a problem was found, or the
+// server ignored the range and the whole file was checked instead.
+func verifyRange(f *fetcher, c *bundleCheck, offset, n int64) bool {
+	resp, err := f.request("GET", c.url, fmt.Sprintf("bytes=%d-%d", offset, offset+n-1))
+	if err != nil {
+		c.status, c.detail = statusError, err.Error()
+		return false
+	}
+	defer resp.Body.Close()
+	switch {
+	case isNotFound(resp.StatusCode):
+		c.status, c.detail = statusMissing, fmt.Sprintf("HTTP %d", resp.StatusCode)
+		return false
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		c.status = statusPartial
+		c.detail = fmt.Sprintf(tr("bytes %d-%d are not on the CDN"), offset, offset+n-1)
+		return false
+	case resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent:
+		c.status, c.detail = statusError, "GET: "+resp.Status
+		return false
+	}
+	if resp.StatusCode == http.StatusOK {
+		// The server ignores ranges and sends the whole file; check all of it
+		resp.Body.Close()
+		verifyWhole(f, c)
+		return false
+	}
+	if m := contentRangeRegex.FindStringSubmatch(resp.Header.Get("Content-Range")); m != nil {
+		if total, _ := strconv.ParseInt(m[1], 10, 64); c.expected >= 0 && total != c.expected {
+			c.status = statusPartial
+			c.detail = fmt.Sprintf(tr("%s on the CDN, %s expected"), formatSize(total), formatSize(c.expected))
+			return false
+		}
+	}
+	got := make([]byte, n)
+	read, err := io.ReadFull(resp.Body, got)
+	if err != nil && !(errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)) {
+		c.status, c.detail = statusError, err.Error()
+		return false
+	}
+	if int64(read) < n {
+		c.status = statusPartial
+		c.detail = fmt.Sprintf(tr("bytes %d-%d are not on the CDN"), offset+int64(read), offset+n-1)
+		return false
+	}
+	if c.local == "" {
+		return true
+	}
+	want, err := readAt(c.local, offset, n)
+	if err != nil {
+		// The size matched, so the local file changed since the catalog was read
+		c.status, c.detail = statusError, err.Error()
+		return false
+	}
+	if !bytes.Equal(got, want) {
+		c.status = statusCorrupt
+		c.detail = fmt.Sprintf(tr("bytes %d-%d differ from the local build"), offset, offset+n-1)
+		return false
+	}
+	return true
+}
+
+func readAt(p string, offset, n int64) ([]byte, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	buf := make([]byte, n)
+	if _, err := f.ReadAt(buf, offset); err != nil {
+		return nil, fmt.Errorf("%s: %v", p, err)
+	}
+	return buf, nil
+}
+
+func newHash(alg string) hash.Hash {
+	switch alg {
+	case "sha256":
+		return sha256.New()
+	case "md5":
+		return md5.New()
+	}
+	return nil
+}
+
+func fileHash(p, alg string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := newHash(alg)
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// parallel runs fn for 0..n-1 on up to jobs goroutines, calling progress after
+// each item from one goroutine at a time
+func parallel(n, jobs int, fn func(i int), progress func(done int)) {
+	if jobs < 1 {
+		jobs = 1
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+				mu.Lock()
+				done++
+				if progress != nil {
+					progress(done)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+var statusLabels = []struct{ status, label string }{
+	{statusMissing, "[MISSING]"},
+	{statusPartial, "[PARTIAL]"},
+	{statusCorrupt, "[CORRUPT]"},
+	{statusError, "[ERROR]"},
+}
+
+// printProblems lists the bundles that failed, grouped by kind
+func printProblems(checks []*bundleCheck) map[string]int {
+	counts := make(map[string]int)
+	for _, c := range checks {
+		counts[c.status]++
+	}
+	if counts[statusMissing]+counts[statusPartial]+counts[statusCorrupt]+counts[statusError] == 0 {
+		return counts
+	}
+	fmt.Println(tr("\nPROBLEMS"))
+	for _, sl := range statusLabels {
+		listed := 0
+		for _, c := range checks {
+			if c.status != sl.status {
+				continue
+			}
+			if listed == maxListed {
+				fmt.Printf(tr("  ...and %d more\n"), counts[sl.status]-maxListed)
+				break
+			}
+			listed++
+			fmt.Printf("%-10s %s\n", sl.label, c.entry.location)
+			if c.detail != "" {
+				fmt.Printf("           %s\n", c.detail)
+			}
+		}
+	}
+	return counts
+}
+
+// printList prints up to maxListed names under a title
+func printList(title string, names []string) {
+	if len(names) == 0 {
+		return
+	}
+	fmt.Printf("  %s (%d)\n", title, len(names))
+	for i, n := range names {
+		if i == maxListed {
+			fmt.Printf(tr("  ...and %d more\n"), len(names)-maxListed)
+			break
+		}
+		fmt.Printf("    %s\n", n)
+	}
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+// parseSize accepts "64KB", "1.5MB" or plain bytes
+func parseSize(s string) (int64, error) {
+	m := sizeRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+	v, _ := strconv.ParseFloat(m[1], 64)
+	switch strings.ToLower(m[2]) {
+	case "kb":
+		v *= 1 << 10
+	case "mb":
+		v *= 1 << 20
+	case "gb":
+		v *= 1 << 30
+	case "tb":
+		v *= 1 << 40
+	}
+	return int64(v), nil
+}
+func printProgressBar(current, total int) {
+	percent := float64(current) / float64(total)
+	if plainMode || jsonMode {
+		// One line per 10% step instead of redrawing the bar with \r
+		step := int(percent * 10)
+		if current == total || step > int(float64(current-1)/float64(total)*10) {
+			fmt.Printf(tr("Progress: %d/%d (%.0f%%)\n"), current, total, percent*100)
+		}
+		return
+	}
+	barLength := 40
+	filledLength := int(float64(barLength) * percent)
+
+	bar := strings.Repeat("█", filledLength) + strings.Repeat("-", barLength-filledLength)
+	fmt.Printf("\r[%s] %.0f%% (%d/%d)", bar, percent*100, current, total)
+	if current == total {
+		fmt.Println()
+	}
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...":               "\n按回车键继续...",
+	"Progress: %d/%d (%.0f%%)\n":                 "进度: %d/%d (%.0f%%)\n",
+	"%s on the CDN, %s expected":                 "CDN 上为 %s，应为 %s",
+	"download stopped after %s: %v":              "下载在 %s 后中断: %v",
+	"%s %s, expected %s":                         "%s 为 %s，应为 %s",
+	"bytes %d-%d are not on the CDN":             "CDN 上缺少字节 %d-%d",
+	"bytes %d-%d differ from the local build":    "字节 %d-%d 与本地构建不同",
+	"\nPROBLEMS":                                 "\n问题",
+	"  ...and %d more\n":                         "  ...以及另外 %d 项\n",
+	"[ERROR] %v\n":                               "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n": "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  CDN CONTENT VERIFY":                                "  CDN 内容校验",
+	"  Project: %s\n":                                     "  项目: %s\n",
+	"  Catalog: %s\n":                                     "  清单: %s\n",
+	"  Version: %s (%s)\n":                                "  版本: %s (%s)\n",
+	"  Local:   %s\n":                                     "  本地: %s\n",
+	"[OK] %s on the CDN matches the local build\n":        "[OK] CDN 上的 %s 与本地构建一致\n",
+	"[FAIL] %s on the CDN differs from the local build\n": "[FAIL] CDN 上的 %s 与本地构建不同\n",
+	"  Version on the CDN: %s, local: %s\n":               "  CDN 上的版本: %s，本地: %s\n",
+	"Only on the CDN":                                     "仅在 CDN 上",
+	"Only in the local build":                             "仅在本地构建中",
+	"Changed":                                             "已更改",
+	"[FAIL] %s is not in the local build %s; the CDN serves another build\n":           "[FAIL] 本地构建 %[2]s 中没有 %[1]s；CDN 上是另一个构建\n",
+	"[WARNING] No local build has %s; bundles are checked against the catalog only.\n": "[WARNING] 没有本地构建包含 %s；仅根据清单检查资源包。\n",
+	"  %s catalog, version %s: %d bundle(s), %s\n":                                     "  %s 清单，版本 %s: %d 个资源包，%s\n",
+	"\n[--] The catalog lists no bundles.":                                             "\n[--] 清单中没有资源包。",
+	"first and last %s":                                                                "首尾各 %s",
+	"full download":                                                                    "完整下载",
+	"\nChecking %d bundle(s) on the CDN (%s, %d parallel)...\n":                        "\n正在检查 CDN 上的 %d 个资源包（%s，%d 个并行）...\n",
+	"  SUMMARY":              "  摘要",
+	"  Bundles:   %d (%s)\n": "  资源包:   %d (%s)\n",
+	"  OK:        %d\n":      "  正常:     %d\n",
+	"  Missing:   %d\n":      "  缺失:     %d\n",
+	"  Partial:   %d\n":      "  不完整:   %d\n",
+	"  Corrupt:   %d\n":      "  已损坏:   %d\n",
+	"  Unchecked: %d\n":      "  未检查:   %d\n",
+	"  Took:      %v\n":      "  耗时:     %v\n",
+	"\n[FAIL] The content on the CDN is incomplete or differs from the build.":                              "\n[FAIL] CDN 上的内容不完整或与构建不同。",
+	"[TIP] Upload the listed files again (bundles before the catalog), then purge them from the CDN cache.": "[TIP] 请重新上传列出的文件（先上传资源包，再上传清单），然后清除 CDN 上这些文件的缓存。",
+	"\n[WARNING] %d bundle(s) could not be checked; run again or raise -timeout / -retries.\n":              "\n[WARNING] %d 个资源包无法检查；请重新运行或调大 -timeout / -retries。\n",
+	"\n[OK] Every bundle is on the CDN and matches the catalog.":                                            "\n[OK] 所有资源包都在 CDN 上且与清单一致。",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode    bool
+		full      bool
+		noCache   bool
+		urlFlag   string
+		localFlag string
+		rangeFl   string
+		jobs      int
+		retries   int
+		timeout   time.Duration
+	)
+
+	flag.StringVar(&urlFlag, "url", "", "URL of the live catalog: a YooAsset .version file or manifest, an Addressables catalog or a content trust manifest (or give it as the first argument)")
+	flag.StringVar(&localFlag, "local", "", "Local build folder or catalog to compare with (default: searched in "+strings.Join(defaultContentDirs, ", ")+")")
+	flag.BoolVar(&full, "full", false, "Download every bundle and check its hash instead of its first and last bytes")
+	flag.StringVar(&rangeFl, "range", "64KB", "Bytes compared at the start and at the end of each bundle")
+	flag.IntVar(&jobs, "jobs", 8, "Bundles checked in parallel")
+	flag.IntVar(&retries, "retries", 2, "Retries after network errors and 5xx / 429 responses")
+	flag.DurationVar(&timeout, "timeout", 2*time.Minute, "Timeout of one request, including the download with -full")
+	flag.BoolVar(&noCache, "no-cache", false, "Send 'Cache-Control: no-cache' so CDN edges revalidate with the origin")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the URL ("https://... -ci")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_cdn_verify")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	catalogArg := urlFlag
+	if catalogArg == "" && len(args) > 0 {
+		catalogArg, args = args[0], args[1:]
+	}
+	if catalogArg == "" {
+		fail(2, "no catalog URL; give it as the first argument or with -url")
+	}
+	if len(args) > 0 {
+		fail(2, "unexpected arguments: %s", strings.Join(args, " "))
+	}
+	catalogURL, err := url.Parse(catalogArg)
+	if err != nil || (catalogURL.Scheme != "http" && catalogURL.Scheme != "https") || catalogURL.Host == "" {
+		fail(2, "not an http(s) URL: %s", catalogArg)
+	}
+	rangeBytes, err := parseSize(rangeFl)
+	if err != nil || rangeBytes <= 0 {
+		fail(2, "-range: invalid size %q", rangeFl)
+	}
+	httpClient.Timeout = timeout
+	f := &fetcher{auth: os.Getenv(envCDNAuth), noCache: noCache, retries: retries}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+
+	var localRoots []string
+	localCatalog := ""
+	if localFlag != "" {
+		p := localFlag
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(basePath, filepath.FromSlash(p))
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			fail(2, "-local: %v", err)
+		}
+		if info.IsDir() {
+			localRoots = []string{p}
+		} else {
+			localCatalog = p
+		}
+	} else {
+		for _, d := range defaultContentDirs {
+			if info, err := os.Stat(filepath.Join(basePath, filepath.FromSlash(d))); err == nil && info.IsDir() {
+				localRoots = append(localRoots, filepath.Join(basePath, filepath.FromSlash(d)))
+			}
+		}
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  CDN CONTENT VERIFY"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project: %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Catalog: %s\n"), catalogURL.Redacted())
+
+	// A YooAsset .version file names the manifest the players download next
+	name := path.Base(catalogURL.Path)
+	yooVersion := ""
+	if m := yooVersionFileRegex.FindStringSubmatch(name); m != nil {
+		data, err := f.fetch(catalogURL.String())
+		if errors.Is(err, errNotFound) {
+			fail(1, "%s is not on the CDN", name)
+		}
+		if err != nil {
+			fail(3, "%v", err)
+		}
+		yooVersion = strings.TrimSpace(string(data))
+		if yooVersion == "" {
+			fail(1, "%s on the CDN is empty", name)
+		}
+		fmt.Printf(tr("  Version: %s (%s)\n"), yooVersion, name)
+		name = "PackageManifest_" + m[1] + "_" + yooVersion + ".json"
+		catalogURL = catalogURL.ResolveReference(&url.URL{Path: name})
+	}
+
+	var live *catalog
+	var liveBinary []byte
+	binName := strings.TrimSuffix(name, ".json") + ".bytes"
+	liveData, err := f.fetch(catalogURL.String())
+	switch {
+	case err == nil:
+		if live, err = parseCatalog(name, liveData); err != nil {
+			fail(1, "%v", err)
+		}
+	case errors.Is(err, errNotFound) && yooVersion != "":
+		// YooAsset uploads the binary manifest; the JSON one is in the build output
+		liveBinary, err = f.fetch(catalogURL.ResolveReference(&url.URL{Path: binName}).String())
+		if errors.Is(err, errNotFound) {
+			fail(1, "neither %s nor %s is on the CDN", name, binName)
+		}
+		if err != nil {
+			fail(3, "%v", err)
+		}
+	case errors.Is(err, errNotFound):
+		fail(1, "%s is not on the CDN", name)
+	default:
+		fail(3, "%v", err)
+	}
+
+	// The same catalog in the local build
+	localPath := localCatalog
+	if localPath == "" {
+		if localPath, err = findLocal(localRoots, name, catalogURL); err != nil {
+			fail(2, "%v", err)
+		}
+	}
+	var local *catalog
+	if localPath != "" {
+		data, err := os.ReadFile(localPath)
+		if err != nil {
+			fail(2, "%v", err)
+		}
+		if local, err = parseCatalog(filepath.Base(localPath), data); err != nil {
+			fail(2, "%v", err)
+		}
+		fmt.Printf(tr("  Local:   %s\n"), localPath)
+	}
+
+	fmt.Println()
+	catalogProblems := 0
+	switch {
+	case liveBinary != nil:
+		if local == nil {
+			fail(3, "only %s is on the CDN and no local build has %s to read the bundle list from; point -local at the YooAsset build output of version %s", binName, name, yooVersion)
+		}
+		localBinary, err := os.ReadFile(filepath.Join(filepath.Dir(localPath), binName))
+		if err != nil {
+			fail(3, "%v", err)
+		}
+		if bytes.Equal(liveBinary, localBinary) {
+			fmt.Printf(tr("[OK] %s on the CDN matches the local build\n"), binName)
+			recordAction("catalog", binName, "ok", "", 0)
+		} else {
+			catalogProblems++
+			fmt.Printf(tr("[FAIL] %s on the CDN differs from the local build\n"), binName)
+			recordAction("catalog", binName, "failed", "differs from the local build", 0)
+			recordError("%s on the CDN differs from the local build", binName)
+		}
+		live = local
+	case local != nil:
+		if bytes.Equal(live.raw, local.raw) {
+			fmt.Printf(tr("[OK] %s on the CDN matches the local build\n"), name)
+			recordAction("catalog", name, "ok", "", 0)
+			break
+		}
+		catalogProblems++
+		added, removed, changed := diffCatalogs(local, live)
+		fmt.Printf(tr("[FAIL] %s on the CDN differs from the local build\n"), name)
+		if live.version != local.version {
+			fmt.Printf(tr("  Version on the CDN: %s, local: %s\n"), live.version, local.version)
+		}
+		printList(tr("Only on the CDN"), added)
+		printList(tr("Only in the local build"), removed)
+		printList(tr("Changed"), changed)
+		detail := fmt.Sprintf("%d added, %d removed, %d changed", len(added), len(removed), len(changed))
+		recordAction("catalog", name, "failed", detail, 0)
+		recordError("%s on the CDN differs from the local build: %s", name, detail)
+	case localFlag != "":
+		catalogProblems++
+		fmt.Printf(tr("[FAIL] %s is not in the local build %s; the CDN serves another build\n"), name, localFlag)
+		recordAction("catalog", name, "failed", "not in the local build", 0)
+		recordError("%s is not in the local build %s", name, localFlag)
+	default:
+		fmt.Printf(tr("[WARNING] No local build has %s; bundles are checked against the catalog only.\n"), name)
+		recordAction("catalog", name, "skipped", "no local build", 0)
+	}
+
+	localDir := ""
+	if localPath != "" {
+		localDir = filepath.Dir(localPath)
+	}
+	var byName map[string]string
+	checks := make([]*bundleCheck, len(live.entries))
+	for i, e := range live.entries {
+		c := &bundleCheck{entry: e, url: resolveURL(catalogURL, live.entryPath(e)), expected: e.size}
+		if localDir != "" {
+			p := filepath.Join(localDir, filepath.FromSlash(live.entryPath(e)))
+			if strings.Contains(e.location, "://") {
+				// Absolute Addressables URLs: the bundle file names are unique
+				if byName == nil {
+					byName = localFiles(localDir)
+				}
+				u, _ := url.Parse(e.location)
+				p = byName[path.Base(u.Path)]
+			}
+			if info, err := os.Stat(p); p != "" && err == nil && !info.IsDir() {
+				c.local = p
+				if c.expected < 0 {
+					c.expected = info.Size()
+				}
+			}
+		}
+		checks[i] = c
+	}
+
+	// Addressables catalogs have no sizes; they come from the local build
+	var totalSize int64
+	for _, c := range checks {
+		if c.expected > 0 {
+			totalSize += c.expected
+		}
+	}
+	fmt.Printf(tr("  %s catalog, version %s: %d bundle(s), %s\n"), live.format, live.version, len(live.entries), formatSize(totalSize))
+	if len(live.entries) == 0 {
+		fmt.Println(tr("\n[--] The catalog lists no bundles."))
+		if catalogProblems > 0 {
+			exit(1)
+		}
+		exit(0)
+	}
+
+	mode := fmt.Sprintf(tr("first and last %s"), formatSize(rangeBytes))
+	if full {
+		mode = tr("full download")
+	}
+	fmt.Printf(tr("\nChecking %d bundle(s) on the CDN (%s, %d parallel)...\n"), len(checks), mode, jobs)
+	start := time.Now()
+	parallel(len(checks), jobs, func(i int) {
+		verifyBundle(f, checks[i], rangeBytes, full)
+	}, func(done int) {
+		printProgressBar(done, len(checks))
+	})
+
+	counts := printProblems(checks)
+	for _, c := range checks {
+		status := "ok"
+		if c.status != statusOK {
+			status = "failed"
+			recordError("%s: %s %s", c.entry.location, c.status, c.detail)
+		}
+		recordAction("verify", c.url, status, strings.TrimSpace(c.status+" "+c.detail), c.duration)
+	}
+
+	fmt.Println()
+	printRule("=============================================")
+	fmt.Println(tr("  SUMMARY"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Bundles:   %d (%s)\n"), len(checks), formatSize(totalSize))
+	fmt.Printf(tr("  OK:        %d\n"), counts[statusOK])
+	fmt.Printf(tr("  Missing:   %d\n"), counts[statusMissing])
+	fmt.Printf(tr("  Partial:   %d\n"), counts[statusPartial])
+	fmt.Printf(tr("  Corrupt:   %d\n"), counts[statusCorrupt])
+	if counts[statusError] > 0 {
+		fmt.Printf(tr("  Unchecked: %d\n"), counts[statusError])
+	}
+	fmt.Printf(tr("  Took:      %v\n"), time.Since(start).Round(time.Millisecond))
+
+	switch {
+	case catalogProblems > 0 || counts[statusMissing]+counts[statusPartial]+counts[statusCorrupt] > 0:
+		fmt.Println(tr("\n[FAIL] The content on the CDN is incomplete or differs from the build."))
+		fmt.Println(tr("[TIP] Upload the listed files again (bundles before the catalog), then purge them from the CDN cache."))
+		exit(1)
+	case counts[statusError] > 0:
+		fmt.Printf(tr("\n[WARNING] %d bundle(s) could not be checked; run again or raise -timeout / -retries.\n"), counts[statusError])
+		exit(3)
+	}
+	fmt.Println(tr("\n[OK] Every bundle is on the CDN and matches the catalog."))
+	exit(0)
+}
@@ -0,0 +1,1093 @@
+// Unity Content Channels — Point dev, beta and release at hot-update content versions, promote and roll back.
+// A content root holds one folder per hot-update content version, as the build
+// pipeline writes them (Build/HotUpdateBundle/<Target>/<Package>/<Version>) or as
+// they sit on the CDN origin. channels.json in that root says which version each
+// channel serves; the game reads it to pick the version it downloads. Setting,
+// promoting and rolling back a channel only rewrites that small index, so a bad
+// release is undone in one command without uploading anything again. Every change
+// is appended to channels-audit.jsonl with who made it, when and why.
+//
+// Build: go build unity_content_channels.go
+//
+// Usage: run anywhere; -root (or UNITYSTARTER_CONTENT_ROOT) is the content root.
+//
+//	unity_content_channels -root Build/HotUpdateBundle/Android/DefaultPackage             # versions and channels
+//	unity_content_channels set dev 2026-10-14-1830 -root ...
+//	unity_content_channels promote dev -reason "QA passed"                                # dev's version to beta
+//	unity_content_channels promote beta release -ci
+//	unity_content_channels rollback release -reason "crash on login"                    # back to the previous version
+//	unity_content_channels rollback release 2026-10-01-0900
+//	unity_content_channels history release
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	envContentRoot = "UNITYSTARTER_CONTENT_ROOT"
+
+	indexFileName = "channels.json"
+	auditFileName = "channels-audit.jsonl"
+	indexSchema   = 1
+
+	// Versions a channel remembers for rollback
+	maxPrevious = 20
+)
+
+// Channels created with a new index, in promotion order
+var defaultChannelOrder = []string{"dev", "beta", "release"}
+
+var (
+	channelNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+	// Catalog files that make a folder a content version: YooAsset package
+	// manifests, Addressables catalogs, and content trust manifests
+	catalogFileRegex = regexp.MustCompile(`^(PackageManifest_.+\.(version|json|bytes)|catalog_.*\.(json|bin|hash)|.*[Tt]rust.*\.json)$`)
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// channelIndex is channels.json
+type channelIndex struct {
+	SchemaVersion int                 `json:"schemaVersion"`
+	Order         []string            `json:"order"` // promotion order
+	Channels      map[string]*channel `json:"channels"`
+}
+
+// channel is one pointer in channels.json
+type channel struct {
+	Version   string   `json:"version"`
+	Previous  []string `json:"previous,omitempty"` // versions it served before, newest first
+	UpdatedAt string   `json:"updatedAt"`
+	UpdatedBy string   `json:"updatedBy"`
+}
+
+// auditEntry is one line of channels-audit.jsonl
+type auditEntry struct {
+	Time    string `json:"time"`
+	User    string `json:"user"`
+	Host    string `json:"host"`
+	Action  string `json:"action"` // "set", "promote", "rollback"
+	Channel string `json:"channel"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// contentVersion is one version folder in the content root
+type contentVersion struct {
+	name    string
+	catalog string // first catalog file found, "" when there is none
+	size    int64
+	files   int
+	modTime time.Time
+}
+
+// channelChange is what a command does to one channel
+type channelChange struct {
+	action  string
+	channel string
+	from    string
+	to      string
+}
+
+// ============================================================
+// Content Versions
+// ============================================================
+
+// scanVersions lists the version folders of the content root, newest first. A
+// folder without a catalog file (YooAsset's OutputCache, a half-copied upload) is
+// listed too, with catalog "", so set and promote can refuse it.
+func scanVersions(root string) ([]*contentVersion, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var versions []*contentVersion
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		v := &contentVersion{name: e.Name()}
+		dir := filepath.Join(root, e.Name())
+		files, _ := os.ReadDir(dir)
+		for _, f := range files {
+			if !f.IsDir() && catalogFileRegex.MatchString(f.Name()) {
+				v.catalog = f.Name()
+				break
+			}
+		}
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			v.files++
+			v.size += info.Size()
+			if info.ModTime().After(v.modTime) {
+				v.modTime = info.ModTime()
+			}
+			return nil
+		})
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		if !versions[i].modTime.Equal(versions[j].modTime) {
+			return versions[i].modTime.After(versions[j].modTime)
+		}
+		return versions[i].name > versions[j].name
+	})
+	return versions, nil
+}
+
+func findVersion(versions []*contentVersion, name string) *contentVersion {
+	for _, v := range versions {
+		if v.name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// checkVersion makes sure a channel can point at name; force accepts a folder
+// without a catalog file
+func checkVersion(versions []*contentVersion, name string, force bool) error {
+	v := findVersion(versions, name)
+	if v == nil {
+		return fmt.Errorf("version '%s' is not a folder in the content root", name)
+	}
+	if v.catalog == "" && !force {
+		return fmt.Errorf("version '%s' has no catalog file (PackageManifest_*, catalog_*, content trust manifest); pass -force if it is complete", name)
+	}
+	return nil
+}
+
+// ============================================================
+// Channel Index
+// ============================================================
+
+// loadIndex reads channels.json; a content root without one gets an empty index
+// with the default channel order
+func loadIndex(root string) (*channelIndex, error) {
+	idx := &channelIndex{SchemaVersion: indexSchema, Channels: map[string]*channel{}}
+	data, err := os.ReadFile(filepath.Join(root, indexFileName))
+	if os.IsNotExist(err) {
+		idx.Order = append([]string(nil), defaultChannelOrder...)
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("%s: %v", indexFileName, err)
+	}
+	if idx.SchemaVersion > indexSchema {
+		return nil, fmt.Errorf("%s has schema version %d; this tool reads up to %d, update it", indexFileName, idx.SchemaVersion, indexSchema)
+	}
+	if idx.Channels == nil {
+		idx.Channels = map[string]*channel{}
+	}
+	for name := range idx.Channels {
+		if !containsString(idx.Order, name) {
+			idx.Order = append(idx.Order, name)
+		}
+	}
+	return idx, nil
+}
+
+// saveIndex replaces channels.json through a temporary file, so a client or an
+// upload never reads half of it
+func saveIndex(root string, idx *channelIndex) error {
+	idx.SchemaVersion = indexSchema
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(root, indexFileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// nextChannel is the channel after name in the promotion order
+func nextChannel(idx *channelIndex, name string) (string, error) {
+	for i, c := range idx.Order {
+		if c == name {
+			if i+1 == len(idx.Order) {
+				return "", fmt.Errorf("'%s' is the last channel in the order (%s); name the target channel", name, strings.Join(idx.Order, " → "))
+			}
+			return idx.Order[i+1], nil
+		}
+	}
+	return "", fmt.Errorf("unknown channel '%s' (channels: %s)", name, strings.Join(idx.Order, ", "))
+}
+
+// setChannel points name at version and remembers the version it served before
+func setChannel(idx *channelIndex, name, version, by string) {
+	ch := idx.Channels[name]
+	if ch == nil {
+		ch = &channel{}
+		idx.Channels[name] = ch
+		if !containsString(idx.Order, name) {
+			idx.Order = append(idx.Order, name)
+		}
+	}
+	previous := []string{}
+	if ch.Version != "" {
+		previous = append(previous, ch.Version)
+	}
+	for _, p := range ch.Previous {
+		if p != version && p != ch.Version {
+			previous = append(previous, p)
+		}
+	}
+	if len(previous) > maxPrevious {
+		previous = previous[:maxPrevious]
+	}
+	ch.Version, ch.Previous = version, previous
+	ch.UpdatedAt, ch.UpdatedBy = time.Now().UTC().Format(time.RFC3339), by
+}
+
+// rollbackChannel points name back at target, or at the version it served last
+// when target is "". The version rolled back from is not remembered, so a second
+// rollback goes further back instead of returning to it.
+func rollbackChannel(idx *channelIndex, name, target, by string) (string, error) {
+	ch := idx.Channels[name]
+	if ch == nil || ch.Version == "" {
+		return "", fmt.Errorf("channel '%s' does not point at a version yet", name)
+	}
+	if len(ch.Previous) == 0 {
+		return "", fmt.Errorf("channel '%s' has no earlier version to roll back to", name)
+	}
+	i := 0
+	if target != "" {
+		for i = 0; i < len(ch.Previous) && ch.Previous[i] != target; i++ {
+		}
+		if i == len(ch.Previous) {
+			return "", fmt.Errorf("channel '%s' never served '%s' (earlier versions: %s); use set to point it there", name, target, strings.Join(ch.Previous, ", "))
+		}
+	}
+	to := ch.Previous[i]
+	ch.Version, ch.Previous = to, ch.Previous[i+1:]
+	ch.UpdatedAt, ch.UpdatedBy = time.Now().UTC().Format(time.RFC3339), by
+	return to, nil
+}
+
+// channelsAt lists the channels that point at version
+func channelsAt(idx *channelIndex, version string) []string {
+	var names []string
+	for _, name := range idx.Order {
+		if ch := idx.Channels[name]; ch != nil && ch.Version == version {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ============================================================
+// Audit Log
+// ============================================================
+
+// currentUser names who runs the tool: the OS account, or USER / USERNAME
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	for _, v := range []string{"USER", "USERNAME"} {
+		if s := os.Getenv(v); s != "" {
+			return s
+		}
+	}
+	return "unknown"
+}
+
+func appendAudit(root string, entries []auditEntry) error {
+	f, err := os.OpenFile(filepath.Join(root, auditFileName), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		data, _ := json.Marshal(e)
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// readAudit returns the audit log, oldest first; lines it cannot parse are skipped
+func readAudit(root string) ([]auditEntry, error) {
+	f, err := os.Open(filepath.Join(root, auditFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []auditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var e auditEntry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil && e.Action != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+func printChannels(idx *channelIndex, versions []*contentVersion) {
+	fmt.Println(tr("\nChannels:"))
+	for _, name := range idx.Order {
+		ch := idx.Channels[name]
+		if ch == nil || ch.Version == "" {
+			fmt.Printf(tr("  %-10s (not set)\n"), name)
+			recordAction("channel", name, "skipped", "not set", 0)
+			continue
+		}
+		fmt.Printf(tr("  %-10s %-24s updated %s by %s\n"), name, ch.Version, formatTime(ch.UpdatedAt), ch.UpdatedBy)
+		if len(ch.Previous) > 0 {
+			fmt.Printf(tr("  %-10s rollback to: %s\n"), "", strings.Join(ch.Previous, ", "))
+		}
+		if findVersion(versions, ch.Version) == nil {
+			fmt.Printf(tr("  [WARNING] %s points at %s, which is not in the content root\n"), name, ch.Version)
+			recordAction("channel", name, "failed", ch.Version+" is not in the content root", 0)
+			recordError("%s points at %s, which is not in the content root", name, ch.Version)
+			continue
+		}
+		recordAction("channel", name, "ok", ch.Version, 0)
+	}
+}
+
+func printVersions(idx *channelIndex, versions []*contentVersion) {
+	fmt.Println(tr("\nVersions, newest first:"))
+	if len(versions) == 0 {
+		fmt.Println(tr("  (none)"))
+		return
+	}
+	for _, v := range versions {
+		served := strings.Join(channelsAt(idx, v.name), ", ")
+		if v.catalog == "" {
+			if served != "" {
+				served += "; "
+			}
+			served += tr("no catalog file")
+		}
+		modified := "-               "
+		if !v.modTime.IsZero() {
+			modified = v.modTime.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Printf(tr("  %-24s %10s %6d files  %s  %s\n"), v.name, formatSize(v.size), v.files, modified, served)
+		recordAction("version", v.name, "ok", fmt.Sprintf("%s, %d files", formatSize(v.size), v.files), 0)
+	}
+}
+
+func printHistory(entries []auditEntry, channelFilter string, limit int) {
+	var shown []auditEntry
+	for _, e := range entries {
+		if channelFilter == "" || e.Channel == channelFilter {
+			shown = append(shown, e)
+		}
+	}
+	if limit > 0 && len(shown) > limit {
+		fmt.Printf(tr("  (%d older entries not shown; -limit 0 shows all)\n"), len(shown)-limit)
+		shown = shown[len(shown)-limit:]
+	}
+	if len(shown) == 0 {
+		fmt.Println(tr("  (no changes recorded)"))
+		return
+	}
+	for _, e := range shown {
+		from := e.From
+		if from == "" {
+			from = "-"
+		}
+		line := fmt.Sprintf("  %s  %-8s %-10s %s → %s  %s@%s", formatTime(e.Time), e.Action, e.Channel, from, e.To, e.User, e.Host)
+		if e.Reason != "" {
+			line += "  \"" + e.Reason + "\""
+		}
+		fmt.Println(line)
+		recordAction(e.Action, e.Channel, "ok", fmt.Sprintf("%s → %s", from, e.To), 0)
+	}
+}
+
+// formatTime shows an RFC 3339 time from the index or the audit log in local time
+func formatTime(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return s
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode   bool
+		dryRun   bool
+		force    bool
+		rootFlag string
+		reason   string
+		limit    int
+	)
+
+	flag.StringVar(&rootFlag, "root", "", "Content root with one folder per content version (default: UNITYSTARTER_CONTENT_ROOT)")
+	flag.StringVar(&reason, "reason", "", "Why the channel changes; written to the audit log")
+	flag.IntVar(&limit, "limit", 20, "history: newest entries to show (0 = all)")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no confirmation, no key press at the end)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Show the change without writing channels.json")
+	flag.BoolVar(&force, "force", false, "Accept a version folder without a catalog file, and run even if '.unitystarter.lock' says another tool is working on the content root")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the command ("promote dev -ci")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_content_channels")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	command := "list"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	valid := false
+	switch command {
+	case "list":
+		valid = len(args) <= 1
+	case "history":
+		valid = len(args) <= 2
+	case "set":
+		valid = len(args) == 3
+	case "promote", "rollback":
+		valid = len(args) == 2 || len(args) == 3
+	}
+	if !valid {
+		fmt.Println(tr("Usage: unity_content_channels [flags] [list | set <channel> <version> | promote <from> [to] | rollback <channel> [version] | history [channel]]"))
+		recordError("unknown command")
+		exit(2)
+	}
+	for i := 1; i < len(args); i++ {
+		if name := args[i]; !channelNameRegex.MatchString(name) {
+			fail(2, "'%s' is not a valid channel or version name", name)
+		}
+	}
+
+	root := rootFlag
+	if root == "" {
+		root = os.Getenv(envContentRoot)
+	}
+	if root == "" {
+		fail(2, "no content root; pass -root or set %s", envContentRoot)
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		fail(1, "%v", err)
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		fail(1, "content root %s is not a folder", root)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  CONTENT CHANNELS"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Content root: %s\n"), root)
+
+	if command == "list" || command == "history" {
+		idx, err := loadIndex(root)
+		if err != nil {
+			fail(1, "%v", err)
+		}
+		if command == "history" {
+			filter := ""
+			if len(args) == 2 {
+				filter = args[1]
+			}
+			entries, err := readAudit(root)
+			if err != nil {
+				fail(1, "%s: %v", auditFileName, err)
+			}
+			fmt.Println(tr("\nChanges, oldest first:"))
+			printHistory(entries, filter, limit)
+			exit(0)
+		}
+		versions, err := scanVersions(root)
+		if err != nil {
+			fail(1, "%v", err)
+		}
+		printChannels(idx, versions)
+		printVersions(idx, versions)
+		if len(jsonDoc.Errors) > 0 {
+			exit(1)
+		}
+		exit(0)
+	}
+
+	// Changing a channel: hold the lock from reading the index to writing it, so
+	// two promotions at once cannot lose one of them
+	if !dryRun {
+		if err := acquireProjectLock(root, "unity_content_channels", command, force); err != nil {
+			fail(1, "%v", err)
+		}
+		defer releaseProjectLock()
+	}
+	idx, err := loadIndex(root)
+	if err != nil {
+		fail(1, "%v", err)
+	}
+	versions, err := scanVersions(root)
+	if err != nil {
+		fail(1, "%v", err)
+	}
+
+	by := currentUser()
+	var change channelChange
+	switch command {
+	case "set":
+		name, version := args[1], args[2]
+		if err := checkVersion(versions, version, force); err != nil {
+			fail(1, "%v", err)
+		}
+		change = channelChange{action: "set", channel: name, to: version}
+		if ch := idx.Channels[name]; ch != nil {
+			change.from = ch.Version
+		}
+		if change.from != version {
+			setChannel(idx, name, version, by)
+		}
+
+	case "promote":
+		from := args[1]
+		src := idx.Channels[from]
+		if src == nil || src.Version == "" {
+			fail(1, "channel '%s' does not point at a version yet", from)
+		}
+		to := ""
+		if len(args) == 3 {
+			to = args[2]
+		} else if to, err = nextChannel(idx, from); err != nil {
+			fail(2, "%v", err)
+		}
+		if to == from {
+			fail(2, "cannot promote '%s' to itself", from)
+		}
+		if err := checkVersion(versions, src.Version, force); err != nil {
+			fail(1, "%v", err)
+		}
+		change = channelChange{action: "promote", channel: to, to: src.Version}
+		if ch := idx.Channels[to]; ch != nil {
+			change.from = ch.Version
+		}
+		if change.from != change.to {
+			setChannel(idx, to, src.Version, by)
+		}
+
+	case "rollback":
+		name, target := args[1], ""
+		if len(args) == 3 {
+			target = args[2]
+		}
+		change = channelChange{action: "rollback", channel: name}
+		if ch := idx.Channels[name]; ch != nil {
+			change.from = ch.Version
+		}
+		if change.to, err = rollbackChannel(idx, name, target, by); err != nil {
+			fail(1, "%v", err)
+		}
+		// The version rolled back to may be gone from the content root already
+		if err := checkVersion(versions, change.to, force); err != nil {
+			fail(1, "%v", err)
+		}
+	}
+
+	from := change.from
+	if from == "" {
+		from = tr("(not set)")
+	}
+	fmt.Printf(tr("\n  %s: %s → %s\n"), change.channel, from, change.to)
+	if change.from == change.to {
+		fmt.Printf(tr("\n[OK] %s already points at %s.\n"), change.channel, change.to)
+		recordAction(change.action, change.channel, "skipped", "already "+change.to, 0)
+		exit(0)
+	}
+	detail := fmt.Sprintf("%s → %s", change.from, change.to)
+	if dryRun {
+		recordAction(change.action, change.channel, "planned", detail, 0)
+		fmt.Println(tr("\n[Dry Run] channels.json was not changed."))
+		exit(0)
+	}
+	if !ciMode {
+		fmt.Printf(tr("\nPoint %s at %s? (y/N): "), change.channel, change.to)
+		confirm, _ := stdinReader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+			fmt.Println(tr("Operation cancelled."))
+			exit(0)
+		}
+	}
+
+	if err := saveIndex(root, idx); err != nil {
+		recordAction(change.action, change.channel, "failed", err.Error(), 0)
+		fail(1, "cannot write %s: %v", indexFileName, err)
+	}
+	recordArtifact(filepath.Join(root, indexFileName))
+	host, _ := os.Hostname()
+	entry := auditEntry{
+		Time: time.Now().UTC().Format(time.RFC3339), User: by, Host: host, Action: change.action,
+		Channel: change.channel, From: change.from, To: change.to, Reason: reason,
+	}
+	if err := appendAudit(root, []auditEntry{entry}); err != nil {
+		// The channel has changed; only the record of it is missing
+		fmt.Printf(tr("[WARNING] Cannot write %s: %v\n"), auditFileName, err)
+		recordError("cannot write %s: %v", auditFileName, err)
+	}
+	recordAction(change.action, change.channel, "ok", detail, 0)
+	fmt.Printf(tr("\n[OK] %s now serves %s.\n"), change.channel, change.to)
+	fmt.Println(tr("[TIP] Upload channels.json to the CDN, then purge its cache with unity_cdn_purge; the version folders do not change."))
+	exit(0)
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...": "\n按回车键继续...",
+
+	"[WARNING] Removing a stale %s (%s)\n":                      "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n": "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+	"\nChannels:":                      "\n渠道:",
+	"  %-10s (not set)\n":              "  %-10s （未设置）\n",
+	"  %-10s %-24s updated %s by %s\n": "  %-10s %-24s 更新于 %s，操作人 %s\n",
+	"  %-10s rollback to: %s\n":        "  %-10s 可回滚到: %s\n",
+	"  [WARNING] %s points at %s, which is not in the content root\n": "  [WARNING] %s 指向 %s，但内容根目录中没有该版本\n",
+	"\nVersions, newest first:":                                       "\n版本（最新在前）:",
+	"  (none)":                                                        "  （无）",
+	"no catalog file":                                                 "没有清单文件",
+	"  %-24s %10s %6d files  %s  %s\n":                                "  %-24s %10s %6d 个文件  %s  %s\n",
+	"  (%d older entries not shown; -limit 0 shows all)\n":            "  （另有 %d 条更早的记录未显示；-limit 0 显示全部）\n",
+	"  (no changes recorded)":                                         "  （没有变更记录）",
+	"[ERROR] %v\n":                                                    "[ERROR] %v\n",
+	"Usage: unity_content_channels [flags] [list | set <channel> <version> | promote <from> [to] | rollback <channel> [version] | history [channel]]": "用法: unity_content_channels [参数] [list | set <渠道> <版本> | promote <源渠道> [目标渠道] | rollback <渠道> [版本] | history [渠道]]",
+	"  CONTENT CHANNELS":                         "  内容渠道",
+	"  Content root: %s\n":                       "  内容根目录: %s\n",
+	"\nChanges, oldest first:":                   "\n变更记录（最早在前）:",
+	"(not set)":                                  "（未设置）",
+	"\n  %s: %s → %s\n":                          "\n  %s: %s → %s\n",
+	"\n[OK] %s already points at %s.\n":          "\n[OK] %s 已经指向 %s。\n",
+	"\n[Dry Run] channels.json was not changed.": "\n[Dry Run] 未修改 channels.json。",
+	"\nPoint %s at %s? (y/N): ":                  "\n将 %s 指向 %s？(y/N): ",
+	"Operation cancelled.":                       "操作已取消。",
+	"[WARNING] Cannot write %s: %v\n":            "[WARNING] 无法写入 %s: %v\n",
+	"\n[OK] %s now serves %s.\n":                 "\n[OK] %s 现在提供 %s。\n",
+	"[TIP] Upload channels.json to the CDN, then purge its cache with unity_cdn_purge; the version folders do not change.": "[TIP] 请将 channels.json 上传到 CDN，再用 unity_cdn_purge 刷新其缓存；版本文件夹无需改动。",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
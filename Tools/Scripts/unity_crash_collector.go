@@ -0,0 +1,1134 @@
+// Unity Crash Collector — Zip crash dumps, editor logs and system info for a bug report.
+// Gathers what Unity support and the engine team ask for after an editor crash:
+// Editor.log and Editor-prev.log (with the other logs next to them, such as upm.log),
+// the crash folders Unity's crash handler writes (crash.dmp, error.log...), macOS
+// diagnostic reports, the project's Logs/ folder, ProjectVersion.txt and the
+// package manifest, plus a system.txt with OS, CPU, memory, GPU and the installed
+// editors. Everything goes into one timestamped zip, so every report a team
+// attaches has the same layout. -scrub replaces the user folder and machine name
+// in all text files before they are packed.
+//
+// Build: go build unity_crash_collector.go
+//
+// Usage: run from the Unity project root, or from any folder for the editor files only.
+//
+//	unity_crash_collector                          # UnityCrash_<project>_<time>.zip here
+//	unity_crash_collector -scrub                   # replace user paths and the machine name
+//	unity_crash_collector -since 2d -no-dumps      # recent crashes only, without .dmp files
+//	unity_crash_collector -include "%LOCALAPPDATA%Low/MyCompany/MyGame" ../report.zip
+//	unity_crash_collector -dry-run                 # list what would be collected
+
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Name of the manifest entry at the root of every report
+const reportManifestName = "unitystarter-crash.json"
+
+// Bump when the manifest layout changes incompatibly
+const reportFormatVersion = 1
+
+// Each system info command gets this long; a hanging WMI query must not hold
+// up the report
+const systemCommandTimeout = 30 * time.Second
+
+// Files scrubbed line by line with -scrub. Anything else (crash.dmp, images)
+// is binary and packed as it is.
+var textExtensions = map[string]bool{
+	".log": true, ".txt": true, ".json": true, ".xml": true, ".yaml": true, ".yml": true,
+	".ini": true, ".crash": true, ".ips": true, ".diag": true, ".csv": true,
+}
+
+// Project files that identify the editor and package set of the crash
+var projectFiles = []string{
+	"ProjectSettings/ProjectVersion.txt",
+	"Packages/manifest.json",
+	"Packages/packages-lock.json",
+}
+
+var projectVersionRegex = regexp.MustCompile(`(?m)^m_EditorVersion:\s*(\S+)`)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// reportManifest is stored in the zip, so scripts triaging many reports can
+// read it without unpacking the logs
+type reportManifest struct {
+	Format       int           `json:"format"`
+	CreatedAt    string        `json:"createdAt"`
+	Project      string        `json:"project,omitempty"`
+	UnityVersion string        `json:"unityVersion,omitempty"`
+	OS           string        `json:"os"`
+	Scrubbed     bool          `json:"scrubbed"`
+	Since        string        `json:"since,omitempty"`
+	Files        []reportEntry `json:"files"`
+}
+
+type reportEntry struct {
+	Path     string `json:"path"`
+	Source   string `json:"source"`
+	Size     int64  `json:"size"`
+	Modified string `json:"modified"`
+	Scrubbed bool   `json:"scrubbed,omitempty"`
+}
+
+// collectedFile is a file picked for the report
+type collectedFile struct {
+	abs    string
+	entry  string // slash-separated path inside the zip
+	source string // "editor", "crash", "diagnostic", "project", "extra"
+	info   os.FileInfo
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks for the Assets and ProjectSettings folders
+func isUnityProject(basePath string) bool {
+	for _, dir := range []string{"Assets", "ProjectSettings"} {
+		if info, err := os.Stat(filepath.Join(basePath, dir)); err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// readUnityVersion returns the editor version from ProjectVersion.txt, or "" when unknown
+func readUnityVersion(basePath string) string {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectVersion.txt"))
+	if err != nil {
+		return ""
+	}
+	if m := projectVersionRegex.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// ============================================================
+// Artifact Locations
+// ============================================================
+
+// editorLogFolder returns the folder Unity writes Editor.log to for the current OS
+func editorLogFolder() string {
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		if local := os.Getenv("LOCALAPPDATA"); local != "" {
+			return filepath.Join(local, "Unity", "Editor")
+		}
+		return filepath.Join(home, "AppData", "Local", "Unity", "Editor")
+	case "darwin":
+		return filepath.Join(home, "Library", "Logs", "Unity")
+	default:
+		return filepath.Join(home, ".config", "unity3d")
+	}
+}
+
+// crashFolderRoots returns the folders the editor's crash handler writes one
+// Crash_<date> folder per crash into
+func crashFolderRoots() []string {
+	roots := []string{filepath.Join(os.TempDir(), "Unity", "Editor", "Crashes")}
+	if runtime.GOOS == "windows" {
+		// TEMP may point elsewhere for the shell the tool runs in (CI agents, sudo)
+		if local := os.Getenv("LOCALAPPDATA"); local != "" {
+			roots = append(roots, filepath.Join(local, "Temp", "Unity", "Editor", "Crashes"))
+		}
+	}
+	return uniquePaths(roots)
+}
+
+// diagnosticReportFolder is where macOS keeps the .crash/.ips reports of
+// crashed applications; only the Unity ones are collected
+func diagnosticReportFolder() string {
+	if runtime.GOOS != "darwin" {
+		return ""
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "Library", "Logs", "DiagnosticReports")
+}
+
+// hubEditorFolders returns the folders Unity Hub installs editors into: the
+// default location plus the custom one from secondaryInstallPath.json
+func hubEditorFolders() []string {
+	home, _ := os.UserHomeDir()
+	var folders []string
+	var hubConfig string
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramW6432"} {
+			if pf := os.Getenv(env); pf != "" {
+				folders = append(folders, filepath.Join(pf, "Unity", "Hub", "Editor"))
+			}
+		}
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			hubConfig = filepath.Join(appData, "UnityHub")
+		}
+	case "darwin":
+		folders = append(folders, "/Applications/Unity/Hub/Editor")
+		hubConfig = filepath.Join(home, "Library", "Application Support", "UnityHub")
+	default:
+		folders = append(folders, filepath.Join(home, "Unity", "Hub", "Editor"))
+		hubConfig = filepath.Join(home, ".config", "UnityHub")
+	}
+	if hubConfig != "" {
+		// The file holds a single JSON string; empty when no custom location is set
+		if data, err := os.ReadFile(filepath.Join(hubConfig, "secondaryInstallPath.json")); err == nil {
+			var custom string
+			if json.Unmarshal(data, &custom) == nil && custom != "" {
+				folders = append(folders, custom)
+			}
+		}
+	}
+	return uniquePaths(folders)
+}
+
+// installedEditors lists editor versions found in the Hub folders; each
+// version folder holds Editor/Unity.exe, Unity.app or Editor/Unity
+func installedEditors() map[string]string {
+	editors := make(map[string]string)
+	for _, folder := range hubEditorFolders() {
+		entries, err := os.ReadDir(folder)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			dir := filepath.Join(folder, e.Name())
+			for _, exe := range []string{filepath.Join("Editor", "Unity.exe"), "Unity.app", filepath.Join("Editor", "Unity")} {
+				if _, err := os.Stat(filepath.Join(dir, exe)); err == nil {
+					if _, seen := editors[e.Name()]; !seen {
+						editors[e.Name()] = dir
+					}
+					break
+				}
+			}
+		}
+	}
+	return editors
+}
+
+// ============================================================
+// Collection
+// ============================================================
+
+// collectEditorLogs picks every .log next to Editor.log: Editor-prev.log holds
+// the session that crashed once the editor was restarted, upm.log the package
+// manager, and on macOS the licensing client logs live there as well
+func collectEditorLogs() ([]collectedFile, error) {
+	folder := editorLogFolder()
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, err
+	}
+	var files []collectedFile
+	for _, e := range entries {
+		if e.IsDir() || !strings.EqualFold(filepath.Ext(e.Name()), ".log") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, collectedFile{
+			abs: filepath.Join(folder, e.Name()), entry: "Editor/" + e.Name(), source: "editor", info: info,
+		})
+	}
+	return files, nil
+}
+
+// collectCrashFolders picks the crash folders modified after since (zero: all)
+func collectCrashFolders(since time.Time) []collectedFile {
+	var files []collectedFile
+	for _, root := range crashFolderRoots() {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			info, err := e.Info()
+			if err != nil || (!since.IsZero() && info.ModTime().Before(since)) {
+				continue
+			}
+			path := filepath.Join(root, e.Name())
+			if !e.IsDir() {
+				files = append(files, collectedFile{abs: path, entry: "Crashes/" + e.Name(), source: "crash", info: info})
+				continue
+			}
+			files = append(files, collectTree(path, "Crashes/"+e.Name(), "crash")...)
+		}
+	}
+	return files
+}
+
+// collectDiagnosticReports picks the macOS reports of Unity processes
+// (Unity, Unity Hub, UnityShaderCompiler...) modified after since
+func collectDiagnosticReports(since time.Time) []collectedFile {
+	folder := diagnosticReportFolder()
+	if folder == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil
+	}
+	var files []collectedFile
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(strings.ToLower(e.Name()), "unity") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || (!since.IsZero() && info.ModTime().Before(since)) {
+			continue
+		}
+		files = append(files, collectedFile{
+			abs: filepath.Join(folder, e.Name()), entry: "DiagnosticReports/" + e.Name(), source: "diagnostic", info: info,
+		})
+	}
+	return files
+}
+
+// collectProject picks the project's Logs/ folder (asset import workers,
+// shader compiler, package manager) and the files that pin editor and packages
+func collectProject(basePath string) []collectedFile {
+	files := collectTree(filepath.Join(basePath, "Logs"), "Project/Logs", "project")
+	for _, rel := range projectFiles {
+		path := filepath.Join(basePath, filepath.FromSlash(rel))
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files = append(files, collectedFile{abs: path, entry: "Project/" + rel, source: "project", info: info})
+		}
+	}
+	return files
+}
+
+// collectExtra picks the -include files and folders, e.g. a player's log folder.
+// Each lands in Extra/ under its own name.
+func collectExtra(list string) ([]collectedFile, []string) {
+	var files []collectedFile
+	var missing []string
+	names := make(map[string]int)
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(os.ExpandEnv(item))
+		if item == "" {
+			continue
+		}
+		info, err := os.Stat(item)
+		if err != nil {
+			missing = append(missing, item)
+			continue
+		}
+		// Two folders both named "Logs" must not end up in the same entry
+		name := filepath.Base(filepath.Clean(item))
+		names[name]++
+		if names[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, names[name])
+		}
+		if info.IsDir() {
+			files = append(files, collectTree(item, "Extra/"+name, "extra")...)
+		} else {
+			files = append(files, collectedFile{abs: item, entry: "Extra/" + name, source: "extra", info: info})
+		}
+	}
+	return files, missing
+}
+
+// collectTree picks every regular file below root; unreadable entries are skipped
+func collectTree(root, prefix, source string) []collectedFile {
+	var files []collectedFile
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		files = append(files, collectedFile{abs: path, entry: prefix + "/" + filepath.ToSlash(rel), source: source, info: info})
+		return nil
+	})
+	return files
+}
+
+// ============================================================
+// System Info
+// ============================================================
+
+// systemCommands are run for system.txt; any that fail or are missing are
+// noted in the file instead
+func systemCommands() [][]string {
+	switch runtime.GOOS {
+	case "windows":
+		ps := func(query string) []string {
+			return []string{"powershell", "-NoProfile", "-NonInteractive", "-Command", query}
+		}
+		return [][]string{
+			ps("Get-CimInstance Win32_OperatingSystem | Format-List Caption,Version,OSArchitecture,TotalVisibleMemorySize,FreePhysicalMemory"),
+			ps("Get-CimInstance Win32_Processor | Format-List Name,NumberOfCores,NumberOfLogicalProcessors"),
+			ps("Get-CimInstance Win32_VideoController | Format-List Name,DriverVersion,DriverDate,AdapterRAM"),
+			ps("Get-CimInstance Win32_LogicalDisk -Filter DriveType=3 | Format-List DeviceID,Size,FreeSpace"),
+		}
+	case "darwin":
+		return [][]string{
+			{"sw_vers"},
+			{"sysctl", "-n", "machdep.cpu.brand_string", "hw.ncpu", "hw.memsize"},
+			{"system_profiler", "SPDisplaysDataType"},
+			{"df", "-h", "/"},
+		}
+	default:
+		return [][]string{
+			{"uname", "-a"},
+			{"cat", "/etc/os-release"},
+			{"lscpu"},
+			{"free", "-m"},
+			{"sh", "-c", "lspci | grep -Ei 'vga|3d|display'"},
+			{"df", "-h", "/"},
+		}
+	}
+}
+
+// buildSystemInfo writes the text of system.txt
+func buildSystemInfo(basePath, project string) string {
+	var b strings.Builder
+	host, _ := os.Hostname()
+	fmt.Fprintf(&b, "Created:   %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Host:      %s\n", host)
+	fmt.Fprintf(&b, "OS:        %s/%s, %d CPU(s)\n", runtime.GOOS, runtime.GOARCH, runtime.NumCPU())
+	if project != "" {
+		fmt.Fprintf(&b, "Project:   %s\n", project)
+		fmt.Fprintf(&b, "Unity:     %s\n", readUnityVersion(basePath))
+	}
+
+	editors := installedEditors()
+	versions := make([]string, 0, len(editors))
+	for v := range editors {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	b.WriteString("\nInstalled editors (Unity Hub):\n")
+	if len(versions) == 0 {
+		b.WriteString("  (none found)\n")
+	}
+	for _, v := range versions {
+		fmt.Fprintf(&b, "  %-16s %s\n", v, editors[v])
+	}
+
+	for _, args := range systemCommands() {
+		fmt.Fprintf(&b, "\n$ %s\n", strings.Join(args, " "))
+		ctx, cancel := context.WithTimeout(context.Background(), systemCommandTimeout)
+		out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+		cancel()
+		text := strings.TrimSpace(strings.ReplaceAll(string(out), "\r\n", "\n"))
+		if text != "" {
+			b.WriteString(text + "\n")
+		}
+		if err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				err = fmt.Errorf("timed out after %s", systemCommandTimeout)
+			}
+			fmt.Fprintf(&b, "(not available: %v)\n", err)
+		}
+	}
+	return b.String()
+}
+
+// ============================================================
+// Scrubbing
+// ============================================================
+
+// scrubber replaces the user folder, other paths with the user name in them
+// and the machine name. Paths appear with both separators and, in JSON, with
+// escaped backslashes, so each is matched in every form.
+type scrubber struct {
+	rules []scrubRule
+}
+
+type scrubRule struct {
+	re   *regexp.Regexp
+	with string
+}
+
+func newScrubber() *scrubber {
+	s := &scrubber{}
+	home, _ := os.UserHomeDir()
+	if home != "" && filepath.Dir(home) != home {
+		forms := []string{filepath.ToSlash(home)}
+		if runtime.GOOS == "windows" {
+			forms = append(forms, strings.ReplaceAll(forms[0], "/", `\\`), home)
+		}
+		for _, form := range forms {
+			s.add(`(?i)`+regexp.QuoteMeta(form)+`\b`, "<home>")
+		}
+		// The same user under another drive or mount point (D:\Users\alice, /Volumes/x/Users/alice)
+		if user := filepath.Base(home); len(user) > 1 {
+			s.add(`(?i)((?:Users|home)(?:\\\\|\\|/))`+regexp.QuoteMeta(user)+`\b`, "${1}<user>")
+		}
+	}
+	if host, _ := os.Hostname(); len(host) > 2 {
+		s.add(`(?i)\b`+regexp.QuoteMeta(host)+`\b`, "<host>")
+	}
+	return s
+}
+
+func (s *scrubber) add(pattern, with string) {
+	if re, err := regexp.Compile(pattern); err == nil {
+		s.rules = append(s.rules, scrubRule{re: re, with: with})
+	}
+}
+
+func (s *scrubber) scrub(text string) string {
+	for _, r := range s.rules {
+		text = r.re.ReplaceAllString(text, r.with)
+	}
+	return text
+}
+
+// copyScrubbed streams src to w line by line, scrubbing each line
+func (s *scrubber) copyScrubbed(w io.Writer, src io.Reader) (int64, error) {
+	r := bufio.NewReaderSize(src, 64*1024)
+	var n int64
+	for {
+		line, err := r.ReadString('\n')
+		if line != "" {
+			m, werr := io.WriteString(w, s.scrub(line))
+			n += int64(m)
+			if werr != nil {
+				return n, werr
+			}
+		}
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// isTextEntry tells whether -scrub can rewrite the file
+func isTextEntry(name string) bool {
+	return textExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// ============================================================
+// Report Writing
+// ============================================================
+
+// writeReport packs the files, system.txt and the manifest into outPath. The
+// zip is written to a temp file next to it and renamed at the end, so an
+// interrupted run never leaves a truncated report with the final name.
+// Unity may still be writing Editor.log; the part written so far is packed.
+func writeReport(outPath string, files []collectedFile, systemInfo string, manifest *reportManifest, scrub *scrubber) error {
+	tmp, err := os.CreateTemp(filepath.Dir(outPath), "."+filepath.Base(outPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	fail := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	zw := zip.NewWriter(tmp)
+	for i, f := range files {
+		header, err := zip.FileInfoHeader(f.info)
+		if err != nil {
+			return fail(err)
+		}
+		header.Name = f.entry
+		header.Method = zip.Deflate
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return fail(err)
+		}
+		src, err := os.Open(f.abs)
+		if err != nil {
+			return fail(fmt.Errorf("cannot read %s: %v", f.abs, err))
+		}
+		scrubbed := scrub != nil && isTextEntry(f.entry)
+		var n int64
+		if scrubbed {
+			n, err = scrub.copyScrubbed(w, src)
+		} else {
+			n, err = io.Copy(w, src)
+		}
+		src.Close()
+		if err != nil {
+			return fail(fmt.Errorf("cannot read %s: %v", f.abs, err))
+		}
+		manifest.Files = append(manifest.Files, reportEntry{
+			Path: f.entry, Source: f.source, Size: n, Modified: f.info.ModTime().Format(time.RFC3339), Scrubbed: scrubbed,
+		})
+		printProgressBar(i+1, len(files))
+	}
+
+	if scrub != nil {
+		systemInfo = scrub.scrub(systemInfo)
+	}
+	data, _ := json.MarshalIndent(manifest, "", "  ")
+	for _, extra := range []struct {
+		name string
+		data []byte
+	}{
+		{"system.txt", []byte(systemInfo)},
+		{reportManifestName, append(data, '\n')},
+	} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: extra.name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return fail(err)
+		}
+		if _, err := w.Write(extra.data); err != nil {
+			return fail(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, outPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// parseAge accepts Go durations plus a "d" (days) suffix, e.g. "14d", "36h"
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age: %s", s)
+		}
+		return time.Duration(days * 24 * float64(time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+// uniquePaths drops repeated folders, compared case-insensitively on Windows
+func uniquePaths(paths []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, p := range paths {
+		key := filepath.Clean(p)
+		if runtime.GOOS == "windows" {
+			key = strings.ToLower(key)
+		}
+		if !seen[key] {
+			seen[key] = true
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+func printProgressBar(current, total int) {
+	percent := float64(current) / float64(total)
+	if plainMode || jsonMode {
+		// One line per 10% step instead of redrawing the bar with \r
+		step := int(percent * 10)
+		if current == total || step > int(float64(current-1)/float64(total)*10) {
+			fmt.Printf(tr("Progress: %d/%d (%.0f%%)\n"), current, total, percent*100)
+		}
+		return
+	}
+	barLength := 40
+	filledLength := int(float64(barLength) * percent)
+
+	bar := strings.Repeat("█", filledLength) + strings.Repeat("-", barLength-filledLength)
+	fmt.Printf("\r[%s] %.0f%% (%d/%d)", bar, percent*100, current, total)
+	if current == total {
+		fmt.Println()
+	}
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"Usage: unity_crash_collector [flags] [report.zip]": "用法: unity_crash_collector [参数] [报告.zip]",
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                   "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Invalid -since value: %v\n":                           "[ERROR] 无效的 -since 值: %v\n",
+	"[--] No Editor.log folder: %s\n":                              "[--] 没有 Editor.log 目录: %s\n",
+	"[WARNING] Not found: %s\n":                                    "[WARNING] 未找到: %s\n",
+	"[TIP] Not a Unity project; collecting the editor files only.": "[TIP] 当前不是 Unity 项目；只收集编辑器文件。",
+	"  CRASH REPORT":                                               "  崩溃报告",
+	"  Project:  %s\n":                                             "  项目:     %s\n",
+	"  Unity:    %s\n":                                             "  Unity:    %s\n",
+	"  Crashes:  since %s\n":                                       "  崩溃:     %s 之后\n",
+	"  Crashes:  all\n":                                            "  崩溃:     全部\n",
+	"  Output:   %s\n":                                             "  输出:     %s\n",
+	"\n  %d file(s), %s, plus system.txt\n":                        "\n  %d 个文件，%s，另加 system.txt\n",
+	"  [--] No crash folders found; if the editor crashed earlier, try -since 0.":                                                         "  [--] 未找到崩溃目录；如果编辑器崩溃时间更早，请尝试 -since 0。",
+	"[WARNING] -scrub cannot rewrite %d binary file(s) (crash dumps); they may still hold user paths. Use -no-dumps to leave them out.\n": "[WARNING] -scrub 无法改写 %d 个二进制文件（崩溃转储），其中仍可能包含用户路径。使用 -no-dumps 可将其排除。\n",
+	"\n[Dry Run] No report was written.":                                                  "\n[Dry Run] 未写入报告。",
+	"\n%s exists. Overwrite? (y/N): ":                                                     "\n%s 已存在，是否覆盖？(y/N): ",
+	"Operation cancelled.":                                                                "操作已取消。",
+	"[ERROR] Cannot create %s: %v\n":                                                      "[ERROR] 无法创建 %s: %v\n",
+	"Collecting system info...":                                                           "正在收集系统信息...",
+	"\n[ERROR] Cannot write report: %v\n":                                                 "\n[ERROR] 无法写入报告: %v\n",
+	"\n[OK] Collected %d file(s) into %s (%s, %.1fs)\n":                                   "\n[OK] 已将 %d 个文件收集到 %s (%s，%.1f 秒)\n",
+	"[TIP] User paths and the machine name were replaced in %d text file(s).\n":           "[TIP] 已在 %d 个文本文件中替换用户路径和计算机名。\n",
+	"[TIP] Attach the zip to the bug report; logs hold user paths unless -scrub is used.": "[TIP] 请将该 zip 附加到缺陷报告；未使用 -scrub 时日志中包含用户路径。",
+	"Progress: %d/%d (%.0f%%)\n":                                                          "进度: %d/%d (%.0f%%)\n",
+
+	"\nPress Enter to continue...": "\n按回车键继续...",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode, dryRun, scrubFlag, noDumps, noProject bool
+	var sinceFlag, includeFlag string
+
+	flag.StringVar(&sinceFlag, "since", "7d", "Only crash folders and diagnostic reports newer than this (e.g. 36h, 2d; 0 for all)")
+	flag.BoolVar(&scrubFlag, "scrub", false, "Replace the user folder and machine name in all text files")
+	flag.BoolVar(&noDumps, "no-dumps", false, "Leave out crash dumps (.dmp), which are large and cannot be scrubbed")
+	flag.BoolVar(&noProject, "no-project", false, "Leave out the project's Logs/ folder, ProjectVersion.txt and package manifest")
+	flag.StringVar(&includeFlag, "include", "", "Comma-separated extra files or folders to add (e.g. a player's log folder)")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no confirmation prompts)")
+	flag.BoolVar(&dryRun, "dry-run", false, "List what would be collected without writing anything")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the output path ("../report.zip -scrub")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_crash_collector")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+
+	if len(args) > 1 {
+		fmt.Println(tr("Usage: unity_crash_collector [flags] [report.zip]"))
+		recordError("expected at most one zip path")
+		exit(2)
+	}
+	age, err := parseAge(strings.TrimSpace(sinceFlag))
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Invalid -since value: %v\n"), err)
+		recordError("invalid -since value: %v", err)
+		exit(2)
+	}
+	var since time.Time
+	if age > 0 {
+		since = time.Now().Add(-age)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	project := ""
+	if !noProject {
+		if isUnityProject(basePath) {
+			project = filepath.Base(basePath)
+		} else {
+			fmt.Println(tr("[TIP] Not a Unity project; collecting the editor files only."))
+		}
+	}
+
+	name := project
+	if name == "" {
+		name = "Editor"
+	}
+	outPath := fmt.Sprintf("UnityCrash_%s_%s.zip", name, time.Now().Format("20060102-150405"))
+	if len(args) == 1 {
+		outPath = args[0]
+	}
+	if outPath, err = filepath.Abs(outPath); err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(2)
+	}
+
+	files, err := collectEditorLogs()
+	if err != nil {
+		fmt.Printf(tr("[--] No Editor.log folder: %s\n"), editorLogFolder())
+	}
+	crashes := collectCrashFolders(since)
+	files = append(files, crashes...)
+	files = append(files, collectDiagnosticReports(since)...)
+	if project != "" {
+		files = append(files, collectProject(basePath)...)
+	}
+	extra, missing := collectExtra(includeFlag)
+	for _, m := range missing {
+		fmt.Printf(tr("[WARNING] Not found: %s\n"), m)
+	}
+	files = append(files, extra...)
+
+	// Drop dumps if asked, and never pack a previous report sitting in a collected folder
+	kept := files[:0]
+	binaries := 0
+	for _, f := range files {
+		if noDumps && strings.EqualFold(filepath.Ext(f.entry), ".dmp") {
+			continue
+		}
+		if strings.EqualFold(f.abs, outPath) {
+			continue
+		}
+		if !isTextEntry(f.entry) {
+			binaries++
+		}
+		kept = append(kept, f)
+	}
+	files = kept
+
+	// Size per source, for the preview
+	var total int64
+	sizes := make(map[string]int64)
+	counts := make(map[string]int)
+	var sources []string
+	for _, f := range files {
+		if counts[f.source] == 0 {
+			sources = append(sources, f.source)
+		}
+		sizes[f.source] += f.info.Size()
+		counts[f.source]++
+		total += f.info.Size()
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  CRASH REPORT"))
+	printRule("=============================================")
+	if project != "" {
+		fmt.Printf(tr("  Project:  %s\n"), project)
+		fmt.Printf(tr("  Unity:    %s\n"), readUnityVersion(basePath))
+	}
+	if since.IsZero() {
+		fmt.Print(tr("  Crashes:  all\n"))
+	} else {
+		fmt.Printf(tr("  Crashes:  since %s\n"), since.Format("2006-01-02 15:04"))
+	}
+	fmt.Printf(tr("  Output:   %s\n"), outPath)
+	fmt.Println()
+	for _, source := range sources {
+		fmt.Printf("  %-28s %6d  %10s\n", source, counts[source], formatSize(sizes[source]))
+	}
+	fmt.Printf(tr("\n  %d file(s), %s, plus system.txt\n"), len(files), formatSize(total))
+	if len(crashes) == 0 {
+		fmt.Println(tr("  [--] No crash folders found; if the editor crashed earlier, try -since 0."))
+	}
+	if scrubFlag && binaries > 0 {
+		fmt.Printf(tr("[WARNING] -scrub cannot rewrite %d binary file(s) (crash dumps); they may still hold user paths. Use -no-dumps to leave them out.\n"), binaries)
+	}
+
+	if dryRun {
+		for _, f := range files {
+			recordAction("collect", f.abs, "planned", f.entry, 0)
+		}
+		fmt.Println(tr("\n[Dry Run] No report was written."))
+		exit(0)
+	}
+	if _, err := os.Stat(outPath); err == nil && !ciMode {
+		fmt.Printf(tr("\n%s exists. Overwrite? (y/N): "), filepath.Base(outPath))
+		confirm, _ := stdinReader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+			fmt.Println(tr("Operation cancelled."))
+			exit(0)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		fmt.Printf(tr("[ERROR] Cannot create %s: %v\n"), filepath.Dir(outPath), err)
+		recordError("cannot create %s: %v", filepath.Dir(outPath), err)
+		exit(1)
+	}
+
+	fmt.Println()
+	start := time.Now()
+	fmt.Println(tr("Collecting system info..."))
+	systemInfo := buildSystemInfo(basePath, project)
+
+	var scrub *scrubber
+	if scrubFlag {
+		scrub = newScrubber()
+	}
+	manifest := &reportManifest{
+		Format:    reportFormatVersion,
+		CreatedAt: time.Now().Format(time.RFC3339),
+		Project:   project,
+		OS:        runtime.GOOS + "/" + runtime.GOARCH,
+		Scrubbed:  scrubFlag,
+		Files:     []reportEntry{},
+	}
+	if project != "" {
+		manifest.UnityVersion = readUnityVersion(basePath)
+	}
+	if !since.IsZero() {
+		manifest.Since = since.Format(time.RFC3339)
+	}
+	if err := writeReport(outPath, files, systemInfo, manifest, scrub); err != nil {
+		fmt.Printf(tr("\n[ERROR] Cannot write report: %v\n"), err)
+		recordError("cannot write report: %v", err)
+		exit(1)
+	}
+	info, _ := os.Stat(outPath)
+	var size int64
+	if info != nil {
+		size = info.Size()
+	}
+	fmt.Printf(tr("\n[OK] Collected %d file(s) into %s (%s, %.1fs)\n"), len(manifest.Files), outPath, formatSize(size), time.Since(start).Seconds())
+	if scrubFlag {
+		scrubbed := 0
+		for _, e := range manifest.Files {
+			if e.Scrubbed {
+				scrubbed++
+			}
+		}
+		fmt.Printf(tr("[TIP] User paths and the machine name were replaced in %d text file(s).\n"), scrubbed)
+	} else {
+		fmt.Println(tr("[TIP] Attach the zip to the bug report; logs hold user paths unless -scrub is used."))
+	}
+	recordAction("collect", outPath, "ok", fmt.Sprintf("%d files, %s", len(manifest.Files), formatSize(size)), time.Since(start))
+	recordArtifact(outPath)
+	exit(0)
+}
@@ -0,0 +1,1047 @@
+// Unity Define Report — Match the #if symbols in C# against the defines of each platform.
+// Collects every symbol the project's scripts test with #if and #elif, [Conditional]
+// and asmdef define constraints, and every symbol something defines: the Scripting
+// Define Symbols of each platform in the Player Settings, build profiles, csc.rsp
+// files, asmdef version defines, BuildMatrix.json define sets and #define. Reports
+// symbols code tests that nothing defines, whose code paths never compile, and
+// defines no code tests any more. Symbols Unity and the compiler define themselves
+// (UNITY_*, ENABLE_*, DEVELOPMENT_BUILD, DEBUG...) are known and not reported.
+//
+// Build: go build unity_define_report.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_define_report                              # report for every platform
+//	unity_define_report -allow "CI_*,STEAM_BUILD"    # symbols set from outside the project
+//	unity_define_report -ci -annotate github
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	playerSettingsFile = "ProjectSettings/ProjectSettings.asset"
+	buildMatrixFile    = "BuildMatrix.json" // unity_build_matrix define sets, in the project root
+)
+
+// Uses listed per symbol before "...and N more"
+const maxUsesListed = 5
+
+// Symbols Unity, its packages and the C# compiler define; never reported
+var builtinSymbolRegex = regexp.MustCompile(`^(UNITY_\w+|ENABLE_\w+|PLATFORM_\w+|NET_\w+|NET\d\w*|NETSTANDARD\w*|NETFRAMEWORK|NETCOREAPP\w*|CSHARP_\d+_\d+_OR_NEWER|DEVELOPMENT_BUILD|DEBUG|TRACE)$`)
+
+// BuildTargetGroup IDs used by older Player Settings files
+var buildTargetGroups = map[string]string{
+	"1": "Standalone", "4": "iOS", "7": "Android", "13": "WebGL", "14": "WSA",
+	"19": "PS4", "21": "XboxOne", "25": "tvOS", "27": "Switch", "28": "Lumin",
+	"29": "Stadia", "30": "LinuxHeadlessSimulation", "31": "GameCoreXboxSeries",
+	"32": "GameCoreXboxOne", "33": "PS5", "34": "EmbeddedLinux", "35": "QNX", "36": "VisionOS",
+}
+
+var (
+	// #if FOO && !BAR / #elif ...
+	directiveIfRegex = regexp.MustCompile(`^\s*#\s*(?:if|elif)\b(.*)$`)
+	// #define FOO / #undef FOO
+	directiveDefineRegex = regexp.MustCompile(`^\s*#\s*(define|undef)\s+(\w+)`)
+	// [Conditional("FOO")], [System.Diagnostics.Conditional("FOO")]
+	conditionalRegex = regexp.MustCompile(`\[\s*(?:[\w.]*\.)?Conditional(?:Attribute)?\s*\(\s*"(\w+)"\s*\)`)
+	identRegex       = regexp.MustCompile(`[A-Za-z_]\w*`)
+	// -define:A;B, -d:A, /define:A
+	rspDefineRegex = regexp.MustCompile(`(?:^|\s)[-/](?:define|d):(\S+)`)
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// definition is one place that defines a symbol
+type definition struct {
+	source   string // "Player Settings", "csc.rsp", "asmdef version define", ...
+	file     string // project-relative
+	platform string // Player Settings and build profiles: the platform; else ""
+}
+
+// use is one place that tests a symbol
+type use struct {
+	file string // project-relative
+	line int    // 0 for asmdef define constraints
+	kind string // "#if", "[Conditional]", "defineConstraints"
+}
+
+// symbols is everything the scan found
+type symbols struct {
+	defined   map[string][]definition
+	used      map[string][]use
+	platforms []string                   // platforms with a define list in the Player Settings
+	local     map[string]map[string]bool // file -> symbols it #defines itself
+	files     int
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Defined Symbols
+// ============================================================
+
+// splitDefines splits a define list ("A;B", "A,B") into symbols
+func splitDefines(list string) []string {
+	var out []string
+	for _, part := range strings.FieldsFunc(list, func(r rune) bool { return r == ';' || r == ',' || r == ' ' }) {
+		if part = strings.TrimSpace(strings.Trim(part, `'"`)); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// readPlayerSettingsDefines reads scriptingDefineSymbols: one define list per
+// platform, keyed by name (2021+) or by BuildTargetGroup ID
+func readPlayerSettingsDefines(basePath string, s *symbols) error {
+	data, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(playerSettingsFile)))
+	if err != nil {
+		return err
+	}
+	inDefines, indent := false, 0
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		lineIndent := len(line) - len(strings.TrimLeft(line, " "))
+		if trimmed == "scriptingDefineSymbols:" {
+			inDefines, indent = true, lineIndent
+			continue
+		}
+		if !inDefines {
+			continue
+		}
+		if lineIndent <= indent || trimmed == "" {
+			break
+		}
+		colon := strings.Index(trimmed, ":")
+		if colon < 0 {
+			continue
+		}
+		platform, value := strings.TrimSpace(trimmed[:colon]), trimmed[colon+1:]
+		if name, ok := buildTargetGroups[platform]; ok {
+			platform = name
+		}
+		if !containsString(s.platforms, platform) {
+			s.platforms = append(s.platforms, platform)
+		}
+		for _, sym := range splitDefines(value) {
+			s.define(sym, definition{source: "Player Settings", file: playerSettingsFile, platform: platform})
+		}
+	}
+	return nil
+}
+
+// readProjectFiles walks Assets/ and the embedded packages for csc.rsp files,
+// asmdefs and build profiles
+func readProjectFiles(basePath string, roots []string, s *symbols) {
+	for _, root := range roots {
+		walkProject(basePath, root, func(p, rel string) {
+			name := strings.ToLower(filepath.Base(p))
+			switch {
+			case name == "csc.rsp" || name == "mcs.rsp":
+				data, err := os.ReadFile(p)
+				if err != nil {
+					return
+				}
+				for _, m := range rspDefineRegex.FindAllStringSubmatch(string(data), -1) {
+					for _, sym := range splitDefines(m[1]) {
+						s.define(sym, definition{source: filepath.Base(p), file: rel})
+					}
+				}
+			case strings.HasSuffix(name, ".asmdef"):
+				readAsmdef(p, rel, s)
+			case strings.HasSuffix(name, ".asset"):
+				readBuildProfile(p, rel, s)
+			}
+		})
+	}
+}
+
+// readAsmdef records versionDefines as definitions and defineConstraints as uses
+func readAsmdef(p, rel string, s *symbols) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return
+	}
+	var asmdef struct {
+		DefineConstraints []string `json:"defineConstraints"`
+		VersionDefines    []struct {
+			Name   string `json:"name"`
+			Define string `json:"define"`
+		} `json:"versionDefines"`
+	}
+	if json.Unmarshal(data, &asmdef) != nil {
+		return
+	}
+	for _, v := range asmdef.VersionDefines {
+		if v.Define != "" {
+			s.define(v.Define, definition{source: "version define (" + v.Name + ")", file: rel})
+		}
+	}
+	for _, c := range asmdef.DefineConstraints {
+		for _, sym := range identRegex.FindAllString(c, -1) {
+			s.use(sym, use{file: rel, kind: "defineConstraints"})
+		}
+	}
+}
+
+// readBuildProfile reads m_ScriptingDefines of a Unity 6 build profile
+func readBuildProfile(p, rel string, s *symbols) {
+	info, err := os.Stat(p)
+	if err != nil || info.Size() > 1<<20 {
+		return
+	}
+	data, err := os.ReadFile(p)
+	if err != nil || !strings.Contains(string(data), "m_ScriptingDefines:") {
+		return
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	profile := strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "m_ScriptingDefines:") {
+			continue
+		}
+		if rest := strings.TrimSpace(strings.TrimPrefix(trimmed, "m_ScriptingDefines:")); rest != "" && rest != "[]" {
+			for _, sym := range splitDefines(strings.Trim(rest, "[]")) {
+				s.define(sym, definition{source: "build profile", file: rel, platform: profile})
+			}
+			continue
+		}
+		for _, item := range lines[i+1:] {
+			item = strings.TrimSpace(item)
+			if !strings.HasPrefix(item, "- ") {
+				break
+			}
+			for _, sym := range splitDefines(strings.TrimPrefix(item, "- ")) {
+				s.define(sym, definition{source: "build profile", file: rel, platform: profile})
+			}
+		}
+	}
+}
+
+// readBuildMatrix reads the define sets of BuildMatrix.json
+func readBuildMatrix(basePath string, s *symbols) error {
+	data, err := os.ReadFile(filepath.Join(basePath, buildMatrixFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var m struct {
+		DefineSets []struct {
+			Name    string   `json:"name"`
+			Defines []string `json:"defines"`
+		} `json:"defineSets"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("invalid %s: %v", buildMatrixFile, err)
+	}
+	for _, set := range m.DefineSets {
+		for _, sym := range set.Defines {
+			s.define(sym, definition{source: "build matrix (" + set.Name + ")", file: buildMatrixFile})
+		}
+	}
+	return nil
+}
+
+func (s *symbols) define(sym string, d definition) {
+	s.defined[sym] = append(s.defined[sym], d)
+}
+
+func (s *symbols) use(sym string, u use) {
+	s.used[sym] = append(s.used[sym], u)
+}
+
+// ============================================================
+// Symbol Usage
+// ============================================================
+
+// scanRoots returns Assets/ and the embedded packages
+func scanRoots(basePath string) []string {
+	roots := []string{"Assets"}
+	entries, _ := os.ReadDir(filepath.Join(basePath, "Packages"))
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(basePath, "Packages", e.Name(), "package.json")); err == nil {
+			roots = append(roots, "Packages/"+e.Name())
+		}
+	}
+	return roots
+}
+
+// walkProject calls fn for every file under a project-relative root, skipping
+// the folders Unity hides ("." and "~")
+func walkProject(basePath, root string, fn func(p, rel string)) {
+	rootPath := filepath.Join(basePath, filepath.FromSlash(root))
+	filepath.Walk(rootPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if name := info.Name(); p != rootPath && (strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(basePath, p)
+		fn(p, filepath.ToSlash(rel))
+		return nil
+	})
+}
+
+// scanScripts reads the #if, #elif, #define and [Conditional] lines of every C#
+// file under the roots. Files under Library/PackageCache count as uses only, so a
+// define a package tests is not reported as dead.
+func scanScripts(basePath string, roots []string, s *symbols) {
+	var files []string
+	for _, root := range roots {
+		walkProject(basePath, root, func(p, rel string) {
+			if strings.EqualFold(filepath.Ext(p), ".cs") {
+				files = append(files, rel)
+			}
+		})
+	}
+	sort.Strings(files)
+	for i, rel := range files {
+		printProgressBar(i+1, len(files))
+		data, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(rel)))
+		if err != nil {
+			continue
+		}
+		s.files++
+		for n, line := range strings.Split(string(data), "\n") {
+			if !strings.Contains(line, "#") && !strings.Contains(line, "Conditional") {
+				continue
+			}
+			if m := directiveDefineRegex.FindStringSubmatch(line); m != nil {
+				if m[1] == "define" {
+					if s.local[rel] == nil {
+						s.local[rel] = make(map[string]bool)
+					}
+					s.local[rel][m[2]] = true
+				}
+				continue
+			}
+			if m := directiveIfRegex.FindStringSubmatch(line); m != nil {
+				expr := m[1]
+				if i := strings.Index(expr, "//"); i >= 0 {
+					expr = expr[:i]
+				}
+				for _, sym := range identRegex.FindAllString(expr, -1) {
+					if sym != "true" && sym != "false" {
+						s.use(sym, use{file: rel, line: n + 1, kind: "#if"})
+					}
+				}
+				continue
+			}
+			for _, m := range conditionalRegex.FindAllStringSubmatch(line, -1) {
+				s.use(m[1], use{file: rel, line: n + 1, kind: "[Conditional]"})
+			}
+		}
+	}
+}
+
+// isAllowed reports whether a symbol matches -allow ("CI_*" matches a prefix)
+func isAllowed(sym string, allow []string) bool {
+	for _, a := range allow {
+		if a == sym || (strings.HasSuffix(a, "*") && strings.HasPrefix(sym, strings.TrimSuffix(a, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+// finding is one reported symbol
+type finding struct {
+	symbol string
+	uses   []use
+	defs   []definition
+}
+
+// analyze splits the symbols into used-but-undefined, defined-but-unused, and the
+// project symbols that are both. Built-in symbols are never undefined, but one the
+// project defines itself and nothing tests is still dead.
+func analyze(s *symbols, ownRoots []string, allow []string) (undefined, dead, custom []finding) {
+	own := func(rel string) bool {
+		for _, r := range ownRoots {
+			if rel == r || strings.HasPrefix(rel, r+"/") {
+				return true
+			}
+		}
+		return rel == buildMatrixFile || rel == playerSettingsFile
+	}
+	for sym, uses := range s.used {
+		if len(s.defined[sym]) > 0 {
+			custom = append(custom, finding{symbol: sym, uses: uses, defs: s.defined[sym]})
+			continue
+		}
+		if builtinSymbolRegex.MatchString(sym) || isAllowed(sym, allow) {
+			continue
+		}
+		var missing []use
+		for _, u := range uses {
+			if own(u.file) && !s.local[u.file][sym] {
+				missing = append(missing, u)
+			}
+		}
+		if len(missing) > 0 {
+			undefined = append(undefined, finding{symbol: sym, uses: missing})
+		}
+	}
+	for sym, defs := range s.defined {
+		if len(s.used[sym]) == 0 && !isAllowed(sym, allow) {
+			dead = append(dead, finding{symbol: sym, defs: defs})
+		}
+	}
+	for _, list := range [][]finding{undefined, dead, custom} {
+		sort.Slice(list, func(i, j int) bool { return list[i].symbol < list[j].symbol })
+	}
+	return undefined, dead, custom
+}
+
+func useWhere(u use) string {
+	if u.line > 0 {
+		return fmt.Sprintf("%s:%d", u.file, u.line)
+	}
+	return u.file + " (" + u.kind + ")"
+}
+
+// describeDefs lists where a symbol is defined, platforms grouped per source
+func describeDefs(defs []definition) string {
+	var parts []string
+	platforms := make(map[string][]string)
+	var order []string
+	for _, d := range defs {
+		key := d.source
+		if d.source == "build profile" || d.source == "csc.rsp" || d.source == "mcs.rsp" || strings.HasPrefix(d.source, "version define") {
+			key = d.source + " " + d.file
+		}
+		if _, seen := platforms[key]; !seen {
+			order = append(order, key)
+		}
+		if d.platform != "" && !containsString(platforms[key], d.platform) {
+			platforms[key] = append(platforms[key], d.platform)
+		} else if platforms[key] == nil {
+			platforms[key] = []string{}
+		}
+	}
+	for _, key := range order {
+		if len(platforms[key]) > 0 {
+			parts = append(parts, key+" ("+strings.Join(platforms[key], ", ")+")")
+		} else {
+			parts = append(parts, key)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// missingPlatforms lists the Player Settings platforms a symbol defined there for
+// some platforms is not defined for
+func missingPlatforms(s *symbols, defs []definition) []string {
+	has := make(map[string]bool)
+	inPlayer := false
+	for _, d := range defs {
+		if d.source != "Player Settings" {
+			if d.platform == "" {
+				return nil // defined everywhere by an rsp, version define or #define
+			}
+			continue
+		}
+		inPlayer = true
+		has[d.platform] = true
+	}
+	if !inPlayer {
+		return nil
+	}
+	var missing []string
+	for _, p := range s.platforms {
+		if !has[p] {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
+
+// printReport prints the define lists and the findings
+func printReport(s *symbols, undefined, dead, custom []finding) {
+	if len(s.platforms) > 0 {
+		fmt.Println(tr("\nPLAYER SETTINGS DEFINES"))
+		for _, p := range s.platforms {
+			var list []string
+			for sym, defs := range s.defined {
+				for _, d := range defs {
+					if d.source == "Player Settings" && d.platform == p {
+						list = append(list, sym)
+						break
+					}
+				}
+			}
+			sort.Strings(list)
+			if len(list) == 0 {
+				fmt.Printf(tr("  %-12s (none)\n"), p)
+			} else {
+				fmt.Printf("  %-12s %s\n", p, strings.Join(list, ";"))
+			}
+		}
+	}
+
+	if len(custom) > 0 {
+		fmt.Println(tr("\nPROJECT SYMBOLS"))
+		for _, f := range custom {
+			fmt.Printf(tr("  %-28s %d use(s)  defined by %s\n"), f.symbol, len(f.uses), describeDefs(f.defs))
+			if missing := missingPlatforms(s, f.defs); len(missing) > 0 {
+				fmt.Printf(tr("  %-28s not defined for: %s\n"), "", strings.Join(missing, ", "))
+			}
+			recordAction("check", f.symbol, "ok", fmt.Sprintf("%d use(s), defined by %s", len(f.uses), describeDefs(f.defs)), 0)
+		}
+	}
+
+	if len(undefined) > 0 {
+		fmt.Println(tr("\nUSED BUT NEVER DEFINED (code that needs them never compiles)"))
+		for _, f := range undefined {
+			fmt.Printf(tr("  %-28s %d use(s)\n"), f.symbol, len(f.uses))
+			for i, u := range f.uses {
+				if i == maxUsesListed {
+					fmt.Printf(tr("      ...and %d more\n"), len(f.uses)-maxUsesListed)
+					break
+				}
+				fmt.Printf("      %s\n", useWhere(u))
+				annotate("warning", u.file, u.line, 0, "Undefined symbol", fmt.Sprintf("'%s' is not defined for any platform; code that needs it never compiles", f.symbol))
+			}
+			recordAction("check", f.symbol, "failed", fmt.Sprintf("used but never defined: %s", useWhere(f.uses[0])), 0)
+		}
+	}
+
+	if len(dead) > 0 {
+		fmt.Println(tr("\nDEFINED BUT NEVER USED"))
+		for _, f := range dead {
+			where := describeDefs(f.defs)
+			fmt.Printf("  %-28s %s\n", f.symbol, where)
+			annotate("warning", f.defs[0].file, 0, 0, "Unused define", fmt.Sprintf("'%s' is defined by %s but no code tests it", f.symbol, where))
+			recordAction("check", f.symbol, "failed", "defined but never used: "+where, 0)
+		}
+	}
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+func printProgressBar(current, total int) {
+	percent := float64(current) / float64(total)
+	if plainMode || jsonMode {
+		// One line per 10% step instead of redrawing the bar with \r
+		step := int(percent * 10)
+		if current == total || step > int(float64(current-1)/float64(total)*10) {
+			fmt.Printf(tr("Progress: %d/%d (%.0f%%)\n"), current, total, percent*100)
+		}
+		return
+	}
+	barLength := 40
+	filledLength := int(float64(barLength) * percent)
+
+	bar := strings.Repeat("█", filledLength) + strings.Repeat("-", barLength-filledLength)
+	fmt.Printf("\r[%s] %.0f%% (%d/%d)", bar, percent*100, current, total)
+	if current == total {
+		fmt.Println()
+	}
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...":                                     "\n按回车键继续...",
+	"Progress: %d/%d (%.0f%%)\n":                                       "进度: %d/%d (%.0f%%)\n",
+	"[ERROR] %v\n":                                                     "[错误] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                       "[错误] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[错误] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  UNITY DEFINE REPORT":                                            "  Unity 宏定义报告",
+	"  Project:   %s\n":                                                "  项目:     %s\n",
+	"  Platforms: %d with a define list\n":                             "  平台:     %d 个平台设置了宏定义列表\n",
+	"  Allowed:   %s\n":                                                "  已允许:   %s\n",
+	"Scanning scripts...":                                              "正在扫描脚本...",
+	"\nPLAYER SETTINGS DEFINES":                                        "\nPlayer Settings 宏定义",
+	"  %-12s (none)\n":                                                 "  %-12s (无)\n",
+	"\nPROJECT SYMBOLS":                                                "\n项目符号",
+	"  %-28s %d use(s)  defined by %s\n":                               "  %-28s %d 处使用  定义于 %s\n",
+	"  %-28s not defined for: %s\n":                                    "  %-28s 未定义于: %s\n",
+	"\nUSED BUT NEVER DEFINED (code that needs them never compiles)":   "\n已使用但从未定义（依赖它们的代码永远不会被编译）",
+	"  %-28s %d use(s)\n":                                              "  %-28s %d 处使用\n",
+	"      ...and %d more\n":                                           "      ...以及另外 %d 处\n",
+	"\nDEFINED BUT NEVER USED":                                         "\n已定义但从未使用",
+	"  SUMMARY":                                                        "  摘要",
+	"  Scripts:         %d\n":                                          "  脚本:           %d\n",
+	"  Project symbols: %d\n":                                          "  项目符号:       %d\n",
+	"  Never defined:   %d\n":                                          "  从未定义:       %d\n",
+	"  Never used:      %d\n":                                          "  从未使用:       %d\n",
+	"\n[TIP] Define a symbol for the platforms that need it in Player Settings > Scripting Define Symbols, remove dead branches and defines, or list symbols set from outside the project with -allow.": "\n[提示] 请在 Player Settings > Scripting Define Symbols 中为需要的平台定义符号，删除无用的分支和宏定义，或使用 -allow 列出由项目外部设置的符号。",
+	"\n[OK] Every tested symbol is defined somewhere and every define is used.": "\n[成功] 所有被检测的符号都有定义，所有宏定义都被使用。",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// CI Annotations
+// ============================================================
+
+// With -annotate, errors and warnings are printed once more as GitHub Actions
+// workflow commands or TeamCity service messages, so the CI lists them on the run
+// page and, when they point at a file, next to that file in the diff.
+var (
+	annotateMode  string              // "", "github" or "teamcity"
+	annotateTool  string              // TeamCity inspection category
+	annotateBase  string              // project root; relative files start here
+	annotateRoot  string              // repository root; CI file paths are relative to it
+	annotateTypes = map[string]bool{} // TeamCity inspection types already declared
+)
+
+// setAnnotateMode checks the -annotate value; "auto" picks the CI the tool runs in
+func setAnnotateMode(mode, basePath, tool string) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "none":
+		annotateMode = ""
+	case "github", "teamcity":
+		annotateMode = strings.ToLower(strings.TrimSpace(mode))
+	case "auto":
+		annotateMode = ""
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			annotateMode = "github"
+		} else if os.Getenv("TEAMCITY_VERSION") != "" {
+			annotateMode = "teamcity"
+		}
+	default:
+		return fmt.Errorf("unknown -annotate mode '%s' (use github, teamcity or auto)", mode)
+	}
+	annotateTool = tool
+	annotateBase, _ = filepath.Abs(basePath)
+	annotateRoot = repositoryRoot(annotateBase)
+	return nil
+}
+
+// repositoryRoot is GITHUB_WORKSPACE, else the nearest folder with a .git entry
+// (a folder, or a file in worktrees and submodules), else the project itself
+func repositoryRoot(basePath string) string {
+	if ws := os.Getenv("GITHUB_WORKSPACE"); ws != "" && annotateMode == "github" {
+		return ws
+	}
+	for dir := basePath; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return basePath
+		}
+	}
+}
+
+// annotate prints one annotation. level is "error" or "warning"; file is absolute
+// or relative to the project root, and may be empty like line and col.
+func annotate(level, file string, line, col int, title, message string) {
+	if annotateMode == "" {
+		return
+	}
+	if file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(annotateBase, filepath.FromSlash(file))
+		}
+		if rel, err := filepath.Rel(annotateRoot, file); err == nil {
+			file = rel
+		}
+		file = filepath.ToSlash(file)
+	}
+
+	if annotateMode == "github" {
+		// Property values also escape ':' and ','; the message only '%' and line breaks
+		data := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+		prop := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+		var props []string
+		if file != "" {
+			props = append(props, "file="+prop.Replace(file))
+			if line > 0 {
+				props = append(props, "line="+strconv.Itoa(line))
+			}
+			if col > 0 {
+				props = append(props, "col="+strconv.Itoa(col))
+			}
+		}
+		if title != "" {
+			props = append(props, "title="+prop.Replace(title))
+		}
+		fmt.Printf("::%s %s::%s\n", level, strings.Join(props, ","), data.Replace(message))
+		return
+	}
+
+	esc := strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]").Replace
+	status := "WARNING"
+	if level == "error" {
+		status = "ERROR"
+	}
+	if file == "" {
+		text := message
+		if title != "" {
+			text = title + ": " + message
+		}
+		fmt.Printf("##teamcity[message text='%s' status='%s']\n", esc(text), status)
+		return
+	}
+	typeID := annotateTool + "." + strings.ReplaceAll(strings.ToLower(title), " ", "-")
+	if !annotateTypes[typeID] {
+		annotateTypes[typeID] = true
+		fmt.Printf("##teamcity[inspectionType id='%s' name='%s' category='%s' description='%s']\n", esc(typeID), esc(title), esc(annotateTool), esc(title))
+	}
+	lineAttr := ""
+	if line > 0 {
+		lineAttr = fmt.Sprintf(" line='%d'", line)
+	}
+	fmt.Printf("##teamcity[inspection typeId='%s' message='%s' file='%s'%s SEVERITY='%s']\n", esc(typeID), esc(message), esc(file), lineAttr, status)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode     bool
+		allowFlag  string
+		annotateFl string
+	)
+
+	flag.StringVar(&allowFlag, "allow", "", "Comma-separated symbols never reported, e.g. symbols CI passes to the build (\"CI_*\" matches a prefix)")
+	flag.StringVar(&annotateFl, "annotate", "", "Also print undefined and unused symbols as CI annotations: github, teamcity, auto")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_define_report")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if err := setAnnotateMode(annotateFl, basePath, "unity_define_report"); err != nil {
+		fail(2, "%v", err)
+	}
+	allow := splitDefines(allowFlag)
+
+	s := &symbols{defined: make(map[string][]definition), used: make(map[string][]use), local: make(map[string]map[string]bool)}
+	if err := readPlayerSettingsDefines(basePath, s); err != nil {
+		fail(1, "cannot read %s: %v", playerSettingsFile, err)
+	}
+	if err := readBuildMatrix(basePath, s); err != nil {
+		fail(1, "%v", err)
+	}
+	roots := scanRoots(basePath)
+	readProjectFiles(basePath, roots, s)
+
+	printRule("=============================================")
+	fmt.Println(tr("  UNITY DEFINE REPORT"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:   %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Platforms: %d with a define list\n"), len(s.platforms))
+	if len(allow) > 0 {
+		fmt.Printf(tr("  Allowed:   %s\n"), strings.Join(allow, ", "))
+	}
+	fmt.Println()
+
+	fmt.Println(tr("Scanning scripts..."))
+	scanRoots := roots
+	if _, err := os.Stat(filepath.Join(basePath, "Library", "PackageCache")); err == nil {
+		scanRoots = append(append([]string{}, roots...), "Library/PackageCache")
+	}
+	scanScripts(basePath, scanRoots, s)
+	if !plainMode {
+		fmt.Println()
+	}
+
+	undefined, dead, custom := analyze(s, roots, allow)
+	printReport(s, undefined, dead, custom)
+
+	fmt.Println()
+	printRule("=============================================")
+	fmt.Println(tr("  SUMMARY"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Scripts:         %d\n"), s.files)
+	fmt.Printf(tr("  Project symbols: %d\n"), len(custom))
+	fmt.Printf(tr("  Never defined:   %d\n"), len(undefined))
+	fmt.Printf(tr("  Never used:      %d\n"), len(dead))
+
+	if len(undefined) > 0 || len(dead) > 0 {
+		fmt.Println(tr("\n[TIP] Define a symbol for the platforms that need it in Player Settings > Scripting Define Symbols, remove dead branches and defines, or list symbols set from outside the project with -allow."))
+		exit(1)
+	}
+	fmt.Println(tr("\n[OK] Every tested symbol is defined somewhere and every define is used."))
+	exit(0)
+}
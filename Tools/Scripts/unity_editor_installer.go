@@ -0,0 +1,1282 @@
+// Unity Editor Installer — Install the exact editor version and modules a project needs.
+// Drives the Unity Hub command line (headless) to install an editor with its
+// modules, or with -direct downloads the installers from Unity's release service,
+// verifies their checksums and runs them without the Hub. Before anything is
+// downloaded it lists what will be installed with the download and disk sizes, and
+// stops when the target drive does not have the room.
+//
+// Build: go build unity_editor_installer.go
+//
+// Usage: inside a Unity project the version defaults to ProjectVersion.txt.
+//
+//	unity_editor_installer install                              # the project's editor
+//	unity_editor_installer install 2022.3.10f1 -modules android,il2cpp
+//	unity_editor_installer install -modules ios,webgl -dry-run  # sizes and the Hub command only
+//	unity_editor_installer install -direct -download-dir D:/UnityInstallers
+//	unity_editor_installer list                                 # installed editors and modules
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	// Unity's public release service: versions, changesets, installer URLs and sizes
+	defaultReleaseAPI = "https://services.api.unity.com/unity/editor/release/v1/releases"
+
+	envReleaseAPI = "UNITYSTARTER_RELEASE_API" // a mirror of the release service
+	envHubPath    = "UNITYSTARTER_HUB"         // Unity Hub executable
+
+	// Kept free on top of the installed size: temp files of the installers
+	diskHeadroom = 2 << 30
+)
+
+var httpClient = &http.Client{Timeout: 2 * time.Hour}
+
+var (
+	projectVersionRegex = regexp.MustCompile(`(?m)^m_EditorVersion:\s*(\S+)`)
+	// m_EditorVersionWithRevision: 2022.3.10f1 (ff3792e53c62)
+	projectRevisionRegex = regexp.MustCompile(`(?m)^m_EditorVersionWithRevision:\s*\S+\s*\(([0-9a-f]+)\)`)
+	editorVersionRegex   = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)([abfpx])(\d+)$`)
+)
+
+// moduleAliases are short names for modules whose id depends on the host
+var moduleAliases = map[string]map[string]string{
+	"il2cpp": {"windows": "windows-il2cpp", "darwin": "mac-il2cpp", "linux": "linux-il2cpp"},
+	"mono":   {"windows": "windows-mono", "darwin": "mac-mono", "linux": "linux-mono"},
+}
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// releaseSize is a size in the release service, e.g. {"value": 3.2, "unit": "GIGABYTE"}
+type releaseSize struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+func (s releaseSize) bytes() int64 {
+	mult := map[string]float64{"KILOBYTE": 1 << 10, "MEGABYTE": 1 << 20, "GIGABYTE": 1 << 30}[strings.ToUpper(s.Unit)]
+	if mult == 0 {
+		mult = 1
+	}
+	return int64(s.Value * mult)
+}
+
+// releaseModule is one installable module; sub-modules are what the Hub calls
+// child modules (the Android SDK, NDK and JDK under "android")
+type releaseModule struct {
+	ID            string          `json:"id"`
+	Name          string          `json:"name"`
+	URL           string          `json:"url"`
+	Integrity     string          `json:"integrity"`
+	Type          string          `json:"type"`
+	DownloadSize  releaseSize     `json:"downloadSize"`
+	InstalledSize releaseSize     `json:"installedSize"`
+	Destination   string          `json:"destination"`
+	SubModules    []releaseModule `json:"subModules"`
+}
+
+// releaseDownload is the editor installer of one platform and architecture
+type releaseDownload struct {
+	URL           string          `json:"url"`
+	Integrity     string          `json:"integrity"`
+	Type          string          `json:"type"`
+	Platform      string          `json:"platform"`
+	Architecture  string          `json:"architecture"`
+	DownloadSize  releaseSize     `json:"downloadSize"`
+	InstalledSize releaseSize     `json:"installedSize"`
+	Modules       []releaseModule `json:"modules"`
+}
+
+type releaseInfo struct {
+	Version       string            `json:"version"`
+	ShortRevision string            `json:"shortRevision"`
+	Downloads     []releaseDownload `json:"downloads"`
+}
+
+// component is one line of the install plan: the editor or a module
+type component struct {
+	id        string
+	name      string
+	url       string
+	integrity string
+	download  int64
+	installed int64
+	dest      string // direct install: folder, relative to the editor folder
+}
+
+// hubModuleEntry is one entry of modules.json, which the Hub writes into each
+// editor folder
+type hubModuleEntry struct {
+	ID       string `json:"id"`
+	Selected bool   `json:"selected"`
+}
+
+// ============================================================
+// Unity Project
+// ============================================================
+
+// readProjectVersion returns the editor version and changeset of the project in
+// basePath; empty strings outside a project
+func readProjectVersion(basePath string) (string, string) {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectVersion.txt"))
+	if err != nil {
+		return "", ""
+	}
+	var version, revision string
+	if m := projectVersionRegex.FindSubmatch(data); m != nil {
+		version = string(m[1])
+	}
+	if m := projectRevisionRegex.FindSubmatch(data); m != nil {
+		revision = string(m[1])
+	}
+	return version, revision
+}
+
+// ============================================================
+// Release Service
+// ============================================================
+
+// fetchRelease looks the version up in the release service
+func fetchRelease(version string) (*releaseInfo, error) {
+	api := os.Getenv(envReleaseAPI)
+	if api == "" {
+		api = defaultReleaseAPI
+	}
+	req := api + "?limit=1&version=" + url.QueryEscape(version)
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", api, resp.Status)
+	}
+	var page struct {
+		Results []releaseInfo `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("cannot read the release service response: %v", err)
+	}
+	for i := range page.Results {
+		if page.Results[i].Version == version {
+			return &page.Results[i], nil
+		}
+	}
+	return nil, fmt.Errorf("Unity %s is not in the release service", version)
+}
+
+// hostDownload picks the editor installer for this machine
+func (r *releaseInfo) hostDownload() *releaseDownload {
+	platform := map[string]string{"windows": "WINDOWS", "darwin": "MAC_OS", "linux": "LINUX"}[runtime.GOOS]
+	arch := "X86_64"
+	if runtime.GOARCH == "arm64" {
+		arch = "ARM64"
+	}
+	var fallback *releaseDownload
+	for i := range r.Downloads {
+		d := &r.Downloads[i]
+		if d.Platform != platform {
+			continue
+		}
+		if d.Architecture == arch {
+			return d
+		}
+		fallback = d
+	}
+	return fallback
+}
+
+// resolveModules maps the -modules names to module ids: aliases become the host's
+// module, and unknown ids are reported when the release lists its modules
+func resolveModules(names []string, d *releaseDownload) ([]string, error) {
+	known := make(map[string]bool)
+	if d != nil {
+		var walk func([]releaseModule)
+		walk = func(mods []releaseModule) {
+			for _, m := range mods {
+				known[m.ID] = true
+				walk(m.SubModules)
+			}
+		}
+		walk(d.Modules)
+	}
+	var ids []string
+	for _, name := range names {
+		id := strings.ToLower(strings.TrimSpace(name))
+		if id == "" {
+			continue
+		}
+		if alias, ok := moduleAliases[id]; ok {
+			id = alias[runtime.GOOS]
+		}
+		if len(known) > 0 && !known[id] {
+			return nil, fmt.Errorf("unknown module '%s' for this editor", name)
+		}
+		if !containsString(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// planComponents lists the editor (unless installed) and the selected modules,
+// with their child modules when withChildren is set
+func planComponents(d *releaseDownload, withEditor bool, ids []string, withChildren bool) []component {
+	var plan []component
+	if withEditor {
+		plan = append(plan, component{id: "editor", name: "Unity Editor", url: d.URL, integrity: d.Integrity,
+			download: d.DownloadSize.bytes(), installed: d.InstalledSize.bytes()})
+	}
+	var add func(m releaseModule, children bool)
+	add = func(m releaseModule, children bool) {
+		for _, c := range plan {
+			if c.id == m.ID {
+				return
+			}
+		}
+		plan = append(plan, component{id: m.ID, name: m.Name, url: m.URL, integrity: m.Integrity,
+			download: m.DownloadSize.bytes(), installed: m.InstalledSize.bytes(), dest: m.Destination})
+		if children {
+			for _, sub := range m.SubModules {
+				add(sub, true)
+			}
+		}
+	}
+	var find func(mods []releaseModule, id string) (releaseModule, bool)
+	find = func(mods []releaseModule, id string) (releaseModule, bool) {
+		for _, m := range mods {
+			if m.ID == id {
+				return m, true
+			}
+			if sub, ok := find(m.SubModules, id); ok {
+				return sub, true
+			}
+		}
+		return releaseModule{}, false
+	}
+	for _, id := range ids {
+		if m, ok := find(d.Modules, id); ok {
+			add(m, withChildren)
+		}
+	}
+	return plan
+}
+
+// ============================================================
+// Installed Editors
+// ============================================================
+
+// hubEditorFolders returns the folders Unity Hub installs editors into: the
+// default location plus the custom one from secondaryInstallPath.json
+func hubEditorFolders() []string {
+	home, _ := os.UserHomeDir()
+	var folders []string
+	var hubConfig string
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramW6432"} {
+			if pf := os.Getenv(env); pf != "" {
+				folders = append(folders, filepath.Join(pf, "Unity", "Hub", "Editor"))
+			}
+		}
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			hubConfig = filepath.Join(appData, "UnityHub")
+		}
+	case "darwin":
+		folders = append(folders, "/Applications/Unity/Hub/Editor")
+		hubConfig = filepath.Join(home, "Library", "Application Support", "UnityHub")
+	default:
+		folders = append(folders, filepath.Join(home, "Unity", "Hub", "Editor"))
+		hubConfig = filepath.Join(home, ".config", "UnityHub")
+	}
+	if hubConfig != "" {
+		// The file holds a single JSON string; empty when no custom location is set
+		if data, err := os.ReadFile(filepath.Join(hubConfig, "secondaryInstallPath.json")); err == nil {
+			var custom string
+			if json.Unmarshal(data, &custom) == nil && custom != "" {
+				folders = append(folders, custom)
+			}
+		}
+	}
+	return folders
+}
+
+// installFolder is where the Hub puts new editors: the custom location when set
+func installFolder() string {
+	folders := hubEditorFolders()
+	return folders[len(folders)-1]
+}
+
+// editorExecutable is the Unity binary inside an editor version folder
+func editorExecutable(dir string) string {
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(dir, "Editor", "Unity.exe")
+	case "darwin":
+		return filepath.Join(dir, "Unity.app", "Contents", "MacOS", "Unity")
+	default:
+		return filepath.Join(dir, "Editor", "Unity")
+	}
+}
+
+// findEditor returns the folder of an installed version, or ""
+func findEditor(version string) string {
+	for _, folder := range hubEditorFolders() {
+		dir := filepath.Join(folder, version)
+		if _, err := os.Stat(editorExecutable(dir)); err == nil {
+			return dir
+		}
+	}
+	return ""
+}
+
+// installedEditors maps each installed version to its folder
+func installedEditors() map[string]string {
+	editors := make(map[string]string)
+	for _, folder := range hubEditorFolders() {
+		entries, err := os.ReadDir(folder)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			dir := filepath.Join(folder, e.Name())
+			if _, err := os.Stat(editorExecutable(dir)); err == nil && editors[e.Name()] == "" {
+				editors[e.Name()] = dir
+			}
+		}
+	}
+	return editors
+}
+
+// installedModules reads the modules the Hub recorded as installed
+func installedModules(editorDir string) []string {
+	data, err := os.ReadFile(filepath.Join(editorDir, "modules.json"))
+	if err != nil {
+		return nil
+	}
+	var entries []hubModuleEntry
+	json.Unmarshal(data, &entries)
+	var ids []string
+	for _, e := range entries {
+		if e.Selected {
+			ids = append(ids, e.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// markModulesInstalled records directly installed modules in modules.json, so
+// the Hub and the next run see them
+func markModulesInstalled(editorDir string, ids []string) error {
+	path := filepath.Join(editorDir, "modules.json")
+	var entries []hubModuleEntry
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &entries)
+	}
+	for _, id := range ids {
+		found := false
+		for i := range entries {
+			if entries[i].ID == id {
+				entries[i].Selected = true
+				found = true
+			}
+		}
+		if !found {
+			entries = append(entries, hubModuleEntry{ID: id, Selected: true})
+		}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func versionLess(a, b string) bool {
+	ka, kb := editorVersionRegex.FindStringSubmatch(a), editorVersionRegex.FindStringSubmatch(b)
+	if ka == nil || kb == nil {
+		return a < b
+	}
+	n := func(m []string, i int) int {
+		if i == 4 {
+			return strings.Index("abxfp", m[4])
+		}
+		v, _ := strconv.Atoi(m[i])
+		return v
+	}
+	for i := 1; i <= 5; i++ {
+		if n(ka, i) != n(kb, i) {
+			return n(ka, i) < n(kb, i)
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Unity Hub
+// ============================================================
+
+// findHub returns the Unity Hub executable: -hub, UNITYSTARTER_HUB, the default
+// install location, then unityhub on PATH
+func findHub(override string) (string, error) {
+	for _, candidate := range []string{override, os.Getenv(envHubPath)} {
+		if candidate == "" {
+			continue
+		}
+		if _, err := os.Stat(candidate); err != nil {
+			return "", fmt.Errorf("Unity Hub not found: %s", candidate)
+		}
+		return candidate, nil
+	}
+	home, _ := os.UserHomeDir()
+	var candidates []string
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramW6432"} {
+			if pf := os.Getenv(env); pf != "" {
+				candidates = append(candidates, filepath.Join(pf, "Unity Hub", "Unity Hub.exe"))
+			}
+		}
+	case "darwin":
+		candidates = []string{"/Applications/Unity Hub.app/Contents/MacOS/Unity Hub"}
+	default:
+		candidates = []string{"/opt/unityhub/unityhub", filepath.Join(home, "Applications", "Unity Hub.AppImage")}
+	}
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			return c, nil
+		}
+	}
+	if p, err := exec.LookPath("unityhub"); err == nil {
+		return p, nil
+	}
+	return "", errors.New("Unity Hub not found; install it, pass -hub or use -direct")
+}
+
+// hubArgs builds the headless Hub command line. Windows and macOS builds of the
+// Hub need "--" before their own arguments.
+func hubArgs(version, changeset string, ids []string, withEditor, withChildren bool) []string {
+	var args []string
+	if runtime.GOOS != "linux" {
+		args = append(args, "--")
+	}
+	args = append(args, "--headless")
+	if withEditor {
+		args = append(args, "install", "--version", version)
+		if changeset != "" {
+			args = append(args, "--changeset", changeset)
+		}
+		if runtime.GOOS == "darwin" && runtime.GOARCH == "arm64" {
+			args = append(args, "--architecture", "arm64")
+		}
+	} else {
+		args = append(args, "install-modules", "--version", version)
+	}
+	for _, id := range ids {
+		args = append(args, "--module", id)
+	}
+	if len(ids) > 0 && withChildren {
+		args = append(args, "--childModules")
+	}
+	return args
+}
+
+// runHub runs the Hub with its output passed through; the Hub prints its own progress
+func runHub(hub string, args []string, timeout time.Duration) error {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, hub, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("Unity Hub failed: %v", err)
+	}
+	return nil
+}
+
+// ============================================================
+// Direct Download
+// ============================================================
+
+// verifyIntegrity checks a file against a Subresource Integrity value
+// ("sha384-<base64>"); the release service uses that format. An empty value
+// cannot be checked.
+func verifyIntegrity(path, integrity string) (bool, error) {
+	if integrity == "" {
+		return false, nil
+	}
+	parts := strings.SplitN(integrity, "-", 2)
+	if len(parts) != 2 {
+		return false, fmt.Errorf("unsupported checksum '%s'", integrity)
+	}
+	algo, want := parts[0], parts[1]
+	var h hash.Hash
+	switch strings.ToLower(algo) {
+	case "sha256":
+		h = sha256.New()
+	case "sha384":
+		h = sha512.New384()
+	case "sha512":
+		h = sha512.New()
+	case "md5":
+		h = md5.New()
+	default:
+		return false, fmt.Errorf("unsupported checksum algorithm '%s'", algo)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return false, err
+	}
+	sum := h.Sum(nil)
+	// Some mirrors write the digest in hex instead of base64
+	if want != base64.StdEncoding.EncodeToString(sum) && !strings.EqualFold(want, hex.EncodeToString(sum)) {
+		return false, errors.New("checksum mismatch")
+	}
+	return true, nil
+}
+
+// downloadInstaller fetches an installer into dir, reusing a complete earlier
+// download whose checksum still matches. The file is only renamed into place
+// once it is complete and verified.
+func downloadInstaller(dir string, c component) (string, bool, error) {
+	u, err := url.Parse(c.url)
+	if err != nil || c.url == "" {
+		return "", false, fmt.Errorf("%s has no download URL", c.id)
+	}
+	dst := filepath.Join(dir, filepath.Base(u.Path))
+	if _, err := os.Stat(dst); err == nil {
+		if verified, err := verifyIntegrity(dst, c.integrity); err == nil && verified {
+			return dst, true, nil
+		}
+	}
+
+	resp, err := httpClient.Get(c.url)
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", false, fmt.Errorf("%s: %s", c.url, resp.Status)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", false, err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return "", false, err
+	}
+	_, err = io.Copy(tmp, resp.Body)
+	tmp.Close()
+	if err == nil {
+		_, err = verifyIntegrity(tmp.Name(), c.integrity)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", false, err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		os.Remove(tmp.Name())
+		return "", false, err
+	}
+	return dst, c.integrity != "", nil
+}
+
+// runInstaller installs a downloaded editor or module into editorDir. Windows
+// installers run silently; Linux archives are extracted. macOS packages install
+// into /Applications/Unity and need the Hub or an administrator, so they are
+// left in the download folder.
+func runInstaller(file, editorDir string, c component) error {
+	lower := strings.ToLower(file)
+	if strings.HasSuffix(lower, ".exe") {
+		// Editor and module installers both take the editor folder; /D must be
+		// the last argument and unquoted
+		return exec.Command(file, "/S", "/D="+editorDir).Run()
+	}
+	// Archives unpack into the module's own folder below the editor
+	dest := editorDir
+	if c.dest != "" {
+		dest = filepath.Join(editorDir, filepath.FromSlash(strings.TrimPrefix(strings.ReplaceAll(c.dest, "{UNITY_PATH}", ""), "/")))
+	} else if c.id != "editor" {
+		return fmt.Errorf("%s has no install destination", c.id)
+	}
+	switch {
+	case strings.HasSuffix(lower, ".tar.xz"), strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		if err := os.MkdirAll(dest, 0755); err != nil {
+			return err
+		}
+		return exec.Command("tar", "-xf", file, "-C", dest).Run()
+	case strings.HasSuffix(lower, ".zip"):
+		if err := os.MkdirAll(dest, 0755); err != nil {
+			return err
+		}
+		return exec.Command("unzip", "-q", "-o", file, "-d", dest).Run()
+	}
+	return fmt.Errorf("%s cannot be installed without the Hub; run it by hand", filepath.Base(file))
+}
+
+// ============================================================
+// Disk Space
+// ============================================================
+
+// freeDiskSpace returns the free bytes on the drive holding dir (or its nearest
+// existing parent)
+func freeDiskSpace(dir string) (int64, error) {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	if runtime.GOOS == "windows" {
+		drive := strings.TrimSuffix(filepath.VolumeName(dir), ":")
+		if drive == "" {
+			return 0, fmt.Errorf("no drive letter in %s", dir)
+		}
+		out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", "(Get-PSDrive -Name "+drive+").Free").Output()
+		if err != nil {
+			return 0, err
+		}
+		return strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	}
+	out, err := exec.Command("df", "-Pk", dir).Output()
+	if err != nil {
+		return 0, err
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 4 {
+		return 0, fmt.Errorf("unexpected df output")
+	}
+	kb, err := strconv.ParseInt(fields[3], 10, 64)
+	return kb * 1024, err
+}
+
+// printPlan lists the components with their sizes and returns the totals
+func printPlan(plan []component) (download, installed int64) {
+	fmt.Printf("\n  %-28s %12s %12s\n", tr("COMPONENT"), tr("DOWNLOAD"), tr("INSTALLED"))
+	for _, c := range plan {
+		name := c.id
+		if c.name != "" && c.id != "editor" {
+			name = c.id + " (" + c.name + ")"
+		}
+		if len(name) > 28 {
+			name = name[:25] + "..."
+		}
+		fmt.Printf("  %-28s %12s %12s\n", name, formatSize(c.download), formatSize(c.installed))
+		download += c.download
+		installed += c.installed
+	}
+	fmt.Printf("  %-28s %12s %12s\n", tr("Total"), formatSize(download), formatSize(installed))
+	return download, installed
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...": "\n按回车键继续...",
+
+	"Usage: unity_editor_installer [flags] [list|install [version]]": "用法: unity_editor_installer [参数] [list|install [版本]]",
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"No Unity editors installed in the Unity Hub folders.":                                       "Unity Hub 文件夹中没有已安装的 Unity 编辑器。",
+	"                 modules: %s\n":                                                             "                 模块: %s\n",
+	"\n[WARNING] The project's Unity %s is not installed; run: unity_editor_installer install\n": "\n[WARNING] 项目使用的 Unity %s 未安装；请运行: unity_editor_installer install\n",
+	"[WARNING] %v; sizes are not known\n":                                                        "[WARNING] %v；无法获取大小\n",
+	"[--] Unity %s is installed: %s\n":                                                           "[--] Unity %s 已安装: %s\n",
+	"[--] Module %s is installed\n":                                                              "[--] 模块 %s 已安装\n",
+	"[OK] Unity %s and the requested modules are installed.\n":                                   "[OK] Unity %s 及所需模块均已安装。\n",
+	"Unity Hub":              "Unity Hub",
+	"direct download":        "直接下载",
+	"  EDITOR INSTALL":       "  编辑器安装",
+	"  Version:   %s (%s)\n": "  版本:      %s (%s)\n",
+	"  Target:    %s\n":      "  目标:      %s\n",
+	"  Method:    %s\n":      "  方式:      %s\n",
+	"  Modules:   %s\n":      "  模块:      %s\n",
+	"COMPONENT":              "组件",
+	"DOWNLOAD":               "下载",
+	"INSTALLED":              "安装后",
+	"Total":                  "合计",
+	"\n[WARNING] Cannot read the free disk space: %v\n":                                                              "\n[WARNING] 无法读取剩余磁盘空间: %v\n",
+	"\n  Free disk space: %s (needs about %s)\n":                                                                     "\n  剩余磁盘空间: %s（约需 %s）\n",
+	"[WARNING] -force: installing without enough disk space":                                                         "[WARNING] -force: 磁盘空间不足，仍继续安装",
+	"[WARNING] The changeset is unknown; the Hub only installs versions in its own release list without -changeset.": "[WARNING] 未知 changeset；没有 -changeset 时 Hub 只能安装其发布列表中的版本。",
+	"[WARNING] %v\n":                     "[WARNING] %v\n",
+	"\n[Dry Run] Nothing was installed.": "\n[Dry Run] 未安装任何内容。",
+	"\nProceed? (y/N): ":                 "\n是否继续？(y/N): ",
+	"Operation cancelled.":               "操作已取消。",
+	"Downloading %s (%s) ...\n":          "正在下载 %s (%s)...\n",
+	"[WARNING] %s has no checksum in the release service; not verified\n": "[WARNING] 发布服务中没有 %s 的校验和；未校验\n",
+	"Installing %s ...\n":                        "正在安装 %s...\n",
+	"[WARNING] Cannot update modules.json: %v\n": "[WARNING] 无法更新 modules.json: %v\n",
+	"\nRunning %s ...\n":                         "\n正在运行 %s...\n",
+	"\n[OK] Unity %s is installed in %s (%s)\n":  "\n[OK] Unity %s 已安装到 %s (%s)\n",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode       bool
+		dryRun       bool
+		force        bool
+		direct       bool
+		withChildren bool
+		modulesFlag  string
+		changeset    string
+		hubFlag      string
+		downloadDir  string
+		timeout      time.Duration
+	)
+
+	flag.StringVar(&modulesFlag, "modules", "", "Comma-separated modules to install (e.g. android,ios,webgl,il2cpp)")
+	flag.StringVar(&changeset, "changeset", "", "Changeset of the version (default: ProjectVersion.txt, then the release service)")
+	flag.BoolVar(&withChildren, "child-modules", true, "Also install the child modules (Android SDK, NDK and JDK under android)")
+	flag.StringVar(&hubFlag, "hub", "", "Unity Hub executable (default: UNITYSTARTER_HUB, then the default install location)")
+	flag.BoolVar(&direct, "direct", false, "Download the installers from the release service and run them without the Hub")
+	flag.StringVar(&downloadDir, "download-dir", filepath.Join(os.TempDir(), "unitystarter_installers"), "-direct: folder for the downloaded installers (kept for the next run)")
+	flag.BoolVar(&force, "force", false, "Install even when the disk space check fails")
+	flag.DurationVar(&timeout, "timeout", 0, "Abort the Hub after this long (e.g. 90m; default: none)")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no confirmation, no key press at the end)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Show the plan, sizes and the Hub command line without installing")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the command ("install 2022.3.10f1 -modules android")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_editor_installer")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	command := "list"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	if (command != "list" && command != "install") || len(args) > 2 || (command == "list" && len(args) > 1) {
+		fmt.Println(tr("Usage: unity_editor_installer [flags] [list|install [version]]"))
+		recordError("invalid command line")
+		exit(2)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fail(1, "cannot get current directory: %v", err)
+	}
+	projectVersion, projectRevision := readProjectVersion(basePath)
+
+	if command == "list" {
+		editors := installedEditors()
+		versions := make([]string, 0, len(editors))
+		for v := range editors {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versionLess(versions[i], versions[j]) })
+		if len(versions) == 0 {
+			fmt.Println(tr("No Unity editors installed in the Unity Hub folders."))
+		}
+		for _, v := range versions {
+			mark := " "
+			if v == projectVersion {
+				mark = "*"
+			}
+			modules := installedModules(editors[v])
+			fmt.Printf("%s %-14s %s\n", mark, v, editors[v])
+			if len(modules) > 0 {
+				fmt.Printf(tr("                 modules: %s\n"), strings.Join(modules, ", "))
+			}
+			recordAction("list", v, "ok", strings.Join(modules, ","), 0)
+		}
+		if projectVersion != "" && editors[projectVersion] == "" {
+			fmt.Printf(tr("\n[WARNING] The project's Unity %s is not installed; run: unity_editor_installer install\n"), projectVersion)
+		}
+		exit(0)
+	}
+
+	version := projectVersion
+	if len(args) > 1 {
+		version = args[1]
+	}
+	if version == "" {
+		fail(2, "no version given and no ProjectSettings/ProjectVersion.txt in the current directory")
+	}
+	if !editorVersionRegex.MatchString(version) {
+		fail(2, "'%s' is not a Unity version (e.g. 2022.3.10f1)", version)
+	}
+	if changeset == "" && version == projectVersion {
+		changeset = projectRevision
+	}
+
+	editorDir := findEditor(version)
+	withEditor := editorDir == ""
+
+	// Sizes, checksums and installer URLs come from the release service; the
+	// Hub can do without them
+	var download *releaseDownload
+	release, err := fetchRelease(version)
+	if err == nil {
+		if changeset == "" {
+			changeset = release.ShortRevision
+		}
+		if download = release.hostDownload(); download == nil {
+			err = fmt.Errorf("Unity %s has no installer for %s/%s", version, runtime.GOOS, runtime.GOARCH)
+		}
+	}
+	if err != nil {
+		if direct {
+			fail(1, "%v", err)
+		}
+		fmt.Printf(tr("[WARNING] %v; sizes are not known\n"), err)
+	}
+
+	var names []string
+	if modulesFlag != "" {
+		names = strings.Split(modulesFlag, ",")
+	}
+	ids, err := resolveModules(names, download)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+	if !withEditor {
+		fmt.Printf(tr("[--] Unity %s is installed: %s\n"), version, editorDir)
+		have := installedModules(editorDir)
+		var missing []string
+		for _, id := range ids {
+			if containsString(have, id) {
+				fmt.Printf(tr("[--] Module %s is installed\n"), id)
+				continue
+			}
+			missing = append(missing, id)
+		}
+		ids = missing
+		if len(ids) == 0 {
+			fmt.Printf(tr("[OK] Unity %s and the requested modules are installed.\n"), version)
+			recordAction("install", version, "skipped", "already installed", 0)
+			exit(0)
+		}
+	}
+
+	target := installFolder()
+	if !withEditor {
+		target = editorDir
+	}
+	method := tr("Unity Hub")
+	if direct {
+		method = tr("direct download")
+	}
+	printRule("\n=============================================")
+	fmt.Println(tr("  EDITOR INSTALL"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Version:   %s (%s)\n"), version, changeset)
+	fmt.Printf(tr("  Target:    %s\n"), target)
+	fmt.Printf(tr("  Method:    %s\n"), method)
+	if len(ids) > 0 {
+		fmt.Printf(tr("  Modules:   %s\n"), strings.Join(ids, ", "))
+	}
+
+	var plan []component
+	if download != nil {
+		plan = planComponents(download, withEditor, ids, withChildren)
+		downloadSize, installedSize := printPlan(plan)
+		need := installedSize + diskHeadroom
+		if direct {
+			need += downloadSize
+		}
+		free, err := freeDiskSpace(target)
+		if err != nil {
+			fmt.Printf(tr("\n[WARNING] Cannot read the free disk space: %v\n"), err)
+		} else {
+			fmt.Printf(tr("\n  Free disk space: %s (needs about %s)\n"), formatSize(free), formatSize(need))
+			if free < need {
+				if !force {
+					fail(1, "not enough disk space on the drive of %s: %s free, about %s needed; free some space or pass -force", target, formatSize(free), formatSize(need))
+				}
+				fmt.Println(tr("[WARNING] -force: installing without enough disk space"))
+			}
+		}
+	}
+	if withEditor && changeset == "" && !direct {
+		fmt.Println(tr("[WARNING] The changeset is unknown; the Hub only installs versions in its own release list without -changeset."))
+	}
+
+	hub := ""
+	hubCmd := hubArgs(version, changeset, ids, withEditor, withChildren)
+	if !direct {
+		if hub, err = findHub(hubFlag); err != nil {
+			if !dryRun {
+				fail(1, "%v", err)
+			}
+			fmt.Printf(tr("[WARNING] %v\n"), err)
+			hub = "Unity Hub"
+		}
+	}
+
+	if dryRun {
+		if direct {
+			fmt.Println()
+			for _, c := range plan {
+				fmt.Printf("  %s\n", c.url)
+				recordAction("download", c.id, "planned", c.url, 0)
+			}
+		} else {
+			quoted := make([]string, 0, len(hubCmd)+1)
+			for _, a := range append([]string{hub}, hubCmd...) {
+				if strings.ContainsAny(a, " \t") {
+					a = strconv.Quote(a)
+				}
+				quoted = append(quoted, a)
+			}
+			fmt.Printf("\n  %s\n", strings.Join(quoted, " "))
+			recordAction("install", version, "planned", strings.Join(ids, ","), 0)
+		}
+		fmt.Println(tr("\n[Dry Run] Nothing was installed."))
+		exit(0)
+	}
+
+	if !ciMode {
+		fmt.Print(tr("\nProceed? (y/N): "))
+		answer, _ := stdinReader.ReadString('\n')
+		if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+			fmt.Println(tr("Operation cancelled."))
+			exit(0)
+		}
+	}
+
+	start := time.Now()
+	if direct {
+		if withEditor {
+			editorDir = filepath.Join(installFolder(), version)
+		}
+		var directModules []string
+		for _, c := range plan {
+			fmt.Printf(tr("Downloading %s (%s) ...\n"), c.id, formatSize(c.download))
+			file, verified, err := downloadInstaller(downloadDir, c)
+			if err != nil {
+				recordAction("download", c.id, "failed", err.Error(), 0)
+				fail(1, "%s: %v", c.id, err)
+			}
+			if !verified {
+				fmt.Printf(tr("[WARNING] %s has no checksum in the release service; not verified\n"), c.id)
+			}
+			fmt.Printf(tr("Installing %s ...\n"), c.id)
+			if err := runInstaller(file, editorDir, c); err != nil {
+				recordAction("install", c.id, "failed", err.Error(), 0)
+				fail(1, "%s: %v (installer: %s)", c.id, err, file)
+			}
+			recordAction("install", c.id, "ok", filepath.Base(file), 0)
+			if c.id != "editor" {
+				directModules = append(directModules, c.id)
+			}
+		}
+		if len(directModules) > 0 {
+			if err := markModulesInstalled(editorDir, directModules); err != nil {
+				fmt.Printf(tr("[WARNING] Cannot update modules.json: %v\n"), err)
+			}
+		}
+	} else {
+		fmt.Printf(tr("\nRunning %s ...\n"), hub)
+		if err := runHub(hub, hubCmd, timeout); err != nil {
+			recordAction("install", version, "failed", err.Error(), time.Since(start))
+			fail(1, "%v", err)
+		}
+	}
+
+	// The Hub exits with 0 on some failures; trust only what is on disk
+	if editorDir = findEditor(version); editorDir == "" {
+		recordAction("install", version, "failed", "editor not found after install", time.Since(start))
+		fail(1, "Unity %s is not in the Unity Hub folders after the install", version)
+	}
+	if have := installedModules(editorDir); have != nil {
+		var missing []string
+		for _, id := range ids {
+			if !containsString(have, id) {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			recordAction("install", version, "failed", "missing modules: "+strings.Join(missing, ","), time.Since(start))
+			fail(1, "modules not installed: %s", strings.Join(missing, ", "))
+		}
+	}
+	fmt.Printf(tr("\n[OK] Unity %s is installed in %s (%s)\n"), version, editorDir, time.Since(start).Round(time.Second))
+	recordAction("install", version, "ok", strings.Join(ids, ","), time.Since(start))
+	exit(0)
+}
@@ -0,0 +1,2287 @@
+// Unity Editor Menu — Install an editor package that runs these tools from Unity's menu.
+// Writes Packages/com.unitystarter.tools, an embedded package with a Tools > Starter
+// menu: Clean Project, Normalize Audio, Generate File Tree and Analyze Build Size.
+// The menu starts the tool executables with -json and streams their output into
+// the Console, so artists can use the tools without opening a terminal. The folder
+// holding the executables is recorded relative to the project root, and a manifest
+// in ProjectSettings records what was installed, so updates replace only files
+// that were not edited locally.
+//
+// Build: go build unity_editor_menu.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_editor_menu                                        # status: missing, outdated or edited files
+//	unity_editor_menu install                                # tools folder: where this executable is
+//	unity_editor_menu install -tools-dir ../Tools/Executable/Windows
+//	unity_editor_menu install -force                         # also overwrite edited files
+//	unity_editor_menu remove
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	// Bump when the embedded sources change
+	menuVersion = 1
+
+	packageName      = "com.unitystarter.tools"
+	packageDir       = "Packages/" + packageName
+	manifestFileName = "UnityStarterEditorMenu.json" // in ProjectSettings/
+
+	// Output of a tool that outlives the editor (Close and Clean), relative to the
+	// project root. The menu shows it in the Console when the project opens again.
+	detachedLogFolder = "UserSettings/UnityStarter/EditorMenu"
+)
+
+// Tools the menu runs; install warns when the tools folder has none of them
+var menuTools = []string{"unity_project_full_clean", "audio_volume_normalizer", "generate_file_tree", "build_size_analyzer"}
+
+// menuFile is one embedded source, installed as <packageDir>/<name>
+type menuFile struct {
+	name    string
+	content string
+}
+
+// menuFiles returns the embedded sources with the tools folder filled in
+func menuFiles(toolsDir string) []menuFile {
+	fill := strings.NewReplacer(
+		"{{VERSION}}", strconv.Itoa(menuVersion),
+		"{{PACKAGE}}", packageName,
+		"{{TOOLS_DIR}}", strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(toolsDir),
+		"{{DETACHED_LOGS}}", detachedLogFolder,
+	)
+	var files []menuFile
+	for _, f := range []menuFile{
+		{"package.json", packageSource},
+		{"Editor/UnityStarter.Tools.Editor.asmdef", asmdefSource},
+		{"Editor/StarterToolRunner.cs", runnerSource},
+		{"Editor/StarterToolsMenu.cs", menuSource},
+	} {
+		files = append(files, menuFile{f.name, fill.Replace(f.content)})
+	}
+	return files
+}
+
+const packageSource = `{
+  "name": "{{PACKAGE}}",
+  "version": "{{VERSION}}.0.0",
+  "displayName": "UnityStarter Tools",
+  "description": "Tools > Starter menu that runs the UnityStarter command line tools and shows their output in the Console. Installed by unity_editor_menu.",
+  "unity": "2021.3"
+}
+`
+
+const asmdefSource = `{
+    "name": "UnityStarter.Tools.Editor",
+    "rootNamespace": "UnityStarter.Tools.Editor",
+    "references": [],
+    "includePlatforms": [
+        "Editor"
+    ],
+    "excludePlatforms": [],
+    "allowUnsafeCode": false,
+    "overrideReferences": false,
+    "precompiledReferences": [],
+    "autoReferenced": false,
+    "defineConstraints": [],
+    "versionDefines": [],
+    "noEngineReferences": false
+}
+`
+
+const runnerSource = `// Installed by unity_editor_menu (menu v{{VERSION}}). Run "unity_editor_menu install"
+// to update; files edited locally are reported and kept.
+using System;
+using System.Collections.Concurrent;
+using System.Diagnostics;
+using System.IO;
+using System.Text;
+using UnityEditor;
+using UnityEngine;
+using Debug = UnityEngine.Debug;
+
+namespace UnityStarter.Tools.Editor
+{
+    /// <summary>
+    /// Runs the UnityStarter command line tools from the editor. A tool runs with -json:
+    /// its console output is streamed into the Console line by line, and its result
+    /// document is summarized when it exits. One tool runs at a time.
+    /// </summary>
+    public static class StarterToolRunner
+    {
+        public const int Version = {{VERSION}};
+
+        // Folder of the tool executables, relative to the project root or absolute.
+        // Written by "unity_editor_menu install -tools-dir"; the EditorPrefs key overrides
+        // it on one machine.
+        public const string DefaultToolsFolder = "{{TOOLS_DIR}}";
+        public const string ToolsFolderPref = "UnityStarter.ToolsFolder";
+
+        // Output of tools started by RunDetached, shown when the project opens again
+        public const string DetachedLogFolder = "{{DETACHED_LOGS}}";
+
+        private const string DEBUG_FLAG = "[UnityStarter]";
+
+        private static readonly ConcurrentQueue<string> pendingLines = new ConcurrentQueue<string>();
+        private static readonly StringBuilder resultText = new StringBuilder();
+        private static Process running;
+        private static string runningTool;
+        private static bool canceled;
+
+        // Filled by JsonUtility from the tool's -json report.
+#pragma warning disable 0649
+        [Serializable]
+        private class ToolResult
+        {
+            public string tool;
+            public bool success;
+            public int exitCode;
+            public long durationMs;
+            public string[] errors;
+            public string[] artifacts;
+        }
+#pragma warning restore 0649
+
+        public static bool IsRunning => running != null;
+
+        public static string ProjectRoot => Path.GetDirectoryName(Application.dataPath);
+
+        public static string ToolsFolder
+        {
+            get => EditorPrefs.GetString(ToolsFolderPref, DefaultToolsFolder);
+            set => EditorPrefs.SetString(ToolsFolderPref, value);
+        }
+
+        /// <summary>
+        /// Full path of a tool: the tools folder first, then PATH. Null when it is in neither.
+        /// </summary>
+        public static string Resolve(string tool)
+        {
+            string file = Application.platform == RuntimePlatform.WindowsEditor ? tool + ".exe" : tool;
+            if (!string.IsNullOrEmpty(ToolsFolder))
+            {
+                string candidate = Path.GetFullPath(Path.Combine(ProjectRoot, ToolsFolder, file));
+                if (File.Exists(candidate))
+                {
+                    return candidate;
+                }
+            }
+            foreach (string dir in (Environment.GetEnvironmentVariable("PATH") ?? "").Split(Path.PathSeparator))
+            {
+                if (string.IsNullOrWhiteSpace(dir))
+                {
+                    continue;
+                }
+                string candidate = Path.Combine(dir.Trim().Trim('"'), file);
+                if (File.Exists(candidate))
+                {
+                    return candidate;
+                }
+            }
+            return null;
+        }
+
+        /// <summary>
+        /// Starts a tool in workingDirectory with -json added to args. Answers, when given,
+        /// are written to its standard input for tools that prompt.
+        /// </summary>
+        public static bool Run(string tool, string workingDirectory, string answers, params string[] args)
+        {
+            if (running != null)
+            {
+                EditorUtility.DisplayDialog("UnityStarter", $"{runningTool} is still running. Wait for it to finish or cancel it first.", "OK");
+                return false;
+            }
+            string exe = ResolveOrComplain(tool);
+            if (exe == null)
+            {
+                return false;
+            }
+
+            var info = new ProcessStartInfo(exe, JoinArguments(args) + " -json")
+            {
+                WorkingDirectory = workingDirectory,
+                UseShellExecute = false,
+                CreateNoWindow = true,
+                RedirectStandardInput = true,
+                RedirectStandardOutput = true,
+                RedirectStandardError = true,
+                StandardOutputEncoding = Encoding.UTF8,
+                StandardErrorEncoding = Encoding.UTF8,
+            };
+            // Progress bars would fill the Console with carriage returns
+            info.EnvironmentVariables["UNITYSTARTER_PLAIN"] = "1";
+
+            var process = new Process { StartInfo = info };
+            resultText.Clear();
+            // Under -json the result document is on stdout and the console output on stderr
+            process.OutputDataReceived += (_, e) =>
+            {
+                if (e.Data != null)
+                {
+                    lock (resultText)
+                    {
+                        resultText.AppendLine(e.Data);
+                    }
+                }
+            };
+            process.ErrorDataReceived += (_, e) =>
+            {
+                if (e.Data != null)
+                {
+                    pendingLines.Enqueue(e.Data);
+                }
+            };
+            try
+            {
+                process.Start();
+            }
+            catch (Exception e)
+            {
+                Debug.LogError($"{DEBUG_FLAG} Cannot start {exe}: {e.Message}");
+                return false;
+            }
+            process.BeginOutputReadLine();
+            process.BeginErrorReadLine();
+            if (!string.IsNullOrEmpty(answers))
+            {
+                process.StandardInput.Write(answers);
+            }
+            process.StandardInput.Close();
+
+            running = process;
+            runningTool = tool;
+            canceled = false;
+            Debug.Log($"{DEBUG_FLAG} Running {tool} {JoinArguments(args)} in {workingDirectory}");
+            // A script reload would lose the process; hold it until the tool is done
+            EditorApplication.LockReloadAssemblies();
+            EditorApplication.update += Drain;
+            EditorApplication.quitting += Cancel;
+            return true;
+        }
+
+        /// <summary>
+        /// Starts a tool that outlives the editor, such as the clean behind Close and Clean.
+        /// Its output goes to a log in DetachedLogFolder and appears in the Console when
+        /// the project opens again.
+        /// </summary>
+        public static bool RunDetached(string tool, params string[] args)
+        {
+            string exe = ResolveOrComplain(tool);
+            if (exe == null)
+            {
+                return false;
+            }
+            string folder = Path.Combine(ProjectRoot, DetachedLogFolder);
+            Directory.CreateDirectory(folder);
+            string log = Path.Combine(folder, tool + ".log");
+
+            ProcessStartInfo info;
+            if (Application.platform == RuntimePlatform.WindowsEditor)
+            {
+                string command = $"{Quote(exe)} {JoinArguments(args)} > {Quote(log)} 2>&1";
+                info = new ProcessStartInfo("cmd.exe", $"/s /c \"{command}\"");
+            }
+            else
+            {
+                string command = $"exec {ShellQuote(exe)} {string.Join(" ", Array.ConvertAll(args, ShellQuote))} > {ShellQuote(log)} 2>&1";
+                info = new ProcessStartInfo("/bin/sh", "-c " + Quote(command));
+            }
+            info.WorkingDirectory = ProjectRoot;
+            info.UseShellExecute = false;
+            info.CreateNoWindow = true;
+            info.EnvironmentVariables["UNITYSTARTER_PLAIN"] = "1";
+            try
+            {
+                Process.Start(info);
+            }
+            catch (Exception e)
+            {
+                Debug.LogError($"{DEBUG_FLAG} Cannot start {exe}: {e.Message}");
+                return false;
+            }
+            return true;
+        }
+
+        /// <summary>
+        /// Kills the running tool.
+        /// </summary>
+        public static void Cancel()
+        {
+            if (running == null)
+            {
+                return;
+            }
+            canceled = true;
+            try
+            {
+                running.Kill();
+            }
+            catch (InvalidOperationException)
+            {
+                // Already exited
+            }
+        }
+
+        private static void Drain()
+        {
+            while (pendingLines.TryDequeue(out string line))
+            {
+                LogLine(runningTool, line);
+            }
+            if (running == null || !running.HasExited)
+            {
+                return;
+            }
+            // Returns once the output handlers have seen the end of both streams
+            running.WaitForExit();
+            while (pendingLines.TryDequeue(out string line))
+            {
+                LogLine(runningTool, line);
+            }
+            Finish();
+        }
+
+        private static void Finish()
+        {
+            Process process = running;
+            string tool = runningTool;
+            running = null;
+            runningTool = null;
+            EditorApplication.update -= Drain;
+            EditorApplication.quitting -= Cancel;
+            EditorApplication.UnlockReloadAssemblies();
+
+            int exitCode = process.ExitCode;
+            process.Dispose();
+            ToolResult result = null;
+            lock (resultText)
+            {
+                try
+                {
+                    result = JsonUtility.FromJson<ToolResult>(resultText.ToString());
+                }
+                catch (ArgumentException)
+                {
+                    // No result document: the tool crashed or was killed
+                }
+            }
+
+            if (canceled)
+            {
+                Debug.LogWarning($"{DEBUG_FLAG} {tool} was canceled.");
+            }
+            else if (result != null && result.success)
+            {
+                Debug.Log($"{DEBUG_FLAG} {tool} finished in {result.durationMs / 1000.0:0.0}s.");
+            }
+            else
+            {
+                string errors = result?.errors != null && result.errors.Length > 0 ? ":\n" + string.Join("\n", result.errors) : ".";
+                Debug.LogError($"{DEBUG_FLAG} {tool} failed with exit code {exitCode}{errors}");
+            }
+            if (result?.artifacts != null)
+            {
+                foreach (string artifact in result.artifacts)
+                {
+                    Debug.Log($"{DEBUG_FLAG} Wrote {artifact}");
+                }
+            }
+            AssetDatabase.Refresh();
+        }
+
+        [InitializeOnLoadMethod]
+        private static void ShowDetachedLogs()
+        {
+            string folder = Path.Combine(ProjectRoot, DetachedLogFolder);
+            // A tool still working on the project holds its lock file
+            if (!Directory.Exists(folder) || File.Exists(Path.Combine(ProjectRoot, ".unitystarter.lock")))
+            {
+                return;
+            }
+            foreach (string log in Directory.GetFiles(folder, "*.log"))
+            {
+                string tool = Path.GetFileNameWithoutExtension(log);
+                try
+                {
+                    string[] lines = File.ReadAllLines(log);
+                    DateTime written = File.GetLastWriteTime(log);
+                    File.Delete(log);
+                    Debug.Log($"{DEBUG_FLAG} Output of {tool} from {written:g}:");
+                    foreach (string line in lines)
+                    {
+                        LogLine(tool, line);
+                    }
+                }
+                catch (IOException)
+                {
+                    // Still being written: the tool has not finished yet
+                }
+            }
+        }
+
+        private static string ResolveOrComplain(string tool)
+        {
+            string exe = Resolve(tool);
+            if (exe == null)
+            {
+                EditorUtility.DisplayDialog("UnityStarter",
+                    $"{tool} was not found in the tools folder ({ToolsFolder}) or on PATH.\n\n" +
+                    "Set the folder with Tools > Starter > Tools Folder..., or run \"unity_editor_menu install -tools-dir <folder>\".", "OK");
+            }
+            return exe;
+        }
+
+        private static void LogLine(string tool, string line)
+        {
+            if (string.IsNullOrWhiteSpace(line))
+            {
+                return;
+            }
+            string text = line.TrimStart();
+            LogType type = LogType.Log;
+            if (text.StartsWith("[ERROR]") || text.StartsWith("Error"))
+            {
+                type = LogType.Error;
+            }
+            else if (text.StartsWith("[WARNING]") || text.StartsWith("Warning"))
+            {
+                type = LogType.Warning;
+            }
+            Debug.LogFormat(type, LogOption.NoStacktrace, null, "[{0}] {1}", tool, line);
+        }
+
+        private static string JoinArguments(string[] args)
+        {
+            return string.Join(" ", Array.ConvertAll(args, Quote));
+        }
+
+        // Command line quoting understood by Windows programs and by Mono's argument parser
+        private static string Quote(string arg)
+        {
+            if (arg.Length > 0 && arg.IndexOfAny(new[] { ' ', '\t', '"' }) < 0)
+            {
+                return arg;
+            }
+            var quoted = new StringBuilder("\"");
+            int backslashes = 0;
+            foreach (char c in arg)
+            {
+                if (c == '\\')
+                {
+                    backslashes++;
+                    continue;
+                }
+                quoted.Append('\\', c == '"' ? backslashes * 2 + 1 : backslashes).Append(c);
+                backslashes = 0;
+            }
+            return quoted.Append('\\', backslashes * 2).Append('"').ToString();
+        }
+
+        private static string ShellQuote(string arg)
+        {
+            return "'" + arg.Replace("'", "'\\''") + "'";
+        }
+    }
+}
+`
+
+const menuSource = `// Installed by unity_editor_menu (menu v{{VERSION}}). Run "unity_editor_menu install"
+// to update; files edited locally are reported and kept.
+using System.Diagnostics;
+using System.IO;
+using UnityEditor;
+using UnityEditor.SceneManagement;
+using Debug = UnityEngine.Debug;
+
+namespace UnityStarter.Tools.Editor
+{
+    /// <summary>
+    /// Tools > Starter: the UnityStarter tools for people who never open a terminal.
+    /// </summary>
+    public static class StarterToolsMenu
+    {
+        private const string MENU = "Tools/Starter/";
+
+        [MenuItem(MENU + "Clean Project...", false, 0)]
+        private static void CleanProject()
+        {
+            int choice = EditorUtility.DisplayDialogComplex("Clean Project",
+                "A full clean deletes Library, Temp, build output and IDE files. Unity must be closed while it runs " +
+                "and re-imports the project the next time it opens.\n\n" +
+                "Close and Clean saves your work, closes the editor and cleans; the output appears in the Console " +
+                "when you open the project again. Preview lists what would be deleted.",
+                "Close and Clean", "Cancel", "Preview");
+            if (choice == 2)
+            {
+                StarterToolRunner.Run("unity_project_full_clean", StarterToolRunner.ProjectRoot, null, "-dry-run");
+                return;
+            }
+            if (choice != 0 || !EditorSceneManager.SaveCurrentModifiedScenesIfUserWantsTo())
+            {
+                return;
+            }
+            AssetDatabase.SaveAssets();
+            // The clean waits for this editor to exit before deleting anything
+            string pid = Process.GetCurrentProcess().Id.ToString();
+            if (StarterToolRunner.RunDetached("unity_project_full_clean", "-ci", "-wait-pid", pid))
+            {
+                EditorApplication.Exit(0);
+            }
+        }
+
+        [MenuItem(MENU + "Normalize Audio", false, 20)]
+        [MenuItem("Assets/Starter/Normalize Audio", false, 1000)]
+        private static void NormalizeAudio()
+        {
+            string folder = SelectedFolder();
+            if (folder == null)
+            {
+                EditorUtility.DisplayDialog("Normalize Audio", "Select the folder with the audio files in the Project window first.", "OK");
+                return;
+            }
+            if (!EditorUtility.DisplayDialog("Normalize Audio",
+                    $"Normalize every audio file under {folder}?\n\n" +
+                    "Each file gets a loudness-normalized WAV copy with the _normalized suffix next to it. FFmpeg must be installed.",
+                    "Normalize", "Cancel"))
+            {
+                return;
+            }
+            // The normalizer asks for the output format (1 = WAV) and then to proceed
+            string directory = Path.GetFullPath(Path.Combine(StarterToolRunner.ProjectRoot, folder));
+            StarterToolRunner.Run("audio_volume_normalizer", directory, "1\ny\n");
+        }
+
+        [MenuItem(MENU + "Generate File Tree", false, 21)]
+        private static void GenerateFileTree()
+        {
+            StarterToolRunner.Run("generate_file_tree", StarterToolRunner.ProjectRoot, null);
+        }
+
+        [MenuItem(MENU + "Analyze Build Size", false, 22)]
+        private static void AnalyzeBuildSize()
+        {
+            // Reads the Build Report of the last build in this editor's log
+            StarterToolRunner.Run("build_size_analyzer", StarterToolRunner.ProjectRoot, null);
+        }
+
+        [MenuItem(MENU + "Cancel Running Tool", false, 40)]
+        private static void CancelRunningTool()
+        {
+            StarterToolRunner.Cancel();
+        }
+
+        [MenuItem(MENU + "Tools Folder...", false, 41)]
+        private static void ChooseToolsFolder()
+        {
+            string current = Path.GetFullPath(Path.Combine(StarterToolRunner.ProjectRoot, StarterToolRunner.ToolsFolder));
+            string folder = EditorUtility.OpenFolderPanel("Folder with the UnityStarter tools", current, "");
+            if (string.IsNullOrEmpty(folder))
+            {
+                return;
+            }
+            StarterToolRunner.ToolsFolder = folder;
+            Debug.Log($"[UnityStarter] Tools folder on this machine: {folder}");
+        }
+
+        [MenuItem(MENU + "Clean Project...", true)]
+        [MenuItem(MENU + "Normalize Audio", true)]
+        [MenuItem(MENU + "Generate File Tree", true)]
+        [MenuItem(MENU + "Analyze Build Size", true)]
+        private static bool CanRun()
+        {
+            return !StarterToolRunner.IsRunning;
+        }
+
+        [MenuItem("Assets/Starter/Normalize Audio", true)]
+        private static bool CanNormalizeAudio()
+        {
+            return !StarterToolRunner.IsRunning && SelectedFolder() != null;
+        }
+
+        [MenuItem(MENU + "Cancel Running Tool", true)]
+        private static bool CanCancel()
+        {
+            return StarterToolRunner.IsRunning;
+        }
+
+        // The folder selected in the Project window, as an "Assets/..." path
+        private static string SelectedFolder()
+        {
+            foreach (string guid in Selection.assetGUIDs)
+            {
+                string path = AssetDatabase.GUIDToAssetPath(guid);
+                if (path.StartsWith("Assets") && AssetDatabase.IsValidFolder(path))
+                {
+                    return path;
+                }
+            }
+            return null;
+        }
+    }
+}
+`
+
+// .meta files written for new sources, folders, the assembly definition and package.json
+const scriptMetaTemplate = `fileFormatVersion: 2
+guid: %s
+MonoImporter:
+  externalObjects: {}
+  serializedVersion: 2
+  defaultReferences: []
+  executionOrder: 0
+  icon: {instanceID: 0}
+  userData:
+  assetBundleName:
+  assetBundleVariant:
+`
+
+const asmdefMetaTemplate = `fileFormatVersion: 2
+guid: %s
+AssemblyDefinitionImporter:
+  externalObjects: {}
+  userData:
+  assetBundleName:
+  assetBundleVariant:
+`
+
+const textMetaTemplate = `fileFormatVersion: 2
+guid: %s
+TextScriptImporter:
+  externalObjects: {}
+  userData:
+  assetBundleName:
+  assetBundleVariant:
+`
+
+const folderMetaTemplate = `fileFormatVersion: 2
+guid: %s
+folderAsset: yes
+DefaultImporter:
+  externalObjects: {}
+  userData:
+  assetBundleName:
+  assetBundleVariant:
+`
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// menuManifest is ProjectSettings/UnityStarterEditorMenu.json: what was installed,
+// with the hash of each file as written, and the tools folder the menu uses
+type menuManifest struct {
+	MenuVersion int               `json:"menuVersion"`
+	ToolsDir    string            `json:"toolsDir"`
+	Files       map[string]string `json:"files"` // name -> sha256 of the installed text
+}
+
+// Per-file states reported by status
+const (
+	stateCurrent  = "current"
+	stateMissing  = "missing"
+	stateOutdated = "outdated" // an older version or another tools folder, unedited: install replaces it
+	stateModified = "modified" // edited locally (or not installed by this tool): kept without -force
+)
+
+// fileStatus is one embedded file compared with the project
+type fileStatus struct {
+	file  menuFile
+	path  string // project-relative
+	state string
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Tools Folder
+// ============================================================
+
+// defaultToolsDir is the folder of this executable, relative to the project root
+// when possible so the path works on every checkout of the project
+func defaultToolsDir(basePath string) string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return relativeToolsDir(basePath, filepath.Dir(exe))
+}
+
+// relativeToolsDir turns a folder into the form the menu stores: relative to the
+// project root when it is inside the project or next to it (../Tools/...), which
+// holds on every checkout, otherwise absolute. Forward slashes either way.
+func relativeToolsDir(basePath, dir string) string {
+	if !filepath.IsAbs(dir) {
+		return filepath.ToSlash(filepath.Clean(dir))
+	}
+	if rel, err := filepath.Rel(basePath, dir); err == nil {
+		rel = filepath.ToSlash(rel)
+		if !strings.HasPrefix(strings.TrimPrefix(rel, "../"), "..") {
+			return rel
+		}
+	}
+	return filepath.ToSlash(dir)
+}
+
+// hasMenuTools reports whether the tools folder holds any tool the menu runs
+func hasMenuTools(basePath, toolsDir string) bool {
+	dir := filepath.FromSlash(toolsDir)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(basePath, dir)
+	}
+	for _, tool := range menuTools {
+		for _, name := range []string{tool, tool + ".exe"} {
+			if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Manifest & Status
+// ============================================================
+
+func manifestPath(basePath string) string {
+	return filepath.Join(basePath, "ProjectSettings", manifestFileName)
+}
+
+// loadManifest returns the manifest, or nil when the menu was never installed
+func loadManifest(basePath string) (*menuManifest, error) {
+	data, err := fsys.ReadFile(manifestPath(basePath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m menuManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", manifestFileName, err)
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]string)
+	}
+	return &m, nil
+}
+
+func saveManifest(basePath string, m *menuManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, statErr := fsys.Stat(manifestPath(basePath))
+	if err := writeFileAtomic(manifestPath(basePath), append(data, '\n')); err != nil {
+		return err
+	}
+	if statErr != nil {
+		activeVCS.add(manifestPath(basePath))
+	}
+	return nil
+}
+
+// textHash hashes text with line endings and BOM normalized, so a checkout that
+// converts to CRLF does not count as an edit
+func textHash(data []byte) string {
+	text, _ := decodeText(data)
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkStatus compares every embedded file with the project
+func checkStatus(basePath, toolsDir string, manifest *menuManifest) []fileStatus {
+	var result []fileStatus
+	for _, f := range menuFiles(toolsDir) {
+		st := fileStatus{file: f, path: packageDir + "/" + f.name}
+		data, err := fsys.ReadFile(filepath.Join(basePath, filepath.FromSlash(st.path)))
+		switch {
+		case err != nil:
+			st.state = stateMissing
+		case textHash(data) == textHash([]byte(f.content)):
+			st.state = stateCurrent
+		case manifest != nil && manifest.Files[f.name] == textHash(data):
+			st.state = stateOutdated
+		default:
+			st.state = stateModified
+		}
+		result = append(result, st)
+	}
+	return result
+}
+
+// ============================================================
+// Install & Remove
+// ============================================================
+
+// newGUID returns a random 32-digit hex GUID in Unity's .meta format
+func newGUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// packageFolders lists the package root and the folders of its files, parents first
+func packageFolders(files []menuFile) []string {
+	seen := map[string]bool{packageDir: true}
+	for _, f := range files {
+		for d := path.Dir(packageDir + "/" + f.name); d != packageDir; d = path.Dir(d) {
+			seen[d] = true
+		}
+	}
+	var dirs []string
+	for d := range seen {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// ensureFolders creates the missing package folders. Folders inside the package get
+// a .meta so their GUIDs are stable; the package root, like any folder directly
+// under Packages/, has none.
+func ensureFolders(basePath string, files []menuFile) ([]string, error) {
+	var created []string
+	for _, d := range packageFolders(files) {
+		abs := filepath.Join(basePath, filepath.FromSlash(d))
+		if _, err := fsys.Stat(abs); err == nil {
+			continue
+		}
+		if err := fsys.MkdirAll(abs, 0755); err != nil {
+			return created, err
+		}
+		if d != packageDir {
+			meta := fmt.Sprintf(folderMetaTemplate, newGUID())
+			if err := writeFileAtomic(abs+".meta", []byte(meta)); err != nil {
+				return created, err
+			}
+			activeVCS.add(abs + ".meta")
+		}
+		created = append(created, d)
+	}
+	return created, nil
+}
+
+// installFile writes one file. An existing file keeps its .meta (and GUID) and its
+// line endings; a new one gets a fresh .meta and is marked for add.
+func installFile(basePath string, st fileStatus) error {
+	abs := filepath.Join(basePath, filepath.FromSlash(st.path))
+	format := textFormat{}
+	if data, err := fsys.ReadFile(abs); err == nil {
+		_, format = decodeText(data)
+	}
+	if err := writeTextFileAtomic(abs, st.file.content, format); err != nil {
+		return err
+	}
+	if st.state == stateMissing {
+		activeVCS.add(abs)
+	}
+	if _, err := fsys.Stat(abs + ".meta"); err == nil {
+		return nil
+	}
+	metaTemplate := scriptMetaTemplate
+	switch path.Ext(st.file.name) {
+	case ".asmdef":
+		metaTemplate = asmdefMetaTemplate
+	case ".json":
+		metaTemplate = textMetaTemplate
+	}
+	if err := writeFileAtomic(abs+".meta", []byte(fmt.Sprintf(metaTemplate, newGUID()))); err != nil {
+		return fmt.Errorf("wrote %s but not its .meta: %v", st.path, err)
+	}
+	activeVCS.add(abs + ".meta")
+	return nil
+}
+
+// removeFile deletes one file and its .meta
+func removeFile(basePath, rel string) error {
+	abs := filepath.Join(basePath, filepath.FromSlash(rel))
+	if err := activeVCS.remove(abs); err != nil {
+		return err
+	}
+	if _, err := fsys.Stat(abs + ".meta"); err == nil {
+		return activeVCS.remove(abs + ".meta")
+	}
+	return nil
+}
+
+// removeEmptyFolders deletes the package folders (and their .meta) that are empty
+// once the files are gone, deepest first
+func removeEmptyFolders(basePath string, files []menuFile) []string {
+	var removed []string
+	dirs := packageFolders(files)
+	for i := len(dirs) - 1; i >= 0; i-- {
+		abs := filepath.Join(basePath, filepath.FromSlash(dirs[i]))
+		entries, err := fsys.ReadDir(abs)
+		if err != nil || len(entries) > 0 {
+			continue
+		}
+		if err := fsys.Remove(abs); err != nil {
+			continue
+		}
+		if _, err := fsys.Stat(abs + ".meta"); err == nil {
+			activeVCS.remove(abs + ".meta")
+		}
+		removed = append(removed, dirs[i])
+	}
+	return removed
+}
+
+// ============================================================
+// Version Control
+// ============================================================
+
+// Package files are added (p4 add, cm add) when installed, checked out before an
+// update and deleted through the VCS on removal, so the change lands in one
+// changelist / changeset. Git needs nothing: the files simply show up as changed.
+const (
+	vcsNone     = "none"
+	vcsPerforce = "p4"
+	vcsPlastic  = "plastic"
+)
+
+// vcsEnvVar overrides detection for every tool (e.g. on build agents)
+const vcsEnvVar = "UNITYSTARTER_VCS"
+
+// vcsClient runs checkouts, adds and deletes for files under version control
+type vcsClient struct {
+	kind       string
+	source     string // how kind was chosen, for the startup message
+	checkedOut map[string]bool
+}
+
+// activeVCS is set up in main; nil means no version control commands
+var activeVCS *vcsClient
+
+// detectVCS picks the provider. Priority: -vcs flag > UNITYSTARTER_VCS >
+// Unity's VersionControlSettings.asset mode > workspace markers (.plastic, P4CONFIG).
+func detectVCS(projectRoot, override string) (*vcsClient, error) {
+	normalize := func(v string) (string, bool) {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "p4", "perforce":
+			return vcsPerforce, true
+		case "plastic", "plasticscm", "cm", "unityvcs":
+			return vcsPlastic, true
+		case "none", "git", "off":
+			return vcsNone, true
+		}
+		return "", false
+	}
+	newClient := func(kind, source string) *vcsClient {
+		return &vcsClient{kind: kind, source: source, checkedOut: make(map[string]bool)}
+	}
+
+	if override != "" && override != "auto" {
+		kind, ok := normalize(override)
+		if !ok {
+			return nil, fmt.Errorf("unknown -vcs value '%s' (use auto, none, p4 or plastic)", override)
+		}
+		return newClient(kind, "-vcs flag"), nil
+	}
+	if env := os.Getenv(vcsEnvVar); env != "" {
+		if kind, ok := normalize(env); ok {
+			return newClient(kind, vcsEnvVar), nil
+		}
+	}
+
+	// Unity stores the project's integrated VCS in m_Mode ("Perforce", "PlasticSCM", ...)
+	if data, err := os.ReadFile(filepath.Join(projectRoot, "ProjectSettings", "VersionControlSettings.asset")); err == nil {
+		if m := regexp.MustCompile(`(?m)^\s*m_Mode:\s*(.+?)\s*$`).FindSubmatch(data); m != nil {
+			switch strings.ToLower(string(m[1])) {
+			case "perforce":
+				return newClient(vcsPerforce, "VersionControlSettings.asset"), nil
+			case "plasticscm", "plastic scm", "unity version control":
+				return newClient(vcsPlastic, "VersionControlSettings.asset"), nil
+			}
+		}
+	}
+
+	// Workspace markers, searched upward from the project root
+	p4config := os.Getenv("P4CONFIG")
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info != nil {
+				return newClient(vcsNone, "git repository"), nil
+			}
+			if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+				return newClient(vcsPlastic, ".plastic workspace"), nil
+			}
+			if p4config != "" {
+				if _, err := os.Stat(filepath.Join(dir, p4config)); err == nil {
+					return newClient(vcsPerforce, "P4CONFIG"), nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if os.Getenv("P4CLIENT") != "" {
+		return newClient(vcsPerforce, "P4CLIENT"), nil
+	}
+	return newClient(vcsNone, "no Perforce/Plastic workspace found"), nil
+}
+
+// run executes a VCS command from dir; a dry run only lists it
+func (v *vcsClient) run(dir string, args ...string) error {
+	if overlay, ok := fsys.(*overlayFS); ok {
+		overlay.noteCommand(args...)
+		return nil
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	// Run from the file's folder so P4CONFIG / the Plastic workspace is resolved
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %v %s", args[0], args[1], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// checkout opens an existing file for edit. Files outside the depot/workspace only
+// produce a warning: the write still goes ahead.
+func (v *vcsClient) checkout(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || v.checkedOut[abs] {
+		return
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return
+	}
+	v.checkedOut[abs] = true
+
+	var err2 error
+	switch v.kind {
+	case vcsPerforce:
+		err2 = v.run(filepath.Dir(abs), "p4", "edit", abs)
+	case vcsPlastic:
+		err2 = v.run(filepath.Dir(abs), "cm", "checkout", abs)
+	}
+	if err2 != nil {
+		fmt.Printf(tr("[WARNING] %s checkout failed for %s: %v\n"), v.kind, filepath.Base(abs), err2)
+		return
+	}
+	fmt.Printf(tr("[VCS] Checked out (%s): %s\n"), v.kind, filepath.Base(abs))
+}
+
+// add marks a newly created file for add
+func (v *vcsClient) add(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	var err2 error
+	switch v.kind {
+	case vcsPerforce:
+		err2 = v.run(filepath.Dir(abs), "p4", "add", abs)
+	case vcsPlastic:
+		err2 = v.run(filepath.Dir(abs), "cm", "add", abs)
+	}
+	if err2 != nil {
+		fmt.Printf(tr("[WARNING] %s add failed for %s: %v\n"), v.kind, filepath.Base(abs), err2)
+	}
+}
+
+// remove deletes a file through the VCS when there is one (p4 delete, cm remove).
+// Files the VCS does not know, and files it leaves on disk, are deleted directly.
+func (v *vcsClient) remove(path string) error {
+	if v != nil && v.kind != vcsNone {
+		if abs, err := filepath.Abs(path); err == nil {
+			switch v.kind {
+			case vcsPerforce:
+				err = v.run(filepath.Dir(abs), "p4", "delete", abs)
+			case vcsPlastic:
+				err = v.run(filepath.Dir(abs), "cm", "remove", abs)
+			}
+			if err != nil {
+				fmt.Printf(tr("[WARNING] %s delete failed for %s, deleting on disk: %v\n"), v.kind, filepath.Base(abs), err)
+			}
+		}
+	}
+	if _, err := fsys.Stat(path); err != nil {
+		return nil
+	}
+	return fsys.Remove(path)
+}
+
+// ============================================================
+// Safe File Writes
+// ============================================================
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// writeFileAtomic replaces path with data without ever leaving a half-written file:
+// data goes to a temp file in the same directory which is then renamed over the target.
+// The target's permission bits are kept, and a read-only flag (files under Perforce or
+// Plastic SCM that were not checked out) is cleared so the write does not fail.
+func writeFileAtomic(path string, data []byte) error {
+	// A dry run writes straight into the overlay: no checkout, no temp file
+	if isDryRun() {
+		return fsys.WriteFile(path, data, 0644)
+	}
+	activeVCS.checkout(path)
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if mode&0200 == 0 {
+			mode |= 0200
+			if err := os.Chmod(path, mode); err != nil {
+				return fmt.Errorf("cannot clear read-only flag on %s: %v", path, err)
+			}
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Retry briefly: editors, indexers and antivirus can hold the target open on Windows
+	for attempt := 0; ; attempt++ {
+		err = os.Rename(tmpPath, path)
+		if err == nil || attempt == 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// textFormat records the BOM and line-ending style of a text file so rewrites keep it
+type textFormat struct {
+	bom  bool
+	crlf bool // ending of most lines, and of the lines a rewrite adds
+	// Mixed files only: the text as read (LF) and the ending of each of its line
+	// breaks, 'r' (CRLF) or 'n' (LF). Strings keep textFormat comparable.
+	orig string
+	eols string
+}
+
+// decodeText strips a UTF-8 BOM and normalizes line endings to LF. A file counts as
+// CRLF when most of its line breaks are CRLF; a file with both keeps the ending of
+// every line, so a rewrite only changes the endings of the lines it edits.
+func decodeText(data []byte) (string, textFormat) {
+	var format textFormat
+	if bytes.HasPrefix(data, utf8BOM) {
+		format.bom = true
+		data = data[len(utf8BOM):]
+	}
+	crlfCount := bytes.Count(data, []byte("\r\n"))
+	lfCount := bytes.Count(data, []byte("\n")) - crlfCount
+	format.crlf = crlfCount > 0 && crlfCount >= lfCount
+	if crlfCount == 0 {
+		return string(data), format
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if lfCount > 0 {
+		eols := make([]byte, 0, crlfCount+lfCount)
+		for i, c := range data {
+			if c == '\n' {
+				if i > 0 && data[i-1] == '\r' {
+					eols = append(eols, 'r')
+				} else {
+					eols = append(eols, 'n')
+				}
+			}
+		}
+		format.orig, format.eols = text, string(eols)
+	}
+	return text, format
+}
+
+// encode converts LF text back to the recorded format
+func (f textFormat) encode(text string) []byte {
+	switch {
+	case f.eols != "":
+		text = f.mixedEndings(text)
+	case f.crlf:
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	if f.bom {
+		return append(append([]byte{}, utf8BOM...), text...)
+	}
+	return []byte(text)
+}
+
+// mixedEndings gives the lines of a rewritten mixed file the endings they had. The
+// unchanged lines before and after the edited region keep theirs; edited lines keep
+// the ending of the line they replace when the line count did not change, and get
+// the majority ending otherwise.
+func (f textFormat) mixedEndings(text string) string {
+	old := strings.Split(f.orig, "\n")
+	lines := strings.Split(text, "\n")
+	prefix := 0
+	for prefix < len(old) && prefix < len(lines) && old[prefix] == lines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(lines)-prefix && old[len(old)-1-suffix] == lines[len(lines)-1-suffix] {
+		suffix++
+	}
+	crlfAt := func(j int) bool {
+		if j >= 0 && j < len(f.eols) {
+			return f.eols[j] == 'r'
+		}
+		return f.crlf
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		b.WriteString(line)
+		if i == len(lines)-1 {
+			break
+		}
+		crlf := f.crlf
+		switch {
+		case i < prefix || len(lines) == len(old):
+			crlf = crlfAt(i)
+		case i >= len(lines)-suffix:
+			crlf = crlfAt(i - len(lines) + len(old))
+		}
+		if crlf {
+			b.WriteByte('\r')
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// writeTextFileAtomic writes LF text in the given format via writeFileAtomic
+func writeTextFileAtomic(path, text string, format textFormat) error {
+	return writeFileAtomic(path, format.encode(text))
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Filesystem
+// ============================================================
+
+// fileSystem is every filesystem call that can change the project. The real
+// run uses osFS; -dry-run swaps in an overlayFS so the same code path runs
+// and the overlay reports what it would have changed.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Walk(root string, fn filepath.WalkFunc) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Chmod(name string, mode os.FileMode) error
+}
+
+var fsys fileSystem = osFS{}
+
+// isDryRun reports whether changes are going to the in-memory overlay
+func isDryRun() bool {
+	_, ok := fsys.(*overlayFS)
+	return ok
+}
+
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)         { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) Walk(root string, fn filepath.WalkFunc) error { return filepath.Walk(root, fn) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// fsChange is one change the overlay absorbed instead of the disk
+type fsChange struct {
+	op     string // "write", "create", "mkdir", "rename", "delete"
+	path   string
+	detail string
+}
+
+// overlayFS reads through to the disk and keeps every write, rename and
+// delete in memory. Later reads see those changes, so multi-step operations
+// (rename a folder, then rewrite files inside it) behave as they would for real.
+type overlayFS struct {
+	mu      sync.Mutex
+	files   map[string][]byte // files written during the run
+	dirs    map[string]bool   // directories created during the run
+	moved   map[string]string // renamed path -> where its content is on disk
+	removed map[string]bool   // deleted paths; hides everything below them
+	changes []fsChange
+}
+
+func newOverlayFS() *overlayFS {
+	return &overlayFS{
+		files:   map[string][]byte{},
+		dirs:    map[string]bool{},
+		moved:   map[string]string{},
+		removed: map[string]bool{},
+	}
+}
+
+func overlayKey(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// isUnder reports whether p is base or inside it
+func isUnder(p, base string) bool {
+	return p == base || strings.HasPrefix(p, strings.TrimSuffix(base, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// locate finds where the content of p lives: in memory (data / memDir) or
+// on disk (disk, after following renames). ok is false if p was deleted.
+func (o *overlayFS) locate(p string) (data []byte, memFile, memDir bool, disk string, ok bool) {
+	if d, found := o.files[p]; found {
+		return d, true, false, "", true
+	}
+	if o.dirs[p] {
+		return nil, false, true, "", true
+	}
+	for q := p; ; {
+		if src, found := o.moved[q]; found {
+			rel, _ := filepath.Rel(q, p)
+			return nil, false, false, filepath.Join(src, rel), true
+		}
+		if o.removed[q] {
+			return nil, false, false, "", false
+		}
+		parent := filepath.Dir(q)
+		if parent == q {
+			break
+		}
+		q = parent
+	}
+	return nil, false, false, p, true
+}
+
+func (o *overlayFS) stat(p string) (os.FileInfo, error) {
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	case memFile:
+		return memFileInfo{name: filepath.Base(p), size: int64(len(data))}, nil
+	case memDir:
+		return memFileInfo{name: filepath.Base(p), dir: true}, nil
+	}
+	info, err := os.Stat(disk)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	if disk != p {
+		return renamedFileInfo{info, filepath.Base(p)}, nil
+	}
+	return info, nil
+}
+
+func (o *overlayFS) Stat(name string) (os.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stat(overlayKey(name))
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case memDir:
+		return nil, &os.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	case memFile:
+		return append([]byte(nil), data...), nil
+	}
+	return os.ReadFile(disk)
+}
+
+func (o *overlayFS) readDir(p string) ([]os.DirEntry, error) {
+	_, memFile, memDir, disk, ok := o.locate(p)
+	if !ok || memFile {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+	names := map[string]bool{}
+	if !memDir {
+		entries, err := os.ReadDir(disk)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			names[e.Name()] = true
+		}
+	}
+	for k := range o.dirs {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.files {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.moved {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	var result []os.DirEntry
+	for name := range names {
+		if info, err := o.stat(filepath.Join(p, name)); err == nil {
+			result = append(result, fs.FileInfoToDirEntry(info))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (o *overlayFS) ReadDir(name string) ([]os.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readDir(overlayKey(name))
+}
+
+// Walk follows filepath.Walk: lexical order, SkipDir and SkipAll honored.
+// The lock is released while fn runs so the callback can change the overlay.
+func (o *overlayFS) Walk(root string, fn filepath.WalkFunc) error {
+	info, err := o.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = o.walk(root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (o *overlayFS) walk(path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	entries, err := o.ReadDir(path)
+	if err1 := fn(path, info, err); err != nil || err1 != nil {
+		return err1
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		childInfo, err := o.Stat(child)
+		if err != nil {
+			if err := fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := o.walk(child, childInfo, fn); err != nil {
+			if err == filepath.SkipDir && childInfo.IsDir() {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *overlayFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	op := "create"
+	if info, err := o.stat(p); err == nil {
+		if info.IsDir() {
+			return &os.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+		}
+		op = "write"
+	}
+	if info, err := o.stat(filepath.Dir(p)); err != nil || !info.IsDir() {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	o.files[p] = append([]byte(nil), data...)
+	o.changes = append(o.changes, fsChange{op, p, fmt.Sprintf("%d bytes", len(data))})
+	return nil
+}
+
+func (o *overlayFS) MkdirAll(path string, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	created := false
+	for q := p; ; q = filepath.Dir(q) {
+		if info, err := o.stat(q); err == nil {
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: q, Err: errors.New("not a directory")}
+			}
+			break
+		}
+		o.dirs[q] = true
+		created = true
+		if filepath.Dir(q) == q {
+			break
+		}
+	}
+	if created {
+		o.changes = append(o.changes, fsChange{"mkdir", p, ""})
+	}
+	return nil
+}
+
+// shift moves every overlay entry under from to the same place under to
+func (o *overlayFS) shift(from, to string) {
+	for k, v := range o.files {
+		if isUnder(k, from) {
+			delete(o.files, k)
+			o.files[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, from) {
+			delete(o.dirs, k)
+			o.dirs[to+strings.TrimPrefix(k, from)] = true
+		}
+	}
+	for k, v := range o.moved {
+		if isUnder(k, from) {
+			delete(o.moved, k)
+			o.moved[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+}
+
+func (o *overlayFS) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	from, to := overlayKey(oldpath), overlayKey(newpath)
+	_, _, _, disk, ok := o.locate(from)
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if from == to {
+		return nil
+	}
+	if info, err := o.stat(filepath.Dir(to)); err != nil || !info.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	o.drop(to)
+	o.shift(from, to)
+	if disk != "" {
+		if _, err := os.Stat(disk); err == nil {
+			o.moved[to] = disk
+		}
+	}
+	o.removed[from] = true
+	o.changes = append(o.changes, fsChange{"rename", from, to})
+	return nil
+}
+
+// drop forgets p and everything below it and hides it from the disk
+func (o *overlayFS) drop(p string) {
+	for k := range o.files {
+		if isUnder(k, p) {
+			delete(o.files, k)
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, p) {
+			delete(o.dirs, k)
+		}
+	}
+	for k := range o.moved {
+		if isUnder(k, p) {
+			delete(o.moved, k)
+		}
+	}
+	o.removed[p] = true
+}
+
+// treeSize is the byte size of p as the overlay currently sees it
+func (o *overlayFS) treeSize(p string) int64 {
+	info, err := o.stat(p)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var size int64
+	entries, _ := o.readDir(p)
+	for _, e := range entries {
+		size += o.treeSize(filepath.Join(p, e.Name()))
+	}
+	return size
+}
+
+func (o *overlayFS) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	info, err := o.stat(p)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	if info.IsDir() {
+		if entries, _ := o.readDir(p); len(entries) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", info.Size())})
+	return nil
+}
+
+func (o *overlayFS) RemoveAll(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	if _, err := o.stat(p); err != nil {
+		return nil
+	}
+	size := o.treeSize(p)
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", size)})
+	return nil
+}
+
+func (o *overlayFS) Chmod(name string, mode os.FileMode) error {
+	if _, err := o.Stat(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+// noteCommand lists a step the overlay cannot simulate, such as a p4 or cm
+// command
+func (o *overlayFS) noteCommand(args ...string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.changes = append(o.changes, fsChange{"run", strings.Join(args, " "), ""})
+}
+
+// printDryRunReport lists what the overlay absorbed, relative to base
+func (o *overlayFS) printDryRunReport(base string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if abs, err := filepath.Abs(base); err == nil {
+		base = abs
+	}
+	if len(o.changes) == 0 {
+		fmt.Println(tr("\n[Dry Run] No changes would be made."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d change(s) would be made; nothing was written:\n"), len(o.changes))
+	rel := func(p string) string {
+		if r, err := filepath.Rel(base, p); err == nil && !strings.HasPrefix(r, "..") {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	for _, c := range o.changes {
+		switch c.op {
+		case "rename":
+			fmt.Printf("  %-7s %s -> %s\n", c.op, rel(c.path), rel(c.detail))
+		case "mkdir", "run":
+			fmt.Printf("  %-7s %s\n", c.op, rel(c.path))
+		default:
+			fmt.Printf("  %-7s %s (%s)\n", c.op, rel(c.path), c.detail)
+		}
+	}
+}
+
+// memFileInfo describes a file or directory that only exists in the overlay
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (m memFileInfo) Name() string       { return m.name }
+func (m memFileInfo) Size() int64        { return m.size }
+func (m memFileInfo) ModTime() time.Time { return time.Time{} }
+func (m memFileInfo) IsDir() bool        { return m.dir }
+func (m memFileInfo) Sys() interface{}   { return nil }
+func (m memFileInfo) Mode() os.FileMode {
+	if m.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// renamedFileInfo is a disk entry seen under the name it was renamed to
+type renamedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (r renamedFileInfo) Name() string { return r.name }
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"Usage: unity_editor_menu [flags] [status|install|remove]":         "用法: unity_editor_menu [参数] [status|install|remove]",
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  EDITOR MENU":             "  编辑器菜单",
+	"  Package:           %s\n": "  包:                %s\n",
+	"  Tools folder:      %s\n": "  工具文件夹:        %s\n",
+	"  Installed version: %s\n": "  已安装版本:        %s\n",
+	"  Bundled version:   %d\n": "  内置版本:          %d\n",
+	"[OK] Up to date":           "[OK] 已是最新",
+	"[--] Missing":              "[--] 缺失",
+	"[WARNING] Outdated":        "[WARNING] 已过期",
+	"[WARNING] Edited locally":  "[WARNING] 已在本地修改",
+	"\n[WARNING] No tool executables in %s; the menu falls back to PATH.\n":                             "\n[WARNING] %s 中没有工具可执行文件；菜单将改从 PATH 中查找。\n",
+	"          Point it at them with -tools-dir, or per machine with Tools > Starter > Tools Folder...": "          请使用 -tools-dir 指定，或在每台机器上通过 Tools > Starter > Tools Folder... 设置",
+	"\nRun 'unity_editor_menu install' to install or update the editor menu.":                           "\n运行 'unity_editor_menu install' 安装或更新编辑器菜单。",
+	"\n[OK] The editor menu is up to date: Tools > Starter in Unity.":                                   "\n[OK] 编辑器菜单已是最新：位于 Unity 的 Tools > Starter。",
+	"\n[WARNING] %d edited file(s) are kept; use -force to replace them.\n":                             "\n[WARNING] 保留了 %d 个本地修改过的文件；使用 -force 可替换它们。\n",
+	"\n[--] Nothing to install.":   "\n[--] 没有需要安装的内容。",
+	"\n[--] Nothing to remove.":    "\n[--] 没有需要移除的内容。",
+	"\nVersion control: %s (%s)\n": "\n版本控制: %s (%s)\n",
+	"\n[Dry Run] Changes go to an in-memory copy; nothing is written to disk": "\n[Dry Run] 所有更改只写入内存副本，不会写入磁盘",
+	"\nInstall or update %d file(s) in %s? (y/N): ":                           "\n是否在 %[2]s 中安装或更新 %[1]d 个文件？(y/N): ",
+	"\nRemove %d file(s) from %s? (y/N): ":                                    "\n是否从 %[2]s 中移除 %[1]d 个文件？(y/N): ",
+	"Operation cancelled.":                                                    "操作已取消。",
+	"[OK] Created folder: %s\n":                                               "[OK] 已创建文件夹: %s\n",
+	"[ERROR] Cannot create %s: %v\n":                                          "[ERROR] 无法创建 %s: %v\n",
+	"[ERROR] Cannot write %s: %v\n":                                           "[ERROR] 无法写入 %s: %v\n",
+	"[OK] Installed: %s\n":                                                    "[OK] 已安装: %s\n",
+	"[OK] Updated: %s\n":                                                      "[OK] 已更新: %s\n",
+	"\n[OK] Switch to Unity: the menu appears under Tools > Starter once the package is imported.": "\n[OK] 切换到 Unity：包导入完成后，菜单会出现在 Tools > Starter 下。",
+	"[ERROR] Cannot remove %s: %v\n":     "[ERROR] 无法移除 %s: %v\n",
+	"[OK] Removed: %s\n":                 "[OK] 已移除: %s\n",
+	"[OK] Removed empty folder: %s\n":    "[OK] 已移除空文件夹: %s\n",
+	"\n[Dry Run] No files were written.": "\n[Dry Run] 未写入任何文件。",
+
+	"[WARNING] %s checkout failed for %s: %v\n":                 "[WARNING] %s 签出 %s 失败: %v\n",
+	"[VCS] Checked out (%s): %s\n":                              "[VCS] 已签出 (%s): %s\n",
+	"[WARNING] %s add failed for %s: %v\n":                      "[WARNING] %s 添加 %s 失败: %v\n",
+	"[WARNING] %s delete failed for %s, deleting on disk: %v\n": "[WARNING] %s 删除 %s 失败，改为直接在磁盘上删除: %v\n",
+	"\nPress Enter to continue...":                              "\n按回车键继续...",
+
+	"\n[Dry Run] No changes would be made.":                          "\n[Dry Run] 不会做任何更改。",
+	"\n[Dry Run] %d change(s) would be made; nothing was written:\n": "\n[Dry Run] 将会进行 %d 项更改，未写入任何内容:\n",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	if status == "ok" && isDryRun() {
+		status = "planned"
+	}
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode, dryRun, force bool
+	var toolsDir, vcsMode string
+
+	flag.StringVar(&toolsDir, "tools-dir", "", "Folder with the tool executables, relative to the project root or absolute (default: from the manifest, else the folder of this executable)")
+	flag.BoolVar(&force, "force", false, "Also overwrite or remove package files that were edited locally")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no confirmation prompts)")
+	flag.BoolVar(&dryRun, "dry-run", false, "List what install/remove would change without touching disk")
+	flag.StringVar(&vcsMode, "vcs", "auto", "Add/checkout/delete through version control: auto, none, p4, plastic")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the command ("install -force")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_editor_menu")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+
+	command := "status"
+	if len(args) > 0 {
+		command = strings.ToLower(args[0])
+	}
+	if len(args) > 1 || (command != "status" && command != "install" && command != "remove") {
+		fmt.Println(tr("Usage: unity_editor_menu [flags] [status|install|remove]"))
+		recordError("expected status, install or remove")
+		exit(2)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+
+	manifest, err := loadManifest(basePath)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(1)
+	}
+	if toolsDir != "" {
+		toolsDir = relativeToolsDir(basePath, toolsDir)
+	} else if manifest != nil && manifest.ToolsDir != "" {
+		toolsDir = manifest.ToolsDir
+	} else {
+		toolsDir = defaultToolsDir(basePath)
+	}
+
+	statuses := checkStatus(basePath, toolsDir, manifest)
+	installed := "-"
+	if manifest != nil {
+		installed = strconv.Itoa(manifest.MenuVersion)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  EDITOR MENU"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Package:           %s\n"), packageDir)
+	fmt.Printf(tr("  Tools folder:      %s\n"), toolsDir)
+	fmt.Printf(tr("  Installed version: %s\n"), installed)
+	fmt.Printf(tr("  Bundled version:   %d\n"), menuVersion)
+	fmt.Println()
+	labels := map[string]string{
+		stateCurrent:  tr("[OK] Up to date"),
+		stateMissing:  tr("[--] Missing"),
+		stateOutdated: tr("[WARNING] Outdated"),
+		stateModified: tr("[WARNING] Edited locally"),
+	}
+	pending := 0
+	for _, st := range statuses {
+		fmt.Printf("  %-40s %s\n", st.file.name, labels[st.state])
+		if st.state != stateCurrent {
+			pending++
+		}
+	}
+	if command != "remove" && !hasMenuTools(basePath, toolsDir) {
+		fmt.Printf(tr("\n[WARNING] No tool executables in %s; the menu falls back to PATH.\n"), toolsDir)
+		fmt.Println(tr("          Point it at them with -tools-dir, or per machine with Tools > Starter > Tools Folder..."))
+	}
+
+	if command == "status" {
+		for _, st := range statuses {
+			recordAction("check", st.path, st.state, "", 0)
+		}
+		if pending > 0 {
+			fmt.Println(tr("\nRun 'unity_editor_menu install' to install or update the editor menu."))
+			exit(1)
+		}
+		fmt.Println(tr("\n[OK] The editor menu is up to date: Tools > Starter in Unity."))
+		exit(0)
+	}
+
+	// Decide what changes before asking
+	var work []fileStatus
+	kept := 0
+	for _, st := range statuses {
+		switch {
+		case command == "install" && st.state == stateCurrent:
+		case command == "remove" && st.state == stateMissing:
+		case st.state == stateModified && !force:
+			kept++
+		default:
+			work = append(work, st)
+		}
+	}
+	if kept > 0 {
+		fmt.Printf(tr("\n[WARNING] %d edited file(s) are kept; use -force to replace them.\n"), kept)
+	}
+	if len(work) == 0 {
+		if command == "install" {
+			fmt.Println(tr("\n[--] Nothing to install."))
+		} else {
+			fmt.Println(tr("\n[--] Nothing to remove."))
+		}
+		exit(0)
+	}
+
+	activeVCS, err = detectVCS(basePath, vcsMode)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(1)
+	}
+	if activeVCS.kind != vcsNone {
+		fmt.Printf(tr("\nVersion control: %s (%s)\n"), activeVCS.kind, activeVCS.source)
+	}
+
+	if dryRun {
+		fmt.Println(tr("\n[Dry Run] Changes go to an in-memory copy; nothing is written to disk"))
+		fsys = newOverlayFS()
+	} else if !ciMode {
+		question := tr("\nInstall or update %d file(s) in %s? (y/N): ")
+		if command == "remove" {
+			question = tr("\nRemove %d file(s) from %s? (y/N): ")
+		}
+		fmt.Printf(question, len(work), packageDir)
+		confirm, _ := stdinReader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+			fmt.Println(tr("Operation cancelled."))
+			exit(0)
+		}
+	}
+
+	fmt.Println()
+	failed := 0
+	files := menuFiles(toolsDir)
+	if command == "install" {
+		created, err := ensureFolders(basePath, files)
+		for _, d := range created {
+			fmt.Printf(tr("[OK] Created folder: %s\n"), d)
+			recordAction("create", d, "ok", "folder", 0)
+		}
+		if err != nil {
+			fmt.Printf(tr("[ERROR] Cannot create %s: %v\n"), packageDir, err)
+			recordError("cannot create %s: %v", packageDir, err)
+			exit(1)
+		}
+
+		if manifest == nil {
+			manifest = &menuManifest{Files: make(map[string]string)}
+		}
+		for _, st := range work {
+			if err := installFile(basePath, st); err != nil {
+				fmt.Printf(tr("[ERROR] Cannot write %s: %v\n"), st.path, err)
+				recordAction("install", st.path, "failed", err.Error(), 0)
+				recordError("cannot write %s: %v", st.path, err)
+				failed++
+				continue
+			}
+			verb := tr("[OK] Installed: %s\n")
+			if st.state != stateMissing {
+				verb = tr("[OK] Updated: %s\n")
+			}
+			fmt.Printf(verb, st.path)
+			recordAction("install", st.path, "ok", st.state, 0)
+			manifest.Files[st.file.name] = textHash([]byte(st.file.content))
+		}
+		manifest.MenuVersion = menuVersion
+		manifest.ToolsDir = toolsDir
+		if err := saveManifest(basePath, manifest); err != nil {
+			fmt.Printf(tr("[ERROR] Cannot write %s: %v\n"), manifestFileName, err)
+			recordError("cannot write %s: %v", manifestFileName, err)
+			failed++
+		}
+		if failed == 0 {
+			fmt.Println(tr("\n[OK] Switch to Unity: the menu appears under Tools > Starter once the package is imported."))
+		}
+	} else {
+		for _, st := range work {
+			if err := removeFile(basePath, st.path); err != nil {
+				fmt.Printf(tr("[ERROR] Cannot remove %s: %v\n"), st.path, err)
+				recordAction("delete", st.path, "failed", err.Error(), 0)
+				recordError("cannot remove %s: %v", st.path, err)
+				failed++
+				continue
+			}
+			fmt.Printf(tr("[OK] Removed: %s\n"), st.path)
+			recordAction("delete", st.path, "ok", "", 0)
+			if manifest != nil {
+				delete(manifest.Files, st.file.name)
+			}
+		}
+		for _, d := range removeEmptyFolders(basePath, files) {
+			fmt.Printf(tr("[OK] Removed empty folder: %s\n"), d)
+		}
+		// Edited files that stay behind keep their manifest entries
+		if manifest != nil && len(manifest.Files) == 0 {
+			if err := activeVCS.remove(manifestPath(basePath)); err != nil {
+				fmt.Printf(tr("[ERROR] Cannot remove %s: %v\n"), manifestFileName, err)
+				recordError("cannot remove %s: %v", manifestFileName, err)
+				failed++
+			}
+		} else if manifest != nil {
+			if err := saveManifest(basePath, manifest); err != nil {
+				fmt.Printf(tr("[ERROR] Cannot write %s: %v\n"), manifestFileName, err)
+				recordError("cannot write %s: %v", manifestFileName, err)
+				failed++
+			}
+		}
+	}
+
+	if overlay, ok := fsys.(*overlayFS); ok {
+		overlay.printDryRunReport(basePath)
+		fmt.Println(tr("\n[Dry Run] No files were written."))
+	}
+	if failed > 0 {
+		exit(1)
+	}
+	exit(0)
+}
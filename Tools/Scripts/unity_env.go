@@ -0,0 +1,1120 @@
+// Unity Env — Capture the machine and project environment for a bug report.
+// Collects what an issue tracker asks for and people forget: the operating
+// system, CPU, memory and GPU, the project's Unity version and whether that
+// editor is installed, the packages with their resolved versions, the graphics
+// APIs and the player settings that change behaviour (color space, input
+// handling, scripting backend, stripping, Android and iOS targets...), and the
+// versions of git, Git LFS, FFmpeg, adb, Java and .NET. The result is one
+// Markdown block, collapsed under a <details> summary, with the user folder,
+// user name, machine name, email addresses and URL credentials replaced.
+//
+// Build: go build unity_env.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_env                              # print the block
+//	unity_env -copy                        # and put it on the clipboard
+//	unity_env -out env.md -all-packages    # write it, with indirect and built-in packages
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Each version or system command gets this long; a hung adb server must not
+// hold up the report
+const commandTimeout = 10 * time.Second
+
+var (
+	projectVersionRegex  = regexp.MustCompile(`(?m)^m_EditorVersion:\s*(\S+)`)
+	projectRevisionRegex = regexp.MustCompile(`(?m)^m_EditorVersionWithRevision:\s*\S+\s*\(([0-9a-f]+)\)`)
+	metaGUIDRegex        = regexp.MustCompile(`(?m)^guid: ([0-9a-fA-F]{32})`)
+	refRegex             = regexp.MustCompile(`fileID: (-?\d+)(?:, guid: ([0-9a-fA-F]{32}))?`)
+	emailRegex           = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	urlCredentialsRegex  = regexp.MustCompile(`(?i)([a-z][a-z0-9+.-]*://)[^/@\s]+@`)
+	gpuLineRegex         = regexp.MustCompile(`(?i)vga|3d|display`)
+	firstVersionRegex    = regexp.MustCompile(`\d+(?:\.\d+)+(?:[-_.+][0-9A-Za-z.]+)?`)
+)
+
+// versionCommands are the external tools the tools of this folder and Unity builds use
+var versionCommands = []struct {
+	name string
+	args []string
+}{
+	{"git", []string{"git", "--version"}},
+	{"Git LFS", []string{"git", "lfs", "version"}},
+	{"FFmpeg", []string{"ffmpeg", "-version"}},
+	{"adb", []string{"adb", "version"}},
+	{"Java", []string{"java", "-version"}},
+	{".NET SDK", []string{"dotnet", "--version"}},
+}
+
+// UnityEngine.Rendering.GraphicsDeviceType
+var graphicsAPINames = map[uint32]string{
+	2: "Direct3D11", 4: "Null", 8: "OpenGLES2", 11: "OpenGLES3", 16: "Metal",
+	17: "OpenGLCore", 18: "Direct3D12", 21: "Vulkan", 22: "Switch", 25: "WebGPU",
+}
+
+// playerSetting is one row of the Player Settings table. perPlatform fields
+// hold a map of platform to value in ProjectSettings.asset.
+type playerSetting struct {
+	label       string
+	field       string
+	perPlatform bool
+	values      map[string]string
+}
+
+var playerSettings = []playerSetting{
+	{label: "Version", field: "bundleVersion"},
+	{label: "Color space", field: "m_ActiveColorSpace", values: map[string]string{"0": "Gamma", "1": "Linear"}},
+	{label: "Active input handling", field: "activeInputHandler", values: map[string]string{"0": "Input Manager", "1": "Input System", "2": "Both"}},
+	{label: "Scripting backend", field: "scriptingBackend", perPlatform: true, values: map[string]string{"0": "Mono", "1": "IL2CPP"}},
+	{label: "API compatibility", field: "apiCompatibilityLevelPerPlatform", perPlatform: true, values: map[string]string{"3": ".NET Framework", "6": ".NET Standard"}},
+	{label: "IL2CPP configuration", field: "il2cppCompilerConfiguration", perPlatform: true, values: map[string]string{"0": "Debug", "1": "Release", "2": "Master"}},
+	{label: "Managed stripping", field: "managedStrippingLevel", perPlatform: true, values: map[string]string{"0": "Disabled", "1": "Low", "2": "Medium", "3": "High", "4": "Minimal"}},
+	{label: "Scripting defines", field: "scriptingDefineSymbols", perPlatform: true},
+	{label: "Graphics jobs", field: "graphicsJobs", values: map[string]string{"0": "Off", "1": "On"}},
+	{label: "GPU skinning", field: "gpuSkinning", values: map[string]string{"0": "Off", "1": "On"}},
+	{label: "Android min SDK", field: "AndroidMinSdkVersion"},
+	{label: "Android target SDK", field: "AndroidTargetSdkVersion", values: map[string]string{"0": "Highest installed"}},
+	{label: "Android architectures", field: "AndroidTargetArchitectures"},
+	{label: "iOS target version", field: "iOSTargetOSVersionString"},
+}
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// envRow is one "name | value" line of a table
+type envRow struct {
+	name  string
+	value string
+}
+
+type projectManifest struct {
+	Dependencies     map[string]string `json:"dependencies"`
+	ScopedRegistries []struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"scopedRegistries"`
+}
+
+// lockEntry is one package in Packages/packages-lock.json
+type lockEntry struct {
+	Version string `json:"version"`
+	Depth   int    `json:"depth"`
+	Source  string `json:"source"` // registry, builtin, embedded, local, git
+}
+
+type lockFile struct {
+	Dependencies map[string]lockEntry `json:"dependencies"`
+}
+
+// packageRow is one line of the Packages table
+type packageRow struct {
+	name, version, source string
+	direct                bool
+}
+
+// report is everything the Markdown block shows
+type report struct {
+	system     []envRow
+	unity      []envRow
+	packages   []packageRow
+	registries []envRow
+	settings   []envRow
+	graphics   []envRow
+	tools      []envRow
+}
+
+// ============================================================
+// System
+// ============================================================
+
+// runCommand returns the combined output of a command, "" when it is missing,
+// fails or times out. Combined, because java prints its version on stderr.
+func runCommand(args ...string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.ReplaceAll(string(out), "\r\n", "\n"))
+}
+
+func powershell(query string) string {
+	return runCommand("powershell", "-NoProfile", "-NonInteractive", "-Command", query)
+}
+
+// firstMatch returns the value after "key:" or "key=" on the first line that has it
+func firstMatch(text, key string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), key) {
+			v := strings.TrimSpace(strings.TrimSpace(line)[len(key):])
+			v = strings.TrimLeft(v, ":= \t")
+			return strings.Trim(v, `"`)
+		}
+	}
+	return ""
+}
+
+// systemRows describes the operating system and the hardware
+func systemRows() []envRow {
+	var osName, cpu, memory string
+	var gpus []string
+	switch runtime.GOOS {
+	case "windows":
+		osName = powershell("$o = Get-CimInstance Win32_OperatingSystem; \"$($o.Caption) $($o.Version)\"")
+		cpu = powershell("(Get-CimInstance Win32_Processor | Select-Object -First 1).Name")
+		if kb, err := strconv.ParseInt(powershell("(Get-CimInstance Win32_OperatingSystem).TotalVisibleMemorySize"), 10, 64); err == nil {
+			memory = formatSize(kb * 1024)
+		}
+		for _, l := range strings.Split(powershell("Get-CimInstance Win32_VideoController | ForEach-Object { \"$($_.Name) (driver $($_.DriverVersion))\" }"), "\n") {
+			if l = strings.TrimSpace(l); l != "" {
+				gpus = append(gpus, l)
+			}
+		}
+	case "darwin":
+		osName = strings.TrimSpace(runCommand("sw_vers", "-productName") + " " + runCommand("sw_vers", "-productVersion") + " (" + runCommand("sw_vers", "-buildVersion") + ")")
+		cpu = runCommand("sysctl", "-n", "machdep.cpu.brand_string")
+		if b, err := strconv.ParseInt(runCommand("sysctl", "-n", "hw.memsize"), 10, 64); err == nil {
+			memory = formatSize(b)
+		}
+		for _, l := range strings.Split(runCommand("system_profiler", "SPDisplaysDataType"), "\n") {
+			if v := firstMatch(l, "Chipset Model"); v != "" {
+				gpus = append(gpus, v)
+			}
+		}
+	default:
+		if data, err := os.ReadFile("/etc/os-release"); err == nil {
+			osName = firstMatch(string(data), "PRETTY_NAME")
+		}
+		osName = strings.TrimSpace(osName + " (kernel " + runCommand("uname", "-r") + ")")
+		if data, err := os.ReadFile("/proc/cpuinfo"); err == nil {
+			cpu = firstMatch(string(data), "model name")
+		}
+		if data, err := os.ReadFile("/proc/meminfo"); err == nil {
+			if kb, err := strconv.ParseInt(strings.TrimSuffix(firstMatch(string(data), "MemTotal"), " kB"), 10, 64); err == nil {
+				memory = formatSize(kb * 1024)
+			}
+		}
+		for _, l := range strings.Split(runCommand("lspci"), "\n") {
+			if !gpuLineRegex.MatchString(l) {
+				continue
+			}
+			// 01:00.0 VGA compatible controller: NVIDIA Corporation ...
+			if i := strings.Index(l, ": "); i >= 0 {
+				gpus = append(gpus, strings.TrimSpace(l[i+2:]))
+			}
+		}
+	}
+	rows := []envRow{
+		{"OS", orUnknown(osName)},
+		{"Architecture", runtime.GOARCH},
+		{"CPU", fmt.Sprintf("%s (%d logical cores)", orUnknown(cpu), runtime.NumCPU())},
+		{"Memory", orUnknown(memory)},
+	}
+	if len(gpus) == 0 {
+		gpus = []string{"unknown"}
+	}
+	for _, g := range gpus {
+		rows = append(rows, envRow{"GPU", g})
+	}
+	return rows
+}
+
+// toolRows lists the version of each external tool, or that it is missing
+func toolRows() []envRow {
+	rows := make([]envRow, len(versionCommands))
+	var wg sync.WaitGroup
+	for i, c := range versionCommands {
+		wg.Add(1)
+		go func(i int, name string, args []string) {
+			defer wg.Done()
+			// Empty when the tool is missing, "git lfs" included
+			rows[i] = envRow{name, "not found"}
+			out := runCommand(args...)
+			if v := firstVersionRegex.FindString(out); v != "" {
+				rows[i].value = v
+			} else if out != "" {
+				rows[i].value = strings.SplitN(out, "\n", 2)[0]
+			}
+		}(i, c.name, c.args)
+	}
+	wg.Wait()
+	return rows
+}
+
+func orUnknown(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "unknown"
+	}
+	return s
+}
+
+// ============================================================
+// Unity Editor
+// ============================================================
+
+// readProjectVersion returns the editor version and changeset of the project
+func readProjectVersion(basePath string) (string, string) {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectVersion.txt"))
+	if err != nil {
+		return "", ""
+	}
+	var version, revision string
+	if m := projectVersionRegex.FindSubmatch(data); m != nil {
+		version = string(m[1])
+	}
+	if m := projectRevisionRegex.FindSubmatch(data); m != nil {
+		revision = string(m[1])
+	}
+	return version, revision
+}
+
+// hubEditorFolders returns the folders Unity Hub installs editors into: the
+// default location plus the custom one from secondaryInstallPath.json
+func hubEditorFolders() []string {
+	home, _ := os.UserHomeDir()
+	var folders []string
+	var hubConfig string
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramW6432"} {
+			if pf := os.Getenv(env); pf != "" {
+				folders = append(folders, filepath.Join(pf, "Unity", "Hub", "Editor"))
+			}
+		}
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			hubConfig = filepath.Join(appData, "UnityHub")
+		}
+	case "darwin":
+		folders = append(folders, "/Applications/Unity/Hub/Editor")
+		hubConfig = filepath.Join(home, "Library", "Application Support", "UnityHub")
+	default:
+		folders = append(folders, filepath.Join(home, "Unity", "Hub", "Editor"))
+		hubConfig = filepath.Join(home, ".config", "UnityHub")
+	}
+	if hubConfig != "" {
+		if data, err := os.ReadFile(filepath.Join(hubConfig, "secondaryInstallPath.json")); err == nil {
+			var custom string
+			if json.Unmarshal(data, &custom) == nil && custom != "" {
+				folders = append(folders, custom)
+			}
+		}
+	}
+	return folders
+}
+
+// editorExecutable is the Unity binary inside an editor version folder
+func editorExecutable(dir string) string {
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(dir, "Editor", "Unity.exe")
+	case "darwin":
+		return filepath.Join(dir, "Unity.app", "Contents", "MacOS", "Unity")
+	default:
+		return filepath.Join(dir, "Editor", "Unity")
+	}
+}
+
+// installedEditors lists the versions installed through the Hub
+func installedEditors() []string {
+	seen := make(map[string]bool)
+	var versions []string
+	for _, folder := range hubEditorFolders() {
+		entries, err := os.ReadDir(folder)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if _, err := os.Stat(editorExecutable(filepath.Join(folder, e.Name()))); err == nil && !seen[e.Name()] {
+				seen[e.Name()] = true
+				versions = append(versions, e.Name())
+			}
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// unityRows describes the project and its editor
+func unityRows(basePath string) []envRow {
+	version, revision := readProjectVersion(basePath)
+	if revision != "" {
+		version += " (" + revision + ")"
+	}
+	installed := installedEditors()
+	editor := "not installed"
+	for _, v := range installed {
+		if strings.HasPrefix(version, v) {
+			editor = "installed"
+		}
+	}
+	rows := []envRow{
+		{"Project version", orUnknown(version)},
+		{"Editor", editor},
+	}
+	if len(installed) > 0 {
+		rows = append(rows, envRow{"Installed editors", strings.Join(installed, ", ")})
+	}
+	if pipeline := renderPipeline(basePath); pipeline != "" {
+		rows = append(rows, envRow{"Render pipeline", pipeline})
+	}
+	if branch := runCommand("git", "-C", basePath, "rev-parse", "--abbrev-ref", "HEAD"); branch != "" {
+		commit := runCommand("git", "-C", basePath, "rev-parse", "--short", "HEAD")
+		if runCommand("git", "-C", basePath, "status", "--porcelain", "--untracked-files=no") != "" {
+			commit += ", uncommitted changes"
+		}
+		rows = append(rows, envRow{"Git", branch + " @ " + commit})
+	}
+	return rows
+}
+
+// ============================================================
+// Packages
+// ============================================================
+
+// readPackages returns the packages of the project, from packages-lock.json
+// when it exists, else the direct dependencies of manifest.json
+func readPackages(basePath string, all bool) ([]packageRow, []envRow, error) {
+	data, err := os.ReadFile(filepath.Join(basePath, "Packages", "manifest.json"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read Packages/manifest.json: %v", err)
+	}
+	var manifest projectManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("invalid Packages/manifest.json: %v", err)
+	}
+	var registries []envRow
+	for _, r := range manifest.ScopedRegistries {
+		registries = append(registries, envRow{r.Name, r.URL})
+	}
+
+	var lock lockFile
+	if data, err := os.ReadFile(filepath.Join(basePath, "Packages", "packages-lock.json")); err == nil {
+		json.Unmarshal(data, &lock)
+	}
+	var rows []packageRow
+	if len(lock.Dependencies) == 0 {
+		for name, version := range manifest.Dependencies {
+			rows = append(rows, packageRow{name: name, version: version, direct: true})
+		}
+	}
+	for name, e := range lock.Dependencies {
+		_, direct := manifest.Dependencies[name]
+		if !all && (!direct || e.Source == "builtin") {
+			continue
+		}
+		rows = append(rows, packageRow{name: name, version: e.Version, source: e.Source, direct: direct})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].name < rows[j].name })
+	return rows, registries, nil
+}
+
+// ============================================================
+// Project Settings
+// ============================================================
+
+// fieldLine splits "    key: value" at the given indent; ok is false for any
+// other line, list items included
+func fieldLine(line string, indent int) (key, value string, ok bool) {
+	if len(line) <= indent || strings.TrimLeft(line[:indent], " ") != "" || line[indent] == ' ' || line[indent] == '-' {
+		return "", "", false
+	}
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return "", "", false
+	}
+	return line[indent:i], strings.TrimSpace(line[i+1:]), true
+}
+
+func yamlUnquote(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// readPlayerSettings reads the top-level fields of ProjectSettings.asset and
+// the platform maps nested under them ("scriptingBackend:\n    Android: 1")
+func readPlayerSettings(lines []string) (map[string]string, map[string]map[string]string) {
+	fields := make(map[string]string)
+	maps := make(map[string]map[string]string)
+	current := ""
+	for _, l := range lines {
+		l = strings.TrimRight(l, "\r")
+		if key, value, ok := fieldLine(l, 2); ok {
+			fields[key] = yamlUnquote(value)
+			current = ""
+			if value == "" {
+				current = key
+				maps[key] = make(map[string]string)
+			}
+			continue
+		}
+		if current == "" {
+			continue
+		}
+		if key, value, ok := fieldLine(l, 4); ok {
+			maps[current][key] = yamlUnquote(value)
+		}
+	}
+	return fields, maps
+}
+
+// androidArchitectures names the bits of AndroidTargetArchitectures
+func androidArchitectures(value string) string {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return value
+	}
+	var names []string
+	for _, a := range []struct {
+		bit  int
+		name string
+	}{{1, "ARMv7"}, {2, "ARM64"}, {4, "x86"}, {8, "x86-64"}} {
+		if n&a.bit != 0 {
+			names = append(names, a.name)
+		}
+	}
+	if len(names) == 0 {
+		return value
+	}
+	return strings.Join(names, ", ")
+}
+
+// graphicsAPIs decodes m_APIs: the device types as little-endian uint32s in hex
+func graphicsAPIs(hexList string) string {
+	var names []string
+	for i := 0; i+8 <= len(hexList); i += 8 {
+		var v uint32
+		for b := 3; b >= 0; b-- {
+			n, err := strconv.ParseUint(hexList[i+b*2:i+b*2+2], 16, 8)
+			if err != nil {
+				return hexList
+			}
+			v = v<<8 | uint32(n)
+		}
+		if name, ok := graphicsAPINames[v]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, strconv.FormatUint(uint64(v), 10))
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// settingsRows reads the Player Settings and Graphics API tables
+func settingsRows(basePath string) ([]envRow, []envRow, error) {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectSettings.asset"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read ProjectSettings/ProjectSettings.asset: %v", err)
+	}
+	lines := strings.Split(string(data), "\n")
+	fields, maps := readPlayerSettings(lines)
+
+	label := func(s playerSetting, raw string) string {
+		if l, ok := s.values[raw]; ok {
+			return l
+		}
+		if s.field == "AndroidTargetArchitectures" {
+			return androidArchitectures(raw)
+		}
+		return raw
+	}
+	var settings []envRow
+	for _, s := range playerSettings {
+		if !s.perPlatform {
+			if raw, ok := fields[s.field]; ok && raw != "" {
+				settings = append(settings, envRow{s.label, label(s, raw)})
+			}
+			continue
+		}
+		platforms := make([]string, 0, len(maps[s.field]))
+		for p := range maps[s.field] {
+			platforms = append(platforms, p)
+		}
+		sort.Strings(platforms)
+		for _, p := range platforms {
+			if raw := maps[s.field][p]; raw != "" {
+				settings = append(settings, envRow{s.label + " (" + p + ")", label(s, raw)})
+			}
+		}
+	}
+	if editor, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "EditorSettings.asset")); err == nil {
+		editorFields, _ := readPlayerSettings(strings.Split(string(editor), "\n"))
+		switch editorFields["m_EnterPlayModeOptionsEnabled"] {
+		case "1":
+			settings = append(settings, envRow{"Enter Play Mode options", "On (options " + editorFields["m_EnterPlayModeOptions"] + ")"})
+		case "0":
+			settings = append(settings, envRow{"Enter Play Mode options", "Off"})
+		}
+	}
+
+	// m_BuildTargetGraphicsAPIs:
+	// - m_BuildTarget: AndroidPlayer
+	//   m_APIs: 150000000b000000
+	//   m_Automatic: 1
+	var graphics []envRow
+	var target, apis string
+	flush := func() {
+		if target == "" {
+			return
+		}
+		value := graphicsAPIs(apis)
+		if value == "" {
+			value = "Automatic"
+		}
+		graphics = append(graphics, envRow{target, value})
+		target, apis = "", ""
+	}
+	inList := false
+	for _, l := range lines {
+		l = strings.TrimRight(l, "\r")
+		t := strings.TrimSpace(l)
+		switch {
+		case strings.HasPrefix(l, "  m_BuildTargetGraphicsAPIs:"):
+			inList = true
+		case !inList:
+		case strings.HasPrefix(t, "- m_BuildTarget:"):
+			flush()
+			target = strings.TrimSpace(strings.TrimPrefix(t, "- m_BuildTarget:"))
+		case strings.HasPrefix(t, "m_APIs:"):
+			apis = strings.TrimSpace(strings.TrimPrefix(t, "m_APIs:"))
+		case strings.HasPrefix(t, "m_Automatic:"):
+			if strings.TrimSpace(strings.TrimPrefix(t, "m_Automatic:")) == "1" {
+				apis = ""
+			}
+		case !strings.HasPrefix(l, "  - ") && !strings.HasPrefix(l, "    "):
+			inList = false
+		}
+	}
+	flush()
+	return settings, graphics, nil
+}
+
+// renderPipeline names the render pipeline asset of GraphicsSettings, or the
+// built-in renderer
+func renderPipeline(basePath string) string {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "GraphicsSettings.asset"))
+	if err != nil {
+		return ""
+	}
+	fields, _ := readPlayerSettings(strings.Split(string(data), "\n"))
+	m := refRegex.FindStringSubmatch(fields["m_CustomRenderPipeline"])
+	if m == nil || m[1] == "0" || m[2] == "" {
+		return "Built-in"
+	}
+	guid := strings.ToLower(m[2])
+	found := ""
+	filepath.Walk(filepath.Join(basePath, "Assets"), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if found != "" {
+			return io.EOF
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".asset.meta") {
+			return nil
+		}
+		if meta, err := os.ReadFile(path); err == nil {
+			if g := metaGUIDRegex.FindSubmatch(meta); g != nil && strings.ToLower(string(g[1])) == guid {
+				found = relPath(basePath, strings.TrimSuffix(path, ".meta"))
+			}
+		}
+		return nil
+	})
+	if found == "" {
+		return "asset " + guid
+	}
+	return found
+}
+
+// ============================================================
+// Markdown
+// ============================================================
+
+// redactor replaces the user folder, the user name and the machine name, plus
+// email addresses and credentials in URLs. Paths are matched with both
+// separators.
+type redactor struct {
+	rules []redactRule
+}
+
+type redactRule struct {
+	re   *regexp.Regexp
+	with string
+}
+
+func newRedactor() *redactor {
+	r := &redactor{}
+	home, _ := os.UserHomeDir()
+	if home != "" && filepath.Dir(home) != home {
+		forms := []string{filepath.ToSlash(home)}
+		if runtime.GOOS == "windows" {
+			forms = append(forms, home)
+		}
+		for _, form := range forms {
+			r.add(`(?i)`+regexp.QuoteMeta(form)+`\b`, "<home>")
+		}
+		if user := filepath.Base(home); len(user) > 1 {
+			r.add(`(?i)((?:Users|home)(?:\\|/))`+regexp.QuoteMeta(user)+`\b`, "${1}<user>")
+		}
+	}
+	if host, _ := os.Hostname(); len(host) > 2 {
+		r.add(`(?i)\b`+regexp.QuoteMeta(host)+`\b`, "<host>")
+	}
+	r.rules = append(r.rules,
+		redactRule{urlCredentialsRegex, "${1}<credentials>@"},
+		redactRule{emailRegex, "<email>"},
+	)
+	return r
+}
+
+func (r *redactor) add(pattern, with string) {
+	if re, err := regexp.Compile(pattern); err == nil {
+		r.rules = append(r.rules, redactRule{re: re, with: with})
+	}
+}
+
+func (r *redactor) redact(text string) string {
+	for _, rule := range r.rules {
+		text = rule.re.ReplaceAllString(text, rule.with)
+	}
+	return text
+}
+
+func mdEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+func writeTable(b *bytes.Buffer, title string, header [2]string, rows []envRow) {
+	if len(rows) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "#### %s\n\n| %s | %s |\n| --- | --- |\n", title, header[0], header[1])
+	for _, r := range rows {
+		fmt.Fprintf(b, "| %s | %s |\n", mdEscape(r.name), mdEscape(r.value))
+	}
+	b.WriteString("\n")
+}
+
+// renderMarkdown builds the block to paste. collapse wraps it in <details>,
+// which GitHub, GitLab and Jira Cloud render as a folded section.
+func renderMarkdown(r *report, project string, collapse bool) string {
+	var b bytes.Buffer
+	if collapse {
+		fmt.Fprintf(&b, "<details>\n<summary>Environment: %s</summary>\n\n", project)
+	} else {
+		fmt.Fprintf(&b, "### Environment: %s\n\n", project)
+	}
+	fmt.Fprintf(&b, "_Captured %s with unity_env._\n\n", time.Now().Format("2006-01-02 15:04 MST"))
+	writeTable(&b, "System", [2]string{"Item", "Value"}, r.system)
+	writeTable(&b, "Unity", [2]string{"Item", "Value"}, r.unity)
+	if len(r.packages) > 0 {
+		fmt.Fprintf(&b, "#### Packages (%d)\n\n| Package | Version | Source |\n| --- | --- | --- |\n", len(r.packages))
+		for _, p := range r.packages {
+			name := p.name
+			if !p.direct {
+				name += " (indirect)"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", mdEscape(name), mdEscape(p.version), mdEscape(p.source))
+		}
+		b.WriteString("\n")
+	}
+	writeTable(&b, "Scoped Registries", [2]string{"Name", "URL"}, r.registries)
+	writeTable(&b, "Player Settings", [2]string{"Setting", "Value"}, r.settings)
+	writeTable(&b, "Graphics APIs", [2]string{"Build target", "APIs"}, r.graphics)
+	writeTable(&b, "Tools", [2]string{"Tool", "Version"}, r.tools)
+	if collapse {
+		b.WriteString("</details>\n")
+	}
+	return b.String()
+}
+
+// copyToClipboard pipes text into the system clipboard tool
+func copyToClipboard(text string) error {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "windows":
+		// clip reads the console code page; Set-Clipboard keeps non-ASCII text intact
+		candidates = [][]string{{"powershell", "-NoProfile", "-NonInteractive", "-Command", "[Console]::InputEncoding = [Text.Encoding]::UTF8; $input | Out-String | Set-Clipboard"}}
+	case "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	default:
+		candidates = [][]string{{"wl-copy"}, {"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}}
+	}
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(c[0], c[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%s: %v %s", c[0], err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	return fmt.Errorf("no clipboard tool found (install wl-clipboard, xclip or xsel)")
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode, copyOut, allPackages, noRedact, collapse bool
+		outPath                                          string
+	)
+
+	flag.StringVar(&outPath, "out", "", "Also write the Markdown block to this file")
+	flag.BoolVar(&copyOut, "copy", false, "Copy the Markdown block to the clipboard")
+	flag.BoolVar(&allPackages, "all-packages", false, "List indirect and built-in packages too, not only the direct dependencies")
+	flag.BoolVar(&noRedact, "no-redact", false, "Keep paths, user and machine names, email addresses and URL credentials")
+	flag.BoolVar(&collapse, "collapse", true, "Wrap the block in a collapsible <details> section")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_env")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fail(1, "cannot get current directory: %v", err)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+
+	project := filepath.Base(basePath)
+	r := &report{system: systemRows(), unity: unityRows(basePath), tools: toolRows()}
+	if r.packages, r.registries, err = readPackages(basePath, allPackages); err != nil {
+		fmt.Printf(tr("[WARNING] %v\n"), err)
+		recordError("%v", err)
+	}
+	if r.settings, r.graphics, err = settingsRows(basePath); err != nil {
+		fmt.Printf(tr("[WARNING] %v\n"), err)
+		recordError("%v", err)
+	}
+
+	text := renderMarkdown(r, project, collapse)
+	if !noRedact {
+		text = newRedactor().redact(text)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  ENVIRONMENT (paste into the issue)"))
+	printRule("=============================================")
+	fmt.Println()
+	fmt.Print(text)
+	printRule("=============================================")
+	recordAction("capture", project, "ok", fmt.Sprintf("%d packages, %d settings", len(r.packages), len(r.settings)), 0)
+
+	if outPath != "" {
+		if err := os.WriteFile(outPath, []byte(text), 0644); err != nil {
+			fail(1, "cannot write %s: %v", outPath, err)
+		}
+		recordArtifact(outPath)
+		fmt.Printf(tr("[OK] Wrote %s\n"), outPath)
+	}
+	if copyOut {
+		if err := copyToClipboard(text); err != nil {
+			fmt.Printf(tr("[WARNING] Cannot copy to the clipboard: %v\n"), err)
+			recordError("cannot copy to the clipboard: %v", err)
+		} else {
+			fmt.Println(tr("[OK] Copied to the clipboard."))
+		}
+	}
+	if noRedact {
+		fmt.Println(tr("[WARNING] Not redacted: check the block for paths and names before posting it."))
+	}
+	exit(0)
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func relPath(basePath, p string) string {
+	if rel, err := filepath.Rel(basePath, p); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(p)
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...": "\n按回车键继续...",
+	"[ERROR] %v\n":                 "[ERROR] %v\n",
+	"[WARNING] %v\n":               "[WARNING] %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.":               "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                         "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  ENVIRONMENT (paste into the issue)":                                           "  环境信息（粘贴到问题单中）",
+	"[OK] Wrote %s\n":                                                                "[OK] 已写入 %s\n",
+	"[WARNING] Cannot copy to the clipboard: %v\n":                                   "[WARNING] 无法复制到剪贴板: %v\n",
+	"[OK] Copied to the clipboard.":                                                  "[OK] 已复制到剪贴板。",
+	"[WARNING] Not redacted: check the block for paths and names before posting it.": "[WARNING] 未脱敏：发布前请检查其中的路径和名称。",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
@@ -0,0 +1,1496 @@
+// Unity Game Params — Validate game parameter files, build the runtime file, version it and publish it.
+// Designers keep balancing values in JSON or CSV files (GameParams/ by default),
+// each optionally next to a <name>.schema.json that says which fields exist, their
+// types and ranges. The tool checks every file against its schema, converts CSV
+// cells to the declared types, fills in defaults, and writes one minified
+// GameParams_<version>.json plus a GameParams.version pointer. The version only
+// goes up when the parameters change, and the values that changed since the last
+// build are listed. publish copies both files into a hot-update content folder,
+// so a balancing change ships with the next content upload instead of a client
+// build.
+//
+// Build: go build unity_game_params.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_game_params                                   # validate GameParams/
+//	unity_game_params build                             # write Build/GameParams/GameParams_<n>.json
+//	unity_game_params publish -to Build/HotUpdateBundle/Android/DefaultPackage/2026-10-14-1830
+//	unity_game_params validate -in Design/Balance -strict -ci
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	defaultInputDir  = "GameParams"
+	defaultOutputDir = "Build/GameParams"
+	schemaSuffix     = ".schema.json"
+	versionFileName  = "GameParams.version"
+	paramsFilePrefix = "GameParams_"
+
+	// Cell separator for array fields in CSV files ("1|2|3")
+	csvArraySeparator = "|"
+
+	// Changed values listed before "...and N more"
+	maxListedChanges = 50
+)
+
+// CSV files with these columns are key/value lists and become one object;
+// any other CSV is a table and becomes an array with one object per row
+var keyValueColumns = map[string]bool{"key": true, "value": true, "comment": true, "description": true}
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// schema is the subset of JSON Schema the tool checks: type, properties, required,
+// additionalProperties (true/false), items, enum, minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, minLength, maxLength, pattern, minItems,
+// maxItems and default. Other keywords ($schema, title, description...) are ignored.
+type schema struct {
+	Type                 interface{}        `json:"type"` // a type name or a list of them
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"-"`
+	Items                *schema            `json:"items"`
+	Enum                 []interface{}      `json:"enum"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Pattern              string             `json:"pattern"`
+	MinItems             *int               `json:"minItems"`
+	MaxItems             *int               `json:"maxItems"`
+	Default              interface{}        `json:"default"`
+
+	pattern *regexp.Regexp
+}
+
+// paramFile is one input file
+type paramFile struct {
+	name   string // key in the built file: the file name without extension
+	path   string
+	rel    string
+	schema *schema // nil without a .schema.json
+	value  interface{}
+	errors []string
+}
+
+// versionInfo is GameParams.version, the small file the game downloads first
+type versionInfo struct {
+	Version     int    `json:"version"`
+	File        string `json:"file"`
+	SHA256      string `json:"sha256"` // of the parameters, without version and time
+	Size        int64  `json:"size"`
+	GeneratedAt string `json:"generatedAt"`
+}
+
+// builtParams is GameParams_<version>.json
+type builtParams struct {
+	Version     int                    `json:"version"`
+	GeneratedAt string                 `json:"generatedAt"`
+	Params      map[string]interface{} `json:"params"`
+}
+
+// ============================================================
+// Schema
+// ============================================================
+
+// UnmarshalJSON keeps numbers in enum and default exact, reads additionalProperties
+// (a schema there counts as true) and compiles the pattern
+func (s *schema) UnmarshalJSON(data []byte) error {
+	type plainSchema schema
+	var p plainSchema
+	if err := decodeJSON(data, &p); err != nil {
+		return err
+	}
+	*s = schema(p)
+	var extra struct {
+		AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	}
+	json.Unmarshal(data, &extra)
+	switch strings.TrimSpace(string(extra.AdditionalProperties)) {
+	case "false":
+		f := false
+		s.AdditionalProperties = &f
+	case "":
+	default:
+		t := true
+		s.AdditionalProperties = &t
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("pattern %q: %v", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	return nil
+}
+
+// decodeJSON decodes one JSON document with numbers kept as json.Number
+func decodeJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	var rest interface{}
+	if err := dec.Decode(&rest); err != io.EOF {
+		return fmt.Errorf("unexpected data after the JSON value")
+	}
+	return nil
+}
+
+func loadSchema(path string) (*schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s schema
+	if err := decodeJSON(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *schema) types() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var list []string
+		for _, v := range t {
+			if name, ok := v.(string); ok {
+				list = append(list, name)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// property finds the schema of a dotted field name ("drop.itemId"); nil when unknown
+func (s *schema) property(dotted string) *schema {
+	for _, key := range strings.Split(dotted, ".") {
+		if s == nil || s.Properties == nil {
+			return nil
+		}
+		s = s.Properties[key]
+	}
+	return s
+}
+
+// jsonType names the JSON Schema type of a decoded value
+func jsonType(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := x.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case float64:
+		if x == math.Trunc(x) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func typeMatches(want, got string) bool {
+	return want == got || (want == "number" && got == "integer")
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case json.Number:
+		f, err := x.Float64()
+		return f, err == nil
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+func equalValues(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return bytes.Equal(ja, jb)
+}
+
+// childPath names a field below path: "player.hp", or "row 5, hp" in a CSV table
+func childPath(path, key string) string {
+	if path == "" || strings.HasSuffix(path, ", ") {
+		return path + key
+	}
+	return path + "." + key
+}
+
+// validateValue checks v against s, appending "path: problem" to errs, and
+// returns v with the defaults of missing properties filled in
+func validateValue(s *schema, v interface{}, path string, errs *[]string) interface{} {
+	if s == nil {
+		return v
+	}
+	where := strings.TrimSuffix(path, ", ")
+	if where == "" {
+		where = "(root)"
+	}
+	if types := s.types(); len(types) > 0 {
+		got, ok := jsonType(v), false
+		for _, t := range types {
+			ok = ok || typeMatches(t, got)
+		}
+		if !ok {
+			*errs = append(*errs, fmt.Sprintf("%s: expected %s, got %s", where, strings.Join(types, " or "), got))
+			return v
+		}
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			found = found || equalValues(e, v)
+		}
+		if !found {
+			var allowed []string
+			for _, e := range s.Enum {
+				allowed = append(allowed, shortJSON(e))
+			}
+			*errs = append(*errs, fmt.Sprintf("%s: %s is not one of %s", where, shortJSON(v), strings.Join(allowed, ", ")))
+		}
+	}
+
+	switch x := v.(type) {
+	case json.Number:
+		f, _ := x.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			*errs = append(*errs, fmt.Sprintf("%s: %s is below the minimum %v", where, x, *s.Minimum))
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			*errs = append(*errs, fmt.Sprintf("%s: %s is above the maximum %v", where, x, *s.Maximum))
+		}
+		if s.ExclusiveMinimum != nil && f <= *s.ExclusiveMinimum {
+			*errs = append(*errs, fmt.Sprintf("%s: %s must be greater than %v", where, x, *s.ExclusiveMinimum))
+		}
+		if s.ExclusiveMaximum != nil && f >= *s.ExclusiveMaximum {
+			*errs = append(*errs, fmt.Sprintf("%s: %s must be less than %v", where, x, *s.ExclusiveMaximum))
+		}
+	case string:
+		n := len([]rune(x))
+		if s.MinLength != nil && n < *s.MinLength {
+			*errs = append(*errs, fmt.Sprintf("%s: %q is shorter than %d characters", where, x, *s.MinLength))
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			*errs = append(*errs, fmt.Sprintf("%s: %q is longer than %d characters", where, x, *s.MaxLength))
+		}
+		if s.pattern != nil && !s.pattern.MatchString(x) {
+			*errs = append(*errs, fmt.Sprintf("%s: %q does not match %s", where, x, s.Pattern))
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(x) < *s.MinItems {
+			*errs = append(*errs, fmt.Sprintf("%s: %d items, at least %d needed", where, len(x), *s.MinItems))
+		}
+		if s.MaxItems != nil && len(x) > *s.MaxItems {
+			*errs = append(*errs, fmt.Sprintf("%s: %d items, at most %d allowed", where, len(x), *s.MaxItems))
+		}
+		for i, item := range x {
+			x[i] = validateValue(s.Items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case map[string]interface{}:
+		var keys []string
+		for k := range s.Properties {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if _, ok := x[k]; !ok && s.Properties[k] != nil && s.Properties[k].Default != nil {
+				x[k] = s.Properties[k].Default
+			}
+		}
+		for _, k := range s.Required {
+			if _, ok := x[k]; !ok {
+				*errs = append(*errs, fmt.Sprintf("%s: required field '%s' is missing", where, k))
+			}
+		}
+		keys = keys[:0]
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			ps, known := s.Properties[k]
+			if !known && s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*errs = append(*errs, fmt.Sprintf("%s: unknown field '%s'", where, k))
+				continue
+			}
+			x[k] = validateValue(ps, x[k], childPath(path, k), errs)
+		}
+	}
+	return v
+}
+
+// ============================================================
+// Input Files
+// ============================================================
+
+// findParamFiles lists the .json and .csv files below dir with their schemas
+func findParamFiles(basePath, dir string) ([]*paramFile, []string, error) {
+	var files []*paramFile
+	var orphanSchemas []string
+	byName := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := info.Name()
+		rel := relPath(basePath, path)
+		if strings.HasSuffix(strings.ToLower(name), schemaSuffix) {
+			stem := name[:len(name)-len(schemaSuffix)]
+			if !fileExists(filepath.Join(filepath.Dir(path), stem+".json")) && !fileExists(filepath.Join(filepath.Dir(path), stem+".csv")) {
+				orphanSchemas = append(orphanSchemas, rel)
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".json" && ext != ".csv" {
+			return nil
+		}
+		stem := strings.TrimSuffix(name, filepath.Ext(name))
+		if other, ok := byName[stem]; ok {
+			return fmt.Errorf("%s and %s would both be '%s' in the built file; rename one", other, rel, stem)
+		}
+		byName[stem] = rel
+		files = append(files, &paramFile{name: stem, path: path, rel: rel})
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].rel < files[j].rel })
+	return files, orphanSchemas, err
+}
+
+// load reads the file and its schema and validates it
+func (f *paramFile) load() {
+	schemaPath := strings.TrimSuffix(f.path, filepath.Ext(f.path)) + schemaSuffix
+	if fileExists(schemaPath) {
+		s, err := loadSchema(schemaPath)
+		if err != nil {
+			f.errors = append(f.errors, fmt.Sprintf("%s: %v", filepath.Base(schemaPath), err))
+			return
+		}
+		f.schema = s
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		f.errors = append(f.errors, err.Error())
+		return
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	var lines []int
+	if strings.EqualFold(filepath.Ext(f.path), ".csv") {
+		f.value, lines, f.errors = readCSV(data, f.schema)
+	} else if err := decodeJSON(data, &f.value); err != nil {
+		f.errors = append(f.errors, describeJSONError(data, err))
+	}
+	if len(f.errors) > 0 {
+		return
+	}
+	rows, isTable := f.value.([]interface{})
+	if lines == nil || !isTable || f.schema == nil {
+		f.value = validateValue(f.schema, f.value, "", &f.errors)
+		return
+	}
+	// CSV rows are reported by their line in the file
+	table := *f.schema
+	table.Items = nil
+	validateValue(&table, rows, "", &f.errors)
+	for i, row := range rows {
+		rows[i] = validateValue(f.schema.Items, row, fmt.Sprintf("row %d, ", lines[i]), &f.errors)
+	}
+}
+
+// describeJSONError adds the line of a JSON syntax error
+func describeJSONError(data []byte, err error) string {
+	if se, ok := err.(*json.SyntaxError); ok {
+		line := bytes.Count(data[:se.Offset], []byte("\n")) + 1
+		return fmt.Sprintf("line %d: %v", line, err)
+	}
+	return err.Error()
+}
+
+// readCSV turns a key/value CSV into an object and any other CSV into an array of
+// row objects. Cells are converted to the types the schema declares (guessed
+// without one); empty cells are left out, so defaults and required apply. Columns
+// with an empty name or one starting with '#' are notes and skipped.
+func readCSV(data []byte, s *schema) (interface{}, []int, []string) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, []string{err.Error()}
+	}
+	if len(records) == 0 {
+		return nil, nil, []string{"the file is empty"}
+	}
+	header := records[0]
+	var columns []int
+	keyValue := true
+	for i, h := range header {
+		h = strings.TrimSpace(h)
+		header[i] = h
+		if h == "" || strings.HasPrefix(h, "#") {
+			continue
+		}
+		columns = append(columns, i)
+		keyValue = keyValue && keyValueColumns[strings.ToLower(h)]
+	}
+	keyCol, valueCol := -1, -1
+	for _, i := range columns {
+		switch strings.ToLower(header[i]) {
+		case "key":
+			keyCol = i
+		case "value":
+			valueCol = i
+		}
+	}
+	keyValue = keyValue && keyCol >= 0 && valueCol >= 0
+
+	var errs []string
+	cell := func(row []string, i int) string {
+		if i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	if keyValue {
+		obj := make(map[string]interface{})
+		seen := make(map[string]int)
+		for n, row := range records[1:] {
+			line := n + 2
+			key := cell(row, keyCol)
+			if key == "" {
+				continue
+			}
+			if first, ok := seen[key]; ok {
+				errs = append(errs, fmt.Sprintf("row %d: '%s' is set again (first in row %d)", line, key, first))
+				continue
+			}
+			seen[key] = line
+			raw := cell(row, valueCol)
+			if raw == "" {
+				continue
+			}
+			if err := setPath(obj, key, convertCell(raw, s.property(key))); err != nil {
+				errs = append(errs, fmt.Sprintf("row %d, %s: %v", line, key, err))
+			}
+		}
+		return obj, nil, errs
+	}
+
+	var items *schema
+	if s != nil {
+		items = s.Items
+	}
+	rows := []interface{}{}
+	var lines []int
+	for n, row := range records[1:] {
+		line := n + 2
+		obj := make(map[string]interface{})
+		for _, i := range columns {
+			raw := cell(row, i)
+			if raw == "" {
+				continue
+			}
+			if err := setPath(obj, header[i], convertCell(raw, items.property(header[i]))); err != nil {
+				errs = append(errs, fmt.Sprintf("row %d, %s: %v", line, header[i], err))
+			}
+		}
+		if len(obj) > 0 {
+			rows = append(rows, obj)
+			lines = append(lines, line)
+		}
+	}
+	return rows, lines, errs
+}
+
+// convertCell reads a CSV cell as the first type of the schema it fits. A cell
+// that fits none stays a string, so validation reports it with its place.
+func convertCell(raw string, s *schema) interface{} {
+	var types []string
+	if s != nil {
+		types = s.types()
+	}
+	if len(types) == 0 {
+		// No declared type: booleans and numbers as they look, else a string
+		if b, err := strconv.ParseBool(raw); err == nil && (raw == "true" || raw == "false") {
+			return b
+		}
+		if f, err := strconv.ParseFloat(raw, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return json.Number(raw)
+		}
+		return raw
+	}
+	for _, t := range types {
+		switch t {
+		case "integer":
+			if _, err := strconv.ParseInt(raw, 10, 64); err == nil {
+				return json.Number(raw)
+			}
+		case "number":
+			if f, err := strconv.ParseFloat(raw, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+				return json.Number(raw)
+			}
+		case "boolean":
+			switch strings.ToLower(raw) {
+			case "true", "yes", "1":
+				return true
+			case "false", "no", "0":
+				return false
+			}
+		case "null":
+			if raw == "null" {
+				return nil
+			}
+		case "array":
+			var items *schema
+			if s != nil {
+				items = s.Items
+			}
+			list := []interface{}{}
+			for _, part := range strings.Split(raw, csvArraySeparator) {
+				list = append(list, convertCell(strings.TrimSpace(part), items))
+			}
+			return list
+		case "object":
+			var obj map[string]interface{}
+			if err := decodeJSON([]byte(raw), &obj); err == nil {
+				return obj
+			}
+		}
+	}
+	return raw
+}
+
+// setPath stores v under a dotted name, creating the objects in between
+func setPath(obj map[string]interface{}, dotted string, v interface{}) error {
+	keys := strings.Split(dotted, ".")
+	for _, k := range keys[:len(keys)-1] {
+		next, ok := obj[k]
+		if !ok {
+			m := make(map[string]interface{})
+			obj[k] = m
+			obj = m
+			continue
+		}
+		m, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("'%s' is a value and a group at once", k)
+		}
+		obj = m
+	}
+	last := keys[len(keys)-1]
+	if _, ok := obj[last]; ok {
+		return fmt.Errorf("'%s' is a value and a group at once", last)
+	}
+	obj[last] = v
+	return nil
+}
+
+// ============================================================
+// Build
+// ============================================================
+
+// paramsHash fingerprints the parameters; the version only goes up when it changes
+func paramsHash(params map[string]interface{}) string {
+	data, _ := json.Marshal(params)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func readVersionInfo(dir string) (*versionInfo, error) {
+	data, err := os.ReadFile(filepath.Join(dir, versionFileName))
+	if err != nil {
+		return nil, err
+	}
+	var info versionInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("%s: %v", versionFileName, err)
+	}
+	return &info, nil
+}
+
+// readBuiltParams reads the parameters of an earlier build, for the change list
+func readBuiltParams(path string) map[string]interface{} {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var built builtParams
+	if decodeJSON(data, &built) != nil {
+		return nil
+	}
+	return built.Params
+}
+
+// writeBuild writes GameParams_<version>.json, then the pointer to it
+func writeBuild(outDir string, version int, hash string, params map[string]interface{}) (*versionInfo, string, error) {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, "", err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	data, err := json.Marshal(builtParams{Version: version, GeneratedAt: now, Params: params})
+	if err != nil {
+		return nil, "", err
+	}
+	name := fmt.Sprintf("%s%d.json", paramsFilePrefix, version)
+	path := filepath.Join(outDir, name)
+	if err := writeFileAtomic(path, data); err != nil {
+		return nil, "", err
+	}
+	info := &versionInfo{Version: version, File: name, SHA256: hash, Size: int64(len(data)), GeneratedAt: now}
+	pointer, _ := json.MarshalIndent(info, "", "  ")
+	if err := writeFileAtomic(filepath.Join(outDir, versionFileName), append(pointer, '\n')); err != nil {
+		return nil, "", err
+	}
+	return info, path, nil
+}
+
+// diffValues lists the values that differ between two builds as "path: old → new"
+func diffValues(a, b interface{}, path string, out *[]string) {
+	ma, aIsMap := a.(map[string]interface{})
+	mb, bIsMap := b.(map[string]interface{})
+	if aIsMap && bIsMap {
+		keys := make(map[string]bool)
+		for k := range ma {
+			keys[k] = true
+		}
+		for k := range mb {
+			keys[k] = true
+		}
+		var sorted []string
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			va, okA := ma[k]
+			vb, okB := mb[k]
+			switch {
+			case !okA:
+				*out = append(*out, fmt.Sprintf("%s: (none) → %s", childPath(path, k), shortJSON(vb)))
+			case !okB:
+				*out = append(*out, fmt.Sprintf("%s: %s → (none)", childPath(path, k), shortJSON(va)))
+			default:
+				diffValues(va, vb, childPath(path, k), out)
+			}
+		}
+		return
+	}
+	la, aIsList := a.([]interface{})
+	lb, bIsList := b.([]interface{})
+	if aIsList && bIsList {
+		for i := 0; i < len(la) || i < len(lb); i++ {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(la):
+				*out = append(*out, fmt.Sprintf("%s: (none) → %s", p, shortJSON(lb[i])))
+			case i >= len(lb):
+				*out = append(*out, fmt.Sprintf("%s: %s → (none)", p, shortJSON(la[i])))
+			default:
+				diffValues(la[i], lb[i], p, out)
+			}
+		}
+		return
+	}
+	if !equalValues(a, b) {
+		*out = append(*out, fmt.Sprintf("%s: %s → %s", path, shortJSON(a), shortJSON(b)))
+	}
+}
+
+// shortJSON renders a value for the output, cut to one short line
+func shortJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	s := string(data)
+	if len(s) > 60 {
+		s = s[:57] + "..."
+	}
+	return s
+}
+
+// copyFile copies src to dst through a temporary file
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(dst, data)
+}
+
+// ============================================================
+// Version Control Checkout
+// ============================================================
+
+// Perforce and Plastic SCM keep files read-only until they are checked out. Writing
+// them directly (even after clearing the flag) leaves changes the server does not know
+// about, so files are opened for edit first. Git and plain folders need nothing.
+const (
+	vcsNone     = "none"
+	vcsPerforce = "p4"
+	vcsPlastic  = "plastic"
+)
+
+// vcsEnvVar overrides detection for every tool (e.g. on build agents)
+const vcsEnvVar = "UNITYSTARTER_VCS"
+
+// vcsClient issues checkouts for files about to be modified
+type vcsClient struct {
+	kind       string
+	source     string // how kind was chosen, for the startup message
+	checkedOut map[string]bool
+}
+
+// activeVCS is set up in main; nil means no checkouts
+var activeVCS *vcsClient
+
+// detectVCS picks the checkout provider. Priority: -vcs flag > UNITYSTARTER_VCS >
+// Unity's VersionControlSettings.asset mode > workspace markers (.plastic, P4CONFIG).
+func detectVCS(projectRoot, override string) (*vcsClient, error) {
+	normalize := func(v string) (string, bool) {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "p4", "perforce":
+			return vcsPerforce, true
+		case "plastic", "plasticscm", "cm", "unityvcs":
+			return vcsPlastic, true
+		case "none", "git", "off":
+			return vcsNone, true
+		}
+		return "", false
+	}
+	newClient := func(kind, source string) *vcsClient {
+		return &vcsClient{kind: kind, source: source, checkedOut: make(map[string]bool)}
+	}
+
+	if override != "" && override != "auto" {
+		kind, ok := normalize(override)
+		if !ok {
+			return nil, fmt.Errorf("unknown -vcs value '%s' (use auto, none, p4 or plastic)", override)
+		}
+		return newClient(kind, "-vcs flag"), nil
+	}
+	if env := os.Getenv(vcsEnvVar); env != "" {
+		if kind, ok := normalize(env); ok {
+			return newClient(kind, vcsEnvVar), nil
+		}
+	}
+
+	// Unity stores the project's integrated VCS in m_Mode ("Perforce", "PlasticSCM", ...)
+	if data, err := os.ReadFile(filepath.Join(projectRoot, "ProjectSettings", "VersionControlSettings.asset")); err == nil {
+		if m := regexp.MustCompile(`(?m)^\s*m_Mode:\s*(.+?)\s*$`).FindSubmatch(data); m != nil {
+			switch strings.ToLower(string(m[1])) {
+			case "perforce":
+				return newClient(vcsPerforce, "VersionControlSettings.asset"), nil
+			case "plasticscm", "plastic scm", "unity version control":
+				return newClient(vcsPlastic, "VersionControlSettings.asset"), nil
+			}
+		}
+	}
+
+	// Workspace markers, searched upward from the project root
+	p4config := os.Getenv("P4CONFIG")
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info != nil {
+				return newClient(vcsNone, "git repository"), nil
+			}
+			if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+				return newClient(vcsPlastic, ".plastic workspace"), nil
+			}
+			if p4config != "" {
+				if _, err := os.Stat(filepath.Join(dir, p4config)); err == nil {
+					return newClient(vcsPerforce, "P4CONFIG"), nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if os.Getenv("P4CLIENT") != "" {
+		return newClient(vcsPerforce, "P4CLIENT"), nil
+	}
+	return newClient(vcsNone, "no Perforce/Plastic workspace found"), nil
+}
+
+// checkout opens an existing file for edit. New files need no checkout, and files
+// outside the depot/workspace only produce a warning: the write still goes ahead.
+func (v *vcsClient) checkout(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || v.checkedOut[abs] {
+		return
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return
+	}
+	v.checkedOut[abs] = true
+
+	var cmd *exec.Cmd
+	switch v.kind {
+	case vcsPerforce:
+		cmd = exec.Command("p4", "edit", abs)
+	case vcsPlastic:
+		cmd = exec.Command("cm", "checkout", abs)
+	default:
+		return
+	}
+	// Run from the file's folder so P4CONFIG / the Plastic workspace is resolved
+	cmd.Dir = filepath.Dir(abs)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		fmt.Printf(tr("[WARNING] %s checkout failed for %s: %v %s\n"), v.kind, filepath.Base(abs), err, strings.TrimSpace(string(out)))
+		return
+	}
+	fmt.Printf(tr("[VCS] Checked out (%s): %s\n"), v.kind, filepath.Base(abs))
+}
+
+// ============================================================
+// Safe File Writes
+// ============================================================
+
+// fsys is where writes go. The tools with an in-memory dry-run overlay swap it;
+// here -dry-run stops before the first write, so it is always the disk.
+var fsys fileSystem = osFS{}
+
+type fileSystem interface {
+	WriteFile(name string, data []byte, perm os.FileMode) error
+}
+
+type osFS struct{}
+
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// isDryRun reports whether writes go to a dry-run overlay, which this tool has not
+func isDryRun() bool {
+	return false
+}
+
+// writeFileAtomic replaces path with data without ever leaving a half-written file:
+// data goes to a temp file in the same directory which is then renamed over the target.
+// The target's permission bits are kept, and a read-only flag (files under Perforce or
+// Plastic SCM that were not checked out) is cleared so the write does not fail.
+func writeFileAtomic(path string, data []byte) error {
+	// A dry run writes straight into the overlay: no checkout, no temp file
+	if isDryRun() {
+		return fsys.WriteFile(path, data, 0644)
+	}
+	activeVCS.checkout(path)
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if mode&0200 == 0 {
+			mode |= 0200
+			if err := os.Chmod(path, mode); err != nil {
+				return fmt.Errorf("cannot clear read-only flag on %s: %v", path, err)
+			}
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Retry briefly: editors, indexers and antivirus can hold the target open on Windows
+	for attempt := 0; ; attempt++ {
+		err = os.Rename(tmpPath, path)
+		if err == nil || attempt == 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode  bool
+		dryRun  bool
+		strict  bool
+		inFlag  string
+		outFlag string
+		toFlag  string
+		vcsMode string
+	)
+
+	flag.StringVar(&inFlag, "in", defaultInputDir, "Folder with the parameter files (.json, .csv) and their .schema.json files")
+	flag.StringVar(&outFlag, "out", defaultOutputDir, "Folder for GameParams_<version>.json and GameParams.version")
+	flag.StringVar(&toFlag, "to", "", "publish: hot-update content folder to copy the built files into")
+	flag.BoolVar(&strict, "strict", false, "Treat a parameter file without a .schema.json as an error")
+	flag.StringVar(&vcsMode, "vcs", "auto", "Checkout before writing: auto, none, p4, plastic")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Validate and show the new version without writing anything")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the command ("build -ci")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_game_params")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	command := "validate"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	if len(args) > 1 || (command != "validate" && command != "build" && command != "publish") {
+		fmt.Println(tr("Usage: unity_game_params [flags] [validate | build | publish -to <content folder>]"))
+		recordError("unknown command")
+		exit(2)
+	}
+	if command == "publish" && toFlag == "" {
+		fail(2, "publish needs -to <hot-update content folder>")
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if activeVCS, err = detectVCS(basePath, vcsMode); err != nil {
+		fail(2, "%v", err)
+	}
+	inDir, outDir := absPath(basePath, inFlag), absPath(basePath, outFlag)
+	if info, err := os.Stat(inDir); err != nil || !info.IsDir() {
+		fail(2, "parameter folder %s does not exist (-in)", inFlag)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  GAME PARAMS"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project: %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Input:   %s\n"), relPath(basePath, inDir))
+
+	files, orphans, err := findParamFiles(basePath, inDir)
+	if err != nil {
+		fail(1, "%v", err)
+	}
+	if len(files) == 0 {
+		fail(1, "no .json or .csv parameter files in %s", relPath(basePath, inDir))
+	}
+
+	fmt.Println()
+	problems := 0
+	params := make(map[string]interface{})
+	for _, f := range files {
+		f.load()
+		if f.schema == nil && strict && len(f.errors) == 0 {
+			f.errors = append(f.errors, fmt.Sprintf("no %s%s (-strict)", f.name, schemaSuffix))
+		}
+		if len(f.errors) > 0 {
+			problems++
+			fmt.Printf(tr("[FAIL] %s\n"), f.rel)
+			for _, e := range f.errors {
+				fmt.Printf("       %s\n", e)
+				recordError("%s: %s", f.rel, e)
+			}
+			recordAction("validate", f.rel, "failed", fmt.Sprintf("%d problem(s)", len(f.errors)), 0)
+			continue
+		}
+		detail := "schema"
+		if f.schema == nil {
+			detail = "no schema, types guessed"
+		}
+		shown := tr(detail)
+		if rows, ok := f.value.([]interface{}); ok {
+			detail += fmt.Sprintf(", %d row(s)", len(rows))
+			shown += fmt.Sprintf(tr(", %d row(s)"), len(rows))
+		}
+		fmt.Printf(tr("[OK]   %s (%s)\n"), f.rel, shown)
+		recordAction("validate", f.rel, "ok", detail, 0)
+		params[f.name] = f.value
+	}
+	for _, s := range orphans {
+		fmt.Printf(tr("[WARNING] %s has no parameter file next to it\n"), s)
+	}
+	if problems > 0 {
+		fmt.Printf(tr("\n[ERROR] %d of %d file(s) have problems; nothing was built.\n"), problems, len(files))
+		exit(1)
+	}
+	if command == "validate" {
+		fmt.Printf(tr("\n[OK] %d file(s) are valid.\n"), len(files))
+		exit(0)
+	}
+
+	hash := paramsHash(params)
+	prev, err := readVersionInfo(outDir)
+	if err != nil && !os.IsNotExist(err) {
+		fail(1, "%v", err)
+	}
+	info := prev
+	builtPath := ""
+	if prev != nil && prev.SHA256 == hash {
+		fmt.Printf(tr("\n[OK] Parameters are unchanged since version %d.\n"), prev.Version)
+		builtPath = filepath.Join(outDir, prev.File)
+		if !fileExists(builtPath) {
+			fail(1, "%s points at %s, which is missing; delete %s to build again", versionFileName, prev.File, versionFileName)
+		}
+		recordAction("build", relPath(basePath, builtPath), "skipped", "unchanged", 0)
+	} else {
+		version := 1
+		var changes []string
+		if prev != nil {
+			version = prev.Version + 1
+			old := readBuiltParams(filepath.Join(outDir, prev.File))
+			if old != nil {
+				diffValues(old, params, "", &changes)
+			}
+			fmt.Printf(tr("\nChanges since version %d:\n"), prev.Version)
+			if old == nil {
+				fmt.Println(tr("  (the previous build is missing; no list)"))
+			} else if len(changes) == 0 {
+				fmt.Println(tr("  (only the formatting of numbers changed)"))
+			}
+			for i, c := range changes {
+				if i == maxListedChanges {
+					fmt.Printf(tr("  ...and %d more\n"), len(changes)-maxListedChanges)
+					break
+				}
+				fmt.Printf("  %s\n", c)
+			}
+		}
+		for _, c := range changes {
+			recordAction("change", c, "ok", "", 0)
+		}
+		if dryRun {
+			fmt.Printf(tr("\n[Dry Run] Would write version %d to %s.\n"), version, relPath(basePath, outDir))
+			recordAction("build", fmt.Sprintf("%s%d.json", paramsFilePrefix, version), "planned", "", 0)
+			exit(0)
+		}
+		info, builtPath, err = writeBuild(outDir, version, hash, params)
+		if err != nil {
+			fail(1, "cannot write the build: %v", err)
+		}
+		recordArtifact(builtPath)
+		recordArtifact(filepath.Join(outDir, versionFileName))
+		recordAction("build", relPath(basePath, builtPath), "ok", fmt.Sprintf("version %d", version), 0)
+		fmt.Printf(tr("\n[OK] Version %d: %s (%s)\n"), version, relPath(basePath, builtPath), formatSize(info.Size))
+	}
+
+	if command == "publish" {
+		toDir := absPath(basePath, toFlag)
+		if st, err := os.Stat(toDir); err != nil || !st.IsDir() {
+			fail(1, "content folder %s does not exist (-to)", toFlag)
+		}
+		if published, err := readVersionInfo(toDir); err == nil && published.SHA256 == info.SHA256 && fileExists(filepath.Join(toDir, published.File)) {
+			fmt.Printf(tr("[OK] %s already has version %d.\n"), relPath(basePath, toDir), published.Version)
+			recordAction("publish", relPath(basePath, toDir), "skipped", "already published", 0)
+			exit(0)
+		}
+		if dryRun {
+			fmt.Printf(tr("\n[Dry Run] Would copy version %d to %s.\n"), info.Version, relPath(basePath, toDir))
+			recordAction("publish", relPath(basePath, toDir), "planned", fmt.Sprintf("version %d", info.Version), 0)
+			exit(0)
+		}
+		// The versioned file first: a client that reads the new pointer must find it
+		for _, name := range []string{info.File, versionFileName} {
+			if err := copyFile(filepath.Join(outDir, name), filepath.Join(toDir, name)); err != nil {
+				recordAction("publish", name, "failed", err.Error(), 0)
+				fail(1, "cannot copy %s: %v", name, err)
+			}
+			recordArtifact(filepath.Join(toDir, name))
+		}
+		recordAction("publish", relPath(basePath, toDir), "ok", fmt.Sprintf("version %d", info.Version), 0)
+		fmt.Printf(tr("[OK] Published version %d to %s\n"), info.Version, relPath(basePath, toDir))
+		fmt.Println(tr("[TIP] Upload the folder with the content, then purge GameParams.version: unity_cdn_purge."))
+	}
+	exit(0)
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+// absPath resolves a flag value against the project root
+func absPath(basePath, p string) string {
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(basePath, filepath.FromSlash(p))
+}
+
+// relPath shows a path relative to the project root, with forward slashes
+func relPath(basePath, p string) string {
+	if rel, err := filepath.Rel(basePath, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return p
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...": "\n按回车键继续...",
+	"[ERROR] %v\n":                 "[ERROR] %v\n",
+	"Usage: unity_game_params [flags] [validate | build | publish -to <content folder>]": "用法: unity_game_params [参数] [validate | build | publish -to <内容目录>]",
+	"[ERROR] Cannot get current directory: %v\n":                                         "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.":                   "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                             "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  GAME PARAMS":            "  游戏参数",
+	"  Project: %s\n":          "  项目: %s\n",
+	"  Input:   %s\n":          "  输入: %s\n",
+	"[FAIL] %s\n":              "[FAIL] %s\n",
+	"schema":                   "有 schema",
+	"no schema, types guessed": "无 schema，按内容推断类型",
+	", %d row(s)":              "，%d 行",
+	"[OK]   %s (%s)\n":         "[OK]   %s（%s）\n",
+	"[WARNING] %s has no parameter file next to it\n":                                           "[WARNING] %s 旁边没有对应的参数文件\n",
+	"\n[ERROR] %d of %d file(s) have problems; nothing was built.\n":                            "\n[ERROR] %[2]d 个文件中有 %[1]d 个存在问题；未进行构建。\n",
+	"\n[OK] %d file(s) are valid.\n":                                                            "\n[OK] %d 个文件均有效。\n",
+	"\n[OK] Parameters are unchanged since version %d.\n":                                       "\n[OK] 参数自版本 %d 以来没有变化。\n",
+	"\nChanges since version %d:\n":                                                             "\n自版本 %d 以来的变更:\n",
+	"  (the previous build is missing; no list)":                                                "  （上一次的构建文件不存在；无法列出）",
+	"  (only the formatting of numbers changed)":                                                "  （只有数字的书写格式发生了变化）",
+	"  ...and %d more\n":                                                                        "  ...另有 %d 项\n",
+	"\n[Dry Run] Would write version %d to %s.\n":                                               "\n[Dry Run] 将把版本 %d 写入 %s。\n",
+	"\n[OK] Version %d: %s (%s)\n":                                                              "\n[OK] 版本 %d: %s（%s）\n",
+	"[OK] %s already has version %d.\n":                                                         "[OK] %s 中已是版本 %d。\n",
+	"\n[Dry Run] Would copy version %d to %s.\n":                                                "\n[Dry Run] 将把版本 %d 复制到 %s。\n",
+	"[OK] Published version %d to %s\n":                                                         "[OK] 已将版本 %d 发布到 %s\n",
+	"[TIP] Upload the folder with the content, then purge GameParams.version: unity_cdn_purge.": "[TIP] 请随内容一起上传该目录，然后用 unity_cdn_purge 刷新 GameParams.version 的缓存。",
+	"[WARNING] %s checkout failed for %s: %v %s\n":                                              "[WARNING] %s 签出失败 %s: %v %s\n",
+	"[VCS] Checked out (%s): %s\n":                                                              "[VCS] 已签出 (%s): %s\n",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
@@ -0,0 +1,2286 @@
+// Unity Git Setup — Write the Unity .gitignore and .gitattributes, and audit what is tracked.
+// setup writes the rules a Unity project needs into .gitignore and .gitattributes
+// in the project root: the folders and project files unity_project_full_clean
+// deletes (or the lists of a studio policy), build output and the files the
+// UnityStarter tools keep next to the project in .gitignore; line endings, Unity
+// YAML merging and Git LFS for binary assets in .gitattributes. Existing files are
+// merged, not replaced: the rules go into a marked block, rules the file already
+// has are left out of it, and running setup again only updates the block. audit
+// lists tracked files these rules ignore, and binary assets committed outside
+// Git LFS, and exits with 1 when something tracked should be ignored. pre-push
+// blocks a push that adds binary files larger than -max-size outside Git LFS,
+// and -install sets it up as the repository's pre-push hook.
+//
+// Build: go build unity_git_setup.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_git_setup                      # write or update .gitignore and .gitattributes
+//	unity_git_setup setup -dry-run       # show the rules that would be added
+//	unity_git_setup setup -no-lfs        # binary assets as plain git binaries
+//	unity_git_setup audit                # tracked files that should be ignored
+//	unity_git_setup audit -fix           # untrack them (git rm --cached)
+//	unity_git_setup audit -fix -dry-run  # list the git rm commands instead
+//	unity_git_setup audit -ci -annotate github
+//	unity_git_setup pre-push -install -max-size 5MB   # check every push from now on
+//	unity_git_setup pre-push             # check what pushing HEAD would add
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// The block setup owns in both files; rules outside it are the project's own
+const (
+	blockBegin = "# >>> unity_git_setup >>>"
+	blockEnd   = "# <<< unity_git_setup <<<"
+	blockNote  = "# Written by unity_git_setup; run it again to update. Rules outside this block are kept."
+)
+
+// Top-level folders and project file extensions unity_project_full_clean deletes;
+// a studio policy replaces them the same way it does for the cleaner
+var directoriesToDelete = []string{
+	".vs",
+	".idea",
+	".vscode",
+	".utmp",
+	"obj",
+	"Logs",
+	"Temp",
+	"Library",
+	"SceneBackups",
+	"MemoryCaptures",
+	"Build",
+	"HybridCLRData",
+	"Bundles",
+	"yoo",
+	"HotUpdateAssetsPreUpload",
+}
+
+var fileExtensionsToDelete = []string{
+	".csproj",
+	".sln",
+	".slnx",
+	".user",
+	".vsconfig",
+}
+
+// Other top-level folders Unity and the tools write; never worth committing
+var generatedDirs = []string{"Builds", "UserSettings", "Recordings", "CodeCoverage", ".unitystarter_snapshots"}
+
+// Generated folders inside the Unity project of the audio middleware the cleaner
+// knows, ignored when the integration folder (marker) exists
+var middlewareDirs = []struct {
+	marker string
+	dir    string
+}{
+	{"Assets/Plugins/FMOD", "Assets/Plugins/FMOD/Cache"},
+	{"Assets/Wwise", "Assets/StreamingAssets/Audio/GeneratedSoundBanks"},
+}
+
+// Binary asset extensions stored in Git LFS (or marked binary with -no-lfs)
+var lfsExtensions = []struct {
+	group string
+	exts  []string
+}{
+	{"Images", []string{"png", "jpg", "jpeg", "tga", "psd", "psb", "tif", "tiff", "exr", "hdr", "gif", "bmp", "iff", "pict", "ktx", "ktx2", "basis"}},
+	{"Audio", []string{"wav", "mp3", "ogg", "aif", "aiff", "flac", "mod", "it", "s3m", "xm", "bank"}},
+	{"Video", []string{"mp4", "mov", "webm", "avi", "m4v", "ogv"}},
+	{"Models", []string{"fbx", "obj", "blend", "max", "ma", "mb", "3ds", "dae", "c4d", "abc", "usdz", "glb"}},
+	{"Fonts", []string{"ttf", "otf"}},
+	{"Native plugins and archives", []string{"dll", "so", "dylib", "a", "aar", "jar", "bundle", "zip", "7z", "rar"}},
+}
+
+// Unity files that are always binary, whatever the asset serialization mode
+var binaryAssets = []string{"LightingData.asset", "NavMesh.asset"}
+
+// Unity YAML, merged with UnityYAMLMerge (Smart Merge) where it is set up
+var unityYAMLExtensions = []string{
+	"unity", "prefab", "asset", "mat", "anim", "controller", "overrideController",
+	"physicMaterial", "physicsMaterial2D", "playable", "mask", "brush", "flare",
+	"fontsettings", "guiskin", "giparams", "renderTexture", "spriteatlas", "spriteatlasv2",
+	"terrainlayer", "mixer", "shadervariants", "preset", "lighting", "signal", "meta",
+}
+
+// Text files kept with LF endings on every platform
+var textExtensions = []string{
+	"cs", "shader", "cginc", "hlsl", "compute", "raytrace", "shadergraph", "shadersubgraph",
+	"vfx", "uss", "uxml", "tss", "json", "asmdef", "asmref", "inputactions", "xml", "yaml", "yml",
+	"md", "txt", "rsp",
+}
+
+// Files a pointer is never larger than; a larger LFS-tracked blob was committed
+// as a regular git file
+const lfsPointerMaxSize = 1024
+
+// Paths listed per finding in the report before "...and N more"
+const maxPathsListed = 5
+
+// Paths passed to one "git rm --cached", so the command line stays short enough
+const untrackBatch = 500
+
+// pre-push: blobs larger than this that are not in Git LFS block the push
+const defaultMaxPushSize = "10MB"
+
+const (
+	zeroSHA         = "0000000000000000000000000000000000000000"
+	hookMarker      = "# unity_git_setup pre-push hook"
+	binarySniffSize = 8000 // bytes git looks at to tell binary from text
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// ruleGroup is one commented section of generated rules
+type ruleGroup struct {
+	comment string
+	rules   []string
+}
+
+// mergeResult is what setup does to one file
+type mergeResult struct {
+	name    string // .gitignore or .gitattributes
+	text    string // the merged file
+	added   []string
+	skipped int  // generated rules the file already had outside the block
+	changed bool // the merged file differs from the one on disk
+	created bool
+}
+
+// trackedFile is a file in the git index
+type trackedFile struct {
+	path string // relative to the project root, slash-separated
+	hash string
+	size int64 // blob size in the index
+}
+
+// finding is a group of tracked files with the same problem
+type finding struct {
+	level  string // "error" or "warning"
+	detail string // the ignore rule or why LFS is missing
+	paths  []string
+	size   int64
+	setup  bool // ignored by a rule setup adds, not by the project's .gitignore yet
+}
+
+// pushedBlob is a large binary file a push would add outside Git LFS
+type pushedBlob struct {
+	path string // relative to the repository root
+	hash string
+	size int64
+	rule string // the .gitattributes line that puts it in Git LFS
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Shared Policy
+// ============================================================
+
+// A studio keeps its policy (clean lists, package categories, naming rules) in one
+// JSON document that "policy" in .unitystarter.json points at: an HTTP(S) URL, a
+// file in a git repository at a pinned revision, or a local path. Fetched copies
+// are cached in the user cache folder; a pinned commit or content hash is fetched
+// once, anything else again after "refresh". When a fetch fails the cached copy is
+// used with a warning, so tools keep working offline.
+const (
+	policyConfigFileName = ".unitystarter.json"
+	policyConfigEnvVar   = "UNITYSTARTER_CONFIG"
+	policyOfflineEnvVar  = "UNITYSTARTER_POLICY_OFFLINE" // "1": only use the cached copy
+	policyDefaultFile    = "unitystarter-policy.json"    // git: path in the repository
+	policyDefaultRefresh = time.Hour
+	policyTimeout        = 30 * time.Second
+)
+
+// policySource is the "policy" object of .unitystarter.json
+type policySource struct {
+	Source   string `json:"source"`   // https://..., git+https://....git, git@host:repo.git or a path
+	Revision string `json:"revision"` // git: commit, tag or branch (default: HEAD)
+	File     string `json:"file"`     // git: path of the document in the repository
+	SHA256   string `json:"sha256"`   // http: expected hash of the document; pins it
+	Refresh  string `json:"refresh"`  // how long an unpinned copy is used, e.g. "30m" (default: 1h)
+}
+
+// sharedPolicy is the policy document. The schema is shared by all tools in this
+// folder; each one reads the parts it applies and ignores the rest.
+type sharedPolicy struct {
+	Clean *struct {
+		Directories []string `json:"directories"` // replaces the built-in top-level folders
+		Extensions  []string `json:"extensions"`  // replaces the built-in top-level file extensions
+	} `json:"clean"`
+	Packages *struct {
+		Categories []struct {
+			Name     string   `json:"name"`
+			Packages []string `json:"packages"`
+		} `json:"categories"` // replaces the built-in removal categories
+	} `json:"packages"`
+	Naming *struct {
+		Scripts  string `json:"scripts"`  // regex new script names must match
+		Packages string `json:"packages"` // regex new package names must match
+	} `json:"naming"`
+}
+
+var gitCommitRegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// loadPolicy reads "policy" from .unitystarter.json (found like loadSharedConfig)
+// and returns the document with a description of where it came from. Returns nil
+// when no policy is configured.
+func loadPolicy(projectRoot string) (*sharedPolicy, string, error) {
+	path := os.Getenv(policyConfigEnvVar)
+	if path == "" {
+		abs, err := filepath.Abs(projectRoot)
+		if err != nil {
+			return nil, "", nil
+		}
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			candidate := filepath.Join(dir, policyConfigFileName)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				return nil, "", nil
+			}
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	var file struct {
+		Policy *policySource `json:"policy"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, "", fmt.Errorf("invalid %s: %v", path, err)
+	}
+	if file.Policy == nil || strings.TrimSpace(file.Policy.Source) == "" {
+		return nil, "", nil
+	}
+
+	doc, origin, err := fetchPolicy(file.Policy, filepath.Dir(path))
+	if err != nil {
+		return nil, origin, fmt.Errorf("policy %s: %v", origin, err)
+	}
+	var policy sharedPolicy
+	if err := json.Unmarshal(doc, &policy); err != nil {
+		return nil, origin, fmt.Errorf("invalid policy %s: %v", origin, err)
+	}
+	return &policy, origin, nil
+}
+
+// isGitSource reports whether a policy source names a git repository
+func isGitSource(source string) bool {
+	return strings.HasPrefix(source, "git+") || strings.HasPrefix(source, "git@") ||
+		strings.HasPrefix(source, "ssh://") || strings.HasSuffix(source, ".git")
+}
+
+// fetchPolicy returns the policy document, from the cache when it is pinned,
+// fresh enough or offline, and from the source otherwise
+func fetchPolicy(src *policySource, configDir string) ([]byte, string, error) {
+	source := strings.TrimSpace(src.Source)
+	isGit := isGitSource(source)
+	isHTTP := !isGit && (strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://"))
+	if !isGit && !isHTTP {
+		p := source
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(configDir, filepath.FromSlash(p))
+		}
+		data, err := os.ReadFile(p)
+		return data, p, err
+	}
+
+	revision, file := src.Revision, src.File
+	if isGit {
+		if revision == "" {
+			revision = "HEAD"
+		}
+		if file == "" {
+			file = policyDefaultFile
+		}
+	}
+	origin := source
+	if isGit {
+		origin = source + "@" + revision + ":" + file
+	}
+	refresh := policyDefaultRefresh
+	if src.Refresh != "" {
+		d, err := time.ParseDuration(src.Refresh)
+		if err != nil {
+			return nil, origin, fmt.Errorf("invalid refresh '%s': %v", src.Refresh, err)
+		}
+		refresh = d
+	}
+	pinned := (isGit && gitCommitRegex.MatchString(strings.ToLower(revision))) || (isHTTP && src.SHA256 != "")
+	matchesPin := func(data []byte) bool {
+		if src.SHA256 == "" {
+			return true
+		}
+		sum := sha256.Sum256(data)
+		return strings.EqualFold(hex.EncodeToString(sum[:]), strings.TrimSpace(src.SHA256))
+	}
+
+	// One cache file per source, revision and path
+	key := sha256.Sum256([]byte(source + "\n" + revision + "\n" + file))
+	cachePath := ""
+	if dir, err := os.UserCacheDir(); err == nil {
+		cachePath = filepath.Join(dir, "UnityStarter", "policy", hex.EncodeToString(key[:8])+".json")
+	}
+	var cached []byte
+	var cachedAt time.Time
+	if cachePath != "" {
+		if info, err := os.Stat(cachePath); err == nil {
+			if data, err := os.ReadFile(cachePath); err == nil && matchesPin(data) {
+				cached, cachedAt = data, info.ModTime()
+			}
+		}
+	}
+	if cached != nil && (pinned || time.Since(cachedAt) < refresh) {
+		return cached, origin, nil
+	}
+	if os.Getenv(policyOfflineEnvVar) == "1" {
+		if cached != nil {
+			return cached, origin, nil
+		}
+		return nil, origin, fmt.Errorf("%s is set and there is no cached copy", policyOfflineEnvVar)
+	}
+
+	var data []byte
+	var err error
+	if isGit {
+		data, err = fetchGitPolicy(strings.TrimPrefix(source, "git+"), revision, file)
+	} else {
+		data, err = fetchHTTPPolicy(source)
+	}
+	if err == nil && !matchesPin(data) {
+		err = fmt.Errorf("the document does not match the pinned sha256")
+	}
+	if err != nil {
+		if cached != nil {
+			fmt.Printf(tr("[WARNING] Cannot refresh the policy, using the copy cached %s: %v\n"), cachedAt.Format("2006-01-02 15:04"), err)
+			return cached, origin, nil
+		}
+		return nil, origin, err
+	}
+	if cachePath != "" {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err == nil {
+			tmp := cachePath + ".tmp"
+			if os.WriteFile(tmp, data, 0644) == nil {
+				os.Rename(tmp, cachePath)
+			}
+		}
+	}
+	return data, origin, nil
+}
+
+func fetchHTTPPolicy(source string) ([]byte, error) {
+	client := &http.Client{Timeout: policyTimeout}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+}
+
+// fetchGitPolicy reads one file at a revision without a full clone: a shallow
+// fetch of just that revision into a scratch repository
+func fetchGitPolicy(repo, revision, file string) ([]byte, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git is not installed")
+	}
+	tmp, err := os.MkdirTemp("", "unitystarter-policy-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	run := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("git", append([]string{"-C", tmp}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("git %s: %v %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return out, nil
+	}
+	if _, err := run("init", "-q"); err != nil {
+		return nil, err
+	}
+	if _, err := run("fetch", "-q", "--depth", "1", repo, revision); err != nil {
+		return nil, err
+	}
+	return run("show", "FETCH_HEAD:"+strings.TrimPrefix(filepath.ToSlash(file), "/"))
+}
+
+// protectedDirs are never deleted, whatever a policy lists
+var protectedDirs = []string{"Assets", "Packages", "ProjectSettings", "UserSettings", ".git", ".plastic", ".svn"}
+
+// applyCleanPolicy replaces the built-in clean lists with the policy's. Entries
+// must be top-level folder names and file extensions; Unity's own folders and the
+// version control folders are refused.
+func applyCleanPolicy(p *sharedPolicy) error {
+	if p == nil || p.Clean == nil {
+		return nil
+	}
+	if len(p.Clean.Directories) > 0 {
+		for _, d := range p.Clean.Directories {
+			if d == "" || d == "." || d == ".." || strings.ContainsAny(d, `/\`) {
+				return fmt.Errorf("policy clean directory '%s' is not a top-level folder name", d)
+			}
+			if containsFold(protectedDirs, d) {
+				return fmt.Errorf("policy clean directory '%s' is protected", d)
+			}
+		}
+		directoriesToDelete = p.Clean.Directories
+	}
+	if len(p.Clean.Extensions) > 0 {
+		for _, ext := range p.Clean.Extensions {
+			if !strings.HasPrefix(ext, ".") || len(ext) < 2 || strings.ContainsAny(ext, `/\*`) {
+				return fmt.Errorf("policy clean extension '%s' is not an extension like \".csproj\"", ext)
+			}
+		}
+		fileExtensionsToDelete = p.Clean.Extensions
+	}
+	return nil
+}
+
+// ============================================================
+// Rules
+// ============================================================
+
+// caseGlob matches a path the way the Unity .gitignore template does: the first
+// letter and every capital of each segment match either case ("[Ll]ibrary")
+func caseGlob(p string) string {
+	var b strings.Builder
+	start := true
+	for _, r := range p {
+		lower, upper := unicode.ToLower(r), unicode.ToUpper(r)
+		if r < unicode.MaxASCII && lower != upper && (start || r == upper) {
+			fmt.Fprintf(&b, "[%c%c]", upper, lower)
+		} else {
+			b.WriteRune(r)
+		}
+		start = r == '/'
+	}
+	return b.String()
+}
+
+// ignoreGroups returns the .gitignore rules for the project, relative to its root
+func ignoreGroups(basePath string) []ruleGroup {
+	var cleaned, others, projectFiles []string
+	for _, d := range directoriesToDelete {
+		cleaned = append(cleaned, "/"+caseGlob(d)+"/")
+	}
+	for _, d := range generatedDirs {
+		others = append(others, "/"+caseGlob(d)+"/")
+	}
+	for _, ext := range fileExtensionsToDelete {
+		projectFiles = append(projectFiles, "/*"+ext)
+	}
+	projectFiles = append(projectFiles, "*.pidb", "*.pdb", "*.mdb", "*.opendb", "*.VC.db",
+		"*.pidb.meta", "*.pdb.meta", "*.mdb.meta")
+
+	generated := []string{
+		"/[Aa]ssets/[Aa]ddressable[Aa]ssets[Dd]ata/*/*.bin*",
+		"/[Aa]ssets/[Ss]treaming[Aa]ssets/aa.meta",
+		"/[Aa]ssets/[Ss]treaming[Aa]ssets/aa/*",
+		"/[Aa]ssets/[Pp]lugins/[Ee]ditor/[Jj]et[Bb]rains*",
+	}
+	for _, m := range middlewareDirs {
+		if info, err := os.Stat(filepath.Join(basePath, filepath.FromSlash(m.marker))); err == nil && info.IsDir() {
+			dir := "/" + caseGlob(m.dir)
+			generated = append(generated, dir+"/", dir+".meta")
+		}
+	}
+
+	return []ruleGroup{
+		{"Folders Unity, the IDEs and the build tools generate (the ones unity_project_full_clean deletes)", cleaned},
+		{"Local settings, builds and captures", others},
+		{"Project files the IDEs generate", projectFiles},
+		{"Generated assets", generated},
+		{"Build output and crash reports", []string{"*.apk", "*.aab", "*.app", "*.unitypackage",
+			"crashlytics-buildid.txt", "sysinfo.txt", "mono_crash.*"}},
+		{"Operating system files", []string{".DS_Store", "Thumbs.db", "Desktop.ini"}},
+		{"UnityStarter tools", []string{"/.unitystarter.lock"}},
+	}
+}
+
+// attributeGroups returns the .gitattributes rules; binary assets go to Git LFS
+// unless lfs is false
+func attributeGroups(lfs bool) []ruleGroup {
+	binary := "filter=lfs diff=lfs merge=lfs -text"
+	if !lfs {
+		binary = "binary"
+	}
+	text := []string{"*.cs text eol=lf diff=csharp"}
+	for _, ext := range textExtensions {
+		if ext != "cs" {
+			text = append(text, "*."+ext+" text eol=lf")
+		}
+	}
+	var yaml []string
+	for _, ext := range unityYAMLExtensions {
+		yaml = append(yaml, "*."+ext+" text eol=lf merge=unityyamlmerge")
+	}
+	// After the YAML rules, so they win over *.asset
+	var always []string
+	for _, name := range binaryAssets {
+		always = append(always, name+" "+binary)
+	}
+
+	groups := []ruleGroup{
+		{"Line endings: normalized in the repository, native in the working copy", []string{"* text=auto"}},
+		{"Code and text, LF on every platform", text},
+		{"Unity YAML, merged with UnityYAMLMerge when it is set up as the merge driver", yaml},
+		{"Unity files that are always binary", always},
+	}
+	suffix := " (Git LFS)"
+	if !lfs {
+		suffix = ""
+	}
+	for _, g := range lfsExtensions {
+		var rules []string
+		for _, ext := range g.exts {
+			rules = append(rules, "*."+ext+" "+binary)
+		}
+		groups = append(groups, ruleGroup{g.group + suffix, rules})
+	}
+	return groups
+}
+
+// flattenRules returns the rules of the groups in order
+func flattenRules(groups []ruleGroup) []string {
+	var rules []string
+	for _, g := range groups {
+		rules = append(rules, g.rules...)
+	}
+	return rules
+}
+
+var caseClassRegex = regexp.MustCompile(`\[([A-Za-z])([A-Za-z])\]`)
+
+// ignoreKey makes rules that match the same paths compare equal: "Library/",
+// "/Library/" and "/[Ll]ibrary/" are one rule to setup
+func ignoreKey(rule string) string {
+	rule = caseClassRegex.ReplaceAllStringFunc(rule, func(m string) string {
+		if strings.EqualFold(m[1:2], m[2:3]) {
+			return m[1:2]
+		}
+		return m
+	})
+	return strings.ToLower(strings.Trim(rule, "/"))
+}
+
+// attributeKey is the pattern of a .gitattributes line; a pattern the file
+// already sets attributes for is left to the file
+func attributeKey(rule string) string {
+	return strings.Fields(rule)[0]
+}
+
+// ============================================================
+// Merging
+// ============================================================
+
+// mergeRules merges the groups into the text of an existing file. The previous
+// block is replaced where it was; rules the file has outside the block are left
+// out. Without a block and without anything to add the text is kept as it is.
+func mergeRules(name, existing string, groups []ruleGroup, key func(string) string) *mergeResult {
+	var before, after []string
+	inBlock, hadBlock := false, false
+	for _, line := range strings.Split(strings.ReplaceAll(existing, "\r\n", "\n"), "\n") {
+		t := strings.TrimSpace(line)
+		switch {
+		case t == blockBegin && !hadBlock:
+			inBlock, hadBlock = true, true
+		case inBlock:
+			inBlock = t != blockEnd
+		case hadBlock:
+			after = append(after, line)
+		default:
+			before = append(before, line)
+		}
+	}
+
+	have := make(map[string]bool)
+	for _, lines := range [][]string{before, after} {
+		for _, line := range lines {
+			if t := strings.TrimSpace(line); t != "" && !strings.HasPrefix(t, "#") {
+				have[key(t)] = true
+			}
+		}
+	}
+	r := &mergeResult{name: name}
+	block := []string{blockBegin, blockNote}
+	for _, g := range groups {
+		var rules []string
+		for _, rule := range g.rules {
+			if k := key(rule); have[k] {
+				r.skipped++
+			} else {
+				have[k] = true
+				rules = append(rules, rule)
+			}
+		}
+		if len(rules) > 0 {
+			block = append(block, "", "# "+g.comment)
+			block = append(block, rules...)
+			r.added = append(r.added, rules...)
+		}
+	}
+	if len(r.added) == 0 && !hadBlock {
+		r.text = existing
+		return r
+	}
+	block = append(block, blockEnd)
+
+	var parts []string
+	if t := strings.Trim(strings.Join(before, "\n"), "\n"); t != "" {
+		parts = append(parts, t)
+	}
+	if len(r.added) > 0 {
+		parts = append(parts, strings.Join(block, "\n"))
+	}
+	if t := strings.Trim(strings.Join(after, "\n"), "\n"); t != "" {
+		parts = append(parts, t)
+	}
+	if len(parts) > 0 {
+		r.text = strings.Join(parts, "\n\n") + "\n"
+	}
+	r.changed = r.text != existing
+	return r
+}
+
+// mergeFile reads name from the project root and merges the groups into it
+func mergeFile(basePath, name string, groups []ruleGroup, key func(string) string) (*mergeResult, error) {
+	data, err := os.ReadFile(filepath.Join(basePath, name))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	r := mergeRules(name, string(data), groups, key)
+	r.created = os.IsNotExist(err) && r.text != ""
+	return r, nil
+}
+
+// ============================================================
+// Git
+// ============================================================
+
+func gitOutput(dir string, args ...string) (string, error) {
+	return gitCommand(runner.Output, dir, "", args)
+}
+
+// gitInput runs git in dir with input on stdin
+func gitInput(dir, input string, args ...string) (string, error) {
+	return gitCommand(runner.Output, dir, input, args)
+}
+
+// gitRun runs a git command that changes the repository; -dry-run lists it instead
+func gitRun(dir string, args ...string) (string, error) {
+	return gitCommand(runner.Run, dir, "", args)
+}
+
+// gitCommand runs git in dir; configuration given as "-c key=value" must come
+// first in args
+func gitCommand(run func(context.Context, string, string, ...string) ([]byte, []byte, error), dir, input string, args []string) (string, error) {
+	var config []string
+	for len(args) >= 2 && args[0] == "-c" {
+		config = append(config, args[0], args[1])
+		args = args[2:]
+	}
+	out, stderr, err := run(context.Background(), input, "git", append(append(config, "-C", dir), args...)...)
+	if err != nil {
+		if msg := strings.TrimSpace(string(stderr)); msg != "" {
+			return "", fmt.Errorf("%s", msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// splitNUL splits -z output, dropping the empty field after the last NUL
+func splitNUL(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\x00"), "\x00")
+}
+
+// repoPrefix returns the project root relative to the repository root
+// ("" when the project is the repository)
+func repoPrefix(basePath string) (string, error) {
+	prefix, err := gitOutput(basePath, "rev-parse", "--show-prefix")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(prefix, "/"), nil
+}
+
+// rebaseRule rewrites a rule relative to the project root so it means the same
+// in a file git reads relative to the repository root
+func rebaseRule(rule, prefix string) string {
+	if prefix == "" {
+		return rule
+	}
+	negate := ""
+	if strings.HasPrefix(rule, "!") {
+		negate, rule = "!", rule[1:]
+	}
+	// Only a slash before the end anchors a rule; "*.apk" and "Thumbs.db" match anywhere
+	pattern := rule
+	if i := strings.IndexByte(rule, ' '); i >= 0 {
+		pattern = rule[:i]
+	}
+	if strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+		return negate + "/" + prefix + "/" + strings.TrimPrefix(rule, "/")
+	}
+	return negate + "/" + prefix + "/**/" + rule
+}
+
+// writeRulesFile writes rules, rebased to the repository root, to a temporary
+// file for core.excludesFile or core.attributesFile. Command fixtures see it
+// under a stable name (see tempFixtureNames).
+func writeRulesFile(rules []string, prefix string) (string, error) {
+	var sb strings.Builder
+	for _, rule := range rules {
+		sb.WriteString(rebaseRule(rule, prefix) + "\n")
+	}
+	f, err := os.CreateTemp("", "unity_git_setup-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(sb.String())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	addTempFixtureName(f.Name(), "unity_git_setup")
+	return f.Name(), nil
+}
+
+// ============================================================
+// Audit
+// ============================================================
+
+// trackedFiles lists the index below the project root, with blob sizes
+func trackedFiles(basePath string) ([]trackedFile, error) {
+	out, err := gitOutput(basePath, "ls-files", "-s", "-z")
+	if err != nil {
+		return nil, err
+	}
+	var files []trackedFile
+	seen := make(map[string]bool)
+	var hashes strings.Builder
+	for _, entry := range splitNUL(out) {
+		// <mode> <hash> <stage>\t<path>
+		tab := strings.IndexByte(entry, '\t')
+		if tab < 0 {
+			continue
+		}
+		fields := strings.Fields(entry[:tab])
+		if len(fields) != 3 || fields[0] == "160000" || seen[entry[tab+1:]] {
+			continue
+		}
+		seen[entry[tab+1:]] = true
+		files = append(files, trackedFile{path: entry[tab+1:], hash: fields[1]})
+		hashes.WriteString(fields[1] + "\n")
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	out, err = gitInput(basePath, hashes.String(), "cat-file", "--batch-check=%(objectname) %(objectsize)")
+	if err != nil {
+		return nil, err
+	}
+	sizes := make(map[string]int64)
+	for _, line := range strings.Split(out, "\n") {
+		if f := strings.Fields(line); len(f) == 2 {
+			sizes[f[0]], _ = strconv.ParseInt(f[1], 10, 64)
+		}
+	}
+	for i := range files {
+		files[i].size = sizes[files[i].hash]
+	}
+	return files, nil
+}
+
+// ignoredFindings groups the tracked files the project's ignore rules or the
+// generated ones match by the rule that matches them
+func ignoredFindings(basePath, prefix string, rules []string, sizes map[string]int64) ([]*finding, []string, error) {
+	excludes, err := writeRulesFile(rules, prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.Remove(excludes)
+	config := "core.excludesFile=" + filepath.ToSlash(excludes)
+	out, err := gitOutput(basePath, "-c", config, "ls-files", "-ci", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, nil, err
+	}
+	ignored := splitNUL(out)
+	if len(ignored) == 0 {
+		return nil, nil, nil
+	}
+	out, err = gitInput(basePath, strings.Join(ignored, "\x00")+"\x00",
+		"-c", config, "check-ignore", "-v", "-z", "--no-index", "--stdin")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	original := make(map[string]string)
+	for _, rule := range rules {
+		original[rebaseRule(rule, prefix)] = rule
+	}
+	byRule := make(map[string]*finding)
+	var findings []*finding
+	fields := splitNUL(out)
+	// <source> <line> <pattern> <path>
+	for i := 0; i+3 < len(fields); i += 4 {
+		source, pattern, path := fields[0+i], fields[2+i], fields[3+i]
+		detail := fmt.Sprintf("'%s' in %s", pattern, strings.TrimPrefix(source, prefix+"/"))
+		if filepath.Clean(source) == filepath.Clean(excludes) {
+			if rule, ok := original[pattern]; ok {
+				pattern = rule
+			}
+			detail = fmt.Sprintf("'%s' (unity_git_setup)", pattern)
+		}
+		f := byRule[detail]
+		if f == nil {
+			f = &finding{level: "error", detail: detail, setup: strings.HasSuffix(detail, "(unity_git_setup)")}
+			byRule[detail] = f
+			findings = append(findings, f)
+		}
+		f.paths = append(f.paths, path)
+		f.size += sizes[path]
+	}
+	return findings, ignored, nil
+}
+
+// lfsFindings groups the tracked binary assets that are not stored in Git LFS by
+// extension: no LFS rule matches them, or one does but the file was committed
+// before it was added
+func lfsFindings(basePath, prefix string, files []trackedFile, skip map[string]bool) ([]*finding, error) {
+	lfsExt := make(map[string]bool)
+	for _, g := range lfsExtensions {
+		for _, ext := range g.exts {
+			lfsExt["."+ext] = true
+		}
+	}
+	var binaries []trackedFile
+	for _, f := range files {
+		ext := strings.ToLower(filepath.Ext(f.path))
+		if !skip[f.path] && (lfsExt[ext] || containsString(binaryAssets, filepath.Base(f.path))) {
+			binaries = append(binaries, f)
+		}
+	}
+	if len(binaries) == 0 {
+		return nil, nil
+	}
+
+	// The generated rules count as if setup had run; the project's own rules win
+	attributes, err := writeRulesFile(flattenRules(attributeGroups(true)), prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(attributes)
+	var input strings.Builder
+	for _, f := range binaries {
+		input.WriteString(f.path + "\x00")
+	}
+	out, err := gitInput(basePath, input.String(),
+		"-c", "core.attributesFile="+filepath.ToSlash(attributes), "check-attr", "-z", "--stdin", "filter")
+	if err != nil {
+		return nil, err
+	}
+	filter := make(map[string]string)
+	fields := splitNUL(out)
+	// <path> <attribute> <value>
+	for i := 0; i+2 < len(fields); i += 3 {
+		filter[fields[i]] = fields[i+2]
+	}
+
+	byKey := make(map[string]*finding)
+	var findings []*finding
+	for _, f := range binaries {
+		var detail string
+		switch {
+		case filter[f.path] != "lfs":
+			detail = "no Git LFS rule matches"
+		case f.size > lfsPointerMaxSize:
+			detail = "committed as a regular git file"
+		default:
+			continue
+		}
+		kind := "*" + filepath.Ext(f.path)
+		if containsString(binaryAssets, filepath.Base(f.path)) {
+			kind = filepath.Base(f.path)
+		}
+		key := kind + ": " + detail
+		fd := byKey[key]
+		if fd == nil {
+			fd = &finding{level: "warning", detail: key}
+			byKey[key] = fd
+			findings = append(findings, fd)
+		}
+		fd.paths = append(fd.paths, f.path)
+		fd.size += f.size
+	}
+	return findings, nil
+}
+
+// untrack removes the files from the index and keeps them on disk
+func untrack(basePath string, paths []string) error {
+	for start := 0; start < len(paths); start += untrackBatch {
+		end := start + untrackBatch
+		if end > len(paths) {
+			end = len(paths)
+		}
+		args := append([]string{"rm", "--cached", "--quiet", "--"}, paths[start:end]...)
+		if _, err := gitRun(basePath, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ============================================================
+// Pre-push Gate
+// ============================================================
+
+// pushRef is one line git passes a pre-push hook on stdin
+type pushRef struct {
+	localRef, localSHA, remoteRef, remoteSHA string
+}
+
+// readPushRefs reads the refs being pushed. A terminal on stdin means the check
+// was started by hand; it then checks HEAD.
+func readPushRefs() ([]pushRef, bool) {
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice != 0 {
+		return []pushRef{{localRef: "HEAD", localSHA: "HEAD", remoteSHA: zeroSHA}}, false
+	}
+	var refs []pushRef
+	scanner := bufio.NewScanner(stdinReader)
+	for scanner.Scan() {
+		// <local ref> <local sha> <remote ref> <remote sha>
+		if f := strings.Fields(scanner.Text()); len(f) == 4 && f[1] != zeroSHA {
+			refs = append(refs, pushRef{f[0], f[1], f[2], f[3]})
+		}
+	}
+	return refs, true
+}
+
+// pushedBlobs returns the blobs over maxSize the refs bring that no remote
+// branch has yet, with the first path each was committed at (relative to the
+// repository root)
+func pushedBlobs(basePath, remote string, refs []pushRef, maxSize int64) ([]pushedBlob, error) {
+	args := []string{"rev-list", "--objects"}
+	for _, r := range refs {
+		args = append(args, r.localSHA)
+		// The remote's tip is excluded too when it is known here
+		if r.remoteSHA != zeroSHA {
+			if _, err := gitOutput(basePath, "cat-file", "-e", r.remoteSHA+"^{commit}"); err == nil {
+				args = append(args, "^"+r.remoteSHA)
+			}
+		}
+	}
+	remotes := "--remotes"
+	if remote != "" {
+		if names, err := gitOutput(basePath, "remote"); err == nil && containsString(strings.Fields(names), remote) {
+			remotes = "--remotes=" + remote
+		}
+	}
+	out, err := gitOutput(basePath, append(args, "--not", remotes)...)
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[string]string)
+	var hashes strings.Builder
+	for _, line := range strings.Split(out, "\n") {
+		// <hash> [<path>]; commits and the root tree have no path
+		if i := strings.IndexByte(line, ' '); i > 0 {
+			if _, ok := paths[line[:i]]; !ok {
+				paths[line[:i]] = line[i+1:]
+				hashes.WriteString(line[:i] + "\n")
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	out, err = gitInput(basePath, hashes.String(), "cat-file", "--batch-check=%(objectname) %(objecttype) %(objectsize)")
+	if err != nil {
+		return nil, err
+	}
+	var blobs []pushedBlob
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Fields(line)
+		if len(f) != 3 || f[1] != "blob" {
+			continue
+		}
+		size, _ := strconv.ParseInt(f[2], 10, 64)
+		if size <= maxSize {
+			continue
+		}
+		b := pushedBlob{path: paths[f[0]], hash: f[0], size: size}
+		if !isBinaryPath(b.path) && !isBinaryBlob(basePath, b.hash) {
+			// Large scenes and data files are text; they diff and merge in git
+			continue
+		}
+		b.rule = lfsRule(b.path)
+		blobs = append(blobs, b)
+	}
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].size > blobs[j].size })
+	return blobs, nil
+}
+
+// isBinaryPath reports whether the path has an extension setup stores in Git LFS
+func isBinaryPath(p string) bool {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(p), "."))
+	for _, g := range lfsExtensions {
+		if containsString(g.exts, ext) {
+			return true
+		}
+	}
+	return containsString(binaryAssets, path.Base(p))
+}
+
+// isBinaryBlob looks for a NUL byte in the start of the blob, as git does
+func isBinaryBlob(basePath, hash string) bool {
+	cmd := exec.Command("git", "-C", basePath, "cat-file", "blob", hash)
+	out, err := cmd.StdoutPipe()
+	if err != nil || cmd.Start() != nil {
+		return false
+	}
+	head := make([]byte, binarySniffSize)
+	n, _ := io.ReadFull(out, head)
+	cmd.Process.Kill()
+	cmd.Wait()
+	return bytes.IndexByte(head[:n], 0) >= 0
+}
+
+// lfsRule is the .gitattributes line that stores files like p in Git LFS
+func lfsRule(p string) string {
+	pattern := path.Base(p)
+	if ext := path.Ext(p); ext != "" && ext != pattern {
+		pattern = "*" + ext
+	}
+	return pattern + " filter=lfs diff=lfs merge=lfs -text"
+}
+
+// hookScript is the pre-push hook -install writes. The refs on stdin are kept
+// for "git lfs pre-push", which the hook replaces when Git LFS installed it.
+func hookScript(exe, projectDir, maxSize string) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString(hookMarker + "\n")
+	b.WriteString("# Blocks pushes of large binary files outside Git LFS; git push --no-verify skips it.\n")
+	b.WriteString("input=$(cat)\n")
+	if projectDir != "" {
+		fmt.Fprintf(&b, "cd %s || exit 1\n", shellQuote(projectDir))
+	}
+	fmt.Fprintf(&b, "printf '%%s\\n' \"$input\" | %s pre-push -ci -max-size %s \"$@\" || exit 1\n", shellQuote(exe), shellQuote(maxSize))
+	b.WriteString("if command -v git-lfs >/dev/null 2>&1; then\n")
+	b.WriteString("\tprintf '%s\\n' \"$input\" | git lfs pre-push \"$@\"\n")
+	b.WriteString("fi\n")
+	return b.String()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// installHook writes the pre-push hook into the repository's hooks folder
+// (core.hooksPath when set). A hook from Git LFS is replaced, since this one
+// runs "git lfs pre-push" as well; any other hook is left alone.
+func installHook(basePath, prefix, maxSize string) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if abs, err := filepath.Abs(exe); err == nil {
+		exe = abs
+	}
+	dir, err := gitOutput(basePath, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(basePath, dir)
+	}
+	hook := filepath.Join(dir, "pre-push")
+	if data, err := os.ReadFile(hook); err == nil {
+		text := string(data)
+		if !strings.Contains(text, hookMarker) && !strings.Contains(text, "git lfs pre-push") {
+			return "", fmt.Errorf("%s already exists; add 'unity_git_setup pre-push -ci \"$@\"' to it", hook)
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	script := hookScript(filepath.ToSlash(exe), prefix, maxSize)
+	if err := os.WriteFile(hook, []byte(script), 0755); err != nil {
+		return "", err
+	}
+	// WriteFile keeps the mode of a hook that was already there
+	return hook, os.Chmod(hook, 0755)
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+// printFindings lists the findings, errors first and the largest first
+func printFindings(findings []*finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].level != findings[j].level {
+			return findings[i].level == "error"
+		}
+		return findings[i].size > findings[j].size
+	})
+	var level string
+	for _, f := range findings {
+		if f.level != level {
+			level = f.level
+			if level == "error" {
+				fmt.Println(tr("\nTRACKED FILES THAT SHOULD BE IGNORED"))
+			} else {
+				fmt.Println(tr("\nBINARY ASSETS OUTSIDE GIT LFS"))
+			}
+		}
+		tag := "[ERROR]  "
+		if f.level == "warning" {
+			tag = "[WARNING]"
+		}
+		fmt.Printf(tr("  %s %s: %d file(s), %s\n"), tag, f.detail, len(f.paths), formatSize(f.size))
+		for i, p := range f.paths {
+			if i == maxPathsListed {
+				fmt.Printf(tr("      ...and %d more\n"), len(f.paths)-maxPathsListed)
+				break
+			}
+			fmt.Printf("      %s\n", p)
+		}
+	}
+}
+
+// ============================================================
+// Commands
+// ============================================================
+
+// commandRunner starts every external program the tool uses. Output is for
+// commands that only read (ls-files, cat-file) and always runs; Run is for
+// commands that change something (rm --cached) and is only noted under -dry-run.
+// stdin, when not empty, is written to the command's standard input.
+//
+// Two environment variables swap the runner for tests and bug reports:
+//   - UNITYSTARTER_EXEC_RECORD=<file.json> runs commands as usual and saves each
+//     one with its output and exit code
+//   - UNITYSTARTER_EXEC_REPLAY=<file.json> starts nothing and answers every
+//     command from such a file, so a run can be repeated without the programs
+//
+// The project root and the temp folder are written as $ROOT and $TMP in fixtures
+// so they replay on any machine.
+type commandRunner interface {
+	Output(ctx context.Context, stdin, name string, args ...string) (stdout, stderr []byte, err error)
+	Run(ctx context.Context, stdin, name string, args ...string) (stdout, stderr []byte, err error)
+}
+
+var runner commandRunner = execRunner{}
+
+// replayingCommands is set when commands are answered from a fixture; lookPath
+// then accepts programs that are not installed
+var replayingCommands bool
+
+// setupRunner picks the runner for this run; the dry runner it returns (nil
+// without -dry-run) lists the skipped commands with printDryRunReport
+func setupRunner(root string, dryRun bool) (*dryRunner, error) {
+	var r commandRunner = execRunner{}
+	if path := os.Getenv("UNITYSTARTER_EXEC_REPLAY"); path != "" {
+		replay, err := loadReplayRunner(path, root)
+		if err != nil {
+			return nil, err
+		}
+		r = replay
+		replayingCommands = true
+	}
+	if path := os.Getenv("UNITYSTARTER_EXEC_RECORD"); path != "" {
+		r = &recordingRunner{inner: r, path: path, hide: fixturePaths(root, false)}
+	}
+	var dry *dryRunner
+	if dryRun {
+		dry = &dryRunner{inner: r}
+		r = dry
+	}
+	runner = r
+	return dry, nil
+}
+
+// lookPath reports whether a program can be started
+func lookPath(name string) error {
+	if replayingCommands {
+		return nil
+	}
+	_, err := exec.LookPath(name)
+	return err
+}
+
+// commandLine formats a command the way it could be pasted into a shell
+func commandLine(name string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, a := range append([]string{name}, args...) {
+		if a == "" || strings.ContainsAny(a, " \t\"'") {
+			a = strconv.Quote(a)
+		}
+		parts = append(parts, a)
+	}
+	return strings.Join(parts, " ")
+}
+
+// tempFixtureNames maps the temp files of this run to the names fixtures use for
+// them: os.CreateTemp picks a random name, which would never match a recording.
+// The n-th file made for a prefix is $TMP/<prefix>-<n> in every run.
+var (
+	tempFixtureNames []string // real path, fixture name, ...
+	tempFixtureCount = map[string]int{}
+)
+
+func addTempFixtureName(path, prefix string) {
+	tempFixtureCount[prefix]++
+	name := fmt.Sprintf("$TMP/%s-%d", prefix, tempFixtureCount[prefix])
+	tempFixtureNames = append(tempFixtureNames, path, name)
+	if slashed := filepath.ToSlash(path); slashed != path {
+		tempFixtureNames = append(tempFixtureNames, slashed, name)
+	}
+}
+
+// hideFixturePaths applies the temp file names before paths: inside the temp
+// folder a temp file would otherwise become $TMP/<random name>
+func hideFixturePaths(paths *strings.Replacer, s string) string {
+	return paths.Replace(strings.NewReplacer(tempFixtureNames...).Replace(s))
+}
+
+// showFixturePaths turns a recorded text back into this run's paths
+func showFixturePaths(paths *strings.Replacer, s string) string {
+	var pairs []string
+	for i := 0; i+1 < len(tempFixtureNames); i += 2 {
+		pairs = append(pairs, tempFixtureNames[i+1], tempFixtureNames[i])
+	}
+	return paths.Replace(strings.NewReplacer(pairs...).Replace(s))
+}
+
+// fixturePaths swaps the machine's paths for $ROOT and $TMP, or back with restore.
+// The longer path goes first so a project inside the temp folder stays $ROOT.
+func fixturePaths(root string, restore bool) *strings.Replacer {
+	paths := [][2]string{{root, "$ROOT"}, {filepath.Clean(os.TempDir()), "$TMP"}}
+	if len(paths[1][0]) > len(paths[0][0]) {
+		paths[0], paths[1] = paths[1], paths[0]
+	}
+	var pairs []string
+	for _, p := range paths {
+		if p[0] == "" || p[0] == "." {
+			continue
+		}
+		if restore {
+			pairs = append(pairs, p[1], p[0])
+		} else {
+			pairs = append(pairs, p[0], p[1])
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// execRunner starts the real programs
+type execRunner struct{}
+
+func (execRunner) Output(ctx context.Context, stdin, name string, args ...string) ([]byte, []byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+func (e execRunner) Run(ctx context.Context, stdin, name string, args ...string) ([]byte, []byte, error) {
+	return e.Output(ctx, stdin, name, args...)
+}
+
+// dryRunner passes queries through and keeps the commands that would change something
+type dryRunner struct {
+	inner   commandRunner
+	mu      sync.Mutex
+	planned []string
+}
+
+func (d *dryRunner) Output(ctx context.Context, stdin, name string, args ...string) ([]byte, []byte, error) {
+	return d.inner.Output(ctx, stdin, name, args...)
+}
+
+func (d *dryRunner) Run(ctx context.Context, stdin, name string, args ...string) ([]byte, []byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.planned = append(d.planned, commandLine(name, args))
+	return nil, nil, nil
+}
+
+// printDryRunReport lists the commands that were skipped, in the order they came
+func (d *dryRunner) printDryRunReport() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.planned) == 0 {
+		fmt.Println(tr("\n[Dry Run] No commands would be run."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d command(s) would be run; nothing was executed:\n"), len(d.planned))
+	for _, line := range d.planned {
+		fmt.Printf("  run     %s\n", line)
+		recordAction("run", line, "dry-run", "", 0)
+	}
+}
+
+// commandRecord is one command in a fixture; Exit is -1 when it did not start
+// or was killed, with the reason in Error
+type commandRecord struct {
+	Args   []string `json:"args"`
+	Stdin  string   `json:"stdin,omitempty"`
+	Stdout string   `json:"stdout,omitempty"`
+	Stderr string   `json:"stderr,omitempty"`
+	Exit   int      `json:"exit"`
+	Error  string   `json:"error,omitempty"`
+}
+
+type commandFixture struct {
+	Commands []commandRecord `json:"commands"`
+}
+
+// recordingRunner saves every command to path, rewriting the file after each
+// one so an interrupted run still leaves a usable fixture
+type recordingRunner struct {
+	inner   commandRunner
+	path    string
+	hide    *strings.Replacer
+	mu      sync.Mutex
+	fixture commandFixture
+}
+
+func (r *recordingRunner) Output(ctx context.Context, stdin, name string, args ...string) ([]byte, []byte, error) {
+	stdout, stderr, err := r.inner.Output(ctx, stdin, name, args...)
+	r.save(stdin, name, args, stdout, stderr, err)
+	return stdout, stderr, err
+}
+
+func (r *recordingRunner) Run(ctx context.Context, stdin, name string, args ...string) ([]byte, []byte, error) {
+	stdout, stderr, err := r.inner.Run(ctx, stdin, name, args...)
+	r.save(stdin, name, args, stdout, stderr, err)
+	return stdout, stderr, err
+}
+
+func (r *recordingRunner) save(stdin, name string, args []string, stdout, stderr []byte, err error) {
+	rec := commandRecord{Stdin: hideFixturePaths(r.hide, stdin), Stdout: hideFixturePaths(r.hide, string(stdout)), Stderr: hideFixturePaths(r.hide, string(stderr))}
+	for _, a := range append([]string{name}, args...) {
+		rec.Args = append(rec.Args, hideFixturePaths(r.hide, a))
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		rec.Exit = exitErr.ExitCode()
+	default:
+		rec.Exit = -1
+		rec.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Commands = append(r.fixture.Commands, rec)
+	data, _ := json.MarshalIndent(r.fixture, "", "  ")
+	os.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// replayRunner answers commands from a fixture. A command that appears several
+// times (the same device query, a retried step) gets its recordings in order,
+// the last one repeating once they run out.
+type replayRunner struct {
+	hide    *strings.Replacer
+	show    *strings.Replacer
+	mu      sync.Mutex
+	records map[string][]commandRecord
+}
+
+func loadReplayRunner(path, root string) (*replayRunner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture commandFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r := &replayRunner{hide: fixturePaths(root, false), show: fixturePaths(root, true), records: make(map[string][]commandRecord)}
+	for _, rec := range fixture.Commands {
+		key := strings.Join(append(rec.Args, rec.Stdin), "\x00")
+		r.records[key] = append(r.records[key], rec)
+	}
+	return r, nil
+}
+
+func (r *replayRunner) Output(ctx context.Context, stdin, name string, args ...string) ([]byte, []byte, error) {
+	var keyArgs []string
+	for _, a := range append([]string{name}, args...) {
+		keyArgs = append(keyArgs, hideFixturePaths(r.hide, a))
+	}
+	key := strings.Join(append(keyArgs, hideFixturePaths(r.hide, stdin)), "\x00")
+
+	r.mu.Lock()
+	queue := r.records[key]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, nil, fmt.Errorf("no recorded output for: %s", commandLine(name, args))
+	}
+	rec := queue[0]
+	if len(queue) > 1 {
+		r.records[key] = queue[1:]
+	}
+	r.mu.Unlock()
+
+	stdout, stderr := []byte(showFixturePaths(r.show, rec.Stdout)), []byte(showFixturePaths(r.show, rec.Stderr))
+	switch {
+	case rec.Error != "":
+		return stdout, stderr, errors.New(rec.Error)
+	case rec.Exit != 0:
+		return stdout, stderr, fmt.Errorf("exit status %d", rec.Exit)
+	}
+	return stdout, stderr, nil
+}
+
+func (r *replayRunner) Run(ctx context.Context, stdin, name string, args ...string) ([]byte, []byte, error) {
+	return r.Output(ctx, stdin, name, args...)
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+var sizeRegex = regexp.MustCompile(`(?i)^([\d.]+)\s*(b|kb|mb|gb|tb)?$`)
+
+// parseSize accepts "500MB", "30GB", "1.5tb" or plain bytes
+func parseSize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	m := sizeRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+	v, _ := strconv.ParseFloat(m[1], 64)
+	switch strings.ToLower(m[2]) {
+	case "kb":
+		v *= 1 << 10
+	case "mb":
+		v *= 1 << 20
+	case "gb":
+		v *= 1 << 30
+	case "tb":
+		v *= 1 << 40
+	}
+	return int64(v), nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), s) {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...": "\n按回车键继续...",
+	"      ...and %d more\n":       "      ...以及另外 %d 个\n",
+	"      Teammates lose the files from their working copy when they pull; Unity regenerates generated folders.": "      队友拉取后这些文件会从其工作副本中删除；生成的文件夹会由 Unity 重新生成。",
+	"  %s %s: %d file(s), %s\n":                                        "  %s %s: %d 个文件, %s\n",
+	"  Clean list: %s\n":                                               "  清理列表: %s\n",
+	"  Ignored:        %d file(s), %s\n":                               "  应忽略:         %d 个文件, %s\n",
+	"  Outside LFS:    %d file(s), %s\n":                               "  未用 LFS:       %d 个文件, %s\n",
+	"  Project:    %s\n":                                               "  项目:       %s\n",
+	"  Repository: none":                                               "  仓库:       无",
+	"  Repository: project in %s/\n":                                   "  仓库:       项目位于 %s/\n",
+	"  Repository: project root":                                       "  仓库:       项目根目录",
+	"  SUMMARY":                                                        "  摘要",
+	"  Took:           %v\n":                                           "  耗时:           %v\n",
+	"  Tracked:        %d file(s)\n":                                   "  已跟踪:         %d 个文件\n",
+	"  UNITY GIT AUDIT":                                                "  UNITY GIT 审计",
+	"  UNITY GIT SETUP":                                                "  UNITY GIT 设置",
+	"  Untracked:      %d file(s)\n":                                   "  已取消跟踪:     %d 个文件\n",
+	"Checking %d tracked file(s)...\n":                                 "正在检查 %d 个已跟踪文件...\n",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"Usage: unity_git_setup [setup|audit] [flags]":                     "用法: unity_git_setup [setup|audit] [选项]",
+	"[--] %s is up to date (%d rule(s) already in place).\n":           "[--] %s 已是最新 (%d 条规则已存在)。\n",
+	"[DRY-RUN] Would add %d rule(s) to %s (%d already in place):\n":    "[DRY-RUN] 将向 %[2]s 添加 %[1]d 条规则 (%[3]d 条已存在):\n",
+	"[ERROR] %v\n":                                                     "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Cannot write %s: %v\n":                                    "[ERROR] 无法写入 %s: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"[ERROR] git rm --cached: %v\n":                                    "[ERROR] git rm --cached: %v\n",
+	"[OK] Created %s: %d rule(s)\n":                                    "[OK] 已创建 %s: %d 条规则\n",
+	"[OK] Untracked %d file(s); they stay on disk. Commit the removal to finish.\n":                           "[OK] 已取消跟踪 %d 个文件；文件仍保留在磁盘上。提交此删除即可完成。\n",
+	"[OK] Updated %s: %d rule(s) in the block, %d already in place\n":                                         "[OK] 已更新 %s: 区块中 %d 条规则, %d 条已存在\n",
+	"[TIP] Install it from https://git-lfs.com and run 'git lfs install', or run setup with -no-lfs.":         "[TIP] 请从 https://git-lfs.com 安装并运行 'git lfs install'，或使用 -no-lfs 运行 setup。",
+	"[TIP] Run 'unity_git_setup setup' so .gitignore keeps them out of the next commit.":                      "[TIP] 运行 'unity_git_setup setup'，让 .gitignore 将它们排除在下次提交之外。",
+	"[WARNING] Cannot refresh the policy, using the copy cached %s: %v\n":                                     "[WARNING] 无法刷新策略，使用 %s 缓存的副本: %v\n",
+	"\nBINARY ASSETS OUTSIDE GIT LFS":                                                                         "\n未存入 GIT LFS 的二进制资源",
+	"\nTRACKED FILES THAT SHOULD BE IGNORED":                                                                  "\n应被忽略的已跟踪文件",
+	"\n[OK] Nothing tracked should be ignored, and binary assets are in Git LFS.":                             "\n[OK] 没有应被忽略的已跟踪文件，二进制资源均在 Git LFS 中。",
+	"\n[OK] Nothing tracked should be ignored.":                                                               "\n[OK] 没有应被忽略的已跟踪文件。",
+	"\n[TIP] Run 'unity_git_setup audit -fix' to untrack them (git rm --cached), and commit the removal.":     "\n[TIP] 运行 'unity_git_setup audit -fix' 取消跟踪它们 (git rm --cached)，然后提交此删除。",
+	"\n[TIP] Run 'unity_git_setup audit' to find tracked files the new rules ignore, then commit both files.": "\n[TIP] 运行 'unity_git_setup audit' 查找被新规则忽略的已跟踪文件，然后提交这两个文件。",
+	"\n[TIP] Run 'unity_git_setup setup', then 'git add --renormalize .' and commit to move them to Git LFS.": "\n[TIP] 运行 'unity_git_setup setup'，再运行 'git add --renormalize .' 并提交，即可将它们移入 Git LFS。",
+	"\n[TIP] The project is not in a git repository yet; run 'git init' and commit both files first.":         "\n[TIP] 项目尚未在 git 仓库中；请先运行 'git init' 并提交这两个文件。",
+	"\n[WARNING] Git LFS is not installed; without it binary assets are committed as regular git files.":      "\n[WARNING] 未安装 Git LFS；没有它，二进制资源会作为普通 git 文件提交。",
+	"\n[WARNING] git is not installed; the rules take effect once the project is in a git repository.":        "\n[WARNING] 未安装 git；项目进入 git 仓库后这些规则才会生效。",
+	"\n[TIP] Add to .gitattributes (unity_git_setup setup writes most of them):":                              "\n[TIP] 在 .gitattributes 中添加 (unity_git_setup setup 会写入其中大部分):",
+	"LARGE BINARY FILES OUTSIDE GIT LFS":                                                                      "未存入 GIT LFS 的大型二进制文件",
+	"[OK] No binary file over %s outside Git LFS in the push.\n":                                              "[OK] 此次推送中没有超过 %s 且未存入 Git LFS 的二进制文件。\n",
+	"[--] Nothing to check: the push only deletes refs.":                                                      "[--] 无需检查: 此次推送只删除引用。",
+	"Usage: unity_git_setup [setup|audit|pre-push] [flags]":                                                   "用法: unity_git_setup [setup|audit|pre-push] [选项]",
+	"      Then move the unpushed commits of the branch to Git LFS and push again:":                           "      然后将该分支尚未推送的提交迁移到 Git LFS 并重新推送:",
+	"[OK] Installed the pre-push hook: %s\n":                                                                  "[OK] 已安装 pre-push 钩子: %s\n",
+	"  UNITY GIT PRE-PUSH CHECK":                                                                              "  UNITY GIT 推送前检查",
+	"\n[ERROR] Push blocked: %d file(s) over %s are not in Git LFS.\n":                                        "\n[ERROR] 推送已阻止: %d 个超过 %s 的文件未存入 Git LFS。\n",
+	"  Limit:      %s per binary file outside Git LFS\n":                                                      "  上限:       未存入 Git LFS 的单个二进制文件 %s\n",
+	"\n[Dry Run] No commands would be run.":                                                                   "\n[Dry Run] 不会运行任何命令。",
+	"\n[Dry Run] %d command(s) would be run; nothing was executed:\n":                                         "\n[Dry Run] 将会运行 %d 条命令，未执行任何命令:\n",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// CI Annotations
+// ============================================================
+
+// With -annotate, errors and warnings are printed once more as GitHub Actions
+// workflow commands or TeamCity service messages, so the CI lists them on the run
+// page and, when they point at a file, next to that file in the diff.
+var (
+	annotateMode  string              // "", "github" or "teamcity"
+	annotateTool  string              // TeamCity inspection category
+	annotateBase  string              // project root; relative files start here
+	annotateRoot  string              // repository root; CI file paths are relative to it
+	annotateTypes = map[string]bool{} // TeamCity inspection types already declared
+)
+
+// setAnnotateMode checks the -annotate value; "auto" picks the CI the tool runs in
+func setAnnotateMode(mode, basePath, tool string) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "none":
+		annotateMode = ""
+	case "github", "teamcity":
+		annotateMode = strings.ToLower(strings.TrimSpace(mode))
+	case "auto":
+		annotateMode = ""
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			annotateMode = "github"
+		} else if os.Getenv("TEAMCITY_VERSION") != "" {
+			annotateMode = "teamcity"
+		}
+	default:
+		return fmt.Errorf("unknown -annotate mode '%s' (use github, teamcity or auto)", mode)
+	}
+	annotateTool = tool
+	annotateBase, _ = filepath.Abs(basePath)
+	annotateRoot = repositoryRoot(annotateBase)
+	return nil
+}
+
+// repositoryRoot is GITHUB_WORKSPACE, else the nearest folder with a .git entry
+// (a folder, or a file in worktrees and submodules), else the project itself
+func repositoryRoot(basePath string) string {
+	if ws := os.Getenv("GITHUB_WORKSPACE"); ws != "" && annotateMode == "github" {
+		return ws
+	}
+	for dir := basePath; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return basePath
+		}
+	}
+}
+
+// annotate prints one annotation. level is "error" or "warning"; file is absolute
+// or relative to the project root, and may be empty like line and col.
+func annotate(level, file string, line, col int, title, message string) {
+	if annotateMode == "" {
+		return
+	}
+	if file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(annotateBase, filepath.FromSlash(file))
+		}
+		if rel, err := filepath.Rel(annotateRoot, file); err == nil {
+			file = rel
+		}
+		file = filepath.ToSlash(file)
+	}
+
+	if annotateMode == "github" {
+		// Property values also escape ':' and ','; the message only '%' and line breaks
+		data := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+		prop := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+		var props []string
+		if file != "" {
+			props = append(props, "file="+prop.Replace(file))
+			if line > 0 {
+				props = append(props, "line="+strconv.Itoa(line))
+			}
+			if col > 0 {
+				props = append(props, "col="+strconv.Itoa(col))
+			}
+		}
+		if title != "" {
+			props = append(props, "title="+prop.Replace(title))
+		}
+		fmt.Printf("::%s %s::%s\n", level, strings.Join(props, ","), data.Replace(message))
+		return
+	}
+
+	esc := strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]").Replace
+	status := "WARNING"
+	if level == "error" {
+		status = "ERROR"
+	}
+	if file == "" {
+		text := message
+		if title != "" {
+			text = title + ": " + message
+		}
+		fmt.Printf("##teamcity[message text='%s' status='%s']\n", esc(text), status)
+		return
+	}
+	typeID := annotateTool + "." + strings.ReplaceAll(strings.ToLower(title), " ", "-")
+	if !annotateTypes[typeID] {
+		annotateTypes[typeID] = true
+		fmt.Printf("##teamcity[inspectionType id='%s' name='%s' category='%s' description='%s']\n", esc(typeID), esc(title), esc(annotateTool), esc(title))
+	}
+	lineAttr := ""
+	if line > 0 {
+		lineAttr = fmt.Sprintf(" line='%d'", line)
+	}
+	fmt.Printf("##teamcity[inspection typeId='%s' message='%s' file='%s'%s SEVERITY='%s']\n", esc(typeID), esc(message), esc(file), lineAttr, status)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode     bool
+		dryRun     bool
+		noLFS      bool
+		fix        bool
+		strict     bool
+		install    bool
+		annotateFl string
+		maxSizeFl  string
+	)
+
+	flag.BoolVar(&dryRun, "dry-run", false, "setup: show the rules that would be added without writing; audit -fix: list the git commands")
+	flag.BoolVar(&noLFS, "no-lfs", false, "setup: mark binary assets binary instead of storing them in Git LFS; audit: skip the LFS check")
+	flag.BoolVar(&fix, "fix", false, "audit: untrack the files that should be ignored (git rm --cached; they stay on disk)")
+	flag.BoolVar(&strict, "strict", false, "audit: also exit with 1 when binary assets are outside Git LFS")
+	flag.StringVar(&annotateFl, "annotate", "", "audit: also print findings as CI annotations: github, teamcity, auto")
+	flag.StringVar(&maxSizeFl, "max-size", defaultMaxPushSize, "pre-push: largest binary file allowed outside Git LFS (e.g. 5MB)")
+	flag.BoolVar(&install, "install", false, "pre-push: install the check as the repository's pre-push hook")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the command ("audit -fix"); a pre-push hook passes
+	// the remote name and URL after it
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_git_setup")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	command := "setup"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	switch {
+	case (command != "setup" && command != "audit" && command != "pre-push") ||
+		(len(args) > 1 && command != "pre-push") || len(args) > 3:
+		fmt.Println(tr("Usage: unity_git_setup [setup|audit|pre-push] [flags]"))
+		recordError("unknown command '%s'", strings.Join(args, " "))
+		exit(2)
+	case command != "audit" && (fix || strict || annotateFl != ""):
+		fail(2, "-fix, -strict and -annotate are audit flags")
+	case dryRun && command != "setup" && !(command == "audit" && fix):
+		fail(2, "-dry-run is a setup and audit -fix flag")
+	case command != "pre-push" && (install || maxSizeFl != defaultMaxPushSize):
+		fail(2, "-install and -max-size are pre-push flags")
+	}
+	maxSize, err := parseSize(maxSizeFl)
+	if err != nil || maxSize <= 0 {
+		fail(2, "invalid -max-size '%s' (e.g. 10MB)", maxSizeFl)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if err := setAnnotateMode(annotateFl, basePath, "unity_git_setup"); err != nil {
+		fail(2, "%v", err)
+	}
+	dry, err := setupRunner(basePath, dryRun && command == "audit")
+	if err != nil {
+		fail(1, "cannot load the command fixture: %v", err)
+	}
+
+	// A studio policy can replace the cleaner's lists, and so the ignored folders
+	policy, policyOrigin, err := loadPolicy(basePath)
+	if err == nil {
+		err = applyCleanPolicy(policy)
+	}
+	if err != nil {
+		fail(1, "%v", err)
+	}
+
+	gitErr := lookPath("git")
+	prefix, repoErr := "", gitErr
+	if gitErr == nil {
+		prefix, repoErr = repoPrefix(basePath)
+	}
+
+	printRule("=============================================")
+	switch command {
+	case "setup":
+		fmt.Println(tr("  UNITY GIT SETUP"))
+	case "audit":
+		fmt.Println(tr("  UNITY GIT AUDIT"))
+	default:
+		fmt.Println(tr("  UNITY GIT PRE-PUSH CHECK"))
+	}
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:    %s\n"), filepath.Base(basePath))
+	switch {
+	case repoErr != nil:
+		fmt.Println(tr("  Repository: none"))
+	case prefix == "":
+		fmt.Println(tr("  Repository: project root"))
+	default:
+		fmt.Printf(tr("  Repository: project in %s/\n"), prefix)
+	}
+	if policy != nil && policy.Clean != nil {
+		fmt.Printf(tr("  Clean list: %s\n"), policyOrigin)
+	}
+	if command == "pre-push" {
+		fmt.Printf(tr("  Limit:      %s per binary file outside Git LFS\n"), formatSize(maxSize))
+	}
+	fmt.Println()
+
+	if command == "setup" {
+		results := make([]*mergeResult, 2)
+		for i, name := range []string{".gitignore", ".gitattributes"} {
+			groups, key := ignoreGroups(basePath), ignoreKey
+			if name == ".gitattributes" {
+				groups, key = attributeGroups(!noLFS), attributeKey
+			}
+			r, err := mergeFile(basePath, name, groups, key)
+			if err != nil {
+				fail(1, "cannot read %s: %v", name, err)
+			}
+			results[i] = r
+		}
+
+		changed := 0
+		for _, r := range results {
+			if !r.changed {
+				fmt.Printf(tr("[--] %s is up to date (%d rule(s) already in place).\n"), r.name, r.skipped)
+				recordAction("merge", r.name, "skipped", "up to date", 0)
+				continue
+			}
+			changed++
+			if dryRun {
+				fmt.Printf(tr("[DRY-RUN] Would add %d rule(s) to %s (%d already in place):\n"), len(r.added), r.name, r.skipped)
+				for _, rule := range r.added {
+					fmt.Printf("    + %s\n", rule)
+				}
+				recordAction("merge", r.name, "dry-run", fmt.Sprintf("%d rule(s)", len(r.added)), 0)
+				continue
+			}
+			path := filepath.Join(basePath, r.name)
+			if err := os.WriteFile(path, []byte(r.text), 0644); err != nil {
+				fmt.Printf(tr("[ERROR] Cannot write %s: %v\n"), r.name, err)
+				recordAction("merge", r.name, "failed", err.Error(), 0)
+				exit(1)
+			}
+			if r.created {
+				fmt.Printf(tr("[OK] Created %s: %d rule(s)\n"), r.name, len(r.added))
+			} else {
+				fmt.Printf(tr("[OK] Updated %s: %d rule(s) in the block, %d already in place\n"), r.name, len(r.added), r.skipped)
+			}
+			recordAction("merge", r.name, "ok", fmt.Sprintf("%d rule(s)", len(r.added)), 0)
+			recordArtifact(path)
+		}
+
+		if !noLFS {
+			if gitErr != nil {
+				fmt.Println(tr("\n[WARNING] git is not installed; the rules take effect once the project is in a git repository."))
+			} else if _, err := gitOutput(basePath, "lfs", "version"); err != nil {
+				fmt.Println(tr("\n[WARNING] Git LFS is not installed; without it binary assets are committed as regular git files."))
+				fmt.Println(tr("[TIP] Install it from https://git-lfs.com and run 'git lfs install', or run setup with -no-lfs."))
+			}
+		}
+		switch {
+		case repoErr != nil && gitErr == nil:
+			fmt.Println(tr("\n[TIP] The project is not in a git repository yet; run 'git init' and commit both files first."))
+		case changed > 0 && !dryRun:
+			fmt.Println(tr("\n[TIP] Run 'unity_git_setup audit' to find tracked files the new rules ignore, then commit both files."))
+		}
+		exit(0)
+	}
+
+	if gitErr != nil {
+		fail(1, "git is not installed")
+	}
+	if repoErr != nil {
+		fail(1, "the project is not in a git repository")
+	}
+
+	if command == "pre-push" {
+		if install {
+			hook, err := installHook(basePath, prefix, maxSizeFl)
+			if err != nil {
+				fail(1, "cannot install the pre-push hook: %v", err)
+			}
+			fmt.Printf(tr("[OK] Installed the pre-push hook: %s\n"), hook)
+			recordAction("install", hook, "ok", "max size "+maxSizeFl, 0)
+			recordArtifact(hook)
+			exit(0)
+		}
+		refs, hooked := readPushRefs()
+		if hooked && len(refs) == 0 {
+			fmt.Println(tr("[--] Nothing to check: the push only deletes refs."))
+			exit(0)
+		}
+		remote := ""
+		if len(args) > 1 {
+			remote = args[1]
+		}
+		blobs, err := pushedBlobs(basePath, remote, refs, maxSize)
+		if err != nil {
+			fail(1, "cannot list the files in the push: %v", err)
+		}
+		if len(blobs) == 0 {
+			fmt.Printf(tr("[OK] No binary file over %s outside Git LFS in the push.\n"), formatSize(maxSize))
+			exit(0)
+		}
+
+		fmt.Println(tr("LARGE BINARY FILES OUTSIDE GIT LFS"))
+		var rules, patterns []string
+		for _, b := range blobs {
+			fmt.Printf("  [ERROR]   %s: %s\n", b.path, formatSize(b.size))
+			recordAction("check", b.path, "failed", formatSize(b.size)+" outside Git LFS", 0)
+			if !containsString(rules, b.rule) {
+				rules = append(rules, b.rule)
+				patterns = append(patterns, strings.Fields(b.rule)[0])
+			}
+		}
+		fmt.Printf(tr("\n[ERROR] Push blocked: %d file(s) over %s are not in Git LFS.\n"), len(blobs), formatSize(maxSize))
+		recordError("push blocked: %d file(s) over %s are not in Git LFS", len(blobs), formatSize(maxSize))
+		fmt.Println(tr("\n[TIP] Add to .gitattributes (unity_git_setup setup writes most of them):"))
+		for _, rule := range rules {
+			fmt.Printf("      %s\n", rule)
+		}
+		fmt.Println(tr("      Then move the unpushed commits of the branch to Git LFS and push again:"))
+		fmt.Printf("      git lfs migrate import --include=\"%s\"\n", strings.Join(patterns, ","))
+		exit(1)
+	}
+
+	start := time.Now()
+	files, err := trackedFiles(basePath)
+	if err != nil {
+		fail(1, "cannot list tracked files: %v", err)
+	}
+	sizes := make(map[string]int64, len(files))
+	for _, f := range files {
+		sizes[f.path] = f.size
+	}
+	fmt.Printf(tr("Checking %d tracked file(s)...\n"), len(files))
+	// What the project ignores now plus what setup would add
+	findings, ignored, err := ignoredFindings(basePath, prefix, flattenRules(ignoreGroups(basePath)), sizes)
+	if err != nil {
+		fail(1, "cannot check ignore rules: %v", err)
+	}
+	if !noLFS {
+		skip := make(map[string]bool, len(ignored))
+		for _, p := range ignored {
+			skip[p] = true
+		}
+		lfs, err := lfsFindings(basePath, prefix, files, skip)
+		if err != nil {
+			fail(1, "cannot check Git LFS attributes: %v", err)
+		}
+		findings = append(findings, lfs...)
+	}
+	printFindings(findings)
+
+	var ignoredSize, outsideSize int64
+	outside, needSetup := 0, false
+	for _, f := range findings {
+		needSetup = needSetup || f.setup
+		// The JSON document and the CI get one entry per rule, not per file
+		title := "Tracked file should be ignored"
+		if f.level == "warning" {
+			title = "Binary asset outside Git LFS"
+			outside += len(f.paths)
+			outsideSize += f.size
+		} else {
+			ignoredSize += f.size
+		}
+		detail := fmt.Sprintf("%s: %d file(s), %s", f.detail, len(f.paths), formatSize(f.size))
+		status := "failed"
+		if f.level == "warning" {
+			status = "warning"
+		}
+		recordAction("audit", f.paths[0], status, detail, 0)
+		annotate(f.level, f.paths[0], 0, 0, title, detail)
+	}
+
+	untracked := 0
+	if fix && len(ignored) > 0 {
+		fmt.Println()
+		if err := untrack(basePath, ignored); err != nil {
+			fmt.Printf(tr("[ERROR] git rm --cached: %v\n"), err)
+			recordAction("untrack", ".", "failed", err.Error(), 0)
+			exit(1)
+		}
+		if dry != nil {
+			dry.printDryRunReport()
+		} else {
+			untracked = len(ignored)
+			fmt.Printf(tr("[OK] Untracked %d file(s); they stay on disk. Commit the removal to finish.\n"), untracked)
+			if needSetup {
+				fmt.Println(tr("[TIP] Run 'unity_git_setup setup' so .gitignore keeps them out of the next commit."))
+			}
+			recordAction("untrack", ".", "ok", fmt.Sprintf("%d file(s)", untracked), 0)
+		}
+	}
+
+	fmt.Println()
+	printRule("=============================================")
+	fmt.Println(tr("  SUMMARY"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Tracked:        %d file(s)\n"), len(files))
+	fmt.Printf(tr("  Ignored:        %d file(s), %s\n"), len(ignored), formatSize(ignoredSize))
+	if untracked > 0 {
+		fmt.Printf(tr("  Untracked:      %d file(s)\n"), untracked)
+	}
+	if !noLFS {
+		fmt.Printf(tr("  Outside LFS:    %d file(s), %s\n"), outside, formatSize(outsideSize))
+	}
+	fmt.Printf(tr("  Took:           %v\n"), time.Since(start).Round(time.Millisecond))
+
+	if len(ignored) > untracked {
+		fmt.Println(tr("\n[TIP] Run 'unity_git_setup audit -fix' to untrack them (git rm --cached), and commit the removal."))
+		fmt.Println(tr("      Teammates lose the files from their working copy when they pull; Unity regenerates generated folders."))
+	}
+	if outside > 0 {
+		fmt.Println(tr("\n[TIP] Run 'unity_git_setup setup', then 'git add --renormalize .' and commit to move them to Git LFS."))
+	}
+	if len(ignored) > untracked || (strict && outside > 0) {
+		exit(1)
+	}
+	if len(findings) == 0 && noLFS {
+		fmt.Println(tr("\n[OK] Nothing tracked should be ignored."))
+	} else if len(findings) == 0 {
+		fmt.Println(tr("\n[OK] Nothing tracked should be ignored, and binary assets are in Git LFS."))
+	}
+	exit(0)
+}
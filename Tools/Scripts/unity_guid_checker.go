@@ -0,0 +1,1243 @@
+// Unity GUID Checker — Find .meta files that share a GUID and give the copies new ones.
+// Copying a folder outside the editor (Explorer, Finder, a merge) copies its .meta
+// files too, so two assets end up with the same GUID. Unity then picks one of them
+// for every reference, silently swaps materials and prefabs, and logs a GUID conflict
+// on import. This tool maps the GUIDs of Assets/ and the embedded packages, reports
+// every GUID held by more than one .meta, and with -fix gives the newer copy a new
+// GUID, rewriting the references inside the copied folder so the copy keeps using
+// its own assets while everything outside it keeps using the original.
+//
+// Build: go build unity_guid_checker.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_guid_checker                         # report duplicate GUIDs
+//	unity_guid_checker -fix -dry-run           # list the new GUIDs and rewritten files
+//	unity_guid_checker -fix                    # regenerate the newer duplicates
+//	unity_guid_checker -fix -snapshot          # with a unity_snapshot restore point first
+//	unity_guid_checker -annotate github -ci    # also as CI annotations
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Files that can reference an asset by GUID: Unity YAML (with Asset
+// Serialization: Force Text), assembly definitions ("GUID:...") and UI Toolkit
+// files ("project://database/...?guid=..."). Same lists as unity_search_replace.
+var referenceFileExts = map[string]bool{
+	".unity": true, ".prefab": true, ".asset": true, ".mat": true, ".anim": true,
+	".controller": true, ".overridecontroller": true, ".mask": true, ".mixer": true,
+	".physicmaterial": true, ".physicsmaterial2d": true, ".guiskin": true,
+	".fontsettings": true, ".spriteatlas": true, ".spriteatlasv2": true, ".lighting": true,
+	".playable": true, ".signal": true, ".preset": true, ".terrainlayer": true,
+	".brush": true, ".flare": true, ".rendertexture": true, ".cubemap": true,
+	".giparams": true, ".shadervariants": true, ".meta": true,
+	".asmdef": true, ".asmref": true, ".uss": true, ".uxml": true,
+	".shadergraph": true, ".shadersubgraph": true, ".vfx": true,
+}
+
+var metaGUIDRegex = regexp.MustCompile(`(?m)^guid:[ \t]*([0-9a-fA-F]{32})[ \t]*\r?$`)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// EditorInstance represents the structure of Library/EditorInstance.json
+type EditorInstance struct {
+	ProcessID int `json:"process_id"`
+}
+
+// metaEntry is one asset holding a duplicated GUID
+type metaEntry struct {
+	asset   string // project-relative, slash-separated, without .meta
+	added   time.Time
+	tracked bool // committed; a copy that is not is always the newer one
+}
+
+// collision is one GUID held by several assets; entries[0] keeps it
+type collision struct {
+	guid    string
+	entries []*metaEntry
+	fixes   []*guidFix
+}
+
+// guidFix gives one duplicate a new GUID. References inside root, the folder
+// the duplicate was copied to, are rewritten to the new GUID.
+type guidFix struct {
+	entry   *metaEntry
+	oldGUID string
+	newGUID string
+	root    string
+	files   []string // reference files that change, besides the .meta
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// checkUnityRunning checks if Unity Editor is running for this project
+// via Library/EditorInstance.json and a liveness check on the recorded PID.
+func checkUnityRunning(basePath string) (bool, int) {
+	data, err := os.ReadFile(filepath.Join(basePath, "Library", "EditorInstance.json"))
+	if err != nil {
+		return false, 0
+	}
+	var instance EditorInstance
+	if err := json.Unmarshal(data, &instance); err != nil || instance.ProcessID <= 0 {
+		return false, 0
+	}
+	if isProcessRunning(instance.ProcessID) {
+		return true, instance.ProcessID
+	}
+	return false, 0
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// ============================================================
+// GUID Scan
+// ============================================================
+
+// scanRoots returns Assets/ and the embedded packages: folders under Packages/
+// with a package.json. Packages from the registry or git live in Library/ and
+// are read-only, so their GUIDs are not the project's to change.
+func scanRoots(basePath string) []string {
+	roots := []string{"Assets"}
+	entries, _ := os.ReadDir(filepath.Join(basePath, "Packages"))
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(basePath, "Packages", e.Name(), "package.json")); err == nil {
+			roots = append(roots, "Packages/"+e.Name())
+		}
+	}
+	return roots
+}
+
+// scanGUIDs maps every GUID to the assets whose .meta holds it, and lists the
+// .meta files without one. Folders starting with "." or ending with "~" are
+// hidden from Unity and skipped, as Unity skips them.
+func scanGUIDs(basePath string, roots []string) (map[string][]string, []string, int) {
+	guids := make(map[string][]string)
+	var noGUID []string
+	count := 0
+	for _, root := range roots {
+		filepath.Walk(filepath.Join(basePath, filepath.FromSlash(root)), func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if name := info.Name(); p != filepath.Join(basePath, filepath.FromSlash(root)) && (strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(p, ".meta") {
+				return nil
+			}
+			rel, _ := filepath.Rel(basePath, strings.TrimSuffix(p, ".meta"))
+			rel = filepath.ToSlash(rel)
+			data, err := os.ReadFile(p)
+			if err != nil {
+				noGUID = append(noGUID, rel)
+				return nil
+			}
+			m := metaGUIDRegex.FindSubmatch(data)
+			if m == nil {
+				noGUID = append(noGUID, rel)
+				return nil
+			}
+			guid := strings.ToLower(string(m[1]))
+			guids[guid] = append(guids[guid], rel)
+			count++
+			return nil
+		})
+	}
+	return guids, noGUID, count
+}
+
+// addedTime is when the .meta was first committed, or for files Git does not
+// know (and without Git) their modification time
+func addedTime(basePath, asset string, useGit bool) (time.Time, bool) {
+	meta := filepath.Join(basePath, filepath.FromSlash(asset)) + ".meta"
+	if useGit {
+		out, err := exec.Command("git", "-C", basePath, "log", "--diff-filter=A", "--format=%at", "--", asset+".meta").Output()
+		// Oldest last; a shallow clone may not have the commit that added it
+		lines := strings.Fields(string(out))
+		if err == nil && len(lines) > 0 {
+			if sec, err := strconv.ParseInt(lines[len(lines)-1], 10, 64); err == nil {
+				return time.Unix(sec, 0), true
+			}
+		}
+	}
+	if info, err := os.Stat(meta); err == nil {
+		return info.ModTime(), false
+	}
+	return time.Time{}, false
+}
+
+// findCollisions returns the duplicated GUIDs, each with the asset that keeps
+// the GUID first: the earliest committed, else the oldest file, else the shortest path
+func findCollisions(basePath string, guids map[string][]string) []*collision {
+	useGit := exec.Command("git", "-C", basePath, "rev-parse", "--is-inside-work-tree").Run() == nil
+	var list []*collision
+	for guid, assets := range guids {
+		if len(assets) < 2 {
+			continue
+		}
+		c := &collision{guid: guid}
+		for _, a := range assets {
+			added, tracked := addedTime(basePath, a, useGit)
+			c.entries = append(c.entries, &metaEntry{asset: a, added: added, tracked: tracked})
+		}
+		sort.SliceStable(c.entries, func(i, j int) bool {
+			a, b := c.entries[i], c.entries[j]
+			if a.tracked != b.tracked {
+				return a.tracked
+			}
+			if !a.added.Equal(b.added) {
+				return a.added.Before(b.added)
+			}
+			if len(a.asset) != len(b.asset) {
+				return len(a.asset) < len(b.asset)
+			}
+			return a.asset < b.asset
+		})
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].entries[0].asset < list[j].entries[0].asset })
+	return list
+}
+
+// copyRoot is the folder a duplicate was copied to: its path without the part it
+// shares with the original at the end. Assets/B/Mat/x.mat copied from
+// Assets/A/Mat/x.mat gives Assets/B; a copied folder Assets/B gives Assets/B itself.
+// A copy that differs only in its top folder is limited to the asset itself.
+func copyRoot(original, duplicate string, roots []string) string {
+	o := strings.Split(original, "/")
+	d := strings.Split(duplicate, "/")
+	for len(o) > 0 && len(d) > 1 && o[len(o)-1] == d[len(d)-1] {
+		o = o[:len(o)-1]
+		d = d[:len(d)-1]
+	}
+	root := strings.Join(d, "/")
+	for _, r := range roots {
+		if root == r || root == "Packages" {
+			return duplicate
+		}
+	}
+	return root
+}
+
+// ============================================================
+// Regeneration
+// ============================================================
+
+// newGUID returns a random 32-digit hex GUID in Unity's .meta format
+func newGUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// isReferenceFile reports whether the file can reference GUIDs; .asset files
+// only when they are serialized as text
+func isReferenceFile(p string, data []byte) bool {
+	ext := strings.ToLower(filepath.Ext(p))
+	if !referenceFileExts[ext] {
+		return false
+	}
+	if ext == ".asset" {
+		return bytes.HasPrefix(data, []byte("%YAML"))
+	}
+	return !bytes.Contains(data, []byte{0})
+}
+
+// within reports whether rel is root or inside it
+func within(rel, root string) bool {
+	return rel == root || strings.HasPrefix(rel, root+"/")
+}
+
+// planFixes picks a new GUID for every duplicate and finds the files inside its
+// copy root that reference the old one
+func planFixes(basePath string, roots []string, collisions []*collision, used map[string][]string) []*guidFix {
+	var fixes []*guidFix
+	for _, c := range collisions {
+		keep := c.entries[0].asset
+		for _, e := range c.entries[1:] {
+			guid := newGUID()
+			for used[guid] != nil {
+				guid = newGUID()
+			}
+			used[guid] = []string{e.asset}
+			f := &guidFix{entry: e, oldGUID: c.guid, newGUID: guid, root: copyRoot(keep, e.asset, roots)}
+			c.fixes = append(c.fixes, f)
+			fixes = append(fixes, f)
+		}
+	}
+	if len(fixes) == 0 {
+		return nil
+	}
+
+	// One pass over the reference files for all fixes
+	for _, root := range roots {
+		filepath.Walk(filepath.Join(basePath, filepath.FromSlash(root)), func(p string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || !referenceFileExts[strings.ToLower(filepath.Ext(p))] {
+				return nil
+			}
+			rel, _ := filepath.Rel(basePath, p)
+			rel = filepath.ToSlash(rel)
+			var candidates []*guidFix
+			for _, f := range fixes {
+				if within(rel, f.root) && rel != f.entry.asset+".meta" {
+					candidates = append(candidates, f)
+				}
+			}
+			if len(candidates) == 0 {
+				return nil
+			}
+			data, err := os.ReadFile(p)
+			if err != nil || !isReferenceFile(p, data) {
+				return nil
+			}
+			lower := bytes.ToLower(data)
+			for _, f := range candidates {
+				if bytes.Contains(lower, []byte(f.oldGUID)) {
+					f.files = append(f.files, rel)
+				}
+			}
+			return nil
+		})
+	}
+	return fixes
+}
+
+// replaceGUID swaps the GUID in one file, in any letter case; checked out first
+func replaceGUID(basePath, rel, oldGUID, newGUID string) (int, error) {
+	p := filepath.Join(basePath, filepath.FromSlash(rel))
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return 0, err
+	}
+	re := regexp.MustCompile(`(?i)` + oldGUID)
+	n := len(re.FindAllIndex(data, -1))
+	if n == 0 {
+		return 0, nil
+	}
+	activeVCS.checkout(p)
+	info, err := os.Stat(p)
+	if err != nil {
+		return 0, err
+	}
+	tmp := p + ".guidtmp"
+	if err := os.WriteFile(tmp, re.ReplaceAllLiteral(data, []byte(newGUID)), info.Mode().Perm()); err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	return n, nil
+}
+
+// applyFix rewrites the duplicate's .meta, then the references inside its copy root
+func applyFix(basePath string, f *guidFix) (int, error) {
+	if _, err := replaceGUID(basePath, f.entry.asset+".meta", f.oldGUID, f.newGUID); err != nil {
+		return 0, fmt.Errorf("%s.meta: %v", f.entry.asset, err)
+	}
+	changed := 0
+	var errs []string
+	for _, rel := range f.files {
+		if _, err := replaceGUID(basePath, rel, f.oldGUID, f.newGUID); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", rel, err))
+			continue
+		}
+		changed++
+	}
+	if len(errs) > 0 {
+		return changed, fmt.Errorf("%d reference file(s) could not be rewritten: %s", len(errs), strings.Join(errs, "; "))
+	}
+	return changed, nil
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+// describeAge renders when an entry was added for the report
+func describeAge(e *metaEntry) string {
+	if e.added.IsZero() {
+		return tr("unknown")
+	}
+	when := e.added.Local().Format("2006-01-02 15:04")
+	if e.tracked {
+		return fmt.Sprintf(tr("committed %s"), when)
+	}
+	return fmt.Sprintf(tr("not committed, modified %s"), when)
+}
+
+// printCollisions lists every duplicated GUID with the asset that keeps it and,
+// once planned, the new GUID and the rewritten files of each copy
+func printCollisions(collisions []*collision) {
+	for _, c := range collisions {
+		fmt.Printf("\n  guid %s\n", c.guid)
+		keep := c.entries[0]
+		fmt.Printf(tr("    [KEEP] %s (%s)\n"), keep.asset, describeAge(keep))
+		for i, e := range c.entries[1:] {
+			fmt.Printf(tr("    [DUP]  %s (%s)\n"), e.asset, describeAge(e))
+			if i < len(c.fixes) {
+				f := c.fixes[i]
+				fmt.Printf(tr("           new guid %s; %d reference file(s) under %s/\n"), f.newGUID, len(f.files), f.root)
+				for _, rel := range f.files {
+					fmt.Printf("             %s\n", rel)
+				}
+			}
+		}
+	}
+}
+
+// ============================================================
+// Version Control Checkout
+// ============================================================
+
+// Perforce and Plastic SCM keep files read-only until they are checked out. Writing
+// them directly (even after clearing the flag) leaves changes the server does not know
+// about, so files are opened for edit first. Git and plain folders need nothing.
+const (
+	vcsNone     = "none"
+	vcsPerforce = "p4"
+	vcsPlastic  = "plastic"
+)
+
+// vcsEnvVar overrides detection for every tool (e.g. on build agents)
+const vcsEnvVar = "UNITYSTARTER_VCS"
+
+// vcsClient issues checkouts for files about to be modified
+type vcsClient struct {
+	kind       string
+	source     string // how kind was chosen, for the startup message
+	checkedOut map[string]bool
+}
+
+// activeVCS is set up in main; nil means no checkouts
+var activeVCS *vcsClient
+
+// detectVCS picks the checkout provider. Priority: -vcs flag > UNITYSTARTER_VCS >
+// Unity's VersionControlSettings.asset mode > workspace markers (.plastic, P4CONFIG).
+func detectVCS(projectRoot, override string) (*vcsClient, error) {
+	normalize := func(v string) (string, bool) {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "p4", "perforce":
+			return vcsPerforce, true
+		case "plastic", "plasticscm", "cm", "unityvcs":
+			return vcsPlastic, true
+		case "none", "git", "off":
+			return vcsNone, true
+		}
+		return "", false
+	}
+	newClient := func(kind, source string) *vcsClient {
+		return &vcsClient{kind: kind, source: source, checkedOut: make(map[string]bool)}
+	}
+
+	if override != "" && override != "auto" {
+		kind, ok := normalize(override)
+		if !ok {
+			return nil, fmt.Errorf("unknown -vcs value '%s' (use auto, none, p4 or plastic)", override)
+		}
+		return newClient(kind, "-vcs flag"), nil
+	}
+	if env := os.Getenv(vcsEnvVar); env != "" {
+		if kind, ok := normalize(env); ok {
+			return newClient(kind, vcsEnvVar), nil
+		}
+	}
+
+	// Unity stores the project's integrated VCS in m_Mode ("Perforce", "PlasticSCM", ...)
+	if data, err := os.ReadFile(filepath.Join(projectRoot, "ProjectSettings", "VersionControlSettings.asset")); err == nil {
+		if m := regexp.MustCompile(`(?m)^\s*m_Mode:\s*(.+?)\s*$`).FindSubmatch(data); m != nil {
+			switch strings.ToLower(string(m[1])) {
+			case "perforce":
+				return newClient(vcsPerforce, "VersionControlSettings.asset"), nil
+			case "plasticscm", "plastic scm", "unity version control":
+				return newClient(vcsPlastic, "VersionControlSettings.asset"), nil
+			}
+		}
+	}
+
+	// Workspace markers, searched upward from the project root
+	p4config := os.Getenv("P4CONFIG")
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info != nil {
+				return newClient(vcsNone, "git repository"), nil
+			}
+			if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+				return newClient(vcsPlastic, ".plastic workspace"), nil
+			}
+			if p4config != "" {
+				if _, err := os.Stat(filepath.Join(dir, p4config)); err == nil {
+					return newClient(vcsPerforce, "P4CONFIG"), nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if os.Getenv("P4CLIENT") != "" {
+		return newClient(vcsPerforce, "P4CLIENT"), nil
+	}
+	return newClient(vcsNone, "no Perforce/Plastic workspace found"), nil
+}
+
+// checkout opens an existing file for edit. New files need no checkout, and files
+// outside the depot/workspace only produce a warning: the write still goes ahead.
+func (v *vcsClient) checkout(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || v.checkedOut[abs] {
+		return
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return
+	}
+	v.checkedOut[abs] = true
+
+	var cmd *exec.Cmd
+	switch v.kind {
+	case vcsPerforce:
+		cmd = exec.Command("p4", "edit", abs)
+	case vcsPlastic:
+		cmd = exec.Command("cm", "checkout", abs)
+	default:
+		return
+	}
+	// Run from the file's folder so P4CONFIG / the Plastic workspace is resolved
+	cmd.Dir = filepath.Dir(abs)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		fmt.Printf(tr("[WARNING] %s checkout failed for %s: %v %s\n"), v.kind, filepath.Base(abs), err, strings.TrimSpace(string(out)))
+		return
+	}
+	fmt.Printf(tr("[VCS] Checked out (%s): %s\n"), v.kind, filepath.Base(abs))
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...":                 "\n按回车键继续...",
+	"[WARNING] %s checkout failed for %s: %v %s\n": "[WARNING] %s 签出失败 %s: %v %s\n",
+	"[VCS] Checked out (%s): %s\n":                 "[VCS] 已签出 (%s): %s\n",
+
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  GUID CHECK":    "  GUID 检查",
+	"  Project: %s\n": "  项目:   %s\n",
+	"  Scanned: %s\n": "  扫描:   %s\n",
+	"  %d .meta file(s), %d GUID(s) held more than once\n":                       "  %d 个 .meta 文件，%d 个 GUID 被重复使用\n",
+	"[WARNING] %s.meta has no GUID; Unity gives the asset a new one on import\n": "[WARNING] %s.meta 没有 GUID；Unity 导入时会为该资源分配新的 GUID\n",
+	"\n[OK] Every GUID is unique.":                                               "\n[OK] 所有 GUID 均唯一。",
+	"unknown":                                                                    "未知",
+	"committed %s":                                                               "提交于 %s",
+	"not committed, modified %s":                                                 "未提交，修改于 %s",
+	"    [KEEP] %s (%s)\n":                                                       "    [保留] %s (%s)\n",
+	"    [DUP]  %s (%s)\n":                                                       "    [重复] %s (%s)\n",
+	"           new guid %s; %d reference file(s) under %s/\n":                   "           新 guid %s；%[3]s/ 下有 %[2]d 个引用文件\n",
+	"\n[TIP] Run with -fix to give the newer copies new GUIDs (add -dry-run to preview).":                                 "\n[TIP] 使用 -fix 为较新的副本分配新 GUID（加上 -dry-run 可预览）。",
+	"\n[Dry Run] No files were changed.":                                                                                  "\n[Dry Run] 未修改任何文件。",
+	"\n[ERROR] Unity Editor is running (PID: %d). Close it first; it would import the copies again with the old GUIDs.\n": "\n[ERROR] Unity 编辑器正在运行 (PID: %d)。请先关闭；否则编辑器会以旧 GUID 重新导入副本。\n",
+	"\nGive %d duplicate(s) new GUIDs? (y/N): ":                                                                           "\n为 %d 个重复项分配新 GUID？(y/N): ",
+	"Operation cancelled.":                            "操作已取消。",
+	"[VCS] Using %s (%s)\n":                           "[VCS] 使用 %s (%s)\n",
+	"[FAIL] %s: %v\n":                                 "[FAIL] %s: %v\n",
+	"[OK]   %s: %s, %d reference file(s) rewritten\n": "[OK]   %s: %s，已改写 %d 个引用文件\n",
+	"\n[TIP] References from outside the copied folders still point at the originals; check them in Unity.": "\n[TIP] 副本文件夹之外的引用仍指向原始资源；请在 Unity 中检查。",
+
+	"[WARNING] Removing a stale %s (%s)\n":                      "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n": "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+
+	"[OK] Snapshot taken: %s (undo with: unity_snapshot restore %s)\n": "[OK] 已创建快照: %s（撤销命令: unity_snapshot restore %s）\n",
+}
+
+// ============================================================
+// Snapshot (-snapshot)
+// ============================================================
+
+// takeSnapshot runs "unity_snapshot create" on the project, looking the tool up
+// next to this executable, then on PATH, and returns the new snapshot's ID
+func takeSnapshot(basePath, label string) (string, error) {
+	file := "unity_snapshot"
+	if runtime.GOOS == "windows" {
+		file += ".exe"
+	}
+	exe := ""
+	if self, err := os.Executable(); err == nil {
+		candidate := filepath.Join(filepath.Dir(self), file)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			exe = candidate
+		}
+	}
+	if exe == "" {
+		p, err := exec.LookPath(file)
+		if err != nil {
+			return "", fmt.Errorf("%s not found next to this tool or on PATH", file)
+		}
+		exe = p
+	}
+
+	cmd := exec.Command(exe, "create", "-json", "-label", label)
+	cmd.Dir = basePath
+	out, runErr := cmd.Output()
+	var result struct {
+		Success   bool     `json:"success"`
+		Errors    []string `json:"errors"`
+		Artifacts []string `json:"artifacts"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil || !result.Success || len(result.Artifacts) == 0 {
+		switch {
+		case len(result.Errors) > 0:
+			return "", fmt.Errorf("%s", strings.Join(result.Errors, "; "))
+		case runErr != nil:
+			return "", fmt.Errorf("%s: %v", file, runErr)
+		}
+		return "", fmt.Errorf("%s did not report a snapshot", file)
+	}
+	return filepath.Base(filepath.FromSlash(result.Artifacts[0])), nil
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// CI Annotations
+// ============================================================
+
+// With -annotate, errors and warnings are printed once more as GitHub Actions
+// workflow commands or TeamCity service messages, so the CI lists them on the run
+// page and, when they point at a file, next to that file in the diff.
+var (
+	annotateMode  string              // "", "github" or "teamcity"
+	annotateTool  string              // TeamCity inspection category
+	annotateBase  string              // project root; relative files start here
+	annotateRoot  string              // repository root; CI file paths are relative to it
+	annotateTypes = map[string]bool{} // TeamCity inspection types already declared
+)
+
+// setAnnotateMode checks the -annotate value; "auto" picks the CI the tool runs in
+func setAnnotateMode(mode, basePath, tool string) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "none":
+		annotateMode = ""
+	case "github", "teamcity":
+		annotateMode = strings.ToLower(strings.TrimSpace(mode))
+	case "auto":
+		annotateMode = ""
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			annotateMode = "github"
+		} else if os.Getenv("TEAMCITY_VERSION") != "" {
+			annotateMode = "teamcity"
+		}
+	default:
+		return fmt.Errorf("unknown -annotate mode '%s' (use github, teamcity or auto)", mode)
+	}
+	annotateTool = tool
+	annotateBase, _ = filepath.Abs(basePath)
+	annotateRoot = repositoryRoot(annotateBase)
+	return nil
+}
+
+// repositoryRoot is GITHUB_WORKSPACE, else the nearest folder with a .git entry
+// (a folder, or a file in worktrees and submodules), else the project itself
+func repositoryRoot(basePath string) string {
+	if ws := os.Getenv("GITHUB_WORKSPACE"); ws != "" && annotateMode == "github" {
+		return ws
+	}
+	for dir := basePath; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return basePath
+		}
+	}
+}
+
+// annotate prints one annotation. level is "error" or "warning"; file is absolute
+// or relative to the project root, and may be empty like line and col.
+func annotate(level, file string, line, col int, title, message string) {
+	if annotateMode == "" {
+		return
+	}
+	if file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(annotateBase, filepath.FromSlash(file))
+		}
+		if rel, err := filepath.Rel(annotateRoot, file); err == nil {
+			file = rel
+		}
+		file = filepath.ToSlash(file)
+	}
+
+	if annotateMode == "github" {
+		// Property values also escape ':' and ','; the message only '%' and line breaks
+		data := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+		prop := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+		var props []string
+		if file != "" {
+			props = append(props, "file="+prop.Replace(file))
+			if line > 0 {
+				props = append(props, "line="+strconv.Itoa(line))
+			}
+			if col > 0 {
+				props = append(props, "col="+strconv.Itoa(col))
+			}
+		}
+		if title != "" {
+			props = append(props, "title="+prop.Replace(title))
+		}
+		fmt.Printf("::%s %s::%s\n", level, strings.Join(props, ","), data.Replace(message))
+		return
+	}
+
+	esc := strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]").Replace
+	status := "WARNING"
+	if level == "error" {
+		status = "ERROR"
+	}
+	if file == "" {
+		text := message
+		if title != "" {
+			text = title + ": " + message
+		}
+		fmt.Printf("##teamcity[message text='%s' status='%s']\n", esc(text), status)
+		return
+	}
+	typeID := annotateTool + "." + strings.ReplaceAll(strings.ToLower(title), " ", "-")
+	if !annotateTypes[typeID] {
+		annotateTypes[typeID] = true
+		fmt.Printf("##teamcity[inspectionType id='%s' name='%s' category='%s' description='%s']\n", esc(typeID), esc(title), esc(annotateTool), esc(title))
+	}
+	lineAttr := ""
+	if line > 0 {
+		lineAttr = fmt.Sprintf(" line='%d'", line)
+	}
+	fmt.Printf("##teamcity[inspection typeId='%s' message='%s' file='%s'%s SEVERITY='%s']\n", esc(typeID), esc(message), esc(file), lineAttr, status)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode, dryRun, fix, force, snapshot bool
+	var vcsMode, annotateFl string
+
+	flag.BoolVar(&fix, "fix", false, "Give the newer duplicates new GUIDs and rewrite the references inside their copied folders")
+	flag.BoolVar(&dryRun, "dry-run", false, "-fix: list the new GUIDs and the files that would change without writing")
+	flag.BoolVar(&snapshot, "snapshot", false, "-fix: take a unity_snapshot restore point of Assets/, Packages/ and ProjectSettings/ first")
+	flag.StringVar(&annotateFl, "annotate", "", "Also print duplicates as CI annotations: github, teamcity, auto")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no confirmation prompts)")
+	flag.BoolVar(&force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
+	flag.StringVar(&vcsMode, "vcs", "auto", "Checkout before writing: auto, none, p4, plastic")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_guid_checker")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if err := setAnnotateMode(annotateFl, basePath, "unity_guid_checker"); err != nil {
+		fail(2, "%v", err)
+	}
+
+	roots := scanRoots(basePath)
+	printRule("=============================================")
+	fmt.Println(tr("  GUID CHECK"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project: %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Scanned: %s\n"), strings.Join(roots, ", "))
+
+	guids, noGUID, count := scanGUIDs(basePath, roots)
+	collisions := findCollisions(basePath, guids)
+	fmt.Printf(tr("  %d .meta file(s), %d GUID(s) held more than once\n"), count, len(collisions))
+	for _, rel := range noGUID {
+		fmt.Printf(tr("[WARNING] %s.meta has no GUID; Unity gives the asset a new one on import\n"), rel)
+		recordAction("check", rel+".meta", "skipped", "no guid", 0)
+	}
+	if len(collisions) == 0 {
+		fmt.Println(tr("\n[OK] Every GUID is unique."))
+		exit(0)
+	}
+
+	var fixes []*guidFix
+	if fix {
+		fixes = planFixes(basePath, roots, collisions, guids)
+	}
+	printCollisions(collisions)
+	for _, c := range collisions {
+		for _, e := range c.entries[1:] {
+			msg := fmt.Sprintf("GUID %s is also used by %s", c.guid, c.entries[0].asset)
+			annotate("error", e.asset+".meta", 0, 0, "Duplicate GUID", msg)
+			if !fix {
+				recordAction("check", e.asset+".meta", "failed", msg, 0)
+			}
+		}
+	}
+
+	if !fix {
+		recordError("%d GUID(s) held more than once", len(collisions))
+		fmt.Println(tr("\n[TIP] Run with -fix to give the newer copies new GUIDs (add -dry-run to preview)."))
+		exit(1)
+	}
+	if dryRun {
+		for _, f := range fixes {
+			recordAction("regenerate", f.entry.asset+".meta", "planned", fmt.Sprintf("%s -> %s, %d reference file(s)", f.oldGUID, f.newGUID, len(f.files)), 0)
+		}
+		fmt.Println(tr("\n[Dry Run] No files were changed."))
+		exit(0)
+	}
+
+	if isRunning, pid := checkUnityRunning(basePath); isRunning {
+		fmt.Printf(tr("\n[ERROR] Unity Editor is running (PID: %d). Close it first; it would import the copies again with the old GUIDs.\n"), pid)
+		recordError("Unity Editor is running (PID: %d)", pid)
+		exit(1)
+	}
+	if !ciMode {
+		fmt.Printf(tr("\nGive %d duplicate(s) new GUIDs? (y/N): "), len(fixes))
+		confirm, _ := stdinReader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+			fmt.Println(tr("Operation cancelled."))
+			exit(0)
+		}
+	}
+	if err := acquireProjectLock(basePath, "unity_guid_checker", "fix", force); err != nil {
+		fail(1, "%v", err)
+	}
+	defer releaseProjectLock()
+	if activeVCS, err = detectVCS(basePath, vcsMode); err != nil {
+		fail(1, "%v", err)
+	}
+	if activeVCS.kind != vcsNone {
+		fmt.Printf(tr("[VCS] Using %s (%s)\n"), activeVCS.kind, activeVCS.source)
+	}
+	if snapshot {
+		id, err := takeSnapshot(basePath, fmt.Sprintf("before unity_guid_checker -fix (%d duplicate(s))", len(fixes)))
+		if err != nil {
+			fail(1, "snapshot failed, nothing was changed: %v", err)
+		}
+		fmt.Printf(tr("[OK] Snapshot taken: %s (undo with: unity_snapshot restore %s)\n"), id, id)
+		recordAction("snapshot", id, "ok", "", 0)
+	}
+
+	fmt.Println()
+	failed := 0
+	for _, f := range fixes {
+		n, err := applyFix(basePath, f)
+		if err != nil {
+			failed++
+			fmt.Printf(tr("[FAIL] %s: %v\n"), f.entry.asset, err)
+			recordAction("regenerate", f.entry.asset+".meta", "failed", err.Error(), 0)
+			recordError("%s: %v", f.entry.asset, err)
+			continue
+		}
+		fmt.Printf(tr("[OK]   %s: %s, %d reference file(s) rewritten\n"), f.entry.asset, f.newGUID, n)
+		recordAction("regenerate", f.entry.asset+".meta", "ok", fmt.Sprintf("%s -> %s, %d reference file(s)", f.oldGUID, f.newGUID, n), 0)
+	}
+	if failed > 0 {
+		exit(1)
+	}
+	fmt.Println(tr("\n[TIP] References from outside the copied folders still point at the originals; check them in Unity."))
+	exit(0)
+}
@@ -0,0 +1,1564 @@
+// Unity Hierarchy Export — Write the hierarchy of scenes and prefabs as Markdown or JSON.
+// Reads the scene or prefab YAML and writes every GameObject with its components,
+// the script behind each MonoBehaviour and the values of its serialized fields, and
+// every prefab instance with its source prefab and overrides. Commit the output next
+// to the assets and changes to a level or a prefab can be reviewed in a pull request
+// without opening Unity; -check fails when an export is out of date.
+//
+// Build: go build unity_hierarchy_export.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_hierarchy_export Assets/Scenes/Main.unity          # Docs/Hierarchy/Assets/Scenes/Main.unity.md
+//	unity_hierarchy_export -all                              # every scene and prefab under Assets/
+//	unity_hierarchy_export -format json -o - Assets/Prefabs/Player.prefab
+//	unity_hierarchy_export -all -check -ci                   # CI: exports must be up to date
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const defaultOutDir = "Docs/Hierarchy" // relative to the project root, outside Assets/
+
+// Output formats
+const (
+	formatMarkdown = "markdown"
+	formatJSON     = "json"
+)
+
+// Which serialized fields are exported
+const (
+	fieldsNone = "none" // components only
+	fieldsKey  = "key"  // fields of scripts, and prefab overrides other than layout
+	fieldsAll  = "all"  // also the fields of built-in components, and every override
+)
+
+// Unity class IDs the hierarchy is built from
+const (
+	classGameObject     = "1"
+	classTransform      = "4"
+	classMonoBehaviour  = "114"
+	classRectTransform  = "224"
+	classPrefabInstance = "1001"
+)
+
+var (
+	// "--- !u!1 &1234567 stripped": class ID, file ID, stripped (a placeholder for
+	// an object inside a prefab instance)
+	yamlDocRegex = regexp.MustCompile(`^--- !u!(\d+) &(-?\d+)( stripped)?`)
+	// "  m_Name: Player": a top-level field of a document
+	yamlFieldRegex = regexp.MustCompile(`^  ([^\s-][^:]*):(?: (.*))?$`)
+	// {fileID: 123} or {fileID: 11500000, guid: 0123abcd..., type: 3}
+	objectRefRegex = regexp.MustCompile(`^\{fileID: (-?\d+)(?:, guid: ([0-9a-fA-F]{32}))?(?:, type: \d+)?\}$`)
+	metaGUIDRegex  = regexp.MustCompile(`(?m)^guid:\s*([0-9a-fA-F]{32})`)
+)
+
+// Fields every document or component has that say nothing about the design
+var boilerplateFields = map[string]bool{
+	"m_ObjectHideFlags": true, "m_CorrespondingSourceObject": true, "m_PrefabInstance": true,
+	"m_PrefabAsset": true, "m_PrefabInternal": true, "m_PrefabParentObject": true,
+	"m_GameObject": true, "m_Script": true, "m_Enabled": true, "m_EditorHideFlags": true,
+	"m_EditorClassIdentifier": true, "serializedVersion": true, "m_Father": true,
+	"m_Children": true, "m_RootOrder": true, "m_Name": true,
+}
+
+// Prefab overrides that only move an instance; left out unless -fields all
+var layoutOverrides = []string{
+	"m_LocalPosition", "m_LocalRotation", "m_LocalEulerAnglesHint", "m_LocalScale",
+	"m_AnchoredPosition", "m_SizeDelta", "m_AnchorMin", "m_AnchorMax", "m_Pivot",
+	"m_RootOrder", "m_Name",
+}
+
+// GUIDs of the resources built into the editor
+var builtinGUIDs = map[string]string{
+	"0000000000000000d000000000000000": "Built-in Editor",
+	"0000000000000000e000000000000000": "Built-in Extra",
+	"0000000000000000f000000000000000": "Built-in Default",
+}
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// yamlField is one top-level field of a document: the value on its line, and the
+// lines of a nested map, a list or a long string below it
+type yamlField struct {
+	key   string
+	value string
+	lines []string
+}
+
+// yamlDoc is one object of a scene or prefab file
+type yamlDoc struct {
+	class    string
+	fileID   string
+	stripped bool
+	typeName string // "GameObject", "MonoBehaviour", ...
+	fields   []*yamlField
+	byKey    map[string]*yamlField
+}
+
+func (d *yamlDoc) get(key string) string {
+	if f := d.byKey[key]; f != nil {
+		return f.value
+	}
+	return ""
+}
+
+// ref returns the file ID a field like m_GameObject: {fileID: 123} points at
+func (d *yamlDoc) ref(key string) string {
+	if m := objectRefRegex.FindStringSubmatch(d.get(key)); m != nil {
+		return m[1]
+	}
+	return "0"
+}
+
+// unityFile is a parsed scene or prefab
+type unityFile struct {
+	docs  []*yamlDoc
+	byID  map[string]*yamlDoc
+	roots []string // file IDs of the roots in hierarchy order (SceneRoots, 2022.2+)
+}
+
+// field is one exported field value
+type field struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type component struct {
+	Type     string  `json:"type"`
+	Script   string  `json:"script,omitempty"` // path of the MonoScript
+	Disabled bool    `json:"disabled,omitempty"`
+	Added    bool    `json:"added,omitempty"` // added to a prefab instance
+	Fields   []field `json:"fields,omitempty"`
+}
+
+type override struct {
+	Target   string `json:"target,omitempty"` // object in the source prefab
+	Property string `json:"property"`
+	Value    string `json:"value"`
+}
+
+// node is a GameObject or a prefab instance
+type node struct {
+	Name       string      `json:"name"`
+	Inactive   bool        `json:"inactive,omitempty"`
+	Tag        string      `json:"tag,omitempty"`
+	Layer      string      `json:"layer,omitempty"`
+	Prefab     string      `json:"prefab,omitempty"` // source of a prefab instance
+	Components []component `json:"components,omitempty"`
+	Overrides  []override  `json:"overrides,omitempty"`
+	Children   []*node     `json:"children,omitempty"`
+
+	order int // m_RootOrder, for roots without SceneRoots
+}
+
+// export is the document written for one scene or prefab
+type export struct {
+	Path            string  `json:"path"`
+	Kind            string  `json:"kind"`                // "scene", "prefab" or "prefab variant"
+	VariantOf       string  `json:"variantOf,omitempty"` // base of a prefab variant
+	GameObjects     int     `json:"gameObjects"`         // GameObjects in the file
+	PrefabInstances int     `json:"prefabInstances"`     // prefab instances in the file
+	Roots           []*node `json:"roots"`
+}
+
+// exporter holds what is shared between files: the GUID index, layer names and
+// the source prefabs read so far
+type exporter struct {
+	basePath string
+	fields   string
+	depth    int
+	guids    map[string]string // GUID -> project-relative asset path
+	layers   map[string]string // layer number -> name
+	sources  map[string]*unityFile
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Asset Index
+// ============================================================
+
+// indexGUIDs maps the GUID of every asset in Assets/, the embedded packages and
+// the package cache to its path, to name scripts and referenced assets
+func indexGUIDs(basePath string) map[string]string {
+	guids := make(map[string]string)
+	roots := []string{"Assets", "Packages", "Library/PackageCache"}
+	for _, root := range roots {
+		rootPath := filepath.Join(basePath, filepath.FromSlash(root))
+		filepath.Walk(rootPath, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if name := info.Name(); p != rootPath && (strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(p, ".meta") {
+				return nil
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return nil
+			}
+			if m := metaGUIDRegex.FindSubmatch(data); m != nil {
+				rel, _ := filepath.Rel(basePath, strings.TrimSuffix(p, ".meta"))
+				rel = filepath.ToSlash(rel)
+				// Cached packages are Library/PackageCache/com.x.y@1.2.3/...; show them as Packages/com.x.y/...
+				if strings.HasPrefix(rel, "Library/PackageCache/") {
+					parts := strings.SplitN(strings.TrimPrefix(rel, "Library/PackageCache/"), "/", 2)
+					if at := strings.Index(parts[0], "@"); at > 0 {
+						parts[0] = parts[0][:at]
+					}
+					rel = "Packages/" + strings.Join(parts, "/")
+				}
+				guid := strings.ToLower(string(m[1]))
+				if _, seen := guids[guid]; !seen {
+					guids[guid] = rel
+				}
+			}
+			return nil
+		})
+	}
+	return guids
+}
+
+// readLayers reads the layer names from the TagManager
+func readLayers(basePath string) map[string]string {
+	layers := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "TagManager.asset"))
+	if err != nil {
+		return layers
+	}
+	inLayers, index := false, 0
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "layers:" {
+			inLayers = true
+			continue
+		}
+		if !inLayers {
+			continue
+		}
+		item := strings.TrimSpace(line)
+		if !strings.HasPrefix(item, "-") {
+			break
+		}
+		if name := strings.TrimSpace(strings.TrimPrefix(item, "-")); name != "" {
+			layers[strconv.Itoa(index)] = name
+		}
+		index++
+	}
+	return layers
+}
+
+// findScenesAndPrefabs lists every .unity and .prefab file under Assets/
+func findScenesAndPrefabs(basePath string) []string {
+	var files []string
+	root := filepath.Join(basePath, "Assets")
+	filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if name := info.Name(); p != root && (strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := strings.ToLower(filepath.Ext(p)); ext == ".unity" || ext == ".prefab" {
+			rel, _ := filepath.Rel(basePath, p)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files
+}
+
+// ============================================================
+// YAML Documents
+// ============================================================
+
+// parseUnityFile splits a scene or prefab into its documents and their top-level
+// fields. Files saved with Force Binary serialization cannot be read.
+func parseUnityFile(data []byte) (*unityFile, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(text, "%YAML") {
+		return nil, fmt.Errorf("not a text asset (set Asset Serialization to Force Text)")
+	}
+	uf := &unityFile{byID: make(map[string]*yamlDoc)}
+	var doc *yamlDoc
+	var last *yamlField
+	for _, line := range strings.Split(text, "\n") {
+		if m := yamlDocRegex.FindStringSubmatch(line); m != nil {
+			doc = &yamlDoc{class: m[1], fileID: m[2], stripped: m[3] != "", byKey: make(map[string]*yamlField)}
+			uf.docs = append(uf.docs, doc)
+			uf.byID[doc.fileID] = doc
+			last = nil
+			continue
+		}
+		if doc == nil {
+			continue
+		}
+		if doc.typeName == "" {
+			doc.typeName = strings.TrimSuffix(strings.TrimSpace(line), ":")
+			continue
+		}
+		if m := yamlFieldRegex.FindStringSubmatch(line); m != nil {
+			last = &yamlField{key: m[1], value: strings.TrimSpace(m[2])}
+			doc.fields = append(doc.fields, last)
+			if _, dup := doc.byKey[last.key]; !dup {
+				doc.byKey[last.key] = last
+			}
+			continue
+		}
+		if last != nil && strings.HasPrefix(line, "  ") {
+			last.lines = append(last.lines, line)
+		}
+	}
+	for _, d := range uf.docs {
+		if d.typeName == "SceneRoots" {
+			uf.roots = listRefs(d.byKey["m_Roots"])
+		}
+	}
+	return uf, nil
+}
+
+// listRefs returns the file IDs of a list of {fileID: ...} items
+func listRefs(f *yamlField) []string {
+	if f == nil {
+		return nil
+	}
+	var ids []string
+	for _, line := range f.lines {
+		item := strings.TrimSpace(line)
+		if !strings.HasPrefix(item, "- ") {
+			continue
+		}
+		item = strings.TrimSpace(strings.TrimPrefix(item, "- "))
+		// GameObject m_Component items are "component: {fileID: 1}" (older: "4: {fileID: 1}")
+		if i := strings.Index(item, ": {"); i >= 0 && !strings.HasPrefix(item, "{") {
+			item = item[i+2:]
+		}
+		if m := objectRefRegex.FindStringSubmatch(item); m != nil {
+			ids = append(ids, m[1])
+		}
+	}
+	return ids
+}
+
+// modification is one entry of a prefab instance's m_Modifications
+type modification struct {
+	targetID, targetGUID string
+	property, value      string
+	objectRef            string
+}
+
+// modifications reads m_Modification.m_Modifications of a prefab instance
+func modifications(d *yamlDoc) []modification {
+	f := d.byKey["m_Modification"]
+	if f == nil {
+		return nil
+	}
+	var mods []modification
+	var cur *modification
+	inList := false
+	for _, line := range f.lines {
+		item := strings.TrimSpace(line)
+		// Keys of m_Modification are indented by four spaces, list items by six
+		if strings.HasPrefix(line, "    ") && !strings.HasPrefix(line, "     ") && !strings.HasPrefix(item, "- ") {
+			inList = item == "m_Modifications:"
+			continue
+		}
+		if !inList {
+			continue
+		}
+		if strings.HasPrefix(item, "- target: ") {
+			mods = append(mods, modification{})
+			cur = &mods[len(mods)-1]
+			if m := objectRefRegex.FindStringSubmatch(strings.TrimPrefix(item, "- target: ")); m != nil {
+				cur.targetID, cur.targetGUID = m[1], strings.ToLower(m[2])
+			}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(item, "propertyPath: "):
+			cur.property = strings.TrimPrefix(item, "propertyPath: ")
+		case strings.HasPrefix(item, "value: "):
+			cur.value = yamlUnquote(strings.TrimPrefix(item, "value: "))
+		case item == "value:":
+			cur.value = ""
+		case strings.HasPrefix(item, "objectReference: "):
+			cur.objectRef = strings.TrimPrefix(item, "objectReference: ")
+		}
+	}
+	return mods
+}
+
+// transformParent reads m_Modification.m_TransformParent of a prefab instance
+func transformParent(d *yamlDoc) string {
+	if f := d.byKey["m_Modification"]; f != nil {
+		for _, line := range f.lines {
+			item := strings.TrimSpace(line)
+			if strings.HasPrefix(item, "m_TransformParent: ") {
+				if m := objectRefRegex.FindStringSubmatch(strings.TrimPrefix(item, "m_TransformParent: ")); m != nil {
+					return m[1]
+				}
+			}
+		}
+	}
+	return "0"
+}
+
+// sourcePrefab is the GUID of the prefab an instance was made from
+func sourcePrefab(d *yamlDoc) string {
+	for _, key := range []string{"m_SourcePrefab", "m_ParentPrefab"} {
+		if m := objectRefRegex.FindStringSubmatch(d.get(key)); m != nil && m[2] != "" {
+			return strings.ToLower(m[2])
+		}
+	}
+	return ""
+}
+
+// yamlUnquote turns a YAML scalar into its text
+func yamlUnquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// ============================================================
+// Hierarchy
+// ============================================================
+
+// transformOf returns the Transform or RectTransform of a GameObject
+func (uf *unityFile) transformOf(gameObject *yamlDoc) *yamlDoc {
+	for _, id := range listRefs(gameObject.byKey["m_Component"]) {
+		if c := uf.byID[id]; c != nil && (c.class == classTransform || c.class == classRectTransform) {
+			return c
+		}
+	}
+	return nil
+}
+
+// source reads a prefab by GUID, once per run
+func (e *exporter) source(guid string) *unityFile {
+	if uf, ok := e.sources[guid]; ok {
+		return uf
+	}
+	var uf *unityFile
+	if rel, ok := e.guids[guid]; ok {
+		if data, err := os.ReadFile(filepath.Join(e.basePath, filepath.FromSlash(rel))); err == nil {
+			uf, _ = parseUnityFile(data)
+		}
+	}
+	e.sources[guid] = uf
+	return uf
+}
+
+// rootName is the name of the root GameObject of a prefab, following variants
+func (e *exporter) rootName(guid string, depth int) string {
+	uf := e.source(guid)
+	if uf != nil && depth < 10 {
+		for _, d := range uf.docs {
+			if d.class == classGameObject && !d.stripped {
+				if t := uf.transformOf(d); t != nil && t.ref("m_Father") == "0" {
+					return yamlUnquote(d.get("m_Name"))
+				}
+			}
+		}
+		for _, d := range uf.docs {
+			if d.class == classPrefabInstance && !d.stripped && transformParent(d) == "0" {
+				return e.instanceName(d, depth+1)
+			}
+		}
+	}
+	return strings.TrimSuffix(filepath.Base(e.assetPath(guid)), ".prefab")
+}
+
+// instanceName is the name a prefab instance shows in the hierarchy: its m_Name
+// override of the source's root, else the name of that root
+func (e *exporter) instanceName(d *yamlDoc, depth int) string {
+	src := sourcePrefab(d)
+	rootIDs := e.rootIDs(src)
+	for _, m := range modifications(d) {
+		if m.property == "m_Name" && m.targetGUID == src && rootIDs[m.targetID] {
+			return m.value
+		}
+	}
+	return e.rootName(src, depth)
+}
+
+// rootIDs returns the file IDs of the root GameObject of a prefab: the GameObject,
+// or in a variant the stripped placeholders of its base's root
+func (e *exporter) rootIDs(guid string) map[string]bool {
+	ids := make(map[string]bool)
+	uf := e.source(guid)
+	if uf == nil {
+		return ids
+	}
+	for _, d := range uf.docs {
+		if d.class != classGameObject {
+			continue
+		}
+		if !d.stripped {
+			if t := uf.transformOf(d); t != nil && t.ref("m_Father") == "0" {
+				ids[d.fileID] = true
+			}
+			continue
+		}
+		if inst := uf.byID[d.ref("m_PrefabInstance")]; inst != nil && transformParent(inst) == "0" {
+			ids[d.fileID] = true
+		}
+	}
+	return ids
+}
+
+// assetPath names an asset by GUID for the export
+func (e *exporter) assetPath(guid string) string {
+	if rel, ok := e.guids[guid]; ok {
+		return rel
+	}
+	if name, ok := builtinGUIDs[guid]; ok {
+		return name
+	}
+	return "guid:" + guid
+}
+
+// scriptName is the class name behind a MonoBehaviour, from the script file name
+func (e *exporter) scriptName(d *yamlDoc) (string, string) {
+	m := objectRefRegex.FindStringSubmatch(d.get("m_Script"))
+	if m == nil || m[1] == "0" {
+		return "Missing script", ""
+	}
+	if m[2] == "" {
+		return d.typeName, ""
+	}
+	guid := strings.ToLower(m[2])
+	rel, ok := e.guids[guid]
+	if !ok {
+		return "Missing script (guid " + guid + ")", ""
+	}
+	if strings.EqualFold(filepath.Ext(rel), ".cs") {
+		return strings.TrimSuffix(filepath.Base(rel), filepath.Ext(rel)), rel
+	}
+	// Scripts in a DLL: the class name is not in the file
+	return filepath.Base(rel) + " (" + m[1] + ")", rel
+}
+
+// build turns a parsed file into its hierarchy
+func (e *exporter) build(rel string, uf *unityFile) *export {
+	ex := &export{Path: rel, Kind: "scene"}
+	if strings.EqualFold(filepath.Ext(rel), ".prefab") {
+		ex.Kind = "prefab"
+	}
+	nodes := make(map[string]*node) // GameObject or PrefabInstance file ID -> node
+	var rootIDs []string
+
+	// Stripped placeholders stand for objects inside a prefab instance
+	instanceOf := func(id string) *node {
+		if d := uf.byID[id]; d != nil && d.stripped {
+			return nodes[d.ref("m_PrefabInstance")]
+		}
+		return nil
+	}
+
+	for _, d := range uf.docs {
+		if d.stripped {
+			continue
+		}
+		switch d.class {
+		case classGameObject:
+			ex.GameObjects++
+			n := &node{Name: yamlUnquote(d.get("m_Name")), Inactive: d.get("m_IsActive") == "0"}
+			if tag := d.get("m_TagString"); tag != "" && tag != "Untagged" {
+				n.Tag = tag
+			}
+			if layer := d.get("m_Layer"); layer != "" && layer != "0" {
+				n.Layer = layer
+				if name, ok := e.layers[layer]; ok {
+					n.Layer = name
+				}
+			}
+			nodes[d.fileID] = n
+		case classPrefabInstance:
+			ex.PrefabInstances++
+			src := sourcePrefab(d)
+			n := &node{Name: e.instanceName(d, 0), Prefab: e.assetPath(src)}
+			if ex.Kind == "prefab" && transformParent(d) == "0" {
+				ex.Kind, ex.VariantOf = "prefab variant", n.Prefab
+			}
+			n.Overrides = e.overrides(uf, d, src)
+			nodes[d.fileID] = n
+		}
+	}
+
+	// Components, in the order of the GameObject's component list; components
+	// added to an object inside a prefab instance go to the instance
+	for _, d := range uf.docs {
+		if d.stripped || d.class != classGameObject {
+			continue
+		}
+		n := nodes[d.fileID]
+		for _, id := range listRefs(d.byKey["m_Component"]) {
+			// Every GameObject has a Transform; only listed with -fields all
+			if c := uf.byID[id]; c != nil && (c.class != classTransform || e.fields == fieldsAll) {
+				n.Components = append(n.Components, e.component(uf, c))
+			}
+		}
+	}
+	for _, d := range uf.docs {
+		if d.stripped || d.class == classGameObject || d.class == classPrefabInstance {
+			continue
+		}
+		if inst := instanceOf(d.ref("m_GameObject")); inst != nil {
+			c := e.component(uf, d)
+			c.Added = true
+			inst.Components = append(inst.Components, c)
+		}
+	}
+
+	// Parents: children are listed by the parent's Transform; objects added under
+	// an object of a prefab instance point at its stripped Transform
+	attached := make(map[*node]bool)
+	attach := func(parent, child *node) {
+		if parent != nil && child != nil && parent != child && !attached[child] {
+			parent.Children = append(parent.Children, child)
+			attached[child] = true
+		}
+	}
+	childNode := func(transformID string) *node {
+		t := uf.byID[transformID]
+		if t == nil {
+			return nil
+		}
+		if t.stripped {
+			return instanceOf(transformID)
+		}
+		return nodes[t.ref("m_GameObject")]
+	}
+	for _, d := range uf.docs {
+		if d.stripped || d.class != classGameObject {
+			continue
+		}
+		t := uf.transformOf(d)
+		if t == nil {
+			continue
+		}
+		for _, id := range listRefs(t.byKey["m_Children"]) {
+			attach(nodes[d.fileID], childNode(id))
+		}
+	}
+	for _, d := range uf.docs {
+		if d.stripped {
+			continue
+		}
+		switch d.class {
+		case classTransform, classRectTransform:
+			father := d.ref("m_Father")
+			if father == "0" {
+				if n := nodes[d.ref("m_GameObject")]; n != nil {
+					n.order, _ = strconv.Atoi(d.get("m_RootOrder"))
+					rootIDs = append(rootIDs, d.ref("m_GameObject"))
+				}
+			} else if inst := instanceOf(father); inst != nil {
+				attach(inst, nodes[d.ref("m_GameObject")])
+			}
+		case classPrefabInstance:
+			parent := transformParent(d)
+			if parent == "0" {
+				for _, m := range modifications(d) {
+					if m.property == "m_RootOrder" {
+						nodes[d.fileID].order, _ = strconv.Atoi(m.value)
+					}
+				}
+				rootIDs = append(rootIDs, d.fileID)
+			} else if inst := instanceOf(parent); inst != nil {
+				attach(inst, nodes[d.fileID])
+			} else if t := uf.byID[parent]; t != nil {
+				// Listed in the parent's m_Children since 2018.3; attach covers older files
+				attach(nodes[t.ref("m_GameObject")], nodes[d.fileID])
+			}
+		}
+	}
+
+	// Roots: SceneRoots order when the file has it, else m_RootOrder
+	if len(uf.roots) > 0 {
+		for _, id := range uf.roots {
+			n := nodes[id]
+			if n == nil {
+				n = childNode(id)
+			}
+			if n != nil && !attached[n] {
+				ex.Roots = append(ex.Roots, n)
+				attached[n] = true
+			}
+		}
+	}
+	var rest []*node
+	for _, id := range rootIDs {
+		if n := nodes[id]; !attached[n] {
+			rest = append(rest, n)
+			attached[n] = true
+		}
+	}
+	sort.SliceStable(rest, func(i, j int) bool { return rest[i].order < rest[j].order })
+	ex.Roots = append(ex.Roots, rest...)
+	return ex
+}
+
+// component describes one component and, depending on -fields, its values
+func (e *exporter) component(uf *unityFile, d *yamlDoc) component {
+	c := component{Type: d.typeName, Disabled: d.get("m_Enabled") == "0"}
+	custom := false
+	if d.class == classMonoBehaviour {
+		c.Type, c.Script = e.scriptName(d)
+		custom = c.Script != "" || !strings.HasPrefix(c.Type, "Missing script")
+	}
+	if e.fields == fieldsNone || (!custom && e.fields != fieldsAll) {
+		return c
+	}
+	for _, f := range d.fields {
+		if boilerplateFields[f.key] {
+			continue
+		}
+		c.Fields = append(c.Fields, field{Name: f.key, Value: e.formatValue(uf, f)})
+	}
+	return c
+}
+
+// overrides lists the property overrides of a prefab instance
+func (e *exporter) overrides(uf *unityFile, d *yamlDoc, src string) []override {
+	if e.fields == fieldsNone {
+		return nil
+	}
+	source := e.source(src)
+	var list []override
+	for _, m := range modifications(d) {
+		if e.fields != fieldsAll && isLayoutOverride(m.property) {
+			continue
+		}
+		o := override{Property: m.property, Value: m.value}
+		if m.objectRef != "" && m.objectRef != "{fileID: 0}" {
+			o.Value = e.formatRef(uf, m.objectRef)
+		}
+		if source != nil {
+			o.Target = describeObject(source, m.targetID)
+		}
+		list = append(list, o)
+	}
+	return list
+}
+
+func isLayoutOverride(property string) bool {
+	for _, p := range layoutOverrides {
+		if property == p || strings.HasPrefix(property, p+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// describeObject names an object of a file: "Body" for a GameObject, "Body
+// (MeshRenderer)" for a component
+func describeObject(uf *unityFile, id string) string {
+	d := uf.byID[id]
+	if d == nil {
+		return ""
+	}
+	if d.class == classGameObject {
+		return gameObjectName(uf, d)
+	}
+	if g := uf.byID[d.ref("m_GameObject")]; g != nil {
+		return gameObjectName(uf, g) + " (" + d.typeName + ")"
+	}
+	return d.typeName
+}
+
+// gameObjectName is the name of a GameObject, or of the prefab object a stripped
+// placeholder stands for
+func gameObjectName(uf *unityFile, d *yamlDoc) string {
+	if d.stripped {
+		return "prefab object " + d.fileID
+	}
+	return yamlUnquote(d.get("m_Name"))
+}
+
+// formatValue renders a field: scalars and references on one line, lists as their
+// items when they are short, nested structures as a summary
+func (e *exporter) formatValue(uf *unityFile, f *yamlField) string {
+	v := f.value
+	if v != "" && (v[0] == '\'' || v[0] == '"') && len(f.lines) > 0 {
+		// A long string continues on the following lines
+		for _, l := range f.lines {
+			v += " " + strings.TrimSpace(l)
+		}
+	}
+	if v != "" {
+		if strings.HasPrefix(v, "{fileID:") {
+			return e.formatRef(uf, v)
+		}
+		return yamlUnquote(v)
+	}
+	var items, members []string
+	nested := false
+	for _, l := range f.lines {
+		trimmed := strings.TrimSpace(l)
+		switch {
+		case strings.HasPrefix(l, "  - "):
+			items = append(items, strings.TrimSpace(strings.TrimPrefix(trimmed, "- ")))
+		case strings.HasPrefix(l, "    ") && !strings.HasPrefix(l, "     ") && !strings.HasSuffix(trimmed, ":"):
+			members = append(members, trimmed) // "health: 100" of a nested struct
+		default:
+			nested = true
+		}
+	}
+	switch {
+	case len(items) == 0 && len(members) == 0 && !nested:
+		return ""
+	case len(items) == 0 && !nested && len(members) <= 5:
+		return "{" + strings.Join(members, ", ") + "}"
+	case len(items) == 0:
+		return "{...}"
+	case nested || len(members) > 0 || len(items) > 5:
+		return fmt.Sprintf("[%d items]", len(items))
+	}
+	for i, item := range items {
+		if strings.HasPrefix(item, "{fileID:") {
+			items[i] = e.formatRef(uf, item)
+		} else {
+			items[i] = yamlUnquote(item)
+		}
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// formatRef renders an object reference: None, an object of this file, or an asset
+func (e *exporter) formatRef(uf *unityFile, ref string) string {
+	m := objectRefRegex.FindStringSubmatch(strings.TrimSpace(ref))
+	if m == nil {
+		return ref
+	}
+	if m[1] == "0" {
+		return "None"
+	}
+	if m[2] == "" {
+		if name := describeObject(uf, m[1]); name != "" {
+			return name
+		}
+		return "fileID " + m[1]
+	}
+	guid := strings.ToLower(m[2])
+	if _, ok := builtinGUIDs[guid]; ok {
+		return fmt.Sprintf("%s resource %s", e.assetPath(guid), m[1])
+	}
+	return e.assetPath(guid)
+}
+
+// ============================================================
+// Rendering
+// ============================================================
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", `\<`)
+
+// renderMarkdown writes the hierarchy as nested lists: GameObjects in bold,
+// components in italics with their fields below
+func renderMarkdown(ex *export, depth int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", markdownEscaper.Replace(filepath.Base(ex.Path)))
+	fmt.Fprintf(&b, "<!-- Generated by unity_hierarchy_export from %s; do not edit. -->\n\n", ex.Path)
+	kind := ex.Kind
+	if ex.VariantOf != "" {
+		kind += " of `" + ex.VariantOf + "`"
+	}
+	fmt.Fprintf(&b, "`%s` · %s · %d GameObject(s) · %d prefab instance(s)\n\n", ex.Path, kind, ex.GameObjects, ex.PrefabInstances)
+	if len(ex.Roots) == 0 {
+		b.WriteString("*Empty.*\n")
+	}
+	var write func(n *node, level int)
+	write = func(n *node, level int) {
+		indent := strings.Repeat("  ", level)
+		line := indent + "- **" + markdownEscaper.Replace(n.Name) + "**"
+		if n.Prefab != "" {
+			line += " (prefab `" + n.Prefab + "`)"
+		}
+		var notes []string
+		if n.Inactive {
+			notes = append(notes, "inactive")
+		}
+		if n.Tag != "" {
+			notes = append(notes, "tag `"+n.Tag+"`")
+		}
+		if n.Layer != "" {
+			notes = append(notes, "layer `"+n.Layer+"`")
+		}
+		if len(notes) > 0 {
+			line += " · " + strings.Join(notes, ", ")
+		}
+		b.WriteString(line + "\n")
+
+		sub := indent + "  "
+		for _, c := range n.Components {
+			text := "_" + markdownEscaper.Replace(c.Type) + "_"
+			if c.Added {
+				text = "added " + text
+			}
+			if c.Disabled {
+				text += " (disabled)"
+			}
+			b.WriteString(sub + "- " + text + "\n")
+			for _, f := range c.Fields {
+				fmt.Fprintf(&b, "%s  - `%s`: %s\n", sub, f.Name, markdownValue(f.Value))
+			}
+		}
+		if len(n.Overrides) > 0 {
+			b.WriteString(sub + "- overrides\n")
+			for _, o := range n.Overrides {
+				target := ""
+				if o.Target != "" {
+					target = markdownEscaper.Replace(o.Target) + ": "
+				}
+				fmt.Fprintf(&b, "%s  - %s`%s` = %s\n", sub, target, o.Property, markdownValue(o.Value))
+			}
+		}
+		if depth > 0 && level+1 >= depth {
+			if len(n.Children) > 0 {
+				fmt.Fprintf(&b, "%s- *%d child object(s)*\n", sub, len(n.Children))
+			}
+			return
+		}
+		for _, child := range n.Children {
+			write(child, level+1)
+		}
+	}
+	for _, root := range ex.Roots {
+		write(root, 0)
+	}
+	return b.String()
+}
+
+// markdownValue puts a value in a code span, or says it is empty
+func markdownValue(v string) string {
+	if v == "" {
+		return "*empty*"
+	}
+	if strings.Contains(v, "`") {
+		return "``" + v + "``"
+	}
+	return "`" + v + "`"
+}
+
+// renderJSON writes the export document; -depth cuts the tree the same way
+func renderJSON(ex *export, depth int) string {
+	if depth > 0 {
+		var cut func(nodes []*node, level int)
+		cut = func(nodes []*node, level int) {
+			for _, n := range nodes {
+				if level+1 >= depth {
+					n.Children = nil
+				} else {
+					cut(n.Children, level+1)
+				}
+			}
+		}
+		cut(ex.Roots, 0)
+	}
+	if ex.Roots == nil {
+		ex.Roots = []*node{}
+	}
+	data, _ := json.MarshalIndent(ex, "", "  ")
+	return string(data) + "\n"
+}
+
+// outputPath is where the export of an asset goes: the asset path under the
+// output folder, with .md or .json appended
+func outputPath(basePath, outDir, rel, format string) string {
+	ext := ".md"
+	if format == formatJSON {
+		ext = ".json"
+	}
+	dir := outDir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(basePath, filepath.FromSlash(dir))
+	}
+	return filepath.Join(dir, filepath.FromSlash(rel)+ext)
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+func printProgressBar(current, total int) {
+	percent := float64(current) / float64(total)
+	if plainMode || jsonMode {
+		// One line per 10% step instead of redrawing the bar with \r
+		step := int(percent * 10)
+		if current == total || step > int(float64(current-1)/float64(total)*10) {
+			fmt.Printf(tr("Progress: %d/%d (%.0f%%)\n"), current, total, percent*100)
+		}
+		return
+	}
+	barLength := 40
+	filledLength := int(float64(barLength) * percent)
+
+	bar := strings.Repeat("█", filledLength) + strings.Repeat("-", barLength-filledLength)
+	fmt.Printf("\r[%s] %.0f%% (%d/%d)", bar, percent*100, current, total)
+	if current == total {
+		fmt.Println()
+	}
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...":                                      "\n按回车键继续...",
+	"Progress: %d/%d (%.0f%%)\n":                                        "进度: %d/%d (%.0f%%)\n",
+	"[ERROR] %v\n":                                                      "[错误] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                        "[错误] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.":  "[错误] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":            "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"Usage: unity_hierarchy_export [flags] <scene or prefab>... | -all": "用法: unity_hierarchy_export [参数] <场景或预制体>... | -all",
+	"  UNITY HIERARCHY EXPORT":                                          "  Unity 层级导出",
+	"  Project: %s\n":                                                   "  项目: %s\n",
+	"  Assets:  %d scene(s) and prefab(s)\n":                            "  资源:  %d 个场景和预制体\n",
+	"  Output:  %s (%s)\n":                                              "  输出:  %s (%s)\n",
+	"[--] No scenes or prefabs found under Assets/.":                    "[--] Assets/ 下未找到场景或预制体。",
+	"Indexing assets...":                                                "正在索引资源...",
+	"[ERROR] %s: %v\n":                                                  "[错误] %s: %v\n",
+	"[ERROR] Missing export: %s\n":                                      "[错误] 缺少导出文件: %s\n",
+	"[ERROR] Out of date: %s\n":                                         "[错误] 已过期: %s\n",
+	"[ERROR] Cannot write %s: %v\n":                                     "[错误] 无法写入 %s: %v\n",
+	"[OK] Wrote: %s\n":                                                  "[成功] 已写入: %s\n",
+	"[ERROR] Export of a deleted asset: %s\n":                           "[错误] 资源已删除的导出文件: %s\n",
+	"[ERROR] Cannot remove %s: %v\n":                                    "[错误] 无法删除 %s: %v\n",
+	"[OK] Removed: %s (asset deleted)\n":                                "[成功] 已删除: %s (资源已删除)\n",
+	"  SUMMARY":                                                         "  摘要",
+	"  Up to date:  %d\n":                                               "  最新:        %d\n",
+	"  Out of date: %d\n":                                               "  已过期:      %d\n",
+	"  Written:     %d\n":                                               "  已写入:      %d\n",
+	"  Unchanged:   %d\n":                                               "  未变化:      %d\n",
+	"  Removed:     %d\n":                                               "  已删除:      %d\n",
+	"  Failed:      %d\n":                                               "  失败:        %d\n",
+	"  Took:        %v\n":                                               "  耗时:        %v\n",
+	"\n[TIP] Run unity_hierarchy_export with the same arguments without -check and commit the result.": "\n[提示] 请使用相同参数（去掉 -check）运行 unity_hierarchy_export，并提交结果。",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode     bool
+		all        bool
+		check      bool
+		depth      int
+		outDir     string
+		formatFlag string
+		fieldsFlag string
+	)
+
+	flag.BoolVar(&all, "all", false, "Export every scene and prefab under Assets/, and remove exports whose asset is gone")
+	flag.StringVar(&outDir, "o", defaultOutDir, "Output folder; the asset path is kept below it. \"-\" prints one export to stdout")
+	flag.StringVar(&formatFlag, "format", formatMarkdown, "Output format: markdown, json")
+	flag.StringVar(&fieldsFlag, "fields", fieldsKey, "Serialized fields to include: none, key (scripts and overrides), all")
+	flag.IntVar(&depth, "depth", 0, "Hierarchy levels to include (0: all)")
+	flag.BoolVar(&check, "check", false, "Write nothing; exit with 1 when an export is missing or out of date")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the assets ("Assets/Main.unity -format json")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	toStdout := outDir == "-"
+	exportOut := os.Stdout
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_hierarchy_export")
+		ciMode = true
+	} else if toStdout {
+		// The export is the output; messages go to stderr
+		os.Stdout = os.Stderr
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	switch {
+	case formatFlag != formatMarkdown && formatFlag != formatJSON:
+		fail(2, "unknown -format '%s' (use markdown or json)", formatFlag)
+	case fieldsFlag != fieldsNone && fieldsFlag != fieldsKey && fieldsFlag != fieldsAll:
+		fail(2, "unknown -fields '%s' (use none, key or all)", fieldsFlag)
+	case depth < 0:
+		fail(2, "-depth must be 0 or more")
+	case toStdout && jsonMode:
+		fail(2, "-o - and -json both write to stdout")
+	case toStdout && check:
+		fail(2, "-check compares the files in an output folder, not stdout")
+	}
+	if !all && len(args) == 0 {
+		fmt.Println(tr("Usage: unity_hierarchy_export [flags] <scene or prefab>... | -all"))
+		recordError("no scene or prefab given")
+		exit(2)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+
+	var targets []string
+	if all {
+		targets = findScenesAndPrefabs(basePath)
+	}
+	for _, a := range args {
+		rel := filepath.ToSlash(filepath.Clean(a))
+		if filepath.IsAbs(a) {
+			if r, err := filepath.Rel(basePath, a); err == nil {
+				rel = filepath.ToSlash(r)
+			}
+		}
+		if ext := strings.ToLower(filepath.Ext(rel)); ext != ".unity" && ext != ".prefab" {
+			fail(2, "%s is not a scene or prefab", a)
+		}
+		if _, err := os.Stat(filepath.Join(basePath, filepath.FromSlash(rel))); err != nil {
+			fail(2, "cannot read %s: %v", a, err)
+		}
+		if !containsString(targets, rel) {
+			targets = append(targets, rel)
+		}
+	}
+	if toStdout && len(targets) != 1 {
+		fail(2, "-o - prints one export; %d scenes and prefabs were given", len(targets))
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  UNITY HIERARCHY EXPORT"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project: %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Assets:  %d scene(s) and prefab(s)\n"), len(targets))
+	if !toStdout {
+		fmt.Printf(tr("  Output:  %s (%s)\n"), outDir, formatFlag)
+	}
+	fmt.Println()
+	if len(targets) == 0 {
+		fmt.Println(tr("[--] No scenes or prefabs found under Assets/."))
+		exit(0)
+	}
+
+	fmt.Println(tr("Indexing assets..."))
+	e := &exporter{
+		basePath: basePath, fields: fieldsFlag, depth: depth,
+		guids: indexGUIDs(basePath), layers: readLayers(basePath), sources: make(map[string]*unityFile),
+	}
+	start := time.Now()
+	written, unchanged, outdated, failed := 0, 0, 0, 0
+	expected := make(map[string]bool)
+	for i, rel := range targets {
+		if len(targets) > 1 {
+			printProgressBar(i+1, len(targets))
+		}
+		data, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(rel)))
+		var uf *unityFile
+		if err == nil {
+			uf, err = parseUnityFile(data)
+		}
+		if err != nil {
+			fmt.Printf(tr("[ERROR] %s: %v\n"), rel, err)
+			recordAction("export", rel, "failed", err.Error(), 0)
+			failed++
+			continue
+		}
+		ex := e.build(rel, uf)
+		var text string
+		if formatFlag == formatJSON {
+			text = renderJSON(ex, depth)
+		} else {
+			text = renderMarkdown(ex, depth)
+		}
+		if toStdout {
+			fmt.Fprint(exportOut, text)
+			recordAction("export", rel, "ok", "", 0)
+			continue
+		}
+
+		out := outputPath(basePath, outDir, rel, formatFlag)
+		expected[out] = true
+		outRel, _ := filepath.Rel(basePath, out)
+		outRel = filepath.ToSlash(outRel)
+		old, readErr := os.ReadFile(out)
+		if readErr == nil && string(old) == text {
+			unchanged++
+			recordAction("export", rel, "skipped", "up to date: "+outRel, 0)
+			continue
+		}
+		if check {
+			outdated++
+			if readErr != nil {
+				fmt.Printf(tr("[ERROR] Missing export: %s\n"), outRel)
+			} else {
+				fmt.Printf(tr("[ERROR] Out of date: %s\n"), outRel)
+			}
+			recordAction("check", rel, "failed", "out of date: "+outRel, 0)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(out), 0755); err == nil {
+			err = os.WriteFile(out, []byte(text), 0644)
+		}
+		if err != nil {
+			fmt.Printf(tr("[ERROR] Cannot write %s: %v\n"), outRel, err)
+			recordAction("export", rel, "failed", err.Error(), 0)
+			failed++
+			continue
+		}
+		written++
+		fmt.Printf(tr("[OK] Wrote: %s\n"), outRel)
+		recordAction("export", rel, "ok", outRel, 0)
+		recordArtifact(out)
+	}
+
+	// With -all, exports whose scene or prefab is gone are removed (or reported)
+	removed := 0
+	if all && !toStdout && failed == 0 {
+		ext := ".md"
+		if formatFlag == formatJSON {
+			ext = ".json"
+		}
+		root := outputPath(basePath, outDir, "", formatFlag)
+		root = strings.TrimSuffix(root, ext)
+		filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || expected[p] {
+				return nil
+			}
+			asset := strings.ToLower(strings.TrimSuffix(p, ext))
+			if !strings.HasSuffix(asset, ".unity") && !strings.HasSuffix(asset, ".prefab") {
+				return nil
+			}
+			outRel, _ := filepath.Rel(basePath, p)
+			outRel = filepath.ToSlash(outRel)
+			if check {
+				outdated++
+				fmt.Printf(tr("[ERROR] Export of a deleted asset: %s\n"), outRel)
+				recordAction("check", outRel, "failed", "asset deleted", 0)
+				return nil
+			}
+			if err := os.Remove(p); err != nil {
+				fmt.Printf(tr("[ERROR] Cannot remove %s: %v\n"), outRel, err)
+				failed++
+				return nil
+			}
+			removed++
+			fmt.Printf(tr("[OK] Removed: %s (asset deleted)\n"), outRel)
+			recordAction("delete", outRel, "ok", "asset deleted", 0)
+			return nil
+		})
+	}
+	if toStdout {
+		exit(failed)
+	}
+
+	fmt.Println()
+	printRule("=============================================")
+	fmt.Println(tr("  SUMMARY"))
+	printRule("=============================================")
+	if check {
+		fmt.Printf(tr("  Up to date:  %d\n"), unchanged)
+		fmt.Printf(tr("  Out of date: %d\n"), outdated)
+	} else {
+		fmt.Printf(tr("  Written:     %d\n"), written)
+		fmt.Printf(tr("  Unchanged:   %d\n"), unchanged)
+		if removed > 0 {
+			fmt.Printf(tr("  Removed:     %d\n"), removed)
+		}
+	}
+	if failed > 0 {
+		fmt.Printf(tr("  Failed:      %d\n"), failed)
+	}
+	fmt.Printf(tr("  Took:        %v\n"), time.Since(start).Round(time.Millisecond))
+
+	if failed > 0 {
+		exit(1)
+	}
+	if outdated > 0 {
+		fmt.Println(tr("\n[TIP] Run unity_hierarchy_export with the same arguments without -check and commit the result."))
+		exit(1)
+	}
+	exit(0)
+}
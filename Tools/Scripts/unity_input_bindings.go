@@ -0,0 +1,1109 @@
+// Unity Input Bindings — Report the bindings of .inputactions assets and find conflicts.
+// Reads the Input System action assets of the project (or the files given), prints
+// every binding per control scheme, action map and action in readable form, and
+// checks them: the same control bound twice to one action, one control driving two
+// actions of the same map, controls shared across maps (a conflict while both maps
+// are enabled), bindings in no or an unknown control scheme, unbound placeholders
+// and actions without bindings. -out writes the report as Markdown for the docs.
+//
+// Build: go build unity_input_bindings.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_input_bindings                                   # every .inputactions in the project
+//	unity_input_bindings Assets/Settings/Player.inputactions
+//	unity_input_bindings -scheme Gamepad                   # one control scheme
+//	unity_input_bindings -out Docs/Input.md                # Markdown report
+//	unity_input_bindings -annotate github -ci              # findings as CI annotations
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const assetExtension = ".inputactions"
+
+// Composites whose modifier parts make the binding a chord: Ctrl+S is not S
+var modifierComposites = map[string]bool{
+	"onemodifier": true, "buttonwithonemodifier": true,
+	"twomodifiers": true, "buttonwithtwomodifiers": true,
+}
+
+var (
+	controlPathRegex = regexp.MustCompile(`^<([^>]+)>(\{[^}]*\})?(?:/(.*))?$`)
+	bindingIDRegex   = regexp.MustCompile(`"id"\s*:\s*"([^"]+)"`)
+	parameterRegex   = regexp.MustCompile(`\([^)]*\)`)
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// actionsAsset is the JSON of an .inputactions file
+type actionsAsset struct {
+	Name           string           `json:"name"`
+	Maps           []*actionMap     `json:"maps"`
+	ControlSchemes []*controlScheme `json:"controlSchemes"`
+
+	file  string         // project-relative
+	lines map[string]int // binding and action id -> line, for annotations
+}
+
+type actionMap struct {
+	Name     string          `json:"name"`
+	ID       string          `json:"id"`
+	Actions  []*inputAction  `json:"actions"`
+	Bindings []*inputBinding `json:"bindings"`
+}
+
+type inputAction struct {
+	Name                string `json:"name"`
+	Type                string `json:"type"`
+	ID                  string `json:"id"`
+	ExpectedControlType string `json:"expectedControlType"`
+	Interactions        string `json:"interactions"`
+}
+
+type inputBinding struct {
+	Name              string `json:"name"`
+	ID                string `json:"id"`
+	Path              string `json:"path"`
+	Interactions      string `json:"interactions"`
+	Processors        string `json:"processors"`
+	Groups            string `json:"groups"`
+	Action            string `json:"action"`
+	IsComposite       bool   `json:"isComposite"`
+	IsPartOfComposite bool   `json:"isPartOfComposite"`
+
+	parts []*inputBinding // a composite's part bindings
+}
+
+type controlScheme struct {
+	Name         string `json:"name"`
+	BindingGroup string `json:"bindingGroup"`
+	Devices      []struct {
+		DevicePath string `json:"devicePath"`
+		IsOptional bool   `json:"isOptional"`
+	} `json:"devices"`
+}
+
+// boundControl is one control (or chord) that triggers an action, the unit the
+// conflict checks compare
+type boundControl struct {
+	asset        *actionsAsset
+	mapName      string
+	action       string
+	key          string // normalized path, modifiers first: "<keyboard>/ctrl+<keyboard>/s"
+	display      string
+	groups       []string
+	binding      *inputBinding
+	interactions string
+}
+
+// finding is one problem the checks report
+type finding struct {
+	level  string // "error" or "warning"
+	asset  *actionsAsset
+	id     string // binding or action id the annotation points at
+	title  string
+	format string
+	args   []interface{}
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Input Actions
+// ============================================================
+
+// findAssets lists the .inputactions files in Assets/ and the embedded packages.
+// Folders starting with "." or ending with "~" are hidden from Unity and skipped.
+func findAssets(basePath string) []string {
+	roots := []string{"Assets"}
+	entries, _ := os.ReadDir(filepath.Join(basePath, "Packages"))
+	for _, e := range entries {
+		if _, err := os.Stat(filepath.Join(basePath, "Packages", e.Name(), "package.json")); e.IsDir() && err == nil {
+			roots = append(roots, "Packages/"+e.Name())
+		}
+	}
+	var files []string
+	for _, root := range roots {
+		rootPath := filepath.Join(basePath, filepath.FromSlash(root))
+		filepath.Walk(rootPath, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if name := info.Name(); p != rootPath && (strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.EqualFold(filepath.Ext(p), assetExtension) {
+				rel, _ := filepath.Rel(basePath, p)
+				files = append(files, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+	}
+	sort.Strings(files)
+	return files
+}
+
+// loadAsset parses one .inputactions file and groups composite parts under
+// their composite
+func loadAsset(basePath, rel string) (*actionsAsset, error) {
+	p := rel
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(basePath, filepath.FromSlash(rel))
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	a := &actionsAsset{file: rel, lines: make(map[string]int)}
+	if err := json.Unmarshal(data, a); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	for n, line := range strings.Split(string(data), "\n") {
+		if m := bindingIDRegex.FindStringSubmatch(line); m != nil {
+			a.lines[m[1]] = n + 1
+		}
+	}
+	for _, m := range a.Maps {
+		var top []*inputBinding
+		var composite *inputBinding
+		for _, b := range m.Bindings {
+			switch {
+			case b.IsPartOfComposite && composite != nil:
+				composite.parts = append(composite.parts, b)
+			case b.IsComposite:
+				composite = b
+				top = append(top, b)
+			default:
+				composite = nil
+				top = append(top, b)
+			}
+		}
+		m.Bindings = top
+	}
+	return a, nil
+}
+
+// splitGroups parses a binding's ";"-separated control scheme groups
+func splitGroups(groups string) []string {
+	var res []string
+	for _, g := range strings.Split(groups, ";") {
+		if g = strings.TrimSpace(g); g != "" {
+			res = append(res, g)
+		}
+	}
+	return res
+}
+
+// bindingGroups is the binding's groups; for a composite, those of its parts
+func bindingGroups(b *inputBinding) []string {
+	groups := splitGroups(b.Groups)
+	for _, p := range b.parts {
+		for _, g := range splitGroups(p.Groups) {
+			if !containsString(groups, g) {
+				groups = append(groups, g)
+			}
+		}
+	}
+	return groups
+}
+
+// actionName strips the map from "Map/Action" references
+func actionName(ref string) string {
+	if i := strings.LastIndex(ref, "/"); i >= 0 {
+		return ref[i+1:]
+	}
+	return ref
+}
+
+// humanize turns "leftShift" into "Left Shift" and "buttonSouth" into "Button South"
+func humanize(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if i == 0 {
+			b.WriteRune(unicode.ToUpper(r))
+			continue
+		}
+		if unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+			b.WriteRune(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// displayPath renders "<Keyboard>/leftShift" as "Keyboard Left Shift" and
+// "<XRController>{LeftHand}/trigger" as "XRController {LeftHand} Trigger"
+func displayPath(p string) string {
+	if p == "" {
+		return tr("(unbound)")
+	}
+	m := controlPathRegex.FindStringSubmatch(p)
+	if m == nil {
+		return p
+	}
+	parts := []string{m[1]}
+	if m[2] != "" {
+		parts = append(parts, m[2])
+	}
+	for _, c := range strings.Split(m[3], "/") {
+		if c != "" {
+			parts = append(parts, humanize(strings.TrimPrefix(c, "#")))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// shortInteractions keeps the names of "Hold(duration=0.4),Tap"
+func shortInteractions(s string) string {
+	return strings.ReplaceAll(parameterRegex.ReplaceAllString(s, ""), ",", ", ")
+}
+
+// describeBinding renders one top-level binding; composites list their parts
+func describeBinding(b *inputBinding) string {
+	var text string
+	if b.IsComposite {
+		var parts []string
+		for _, p := range b.parts {
+			parts = append(parts, humanize(p.Name)+" "+displayPath(p.Path))
+		}
+		name := b.Name
+		if name == "" {
+			name = b.Path
+		}
+		text = name + ": " + strings.Join(parts, ", ")
+	} else {
+		text = displayPath(b.Path)
+	}
+	if b.Interactions != "" {
+		text += " (" + shortInteractions(b.Interactions) + ")"
+	}
+	return text
+}
+
+// controlsOf breaks a binding into the controls that trigger its action. Modifier
+// composites give one chord; modifiers alone do not trigger.
+func controlsOf(a *actionsAsset, m *actionMap, b *inputBinding) []*boundControl {
+	base := func(part *inputBinding, key, display string, groups []string) *boundControl {
+		return &boundControl{asset: a, mapName: m.Name, action: actionName(b.Action), key: key,
+			display: display, groups: groups, binding: part, interactions: b.Interactions}
+	}
+	if !b.IsComposite {
+		if b.Path == "" {
+			return nil
+		}
+		return []*boundControl{base(b, normalizePath(b.Path), displayPath(b.Path), splitGroups(b.Groups))}
+	}
+	var controls []*boundControl
+	if modifierComposites[strings.ToLower(parameterRegex.ReplaceAllString(b.Path, ""))] {
+		var mods []string
+		var modNames []string
+		var trigger *inputBinding
+		for _, p := range b.parts {
+			if strings.HasPrefix(strings.ToLower(p.Name), "modifier") {
+				mods = append(mods, normalizePath(p.Path))
+				modNames = append(modNames, displayPath(p.Path))
+			} else if p.Path != "" {
+				trigger = p
+			}
+		}
+		if trigger == nil {
+			return nil
+		}
+		sort.Strings(mods)
+		key := strings.Join(append(mods, normalizePath(trigger.Path)), "+")
+		display := strings.Join(append(modNames, displayPath(trigger.Path)), " + ")
+		return []*boundControl{base(trigger, key, display, bindingGroups(b))}
+	}
+	for _, p := range b.parts {
+		if p.Path == "" {
+			continue
+		}
+		groups := splitGroups(p.Groups)
+		if len(groups) == 0 {
+			groups = splitGroups(b.Groups)
+		}
+		controls = append(controls, base(p, normalizePath(p.Path), displayPath(p.Path), groups))
+	}
+	return controls
+}
+
+func normalizePath(p string) string {
+	return strings.ToLower(strings.ReplaceAll(p, " ", ""))
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Checks
+// ============================================================
+
+func newFinding(level string, a *actionsAsset, id, title, format string, args ...interface{}) finding {
+	return finding{level: level, asset: a, id: id, title: title, format: format, args: args}
+}
+
+// message is the finding in English, for annotations and the JSON document
+func (f finding) message() string {
+	return fmt.Sprintf(f.format, f.args...)
+}
+
+// schemeNames returns the binding groups of the asset's control schemes
+func schemeNames(a *actionsAsset) []string {
+	var names []string
+	for _, s := range a.ControlSchemes {
+		g := s.BindingGroup
+		if g == "" {
+			g = s.Name
+		}
+		names = append(names, g)
+	}
+	return names
+}
+
+// checkAsset looks for problems inside one asset and returns its controls
+func checkAsset(a *actionsAsset) ([]finding, []*boundControl) {
+	var findings []finding
+	var controls []*boundControl
+	schemes := schemeNames(a)
+	for _, m := range a.Maps {
+		used := make(map[string]bool)
+		actions := make(map[string]bool)
+		for _, act := range m.Actions {
+			actions[act.Name] = true
+		}
+		for _, b := range m.Bindings {
+			name := actionName(b.Action)
+			used[name] = true
+			where := fmt.Sprintf("%s/%s", m.Name, name)
+			if !actions[name] {
+				findings = append(findings, newFinding("error", a, b.ID, "Unknown action", "%s: binding %s refers to an action that does not exist", where, describeBinding(b)))
+				continue
+			}
+			if (!b.IsComposite && b.Path == "") || (b.IsComposite && len(b.parts) == 0) {
+				findings = append(findings, newFinding("warning", a, b.ID, "Unbound binding", "%s has an empty binding", where))
+			}
+			groups := bindingGroups(b)
+			if len(schemes) > 0 && len(groups) == 0 && !(b.Path == "" && !b.IsComposite) {
+				findings = append(findings, newFinding("warning", a, b.ID, "No control scheme", "%s: %s is in no control scheme and is masked out while a scheme is active", where, describeBinding(b)))
+			}
+			for _, g := range groups {
+				if !containsString(schemes, g) {
+					findings = append(findings, newFinding("warning", a, b.ID, "Unknown control scheme", "%s: %s is in group '%s', which is not a control scheme", where, describeBinding(b), g))
+				}
+			}
+			controls = append(controls, controlsOf(a, m, b)...)
+		}
+		for _, act := range m.Actions {
+			if !used[act.Name] {
+				findings = append(findings, newFinding("warning", a, act.ID, "Action without bindings", "%s/%s has no bindings", m.Name, act.Name))
+			}
+		}
+	}
+	return findings, controls
+}
+
+// sharesScheme reports whether two controls can be active in the same scheme;
+// a control without groups is compared with everything
+func sharesScheme(a, b *boundControl) bool {
+	if len(a.groups) == 0 || len(b.groups) == 0 {
+		return true
+	}
+	for _, g := range a.groups {
+		if containsString(b.groups, g) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkConflicts compares the controls of one asset. Controls that differ in
+// their interactions (Tap and Hold on one button) are deliberate and skipped.
+func checkConflicts(controls []*boundControl) []finding {
+	var findings []finding
+	byKey := make(map[string][]*boundControl)
+	var keys []string
+	for _, c := range controls {
+		if byKey[c.key] == nil {
+			keys = append(keys, c.key)
+		}
+		byKey[c.key] = append(byKey[c.key], c)
+	}
+	// One finding per control and pair of actions, however often each is bound
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		list := byKey[key]
+		for i := 0; i < len(list); i++ {
+			for j := i + 1; j < len(list); j++ {
+				x, y := list[i], list[j]
+				if !sharesScheme(x, y) || (x.interactions != y.interactions && x.interactions != "" && y.interactions != "") {
+					continue
+				}
+				pair := key + "|" + x.mapName + "/" + x.action + "|" + y.mapName + "/" + y.action
+				if (x.mapName != y.mapName || x.action != y.action) && seen[pair] {
+					continue
+				}
+				seen[pair] = true
+				switch {
+				case x.mapName == y.mapName && x.action == y.action:
+					findings = append(findings, newFinding("error", x.asset, y.binding.ID, "Duplicate binding", "%s/%s: %s is bound twice", x.mapName, x.action, x.display))
+				case x.mapName == y.mapName:
+					findings = append(findings, newFinding("error", x.asset, y.binding.ID, "Conflicting binding", "%s: %s triggers both %s and %s", x.mapName, x.display, x.action, y.action))
+				default:
+					findings = append(findings, newFinding("warning", x.asset, y.binding.ID, "Binding shared across maps", "%s triggers %s/%s and %s/%s; they conflict while both maps are enabled", x.display, x.mapName, x.action, y.mapName, y.action))
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+// schemeSections lists the report sections of an asset: one per control scheme,
+// then the bindings in none; an asset without schemes has one section for all
+func schemeSections(a *actionsAsset) []string {
+	sections := schemeNames(a)
+	if len(sections) == 0 {
+		return []string{""}
+	}
+	for _, m := range a.Maps {
+		for _, b := range m.Bindings {
+			if len(bindingGroups(b)) == 0 {
+				return append(sections, "-")
+			}
+		}
+	}
+	return sections
+}
+
+// inSection reports whether a binding belongs to a report section
+func inSection(b *inputBinding, section string) bool {
+	groups := bindingGroups(b)
+	switch section {
+	case "":
+		return true
+	case "-":
+		return len(groups) == 0
+	}
+	return containsString(groups, section)
+}
+
+// sectionTitle names a report section, in English as the Markdown report uses it
+func sectionTitle(section string) string {
+	switch section {
+	case "":
+		return "All bindings"
+	case "-":
+		return "In no control scheme"
+	}
+	return section
+}
+
+// sectionRows returns map, action and bindings rows of one section
+func sectionRows(a *actionsAsset, section string) [][3]string {
+	var rows [][3]string
+	for _, m := range a.Maps {
+		for _, act := range m.Actions {
+			var texts []string
+			for _, b := range m.Bindings {
+				if actionName(b.Action) == act.Name && inSection(b, section) {
+					texts = append(texts, describeBinding(b))
+				}
+			}
+			if len(texts) > 0 {
+				rows = append(rows, [3]string{m.Name, act.Name, strings.Join(texts, "; ")})
+			}
+		}
+	}
+	return rows
+}
+
+// printAsset prints the bindings of one asset per section
+func printAsset(a *actionsAsset, schemeFilter string) {
+	actions := 0
+	for _, m := range a.Maps {
+		actions += len(m.Actions)
+	}
+	fmt.Printf(tr("\n%s (%d map(s), %d action(s), %d control scheme(s))\n"), a.file, len(a.Maps), actions, len(a.ControlSchemes))
+	for _, section := range schemeSections(a) {
+		if schemeFilter != "" && section != schemeFilter {
+			continue
+		}
+		fmt.Printf("\n  [%s]\n", tr(sectionTitle(section)))
+		width := 0
+		rows := sectionRows(a, section)
+		for _, r := range rows {
+			if len(r[1]) > width {
+				width = len(r[1])
+			}
+		}
+		lastMap := ""
+		for _, r := range rows {
+			if r[0] != lastMap {
+				fmt.Printf("    %s\n", r[0])
+				lastMap = r[0]
+			}
+			fmt.Printf("      %-*s  %s\n", width, r[1], r[2])
+		}
+		if len(rows) == 0 {
+			fmt.Println(tr("      (no bindings)"))
+		}
+	}
+}
+
+// mdEscape keeps "|" from splitting a Markdown table cell
+func mdEscape(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// writeMarkdown writes the report of every asset and the findings
+func writeMarkdown(p string, assets []*actionsAsset, findings []finding, schemeFilter string) error {
+	var b strings.Builder
+	b.WriteString("# Input Bindings\n")
+	for _, a := range assets {
+		fmt.Fprintf(&b, "\n## %s\n\n`%s`\n", a.Name, a.file)
+		for _, section := range schemeSections(a) {
+			if schemeFilter != "" && section != schemeFilter {
+				continue
+			}
+			fmt.Fprintf(&b, "\n### %s\n\n", sectionTitle(section))
+			rows := sectionRows(a, section)
+			if len(rows) == 0 {
+				b.WriteString("No bindings.\n")
+				continue
+			}
+			b.WriteString("| Map | Action | Bindings |\n| --- | --- | --- |\n")
+			for _, r := range rows {
+				fmt.Fprintf(&b, "| %s | %s | %s |\n", mdEscape(r[0]), mdEscape(r[1]), mdEscape(r[2]))
+			}
+		}
+	}
+	if len(findings) > 0 {
+		b.WriteString("\n## Findings\n\n")
+		for _, f := range findings {
+			fmt.Fprintf(&b, "- **%s** (%s): %s\n", f.title, f.asset.file, mdEscape(f.message()))
+		}
+	}
+	if dir := filepath.Dir(p); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(p, []byte(b.String()), 0644)
+}
+
+// printFindings prints and annotates the findings and returns the error count
+func printFindings(findings []finding) int {
+	errors := 0
+	if len(findings) == 0 {
+		return 0
+	}
+	fmt.Println(tr("\nFINDINGS"))
+	for _, f := range findings {
+		tag := "[WARNING]"
+		status := "warning"
+		if f.level == "error" {
+			tag = "[ERROR]  "
+			status = "failed"
+			errors++
+		}
+		fmt.Printf("  %s %s: %s\n", tag, f.asset.file, fmt.Sprintf(tr(f.format), f.args...))
+		annotate(f.level, f.asset.file, f.asset.lines[f.id], 0, f.title, f.message())
+		recordAction("check", f.asset.file, status, f.message(), 0)
+	}
+	return errors
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...": "\n按回车键继续...",
+
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  INPUT BINDINGS":                                       "  输入绑定",
+	"  Project: %s\n":                                        "  项目:   %s\n",
+	"  Assets:  %d .inputactions file(s)\n":                  "  资源:   %d 个 .inputactions 文件\n",
+	"\n[INFO] No .inputactions assets found.":                "\n[INFO] 未找到 .inputactions 资源。",
+	"[ERROR] %s: %v\n":                                       "[ERROR] %s: %v\n",
+	"[WARNING] %s has no control scheme '%s'\n":              "[WARNING] %s 没有控制方案 '%s'\n",
+	"\n%s (%d map(s), %d action(s), %d control scheme(s))\n": "\n%s（%d 个动作映射，%d 个动作，%d 个控制方案）\n",
+	"All bindings":                                           "全部绑定",
+	"In no control scheme":                                   "不属于任何控制方案",
+	"(unbound)":                                              "（未绑定）",
+	"      (no bindings)":                                    "      （无绑定）",
+	"\nFINDINGS":                                             "\n检查结果",
+	"%s: binding %s refers to an action that does not exist": "%s: 绑定 %s 指向不存在的动作",
+	"%s has an empty binding":                                "%s 有一个空绑定",
+	"%s: %s is in no control scheme and is masked out while a scheme is active": "%s: %s 不属于任何控制方案，启用控制方案时会被屏蔽",
+	"%s: %s is in group '%s', which is not a control scheme":                    "%s: %s 位于分组 '%s'，但该分组不是控制方案",
+	"%s/%s has no bindings":          "%s/%s 没有绑定",
+	"%s/%s: %s is bound twice":       "%s/%s: %s 被绑定了两次",
+	"%s: %s triggers both %s and %s": "%s: %s 会同时触发 %s 和 %s",
+	"%s triggers %s/%s and %s/%s; they conflict while both maps are enabled":    "%s 会触发 %s/%s 和 %s/%s；两个映射同时启用时会冲突",
+	"\n[OK] Report written to %s\n":                                             "\n[OK] 报告已写入 %s\n",
+	"\n[FAIL] %d duplicate or conflicting binding(s), %d unreadable asset(s)\n": "\n[FAIL] %d 个重复或冲突的绑定，%d 个无法读取的资源\n",
+	"\n[OK] No duplicate or conflicting bindings.":                              "\n[OK] 没有重复或冲突的绑定。",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// CI Annotations
+// ============================================================
+
+// With -annotate, errors and warnings are printed once more as GitHub Actions
+// workflow commands or TeamCity service messages, so the CI lists them on the run
+// page and, when they point at a file, next to that file in the diff.
+var (
+	annotateMode  string              // "", "github" or "teamcity"
+	annotateTool  string              // TeamCity inspection category
+	annotateBase  string              // project root; relative files start here
+	annotateRoot  string              // repository root; CI file paths are relative to it
+	annotateTypes = map[string]bool{} // TeamCity inspection types already declared
+)
+
+// setAnnotateMode checks the -annotate value; "auto" picks the CI the tool runs in
+func setAnnotateMode(mode, basePath, tool string) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "none":
+		annotateMode = ""
+	case "github", "teamcity":
+		annotateMode = strings.ToLower(strings.TrimSpace(mode))
+	case "auto":
+		annotateMode = ""
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			annotateMode = "github"
+		} else if os.Getenv("TEAMCITY_VERSION") != "" {
+			annotateMode = "teamcity"
+		}
+	default:
+		return fmt.Errorf("unknown -annotate mode '%s' (use github, teamcity or auto)", mode)
+	}
+	annotateTool = tool
+	annotateBase, _ = filepath.Abs(basePath)
+	annotateRoot = repositoryRoot(annotateBase)
+	return nil
+}
+
+// repositoryRoot is GITHUB_WORKSPACE, else the nearest folder with a .git entry
+// (a folder, or a file in worktrees and submodules), else the project itself
+func repositoryRoot(basePath string) string {
+	if ws := os.Getenv("GITHUB_WORKSPACE"); ws != "" && annotateMode == "github" {
+		return ws
+	}
+	for dir := basePath; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return basePath
+		}
+	}
+}
+
+// annotate prints one annotation. level is "error" or "warning"; file is absolute
+// or relative to the project root, and may be empty like line and col.
+func annotate(level, file string, line, col int, title, message string) {
+	if annotateMode == "" {
+		return
+	}
+	if file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(annotateBase, filepath.FromSlash(file))
+		}
+		if rel, err := filepath.Rel(annotateRoot, file); err == nil {
+			file = rel
+		}
+		file = filepath.ToSlash(file)
+	}
+
+	if annotateMode == "github" {
+		// Property values also escape ':' and ','; the message only '%' and line breaks
+		data := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+		prop := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+		var props []string
+		if file != "" {
+			props = append(props, "file="+prop.Replace(file))
+			if line > 0 {
+				props = append(props, "line="+strconv.Itoa(line))
+			}
+			if col > 0 {
+				props = append(props, "col="+strconv.Itoa(col))
+			}
+		}
+		if title != "" {
+			props = append(props, "title="+prop.Replace(title))
+		}
+		fmt.Printf("::%s %s::%s\n", level, strings.Join(props, ","), data.Replace(message))
+		return
+	}
+
+	esc := strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]").Replace
+	status := "WARNING"
+	if level == "error" {
+		status = "ERROR"
+	}
+	if file == "" {
+		text := message
+		if title != "" {
+			text = title + ": " + message
+		}
+		fmt.Printf("##teamcity[message text='%s' status='%s']\n", esc(text), status)
+		return
+	}
+	typeID := annotateTool + "." + strings.ReplaceAll(strings.ToLower(title), " ", "-")
+	if !annotateTypes[typeID] {
+		annotateTypes[typeID] = true
+		fmt.Printf("##teamcity[inspectionType id='%s' name='%s' category='%s' description='%s']\n", esc(typeID), esc(title), esc(annotateTool), esc(title))
+	}
+	lineAttr := ""
+	if line > 0 {
+		lineAttr = fmt.Sprintf(" line='%d'", line)
+	}
+	fmt.Printf("##teamcity[inspection typeId='%s' message='%s' file='%s'%s SEVERITY='%s']\n", esc(typeID), esc(message), esc(file), lineAttr, status)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode bool
+	var schemeFlag, outFlag, annotateFl string
+
+	flag.StringVar(&schemeFlag, "scheme", "", "Only report this control scheme (binding group)")
+	flag.StringVar(&outFlag, "out", "", "Also write the report as Markdown to this file")
+	flag.StringVar(&annotateFl, "annotate", "", "Also print findings as CI annotations: github, teamcity, auto")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the files ("Player.inputactions -out Input.md")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_input_bindings")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	files := args
+	if len(files) == 0 {
+		if !isUnityProject(basePath) {
+			fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+			recordError("current directory does not appear to be a Unity project")
+			fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+			exit(1)
+		}
+		files = findAssets(basePath)
+	}
+	if err := setAnnotateMode(annotateFl, basePath, "unity_input_bindings"); err != nil {
+		fail(2, "%v", err)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  INPUT BINDINGS"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project: %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Assets:  %d .inputactions file(s)\n"), len(files))
+	if len(files) == 0 {
+		fmt.Println(tr("\n[INFO] No .inputactions assets found."))
+		exit(0)
+	}
+
+	var assets []*actionsAsset
+	var findings []finding
+	loadFailed := 0
+	for _, f := range files {
+		a, err := loadAsset(basePath, filepath.ToSlash(f))
+		if err != nil {
+			fmt.Printf(tr("[ERROR] %s: %v\n"), f, err)
+			recordError("%s: %v", f, err)
+			loadFailed++
+			continue
+		}
+		if schemeFlag != "" && !containsString(schemeNames(a), schemeFlag) {
+			fmt.Printf(tr("[WARNING] %s has no control scheme '%s'\n"), a.file, schemeFlag)
+		}
+		list, controls := checkAsset(a)
+		findings = append(findings, list...)
+		findings = append(findings, checkConflicts(controls)...)
+		assets = append(assets, a)
+		printAsset(a, schemeFlag)
+	}
+
+	errors := printFindings(findings)
+	if outFlag != "" {
+		if err := writeMarkdown(outFlag, assets, findings, schemeFlag); err != nil {
+			fail(1, "cannot write %s: %v", outFlag, err)
+		}
+		fmt.Printf(tr("\n[OK] Report written to %s\n"), outFlag)
+		recordArtifact(outFlag)
+	}
+	if errors+loadFailed > 0 {
+		fmt.Printf(tr("\n[FAIL] %d duplicate or conflicting binding(s), %d unreadable asset(s)\n"), errors, loadFailed)
+		recordError("%d duplicate or conflicting binding(s), %d unreadable asset(s)", errors, loadFailed)
+		exit(1)
+	}
+	if len(findings) == 0 {
+		fmt.Println(tr("\n[OK] No duplicate or conflicting bindings."))
+	}
+	exit(0)
+}
@@ -0,0 +1,960 @@
+// Unity License Manager — Activate, inspect and return Unity licenses on headless build agents.
+// Wraps the editor's batch-mode licensing flags so a build machine can be set up
+// and torn down from a script: serial activation and return with the account
+// credentials, or the manual flow for machines without a Unity account login
+// (write an .alf request, activate it on the Unity website on any machine, then
+// import the .ulf that comes back). "status" reports the serial license, Unity
+// Licensing Client entitlements and a floating license server, and exits 1 when
+// the machine has no usable license.
+//
+// Build: go build unity_license_manager.go
+//
+// Usage: run anywhere; inside a Unity project the project's editor version is used.
+//
+//	unity_license_manager                               # status of this machine
+//	unity_license_manager status -min-days 14           # fail when the license expires within 14 days
+//	unity_license_manager activate                      # UNITY_SERIAL, UNITY_USERNAME, UNITY_PASSWORD
+//	unity_license_manager return                        # give the seat back before the agent is discarded
+//	unity_license_manager request -out licenses/        # write Unity_v<version>.alf
+//	unity_license_manager import Unity_v2022.x.ulf      # activate with the file from the website
+//	unity_license_manager activate -dry-run -version 2022.3.10f1
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	// Overrides the editor picked from -version, ProjectVersion.txt and the Unity Hub folders
+	envUnityPath = "UNITYSTARTER_UNITY"
+
+	// Credentials, named as in GameCI so existing CI secrets work unchanged
+	envSerial   = "UNITY_SERIAL"
+	envUsername = "UNITY_USERNAME"
+	envEmail    = "UNITY_EMAIL" // read when UNITY_USERNAME is not set
+	envPassword = "UNITY_PASSWORD"
+)
+
+var (
+	projectVersionRegex = regexp.MustCompile(`(?m)^m_EditorVersion:\s*(\S+)`)
+	// Editor version folders: 2022.3.10f1
+	editorVersionRegex = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)([abfpx])(\d+)$`)
+	// Unity_lic.ulf fields: <StopDate Value="2025-01-01T00:00:00"/>
+	ulfFieldRegex = regexp.MustCompile(`<(\w+) Value="([^"]*)"\s*/>`)
+
+	// Log lines worth showing when Unity fails
+	logErrorRegex = regexp.MustCompile(`(?i)(\[Licens|LICENSE SYSTEM|licen[cs]e.*(error|fail|invalid|expired|not)|error:|Exception:)`)
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// serialLicense is what Unity_lic.ulf says about the serial activation of this machine
+type serialLicense struct {
+	path       string
+	serial     string // as masked by Unity: "SC-XXXX-XXXX-XXXX-XXXX-ABCD"
+	editor     string // version that activated it
+	activated  time.Time
+	updated    time.Time
+	expires    time.Time // zero: does not expire
+	ulfVersion string
+}
+
+// ============================================================
+// License Files
+// ============================================================
+
+// unityDataDir is the machine-wide folder the editor and the Unity Licensing
+// Client keep their license files in
+func unityDataDir() string {
+	switch runtime.GOOS {
+	case "windows":
+		pd := os.Getenv("PROGRAMDATA")
+		if pd == "" {
+			pd = `C:\ProgramData`
+		}
+		return filepath.Join(pd, "Unity")
+	case "darwin":
+		return "/Library/Application Support/Unity"
+	default:
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, ".local", "share", "unity3d", "Unity")
+	}
+}
+
+// ulfPath is the serial license of this machine
+func ulfPath() string {
+	return filepath.Join(unityDataDir(), "Unity_lic.ulf")
+}
+
+// entitlementDir holds the licenses the Unity Licensing Client (Hub sign-in,
+// Unity 2021.2 and later) keeps per seat
+func entitlementDir() string {
+	if runtime.GOOS == "linux" {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, ".config", "unity3d", "Unity", "licenses")
+	}
+	return filepath.Join(unityDataDir(), "licenses")
+}
+
+// servicesConfigPath is the client configuration that points the editor at a
+// floating license server
+func servicesConfigPath() string {
+	if runtime.GOOS == "linux" {
+		return "/usr/share/unity3d/config/services-config.json"
+	}
+	return filepath.Join(unityDataDir(), "config", "services-config.json")
+}
+
+// readSerialLicense parses Unity_lic.ulf; nil when the machine has none
+func readSerialLicense(path string) (*serialLicense, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string)
+	for _, m := range ulfFieldRegex.FindAllStringSubmatch(string(data), -1) {
+		// Entitlement groups repeat some names further down; the license's own come first
+		if _, ok := fields[m[1]]; !ok {
+			fields[m[1]] = m[2]
+		}
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%s is not a Unity license file", path)
+	}
+	lic := &serialLicense{
+		path:       path,
+		serial:     fields["SerialMasked"],
+		editor:     fields["ClientProvidedVersion"],
+		ulfVersion: fields["LicenseVersion"],
+		activated:  parseULFDate(fields["InitialActivationDate"]),
+		updated:    parseULFDate(fields["UpdateDate"]),
+		expires:    parseULFDate(fields["StopDate"]),
+	}
+	if lic.activated.IsZero() {
+		lic.activated = parseULFDate(fields["StartDate"])
+	}
+	return lic, nil
+}
+
+// parseULFDate reads "2021-01-22T10:20:31"; empty or unreadable dates are zero
+func parseULFDate(s string) time.Time {
+	t, err := time.Parse("2006-01-02T15:04:05", strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// entitlementFiles lists the Licensing Client license files
+func entitlementFiles() []string {
+	entries, err := os.ReadDir(entitlementDir())
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.EqualFold(filepath.Ext(e.Name()), ".xml") {
+			files = append(files, e.Name())
+		}
+	}
+	return files
+}
+
+// floatingServer returns the license server from services-config.json, or ""
+func floatingServer() string {
+	data, err := os.ReadFile(servicesConfigPath())
+	if err != nil {
+		return ""
+	}
+	var cfg struct {
+		LicensingServiceBaseURL string `json:"licensingServiceBaseUrl"`
+	}
+	json.Unmarshal(data, &cfg)
+	return cfg.LicensingServiceBaseURL
+}
+
+// ============================================================
+// Installed Editors
+// ============================================================
+
+// hubEditorFolders returns the folders Unity Hub installs editors into: the
+// default location plus the custom one from secondaryInstallPath.json
+func hubEditorFolders() []string {
+	home, _ := os.UserHomeDir()
+	var folders []string
+	var hubConfig string
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramW6432"} {
+			if pf := os.Getenv(env); pf != "" {
+				folders = append(folders, filepath.Join(pf, "Unity", "Hub", "Editor"))
+			}
+		}
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			hubConfig = filepath.Join(appData, "UnityHub")
+		}
+	case "darwin":
+		folders = append(folders, "/Applications/Unity/Hub/Editor")
+		hubConfig = filepath.Join(home, "Library", "Application Support", "UnityHub")
+	default:
+		folders = append(folders, filepath.Join(home, "Unity", "Hub", "Editor"))
+		hubConfig = filepath.Join(home, ".config", "UnityHub")
+	}
+	if hubConfig != "" {
+		// The file holds a single JSON string; empty when no custom location is set
+		if data, err := os.ReadFile(filepath.Join(hubConfig, "secondaryInstallPath.json")); err == nil {
+			var custom string
+			if json.Unmarshal(data, &custom) == nil && custom != "" {
+				folders = append(folders, custom)
+			}
+		}
+	}
+	return folders
+}
+
+// editorExecutable is the Unity binary inside an editor version folder
+func editorExecutable(dir string) string {
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(dir, "Editor", "Unity.exe")
+	case "darwin":
+		return filepath.Join(dir, "Unity.app", "Contents", "MacOS", "Unity")
+	default:
+		return filepath.Join(dir, "Editor", "Unity")
+	}
+}
+
+// versionKey orders editor versions: major, minor, patch, then alpha < beta <
+// experimental < final < patch release, then the build number
+func versionKey(v string) []int {
+	m := editorVersionRegex.FindStringSubmatch(v)
+	if m == nil {
+		return nil
+	}
+	n := func(s string) int {
+		i, _ := strconv.Atoi(s)
+		return i
+	}
+	return []int{n(m[1]), n(m[2]), n(m[3]), strings.Index("abxfp", m[4]), n(m[5])}
+}
+
+func versionLess(a, b string) bool {
+	ka, kb := versionKey(a), versionKey(b)
+	for i := 0; i < len(ka) && i < len(kb); i++ {
+		if ka[i] != kb[i] {
+			return ka[i] < kb[i]
+		}
+	}
+	return len(ka) < len(kb)
+}
+
+// findUnity picks the editor: -unity, UNITYSTARTER_UNITY, then the Hub install
+// of -version or, inside a Unity project, of the project's version. Any editor
+// can activate the machine, so without either the newest installed one is used.
+func findUnity(basePath, override, version string) (string, error) {
+	for _, candidate := range []string{override, os.Getenv(envUnityPath)} {
+		if candidate == "" {
+			continue
+		}
+		// A version folder works as well as the binary itself
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			candidate = editorExecutable(candidate)
+		}
+		if _, err := os.Stat(candidate); err != nil {
+			return "", fmt.Errorf("Unity editor not found: %s", candidate)
+		}
+		return candidate, nil
+	}
+	if version == "" {
+		if data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectVersion.txt")); err == nil {
+			if m := projectVersionRegex.FindSubmatch(data); m != nil {
+				version = string(m[1])
+			}
+		}
+	}
+
+	var installed []string
+	exes := make(map[string]string)
+	for _, folder := range hubEditorFolders() {
+		entries, err := os.ReadDir(folder)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			exe := editorExecutable(filepath.Join(folder, e.Name()))
+			if _, err := os.Stat(exe); err != nil || exes[e.Name()] != "" {
+				continue
+			}
+			exes[e.Name()] = exe
+			installed = append(installed, e.Name())
+		}
+	}
+	if version != "" {
+		if exe := exes[version]; exe != "" {
+			return exe, nil
+		}
+		return "", fmt.Errorf("Unity %s is not installed in the Unity Hub folders; install it or pass -unity", version)
+	}
+	if len(installed) == 0 {
+		return "", errors.New("no Unity editor found in the Unity Hub folders; pass -unity or set UNITYSTARTER_UNITY")
+	}
+	sort.Slice(installed, func(i, j int) bool { return versionLess(installed[i], installed[j]) })
+	return exes[installed[len(installed)-1]], nil
+}
+
+// ============================================================
+// Unity Commands
+// ============================================================
+
+// credentials are read from the flags, then the environment. The password is
+// environment-only so it stays out of shell history.
+type credentials struct {
+	serial, username, password string
+}
+
+func readCredentials(serialFlag, usernameFlag string) credentials {
+	c := credentials{serial: serialFlag, username: usernameFlag, password: os.Getenv(envPassword)}
+	if c.serial == "" {
+		c.serial = os.Getenv(envSerial)
+	}
+	if c.username == "" {
+		c.username = os.Getenv(envUsername)
+	}
+	if c.username == "" {
+		c.username = os.Getenv(envEmail)
+	}
+	return c
+}
+
+// maskSerial keeps the last group of a serial: "XX-XXXX-XXXX-XXXX-XXXX-ABCD"
+func maskSerial(serial string) string {
+	groups := strings.Split(serial, "-")
+	for i := 0; i < len(groups)-1; i++ {
+		groups[i] = strings.Repeat("X", len(groups[i]))
+	}
+	return strings.Join(groups, "-")
+}
+
+// licenseArgs are the editor arguments of a command; secrets are the values
+// to hide in anything printed or logged
+func licenseArgs(command string, c credentials, file string) (args, secrets []string) {
+	switch command {
+	case "activate":
+		return []string{"-serial", c.serial, "-username", c.username, "-password", c.password}, []string{c.serial, c.password}
+	case "return":
+		return []string{"-returnlicense", "-username", c.username, "-password", c.password}, []string{c.password}
+	case "request":
+		return []string{"-createManualActivationFile"}, nil
+	case "import":
+		return []string{"-manualLicenseFile", file}, nil
+	}
+	return nil, nil
+}
+
+// displayCommand renders the Unity command line with the secrets masked
+func displayCommand(unity, logPath string, args, secrets []string) string {
+	parts := []string{unity, "-batchmode", "-nographics", "-quit", "-logFile", logPath}
+	for _, a := range args {
+		for _, s := range secrets {
+			if s != "" && a == s {
+				a = "****"
+			}
+		}
+		if strings.ContainsAny(a, " \t") {
+			a = strconv.Quote(a)
+		}
+		parts = append(parts, a)
+	}
+	return strings.Join(parts, " ")
+}
+
+// runUnity runs a licensing command in batch mode without a project. The editor
+// writes its command line into the log, so the secrets are scrubbed from it
+// before anything else reads it.
+func runUnity(unity, dir, logPath string, args, secrets []string, timeout time.Duration) error {
+	os.Remove(logPath)
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, unity, append([]string{"-batchmode", "-nographics", "-quit", "-logFile", logPath}, args...)...)
+	cmd.Dir = dir
+	runErr := cmd.Run()
+	scrubLog(logPath, secrets)
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+	if runErr != nil {
+		return fmt.Errorf("Unity failed: %v", runErr)
+	}
+	return nil
+}
+
+func scrubLog(logPath string, secrets []string) {
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		return
+	}
+	text := string(data)
+	for _, s := range secrets {
+		if s != "" {
+			text = strings.ReplaceAll(text, s, "****")
+		}
+	}
+	if text != string(data) {
+		os.WriteFile(logPath, []byte(text), 0600)
+	}
+}
+
+// logExcerpt returns the last error lines of a log, or its last lines when no
+// line looks like an error
+func logExcerpt(logPath string, max int) []string {
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	var errorLines []string
+	for _, line := range lines {
+		if logErrorRegex.MatchString(line) {
+			errorLines = append(errorLines, strings.TrimSpace(line))
+		}
+	}
+	if len(errorLines) == 0 {
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		errorLines = lines
+	}
+	if len(errorLines) > max {
+		errorLines = errorLines[len(errorLines)-max:]
+	}
+	return errorLines
+}
+
+// ============================================================
+// Status
+// ============================================================
+
+// printStatus reports the licenses of this machine and returns whether one is
+// usable for at least minDays more days
+func printStatus(minDays int) bool {
+	printRule("=============================================")
+	fmt.Println(tr("  UNITY LICENSE STATUS"))
+	printRule("=============================================")
+
+	usable := false
+	path := ulfPath()
+	lic, err := readSerialLicense(path)
+	switch {
+	case err != nil:
+		fmt.Printf(tr("  Serial license:  unreadable (%v)\n"), err)
+		recordAction("status", filepath.ToSlash(path), "failed", err.Error(), 0)
+	case lic == nil:
+		fmt.Printf(tr("  Serial license:  none (%s)\n"), path)
+		recordAction("status", filepath.ToSlash(path), "skipped", "no serial license", 0)
+	default:
+		fmt.Printf(tr("  Serial license:  %s\n"), path)
+		fmt.Printf(tr("    Serial:        %s\n"), lic.serial)
+		if !lic.activated.IsZero() {
+			fmt.Printf(tr("    Activated:     %s (Unity %s)\n"), lic.activated.Format("2006-01-02"), lic.editor)
+		}
+		if !lic.updated.IsZero() {
+			fmt.Printf(tr("    Last update:   %s\n"), lic.updated.Format("2006-01-02"))
+		}
+		status, detail := "ok", lic.serial
+		left := time.Until(lic.expires)
+		switch {
+		case lic.expires.IsZero():
+			fmt.Println(tr("    Expires:       never"))
+			usable = true
+		case left <= 0:
+			fmt.Printf(tr("    Expires:       EXPIRED on %s\n"), lic.expires.Format("2006-01-02"))
+			status, detail = "failed", "expired on "+lic.expires.Format("2006-01-02")
+		case left < time.Duration(minDays)*24*time.Hour:
+			fmt.Printf(tr("    Expires:       %s (in %s, less than %d days)\n"), lic.expires.Format("2006-01-02"), formatDuration(left), minDays)
+			status, detail = "failed", "expires on "+lic.expires.Format("2006-01-02")
+		default:
+			fmt.Printf(tr("    Expires:       %s (in %s)\n"), lic.expires.Format("2006-01-02"), formatDuration(left))
+			usable = true
+		}
+		recordAction("status", filepath.ToSlash(path), status, detail, 0)
+	}
+
+	// The Licensing Client and floating servers check out seats at run time; their
+	// expiry is not on disk, so both count as usable while present
+	if files := entitlementFiles(); len(files) > 0 {
+		fmt.Printf(tr("  Entitlements:    %d license file(s) in %s\n"), len(files), entitlementDir())
+		recordAction("status", filepath.ToSlash(entitlementDir()), "ok", fmt.Sprintf("%d license file(s)", len(files)), 0)
+		usable = true
+	} else {
+		fmt.Println(tr("  Entitlements:    none"))
+	}
+	if server := floatingServer(); server != "" {
+		fmt.Printf(tr("  Floating server: %s\n"), server)
+		recordAction("status", server, "ok", "floating license server", 0)
+		usable = true
+	} else {
+		fmt.Println(tr("  Floating server: none"))
+	}
+	return usable
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+// formatDuration renders d as "3d 4h" / "5h 12m" / "40s"
+func formatDuration(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh %dm", hours, int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...": "\n按回车键继续...",
+
+	"Usage: unity_license_manager [flags] [status|activate|return|request|import <file.ulf>]": "用法: unity_license_manager [参数] [status|activate|return|request|import <file.ulf>]",
+	"[ERROR] %v\n":                                       "[ERROR] %v\n",
+	"  UNITY LICENSE STATUS":                             "  Unity 许可证状态",
+	"  Serial license:  unreadable (%v)\n":               "  序列号许可证:    无法读取 (%v)\n",
+	"  Serial license:  none (%s)\n":                     "  序列号许可证:    无 (%s)\n",
+	"  Serial license:  %s\n":                            "  序列号许可证:    %s\n",
+	"    Serial:        %s\n":                            "    序列号:        %s\n",
+	"    Activated:     %s (Unity %s)\n":                 "    激活于:        %s (Unity %s)\n",
+	"    Last update:   %s\n":                            "    最后更新:      %s\n",
+	"    Expires:       never":                           "    到期:          永不",
+	"    Expires:       EXPIRED on %s\n":                 "    到期:          已于 %s 过期\n",
+	"    Expires:       %s (in %s, less than %d days)\n": "    到期:          %s (剩余 %s，不足 %d 天)\n",
+	"    Expires:       %s (in %s)\n":                    "    到期:          %s (剩余 %s)\n",
+	"  Entitlements:    %d license file(s) in %s\n":      "  授权文件:        %[2]s 中有 %[1]d 个许可证文件\n",
+	"  Entitlements:    none":                            "  授权文件:        无",
+	"  Floating server: %s\n":                            "  浮动许可证服务器: %s\n",
+	"  Floating server: none":                            "  浮动许可证服务器: 无",
+	"\n[FAIL] This machine has no usable Unity license.": "\n[FAIL] 本机没有可用的 Unity 许可证。",
+	"[TIP] Run \"unity_license_manager activate\" with UNITY_SERIAL, UNITY_USERNAME and UNITY_PASSWORD set.": "[TIP] 设置 UNITY_SERIAL、UNITY_USERNAME 和 UNITY_PASSWORD 后运行 \"unity_license_manager activate\"。",
+	"\n[OK] This machine has a usable Unity license.":                                                        "\n[OK] 本机有可用的 Unity 许可证。",
+	"[WARNING] %v\n":                     "[WARNING] %v\n",
+	"\n[Dry Run] Unity was not started.": "\n[Dry Run] 未启动 Unity。",
+	"Running %s with %s ...\n":           "正在用 %[2]s 执行 %[1]s...\n",
+	"[FAIL] %s: %v\n":                    "[FAIL] %s: %v\n",
+	"       Log: %s\n":                   "       日志: %s\n",
+	"[OK]   Wrote %s\n":                  "[OK]   已写入 %s\n",
+	"[TIP] Upload it at https://license.unity3d.com/manual, then run: unity_license_manager import <file.ulf>": "[TIP] 请在 https://license.unity3d.com/manual 上传该文件，然后运行: unity_license_manager import <file.ulf>",
+	"[OK]   Activated %s (%s)\n": "[OK]   已激活 %s (%s)\n",
+	"[WARNING] %s is still present; the seat may not have been returned. See %s\n": "[WARNING] %s 仍然存在，席位可能未归还。请查看 %s\n",
+	"[OK]   Returned the license of this machine.":                                 "[OK]   已归还本机的许可证。",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode       bool
+		dryRun       bool
+		minDays      int
+		unityFlag    string
+		versionFlag  string
+		serialFlag   string
+		usernameFlag string
+		outDir       string
+		logPath      string
+		timeout      time.Duration
+	)
+
+	flag.StringVar(&unityFlag, "unity", "", "Unity editor binary or version folder (default: UNITYSTARTER_UNITY, then the Hub install of -version)")
+	flag.StringVar(&versionFlag, "version", "", "Editor version to use from the Unity Hub folders (default: the project's version, else the newest installed)")
+	flag.StringVar(&serialFlag, "serial", "", "activate: serial number (default: UNITY_SERIAL)")
+	flag.StringVar(&usernameFlag, "username", "", "activate/return: Unity account e-mail (default: UNITY_USERNAME, then UNITY_EMAIL); the password is read from UNITY_PASSWORD")
+	flag.StringVar(&outDir, "out", ".", "request: folder to write the .alf file to")
+	flag.IntVar(&minDays, "min-days", 0, "status: also fail when the serial license expires within this many days")
+	flag.StringVar(&logPath, "log", filepath.Join(os.TempDir(), "unitystarter_license.log"), "Editor log of the licensing command")
+	flag.DurationVar(&timeout, "timeout", 10*time.Minute, "Abort Unity after this long (0: no limit)")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Print the Unity command line (secrets masked) without running it")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the command ("activate -dry-run")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_license_manager")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	command := "status"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	valid := map[string]int{"status": 1, "activate": 1, "return": 1, "request": 1, "import": 2}
+	if n, ok := valid[command]; !ok || len(args) > n || (command == "import" && len(args) != 2) {
+		fmt.Println(tr("Usage: unity_license_manager [flags] [status|activate|return|request|import <file.ulf>]"))
+		recordError("invalid command line")
+		exit(2)
+	}
+
+	if command == "status" {
+		if !printStatus(minDays) {
+			fmt.Println(tr("\n[FAIL] This machine has no usable Unity license."))
+			fmt.Println(tr("[TIP] Run \"unity_license_manager activate\" with UNITY_SERIAL, UNITY_USERNAME and UNITY_PASSWORD set."))
+			recordError("no usable Unity license")
+			exit(1)
+		}
+		fmt.Println(tr("\n[OK] This machine has a usable Unity license."))
+		exit(0)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fail(1, "cannot get current directory: %v", err)
+	}
+	creds := readCredentials(serialFlag, usernameFlag)
+	var file string
+	switch command {
+	case "activate":
+		if creds.serial == "" || creds.username == "" || creds.password == "" {
+			fail(2, "activate needs a serial, a username and a password (%s, %s, %s)", envSerial, envUsername, envPassword)
+		}
+	case "return":
+		if creds.username == "" || creds.password == "" {
+			fail(2, "return needs a username and a password (%s, %s)", envUsername, envPassword)
+		}
+	case "import":
+		if file, err = filepath.Abs(args[1]); err == nil {
+			_, err = os.Stat(file)
+		}
+		if err != nil {
+			fail(1, "cannot read %s: %v", args[1], err)
+		}
+	}
+
+	unity, err := findUnity(basePath, unityFlag, versionFlag)
+	if err != nil {
+		if !dryRun {
+			fail(1, "%v", err)
+		}
+		// The dry run still shows the command line
+		fmt.Printf(tr("[WARNING] %v\n"), err)
+		unity = "Unity"
+	}
+	unityArgs, secrets := licenseArgs(command, creds, file)
+	target := unity
+	if command == "activate" {
+		target = maskSerial(creds.serial)
+	}
+
+	if dryRun {
+		fmt.Printf("\n  %s\n", displayCommand(unity, logPath, unityArgs, secrets))
+		recordAction(command, target, "planned", "", 0)
+		fmt.Println(tr("\n[Dry Run] Unity was not started."))
+		exit(0)
+	}
+
+	// The editor writes the .alf into its working directory; exit skips defers
+	workDir := basePath
+	if command == "request" {
+		if workDir, err = os.MkdirTemp("", "unitystarter_alf"); err != nil {
+			fail(1, "%v", err)
+		}
+		tmp := workDir
+		exitFn := exit
+		exit = func(code int) {
+			os.RemoveAll(tmp)
+			exitFn(code)
+		}
+	}
+
+	fmt.Printf(tr("Running %s with %s ...\n"), command, unity)
+	start := time.Now()
+	if err := runUnity(unity, workDir, logPath, unityArgs, secrets, timeout); err != nil {
+		fmt.Printf(tr("[FAIL] %s: %v\n"), command, err)
+		for _, line := range logExcerpt(logPath, 20) {
+			fmt.Printf("       %s\n", line)
+		}
+		fmt.Printf(tr("       Log: %s\n"), logPath)
+		recordAction(command, target, "failed", err.Error(), time.Since(start))
+		recordError("%s: %v", command, err)
+		exit(1)
+	}
+	elapsed := time.Since(start)
+
+	switch command {
+	case "request":
+		matches, _ := filepath.Glob(filepath.Join(workDir, "*.alf"))
+		if len(matches) == 0 {
+			fail(1, "Unity finished but wrote no .alf file; see %s", logPath)
+		}
+		if err := os.MkdirAll(outDir, 0755); err != nil {
+			fail(1, "%v", err)
+		}
+		dest := filepath.Join(outDir, filepath.Base(matches[0]))
+		data, err := os.ReadFile(matches[0])
+		if err == nil {
+			err = os.WriteFile(dest, data, 0644)
+		}
+		if err != nil {
+			fail(1, "cannot write %s: %v", dest, err)
+		}
+		fmt.Printf(tr("[OK]   Wrote %s\n"), dest)
+		recordAction("request", filepath.ToSlash(dest), "ok", "", elapsed)
+		recordArtifact(dest)
+		fmt.Println(tr("[TIP] Upload it at https://license.unity3d.com/manual, then run: unity_license_manager import <file.ulf>"))
+
+	case "activate", "import":
+		// A zero exit code without a license file means the server refused the seat
+		lic, err := readSerialLicense(ulfPath())
+		if err != nil || lic == nil {
+			fail(1, "Unity finished but %s was not written; see %s", ulfPath(), logPath)
+		}
+		fmt.Printf(tr("[OK]   Activated %s (%s)\n"), lic.serial, ulfPath())
+		recordAction(command, target, "ok", lic.serial, elapsed)
+
+	case "return":
+		if _, err := os.Stat(ulfPath()); err == nil {
+			fmt.Printf(tr("[WARNING] %s is still present; the seat may not have been returned. See %s\n"), ulfPath(), logPath)
+			recordAction("return", target, "failed", "license file still present", elapsed)
+			recordError("license file still present after return")
+			exit(1)
+		}
+		fmt.Println(tr("[OK]   Returned the license of this machine."))
+		recordAction("return", target, "ok", "", elapsed)
+	}
+	exit(0)
+}
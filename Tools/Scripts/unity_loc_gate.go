@@ -0,0 +1,1194 @@
+// Unity Localization Gate — Fail a release when localized text is missing.
+// Reads the CycloneGames.Localization string tables, locales and settings
+// straight from the project's YAML, and checks two things: every key the game
+// uses — GetString / TryGetString / GetFormattedString / GetPluralString and
+// new LocalizedString calls with literal arguments in C#, and LocalizedString
+// fields in scenes, prefabs and assets — has a translation in every locale, and
+// no locale falls below the completion percentage the release needs. Keys built
+// at runtime cannot be seen; the completion check covers them. Exits with 1 when
+// the gate fails, so the release pipeline stops before the build.
+//
+// Build: go build unity_loc_gate.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_loc_gate                                   # every used key translated, every locale 100%
+//	unity_loc_gate -min 95 -min-locale ja=80,ko=80   # per-locale thresholds
+//	unity_loc_gate -locales zh-CN,ja -ci             # only the locales this release ships
+//	unity_loc_gate -exclude "**/Tests/**,Assets/Prototypes/**" -json
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Scripts of CycloneGames.Localization whose assets the gate reads, found by
+// file name and namespace so the GUIDs do not have to be hard-coded
+var localizationScripts = map[string]string{
+	"StringTable.cs":          kindStringTable,
+	"AssetTable.cs":           kindAssetTable,
+	"Locale.cs":               kindLocale,
+	"LocalizationSettings.cs": kindSettings,
+}
+
+const (
+	kindStringTable = "StringTable"
+	kindAssetTable  = "AssetTable"
+	kindLocale      = "Locale"
+	kindSettings    = "LocalizationSettings"
+
+	localizationNamespace = "namespace CycloneGames.Localization"
+
+	defaultExclude = "**/Tests/**"
+
+	// Untranslated keys listed per locale, and used keys per problem, before "...and N more"
+	maxListed = 20
+)
+
+// Plural variants are stored as <key>.<category>; a locale has a plural key when
+// it has the "other" variant, which every language uses
+var pluralSuffixes = []string{".zero", ".one", ".two", ".few", ".many", ".other"}
+
+// Folders scanned for the scripts; packages may be embedded or in the cache
+var scriptRoots = []string{"Assets", "Packages", filepath.Join("Library", "PackageCache")}
+
+var (
+	// m_Script: {fileID: 11500000, guid: 247dd70ab61bcf74ba3f638b8b41aaa6, type: 3}
+	scriptRefRegex = regexp.MustCompile(`m_Script: \{fileID: 11500000, guid: ([0-9a-fA-F]{32})`)
+	metaGUIDRegex  = regexp.MustCompile(`(?m)^guid: ([0-9a-fA-F]{32})`)
+	objectRefRegex = regexp.MustCompile(`guid: ([0-9a-fA-F]{32})`)
+
+	// service.GetString("menu", "main.title") and the other lookups with literal table and key
+	codeCallRegex     = regexp.MustCompile(`\b(GetString|TryGetString|GetFormattedString|GetPluralString)\s*\(\s*"((?:[^"\\\n]|\\.)*)"\s*,\s*"((?:[^"\\\n]|\\.)*)"`)
+	newLocalizedRegex = regexp.MustCompile(`\bnew\s+LocalizedString\s*\(\s*"((?:[^"\\\n]|\\.)*)"\s*,\s*"((?:[^"\\\n]|\\.)*)"\s*\)`)
+
+	// A serialized LocalizedString (or LocalizedAsset) field
+	serializedRefRegex = regexp.MustCompile(`(?m)^[ \t]*m_TableId: *(.*?)\r?\n[ \t]*m_EntryKey: *(.*?)\r?$`)
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// stringTable is one StringTable asset: one table ID in one locale
+type stringTable struct {
+	id     string
+	locale string
+	path   string
+	keys   []string        // in table order
+	values map[string]bool // key -> has a non-empty value
+}
+
+// project is what the gate reads from the project
+type project struct {
+	tables      map[string]map[string]*stringTable // table ID -> locale -> table
+	assetTables map[string]bool                    // IDs of asset tables; their references are not checked
+	locales     []string                           // from the settings, else every locale with a table
+	source      string                             // authoring locale: its keys define each table
+}
+
+// usage is one place the game asks for a key
+type usage struct {
+	table  string
+	key    string
+	file   string
+	line   int
+	plural bool
+	code   bool // found in C#, not in a serialized field
+}
+
+// localeResult is the completion of one locale
+type localeResult struct {
+	locale       string
+	total        int
+	translated   int
+	untranslated []string // "table/key"
+	min          float64
+}
+
+func (r *localeResult) percent() float64 {
+	if r.total == 0 {
+		return 100
+	}
+	return float64(r.translated) * 100 / float64(r.total)
+}
+
+// ============================================================
+// Path Filters
+// ============================================================
+
+// globToRegexp converts a glob (*, ?, **) on slash-separated project paths. A
+// pattern without a slash matches the file name in any folder.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compileGlobs parses a comma-separated glob list
+func compileGlobs(list string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := globToRegexp(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, rel string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Localization Assets
+// ============================================================
+
+// findLocalizationScripts maps the script GUIDs of the localization assets to their kind
+func findLocalizationScripts(basePath string) map[string]string {
+	scripts := make(map[string]string)
+	for _, root := range scriptRoots {
+		filepath.Walk(filepath.Join(basePath, root), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if strings.HasPrefix(info.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			kind, ok := localizationScripts[info.Name()]
+			if !ok {
+				return nil
+			}
+			src, err := os.ReadFile(path)
+			if err != nil || !bytes.Contains(src, []byte(localizationNamespace)) {
+				return nil
+			}
+			if guid := metaGUID(path); guid != "" {
+				scripts[guid] = kind
+			}
+			return nil
+		})
+	}
+	return scripts
+}
+
+// metaGUID reads the GUID from the .meta file next to path
+func metaGUID(path string) string {
+	data, err := os.ReadFile(path + ".meta")
+	if err != nil {
+		return ""
+	}
+	if m := metaGUIDRegex.FindSubmatch(data); m != nil {
+		return strings.ToLower(string(m[1]))
+	}
+	return ""
+}
+
+// skipFolder reports folders Unity does not import: hidden ones and those ending in ~
+func skipFolder(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~")
+}
+
+// yamlValue returns the scalar after "key:" on a line, unquoted
+func yamlValue(line, key string) (string, bool) {
+	t := strings.TrimSpace(line)
+	if !strings.HasPrefix(t, key+":") {
+		return "", false
+	}
+	return yamlUnquote(strings.TrimSpace(t[len(key)+1:])), true
+}
+
+func yamlUnquote(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// readProject reads every string table, locale and the settings under Assets/ and Packages/
+func readProject(basePath string, scripts map[string]string, localesFlag, sourceFlag string) (*project, []string) {
+	p := &project{
+		tables:      make(map[string]map[string]*stringTable),
+		assetTables: make(map[string]bool),
+	}
+	var warnings []string
+	localeCodes := make(map[string]string) // Locale asset GUID -> code
+	var settingsPaths []string
+	settingsData := make(map[string][]byte)
+
+	for _, root := range []string{"Assets", "Packages"} {
+		filepath.Walk(filepath.Join(basePath, root), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if skipFolder(info.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(info.Name(), ".asset") {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			m := scriptRefRegex.FindSubmatch(data)
+			if m == nil {
+				return nil
+			}
+			rel := relPath(basePath, path)
+			switch scripts[strings.ToLower(string(m[1]))] {
+			case kindStringTable:
+				t := parseStringTable(data)
+				t.path = rel
+				if t.id == "" || t.locale == "" {
+					warnings = append(warnings, fmt.Sprintf(tr("%s has no table ID or locale; skipped"), rel))
+					return nil
+				}
+				if p.tables[t.id] == nil {
+					p.tables[t.id] = make(map[string]*stringTable)
+				}
+				if prev := p.tables[t.id][t.locale]; prev != nil {
+					warnings = append(warnings, fmt.Sprintf(tr("%s and %s are both table '%s' in %s; only the first is checked"), prev.path, rel, t.id, t.locale))
+					return nil
+				}
+				p.tables[t.id][t.locale] = t
+			case kindAssetTable:
+				for _, line := range strings.Split(string(data), "\n") {
+					if v, ok := yamlValue(line, "tableId"); ok && v != "" {
+						p.assetTables[v] = true
+						break
+					}
+				}
+			case kindLocale:
+				for _, line := range strings.Split(string(data), "\n") {
+					if v, ok := yamlValue(line, "localeCode"); ok {
+						if guid := metaGUID(path); guid != "" && v != "" {
+							localeCodes[guid] = v
+						}
+						break
+					}
+				}
+			case kindSettings:
+				settingsPaths = append(settingsPaths, rel)
+				settingsData[rel] = data
+			}
+			return nil
+		})
+	}
+
+	// The authoring locale's keys define each table; the settings say which locales ship
+	var settingsLocales []string
+	settingsSource := ""
+	sort.Strings(settingsPaths)
+	if len(settingsPaths) > 1 {
+		warnings = append(warnings, fmt.Sprintf(tr("%d LocalizationSettings assets; using %s"), len(settingsPaths), settingsPaths[0]))
+	}
+	if len(settingsPaths) > 0 {
+		refs := parseSettings(settingsData[settingsPaths[0]])
+		for _, guid := range refs["availableLocales"] {
+			if code, ok := localeCodes[guid]; ok {
+				settingsLocales = append(settingsLocales, code)
+			} else {
+				warnings = append(warnings, fmt.Sprintf(tr("%s lists a locale that does not exist (guid %s)"), settingsPaths[0], guid))
+			}
+		}
+		for _, field := range []string{"authoringLocale", "defaultLocale"} {
+			if g := refs[field]; len(g) > 0 && localeCodes[g[0]] != "" {
+				settingsSource = localeCodes[g[0]]
+				break
+			}
+		}
+	}
+
+	switch {
+	case localesFlag != "":
+		for _, l := range strings.Split(localesFlag, ",") {
+			if l = strings.TrimSpace(l); l != "" && !containsString(p.locales, l) {
+				p.locales = append(p.locales, l)
+			}
+		}
+	case len(settingsLocales) > 0:
+		p.locales = settingsLocales
+	default:
+		for _, byLocale := range p.tables {
+			for l := range byLocale {
+				if !containsString(p.locales, l) {
+					p.locales = append(p.locales, l)
+				}
+			}
+		}
+		sort.Strings(p.locales)
+	}
+
+	p.source = sourceFlag
+	if p.source == "" {
+		p.source = settingsSource
+	}
+	if p.source == "" && len(p.locales) > 0 {
+		p.source = p.locales[0]
+		if containsString(p.locales, "en") {
+			p.source = "en"
+		}
+	}
+	return p, warnings
+}
+
+// parseStringTable reads the table ID, locale and entries of a StringTable asset
+func parseStringTable(data []byte) *stringTable {
+	t := &stringTable{values: make(map[string]bool)}
+	key := ""
+	inEntries := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "    ") && !strings.HasPrefix(trimmed, "- ") {
+			inEntries = false
+		}
+		if v, ok := yamlValue(line, "tableId"); ok {
+			t.id = v
+			continue
+		}
+		if v, ok := yamlValue(line, "localeCode"); ok {
+			t.locale = v
+			continue
+		}
+		if strings.HasPrefix(trimmed, "entries:") {
+			inEntries = true
+			continue
+		}
+		if !inEntries {
+			continue
+		}
+		if v, ok := yamlValue(strings.TrimPrefix(trimmed, "- "), "Key"); ok {
+			key = v
+			if _, seen := t.values[key]; !seen {
+				t.keys = append(t.keys, key)
+				t.values[key] = false
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "Value:") && key != "" {
+			// A value that goes on over several lines starts on this one
+			raw := strings.TrimSpace(strings.TrimPrefix(trimmed, "Value:"))
+			t.values[key] = t.values[key] || (raw != "" && raw != "''" && raw != `""`)
+			key = ""
+		}
+	}
+	return t
+}
+
+// parseSettings returns the Locale GUIDs of defaultLocale, authoringLocale and availableLocales
+func parseSettings(data []byte) map[string][]string {
+	refs := make(map[string][]string)
+	inList := false
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if inList {
+			if strings.HasPrefix(trimmed, "- ") {
+				if m := objectRefRegex.FindStringSubmatch(trimmed); m != nil {
+					refs["availableLocales"] = append(refs["availableLocales"], strings.ToLower(m[1]))
+				}
+				continue
+			}
+			inList = false
+		}
+		for _, field := range []string{"defaultLocale", "authoringLocale"} {
+			if strings.HasPrefix(trimmed, field+":") {
+				if m := objectRefRegex.FindStringSubmatch(trimmed); m != nil {
+					refs[field] = []string{strings.ToLower(m[1])}
+				}
+			}
+		}
+		if trimmed == "availableLocales:" {
+			inList = true
+		}
+	}
+	return refs
+}
+
+// sourceKeys returns the keys of a table in the authoring locale, or of all its
+// locales together when it has no authoring-locale table
+func (p *project) sourceKeys(id string) ([]string, bool) {
+	byLocale := p.tables[id]
+	if t := byLocale[p.source]; t != nil {
+		return t.keys, true
+	}
+	var keys []string
+	seen := make(map[string]bool)
+	locales := make([]string, 0, len(byLocale))
+	for l := range byLocale {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	for _, l := range locales {
+		for _, k := range byLocale[l].keys {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	return keys, false
+}
+
+func (p *project) tableIDs() []string {
+	ids := make([]string, 0, len(p.tables))
+	for id := range p.tables {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// translated reports whether a locale has a non-empty value for a key; a plural
+// key needs its "other" variant
+func (p *project) translated(id, locale, key string, plural bool) bool {
+	t := p.tables[id][locale]
+	if t == nil {
+		return false
+	}
+	if t.values[key] {
+		return true
+	}
+	return plural && t.values[key+".other"]
+}
+
+// hasKey reports whether the authoring locale defines a key (any plural variant for a plural key)
+func hasKey(keys map[string]bool, key string, plural bool) bool {
+	if keys[key] {
+		return true
+	}
+	if plural {
+		for _, s := range pluralSuffixes {
+			if keys[key+s] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Used Keys
+// ============================================================
+
+// scanUsages finds the keys the game asks for in C# code and serialized LocalizedString fields
+func scanUsages(basePath string, excludes []*regexp.Regexp) ([]usage, int) {
+	var usages []usage
+	files := 0
+	for _, root := range []string{"Assets", "Packages"} {
+		filepath.Walk(filepath.Join(basePath, root), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if skipFolder(info.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			rel := relPath(basePath, path)
+			ext := strings.ToLower(filepath.Ext(path))
+			if (ext != ".cs" && ext != ".prefab" && ext != ".unity" && ext != ".asset") || matchesAny(excludes, rel) {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			before := len(usages)
+			if ext == ".cs" {
+				usages = append(usages, codeUsages(data, rel)...)
+			} else if bytes.Contains(data, []byte("m_EntryKey:")) {
+				for _, m := range serializedRefRegex.FindAllSubmatchIndex(data, -1) {
+					table := yamlUnquote(strings.TrimSpace(string(data[m[2]:m[3]])))
+					key := yamlUnquote(strings.TrimSpace(string(data[m[4]:m[5]])))
+					if table == "" || key == "" {
+						continue // an unset field
+					}
+					usages = append(usages, usage{table: table, key: key, file: rel, line: lineAt(data, m[0])})
+				}
+			}
+			if len(usages) > before {
+				files++
+			}
+			return nil
+		})
+	}
+	return usages, files
+}
+
+// codeUsages finds lookups with a literal table ID and key in one C# file
+func codeUsages(src []byte, rel string) []usage {
+	var res []usage
+	for _, m := range codeCallRegex.FindAllSubmatchIndex(src, -1) {
+		// PlayerPrefs.GetString("name", "default") has the same shape
+		if bytes.HasSuffix(bytes.TrimRight(src[:m[0]], " \t"), []byte("PlayerPrefs.")) {
+			continue
+		}
+		res = append(res, usage{
+			table:  csharpUnquote(string(src[m[4]:m[5]])),
+			key:    csharpUnquote(string(src[m[6]:m[7]])),
+			file:   rel,
+			line:   lineAt(src, m[0]),
+			plural: string(src[m[2]:m[3]]) == "GetPluralString",
+			code:   true,
+		})
+	}
+	for _, m := range newLocalizedRegex.FindAllSubmatchIndex(src, -1) {
+		res = append(res, usage{
+			table: csharpUnquote(string(src[m[2]:m[3]])),
+			key:   csharpUnquote(string(src[m[4]:m[5]])),
+			file:  rel,
+			line:  lineAt(src, m[0]),
+			code:  true,
+		})
+	}
+	return res
+}
+
+func csharpUnquote(s string) string {
+	if u, err := strconv.Unquote(`"` + s + `"`); err == nil {
+		return u
+	}
+	return s
+}
+
+func lineAt(data []byte, offset int) int {
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// keyProblem is one used key that is not ready for release
+type keyProblem struct {
+	table   string
+	key     string
+	reason  string // English format with one %s, translated when printed
+	arg     string
+	places  []string
+	warning bool // a code lookup on a table that does not exist: may not be a localization call
+}
+
+// checkUsages groups the used keys and reports the ones that are missing somewhere
+func checkUsages(p *project, usages []usage) ([]*keyProblem, int) {
+	sourceSets := make(map[string]map[string]bool)
+	for _, id := range p.tableIDs() {
+		keys, _ := p.sourceKeys(id)
+		set := make(map[string]bool, len(keys))
+		for _, k := range keys {
+			set[k] = true
+		}
+		sourceSets[id] = set
+	}
+
+	byKey := make(map[string]*keyProblem)
+	var order []string
+	distinct := make(map[string]bool)
+	for _, u := range usages {
+		if p.assetTables[u.table] && p.tables[u.table] == nil {
+			continue // a LocalizedAsset reference
+		}
+		id := u.table + "/" + u.key
+		distinct[id] = true
+		place := fmt.Sprintf("%s:%d", u.file, u.line)
+		if kp, ok := byKey[id]; ok {
+			if kp != nil && !containsString(kp.places, place) {
+				kp.places = append(kp.places, place)
+			}
+			continue
+		}
+
+		var kp *keyProblem
+		switch {
+		case p.tables[u.table] == nil:
+			kp = &keyProblem{reason: "no string table '%s'", arg: u.table, warning: u.code}
+		case !hasKey(sourceSets[u.table], u.key, u.plural):
+			kp = &keyProblem{reason: "not in table '%s'", arg: u.table}
+		default:
+			var missing []string
+			for _, l := range p.locales {
+				if !p.translated(u.table, l, u.key, u.plural) {
+					missing = append(missing, l)
+				}
+			}
+			if len(missing) > 0 {
+				kp = &keyProblem{reason: "not translated in %s", arg: strings.Join(missing, ", ")}
+			}
+		}
+		byKey[id] = kp
+		if kp != nil {
+			kp.table, kp.key = u.table, u.key
+			kp.places = []string{place}
+			order = append(order, id)
+		}
+	}
+
+	var problems []*keyProblem
+	for _, id := range order {
+		problems = append(problems, byKey[id])
+	}
+	sort.SliceStable(problems, func(i, j int) bool { return !problems[i].warning && problems[j].warning })
+	return problems, len(distinct)
+}
+
+// ============================================================
+// Completion
+// ============================================================
+
+// completion counts the translated keys of every locale against the authoring locale
+func completion(p *project, minAll float64, minLocale map[string]float64) []*localeResult {
+	var results []*localeResult
+	for _, l := range p.locales {
+		r := &localeResult{locale: l, min: minAll}
+		if v, ok := minLocale[l]; ok {
+			r.min = v
+		}
+		for _, id := range p.tableIDs() {
+			keys, _ := p.sourceKeys(id)
+			set := make(map[string]bool, len(keys))
+			for _, k := range keys {
+				set[k] = true
+			}
+			for _, k := range keys {
+				if optionalPluralVariant(set, k) {
+					continue
+				}
+				r.total++
+				if p.translated(id, l, k, false) {
+					r.translated++
+				} else {
+					r.untranslated = append(r.untranslated, id+"/"+k)
+				}
+			}
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// optionalPluralVariant reports a plural variant other than "other": languages
+// use different categories (Japanese only has "other"), so only that one is counted
+func optionalPluralVariant(keys map[string]bool, key string) bool {
+	for _, s := range pluralSuffixes {
+		if s != ".other" && strings.HasSuffix(key, s) {
+			return keys[strings.TrimSuffix(key, s)+".other"]
+		}
+	}
+	return false
+}
+
+// parseMinLocale reads "ja=80,ko=85.5"
+func parseMinLocale(s string) (map[string]float64, error) {
+	res := make(map[string]float64)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		i := strings.Index(part, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("-min-locale wants locale=percent, got '%s'", part)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(part[i+1:]), "%"), 64)
+		if err != nil || v < 0 || v > 100 {
+			return nil, fmt.Errorf("-min-locale: '%s' is not a percentage from 0 to 100", part[i+1:])
+		}
+		res[strings.TrimSpace(part[:i])] = v
+	}
+	return res, nil
+}
+
+// formatPercent shows 99.95% as 99.9% rather than rounding a failing locale up to 100%
+func formatPercent(v float64) string {
+	floor := float64(int64(v*10)) / 10
+	return strconv.FormatFloat(floor, 'f', 1, 64) + "%"
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode      bool
+		minAll      float64
+		minLocaleIn string
+		localesFlag string
+		sourceFlag  string
+		excludeFlag string
+	)
+
+	flag.Float64Var(&minAll, "min", 100, "Lowest completion percentage a locale may have")
+	flag.StringVar(&minLocaleIn, "min-locale", "", "Per-locale minimums, e.g. ja=80,ko=85 (override -min)")
+	flag.StringVar(&localesFlag, "locales", "", "Comma-separated locales to check (default: availableLocales of LocalizationSettings)")
+	flag.StringVar(&sourceFlag, "source", "", "Authoring locale whose keys define each table (default: from LocalizationSettings)")
+	flag.StringVar(&excludeFlag, "exclude", defaultExclude, "Comma-separated globs of files not scanned for used keys")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_loc_gate")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	if flag.NArg() > 0 {
+		fmt.Println(tr("Usage: unity_loc_gate [-min <percent>] [-min-locale ja=80,...] [-locales zh-CN,ja] [-ci] [-json]"))
+		recordError("unexpected argument '%s'", flag.Arg(0))
+		exit(2)
+	}
+	if minAll < 0 || minAll > 100 {
+		fail(2, "-min must be a percentage from 0 to 100")
+	}
+	minLocale, err := parseMinLocale(minLocaleIn)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+	excludes, err := compileGlobs(excludeFlag)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  LOCALIZATION GATE"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project: %s\n"), filepath.Base(basePath))
+
+	scripts := findLocalizationScripts(basePath)
+	hasTables := false
+	for _, kind := range scripts {
+		hasTables = hasTables || kind == kindStringTable
+	}
+	if !hasTables {
+		fail(1, "CycloneGames.Localization is not in this project (no StringTable.cs with its .meta)")
+	}
+	p, warnings := readProject(basePath, scripts, localesFlag, sourceFlag)
+	keyCount := 0
+	for _, id := range p.tableIDs() {
+		keys, ok := p.sourceKeys(id)
+		keyCount += len(keys)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf(tr("table '%s' has no %s version; its keys are those of all its locales"), id, p.source))
+		}
+	}
+	for l := range minLocale {
+		if !containsString(p.locales, l) {
+			warnings = append(warnings, fmt.Sprintf(tr("-min-locale names %s, which is not checked"), l))
+		}
+	}
+	fmt.Printf(tr("  Source:  %s\n"), p.source)
+	fmt.Printf(tr("  Locales: %s\n"), strings.Join(p.locales, ", "))
+	fmt.Printf(tr("  Tables:  %d (%d key(s))\n"), len(p.tables), keyCount)
+	for _, w := range warnings {
+		fmt.Printf(tr("[WARNING] %s\n"), w)
+	}
+
+	// Completion of every locale
+	fmt.Println(tr("\nCompletion:"))
+	if len(p.locales) == 0 {
+		fmt.Println(tr("  (no string tables, and no availableLocales in LocalizationSettings)"))
+	}
+	results := completion(p, minAll, minLocale)
+	below := 0
+	for _, r := range results {
+		status, mark := "ok", tr("OK")
+		if r.percent() < r.min {
+			status, mark = "failed", tr("FAIL")
+			below++
+		}
+		detail := fmt.Sprintf("%s (%d/%d), min %s", formatPercent(r.percent()), r.translated, r.total, formatPercent(r.min))
+		fmt.Printf(tr("  %-10s %7s  %d/%d  min %s  %s\n"), r.locale, formatPercent(r.percent()), r.translated, r.total, formatPercent(r.min), mark)
+		recordAction("completion", r.locale, status, detail, 0)
+	}
+	for _, r := range results {
+		if r.percent() >= r.min {
+			continue
+		}
+		recordError("%s is %s translated, below the minimum of %s", r.locale, formatPercent(r.percent()), formatPercent(r.min))
+		fmt.Printf(tr("\nUntranslated in %s:\n"), r.locale)
+		for i, k := range r.untranslated {
+			if i == maxListed {
+				fmt.Printf(tr("  ...and %d more\n"), len(r.untranslated)-maxListed)
+				break
+			}
+			fmt.Printf("  %s\n", k)
+		}
+	}
+
+	// Keys the game asks for
+	usages, files := scanUsages(basePath, excludes)
+	problems, distinct := checkUsages(p, usages)
+	fmt.Printf(tr("\nUsed keys: %d in %d file(s)\n"), distinct, files)
+	missing := 0
+	for _, kp := range problems {
+		label, status := tr("[FAIL]"), "failed"
+		if kp.warning {
+			label, status = tr("[WARNING]"), "warning"
+		} else {
+			missing++
+			recordError("%s/%s: %s", kp.table, kp.key, fmt.Sprintf(kp.reason, kp.arg))
+		}
+		fmt.Printf("%s %s/%s: %s\n", label, kp.table, kp.key, fmt.Sprintf(tr(kp.reason), kp.arg))
+		for i, place := range kp.places {
+			if i == 3 {
+				fmt.Printf(tr("       ...and %d more\n"), len(kp.places)-3)
+				break
+			}
+			fmt.Printf("       %s\n", place)
+		}
+		recordAction("key", kp.table+"/"+kp.key, status, fmt.Sprintf(kp.reason, kp.arg)+"; "+strings.Join(kp.places, ", "), 0)
+	}
+	if len(problems) == 0 {
+		fmt.Println(tr("[OK] Every used key is translated in every locale."))
+	}
+
+	if missing > 0 || below > 0 {
+		fmt.Printf(tr("\n[ERROR] Localization gate failed: %d used key(s) not ready, %d locale(s) below the minimum.\n"), missing, below)
+		exit(1)
+	}
+	fmt.Println(tr("\n[OK] Localization gate passed."))
+	exit(0)
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+// relPath shows a path relative to the project root, with forward slashes
+func relPath(basePath, p string) string {
+	if rel, err := filepath.Rel(basePath, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return p
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"%s has no table ID or locale; skipped":                          "%s 没有表 ID 或语言，已跳过",
+	"%s and %s are both table '%s' in %s; only the first is checked": "%s 和 %s 都是 %[4]s 的表 '%[3]s'，只检查第一个",
+	"%d LocalizationSettings assets; using %s":                       "有 %d 个 LocalizationSettings 资源，使用 %s",
+	"%s lists a locale that does not exist (guid %s)":                "%s 引用了不存在的语言 (guid %s)",
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"Usage: unity_loc_gate [-min <percent>] [-min-locale ja=80,...] [-locales zh-CN,ja] [-ci] [-json]": "用法: unity_loc_gate [-min <百分比>] [-min-locale ja=80,...] [-locales zh-CN,ja] [-ci] [-json]",
+	"[ERROR] Cannot get current directory: %v\n":                                                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.":                                 "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                                           "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  LOCALIZATION GATE": "  本地化发布检查",
+	"  Project: %s\n":     "  项目: %s\n",
+	"table '%s' has no %s version; its keys are those of all its locales": "表 '%s' 没有 %s 版本，以其所有语言的键合并作为键列表",
+	"-min-locale names %s, which is not checked":                          "-min-locale 指定了 %s，但该语言不在检查范围内",
+	"  Source:  %s\n":             "  源语言: %s\n",
+	"  Locales: %s\n":             "  语言:   %s\n",
+	"  Tables:  %d (%d key(s))\n": "  表:     %d 个 (%d 个键)\n",
+	"[WARNING] %s\n":              "[WARNING] %s\n",
+	"  (no string tables, and no availableLocales in LocalizationSettings)": "  (没有字符串表，LocalizationSettings 中也没有 availableLocales)",
+	"\nCompletion:":                    "\n完成度:",
+	"OK":                               "通过",
+	"FAIL":                             "未通过",
+	"  %-10s %7s  %d/%d  min %s  %s\n": "  %-10s %7s  %d/%d  最低 %s  %s\n",
+	"\nUntranslated in %s:\n":          "\n%s 中未翻译:\n",
+	"  ...and %d more\n":               "  ...另有 %d 个\n",
+	"\nUsed keys: %d in %d file(s)\n":  "\n使用的键: %d 个，位于 %d 个文件\n",
+	"[FAIL]":                           "[FAIL]",
+	"[WARNING]":                        "[WARNING]",
+	"       ...and %d more\n":          "       ...另有 %d 处\n",
+	"[OK] Every used key is translated in every locale.":                                              "[OK] 所有使用的键在每种语言中都已翻译。",
+	"\n[ERROR] Localization gate failed: %d used key(s) not ready, %d locale(s) below the minimum.\n": "\n[ERROR] 本地化检查未通过: %d 个使用的键未就绪，%d 种语言低于最低完成度。\n",
+	"\n[OK] Localization gate passed.":                                                                "\n[OK] 本地化检查通过。",
+	"\nPress Enter to continue...":                                                                    "\n按回车键继续...",
+	"no string table '%s'":                                                                            "没有字符串表 '%s'",
+	"not in table '%s'":                                                                               "不在表 '%s' 中",
+	"not translated in %s":                                                                            "在 %s 中未翻译",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
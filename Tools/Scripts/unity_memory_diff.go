@@ -0,0 +1,1569 @@
+// Unity Memory Diff — Compare Memory Profiler snapshots and report what grew between them.
+// Reads two or more snapshots (.snap files from the Memory Profiler package, taken
+// in that order, e.g. at the start and every few minutes of a soak test), and
+// reports the change in native object count and size per type and per object
+// (name, and whether it was loaded from an asset), plus the managed heap size.
+// Objects that grow in every step of a series of three or more snapshots are
+// flagged as leaks. The snapshots are exported in batch mode with the installed
+// editor through MemorySnapshotExporter from "unity_build_hooks install"; the
+// report is written as Markdown and HTML for pull requests and bug reports.
+//
+// Build: go build unity_memory_diff.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_memory_diff                                     # every snapshot in MemoryCaptures, oldest first
+//	unity_memory_diff before.snap after.snap
+//	unity_memory_diff start.snap 10min.snap 20min.snap    # soak test: flags steady growth
+//	unity_memory_diff -top 50 -min-delta 256KB -ci -fail-on-leak
+//	unity_memory_diff -dry-run                            # show the Unity command line
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	defaultSnapshotDir = "MemoryCaptures"   // where the Memory Profiler saves snapshots
+	defaultOutDir      = "Build/MemoryDiff" // exported snapshots, report.md and report.html
+	exportsFolderName  = "exports"
+	exportLogRel       = "Library/UnityStarter/SnapshotExport.log"
+	exportMethod       = "Build.Hooks.Editor.MemorySnapshotExporter.ExportFromCommandLine"
+
+	// Overrides the editor picked from ProjectVersion.txt and the Unity Hub folders
+	envUnityPath = "UNITYSTARTER_UNITY"
+)
+
+// Contract with the editor hooks; keep in sync with unity_build_hooks.go
+const (
+	envSnapshotExports       = "UNITYSTARTER_SNAPSHOT_EXPORTS"
+	envSnapshotReport        = "UNITYSTARTER_SNAPSHOT_REPORT"
+	defaultSnapshotReportRel = "Library/UnityStarter/LastSnapshotExport.json"
+
+	hooksManifestFile = "UnityStarterBuildHooks.json" // in ProjectSettings/
+	exportHooksMin    = 5                             // first hooks version with MemorySnapshotExporter
+)
+
+// Snapshot files: .snap is exported first, .json is an earlier export
+const (
+	snapshotExtension = ".snap"
+	exportExtension   = ".json"
+)
+
+// Name of native objects without one, e.g. most runtime-created meshes
+const unnamedObject = "(unnamed)"
+
+var (
+	projectVersionRegex = regexp.MustCompile(`(?m)^m_EditorVersion:\s*(\S+)`)
+
+	// Log lines worth showing when the export fails
+	logErrorRegex = regexp.MustCompile(`(?i)(error CS\d+|\[MemorySnapshotExporter\].*:|\[Error\]|Exception:|error:)`)
+
+	sizeRegex = regexp.MustCompile(`(?i)^([\d.]+)\s*(b|kb|mb|gb|tb)?$`)
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// EditorInstance represents the structure of Library/EditorInstance.json
+type EditorInstance struct {
+	ProcessID int `json:"process_id"`
+}
+
+// snapshotExport is the JSON MemorySnapshotExporter writes for one snapshot
+type snapshotExport struct {
+	ContractVersion   int           `json:"contractVersion"`
+	Snapshot          string        `json:"snapshot"`
+	RecordDate        string        `json:"recordDate"`
+	Platform          string        `json:"platform"`
+	NativeObjects     int           `json:"nativeObjects"`
+	NativeObjectBytes int64         `json:"nativeObjectBytes"`
+	ManagedHeapBytes  int64         `json:"managedHeapBytes"`
+	Objects           []objectGroup `json:"objects"`
+}
+
+// objectGroup is the native objects of one type and name
+type objectGroup struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Asset bool   `json:"asset"` // loaded from an asset rather than created at runtime
+	Count int    `json:"count"`
+	Size  int64  `json:"size"`
+}
+
+// exportReport is the LastSnapshotExport.json MemorySnapshotExporter writes
+type exportReport struct {
+	ContractVersion int    `json:"contractVersion"`
+	UnityVersion    string `json:"unityVersion"`
+	FinishedAt      string `json:"finishedAt"`
+	Snapshots       []struct {
+		Snapshot string `json:"snapshot"`
+		JSON     string `json:"json"`
+		Objects  int    `json:"objects"`
+		Error    string `json:"error"`
+	} `json:"snapshots"`
+}
+
+// snapshot is one file of the series
+type snapshot struct {
+	path     string // absolute
+	rel      string // relative to the project root (or as given), slash-separated
+	name     string // file name without extension, the column title in the report
+	jsonPath string // the export that is read
+	convert  bool   // a .snap whose export is missing or older than it
+	data     *snapshotExport
+}
+
+// deltaRow is one type or object across the series
+type deltaRow struct {
+	Type       string
+	Name       string // "" for the rows per type
+	Asset      bool
+	Counts     []int
+	Sizes      []int64
+	CountDelta int   // last - first
+	SizeDelta  int64 // last - first
+	Leak       bool  // grew in every step of three or more snapshots
+}
+
+// seriesDiff is the whole comparison
+type seriesDiff struct {
+	Snapshots []*snapshot
+	Types     []*deltaRow
+	Objects   []*deltaRow
+	Leaks     []*deltaRow
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// checkUnityRunning checks if Unity Editor is running for this project
+// via Library/EditorInstance.json and a liveness check on the recorded PID.
+func checkUnityRunning(basePath string) (bool, int) {
+	data, err := os.ReadFile(filepath.Join(basePath, "Library", "EditorInstance.json"))
+	if err != nil {
+		return false, 0
+	}
+	var instance EditorInstance
+	if err := json.Unmarshal(data, &instance); err != nil || instance.ProcessID <= 0 {
+		return false, 0
+	}
+	if isProcessRunning(instance.ProcessID) {
+		return true, instance.ProcessID
+	}
+	return false, 0
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// readUnityVersion returns the editor version from ProjectSettings/ProjectVersion.txt
+func readUnityVersion(basePath string) string {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectVersion.txt"))
+	if err != nil {
+		return ""
+	}
+	if m := projectVersionRegex.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// installedHooksVersion reads the hooks manifest; 0 when the hooks are not installed
+func installedHooksVersion(basePath string) int {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", hooksManifestFile))
+	if err != nil {
+		return 0
+	}
+	var m struct {
+		HooksVersion int `json:"hooksVersion"`
+	}
+	json.Unmarshal(data, &m)
+	return m.HooksVersion
+}
+
+// ============================================================
+// Snapshots
+// ============================================================
+
+// findSnapshots resolves the arguments: files in the given order, folders with
+// their .snap files oldest first. Exports in a folder are used only when the
+// folder has no .snap files, so a folder of earlier exports can be compared too.
+func findSnapshots(basePath string, args []string) ([]*snapshot, error) {
+	var found []*snapshot
+	seen := make(map[string]bool)
+	add := func(abs string) {
+		if seen[abs] {
+			return
+		}
+		seen[abs] = true
+		rel := filepath.ToSlash(abs)
+		if r, err := filepath.Rel(basePath, abs); err == nil && !strings.HasPrefix(r, "..") {
+			rel = filepath.ToSlash(r)
+		}
+		name := filepath.Base(abs)
+		found = append(found, &snapshot{path: abs, rel: rel, name: strings.TrimSuffix(name, filepath.Ext(name))})
+	}
+	for _, arg := range args {
+		abs := arg
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(basePath, filepath.FromSlash(arg))
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot not found: %s", arg)
+		}
+		if !info.IsDir() {
+			if ext := strings.ToLower(filepath.Ext(abs)); ext != snapshotExtension && ext != exportExtension {
+				return nil, fmt.Errorf("%s is not a snapshot (use .snap, or a .json export)", arg)
+			}
+			add(abs)
+			continue
+		}
+		entries, err := os.ReadDir(abs)
+		if err != nil {
+			return nil, err
+		}
+		type dated struct {
+			path string
+			mod  time.Time
+		}
+		var snaps, exports []dated
+		for _, e := range entries {
+			fi, err := e.Info()
+			if err != nil || e.IsDir() {
+				continue
+			}
+			switch strings.ToLower(filepath.Ext(e.Name())) {
+			case snapshotExtension:
+				snaps = append(snaps, dated{filepath.Join(abs, e.Name()), fi.ModTime()})
+			case exportExtension:
+				exports = append(exports, dated{filepath.Join(abs, e.Name()), fi.ModTime()})
+			}
+		}
+		if len(snaps) == 0 {
+			snaps = exports
+		}
+		sort.SliceStable(snaps, func(i, j int) bool {
+			if !snaps[i].mod.Equal(snaps[j].mod) {
+				return snaps[i].mod.Before(snaps[j].mod)
+			}
+			return snaps[i].path < snaps[j].path
+		})
+		for _, s := range snaps {
+			add(s.path)
+		}
+	}
+	return found, nil
+}
+
+// exportPath is where the export of a .snap goes: its project path with '/'
+// replaced, so snapshots of the same name in different folders do not collide
+func exportPath(outDir string, s *snapshot) string {
+	name := strings.NewReplacer("/", "_", ":", "_").Replace(strings.TrimPrefix(s.rel, "/"))
+	return filepath.Join(outDir, exportsFolderName, name+exportExtension)
+}
+
+// planExports sets the export of every snapshot and marks the .snap files
+// whose export is missing or older than the snapshot
+func planExports(snapshots []*snapshot, outDir string, reexport bool) []*snapshot {
+	var pending []*snapshot
+	for _, s := range snapshots {
+		if strings.ToLower(filepath.Ext(s.path)) == exportExtension {
+			s.jsonPath = s.path
+			continue
+		}
+		s.jsonPath = exportPath(outDir, s)
+		src, err := os.Stat(s.path)
+		dst, dstErr := os.Stat(s.jsonPath)
+		if reexport || err != nil || dstErr != nil || dst.ModTime().Before(src.ModTime()) {
+			s.convert = true
+			pending = append(pending, s)
+		}
+	}
+	return pending
+}
+
+// loadExport reads the export of a snapshot
+func loadExport(p string) (*snapshotExport, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var e snapshotExport
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("not a snapshot export: %v", err)
+	}
+	if e.ContractVersion == 0 && e.NativeObjects == 0 && len(e.Objects) == 0 {
+		return nil, errors.New("not a snapshot export (no native objects)")
+	}
+	return &e, nil
+}
+
+// ============================================================
+// Snapshot Export
+// ============================================================
+
+// exportCommand returns the Unity arguments and the extra environment that
+// export the given snapshots
+func exportCommand(basePath string, snapshots []*snapshot) ([]string, []string) {
+	args := []string{
+		"-batchmode", "-quit",
+		"-projectPath", basePath,
+		"-executeMethod", exportMethod,
+		"-logFile", filepath.Join(basePath, filepath.FromSlash(exportLogRel)),
+	}
+	var entries []string
+	for _, s := range snapshots {
+		entries = append(entries, s.path+"="+s.jsonPath)
+	}
+	env := []string{
+		envSnapshotExports + "=" + strings.Join(entries, ";"),
+		envSnapshotReport + "=" + filepath.Join(basePath, filepath.FromSlash(defaultSnapshotReportRel)),
+	}
+	return args, env
+}
+
+// runExport starts Unity and returns the report MemorySnapshotExporter wrote.
+// Unity exits with 1 when a snapshot failed, so the report is read in that case too.
+func runExport(basePath, unity string, snapshots []*snapshot, timeout time.Duration) (*exportReport, error) {
+	reportPath := filepath.Join(basePath, filepath.FromSlash(defaultSnapshotReportRel))
+	logPath := filepath.Join(basePath, filepath.FromSlash(exportLogRel))
+	// Leftovers of the previous run must not pass for this run's results
+	os.Remove(reportPath)
+	os.Remove(logPath)
+	if err := os.MkdirAll(filepath.Dir(reportPath), 0755); err != nil {
+		return nil, err
+	}
+	for _, s := range snapshots {
+		if err := os.MkdirAll(filepath.Dir(s.jsonPath), 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	args, env := exportCommand(basePath, snapshots)
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, unity, args...)
+	cmd.Dir = basePath
+	cmd.Env = append(os.Environ(), env...)
+	runErr := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("Unity failed (%v) and wrote no export report", runErr)
+		}
+		return nil, errors.New("no export report was written (hooks missing or the project did not compile)")
+	}
+	var report exportReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("cannot read %s: %v", defaultSnapshotReportRel, err)
+	}
+	return &report, nil
+}
+
+// logExcerpt returns the last error lines of a log, or its last lines when no
+// line looks like an error
+func logExcerpt(logPath string, max int) []string {
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	var errorLines []string
+	for _, line := range lines {
+		if logErrorRegex.MatchString(line) {
+			errorLines = append(errorLines, strings.TrimSpace(line))
+		}
+	}
+	if len(errorLines) == 0 {
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		errorLines = lines
+	}
+	if len(errorLines) > max {
+		errorLines = errorLines[len(errorLines)-max:]
+	}
+	return errorLines
+}
+
+// ============================================================
+// Diff
+// ============================================================
+
+// diffSeries builds the rows per type and per object. Rows whose size changed
+// by less than minDelta, or that did not change at all, are left out.
+func diffSeries(snapshots []*snapshot, minDelta int64) *seriesDiff {
+	n := len(snapshots)
+	types := make(map[string]*deltaRow)
+	objects := make(map[string]*deltaRow)
+	row := func(index map[string]*deltaRow, key, typ, name string, asset bool) *deltaRow {
+		r, ok := index[key]
+		if !ok {
+			r = &deltaRow{Type: typ, Name: name, Asset: asset, Counts: make([]int, n), Sizes: make([]int64, n)}
+			index[key] = r
+		}
+		return r
+	}
+	for i, s := range snapshots {
+		for _, g := range s.data.Objects {
+			name := g.Name
+			if strings.TrimSpace(name) == "" {
+				name = unnamedObject
+			}
+			t := row(types, g.Type, g.Type, "", false)
+			t.Counts[i] += g.Count
+			t.Sizes[i] += g.Size
+			o := row(objects, fmt.Sprintf("%s\x00%s\x00%v", g.Type, name, g.Asset), g.Type, name, g.Asset)
+			o.Counts[i] += g.Count
+			o.Sizes[i] += g.Size
+		}
+	}
+
+	d := &seriesDiff{Snapshots: snapshots}
+	collect := func(index map[string]*deltaRow) []*deltaRow {
+		var rows []*deltaRow
+		for _, r := range index {
+			r.CountDelta = r.Counts[n-1] - r.Counts[0]
+			r.SizeDelta = r.Sizes[n-1] - r.Sizes[0]
+			if (r.CountDelta == 0 && r.SizeDelta == 0) || abs64(r.SizeDelta) < minDelta {
+				continue
+			}
+			r.Leak = n >= 3 && growsEveryStep(r)
+			rows = append(rows, r)
+		}
+		sortRows(rows)
+		return rows
+	}
+	d.Types = collect(types)
+	d.Objects = collect(objects)
+	for _, r := range append(append([]*deltaRow{}, d.Types...), d.Objects...) {
+		if r.Leak {
+			d.Leaks = append(d.Leaks, r)
+		}
+	}
+	sortRows(d.Leaks)
+	return d
+}
+
+// growsEveryStep reports whether the count, or else the size at an unchanged or
+// growing count, went up from each snapshot to the next
+func growsEveryStep(r *deltaRow) bool {
+	counts, sizes := true, true
+	for i := 1; i < len(r.Counts); i++ {
+		if r.Counts[i] <= r.Counts[i-1] {
+			counts = false
+		}
+		if r.Sizes[i] <= r.Sizes[i-1] || r.Counts[i] < r.Counts[i-1] {
+			sizes = false
+		}
+	}
+	return counts || sizes
+}
+
+// sortRows orders by the size change, largest growth first, then by the count change
+func sortRows(rows []*deltaRow) {
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.SizeDelta != b.SizeDelta {
+			return a.SizeDelta > b.SizeDelta
+		}
+		if a.CountDelta != b.CountDelta {
+			return a.CountDelta > b.CountDelta
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Name < b.Name
+	})
+}
+
+// topRows returns the largest growths and, when there is room, the largest
+// shrinks, up to n rows
+func topRows(rows []*deltaRow, n int) []*deltaRow {
+	if n <= 0 || len(rows) <= n {
+		return rows
+	}
+	var grown, shrunk []*deltaRow
+	for _, r := range rows {
+		if r.SizeDelta > 0 || (r.SizeDelta == 0 && r.CountDelta > 0) {
+			grown = append(grown, r)
+		} else {
+			shrunk = append(shrunk, r)
+		}
+	}
+	if len(grown) >= n {
+		return grown[:n]
+	}
+	// Shrinks are sorted smallest first; the largest ones are at the end
+	rest := n - len(grown)
+	return append(grown, shrunk[len(shrunk)-rest:]...)
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+// formatDelta renders a size change with its sign
+func formatDelta(v int64) string {
+	switch {
+	case v > 0:
+		return "+" + formatSize(v)
+	case v < 0:
+		return "-" + formatSize(-v)
+	}
+	return "0 B"
+}
+
+func formatCountDelta(v int) string {
+	if v > 0 {
+		return "+" + strconv.Itoa(v)
+	}
+	return strconv.Itoa(v)
+}
+
+// seriesText joins the values of a row across the snapshots: "10 → 12 → 15"
+func seriesText(values []string) string {
+	return strings.Join(values, " → ")
+}
+
+func countSeries(r *deltaRow) string {
+	var values []string
+	for _, c := range r.Counts {
+		values = append(values, strconv.Itoa(c))
+	}
+	return seriesText(values)
+}
+
+func sizeSeries(r *deltaRow) string {
+	var values []string
+	for _, s := range r.Sizes {
+		values = append(values, formatSize(s))
+	}
+	return seriesText(values)
+}
+
+// rowLabel is "Texture2D" for a type row and "Texture2D 'Hero_D'" for an object
+func rowLabel(r *deltaRow) string {
+	if r.Name == "" {
+		return r.Type
+	}
+	label := fmt.Sprintf("%s '%s'", r.Type, r.Name)
+	if r.Asset {
+		label += " (asset)"
+	}
+	return label
+}
+
+// printRows prints a table of rows to the console
+func printRows(title string, rows []*deltaRow, total int) {
+	fmt.Printf("\n%s", title)
+	if total > len(rows) {
+		fmt.Printf(tr(" (top %d of %d)"), len(rows), total)
+	}
+	fmt.Println()
+	if len(rows) == 0 {
+		fmt.Println(tr("  (none)"))
+		return
+	}
+	for _, r := range rows {
+		marker := "  "
+		if r.Leak {
+			marker = "! "
+		}
+		fmt.Printf("%s%-12s %-8s  %s\n", marker, formatDelta(r.SizeDelta), formatCountDelta(r.CountDelta), rowLabel(r))
+	}
+}
+
+// mdCell escapes text for a Markdown table cell
+func mdCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ", "`", "'").Replace(s)
+}
+
+// renderMarkdown writes the report as Markdown
+func renderMarkdown(project string, d *seriesDiff, topN int) string {
+	var sb strings.Builder
+	first, last := d.Snapshots[0].data, d.Snapshots[len(d.Snapshots)-1].data
+	sb.WriteString(fmt.Sprintf("# Memory Snapshot Diff — %s\n\n", mdCell(project)))
+	sb.WriteString(fmt.Sprintf("Generated %s from %d snapshots.\n\n", time.Now().Format("2006-01-02 15:04"), len(d.Snapshots)))
+
+	sb.WriteString("## Snapshots\n\n| # | Snapshot | Recorded | Platform | Native objects | Native size | Managed heap |\n|--:|----------|----------|----------|---------------:|------------:|-------------:|\n")
+	for i, s := range d.Snapshots {
+		sb.WriteString(fmt.Sprintf("| %d | `%s` | %s | %s | %d | %s | %s |\n", i+1, mdCell(s.name), mdCell(s.data.RecordDate), mdCell(s.data.Platform), s.data.NativeObjects, formatSize(s.data.NativeObjectBytes), formatSize(s.data.ManagedHeapBytes)))
+	}
+
+	sb.WriteString("\n## Totals\n\n| | First | Last | Change |\n|--|------:|-----:|-------:|\n")
+	sb.WriteString(fmt.Sprintf("| Native objects | %d | %d | %s |\n", first.NativeObjects, last.NativeObjects, formatCountDelta(last.NativeObjects-first.NativeObjects)))
+	sb.WriteString(fmt.Sprintf("| Native size | %s | %s | %s |\n", formatSize(first.NativeObjectBytes), formatSize(last.NativeObjectBytes), formatDelta(last.NativeObjectBytes-first.NativeObjectBytes)))
+	sb.WriteString(fmt.Sprintf("| Managed heap | %s | %s | %s |\n", formatSize(first.ManagedHeapBytes), formatSize(last.ManagedHeapBytes), formatDelta(last.ManagedHeapBytes-first.ManagedHeapBytes)))
+
+	sb.WriteString("\n## Leak Suspects\n\n")
+	switch {
+	case len(d.Snapshots) < 3:
+		sb.WriteString("Leaks are flagged with three or more snapshots; with two, check the growth below.\n")
+	case len(d.Leaks) == 0:
+		sb.WriteString("Nothing grew in every step.\n")
+	default:
+		writeMarkdownRows(&sb, topRows(d.Leaks, topN), true)
+	}
+
+	sb.WriteString("\n## By Type\n\n")
+	writeMarkdownRows(&sb, topRows(d.Types, topN), false)
+	sb.WriteString("\n## By Object\n\n")
+	writeMarkdownRows(&sb, topRows(d.Objects, topN), true)
+	return sb.String()
+}
+
+func writeMarkdownRows(sb *strings.Builder, rows []*deltaRow, objects bool) {
+	if len(rows) == 0 {
+		sb.WriteString("No changes.\n")
+		return
+	}
+	if objects {
+		sb.WriteString("| Type | Object | Count | Size | Size change |\n|------|--------|-------|------|------------:|\n")
+	} else {
+		sb.WriteString("| Type | Count | Size | Size change |\n|------|-------|------|------------:|\n")
+	}
+	for _, r := range rows {
+		typ := mdCell(r.Type)
+		if r.Leak {
+			typ = "**" + typ + "** (leak)"
+		}
+		if !objects {
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", typ, countSeries(r), sizeSeries(r), formatDelta(r.SizeDelta)))
+			continue
+		}
+		name := "all"
+		if r.Name != "" {
+			name = "`" + mdCell(r.Name) + "`"
+			if r.Asset {
+				name += " (asset)"
+			}
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n", typ, name, countSeries(r), sizeSeries(r), formatDelta(r.SizeDelta)))
+	}
+}
+
+// Name and Data give the HTML template the snapshot's title and export
+func (s *snapshot) Name() string          { return s.name }
+func (s *snapshot) Data() *snapshotExport { return s.data }
+
+// htmlData is what the HTML report shows
+type htmlData struct {
+	Project     string
+	GeneratedAt string
+	Snapshots   []*snapshot
+	First       *snapshotExport
+	Last        *snapshotExport
+	Leaks       []*deltaRow
+	Types       []*deltaRow
+	Objects     []*deltaRow
+	Series      bool // three or more snapshots
+}
+
+var htmlReport = template.Must(template.New("memory").Funcs(template.FuncMap{
+	"size":        func(v int64) string { return formatSize(v) },
+	"delta":       formatDelta,
+	"countDelta":  formatCountDelta,
+	"countSeries": countSeries,
+	"sizeSeries":  sizeSeries,
+	"inc":         func(i int) int { return i + 1 },
+	"sub":         func(a, b int64) int64 { return a - b },
+	"subInt":      func(a, b int) int { return a - b },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Memory Snapshot Diff — {{.Project}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; font-size: 14px; }
+th { background: #f0f0f0; }
+td.num { text-align: right; white-space: nowrap; }
+.grow { color: #cf222e; }
+.shrink { color: #1a7f37; }
+tr.leak td { background: #ffebe9; }
+.leak-tag { color: #cf222e; font-weight: bold; }
+.asset { color: #555; font-size: 12px; }
+</style>
+</head>
+<body>
+<h1>Memory Snapshot Diff — {{.Project}}</h1>
+<p>{{.GeneratedAt}} · {{len .Snapshots}} snapshots</p>
+<h2>Snapshots</h2>
+<table>
+<tr><th>#</th><th>Snapshot</th><th>Recorded</th><th>Platform</th><th>Native objects</th><th>Native size</th><th>Managed heap</th></tr>
+{{range $i, $s := .Snapshots}}<tr><td>{{inc $i}}</td><td>{{$s.Name}}</td><td>{{$s.Data.RecordDate}}</td><td>{{$s.Data.Platform}}</td><td class="num">{{$s.Data.NativeObjects}}</td><td class="num">{{size $s.Data.NativeObjectBytes}}</td><td class="num">{{size $s.Data.ManagedHeapBytes}}</td></tr>
+{{end}}</table>
+<h2>Totals</h2>
+<table>
+<tr><th></th><th>First</th><th>Last</th><th>Change</th></tr>
+<tr><td>Native objects</td><td class="num">{{.First.NativeObjects}}</td><td class="num">{{.Last.NativeObjects}}</td><td class="num">{{countDelta (subInt .Last.NativeObjects .First.NativeObjects)}}</td></tr>
+<tr><td>Native size</td><td class="num">{{size .First.NativeObjectBytes}}</td><td class="num">{{size .Last.NativeObjectBytes}}</td><td class="num">{{delta (sub .Last.NativeObjectBytes .First.NativeObjectBytes)}}</td></tr>
+<tr><td>Managed heap</td><td class="num">{{size .First.ManagedHeapBytes}}</td><td class="num">{{size .Last.ManagedHeapBytes}}</td><td class="num">{{delta (sub .Last.ManagedHeapBytes .First.ManagedHeapBytes)}}</td></tr>
+</table>
+<h2>Leak Suspects</h2>
+{{if not .Series}}<p>Leaks are flagged with three or more snapshots; with two, check the growth below.</p>
+{{else if not .Leaks}}<p>Nothing grew in every step.</p>
+{{else}}{{template "rows" .Leaks}}{{end}}
+<h2>By Type</h2>
+{{template "rows" .Types}}
+<h2>By Object</h2>
+{{template "rows" .Objects}}
+</body>
+</html>
+{{define "rows"}}{{if not .}}<p>No changes.</p>{{else}}<table>
+<tr><th>Type</th><th>Object</th><th>Count</th><th>Size</th><th>Count change</th><th>Size change</th></tr>
+{{range .}}<tr{{if .Leak}} class="leak"{{end}}>
+<td>{{.Type}}{{if .Leak}} <span class="leak-tag">leak</span>{{end}}</td>
+<td>{{if .Name}}{{.Name}}{{if .Asset}} <span class="asset">asset</span>{{end}}{{else}}all{{end}}</td>
+<td>{{countSeries .}}</td><td>{{sizeSeries .}}</td>
+<td class="num {{if gt .CountDelta 0}}grow{{else if lt .CountDelta 0}}shrink{{end}}">{{countDelta .CountDelta}}</td>
+<td class="num {{if gt .SizeDelta 0}}grow{{else if lt .SizeDelta 0}}shrink{{end}}">{{delta .SizeDelta}}</td>
+</tr>{{end}}
+</table>{{end}}{{end}}
+`))
+
+// writeReports writes report.md and report.html into outDir and returns their paths
+func writeReports(outDir, project string, d *seriesDiff, topN int, formats []string) ([]string, error) {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+	var written []string
+	if containsString(formats, "md") {
+		p := filepath.Join(outDir, "report.md")
+		if err := os.WriteFile(p, []byte(renderMarkdown(project, d, topN)), 0644); err != nil {
+			return written, err
+		}
+		written = append(written, p)
+	}
+	if containsString(formats, "html") {
+		p := filepath.Join(outDir, "report.html")
+		f, err := os.Create(p)
+		if err != nil {
+			return written, err
+		}
+		data := &htmlData{
+			Project:     project,
+			GeneratedAt: time.Now().Format("2006-01-02 15:04"),
+			Snapshots:   d.Snapshots,
+			First:       d.Snapshots[0].data,
+			Last:        d.Snapshots[len(d.Snapshots)-1].data,
+			Leaks:       topRows(d.Leaks, topN),
+			Types:       topRows(d.Types, topN),
+			Objects:     topRows(d.Objects, topN),
+			Series:      len(d.Snapshots) >= 3,
+		}
+		if err := htmlReport.Execute(f, data); err != nil {
+			f.Close()
+			return written, err
+		}
+		if err := f.Close(); err != nil {
+			return written, err
+		}
+		written = append(written, p)
+	}
+	return written, nil
+}
+
+// ============================================================
+// Installed Editors
+// ============================================================
+
+// hubEditorFolders returns the folders Unity Hub installs editors into: the
+// default location plus the custom one from secondaryInstallPath.json
+func hubEditorFolders() []string {
+	home, _ := os.UserHomeDir()
+	var folders []string
+	var hubConfig string
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramW6432"} {
+			if pf := os.Getenv(env); pf != "" {
+				folders = append(folders, filepath.Join(pf, "Unity", "Hub", "Editor"))
+			}
+		}
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			hubConfig = filepath.Join(appData, "UnityHub")
+		}
+	case "darwin":
+		folders = append(folders, "/Applications/Unity/Hub/Editor")
+		hubConfig = filepath.Join(home, "Library", "Application Support", "UnityHub")
+	default:
+		folders = append(folders, filepath.Join(home, "Unity", "Hub", "Editor"))
+		hubConfig = filepath.Join(home, ".config", "UnityHub")
+	}
+	if hubConfig != "" {
+		// The file holds a single JSON string; empty when no custom location is set
+		if data, err := os.ReadFile(filepath.Join(hubConfig, "secondaryInstallPath.json")); err == nil {
+			var custom string
+			if json.Unmarshal(data, &custom) == nil && custom != "" {
+				folders = append(folders, custom)
+			}
+		}
+	}
+	return folders
+}
+
+// editorExecutable is the Unity binary inside an editor version folder
+func editorExecutable(dir string) string {
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(dir, "Editor", "Unity.exe")
+	case "darwin":
+		return filepath.Join(dir, "Unity.app", "Contents", "MacOS", "Unity")
+	default:
+		return filepath.Join(dir, "Editor", "Unity")
+	}
+}
+
+// findUnity picks the editor: -unity, UNITYSTARTER_UNITY, then the Hub install
+// of the project's exact version. Another patch version is never picked
+// silently; it would upgrade the project.
+func findUnity(basePath, override string) (string, error) {
+	for _, candidate := range []string{override, os.Getenv(envUnityPath)} {
+		if candidate == "" {
+			continue
+		}
+		// A version folder works as well as the binary itself
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			candidate = editorExecutable(candidate)
+		}
+		if _, err := os.Stat(candidate); err != nil {
+			return "", fmt.Errorf("Unity editor not found: %s", candidate)
+		}
+		return candidate, nil
+	}
+	version := readUnityVersion(basePath)
+	if version == "" {
+		return "", errors.New("cannot read the Unity version from ProjectSettings/ProjectVersion.txt; pass -unity")
+	}
+	for _, folder := range hubEditorFolders() {
+		exe := editorExecutable(filepath.Join(folder, version))
+		if _, err := os.Stat(exe); err == nil {
+			return exe, nil
+		}
+	}
+	return "", fmt.Errorf("Unity %s is not installed in the Unity Hub folders; install it or pass -unity", version)
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+// parseSize accepts "64KB", "1.5MB" or plain bytes
+func parseSize(s string) (int64, error) {
+	m := sizeRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+	v, _ := strconv.ParseFloat(m[1], 64)
+	switch strings.ToLower(m[2]) {
+	case "kb":
+		v *= 1 << 10
+	case "mb":
+		v *= 1 << 20
+	case "gb":
+		v *= 1 << 30
+	case "tb":
+		v *= 1 << 40
+	}
+	return int64(v), nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...": "\n按回车键继续...",
+
+	"[WARNING] Removing a stale %s (%s)\n":                      "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n": "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+	" (top %d of %d)": "（前 %d 项，共 %d 项）",
+	"  (none)":        "  （无）",
+	"[ERROR] %v\n":    "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  MEMORY SNAPSHOT DIFF":                                           "  内存快照对比",
+	"  Project:   %s\n":                                                "  项目:     %s\n",
+	"  Snapshots: %d\n":                                                "  快照:     %d\n",
+	"[WARNING] %v\n":                                                   "[WARNING] %v\n",
+	"[WARNING] Exporting snapshots needs build hooks v%d or newer (installed: v%d); run: unity_build_hooks install\n": "[WARNING] 导出快照需要 v%d 或更高版本的构建钩子（已安装: v%d）；请运行: unity_build_hooks install\n",
+	"\n[Dry Run] Unity was not started.": "\n[Dry Run] 未启动 Unity。",
+	"\n[ERROR] Unity Editor is running (PID: %d). Close it before exporting snapshots; batch mode cannot open a project that is already open.\n": "\n[ERROR] Unity 编辑器正在运行 (PID: %d)。请先关闭再导出快照；批处理模式无法打开已被打开的项目。\n",
+	"\n[ERROR] %v\n": "\n[ERROR] %v\n",
+	"\nExporting %d snapshot(s) with %s ...\n": "\n正在使用 %[2]s 导出 %[1]d 个快照...\n",
+	"[FAIL] %v\n":      "[FAIL] %v\n",
+	"       Log: %s\n": "       日志: %s\n",
+	"[FAIL] %s: %s\n":  "[FAIL] %s: %s\n",
+	"[OK]   %s\n":      "[OK]   %s\n",
+	"Exported in %s\n": "导出耗时 %s\n",
+	"Log: %s\n":        "日志: %s\n",
+	"\n[Dry Run] Every snapshot is exported already; comparing.":   "\n[Dry Run] 所有快照均已导出；开始对比。",
+	"  %d. %-28s %s  %7d objects  native %-10s  managed heap %s\n": "  %d. %-28s %s  %7d 个对象  原生 %-10s  托管堆 %s\n",
+	"\n  Native objects: %d → %d (%s)\n":                           "\n  原生对象: %d → %d (%s)\n",
+	"  Native size:    %s → %s (%s)\n":                             "  原生大小: %s → %s (%s)\n",
+	"  Managed heap:   %s → %s (%s)\n":                             "  托管堆:   %s → %s (%s)\n",
+	"By type, size change first:":                                  "按类型（按大小变化排序）:",
+	"By object:":                                                   "按对象:",
+	"\n[--] Leaks are flagged with three or more snapshots; with two, check the growth above.": "\n[--] 需要三个或更多快照才能标记泄漏；只有两个时请查看上面的增长。",
+	"\n[OK] Nothing grew in every step.":                        "\n[OK] 没有在每一步都增长的对象。",
+	"[WARNING] Leak suspects, grew in every step (marked '!'):": "[WARNING] 疑似泄漏，每一步都在增长（标记为 '!'）:",
+	"[OK] Report: %s\n": "[OK] 报告: %s\n",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode     bool
+		dryRun     bool
+		force      bool
+		reexport   bool
+		failOnLeak bool
+		topN       int
+		minDeltaFl string
+		outFlag    string
+		formatFlag string
+		unityFlag  string
+		timeout    time.Duration
+	)
+
+	flag.IntVar(&topN, "top", 25, "Rows per table in the console and the reports (0 = all)")
+	flag.StringVar(&minDeltaFl, "min-delta", "0", "Leave out rows whose size changed by less than this (e.g. 64KB)")
+	flag.StringVar(&outFlag, "out", defaultOutDir, "Folder for the exported snapshots, report.md and report.html")
+	flag.StringVar(&formatFlag, "format", "md,html", "Reports to write: md, html, or none")
+	flag.BoolVar(&reexport, "reexport", false, "Export .snap files again even if their export is up to date")
+	flag.BoolVar(&failOnLeak, "fail-on-leak", false, "Exit with 1 when a type or object grew in every step")
+	flag.StringVar(&unityFlag, "unity", "", "Unity editor binary or version folder, for .snap files (default: UNITYSTARTER_UNITY, then the Hub install of the project's version)")
+	flag.DurationVar(&timeout, "timeout", 0, "Abort the Unity export after this long (e.g. 20m; default: none)")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Print the Unity command line for the snapshots that need exporting without running it")
+	flag.BoolVar(&force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the snapshots ("a.snap b.snap -ci")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_memory_diff")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	minDelta, err := parseSize(minDeltaFl)
+	if err != nil {
+		fail(2, "-min-delta: %v", err)
+	}
+	var formats []string
+	for _, f := range strings.Split(strings.ToLower(formatFlag), ",") {
+		switch f = strings.TrimSpace(f); f {
+		case "":
+		case "none":
+			formats = nil
+		case "md", "markdown":
+			formats = append(formats, "md")
+		case "html":
+			formats = append(formats, "html")
+		default:
+			fail(2, "unknown report format '%s' (use md, html, none)", f)
+		}
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	outDir := outFlag
+	if !filepath.IsAbs(outDir) {
+		outDir = filepath.Join(basePath, filepath.FromSlash(outDir))
+	}
+
+	if len(args) == 0 {
+		if _, err := os.Stat(filepath.Join(basePath, defaultSnapshotDir)); err != nil {
+			fail(2, "no snapshots given and %s/ does not exist", defaultSnapshotDir)
+		}
+		args = []string{defaultSnapshotDir}
+	}
+	snapshots, err := findSnapshots(basePath, args)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+	if len(snapshots) < 2 {
+		fail(2, "need at least two snapshots to compare, found %d", len(snapshots))
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  MEMORY SNAPSHOT DIFF"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:   %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Snapshots: %d\n"), len(snapshots))
+
+	pending := planExports(snapshots, outDir, reexport)
+	if len(pending) > 0 {
+		unity, err := findUnity(basePath, unityFlag)
+		if dryRun {
+			if err != nil {
+				// The dry run still shows the command line
+				fmt.Printf(tr("[WARNING] %v\n"), err)
+				unity = "Unity"
+			}
+			if hooks := installedHooksVersion(basePath); hooks < exportHooksMin {
+				fmt.Printf(tr("[WARNING] Exporting snapshots needs build hooks v%d or newer (installed: v%d); run: unity_build_hooks install\n"), exportHooksMin, hooks)
+			}
+			exportArgs, env := exportCommand(basePath, pending)
+			fmt.Printf("\n  %s %s\n", unity, strings.Join(exportArgs, " "))
+			for _, e := range env {
+				fmt.Printf("  %s\n", e)
+			}
+			for _, s := range pending {
+				recordAction("export", s.rel, "planned", "", 0)
+			}
+			fmt.Println(tr("\n[Dry Run] Unity was not started."))
+			exit(0)
+		}
+		if err != nil {
+			fail(1, "%v", err)
+		}
+		if hooks := installedHooksVersion(basePath); hooks < exportHooksMin {
+			fail(1, "exporting snapshots needs build hooks v%d or newer (installed: v%d); run: unity_build_hooks install", exportHooksMin, hooks)
+		}
+		if isRunning, pid := checkUnityRunning(basePath); isRunning {
+			fmt.Printf(tr("\n[ERROR] Unity Editor is running (PID: %d). Close it before exporting snapshots; batch mode cannot open a project that is already open.\n"), pid)
+			recordError("Unity Editor is running (PID: %d). Close it before exporting snapshots", pid)
+			exit(1)
+		}
+		if err := acquireProjectLock(basePath, "unity_memory_diff", "export", force); err != nil {
+			fmt.Printf(tr("\n[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exit(1)
+		}
+		fmt.Printf(tr("\nExporting %d snapshot(s) with %s ...\n"), len(pending), unity)
+		start := time.Now()
+		report, err := runExport(basePath, unity, pending, timeout)
+		releaseProjectLock()
+		logPath := filepath.Join(basePath, filepath.FromSlash(exportLogRel))
+		if err != nil {
+			fmt.Printf(tr("[FAIL] %v\n"), err)
+			for _, line := range logExcerpt(logPath, 20) {
+				fmt.Printf("       %s\n", line)
+			}
+			fmt.Printf(tr("       Log: %s\n"), logPath)
+			recordError("%v", err)
+			exit(3)
+		}
+		recordArtifact(logPath)
+		failed := 0
+		failedExports := make(map[string]string)
+		for _, r := range report.Snapshots {
+			if r.Error != "" {
+				failedExports[filepath.Clean(r.Snapshot)] = r.Error
+			}
+		}
+		for _, s := range pending {
+			if msg, ok := failedExports[filepath.Clean(s.path)]; ok {
+				failed++
+				fmt.Printf(tr("[FAIL] %s: %s\n"), s.rel, msg)
+				recordAction("export", s.rel, "failed", msg, 0)
+				recordError("%s: %s", s.rel, msg)
+				continue
+			}
+			fmt.Printf(tr("[OK]   %s\n"), s.rel)
+			recordAction("export", s.rel, "ok", "", 0)
+		}
+		fmt.Printf(tr("Exported in %s\n"), time.Since(start).Round(time.Second))
+		if failed > 0 {
+			fmt.Printf(tr("Log: %s\n"), logPath)
+			exit(3)
+		}
+	}
+
+	for _, s := range snapshots {
+		data, err := loadExport(s.jsonPath)
+		if err != nil {
+			fail(1, "%s: %v", s.rel, err)
+		}
+		s.data = data
+	}
+	if dryRun {
+		fmt.Println(tr("\n[Dry Run] Every snapshot is exported already; comparing."))
+	}
+
+	fmt.Println()
+	for i, s := range snapshots {
+		recorded := s.data.RecordDate
+		if recorded == "" {
+			recorded = "-"
+		}
+		fmt.Printf(tr("  %d. %-28s %s  %7d objects  native %-10s  managed heap %s\n"), i+1, s.name, recorded, s.data.NativeObjects, formatSize(s.data.NativeObjectBytes), formatSize(s.data.ManagedHeapBytes))
+		recordAction("snapshot", s.rel, "ok", fmt.Sprintf("%d native objects, %s native, %s managed heap", s.data.NativeObjects, formatSize(s.data.NativeObjectBytes), formatSize(s.data.ManagedHeapBytes)), 0)
+	}
+
+	d := diffSeries(snapshots, minDelta)
+	first, last := snapshots[0].data, snapshots[len(snapshots)-1].data
+	fmt.Printf(tr("\n  Native objects: %d → %d (%s)\n"), first.NativeObjects, last.NativeObjects, formatCountDelta(last.NativeObjects-first.NativeObjects))
+	fmt.Printf(tr("  Native size:    %s → %s (%s)\n"), formatSize(first.NativeObjectBytes), formatSize(last.NativeObjectBytes), formatDelta(last.NativeObjectBytes-first.NativeObjectBytes))
+	fmt.Printf(tr("  Managed heap:   %s → %s (%s)\n"), formatSize(first.ManagedHeapBytes), formatSize(last.ManagedHeapBytes), formatDelta(last.ManagedHeapBytes-first.ManagedHeapBytes))
+
+	printRows(tr("By type, size change first:"), topRows(d.Types, topN), len(d.Types))
+	printRows(tr("By object:"), topRows(d.Objects, topN), len(d.Objects))
+	if len(snapshots) < 3 {
+		fmt.Println(tr("\n[--] Leaks are flagged with three or more snapshots; with two, check the growth above."))
+	} else if len(d.Leaks) == 0 {
+		fmt.Println(tr("\n[OK] Nothing grew in every step."))
+	} else {
+		printRows(tr("[WARNING] Leak suspects, grew in every step (marked '!'):"), topRows(d.Leaks, topN), len(d.Leaks))
+	}
+	for _, r := range d.Leaks {
+		recordAction("leak", rowLabel(r), "failed", fmt.Sprintf("count %s, size %s", countSeries(r), sizeSeries(r)), 0)
+	}
+
+	if len(formats) > 0 {
+		written, err := writeReports(outDir, filepath.Base(basePath), d, topN, formats)
+		for _, p := range written {
+			recordArtifact(p)
+		}
+		if err != nil {
+			fail(1, "cannot write the report: %v", err)
+		}
+		fmt.Println()
+		for _, p := range written {
+			fmt.Printf(tr("[OK] Report: %s\n"), p)
+		}
+	}
+
+	if failOnLeak && len(d.Leaks) > 0 {
+		recordError("%d leak suspect(s)", len(d.Leaks))
+		exit(1)
+	}
+	exit(0)
+}
@@ -0,0 +1,1907 @@
+// Unity Nightly — Run the project's maintenance tools in one unattended pass and report once.
+// Chains the maintenance tools of this folder on one project: a deep clean that
+// only runs once the project has sat unopened for a while, the player build
+// cache prune, the package update check, the asset health check and the file
+// tree, followed by a disk report of what the run freed. Each tool runs with
+// -json, its console output goes to a log file, and the results are gathered
+// into one Markdown summary that is posted to the webhooks of .unitystarter.json
+// and mailed through SMTP, instead of one message per tool. The task list,
+// email and report folder come from "nightly" in .unitystarter.json; without it
+// the default list runs. Meant for cron or the Task Scheduler on shared
+// workstations and build agents.
+//
+// Build: go build unity_nightly.go (the other tools are looked up next to it, then on PATH)
+//
+// Usage: run from the Unity project root.
+//
+//	unity_nightly                                   # every task
+//	unity_nightly -tasks clean,disk-report          # some of them
+//	unity_nightly -list                             # show the tasks and when they last ran
+//	unity_nightly -dry-run                          # show the commands without running them
+//	unity_nightly install -at 02:30                 # run every night: systemd timer, launchd agent or scheduled task
+//	unity_nightly status                            # last and next run, tail of service.log
+//	unity_nightly start | stop | uninstall
+//
+// .unitystarter.json:
+//
+//	{"nightly": {"tasks": [{"name": "clean", "tool": "unity_project_full_clean", "args": ["-no-notify"], "ifIdle": "14d"},
+//	                       {"name": "cache-prune", "tool": "il2cpp_cache_manager", "args": ["-prune", "-max-size", "30GB"]},
+//	                       {"name": "disk-report", "tool": "disk-report"}],
+//	             "email": {"smtp": "smtp.example.com:587", "from": "nightly@example.com", "to": ["team@example.com"],
+//	                       "username": "nightly@example.com", "password": "${NIGHTLY_SMTP_PASSWORD}", "on": ["failure"]}}}
+//
+// Without install, cron works as well:
+//
+//	30 2 * * * cd /work/MyGame && /opt/unitystarter/unity_nightly -ci
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	sharedConfigFileName = ".unitystarter.json"
+	sharedConfigEnvVar   = "UNITYSTARTER_CONFIG"
+)
+
+// Reports and task logs go to <reportDir>/<run>/, which no clean removes
+const (
+	defaultReportDir   = "NightlyReports"
+	defaultKeepReports = 14
+	stateFileName      = "state.json" // when each task last succeeded, for "every"
+	summaryFileName    = "summary.md"
+)
+
+const defaultTaskTimeout = 2 * time.Hour
+
+// Last lines of a failed task's log attached to the notification
+const logTailLines = 20
+
+// diskReportTool is the built-in task that compares disk use with the start of the run
+const diskReportTool = "disk-report"
+
+// The tasks run when .unitystarter.json has no "nightly" list. The clean only
+// runs when nobody opened the project for two weeks, so active projects keep
+// their Library; the cleaner's own webhook message is turned off, the nightly
+// summary reports it.
+var defaultTasks = []nightlyTask{
+	{Name: "clean", Tool: "unity_project_full_clean", Args: []string{"-no-notify"}, IfIdle: "14d"},
+	{Name: "cache-prune", Tool: "il2cpp_cache_manager", Args: []string{"-prune", "-max-age", "14d"}},
+	{Name: "update-check", Tool: "unity_package_mirror", Args: []string{"-check-updates"}},
+	{Name: "doctor", Tool: "unity_asset_integrity"},
+	{Name: "file-tree", Tool: "generate_file_tree"},
+	{Name: "disk-report", Tool: diskReportTool},
+}
+
+// Files the editor rewrites while the project is open; the newest of them
+// tells when the project was last used
+var editorActivityFiles = []string{
+	"Library/ArtifactDB",
+	"Library/SourceAssetDB",
+	"Library/EditorUserBuildSettings.asset",
+	"Library/LastSceneManagerSetup.txt",
+	"Library/CurrentLayout-default.dwlt",
+}
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// nightlyConfig is the "nightly" object of .unitystarter.json
+type nightlyConfig struct {
+	Tasks       []nightlyTask `json:"tasks"`
+	ReportDir   string        `json:"reportDir"`   // relative to the project root or absolute
+	KeepReports int           `json:"keepReports"` // runs kept in reportDir
+	Email       *emailConfig  `json:"email"`
+}
+
+// nightlyTask is one step of the run
+type nightlyTask struct {
+	Name     string   `json:"name"`
+	Tool     string   `json:"tool"`    // a tool of this folder, a path, or "disk-report"
+	Args     []string `json:"args"`    // -json is added
+	Every    string   `json:"every"`   // run at most once per period, e.g. "7d"
+	IfIdle   string   `json:"ifIdle"`  // run only when the project was not opened for this long
+	Timeout  string   `json:"timeout"` // default 2h
+	Disabled bool     `json:"disabled"`
+}
+
+// emailConfig sends the summary through an SMTP server with STARTTLS (port 587
+// or 25). The password may use ${VAR}, so the secret stays out of the file.
+type emailConfig struct {
+	SMTP     string   `json:"smtp"` // host:port
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	On       []string `json:"on"` // success, failure (default: both)
+}
+
+// nightlyState is state.json in the report folder
+type nightlyState struct {
+	LastSuccess map[string]string `json:"lastSuccess"` // task name -> RFC3339
+}
+
+type taskResult struct {
+	task     nightlyTask
+	status   string // ok, failed, skipped
+	detail   string
+	duration time.Duration
+	logPath  string
+}
+
+// diskSnapshot is the disk use of the project at one moment
+type diskSnapshot struct {
+	folders map[string]int64 // top-level entries of the project root
+	free    int64            // free space on the volume, -1 when unknown
+}
+
+// ============================================================
+// Configuration Loading
+// ============================================================
+
+// loadNightlyConfig reads UNITYSTARTER_CONFIG, or else the first .unitystarter.json
+// found from the project root upward. Returns the defaults when none exists or
+// it has no "nightly" object.
+func loadNightlyConfig(projectRoot string) (*nightlyConfig, string, error) {
+	cfg := &nightlyConfig{}
+	path := os.Getenv(sharedConfigEnvVar)
+	if path == "" {
+		abs, err := filepath.Abs(projectRoot)
+		if err != nil {
+			return withDefaults(cfg), "", nil
+		}
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			candidate := filepath.Join(dir, sharedConfigFileName)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				return withDefaults(cfg), "", nil
+			}
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, path, err
+	}
+	var file struct {
+		Nightly *nightlyConfig `json:"nightly"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, path, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	if file.Nightly != nil {
+		cfg = file.Nightly
+	}
+	return withDefaults(cfg), path, validateConfig(cfg)
+}
+
+func withDefaults(cfg *nightlyConfig) *nightlyConfig {
+	if len(cfg.Tasks) == 0 {
+		cfg.Tasks = append([]nightlyTask{}, defaultTasks...)
+	}
+	if cfg.ReportDir == "" {
+		cfg.ReportDir = defaultReportDir
+	}
+	if cfg.KeepReports <= 0 {
+		cfg.KeepReports = defaultKeepReports
+	}
+	return cfg
+}
+
+// validateConfig rejects task lists that would fail halfway through the night
+func validateConfig(cfg *nightlyConfig) error {
+	seen := make(map[string]bool)
+	for i, t := range cfg.Tasks {
+		if t.Name == "" {
+			return fmt.Errorf("nightly task %d has no name", i+1)
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("nightly task '%s' is listed twice", t.Name)
+		}
+		seen[t.Name] = true
+		if t.Tool == "" {
+			return fmt.Errorf("nightly task '%s' has no tool", t.Name)
+		}
+		for _, v := range []struct{ key, value string }{{"every", t.Every}, {"ifIdle", t.IfIdle}, {"timeout", t.Timeout}} {
+			if _, err := parseAge(v.value); err != nil {
+				return fmt.Errorf("nightly task '%s': %s: %v", t.Name, v.key, err)
+			}
+		}
+	}
+	if e := cfg.Email; e != nil {
+		if _, _, err := net.SplitHostPort(e.SMTP); err != nil {
+			return fmt.Errorf("nightly email: smtp must be host:port (got '%s')", e.SMTP)
+		}
+		if e.From == "" || len(e.To) == 0 {
+			return fmt.Errorf("nightly email needs from and to")
+		}
+	}
+	return nil
+}
+
+// ============================================================
+// State
+// ============================================================
+
+func loadState(reportDir string) *nightlyState {
+	state := &nightlyState{LastSuccess: make(map[string]string)}
+	if data, err := os.ReadFile(filepath.Join(reportDir, stateFileName)); err == nil {
+		json.Unmarshal(data, state)
+		if state.LastSuccess == nil {
+			state.LastSuccess = make(map[string]string)
+		}
+	}
+	return state
+}
+
+func saveState(reportDir string, state *nightlyState) error {
+	data, _ := json.MarshalIndent(state, "", "  ")
+	return os.WriteFile(filepath.Join(reportDir, stateFileName), append(data, '\n'), 0644)
+}
+
+// lastUsed returns when the editor last wrote to the project's Library, or the
+// zero time when there is no Library
+func lastUsed(basePath string) time.Time {
+	var newest time.Time
+	for _, rel := range editorActivityFiles {
+		if info, err := os.Stat(filepath.Join(basePath, filepath.FromSlash(rel))); err == nil && info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	return newest
+}
+
+// skipReason explains why a task does not run tonight, or returns ""
+func skipReason(basePath string, t nightlyTask, state *nightlyState, now time.Time) string {
+	if t.Disabled {
+		return "disabled"
+	}
+	if every, _ := parseAge(t.Every); every > 0 {
+		if last, err := time.Parse(time.RFC3339, state.LastSuccess[t.Name]); err == nil && now.Sub(last) < every {
+			return fmt.Sprintf("ran %s ago, every %s", formatAge(last), t.Every)
+		}
+	}
+	if idle, _ := parseAge(t.IfIdle); idle > 0 {
+		if used := lastUsed(basePath); !used.IsZero() && now.Sub(used) < idle {
+			if _, err := os.Stat(filepath.Join(basePath, "Temp")); err == nil {
+				return "the project is open in the editor"
+			}
+			return fmt.Sprintf("project opened %s ago, ifIdle %s", formatAge(used), t.IfIdle)
+		}
+	}
+	return ""
+}
+
+// ============================================================
+// Task Runner
+// ============================================================
+
+// resolveTool finds a tool next to this executable, then on PATH. A path is
+// used as it is.
+func resolveTool(name string) (string, error) {
+	if strings.ContainsAny(name, `/\`) {
+		return filepath.Abs(name)
+	}
+	file := name
+	if runtime.GOOS == "windows" && !strings.HasSuffix(strings.ToLower(file), ".exe") {
+		file += ".exe"
+	}
+	if exe, err := os.Executable(); err == nil {
+		candidate := filepath.Join(filepath.Dir(exe), file)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	p, err := exec.LookPath(file)
+	if err != nil {
+		return "", fmt.Errorf("%s not found next to unity_nightly or on PATH", file)
+	}
+	return p, nil
+}
+
+// runTask runs one tool with -json. Its console output (stderr under -json) goes
+// to the task's log file; its result document is read from stdout.
+func runTask(basePath, runDir string, t nightlyTask) taskResult {
+	result := taskResult{task: t}
+	exe, err := resolveTool(t.Tool)
+	if err != nil {
+		result.status, result.detail = "failed", err.Error()
+		recordError("%s: %v", t.Name, err)
+		return result
+	}
+	timeout, _ := parseAge(t.Timeout)
+	if timeout <= 0 {
+		timeout = defaultTaskTimeout
+	}
+
+	logPath := filepath.Join(runDir, t.Name+".log")
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		result.status, result.detail = "failed", err.Error()
+		recordError("%s: %v", t.Name, err)
+		return result
+	}
+	result.logPath = logPath
+	defer logFile.Close()
+	args := append(append([]string{}, t.Args...), "-json")
+	fmt.Fprintf(logFile, "$ %s %s\n\n", exe, strings.Join(args, " "))
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, exe, args...)
+	cmd.Dir = basePath
+	cmd.Stdout = &stdout
+	cmd.Stderr = logFile
+	// Progress bars would fill the log with carriage returns
+	cmd.Env = append(os.Environ(), "UNITYSTARTER_PLAIN=1")
+
+	start := time.Now()
+	runErr := cmd.Run()
+	result.duration = time.Since(start)
+
+	var doc jsonResult
+	parsed := json.Unmarshal(stdout.Bytes(), &doc) == nil && doc.Tool != ""
+	if parsed {
+		for _, e := range doc.Errors {
+			recordError("%s: %s", t.Name, e)
+		}
+		for _, a := range doc.Artifacts {
+			recordArtifact(a)
+		}
+	}
+
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		result.status, result.detail = "failed", fmt.Sprintf("timed out after %s", timeout)
+	case runErr != nil:
+		result.status = "failed"
+		result.detail = runErr.Error()
+		if parsed {
+			result.detail = fmt.Sprintf("exit code %d, %s", doc.ExitCode, countSummary(doc.Actions))
+		}
+	default:
+		result.status = "ok"
+		if parsed {
+			result.detail = countSummary(doc.Actions)
+		}
+	}
+	if result.status == "failed" && !parsed {
+		recordError("%s: %s", t.Name, result.detail)
+	}
+	return result
+}
+
+// countSummary renders "3 ok, 1 failed" for the actions of a result document
+func countSummary(actions []jsonAction) string {
+	counts := make(map[string]int)
+	for _, a := range actions {
+		counts[a.Status]++
+	}
+	var parts []string
+	for _, status := range []string{"ok", "failed", "skipped", "planned"} {
+		if counts[status] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	if len(parts) == 0 {
+		return "nothing to do"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// logTail returns the last lines of a task log
+func logTail(path string, n int) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// ============================================================
+// Disk Report
+// ============================================================
+
+// takeSnapshot measures every top-level entry of the project root and the free
+// space of its volume. Folders are measured in parallel; Library is the slow one.
+func takeSnapshot(basePath string) diskSnapshot {
+	snap := diskSnapshot{folders: make(map[string]int64), free: -1}
+	entries, _ := os.ReadDir(basePath)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, e := range entries {
+		wg.Add(1)
+		go func(e os.DirEntry) {
+			defer wg.Done()
+			size := treeSize(filepath.Join(basePath, e.Name()))
+			mu.Lock()
+			snap.folders[e.Name()] = size
+			mu.Unlock()
+		}(e)
+	}
+	wg.Wait()
+	if free, err := freeSpace(basePath); err == nil {
+		snap.free = free
+	}
+	return snap
+}
+
+func treeSize(root string) int64 {
+	var total int64
+	filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// freeSpace asks the system for the free space of the volume holding path
+func freeSpace(p string) (int64, error) {
+	if runtime.GOOS == "windows" {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return 0, err
+		}
+		drive := strings.TrimSuffix(filepath.VolumeName(abs), ":")
+		if len(drive) != 1 {
+			return 0, fmt.Errorf("no drive letter in %s", abs)
+		}
+		out, err := exec.Command("powershell", "-NoProfile", "-Command", "(Get-PSDrive -Name "+drive+").Free").Output()
+		if err != nil {
+			return 0, err
+		}
+		return strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	}
+	out, err := exec.Command("df", "-Pk", p).Output()
+	if err != nil {
+		return 0, err
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 4 {
+		return 0, fmt.Errorf("unexpected df output")
+	}
+	kb, err := strconv.ParseInt(fields[3], 10, 64)
+	return kb * 1024, err
+}
+
+// diskRow is one line of the disk report
+type diskRow struct {
+	name          string
+	before, after int64
+}
+
+// diskRows lists the folders that changed or hold at least 1 MB, largest first
+func diskRows(before, after diskSnapshot) []diskRow {
+	names := make(map[string]bool)
+	for name := range before.folders {
+		names[name] = true
+	}
+	for name := range after.folders {
+		names[name] = true
+	}
+	var rows []diskRow
+	for name := range names {
+		r := diskRow{name, before.folders[name], after.folders[name]}
+		if r.before != r.after || r.after >= 1<<20 {
+			rows = append(rows, r)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].after != rows[j].after {
+			return rows[i].after > rows[j].after
+		}
+		return rows[i].name < rows[j].name
+	})
+	return rows
+}
+
+func totalSize(s diskSnapshot) int64 {
+	var total int64
+	for _, size := range s.folders {
+		total += size
+	}
+	return total
+}
+
+// formatChange renders a size difference as "-1.20 GB" / "+3.00 MB" / "—"
+func formatChange(delta int64) string {
+	switch {
+	case delta < 0:
+		return "-" + formatSize(-delta)
+	case delta > 0:
+		return "+" + formatSize(delta)
+	}
+	return "—"
+}
+
+// ============================================================
+// Summary
+// ============================================================
+
+// writeSummary writes the Markdown summary that is also the email body
+func writeSummary(p, project string, start time.Time, results []taskResult, before diskSnapshot, after *diskSnapshot) (string, error) {
+	host, _ := os.Hostname()
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Nightly Maintenance — %s\n\n", project)
+	fmt.Fprintf(&b, "%s on %s, %s\n\n", start.Format("2006-01-02 15:04"), host, time.Since(start).Round(time.Second))
+	b.WriteString("| Task | Status | Time | Detail |\n")
+	b.WriteString("| ---- | ------ | ---- | ------ |\n")
+	for _, r := range results {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", r.task.Name, r.status, r.duration.Round(time.Second), strings.ReplaceAll(r.detail, "|", "\\|"))
+	}
+
+	jsonMu.Lock()
+	errs := append([]string{}, jsonDoc.Errors...)
+	artifacts := append([]string{}, jsonDoc.Artifacts...)
+	jsonMu.Unlock()
+	if len(errs) > 0 {
+		b.WriteString("\n## Errors\n\n")
+		for _, e := range errs {
+			fmt.Fprintf(&b, "- %s\n", e)
+		}
+	}
+	if after != nil {
+		b.WriteString("\n## Disk\n\n")
+		b.WriteString("| Folder | Before | After | Change |\n")
+		b.WriteString("| ------ | ------ | ----- | ------ |\n")
+		for _, r := range diskRows(before, *after) {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", r.name, formatSize(r.before), formatSize(r.after), formatChange(r.after-r.before))
+		}
+		fmt.Fprintf(&b, "| **Project** | %s | %s | %s |\n", formatSize(totalSize(before)), formatSize(totalSize(*after)), formatChange(totalSize(*after)-totalSize(before)))
+		if before.free >= 0 && after.free >= 0 {
+			fmt.Fprintf(&b, "\nFree space on the volume: %s → %s\n", formatSize(before.free), formatSize(after.free))
+		}
+	}
+	if len(artifacts) > 0 {
+		b.WriteString("\n## Artifacts\n\n")
+		for _, a := range artifacts {
+			fmt.Fprintf(&b, "- %s\n", a)
+		}
+	}
+	text := b.String()
+	return text, os.WriteFile(p, []byte(text), 0644)
+}
+
+// pruneReports keeps the newest keep run folders. Run folders are named by
+// their start time, so name order is age order.
+func pruneReports(reportDir string, keep int) {
+	entries, err := os.ReadDir(reportDir)
+	if err != nil {
+		return
+	}
+	var runs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			runs = append(runs, e.Name())
+		}
+	}
+	sort.Strings(runs)
+	for i := 0; i < len(runs)-keep; i++ {
+		os.RemoveAll(filepath.Join(reportDir, runs[i]))
+	}
+}
+
+// ============================================================
+// Email
+// ============================================================
+
+// sendEmail mails the summary as plain text; smtp.SendMail switches to TLS
+// when the server offers STARTTLS
+func sendEmail(cfg *emailConfig, success bool, project, body string) error {
+	event := "success"
+	if !success {
+		event = "failure"
+	}
+	if len(cfg.On) > 0 && !containsFold(cfg.On, event) {
+		return nil
+	}
+	host, _, _ := net.SplitHostPort(cfg.SMTP)
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, os.ExpandEnv(cfg.Password), host)
+	}
+	machine, _ := os.Hostname()
+	subject := fmt.Sprintf("Nightly maintenance succeeded: %s on %s", project, machine)
+	if !success {
+		subject = fmt.Sprintf("Nightly maintenance FAILED: %s on %s", project, machine)
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return smtp.SendMail(cfg.SMTP, auth, cfg.From, cfg.To, []byte(msg.String()))
+}
+
+// ============================================================
+// Scheduled Service
+// ============================================================
+
+// install registers the nightly run with the system scheduler of the platform:
+// a systemd user timer on Linux, a launchd agent on macOS and a Task Scheduler
+// task on Windows. A Windows service would have to stay resident and answer the
+// service control manager; a scheduled task starts the run once a day like the
+// timer and the agent do. Each project gets its own entry, named after it, and
+// the run's console output is appended to service.log in the report folder.
+const (
+	serviceLogFile     = "service.log"
+	serviceWrapperFile = "nightly-service.cmd" // Windows: keeps /TR under its 261 characters
+	defaultServiceTime = "02:30"
+	serviceLogTail     = 15 // lines of service.log shown by status
+)
+
+// serviceSpec is everything the scheduler entry is built from
+type serviceSpec struct {
+	name      string // systemd unit / launchd label / task name
+	exe       string
+	project   string
+	logPath   string
+	hour      int
+	minute    int
+	unitDir   string // where the unit files or the plist go
+	reportDir string
+}
+
+// serviceSlug turns a project folder name into something every scheduler accepts
+func serviceSlug(project string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(project) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else if b.Len() > 0 && !strings.HasSuffix(b.String(), "-") {
+			b.WriteByte('-')
+		}
+	}
+	if s := strings.Trim(b.String(), "-"); s != "" {
+		return s
+	}
+	return "project"
+}
+
+func newServiceSpec(basePath, reportDir, at string) (*serviceSpec, error) {
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -at '%s' (use HH:MM, e.g. 02:30)", at)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	if abs, err := filepath.Abs(exe); err == nil {
+		exe = abs
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	spec := &serviceSpec{exe: exe, project: basePath, logPath: filepath.Join(reportDir, serviceLogFile),
+		hour: t.Hour(), minute: t.Minute(), reportDir: reportDir}
+	slug := serviceSlug(filepath.Base(basePath))
+	switch runtime.GOOS {
+	case "linux":
+		spec.name = "unitystarter-nightly-" + slug
+		spec.unitDir = filepath.Join(home, ".config", "systemd", "user")
+	case "darwin":
+		spec.name = "com.unitystarter.nightly." + slug
+		spec.unitDir = filepath.Join(home, "Library", "LaunchAgents")
+	case "windows":
+		spec.name = `UnityStarter\Nightly ` + slug
+	default:
+		return nil, fmt.Errorf("no scheduler support on %s; run unity_nightly from cron", runtime.GOOS)
+	}
+	return spec, nil
+}
+
+// serviceFiles returns the files install writes, by path
+func serviceFiles(s *serviceSpec) map[string]string {
+	switch runtime.GOOS {
+	case "linux":
+		quote := func(p string) string { return `"` + strings.ReplaceAll(p, `"`, `\"`) + `"` }
+		service := fmt.Sprintf(`[Unit]
+Description=UnityStarter nightly maintenance for %s
+
+[Service]
+Type=oneshot
+WorkingDirectory=%s
+ExecStart=%s -ci -plain
+StandardOutput=append:%s
+StandardError=append:%s
+`, filepath.Base(s.project), s.project, quote(s.exe), s.logPath, s.logPath)
+		timer := fmt.Sprintf(`[Unit]
+Description=Run UnityStarter nightly maintenance for %s every day
+
+[Timer]
+OnCalendar=*-*-* %02d:%02d:00
+Persistent=true
+
+[Install]
+WantedBy=timers.target
+`, filepath.Base(s.project), s.hour, s.minute)
+		return map[string]string{
+			filepath.Join(s.unitDir, s.name+".service"): service,
+			filepath.Join(s.unitDir, s.name+".timer"):   timer,
+		}
+	case "darwin":
+		esc := func(v string) string {
+			var b bytes.Buffer
+			xml.EscapeText(&b, []byte(v))
+			return b.String()
+		}
+		plist := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>%s</string>
+		<string>-ci</string>
+		<string>-plain</string>
+	</array>
+	<key>WorkingDirectory</key>
+	<string>%s</string>
+	<key>StartCalendarInterval</key>
+	<dict>
+		<key>Hour</key>
+		<integer>%d</integer>
+		<key>Minute</key>
+		<integer>%d</integer>
+	</dict>
+	<key>StandardOutPath</key>
+	<string>%s</string>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+</dict>
+</plist>
+`, esc(s.name), esc(s.exe), esc(s.project), s.hour, s.minute, esc(s.logPath), esc(s.logPath))
+		return map[string]string{filepath.Join(s.unitDir, s.name+".plist"): plist}
+	case "windows":
+		wrapper := fmt.Sprintf("@echo off\r\ncd /d \"%s\"\r\n\"%s\" -ci -plain >> \"%s\" 2>&1\r\n", s.project, s.exe, s.logPath)
+		return map[string]string{filepath.Join(s.reportDir, serviceWrapperFile): wrapper}
+	}
+	return nil
+}
+
+// serviceCommands returns the scheduler commands of one action
+func serviceCommands(s *serviceSpec, action string) [][]string {
+	domain := fmt.Sprintf("gui/%d", os.Getuid())
+	plist := filepath.Join(s.unitDir, s.name+".plist")
+	switch runtime.GOOS + "/" + action {
+	case "linux/install":
+		return [][]string{{"systemctl", "--user", "daemon-reload"}, {"systemctl", "--user", "enable", "--now", s.name + ".timer"}}
+	case "linux/uninstall":
+		return [][]string{{"systemctl", "--user", "disable", "--now", s.name + ".timer"}}
+	case "linux/start":
+		return [][]string{{"systemctl", "--user", "start", "--no-block", s.name + ".service"}}
+	case "linux/stop":
+		return [][]string{{"systemctl", "--user", "stop", s.name + ".service"}}
+	case "linux/status":
+		return [][]string{
+			{"systemctl", "--user", "show", s.name + ".timer", "-p", "ActiveState,LastTriggerUSec,NextElapseUSecRealtime"},
+			{"systemctl", "--user", "show", s.name + ".service", "-p", "ActiveState,Result,ExecMainStatus"},
+		}
+	case "darwin/install":
+		return [][]string{{"launchctl", "bootstrap", domain, plist}}
+	case "darwin/uninstall":
+		return [][]string{{"launchctl", "bootout", domain + "/" + s.name}}
+	case "darwin/start":
+		return [][]string{{"launchctl", "kickstart", domain + "/" + s.name}}
+	case "darwin/stop":
+		return [][]string{{"launchctl", "kill", "SIGTERM", domain + "/" + s.name}}
+	case "darwin/status":
+		return [][]string{{"launchctl", "print", domain + "/" + s.name}}
+	case "windows/install":
+		at := fmt.Sprintf("%02d:%02d", s.hour, s.minute)
+		return [][]string{{"schtasks", "/Create", "/F", "/SC", "DAILY", "/ST", at, "/TN", s.name, "/TR", filepath.Join(s.reportDir, serviceWrapperFile)}}
+	case "windows/uninstall":
+		return [][]string{{"schtasks", "/Delete", "/F", "/TN", s.name}}
+	case "windows/start":
+		return [][]string{{"schtasks", "/Run", "/TN", s.name}}
+	case "windows/stop":
+		return [][]string{{"schtasks", "/End", "/TN", s.name}}
+	case "windows/status":
+		return [][]string{{"schtasks", "/Query", "/TN", s.name, "/V", "/FO", "LIST"}}
+	}
+	return nil
+}
+
+// statusLines keeps the interesting lines of the scheduler's status output
+var statusLineRegex = regexp.MustCompile(`(?i)^\s*(ActiveState|Result|ExecMainStatus|LastTriggerUSec|NextElapseUSecRealtime|state|last exit code|runs|Status|Last Run Time|Last Result|Next Run Time|Scheduled Task State)\s*[=:]`)
+
+// runServiceCommand runs one of the service actions. Install writes the files
+// first; uninstall removes them after the scheduler let go of the entry.
+func runServiceCommand(action string, s *serviceSpec, dryRun bool) error {
+	files := serviceFiles(s)
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	if action == "install" {
+		for _, p := range paths {
+			if dryRun {
+				fmt.Printf(tr("[Dry Run] Would write %s:\n"), p)
+				fmt.Println(files[p])
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(p, []byte(files[p]), 0644); err != nil {
+				return err
+			}
+			fmt.Printf(tr("[OK] Wrote %s\n"), p)
+			recordAction("write", p, "ok", "", 0)
+		}
+		if !dryRun {
+			if err := os.MkdirAll(s.reportDir, 0755); err != nil {
+				return err
+			}
+		}
+		if runtime.GOOS == "darwin" && !dryRun {
+			// A previous install is still loaded; bootstrap would refuse the new plist
+			exec.Command("launchctl", "bootout", fmt.Sprintf("gui/%d/%s", os.Getuid(), s.name)).Run()
+		}
+	}
+
+	for _, args := range serviceCommands(s, action) {
+		if dryRun {
+			fmt.Printf(tr("[Dry Run] Would run: %s\n"), strings.Join(args, " "))
+			continue
+		}
+		out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+		if action == "status" {
+			if err != nil {
+				return fmt.Errorf("cannot query %s; is it installed? (%s)", s.name, strings.TrimSpace(string(out)))
+			}
+			for _, line := range strings.Split(string(out), "\n") {
+				if statusLineRegex.MatchString(line) {
+					fmt.Println("  " + strings.TrimSpace(line))
+				}
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+		}
+		recordAction(action, s.name, "ok", strings.Join(args, " "), 0)
+	}
+
+	switch action {
+	case "uninstall":
+		for _, p := range paths {
+			if dryRun {
+				fmt.Printf(tr("[Dry Run] Would remove %s\n"), p)
+				continue
+			}
+			if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			fmt.Printf(tr("[OK] Removed %s\n"), p)
+		}
+		if runtime.GOOS == "linux" && !dryRun {
+			exec.Command("systemctl", "--user", "daemon-reload").Run()
+		}
+	case "status":
+		fmt.Printf(tr("\n  Log: %s\n"), s.logPath)
+		for _, line := range logTail(s.logPath, serviceLogTail) {
+			fmt.Println("  | " + line)
+		}
+	}
+	return nil
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode, dryRun, list, noNotify bool
+		tasksFlag, outFlag, atFlag     string
+	)
+
+	flag.StringVar(&tasksFlag, "tasks", "", "Comma-separated task names to run (default: all)")
+	flag.StringVar(&outFlag, "out", "", "Report folder, relative to the project root or absolute (default: reportDir or NightlyReports)")
+	flag.BoolVar(&list, "list", false, "List the tasks and when they last succeeded, then exit")
+	flag.BoolVar(&dryRun, "dry-run", false, "Show which tasks would run with which command, without running them; install/uninstall: show the files and scheduler commands")
+	flag.BoolVar(&noNotify, "no-notify", false, "Do not post or mail the summary")
+	flag.StringVar(&atFlag, "at", defaultServiceTime, "install: time of day the run starts (HH:MM, local time)")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	// Flags may also follow the command ("install -at 03:00")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_nightly")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		sendNotifications(code)
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	command := "run"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	switch command {
+	case "run", "install", "uninstall", "start", "stop", "status":
+	default:
+		fmt.Println(tr("Usage: unity_nightly [run|install|uninstall|start|stop|status] [flags]"))
+		recordError("unknown command '%s'", strings.Join(args, " "))
+		exit(2)
+	}
+	if len(args) > 1 {
+		fmt.Println(tr("Usage: unity_nightly [run|install|uninstall|start|stop|status] [flags]"))
+		recordError("unexpected argument '%s'", args[1])
+		exit(2)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fail(1, "cannot get current directory: %v", err)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+
+	cfg, cfgPath, err := loadNightlyConfig(basePath)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+	if outFlag != "" {
+		cfg.ReportDir = outFlag
+	}
+	reportDir := cfg.ReportDir
+	if !filepath.IsAbs(reportDir) {
+		reportDir = filepath.Join(basePath, filepath.FromSlash(reportDir))
+	}
+
+	if command != "run" {
+		spec, err := newServiceSpec(basePath, reportDir, atFlag)
+		if err != nil {
+			fail(2, "%v", err)
+		}
+		printRule("=============================================")
+		fmt.Printf(tr("  NIGHTLY SERVICE: %s\n"), strings.ToUpper(command))
+		printRule("=============================================")
+		fmt.Printf(tr("  Project:  %s\n"), filepath.Base(basePath))
+		fmt.Printf(tr("  Entry:    %s\n"), spec.name)
+		if command == "install" {
+			fmt.Printf(tr("  Runs at:  %02d:%02d every day\n"), spec.hour, spec.minute)
+		}
+		fmt.Println()
+		if err := runServiceCommand(command, spec, dryRun); err != nil {
+			fail(1, "%v", err)
+		}
+		switch {
+		case dryRun:
+			fmt.Println(tr("\n[Dry Run] Nothing was installed or changed."))
+		case command == "install":
+			fmt.Printf(tr("\n[OK] Installed %s; output goes to %s\n"), spec.name, relPath(basePath, spec.logPath))
+			if runtime.GOOS == "linux" {
+				fmt.Println(tr("[TIP] Run 'loginctl enable-linger' once so the timer also fires while you are logged out."))
+			}
+		case command == "uninstall":
+			fmt.Printf(tr("\n[OK] Uninstalled %s\n"), spec.name)
+		case command == "start":
+			fmt.Printf(tr("[OK] Started %s; follow %s\n"), spec.name, relPath(basePath, spec.logPath))
+		case command == "stop":
+			fmt.Printf(tr("[OK] Stopped %s\n"), spec.name)
+		}
+		exit(0)
+	}
+
+	state := loadState(reportDir)
+
+	tasks := cfg.Tasks
+	if tasksFlag != "" {
+		tasks = nil
+		for _, name := range strings.Split(tasksFlag, ",") {
+			name = strings.TrimSpace(name)
+			found := false
+			for _, t := range cfg.Tasks {
+				if t.Name == name {
+					// Naming a task runs it even when "every" or "ifIdle" would skip it
+					t.Every, t.IfIdle, t.Disabled = "", "", false
+					tasks = append(tasks, t)
+					found = true
+				}
+			}
+			if !found {
+				fail(2, "unknown task '%s'", name)
+			}
+		}
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  NIGHTLY MAINTENANCE"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:  %s\n"), filepath.Base(basePath))
+	if cfgPath != "" {
+		fmt.Printf(tr("  Config:   %s\n"), cfgPath)
+	}
+	fmt.Printf(tr("  Reports:  %s\n"), relPath(basePath, reportDir))
+	fmt.Printf(tr("  Tasks:    %d\n"), len(tasks))
+	fmt.Println()
+
+	now := time.Now()
+	if list || dryRun {
+		for _, t := range tasks {
+			last := tr("never")
+			if at, err := time.Parse(time.RFC3339, state.LastSuccess[t.Name]); err == nil {
+				last = at.Local().Format("2006-01-02 15:04")
+			}
+			command := t.Tool
+			if t.Tool != diskReportTool {
+				if exe, err := resolveTool(t.Tool); err == nil {
+					command = strings.Join(append(append([]string{exe}, t.Args...), "-json"), " ")
+				} else {
+					command = err.Error()
+				}
+			}
+			fmt.Printf("  %-14s %s\n", t.Name, command)
+			fmt.Printf(tr("  %-14s last success: %s\n"), "", last)
+			if reason := skipReason(basePath, t, state, now); reason != "" {
+				fmt.Printf(tr("  %-14s [--] skipped tonight: %s\n"), "", reason)
+				recordAction("task", t.Name, "skipped", reason, 0)
+			} else if dryRun {
+				recordAction("task", t.Name, "planned", command, 0)
+			}
+		}
+		if dryRun {
+			fmt.Println(tr("\n[Dry Run] No task was run."))
+		}
+		exit(0)
+	}
+
+	if !noNotify {
+		enableNotifications(basePath, "unity_nightly")
+	}
+	runDir := filepath.Join(reportDir, now.Format("2006-01-02_150405"))
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		fail(1, "cannot create %s: %v", relPath(basePath, runDir), err)
+	}
+
+	before := takeSnapshot(basePath)
+	var after *diskSnapshot
+	var results []taskResult
+	failed := 0
+	for i, t := range tasks {
+		if reason := skipReason(basePath, t, state, now); reason != "" {
+			fmt.Printf(tr("[%d/%d] [--] %s skipped: %s\n"), i+1, len(tasks), t.Name, reason)
+			results = append(results, taskResult{task: t, status: "skipped", detail: reason})
+			recordAction("task", t.Name, "skipped", reason, 0)
+			continue
+		}
+		fmt.Printf(tr("[%d/%d] Running %s...\n"), i+1, len(tasks), t.Name)
+
+		var r taskResult
+		if t.Tool == diskReportTool {
+			start := time.Now()
+			snap := takeSnapshot(basePath)
+			after = &snap
+			r = taskResult{task: t, status: "ok", duration: time.Since(start),
+				detail: fmt.Sprintf("project %s (%s)", formatSize(totalSize(snap)), formatChange(totalSize(snap)-totalSize(before)))}
+			if snap.free >= 0 {
+				r.detail += fmt.Sprintf(", %s free", formatSize(snap.free))
+			}
+		} else {
+			r = runTask(basePath, runDir, t)
+		}
+		results = append(results, r)
+		recordAction("task", t.Name, r.status, r.detail, r.duration)
+
+		if r.status == "failed" {
+			failed++
+			fmt.Printf(tr("[%d/%d] [ERROR] %s: %s\n"), i+1, len(tasks), t.Name, r.detail)
+			if r.logPath != "" {
+				fmt.Printf(tr("        Log: %s\n"), relPath(basePath, r.logPath))
+				addNotifyExcerpt(t.Name, logTail(r.logPath, logTailLines))
+			}
+			continue
+		}
+		state.LastSuccess[t.Name] = time.Now().UTC().Format(time.RFC3339)
+		fmt.Printf(tr("[%d/%d] [OK] %s: %s (%s)\n"), i+1, len(tasks), t.Name, r.detail, r.duration.Round(time.Second))
+	}
+
+	if err := saveState(reportDir, state); err != nil {
+		fmt.Printf(tr("[WARNING] Cannot write %s: %v\n"), stateFileName, err)
+	}
+	summaryPath := filepath.Join(runDir, summaryFileName)
+	summary, err := writeSummary(summaryPath, filepath.Base(basePath), now, results, before, after)
+	if err != nil {
+		fmt.Printf(tr("[WARNING] Cannot write %s: %v\n"), relPath(basePath, summaryPath), err)
+	} else {
+		recordArtifact(summaryPath)
+	}
+	pruneReports(reportDir, cfg.KeepReports)
+
+	ok, skipped := 0, 0
+	for _, r := range results {
+		switch r.status {
+		case "ok":
+			ok++
+		case "skipped":
+			skipped++
+		}
+	}
+	fmt.Println()
+	printRule("=============================================")
+	fmt.Printf(tr("  Tasks: %d ok, %d failed, %d skipped\n"), ok, failed, skipped)
+	if after != nil {
+		fmt.Printf(tr("  Project size: %s (%s)\n"), formatSize(totalSize(*after)), formatChange(totalSize(*after)-totalSize(before)))
+	}
+	fmt.Printf(tr("  Summary: %s\n"), relPath(basePath, summaryPath))
+	printRule("=============================================")
+
+	notifySummaryText := fmt.Sprintf("%d task(s) ok, %d failed, %d skipped", ok, failed, skipped)
+	if after != nil {
+		notifySummaryText += fmt.Sprintf("; project %s (%s)", formatSize(totalSize(*after)), formatChange(totalSize(*after)-totalSize(before)))
+	}
+	setNotifySummary("%s", notifySummaryText)
+
+	code := 0
+	if failed > 0 {
+		code = 1
+	}
+	if cfg.Email != nil && !noNotify {
+		if err := sendEmail(cfg.Email, code == 0 && len(jsonDoc.Errors) == 0, filepath.Base(basePath), summary); err != nil {
+			fmt.Printf(tr("[WARNING] Email to %s failed: %v\n"), strings.Join(cfg.Email.To, ", "), err)
+		} else {
+			fmt.Printf(tr("[OK] Mailed the summary to %s\n"), strings.Join(cfg.Email.To, ", "))
+		}
+	}
+	exit(code)
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+// relPath shows a path relative to the project root, with forward slashes
+func relPath(basePath, p string) string {
+	if rel, err := filepath.Rel(basePath, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return p
+}
+
+// parseAge accepts Go durations plus a "d" (days) suffix, e.g. "14d", "36h"
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age: %s", s)
+		}
+		return time.Duration(days * 24 * float64(time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+// formatAge renders the time since t as "3d 4h" / "5h 12m"
+func formatAge(t time.Time) string {
+	d := time.Since(t)
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh", days, hours)
+	}
+	return fmt.Sprintf("%dh %dm", hours, int(d.Minutes())%60)
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Notifications
+// ============================================================
+
+// Webhooks come from "notifications" in .unitystarter.json (UNITYSTARTER_CONFIG, or
+// the first file from the project root upward) and from UNITYSTARTER_WEBHOOK_URL.
+// The message is built from the recorded actions, errors and artifacts, so it says
+// the same as the -json document. A webhook that fails only prints a warning.
+const (
+	notifyConfigFileName = ".unitystarter.json"
+	notifyConfigEnvVar   = "UNITYSTARTER_CONFIG"
+	notifyWebhookEnvVar  = "UNITYSTARTER_WEBHOOK_URL"
+	notifyTimeout        = 15 * time.Second
+	notifyMaxLines       = 20 // errors, log lines and artifacts per message
+)
+
+// notifyConfig is the "notifications" object of .unitystarter.json
+type notifyConfig struct {
+	Webhooks        []webhookConfig `json:"webhooks"`
+	ArtifactBaseURL string          `json:"artifactBaseUrl"` // project-relative artifact paths are appended
+}
+
+// webhookConfig is one target. The URL may use ${VAR} so the secret stays in the
+// CI environment instead of the repository.
+type webhookConfig struct {
+	URL    string   `json:"url"`
+	Format string   `json:"format"` // slack, discord, json (default: from the URL host)
+	On     []string `json:"on"`     // success, failure (default: both)
+	Tools  []string `json:"tools"`  // tool names (default: every tool)
+}
+
+// notifyExcerpt is a titled block of log lines shown under the errors
+type notifyExcerpt struct {
+	Title string   `json:"title"`
+	Lines []string `json:"lines"`
+}
+
+// notification is the body posted to "json" webhooks
+type notification struct {
+	Tool       string          `json:"tool"`
+	Project    string          `json:"project"`
+	Host       string          `json:"host"`
+	Success    bool            `json:"success"`
+	ExitCode   int             `json:"exitCode"`
+	DurationMs int64           `json:"durationMs"`
+	Summary    string          `json:"summary"`
+	Counts     map[string]int  `json:"counts"` // actions per status
+	Errors     []string        `json:"errors"`
+	Logs       []notifyExcerpt `json:"logs"`
+	Artifacts  []string        `json:"artifacts"` // links with artifactBaseUrl, else paths
+	RunURL     string          `json:"runUrl,omitempty"`
+}
+
+var (
+	notifyHooks       []webhookConfig
+	notifyTool        string
+	notifyBasePath    string
+	notifyArtifactURL string
+	notifySummary     string
+	notifyExcerpts    []notifyExcerpt
+	notifySent        bool
+)
+
+// loadNotifyConfig reads the "notifications" object the same way loadSharedConfig
+// finds .unitystarter.json. Returns an empty config when no file exists.
+func loadNotifyConfig(projectRoot string) (*notifyConfig, string, error) {
+	path := os.Getenv(notifyConfigEnvVar)
+	if path == "" {
+		abs, err := filepath.Abs(projectRoot)
+		if err != nil {
+			return &notifyConfig{}, "", nil
+		}
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			candidate := filepath.Join(dir, notifyConfigFileName)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				return &notifyConfig{}, "", nil
+			}
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, path, err
+	}
+	var file struct {
+		Notifications notifyConfig `json:"notifications"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, path, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	return &file.Notifications, path, nil
+}
+
+// enableNotifications loads the webhooks of this tool. Call it once the real work
+// starts, so listings, dry runs and cancelled prompts never post.
+func enableNotifications(basePath, tool string) {
+	cfg, path, err := loadNotifyConfig(basePath)
+	if err != nil {
+		fmt.Printf(tr("[WARNING] Notifications are off: %v\n"), err)
+		return
+	}
+	hooks := cfg.Webhooks
+	if u := os.Getenv(notifyWebhookEnvVar); u != "" {
+		hooks = append(hooks, webhookConfig{URL: u})
+	}
+	notifyBasePath = basePath
+	notifyArtifactURL = cfg.ArtifactBaseURL
+	for _, h := range hooks {
+		if len(h.Tools) > 0 && !containsFold(h.Tools, tool) {
+			continue
+		}
+		h.URL = strings.TrimSpace(os.ExpandEnv(h.URL))
+		if u, err := url.Parse(h.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			fmt.Printf(tr("[WARNING] Skipping webhook in %s: the URL is empty or not http(s) (unset variable?)\n"), path)
+			continue
+		}
+		h.Format = webhookFormat(h)
+		if h.Format != "slack" && h.Format != "discord" && h.Format != "json" {
+			fmt.Printf(tr("[WARNING] Skipping webhook %s: unknown format '%s' (use slack, discord or json)\n"), webhookHost(h.URL), h.Format)
+			continue
+		}
+		notifyHooks = append(notifyHooks, h)
+	}
+	notifyTool = tool
+}
+
+// setNotifySummary replaces the default "3 ok, 1 failed" line of the message
+func setNotifySummary(format string, args ...interface{}) {
+	notifySummary = fmt.Sprintf(format, args...)
+}
+
+// addNotifyExcerpt attaches log lines of a failure to the message
+func addNotifyExcerpt(title string, lines []string) {
+	if len(lines) > 0 {
+		notifyExcerpts = append(notifyExcerpts, notifyExcerpt{Title: title, Lines: lines})
+	}
+}
+
+// sendNotifications posts the result to every webhook that wants it. Call it
+// before waiting for a key press, so the message does not wait for the user.
+func sendNotifications(exitCode int) {
+	if notifySent || len(notifyHooks) == 0 {
+		return
+	}
+	notifySent = true
+	n := buildNotification(exitCode)
+	event := "success"
+	if !n.Success {
+		event = "failure"
+	}
+	for _, h := range notifyHooks {
+		if len(h.On) > 0 && !containsFold(h.On, event) {
+			continue
+		}
+		if err := postWebhook(h, n); err != nil {
+			fmt.Printf(tr("[WARNING] Webhook %s failed: %v\n"), webhookHost(h.URL), err)
+			continue
+		}
+		fmt.Printf(tr("[OK] Notified %s\n"), webhookHost(h.URL))
+	}
+}
+
+func buildNotification(exitCode int) *notification {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	host, _ := os.Hostname()
+	n := &notification{
+		Tool:       notifyTool,
+		Project:    filepath.Base(notifyBasePath),
+		Host:       host,
+		Success:    exitCode == 0 && len(jsonDoc.Errors) == 0,
+		ExitCode:   exitCode,
+		DurationMs: time.Since(jsonStart).Milliseconds(),
+		Summary:    notifySummary,
+		Counts:     make(map[string]int),
+		Errors:     append([]string{}, jsonDoc.Errors...),
+		Logs:       append([]notifyExcerpt{}, notifyExcerpts...),
+		Artifacts:  []string{},
+		RunURL:     ciRunURL(),
+	}
+	for _, a := range jsonDoc.Actions {
+		n.Counts[a.Status]++
+	}
+	if n.Summary == "" {
+		var parts []string
+		for _, status := range []string{"ok", "failed", "skipped"} {
+			if n.Counts[status] > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", n.Counts[status], status))
+			}
+		}
+		n.Summary = strings.Join(parts, ", ")
+	}
+	for _, a := range jsonDoc.Artifacts {
+		n.Artifacts = append(n.Artifacts, artifactLink(a))
+	}
+	return n
+}
+
+// artifactLink turns a path into a link below artifactBaseUrl; paths outside the
+// project, or without a base URL, are kept as they are
+func artifactLink(p string) string {
+	rel := filepath.ToSlash(p)
+	if filepath.IsAbs(p) {
+		r, err := filepath.Rel(notifyBasePath, p)
+		if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+			return rel
+		}
+		rel = filepath.ToSlash(r)
+	}
+	if notifyArtifactURL == "" {
+		return rel
+	}
+	parts := strings.Split(rel, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.TrimSuffix(notifyArtifactURL, "/") + "/" + strings.Join(parts, "/")
+}
+
+// ciRunURL links the CI job the tool runs in, when the CI exposes one
+func ciRunURL() string {
+	if server, repo, run := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"); server != "" && repo != "" && run != "" {
+		return server + "/" + repo + "/actions/runs/" + run
+	}
+	for _, name := range []string{"CI_JOB_URL", "BUILD_URL"} { // GitLab, Jenkins
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// webhookFormat returns the configured format, else guesses it from the host.
+// Mattermost and Rocket.Chat accept "slack".
+func webhookFormat(h webhookConfig) string {
+	if h.Format != "" {
+		return strings.ToLower(h.Format)
+	}
+	host := webhookHost(h.URL)
+	switch {
+	case host == "hooks.slack.com":
+		return "slack"
+	case host == "discord.com" || host == "discordapp.com" || strings.HasSuffix(host, ".discord.com"):
+		return "discord"
+	}
+	return "json"
+}
+
+// webhookHost is what the output shows of a webhook: the path holds the secret
+func webhookHost(raw string) string {
+	if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		return strings.ToLower(u.Hostname())
+	}
+	return "webhook"
+}
+
+// notificationText renders the message as Slack mrkdwn or Discord markdown,
+// cut to limit characters
+func notificationText(n *notification, slack bool, limit int) string {
+	esc := func(s string) string { return s }
+	bold := func(s string) string { return "**" + s + "**" }
+	link := func(u, text string) string { return text + ": " + u }
+	if slack {
+		esc = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+		bold = func(s string) string { return "*" + s + "*" }
+		link = func(u, text string) string { return "<" + u + "|" + esc(text) + ">" }
+	}
+
+	status := "✅ " + bold(esc(n.Tool)) + " succeeded"
+	if !n.Success {
+		status = "❌ " + bold(esc(n.Tool)) + fmt.Sprintf(" failed (exit code %d)", n.ExitCode)
+	}
+	lines := []string{fmt.Sprintf("%s: %s on %s, %s", status, esc(n.Project), esc(n.Host), (time.Duration(n.DurationMs) * time.Millisecond).Round(time.Second))}
+	if n.Summary != "" {
+		lines = append(lines, esc(n.Summary))
+	}
+	if n.RunURL != "" {
+		lines = append(lines, link(n.RunURL, "CI run"))
+	}
+	if len(n.Errors) > 0 {
+		lines = append(lines, "", bold("Errors"))
+		for i, e := range n.Errors {
+			if i == notifyMaxLines {
+				lines = append(lines, fmt.Sprintf("… %d more", len(n.Errors)-i))
+				break
+			}
+			lines = append(lines, "• "+esc(e))
+		}
+	}
+	for _, x := range n.Logs {
+		excerpt := x.Lines
+		if len(excerpt) > notifyMaxLines {
+			excerpt = excerpt[len(excerpt)-notifyMaxLines:]
+		}
+		// A stray fence inside the log would end the code block early
+		block := strings.ReplaceAll(strings.Join(excerpt, "\n"), "```", "'''")
+		lines = append(lines, "", bold(esc(x.Title)), "```", esc(block), "```")
+	}
+	if len(n.Artifacts) > 0 {
+		lines = append(lines, "", bold("Artifacts"))
+		for i, a := range n.Artifacts {
+			if i == notifyMaxLines {
+				lines = append(lines, fmt.Sprintf("… %d more", len(n.Artifacts)-i))
+				break
+			}
+			if strings.HasPrefix(a, "http://") || strings.HasPrefix(a, "https://") {
+				lines = append(lines, "• "+link(a, path.Base(a)))
+			} else {
+				lines = append(lines, "• "+esc(a))
+			}
+		}
+	}
+
+	text := strings.Join(lines, "\n")
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	text = string(runes[:limit-8])
+	if strings.Count(text, "```")%2 == 1 {
+		text += "\n```"
+	}
+	return text + "\n…"
+}
+
+// postWebhook sends one message; a 429 or 5xx answer is retried once
+func postWebhook(h webhookConfig, n *notification) error {
+	var payload interface{} = n
+	switch h.Format {
+	case "slack":
+		payload = map[string]string{"text": notificationText(n, true, 3900)}
+	case "discord":
+		payload = map[string]string{"content": notificationText(n, false, 2000)}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: notifyTimeout}
+	for attempt := 0; ; attempt++ {
+		resp, err := client.Post(h.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			// The error text repeats the URL, secret included
+			if ue, ok := err.(*url.Error); ok {
+				err = ue.Err
+			}
+			return err
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+		if resp.StatusCode < 300 {
+			return nil
+		}
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !retry || attempt > 0 {
+			return fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		wait := 2 * time.Second
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 && s <= 30 {
+			wait = time.Duration(s) * time.Second
+		}
+		time.Sleep(wait)
+	}
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...": "\n按回车键继续...",
+	"[ERROR] %v\n":                 "[ERROR] %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  NIGHTLY MAINTENANCE":                   "  夜间维护",
+	"  Project:  %s\n":                        "  项目:     %s\n",
+	"  Config:   %s\n":                        "  配置:     %s\n",
+	"  Reports:  %s\n":                        "  报告:     %s\n",
+	"  Tasks:    %d\n":                        "  任务:     %d\n",
+	"never":                                   "从未",
+	"  %-14s last success: %s\n":              "  %-14s 上次成功: %s\n",
+	"  %-14s [--] skipped tonight: %s\n":      "  %-14s [--] 今晚跳过: %s\n",
+	"\n[Dry Run] No task was run.":            "\n[Dry Run] 未运行任何任务。",
+	"[%d/%d] [--] %s skipped: %s\n":           "[%d/%d] [--] 已跳过 %s: %s\n",
+	"[%d/%d] Running %s...\n":                 "[%d/%d] 正在运行 %s...\n",
+	"[%d/%d] [ERROR] %s: %s\n":                "[%d/%d] [ERROR] %s: %s\n",
+	"        Log: %s\n":                       "        日志: %s\n",
+	"[%d/%d] [OK] %s: %s (%s)\n":              "[%d/%d] [OK] %s: %s (%s)\n",
+	"[WARNING] Cannot write %s: %v\n":         "[WARNING] 无法写入 %s: %v\n",
+	"  Tasks: %d ok, %d failed, %d skipped\n": "  任务: %d 个成功，%d 个失败，%d 个跳过\n",
+	"  Project size: %s (%s)\n":               "  项目大小: %s (%s)\n",
+	"  Summary: %s\n":                         "  汇总: %s\n",
+	"[WARNING] Email to %s failed: %v\n":      "[WARNING] 发送邮件到 %s 失败: %v\n",
+	"[OK] Mailed the summary to %s\n":         "[OK] 已将汇总邮件发送到 %s\n",
+
+	"Usage: unity_nightly [run|install|uninstall|start|stop|status] [flags]": "用法: unity_nightly [run|install|uninstall|start|stop|status] [参数]",
+	"  NIGHTLY SERVICE: %s\n":                       "  夜间维护服务: %s\n",
+	"  Entry:    %s\n":                              "  条目:     %s\n",
+	"  Runs at:  %02d:%02d every day\n":             "  运行时间: 每天 %02d:%02d\n",
+	"[Dry Run] Would write %s:\n":                   "[Dry Run] 将写入 %s:\n",
+	"[Dry Run] Would run: %s\n":                     "[Dry Run] 将运行: %s\n",
+	"[Dry Run] Would remove %s\n":                   "[Dry Run] 将删除 %s\n",
+	"[OK] Wrote %s\n":                               "[OK] 已写入 %s\n",
+	"[OK] Removed %s\n":                             "[OK] 已删除 %s\n",
+	"\n  Log: %s\n":                                 "\n  日志: %s\n",
+	"\n[Dry Run] Nothing was installed or changed.": "\n[Dry Run] 未安装或更改任何内容。",
+	"\n[OK] Installed %s; output goes to %s\n":      "\n[OK] 已安装 %s，输出写入 %s\n",
+	"[TIP] Run 'loginctl enable-linger' once so the timer also fires while you are logged out.": "[TIP] 运行一次 'loginctl enable-linger'，让定时器在注销后也能触发。",
+	"\n[OK] Uninstalled %s\n":      "\n[OK] 已卸载 %s\n",
+	"[OK] Started %s; follow %s\n": "[OK] 已启动 %s，可查看 %s\n",
+	"[OK] Stopped %s\n":            "[OK] 已停止 %s\n",
+
+	"[WARNING] Notifications are off: %v\n":                                                 "[WARNING] 通知已关闭: %v\n",
+	"[WARNING] Skipping webhook in %s: the URL is empty or not http(s) (unset variable?)\n": "[WARNING] 跳过 %s 中的 webhook: URL 为空或不是 http(s)（变量未设置？）\n",
+	"[WARNING] Skipping webhook %s: unknown format '%s' (use slack, discord or json)\n":     "[WARNING] 跳过 webhook %s: 未知格式 '%s'（可用 slack、discord 或 json）\n",
+	"[WARNING] Webhook %s failed: %v\n":                                                     "[WARNING] Webhook %s 发送失败: %v\n",
+	"[OK] Notified %s\n":                                                                    "[OK] 已通知 %s\n",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
@@ -0,0 +1,1059 @@
+// Unity Onboard — Check that a new developer's machine can open and build the project.
+// Runs the checks a new team member otherwise works through by trial and error:
+// the project's exact Unity version installed through the Hub (with the build
+// modules the team targets), git and Git LFS installed and hooked into the clone,
+// the Android SDK, NDK and JDK the editor is configured with, free disk space
+// for the Library and builds, and FFmpeg for the audio and video tools. Every
+// failed check prints the steps that fix it on this operating system. The team
+// records what it needs once under "onboarding" in .unitystarter.json; without
+// platforms there, a project with Assets/Plugins/Android is checked for Android.
+// Exits with 1 when a required check fails.
+//
+// Build: go build unity_onboard.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_onboard                                   # the checks .unitystarter.json asks for
+//	unity_onboard -platforms android,ios            # also the build modules and SDKs of these
+//	unity_onboard -min-free 100GB -strict           # warnings (e.g. no FFmpeg) fail as well
+//
+// .unitystarter.json:
+//
+//	{"onboarding": {"platforms": ["android"], "minFreeSpace": "60GB", "ffmpeg": "required"}}
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	sharedConfigFileName = ".unitystarter.json"
+	sharedConfigEnvVar   = "UNITYSTARTER_CONFIG"
+)
+
+// A fresh Library of a mid-sized project plus a few player builds
+const defaultMinFreeSpace = "30GB"
+
+var (
+	projectVersionRegex = regexp.MustCompile(`(?m)^m_EditorVersion:\s*(\S+)`)
+	// m_EditorVersionWithRevision: 2022.3.10f1 (ff3792e53c62)
+	projectRevisionRegex = regexp.MustCompile(`(?m)^m_EditorVersionWithRevision:\s*\S+\s*\(([0-9a-f]+)\)`)
+)
+
+// Hub module ids each platform needs, in the order the fix lists them
+var platformModules = map[string][]string{
+	"android": {"android", "android-sdk-ndk-tools", "android-open-jdk"},
+	"ios":     {"ios"},
+	"webgl":   {"webgl"},
+	"windows": {"windows-il2cpp"},
+	"mac":     {"mac-il2cpp"},
+	"linux":   {"linux-il2cpp"},
+}
+
+// Editor preferences that locate the Android tools. Windows appends a hash to
+// each registry value name ("AndroidSdkRoot_h2291459434"); the NDK key also
+// exists per NDK revision ("AndroidNdkRootR23b").
+const (
+	prefSDK         = "AndroidSdkRoot"
+	prefNDK         = "AndroidNdkRoot"
+	prefJDK         = "JdkPath"
+	prefSDKEmbedded = "SdkUseEmbedded"
+	prefNDKEmbedded = "NdkUseEmbedded"
+	prefJDKEmbedded = "JdkUseEmbedded"
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// onboardingConfig is the "onboarding" object of .unitystarter.json
+type onboardingConfig struct {
+	Platforms    []string `json:"platforms"`    // android, ios, webgl, windows, mac, linux
+	MinFreeSpace string   `json:"minFreeSpace"` // e.g. "60GB"
+	FFmpeg       string   `json:"ffmpeg"`       // "required", "optional" (default) or "off"
+}
+
+// Check results, worst last
+const (
+	statusOK   = "ok"
+	statusWarn = "warning"
+	statusFail = "failed"
+	statusSkip = "skipped"
+)
+
+// checkResult is one line of the report with the steps that fix it
+type checkResult struct {
+	name   string
+	status string
+	detail string
+	fix    []string
+}
+
+type hubModuleEntry struct {
+	ID       string `json:"id"`
+	Selected bool   `json:"selected"`
+}
+
+// ============================================================
+// Configuration Loading
+// ============================================================
+
+// loadOnboardingConfig reads UNITYSTARTER_CONFIG, or else the first
+// .unitystarter.json found from the project root upward. Returns an empty
+// config when none exists.
+func loadOnboardingConfig(projectRoot string) (*onboardingConfig, string, error) {
+	path := os.Getenv(sharedConfigEnvVar)
+	if path == "" {
+		abs, err := filepath.Abs(projectRoot)
+		if err != nil {
+			return &onboardingConfig{}, "", nil
+		}
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			candidate := filepath.Join(dir, sharedConfigFileName)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				return &onboardingConfig{}, "", nil
+			}
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, path, err
+	}
+	var file struct {
+		Onboarding onboardingConfig `json:"onboarding"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, path, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	return &file.Onboarding, path, nil
+}
+
+// ============================================================
+// Unity Editor
+// ============================================================
+
+// readProjectVersion returns the editor version and changeset of the project
+func readProjectVersion(basePath string) (string, string) {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectVersion.txt"))
+	if err != nil {
+		return "", ""
+	}
+	var version, revision string
+	if m := projectVersionRegex.FindSubmatch(data); m != nil {
+		version = string(m[1])
+	}
+	if m := projectRevisionRegex.FindSubmatch(data); m != nil {
+		revision = string(m[1])
+	}
+	return version, revision
+}
+
+// hubEditorFolders returns the folders Unity Hub installs editors into: the
+// default location plus the custom one from secondaryInstallPath.json
+func hubEditorFolders() []string {
+	home, _ := os.UserHomeDir()
+	var folders []string
+	var hubConfig string
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramW6432"} {
+			if pf := os.Getenv(env); pf != "" {
+				folders = append(folders, filepath.Join(pf, "Unity", "Hub", "Editor"))
+			}
+		}
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			hubConfig = filepath.Join(appData, "UnityHub")
+		}
+	case "darwin":
+		folders = append(folders, "/Applications/Unity/Hub/Editor")
+		hubConfig = filepath.Join(home, "Library", "Application Support", "UnityHub")
+	default:
+		folders = append(folders, filepath.Join(home, "Unity", "Hub", "Editor"))
+		hubConfig = filepath.Join(home, ".config", "UnityHub")
+	}
+	if hubConfig != "" {
+		// The file holds a single JSON string; empty when no custom location is set
+		if data, err := os.ReadFile(filepath.Join(hubConfig, "secondaryInstallPath.json")); err == nil {
+			var custom string
+			if json.Unmarshal(data, &custom) == nil && custom != "" {
+				folders = append(folders, custom)
+			}
+		}
+	}
+	return folders
+}
+
+// editorExecutable is the Unity binary inside an editor version folder
+func editorExecutable(dir string) string {
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(dir, "Editor", "Unity.exe")
+	case "darwin":
+		return filepath.Join(dir, "Unity.app", "Contents", "MacOS", "Unity")
+	default:
+		return filepath.Join(dir, "Editor", "Unity")
+	}
+}
+
+// installedEditors maps each installed version to its folder
+func installedEditors() map[string]string {
+	editors := make(map[string]string)
+	for _, folder := range hubEditorFolders() {
+		entries, err := os.ReadDir(folder)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			dir := filepath.Join(folder, e.Name())
+			if _, err := os.Stat(editorExecutable(dir)); err == nil && editors[e.Name()] == "" {
+				editors[e.Name()] = dir
+			}
+		}
+	}
+	return editors
+}
+
+// installedModules reads the modules the Hub recorded as installed
+func installedModules(editorDir string) map[string]bool {
+	modules := make(map[string]bool)
+	data, err := os.ReadFile(filepath.Join(editorDir, "modules.json"))
+	if err != nil {
+		return modules
+	}
+	var entries []hubModuleEntry
+	json.Unmarshal(data, &entries)
+	for _, e := range entries {
+		if e.Selected {
+			modules[e.ID] = true
+		}
+	}
+	return modules
+}
+
+// editorStream is the major.minor part ("2022.3") of a version like 2022.3.10f1
+func editorStream(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// hubLink opens the Hub's install dialog for a version
+func hubLink(version, revision string) string {
+	if revision == "" {
+		return "https://unity.com/releases/editor/archive"
+	}
+	return "unityhub://" + version + "/" + revision
+}
+
+func checkEditor(version, revision string, editors map[string]string) (checkResult, string) {
+	r := checkResult{name: "Unity editor"}
+	if version == "" {
+		r.status, r.detail = statusFail, "ProjectSettings/ProjectVersion.txt has no editor version"
+		r.fix = []string{"Pull the project again; ProjectVersion.txt is part of ProjectSettings/ and must be committed"}
+		return r, ""
+	}
+	if dir, ok := editors[version]; ok {
+		r.status, r.detail = statusOK, fmt.Sprintf("%s in %s", version, dir)
+		return r, dir
+	}
+	var same []string
+	for v := range editors {
+		if editorStream(v) == editorStream(version) {
+			same = append(same, v)
+		}
+	}
+	sort.Strings(same)
+	r.status = statusFail
+	r.detail = fmt.Sprintf("%s is not installed", version)
+	if len(same) > 0 {
+		r.detail += fmt.Sprintf(" (installed from %s: %s)", editorStream(version), strings.Join(same, ", "))
+	}
+	r.fix = []string{
+		fmt.Sprintf("unity_editor_installer install %s", version),
+		fmt.Sprintf("or open %s to install it from Unity Hub", hubLink(version, revision)),
+		"Opening the project with another patch version reimports everything and changes files in ProjectSettings/",
+	}
+	return r, ""
+}
+
+func checkModules(editorDir string, platforms []string) checkResult {
+	r := checkResult{name: "Build modules"}
+	if editorDir == "" {
+		r.status, r.detail = statusSkip, "no matching editor"
+		return r
+	}
+	if len(platforms) == 0 {
+		r.status, r.detail = statusSkip, "no target platforms configured"
+		return r
+	}
+	modules := installedModules(editorDir)
+	// The installer adds the child modules (SDK, NDK, JDK) with their parent
+	var missing, install []string
+	for _, p := range platforms {
+		ids := platformModules[p]
+		before := len(missing)
+		for _, id := range ids {
+			if !modules[id] {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > before && !containsString(install, ids[0]) {
+			install = append(install, ids[0])
+		}
+	}
+	if len(missing) == 0 {
+		r.status, r.detail = statusOK, strings.Join(platforms, ", ")
+		return r
+	}
+	r.status, r.detail = statusFail, "missing "+strings.Join(missing, ", ")
+	r.fix = []string{
+		fmt.Sprintf("unity_editor_installer install %s -modules %s", filepath.Base(editorDir), strings.Join(install, ",")),
+		"or Unity Hub > Installs > the editor's gear icon > Add modules",
+	}
+	return r
+}
+
+// ============================================================
+// Git
+// ============================================================
+
+func commandOutput(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	return strings.TrimSpace(string(out)), err
+}
+
+// installHint returns the package manager command for a tool on this system
+func installHint(winget, brew, apt string) string {
+	switch runtime.GOOS {
+	case "windows":
+		return "winget install " + winget
+	case "darwin":
+		return "brew install " + brew
+	}
+	return "sudo apt install " + apt + " (or your distribution's package manager)"
+}
+
+func checkGit(basePath string) []checkResult {
+	gitResult := checkResult{name: "Git"}
+	version, err := commandOutput("git", "--version")
+	if err != nil {
+		gitResult.status, gitResult.detail = statusFail, "git is not on PATH"
+		gitResult.fix = []string{installHint("Git.Git", "git", "git")}
+		return []checkResult{gitResult, {name: "Git LFS", status: statusSkip, detail: "no git"}}
+	}
+	gitResult.status, gitResult.detail = statusOK, strings.TrimPrefix(version, "git version ")
+
+	lfs := checkResult{name: "Git LFS"}
+	attrs, _ := os.ReadFile(filepath.Join(basePath, ".gitattributes"))
+	usesLFS := bytes.Contains(attrs, []byte("filter=lfs"))
+	lfsVersion, err := commandOutput("git", "lfs", "version")
+	switch {
+	case err != nil && usesLFS:
+		lfs.status, lfs.detail = statusFail, "the project stores assets in Git LFS, but git-lfs is not installed"
+		lfs.fix = []string{installHint("GitHub.GitLFS", "git-lfs", "git-lfs"), "git lfs install", "git lfs pull"}
+		return []checkResult{gitResult, lfs}
+	case err != nil:
+		lfs.status, lfs.detail = statusSkip, "not installed; the project does not use it"
+		return []checkResult{gitResult, lfs}
+	}
+	lfs.status, lfs.detail = statusOK, strings.Fields(lfsVersion)[0]
+	if !usesLFS {
+		return []checkResult{gitResult, lfs}
+	}
+	// Without the filter, a clone holds pointer files instead of the assets
+	if process, _ := commandOutput("git", "-C", basePath, "config", "--get", "filter.lfs.process"); process == "" {
+		lfs.status, lfs.detail = statusFail, "git-lfs is installed but not set up for this user"
+		lfs.fix = []string{"git lfs install", "git lfs pull"}
+	}
+	return []checkResult{gitResult, lfs}
+}
+
+// ============================================================
+// Android
+// ============================================================
+
+// readEditorPrefs returns the editor preferences whose names start with one of
+// the prefixes, as strings. Windows keeps them in the registry, macOS in a
+// plist read through defaults, Linux in an XML file with base64 strings.
+func readEditorPrefs(prefixes ...string) map[string]string {
+	prefs := make(map[string]string)
+	keep := func(name, value string) {
+		for _, p := range prefixes {
+			if strings.HasPrefix(name, p) {
+				prefs[name] = value
+			}
+		}
+	}
+	switch runtime.GOOS {
+	case "windows":
+		out, err := exec.Command("reg", "query", `HKCU\Software\Unity Technologies\Unity Editor 5.x`).Output()
+		if err != nil {
+			return prefs
+		}
+		// AndroidSdkRoot_h2291459434    REG_BINARY    433A5C...00
+		for _, line := range strings.Split(string(out), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				continue
+			}
+			name := fields[0]
+			if i := strings.LastIndex(name, "_h"); i > 0 {
+				name = name[:i]
+			}
+			switch fields[1] {
+			case "REG_BINARY":
+				data, err := hex.DecodeString(fields[2])
+				if err == nil {
+					keep(name, string(bytes.TrimRight(data, "\x00")))
+				}
+			case "REG_DWORD":
+				v, _ := strconv.ParseInt(strings.TrimPrefix(fields[2], "0x"), 16, 64)
+				keep(name, strconv.FormatInt(v, 10))
+			}
+		}
+	case "darwin":
+		out, err := exec.Command("defaults", "read", "com.unity3d.UnityEditor5.x").Output()
+		if err != nil {
+			return prefs
+		}
+		re := regexp.MustCompile(`^\s*"?([A-Za-z0-9_]+)"?\s*=\s*"?(.*?)"?;\s*$`)
+		for _, line := range strings.Split(string(out), "\n") {
+			if m := re.FindStringSubmatch(line); m != nil {
+				keep(m[1], m[2])
+			}
+		}
+	default:
+		home, _ := os.UserHomeDir()
+		data, err := os.ReadFile(filepath.Join(home, ".local", "share", "unity3d", "prefs"))
+		if err != nil {
+			return prefs
+		}
+		re := regexp.MustCompile(`<pref name="([^"]+)" type="(\w+)">([^<]*)</pref>`)
+		for _, m := range re.FindAllStringSubmatch(string(data), -1) {
+			value := m[3]
+			if m[2] == "string" {
+				if decoded, err := base64.StdEncoding.DecodeString(value); err == nil {
+					value = string(decoded)
+				}
+			}
+			keep(m[1], value)
+		}
+	}
+	return prefs
+}
+
+// androidPlayerDir is where the Android module installs its embedded tools
+func androidPlayerDir(editorDir string) string {
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(editorDir, "PlaybackEngines", "AndroidPlayer")
+	}
+	return filepath.Join(editorDir, "Editor", "Data", "PlaybackEngines", "AndroidPlayer")
+}
+
+// androidTool resolves one tool: the configured path, or the embedded copy
+// unless the preference turned it off (it is on when never set)
+func androidTool(prefs map[string]string, pathKey, embeddedKey, editorDir, embedded string) (string, bool) {
+	useEmbedded := prefs[embeddedKey] != "0" && !strings.EqualFold(prefs[embeddedKey], "false")
+	if useEmbedded && editorDir != "" {
+		return filepath.Join(androidPlayerDir(editorDir), embedded), true
+	}
+	path := prefs[pathKey]
+	if pathKey == prefNDK && path == "" {
+		// Newer editors keep one key per NDK revision
+		var keys []string
+		for k := range prefs {
+			if strings.HasPrefix(k, prefNDK) && k != prefNDK {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		if len(keys) > 0 {
+			path = prefs[keys[len(keys)-1]]
+		}
+	}
+	return path, false
+}
+
+func checkAndroid(editorDir string) []checkResult {
+	prefs := readEditorPrefs(prefSDK, prefNDK, prefJDK, prefSDKEmbedded, prefNDKEmbedded, prefJDKEmbedded)
+	moduleFix := "unity_editor_installer install " + filepath.Base(editorDir) + " -modules android"
+	if editorDir == "" {
+		moduleFix = "Install the project's editor first, with the Android Build Support module"
+	}
+	tools := []struct {
+		name, pathKey, embeddedKey, embedded, marker, env string
+	}{
+		{"Android SDK", prefSDK, prefSDKEmbedded, "SDK", "platform-tools", "ANDROID_SDK_ROOT"},
+		{"Android NDK", prefNDK, prefNDKEmbedded, "NDK", "source.properties", "ANDROID_NDK_ROOT"},
+		{"JDK", prefJDK, prefJDKEmbedded, "OpenJDK", "bin", "JAVA_HOME"},
+	}
+	var results []checkResult
+	for _, t := range tools {
+		r := checkResult{name: t.name}
+		path, embedded := androidTool(prefs, t.pathKey, t.embeddedKey, editorDir, t.embedded)
+		source := "Preferences > External Tools"
+		if embedded {
+			source = "installed with Unity"
+		}
+		switch {
+		case path == "":
+			r.status, r.detail = statusFail, "no path set in Preferences > External Tools"
+			r.fix = []string{moduleFix, "or set the path in Unity > Preferences > External Tools"}
+			if env := os.Getenv(t.env); env != "" {
+				r.fix = append(r.fix, fmt.Sprintf("%s is set to %s; the editor ignores it, copy it into Preferences", t.env, env))
+			}
+		case !exists(filepath.Join(path, t.marker)):
+			r.status, r.detail = statusFail, fmt.Sprintf("%s (%s) does not exist or is incomplete", path, source)
+			if embedded {
+				r.fix = []string{moduleFix}
+			} else {
+				r.fix = []string{"Point Unity > Preferences > External Tools at an existing " + t.name, "or tick the 'installed with Unity' box and " + moduleFix}
+			}
+		default:
+			r.status, r.detail = statusOK, fmt.Sprintf("%s (%s)", path, source)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// ============================================================
+// Machine
+// ============================================================
+
+// freeDiskSpace returns the free bytes on the drive holding dir
+func freeDiskSpace(dir string) (int64, error) {
+	if runtime.GOOS == "windows" {
+		drive := strings.TrimSuffix(filepath.VolumeName(dir), ":")
+		if drive == "" {
+			return 0, fmt.Errorf("no drive letter in %s", dir)
+		}
+		out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", "(Get-PSDrive -Name "+drive+").Free").Output()
+		if err != nil {
+			return 0, err
+		}
+		return strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	}
+	out, err := exec.Command("df", "-Pk", dir).Output()
+	if err != nil {
+		return 0, err
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 4 {
+		return 0, fmt.Errorf("unexpected df output")
+	}
+	kb, err := strconv.ParseInt(fields[3], 10, 64)
+	return kb * 1024, err
+}
+
+func checkDisk(basePath string, minFree int64) checkResult {
+	r := checkResult{name: "Disk space"}
+	free, err := freeDiskSpace(basePath)
+	if err != nil {
+		r.status, r.detail = statusWarn, fmt.Sprintf("cannot measure: %v", err)
+		return r
+	}
+	if free < minFree {
+		r.status, r.detail = statusFail, fmt.Sprintf("%s free, the project needs %s", formatSize(free), formatSize(minFree))
+		r.fix = []string{
+			"Free space on this drive, or clone the project onto a larger one",
+			"unity_project_full_clean removes the caches of projects you no longer work on",
+		}
+		return r
+	}
+	r.status, r.detail = statusOK, fmt.Sprintf("%s free", formatSize(free))
+	return r
+}
+
+func checkFFmpeg(mode string) checkResult {
+	r := checkResult{name: "FFmpeg"}
+	if mode == "off" {
+		r.status, r.detail = statusSkip, "not needed"
+		return r
+	}
+	out, err := commandOutput("ffmpeg", "-version")
+	if err != nil {
+		r.status, r.detail = statusWarn, "ffmpeg is not on PATH; audio_volume_normalizer and unity_video_webm_converter need it"
+		if mode == "required" {
+			r.status = statusFail
+		}
+		r.fix = []string{installHint("Gyan.FFmpeg", "ffmpeg", "ffmpeg"), "then open a new terminal so PATH is reloaded"}
+		return r
+	}
+	// ffmpeg version 6.1.1 Copyright (c) ...
+	fields := strings.Fields(strings.SplitN(out, "\n", 2)[0])
+	r.status = statusOK
+	if len(fields) >= 3 {
+		r.detail = fields[2]
+	}
+	return r
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+func printResult(r checkResult) {
+	label := map[string]string{statusOK: "[OK]  ", statusWarn: "[WARN]", statusFail: "[FAIL]", statusSkip: "[--]  "}[r.status]
+	fmt.Printf("%s %-14s %s\n", label, tr(r.name), r.detail)
+	for i, step := range r.fix {
+		if i == 0 {
+			fmt.Printf(tr("       Fix: %s\n"), step)
+		} else {
+			fmt.Printf("            %s\n", step)
+		}
+	}
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode, strict           bool
+		platformsFlag, minFreeFl string
+	)
+
+	flag.StringVar(&platformsFlag, "platforms", "", "Comma-separated target platforms to check modules and SDKs for: android, ios, webgl, windows, mac, linux (default: onboarding.platforms)")
+	flag.StringVar(&minFreeFl, "min-free", "", "Free disk space the project needs (default: onboarding.minFreeSpace or 30GB)")
+	flag.BoolVar(&strict, "strict", false, "Warnings fail the check too")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_onboard")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	if flag.NArg() > 0 {
+		fmt.Println(tr("Usage: unity_onboard [-platforms <list>] [-min-free <size>] [-strict] [-ci] [-json]"))
+		recordError("unexpected argument '%s'", flag.Arg(0))
+		exit(2)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fail(1, "cannot get current directory: %v", err)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+
+	cfg, cfgPath, err := loadOnboardingConfig(basePath)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+	platforms := cfg.Platforms
+	if platformsFlag != "" {
+		platforms = strings.Split(platformsFlag, ",")
+	}
+	for i, p := range platforms {
+		platforms[i] = strings.ToLower(strings.TrimSpace(p))
+		if _, ok := platformModules[platforms[i]]; !ok {
+			fail(2, "unknown platform '%s' (use android, ios, webgl, windows, mac, linux)", p)
+		}
+	}
+	if len(platforms) == 0 && exists(filepath.Join(basePath, "Assets", "Plugins", "Android")) {
+		platforms = []string{"android"}
+	}
+	if minFreeFl == "" {
+		minFreeFl = cfg.MinFreeSpace
+	}
+	if minFreeFl == "" {
+		minFreeFl = defaultMinFreeSpace
+	}
+	minFree, err := parseSize(minFreeFl)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+	ffmpegMode := strings.ToLower(cfg.FFmpeg)
+	switch ffmpegMode {
+	case "", "optional", "required", "off":
+	default:
+		fail(2, "onboarding.ffmpeg must be required, optional or off (got '%s')", cfg.FFmpeg)
+	}
+
+	version, revision := readProjectVersion(basePath)
+	host, _ := os.Hostname()
+	printRule("=============================================")
+	fmt.Println(tr("  ONBOARDING CHECK"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:   %s (Unity %s)\n"), filepath.Base(basePath), version)
+	fmt.Printf(tr("  Machine:   %s (%s/%s)\n"), host, runtime.GOOS, runtime.GOARCH)
+	if len(platforms) > 0 {
+		fmt.Printf(tr("  Platforms: %s\n"), strings.Join(platforms, ", "))
+	}
+	if cfgPath != "" {
+		fmt.Printf(tr("  Config:    %s\n"), cfgPath)
+	}
+	fmt.Println()
+
+	editorResult, editorDir := checkEditor(version, revision, installedEditors())
+	results := []checkResult{editorResult, checkModules(editorDir, platforms)}
+	results = append(results, checkGit(basePath)...)
+	if containsString(platforms, "android") {
+		results = append(results, checkAndroid(editorDir)...)
+	}
+	results = append(results, checkDisk(basePath, minFree), checkFFmpeg(ffmpegMode))
+
+	failed, warned := 0, 0
+	for _, r := range results {
+		printResult(r)
+		status := r.status
+		switch r.status {
+		case statusFail:
+			failed++
+			recordError("%s: %s", r.name, r.detail)
+		case statusWarn:
+			warned++
+			if strict {
+				status = statusFail
+				recordError("%s: %s", r.name, r.detail)
+			} else {
+				status = statusOK
+			}
+		}
+		detail := r.detail
+		if len(r.fix) > 0 {
+			detail += "; fix: " + strings.Join(r.fix, "; ")
+		}
+		recordAction("check", r.name, status, detail, 0)
+	}
+
+	fmt.Println()
+	if failed == 0 && (warned == 0 || !strict) {
+		if warned > 0 {
+			fmt.Printf(tr("[OK] Ready to work on %s, with %d warning(s).\n"), filepath.Base(basePath), warned)
+		} else {
+			fmt.Printf(tr("[OK] Ready to work on %s.\n"), filepath.Base(basePath))
+		}
+		exit(0)
+	}
+	fmt.Printf(tr("[ERROR] %d check(s) failed, %d warning(s). Follow the Fix steps above and run unity_onboard again.\n"), failed, warned)
+	exit(1)
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+var sizeRegex = regexp.MustCompile(`(?i)^([\d.]+)\s*(b|kb|mb|gb|tb)?$`)
+
+// parseSize accepts "500MB", "30GB", "1.5tb" or plain bytes
+func parseSize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	m := sizeRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+	v, _ := strconv.ParseFloat(m[1], 64)
+	switch strings.ToLower(m[2]) {
+	case "kb":
+		v *= 1 << 10
+	case "mb":
+		v *= 1 << 20
+	case "gb":
+		v *= 1 << 30
+	case "tb":
+		v *= 1 << 40
+	}
+	return int64(v), nil
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...": "\n按回车键继续...",
+	"[ERROR] %v\n":                 "[ERROR] %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.":                    "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                              "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"Usage: unity_onboard [-platforms <list>] [-min-free <size>] [-strict] [-ci] [-json]": "用法: unity_onboard [-platforms <列表>] [-min-free <大小>] [-strict] [-ci] [-json]",
+	"  ONBOARDING CHECK":           "  新成员环境检查",
+	"  Project:   %s (Unity %s)\n": "  项目:     %s (Unity %s)\n",
+	"  Machine:   %s (%s/%s)\n":    "  机器:     %s (%s/%s)\n",
+	"  Platforms: %s\n":            "  平台:     %s\n",
+	"  Config:    %s\n":            "  配置:     %s\n",
+	"       Fix: %s\n":             "       修复: %s\n",
+	"Unity editor":                 "Unity 编辑器",
+	"Build modules":                "构建模块",
+	"Disk space":                   "磁盘空间",
+	"[OK] Ready to work on %s.\n":  "[OK] 已可以开始开发 %s。\n",
+	"[OK] Ready to work on %s, with %d warning(s).\n":                                                      "[OK] 已可以开始开发 %s，有 %d 个警告。\n",
+	"[ERROR] %d check(s) failed, %d warning(s). Follow the Fix steps above and run unity_onboard again.\n": "[ERROR] %d 项检查失败，%d 个警告。请按上面的修复步骤操作后重新运行 unity_onboard。\n",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
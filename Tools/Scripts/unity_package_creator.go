@@ -0,0 +1,2273 @@
+// Unity Package Creator — Scaffold a local UPM package under Packages/.
+// Writes package.json, runtime/editor/test assembly definitions, a sample, a
+// README, CHANGELOG and LICENSE, with .meta files and fresh GUIDs so the package
+// can be committed before Unity has imported it, and adds it to the manifest's
+// testables so its tests show up in the Test Runner. Author and license come from
+// .unitystarter.json, the Unity version from ProjectSettings/ProjectVersion.txt.
+//
+// Build: go build unity_package_creator.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_package_creator com.company.feature
+//	unity_package_creator com.company.save-system -display-name "Save System" -description "Slots and cloud sync"
+//	unity_package_creator com.company.ui-kit -namespace Company.UI -no-tests
+//	unity_package_creator -dry-run com.company.feature
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	// Shared, company-wide values (author, license, year); the env var points at an
+	// explicit file, otherwise the nearest one above the project root wins
+	sharedConfigFileName = ".unitystarter.json"
+	sharedConfigEnvVar   = "UNITYSTARTER_CONFIG"
+
+	// License used when neither -license nor .unitystarter.json names one
+	defaultLicense = "Proprietary"
+
+	defaultVersion = "0.1.0"
+
+	// Needed for the package's tests to show up in the Test Runner
+	testFrameworkPackage = "com.unity.test-framework"
+)
+
+// Package names follow the Package Manager rules: lower case, reverse domain
+// ("com.company.feature"), at most 214 characters
+var packageNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-_]*(\.[a-z0-9][a-z0-9-_]*){2,}$`)
+
+var semverRegex = regexp.MustCompile(`^\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?$`)
+
+var projectVersionRegex = regexp.MustCompile(`(?m)^m_EditorVersion:\s*(\S+)`)
+
+var identifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Folder .meta for the folders Unity imports; folders ending in "~" are hidden
+// from the Asset Database and get none
+const folderMetaTemplate = `fileFormatVersion: 2
+guid: %s
+folderAsset: yes
+DefaultImporter:
+  externalObjects: {}
+  userData:
+  assetBundleName:
+  assetBundleVariant:
+`
+
+// File .meta per extension, with the importer Unity picks for it
+var fileMetaTemplates = map[string]string{
+	".json": `fileFormatVersion: 2
+guid: %s
+PackageManifestImporter:
+  externalObjects: {}
+  userData:
+  assetBundleName:
+  assetBundleVariant:
+`,
+	".asmdef": `fileFormatVersion: 2
+guid: %s
+AssemblyDefinitionImporter:
+  externalObjects: {}
+  userData:
+  assetBundleName:
+  assetBundleVariant:
+`,
+	".md": `fileFormatVersion: 2
+guid: %s
+TextScriptImporter:
+  externalObjects: {}
+  userData:
+  assetBundleName:
+  assetBundleVariant:
+`,
+}
+
+const mitLicense = `MIT License
+
+Copyright (c) %s %s
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+`
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// SharedConfig is the company-wide .unitystarter.json, shared with rename_project
+// and unity_script_generator
+type SharedConfig struct {
+	Author        string `json:"author"`
+	License       string `json:"license"`       // SPDX identifier, e.g. "MIT"
+	CopyrightYear string `json:"copyrightYear"` // defaults to the current year
+}
+
+// packageSpec is everything the scaffold is rendered from
+type packageSpec struct {
+	name        string // com.company.feature
+	displayName string
+	description string
+	version     string
+	unity       string // "2022.3", empty when the project has no ProjectVersion.txt
+	namespace   string // root namespace and runtime assembly name
+	author      string
+	license     string
+	year        string
+	editor      bool
+	tests       bool
+	samples     bool
+}
+
+// packageFile is one file of the scaffold, relative to the package root
+type packageFile struct {
+	path    string
+	content string
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Project Settings
+// ============================================================
+
+// readCompany reads companyName from ProjectSettings.asset; empty when missing
+func readCompany(basePath string) string {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectSettings.asset"))
+	if err != nil {
+		return ""
+	}
+	if m := regexp.MustCompile(`(?m)^\s*companyName:[ \t]*(.*?)\s*$`).FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// readUnityVersion returns the project's editor version as package.json wants
+// it ("2022.3"); empty when ProjectVersion.txt is missing
+func readUnityVersion(basePath string) string {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectVersion.txt"))
+	if err != nil {
+		return ""
+	}
+	m := projectVersionRegex.FindSubmatch(data)
+	if m == nil {
+		return ""
+	}
+	parts := strings.SplitN(string(m[1]), ".", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// loadSharedConfig reads UNITYSTARTER_CONFIG, or else the first .unitystarter.json
+// found from the project root upward. Returns an empty config when none exists.
+func loadSharedConfig(projectRoot string) (*SharedConfig, string, error) {
+	path := os.Getenv(sharedConfigEnvVar)
+	if path == "" {
+		abs, err := filepath.Abs(projectRoot)
+		if err != nil {
+			return &SharedConfig{}, "", nil
+		}
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			candidate := filepath.Join(dir, sharedConfigFileName)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				return &SharedConfig{}, "", nil
+			}
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, path, err
+	}
+	var cfg SharedConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, path, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	return &cfg, path, nil
+}
+
+// ============================================================
+// Shared Policy
+// ============================================================
+
+// A studio keeps its policy (clean lists, package categories, naming rules) in one
+// JSON document that "policy" in .unitystarter.json points at: an HTTP(S) URL, a
+// file in a git repository at a pinned revision, or a local path. Fetched copies
+// are cached in the user cache folder; a pinned commit or content hash is fetched
+// once, anything else again after "refresh". When a fetch fails the cached copy is
+// used with a warning, so tools keep working offline.
+const (
+	policyConfigFileName = ".unitystarter.json"
+	policyConfigEnvVar   = "UNITYSTARTER_CONFIG"
+	policyOfflineEnvVar  = "UNITYSTARTER_POLICY_OFFLINE" // "1": only use the cached copy
+	policyDefaultFile    = "unitystarter-policy.json"    // git: path in the repository
+	policyDefaultRefresh = time.Hour
+	policyTimeout        = 30 * time.Second
+)
+
+// policySource is the "policy" object of .unitystarter.json
+type policySource struct {
+	Source   string `json:"source"`   // https://..., git+https://....git, git@host:repo.git or a path
+	Revision string `json:"revision"` // git: commit, tag or branch (default: HEAD)
+	File     string `json:"file"`     // git: path of the document in the repository
+	SHA256   string `json:"sha256"`   // http: expected hash of the document; pins it
+	Refresh  string `json:"refresh"`  // how long an unpinned copy is used, e.g. "30m" (default: 1h)
+}
+
+// sharedPolicy is the policy document. The schema is shared by all tools in this
+// folder; each one reads the parts it applies and ignores the rest.
+type sharedPolicy struct {
+	Clean *struct {
+		Directories []string `json:"directories"` // replaces the built-in top-level folders
+		Extensions  []string `json:"extensions"`  // replaces the built-in top-level file extensions
+	} `json:"clean"`
+	Packages *struct {
+		Categories []struct {
+			Name     string   `json:"name"`
+			Packages []string `json:"packages"`
+		} `json:"categories"` // replaces the built-in removal categories
+	} `json:"packages"`
+	Naming *struct {
+		Scripts  string `json:"scripts"`  // regex new script names must match
+		Packages string `json:"packages"` // regex new package names must match
+	} `json:"naming"`
+}
+
+var gitCommitRegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// loadPolicy reads "policy" from .unitystarter.json (found like loadSharedConfig)
+// and returns the document with a description of where it came from. Returns nil
+// when no policy is configured.
+func loadPolicy(projectRoot string) (*sharedPolicy, string, error) {
+	path := os.Getenv(policyConfigEnvVar)
+	if path == "" {
+		abs, err := filepath.Abs(projectRoot)
+		if err != nil {
+			return nil, "", nil
+		}
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			candidate := filepath.Join(dir, policyConfigFileName)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				return nil, "", nil
+			}
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	var file struct {
+		Policy *policySource `json:"policy"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, "", fmt.Errorf("invalid %s: %v", path, err)
+	}
+	if file.Policy == nil || strings.TrimSpace(file.Policy.Source) == "" {
+		return nil, "", nil
+	}
+
+	doc, origin, err := fetchPolicy(file.Policy, filepath.Dir(path))
+	if err != nil {
+		return nil, origin, fmt.Errorf("policy %s: %v", origin, err)
+	}
+	var policy sharedPolicy
+	if err := json.Unmarshal(doc, &policy); err != nil {
+		return nil, origin, fmt.Errorf("invalid policy %s: %v", origin, err)
+	}
+	return &policy, origin, nil
+}
+
+// isGitSource reports whether a policy source names a git repository
+func isGitSource(source string) bool {
+	return strings.HasPrefix(source, "git+") || strings.HasPrefix(source, "git@") ||
+		strings.HasPrefix(source, "ssh://") || strings.HasSuffix(source, ".git")
+}
+
+// fetchPolicy returns the policy document, from the cache when it is pinned,
+// fresh enough or offline, and from the source otherwise
+func fetchPolicy(src *policySource, configDir string) ([]byte, string, error) {
+	source := strings.TrimSpace(src.Source)
+	isGit := isGitSource(source)
+	isHTTP := !isGit && (strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://"))
+	if !isGit && !isHTTP {
+		p := source
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(configDir, filepath.FromSlash(p))
+		}
+		data, err := os.ReadFile(p)
+		return data, p, err
+	}
+
+	revision, file := src.Revision, src.File
+	if isGit {
+		if revision == "" {
+			revision = "HEAD"
+		}
+		if file == "" {
+			file = policyDefaultFile
+		}
+	}
+	origin := source
+	if isGit {
+		origin = source + "@" + revision + ":" + file
+	}
+	refresh := policyDefaultRefresh
+	if src.Refresh != "" {
+		d, err := time.ParseDuration(src.Refresh)
+		if err != nil {
+			return nil, origin, fmt.Errorf("invalid refresh '%s': %v", src.Refresh, err)
+		}
+		refresh = d
+	}
+	pinned := (isGit && gitCommitRegex.MatchString(strings.ToLower(revision))) || (isHTTP && src.SHA256 != "")
+	matchesPin := func(data []byte) bool {
+		if src.SHA256 == "" {
+			return true
+		}
+		sum := sha256.Sum256(data)
+		return strings.EqualFold(hex.EncodeToString(sum[:]), strings.TrimSpace(src.SHA256))
+	}
+
+	// One cache file per source, revision and path
+	key := sha256.Sum256([]byte(source + "\n" + revision + "\n" + file))
+	cachePath := ""
+	if dir, err := os.UserCacheDir(); err == nil {
+		cachePath = filepath.Join(dir, "UnityStarter", "policy", hex.EncodeToString(key[:8])+".json")
+	}
+	var cached []byte
+	var cachedAt time.Time
+	if cachePath != "" {
+		if info, err := os.Stat(cachePath); err == nil {
+			if data, err := os.ReadFile(cachePath); err == nil && matchesPin(data) {
+				cached, cachedAt = data, info.ModTime()
+			}
+		}
+	}
+	if cached != nil && (pinned || time.Since(cachedAt) < refresh) {
+		return cached, origin, nil
+	}
+	if os.Getenv(policyOfflineEnvVar) == "1" {
+		if cached != nil {
+			return cached, origin, nil
+		}
+		return nil, origin, fmt.Errorf("%s is set and there is no cached copy", policyOfflineEnvVar)
+	}
+
+	var data []byte
+	var err error
+	if isGit {
+		data, err = fetchGitPolicy(strings.TrimPrefix(source, "git+"), revision, file)
+	} else {
+		data, err = fetchHTTPPolicy(source)
+	}
+	if err == nil && !matchesPin(data) {
+		err = fmt.Errorf("the document does not match the pinned sha256")
+	}
+	if err != nil {
+		if cached != nil {
+			fmt.Printf(tr("[WARNING] Cannot refresh the policy, using the copy cached %s: %v\n"), cachedAt.Format("2006-01-02 15:04"), err)
+			return cached, origin, nil
+		}
+		return nil, origin, err
+	}
+	if cachePath != "" {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err == nil {
+			tmp := cachePath + ".tmp"
+			if os.WriteFile(tmp, data, 0644) == nil {
+				os.Rename(tmp, cachePath)
+			}
+		}
+	}
+	return data, origin, nil
+}
+
+func fetchHTTPPolicy(source string) ([]byte, error) {
+	client := &http.Client{Timeout: policyTimeout}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+}
+
+// fetchGitPolicy reads one file at a revision without a full clone: a shallow
+// fetch of just that revision into a scratch repository
+func fetchGitPolicy(repo, revision, file string) ([]byte, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git is not installed")
+	}
+	tmp, err := os.MkdirTemp("", "unitystarter-policy-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	run := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("git", append([]string{"-C", tmp}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("git %s: %v %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return out, nil
+	}
+	if _, err := run("init", "-q"); err != nil {
+		return nil, err
+	}
+	if _, err := run("fetch", "-q", "--depth", "1", repo, revision); err != nil {
+		return nil, err
+	}
+	return run("show", "FETCH_HEAD:"+strings.TrimPrefix(filepath.ToSlash(file), "/"))
+}
+
+// namingRule compiles a naming regex of the policy; nil when there is none
+func namingRule(pattern string) (*regexp.Regexp, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid naming rule '%s' in the policy: %v", pattern, err)
+	}
+	return re, nil
+}
+
+// ============================================================
+// Package Layout
+// ============================================================
+
+// validatePackageName checks the name against the Package Manager rules
+func validatePackageName(name string) error {
+	if len(name) > 214 {
+		return fmt.Errorf("package name is longer than 214 characters")
+	}
+	if !packageNameRegex.MatchString(name) {
+		return fmt.Errorf("'%s' is not a valid package name (expected lower-case reverse domain, e.g. com.company.feature)", name)
+	}
+	return nil
+}
+
+// pascalWords turns "save-system" or "ui_kit" into "SaveSystem" / "UiKit"
+func pascalWords(s string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == '_' }) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// deriveNamespace builds the root namespace from the name: the company part and
+// the feature parts, "com.company.save-system" -> "Company.SaveSystem"
+func deriveNamespace(name string) string {
+	parts := strings.Split(name, ".")[1:]
+	for i, p := range parts {
+		parts[i] = pascalWords(p)
+		if parts[i] != "" && parts[i][0] >= '0' && parts[i][0] <= '9' {
+			parts[i] = "_" + parts[i]
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// deriveDisplayName turns the feature parts into words: "save-system" -> "Save System"
+func deriveDisplayName(name string) string {
+	var words []string
+	for _, p := range strings.Split(name, ".")[2:] {
+		for _, word := range strings.FieldsFunc(p, func(r rune) bool { return r == '-' || r == '_' }) {
+			words = append(words, strings.ToUpper(word[:1])+word[1:])
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// validateNamespace rejects namespaces that are not dotted C# identifiers
+func validateNamespace(ns string) error {
+	for _, part := range strings.Split(ns, ".") {
+		if !identifierRegex.MatchString(part) {
+			return fmt.Errorf("'%s' is not a valid namespace", ns)
+		}
+	}
+	return nil
+}
+
+// marshalJSON encodes v with two-space indentation, as Unity writes its own
+// package.json and .asmdef files, without escaping <, > and &
+func marshalJSON(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+	return buf.String()
+}
+
+// orderedMap keeps the key order of package.json and .asmdef files the way
+// Unity's inspectors write them
+type orderedMap []keyValue
+
+type keyValue struct {
+	key   string
+	value interface{}
+}
+
+func (m orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, kv := range m {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		key, _ := json.Marshal(kv.key)
+		buf.Write(key)
+		buf.WriteString(":")
+		var value bytes.Buffer
+		enc := json.NewEncoder(&value)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(kv.value); err != nil {
+			return nil, err
+		}
+		buf.Write(bytes.TrimRight(value.Bytes(), "\n"))
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// asmdef renders an assembly definition. Test assemblies reference NUnit and the
+// test runner and only compile when the package is testable.
+func asmdef(name, rootNamespace string, references []string, editorOnly, test bool) string {
+	platforms := []string{}
+	if editorOnly {
+		platforms = []string{"Editor"}
+	}
+	precompiled, defines := []string{}, []string{}
+	if test {
+		references = append([]string{"UnityEngine.TestRunner", "UnityEditor.TestRunner"}, references...)
+		precompiled = []string{"nunit.framework.dll"}
+		defines = []string{"UNITY_INCLUDE_TESTS"}
+	}
+	return marshalJSON(orderedMap{
+		{"name", name},
+		{"rootNamespace", rootNamespace},
+		{"references", references},
+		{"includePlatforms", platforms},
+		{"excludePlatforms", []string{}},
+		{"allowUnsafeCode", false},
+		{"overrideReferences", test},
+		{"precompiledReferences", precompiled},
+		{"autoReferenced", !test},
+		{"defineConstraints", defines},
+		{"versionDefines", []string{}},
+		{"noEngineReferences", false},
+	})
+}
+
+// packageJSON renders package.json; "unity" is left out when the project
+// version is unknown so the package does not claim a wrong minimum
+func packageJSON(s *packageSpec) string {
+	m := orderedMap{
+		{"name", s.name},
+		{"version", s.version},
+		{"displayName", s.displayName},
+		{"description", s.description},
+	}
+	if s.unity != "" {
+		m = append(m, keyValue{"unity", s.unity})
+	}
+	m = append(m, keyValue{"dependencies", orderedMap{}})
+	if s.author != "" {
+		m = append(m, keyValue{"author", orderedMap{{"name", s.author}}})
+	}
+	m = append(m, keyValue{"license", s.license})
+	if s.samples {
+		m = append(m, keyValue{"samples", []orderedMap{{
+			{"displayName", "Example"},
+			{"description", "Shows how to use " + s.displayName + "."},
+			{"path", "Samples~/Example"},
+		}}})
+	}
+	return marshalJSON(m)
+}
+
+// licenseText renders LICENSE.md: the full text for MIT, a notice otherwise
+func licenseText(s *packageSpec) string {
+	holder := s.author
+	if holder == "" {
+		holder = "the authors"
+	}
+	switch strings.ToUpper(s.license) {
+	case "MIT":
+		return fmt.Sprintf(mitLicense, s.year, holder)
+	case "PROPRIETARY", "UNLICENSED":
+		return fmt.Sprintf("Copyright (c) %s %s. All rights reserved.\n\nThis package is proprietary and may not be copied, modified or distributed\nwithout the written permission of the copyright holder.\n", s.year, holder)
+	}
+	return fmt.Sprintf("Copyright (c) %s %s\n\nLicensed under %s. See https://spdx.org/licenses/%s.html for the full text.\n", s.year, holder, s.license, s.license)
+}
+
+// scaffoldFiles lists the package's files in the order they are written
+func scaffoldFiles(s *packageSpec) []packageFile {
+	runtimeName := s.namespace
+	editorName := s.namespace + ".Editor"
+	intro := ""
+	if s.description != "" {
+		intro = s.description + "\n\n"
+	}
+	usage := fmt.Sprintf("The runtime code is in the `%s` assembly (`Runtime/`).\n", runtimeName)
+	if s.editor {
+		usage = fmt.Sprintf("The runtime code is in the `%s` assembly (`Runtime/`), editor code in `%s` (`Editor/`).\n", runtimeName, editorName)
+	}
+	files := []packageFile{
+		{"package.json", packageJSON(s)},
+		{"README.md", fmt.Sprintf("# %s\n\n%s## Installation\n\nThis package is embedded in the project under `Packages/%s`. To use it in another project, copy the folder into that project's `Packages/` folder, or add it in the Package Manager with *Add package from disk*.\n\n## Usage\n\n%s", s.displayName, intro, s.name, usage)},
+		{"CHANGELOG.md", fmt.Sprintf("# Changelog\n\nAll notable changes to this package are documented in this file.\nThe format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),\nand this package follows [Semantic Versioning](https://semver.org/).\n\n## [%s] - %s\n\n### Added\n\n- Initial package.\n", s.version, time.Now().Format("2006-01-02"))},
+		{"LICENSE.md", licenseText(s)},
+		{"Runtime/" + runtimeName + ".asmdef", asmdef(runtimeName, s.namespace, []string{}, false, false)},
+	}
+	if s.editor {
+		files = append(files, packageFile{"Editor/" + editorName + ".asmdef", asmdef(editorName, editorName, []string{runtimeName}, true, false)})
+	}
+	if s.tests {
+		files = append(files, packageFile{"Tests/Runtime/" + runtimeName + ".Tests.asmdef", asmdef(runtimeName+".Tests", runtimeName+".Tests", []string{runtimeName}, false, true)})
+		refs := []string{runtimeName}
+		if s.editor {
+			refs = append(refs, editorName)
+		}
+		files = append(files, packageFile{"Tests/Editor/" + editorName + ".Tests.asmdef", asmdef(editorName+".Tests", editorName+".Tests", refs, true, true)})
+	}
+	if s.samples {
+		// Samples~ is copied into Assets/Samples on import; its asmdef keeps the
+		// sample scripts next to the package's own assembly
+		files = append(files,
+			packageFile{"Samples~/Example/" + runtimeName + ".Samples.asmdef", asmdef(runtimeName+".Samples", runtimeName+".Samples", []string{runtimeName}, false, false)},
+			packageFile{"Samples~/Example/README.md", fmt.Sprintf("# %s Example\n\nImport this sample from the Package Manager window (%s > Samples) and put its scripts in this folder.\n", s.displayName, s.displayName)},
+		)
+	}
+	return files
+}
+
+// isHidden reports whether a package-relative path is inside a folder Unity does
+// not import (ending in "~" or starting with "."), which takes no .meta
+func isHidden(rel string) bool {
+	for _, part := range strings.Split(rel, "/") {
+		if strings.HasSuffix(part, "~") || strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// newGUID returns a random 32-digit hex GUID in Unity's .meta format
+func newGUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// writePackage creates the package folder and its files. Folders and files Unity
+// imports get a .meta with a fresh GUID; everything is marked for add.
+func writePackage(root string, files []packageFile) ([]string, error) {
+	if err := fsys.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	var written []string
+	folders := make(map[string]bool)
+	for _, f := range files {
+		// Parent folders first, each with its .meta
+		var dirs []string
+		for d := path.Dir(f.path); d != "."; d = path.Dir(d) {
+			dirs = append([]string{d}, dirs...)
+		}
+		for _, d := range dirs {
+			if folders[d] {
+				continue
+			}
+			folders[d] = true
+			abs := filepath.Join(root, filepath.FromSlash(d))
+			if err := fsys.MkdirAll(abs, 0755); err != nil {
+				return written, err
+			}
+			if isHidden(d) {
+				continue
+			}
+			if err := writeFileAtomic(abs+".meta", []byte(fmt.Sprintf(folderMetaTemplate, newGUID()))); err != nil {
+				return written, err
+			}
+			activeVCS.add(abs + ".meta")
+		}
+
+		abs := filepath.Join(root, filepath.FromSlash(f.path))
+		if err := writeTextFileAtomic(abs, f.content, textFormat{}); err != nil {
+			return written, err
+		}
+		activeVCS.add(abs)
+		written = append(written, f.path)
+		if isHidden(f.path) {
+			continue
+		}
+		meta := fileMetaTemplates[path.Ext(f.path)]
+		if meta == "" {
+			meta = fileMetaTemplates[".md"]
+		}
+		if err := writeTextFileAtomic(abs+".meta", fmt.Sprintf(meta, newGUID()), textFormat{}); err != nil {
+			return written, fmt.Errorf("wrote %s but not its .meta: %v", f.path, err)
+		}
+		activeVCS.add(abs + ".meta")
+	}
+	return written, nil
+}
+
+// ============================================================
+// Manifest
+// ============================================================
+
+// orderedObject is a JSON object that keeps its key order, so adding the
+// testable only changes that entry in the manifest diff
+type orderedObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+func parseOrderedObject(data []byte) (*orderedObject, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	obj := &orderedObject{values: make(map[string]json.RawMessage)}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		obj.set(key, value)
+	}
+	return obj, nil
+}
+
+func (o *orderedObject) set(key string, value json.RawMessage) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// marshal writes the object with two-space indentation, as Unity does
+func (o *orderedObject) marshal() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	for i, key := range o.keys {
+		buf.WriteString("  ")
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteString(": ")
+		if err := json.Indent(&buf, o.values[key], "  ", "  "); err != nil {
+			buf.Write(o.values[key])
+		}
+		if i < len(o.keys)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}")
+	return buf.Bytes()
+}
+
+// manifestInfo is what the creator needs to know about Packages/manifest.json
+type manifestInfo struct {
+	root          *orderedObject
+	format        textFormat
+	trailingLF    bool
+	dependencies  map[string]string
+	testables     []string
+	testFramework bool
+}
+
+func readManifest(manifestPath string) (*manifestInfo, error) {
+	data, err := fsys.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	text, format := decodeText(data)
+	root, err := parseOrderedObject([]byte(text))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Packages/manifest.json: %v", err)
+	}
+	m := &manifestInfo{root: root, format: format, trailingLF: strings.HasSuffix(text, "\n")}
+	if raw, ok := root.values["dependencies"]; ok {
+		if err := json.Unmarshal(raw, &m.dependencies); err != nil {
+			return nil, fmt.Errorf("invalid dependencies in Packages/manifest.json: %v", err)
+		}
+	}
+	if raw, ok := root.values["testables"]; ok {
+		if err := json.Unmarshal(raw, &m.testables); err != nil {
+			return nil, fmt.Errorf("invalid testables in Packages/manifest.json: %v", err)
+		}
+	}
+	_, m.testFramework = m.dependencies[testFrameworkPackage]
+	return m, nil
+}
+
+// addTestable adds the package to "testables", creating the list at the end of
+// the manifest when it has none. Returns false when it is already there.
+func addTestable(manifestPath string, m *manifestInfo, name string) (bool, error) {
+	for _, t := range m.testables {
+		if t == name {
+			return false, nil
+		}
+	}
+	list, _ := json.Marshal(append(m.testables, name))
+	m.root.set("testables", list)
+	out := string(m.root.marshal())
+	if m.trailingLF {
+		out += "\n"
+	}
+	return true, writeTextFileAtomic(manifestPath, out, m.format)
+}
+
+// ============================================================
+// Version Control
+// ============================================================
+
+// The new package files and their .meta files are marked for add (p4 add, cm add)
+// so they land in the next changelist / changeset; the manifest is checked out
+// before testables is changed. Git needs nothing: new files show up as untracked.
+const (
+	vcsNone     = "none"
+	vcsPerforce = "p4"
+	vcsPlastic  = "plastic"
+)
+
+// vcsEnvVar overrides detection for every tool (e.g. on build agents)
+const vcsEnvVar = "UNITYSTARTER_VCS"
+
+// vcsClient runs checkouts and adds for files under version control
+type vcsClient struct {
+	kind       string
+	source     string // how kind was chosen, for the startup message
+	checkedOut map[string]bool
+}
+
+// activeVCS is set up in main; nil means no version control commands
+var activeVCS *vcsClient
+
+// detectVCS picks the provider. Priority: -vcs flag > UNITYSTARTER_VCS >
+// Unity's VersionControlSettings.asset mode > workspace markers (.plastic, P4CONFIG).
+func detectVCS(projectRoot, override string) (*vcsClient, error) {
+	normalize := func(v string) (string, bool) {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "p4", "perforce":
+			return vcsPerforce, true
+		case "plastic", "plasticscm", "cm", "unityvcs":
+			return vcsPlastic, true
+		case "none", "git", "off":
+			return vcsNone, true
+		}
+		return "", false
+	}
+	newClient := func(kind, source string) *vcsClient {
+		return &vcsClient{kind: kind, source: source, checkedOut: make(map[string]bool)}
+	}
+
+	if override != "" && override != "auto" {
+		kind, ok := normalize(override)
+		if !ok {
+			return nil, fmt.Errorf("unknown -vcs value '%s' (use auto, none, p4 or plastic)", override)
+		}
+		return newClient(kind, "-vcs flag"), nil
+	}
+	if env := os.Getenv(vcsEnvVar); env != "" {
+		if kind, ok := normalize(env); ok {
+			return newClient(kind, vcsEnvVar), nil
+		}
+	}
+
+	// Unity stores the project's integrated VCS in m_Mode ("Perforce", "PlasticSCM", ...)
+	if data, err := os.ReadFile(filepath.Join(projectRoot, "ProjectSettings", "VersionControlSettings.asset")); err == nil {
+		if m := regexp.MustCompile(`(?m)^\s*m_Mode:\s*(.+?)\s*$`).FindSubmatch(data); m != nil {
+			switch strings.ToLower(string(m[1])) {
+			case "perforce":
+				return newClient(vcsPerforce, "VersionControlSettings.asset"), nil
+			case "plasticscm", "plastic scm", "unity version control":
+				return newClient(vcsPlastic, "VersionControlSettings.asset"), nil
+			}
+		}
+	}
+
+	// Workspace markers, searched upward from the project root
+	p4config := os.Getenv("P4CONFIG")
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info != nil {
+				return newClient(vcsNone, "git repository"), nil
+			}
+			if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+				return newClient(vcsPlastic, ".plastic workspace"), nil
+			}
+			if p4config != "" {
+				if _, err := os.Stat(filepath.Join(dir, p4config)); err == nil {
+					return newClient(vcsPerforce, "P4CONFIG"), nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if os.Getenv("P4CLIENT") != "" {
+		return newClient(vcsPerforce, "P4CLIENT"), nil
+	}
+	return newClient(vcsNone, "no Perforce/Plastic workspace found"), nil
+}
+
+// run executes a VCS command from dir; a dry run only lists it
+func (v *vcsClient) run(dir string, args ...string) error {
+	if overlay, ok := fsys.(*overlayFS); ok {
+		overlay.noteCommand(args...)
+		return nil
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	// Run from the file's folder so P4CONFIG / the Plastic workspace is resolved
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %v %s", args[0], args[1], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// checkout opens an existing file for edit. Files outside the depot/workspace only
+// produce a warning: the write still goes ahead.
+func (v *vcsClient) checkout(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || v.checkedOut[abs] {
+		return
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return
+	}
+	v.checkedOut[abs] = true
+
+	var err2 error
+	switch v.kind {
+	case vcsPerforce:
+		err2 = v.run(filepath.Dir(abs), "p4", "edit", abs)
+	case vcsPlastic:
+		err2 = v.run(filepath.Dir(abs), "cm", "checkout", abs)
+	}
+	if err2 != nil {
+		fmt.Printf(tr("[WARNING] %s checkout failed for %s: %v\n"), v.kind, filepath.Base(abs), err2)
+		return
+	}
+	fmt.Printf(tr("[VCS] Checked out (%s): %s\n"), v.kind, filepath.Base(abs))
+}
+
+// add marks a newly created file for add
+func (v *vcsClient) add(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	var err2 error
+	switch v.kind {
+	case vcsPerforce:
+		err2 = v.run(filepath.Dir(abs), "p4", "add", abs)
+	case vcsPlastic:
+		err2 = v.run(filepath.Dir(abs), "cm", "add", abs)
+	}
+	if err2 != nil {
+		fmt.Printf(tr("[WARNING] %s add failed for %s: %v\n"), v.kind, filepath.Base(abs), err2)
+	}
+}
+
+// ============================================================
+// Safe File Writes
+// ============================================================
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// writeFileAtomic replaces path with data without ever leaving a half-written file:
+// data goes to a temp file in the same directory which is then renamed over the target.
+// The target's permission bits are kept, and a read-only flag (files under Perforce or
+// Plastic SCM that were not checked out) is cleared so the write does not fail.
+func writeFileAtomic(path string, data []byte) error {
+	// A dry run writes straight into the overlay: no checkout, no temp file
+	if isDryRun() {
+		return fsys.WriteFile(path, data, 0644)
+	}
+	activeVCS.checkout(path)
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if mode&0200 == 0 {
+			mode |= 0200
+			if err := os.Chmod(path, mode); err != nil {
+				return fmt.Errorf("cannot clear read-only flag on %s: %v", path, err)
+			}
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Retry briefly: editors, indexers and antivirus can hold the target open on Windows
+	for attempt := 0; ; attempt++ {
+		err = os.Rename(tmpPath, path)
+		if err == nil || attempt == 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// textFormat records the BOM and line-ending style of a text file so rewrites keep it
+type textFormat struct {
+	bom  bool
+	crlf bool // ending of most lines, and of the lines a rewrite adds
+	// Mixed files only: the text as read (LF) and the ending of each of its line
+	// breaks, 'r' (CRLF) or 'n' (LF). Strings keep textFormat comparable.
+	orig string
+	eols string
+}
+
+// decodeText strips a UTF-8 BOM and normalizes line endings to LF. A file counts as
+// CRLF when most of its line breaks are CRLF; a file with both keeps the ending of
+// every line, so a rewrite only changes the endings of the lines it edits.
+func decodeText(data []byte) (string, textFormat) {
+	var format textFormat
+	if bytes.HasPrefix(data, utf8BOM) {
+		format.bom = true
+		data = data[len(utf8BOM):]
+	}
+	crlfCount := bytes.Count(data, []byte("\r\n"))
+	lfCount := bytes.Count(data, []byte("\n")) - crlfCount
+	format.crlf = crlfCount > 0 && crlfCount >= lfCount
+	if crlfCount == 0 {
+		return string(data), format
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if lfCount > 0 {
+		eols := make([]byte, 0, crlfCount+lfCount)
+		for i, c := range data {
+			if c == '\n' {
+				if i > 0 && data[i-1] == '\r' {
+					eols = append(eols, 'r')
+				} else {
+					eols = append(eols, 'n')
+				}
+			}
+		}
+		format.orig, format.eols = text, string(eols)
+	}
+	return text, format
+}
+
+// encode converts LF text back to the recorded format
+func (f textFormat) encode(text string) []byte {
+	switch {
+	case f.eols != "":
+		text = f.mixedEndings(text)
+	case f.crlf:
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	if f.bom {
+		return append(append([]byte{}, utf8BOM...), text...)
+	}
+	return []byte(text)
+}
+
+// mixedEndings gives the lines of a rewritten mixed file the endings they had. The
+// unchanged lines before and after the edited region keep theirs; edited lines keep
+// the ending of the line they replace when the line count did not change, and get
+// the majority ending otherwise.
+func (f textFormat) mixedEndings(text string) string {
+	old := strings.Split(f.orig, "\n")
+	lines := strings.Split(text, "\n")
+	prefix := 0
+	for prefix < len(old) && prefix < len(lines) && old[prefix] == lines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(lines)-prefix && old[len(old)-1-suffix] == lines[len(lines)-1-suffix] {
+		suffix++
+	}
+	crlfAt := func(j int) bool {
+		if j >= 0 && j < len(f.eols) {
+			return f.eols[j] == 'r'
+		}
+		return f.crlf
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		b.WriteString(line)
+		if i == len(lines)-1 {
+			break
+		}
+		crlf := f.crlf
+		switch {
+		case i < prefix || len(lines) == len(old):
+			crlf = crlfAt(i)
+		case i >= len(lines)-suffix:
+			crlf = crlfAt(i - len(lines) + len(old))
+		}
+		if crlf {
+			b.WriteByte('\r')
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// writeTextFileAtomic writes LF text in the given format via writeFileAtomic
+func writeTextFileAtomic(path, text string, format textFormat) error {
+	return writeFileAtomic(path, format.encode(text))
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Filesystem
+// ============================================================
+
+// fileSystem is every filesystem call that can change the project. The real
+// run uses osFS; -dry-run swaps in an overlayFS so the same code path runs
+// and the overlay reports what it would have changed.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Walk(root string, fn filepath.WalkFunc) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Chmod(name string, mode os.FileMode) error
+}
+
+var fsys fileSystem = osFS{}
+
+// isDryRun reports whether changes are going to the in-memory overlay
+func isDryRun() bool {
+	_, ok := fsys.(*overlayFS)
+	return ok
+}
+
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)         { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) Walk(root string, fn filepath.WalkFunc) error { return filepath.Walk(root, fn) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// fsChange is one change the overlay absorbed instead of the disk
+type fsChange struct {
+	op     string // "write", "create", "mkdir", "rename", "delete"
+	path   string
+	detail string
+}
+
+// overlayFS reads through to the disk and keeps every write, rename and
+// delete in memory. Later reads see those changes, so multi-step operations
+// (rename a folder, then rewrite files inside it) behave as they would for real.
+type overlayFS struct {
+	mu      sync.Mutex
+	files   map[string][]byte // files written during the run
+	dirs    map[string]bool   // directories created during the run
+	moved   map[string]string // renamed path -> where its content is on disk
+	removed map[string]bool   // deleted paths; hides everything below them
+	changes []fsChange
+}
+
+func newOverlayFS() *overlayFS {
+	return &overlayFS{
+		files:   map[string][]byte{},
+		dirs:    map[string]bool{},
+		moved:   map[string]string{},
+		removed: map[string]bool{},
+	}
+}
+
+func overlayKey(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// isUnder reports whether p is base or inside it
+func isUnder(p, base string) bool {
+	return p == base || strings.HasPrefix(p, strings.TrimSuffix(base, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// locate finds where the content of p lives: in memory (data / memDir) or
+// on disk (disk, after following renames). ok is false if p was deleted.
+func (o *overlayFS) locate(p string) (data []byte, memFile, memDir bool, disk string, ok bool) {
+	if d, found := o.files[p]; found {
+		return d, true, false, "", true
+	}
+	if o.dirs[p] {
+		return nil, false, true, "", true
+	}
+	for q := p; ; {
+		if src, found := o.moved[q]; found {
+			rel, _ := filepath.Rel(q, p)
+			return nil, false, false, filepath.Join(src, rel), true
+		}
+		if o.removed[q] {
+			return nil, false, false, "", false
+		}
+		parent := filepath.Dir(q)
+		if parent == q {
+			break
+		}
+		q = parent
+	}
+	return nil, false, false, p, true
+}
+
+func (o *overlayFS) stat(p string) (os.FileInfo, error) {
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	case memFile:
+		return memFileInfo{name: filepath.Base(p), size: int64(len(data))}, nil
+	case memDir:
+		return memFileInfo{name: filepath.Base(p), dir: true}, nil
+	}
+	info, err := os.Stat(disk)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	if disk != p {
+		return renamedFileInfo{info, filepath.Base(p)}, nil
+	}
+	return info, nil
+}
+
+func (o *overlayFS) Stat(name string) (os.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stat(overlayKey(name))
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case memDir:
+		return nil, &os.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	case memFile:
+		return append([]byte(nil), data...), nil
+	}
+	return os.ReadFile(disk)
+}
+
+func (o *overlayFS) readDir(p string) ([]os.DirEntry, error) {
+	_, memFile, memDir, disk, ok := o.locate(p)
+	if !ok || memFile {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+	names := map[string]bool{}
+	if !memDir {
+		entries, err := os.ReadDir(disk)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			names[e.Name()] = true
+		}
+	}
+	for k := range o.dirs {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.files {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.moved {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	var result []os.DirEntry
+	for name := range names {
+		if info, err := o.stat(filepath.Join(p, name)); err == nil {
+			result = append(result, fs.FileInfoToDirEntry(info))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (o *overlayFS) ReadDir(name string) ([]os.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readDir(overlayKey(name))
+}
+
+// Walk follows filepath.Walk: lexical order, SkipDir and SkipAll honored.
+// The lock is released while fn runs so the callback can change the overlay.
+func (o *overlayFS) Walk(root string, fn filepath.WalkFunc) error {
+	info, err := o.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = o.walk(root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (o *overlayFS) walk(path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	entries, err := o.ReadDir(path)
+	if err1 := fn(path, info, err); err != nil || err1 != nil {
+		return err1
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		childInfo, err := o.Stat(child)
+		if err != nil {
+			if err := fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := o.walk(child, childInfo, fn); err != nil {
+			if err == filepath.SkipDir && childInfo.IsDir() {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *overlayFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	op := "create"
+	if info, err := o.stat(p); err == nil {
+		if info.IsDir() {
+			return &os.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+		}
+		op = "write"
+	}
+	if info, err := o.stat(filepath.Dir(p)); err != nil || !info.IsDir() {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	o.files[p] = append([]byte(nil), data...)
+	o.changes = append(o.changes, fsChange{op, p, fmt.Sprintf("%d bytes", len(data))})
+	return nil
+}
+
+func (o *overlayFS) MkdirAll(path string, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	created := false
+	for q := p; ; q = filepath.Dir(q) {
+		if info, err := o.stat(q); err == nil {
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: q, Err: errors.New("not a directory")}
+			}
+			break
+		}
+		o.dirs[q] = true
+		created = true
+		if filepath.Dir(q) == q {
+			break
+		}
+	}
+	if created {
+		o.changes = append(o.changes, fsChange{"mkdir", p, ""})
+	}
+	return nil
+}
+
+// shift moves every overlay entry under from to the same place under to
+func (o *overlayFS) shift(from, to string) {
+	for k, v := range o.files {
+		if isUnder(k, from) {
+			delete(o.files, k)
+			o.files[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, from) {
+			delete(o.dirs, k)
+			o.dirs[to+strings.TrimPrefix(k, from)] = true
+		}
+	}
+	for k, v := range o.moved {
+		if isUnder(k, from) {
+			delete(o.moved, k)
+			o.moved[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+}
+
+func (o *overlayFS) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	from, to := overlayKey(oldpath), overlayKey(newpath)
+	_, _, _, disk, ok := o.locate(from)
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if from == to {
+		return nil
+	}
+	if info, err := o.stat(filepath.Dir(to)); err != nil || !info.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	o.drop(to)
+	o.shift(from, to)
+	if disk != "" {
+		if _, err := os.Stat(disk); err == nil {
+			o.moved[to] = disk
+		}
+	}
+	o.removed[from] = true
+	o.changes = append(o.changes, fsChange{"rename", from, to})
+	return nil
+}
+
+// drop forgets p and everything below it and hides it from the disk
+func (o *overlayFS) drop(p string) {
+	for k := range o.files {
+		if isUnder(k, p) {
+			delete(o.files, k)
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, p) {
+			delete(o.dirs, k)
+		}
+	}
+	for k := range o.moved {
+		if isUnder(k, p) {
+			delete(o.moved, k)
+		}
+	}
+	o.removed[p] = true
+}
+
+// treeSize is the byte size of p as the overlay currently sees it
+func (o *overlayFS) treeSize(p string) int64 {
+	info, err := o.stat(p)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var size int64
+	entries, _ := o.readDir(p)
+	for _, e := range entries {
+		size += o.treeSize(filepath.Join(p, e.Name()))
+	}
+	return size
+}
+
+func (o *overlayFS) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	info, err := o.stat(p)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	if info.IsDir() {
+		if entries, _ := o.readDir(p); len(entries) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", info.Size())})
+	return nil
+}
+
+func (o *overlayFS) RemoveAll(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	if _, err := o.stat(p); err != nil {
+		return nil
+	}
+	size := o.treeSize(p)
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", size)})
+	return nil
+}
+
+func (o *overlayFS) Chmod(name string, mode os.FileMode) error {
+	if _, err := o.Stat(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+// noteCommand lists a step the overlay cannot simulate, such as a p4 or cm
+// command
+func (o *overlayFS) noteCommand(args ...string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.changes = append(o.changes, fsChange{"run", strings.Join(args, " "), ""})
+}
+
+// printDryRunReport lists what the overlay absorbed, relative to base
+func (o *overlayFS) printDryRunReport(base string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if abs, err := filepath.Abs(base); err == nil {
+		base = abs
+	}
+	if len(o.changes) == 0 {
+		fmt.Println(tr("\n[Dry Run] No changes would be made."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d change(s) would be made; nothing was written:\n"), len(o.changes))
+	rel := func(p string) string {
+		if r, err := filepath.Rel(base, p); err == nil && !strings.HasPrefix(r, "..") {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	for _, c := range o.changes {
+		switch c.op {
+		case "rename":
+			fmt.Printf("  %-7s %s -> %s\n", c.op, rel(c.path), rel(c.detail))
+		case "mkdir", "run":
+			fmt.Printf("  %-7s %s\n", c.op, rel(c.path))
+		default:
+			fmt.Printf("  %-7s %s (%s)\n", c.op, rel(c.path), c.detail)
+		}
+	}
+}
+
+// memFileInfo describes a file or directory that only exists in the overlay
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (m memFileInfo) Name() string       { return m.name }
+func (m memFileInfo) Size() int64        { return m.size }
+func (m memFileInfo) ModTime() time.Time { return time.Time{} }
+func (m memFileInfo) IsDir() bool        { return m.dir }
+func (m memFileInfo) Sys() interface{}   { return nil }
+func (m memFileInfo) Mode() os.FileMode {
+	if m.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// renamedFileInfo is a disk entry seen under the name it was renamed to
+type renamedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (r renamedFileInfo) Name() string { return r.name }
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"  Author:    %s\n":          "  作者:     %s\n",
+	"  Config:    %s\n":          "  配置:     %s\n",
+	"  License:   %s\n":          "  许可证:   %s\n",
+	"  Namespace: %s\n":          "  命名空间: %s\n",
+	"  Package:   %s %s (%s)\n":  "  包:       %s %s（%s）\n",
+	"  UNITY PACKAGE CREATOR":    "  Unity 包创建工具",
+	"  Unity:     %s or newer\n": "  Unity:    %s 或更高版本\n",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":     "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"Usage: unity_package_creator [flags] <com.company.feature>": "用法: unity_package_creator [参数] <com.company.feature>",
+	"Version control: %s (%s), new files are marked for add\n":   "版本控制: %s (%s)，新文件会被标记为添加\n",
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                                                 "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.":                           "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"[OK] Added to testables in Packages/manifest.json":                                          "[OK] 已添加到 Packages/manifest.json 的 testables",
+	"[OK] Created: %s/%s\n":                                                                      "[OK] 已创建: %s/%s\n",
+	"[VCS] Checked out (%s): %s\n":                                                               "[VCS] 已签出（%s）: %s\n",
+	"[WARNING] %s add failed for %s: %v\n":                                                       "[WARNING] %s 添加 %s 失败: %v\n",
+	"[WARNING] %s checkout failed for %s: %v\n":                                                  "[WARNING] %s 签出 %s 失败: %v\n",
+	"[WARNING] %s is also a dependency in the manifest (%s); the embedded package replaces it\n": "[WARNING] %s 同时是清单中的依赖（%s）；嵌入式包会取代它\n",
+	"[WARNING] %s is not in the manifest; the test assemblies need it to compile\n":              "[WARNING] 清单中没有 %s；测试程序集需要它才能编译\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n":                                  "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+	"[WARNING] Removing a stale %s (%s)\n":                                                       "[WARNING] 删除过期的 %s（%s）\n",
+	"\nPress Enter to continue...":                                                               "\n按回车键继续...",
+	"\n[Dry Run] %d change(s) would be made; nothing was written:\n":                             "\n[Dry Run] 将进行 %d 处更改；未写入任何内容:\n",
+	"\n[Dry Run] No changes would be made.":                                                      "\n[Dry Run] 不会进行任何更改。",
+	"\n[Dry Run] No files were written.":                                                         "\n[Dry Run] 未写入任何文件。",
+	"\n[TIP] Unity imports the package when it gets focus; add its scripts under %s.\n":          "\n[TIP] Unity 获得焦点时会导入该包；请将脚本添加到 %s 下。\n",
+	"[WARNING] Cannot refresh the policy, using the copy cached %s: %v\n":                        "[WARNING] 无法刷新策略，使用 %s 缓存的副本: %v\n",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode, dryRun, force, noEditor, noTests, noSamples, noTestables bool
+	var displayName, description, namespaceFlag, version, author, license, vcsMode string
+
+	flag.StringVar(&displayName, "display-name", "", "Name shown in the Package Manager (default: from the package name)")
+	flag.StringVar(&description, "description", "", "Package description")
+	flag.StringVar(&namespaceFlag, "namespace", "", "Root namespace and runtime assembly name (default: from the package name)")
+	flag.StringVar(&version, "version", defaultVersion, "Initial version")
+	flag.StringVar(&author, "author", "", "Author (default: author in .unitystarter.json, else the company name)")
+	flag.StringVar(&license, "license", "", "SPDX license identifier (default: license in .unitystarter.json, else "+defaultLicense+")")
+	flag.BoolVar(&noEditor, "no-editor", false, "No Editor assembly")
+	flag.BoolVar(&noTests, "no-tests", false, "No test assemblies (and no testables entry)")
+	flag.BoolVar(&noSamples, "no-samples", false, "No Samples~ folder")
+	flag.BoolVar(&noTestables, "no-testables", false, "Do not add the package to testables in Packages/manifest.json")
+	flag.BoolVar(&dryRun, "dry-run", false, "Print what would be created without writing anything")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
+	flag.StringVar(&vcsMode, "vcs", "auto", "Mark new files for add and check out the manifest: auto, none, p4, plastic")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the name ("com.company.feature -no-tests")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_package_creator")
+		ciMode = true
+	}
+	if dryRun {
+		fsys = newOverlayFS()
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	if len(args) != 1 {
+		fmt.Println(tr("Usage: unity_package_creator [flags] <com.company.feature>"))
+		recordError("expected one package name")
+		exit(2)
+	}
+	name := args[0]
+	if err := validatePackageName(name); err != nil {
+		fail(2, "%v", err)
+	}
+	if !semverRegex.MatchString(version) {
+		fail(2, "'%s' is not a valid version (expected major.minor.patch)", version)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	cfg, cfgPath, err := loadSharedConfig(basePath)
+	if err != nil {
+		fail(1, "cannot read %s: %v", cfgPath, err)
+	}
+	policy, policyOrigin, err := loadPolicy(basePath)
+	if err != nil {
+		fail(1, "%v", err)
+	}
+	if policy != nil && policy.Naming != nil {
+		rule, err := namingRule(policy.Naming.Packages)
+		if err != nil {
+			fail(1, "%v", err)
+		}
+		if rule != nil && !rule.MatchString(name) {
+			fail(2, "'%s' does not match the naming rule %s of the policy %s", name, rule, policyOrigin)
+		}
+	}
+
+	spec := &packageSpec{
+		name: name, displayName: displayName, description: description, version: version,
+		unity: readUnityVersion(basePath), namespace: namespaceFlag, author: author, license: license,
+		year: cfg.CopyrightYear, editor: !noEditor, tests: !noTests, samples: !noSamples,
+	}
+	if spec.displayName == "" {
+		spec.displayName = deriveDisplayName(name)
+	}
+	if spec.namespace == "" {
+		spec.namespace = deriveNamespace(name)
+	}
+	if err := validateNamespace(spec.namespace); err != nil {
+		fail(2, "%v", err)
+	}
+	if spec.author == "" {
+		spec.author = cfg.Author
+	}
+	if spec.author == "" {
+		spec.author = readCompany(basePath)
+	}
+	if spec.license == "" {
+		spec.license = cfg.License
+	}
+	if spec.license == "" {
+		spec.license = defaultLicense
+	}
+	if spec.year == "" {
+		spec.year = strconv.Itoa(time.Now().Year())
+	}
+
+	rel := "Packages/" + name
+	root := filepath.Join(basePath, "Packages", name)
+	if _, err := fsys.Stat(root); err == nil {
+		fail(1, "%s already exists", rel)
+	}
+	manifestPath := filepath.Join(basePath, "Packages", "manifest.json")
+	manifest, err := readManifest(manifestPath)
+	if err != nil {
+		fail(1, "%v", err)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  UNITY PACKAGE CREATOR"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Package:   %s %s (%s)\n"), spec.name, spec.version, spec.displayName)
+	fmt.Printf(tr("  Namespace: %s\n"), spec.namespace)
+	if spec.unity != "" {
+		fmt.Printf(tr("  Unity:     %s or newer\n"), spec.unity)
+	}
+	fmt.Printf(tr("  Author:    %s\n"), spec.author)
+	fmt.Printf(tr("  License:   %s\n"), spec.license)
+	if cfgPath != "" {
+		fmt.Printf(tr("  Config:    %s\n"), cfgPath)
+	}
+	if v, ok := manifest.dependencies[name]; ok {
+		fmt.Printf(tr("[WARNING] %s is also a dependency in the manifest (%s); the embedded package replaces it\n"), name, v)
+	}
+
+	if !dryRun {
+		if err := acquireProjectLock(basePath, "unity_package_creator", "create "+name, force); err != nil {
+			fail(1, "%v", err)
+		}
+		defer releaseProjectLock()
+	}
+	if activeVCS, err = detectVCS(basePath, vcsMode); err != nil {
+		fail(1, "%v", err)
+	}
+	if activeVCS.kind != vcsNone {
+		fmt.Printf(tr("Version control: %s (%s), new files are marked for add\n"), activeVCS.kind, activeVCS.source)
+	}
+
+	fmt.Println()
+	files := scaffoldFiles(spec)
+	written, err := writePackage(root, files)
+	for _, f := range written {
+		fmt.Printf(tr("[OK] Created: %s/%s\n"), rel, f)
+		recordAction("create", rel+"/"+f, "ok", "", 0)
+	}
+	if err != nil {
+		fail(1, "cannot write %s: %v", rel, err)
+	}
+	if !dryRun {
+		recordArtifact(root)
+	}
+
+	if spec.tests && !noTestables {
+		added, err := addTestable(manifestPath, manifest, name)
+		if err != nil {
+			fail(1, "cannot write Packages/manifest.json: %v", err)
+		}
+		if added {
+			fmt.Println(tr("[OK] Added to testables in Packages/manifest.json"))
+			recordAction("modify", "Packages/manifest.json", "ok", "testables += "+name, 0)
+		}
+		if !manifest.testFramework {
+			fmt.Printf(tr("[WARNING] %s is not in the manifest; the test assemblies need it to compile\n"), testFrameworkPackage)
+		}
+	}
+
+	if overlay, ok := fsys.(*overlayFS); ok {
+		printRule("---------------------------------------------")
+		fmt.Print(files[0].content)
+		printRule("---------------------------------------------")
+		overlay.printDryRunReport(basePath)
+		fmt.Println(tr("\n[Dry Run] No files were written."))
+		exit(0)
+	}
+	fmt.Printf(tr("\n[TIP] Unity imports the package when it gets focus; add its scripts under %s.\n"), rel)
+	exit(0)
+}
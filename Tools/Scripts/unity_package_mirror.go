@@ -0,0 +1,2457 @@
+// Unity Package Mirror — Offline copy of every registry package a project uses.
+// Downloads the packages in Packages/manifest.json, including the transitive
+// dependencies resolved in packages-lock.json, as tarballs into a local mirror folder,
+// and can rewrite the manifest to use that mirror through file: references or a
+// scoped registry served by this tool. Build machines without internet access can
+// then open the project with only the mirror folder. -check-updates instead
+// lists the direct dependencies that have a newer version and gathers the
+// changelog sections of the releases in between into one review document.
+//
+// Build: go build unity_package_mirror.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_package_mirror                                 # download into PackageMirror/
+//	unity_package_mirror -out D:/Mirrors/MyGame
+//	unity_package_mirror -rewrite file                   # manifest uses file:../PackageMirror/*.tgz
+//	unity_package_mirror -rewrite registry -registry-url http://buildhost:8780
+//	unity_package_mirror -serve :8780                    # serve the mirror as a registry
+//	unity_package_mirror -dry-run -rewrite file
+//	unity_package_mirror -check-updates                  # changelogs into PackageUpdates.md
+
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Registry used for packages without an url in the lock file or a matching scope
+const defaultRegistry = "https://packages.unity.com"
+
+const (
+	defaultMirrorDir   = "PackageMirror"
+	mirrorIndexFile    = "mirror.json"
+	manifestBackupFile = "manifest.original.json"
+	// Name of the scopedRegistries entry written by -rewrite registry
+	mirrorRegistryName = "UnityStarter Offline Mirror"
+	// Review document of -check-updates, in the project root
+	defaultChangelogFile = "PackageUpdates.md"
+)
+
+var httpClient = &http.Client{Timeout: 10 * time.Minute}
+
+// Registry versions look like 1.2.3 or 1.2.3-pre.1; anything else in the
+// manifest (file:, git URLs, tarball URLs) is not a registry reference
+var registryVersionRegex = regexp.MustCompile(`^\d+\.\d+\.\d+([-+][0-9A-Za-z.+-]*)?$`)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+type scopedRegistry struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Scopes []string `json:"scopes"`
+}
+
+type projectManifest struct {
+	Dependencies     map[string]string `json:"dependencies"`
+	ScopedRegistries []scopedRegistry  `json:"scopedRegistries"`
+}
+
+// lockEntry is one package in Packages/packages-lock.json
+type lockEntry struct {
+	Version      string            `json:"version"`
+	Depth        int               `json:"depth"`
+	Source       string            `json:"source"` // registry, builtin, embedded, local, git
+	Dependencies map[string]string `json:"dependencies"`
+	URL          string            `json:"url"`
+}
+
+type lockFile struct {
+	Dependencies map[string]lockEntry `json:"dependencies"`
+}
+
+// mirrorPackage is one tarball in the mirror. mirror.json lists them; keep
+// field names stable, the serve mode of older tool versions reads the file.
+type mirrorPackage struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Registry string `json:"registry"`
+	File     string `json:"file"` // relative to the mirror folder
+	Shasum   string `json:"shasum"`
+	Direct   bool   `json:"direct"`
+}
+
+type mirrorIndex struct {
+	GeneratedAt string           `json:"generatedAt"`
+	Project     string           `json:"project"`
+	Packages    []*mirrorPackage `json:"packages"`
+}
+
+type skippedPackage struct {
+	name   string
+	reason string
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks for Assets/ and ProjectSettings/ in the given directory
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Package Resolution
+// ============================================================
+
+func readManifest(basePath string) (*projectManifest, error) {
+	data, err := os.ReadFile(filepath.Join(basePath, "Packages", "manifest.json"))
+	if err != nil {
+		return nil, err
+	}
+	var m projectManifest
+	if err := json.Unmarshal(bytes.TrimPrefix(data, utf8BOM), &m); err != nil {
+		return nil, fmt.Errorf("invalid Packages/manifest.json: %v", err)
+	}
+	return &m, nil
+}
+
+// registryFor picks the scoped registry with the longest matching scope, the
+// way the Package Manager does; the default registry otherwise
+func registryFor(name string, scoped []scopedRegistry) string {
+	best, bestLen := defaultRegistry, 0
+	for _, r := range scoped {
+		for _, scope := range r.Scopes {
+			if (name == scope || strings.HasPrefix(name, scope+".")) && len(scope) > bestLen {
+				best, bestLen = r.URL, len(scope)
+			}
+		}
+	}
+	return strings.TrimRight(best, "/")
+}
+
+// collectPackages lists the registry packages to mirror. The lock file holds
+// the resolved versions of direct and transitive dependencies; without it only
+// the direct dependencies in the manifest are known.
+func collectPackages(basePath string, manifest *projectManifest) ([]*mirrorPackage, []skippedPackage, bool, error) {
+	var packages []*mirrorPackage
+	var skipped []skippedPackage
+
+	data, err := os.ReadFile(filepath.Join(basePath, "Packages", "packages-lock.json"))
+	if err == nil {
+		var lock lockFile
+		if err := json.Unmarshal(bytes.TrimPrefix(data, utf8BOM), &lock); err != nil {
+			return nil, nil, false, fmt.Errorf("invalid Packages/packages-lock.json: %v", err)
+		}
+		for name, entry := range lock.Dependencies {
+			switch entry.Source {
+			case "registry":
+				registry := strings.TrimRight(entry.URL, "/")
+				if registry == "" {
+					registry = registryFor(name, manifest.ScopedRegistries)
+				}
+				_, direct := manifest.Dependencies[name]
+				packages = append(packages, &mirrorPackage{Name: name, Version: entry.Version, Registry: registry, Direct: direct})
+			case "builtin":
+				// Ships with the editor
+			case "git":
+				skipped = append(skipped, skippedPackage{name, "git package: " + entry.Version})
+			default:
+				skipped = append(skipped, skippedPackage{name, entry.Source + " package, already on disk"})
+			}
+		}
+	} else if os.IsNotExist(err) {
+		for name, version := range manifest.Dependencies {
+			if strings.HasPrefix(name, "com.unity.modules.") {
+				continue
+			}
+			if !registryVersionRegex.MatchString(version) {
+				skipped = append(skipped, skippedPackage{name, "not a registry version: " + version})
+				continue
+			}
+			packages = append(packages, &mirrorPackage{Name: name, Version: version, Registry: registryFor(name, manifest.ScopedRegistries), Direct: true})
+		}
+	} else {
+		return nil, nil, false, err
+	}
+
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Name < packages[j].Name })
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i].name < skipped[j].name })
+	return packages, skipped, err == nil, nil
+}
+
+// ============================================================
+// Registry Access
+// ============================================================
+
+// upmAuth maps registry URLs to Authorization headers, read from the same
+// .upmconfig.toml the Package Manager uses for private scoped registries
+type upmAuth map[string]string
+
+var (
+	upmSectionRegex = regexp.MustCompile(`^\[npmAuth\."([^"]+)"\]\s*$`)
+	upmValueRegex   = regexp.MustCompile(`^(\w+)\s*=\s*"([^"]*)"`)
+)
+
+// loadUPMAuth reads UPM_USER_CONFIG_FILE or ~/.upmconfig.toml. Only the
+// npmAuth token / _auth keys are needed here; everything else is ignored.
+func loadUPMAuth() upmAuth {
+	path := os.Getenv("UPM_USER_CONFIG_FILE")
+	if path == "" {
+		home, _ := os.UserHomeDir()
+		path = filepath.Join(home, ".upmconfig.toml")
+	}
+	auth := make(upmAuth)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return auth
+	}
+	current := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if m := upmSectionRegex.FindStringSubmatch(line); m != nil {
+			current = strings.TrimRight(m[1], "/")
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			current = ""
+			continue
+		}
+		m := upmValueRegex.FindStringSubmatch(line)
+		if m == nil || current == "" {
+			continue
+		}
+		switch m[1] {
+		case "token":
+			auth[current] = "Bearer " + m[2]
+		case "_auth":
+			auth[current] = "Basic " + m[2]
+		}
+	}
+	return auth
+}
+
+// header returns the Authorization value for a URL under a configured registry
+func (a upmAuth) header(url string) string {
+	best, bestLen := "", 0
+	for registry, value := range a {
+		if strings.HasPrefix(url, registry) && len(registry) > bestLen {
+			best, bestLen = value, len(registry)
+		}
+	}
+	return best
+}
+
+func (a upmAuth) get(url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if h := a.header(url); h != "" {
+		req.Header.Set("Authorization", h)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+// fetchVersion returns the registry's metadata for one package version: the
+// package.json fields plus dist.tarball and dist.shasum
+func (a upmAuth) fetchVersion(pkg *mirrorPackage) (json.RawMessage, string, string, error) {
+	resp, err := a.get(pkg.Registry + "/" + pkg.Name)
+	if err != nil {
+		return nil, "", "", err
+	}
+	defer resp.Body.Close()
+	var packument struct {
+		Versions map[string]json.RawMessage `json:"versions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&packument); err != nil {
+		return nil, "", "", fmt.Errorf("invalid registry response for %s: %v", pkg.Name, err)
+	}
+	raw, ok := packument.Versions[pkg.Version]
+	if !ok {
+		return nil, "", "", fmt.Errorf("version %s not found in %s", pkg.Version, pkg.Registry)
+	}
+	tarball, shasum := distInfo(raw)
+	if tarball == "" {
+		return nil, "", "", fmt.Errorf("no tarball listed for %s@%s", pkg.Name, pkg.Version)
+	}
+	return raw, tarball, shasum, nil
+}
+
+func distInfo(raw json.RawMessage) (tarball, shasum string) {
+	var meta struct {
+		Dist struct {
+			Tarball string `json:"tarball"`
+			Shasum  string `json:"shasum"`
+		} `json:"dist"`
+	}
+	json.Unmarshal(raw, &meta)
+	return meta.Dist.Tarball, strings.ToLower(meta.Dist.Shasum)
+}
+
+// ============================================================
+// Mirror
+// ============================================================
+
+// tarballName keeps each package in its own folder: <mirror>/<name>/<name>-<version>.tgz
+// next to <version>.json, which is also the layout the serve mode reads
+func tarballName(pkg *mirrorPackage) string {
+	return pkg.Name + "/" + pkg.Name + "-" + pkg.Version + ".tgz"
+}
+
+func metadataName(pkg *mirrorPackage) string {
+	return pkg.Name + "/" + pkg.Version + ".json"
+}
+
+func fileSHA1(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// alreadyMirrored reports whether the tarball and its metadata are present and
+// the tarball still matches the registry's checksum, so no request is needed
+func alreadyMirrored(mirrorDir string, pkg *mirrorPackage) bool {
+	raw, err := os.ReadFile(filepath.Join(mirrorDir, filepath.FromSlash(metadataName(pkg))))
+	if err != nil {
+		return false
+	}
+	_, shasum := distInfo(raw)
+	sum, err := fileSHA1(filepath.Join(mirrorDir, filepath.FromSlash(tarballName(pkg))))
+	if err != nil || (shasum != "" && sum != shasum) {
+		return false
+	}
+	pkg.Shasum = sum
+	return true
+}
+
+// download fetches one package into the mirror. The tarball is written to a
+// temp file and only renamed into place once its checksum matched.
+func download(auth upmAuth, mirrorDir string, pkg *mirrorPackage) (int64, error) {
+	raw, tarball, shasum, err := auth.fetchVersion(pkg)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := auth.get(tarball)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	dst := filepath.Join(mirrorDir, filepath.FromSlash(tarballName(pkg)))
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	h := sha1.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), resp.Body)
+	tmp.Close()
+	if err != nil {
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if shasum != "" && sum != shasum {
+		os.Remove(tmp.Name())
+		return 0, fmt.Errorf("checksum mismatch for %s (expected %s, got %s)", filepath.Base(dst), shasum, sum)
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	if err := os.WriteFile(filepath.Join(mirrorDir, filepath.FromSlash(metadataName(pkg))), raw, 0644); err != nil {
+		return 0, err
+	}
+	pkg.Shasum = sum
+	return size, nil
+}
+
+func loadMirrorIndex(mirrorDir string) (*mirrorIndex, error) {
+	data, err := os.ReadFile(filepath.Join(mirrorDir, mirrorIndexFile))
+	if err != nil {
+		return nil, err
+	}
+	var index mirrorIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", mirrorIndexFile, err)
+	}
+	for _, pkg := range index.Packages {
+		pkg.File = filepath.ToSlash(pkg.File)
+	}
+	return &index, nil
+}
+
+func saveMirrorIndex(mirrorDir string, index *mirrorIndex) error {
+	data, _ := json.MarshalIndent(index, "", "  ")
+	return os.WriteFile(filepath.Join(mirrorDir, mirrorIndexFile), append(data, '\n'), 0644)
+}
+
+// ============================================================
+// Manifest Rewrite
+// ============================================================
+
+// orderedObject is a JSON object that keeps its key order, so rewriting
+// manifest.json only changes the entries that actually change in the diff
+type orderedObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+func parseOrderedObject(data []byte) (*orderedObject, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	obj := &orderedObject{values: make(map[string]json.RawMessage)}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		obj.set(key, value)
+	}
+	return obj, nil
+}
+
+func (o *orderedObject) set(key string, value json.RawMessage) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// marshal writes the object with two-space indentation, as Unity does
+func (o *orderedObject) marshal() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	for i, key := range o.keys {
+		buf.WriteString("  ")
+		buf.Write(jsonString(key))
+		buf.WriteString(": ")
+		if err := json.Indent(&buf, o.values[key], "  ", "  "); err != nil {
+			buf.Write(o.values[key])
+		}
+		if i < len(o.keys)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}")
+	return buf.Bytes()
+}
+
+// jsonString encodes s without escaping <, > and &
+func jsonString(s string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return bytes.TrimRight(buf.Bytes(), "\n")
+}
+
+// fileReference is the manifest value for a mirrored tarball. file: paths are
+// resolved from the Packages folder; relative paths keep the project portable.
+func fileReference(basePath, mirrorDir string, pkg *mirrorPackage) string {
+	tgz := filepath.Join(mirrorDir, filepath.FromSlash(tarballName(pkg)))
+	if rel, err := filepath.Rel(filepath.Join(basePath, "Packages"), tgz); err == nil {
+		return "file:" + filepath.ToSlash(rel)
+	}
+	return "file:" + filepath.ToSlash(tgz)
+}
+
+// rewriteManifest points the manifest at the mirror. "file" replaces every
+// mirrored package (transitive ones included, so nothing is resolved from a
+// registry) with a file: reference; "registry" adds a scoped registry whose
+// scopes are the exact names of the mirrored packages.
+func rewriteManifest(text string, mode, registryURL string, refs map[string]string, names []string) (string, int, error) {
+	root, err := parseOrderedObject([]byte(text))
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid Packages/manifest.json: %v", err)
+	}
+	changes := 0
+
+	switch mode {
+	case "file":
+		deps := &orderedObject{values: make(map[string]json.RawMessage)}
+		if raw, ok := root.values["dependencies"]; ok {
+			if deps, err = parseOrderedObject(raw); err != nil {
+				return "", 0, fmt.Errorf("invalid dependencies in Packages/manifest.json: %v", err)
+			}
+		}
+		for _, name := range names {
+			value := json.RawMessage(jsonString(refs[name]))
+			if old, ok := deps.values[name]; ok && bytes.Equal(old, value) {
+				continue
+			}
+			deps.set(name, value)
+			changes++
+		}
+		root.set("dependencies", deps.marshal())
+
+	case "registry":
+		var registries []scopedRegistry
+		if raw, ok := root.values["scopedRegistries"]; ok {
+			if err := json.Unmarshal(raw, &registries); err != nil {
+				return "", 0, fmt.Errorf("invalid scopedRegistries in Packages/manifest.json: %v", err)
+			}
+		}
+		entry := scopedRegistry{Name: mirrorRegistryName, URL: strings.TrimRight(registryURL, "/"), Scopes: names}
+		replaced := false
+		for i, r := range registries {
+			if r.Name == mirrorRegistryName {
+				if r.URL == entry.URL && strings.Join(r.Scopes, ",") == strings.Join(names, ",") {
+					return text, 0, nil
+				}
+				registries[i] = entry
+				replaced = true
+			}
+		}
+		if !replaced {
+			registries = append(registries, entry)
+		}
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.Encode(registries)
+		root.set("scopedRegistries", bytes.TrimRight(buf.Bytes(), "\n"))
+		changes = len(names)
+	}
+
+	out := string(root.marshal())
+	if strings.HasSuffix(text, "\n") {
+		out += "\n"
+	}
+	return out, changes, nil
+}
+
+// ============================================================
+// Registry Server
+// ============================================================
+
+// serveMirror answers the requests the Package Manager sends to a scoped
+// registry: the packument per package, the tarballs, and the search endpoints
+// the Package Manager window lists packages with
+func serveMirror(addr, mirrorDir string, index *mirrorIndex) error {
+	byName := make(map[string][]*mirrorPackage)
+	for _, pkg := range index.Packages {
+		byName[pkg.Name] = append(byName[pkg.Name], pkg)
+	}
+
+	writeJSON := func(w http.ResponseWriter, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.Encode(v)
+	}
+	latest := func(list []*mirrorPackage) *mirrorPackage {
+		best := list[0]
+		for _, pkg := range list[1:] {
+			if compareVersions(pkg.Version, best.Version) > 0 {
+				best = pkg
+			}
+		}
+		return best
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(r.URL.Path, "/")
+		base := "http://" + r.Host
+
+		switch {
+		case path == "-/v1/search":
+			objects := []interface{}{}
+			for name, list := range byName {
+				pkg := latest(list)
+				objects = append(objects, map[string]interface{}{
+					"package": map[string]interface{}{"name": name, "version": pkg.Version, "description": ""},
+				})
+			}
+			writeJSON(w, map[string]interface{}{"objects": objects, "total": len(objects)})
+			return
+		case path == "-/all":
+			all := map[string]interface{}{"_updated": 0}
+			for name, list := range byName {
+				versions := map[string]string{}
+				for _, pkg := range list {
+					versions[pkg.Version] = "latest"
+				}
+				all[name] = map[string]interface{}{"name": name, "dist-tags": map[string]string{"latest": latest(list).Version}, "versions": versions}
+			}
+			writeJSON(w, all)
+			return
+		case strings.Contains(path, "/-/"):
+			// Tarball: /<name>/-/<file>
+			parts := strings.SplitN(path, "/-/", 2)
+			for _, pkg := range byName[parts[0]] {
+				if filepath.Base(pkg.File) == parts[1] {
+					w.Header().Set("Content-Type", "application/octet-stream")
+					http.ServeFile(w, r, filepath.Join(mirrorDir, filepath.FromSlash(pkg.File)))
+					return
+				}
+			}
+			http.NotFound(w, r)
+			return
+		}
+
+		list := byName[path]
+		if len(list) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		versions := map[string]interface{}{}
+		times := map[string]string{"modified": index.GeneratedAt, "created": index.GeneratedAt}
+		for _, pkg := range list {
+			raw, err := os.ReadFile(filepath.Join(mirrorDir, filepath.FromSlash(metadataName(pkg))))
+			if err != nil {
+				continue
+			}
+			var meta map[string]interface{}
+			if json.Unmarshal(raw, &meta) != nil {
+				continue
+			}
+			dist, _ := meta["dist"].(map[string]interface{})
+			if dist == nil {
+				dist = map[string]interface{}{}
+			}
+			dist["tarball"] = base + "/" + pkg.Name + "/-/" + filepath.Base(pkg.File)
+			dist["shasum"] = pkg.Shasum
+			delete(dist, "integrity")
+			meta["dist"] = dist
+			versions[pkg.Version] = meta
+			times[pkg.Version] = index.GeneratedAt
+		}
+		writeJSON(w, map[string]interface{}{
+			"_id": path, "name": path, "versions": versions, "time": times,
+			"dist-tags": map[string]string{"latest": latest(list).Version},
+		})
+		fmt.Printf("  %s %s\n", r.Method, r.URL.Path)
+	})
+	return http.ListenAndServe(addr, mux)
+}
+
+// compareVersions orders semantic versions; a pre-release sorts before its release
+func compareVersions(a, b string) int {
+	split := func(v string) ([]int, string) {
+		pre := ""
+		if i := strings.IndexAny(v, "-+"); i >= 0 {
+			v, pre = v[:i], v[i:]
+		}
+		var nums []int
+		for _, part := range strings.Split(v, ".") {
+			n := 0
+			fmt.Sscanf(part, "%d", &n)
+			nums = append(nums, n)
+		}
+		return nums, pre
+	}
+	na, pa := split(a)
+	nb, pb := split(b)
+	for i := 0; i < len(na) || i < len(nb); i++ {
+		var x, y int
+		if i < len(na) {
+			x = na[i]
+		}
+		if i < len(nb) {
+			y = nb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case pa == pb:
+		return 0
+	case pa == "":
+		return 1
+	case pb == "":
+		return -1
+	}
+	return strings.Compare(pa, pb)
+}
+
+// ============================================================
+// Update Check
+// ============================================================
+
+// Largest CHANGELOG.md read from a package tarball
+const maxChangelogSize = 4 << 20
+
+// Release headings in Keep a Changelog style: "## [1.2.3] - 2024-01-01",
+// "## 1.2.3", "# [v1.2.3]"
+var changelogHeadingRegex = regexp.MustCompile(`^(#{1,2})\s+\[?v?(\d+\.\d+\.\d+(?:[-+][0-9A-Za-z.+-]*)?)\]?`)
+
+// Text in a release section that calls for a closer look before upgrading
+var breakingChangeRegex = regexp.MustCompile(`(?im)\bbreaking\b|^#+\s*removed\b`)
+
+// packageUpdate is one direct dependency with a newer version on its registry
+type packageUpdate struct {
+	pkg      *mirrorPackage
+	latest   string
+	tarball  string
+	sections []changelogSection
+	note     string // why there is no changelog, if there is none
+}
+
+type changelogSection struct {
+	version string
+	text    string // the section with its headings moved below the package's
+}
+
+// fetchLatest returns the version the registry tags as latest (the newest
+// release when there is no tag) and its tarball URL
+func (a upmAuth) fetchLatest(pkg *mirrorPackage) (string, string, error) {
+	resp, err := a.get(pkg.Registry + "/" + pkg.Name)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	var packument struct {
+		DistTags map[string]string          `json:"dist-tags"`
+		Versions map[string]json.RawMessage `json:"versions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&packument); err != nil {
+		return "", "", fmt.Errorf("invalid registry response for %s: %v", pkg.Name, err)
+	}
+	latest := packument.DistTags["latest"]
+	if _, ok := packument.Versions[latest]; !ok {
+		latest = ""
+		for v := range packument.Versions {
+			if !strings.ContainsAny(v, "-+") && (latest == "" || compareVersions(v, latest) > 0) {
+				latest = v
+			}
+		}
+	}
+	if latest == "" {
+		return "", "", fmt.Errorf("no released version of %s in %s", pkg.Name, pkg.Registry)
+	}
+	tarball, _ := distInfo(packument.Versions[latest])
+	return latest, tarball, nil
+}
+
+// fetchChangelog reads CHANGELOG.md from the root of a package tarball
+func (a upmAuth) fetchChangelog(tarball string) (string, error) {
+	resp, err := a.get(tarball)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	defer gz.Close()
+	archive := tar.NewReader(gz)
+	for {
+		hdr, err := archive.Next()
+		if err == io.EOF {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		// Entries are under package/ in registry tarballs
+		parts := strings.Split(strings.TrimPrefix(hdr.Name, "./"), "/")
+		if len(parts) != 2 || !strings.EqualFold(parts[1], "CHANGELOG.md") || hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(archive, maxChangelogSize))
+		if err != nil {
+			return "", err
+		}
+		text, _ := decodeText(data)
+		return strings.ReplaceAll(text, "\r\n", "\n"), nil
+	}
+}
+
+// changelogSections cuts a changelog into its release sections and keeps those
+// after current up to and including latest, newest first as changelogs list them.
+// Headings inside a section are moved down so the release heading becomes ###.
+func changelogSections(changelog, current, latest string) []changelogSection {
+	var sections []changelogSection
+	var section *changelogSection
+	var lines []string
+	shift := 0
+	flush := func() {
+		if section != nil {
+			section.text = strings.TrimSpace(strings.Join(lines, "\n"))
+			sections = append(sections, *section)
+		}
+		section, lines = nil, nil
+	}
+	for _, line := range strings.Split(changelog, "\n") {
+		if m := changelogHeadingRegex.FindStringSubmatch(line); m != nil {
+			flush()
+			if compareVersions(m[2], current) > 0 && compareVersions(m[2], latest) <= 0 {
+				section = &changelogSection{version: m[2]}
+				shift = 3 - len(m[1])
+			}
+		}
+		if section == nil {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			level := len(line) - len(strings.TrimLeft(line, "#"))
+			moved := level + shift
+			if moved > 6 {
+				moved = 6
+			}
+			line = strings.Repeat("#", moved) + line[level:]
+		}
+		lines = append(lines, line)
+	}
+	flush()
+	return sections
+}
+
+// updateRisk summarizes what to look at before taking an update
+func updateRisk(u *packageUpdate) string {
+	var risks []string
+	major := func(v string) string {
+		parts := strings.SplitN(v, ".", 3)
+		if parts[0] == "0" && len(parts) > 1 {
+			return "0." + parts[1] // 0.x releases break on minor versions
+		}
+		return parts[0]
+	}
+	if major(u.pkg.Version) != major(u.latest) {
+		risks = append(risks, "major version")
+	}
+	for _, s := range u.sections {
+		if breakingChangeRegex.MatchString(s.text) {
+			risks = append(risks, "breaking changes noted")
+			break
+		}
+	}
+	if strings.ContainsAny(u.pkg.Version, "-+") {
+		risks = append(risks, "from a pre-release")
+	}
+	return strings.Join(risks, ", ")
+}
+
+// writeUpdateReport writes the review document: one table of the updates and
+// the changelog sections of every skipped-over release per package
+func writeUpdateReport(path, project string, updates []*packageUpdate) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Package Updates — %s\n\n", project)
+	fmt.Fprintf(&b, "Generated %s by unity_package_mirror -check-updates.\n\n", time.Now().Format("2006-01-02 15:04"))
+	b.WriteString("| Package | Current | Latest | Releases | Risk |\n")
+	b.WriteString("| ------- | ------- | ------ | -------- | ---- |\n")
+	for _, u := range updates {
+		releases := strconv.Itoa(len(u.sections))
+		if u.note != "" {
+			releases = "?"
+		}
+		fmt.Fprintf(&b, "| [%s](#%s) | %s | %s | %s | %s |\n", u.pkg.Name, strings.ReplaceAll(u.pkg.Name, ".", ""), u.pkg.Version, u.latest, releases, updateRisk(u))
+	}
+	for _, u := range updates {
+		fmt.Fprintf(&b, "\n## %s\n\n", u.pkg.Name)
+		fmt.Fprintf(&b, "%s → %s, from %s\n\n", u.pkg.Version, u.latest, u.pkg.Registry)
+		if u.note != "" {
+			fmt.Fprintf(&b, "_%s_\n", u.note)
+			continue
+		}
+		for _, s := range u.sections {
+			b.WriteString(s.text)
+			b.WriteString("\n\n")
+		}
+	}
+	return writeFileAtomic(path, []byte(strings.TrimRight(b.String(), "\n")+"\n"))
+}
+
+// runUpdateCheck looks up the latest version of every direct registry
+// dependency and writes the changelogs of the newer releases into one document.
+// Transitive packages follow the packages that depend on them.
+func runUpdateCheck(basePath, reportPath string, packages []*mirrorPackage, dryRun bool) int {
+	var direct []*mirrorPackage
+	for _, pkg := range packages {
+		if pkg.Direct {
+			direct = append(direct, pkg)
+		}
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  PACKAGE UPDATES"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Direct dependencies:  %d\n"), len(direct))
+	fmt.Println()
+
+	auth := loadUPMAuth()
+	var updates []*packageUpdate
+	failed := 0
+	for i, pkg := range direct {
+		label := pkg.Name + "@" + pkg.Version
+		latest, tarball, err := auth.fetchLatest(pkg)
+		if err != nil {
+			fmt.Printf(tr("[%d/%d] [ERROR] %s: %v\n"), i+1, len(direct), label, err)
+			recordAction("check-update", label, "failed", err.Error(), 0)
+			recordError("%s: %v", label, err)
+			failed++
+			continue
+		}
+		if compareVersions(latest, pkg.Version) <= 0 {
+			fmt.Printf(tr("[%d/%d] [--] Up to date: %s\n"), i+1, len(direct), label)
+			recordAction("check-update", label, "skipped", "up to date", 0)
+			continue
+		}
+		u := &packageUpdate{pkg: pkg, latest: latest, tarball: tarball}
+		changelog, err := "", error(nil)
+		if tarball != "" {
+			changelog, err = auth.fetchChangelog(tarball)
+		}
+		switch {
+		case tarball == "":
+			u.note = "The registry lists no tarball for this version."
+		case err != nil:
+			u.note = fmt.Sprintf("The changelog could not be read: %v", err)
+		case changelog == "":
+			u.note = "The package has no CHANGELOG.md."
+		default:
+			u.sections = changelogSections(changelog, pkg.Version, latest)
+			if len(u.sections) == 0 {
+				u.note = "CHANGELOG.md has no section for the releases after " + pkg.Version + "."
+			}
+		}
+		updates = append(updates, u)
+		detail := pkg.Version + " -> " + latest
+		if risk := updateRisk(u); risk != "" {
+			detail += " (" + risk + ")"
+		}
+		fmt.Printf(tr("[%d/%d] [UPDATE] %s: %s\n"), i+1, len(direct), pkg.Name, detail)
+		recordAction("check-update", label, "ok", detail, 0)
+	}
+
+	fmt.Println()
+	fmt.Printf(tr("  Updates available: %d\n"), len(updates))
+	if len(updates) > 0 {
+		rel := reportPath
+		if r, err := filepath.Rel(basePath, reportPath); err == nil {
+			rel = filepath.ToSlash(r)
+		}
+		if dryRun {
+			fmt.Printf(tr("[Dry Run] Would write %s\n"), rel)
+		} else if err := writeUpdateReport(reportPath, filepath.Base(basePath), updates); err != nil {
+			fmt.Printf(tr("[ERROR] Cannot write %s: %v\n"), rel, err)
+			recordError("cannot write %s: %v", rel, err)
+			return 1
+		} else {
+			fmt.Printf(tr("[OK] Changelogs written to %s\n"), rel)
+			recordArtifact(reportPath)
+		}
+	}
+	if failed > 0 {
+		fmt.Printf(tr("\n[ERROR] %d package(s) could not be checked.\n"), failed)
+		return 1
+	}
+	return 0
+}
+
+// ============================================================
+// Version Control Checkout
+// ============================================================
+
+// Perforce and Plastic SCM keep files read-only until they are checked out. Writing
+// them directly (even after clearing the flag) leaves changes the server does not know
+// about, so files are opened for edit first. Git and plain folders need nothing.
+const (
+	vcsNone     = "none"
+	vcsPerforce = "p4"
+	vcsPlastic  = "plastic"
+)
+
+// vcsEnvVar overrides detection for every tool (e.g. on build agents)
+const vcsEnvVar = "UNITYSTARTER_VCS"
+
+// vcsClient issues checkouts for files about to be modified
+type vcsClient struct {
+	kind       string
+	source     string // how kind was chosen, for the startup message
+	checkedOut map[string]bool
+}
+
+// activeVCS is set up in main; nil means no checkouts
+var activeVCS *vcsClient
+
+// detectVCS picks the checkout provider. Priority: -vcs flag > UNITYSTARTER_VCS >
+// Unity's VersionControlSettings.asset mode > workspace markers (.plastic, P4CONFIG).
+func detectVCS(projectRoot, override string) (*vcsClient, error) {
+	normalize := func(v string) (string, bool) {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "p4", "perforce":
+			return vcsPerforce, true
+		case "plastic", "plasticscm", "cm", "unityvcs":
+			return vcsPlastic, true
+		case "none", "git", "off":
+			return vcsNone, true
+		}
+		return "", false
+	}
+	newClient := func(kind, source string) *vcsClient {
+		return &vcsClient{kind: kind, source: source, checkedOut: make(map[string]bool)}
+	}
+
+	if override != "" && override != "auto" {
+		kind, ok := normalize(override)
+		if !ok {
+			return nil, fmt.Errorf("unknown -vcs value '%s' (use auto, none, p4 or plastic)", override)
+		}
+		return newClient(kind, "-vcs flag"), nil
+	}
+	if env := os.Getenv(vcsEnvVar); env != "" {
+		if kind, ok := normalize(env); ok {
+			return newClient(kind, vcsEnvVar), nil
+		}
+	}
+
+	// Unity stores the project's integrated VCS in m_Mode ("Perforce", "PlasticSCM", ...)
+	if data, err := os.ReadFile(filepath.Join(projectRoot, "ProjectSettings", "VersionControlSettings.asset")); err == nil {
+		if m := regexp.MustCompile(`(?m)^\s*m_Mode:\s*(.+?)\s*$`).FindSubmatch(data); m != nil {
+			switch strings.ToLower(string(m[1])) {
+			case "perforce":
+				return newClient(vcsPerforce, "VersionControlSettings.asset"), nil
+			case "plasticscm", "plastic scm", "unity version control":
+				return newClient(vcsPlastic, "VersionControlSettings.asset"), nil
+			}
+		}
+	}
+
+	// Workspace markers, searched upward from the project root
+	p4config := os.Getenv("P4CONFIG")
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info != nil {
+				return newClient(vcsNone, "git repository"), nil
+			}
+			if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+				return newClient(vcsPlastic, ".plastic workspace"), nil
+			}
+			if p4config != "" {
+				if _, err := os.Stat(filepath.Join(dir, p4config)); err == nil {
+					return newClient(vcsPerforce, "P4CONFIG"), nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if os.Getenv("P4CLIENT") != "" {
+		return newClient(vcsPerforce, "P4CLIENT"), nil
+	}
+	return newClient(vcsNone, "no Perforce/Plastic workspace found"), nil
+}
+
+// checkout opens an existing file for edit. New files need no checkout, and files
+// outside the depot/workspace only produce a warning: the write still goes ahead.
+func (v *vcsClient) checkout(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || v.checkedOut[abs] {
+		return
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return
+	}
+	v.checkedOut[abs] = true
+
+	var cmd *exec.Cmd
+	switch v.kind {
+	case vcsPerforce:
+		cmd = exec.Command("p4", "edit", abs)
+	case vcsPlastic:
+		cmd = exec.Command("cm", "checkout", abs)
+	default:
+		return
+	}
+	// Run from the file's folder so P4CONFIG / the Plastic workspace is resolved
+	cmd.Dir = filepath.Dir(abs)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		fmt.Printf(tr("[WARNING] %s checkout failed for %s: %v %s\n"), v.kind, filepath.Base(abs), err, strings.TrimSpace(string(out)))
+		return
+	}
+	fmt.Printf(tr("[VCS] Checked out (%s): %s\n"), v.kind, filepath.Base(abs))
+}
+
+// ============================================================
+// Safe File Writes
+// ============================================================
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// writeFileAtomic replaces path with data without ever leaving a half-written file:
+// data goes to a temp file in the same directory which is then renamed over the target.
+// The target's permission bits are kept, and a read-only flag (files under Perforce or
+// Plastic SCM that were not checked out) is cleared so the write does not fail.
+func writeFileAtomic(path string, data []byte) error {
+	// A dry run writes straight into the overlay: no checkout, no temp file
+	if isDryRun() {
+		return fsys.WriteFile(path, data, 0644)
+	}
+	activeVCS.checkout(path)
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if mode&0200 == 0 {
+			mode |= 0200
+			if err := os.Chmod(path, mode); err != nil {
+				return fmt.Errorf("cannot clear read-only flag on %s: %v", path, err)
+			}
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Retry briefly: editors, indexers and antivirus can hold the target open on Windows
+	for attempt := 0; ; attempt++ {
+		err = os.Rename(tmpPath, path)
+		if err == nil || attempt == 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// textFormat records the BOM and line-ending style of a text file. Rewrites work on
+// BOM-less, LF-only text; encode restores the original format when writing back so
+// CRLF files (common for files edited on Windows) and UTF-8 BOM files stay intact.
+type textFormat struct {
+	bom  bool
+	crlf bool // ending of most lines, and of the lines a rewrite adds
+	// Mixed files only: the text as read (LF) and the ending of each of its line
+	// breaks, 'r' (CRLF) or 'n' (LF). Strings keep textFormat comparable.
+	orig string
+	eols string
+}
+
+// decodeText strips a UTF-8 BOM and normalizes line endings to LF. A file counts as
+// CRLF when most of its line breaks are CRLF; a file with both keeps the ending of
+// every line, so a rewrite only changes the endings of the lines it edits.
+func decodeText(data []byte) (string, textFormat) {
+	var format textFormat
+	if bytes.HasPrefix(data, utf8BOM) {
+		format.bom = true
+		data = data[len(utf8BOM):]
+	}
+	crlfCount := bytes.Count(data, []byte("\r\n"))
+	lfCount := bytes.Count(data, []byte("\n")) - crlfCount
+	format.crlf = crlfCount > 0 && crlfCount >= lfCount
+	if crlfCount == 0 {
+		return string(data), format
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if lfCount > 0 {
+		eols := make([]byte, 0, crlfCount+lfCount)
+		for i, c := range data {
+			if c == '\n' {
+				if i > 0 && data[i-1] == '\r' {
+					eols = append(eols, 'r')
+				} else {
+					eols = append(eols, 'n')
+				}
+			}
+		}
+		format.orig, format.eols = text, string(eols)
+	}
+	return text, format
+}
+
+// encode converts LF text back to the recorded format
+func (f textFormat) encode(text string) []byte {
+	switch {
+	case f.eols != "":
+		text = f.mixedEndings(text)
+	case f.crlf:
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	if f.bom {
+		return append(append([]byte{}, utf8BOM...), text...)
+	}
+	return []byte(text)
+}
+
+// mixedEndings gives the lines of a rewritten mixed file the endings they had. The
+// unchanged lines before and after the edited region keep theirs; edited lines keep
+// the ending of the line they replace when the line count did not change, and get
+// the majority ending otherwise.
+func (f textFormat) mixedEndings(text string) string {
+	old := strings.Split(f.orig, "\n")
+	lines := strings.Split(text, "\n")
+	prefix := 0
+	for prefix < len(old) && prefix < len(lines) && old[prefix] == lines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(lines)-prefix && old[len(old)-1-suffix] == lines[len(lines)-1-suffix] {
+		suffix++
+	}
+	crlfAt := func(j int) bool {
+		if j >= 0 && j < len(f.eols) {
+			return f.eols[j] == 'r'
+		}
+		return f.crlf
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		b.WriteString(line)
+		if i == len(lines)-1 {
+			break
+		}
+		crlf := f.crlf
+		switch {
+		case i < prefix || len(lines) == len(old):
+			crlf = crlfAt(i)
+		case i >= len(lines)-suffix:
+			crlf = crlfAt(i - len(lines) + len(old))
+		}
+		if crlf {
+			b.WriteByte('\r')
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// writeTextFileAtomic writes LF text in the given format via writeFileAtomic
+func writeTextFileAtomic(path, text string, format textFormat) error {
+	return writeFileAtomic(path, format.encode(text))
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+// ============================================================
+// Filesystem
+// ============================================================
+
+// fileSystem is every filesystem call that can change the project. The real
+// run uses osFS; -dry-run swaps in an overlayFS so the same code path runs
+// and the overlay reports what it would have changed.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Walk(root string, fn filepath.WalkFunc) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Chmod(name string, mode os.FileMode) error
+}
+
+var fsys fileSystem = osFS{}
+
+// isDryRun reports whether changes are going to the in-memory overlay
+func isDryRun() bool {
+	_, ok := fsys.(*overlayFS)
+	return ok
+}
+
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)         { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) Walk(root string, fn filepath.WalkFunc) error { return filepath.Walk(root, fn) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// fsChange is one change the overlay absorbed instead of the disk
+type fsChange struct {
+	op     string // "write", "create", "mkdir", "rename", "delete"
+	path   string
+	detail string
+}
+
+// overlayFS reads through to the disk and keeps every write, rename and
+// delete in memory. Later reads see those changes, so multi-step operations
+// (rename a folder, then rewrite files inside it) behave as they would for real.
+type overlayFS struct {
+	mu      sync.Mutex
+	files   map[string][]byte // files written during the run
+	dirs    map[string]bool   // directories created during the run
+	moved   map[string]string // renamed path -> where its content is on disk
+	removed map[string]bool   // deleted paths; hides everything below them
+	changes []fsChange
+}
+
+func newOverlayFS() *overlayFS {
+	return &overlayFS{
+		files:   map[string][]byte{},
+		dirs:    map[string]bool{},
+		moved:   map[string]string{},
+		removed: map[string]bool{},
+	}
+}
+
+func overlayKey(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// isUnder reports whether p is base or inside it
+func isUnder(p, base string) bool {
+	return p == base || strings.HasPrefix(p, strings.TrimSuffix(base, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// locate finds where the content of p lives: in memory (data / memDir) or
+// on disk (disk, after following renames). ok is false if p was deleted.
+func (o *overlayFS) locate(p string) (data []byte, memFile, memDir bool, disk string, ok bool) {
+	if d, found := o.files[p]; found {
+		return d, true, false, "", true
+	}
+	if o.dirs[p] {
+		return nil, false, true, "", true
+	}
+	for q := p; ; {
+		if src, found := o.moved[q]; found {
+			rel, _ := filepath.Rel(q, p)
+			return nil, false, false, filepath.Join(src, rel), true
+		}
+		if o.removed[q] {
+			return nil, false, false, "", false
+		}
+		parent := filepath.Dir(q)
+		if parent == q {
+			break
+		}
+		q = parent
+	}
+	return nil, false, false, p, true
+}
+
+func (o *overlayFS) stat(p string) (os.FileInfo, error) {
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	case memFile:
+		return memFileInfo{name: filepath.Base(p), size: int64(len(data))}, nil
+	case memDir:
+		return memFileInfo{name: filepath.Base(p), dir: true}, nil
+	}
+	info, err := os.Stat(disk)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	if disk != p {
+		return renamedFileInfo{info, filepath.Base(p)}, nil
+	}
+	return info, nil
+}
+
+func (o *overlayFS) Stat(name string) (os.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stat(overlayKey(name))
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case memDir:
+		return nil, &os.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	case memFile:
+		return append([]byte(nil), data...), nil
+	}
+	return os.ReadFile(disk)
+}
+
+func (o *overlayFS) readDir(p string) ([]os.DirEntry, error) {
+	_, memFile, memDir, disk, ok := o.locate(p)
+	if !ok || memFile {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+	names := map[string]bool{}
+	if !memDir {
+		entries, err := os.ReadDir(disk)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			names[e.Name()] = true
+		}
+	}
+	for k := range o.dirs {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.files {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.moved {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	var result []os.DirEntry
+	for name := range names {
+		if info, err := o.stat(filepath.Join(p, name)); err == nil {
+			result = append(result, fs.FileInfoToDirEntry(info))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (o *overlayFS) ReadDir(name string) ([]os.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readDir(overlayKey(name))
+}
+
+// Walk follows filepath.Walk: lexical order, SkipDir and SkipAll honored.
+// The lock is released while fn runs so the callback can change the overlay.
+func (o *overlayFS) Walk(root string, fn filepath.WalkFunc) error {
+	info, err := o.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = o.walk(root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (o *overlayFS) walk(path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	entries, err := o.ReadDir(path)
+	if err1 := fn(path, info, err); err != nil || err1 != nil {
+		return err1
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		childInfo, err := o.Stat(child)
+		if err != nil {
+			if err := fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := o.walk(child, childInfo, fn); err != nil {
+			if err == filepath.SkipDir && childInfo.IsDir() {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *overlayFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	op := "create"
+	if info, err := o.stat(p); err == nil {
+		if info.IsDir() {
+			return &os.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+		}
+		op = "write"
+	}
+	if info, err := o.stat(filepath.Dir(p)); err != nil || !info.IsDir() {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	o.files[p] = append([]byte(nil), data...)
+	o.changes = append(o.changes, fsChange{op, p, fmt.Sprintf("%d bytes", len(data))})
+	return nil
+}
+
+func (o *overlayFS) MkdirAll(path string, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	created := false
+	for q := p; ; q = filepath.Dir(q) {
+		if info, err := o.stat(q); err == nil {
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: q, Err: errors.New("not a directory")}
+			}
+			break
+		}
+		o.dirs[q] = true
+		created = true
+		if filepath.Dir(q) == q {
+			break
+		}
+	}
+	if created {
+		o.changes = append(o.changes, fsChange{"mkdir", p, ""})
+	}
+	return nil
+}
+
+// shift moves every overlay entry under from to the same place under to
+func (o *overlayFS) shift(from, to string) {
+	for k, v := range o.files {
+		if isUnder(k, from) {
+			delete(o.files, k)
+			o.files[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, from) {
+			delete(o.dirs, k)
+			o.dirs[to+strings.TrimPrefix(k, from)] = true
+		}
+	}
+	for k, v := range o.moved {
+		if isUnder(k, from) {
+			delete(o.moved, k)
+			o.moved[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+}
+
+func (o *overlayFS) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	from, to := overlayKey(oldpath), overlayKey(newpath)
+	_, _, _, disk, ok := o.locate(from)
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if from == to {
+		return nil
+	}
+	if info, err := o.stat(filepath.Dir(to)); err != nil || !info.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	o.drop(to)
+	o.shift(from, to)
+	if disk != "" {
+		if _, err := os.Stat(disk); err == nil {
+			o.moved[to] = disk
+		}
+	}
+	o.removed[from] = true
+	o.changes = append(o.changes, fsChange{"rename", from, to})
+	return nil
+}
+
+// drop forgets p and everything below it and hides it from the disk
+func (o *overlayFS) drop(p string) {
+	for k := range o.files {
+		if isUnder(k, p) {
+			delete(o.files, k)
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, p) {
+			delete(o.dirs, k)
+		}
+	}
+	for k := range o.moved {
+		if isUnder(k, p) {
+			delete(o.moved, k)
+		}
+	}
+	o.removed[p] = true
+}
+
+// treeSize is the byte size of p as the overlay currently sees it
+func (o *overlayFS) treeSize(p string) int64 {
+	info, err := o.stat(p)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var size int64
+	entries, _ := o.readDir(p)
+	for _, e := range entries {
+		size += o.treeSize(filepath.Join(p, e.Name()))
+	}
+	return size
+}
+
+func (o *overlayFS) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	info, err := o.stat(p)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	if info.IsDir() {
+		if entries, _ := o.readDir(p); len(entries) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", info.Size())})
+	return nil
+}
+
+func (o *overlayFS) RemoveAll(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	if _, err := o.stat(p); err != nil {
+		return nil
+	}
+	size := o.treeSize(p)
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", size)})
+	return nil
+}
+
+func (o *overlayFS) Chmod(name string, mode os.FileMode) error {
+	if _, err := o.Stat(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+// printDryRunReport lists what the overlay absorbed, relative to base
+func (o *overlayFS) printDryRunReport(base string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if abs, err := filepath.Abs(base); err == nil {
+		base = abs
+	}
+	if len(o.changes) == 0 {
+		fmt.Println(tr("\n[Dry Run] No changes would be made."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d change(s) would be made; nothing was written:\n"), len(o.changes))
+	rel := func(p string) string {
+		if r, err := filepath.Rel(base, p); err == nil && !strings.HasPrefix(r, "..") {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	for _, c := range o.changes {
+		switch c.op {
+		case "rename":
+			fmt.Printf("  %-7s %s -> %s\n", c.op, rel(c.path), rel(c.detail))
+		case "mkdir":
+			fmt.Printf("  %-7s %s\n", c.op, rel(c.path))
+		default:
+			fmt.Printf("  %-7s %s (%s)\n", c.op, rel(c.path), c.detail)
+		}
+	}
+}
+
+// memFileInfo describes a file or directory that only exists in the overlay
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (m memFileInfo) Name() string       { return m.name }
+func (m memFileInfo) Size() int64        { return m.size }
+func (m memFileInfo) ModTime() time.Time { return time.Time{} }
+func (m memFileInfo) IsDir() bool        { return m.dir }
+func (m memFileInfo) Sys() interface{}   { return nil }
+func (m memFileInfo) Mode() os.FileMode {
+	if m.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// renamedFileInfo is a disk entry seen under the name it was renamed to
+type renamedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (r renamedFileInfo) Name() string { return r.name }
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"[ERROR] -rewrite registry needs -registry-url (where -serve will run)": "[ERROR] -rewrite registry 需要 -registry-url（-serve 运行的地址）",
+	"[ERROR] Unknown -rewrite mode '%s' (use none, file, registry)\n":       "[ERROR] 未知的 -rewrite 模式 '%s'（可用 none、file、registry）\n",
+	"[ERROR] Cannot get current directory: %v\n":                            "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.":      "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"[ERROR] The mirror folder cannot be inside Assets/: %s\n":              "[ERROR] 镜像文件夹不能位于 Assets/ 内: %s\n",
+	"[ERROR] Cannot read the mirror in %s: %v\n":                            "[ERROR] 无法读取 %s 中的镜像: %v\n",
+	"Serving %d package(s) from %s on %s (Ctrl+C to stop)\n":                "正在从 %[2]s 提供 %[1]d 个包，地址 %[3]s（按 Ctrl+C 停止）\n",
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"[WARNING] Packages/packages-lock.json not found; only direct dependencies are mirrored.": "[WARNING] 未找到 Packages/packages-lock.json，只镜像直接依赖。",
+	"          Open the project in Unity once so the lock file lists the transitive ones.":    "          请先用 Unity 打开一次项目，让锁文件列出间接依赖。",
+	"  PACKAGE MIRROR":                              "  包镜像",
+	"  Mirror folder:  %s\n":                        "  镜像文件夹:  %s\n",
+	"  Packages:       %d\n":                        "  包数量:      %d\n",
+	"  [--] Skipped %s (%s)\n":                      "  [--] 已跳过 %s (%s)\n",
+	"[--] No registry packages to mirror.":          "[--] 没有需要镜像的注册表包。",
+	"[%d/%d] [--] Already mirrored: %s\n":           "[%d/%d] [--] 已在镜像中: %s\n",
+	"[%d/%d] [Dry Run] Would download %s from %s\n": "[%d/%d] [Dry Run] 将从 %[4]s 下载 %[3]s\n",
+	"[%d/%d] [ERROR] %s: %v\n":                      "[%d/%d] [ERROR] %s: %v\n",
+	"[%d/%d] [OK] Downloaded: %s (%s)\n":            "[%d/%d] [OK] 已下载: %s (%s)\n",
+	"[ERROR] Cannot write %s: %v\n":                 "[ERROR] 无法写入 %s: %v\n",
+	"  Downloaded: %s\n":                            "  已下载: %s\n",
+	"\n[ERROR] %d package(s) could not be downloaded; the manifest was not changed.\n": "\n[ERROR] 有 %d 个包下载失败，未修改清单。\n",
+	"\n[--] Packages/manifest.json already uses the mirror.":                           "\n[--] Packages/manifest.json 已在使用镜像。",
+	"\nRewrite Packages/manifest.json to use the mirror (%s, %d package(s))? (y/N): ":  "\n是否改写 Packages/manifest.json 以使用镜像（%s，%d 个包）？(y/N): ",
+	"Operation cancelled.":                                       "操作已取消。",
+	"[OK] Original manifest saved as %s\n":                       "[OK] 原始清单已保存为 %s\n",
+	"[OK] Updated: Packages/manifest.json (%s, %d package(s))\n": "[OK] 已更新: Packages/manifest.json（%s，%d 个包）\n",
+	"[TIP] Start the registry on the build machine: unity_package_mirror -out %s -serve <addr>\n": "[TIP] 在构建机上启动注册表: unity_package_mirror -out %s -serve <地址>\n",
+	"\n[Dry Run] Nothing was downloaded or written.":                                              "\n[Dry Run] 未下载或写入任何内容。",
+
+	"  PACKAGE UPDATES":                               "  包更新",
+	"  Direct dependencies:  %d\n":                    "  直接依赖:  %d\n",
+	"[%d/%d] [--] Up to date: %s\n":                   "[%d/%d] [--] 已是最新: %s\n",
+	"[%d/%d] [UPDATE] %s: %s\n":                       "[%d/%d] [UPDATE] %s: %s\n",
+	"  Updates available: %d\n":                       "  可用更新: %d\n",
+	"[Dry Run] Would write %s\n":                      "[Dry Run] 将写入 %s\n",
+	"[OK] Changelogs written to %s\n":                 "[OK] 更新日志已写入 %s\n",
+	"\n[ERROR] %d package(s) could not be checked.\n": "\n[ERROR] 有 %d 个包无法检查。\n",
+
+	"[WARNING] %s checkout failed for %s: %v %s\n": "[WARNING] %s 签出失败 %s: %v %s\n",
+	"[VCS] Checked out (%s): %s\n":                 "[VCS] 已签出 (%s): %s\n",
+	"\nPress Enter to continue...":                 "\n按回车键继续...",
+
+	"\n[Dry Run] No changes would be made.":                          "\n[Dry Run] 不会做任何更改。",
+	"\n[Dry Run] %d change(s) would be made; nothing was written:\n": "\n[Dry Run] 将会进行 %d 项更改，未写入任何内容:\n",
+
+	"[WARNING] Removing a stale %s (%s)\n":                      "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n": "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	if status == "ok" && isDryRun() {
+		status = "planned"
+	}
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode, dryRun, force, checkUpdates bool
+	var outDir, rewrite, registryURL, serveAddr, vcsMode, changelogPath string
+
+	flag.StringVar(&outDir, "out", defaultMirrorDir, "Mirror folder, relative to the project root or absolute")
+	flag.StringVar(&rewrite, "rewrite", "none", "Point Packages/manifest.json at the mirror: none, file, registry")
+	flag.StringVar(&registryURL, "registry-url", "", "URL the mirror is served from (required for -rewrite registry)")
+	flag.StringVar(&serveAddr, "serve", "", "Serve the mirror as a scoped registry on this address (e.g. :8780) instead of downloading")
+	flag.BoolVar(&checkUpdates, "check-updates", false, "List direct dependencies with newer registry versions and collect their changelogs instead of downloading")
+	flag.StringVar(&changelogPath, "changelog", defaultChangelogFile, "Review document written by -check-updates, relative to the project root or absolute")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no confirmation prompts)")
+	flag.BoolVar(&dryRun, "dry-run", false, "List what would be downloaded and show the manifest changes without writing")
+	flag.BoolVar(&force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
+	flag.StringVar(&vcsMode, "vcs", "auto", "Checkout manifest.json before writing: auto, none, p4, plastic")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_package_mirror")
+		ciMode = true
+	}
+	if dryRun {
+		fsys = newOverlayFS()
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+
+	switch rewrite {
+	case "none", "file":
+	case "registry":
+		if registryURL == "" {
+			fmt.Println(tr("[ERROR] -rewrite registry needs -registry-url (where -serve will run)"))
+			recordError("-rewrite registry needs -registry-url")
+			exit(2)
+		}
+	default:
+		fmt.Printf(tr("[ERROR] Unknown -rewrite mode '%s' (use none, file, registry)\n"), rewrite)
+		recordError("unknown -rewrite mode '%s'", rewrite)
+		exit(2)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+
+	mirrorDir := outDir
+	if !filepath.IsAbs(mirrorDir) {
+		mirrorDir = filepath.Join(basePath, filepath.FromSlash(outDir))
+	}
+	// Unity would import the tarballs as assets
+	if rel, err := filepath.Rel(basePath, mirrorDir); err == nil {
+		if rel = filepath.ToSlash(rel); rel == "Assets" || strings.HasPrefix(rel, "Assets/") {
+			fmt.Printf(tr("[ERROR] The mirror folder cannot be inside Assets/: %s\n"), outDir)
+			recordError("the mirror folder cannot be inside Assets/: %s", outDir)
+			exit(2)
+		}
+	}
+
+	// Serve mode: no downloads, runs until interrupted
+	if serveAddr != "" {
+		index, err := loadMirrorIndex(mirrorDir)
+		if err != nil {
+			fmt.Printf(tr("[ERROR] Cannot read the mirror in %s: %v\n"), outDir, err)
+			recordError("cannot read the mirror in %s: %v", outDir, err)
+			exitTool(1)
+		}
+		fmt.Printf(tr("Serving %d package(s) from %s on %s (Ctrl+C to stop)\n"), len(index.Packages), outDir, serveAddr)
+		if err := serveMirror(serveAddr, mirrorDir, index); err != nil {
+			fmt.Printf(tr("[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exitTool(1)
+		}
+		exitTool(0)
+	}
+
+	manifest, err := readManifest(basePath)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(1)
+	}
+	packages, skipped, fromLock, err := collectPackages(basePath, manifest)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(1)
+	}
+	if checkUpdates {
+		if !filepath.IsAbs(changelogPath) {
+			changelogPath = filepath.Join(basePath, filepath.FromSlash(changelogPath))
+		}
+		exit(runUpdateCheck(basePath, changelogPath, packages, dryRun))
+	}
+	if !fromLock {
+		fmt.Println(tr("[WARNING] Packages/packages-lock.json not found; only direct dependencies are mirrored."))
+		fmt.Println(tr("          Open the project in Unity once so the lock file lists the transitive ones."))
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  PACKAGE MIRROR"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Mirror folder:  %s\n"), outDir)
+	fmt.Printf(tr("  Packages:       %d\n"), len(packages))
+	for _, s := range skipped {
+		fmt.Printf(tr("  [--] Skipped %s (%s)\n"), s.name, s.reason)
+		recordAction("download", s.name, "skipped", s.reason, 0)
+	}
+	fmt.Println()
+
+	if len(packages) == 0 {
+		fmt.Println(tr("[--] No registry packages to mirror."))
+		exit(0)
+	}
+
+	// Download
+	auth := loadUPMAuth()
+	failed := 0
+	var total int64
+	for i, pkg := range packages {
+		pkg.File = tarballName(pkg)
+		label := pkg.Name + "@" + pkg.Version
+		if alreadyMirrored(mirrorDir, pkg) {
+			fmt.Printf(tr("[%d/%d] [--] Already mirrored: %s\n"), i+1, len(packages), label)
+			recordAction("download", label, "skipped", "already mirrored", 0)
+			continue
+		}
+		if dryRun {
+			fmt.Printf(tr("[%d/%d] [Dry Run] Would download %s from %s\n"), i+1, len(packages), label, pkg.Registry)
+			recordAction("download", label, "planned", pkg.Registry, 0)
+			continue
+		}
+		start := time.Now()
+		size, err := download(auth, mirrorDir, pkg)
+		if err != nil {
+			fmt.Printf(tr("[%d/%d] [ERROR] %s: %v\n"), i+1, len(packages), label, err)
+			recordAction("download", label, "failed", err.Error(), time.Since(start))
+			recordError("%s: %v", label, err)
+			failed++
+			continue
+		}
+		total += size
+		fmt.Printf(tr("[%d/%d] [OK] Downloaded: %s (%s)\n"), i+1, len(packages), label, formatSize(size))
+		recordAction("download", label, "ok", formatSize(size), time.Since(start))
+	}
+
+	if !dryRun {
+		index := &mirrorIndex{GeneratedAt: time.Now().Format(time.RFC3339), Project: filepath.Base(basePath)}
+		seen := make(map[string]bool)
+		for _, pkg := range packages {
+			if pkg.Shasum != "" {
+				index.Packages = append(index.Packages, pkg)
+				seen[pkg.Name+"@"+pkg.Version] = true
+			}
+		}
+		// Earlier entries stay: once the manifest uses file: references, the
+		// lock file no longer lists those packages as registry packages
+		if previous, err := loadMirrorIndex(mirrorDir); err == nil {
+			for _, pkg := range previous.Packages {
+				if seen[pkg.Name+"@"+pkg.Version] {
+					continue
+				}
+				if _, err := os.Stat(filepath.Join(mirrorDir, filepath.FromSlash(pkg.File))); err == nil {
+					index.Packages = append(index.Packages, pkg)
+				}
+			}
+		}
+		if err := saveMirrorIndex(mirrorDir, index); err != nil {
+			fmt.Printf(tr("[ERROR] Cannot write %s: %v\n"), mirrorIndexFile, err)
+			recordError("cannot write %s: %v", mirrorIndexFile, err)
+			exit(1)
+		}
+		recordArtifact(mirrorDir)
+	}
+
+	fmt.Println()
+	fmt.Printf(tr("  Downloaded: %s\n"), formatSize(total))
+	if failed > 0 {
+		fmt.Printf(tr("\n[ERROR] %d package(s) could not be downloaded; the manifest was not changed.\n"), failed)
+		exit(1)
+	}
+
+	if rewrite != "none" {
+		manifestPath := filepath.Join(basePath, "Packages", "manifest.json")
+		data, err := os.ReadFile(manifestPath)
+		if err != nil {
+			fmt.Printf(tr("[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exit(1)
+		}
+		text, format := decodeText(data)
+		refs := make(map[string]string)
+		var names []string
+		for _, pkg := range packages {
+			refs[pkg.Name] = fileReference(basePath, mirrorDir, pkg)
+			names = append(names, pkg.Name)
+		}
+		updated, changes, err := rewriteManifest(text, rewrite, registryURL, refs, names)
+		if err != nil {
+			fmt.Printf(tr("[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exit(1)
+		}
+		if changes == 0 {
+			fmt.Println(tr("\n[--] Packages/manifest.json already uses the mirror."))
+			exit(0)
+		}
+
+		activeVCS, err = detectVCS(basePath, vcsMode)
+		if err != nil {
+			fmt.Printf(tr("[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exit(1)
+		}
+		if !dryRun {
+			if err := acquireProjectLock(basePath, "unity_package_mirror", "rewrite manifest", force); err != nil {
+				fmt.Printf(tr("[ERROR] %v\n"), err)
+				recordError("%v", err)
+				exit(1)
+			}
+			defer releaseProjectLock()
+		}
+		if !ciMode && !dryRun {
+			fmt.Printf(tr("\nRewrite Packages/manifest.json to use the mirror (%s, %d package(s))? (y/N): "), rewrite, changes)
+			confirm, _ := stdinReader.ReadString('\n')
+			if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+				fmt.Println(tr("Operation cancelled."))
+				exit(0)
+			}
+		}
+
+		// Keep the online manifest next to the mirror so switching back is a copy
+		backup := filepath.Join(mirrorDir, manifestBackupFile)
+		if _, err := os.Stat(backup); os.IsNotExist(err) && !dryRun {
+			if err := os.WriteFile(backup, data, 0644); err != nil {
+				fmt.Printf(tr("[ERROR] Cannot write %s: %v\n"), manifestBackupFile, err)
+				recordError("cannot write %s: %v", manifestBackupFile, err)
+				exit(1)
+			}
+			fmt.Printf(tr("[OK] Original manifest saved as %s\n"), filepath.Join(outDir, manifestBackupFile))
+		}
+		if err := writeTextFileAtomic(manifestPath, updated, format); err != nil {
+			fmt.Printf(tr("[ERROR] Cannot write %s: %v\n"), "Packages/manifest.json", err)
+			recordError("cannot write Packages/manifest.json: %v", err)
+			exit(1)
+		}
+		fmt.Printf(tr("[OK] Updated: Packages/manifest.json (%s, %d package(s))\n"), rewrite, changes)
+		recordAction("modify", "Packages/manifest.json", "ok", rewrite, 0)
+		if rewrite == "registry" {
+			fmt.Printf(tr("[TIP] Start the registry on the build machine: unity_package_mirror -out %s -serve <addr>\n"), outDir)
+		}
+	}
+
+	if overlay, ok := fsys.(*overlayFS); ok {
+		overlay.printDryRunReport(basePath)
+		fmt.Println(tr("\n[Dry Run] Nothing was downloaded or written."))
+	}
+	exit(0)
+}
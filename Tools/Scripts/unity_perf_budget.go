@@ -0,0 +1,1697 @@
+// Unity Perf Budget — Check profiler captures against frame time, GC and draw call budgets.
+// Reads per-frame captures: CSV files written by a custom in-game recorder or a
+// device farm, and Unity Profiler .data / .raw captures, which are converted to
+// CSV in batch mode with the installed editor through ProfilerDataExporter from
+// "unity_build_hooks install". Each capture is checked against the budgets in
+// PerfBudgets.json (average, percentiles or maximum of any column: frame time,
+// GC allocated per frame, draw calls...), with overrides per capture for low-end
+// devices. Prints one table per capture and exits with 1 when a budget is
+// exceeded, so a CI job fails on a performance regression.
+//
+// Build: go build unity_perf_budget.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_perf_budget                                  # captures in Build/Profiles
+//	unity_perf_budget Captures/Pixel7.data run.csv     # the given files or folders
+//	unity_perf_budget -ci -annotate github
+//	unity_perf_budget -dry-run                         # show the Unity command line
+//
+// A CSV capture has a header row and one row per frame; columns are matched by
+// name without case, spaces or punctuation ("Frame Time (ms)" is frameTimeMs).
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	defaultBudgetFile = "PerfBudgets.json" // in the project root
+	defaultCaptureDir = "Build/Profiles"   // scanned when no capture is given
+	defaultOutDir     = "Build/PerfBudget" // CSV converted from .data / .raw captures
+	exportLogRel      = "Library/UnityStarter/ProfilerExport.log"
+	exportMethod      = "Build.Hooks.Editor.ProfilerDataExporter.ExportFromCommandLine"
+
+	// Overrides the editor picked from ProjectVersion.txt and the Unity Hub folders
+	envUnityPath = "UNITYSTARTER_UNITY"
+)
+
+// Contract with the editor hooks; keep in sync with unity_build_hooks.go
+const (
+	envProfileCaptures      = "UNITYSTARTER_PROFILE_CAPTURES"
+	envProfileReport        = "UNITYSTARTER_PROFILE_REPORT"
+	defaultProfileReportRel = "Library/UnityStarter/LastProfileExport.json"
+
+	hooksManifestFile = "UnityStarterBuildHooks.json" // in ProjectSettings/
+	exportHooksMin    = 4                             // first hooks version with ProfilerDataExporter
+)
+
+// Capture files: CSV is read directly, Profiler captures are converted first
+var (
+	csvExtensions      = []string{".csv", ".tsv"}
+	profilerExtensions = []string{".data", ".raw"}
+)
+
+// Statistics a budget can limit, in the order they are printed
+var budgetStats = []string{"avg", "p50", "p90", "p95", "p99", "max"}
+
+// Column names that mean the same metric, by normalized name. The columns
+// ProfilerDataExporter writes are the canonical names.
+var metricAliases = map[string]string{
+	"frametimems":        "frametime",
+	"cpuframetime":       "frametime",
+	"cpuframetimems":     "frametime",
+	"gcallocbytes":       "gcalloc",
+	"gcallocated":        "gcalloc",
+	"gcallocatedinframe": "gcalloc",
+	"gcallocinframe":     "gcalloc",
+	"drawcallscount":     "drawcalls",
+	"batchescount":       "batches",
+	"setpasscallscount":  "setpasscalls",
+	"totalusedmemory":    "usedmemory",
+}
+
+// Units of the canonical metrics; other columns print as plain numbers
+var metricUnits = map[string]string{
+	"frametime":  "ms",
+	"gcalloc":    "bytes",
+	"usedmemory": "bytes",
+}
+
+// Budget results
+const (
+	statusOK     = "ok"
+	statusOver   = "over"
+	statusNoData = "nodata" // the capture has no such column, or no frame with a value
+)
+
+var (
+	projectVersionRegex = regexp.MustCompile(`(?m)^m_EditorVersion:\s*(\S+)`)
+
+	// Log lines worth showing when the export fails
+	logErrorRegex = regexp.MustCompile(`(?i)(error CS\d+|\[ProfilerDataExporter\].*:|\[Error\]|Exception:|error:)`)
+
+	sizeRegex = regexp.MustCompile(`(?i)^([\d.]+)\s*(b|kb|mb|gb|tb)?$`)
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// EditorInstance represents the structure of Library/EditorInstance.json
+type EditorInstance struct {
+	ProcessID int `json:"process_id"`
+}
+
+// budgetLimit is an upper bound; sizes may be written as "64KB"
+type budgetLimit float64
+
+func (l *budgetLimit) UnmarshalJSON(data []byte) error {
+	var n float64
+	if err := json.Unmarshal(data, &n); err == nil {
+		*l = budgetLimit(n)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("a budget must be a number or a size such as \"64KB\", not %s", data)
+	}
+	size, err := parseSize(s)
+	if err != nil {
+		return err
+	}
+	*l = budgetLimit(size)
+	return nil
+}
+
+// metricBudget maps a statistic (avg, p50, p90, p95, p99, max) to its limit
+type metricBudget map[string]budgetLimit
+
+// budgetFile is PerfBudgets.json. Budgets are keyed by column name; overrides
+// apply in order to the captures they match and replace single limits.
+type budgetFile struct {
+	WarmupFrames int                     `json:"warmupFrames"` // frames skipped at the start of every capture
+	Budgets      map[string]metricBudget `json:"budgets"`
+	Overrides    []budgetOverride        `json:"overrides"`
+}
+
+type budgetOverride struct {
+	Match        string                  `json:"match"` // glob of capture paths relative to the project root
+	WarmupFrames *int                    `json:"warmupFrames"`
+	Budgets      map[string]metricBudget `json:"budgets"`
+}
+
+// captureBudgets is what applies to one capture after the overrides
+type captureBudgets struct {
+	warmup  int
+	metrics []string // budgeted column names as written in the config, sorted
+	limits  map[string]metricBudget
+}
+
+// capture is one file to check
+type capture struct {
+	path     string // absolute
+	rel      string // relative to the project root (or as given), slash-separated
+	csvPath  string // the CSV that is read; the converted file for Profiler captures
+	profiler bool
+	convert  bool // the CSV is missing or older than the capture
+}
+
+// frameTable holds the columns of a CSV capture by normalized metric name;
+// NaN marks a frame without a value
+type frameTable struct {
+	frames  int
+	skipped int
+	columns map[string][]float64
+}
+
+// budgetCheck is one limit of one capture
+type budgetCheck struct {
+	metric string
+	stat   string
+	value  float64
+	limit  float64
+	status string
+}
+
+// exportReport is the LastProfileExport.json ProfilerDataExporter writes
+type exportReport struct {
+	ContractVersion int    `json:"contractVersion"`
+	UnityVersion    string `json:"unityVersion"`
+	FinishedAt      string `json:"finishedAt"`
+	Captures        []struct {
+		Capture string `json:"capture"`
+		CSV     string `json:"csv"`
+		Frames  int    `json:"frames"`
+		Error   string `json:"error"`
+	} `json:"captures"`
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// checkUnityRunning checks if Unity Editor is running for this project
+// via Library/EditorInstance.json and a liveness check on the recorded PID.
+func checkUnityRunning(basePath string) (bool, int) {
+	data, err := os.ReadFile(filepath.Join(basePath, "Library", "EditorInstance.json"))
+	if err != nil {
+		return false, 0
+	}
+	var instance EditorInstance
+	if err := json.Unmarshal(data, &instance); err != nil || instance.ProcessID <= 0 {
+		return false, 0
+	}
+	if isProcessRunning(instance.ProcessID) {
+		return true, instance.ProcessID
+	}
+	return false, 0
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// readUnityVersion returns the editor version from ProjectSettings/ProjectVersion.txt
+func readUnityVersion(basePath string) string {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectVersion.txt"))
+	if err != nil {
+		return ""
+	}
+	if m := projectVersionRegex.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// installedHooksVersion reads the hooks manifest; 0 when the hooks are not installed
+func installedHooksVersion(basePath string) int {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", hooksManifestFile))
+	if err != nil {
+		return 0
+	}
+	var m struct {
+		HooksVersion int `json:"hooksVersion"`
+	}
+	json.Unmarshal(data, &m)
+	return m.HooksVersion
+}
+
+// ============================================================
+// Budgets
+// ============================================================
+
+// loadBudgets reads PerfBudgets.json and checks its statistics and globs
+func loadBudgets(p string) (*budgetFile, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var b budgetFile
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", filepath.Base(p), err)
+	}
+	if len(b.Budgets) == 0 {
+		return nil, fmt.Errorf("%s defines no budgets", filepath.Base(p))
+	}
+	check := func(where string, budgets map[string]metricBudget) error {
+		for metric, limits := range budgets {
+			if normalizeMetric(metric) == "" {
+				return fmt.Errorf("%s: invalid metric name '%s'", where, metric)
+			}
+			for stat := range limits {
+				if !containsString(budgetStats, stat) {
+					return fmt.Errorf("%s: unknown statistic '%s' for %s (use %s)", where, stat, metric, strings.Join(budgetStats, ", "))
+				}
+			}
+		}
+		return nil
+	}
+	if err := check("budgets", b.Budgets); err != nil {
+		return nil, err
+	}
+	for i, o := range b.Overrides {
+		if strings.TrimSpace(o.Match) == "" {
+			return nil, fmt.Errorf("overrides[%d] has no \"match\"", i)
+		}
+		if _, err := globToRegexp(o.Match); err != nil {
+			return nil, fmt.Errorf("overrides[%d]: invalid glob '%s': %v", i, o.Match, err)
+		}
+		if err := check(fmt.Sprintf("overrides[%d]", i), o.Budgets); err != nil {
+			return nil, err
+		}
+	}
+	return &b, nil
+}
+
+// budgetsFor applies the matching overrides to the base budgets
+func (b *budgetFile) budgetsFor(rel string) *captureBudgets {
+	cb := &captureBudgets{warmup: b.WarmupFrames, limits: make(map[string]metricBudget)}
+	merge := func(budgets map[string]metricBudget) {
+		for metric, limits := range budgets {
+			// "frameTime" in an override replaces limits of "FrameTime (ms)" in the base
+			key := metric
+			for existing := range cb.limits {
+				if normalizeMetric(existing) == normalizeMetric(metric) {
+					key = existing
+				}
+			}
+			if cb.limits[key] == nil {
+				cb.limits[key] = make(metricBudget)
+			}
+			for stat, limit := range limits {
+				cb.limits[key][stat] = limit
+			}
+		}
+	}
+	merge(b.Budgets)
+	for _, o := range b.Overrides {
+		re, _ := globToRegexp(o.Match)
+		if !re.MatchString(rel) {
+			continue
+		}
+		if o.WarmupFrames != nil {
+			cb.warmup = *o.WarmupFrames
+		}
+		merge(o.Budgets)
+	}
+	for metric := range cb.limits {
+		cb.metrics = append(cb.metrics, metric)
+	}
+	sort.Strings(cb.metrics)
+	return cb
+}
+
+// normalizeMetric turns a column or budget name into its canonical metric:
+// lower case letters and digits only, with the aliases resolved
+func normalizeMetric(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	key := b.String()
+	if alias, ok := metricAliases[key]; ok {
+		return alias
+	}
+	return key
+}
+
+// ============================================================
+// Captures
+// ============================================================
+
+func hasExtension(path string, list []string) bool {
+	return containsString(list, strings.ToLower(filepath.Ext(path)))
+}
+
+// findCaptures resolves the capture arguments: files as they are, folders
+// scanned for CSV and Profiler captures. The folder converted CSVs go to is skipped.
+func findCaptures(basePath string, args []string, outDir string) ([]*capture, error) {
+	var found []*capture
+	seen := make(map[string]bool)
+	add := func(abs string) {
+		if seen[abs] {
+			return
+		}
+		seen[abs] = true
+		rel := filepath.ToSlash(abs)
+		if r, err := filepath.Rel(basePath, abs); err == nil && !strings.HasPrefix(r, "..") {
+			rel = filepath.ToSlash(r)
+		}
+		found = append(found, &capture{path: abs, rel: rel, profiler: hasExtension(abs, profilerExtensions)})
+	}
+	for _, arg := range args {
+		abs := arg
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(basePath, filepath.FromSlash(arg))
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, fmt.Errorf("capture not found: %s", arg)
+		}
+		if !info.IsDir() {
+			if !hasExtension(abs, csvExtensions) && !hasExtension(abs, profilerExtensions) {
+				return nil, fmt.Errorf("%s is not a capture (use .csv, .tsv, .data or .raw)", arg)
+			}
+			add(abs)
+			continue
+		}
+		var files []string
+		filepath.Walk(abs, func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if fi.IsDir() {
+				if path == outDir {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if hasExtension(path, csvExtensions) || hasExtension(path, profilerExtensions) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		sort.Strings(files)
+		for _, f := range files {
+			add(f)
+		}
+	}
+	return found, nil
+}
+
+// convertedPath is where the CSV of a Profiler capture goes: the capture's
+// project path with '/' replaced, so captures of the same name do not collide
+func convertedPath(outDir string, c *capture) string {
+	name := strings.NewReplacer("/", "_", ":", "_").Replace(strings.TrimPrefix(c.rel, "/"))
+	return filepath.Join(outDir, name+".csv")
+}
+
+// planConversions sets the CSV of every capture and marks the Profiler captures
+// whose CSV is missing or older than the capture
+func planConversions(captures []*capture, outDir string, reconvert bool) []*capture {
+	var pending []*capture
+	for _, c := range captures {
+		if !c.profiler {
+			c.csvPath = c.path
+			continue
+		}
+		c.csvPath = convertedPath(outDir, c)
+		src, err := os.Stat(c.path)
+		dst, dstErr := os.Stat(c.csvPath)
+		if reconvert || err != nil || dstErr != nil || dst.ModTime().Before(src.ModTime()) {
+			c.convert = true
+			pending = append(pending, c)
+		}
+	}
+	return pending
+}
+
+// ============================================================
+// Profiler Export
+// ============================================================
+
+// exportCommand returns the Unity arguments and the extra environment that
+// convert the given captures
+func exportCommand(basePath string, captures []*capture) ([]string, []string) {
+	args := []string{
+		"-batchmode", "-quit",
+		"-projectPath", basePath,
+		"-executeMethod", exportMethod,
+		"-logFile", filepath.Join(basePath, filepath.FromSlash(exportLogRel)),
+	}
+	var entries []string
+	for _, c := range captures {
+		entries = append(entries, c.path+"="+c.csvPath)
+	}
+	env := []string{
+		envProfileCaptures + "=" + strings.Join(entries, ";"),
+		envProfileReport + "=" + filepath.Join(basePath, filepath.FromSlash(defaultProfileReportRel)),
+	}
+	return args, env
+}
+
+// runExport starts Unity and returns the report ProfilerDataExporter wrote. Unity
+// exits with 1 when a capture failed, so the report is read in that case too.
+func runExport(basePath, unity string, captures []*capture, timeout time.Duration) (*exportReport, error) {
+	reportPath := filepath.Join(basePath, filepath.FromSlash(defaultProfileReportRel))
+	logPath := filepath.Join(basePath, filepath.FromSlash(exportLogRel))
+	// Leftovers of the previous run must not pass for this run's results
+	os.Remove(reportPath)
+	os.Remove(logPath)
+	if err := os.MkdirAll(filepath.Dir(reportPath), 0755); err != nil {
+		return nil, err
+	}
+	for _, c := range captures {
+		if err := os.MkdirAll(filepath.Dir(c.csvPath), 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	args, env := exportCommand(basePath, captures)
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, unity, args...)
+	cmd.Dir = basePath
+	cmd.Env = append(os.Environ(), env...)
+	runErr := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("Unity failed (%v) and wrote no export report", runErr)
+		}
+		return nil, errors.New("no export report was written (hooks missing or the project did not compile)")
+	}
+	var report exportReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("cannot read %s: %v", defaultProfileReportRel, err)
+	}
+	return &report, nil
+}
+
+// logExcerpt returns the last error lines of a log, or its last lines when no
+// line looks like an error
+func logExcerpt(logPath string, max int) []string {
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	var errorLines []string
+	for _, line := range lines {
+		if logErrorRegex.MatchString(line) {
+			errorLines = append(errorLines, strings.TrimSpace(line))
+		}
+	}
+	if len(errorLines) == 0 {
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		errorLines = lines
+	}
+	if len(errorLines) > max {
+		errorLines = errorLines[len(errorLines)-max:]
+	}
+	return errorLines
+}
+
+// ============================================================
+// Frame Data
+// ============================================================
+
+// readFrameTable reads a CSV capture, skipping the first warmup frames. The
+// delimiter (',', ';' or tab) is taken from the header row; "#" lines are comments.
+func readFrameTable(path string, warmup int) (*frameTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	header := text
+	for strings.HasPrefix(header, "#") {
+		if i := strings.IndexByte(header, '\n'); i >= 0 {
+			header = header[i+1:]
+		} else {
+			header = ""
+		}
+	}
+	if i := strings.IndexByte(header, '\n'); i >= 0 {
+		header = header[:i]
+	}
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = ','
+	for _, sep := range []rune{'\t', ';'} {
+		if strings.Count(header, string(sep)) > strings.Count(header, string(r.Comma)) {
+			r.Comma = sep
+		}
+	}
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	names, err := r.Read()
+	if err != nil {
+		return nil, errors.New("no header row")
+	}
+	keys := make([]string, len(names))
+	table := &frameTable{columns: make(map[string][]float64)}
+	used := make(map[string]bool)
+	for i, name := range names {
+		key := normalizeMetric(name)
+		// The frame number is not a metric; of two columns for one metric the first counts
+		if key == "frame" || key == "frameindex" || used[key] {
+			continue
+		}
+		used[key] = true
+		keys[i] = key
+	}
+	for row := 0; ; row++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if row < warmup {
+			table.skipped++
+			continue
+		}
+		for i, key := range keys {
+			if key == "" {
+				continue
+			}
+			v := math.NaN()
+			if i < len(record) {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(record[i]), 64); err == nil {
+					v = f
+				}
+			}
+			table.columns[key] = append(table.columns[key], v)
+		}
+		table.frames++
+	}
+	return table, nil
+}
+
+// computeStat returns the statistic of the values that are set; false when none is.
+// Percentiles use the nearest rank.
+func computeStat(values []float64, stat string) (float64, bool) {
+	var set []float64
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			set = append(set, v)
+		}
+	}
+	if len(set) == 0 {
+		return 0, false
+	}
+	sort.Float64s(set)
+	switch stat {
+	case "avg":
+		sum := 0.0
+		for _, v := range set {
+			sum += v
+		}
+		return sum / float64(len(set)), true
+	case "max":
+		return set[len(set)-1], true
+	}
+	p, _ := strconv.ParseFloat(strings.TrimPrefix(stat, "p"), 64)
+	rank := int(math.Ceil(p/100*float64(len(set)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return set[rank], true
+}
+
+// evaluate checks every limit that applies to a capture
+func evaluate(table *frameTable, budgets *captureBudgets) []budgetCheck {
+	var checks []budgetCheck
+	for _, metric := range budgets.metrics {
+		values := table.columns[normalizeMetric(metric)]
+		for _, stat := range budgetStats {
+			limit, ok := budgets.limits[metric][stat]
+			if !ok {
+				continue
+			}
+			c := budgetCheck{metric: metric, stat: stat, limit: float64(limit), status: statusNoData}
+			if v, ok := computeStat(values, stat); ok {
+				c.value = v
+				c.status = statusOK
+				if v > c.limit {
+					c.status = statusOver
+				}
+			}
+			checks = append(checks, c)
+		}
+	}
+	return checks
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+// formatMetric renders a value in the unit of its metric
+func formatMetric(metric string, v float64) string {
+	switch metricUnits[normalizeMetric(metric)] {
+	case "ms":
+		return strconv.FormatFloat(v, 'f', 2, 64) + " ms"
+	case "bytes":
+		return formatSize(int64(math.Round(v)))
+	}
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// printChecks prints the budget table of one capture
+func printChecks(checks []budgetCheck) {
+	width := len("Metric")
+	for _, c := range checks {
+		if len(c.metric) > width {
+			width = len(c.metric)
+		}
+	}
+	fmt.Printf("  %-*s  %-4s  %12s  %12s\n", width, tr("Metric"), tr("Stat"), tr("Value"), tr("Budget"))
+	for _, c := range checks {
+		value, result := "-", tr("[--]   no data")
+		switch c.status {
+		case statusOK:
+			value, result = formatMetric(c.metric, c.value), "[OK]"
+		case statusOver:
+			value = formatMetric(c.metric, c.value)
+			result = "[FAIL] " + tr("over budget")
+			if c.limit > 0 {
+				result = fmt.Sprintf("[FAIL] +%.1f%%", (c.value-c.limit)/c.limit*100)
+			}
+		}
+		fmt.Printf("  %-*s  %-4s  %12s  %12s  %s\n", width, c.metric, c.stat, value, formatMetric(c.metric, c.limit), result)
+	}
+}
+
+// ============================================================
+// Installed Editors
+// ============================================================
+
+// hubEditorFolders returns the folders Unity Hub installs editors into: the
+// default location plus the custom one from secondaryInstallPath.json
+func hubEditorFolders() []string {
+	home, _ := os.UserHomeDir()
+	var folders []string
+	var hubConfig string
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramW6432"} {
+			if pf := os.Getenv(env); pf != "" {
+				folders = append(folders, filepath.Join(pf, "Unity", "Hub", "Editor"))
+			}
+		}
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			hubConfig = filepath.Join(appData, "UnityHub")
+		}
+	case "darwin":
+		folders = append(folders, "/Applications/Unity/Hub/Editor")
+		hubConfig = filepath.Join(home, "Library", "Application Support", "UnityHub")
+	default:
+		folders = append(folders, filepath.Join(home, "Unity", "Hub", "Editor"))
+		hubConfig = filepath.Join(home, ".config", "UnityHub")
+	}
+	if hubConfig != "" {
+		// The file holds a single JSON string; empty when no custom location is set
+		if data, err := os.ReadFile(filepath.Join(hubConfig, "secondaryInstallPath.json")); err == nil {
+			var custom string
+			if json.Unmarshal(data, &custom) == nil && custom != "" {
+				folders = append(folders, custom)
+			}
+		}
+	}
+	return folders
+}
+
+// editorExecutable is the Unity binary inside an editor version folder
+func editorExecutable(dir string) string {
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(dir, "Editor", "Unity.exe")
+	case "darwin":
+		return filepath.Join(dir, "Unity.app", "Contents", "MacOS", "Unity")
+	default:
+		return filepath.Join(dir, "Editor", "Unity")
+	}
+}
+
+// findUnity picks the editor: -unity, UNITYSTARTER_UNITY, then the Hub install
+// of the project's exact version. Another patch version is never picked
+// silently; it would upgrade the project.
+func findUnity(basePath, override string) (string, error) {
+	for _, candidate := range []string{override, os.Getenv(envUnityPath)} {
+		if candidate == "" {
+			continue
+		}
+		// A version folder works as well as the binary itself
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			candidate = editorExecutable(candidate)
+		}
+		if _, err := os.Stat(candidate); err != nil {
+			return "", fmt.Errorf("Unity editor not found: %s", candidate)
+		}
+		return candidate, nil
+	}
+	version := readUnityVersion(basePath)
+	if version == "" {
+		return "", errors.New("cannot read the Unity version from ProjectSettings/ProjectVersion.txt; pass -unity")
+	}
+	for _, folder := range hubEditorFolders() {
+		exe := editorExecutable(filepath.Join(folder, version))
+		if _, err := os.Stat(exe); err == nil {
+			return exe, nil
+		}
+	}
+	return "", fmt.Errorf("Unity %s is not installed in the Unity Hub folders; install it or pass -unity", version)
+}
+
+// ============================================================
+// Path Filters
+// ============================================================
+
+// globToRegexp converts a glob (*, ?, **) on slash-separated project paths. A
+// pattern without a slash matches the file name in any folder.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compileGlobs parses a comma-separated glob list
+func compileGlobs(list string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := globToRegexp(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, rel string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+// parseSize accepts "64KB", "1.5MB" or plain bytes
+func parseSize(s string) (int64, error) {
+	m := sizeRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid size: %s", s)
+	}
+	v, _ := strconv.ParseFloat(m[1], 64)
+	switch strings.ToLower(m[2]) {
+	case "kb":
+		v *= 1 << 10
+	case "mb":
+		v *= 1 << 20
+	case "gb":
+		v *= 1 << 30
+	case "tb":
+		v *= 1 << 40
+	}
+	return int64(v), nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...":                              "\n按回车键继续...",
+	"[WARNING] Removing a stale %s (%s)\n":                      "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n": "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+	"Metric":         "指标",
+	"Stat":           "统计",
+	"Value":          "数值",
+	"Budget":         "预算",
+	"[--]   no data": "[--]   无数据",
+	"over budget":    "超出预算",
+	"[ERROR] %v\n":   "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                                                                 "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.":                                           "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                                                     "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"[ERROR] No budget file: %s\n":                                                                               "[ERROR] 没有预算文件: %s\n",
+	"[TIP] Create PerfBudgets.json with limits per metric, e.g. {\"budgets\": {\"frameTime\": {\"p95\": 16.7}}}": "[TIP] 创建 PerfBudgets.json 并为每个指标设置上限，例如 {\"budgets\": {\"frameTime\": {\"p95\": 16.7}}}",
+	"  PERFORMANCE BUDGETS":                                                                                      "  性能预算",
+	"  Project:  %s\n":                                                                                           "  项目:     %s\n",
+	"  Budgets:  %s\n":                                                                                           "  预算:     %s\n",
+	"  Captures: %d\n":                                                                                           "  采集:     %d\n",
+	"\n[--] No captures found (.csv, .tsv, .data or .raw).":                                                      "\n[--] 未找到采集文件（.csv、.tsv、.data 或 .raw）。",
+	"[WARNING] %v\n":                                                                                             "[WARNING] %v\n",
+	"[WARNING] Converting Profiler captures needs build hooks v%d or newer (installed: v%d); run: unity_build_hooks install\n":                            "[WARNING] 转换 Profiler 采集需要 v%d 或更新的构建钩子（已安装: v%d）；请运行: unity_build_hooks install\n",
+	"\n[Dry Run] Unity was not started; %d Profiler capture(s) are not checked.\n":                                                                        "\n[Dry Run] 未启动 Unity；%d 个 Profiler 采集未检查。\n",
+	"\n[ERROR] Unity Editor is running (PID: %d). Close it before converting Profiler captures; batch mode cannot open a project that is already open.\n": "\n[ERROR] Unity 编辑器正在运行 (PID: %d)。请先关闭再转换 Profiler 采集；批处理模式无法打开已经打开的项目。\n",
+	"\n[ERROR] %v\n": "\n[ERROR] %v\n",
+	"\nConverting %d Profiler capture(s) with %s ...\n": "\n正在使用 %[2]s 转换 %[1]d 个 Profiler 采集 ...\n",
+	"[FAIL] %v\n":                           "[FAIL] %v\n",
+	"       Log: %s\n":                      "       日志: %s\n",
+	"[FAIL] %s: %s\n":                       "[FAIL] %s: %s\n",
+	"[OK]   %s\n":                           "[OK]   %s\n",
+	"Converted in %s\n":                     "转换耗时 %s\n",
+	"Capture":                               "采集",
+	"[ERROR] %s: %v\n":                      "[ERROR] %s: %v\n",
+	"  %d frame(s), the first %d skipped\n": "  %d 帧，跳过前 %d 帧\n",
+	"[ERROR] %s has no frames after the warm-up\n": "[ERROR] %s 在预热之后没有帧\n",
+	"  SUMMARY":                              "  摘要",
+	"  Budgets checked: %d\n":                "  已检查预算: %d\n",
+	"  Exceeded:        %d\n":                "  超出:       %d\n",
+	"  No data:         %d\n":                "  无数据:     %d\n",
+	"  Unreadable:      %d capture(s)\n":     "  无法读取:   %d 个采集\n",
+	"\n[FAIL] Performance budgets exceeded.": "\n[FAIL] 超出性能预算。",
+	"\n[FAIL] Budgeted metrics are missing from captures (-strict).":                           "\n[FAIL] 采集中缺少已设预算的指标 (-strict)。",
+	"\n[WARNING] Some budgeted metrics are missing from the captures; check the column names.": "\n[WARNING] 部分已设预算的指标在采集中缺失；请检查列名。",
+	"\n[OK] All captures are within budget.":                                                   "\n[OK] 所有采集都在预算之内。",
+	"\n[--] No budgets were checked.":                                                          "\n[--] 没有检查任何预算。",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// CI Annotations
+// ============================================================
+
+// With -annotate, errors and warnings are printed once more as GitHub Actions
+// workflow commands or TeamCity service messages, so the CI lists them on the run
+// page and, when they point at a file, next to that file in the diff.
+var (
+	annotateMode  string              // "", "github" or "teamcity"
+	annotateTool  string              // TeamCity inspection category
+	annotateBase  string              // project root; relative files start here
+	annotateRoot  string              // repository root; CI file paths are relative to it
+	annotateTypes = map[string]bool{} // TeamCity inspection types already declared
+)
+
+// setAnnotateMode checks the -annotate value; "auto" picks the CI the tool runs in
+func setAnnotateMode(mode, basePath, tool string) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "none":
+		annotateMode = ""
+	case "github", "teamcity":
+		annotateMode = strings.ToLower(strings.TrimSpace(mode))
+	case "auto":
+		annotateMode = ""
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			annotateMode = "github"
+		} else if os.Getenv("TEAMCITY_VERSION") != "" {
+			annotateMode = "teamcity"
+		}
+	default:
+		return fmt.Errorf("unknown -annotate mode '%s' (use github, teamcity or auto)", mode)
+	}
+	annotateTool = tool
+	annotateBase, _ = filepath.Abs(basePath)
+	annotateRoot = repositoryRoot(annotateBase)
+	return nil
+}
+
+// repositoryRoot is GITHUB_WORKSPACE, else the nearest folder with a .git entry
+// (a folder, or a file in worktrees and submodules), else the project itself
+func repositoryRoot(basePath string) string {
+	if ws := os.Getenv("GITHUB_WORKSPACE"); ws != "" && annotateMode == "github" {
+		return ws
+	}
+	for dir := basePath; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return basePath
+		}
+	}
+}
+
+// annotate prints one annotation. level is "error" or "warning"; file is absolute
+// or relative to the project root, and may be empty like line and col.
+func annotate(level, file string, line, col int, title, message string) {
+	if annotateMode == "" {
+		return
+	}
+	if file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(annotateBase, filepath.FromSlash(file))
+		}
+		if rel, err := filepath.Rel(annotateRoot, file); err == nil {
+			file = rel
+		}
+		file = filepath.ToSlash(file)
+	}
+
+	if annotateMode == "github" {
+		// Property values also escape ':' and ','; the message only '%' and line breaks
+		data := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+		prop := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+		var props []string
+		if file != "" {
+			props = append(props, "file="+prop.Replace(file))
+			if line > 0 {
+				props = append(props, "line="+strconv.Itoa(line))
+			}
+			if col > 0 {
+				props = append(props, "col="+strconv.Itoa(col))
+			}
+		}
+		if title != "" {
+			props = append(props, "title="+prop.Replace(title))
+		}
+		fmt.Printf("::%s %s::%s\n", level, strings.Join(props, ","), data.Replace(message))
+		return
+	}
+
+	esc := strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]").Replace
+	status := "WARNING"
+	if level == "error" {
+		status = "ERROR"
+	}
+	if file == "" {
+		text := message
+		if title != "" {
+			text = title + ": " + message
+		}
+		fmt.Printf("##teamcity[message text='%s' status='%s']\n", esc(text), status)
+		return
+	}
+	typeID := annotateTool + "." + strings.ReplaceAll(strings.ToLower(title), " ", "-")
+	if !annotateTypes[typeID] {
+		annotateTypes[typeID] = true
+		fmt.Printf("##teamcity[inspectionType id='%s' name='%s' category='%s' description='%s']\n", esc(typeID), esc(title), esc(annotateTool), esc(title))
+	}
+	lineAttr := ""
+	if line > 0 {
+		lineAttr = fmt.Sprintf(" line='%d'", line)
+	}
+	fmt.Printf("##teamcity[inspection typeId='%s' message='%s' file='%s'%s SEVERITY='%s']\n", esc(typeID), esc(message), esc(file), lineAttr, status)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode     bool
+		dryRun     bool
+		force      bool
+		strict     bool
+		reconvert  bool
+		configFlag string
+		outFlag    string
+		unityFlag  string
+		annotateFl string
+		warmupFlag int
+		timeout    time.Duration
+	)
+
+	flag.StringVar(&configFlag, "config", defaultBudgetFile, "Budget file, relative to the project root or absolute")
+	flag.IntVar(&warmupFlag, "warmup", -1, "Frames to skip at the start of every capture (default: warmupFrames of the budget file)")
+	flag.StringVar(&outFlag, "out", defaultOutDir, "Folder for the CSV converted from .data / .raw captures")
+	flag.BoolVar(&reconvert, "reconvert", false, "Convert Profiler captures again even if their CSV is up to date")
+	flag.BoolVar(&strict, "strict", false, "Also fail when a capture has no data for a budgeted metric")
+	flag.StringVar(&unityFlag, "unity", "", "Unity editor binary or version folder, for .data / .raw captures (default: UNITYSTARTER_UNITY, then the Hub install of the project's version)")
+	flag.DurationVar(&timeout, "timeout", 0, "Abort the Unity conversion after this long (e.g. 20m; default: none)")
+	flag.StringVar(&annotateFl, "annotate", "", "Also print exceeded budgets as CI annotations: github, teamcity, auto")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Print the Unity command line for the captures that need converting, and check only the others")
+	flag.BoolVar(&force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the captures ("run.csv -ci")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_perf_budget")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if err := setAnnotateMode(annotateFl, basePath, "unity_perf_budget"); err != nil {
+		fail(2, "%v", err)
+	}
+
+	configPath := configFlag
+	if !filepath.IsAbs(configPath) {
+		configPath = filepath.Join(basePath, filepath.FromSlash(configPath))
+	}
+	budgets, err := loadBudgets(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf(tr("[ERROR] No budget file: %s\n"), configPath)
+		recordError("no budget file: %s", configPath)
+		fmt.Println(tr("[TIP] Create PerfBudgets.json with limits per metric, e.g. {\"budgets\": {\"frameTime\": {\"p95\": 16.7}}}"))
+		exit(2)
+	}
+	if err != nil {
+		fail(2, "%v", err)
+	}
+	outDir := outFlag
+	if !filepath.IsAbs(outDir) {
+		outDir = filepath.Join(basePath, filepath.FromSlash(outDir))
+	}
+
+	if len(args) == 0 {
+		if _, err := os.Stat(filepath.Join(basePath, filepath.FromSlash(defaultCaptureDir))); err != nil {
+			fail(2, "no captures given and %s does not exist", defaultCaptureDir)
+		}
+		args = []string{defaultCaptureDir}
+	}
+	captures, err := findCaptures(basePath, args, outDir)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  PERFORMANCE BUDGETS"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:  %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Budgets:  %s\n"), configPath)
+	fmt.Printf(tr("  Captures: %d\n"), len(captures))
+	if len(captures) == 0 {
+		fmt.Println(tr("\n[--] No captures found (.csv, .tsv, .data or .raw)."))
+		exit(0)
+	}
+
+	captureErrors := 0
+	pending := planConversions(captures, outDir, reconvert)
+	if len(pending) > 0 {
+		unity, err := findUnity(basePath, unityFlag)
+		if err != nil && !dryRun {
+			fail(1, "%v", err)
+		}
+		if dryRun {
+			if err != nil {
+				// The dry run still shows the command line
+				fmt.Printf(tr("[WARNING] %v\n"), err)
+				unity = "Unity"
+			}
+			if hooks := installedHooksVersion(basePath); hooks < exportHooksMin {
+				fmt.Printf(tr("[WARNING] Converting Profiler captures needs build hooks v%d or newer (installed: v%d); run: unity_build_hooks install\n"), exportHooksMin, hooks)
+			}
+			exportArgs, env := exportCommand(basePath, pending)
+			fmt.Printf("\n  %s %s\n", unity, strings.Join(exportArgs, " "))
+			for _, e := range env {
+				fmt.Printf("  %s\n", e)
+			}
+			for _, c := range pending {
+				recordAction("convert", c.rel, "planned", "", 0)
+			}
+			fmt.Printf(tr("\n[Dry Run] Unity was not started; %d Profiler capture(s) are not checked.\n"), len(pending))
+		} else {
+			if hooks := installedHooksVersion(basePath); hooks < exportHooksMin {
+				fail(1, "converting Profiler captures needs build hooks v%d or newer (installed: v%d); run: unity_build_hooks install", exportHooksMin, hooks)
+			}
+			if isRunning, pid := checkUnityRunning(basePath); isRunning {
+				fmt.Printf(tr("\n[ERROR] Unity Editor is running (PID: %d). Close it before converting Profiler captures; batch mode cannot open a project that is already open.\n"), pid)
+				recordError("Unity Editor is running (PID: %d). Close it before converting Profiler captures", pid)
+				exit(1)
+			}
+			if err := acquireProjectLock(basePath, "unity_perf_budget", "convert", force); err != nil {
+				fmt.Printf(tr("\n[ERROR] %v\n"), err)
+				recordError("%v", err)
+				exit(1)
+			}
+			fmt.Printf(tr("\nConverting %d Profiler capture(s) with %s ...\n"), len(pending), unity)
+			start := time.Now()
+			report, err := runExport(basePath, unity, pending, timeout)
+			releaseProjectLock()
+			logPath := filepath.Join(basePath, filepath.FromSlash(exportLogRel))
+			if err != nil {
+				fmt.Printf(tr("[FAIL] %v\n"), err)
+				for _, line := range logExcerpt(logPath, 20) {
+					fmt.Printf("       %s\n", line)
+				}
+				fmt.Printf(tr("       Log: %s\n"), logPath)
+				recordError("%v", err)
+				annotate("error", "", 0, 0, "Profiler capture conversion failed", err.Error())
+				exit(3)
+			}
+			recordArtifact(logPath)
+			failedExports := make(map[string]string)
+			for _, r := range report.Captures {
+				if r.Error != "" {
+					failedExports[filepath.Clean(r.Capture)] = r.Error
+				}
+			}
+			for _, c := range pending {
+				if msg, ok := failedExports[filepath.Clean(c.path)]; ok {
+					fmt.Printf(tr("[FAIL] %s: %s\n"), c.rel, msg)
+					recordAction("convert", c.rel, "failed", msg, 0)
+					continue
+				}
+				c.convert = false
+				fmt.Printf(tr("[OK]   %s\n"), c.rel)
+				recordAction("convert", c.rel, "ok", "", 0)
+				recordArtifact(c.csvPath)
+			}
+			fmt.Printf(tr("Converted in %s\n"), time.Since(start).Round(time.Second))
+		}
+	}
+
+	checked, exceeded, noData := 0, 0, 0
+	for _, c := range captures {
+		if c.convert {
+			if !dryRun {
+				// The conversion failed; it was reported above
+				captureErrors++
+				recordError("%s: not converted", c.rel)
+			}
+			continue
+		}
+		cb := budgets.budgetsFor(c.rel)
+		warmup := cb.warmup
+		if warmupFlag >= 0 {
+			warmup = warmupFlag
+		}
+		table, err := readFrameTable(c.csvPath, warmup)
+		fmt.Printf("\n[%s] %s\n", tr("Capture"), c.rel)
+		if err != nil {
+			captureErrors++
+			fmt.Printf(tr("[ERROR] %s: %v\n"), c.rel, err)
+			recordAction("check", c.rel, "failed", err.Error(), 0)
+			recordError("%s: %v", c.rel, err)
+			annotate("error", c.rel, 0, 0, "Unreadable capture", err.Error())
+			continue
+		}
+		fmt.Printf(tr("  %d frame(s), the first %d skipped\n"), table.frames, table.skipped)
+		if table.frames == 0 {
+			captureErrors++
+			fmt.Printf(tr("[ERROR] %s has no frames after the warm-up\n"), c.rel)
+			recordAction("check", c.rel, "failed", "no frames", 0)
+			recordError("%s has no frames after the warm-up", c.rel)
+			continue
+		}
+		checks := evaluate(table, cb)
+		printChecks(checks)
+		for _, check := range checks {
+			checked++
+			target := fmt.Sprintf("%s %s %s", c.rel, check.metric, check.stat)
+			detail := fmt.Sprintf("%s (budget %s)", formatMetric(check.metric, check.value), formatMetric(check.metric, check.limit))
+			switch check.status {
+			case statusOver:
+				exceeded++
+				recordAction("budget", target, "failed", detail, 0)
+				recordError("%s: %s", target, detail)
+				annotate("error", c.rel, 0, 0, "Performance budget exceeded", fmt.Sprintf("%s %s is %s", check.metric, check.stat, detail))
+			case statusNoData:
+				noData++
+				recordAction("budget", target, "skipped", "no data", 0)
+				if strict {
+					recordError("%s: no data", target)
+					annotate("error", c.rel, 0, 0, "Performance budget without data", fmt.Sprintf("the capture has no '%s' column or no value in it", check.metric))
+				}
+			default:
+				recordAction("budget", target, "ok", detail, 0)
+			}
+		}
+	}
+
+	fmt.Println()
+	printRule("=============================================")
+	fmt.Println(tr("  SUMMARY"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Budgets checked: %d\n"), checked)
+	fmt.Printf(tr("  Exceeded:        %d\n"), exceeded)
+	if noData > 0 {
+		fmt.Printf(tr("  No data:         %d\n"), noData)
+	}
+	if captureErrors > 0 {
+		fmt.Printf(tr("  Unreadable:      %d capture(s)\n"), captureErrors)
+	}
+
+	switch {
+	case captureErrors > 0:
+		exit(3)
+	case exceeded > 0:
+		fmt.Println(tr("\n[FAIL] Performance budgets exceeded."))
+		exit(1)
+	case strict && noData > 0:
+		fmt.Println(tr("\n[FAIL] Budgeted metrics are missing from captures (-strict)."))
+		exit(1)
+	case checked == 0:
+		fmt.Println(tr("\n[--] No budgets were checked."))
+	case noData > 0:
+		fmt.Println(tr("\n[WARNING] Some budgeted metrics are missing from the captures; check the column names."))
+	default:
+		fmt.Println(tr("\n[OK] All captures are within budget."))
+	}
+	exit(0)
+}
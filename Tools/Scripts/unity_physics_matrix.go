@@ -0,0 +1,874 @@
+// Unity Physics Matrix — Document the layers and collision matrices, and show how they changed.
+// Reads the tags, layers and sorting layers from ProjectSettings/TagManager.asset
+// and the layer collision matrices from DynamicsManager.asset (3D) and
+// Physics2DSettings.asset (2D), and renders them as one Markdown document: the
+// layer list with what each layer collides with, and each matrix as a table.
+// Unity stores a matrix as one hex string, so a change to it is unreadable in a
+// diff; committing the document next to the project (Docs/PhysicsLayers.md by
+// default) makes it readable. Without -update the tool compares the project
+// with the committed document and lists the layers, tags and layer pairs that
+// changed, exiting with 1 when they differ so CI catches a matrix changed
+// without updating the document.
+//
+// Build: go build unity_physics_matrix.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_physics_matrix                                # compare with Docs/PhysicsLayers.md
+//	unity_physics_matrix -update                        # write the document
+//	unity_physics_matrix -baseline Docs/Physics.md -ci  # another document, in CI
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const defaultBaseline = "Docs/PhysicsLayers.md"
+
+const layerCount = 32
+
+// Settings files, relative to ProjectSettings/
+const (
+	tagManagerFile = "TagManager.asset"
+	physics3DFile  = "DynamicsManager.asset"
+	physics2DFile  = "Physics2DSettings.asset"
+)
+
+// Headings of the document, which the comparison reads back
+const (
+	headingLayers   = "## Layers"
+	headingTags     = "## Tags"
+	headingSorting  = "## Sorting Layers"
+	headingMatrix3D = "## 3D Collision Matrix"
+	headingMatrix2D = "## 2D Collision Matrix"
+)
+
+// Cells of a matrix table
+const (
+	cellOn  = "✓"
+	cellOff = "·"
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// matrix is a layer collision matrix: bit j of row i is set when layers i and j collide
+type matrix [layerCount]uint32
+
+func (m *matrix) collides(i, j int) bool {
+	return m[i]&(1<<uint(j)) != 0
+}
+
+func (m *matrix) set(i, j int) {
+	m[i] |= 1 << uint(j)
+	m[j] |= 1 << uint(i)
+}
+
+// physicsLayers is what the document shows, read from the project or from the document
+type physicsLayers struct {
+	layers   [layerCount]string
+	tags     []string
+	sorting  []string
+	matrix3D *matrix // nil when the settings file is missing
+	matrix2D *matrix
+}
+
+// named lists the indices of the layers that have a name
+func (p *physicsLayers) named() []int {
+	var idx []int
+	for i, name := range p.layers {
+		if name != "" {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// change is one difference between the document and the project, as an English
+// format so the JSON stays English
+type change struct {
+	format string
+	args   []interface{}
+}
+
+// ============================================================
+// Project Settings
+// ============================================================
+
+// readProject reads the layers, tags and collision matrices of the project
+func readProject(basePath string) (*physicsLayers, error) {
+	dir := filepath.Join(basePath, "ProjectSettings")
+	data, err := os.ReadFile(filepath.Join(dir, tagManagerFile))
+	if err != nil {
+		return nil, err
+	}
+	p := &physicsLayers{tags: topLevelList(data, "tags")}
+	for i, name := range topLevelList(data, "layers") {
+		if i < layerCount {
+			p.layers[i] = name
+		}
+	}
+	for _, item := range topLevelList(data, "m_SortingLayers") {
+		if strings.HasPrefix(item, "name:") {
+			p.sorting = append(p.sorting, yamlUnquote(strings.TrimSpace(item[len("name:"):])))
+		}
+	}
+	if p.matrix3D, err = readMatrix(filepath.Join(dir, physics3DFile)); err != nil {
+		return nil, err
+	}
+	if p.matrix2D, err = readMatrix(filepath.Join(dir, physics2DFile)); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// topLevelList returns the items of a top-level list of a settings asset; for
+// items that are maps, the first field of each
+func topLevelList(data []byte, key string) []string {
+	var items []string
+	in := false
+	for _, l := range strings.Split(string(data), "\n") {
+		l = strings.TrimRight(l, "\r")
+		if !in {
+			in = l == "  "+key+":"
+			continue
+		}
+		if strings.HasPrefix(l, "   ") {
+			continue // further fields of a map item
+		}
+		if l != "  -" && !strings.HasPrefix(l, "  - ") {
+			break
+		}
+		items = append(items, yamlUnquote(strings.TrimSpace(l[3:])))
+	}
+	return items
+}
+
+// readMatrix reads m_LayerCollisionMatrix: 32 rows of a little-endian uint32 in hex.
+// A missing settings file gives nil.
+func readMatrix(path string) (*matrix, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range strings.Split(string(data), "\n") {
+		v, ok := yamlValue(strings.TrimRight(l, "\r"), "m_LayerCollisionMatrix")
+		if !ok {
+			continue
+		}
+		raw, err := hex.DecodeString(v)
+		if err != nil || len(raw) != layerCount*4 {
+			return nil, fmt.Errorf("%s: m_LayerCollisionMatrix is not %d hex bytes", filepath.Base(path), layerCount*4)
+		}
+		m := &matrix{}
+		for i := range m {
+			m[i] = binary.LittleEndian.Uint32(raw[i*4:])
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("%s has no m_LayerCollisionMatrix", filepath.Base(path))
+}
+
+func yamlValue(line, key string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, key+":") {
+		return "", false
+	}
+	return yamlUnquote(strings.TrimSpace(trimmed[len(key)+1:])), true
+}
+
+func yamlUnquote(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// ============================================================
+// Document
+// ============================================================
+
+// mdEscape keeps "|" from splitting a Markdown table cell
+func mdEscape(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// collidesWith names the layers a layer collides with, for the layer list
+func collidesWith(p *physicsLayers, m *matrix, i int) string {
+	if m == nil {
+		return "-"
+	}
+	var names []string
+	named := p.named()
+	for _, j := range named {
+		if m.collides(i, j) {
+			names = append(names, p.layers[j])
+		}
+	}
+	switch len(names) {
+	case 0:
+		return "nothing"
+	case len(named):
+		return "everything"
+	}
+	return strings.Join(names, ", ")
+}
+
+// render writes the document
+func render(p *physicsLayers) string {
+	var b strings.Builder
+	b.WriteString("# Physics Layers\n\n")
+	b.WriteString("Generated by `unity_physics_matrix -update` from ProjectSettings/" + tagManagerFile + ", " + physics3DFile + " and " + physics2DFile + ". ")
+	b.WriteString("Run it again after changing a layer or a collision matrix, and commit this file with the settings.\n")
+
+	named := p.named()
+	fmt.Fprintf(&b, "\n%s\n\n| Layer | Name | 3D collides with | 2D collides with |\n| ---: | --- | --- | --- |\n", headingLayers)
+	for _, i := range named {
+		fmt.Fprintf(&b, "| %d | %s | %s | %s |\n", i, mdEscape(p.layers[i]),
+			mdEscape(collidesWith(p, p.matrix3D, i)), mdEscape(collidesWith(p, p.matrix2D, i)))
+	}
+
+	fmt.Fprintf(&b, "\n%s\n\n", headingTags)
+	if len(p.tags) == 0 {
+		b.WriteString("No custom tags.\n")
+	}
+	for _, t := range p.tags {
+		fmt.Fprintf(&b, "- `%s`\n", t)
+	}
+
+	fmt.Fprintf(&b, "\n%s\n\nIn draw order, back to front.\n\n", headingSorting)
+	for i, s := range p.sorting {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, s)
+	}
+
+	for _, section := range []struct {
+		heading string
+		m       *matrix
+	}{{headingMatrix3D, p.matrix3D}, {headingMatrix2D, p.matrix2D}} {
+		if section.m == nil {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s\n\n|  |", section.heading)
+		for _, j := range named {
+			fmt.Fprintf(&b, " %s |", mdEscape(p.layers[j]))
+		}
+		b.WriteString("\n| --- |" + strings.Repeat(" :-: |", len(named)) + "\n")
+		for _, i := range named {
+			fmt.Fprintf(&b, "| **%s** |", mdEscape(p.layers[i]))
+			for _, j := range named {
+				cell := cellOff
+				if section.m.collides(i, j) {
+					cell = cellOn
+				}
+				fmt.Fprintf(&b, " %s |", cell)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// tableCells splits a Markdown table row, keeping escaped "\|" in the cell
+func tableCells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+	var cells []string
+	var cur strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cur.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		default:
+			cur.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cur.String()))
+}
+
+// parseDocument reads a document written by render back into layers and matrices
+func parseDocument(data []byte) (*physicsLayers, error) {
+	p := &physicsLayers{}
+	section := ""
+	row := 0
+	for _, l := range strings.Split(string(data), "\n") {
+		l = strings.TrimRight(l, "\r")
+		if strings.HasPrefix(l, "## ") {
+			section, row = l, 0
+			switch section {
+			case headingMatrix3D:
+				p.matrix3D = &matrix{}
+			case headingMatrix2D:
+				p.matrix2D = &matrix{}
+			}
+			continue
+		}
+		switch section {
+		case headingLayers:
+			cells := tableCells(l)
+			if !strings.HasPrefix(l, "|") || len(cells) < 2 {
+				continue
+			}
+			if i, err := strconv.Atoi(cells[0]); err == nil && i >= 0 && i < layerCount {
+				p.layers[i] = cells[1]
+			}
+		case headingTags:
+			if strings.HasPrefix(l, "- ") {
+				p.tags = append(p.tags, strings.Trim(l[2:], "`"))
+			}
+		case headingSorting:
+			if i := strings.Index(l, ". "); i > 0 {
+				if _, err := strconv.Atoi(l[:i]); err == nil {
+					p.sorting = append(p.sorting, l[i+2:])
+				}
+			}
+		case headingMatrix3D, headingMatrix2D:
+			m := p.matrix3D
+			if section == headingMatrix2D {
+				m = p.matrix2D
+			}
+			if !strings.HasPrefix(l, "| **") {
+				continue
+			}
+			named := p.named()
+			cells := tableCells(l)
+			if row >= len(named) || len(cells) != len(named)+1 {
+				return nil, fmt.Errorf("%s does not match the layer list", strings.TrimPrefix(section, "## "))
+			}
+			for c, cell := range cells[1:] {
+				if cell == cellOn {
+					m.set(named[row], named[c])
+				}
+			}
+			row++
+		}
+	}
+	if len(p.named()) == 0 {
+		return nil, fmt.Errorf("no layer list found")
+	}
+	return p, nil
+}
+
+// ============================================================
+// Comparison
+// ============================================================
+
+// compare lists what changed from the document to the project
+func compare(old, cur *physicsLayers) []change {
+	var changes []change
+	add := func(format string, args ...interface{}) {
+		changes = append(changes, change{format: format, args: args})
+	}
+	for i := 0; i < layerCount; i++ {
+		o, c := old.layers[i], cur.layers[i]
+		switch {
+		case o == c:
+		case o == "":
+			add("layer %d \"%s\" was added", i, c)
+		case c == "":
+			add("layer %d \"%s\" was removed", i, o)
+		default:
+			add("layer %d was renamed from \"%s\" to \"%s\"", i, o, c)
+		}
+	}
+	for _, t := range cur.tags {
+		if !containsString(old.tags, t) {
+			add("tag \"%s\" was added", t)
+		}
+	}
+	for _, t := range old.tags {
+		if !containsString(cur.tags, t) {
+			add("tag \"%s\" was removed", t)
+		}
+	}
+	if strings.Join(old.sorting, "\n") != strings.Join(cur.sorting, "\n") {
+		add("sorting layers changed from %s to %s", strings.Join(old.sorting, ", "), strings.Join(cur.sorting, ", "))
+	}
+
+	// Pairs of layers named in both, so an added or removed layer is one change
+	var common []int
+	for _, i := range cur.named() {
+		if old.layers[i] != "" {
+			common = append(common, i)
+		}
+	}
+	for _, section := range []struct {
+		kind     string
+		old, cur *matrix
+	}{{"3D", old.matrix3D, cur.matrix3D}, {"2D", old.matrix2D, cur.matrix2D}} {
+		switch {
+		case section.old == nil && section.cur == nil:
+			continue
+		case section.old == nil:
+			add("the %s collision matrix was added", section.kind)
+			continue
+		case section.cur == nil:
+			add("the %s collision matrix was removed", section.kind)
+			continue
+		}
+		for a, i := range common {
+			for _, j := range common[a:] {
+				was, is := section.old.collides(i, j), section.cur.collides(i, j)
+				switch {
+				case was == is:
+				case i == j && is:
+					add("%s: %s now collides with itself", section.kind, cur.layers[i])
+				case i == j:
+					add("%s: %s no longer collides with itself", section.kind, cur.layers[i])
+				case is:
+					add("%s: %s and %s now collide", section.kind, cur.layers[i], cur.layers[j])
+				default:
+					add("%s: %s and %s no longer collide", section.kind, cur.layers[i], cur.layers[j])
+				}
+			}
+		}
+	}
+	return changes
+}
+
+// ============================================================
+// Console
+// ============================================================
+
+// printMatrix prints a matrix as a grid of layer indices, x where two layers collide
+func printMatrix(p *physicsLayers, title string, m *matrix) {
+	if m == nil {
+		return
+	}
+	named := p.named()
+	fmt.Printf("\n%s\n%-22s", tr(title), "")
+	for _, j := range named {
+		fmt.Printf("%3d", j)
+	}
+	fmt.Println()
+	for _, i := range named {
+		name := []rune(p.layers[i])
+		if len(name) > 16 {
+			name = append(name[:15], '…')
+		}
+		fmt.Printf("  %2d %-17s", i, string(name))
+		for _, j := range named {
+			cell := "."
+			if m.collides(i, j) {
+				cell = "x"
+			}
+			fmt.Printf("%3s", cell)
+		}
+		fmt.Println()
+	}
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode       bool
+		update       bool
+		baselineFlag string
+	)
+
+	flag.StringVar(&baselineFlag, "baseline", defaultBaseline, "Committed document to compare with or write, relative to the project root or absolute")
+	flag.BoolVar(&update, "update", false, "Write the document from the project settings instead of comparing")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_physics_matrix")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	if flag.NArg() > 0 {
+		fmt.Println(tr("Usage: unity_physics_matrix [-update] [-baseline <file>] [-ci] [-json]"))
+		recordError("unexpected argument '%s'", flag.Arg(0))
+		exit(2)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	baseline := baselineFlag
+	if !filepath.IsAbs(baseline) {
+		baseline = filepath.Join(basePath, filepath.FromSlash(baseline))
+	}
+	shown := relPath(basePath, baseline)
+
+	p, err := readProject(basePath)
+	if err != nil {
+		fail(2, "cannot read the project settings: %v", err)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  PHYSICS LAYERS"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:  %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Layers:   %d named, %d custom tag(s), %d sorting layer(s)\n"), len(p.named()), len(p.tags), len(p.sorting))
+	fmt.Printf(tr("  Document: %s\n"), shown)
+
+	fmt.Println(tr("\nLAYERS"))
+	for _, i := range p.named() {
+		fmt.Printf("  %2d  %s\n", i, p.layers[i])
+	}
+	printMatrix(p, "3D COLLISION MATRIX", p.matrix3D)
+	printMatrix(p, "2D COLLISION MATRIX", p.matrix2D)
+	fmt.Println()
+
+	doc := render(p)
+	if update {
+		if err := os.MkdirAll(filepath.Dir(baseline), 0755); err != nil {
+			fail(1, "cannot write %s: %v", shown, err)
+		}
+		if err := os.WriteFile(baseline, []byte(doc), 0644); err != nil {
+			fail(1, "cannot write %s: %v", shown, err)
+		}
+		recordArtifact(baseline)
+		recordAction("write", shown, "ok", "", 0)
+		fmt.Printf(tr("[OK] Wrote %s\n"), shown)
+		fmt.Println(tr("[TIP] Commit it with the settings, so reviewers see collision changes as a table diff."))
+		exit(0)
+	}
+
+	data, err := os.ReadFile(baseline)
+	if os.IsNotExist(err) {
+		fmt.Printf(tr("[WARNING] %s does not exist; nothing to compare with.\n"), shown)
+		fmt.Println(tr("[TIP] Run with -update to write it, then commit it."))
+		recordAction("compare", shown, "skipped", "no document", 0)
+		exit(0)
+	}
+	if err != nil {
+		fail(2, "cannot read %s: %v", shown, err)
+	}
+	old, err := parseDocument(data)
+	if err != nil {
+		fail(2, "cannot read %s: %v", shown, err)
+	}
+
+	changes := compare(old, p)
+	if len(changes) == 0 {
+		recordAction("compare", shown, "ok", "", 0)
+		fmt.Printf(tr("[OK] %s matches the project settings.\n"), shown)
+		if string(data) != doc {
+			fmt.Println(tr("[TIP] The document's text is out of date, although nothing it shows changed; -update rewrites it."))
+		}
+		exit(0)
+	}
+	fmt.Printf(tr("CHANGES SINCE %s\n"), shown)
+	for _, c := range changes {
+		fmt.Printf("  - %s\n", fmt.Sprintf(tr(c.format), c.args...))
+		recordAction("change", shown, "failed", fmt.Sprintf(c.format, c.args...), 0)
+	}
+	fmt.Printf(tr("\n[ERROR] %d change(s) are not in %s.\n"), len(changes), shown)
+	recordError("%d change(s) are not in %s", len(changes), shown)
+	fmt.Println(tr("[TIP] If they are intended, run with -update and commit the document with the settings."))
+	exit(1)
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+// relPath shows a path relative to the project root, with forward slashes
+func relPath(basePath, p string) string {
+	if rel, err := filepath.Rel(basePath, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return p
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...": "\n按回车键继续...",
+	"[ERROR] %v\n":                 "[ERROR] %v\n",
+	"Usage: unity_physics_matrix [-update] [-baseline <file>] [-ci] [-json]": "用法: unity_physics_matrix [-update] [-baseline <文件>] [-ci] [-json]",
+	"[ERROR] Cannot get current directory: %v\n":                             "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.":       "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                 "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  PHYSICS LAYERS": "  物理层",
+	"  Project:  %s\n": "  项目:     %s\n",
+	"  Layers:   %d named, %d custom tag(s), %d sorting layer(s)\n": "  层:       %d 个已命名，%d 个自定义标签，%d 个排序层\n",
+	"  Document: %s\n":    "  文档:     %s\n",
+	"\nLAYERS":            "\n层",
+	"3D COLLISION MATRIX": "3D 碰撞矩阵",
+	"2D COLLISION MATRIX": "2D 碰撞矩阵",
+	"[OK] Wrote %s\n":     "[OK] 已写入 %s\n",
+	"[TIP] Commit it with the settings, so reviewers see collision changes as a table diff.":            "[TIP] 将其与设置一起提交，审查者即可以表格差异的形式看到碰撞变更。",
+	"[WARNING] %s does not exist; nothing to compare with.\n":                                           "[WARNING] %s 不存在，无可比较。\n",
+	"[TIP] Run with -update to write it, then commit it.":                                               "[TIP] 使用 -update 运行以写入该文档，然后提交。",
+	"[OK] %s matches the project settings.\n":                                                           "[OK] %s 与项目设置一致。\n",
+	"[TIP] The document's text is out of date, although nothing it shows changed; -update rewrites it.": "[TIP] 文档内容未变，但文本格式已过时；-update 会重写它。",
+	"CHANGES SINCE %s\n":                      "相对 %s 的变更\n",
+	"\n[ERROR] %d change(s) are not in %s.\n": "\n[ERROR] %d 处变更未写入 %s。\n",
+	"[TIP] If they are intended, run with -update and commit the document with the settings.": "[TIP] 如果这些变更是有意的，请使用 -update 运行并将文档与设置一起提交。",
+	"layer %d \"%s\" was added":                  "新增了层 %d \"%s\"",
+	"layer %d \"%s\" was removed":                "删除了层 %d \"%s\"",
+	"layer %d was renamed from \"%s\" to \"%s\"": "层 %d 从 \"%s\" 重命名为 \"%s\"",
+	"tag \"%s\" was added":                       "新增了标签 \"%s\"",
+	"tag \"%s\" was removed":                     "删除了标签 \"%s\"",
+	"sorting layers changed from %s to %s":       "排序层从 %s 变为 %s",
+	"the %s collision matrix was added":          "新增了 %s 碰撞矩阵",
+	"the %s collision matrix was removed":        "删除了 %s 碰撞矩阵",
+	"%s: %s now collides with itself":            "%s: %s 现在与自身碰撞",
+	"%s: %s no longer collides with itself":      "%s: %s 不再与自身碰撞",
+	"%s: %s and %s now collide":                  "%s: %s 与 %s 现在会碰撞",
+	"%s: %s and %s no longer collide":            "%s: %s 与 %s 不再碰撞",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
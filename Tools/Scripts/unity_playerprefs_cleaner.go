@@ -0,0 +1,1271 @@
+// Unity PlayerPrefs Cleaner — Reset local PlayerPrefs and persistentDataPath contents.
+// Reads companyName/productName from ProjectSettings.asset and removes the matching
+// registry keys (Windows), plist domains (macOS), or prefs files (Linux), plus the
+// persistent data folder used by the Editor and standalone players.
+//
+// Build: go build unity_playerprefs_cleaner.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_playerprefs_cleaner                 # interactive, prefs + data
+//	unity_playerprefs_cleaner -dry-run        # preview only
+//	unity_playerprefs_cleaner -prefs-only     # keep persistentDataPath
+//	unity_playerprefs_cleaner -android --ci   # also `adb shell pm clear` on devices
+//	unity_playerprefs_cleaner -json           # result document on stdout
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Types
+// ============================================================
+
+// projectIdentity holds the values Unity uses to build PlayerPrefs and data paths
+type projectIdentity struct {
+	companyName string
+	productName string
+	androidID   string // applicationIdentifier for Android (used for adb pm clear)
+}
+
+// cleanTarget is a single location that will be removed
+type cleanTarget struct {
+	kind  string // "registry", "plist", "file", "directory", "android"
+	label string // human-readable scope, e.g. "Editor PlayerPrefs"
+	path  string // registry key, plist domain/path, filesystem path, or package name
+	size  int64  // filesystem size in bytes (0 if unknown or not applicable)
+}
+
+// EditorInstance represents the structure of Library/EditorInstance.json
+type EditorInstance struct {
+	ProcessID int `json:"process_id"`
+}
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Project Settings
+// ============================================================
+
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// readProjectIdentity extracts companyName, productName and the Android bundle ID
+// from ProjectSettings.asset. Only the top-level scalar fields are read.
+func readProjectIdentity(basePath string) (projectIdentity, error) {
+	settingsPath := filepath.Join(basePath, "ProjectSettings", "ProjectSettings.asset")
+	data, err := os.ReadFile(settingsPath)
+	if err != nil {
+		return projectIdentity{}, fmt.Errorf("failed to read %s: %v", settingsPath, err)
+	}
+	content := string(data)
+
+	companyMatch := regexp.MustCompile(`(?m)^\s*companyName: (.*)$`).FindStringSubmatch(content)
+	if len(companyMatch) < 2 {
+		return projectIdentity{}, fmt.Errorf("could not find companyName in %s", settingsPath)
+	}
+	productMatch := regexp.MustCompile(`(?m)^\s*productName: (.*)$`).FindStringSubmatch(content)
+	if len(productMatch) < 2 {
+		return projectIdentity{}, fmt.Errorf("could not find productName in %s", settingsPath)
+	}
+
+	id := projectIdentity{
+		companyName: strings.TrimSpace(companyMatch[1]),
+		productName: strings.TrimSpace(productMatch[1]),
+	}
+
+	// applicationIdentifier is a per-platform map:
+	//   applicationIdentifier:
+	//     Android: com.Company.Product
+	if androidMatch := regexp.MustCompile(`(?m)^\s*applicationIdentifier:\s*\n(?:\s+\w+: .*\n)*?\s+Android: (.*)$`).FindStringSubmatch(content); len(androidMatch) >= 2 {
+		id.androidID = strings.TrimSpace(androidMatch[1])
+	}
+	if id.androidID == "" {
+		id.androidID = "com." + id.companyName + "." + id.productName
+	}
+
+	return id, nil
+}
+
+// ============================================================
+// Unity Running Detection
+// ============================================================
+
+// checkUnityRunning reports whether the Editor that owns this project is alive.
+// The Editor keeps PlayerPrefs cached in memory and rewrites them on exit,
+// so clearing them while it runs has no lasting effect.
+func checkUnityRunning(basePath string) (bool, int) {
+	data, err := os.ReadFile(filepath.Join(basePath, "Library", "EditorInstance.json"))
+	if err != nil {
+		return false, 0
+	}
+	var instance EditorInstance
+	if err := json.Unmarshal(data, &instance); err != nil || instance.ProcessID <= 0 {
+		return false, 0
+	}
+	if isProcessRunning(instance.ProcessID) {
+		return true, instance.ProcessID
+	}
+	return false, 0
+}
+
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		cmd := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV")
+		output, err := cmd.Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// ============================================================
+// Target Discovery
+// ============================================================
+
+// collectTargets resolves every PlayerPrefs store and persistent data folder
+// for the current OS. Entries that do not exist are skipped.
+func collectTargets(id projectIdentity, includePrefs, includeData bool) []cleanTarget {
+	var targets []cleanTarget
+	home, _ := os.UserHomeDir()
+
+	addPath := func(label, path string) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return
+		}
+		kind := "file"
+		size := info.Size()
+		if info.IsDir() {
+			kind = "directory"
+			size = getDirSize(path)
+		}
+		targets = append(targets, cleanTarget{kind: kind, label: label, path: path, size: size})
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		if includePrefs {
+			keys := []struct{ label, key string }{
+				{"Editor PlayerPrefs", `HKCU\Software\Unity\UnityEditor\` + id.companyName + `\` + id.productName},
+				{"Player PlayerPrefs", `HKCU\Software\` + id.companyName + `\` + id.productName},
+			}
+			for _, k := range keys {
+				if registryKeyExists(k.key) {
+					targets = append(targets, cleanTarget{kind: "registry", label: k.label, path: k.key})
+				}
+			}
+		}
+		if includeData && home != "" {
+			addPath("persistentDataPath", filepath.Join(home, "AppData", "LocalLow", id.companyName, id.productName))
+		}
+
+	case "darwin":
+		if includePrefs && home != "" {
+			prefsDir := filepath.Join(home, "Library", "Preferences")
+			domains := []struct{ label, domain string }{
+				{"Editor/Player PlayerPrefs", "unity." + id.companyName + "." + id.productName},
+				{"Player PlayerPrefs (bundle ID)", "com." + id.companyName + "." + id.productName},
+			}
+			for _, d := range domains {
+				plistPath := filepath.Join(prefsDir, d.domain+".plist")
+				if info, err := os.Stat(plistPath); err == nil {
+					targets = append(targets, cleanTarget{kind: "plist", label: d.label, path: plistPath, size: info.Size()})
+				}
+			}
+		}
+		if includeData && home != "" {
+			addPath("persistentDataPath", filepath.Join(home, "Library", "Application Support", id.companyName, id.productName))
+			addPath("persistentDataPath (bundle ID)", filepath.Join(home, "Library", "Application Support", "com."+id.companyName+"."+id.productName))
+		}
+
+	default: // linux and other unix
+		configDir := os.Getenv("XDG_CONFIG_HOME")
+		if configDir == "" && home != "" {
+			configDir = filepath.Join(home, ".config")
+		}
+		if configDir != "" {
+			base := filepath.Join(configDir, "unity3d", id.companyName, id.productName)
+			if includePrefs {
+				addPath("PlayerPrefs", filepath.Join(base, "prefs"))
+			}
+			if includeData {
+				// On Linux persistentDataPath and the prefs file share one folder, so the
+				// data pass removes everything except prefs (handled by the prefs pass).
+				entries, err := os.ReadDir(base)
+				if err == nil {
+					for _, entry := range entries {
+						if entry.Name() == "prefs" {
+							continue
+						}
+						addPath("persistentDataPath", filepath.Join(base, entry.Name()))
+					}
+				}
+			}
+		}
+	}
+
+	return targets
+}
+
+// registryKeyExists queries the Windows registry for a key.
+func registryKeyExists(key string) bool {
+	return exec.Command("reg", "query", key).Run() == nil
+}
+
+// listAndroidDevices returns serials of devices in the "device" state.
+func listAndroidDevices() ([]string, error) {
+	output, err := exec.Command("adb", "devices").Output()
+	if err != nil {
+		return nil, err
+	}
+	var serials []string
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == "device" {
+			serials = append(serials, fields[0])
+		}
+	}
+	return serials, nil
+}
+
+// ============================================================
+// Size Calculation
+// ============================================================
+
+func getDirSize(path string) int64 {
+	var size int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+// ============================================================
+// Preview
+// ============================================================
+
+func printPreview(targets []cleanTarget) {
+	printRule("\n=============================================")
+	fmt.Println(tr("  LOCAL STATE TO RESET"))
+	printRule("=============================================")
+
+	if len(targets) == 0 {
+		fmt.Println(tr("\n  (nothing found — local state is already clean)"))
+		return
+	}
+
+	for _, t := range targets {
+		switch t.kind {
+		case "registry", "android":
+			fmt.Printf("  [%-8s] %-32s %s\n", strings.ToUpper(t.kind), t.label, t.path)
+		default:
+			fmt.Printf("  [%-8s] %-32s %s (%s)\n", strings.ToUpper(t.kind), t.label, t.path, formatSize(t.size))
+		}
+	}
+	fmt.Printf(tr("\n  Total: %d item(s)\n"), len(targets))
+}
+
+// ============================================================
+// Clean Operations
+// ============================================================
+
+func cleanOne(t cleanTarget) error {
+	switch t.kind {
+	case "registry":
+		if overlay, ok := fsys.(*overlayFS); ok {
+			overlay.noteCommand("reg", "delete", t.path, "/f")
+			return nil
+		}
+		output, err := exec.Command("reg", "delete", t.path, "/f").CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+		}
+		return nil
+
+	case "plist":
+		// cfprefsd caches preferences in memory; `defaults delete` flushes the
+		// cache so the removed file is not written back on next access.
+		domain := strings.TrimSuffix(filepath.Base(t.path), ".plist")
+		if overlay, ok := fsys.(*overlayFS); ok {
+			overlay.noteCommand("defaults", "delete", domain)
+		} else {
+			exec.Command("defaults", "delete", domain).Run()
+		}
+		if err := fsys.Remove(t.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+
+	case "android":
+		// t.path holds "serial|package"
+		parts := strings.SplitN(t.path, "|", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid android target: %s", t.path)
+		}
+		if overlay, ok := fsys.(*overlayFS); ok {
+			overlay.noteCommand("adb", "-s", parts[0], "shell", "pm", "clear", parts[1])
+			return nil
+		}
+		output, err := exec.Command("adb", "-s", parts[0], "shell", "pm", "clear", parts[1]).CombinedOutput()
+		text := strings.TrimSpace(string(output))
+		if err != nil {
+			return fmt.Errorf("%v: %s", err, text)
+		}
+		if !strings.Contains(text, "Success") {
+			return fmt.Errorf("pm clear failed: %s", text)
+		}
+		return nil
+
+	default:
+		var lastErr error
+		for attempt := 0; attempt < 3; attempt++ {
+			lastErr = fsys.RemoveAll(t.path)
+			if lastErr == nil {
+				return nil
+			}
+			if os.IsPermission(lastErr) {
+				fsys.Walk(t.path, func(p string, _ os.FileInfo, err error) error {
+					if err == nil {
+						fsys.Chmod(p, 0777)
+					}
+					return nil
+				})
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		return lastErr
+	}
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to exit..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Filesystem
+// ============================================================
+
+// fileSystem is every filesystem call that can change the project. The real
+// run uses osFS; -dry-run swaps in an overlayFS so the same code path runs
+// and the overlay reports what it would have changed.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Walk(root string, fn filepath.WalkFunc) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Chmod(name string, mode os.FileMode) error
+}
+
+var fsys fileSystem = osFS{}
+
+// isDryRun reports whether changes are going to the in-memory overlay
+func isDryRun() bool {
+	_, ok := fsys.(*overlayFS)
+	return ok
+}
+
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)         { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) Walk(root string, fn filepath.WalkFunc) error { return filepath.Walk(root, fn) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// fsChange is one change the overlay absorbed instead of the disk
+type fsChange struct {
+	op     string // "write", "create", "mkdir", "rename", "delete"
+	path   string
+	detail string
+}
+
+// overlayFS reads through to the disk and keeps every write, rename and
+// delete in memory. Later reads see those changes, so multi-step operations
+// (rename a folder, then rewrite files inside it) behave as they would for real.
+type overlayFS struct {
+	mu      sync.Mutex
+	files   map[string][]byte // files written during the run
+	dirs    map[string]bool   // directories created during the run
+	moved   map[string]string // renamed path -> where its content is on disk
+	removed map[string]bool   // deleted paths; hides everything below them
+	changes []fsChange
+}
+
+func newOverlayFS() *overlayFS {
+	return &overlayFS{
+		files:   map[string][]byte{},
+		dirs:    map[string]bool{},
+		moved:   map[string]string{},
+		removed: map[string]bool{},
+	}
+}
+
+func overlayKey(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// isUnder reports whether p is base or inside it
+func isUnder(p, base string) bool {
+	return p == base || strings.HasPrefix(p, strings.TrimSuffix(base, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// locate finds where the content of p lives: in memory (data / memDir) or
+// on disk (disk, after following renames). ok is false if p was deleted.
+func (o *overlayFS) locate(p string) (data []byte, memFile, memDir bool, disk string, ok bool) {
+	if d, found := o.files[p]; found {
+		return d, true, false, "", true
+	}
+	if o.dirs[p] {
+		return nil, false, true, "", true
+	}
+	for q := p; ; {
+		if src, found := o.moved[q]; found {
+			rel, _ := filepath.Rel(q, p)
+			return nil, false, false, filepath.Join(src, rel), true
+		}
+		if o.removed[q] {
+			return nil, false, false, "", false
+		}
+		parent := filepath.Dir(q)
+		if parent == q {
+			break
+		}
+		q = parent
+	}
+	return nil, false, false, p, true
+}
+
+func (o *overlayFS) stat(p string) (os.FileInfo, error) {
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	case memFile:
+		return memFileInfo{name: filepath.Base(p), size: int64(len(data))}, nil
+	case memDir:
+		return memFileInfo{name: filepath.Base(p), dir: true}, nil
+	}
+	info, err := os.Stat(disk)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	if disk != p {
+		return renamedFileInfo{info, filepath.Base(p)}, nil
+	}
+	return info, nil
+}
+
+func (o *overlayFS) Stat(name string) (os.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stat(overlayKey(name))
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case memDir:
+		return nil, &os.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	case memFile:
+		return append([]byte(nil), data...), nil
+	}
+	return os.ReadFile(disk)
+}
+
+func (o *overlayFS) readDir(p string) ([]os.DirEntry, error) {
+	_, memFile, memDir, disk, ok := o.locate(p)
+	if !ok || memFile {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+	names := map[string]bool{}
+	if !memDir {
+		entries, err := os.ReadDir(disk)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			names[e.Name()] = true
+		}
+	}
+	for k := range o.dirs {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.files {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.moved {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	var result []os.DirEntry
+	for name := range names {
+		if info, err := o.stat(filepath.Join(p, name)); err == nil {
+			result = append(result, fs.FileInfoToDirEntry(info))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (o *overlayFS) ReadDir(name string) ([]os.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readDir(overlayKey(name))
+}
+
+// Walk follows filepath.Walk: lexical order, SkipDir and SkipAll honored.
+// The lock is released while fn runs so the callback can change the overlay.
+func (o *overlayFS) Walk(root string, fn filepath.WalkFunc) error {
+	info, err := o.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = o.walk(root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (o *overlayFS) walk(path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	entries, err := o.ReadDir(path)
+	if err1 := fn(path, info, err); err != nil || err1 != nil {
+		return err1
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		childInfo, err := o.Stat(child)
+		if err != nil {
+			if err := fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := o.walk(child, childInfo, fn); err != nil {
+			if err == filepath.SkipDir && childInfo.IsDir() {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *overlayFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	op := "create"
+	if info, err := o.stat(p); err == nil {
+		if info.IsDir() {
+			return &os.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+		}
+		op = "write"
+	}
+	if info, err := o.stat(filepath.Dir(p)); err != nil || !info.IsDir() {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	o.files[p] = append([]byte(nil), data...)
+	o.changes = append(o.changes, fsChange{op, p, fmt.Sprintf("%d bytes", len(data))})
+	return nil
+}
+
+func (o *overlayFS) MkdirAll(path string, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	created := false
+	for q := p; ; q = filepath.Dir(q) {
+		if info, err := o.stat(q); err == nil {
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: q, Err: errors.New("not a directory")}
+			}
+			break
+		}
+		o.dirs[q] = true
+		created = true
+		if filepath.Dir(q) == q {
+			break
+		}
+	}
+	if created {
+		o.changes = append(o.changes, fsChange{"mkdir", p, ""})
+	}
+	return nil
+}
+
+// shift moves every overlay entry under from to the same place under to
+func (o *overlayFS) shift(from, to string) {
+	for k, v := range o.files {
+		if isUnder(k, from) {
+			delete(o.files, k)
+			o.files[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, from) {
+			delete(o.dirs, k)
+			o.dirs[to+strings.TrimPrefix(k, from)] = true
+		}
+	}
+	for k, v := range o.moved {
+		if isUnder(k, from) {
+			delete(o.moved, k)
+			o.moved[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+}
+
+func (o *overlayFS) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	from, to := overlayKey(oldpath), overlayKey(newpath)
+	_, _, _, disk, ok := o.locate(from)
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if from == to {
+		return nil
+	}
+	if info, err := o.stat(filepath.Dir(to)); err != nil || !info.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	o.drop(to)
+	o.shift(from, to)
+	if disk != "" {
+		if _, err := os.Stat(disk); err == nil {
+			o.moved[to] = disk
+		}
+	}
+	o.removed[from] = true
+	o.changes = append(o.changes, fsChange{"rename", from, to})
+	return nil
+}
+
+// drop forgets p and everything below it and hides it from the disk
+func (o *overlayFS) drop(p string) {
+	for k := range o.files {
+		if isUnder(k, p) {
+			delete(o.files, k)
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, p) {
+			delete(o.dirs, k)
+		}
+	}
+	for k := range o.moved {
+		if isUnder(k, p) {
+			delete(o.moved, k)
+		}
+	}
+	o.removed[p] = true
+}
+
+// treeSize is the byte size of p as the overlay currently sees it
+func (o *overlayFS) treeSize(p string) int64 {
+	info, err := o.stat(p)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var size int64
+	entries, _ := o.readDir(p)
+	for _, e := range entries {
+		size += o.treeSize(filepath.Join(p, e.Name()))
+	}
+	return size
+}
+
+func (o *overlayFS) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	info, err := o.stat(p)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	if info.IsDir() {
+		if entries, _ := o.readDir(p); len(entries) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", info.Size())})
+	return nil
+}
+
+func (o *overlayFS) RemoveAll(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	if _, err := o.stat(p); err != nil {
+		return nil
+	}
+	size := o.treeSize(p)
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", size)})
+	return nil
+}
+
+func (o *overlayFS) Chmod(name string, mode os.FileMode) error {
+	if _, err := o.Stat(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+// noteCommand lists a command that changes state outside the filesystem
+// (registry, cfprefsd, adb); the overlay cannot simulate those, only report them
+func (o *overlayFS) noteCommand(args ...string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.changes = append(o.changes, fsChange{"run", strings.Join(args, " "), ""})
+}
+
+// printDryRunReport lists what the overlay absorbed, relative to base
+func (o *overlayFS) printDryRunReport(base string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if abs, err := filepath.Abs(base); err == nil {
+		base = abs
+	}
+	if len(o.changes) == 0 {
+		fmt.Println(tr("\n[Dry Run] No changes would be made."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d change(s) would be made; nothing was written:\n"), len(o.changes))
+	rel := func(p string) string {
+		if r, err := filepath.Rel(base, p); err == nil && !strings.HasPrefix(r, "..") {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	for _, c := range o.changes {
+		switch c.op {
+		case "rename":
+			fmt.Printf("  %-7s %s -> %s\n", c.op, rel(c.path), rel(c.detail))
+		case "mkdir", "run":
+			fmt.Printf("  %-7s %s\n", c.op, rel(c.path))
+		default:
+			fmt.Printf("  %-7s %s (%s)\n", c.op, rel(c.path), c.detail)
+		}
+	}
+}
+
+// memFileInfo describes a file or directory that only exists in the overlay
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (m memFileInfo) Name() string       { return m.name }
+func (m memFileInfo) Size() int64        { return m.size }
+func (m memFileInfo) ModTime() time.Time { return time.Time{} }
+func (m memFileInfo) IsDir() bool        { return m.dir }
+func (m memFileInfo) Sys() interface{}   { return nil }
+func (m memFileInfo) Mode() os.FileMode {
+	if m.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// renamedFileInfo is a disk entry seen under the name it was renamed to
+type renamedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (r renamedFileInfo) Name() string { return r.name }
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"  LOCAL STATE TO RESET":                             "  待重置的本地状态",
+	"\n  (nothing found — local state is already clean)": "\n  (未发现任何内容 — 本地状态已是干净的)",
+	"\n  Total: %d item(s)\n":                            "\n  合计: %d 项\n",
+	"\nPress Enter to exit...":                           "\n按回车键退出...",
+
+	"[ERROR] -prefs-only and -data-only cannot be combined.":                    "[ERROR] -prefs-only 与 -data-only 不能同时使用。",
+	"[ERROR] Cannot get current directory: %v\n":                                "[ERROR] 无法获取当前目录: %v\n",
+	"  Unity PlayerPrefs Cleaner":                                               "  Unity PlayerPrefs 清理工具",
+	"Target: %s\n":                                                              "目标: %s\n",
+	"[Dry Run] Nothing will be removed":                                         "[Dry Run] 不会删除任何内容",
+	"\n[ERROR] Current directory does not appear to be a Unity project.":        "\n[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                    "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"Run from the project root, or pass both -company and -product.":            "请在项目根目录运行，或同时传入 -company 和 -product。",
+	"\n  Company: %s\n":                                                         "\n  公司名: %s\n",
+	"  Product: %s\n":                                                           "  产品名: %s\n",
+	"\n[WARNING] Unity Editor appears to be running (PID: %d).\n":               "\n[WARNING] Unity 编辑器似乎正在运行 (PID: %d)。\n",
+	"The Editor rewrites PlayerPrefs on exit, so changes may be lost.":          "编辑器退出时会重写 PlayerPrefs，因此这些更改可能会丢失。",
+	"\n[CI Mode] Aborting due to Unity running. Exit code: 1":                   "\n[CI Mode] Unity 正在运行，已中止。退出码: 1",
+	"\nPress Enter to FORCE continue (not recommended), or Ctrl+C to cancel...": "\n按回车键强制继续 (不推荐)，或按 Ctrl+C 取消...",
+	"\n[WARNING] adb not found in PATH, skipping Android devices.":              "\n[WARNING] PATH 中未找到 adb，跳过 Android 设备。",
+	"\n[WARNING] Failed to list Android devices: %v\n":                          "\n[WARNING] 获取 Android 设备列表失败: %v\n",
+	"\n[WARNING] No Android devices connected.":                                 "\n[WARNING] 没有已连接的 Android 设备。",
+	"Device ":                          "设备 ",
+	"\n[Dry Run] Nothing was removed.": "\n[Dry Run] 未删除任何内容。",
+	"\nProceed with reset? (y/N): ":    "\n是否继续重置？(y/N): ",
+	"Operation cancelled.":             "操作已取消。",
+	"\nCleaning...":                    "\n正在清理...",
+	"  RESET COMPLETE":                 "  重置完成",
+	"  Cleared: %d items\n":            "  已清除: %d 项\n",
+	"  Failed:  %d items\n":            "  失败:   %d 项\n",
+	"  Freed:   %s\n":                  "  释放:   %s\n",
+	"  Time:    %s\n":                  "  耗时:   %s\n",
+
+	"\n[Dry Run] No changes would be made.":                          "\n[Dry Run] 不会做任何更改。",
+	"\n[Dry Run] %d change(s) would be made; nothing was written:\n": "\n[Dry Run] 将会进行 %d 项更改，未写入任何内容:\n",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	if status == "ok" && isDryRun() {
+		status = "planned"
+	}
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode     bool
+		dryRun     bool
+		prefsOnly  bool
+		dataOnly   bool
+		android    bool
+		companyArg string
+		productArg string
+	)
+
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no confirmation prompts)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Preview what would be removed without removing anything")
+	flag.BoolVar(&prefsOnly, "prefs-only", false, "Only clear PlayerPrefs, keep persistentDataPath")
+	flag.BoolVar(&dataOnly, "data-only", false, "Only clear persistentDataPath, keep PlayerPrefs")
+	flag.BoolVar(&android, "android", false, "Also run 'adb shell pm clear <bundle id>' on connected devices")
+	flag.StringVar(&companyArg, "company", "", "Override companyName (default: from ProjectSettings.asset)")
+	flag.StringVar(&productArg, "product", "", "Override productName (default: from ProjectSettings.asset)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_playerprefs_cleaner")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+
+	if prefsOnly && dataOnly {
+		fmt.Println(tr("[ERROR] -prefs-only and -data-only cannot be combined."))
+		recordError("-prefs-only and -data-only cannot be combined")
+		exit(1)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  Unity PlayerPrefs Cleaner"))
+	printRule("=============================================")
+	fmt.Printf(tr("Target: %s\n"), basePath)
+	if dryRun {
+		fmt.Println(tr("[Dry Run] Nothing will be removed"))
+		fsys = newOverlayFS()
+	}
+
+	var id projectIdentity
+	if companyArg != "" && productArg != "" {
+		id = projectIdentity{companyName: companyArg, productName: productArg, androidID: "com." + companyArg + "." + productArg}
+	} else {
+		if !isUnityProject(basePath) {
+			fmt.Println(tr("\n[ERROR] Current directory does not appear to be a Unity project."))
+			recordError("not a Unity project: %s", basePath)
+			fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+			fmt.Println(tr("Run from the project root, or pass both -company and -product."))
+			exit(1)
+		}
+		id, err = readProjectIdentity(basePath)
+		if err != nil {
+			fmt.Printf("\n[ERROR] %v\n", err)
+			recordError("%v", err)
+			exit(1)
+		}
+		if companyArg != "" {
+			id.companyName = companyArg
+		}
+		if productArg != "" {
+			id.productName = productArg
+		}
+	}
+
+	fmt.Printf(tr("\n  Company: %s\n"), id.companyName)
+	fmt.Printf(tr("  Product: %s\n"), id.productName)
+	if android {
+		fmt.Printf("  Android: %s\n", id.androidID)
+	}
+
+	if isRunning, pid := checkUnityRunning(basePath); isRunning {
+		fmt.Printf(tr("\n[WARNING] Unity Editor appears to be running (PID: %d).\n"), pid)
+		fmt.Println(tr("The Editor rewrites PlayerPrefs on exit, so changes may be lost."))
+		if ciMode {
+			fmt.Println(tr("\n[CI Mode] Aborting due to Unity running. Exit code: 1"))
+			recordError("Unity Editor is running (PID: %d)", pid)
+			exitTool(1)
+		}
+		fmt.Println(tr("\nPress Enter to FORCE continue (not recommended), or Ctrl+C to cancel..."))
+		stdinReader.ReadBytes('\n')
+	}
+
+	targets := collectTargets(id, !dataOnly, !prefsOnly)
+
+	if android {
+		if _, lookErr := exec.LookPath("adb"); lookErr != nil {
+			fmt.Println(tr("\n[WARNING] adb not found in PATH, skipping Android devices."))
+		} else if serials, adbErr := listAndroidDevices(); adbErr != nil {
+			fmt.Printf(tr("\n[WARNING] Failed to list Android devices: %v\n"), adbErr)
+		} else if len(serials) == 0 {
+			fmt.Println(tr("\n[WARNING] No Android devices connected."))
+		} else {
+			for _, serial := range serials {
+				targets = append(targets, cleanTarget{
+					kind:  "android",
+					label: tr("Device ") + serial,
+					path:  serial + "|" + id.androidID,
+				})
+			}
+		}
+	}
+
+	printPreview(targets)
+
+	if len(targets) == 0 {
+		exit(0)
+	}
+
+	if !ciMode && !dryRun {
+		fmt.Print(tr("\nProceed with reset? (y/N): "))
+		confirm, _ := stdinReader.ReadString('\n')
+		confirm = strings.TrimSpace(strings.ToLower(confirm))
+		if confirm != "y" {
+			fmt.Println(tr("Operation cancelled."))
+			exit(0)
+		}
+	}
+
+	fmt.Println(tr("\nCleaning..."))
+	startTime := time.Now()
+	var cleaned, failed int
+	var freed int64
+	for _, t := range targets {
+		display := t.path
+		if t.kind == "android" {
+			display = strings.Replace(t.path, "|", " ", 1)
+		}
+		itemStart := time.Now()
+		if err := cleanOne(t); err != nil {
+			fmt.Printf("[FAIL] %s: %s: %v\n", t.label, display, err)
+			recordAction("clear", display, "failed", err.Error(), time.Since(itemStart))
+			failed++
+			continue
+		}
+		fmt.Printf("[OK]   %s: %s\n", t.label, display)
+		recordAction("clear", display, "ok", t.label, time.Since(itemStart))
+		cleaned++
+		freed += t.size
+	}
+
+	printRule("\n===========================================")
+	fmt.Println(tr("  RESET COMPLETE"))
+	printRule("===========================================")
+	fmt.Printf(tr("  Cleared: %d items\n"), cleaned)
+	if failed > 0 {
+		fmt.Printf(tr("  Failed:  %d items\n"), failed)
+	}
+	fmt.Printf(tr("  Freed:   %s\n"), formatSize(freed))
+	fmt.Printf(tr("  Time:    %s\n"), time.Since(startTime).Round(time.Millisecond))
+
+	if overlay, ok := fsys.(*overlayFS); ok {
+		overlay.printDryRunReport(basePath)
+		fmt.Println(tr("\n[Dry Run] Nothing was removed."))
+	}
+
+	if failed > 0 {
+		exit(1)
+	}
+	exit(0)
+}
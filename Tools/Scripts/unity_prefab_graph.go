@@ -0,0 +1,1141 @@
+// Unity Prefab Graph — Map prefab variants and nested prefabs as a Mermaid or DOT graph.
+// Reads the prefab and scene YAML of the project without Unity and collects every
+// prefab instance: the root instance a prefab variant is built on, and the
+// prefabs nested in prefabs and placed in scenes. The graph draws an arrow from
+// each base to its variants and a dashed arrow from each nested prefab to the
+// prefab or scene using it (with the count when it is used more than once);
+// prefabs with neither are left out. It reports variants whose base was deleted,
+// nested instances of deleted prefabs, variant loops, and variant chains deeper
+// than -max-depth, which make an override hard to trace back to where it is set.
+//
+// Build: go build unity_prefab_graph.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_prefab_graph                                  # variant chains and findings
+//	unity_prefab_graph -out Docs/Prefabs.md             # Mermaid graph in Markdown
+//	unity_prefab_graph -out Docs/Prefabs.dot            # Graphviz: dot -Tsvg Docs/Prefabs.dot
+//	unity_prefab_graph -prefabs-only Assets/Characters  # one folder, without scenes
+//	unity_prefab_graph -max-depth 2 -strict -ci         # CI: deep chains fail too
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Class ID of a prefab instance in scene and prefab YAML
+const classPrefabInstance = "1001"
+
+// Variant steps from the original prefab above which a chain is reported
+const defaultMaxDepth = 3
+
+// Graph formats
+const (
+	formatMermaid = "mermaid"
+	formatDOT     = "dot"
+)
+
+// Node kinds
+const (
+	kindPrefab  = "prefab"
+	kindVariant = "variant"
+	kindModel   = "model"
+	kindScene   = "scene"
+	kindMissing = "missing"
+	kindOther   = "other"
+)
+
+// Model files a prefab variant can be based on
+var modelExtensions = map[string]bool{
+	".fbx": true, ".obj": true, ".blend": true, ".dae": true, ".3ds": true,
+	".max": true, ".ma": true, ".mb": true,
+}
+
+// Finding kinds, in report order
+const (
+	findBroken  = "broken"
+	findMissing = "missing"
+	findCycle   = "cycle"
+	findDeep    = "deep"
+)
+
+var findingSections = []struct {
+	kind  string
+	title string
+	tag   string
+}{
+	{findBroken, "Broken variant bases", "[BROKEN] "},
+	{findMissing, "Missing nested prefabs", "[MISSING]"},
+	{findCycle, "Variant loops", "[LOOP]   "},
+	{findDeep, "Deep variant chains", "[DEEP]   "},
+}
+
+var (
+	metaGUIDRegex = regexp.MustCompile(`(?m)^guid: ([0-9a-fA-F]{32})`)
+	docRegex      = regexp.MustCompile(`(?m)^--- !u!(\d+) &(-?\d+)`)
+	// m_SourcePrefab of an instance; m_ParentPrefab in projects from before Unity 2018.3
+	sourceRegex = regexp.MustCompile(`(?m)^  (?:m_SourcePrefab|m_ParentPrefab): \{fileID: -?\d+, guid: ([0-9a-fA-F]{32})`)
+	parentRegex = regexp.MustCompile(`(?m)^    m_TransformParent: \{fileID: (-?\d+)`)
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// yamlDoc is one object of a Unity YAML file
+type yamlDoc struct {
+	class string
+	id    string
+	line  int
+	body  string
+}
+
+// asset is one node of the graph: a prefab or scene that was read, or an asset
+// one of them names as a source
+type asset struct {
+	rel      string
+	guid     string
+	kind     string
+	base     string         // GUID of the prefab a variant is built on
+	baseLine int            // line of the root instance in the variant
+	nested   map[string]int // GUID of each nested prefab -> instance count
+	variants []*asset
+	usedIn   int // prefabs and scenes nesting it
+}
+
+// finding is one problem, kept as an English format so the report and JSON stay English
+type finding struct {
+	kind   string
+	file   string
+	line   int
+	format string
+	args   []interface{}
+}
+
+func (f finding) message() string {
+	return fmt.Sprintf(f.format, f.args...)
+}
+
+// graph is everything the scan found
+type graph struct {
+	basePath string
+	roots    []string
+	guids    map[string]string // every GUID in the project -> asset path
+	assets   map[string]*asset // GUID -> node
+	findings []finding
+}
+
+// ============================================================
+// Project Index
+// ============================================================
+
+// Folders indexed for GUIDs, so references into packages are not reported as missing
+var indexRoots = []string{"Assets", "Packages", filepath.Join("Library", "PackageCache")}
+
+// indexGUIDs maps the GUID of every asset in the project and its packages to its path
+func indexGUIDs(basePath string) map[string]string {
+	guids := make(map[string]string)
+	for _, root := range indexRoots {
+		filepath.Walk(filepath.Join(basePath, root), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if strings.HasPrefix(info.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(info.Name(), ".meta") {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			if m := metaGUIDRegex.FindSubmatch(data); m != nil {
+				guids[strings.ToLower(string(m[1]))] = relPath(basePath, strings.TrimSuffix(path, ".meta"))
+			}
+			return nil
+		})
+	}
+	return guids
+}
+
+// projectFiles lists the prefabs, and unless prefabsOnly the scenes, under the roots
+func projectFiles(basePath string, roots []string, prefabsOnly bool, excludes []*regexp.Regexp) []string {
+	var files []string
+	for _, root := range roots {
+		filepath.Walk(filepath.Join(basePath, filepath.FromSlash(root)), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if strings.HasPrefix(info.Name(), ".") || strings.HasSuffix(info.Name(), "~") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			rel := relPath(basePath, path)
+			ext := filepath.Ext(path)
+			if (ext == ".prefab" || (ext == ".unity" && !prefabsOnly)) && !matchesAny(excludes, rel) {
+				files = append(files, rel)
+			}
+			return nil
+		})
+	}
+	sort.Strings(files)
+	return files
+}
+
+// metaGUID reads the GUID from the .meta file next to path
+func metaGUID(path string) string {
+	data, err := os.ReadFile(path + ".meta")
+	if err != nil {
+		return ""
+	}
+	if m := metaGUIDRegex.FindSubmatch(data); m != nil {
+		return strings.ToLower(string(m[1]))
+	}
+	return ""
+}
+
+func (g *graph) add(kind, file string, line int, format string, args ...interface{}) {
+	g.findings = append(g.findings, finding{kind: kind, file: file, line: line, format: format, args: args})
+}
+
+// ============================================================
+// Unity YAML
+// ============================================================
+
+// splitDocs splits a Unity YAML file into its objects
+func splitDocs(data []byte) []yamlDoc {
+	locs := docRegex.FindAllSubmatchIndex(data, -1)
+	docs := make([]yamlDoc, 0, len(locs))
+	line := 1
+	prev := 0
+	for i, m := range locs {
+		end := len(data)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		line += bytes.Count(data[prev:m[0]], []byte("\n"))
+		prev = m[0]
+		docs = append(docs, yamlDoc{
+			class: string(data[m[2]:m[3]]),
+			id:    string(data[m[4]:m[5]]),
+			line:  line,
+			body:  string(data[m[1]:end]),
+		})
+	}
+	return docs
+}
+
+// ============================================================
+// Graph
+// ============================================================
+
+// node returns the node of a GUID, creating it from the GUID index when it was
+// not read; a GUID no asset has gives a missing node
+func (g *graph) node(guid string) *asset {
+	if a := g.assets[guid]; a != nil {
+		return a
+	}
+	a := &asset{guid: guid, nested: make(map[string]int)}
+	rel, ok := g.guids[guid]
+	ext := strings.ToLower(filepath.Ext(rel))
+	switch {
+	case !ok:
+		a.rel, a.kind = guid, kindMissing
+	case ext == ".prefab":
+		a.rel, a.kind = rel, kindPrefab
+	case ext == ".unity":
+		a.rel, a.kind = rel, kindScene
+	case modelExtensions[ext]:
+		a.rel, a.kind = rel, kindModel
+	default:
+		a.rel, a.kind = rel, kindOther
+	}
+	g.assets[guid] = a
+	return a
+}
+
+// read collects the prefab instances of a prefab or scene. In a prefab, the
+// instance without a parent transform is the root of a variant; every other
+// instance is a nested prefab.
+func (g *graph) read(rel, guid string, data []byte) {
+	a := g.node(guid)
+	if a.kind == kindMissing {
+		a.rel = rel // no .meta: nothing can reference it
+		a.kind = kindPrefab
+		if filepath.Ext(rel) == ".unity" {
+			a.kind = kindScene
+		}
+	}
+	for _, d := range splitDocs(data) {
+		if d.class != classPrefabInstance {
+			continue
+		}
+		m := sourceRegex.FindStringSubmatch(d.body)
+		if m == nil {
+			continue
+		}
+		source := strings.ToLower(m[1])
+		p := parentRegex.FindStringSubmatch(d.body)
+		if a.kind != kindScene && a.base == "" && (p == nil || p[1] == "0") {
+			a.kind, a.base, a.baseLine = kindVariant, source, d.line
+			continue
+		}
+		if a.nested[source] == 0 && g.node(source).kind == kindMissing {
+			g.add(findMissing, rel, d.line, "nested prefab %s does not exist", source)
+		}
+		a.nested[source]++
+	}
+}
+
+// link connects bases to their variants and counts where each prefab is nested,
+// once every file was read
+func (g *graph) link() {
+	for _, a := range g.sorted() {
+		if a.base != "" {
+			b := g.node(a.base)
+			b.variants = append(b.variants, a)
+			switch b.kind {
+			case kindMissing:
+				g.add(findBroken, a.rel, a.baseLine, "variant base %s does not exist", a.base)
+			case kindOther, kindScene:
+				g.add(findBroken, a.rel, a.baseLine, "variant base %s is not a prefab or model", b.rel)
+			}
+		}
+		for guid := range a.nested {
+			g.node(guid).usedIn++
+		}
+	}
+}
+
+// sorted returns the nodes by path
+func (g *graph) sorted() []*asset {
+	list := make([]*asset, 0, len(g.assets))
+	for _, a := range g.assets {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].rel < list[j].rel })
+	return list
+}
+
+// chain returns the variant chain of a prefab from the original base down to
+// it, and whether the chain loops back on itself
+func (g *graph) chain(a *asset) ([]*asset, bool) {
+	var c []*asset
+	seen := make(map[*asset]bool)
+	for x := a; x != nil; x = g.assets[x.base] {
+		if seen[x] {
+			reverseAssets(c)
+			return c, true
+		}
+		seen[x] = true
+		c = append(c, x)
+		if x.base == "" {
+			break
+		}
+	}
+	reverseAssets(c)
+	return c, false
+}
+
+func reverseAssets(list []*asset) {
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+}
+
+// checkChains reports variant loops, and the chains deeper than maxDepth at
+// the variant that ends them
+func (g *graph) checkChains(maxDepth int) {
+	looped := make(map[*asset]bool)
+	for _, a := range g.sorted() {
+		if a.base == "" {
+			continue
+		}
+		c, loop := g.chain(a)
+		if loop {
+			if !looped[a] {
+				for _, x := range c {
+					looped[x] = true
+				}
+				g.add(findCycle, a.rel, a.baseLine, "variant chain loops back: %s", chainNames(c))
+			}
+			continue
+		}
+		if depth := len(c) - 1; maxDepth > 0 && depth > maxDepth && len(a.variants) == 0 {
+			g.add(findDeep, a.rel, a.baseLine, "%d variant steps from the original (limit %d): %s", depth, maxDepth, chainNames(c))
+		}
+	}
+}
+
+// chainNames joins the names of a chain with arrows
+func chainNames(c []*asset) string {
+	names := make([]string, len(c))
+	for i, a := range c {
+		names[i] = a.name()
+	}
+	return strings.Join(names, " → ")
+}
+
+// name is how a node is shown: the file name without .prefab, or the start of
+// the GUID of a missing asset
+func (a *asset) name() string {
+	if a.kind == kindMissing {
+		return "missing " + a.guid[:8]
+	}
+	return strings.TrimSuffix(filepath.Base(a.rel), ".prefab")
+}
+
+// ============================================================
+// Output
+// ============================================================
+
+// graphNodes returns the nodes with at least one edge, by path, and the label of
+// each; a name two nodes share is replaced by the path
+func (g *graph) graphNodes() ([]*asset, map[*asset]string) {
+	var nodes []*asset
+	linked := make(map[*asset]bool)
+	for _, a := range g.assets {
+		if a.base != "" {
+			linked[a], linked[g.assets[a.base]] = true, true
+		}
+		for guid := range a.nested {
+			linked[a], linked[g.assets[guid]] = true, true
+		}
+	}
+	count := make(map[string]int)
+	for _, a := range g.sorted() {
+		if linked[a] {
+			nodes = append(nodes, a)
+			count[a.name()]++
+		}
+	}
+	labels := make(map[*asset]string)
+	for _, a := range nodes {
+		labels[a] = a.name()
+		if count[a.name()] > 1 && a.kind != kindMissing {
+			labels[a] = a.rel
+		}
+	}
+	return nodes, labels
+}
+
+// edge is one arrow of the graph
+type edge struct {
+	from, to *asset
+	variant  bool
+	count    int
+}
+
+func (g *graph) edges() []edge {
+	var list []edge
+	for _, a := range g.sorted() {
+		if a.base != "" {
+			list = append(list, edge{from: g.assets[a.base], to: a, variant: true})
+		}
+		guids := make([]string, 0, len(a.nested))
+		for guid := range a.nested {
+			guids = append(guids, guid)
+		}
+		sort.Slice(guids, func(i, j int) bool { return g.assets[guids[i]].rel < g.assets[guids[j]].rel })
+		for _, guid := range guids {
+			list = append(list, edge{from: g.assets[guid], to: a, count: a.nested[guid]})
+		}
+	}
+	return list
+}
+
+// renderMermaid writes the graph as a Mermaid flowchart
+func (g *graph) renderMermaid() string {
+	nodes, labels := g.graphNodes()
+	ids := make(map[*asset]string)
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	classes := make(map[string][]string)
+	for i, a := range nodes {
+		ids[a] = fmt.Sprintf("n%d", i+1)
+		label := strings.ReplaceAll(labels[a], "\"", "#quot;")
+		if a.kind == kindScene {
+			fmt.Fprintf(&b, "    %s[/\"%s\"/]\n", ids[a], label)
+		} else {
+			fmt.Fprintf(&b, "    %s[\"%s\"]\n", ids[a], label)
+		}
+		classes[a.kind] = append(classes[a.kind], ids[a])
+	}
+	for _, e := range g.edges() {
+		switch {
+		case e.variant:
+			fmt.Fprintf(&b, "    %s --> %s\n", ids[e.from], ids[e.to])
+		case e.count > 1:
+			fmt.Fprintf(&b, "    %s -.->|x%d| %s\n", ids[e.from], e.count, ids[e.to])
+		default:
+			fmt.Fprintf(&b, "    %s -.-> %s\n", ids[e.from], ids[e.to])
+		}
+	}
+	for _, k := range []struct{ kind, style string }{
+		{kindVariant, "fill:#e3ecfa,stroke:#4a6fa5"},
+		{kindModel, "fill:#eef6e8,stroke:#5a8a3c"},
+		{kindScene, "fill:#f2f2f2,stroke:#888"},
+		{kindMissing, "fill:#fbe0e0,stroke:#c0392b,stroke-dasharray:4"},
+		{kindOther, "fill:#fbe0e0,stroke:#c0392b"},
+	} {
+		if len(classes[k.kind]) > 0 {
+			fmt.Fprintf(&b, "    classDef %s %s\n    class %s %s\n", k.kind, k.style, strings.Join(classes[k.kind], ","), k.kind)
+		}
+	}
+	return b.String()
+}
+
+// renderDOT writes the graph for Graphviz
+func (g *graph) renderDOT() string {
+	nodes, labels := g.graphNodes()
+	ids := make(map[*asset]string)
+	var b strings.Builder
+	b.WriteString("digraph prefabs {\n    rankdir=LR;\n    node [shape=box, style=\"rounded,filled\", fillcolor=white, fontname=\"Helvetica\"];\n")
+	styles := map[string]string{
+		kindVariant: `fillcolor="#e3ecfa", color="#4a6fa5"`,
+		kindModel:   `fillcolor="#eef6e8", color="#5a8a3c"`,
+		kindScene:   `shape=note, style=filled, fillcolor="#f2f2f2", color="#888888"`,
+		kindMissing: `style="rounded,filled,dashed", fillcolor="#fbe0e0", color="#c0392b"`,
+		kindOther:   `fillcolor="#fbe0e0", color="#c0392b"`,
+	}
+	for i, a := range nodes {
+		ids[a] = fmt.Sprintf("n%d", i+1)
+		attrs := fmt.Sprintf("label=%s, tooltip=%s", dotQuote(labels[a]), dotQuote(a.rel))
+		if s := styles[a.kind]; s != "" {
+			attrs += ", " + s
+		}
+		fmt.Fprintf(&b, "    %s [%s];\n", ids[a], attrs)
+	}
+	for _, e := range g.edges() {
+		switch {
+		case e.variant:
+			fmt.Fprintf(&b, "    %s -> %s;\n", ids[e.from], ids[e.to])
+		case e.count > 1:
+			fmt.Fprintf(&b, "    %s -> %s [style=dashed, label=\"x%d\"];\n", ids[e.from], ids[e.to], e.count)
+		default:
+			fmt.Fprintf(&b, "    %s -> %s [style=dashed];\n", ids[e.from], ids[e.to])
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotQuote(s string) string {
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(s) + "\""
+}
+
+// writeGraph writes the graph in format; a .md path gets the Mermaid chart in a
+// fenced block with the findings below it
+func writeGraph(path, format string, g *graph) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if format == formatDOT {
+		return os.WriteFile(path, []byte(g.renderDOT()), 0644)
+	}
+	chart := g.renderMermaid()
+	if !strings.EqualFold(filepath.Ext(path), ".md") {
+		return os.WriteFile(path, []byte(chart), 0644)
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Prefab Graph\n\n%s, %s. Generated by `unity_prefab_graph`. ", filepath.Base(g.basePath), strings.Join(g.roots, ", "))
+	buf.WriteString("Solid arrows go from a base to its variants, dashed arrows from a nested prefab to the prefab or scene using it.\n\n")
+	buf.WriteString("```mermaid\n" + chart + "```\n")
+	if len(g.findings) > 0 {
+		buf.WriteString("\n## Findings\n\n")
+		for _, f := range g.findings {
+			fmt.Fprintf(&buf, "- `%s:%d` %s\n", f.file, f.line, f.message())
+		}
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// ============================================================
+// Console
+// ============================================================
+
+// printChains prints every base with variants as a tree of its variants
+func printChains(g *graph) int {
+	printed := 0
+	seen := make(map[*asset]bool)
+	var walk func(a *asset, indent string)
+	walk = func(a *asset, indent string) {
+		seen[a] = true
+		for i, v := range a.variants {
+			if seen[v] {
+				continue
+			}
+			branch, next := "├─ ", "│  "
+			if i == len(a.variants)-1 {
+				branch, next = "└─ ", "   "
+			}
+			if plainMode {
+				branch, next = "- ", "  "
+			}
+			fmt.Printf("%s%s%s\n", indent, branch, v.name())
+			walk(v, indent+next)
+		}
+	}
+	for _, a := range g.sorted() {
+		if len(a.variants) == 0 || a.base != "" {
+			continue // variants are printed under their base
+		}
+		if printed == 0 {
+			fmt.Println(tr("\nVARIANT CHAINS"))
+		}
+		printed++
+		fmt.Printf("  %s (%s)\n", a.name(), a.rel)
+		walk(a, "    ")
+	}
+	return printed
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode      bool
+		strict      bool
+		prefabsOnly bool
+		maxDepth    int
+		formatFlag  string
+		outFlag     string
+		excludeFlag string
+	)
+
+	flag.IntVar(&maxDepth, "max-depth", defaultMaxDepth, "Variant steps from the original prefab above which a chain is reported (0 = off)")
+	flag.StringVar(&outFlag, "out", "", "Write the graph to this file: Markdown with a Mermaid chart for a .md path")
+	flag.StringVar(&formatFlag, "format", "", "Graph format: mermaid, dot (default: dot for a .dot or .gv path, else mermaid)")
+	flag.BoolVar(&prefabsOnly, "prefabs-only", false, "Leave scenes out: only prefabs nested in prefabs are read")
+	flag.StringVar(&excludeFlag, "exclude", "", "Comma-separated globs of files left out")
+	flag.BoolVar(&strict, "strict", false, "Deep variant chains fail the check too")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the folders ("Assets/Characters -ci")
+	flag.Parse()
+	var roots []string
+	for flag.NArg() > 0 {
+		roots = append(roots, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_prefab_graph")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	format := strings.ToLower(formatFlag)
+	if format == "" {
+		format = formatMermaid
+		if ext := strings.ToLower(filepath.Ext(outFlag)); ext == ".dot" || ext == ".gv" {
+			format = formatDOT
+		}
+	}
+	if format != formatMermaid && format != formatDOT {
+		fail(2, "unknown format '%s' (use mermaid or dot)", formatFlag)
+	}
+	excludes, err := compileGlobs(excludeFlag)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if len(roots) == 0 {
+		roots = []string{"Assets"}
+	}
+	for i, r := range roots {
+		abs := r
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(basePath, filepath.FromSlash(r))
+		}
+		if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+			fail(2, "folder %s does not exist", r)
+		}
+		roots[i] = relPath(basePath, abs)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  PREFAB GRAPH"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:  %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Scanning: %s\n"), strings.Join(roots, ", "))
+
+	g := &graph{
+		basePath: basePath,
+		roots:    roots,
+		guids:    indexGUIDs(basePath),
+		assets:   make(map[string]*asset),
+	}
+	prefabs, scenes := 0, 0
+	for _, rel := range projectFiles(basePath, roots, prefabsOnly, excludes) {
+		path := filepath.Join(basePath, filepath.FromSlash(rel))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf(tr("[WARNING] %s: %v\n"), rel, err)
+			continue
+		}
+		guid := metaGUID(path)
+		if guid == "" {
+			guid = rel
+		}
+		g.read(rel, guid, data)
+		if filepath.Ext(rel) == ".unity" {
+			scenes++
+		} else {
+			prefabs++
+		}
+	}
+	g.link()
+	g.checkChains(maxDepth)
+
+	variants, nested := 0, 0
+	for _, a := range g.sorted() {
+		if a.kind == kindVariant {
+			variants++
+		}
+		for _, n := range a.nested {
+			nested += n
+		}
+	}
+	fmt.Printf(tr("  Prefabs:  %d (%d variant(s)), %d scene(s)\n"), prefabs, variants, scenes)
+	fmt.Printf(tr("  Nested:   %d prefab instance(s)\n"), nested)
+
+	if printChains(g) == 0 {
+		fmt.Println(tr("\nNo prefab variants."))
+	}
+	for _, a := range g.sorted() {
+		if a.kind != kindPrefab && a.kind != kindVariant {
+			continue
+		}
+		detail := fmt.Sprintf("%d nested, nested in %d, %d variant(s)", len(a.nested), a.usedIn, len(a.variants))
+		if a.base != "" {
+			detail = fmt.Sprintf("variant of %s, %s", g.assets[a.base].rel, detail)
+		}
+		recordAction(a.kind, a.rel, "ok", detail, 0)
+	}
+
+	order := make(map[string]int)
+	for i, s := range findingSections {
+		order[s.kind] = i
+	}
+	sort.SliceStable(g.findings, func(i, j int) bool {
+		fi, fj := g.findings[i], g.findings[j]
+		if fi.kind != fj.kind {
+			return order[fi.kind] < order[fj.kind]
+		}
+		if fi.file != fj.file {
+			return fi.file < fj.file
+		}
+		return fi.line < fj.line
+	})
+
+	errors, warnings := 0, 0
+	for _, s := range findingSections {
+		count := 0
+		for _, f := range g.findings {
+			if f.kind != s.kind {
+				continue
+			}
+			if count == 0 {
+				fmt.Printf("\n%s:\n", tr(s.title))
+			}
+			count++
+			where := fmt.Sprintf("%s:%d", f.file, f.line)
+			fmt.Printf("%s %s: %s\n", s.tag, where, fmt.Sprintf(tr(f.format), f.args...))
+			status := "warning"
+			if f.kind != findDeep || strict {
+				status = "failed"
+				recordError("%s: %s", where, f.message())
+			}
+			recordAction(f.kind, where, status, f.message(), 0)
+			if f.kind == findDeep {
+				warnings++
+			} else {
+				errors++
+			}
+		}
+	}
+
+	fmt.Println()
+	switch {
+	case errors > 0:
+		fmt.Printf(tr("[ERROR] %d broken or missing prefab reference(s), %d deep chain(s).\n"), errors, warnings)
+	case warnings > 0 && strict:
+		fmt.Printf(tr("[ERROR] %d deep variant chain(s) (-strict).\n"), warnings)
+	case warnings > 0:
+		fmt.Printf(tr("[WARNING] %d deep variant chain(s); every base and nested prefab exists.\n"), warnings)
+	default:
+		fmt.Println(tr("[OK] Every variant base and nested prefab exists."))
+	}
+
+	if outFlag != "" {
+		out := outFlag
+		if !filepath.IsAbs(out) {
+			out = filepath.Join(basePath, filepath.FromSlash(out))
+		}
+		if err := writeGraph(out, format, g); err != nil {
+			fail(1, "cannot write %s: %v", outFlag, err)
+		}
+		recordArtifact(out)
+		fmt.Printf(tr("[OK] Wrote %s\n"), relPath(basePath, out))
+	} else if variants > 0 || nested > 0 {
+		fmt.Println(tr("[TIP] -out Docs/Prefabs.md writes the graph as a Mermaid chart; -out Docs/Prefabs.dot for Graphviz."))
+	}
+	if warnings > 0 {
+		fmt.Println(tr("[TIP] A deep chain can often be flattened by basing the last variants on an earlier one and keeping their overrides."))
+	}
+	if errors > 0 || (strict && warnings > 0) {
+		exit(1)
+	}
+	exit(0)
+}
+
+// ============================================================
+// Path Filters
+// ============================================================
+
+// globToRegexp converts a glob (*, ?, **) on slash-separated project paths. A
+// pattern without a slash matches the file name in any folder.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compileGlobs parses a comma-separated glob list
+func compileGlobs(list string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := globToRegexp(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, rel string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+// relPath shows a path relative to the project root, with forward slashes
+func relPath(basePath, p string) string {
+	if rel, err := filepath.Rel(basePath, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return p
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...":                                     "\n按回车键继续...",
+	"[ERROR] %v\n":                                                     "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  PREFAB GRAPH":                                                   "  预制体关系图",
+	"  Project:  %s\n":                                                 "  项目:     %s\n",
+	"  Scanning: %s\n":                                                 "  扫描:     %s\n",
+	"[WARNING] %s: %v\n":                                               "[WARNING] %s: %v\n",
+	"  Prefabs:  %d (%d variant(s)), %d scene(s)\n":                    "  预制体:   %d 个（%d 个变体），%d 个场景\n",
+	"  Nested:   %d prefab instance(s)\n":                              "  嵌套:     %d 个预制体实例\n",
+	"\nVARIANT CHAINS":                                                 "\n变体链",
+	"\nNo prefab variants.":                                            "\n没有预制体变体。",
+	"Broken variant bases":                                             "损坏的变体基础",
+	"Missing nested prefabs":                                           "缺失的嵌套预制体",
+	"Variant loops":                                                    "变体循环",
+	"Deep variant chains":                                              "过深的变体链",
+	"variant base %s does not exist":                                   "变体基础 %s 不存在",
+	"variant base %s is not a prefab or model":                         "变体基础 %s 不是预制体或模型",
+	"nested prefab %s does not exist":                                  "嵌套预制体 %s 不存在",
+	"variant chain loops back: %s":                                     "变体链形成循环: %s",
+	"%d variant steps from the original (limit %d): %s":                "距原始预制体 %d 层变体（上限 %d）: %s",
+	"[ERROR] %d broken or missing prefab reference(s), %d deep chain(s).\n":      "[ERROR] %d 个损坏或缺失的预制体引用，%d 条过深的变体链。\n",
+	"[ERROR] %d deep variant chain(s) (-strict).\n":                              "[ERROR] %d 条过深的变体链 (-strict)。\n",
+	"[WARNING] %d deep variant chain(s); every base and nested prefab exists.\n": "[WARNING] %d 条过深的变体链；所有变体基础和嵌套预制体都存在。\n",
+	"[OK] Every variant base and nested prefab exists.":                          "[OK] 所有变体基础和嵌套预制体都存在。",
+	"[OK] Wrote %s\n": "[OK] 已写入 %s\n",
+	"[TIP] -out Docs/Prefabs.md writes the graph as a Mermaid chart; -out Docs/Prefabs.dot for Graphviz.":                  "[TIP] -out Docs/Prefabs.md 将关系图写为 Mermaid 图表；-out Docs/Prefabs.dot 用于 Graphviz。",
+	"[TIP] A deep chain can often be flattened by basing the last variants on an earlier one and keeping their overrides.": "[TIP] 过深的变体链通常可以展平：将末端变体改为基于更早的变体，并保留它们的覆盖。",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
@@ -0,0 +1,1324 @@
+// Unity Project Archive — Zip a project for sharing, without caches and build output.
+// Packs the project root into a zip, leaving out everything unity_project_full_clean
+// deletes (Library, Temp, Logs, IDE files, build folders...), optionally the .git
+// folder, and writes a manifest with the SHA-256 of every file into the archive so
+// the receiving end can check that nothing was lost or corrupted on the way.
+// verify and extract do that check on the receiving end, compare the project's
+// Unity version with the editors installed through Unity Hub, and extract with
+// every file hash-checked before it is moved into place.
+//
+// Build: go build unity_project_archive.go
+//
+// Usage: run from the Unity project root; verify and extract run from any folder.
+//
+//	unity_project_archive ../MyGame.zip
+//	unity_project_archive -no-git ../MyGame.zip          # leave out history and LFS objects
+//	unity_project_archive -exclude "Assets/Art/Source/**" ../MyGame.zip
+//	unity_project_archive -dry-run ../MyGame.zip         # list what would be packed
+//	unity_project_archive verify MyGame.zip              # check every file against the manifest
+//	unity_project_archive extract MyGame.zip D:/Work/MyGame
+
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Top-level directories left out of the archive. Keep in sync with
+// directoriesToDelete in unity_project_full_clean.go: everything the cleaner
+// deletes is regenerated by Unity or the IDE and has no place in a shared copy.
+var excludedDirectories = []string{
+	".vs",
+	".idea",
+	".vscode",
+	".utmp",
+	"obj",
+	"Logs",
+	"Temp",
+	"Library",
+	"SceneBackups",
+	"MemoryCaptures",
+	"Build",
+	"HybridCLRData",
+	"Bundles",
+	"yoo",
+	"HotUpdateAssetsPreUpload",
+	// Per-user editor state (layouts, search index settings); not cleaned, but personal
+	"UserSettings",
+	// Local restore points of unity_snapshot, each a copy of the project
+	".unitystarter_snapshots",
+}
+
+// Top-level file extensions left out, as fileExtensionsToDelete in the cleaner
+var excludedFileExtensions = []string{
+	".csproj",
+	".sln",
+	".slnx",
+	".user",
+	".vsconfig",
+}
+
+// Already-compressed formats are stored instead of deflated: compressing them
+// again costs time and saves nothing
+var storedExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".ktx2": true,
+	".mp3": true, ".ogg": true, ".m4a": true, ".aac": true,
+	".mp4": true, ".webm": true, ".mov": true,
+	".zip": true, ".gz": true, ".tgz": true, ".7z": true, ".rar": true, ".unitypackage": true,
+	".bundle": true, ".br": true,
+}
+
+// Name of the manifest entry at the root of every archive
+const archiveManifestName = "unitystarter-archive.json"
+
+// Bump when the manifest layout changes incompatibly
+const archiveFormatVersion = 1
+
+var projectVersionRegex = regexp.MustCompile(`(?m)^m_EditorVersion:\s*(\S+)`)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// archiveManifest is stored in the zip. Keep field names stable: archives
+// outlive the tool version that created them.
+type archiveManifest struct {
+	Format       int            `json:"format"`
+	Project      string         `json:"project"`
+	UnityVersion string         `json:"unityVersion"`
+	CreatedAt    string         `json:"createdAt"`
+	Host         string         `json:"host,omitempty"`
+	IncludesGit  bool           `json:"includesGit"`
+	TotalBytes   int64          `json:"totalBytes"`
+	Files        []archivedFile `json:"files"`
+}
+
+type archivedFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// sourceFile is a file picked for the archive
+type sourceFile struct {
+	abs  string
+	rel  string // slash-separated, relative to the project root
+	info os.FileInfo
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks for Assets/ and ProjectSettings/ in the given directory
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// readUnityVersion returns the editor version from ProjectSettings/ProjectVersion.txt
+func readUnityVersion(basePath string) string {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectVersion.txt"))
+	if err != nil {
+		return ""
+	}
+	if m := projectVersionRegex.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// ============================================================
+// File Selection
+// ============================================================
+
+// globToRegexp converts a glob to a regexp: ** spans folders, * and ? stay within
+// one path segment. Patterns without a slash match the file name alone.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compileGlobs parses a comma-separated glob list
+func compileGlobs(list string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := globToRegexp(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, rel string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExcludedTopLevel applies the cleaner's lists; like the cleaner, they only
+// apply directly under the project root
+func isExcludedTopLevel(name string, isDir, includeGit bool) bool {
+	if isDir {
+		if name == ".git" {
+			return !includeGit
+		}
+		for _, d := range excludedDirectories {
+			if strings.EqualFold(name, d) {
+				return true
+			}
+		}
+		return false
+	}
+	// The lock of a tool running right now means nothing in a copy
+	if strings.EqualFold(name, ".unitystarter.lock") {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range excludedFileExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// collectFiles walks the project and returns the files to archive in path
+// order. skip is the output zip itself when it is written inside the project.
+func collectFiles(basePath string, includeGit bool, excludes []*regexp.Regexp, skip string) ([]sourceFile, []string, error) {
+	var files []sourceFile
+	var skipped []string
+	err := filepath.Walk(basePath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", p, err))
+			return nil
+		}
+		if p == basePath {
+			return nil
+		}
+		rel, _ := filepath.Rel(basePath, p)
+		rel = filepath.ToSlash(rel)
+		if !strings.Contains(rel, "/") && isExcludedTopLevel(info.Name(), info.IsDir(), includeGit) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if matchesAny(excludes, rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			skipped = append(skipped, rel+" (not a regular file)")
+			return nil
+		}
+		if skip != "" && p == skip {
+			return nil
+		}
+		files = append(files, sourceFile{abs: p, rel: rel, info: info})
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].rel < files[j].rel })
+	return files, skipped, err
+}
+
+// ============================================================
+// Archive Writing
+// ============================================================
+
+// writeArchive packs the files into outPath. The zip is written to a temp file
+// next to it and renamed at the end, so an interrupted run never leaves a
+// truncated archive with the final name. Files are hashed while they are
+// copied, which reads each file once.
+func writeArchive(outPath string, files []sourceFile, manifest *archiveManifest) error {
+	tmp, err := os.CreateTemp(filepath.Dir(outPath), "."+filepath.Base(outPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	fail := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	zw := zip.NewWriter(tmp)
+	for i, f := range files {
+		header, err := zip.FileInfoHeader(f.info)
+		if err != nil {
+			return fail(err)
+		}
+		header.Name = f.rel
+		header.Method = zip.Deflate
+		if storedExtensions[strings.ToLower(filepath.Ext(f.rel))] {
+			header.Method = zip.Store
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return fail(err)
+		}
+		src, err := os.Open(f.abs)
+		if err != nil {
+			return fail(fmt.Errorf("cannot read %s: %v", f.rel, err))
+		}
+		h := sha256.New()
+		n, err := io.Copy(io.MultiWriter(w, h), src)
+		src.Close()
+		if err != nil {
+			return fail(fmt.Errorf("cannot read %s: %v", f.rel, err))
+		}
+		manifest.Files = append(manifest.Files, archivedFile{Path: f.rel, Size: n, SHA256: hex.EncodeToString(h.Sum(nil))})
+		manifest.TotalBytes += n
+		printProgressBar(i+1, len(files))
+	}
+
+	data, _ := json.MarshalIndent(manifest, "", "  ")
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: archiveManifestName, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return fail(err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fail(err)
+	}
+	if err := zw.Close(); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, outPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// ============================================================
+// Archive Verification
+// ============================================================
+
+// verifyResult is the outcome of checking an archive against its manifest
+type verifyResult struct {
+	manifest   *archiveManifest
+	ok         int
+	missing    []string // in the manifest, not in the zip
+	corrupted  []string // size or hash differs
+	unexpected []string // in the zip, not in the manifest
+}
+
+func (r *verifyResult) failed() bool {
+	return len(r.missing) > 0 || len(r.corrupted) > 0
+}
+
+// safeEntryName rejects entries that would land outside the destination
+// ("../x", absolute paths, drive letters) when extracted
+func safeEntryName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "/") || strings.HasPrefix(name, "\\") || strings.Contains(name, ":") {
+		return false
+	}
+	for _, part := range strings.Split(strings.ReplaceAll(name, "\\", "/"), "/") {
+		if part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// safeProjectName accepts a manifest's project name as the default extract
+// folder only when it is a single folder name: no separators, "..", drive
+// letter or absolute path that would put the project outside the working directory
+func safeProjectName(name string) bool {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\:") {
+		return false
+	}
+	return filepath.VolumeName(name) == "" && !filepath.IsAbs(name) && filepath.Base(name) == name
+}
+
+// readArchiveManifest returns the manifest and the file entries by name
+func readArchiveManifest(zr *zip.Reader) (*archiveManifest, map[string]*zip.File, error) {
+	entries := make(map[string]*zip.File)
+	var manifestEntry *zip.File
+	for _, f := range zr.File {
+		if !safeEntryName(f.Name) {
+			return nil, nil, fmt.Errorf("unsafe path in archive: %s", f.Name)
+		}
+		if f.Name == archiveManifestName {
+			manifestEntry = f
+			continue
+		}
+		if !f.FileInfo().IsDir() {
+			entries[f.Name] = f
+		}
+	}
+	if manifestEntry == nil {
+		return nil, nil, fmt.Errorf("%s not found; the archive was not created by unity_project_archive", archiveManifestName)
+	}
+	rc, err := manifestEntry.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rc.Close()
+	var manifest archiveManifest
+	if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %v", archiveManifestName, err)
+	}
+	if manifest.Format > archiveFormatVersion {
+		return nil, nil, fmt.Errorf("archive format %d is newer than this tool supports (%d); update the tools", manifest.Format, archiveFormatVersion)
+	}
+	return &manifest, entries, nil
+}
+
+// hashEntry reads one zip entry, optionally copying it to w, and returns its
+// size and SHA-256
+func hashEntry(f *zip.File, w io.Writer) (int64, string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return 0, "", err
+	}
+	defer rc.Close()
+	h := sha256.New()
+	dst := io.Writer(h)
+	if w != nil {
+		dst = io.MultiWriter(w, h)
+	}
+	n, err := io.Copy(dst, rc)
+	if err != nil {
+		return n, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyArchive checks every manifest entry's size and hash. The zip's own
+// CRC only catches transport damage; the manifest also catches files that
+// were swapped or edited after the archive was created.
+func verifyArchive(zr *zip.Reader) (*verifyResult, error) {
+	manifest, entries, err := readArchiveManifest(zr)
+	if err != nil {
+		return nil, err
+	}
+	res := &verifyResult{manifest: manifest}
+	listed := make(map[string]bool, len(manifest.Files))
+	for i, mf := range manifest.Files {
+		listed[mf.Path] = true
+		f, ok := entries[mf.Path]
+		if !ok {
+			res.missing = append(res.missing, mf.Path)
+			continue
+		}
+		size, sum, err := hashEntry(f, nil)
+		if err != nil || size != mf.Size || sum != mf.SHA256 {
+			res.corrupted = append(res.corrupted, mf.Path)
+		} else {
+			res.ok++
+		}
+		printProgressBar(i+1, len(manifest.Files))
+	}
+	for name := range entries {
+		if !listed[name] {
+			res.unexpected = append(res.unexpected, name)
+		}
+	}
+	sort.Strings(res.unexpected)
+	return res, nil
+}
+
+// ============================================================
+// Installed Editors
+// ============================================================
+
+// hubEditorFolders returns the folders Unity Hub installs editors into: the
+// default location plus the custom one from secondaryInstallPath.json
+func hubEditorFolders() []string {
+	home, _ := os.UserHomeDir()
+	var folders []string
+	var hubConfig string
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramW6432"} {
+			if pf := os.Getenv(env); pf != "" {
+				folders = append(folders, filepath.Join(pf, "Unity", "Hub", "Editor"))
+			}
+		}
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			hubConfig = filepath.Join(appData, "UnityHub")
+		}
+	case "darwin":
+		folders = append(folders, "/Applications/Unity/Hub/Editor")
+		hubConfig = filepath.Join(home, "Library", "Application Support", "UnityHub")
+	default:
+		folders = append(folders, filepath.Join(home, "Unity", "Hub", "Editor"))
+		hubConfig = filepath.Join(home, ".config", "UnityHub")
+	}
+	if hubConfig != "" {
+		// The file holds a single JSON string; empty when no custom location is set
+		if data, err := os.ReadFile(filepath.Join(hubConfig, "secondaryInstallPath.json")); err == nil {
+			var custom string
+			if json.Unmarshal(data, &custom) == nil && custom != "" {
+				folders = append(folders, custom)
+			}
+		}
+	}
+	return folders
+}
+
+// installedEditors lists editor versions found in the Hub folders; each
+// version folder holds Editor/Unity.exe, Unity.app or Editor/Unity
+func installedEditors() map[string]string {
+	editors := make(map[string]string)
+	for _, folder := range hubEditorFolders() {
+		entries, err := os.ReadDir(folder)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			dir := filepath.Join(folder, e.Name())
+			for _, exe := range []string{filepath.Join("Editor", "Unity.exe"), "Unity.app", filepath.Join("Editor", "Unity")} {
+				if _, err := os.Stat(filepath.Join(dir, exe)); err == nil {
+					if _, seen := editors[e.Name()]; !seen {
+						editors[e.Name()] = dir
+					}
+					break
+				}
+			}
+		}
+	}
+	return editors
+}
+
+// editorStream is the major.minor part ("2022.3") of a version like 2022.3.10f1
+func editorStream(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// checkEditor reports how the archive's Unity version matches the installed
+// editors: "exact", "stream" (same major.minor, different patch), or "none"
+func checkEditor(version string, editors map[string]string) (string, []string) {
+	if _, ok := editors[version]; ok {
+		return "exact", []string{version}
+	}
+	var same []string
+	for v := range editors {
+		if editorStream(v) == editorStream(version) {
+			same = append(same, v)
+		}
+	}
+	if len(same) > 0 {
+		sort.Strings(same)
+		return "stream", same
+	}
+	return "none", nil
+}
+
+// ============================================================
+// Extraction
+// ============================================================
+
+// longPathLimit is MAX_PATH minus the terminating NUL. Go's os package adds
+// the \\?\ prefix itself for long absolute paths, so extraction works; Unity
+// and Explorer only cope when long paths are enabled in Windows.
+const longPathLimit = 259
+
+// windowsLongPathsEnabled reads HKLM\SYSTEM\CurrentControlSet\Control\FileSystem\LongPathsEnabled
+func windowsLongPathsEnabled() bool {
+	out, err := exec.Command("reg", "query", `HKLM\SYSTEM\CurrentControlSet\Control\FileSystem`, "/v", "LongPathsEnabled").Output()
+	return err == nil && strings.Contains(string(out), "0x1")
+}
+
+// extractFile writes one entry under dest, checking its hash on the way. The
+// data goes to a temp file that is only renamed into place when it matches.
+// dest must be absolute so Go can apply the long path prefix on Windows.
+func extractFile(dest string, f *zip.File, mf archivedFile) error {
+	target := filepath.Join(dest, filepath.FromSlash(mf.Path))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	size, sum, err := hashEntry(f, tmp)
+	tmp.Close()
+	if err == nil && (size != mf.Size || sum != mf.SHA256) {
+		err = fmt.Errorf("hash mismatch")
+	}
+	if err == nil {
+		mode := f.Mode().Perm()
+		if mode == 0 {
+			mode = 0644
+		}
+		err = os.Chmod(tmp.Name(), mode|0200)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), target)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if !f.Modified.IsZero() {
+		os.Chtimes(target, f.Modified, f.Modified)
+	}
+	return nil
+}
+
+// isEmptyDir reports whether dir is missing or has no entries
+func isEmptyDir(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	return err != nil || len(entries) == 0
+}
+
+// ============================================================
+// Verify & Extract Commands
+// ============================================================
+
+// printArchiveInfo shows the manifest header and how its Unity version
+// matches the editors installed through Unity Hub; dest is empty for verify
+func printArchiveInfo(zipPath, dest string, manifest *archiveManifest) {
+	printRule("=============================================")
+	fmt.Println(tr("  PROJECT ARCHIVE"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Archive:  %s\n"), zipPath)
+	fmt.Printf(tr("  Project:  %s\n"), manifest.Project)
+	fmt.Printf(tr("  Unity:    %s\n"), manifest.UnityVersion)
+	if manifest.Host != "" {
+		fmt.Printf(tr("  Created:  %s on %s\n"), manifest.CreatedAt, manifest.Host)
+	} else {
+		fmt.Printf(tr("  Created:  %s\n"), manifest.CreatedAt)
+	}
+	fmt.Printf(tr("  Files:    %d (%s)\n"), len(manifest.Files), formatSize(manifest.TotalBytes))
+	if dest != "" {
+		fmt.Printf(tr("  Destination: %s\n"), dest)
+	}
+	fmt.Println()
+
+	if manifest.UnityVersion == "" {
+		return
+	}
+	editors := installedEditors()
+	match, versions := checkEditor(manifest.UnityVersion, editors)
+	switch match {
+	case "exact":
+		fmt.Printf(tr("[OK] Unity %s is installed: %s\n"), manifest.UnityVersion, editors[manifest.UnityVersion])
+		recordAction("editor-check", manifest.UnityVersion, "ok", editors[manifest.UnityVersion], 0)
+	case "stream":
+		fmt.Printf(tr("[WARNING] Unity %s is not installed; same stream: %s (opening with another patch version updates ProjectVersion.txt)\n"), manifest.UnityVersion, strings.Join(versions, ", "))
+		recordAction("editor-check", manifest.UnityVersion, "skipped", "same stream: "+strings.Join(versions, ", "), 0)
+	default:
+		installed := make([]string, 0, len(editors))
+		for v := range editors {
+			installed = append(installed, v)
+		}
+		sort.Strings(installed)
+		if len(installed) == 0 {
+			fmt.Printf(tr("[WARNING] Unity %s is not installed (no Unity Hub editors found)\n"), manifest.UnityVersion)
+		} else {
+			fmt.Printf(tr("[WARNING] Unity %s is not installed; installed: %s\n"), manifest.UnityVersion, strings.Join(installed, ", "))
+		}
+		fmt.Printf(tr("          Install it with: unityhub -- --headless install --version %s\n"), manifest.UnityVersion)
+		recordAction("editor-check", manifest.UnityVersion, "skipped", "not installed", 0)
+	}
+}
+
+func verifyCommand(zipPath string) int {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot open %s: %v\n"), zipPath, err)
+		recordError("cannot open %s: %v", zipPath, err)
+		return 1
+	}
+	defer zr.Close()
+
+	manifest, _, err := readArchiveManifest(&zr.Reader)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		return 1
+	}
+	printArchiveInfo(zipPath, "", manifest)
+	fmt.Println()
+
+	res, err := verifyArchive(&zr.Reader)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		return 1
+	}
+	reportVerify(res)
+	detail := fmt.Sprintf("%d ok, %d missing, %d corrupted", res.ok, len(res.missing), len(res.corrupted))
+	if res.failed() {
+		recordAction("verify", zipPath, "failed", detail, 0)
+		return 1
+	}
+	recordAction("verify", zipPath, "ok", detail, 0)
+	return 0
+}
+
+func reportVerify(res *verifyResult) {
+	for _, p := range res.missing {
+		fmt.Printf(tr("[ERROR] Missing: %s\n"), p)
+		recordError("missing: %s", p)
+	}
+	for _, p := range res.corrupted {
+		fmt.Printf(tr("[ERROR] Corrupted: %s\n"), p)
+		recordError("corrupted: %s", p)
+	}
+	for _, p := range res.unexpected {
+		fmt.Printf(tr("[WARNING] Not in the manifest: %s\n"), p)
+	}
+	if res.failed() {
+		fmt.Printf(tr("\n[ERROR] %d of %d file(s) failed verification.\n"), len(res.missing)+len(res.corrupted), len(res.manifest.Files))
+		return
+	}
+	fmt.Printf(tr("\n[OK] All %d file(s) match the manifest.\n"), res.ok)
+}
+
+func extractCommand(zipPath, dest string, ciMode, dryRun, force bool) int {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot open %s: %v\n"), zipPath, err)
+		recordError("cannot open %s: %v", zipPath, err)
+		return 1
+	}
+	defer zr.Close()
+
+	manifest, entries, err := readArchiveManifest(&zr.Reader)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		return 1
+	}
+	if dest == "" {
+		if !safeProjectName(manifest.Project) {
+			fmt.Printf(tr("[ERROR] The archive's project name %q is not a plain folder name; pass the destination folder after the zip\n"), manifest.Project)
+			recordError("unsafe project name in the manifest: %q", manifest.Project)
+			return 1
+		}
+		dest = filepath.Base(manifest.Project)
+	}
+	if dest, err = filepath.Abs(dest); err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		return 1
+	}
+	printArchiveInfo(zipPath, dest, manifest)
+
+	if !isEmptyDir(dest) && !force {
+		fmt.Printf(tr("[ERROR] %s is not empty (use -force to extract into it anyway)\n"), dest)
+		recordError("%s is not empty", dest)
+		return 1
+	}
+
+	// Paths over MAX_PATH extract fine, but Unity fails on them unless long paths are enabled
+	if runtime.GOOS == "windows" {
+		long := 0
+		for _, mf := range manifest.Files {
+			if len(dest)+1+len(mf.Path) > longPathLimit {
+				long++
+			}
+		}
+		if long > 0 && !windowsLongPathsEnabled() {
+			fmt.Printf(tr("[WARNING] %d path(s) are longer than %d characters and Windows long paths are disabled.\n"), long, longPathLimit)
+			fmt.Println(tr("          Unity may fail to import them; extract closer to the drive root or enable LongPathsEnabled."))
+		}
+	}
+
+	listed := make(map[string]bool, len(manifest.Files))
+	for _, mf := range manifest.Files {
+		listed[mf.Path] = true
+	}
+	var unexpected []string
+	for name := range entries {
+		if !listed[name] {
+			unexpected = append(unexpected, name)
+		}
+	}
+	sort.Strings(unexpected)
+	for _, p := range unexpected {
+		fmt.Printf(tr("[WARNING] Not in the manifest, not extracted: %s\n"), p)
+	}
+
+	if dryRun {
+		for _, mf := range manifest.Files {
+			recordAction("extract", mf.Path, "planned", formatSize(mf.Size), 0)
+		}
+		fmt.Printf(tr("\n[Dry Run] %d file(s) would be extracted into %s; nothing was written.\n"), len(manifest.Files), dest)
+		return 0
+	}
+	if !ciMode {
+		fmt.Printf(tr("\nExtract %d file(s) into %s? (y/N): "), len(manifest.Files), dest)
+		confirm, _ := stdinReader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+			fmt.Println(tr("Operation cancelled."))
+			return 0
+		}
+	}
+
+	fmt.Println()
+	start := time.Now()
+	var failedFiles []string
+	extracted := 0
+	for i, mf := range manifest.Files {
+		f, ok := entries[mf.Path]
+		if !ok {
+			failedFiles = append(failedFiles, mf.Path+": "+tr("missing from the archive"))
+		} else if err := extractFile(dest, f, mf); err != nil {
+			failedFiles = append(failedFiles, fmt.Sprintf("%s: %v", mf.Path, err))
+		} else {
+			extracted++
+		}
+		printProgressBar(i+1, len(manifest.Files))
+	}
+	for _, msg := range failedFiles {
+		fmt.Printf(tr("[ERROR] %s\n"), msg)
+		recordError("%s", msg)
+	}
+	detail := fmt.Sprintf("%d of %d files", extracted, len(manifest.Files))
+	if len(failedFiles) > 0 {
+		recordAction("extract", zipPath, "failed", detail, time.Since(start))
+		fmt.Printf(tr("\n[ERROR] %d file(s) could not be extracted or failed verification.\n"), len(failedFiles))
+		return 1
+	}
+	fmt.Printf(tr("\n[OK] Extracted and verified %d file(s) into %s\n"), extracted, dest)
+	if manifest.UnityVersion != "" {
+		fmt.Printf(tr("[TIP] Open the folder with Unity %s; the first import rebuilds Library/.\n"), manifest.UnityVersion)
+	}
+	recordAction("extract", zipPath, "ok", detail, time.Since(start))
+	recordArtifact(dest)
+	return 0
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+func printProgressBar(current, total int) {
+	percent := float64(current) / float64(total)
+	if plainMode || jsonMode {
+		// One line per 10% step instead of redrawing the bar with \r
+		step := int(percent * 10)
+		if current == total || step > int(float64(current-1)/float64(total)*10) {
+			fmt.Printf(tr("Progress: %d/%d (%.0f%%)\n"), current, total, percent*100)
+		}
+		return
+	}
+	barLength := 40
+	filledLength := int(float64(barLength) * percent)
+
+	bar := strings.Repeat("█", filledLength) + strings.Repeat("-", barLength-filledLength)
+	fmt.Printf("\r[%s] %.0f%% (%d/%d)", bar, percent*100, current, total)
+	if current == total {
+		fmt.Println()
+	}
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"Usage: unity_project_archive [flags] [create] <out.zip>": "用法: unity_project_archive [参数] [create] <输出.zip>",
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                                                   "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.":                             "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                                       "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"[WARNING] The project seems to be open in Unity; save and close it for a consistent archive.": "[WARNING] 项目似乎正在 Unity 中打开；请保存并关闭后再打包，以保证内容一致。",
+	"[ERROR] Cannot scan the project: %v\n":                                                        "[ERROR] 无法扫描项目: %v\n",
+	"[WARNING] Skipped: %s\n":                                                                      "[WARNING] 已跳过: %s\n",
+	"  PROJECT ARCHIVE":                                                                            "  项目打包",
+	"  Project:  %s\n":                                                                             "  项目:     %s\n",
+	"  Unity:    %s\n":                                                                             "  Unity:    %s\n",
+	"  Output:   %s\n":                                                                             "  输出:     %s\n",
+	"\n  %d file(s), %s uncompressed\n":                                                            "\n  %d 个文件，未压缩 %s\n",
+	"  [TIP] .git is included; use -no-git to share the working copy only.":                        "  [TIP] 包含 .git；使用 -no-git 只分享工作副本。",
+	"\n[--] Nothing to archive.":                                                                   "\n[--] 没有可打包的内容。",
+	"\n[Dry Run] No archive was written.":                                                          "\n[Dry Run] 未写入压缩包。",
+	"\n%s exists. Overwrite? (y/N): ":                                                              "\n%s 已存在，是否覆盖？(y/N): ",
+	"Operation cancelled.":                                                                         "操作已取消。",
+	"[ERROR] Cannot create %s: %v\n":                                                               "[ERROR] 无法创建 %s: %v\n",
+	"\n[ERROR] Cannot write archive: %v\n":                                                         "\n[ERROR] 无法写入压缩包: %v\n",
+	"\n[OK] Archived %d file(s) into %s (%s, %.1fs)\n":                                             "\n[OK] 已将 %d 个文件打包到 %s (%s，%.1f 秒)\n",
+	"       unity_project_archive [flags] verify <archive.zip>":                                    "       unity_project_archive [参数] verify <压缩包.zip>",
+	"       unity_project_archive [flags] extract <archive.zip> [folder]":                          "       unity_project_archive [参数] extract <压缩包.zip> [文件夹]",
+	"  Archive:  %s\n":                                                                             "  压缩包:   %s\n",
+	"  Created:  %s on %s\n":                                                                       "  创建:     %s，于 %s\n",
+	"  Created:  %s\n":                                                                             "  创建:     %s\n",
+	"  Files:    %d (%s)\n":                                                                        "  文件:     %d (%s)\n",
+	"  Destination: %s\n":                                                                          "  目标:     %s\n",
+	"[OK] Unity %s is installed: %s\n":                                                             "[OK] 已安装 Unity %s: %s\n",
+	"[WARNING] Unity %s is not installed; same stream: %s (opening with another patch version updates ProjectVersion.txt)\n": "[WARNING] 未安装 Unity %s；同一版本线: %s（用其他补丁版本打开会更新 ProjectVersion.txt）\n",
+	"[WARNING] Unity %s is not installed (no Unity Hub editors found)\n":                                                     "[WARNING] 未安装 Unity %s（未找到 Unity Hub 编辑器）\n",
+	"[WARNING] Unity %s is not installed; installed: %s\n":                                                                   "[WARNING] 未安装 Unity %s；已安装: %s\n",
+	"          Install it with: unityhub -- --headless install --version %s\n":                                               "          安装命令: unityhub -- --headless install --version %s\n",
+	"[ERROR] Cannot open %s: %v\n":                                                                          "[ERROR] 无法打开 %s: %v\n",
+	"[ERROR] Missing: %s\n":                                                                                 "[ERROR] 缺失: %s\n",
+	"[ERROR] Corrupted: %s\n":                                                                               "[ERROR] 已损坏: %s\n",
+	"[WARNING] Not in the manifest: %s\n":                                                                   "[WARNING] 不在清单中: %s\n",
+	"\n[ERROR] %d of %d file(s) failed verification.\n":                                                     "\n[ERROR] %[2]d 个文件中有 %[1]d 个校验失败。\n",
+	"\n[OK] All %d file(s) match the manifest.\n":                                                           "\n[OK] 全部 %d 个文件与清单一致。\n",
+	"[ERROR] %s is not empty (use -force to extract into it anyway)\n":                                      "[ERROR] %s 不为空（使用 -force 仍然解压到此处）\n",
+	"[WARNING] %d path(s) are longer than %d characters and Windows long paths are disabled.\n":             "[WARNING] 有 %d 个路径超过 %d 个字符，且 Windows 未启用长路径。\n",
+	"          Unity may fail to import them; extract closer to the drive root or enable LongPathsEnabled.": "          Unity 可能无法导入这些文件；请解压到更靠近盘符根目录的位置，或启用 LongPathsEnabled。",
+	"[WARNING] Not in the manifest, not extracted: %s\n":                                                    "[WARNING] 不在清单中，未解压: %s\n",
+	"\n[Dry Run] %d file(s) would be extracted into %s; nothing was written.\n":                             "\n[Dry Run] 将解压 %d 个文件到 %s；未写入任何内容。\n",
+	"\nExtract %d file(s) into %s? (y/N): ":                                                                 "\n是否解压 %d 个文件到 %s？(y/N): ",
+	"missing from the archive":                                                                              "压缩包中缺失",
+	"[ERROR] %s\n":                                                                                          "[ERROR] %s\n",
+	"\n[ERROR] %d file(s) could not be extracted or failed verification.\n":                                 "\n[ERROR] 有 %d 个文件无法解压或校验失败。\n",
+	"\n[OK] Extracted and verified %d file(s) into %s\n":                                                    "\n[OK] 已解压并校验 %d 个文件到 %s\n",
+	"[TIP] Open the folder with Unity %s; the first import rebuilds Library/.\n":                            "[TIP] 请用 Unity %s 打开该文件夹；首次导入会重建 Library/。\n",
+	"Progress: %d/%d (%.0f%%)\n":                                                                            "进度: %d/%d (%.0f%%)\n",
+
+	"\nPress Enter to continue...": "\n按回车键继续...",
+	"[ERROR] The archive's project name %q is not a plain folder name; pass the destination folder after the zip\n": "[ERROR] 归档中的项目名 %q 不是单纯的文件夹名；请在 zip 之后指定目标文件夹\n",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode, dryRun, noGit, force bool
+	var excludeFlag string
+
+	flag.BoolVar(&noGit, "no-git", false, "Leave out the .git folder (history, LFS objects)")
+	flag.StringVar(&excludeFlag, "exclude", "", "Comma-separated globs to leave out as well (e.g. \"Assets/Art/Source/**,*.psd\")")
+	flag.BoolVar(&force, "force", false, "extract: allow a destination folder that is not empty")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no confirmation prompts)")
+	flag.BoolVar(&dryRun, "dry-run", false, "List what would be archived or extracted without writing anything")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the output path ("../MyGame.zip -no-git")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_project_archive")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+
+	command := "create"
+	if len(args) > 0 && (args[0] == "create" || args[0] == "verify" || args[0] == "extract") {
+		command = args[0]
+		args = args[1:]
+	}
+	if len(args) < 1 || len(args) > 2 || (len(args) == 2 && command != "extract") {
+		fmt.Println(tr("Usage: unity_project_archive [flags] [create] <out.zip>"))
+		fmt.Println(tr("       unity_project_archive [flags] verify <archive.zip>"))
+		fmt.Println(tr("       unity_project_archive [flags] extract <archive.zip> [folder]"))
+		recordError("expected a zip path")
+		exit(2)
+	}
+
+	// verify and extract work on the zip alone and can run from any folder
+	switch command {
+	case "verify":
+		exit(verifyCommand(args[0]))
+	case "extract":
+		dest := ""
+		if len(args) == 2 {
+			dest = args[1]
+		}
+		exit(extractCommand(args[0], dest, ciMode, dryRun, force))
+	}
+
+	outPath, err := filepath.Abs(args[0])
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(2)
+	}
+	excludes, err := compileGlobs(excludeFlag)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(2)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	// Unity holds this file while the project is open; assets may be half-saved
+	if _, err := os.Stat(filepath.Join(basePath, "Temp", "UnityLockfile")); err == nil {
+		fmt.Println(tr("[WARNING] The project seems to be open in Unity; save and close it for a consistent archive."))
+	}
+
+	files, skipped, err := collectFiles(basePath, !noGit, excludes, outPath)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot scan the project: %v\n"), err)
+		recordError("cannot scan the project: %v", err)
+		exit(1)
+	}
+	for _, s := range skipped {
+		fmt.Printf(tr("[WARNING] Skipped: %s\n"), s)
+	}
+
+	// Size per top-level entry, for the preview
+	var total int64
+	sizes := make(map[string]int64)
+	counts := make(map[string]int)
+	for _, f := range files {
+		top := strings.SplitN(f.rel, "/", 2)[0]
+		sizes[top] += f.info.Size()
+		counts[top]++
+		total += f.info.Size()
+	}
+	tops := make([]string, 0, len(sizes))
+	for top := range sizes {
+		tops = append(tops, top)
+	}
+	sort.Strings(tops)
+
+	printRule("=============================================")
+	fmt.Println(tr("  PROJECT ARCHIVE"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:  %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Unity:    %s\n"), readUnityVersion(basePath))
+	fmt.Printf(tr("  Output:   %s\n"), outPath)
+	fmt.Println()
+	for _, top := range tops {
+		fmt.Printf("  %-28s %6d  %10s\n", top, counts[top], formatSize(sizes[top]))
+	}
+	fmt.Printf(tr("\n  %d file(s), %s uncompressed\n"), len(files), formatSize(total))
+	if !noGit {
+		if _, err := os.Stat(filepath.Join(basePath, ".git")); err == nil {
+			fmt.Println(tr("  [TIP] .git is included; use -no-git to share the working copy only."))
+		}
+	}
+
+	if len(files) == 0 {
+		fmt.Println(tr("\n[--] Nothing to archive."))
+		exit(0)
+	}
+	if dryRun {
+		for _, f := range files {
+			recordAction("archive", f.rel, "planned", formatSize(f.info.Size()), 0)
+		}
+		fmt.Println(tr("\n[Dry Run] No archive was written."))
+		exit(0)
+	}
+	if _, err := os.Stat(outPath); err == nil && !ciMode {
+		fmt.Printf(tr("\n%s exists. Overwrite? (y/N): "), filepath.Base(outPath))
+		confirm, _ := stdinReader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+			fmt.Println(tr("Operation cancelled."))
+			exit(0)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		fmt.Printf(tr("[ERROR] Cannot create %s: %v\n"), filepath.Dir(outPath), err)
+		recordError("cannot create %s: %v", filepath.Dir(outPath), err)
+		exit(1)
+	}
+
+	fmt.Println()
+	start := time.Now()
+	host, _ := os.Hostname()
+	manifest := &archiveManifest{
+		Format:       archiveFormatVersion,
+		Project:      filepath.Base(basePath),
+		UnityVersion: readUnityVersion(basePath),
+		CreatedAt:    time.Now().Format(time.RFC3339),
+		Host:         host,
+		IncludesGit:  !noGit,
+		Files:        []archivedFile{},
+	}
+	if err := writeArchive(outPath, files, manifest); err != nil {
+		fmt.Printf(tr("\n[ERROR] Cannot write archive: %v\n"), err)
+		recordError("cannot write archive: %v", err)
+		exit(1)
+	}
+	info, _ := os.Stat(outPath)
+	var size int64
+	if info != nil {
+		size = info.Size()
+	}
+	fmt.Printf(tr("\n[OK] Archived %d file(s) into %s (%s, %.1fs)\n"), len(manifest.Files), outPath, formatSize(size), time.Since(start).Seconds())
+	recordAction("archive", outPath, "ok", fmt.Sprintf("%d files, %s", len(manifest.Files), formatSize(size)), time.Since(start))
+	recordArtifact(outPath)
+	exit(0)
+}
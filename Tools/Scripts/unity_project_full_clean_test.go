@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// TestCleanGolden cleans testdata/unity_project_full_clean, which has every kind
+// of generated folder, a kept Build/Tools and an FMOD cache, and compares what is
+// left to testdata/unity_project_full_clean.golden
+func TestCleanGolden(t *testing.T) {
+	dir := copyFixture(t, "unity_project_full_clean")
+	runCleanTest(t, dir, cleanOptions{middleware: "fmod"})
+	// The history records sizes and durations of this run
+	assertGoldenTree(t, dir, "testdata/unity_project_full_clean.golden", "UserSettings/UnityStarter/")
+}
+
+// TestCleanDryRunGolden checks that a dry run leaves the fixture untouched
+func TestCleanDryRunGolden(t *testing.T) {
+	dir := copyFixture(t, "unity_project_full_clean")
+	runCleanTest(t, dir, cleanOptions{dryRun: true, middleware: "fmod"})
+	assertGoldenTree(t, dir, "testdata/unity_project_full_clean_dry_run.golden")
+}
+
+// runCleanTest runs a clean the way CI does (no prompts, throttling or webhooks)
+// with a runner that fails the test if a program is started
+func runCleanTest(t *testing.T, dir string, opts cleanOptions) {
+	t.Helper()
+	setupRun(t, "")
+	savedRunner := runner
+	t.Cleanup(func() { runner = savedRunner })
+	runner = noCommands{t}
+
+	opts.ci, opts.throttle, opts.noNotify = true, "off", true
+	if code := runClean(dir, opts); code != 0 {
+		t.Fatalf("runClean = %d, want 0", code)
+	}
+}
+
+// noCommands is a runner for tests of runs that start no programs
+type noCommands struct{ t *testing.T }
+
+func (r noCommands) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	r.t.Errorf("unexpected command: %s", commandLine(name, args))
+	return nil, nil, errors.New("no programs are started in tests")
+}
+
+// TestProgressBoardConcurrentClose closes boards from several goroutines at once;
+// run with -race. A second close(b.stop) would panic.
+func TestProgressBoardConcurrentClose(t *testing.T) {
+	for n := 0; n < 200; n++ {
+		board := newProgressBoard()
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				board.close()
+			}()
+		}
+		close(start)
+		wg.Wait()
+		board.close()
+	}
+}
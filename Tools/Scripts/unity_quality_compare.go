@@ -0,0 +1,1124 @@
+// Unity Quality Compare — Compare quality tiers and their render pipeline assets, and check them against a platform policy.
+// Reads ProjectSettings/QualitySettings.asset and the URP asset each quality
+// level uses (its own, else the default one in GraphicsSettings), and puts the
+// values that matter for performance side by side, one column per tier: pixel
+// lights, shadows, anti-aliasing, texture limits and LOD of the quality level,
+// and render scale, MSAA, HDR, shadow support and resolution, additional lights
+// and the depth and opaque textures of the pipeline asset. The tiers each
+// platform builds with (those not excluded for it) are then checked against
+// QualityPolicy.json, or against a built-in mobile policy when there is none
+// (no shadows and at most 2x MSAA on the lowest Android and iOS tier, no render
+// scale above 1 on any of them), and every tier that diverges is reported. -out writes the table as Markdown
+// or CSV.
+//
+// Build: go build unity_quality_compare.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_quality_compare                               # table and policy check
+//	unity_quality_compare -policy Docs/QualityPolicy.json
+//	unity_quality_compare -out Docs/Quality.md          # table for the docs (.md or .csv)
+//	unity_quality_compare -ci -json                     # CI: exit 1 when a tier breaks the policy
+//
+// QualityPolicy.json maps a platform (as in "Default quality per platform":
+// Android, iPhone, Standalone, WebGL...) to rules per tier. A tier is named, or
+// "lowest", "highest" or "*" for every tier the platform builds with. A rule
+// maps a setting key of the table to "on", "off", a value, or a comparison:
+//
+//	{"platforms": {"Android": {"lowest": {"shadows": "off", "msaa": "<=2"},
+//	                           "*": {"renderScale": "<=1"}}}}
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const defaultPolicyFile = "QualityPolicy.json" // in the project root
+
+// Tier selectors of a policy besides tier names
+const (
+	tierLowest  = "lowest"
+	tierHighest = "highest"
+	tierAll     = "*"
+)
+
+// Labels of enum values, shared by several settings
+var (
+	onOff       = map[string]string{"0": "off", "1": "on"}
+	msaaLabels  = map[string]string{"0": "off", "1": "off", "2": "2x", "4": "4x", "8": "8x"}
+	shadowModes = map[string]string{"0": "off", "1": "hard only", "2": "hard and soft"}
+)
+
+// Rows of the comparison table. key is the name rules use in QualityPolicy.json;
+// field is the QualitySettings field of a tier, or the field of the pipeline
+// asset for pipeline rows.
+var settingRows = []settingRow{
+	{key: "shadows", label: "Shadows (effective)", labels: onOff},
+	{key: "pixelLights", label: "Pixel lights", field: "pixelLightCount"},
+	{key: "qualityShadows", label: "Shadows", field: "shadows", labels: shadowModes},
+	{key: "shadowResolution", label: "Shadow resolution", field: "shadowResolution", labels: map[string]string{"0": "low", "1": "medium", "2": "high", "3": "very high"}},
+	{key: "shadowDistance", label: "Shadow distance", field: "shadowDistance"},
+	{key: "antiAliasing", label: "Anti-aliasing", field: "antiAliasing", labels: msaaLabels},
+	{key: "anisotropic", label: "Anisotropic textures", field: "anisotropicTextures", labels: map[string]string{"0": "off", "1": "per texture", "2": "forced on"}},
+	{key: "textureLimit", label: "Texture mipmap limit", field: "globalTextureMipmapLimit", labels: map[string]string{"0": "full", "1": "half", "2": "quarter", "3": "eighth"}},
+	{key: "vSync", label: "VSync", field: "vSyncCount", labels: map[string]string{"0": "off", "1": "every V blank", "2": "every second V blank"}},
+	{key: "lodBias", label: "LOD bias", field: "lodBias"},
+	{key: "maxLOD", label: "Maximum LOD level", field: "maximumLODLevel"},
+	{key: "skinWeights", label: "Skin weights", field: "skinWeights", labels: map[string]string{"1": "1 bone", "2": "2 bones", "4": "4 bones", "255": "unlimited"}},
+	{key: "softParticles", label: "Soft particles", field: "softParticles", labels: onOff},
+	{key: "realtimeReflections", label: "Realtime reflection probes", field: "realtimeReflectionProbes", labels: onOff},
+	{key: "textureStreaming", label: "Texture streaming", field: "streamingMipmapsActive", labels: onOff},
+
+	{key: "pipeline", label: "Pipeline asset", pipeline: true},
+	{key: "renderScale", label: "Render scale", field: "m_RenderScale", pipeline: true},
+	{key: "upscaling", label: "Upscaling filter", field: "m_UpscalingFilter", pipeline: true, labels: map[string]string{"0": "auto", "1": "bilinear", "2": "point", "3": "FSR", "4": "STP"}},
+	{key: "msaa", label: "MSAA", field: "m_MSAA", pipeline: true, labels: msaaLabels},
+	{key: "hdr", label: "HDR", field: "m_SupportsHDR", pipeline: true, labels: onOff},
+	{key: "mainLightShadows", label: "Main light shadows", field: "m_MainLightShadowsSupported", pipeline: true, labels: onOff},
+	{key: "mainShadowResolution", label: "Main light shadowmap", field: "m_MainLightShadowmapResolution", pipeline: true},
+	{key: "additionalLights", label: "Additional lights", field: "m_AdditionalLightsRenderingMode", pipeline: true, labels: map[string]string{"0": "off", "1": "per vertex", "2": "per pixel"}},
+	{key: "additionalLightsPerObject", label: "Additional lights per object", field: "m_AdditionalLightsPerObjectLimit", pipeline: true},
+	{key: "additionalLightShadows", label: "Additional light shadows", field: "m_AdditionalLightShadowsSupported", pipeline: true, labels: onOff},
+	{key: "pipelineShadowDistance", label: "Pipeline shadow distance", field: "m_ShadowDistance", pipeline: true},
+	{key: "shadowCascades", label: "Shadow cascades", field: "m_ShadowCascadeCount", pipeline: true},
+	{key: "softShadows", label: "Soft shadows", field: "m_SoftShadowsSupported", pipeline: true, labels: onOff},
+	{key: "depthTexture", label: "Depth texture", field: "m_RequireDepthTexture", pipeline: true, labels: onOff},
+	{key: "opaqueTexture", label: "Opaque texture", field: "m_RequireOpaqueTexture", pipeline: true, labels: onOff},
+	{key: "srpBatcher", label: "SRP Batcher", field: "m_UseSRPBatcher", pipeline: true, labels: onOff},
+	{key: "dynamicBatching", label: "Dynamic batching", field: "m_SupportsDynamicBatching", pipeline: true, labels: onOff},
+}
+
+// Policy used when the project has no QualityPolicy.json: the lowest tier of a
+// phone should not pay for shadows or heavy MSAA, and no phone tier should render
+// above native resolution
+var builtinPolicy = policyFile{Platforms: map[string]map[string]map[string]string{
+	"Android": {
+		tierLowest: {"shadows": "off", "msaa": "<=2", "softShadows": "off"},
+		tierAll:    {"renderScale": "<=1"},
+	},
+	"iPhone": {
+		tierLowest: {"shadows": "off", "msaa": "<=2", "softShadows": "off"},
+		tierAll:    {"renderScale": "<=1"},
+	},
+}}
+
+var (
+	metaGUIDRegex = regexp.MustCompile(`(?m)^guid: ([0-9a-fA-F]{32})`)
+	refRegex      = regexp.MustCompile(`fileID: (-?\d+)(?:, guid: ([0-9a-fA-F]{32}))?`)
+	ruleRegex     = regexp.MustCompile(`^(<=|>=|==|!=|<|>)\s*(-?[0-9.]+)$`)
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+type settingRow struct {
+	key      string
+	label    string
+	field    string
+	pipeline bool
+	labels   map[string]string
+}
+
+// tier is one quality level and the values of its table column
+type tier struct {
+	index    int
+	name     string
+	fields   map[string]string // QualitySettings fields of the level
+	excluded []string          // platforms that do not build with it
+	pipeline string            // pipeline asset path, "" for the built-in renderer
+	values   map[string]string // raw value per row key, "" when not known
+}
+
+// policyFile is QualityPolicy.json: platform -> tier selector -> setting key -> rule
+type policyFile struct {
+	Platforms map[string]map[string]map[string]string `json:"platforms"`
+}
+
+// violation is one setting of one tier that breaks the policy
+type violation struct {
+	platform string
+	tier     *tier
+	row      settingRow
+	rule     string
+}
+
+// ============================================================
+// Project Settings
+// ============================================================
+
+// Folders indexed for GUIDs, so a pipeline asset inside a package is found
+var indexRoots = []string{"Assets", "Packages", filepath.Join("Library", "PackageCache")}
+
+// indexGUIDs maps the GUID of every asset in the project and its packages to its path
+func indexGUIDs(basePath string) map[string]string {
+	guids := make(map[string]string)
+	for _, root := range indexRoots {
+		filepath.Walk(filepath.Join(basePath, root), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if strings.HasPrefix(info.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(info.Name(), ".meta") {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			if m := metaGUIDRegex.FindSubmatch(data); m != nil {
+				guids[strings.ToLower(string(m[1]))] = relPath(basePath, strings.TrimSuffix(path, ".meta"))
+			}
+			return nil
+		})
+	}
+	return guids
+}
+
+// fieldLine splits "    key: value" at the given indent; ok is false for any
+// other line, list items included
+func fieldLine(line string, indent int) (key, value string, ok bool) {
+	if len(line) <= indent || strings.TrimLeft(line[:indent], " ") != "" || line[indent] == ' ' || line[indent] == '-' {
+		return "", "", false
+	}
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return "", "", false
+	}
+	return line[indent:i], strings.TrimSpace(line[i+1:]), true
+}
+
+// readFields reads the fields at one indent of a block of lines. Unity folds
+// long values onto indented continuation lines
+// ("customRenderPipeline: {fileID: 11400000, guid: ...,\n      type: 2}").
+func readFields(lines []string, indent int) map[string]string {
+	fields := make(map[string]string)
+	last := ""
+	for _, l := range lines {
+		l = strings.TrimRight(l, "\r")
+		if key, value, ok := fieldLine(l, indent); ok {
+			fields[key] = yamlUnquote(value)
+			last = key
+			continue
+		}
+		t := strings.TrimSpace(l)
+		if last != "" && strings.HasPrefix(l, strings.Repeat(" ", indent+1)) && !strings.HasPrefix(t, "- ") && strings.HasPrefix(fields[last], "{") && !strings.HasSuffix(fields[last], "}") {
+			fields[last] += " " + t
+		}
+	}
+	return fields
+}
+
+// readQuality reads the quality levels and the default level of each platform
+func readQuality(basePath string) ([]*tier, map[string]int, error) {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "QualitySettings.asset"))
+	if err != nil {
+		return nil, nil, err
+	}
+	var tiers []*tier
+	var block []string
+	defaults := make(map[string]int)
+	section := ""
+	finish := func() {
+		if block == nil {
+			return
+		}
+		t := &tier{index: len(tiers), fields: readFields(block, 4)}
+		t.name = t.fields["name"]
+		in := false
+		for _, l := range block {
+			l = strings.TrimRight(l, "\r")
+			if _, _, ok := fieldLine(l, 4); ok {
+				in = strings.HasPrefix(l, "    excludedTargetPlatforms:")
+				continue
+			}
+			if in && strings.HasPrefix(l, "    - ") {
+				t.excluded = append(t.excluded, yamlUnquote(strings.TrimSpace(l[6:])))
+			}
+		}
+		tiers = append(tiers, t)
+		block = nil
+	}
+	for _, l := range strings.Split(string(data), "\n") {
+		l = strings.TrimRight(l, "\r")
+		if key, _, ok := fieldLine(l, 2); ok {
+			finish()
+			section = key
+			continue
+		}
+		switch section {
+		case "m_QualitySettings":
+			if strings.HasPrefix(l, "  - ") {
+				finish()
+				block = []string{"    " + l[4:]}
+				continue
+			}
+			if block != nil {
+				block = append(block, l)
+			}
+		case "m_PerPlatformDefaultQuality":
+			if key, value, ok := fieldLine(l, 4); ok {
+				if n, err := strconv.Atoi(value); err == nil {
+					defaults[yamlUnquote(key)] = n
+				}
+			}
+		}
+	}
+	finish()
+	if len(tiers) == 0 {
+		return nil, nil, fmt.Errorf("QualitySettings.asset has no quality levels")
+	}
+	return tiers, defaults, nil
+}
+
+func yamlUnquote(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// assetRef returns the GUID of an object reference, "" when it is empty
+func assetRef(value string) string {
+	if m := refRegex.FindStringSubmatch(value); m != nil && m[1] != "0" {
+		return strings.ToLower(m[2])
+	}
+	return ""
+}
+
+// defaultPipeline returns the GUID of the render pipeline asset in GraphicsSettings
+func defaultPipeline(basePath string) string {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "GraphicsSettings.asset"))
+	if err != nil {
+		return ""
+	}
+	return assetRef(readFields(strings.Split(string(data), "\n"), 2)["m_CustomRenderPipeline"])
+}
+
+// readPipeline reads the top-level fields of a render pipeline asset
+func readPipeline(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return readFields(strings.Split(string(data), "\n"), 2), nil
+}
+
+// fill works out the value of every row for a tier
+func (t *tier) fill(pipeline map[string]string) {
+	t.values = make(map[string]string)
+	for _, r := range settingRows {
+		switch {
+		case r.key == "pipeline":
+			if t.pipeline != "" {
+				t.values[r.key] = strings.TrimSuffix(filepath.Base(t.pipeline), filepath.Ext(t.pipeline))
+			}
+		case r.pipeline:
+			t.values[r.key] = pipeline[r.field]
+		case r.field != "":
+			t.values[r.key] = t.fields[r.field]
+		}
+	}
+	// With a pipeline asset its shadow support decides, else the quality level's
+	shadows := t.values["qualityShadows"] != "" && t.values["qualityShadows"] != "0"
+	if t.pipeline != "" && t.values["mainLightShadows"] != "" {
+		shadows = t.values["mainLightShadows"] == "1" || t.values["additionalLightShadows"] == "1"
+	}
+	t.values["shadows"] = "0"
+	if shadows {
+		t.values["shadows"] = "1"
+	}
+}
+
+// display shows a raw value with the row's label for it
+func (r settingRow) display(raw string) string {
+	if raw == "" {
+		return "-"
+	}
+	if l, ok := r.labels[raw]; ok {
+		return l
+	}
+	return raw
+}
+
+// includes reports whether a platform builds with the tier
+func (t *tier) includes(platform string) bool {
+	for _, p := range t.excluded {
+		if strings.EqualFold(p, platform) {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Policy
+// ============================================================
+
+// loadPolicy reads QualityPolicy.json and checks its setting keys and rules
+func loadPolicy(p string) (*policyFile, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var policy policyFile
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", filepath.Base(p), err)
+	}
+	if len(policy.Platforms) == 0 {
+		return nil, fmt.Errorf("%s defines no platforms", filepath.Base(p))
+	}
+	keys := make(map[string]bool)
+	for _, r := range settingRows {
+		keys[r.key] = true
+	}
+	for platform, selectors := range policy.Platforms {
+		for selector, rules := range selectors {
+			for key, rule := range rules {
+				if !keys[key] {
+					return nil, fmt.Errorf("%s / %s: unknown setting '%s'", platform, selector, key)
+				}
+				if strings.TrimSpace(rule) == "" {
+					return nil, fmt.Errorf("%s / %s: empty rule for %s", platform, selector, key)
+				}
+			}
+		}
+	}
+	return &policy, nil
+}
+
+// selectTiers resolves a tier selector among the tiers a platform builds with
+func selectTiers(selector string, tiers []*tier) ([]*tier, bool) {
+	if len(tiers) == 0 {
+		return nil, true
+	}
+	switch strings.ToLower(selector) {
+	case tierAll:
+		return tiers, true
+	case tierLowest:
+		return tiers[:1], true
+	case tierHighest:
+		return tiers[len(tiers)-1:], true
+	}
+	for _, t := range tiers {
+		if strings.EqualFold(t.name, selector) {
+			return []*tier{t}, true
+		}
+	}
+	return nil, false
+}
+
+// matches checks a raw value against a rule: on, off, a comparison or a value.
+// A value that is not known never breaks a rule.
+func (r settingRow) matches(rule, raw string) bool {
+	if raw == "" {
+		return true
+	}
+	rule = strings.TrimSpace(rule)
+	off := raw == "0" || r.labels[raw] == "off"
+	switch strings.ToLower(rule) {
+	case "on", "true":
+		return !off
+	case "off", "false":
+		return off
+	}
+	if m := ruleRegex.FindStringSubmatch(rule); m != nil {
+		v, err1 := strconv.ParseFloat(raw, 64)
+		limit, err2 := strconv.ParseFloat(m[2], 64)
+		if err1 != nil || err2 != nil {
+			return true
+		}
+		switch m[1] {
+		case "<=":
+			return v <= limit
+		case ">=":
+			return v >= limit
+		case "<":
+			return v < limit
+		case ">":
+			return v > limit
+		case "==":
+			return v == limit
+		case "!=":
+			return v != limit
+		}
+	}
+	return strings.EqualFold(rule, raw) || strings.EqualFold(rule, r.display(raw))
+}
+
+// checkPolicy lists the settings of every tier a platform builds with that break its rules
+func checkPolicy(policy *policyFile, tiers []*tier) ([]violation, error) {
+	rows := make(map[string]settingRow)
+	for _, r := range settingRows {
+		rows[r.key] = r
+	}
+	platforms := make([]string, 0, len(policy.Platforms))
+	for p := range policy.Platforms {
+		platforms = append(platforms, p)
+	}
+	sort.Strings(platforms)
+
+	var found []violation
+	for _, platform := range platforms {
+		var included []*tier
+		for _, t := range tiers {
+			if t.includes(platform) {
+				included = append(included, t)
+			}
+		}
+		selectors := make([]string, 0, len(policy.Platforms[platform]))
+		for s := range policy.Platforms[platform] {
+			selectors = append(selectors, s)
+		}
+		sort.Strings(selectors)
+		seen := make(map[string]bool)
+		for _, selector := range selectors {
+			selected, ok := selectTiers(selector, included)
+			if !ok {
+				return nil, fmt.Errorf("%s: no quality level named '%s' (levels: %s)", platform, selector, tierNames(tiers))
+			}
+			keys := make([]string, 0, len(policy.Platforms[platform][selector]))
+			for k := range policy.Platforms[platform][selector] {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, t := range selected {
+				for _, key := range keys {
+					rule := policy.Platforms[platform][selector][key]
+					id := fmt.Sprintf("%d/%s", t.index, key)
+					if seen[id] || rows[key].matches(rule, t.values[key]) {
+						continue
+					}
+					seen[id] = true
+					found = append(found, violation{platform: platform, tier: t, row: rows[key], rule: rule})
+				}
+			}
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].platform != found[j].platform {
+			return found[i].platform < found[j].platform
+		}
+		return found[i].tier.index < found[j].tier.index
+	})
+	return found, nil
+}
+
+func tierNames(tiers []*tier) string {
+	names := make([]string, len(tiers))
+	for i, t := range tiers {
+		names[i] = t.name
+	}
+	return strings.Join(names, ", ")
+}
+
+const violationFormat = "%s, tier \"%s\": %s is %s, the policy wants %s"
+
+// message describes the violation in English, or translated for the console
+func (v violation) message(translate bool) string {
+	format, label := violationFormat, v.row.label
+	if translate {
+		format, label = tr(format), tr(label)
+	}
+	return fmt.Sprintf(format, v.platform, v.tier.name, label, v.row.display(v.tier.values[v.row.key]), v.rule)
+}
+
+// ============================================================
+// Table
+// ============================================================
+
+// tableRows returns the rows to show: pipeline rows only when a tier has a pipeline asset
+func tableRows(tiers []*tier) []settingRow {
+	hasPipeline := false
+	for _, t := range tiers {
+		hasPipeline = hasPipeline || t.pipeline != ""
+	}
+	var rows []settingRow
+	for _, r := range settingRows {
+		if r.pipeline && !hasPipeline {
+			continue
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// printTable prints the settings side by side, marking the values that differ between tiers
+func printTable(tiers []*tier, violations []violation) {
+	bad := make(map[string]bool)
+	for _, v := range violations {
+		bad[fmt.Sprintf("%d/%s", v.tier.index, v.row.key)] = true
+	}
+	rows := tableRows(tiers)
+	labelWidth := 0
+	for _, r := range rows {
+		if n := len([]rune(tr(r.label))); n > labelWidth {
+			labelWidth = n
+		}
+	}
+	widths := make([]int, len(tiers))
+	for i, t := range tiers {
+		widths[i] = len([]rune(t.name))
+		for _, r := range rows {
+			if n := len([]rune(r.display(t.values[r.key]))) + 1; n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "  %-*s", labelWidth, "")
+	for i, t := range tiers {
+		fmt.Fprintf(&b, "  %-*s", widths[i], t.name)
+	}
+	fmt.Printf("\n%s\n", strings.TrimRight(b.String(), " "))
+	for _, r := range rows {
+		if r.key == "pipeline" {
+			fmt.Println()
+		}
+		b.Reset()
+		fmt.Fprintf(&b, "  %-*s", labelWidth, tr(r.label))
+		for i, t := range tiers {
+			cell := r.display(t.values[r.key])
+			if bad[fmt.Sprintf("%d/%s", t.index, r.key)] {
+				cell += "!"
+			}
+			fmt.Fprintf(&b, "  %-*s", widths[i], cell)
+		}
+		fmt.Println(strings.TrimRight(b.String(), " "))
+	}
+}
+
+// mdEscape keeps "|" from splitting a Markdown table cell
+func mdEscape(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// writeTable writes the table as CSV for a .csv path, else as Markdown with the violations
+func writeTable(path string, tiers []*tier, defaults map[string]int, violations []violation) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	rows := tableRows(tiers)
+	var buf bytes.Buffer
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		buf.WriteString("\xEF\xBB\xBF")
+		w := csv.NewWriter(&buf)
+		header := []string{"key", "setting"}
+		for _, t := range tiers {
+			header = append(header, t.name)
+		}
+		w.Write(header)
+		for _, r := range rows {
+			record := []string{r.key, r.label}
+			for _, t := range tiers {
+				record = append(record, r.display(t.values[r.key]))
+			}
+			w.Write(record)
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		return os.WriteFile(path, buf.Bytes(), 0644)
+	}
+
+	buf.WriteString("# Quality Tiers\n\n| Setting |")
+	for _, t := range tiers {
+		fmt.Fprintf(&buf, " %s |", mdEscape(t.name))
+	}
+	buf.WriteString("\n| --- |" + strings.Repeat(" --- |", len(tiers)) + "\n")
+	for _, r := range rows {
+		fmt.Fprintf(&buf, "| %s |", r.label)
+		for _, t := range tiers {
+			fmt.Fprintf(&buf, " %s |", mdEscape(r.display(t.values[r.key])))
+		}
+		buf.WriteString("\n")
+	}
+	if len(defaults) > 0 {
+		buf.WriteString("\n## Default Tier per Platform\n\n| Platform | Tier |\n| --- | --- |\n")
+		for _, p := range sortedPlatforms(defaults) {
+			name := "-"
+			if i := defaults[p]; i >= 0 && i < len(tiers) {
+				name = tiers[i].name
+			}
+			fmt.Fprintf(&buf, "| %s | %s |\n", p, mdEscape(name))
+		}
+	}
+	if len(violations) > 0 {
+		buf.WriteString("\n## Policy Violations\n\n")
+		for _, v := range violations {
+			fmt.Fprintf(&buf, "- %s\n", v.message(false))
+		}
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+func sortedPlatforms(defaults map[string]int) []string {
+	platforms := make([]string, 0, len(defaults))
+	for p := range defaults {
+		platforms = append(platforms, p)
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode     bool
+		policyFlag string
+		outFlag    string
+	)
+
+	flag.StringVar(&policyFlag, "policy", "", "Platform policy to check the tiers against (default: "+defaultPolicyFile+" if present, else the built-in mobile policy)")
+	flag.StringVar(&outFlag, "out", "", "Also write the table to this file: Markdown, or CSV for a .csv path")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_quality_compare")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	if flag.NArg() > 0 {
+		fmt.Println(tr("Usage: unity_quality_compare [-policy <file>] [-out <file>] [-ci] [-json]"))
+		recordError("unexpected argument '%s'", flag.Arg(0))
+		exit(2)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+
+	policy := &builtinPolicy
+	policyName := tr("built-in mobile policy")
+	policyPath := policyFlag
+	if policyPath == "" {
+		policyPath = defaultPolicyFile
+		if _, err := os.Stat(filepath.Join(basePath, policyPath)); err != nil {
+			policyPath = ""
+		}
+	}
+	if policyPath != "" {
+		abs := policyPath
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(basePath, filepath.FromSlash(policyPath))
+		}
+		if policy, err = loadPolicy(abs); err != nil {
+			fail(2, "%v", err)
+		}
+		policyName = relPath(basePath, abs)
+	}
+
+	tiers, defaults, err := readQuality(basePath)
+	if err != nil {
+		fail(2, "cannot read the quality settings: %v", err)
+	}
+	guids := indexGUIDs(basePath)
+	fallback := defaultPipeline(basePath)
+	for _, t := range tiers {
+		guid := assetRef(t.fields["customRenderPipeline"])
+		if guid == "" {
+			guid = fallback
+		}
+		var fields map[string]string
+		if guid != "" {
+			rel, ok := guids[guid]
+			if !ok {
+				fmt.Printf(tr("[WARNING] Tier \"%s\": pipeline asset %s is missing.\n"), t.name, guid)
+			} else if fields, err = readPipeline(filepath.Join(basePath, filepath.FromSlash(rel))); err != nil {
+				fmt.Printf(tr("[WARNING] %s: %v\n"), rel, err)
+			} else {
+				t.pipeline = rel
+			}
+		}
+		t.fill(fields)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  QUALITY TIERS"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project: %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Tiers:   %s\n"), tierNames(tiers))
+	fmt.Printf(tr("  Policy:  %s\n"), policyName)
+
+	violations, err := checkPolicy(policy, tiers)
+	if err != nil {
+		fail(2, "%s: %v", policyName, err)
+	}
+	printTable(tiers, violations)
+
+	if len(defaults) > 0 {
+		fmt.Println(tr("\nDEFAULT TIER PER PLATFORM"))
+		for _, p := range sortedPlatforms(defaults) {
+			name := "-"
+			if i := defaults[p]; i >= 0 && i < len(tiers) {
+				name = tiers[i].name
+			}
+			fmt.Printf("  %-20s %s\n", p, name)
+		}
+	}
+
+	for _, t := range tiers {
+		detail := t.pipeline
+		if detail == "" {
+			detail = "built-in renderer"
+		}
+		recordAction("tier", t.name, "ok", detail, 0)
+	}
+	fmt.Println()
+	if len(violations) > 0 {
+		fmt.Println(tr("POLICY VIOLATIONS"))
+		for _, v := range violations {
+			fmt.Printf("  [POLICY] %s\n", v.message(true))
+			recordAction("policy", fmt.Sprintf("%s/%s", v.platform, v.tier.name), "failed", v.message(false), 0)
+			recordError("%s", v.message(false))
+		}
+		fmt.Printf(tr("\n[ERROR] %d setting(s) break the policy (marked ! in the table).\n"), len(violations))
+	} else {
+		fmt.Println(tr("[OK] Every tier follows the policy."))
+	}
+
+	if outFlag != "" {
+		out := outFlag
+		if !filepath.IsAbs(out) {
+			out = filepath.Join(basePath, filepath.FromSlash(out))
+		}
+		if err := writeTable(out, tiers, defaults, violations); err != nil {
+			fail(1, "cannot write %s: %v", outFlag, err)
+		}
+		recordArtifact(out)
+		fmt.Printf(tr("[OK] Wrote %s\n"), relPath(basePath, out))
+	}
+	for _, t := range tiers {
+		if t.pipeline != "" {
+			fmt.Println(tr("[TIP] With a render pipeline asset, the pipeline rows decide shadows and MSAA; the Quality Settings rows above them are ignored for those."))
+			break
+		}
+	}
+	if len(violations) > 0 {
+		exit(1)
+	}
+	exit(0)
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+// relPath shows a path relative to the project root, with forward slashes
+func relPath(basePath, p string) string {
+	if rel, err := filepath.Rel(basePath, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return p
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...": "\n按回车键继续...",
+	"[ERROR] %v\n":                 "[ERROR] %v\n",
+	"Usage: unity_quality_compare [-policy <file>] [-out <file>] [-ci] [-json]": "用法: unity_quality_compare [-policy <文件>] [-out <文件>] [-ci] [-json]",
+	"[ERROR] Cannot get current directory: %v\n":                                "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.":          "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                    "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"built-in mobile policy":                                                    "内置移动平台策略",
+	"[WARNING] Tier \"%s\": pipeline asset %s is missing.\n":                    "[WARNING] 档位 \"%s\": 渲染管线资源 %s 不存在。\n",
+	"[WARNING] %s: %v\n":          "[WARNING] %s: %v\n",
+	"  QUALITY TIERS":             "  画质档位",
+	"  Project: %s\n":             "  项目: %s\n",
+	"  Tiers:   %s\n":             "  档位: %s\n",
+	"  Policy:  %s\n":             "  策略: %s\n",
+	"\nDEFAULT TIER PER PLATFORM": "\n各平台默认档位",
+	"POLICY VIOLATIONS":           "违反策略",
+	"\n[ERROR] %d setting(s) break the policy (marked ! in the table).\n": "\n[ERROR] %d 项设置违反策略（表中以 ! 标记）。\n",
+	"[OK] Every tier follows the policy.":                                 "[OK] 所有档位都符合策略。",
+	"[OK] Wrote %s\n":                                                     "[OK] 已写入 %s\n",
+	"[TIP] With a render pipeline asset, the pipeline rows decide shadows and MSAA; the Quality Settings rows above them are ignored for those.": "[TIP] 使用渲染管线资源时，阴影和 MSAA 由管线各行决定，上方 Quality Settings 中的对应行不生效。",
+	"%s, tier \"%s\": %s is %s, the policy wants %s": "%s，档位 \"%s\": %s 为 %s，策略要求 %s",
+	"Shadows (effective)":                            "阴影（实际）",
+	"Pixel lights":                                   "像素光数量",
+	"Shadows":                                        "阴影",
+	"Shadow resolution":                              "阴影分辨率",
+	"Shadow distance":                                "阴影距离",
+	"Anti-aliasing":                                  "抗锯齿",
+	"Anisotropic textures":                           "各向异性纹理",
+	"Texture mipmap limit":                           "纹理 Mipmap 限制",
+	"VSync":                                          "垂直同步",
+	"LOD bias":                                       "LOD 偏移",
+	"Maximum LOD level":                              "最大 LOD 级别",
+	"Skin weights":                                   "蒙皮权重",
+	"Soft particles":                                 "软粒子",
+	"Realtime reflection probes":                     "实时反射探针",
+	"Texture streaming":                              "纹理流送",
+	"Pipeline asset":                                 "管线资源",
+	"Render scale":                                   "渲染缩放",
+	"Upscaling filter":                               "放大滤波",
+	"MSAA":                                           "MSAA",
+	"HDR":                                            "HDR",
+	"Main light shadows":                             "主光源阴影",
+	"Main light shadowmap":                           "主光源阴影贴图",
+	"Additional lights":                              "附加光源",
+	"Additional lights per object":                   "每物体附加光源数",
+	"Additional light shadows":                       "附加光源阴影",
+	"Pipeline shadow distance":                       "管线阴影距离",
+	"Shadow cascades":                                "阴影级联",
+	"Soft shadows":                                   "软阴影",
+	"Depth texture":                                  "深度纹理",
+	"Opaque texture":                                 "不透明纹理",
+	"SRP Batcher":                                    "SRP Batcher",
+	"Dynamic batching":                               "动态合批",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
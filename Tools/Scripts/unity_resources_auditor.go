@@ -0,0 +1,1020 @@
+// Unity Resources Auditor — Check Resources.Load calls against the Resources folders.
+// Everything under a Resources folder ships in every build whether code still loads
+// it or not, and a load by a wrong path only fails at run time. This tool maps the
+// Resources.Load, LoadAsync and LoadAll calls in the project's scripts to the files
+// in its Resources folders and reports loads that find nothing, assets no call loads
+// by name and paths two Resources folders both provide. With -plan it writes an
+// AddressablesRules.json for unity_addressables_editor that makes the Resources
+// assets addressable under their old load paths, and lists the calls to change.
+//
+// Build: go build unity_resources_auditor.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_resources_auditor                          # audit loads and Resources assets
+//	unity_resources_auditor -annotate github -ci     # dead loads as CI annotations
+//	unity_resources_auditor -plan                    # also write AddressablesRules.json
+//	unity_resources_auditor -plan -group Migrated    # put the migrated assets in a group
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const defaultPlanFile = "AddressablesRules.json" // read by unity_addressables_editor apply
+
+// Resources.Load("a"), Load<T>("a"), LoadAsync(...), LoadAll(...); the argument
+// is parsed by hand from the first character after the parenthesis
+var resourcesCallRegex = regexp.MustCompile(`\bResources\s*\.\s*(Load|LoadAsync|LoadAll)\s*(<\s*([\w.]+)\s*>)?\s*\(`)
+
+// const/static readonly string fields, so Load(Paths.Icon) resolves
+var stringConstRegex = regexp.MustCompile(`\b(?:const|static\s+readonly)\s+string\s+(\w+)\s*=\s*@?"((?:[^"\\]|\\.)*)"\s*;`)
+
+var identifierRegex = regexp.MustCompile(`^(?:[A-Za-z_]\w*\.)*([A-Za-z_]\w*)$`)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// resourceAsset is one file under a Resources folder
+type resourceAsset struct {
+	file    string // project-relative
+	folder  string // the Resources folder it is in
+	name    string // load path: relative to the folder, without extension
+	size    int64
+	editor  bool // inside an Editor folder: editor-only, not in builds
+	loaded  bool
+	dynamic bool // only a dynamic load's prefix covers it
+}
+
+// loadCall is one Resources call in a script
+type loadCall struct {
+	file    string
+	line    int
+	method  string // Load, LoadAsync, LoadAll
+	typ     string // generic argument, "" if none
+	path    string // literal path, or the literal prefix of a dynamic one
+	dynamic bool   // the path is built at run time
+	text    string // the call as written, for the report
+	matches []*resourceAsset
+}
+
+// planRule is one entry of the AddressablesRules.json the migration plan writes;
+// fields as unity_addressables_editor reads them
+type planRule struct {
+	Paths   []string `json:"paths"`
+	Exclude []string `json:"exclude,omitempty"`
+	Group   string   `json:"group,omitempty"`
+	Address string   `json:"address"`
+	Add     bool     `json:"add"`
+}
+
+type planFile struct {
+	Rules []*planRule `json:"rules"`
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Project Scan
+// ============================================================
+
+// scanRoots returns Assets/ and the embedded packages: folders under Packages/
+// with a package.json
+func scanRoots(basePath string) []string {
+	roots := []string{"Assets"}
+	entries, _ := os.ReadDir(filepath.Join(basePath, "Packages"))
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(basePath, "Packages", e.Name(), "package.json")); err == nil {
+			roots = append(roots, "Packages/"+e.Name())
+		}
+	}
+	return roots
+}
+
+// inEditorFolder reports whether a path has an Editor folder above it
+func inEditorFolder(rel string) bool {
+	for _, seg := range strings.Split(path.Dir(rel), "/") {
+		if seg == "Editor" {
+			return true
+		}
+	}
+	return false
+}
+
+// scanProject lists the Resources assets and the scripts. Folders starting
+// with "." or ending with "~" are hidden from Unity and skipped, as Unity skips them.
+func scanProject(basePath string) ([]*resourceAsset, []string) {
+	var assets []*resourceAsset
+	var scripts []string
+	for _, root := range scanRoots(basePath) {
+		rootPath := filepath.Join(basePath, filepath.FromSlash(root))
+		filepath.Walk(rootPath, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if name := info.Name(); p != rootPath && (strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			rel, _ := filepath.Rel(basePath, p)
+			rel = filepath.ToSlash(rel)
+			if strings.EqualFold(path.Ext(rel), ".cs") {
+				scripts = append(scripts, rel)
+			}
+			if strings.HasSuffix(rel, ".meta") {
+				return nil
+			}
+			// The innermost Resources folder gives the load path
+			segs := strings.Split(rel, "/")
+			for i := len(segs) - 2; i >= 0; i-- {
+				if segs[i] != "Resources" {
+					continue
+				}
+				name := strings.Join(segs[i+1:], "/")
+				assets = append(assets, &resourceAsset{
+					file:   rel,
+					folder: strings.Join(segs[:i+1], "/"),
+					name:   strings.TrimSuffix(name, path.Ext(name)),
+					size:   info.Size(),
+					editor: inEditorFolder(rel),
+				})
+				break
+			}
+			return nil
+		})
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].file < assets[j].file })
+	sort.Strings(scripts)
+	return assets, scripts
+}
+
+// ============================================================
+// Code Scan
+// ============================================================
+
+// readStringLiteral reads a C# string literal at the start of s: "..." or
+// @"...". It returns the value and the rest after it.
+func readStringLiteral(s string) (string, string, bool) {
+	verbatim := strings.HasPrefix(s, "@\"")
+	if verbatim {
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "\"") {
+		return "", s, false
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case verbatim && c == '"' && i+1 < len(s) && s[i+1] == '"':
+			b.WriteByte('"')
+			i++
+		case c == '"':
+			return b.String(), s[i+1:], true
+		case !verbatim && c == '\\' && i+1 < len(s):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", s, false
+}
+
+// parseArgument works out the path argument of a call: a literal, a known
+// constant, an interpolated string or a concatenation starting with a literal
+// (dynamic, with its literal prefix), or anything else (dynamic, no prefix).
+func parseArgument(arg string, consts map[string]string) (string, bool) {
+	arg = strings.TrimSpace(arg)
+	if strings.HasPrefix(arg, "$\"") || strings.HasPrefix(arg, "$@\"") || strings.HasPrefix(arg, "@$\"") {
+		body := arg[strings.Index(arg, "\"")+1:]
+		if i := strings.IndexAny(body, "{\""); i >= 0 && body[i] == '{' {
+			return body[:i], true
+		}
+		v, _, _ := readStringLiteral("\"" + body)
+		return v, false
+	}
+	if v, rest, ok := readStringLiteral(arg); ok {
+		rest = strings.TrimSpace(rest)
+		if rest == "" || rest[0] == ',' || rest[0] == ')' {
+			return v, false
+		}
+		return v, true
+	}
+	// Stop at the next argument or operator
+	end := strings.IndexAny(arg, ",)+ ")
+	if end < 0 {
+		end = len(arg)
+	}
+	if m := identifierRegex.FindStringSubmatch(arg[:end]); m != nil {
+		if v, ok := consts[m[1]]; ok {
+			rest := strings.TrimSpace(arg[end:])
+			if rest == "" || rest[0] == ',' || rest[0] == ')' {
+				return v, false
+			}
+			return v, true
+		}
+	}
+	return "", true
+}
+
+// collectConstants reads the string constants of every script. Names defined
+// twice with different values are dropped.
+func collectConstants(basePath string, scripts []string) map[string]string {
+	consts := make(map[string]string)
+	conflicting := make(map[string]bool)
+	for _, rel := range scripts {
+		data, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(rel)))
+		if err != nil {
+			continue
+		}
+		for _, m := range stringConstRegex.FindAllStringSubmatch(string(data), -1) {
+			v, _, _ := readStringLiteral("\"" + m[2] + "\"")
+			if old, ok := consts[m[1]]; ok && old != v {
+				conflicting[m[1]] = true
+			}
+			consts[m[1]] = v
+		}
+	}
+	for name := range conflicting {
+		delete(consts, name)
+	}
+	return consts
+}
+
+// scanCalls finds the Resources calls of every script. Line comments and
+// whole-line block comments are skipped.
+func scanCalls(basePath string, scripts []string, consts map[string]string) []*loadCall {
+	var calls []*loadCall
+	for _, rel := range scripts {
+		data, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(rel)))
+		if err != nil {
+			continue
+		}
+		inBlock := false
+		for n, line := range strings.Split(string(data), "\n") {
+			t := strings.TrimSpace(line)
+			if inBlock {
+				if strings.Contains(t, "*/") {
+					inBlock = false
+				}
+				continue
+			}
+			if strings.HasPrefix(t, "/*") && !strings.Contains(t, "*/") {
+				inBlock = true
+				continue
+			}
+			if i := strings.Index(line, "//"); i >= 0 && !strings.Contains(line[:i], "\"") {
+				line = line[:i]
+			}
+			for _, loc := range resourcesCallRegex.FindAllStringSubmatchIndex(line, -1) {
+				c := &loadCall{file: rel, line: n + 1, method: line[loc[2]:loc[3]]}
+				if loc[6] >= 0 {
+					c.typ = line[loc[6]:loc[7]]
+				}
+				c.path, c.dynamic = parseArgument(line[loc[1]:], consts)
+				end := strings.Index(line[loc[0]:], ";")
+				if end < 0 {
+					end = len(line) - loc[0]
+				}
+				c.text = strings.TrimSpace(line[loc[0] : loc[0]+end])
+				calls = append(calls, c)
+			}
+		}
+	}
+	return calls
+}
+
+// ============================================================
+// Analysis
+// ============================================================
+
+// matchCalls links the calls to the assets they load. Load takes one path
+// (Unity resolves it without regard to letter case on some platforms only, so
+// a case-only match is reported); LoadAll takes a folder, or a single asset.
+func matchCalls(calls []*loadCall, assets []*resourceAsset) {
+	for _, c := range calls {
+		for _, a := range assets {
+			switch {
+			case c.dynamic:
+				// A path with no fixed part could be anything; it covers nothing
+				if c.path != "" && strings.HasPrefix(a.name, c.path) {
+					c.matches = append(c.matches, a)
+					if !a.loaded {
+						a.dynamic = true
+					}
+				}
+			case c.method == "LoadAll" && (c.path == "" || a.name == c.path || strings.HasPrefix(a.name, strings.TrimSuffix(c.path, "/")+"/")):
+				c.matches = append(c.matches, a)
+				a.loaded, a.dynamic = true, false
+			case a.name == c.path:
+				c.matches = append(c.matches, a)
+				a.loaded, a.dynamic = true, false
+			case strings.EqualFold(a.name, c.path):
+				c.matches = append(c.matches, a)
+				a.loaded, a.dynamic = true, false
+			}
+		}
+	}
+}
+
+// duplicateNames returns the load paths more than one Resources folder provides;
+// Unity loads one of them, which one is not defined
+func duplicateNames(assets []*resourceAsset) map[string][]*resourceAsset {
+	byName := make(map[string][]*resourceAsset)
+	for _, a := range assets {
+		if !a.editor {
+			byName[a.name] = append(byName[a.name], a)
+		}
+	}
+	for name, list := range byName {
+		if len(list) < 2 {
+			delete(byName, name)
+		}
+	}
+	return byName
+}
+
+// ============================================================
+// Migration Plan
+// ============================================================
+
+// buildPlan writes one rule per Resources folder that ships in builds. The rule's
+// glob ends at the folder, so {relname} is the path Resources.Load used.
+func buildPlan(assets []*resourceAsset, group string) *planFile {
+	plan := &planFile{}
+	seen := make(map[string]bool)
+	for _, a := range assets {
+		if a.editor || seen[a.folder] {
+			continue
+		}
+		seen[a.folder] = true
+		plan.Rules = append(plan.Rules, &planRule{
+			Paths:   []string{a.folder + "/**"},
+			Group:   group,
+			Address: "{relname}",
+			Add:     true,
+		})
+	}
+	return plan
+}
+
+// writePlan saves the rules as indented JSON
+func writePlan(p string, plan *planFile) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, append(data, '\n'), 0644)
+}
+
+// addressablesCall suggests the replacement for a call
+func addressablesCall(c *loadCall) string {
+	typ := c.typ
+	if typ == "" {
+		typ = "Object"
+	}
+	arg := fmt.Sprintf("%q", c.path)
+	if len(c.matches) > 0 {
+		arg = fmt.Sprintf("%q", c.matches[0].name) // the asset's own letter case
+	}
+	if c.dynamic {
+		arg = "..."
+	}
+	if c.method == "LoadAll" {
+		return fmt.Sprintf("Addressables.LoadAssetsAsync<%s>(<label>, null)", typ)
+	}
+	return fmt.Sprintf("Addressables.LoadAssetAsync<%s>(%s)", typ, arg)
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+func callWhere(c *loadCall) string {
+	return fmt.Sprintf("%s:%d", c.file, c.line)
+}
+
+// printAudit prints the findings and returns the number of dead loads
+func printAudit(calls []*loadCall, assets []*resourceAsset, dups map[string][]*resourceAsset, showAll bool) int {
+	dead := 0
+	var deadCalls, caseCalls, dynamicCalls []*loadCall
+	for _, c := range calls {
+		switch {
+		case c.dynamic:
+			dynamicCalls = append(dynamicCalls, c)
+		case len(c.matches) == 0:
+			deadCalls = append(deadCalls, c)
+		default:
+			for _, a := range c.matches {
+				if a.name != c.path && c.method != "LoadAll" {
+					caseCalls = append(caseCalls, c)
+					break
+				}
+			}
+		}
+	}
+
+	if len(deadCalls) > 0 {
+		fmt.Println(tr("\nDEAD LOADS (no Resources asset at that path)"))
+		for _, c := range deadCalls {
+			fmt.Printf("  %s  %s\n", callWhere(c), c.text)
+			annotate("error", c.file, c.line, 0, "Dead Resources load", fmt.Sprintf("No Resources asset at '%s'", c.path))
+			recordAction("check", callWhere(c), "failed", fmt.Sprintf("no Resources asset at '%s'", c.path), 0)
+			dead++
+		}
+	}
+	if len(caseCalls) > 0 {
+		fmt.Println(tr("\nLETTER CASE DIFFERS (fails where paths are case-sensitive)"))
+		for _, c := range caseCalls {
+			fmt.Printf(tr("  %s  %s -> %s\n"), callWhere(c), c.text, c.matches[0].name)
+			annotate("warning", c.file, c.line, 0, "Resources path case", fmt.Sprintf("'%s' only matches '%s'", c.path, c.matches[0].name))
+			recordAction("check", callWhere(c), "warning", fmt.Sprintf("'%s' only matches '%s'", c.path, c.matches[0].name), 0)
+		}
+	}
+	if len(dynamicCalls) > 0 {
+		fmt.Println(tr("\nDYNAMIC LOADS (path built at run time)"))
+		for _, c := range dynamicCalls {
+			if c.path == "" {
+				fmt.Printf(tr("  %s  %s  (any asset)\n"), callWhere(c), c.text)
+			} else {
+				fmt.Printf(tr("  %s  %s  (%d asset(s) under '%s')\n"), callWhere(c), c.text, len(c.matches), c.path)
+			}
+		}
+	}
+	if len(dups) > 0 {
+		fmt.Println(tr("\nPROVIDED BY MORE THAN ONE RESOURCES FOLDER"))
+		var names []string
+		for n := range dups {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			fmt.Printf("  %s\n", n)
+			for _, a := range dups[n] {
+				fmt.Printf("      %s\n", a.file)
+				annotate("warning", a.file, 0, 0, "Duplicate Resources path", fmt.Sprintf("'%s' is also provided by another Resources folder", n))
+			}
+		}
+	}
+
+	var unused []*resourceAsset
+	var unusedSize int64
+	for _, a := range assets {
+		if !a.loaded && !a.dynamic && !a.editor {
+			unused = append(unused, a)
+			unusedSize += a.size
+		}
+	}
+	if len(unused) > 0 {
+		fmt.Printf(tr("\nNOT LOADED BY NAME (%d asset(s), %s, still in every build)\n"), len(unused), formatSize(unusedSize))
+		limit := len(unused)
+		if !showAll && limit > 30 {
+			limit = 30
+		}
+		for _, a := range unused[:limit] {
+			fmt.Printf("  %-60s %10s\n", a.file, formatSize(a.size))
+			recordAction("check", a.file, "warning", "not loaded by name", 0)
+		}
+		if limit < len(unused) {
+			fmt.Printf(tr("  ... and %d more (-all lists every one)\n"), len(unused)-limit)
+		}
+		for _, c := range dynamicCalls {
+			if c.path == "" {
+				fmt.Println(tr("[NOTE] Some loads build their whole path at run time; these assets may still be loaded that way."))
+				break
+			}
+		}
+		fmt.Println(tr("[NOTE] Assets referenced by a loaded asset (a prefab's materials) are loaded with it; move them out of Resources rather than deleting them."))
+	}
+	return dead
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...": "\n按回车键继续...",
+
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  RESOURCES AUDIT": "  RESOURCES 审计",
+	"  Project: %s\n":   "  项目:   %s\n",
+	"  Folders: %d Resources folder(s), %d asset(s), %s in builds\n": "  文件夹: %d 个 Resources 文件夹，%d 个资源，构建中占 %s\n",
+	"  Code:    %d script(s), %d Resources call(s), %d dynamic\n":    "  代码:   %d 个脚本，%d 处 Resources 调用，其中 %d 处为动态路径\n",
+	"\nDEAD LOADS (no Resources asset at that path)":                 "\n无效加载（该路径下没有 Resources 资源）",
+	"\nLETTER CASE DIFFERS (fails where paths are case-sensitive)":   "\n大小写不一致（在路径区分大小写的平台上会失败）",
+	"  %s  %s -> %s\n":                                               "  %s  %s -> %s\n",
+	"\nDYNAMIC LOADS (path built at run time)":                       "\n动态加载（路径在运行时生成）",
+	"  %s  %s  (any asset)\n":                                        "  %s  %s  (任意资源)\n",
+	"  %s  %s  (%d asset(s) under '%s')\n":                           "  %s  %s  ('%[4]s' 下 %[3]d 个资源)\n",
+	"\nPROVIDED BY MORE THAN ONE RESOURCES FOLDER":                   "\n多个 Resources 文件夹中都存在",
+	"\nNOT LOADED BY NAME (%d asset(s), %s, still in every build)\n": "\n未按名称加载（%d 个资源，%s，仍包含在每次构建中）\n",
+	"  ... and %d more (-all lists every one)\n":                     "  ... 以及另外 %d 个（使用 -all 列出全部）\n",
+	"[NOTE] Some loads build their whole path at run time; these assets may still be loaded that way.":                                            "[NOTE] 部分加载的完整路径在运行时生成；这些资源仍可能以这种方式被加载。",
+	"[NOTE] Assets referenced by a loaded asset (a prefab's materials) are loaded with it; move them out of Resources rather than deleting them.": "[NOTE] 被已加载资源引用的资源（如预制体的材质）会随之一起加载；请将它们移出 Resources，而不是直接删除。",
+	"\n[OK] Every Resources load with a fixed path finds an asset.":                                                                               "\n[OK] 所有固定路径的 Resources 加载都能找到资源。",
+	"\n[INFO] No Resources folders that ship in builds; nothing to migrate.":                                                                      "\n[INFO] 没有会进入构建的 Resources 文件夹；无需迁移。",
+	"\n%s exists. Overwrite? (y/N): ":       "\n%s 已存在。是否覆盖？(y/N): ",
+	"Operation cancelled.":                  "操作已取消。",
+	"\nMIGRATION PLAN":                      "\n迁移计划",
+	"  %s -> addresses = Resources paths\n": "  %s -> 地址 = Resources 路径\n",
+	"[OK] Wrote %s\n":                       "[OK] 已写入 %s\n",
+	"\nCALLS TO CHANGE":                     "\n需要修改的调用",
+	"\n[TIP] Preview with \"unity_addressables_editor apply -dry-run\", apply it, then move the folders out of Resources in Unity so the assets stop shipping twice.": "\n[TIP] 先用 \"unity_addressables_editor apply -dry-run\" 预览并应用，然后在 Unity 中将这些文件夹移出 Resources，避免资源被打包两次。",
+	"[TIP] LoadAll calls have no address to load; give those assets a label in the rules first.":                                                                      "[TIP] LoadAll 调用没有可加载的地址；请先在规则中为这些资源添加标签。",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// CI Annotations
+// ============================================================
+
+// With -annotate, errors and warnings are printed once more as GitHub Actions
+// workflow commands or TeamCity service messages, so the CI lists them on the run
+// page and, when they point at a file, next to that file in the diff.
+var (
+	annotateMode  string              // "", "github" or "teamcity"
+	annotateTool  string              // TeamCity inspection category
+	annotateBase  string              // project root; relative files start here
+	annotateRoot  string              // repository root; CI file paths are relative to it
+	annotateTypes = map[string]bool{} // TeamCity inspection types already declared
+)
+
+// setAnnotateMode checks the -annotate value; "auto" picks the CI the tool runs in
+func setAnnotateMode(mode, basePath, tool string) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "none":
+		annotateMode = ""
+	case "github", "teamcity":
+		annotateMode = strings.ToLower(strings.TrimSpace(mode))
+	case "auto":
+		annotateMode = ""
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			annotateMode = "github"
+		} else if os.Getenv("TEAMCITY_VERSION") != "" {
+			annotateMode = "teamcity"
+		}
+	default:
+		return fmt.Errorf("unknown -annotate mode '%s' (use github, teamcity or auto)", mode)
+	}
+	annotateTool = tool
+	annotateBase, _ = filepath.Abs(basePath)
+	annotateRoot = repositoryRoot(annotateBase)
+	return nil
+}
+
+// repositoryRoot is GITHUB_WORKSPACE, else the nearest folder with a .git entry
+// (a folder, or a file in worktrees and submodules), else the project itself
+func repositoryRoot(basePath string) string {
+	if ws := os.Getenv("GITHUB_WORKSPACE"); ws != "" && annotateMode == "github" {
+		return ws
+	}
+	for dir := basePath; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return basePath
+		}
+	}
+}
+
+// annotate prints one annotation. level is "error" or "warning"; file is absolute
+// or relative to the project root, and may be empty like line and col.
+func annotate(level, file string, line, col int, title, message string) {
+	if annotateMode == "" {
+		return
+	}
+	if file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(annotateBase, filepath.FromSlash(file))
+		}
+		if rel, err := filepath.Rel(annotateRoot, file); err == nil {
+			file = rel
+		}
+		file = filepath.ToSlash(file)
+	}
+
+	if annotateMode == "github" {
+		// Property values also escape ':' and ','; the message only '%' and line breaks
+		data := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+		prop := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+		var props []string
+		if file != "" {
+			props = append(props, "file="+prop.Replace(file))
+			if line > 0 {
+				props = append(props, "line="+strconv.Itoa(line))
+			}
+			if col > 0 {
+				props = append(props, "col="+strconv.Itoa(col))
+			}
+		}
+		if title != "" {
+			props = append(props, "title="+prop.Replace(title))
+		}
+		fmt.Printf("::%s %s::%s\n", level, strings.Join(props, ","), data.Replace(message))
+		return
+	}
+
+	esc := strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]").Replace
+	status := "WARNING"
+	if level == "error" {
+		status = "ERROR"
+	}
+	if file == "" {
+		text := message
+		if title != "" {
+			text = title + ": " + message
+		}
+		fmt.Printf("##teamcity[message text='%s' status='%s']\n", esc(text), status)
+		return
+	}
+	typeID := annotateTool + "." + strings.ReplaceAll(strings.ToLower(title), " ", "-")
+	if !annotateTypes[typeID] {
+		annotateTypes[typeID] = true
+		fmt.Printf("##teamcity[inspectionType id='%s' name='%s' category='%s' description='%s']\n", esc(typeID), esc(title), esc(annotateTool), esc(title))
+	}
+	lineAttr := ""
+	if line > 0 {
+		lineAttr = fmt.Sprintf(" line='%d'", line)
+	}
+	fmt.Printf("##teamcity[inspection typeId='%s' message='%s' file='%s'%s SEVERITY='%s']\n", esc(typeID), esc(message), esc(file), lineAttr, status)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode, plan, showAll bool
+	var outFlag, groupFlag, annotateFl string
+
+	flag.BoolVar(&plan, "plan", false, "Write an Addressables migration plan for unity_addressables_editor and list the calls to change")
+	flag.StringVar(&outFlag, "out", defaultPlanFile, "-plan: rules file to write, relative to the project root")
+	flag.StringVar(&groupFlag, "group", "", "-plan: existing Addressables group for the migrated assets (default: the default group)")
+	flag.BoolVar(&showAll, "all", false, "List every asset that is not loaded by name, not just the first 30")
+	flag.StringVar(&annotateFl, "annotate", "", "Also print findings as CI annotations: github, teamcity, auto")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no confirmation prompts)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_resources_auditor")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if err := setAnnotateMode(annotateFl, basePath, "unity_resources_auditor"); err != nil {
+		fail(2, "%v", err)
+	}
+
+	assets, scripts := scanProject(basePath)
+	calls := scanCalls(basePath, scripts, collectConstants(basePath, scripts))
+	matchCalls(calls, assets)
+	dups := duplicateNames(assets)
+
+	folders := make(map[string]bool)
+	var total int64
+	for _, a := range assets {
+		folders[a.folder] = true
+		if !a.editor {
+			total += a.size
+		}
+	}
+	dynamic := 0
+	for _, c := range calls {
+		if c.dynamic {
+			dynamic++
+		}
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  RESOURCES AUDIT"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project: %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Folders: %d Resources folder(s), %d asset(s), %s in builds\n"), len(folders), len(assets), formatSize(total))
+	fmt.Printf(tr("  Code:    %d script(s), %d Resources call(s), %d dynamic\n"), len(scripts), len(calls), dynamic)
+
+	dead := printAudit(calls, assets, dups, showAll)
+	if dead == 0 {
+		fmt.Println(tr("\n[OK] Every Resources load with a fixed path finds an asset."))
+	}
+
+	if plan {
+		p := buildPlan(assets, groupFlag)
+		if len(p.Rules) == 0 {
+			fmt.Println(tr("\n[INFO] No Resources folders that ship in builds; nothing to migrate."))
+		} else {
+			outPath := outFlag
+			if !filepath.IsAbs(outPath) {
+				outPath = filepath.Join(basePath, outPath)
+			}
+			if _, err := os.Stat(outPath); err == nil && !ciMode {
+				fmt.Printf(tr("\n%s exists. Overwrite? (y/N): "), filepath.Base(outPath))
+				confirm, _ := stdinReader.ReadString('\n')
+				if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+					fmt.Println(tr("Operation cancelled."))
+					exit(0)
+				}
+			}
+			if err := writePlan(outPath, p); err != nil {
+				fail(1, "cannot write %s: %v", outPath, err)
+			}
+			recordArtifact(outPath)
+
+			fmt.Println(tr("\nMIGRATION PLAN"))
+			for _, r := range p.Rules {
+				fmt.Printf(tr("  %s -> addresses = Resources paths\n"), r.Paths[0])
+			}
+			fmt.Printf(tr("[OK] Wrote %s\n"), outPath)
+			fmt.Println(tr("\nCALLS TO CHANGE"))
+			for _, c := range calls {
+				if !c.dynamic && len(c.matches) == 0 {
+					continue
+				}
+				fmt.Printf("  %s\n      %s\n   -> %s\n", callWhere(c), c.text, addressablesCall(c))
+				recordAction("migrate", callWhere(c), "planned", addressablesCall(c), 0)
+			}
+			fmt.Println(tr("\n[TIP] Preview with \"unity_addressables_editor apply -dry-run\", apply it, then move the folders out of Resources in Unity so the assets stop shipping twice."))
+			fmt.Println(tr("[TIP] LoadAll calls have no address to load; give those assets a label in the rules first."))
+		}
+	}
+
+	if dead > 0 {
+		recordError("%d Resources load(s) find no asset", dead)
+		exit(1)
+	}
+	exit(0)
+}
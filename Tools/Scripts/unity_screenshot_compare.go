@@ -0,0 +1,1719 @@
+// Unity Screenshot Compare — Collect screenshots from devices and compare them with baseline images.
+// "collect" pulls the screenshots an in-game capture script saved on each
+// connected Android device (adb pull from the app's persistentDataPath), or
+// copies them from a folder a device farm or desktop build wrote, into one folder
+// per device. "compare" matches them with the baseline images of the same device
+// and name, diffs each pair perceptually (YIQ color distance, anti-aliased edges
+// are not counted), writes a diff image for every pair that changed and an HTML
+// report with the baseline, current and diff side by side, and exits with 1 on
+// a visual regression. "approve" copies the current screenshots over the
+// baselines once the changes are intended.
+//
+// Build: go build unity_screenshot_compare.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_screenshot_compare collect                      # adb pull from every device
+//	unity_screenshot_compare collect -from Farm/Results   # copy from a folder
+//	unity_screenshot_compare                              # compare with the baselines
+//	unity_screenshot_compare compare -max-diff 0.5 -ci
+//	unity_screenshot_compare approve -only "Pixel_7/*"    # accept the new images
+//
+// The capture script saves PNG (or JPG) files into Screenshots/ under
+// Application.persistentDataPath, named after the view they show.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	defaultBaselineDir = "ScreenshotBaselines"       // committed with the project
+	defaultCurrentDir  = "Build/Screenshots/Current" // what collect writes
+	defaultReportDir   = "Build/Screenshots"         // report.html and diff/
+	remoteFolderName   = "Screenshots"               // under persistentDataPath
+	defaultDeviceName  = "Desktop"                   // collect -from: images not in a device folder
+	reportFileName     = "report.html"
+	diffFolderName     = "diff"
+)
+
+// Image files collected and compared
+var imageExtensions = []string{".png", ".jpg", ".jpeg"}
+
+// Comparison outcomes
+const (
+	statusSame    = "ok"
+	statusChanged = "changed" // more pixels differ than -max-diff allows
+	statusSize    = "size"    // the resolution differs
+	statusNew     = "new"     // no baseline yet
+	statusMissing = "missing" // the device has a baseline but took no such screenshot
+	statusError   = "error"   // an image could not be read
+)
+
+// pixelmatch constants: the largest YIQ distance between two colors, and how
+// much a diff image fades the unchanged baseline
+const (
+	maxYIQDelta = 35215.0
+	fadeAlpha   = 0.1
+)
+
+var (
+	diffColor = color.NRGBA{255, 0, 0, 255}   // pixels that differ
+	aaColor   = color.NRGBA{255, 200, 0, 255} // anti-aliased pixels that differ, not counted
+)
+
+// Characters taken out of the device model when it names a folder
+var deviceNameRegex = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// shot is a screenshot found in a folder; key is "<device>/<name>" without extension
+type shot struct {
+	key  string
+	path string
+}
+
+// comparison is the result for one screenshot
+type comparison struct {
+	Key          string
+	Device       string
+	Name         string
+	Status       string
+	Baseline     string // paths relative to the report, for the HTML
+	Current      string
+	Diff         string
+	DiffPixels   int
+	Percent      float64
+	Size         string // "1080x2400", or "1080x2400 -> 1440x3200" when it changed
+	Error        string
+	baselinePath string
+	currentPath  string
+	diffFile     string
+}
+
+// diffOptions are the comparison settings
+type diffOptions struct {
+	threshold float64 // per-pixel color distance that counts as different, 0..1
+	maxDiff   float64 // percent of the pixels that may differ
+	includeAA bool    // count anti-aliased pixels as different too
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// readAndroidPackageName reads the Android applicationIdentifier from ProjectSettings.asset
+func readAndroidPackageName(projectRoot string) (string, error) {
+	path := filepath.Join(projectRoot, "ProjectSettings", "ProjectSettings.asset")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	inBlock := false
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "applicationIdentifier:") {
+			inBlock = true
+			continue
+		}
+		if inBlock {
+			if !strings.HasPrefix(line, "    ") {
+				break
+			}
+			if strings.HasPrefix(trimmed, "Android:") {
+				return strings.TrimSpace(strings.TrimPrefix(trimmed, "Android:")), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no Android applicationIdentifier found in %s", path)
+}
+
+// ============================================================
+// Commands
+// ============================================================
+
+// commandRunner starts every external program the tool uses. Output is for
+// commands that only read (a device query); Run is for commands that change
+// something (pulling the screenshots, removing them from the device).
+//
+// Two environment variables swap the runner for tests and bug reports:
+//   - UNITYSTARTER_EXEC_RECORD=<file.json> runs commands as usual and saves each
+//     one with its output and exit code
+//   - UNITYSTARTER_EXEC_REPLAY=<file.json> starts nothing and answers every
+//     command from such a file, so a run can be repeated without the programs
+//
+// The project root and the temp folder are written as $ROOT and $TMP in fixtures
+// so they replay on any machine.
+type commandRunner interface {
+	Output(ctx context.Context, name string, args ...string) (stdout, stderr []byte, err error)
+	Run(ctx context.Context, name string, args ...string) (stdout, stderr []byte, err error)
+}
+
+var runner commandRunner = execRunner{}
+
+// replayingCommands is set when commands are answered from a fixture; lookPath
+// then accepts programs that are not installed
+var replayingCommands bool
+
+// setupRunner picks the runner for this run
+func setupRunner(root string) error {
+	var r commandRunner = execRunner{}
+	if path := os.Getenv("UNITYSTARTER_EXEC_REPLAY"); path != "" {
+		replay, err := loadReplayRunner(path, root)
+		if err != nil {
+			return err
+		}
+		r = replay
+		replayingCommands = true
+	}
+	if path := os.Getenv("UNITYSTARTER_EXEC_RECORD"); path != "" {
+		r = &recordingRunner{inner: r, path: path, hide: fixturePaths(root, false)}
+	}
+	runner = r
+	return nil
+}
+
+// lookPath reports whether a program can be started
+func lookPath(name string) error {
+	if replayingCommands {
+		return nil
+	}
+	_, err := exec.LookPath(name)
+	return err
+}
+
+// commandLine formats a command the way it could be pasted into a shell
+func commandLine(name string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, a := range append([]string{name}, args...) {
+		if a == "" || strings.ContainsAny(a, " \t\"'") {
+			a = strconv.Quote(a)
+		}
+		parts = append(parts, a)
+	}
+	return strings.Join(parts, " ")
+}
+
+// fixturePaths swaps the machine's paths for $ROOT and $TMP, or back with restore.
+// The longer path goes first so a project inside the temp folder stays $ROOT.
+func fixturePaths(root string, restore bool) *strings.Replacer {
+	paths := [][2]string{{root, "$ROOT"}, {filepath.Clean(os.TempDir()), "$TMP"}}
+	if len(paths[1][0]) > len(paths[0][0]) {
+		paths[0], paths[1] = paths[1], paths[0]
+	}
+	var pairs []string
+	for _, p := range paths {
+		if p[0] == "" || p[0] == "." {
+			continue
+		}
+		if restore {
+			pairs = append(pairs, p[1], p[0])
+		} else {
+			pairs = append(pairs, p[0], p[1])
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// execRunner starts the real programs
+type execRunner struct{}
+
+func (execRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+func (e execRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	return e.Output(ctx, name, args...)
+}
+
+// commandRecord is one command in a fixture; Exit is -1 when it did not start
+// or was killed, with the reason in Error
+type commandRecord struct {
+	Args   []string `json:"args"`
+	Stdout string   `json:"stdout,omitempty"`
+	Stderr string   `json:"stderr,omitempty"`
+	Exit   int      `json:"exit"`
+	Error  string   `json:"error,omitempty"`
+}
+
+type commandFixture struct {
+	Commands []commandRecord `json:"commands"`
+}
+
+// recordingRunner saves every command to path, rewriting the file after each
+// one so an interrupted run still leaves a usable fixture
+type recordingRunner struct {
+	inner   commandRunner
+	path    string
+	hide    *strings.Replacer
+	mu      sync.Mutex
+	fixture commandFixture
+}
+
+func (r *recordingRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	stdout, stderr, err := r.inner.Output(ctx, name, args...)
+	r.save(name, args, stdout, stderr, err)
+	return stdout, stderr, err
+}
+
+func (r *recordingRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	stdout, stderr, err := r.inner.Run(ctx, name, args...)
+	r.save(name, args, stdout, stderr, err)
+	return stdout, stderr, err
+}
+
+func (r *recordingRunner) save(name string, args []string, stdout, stderr []byte, err error) {
+	rec := commandRecord{Stdout: r.hide.Replace(string(stdout)), Stderr: r.hide.Replace(string(stderr))}
+	for _, a := range append([]string{name}, args...) {
+		rec.Args = append(rec.Args, r.hide.Replace(a))
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		rec.Exit = exitErr.ExitCode()
+	default:
+		rec.Exit = -1
+		rec.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Commands = append(r.fixture.Commands, rec)
+	data, _ := json.MarshalIndent(r.fixture, "", "  ")
+	os.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// replayRunner answers commands from a fixture. A command that appears several
+// times (the same device query, a retried step) gets its recordings in order,
+// the last one repeating once they run out.
+type replayRunner struct {
+	hide    *strings.Replacer
+	show    *strings.Replacer
+	mu      sync.Mutex
+	records map[string][]commandRecord
+}
+
+func loadReplayRunner(path, root string) (*replayRunner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture commandFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r := &replayRunner{hide: fixturePaths(root, false), show: fixturePaths(root, true), records: make(map[string][]commandRecord)}
+	for _, rec := range fixture.Commands {
+		key := strings.Join(rec.Args, "\x00")
+		r.records[key] = append(r.records[key], rec)
+	}
+	return r, nil
+}
+
+func (r *replayRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	var keyArgs []string
+	for _, a := range append([]string{name}, args...) {
+		keyArgs = append(keyArgs, r.hide.Replace(a))
+	}
+	key := strings.Join(keyArgs, "\x00")
+
+	r.mu.Lock()
+	queue := r.records[key]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, nil, fmt.Errorf("no recorded output for: %s", commandLine(name, args))
+	}
+	rec := queue[0]
+	if len(queue) > 1 {
+		r.records[key] = queue[1:]
+	}
+	r.mu.Unlock()
+
+	stdout, stderr := []byte(r.show.Replace(rec.Stdout)), []byte(r.show.Replace(rec.Stderr))
+	switch {
+	case rec.Error != "":
+		return stdout, stderr, errors.New(rec.Error)
+	case rec.Exit != 0:
+		return stdout, stderr, fmt.Errorf("exit status %d", rec.Exit)
+	}
+	return stdout, stderr, nil
+}
+
+func (r *replayRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	return r.Output(ctx, name, args...)
+}
+
+// ============================================================
+// adb Helpers
+// ============================================================
+
+// adb runs a command that changes the device or the local folders
+func adb(serial string, args ...string) (string, error) {
+	return adbCommand(runner.Run, serial, args)
+}
+
+// adbQuery runs a command that only reads from the device
+func adbQuery(serial string, args ...string) (string, error) {
+	return adbCommand(runner.Output, serial, args)
+}
+
+func adbCommand(run func(context.Context, string, ...string) ([]byte, []byte, error), serial string, args []string) (string, error) {
+	full := append([]string{"-s", serial}, args...)
+	stdout, stderr, err := run(context.Background(), "adb", full...)
+	text := strings.TrimSpace(string(stdout) + string(stderr))
+	if err != nil {
+		return text, fmt.Errorf("adb %s: %v\n%s", strings.Join(args, " "), err, text)
+	}
+	return text, nil
+}
+
+// listDevices returns serials of attached devices in the "device" state
+func listDevices() ([]string, error) {
+	output, _, err := runner.Output(context.Background(), "adb", "devices")
+	if err != nil {
+		return nil, err
+	}
+	var serials []string
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == "device" {
+			serials = append(serials, fields[0])
+		}
+	}
+	return serials, nil
+}
+
+// persistentDataPath mirrors Application.persistentDataPath on Android for external storage
+func persistentDataPath(packageName string) string {
+	return "/sdcard/Android/data/" + packageName + "/files"
+}
+
+// deviceFolderNames names the folder of each device after its model, so the
+// baselines of a model are shared by every device of it; a second device of the
+// same model gets its serial appended
+func deviceFolderNames(serials []string) map[string]string {
+	names := make(map[string]string, len(serials))
+	used := make(map[string]bool)
+	for _, serial := range serials {
+		model, err := adbQuery(serial, "shell", "getprop", "ro.product.model")
+		name := strings.Trim(deviceNameRegex.ReplaceAllString(strings.TrimSpace(model), "_"), "_")
+		if err != nil || name == "" {
+			name = deviceNameRegex.ReplaceAllString(serial, "_")
+		}
+		if used[strings.ToLower(name)] {
+			name += "-" + deviceNameRegex.ReplaceAllString(serial, "_")
+		}
+		used[strings.ToLower(name)] = true
+		names[serial] = name
+	}
+	return names
+}
+
+// ============================================================
+// Collect
+// ============================================================
+
+func isImageFile(name string) bool {
+	return containsString(imageExtensions, strings.ToLower(filepath.Ext(name)))
+}
+
+// countImages returns how many screenshots a folder tree holds
+func countImages(dir string) int {
+	n := 0
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && isImageFile(info.Name()) {
+			n++
+		}
+		return nil
+	})
+	return n
+}
+
+// pullDevice copies the screenshot folder of one device into dest
+func pullDevice(serial, remote, dest string, clear bool) (int, error) {
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return 0, err
+	}
+	// "<dir>/." pulls the folder's contents instead of the folder itself
+	if _, err := adb(serial, "pull", remote+"/.", dest); err != nil {
+		os.Remove(dest)
+		return 0, err
+	}
+	n := countImages(dest)
+	if n == 0 {
+		os.Remove(dest)
+	}
+	if clear && n > 0 {
+		if _, err := adb(serial, "shell", "rm", "-rf", remote); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// copyFolder copies the screenshots of a folder into dest. Screenshots in
+// subfolders keep them as the device folder; those at the top go into
+// dest/<device>.
+func copyFolder(from, dest, device string) (map[string]int, error) {
+	counts := make(map[string]int)
+	err := filepath.Walk(from, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !isImageFile(info.Name()) {
+			return nil
+		}
+		rel, _ := filepath.Rel(from, path)
+		parts := strings.SplitN(filepath.ToSlash(rel), "/", 2)
+		if len(parts) == 1 {
+			parts = []string{device, parts[0]}
+		}
+		target := filepath.Join(dest, parts[0], filepath.FromSlash(parts[1]))
+		if err := copyFile(path, target); err != nil {
+			return err
+		}
+		counts[parts[0]]++
+		return nil
+	})
+	return counts, err
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// ============================================================
+// Image Diff
+// ============================================================
+
+// loadImage decodes a PNG or JPG into an NRGBA image at the origin
+func loadImage(path string) (*image.NRGBA, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decode failed: %w", err)
+	}
+	if nrgba, ok := img.(*image.NRGBA); ok && nrgba.Rect.Min == (image.Point{}) {
+		return nrgba, nil
+	}
+	b := img.Bounds()
+	nrgba := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(nrgba, nrgba.Rect, img, b.Min, draw.Src)
+	return nrgba, nil
+}
+
+func rgb2y(r, g, b float64) float64 { return r*0.29889531 + g*0.58662247 + b*0.11448223 }
+func rgb2i(r, g, b float64) float64 { return r*0.59597799 - g*0.27417610 - b*0.32180189 }
+func rgb2q(r, g, b float64) float64 { return r*0.21147017 - g*0.52261711 + b*0.31114694 }
+
+// blend composes a channel with white by its alpha
+func blend(c, a float64) float64 { return 255 + (c-255)*a }
+
+// colorDelta is the perceptual distance between two pixels (offsets into Pix) in
+// YIQ space, negative when the second is lighter. yOnly compares brightness only.
+func colorDelta(p1, p2 []uint8, k, m int, yOnly bool) float64 {
+	r1, g1, b1, a1 := float64(p1[k]), float64(p1[k+1]), float64(p1[k+2]), float64(p1[k+3])
+	r2, g2, b2, a2 := float64(p2[m]), float64(p2[m+1]), float64(p2[m+2]), float64(p2[m+3])
+	if a1 == a2 && r1 == r2 && g1 == g2 && b1 == b2 {
+		return 0
+	}
+	if a1 < 255 {
+		a1 /= 255
+		r1, g1, b1 = blend(r1, a1), blend(g1, a1), blend(b1, a1)
+	}
+	if a2 < 255 {
+		a2 /= 255
+		r2, g2, b2 = blend(r2, a2), blend(g2, a2), blend(b2, a2)
+	}
+	y1, y2 := rgb2y(r1, g1, b1), rgb2y(r2, g2, b2)
+	y := y1 - y2
+	if yOnly {
+		return y
+	}
+	i := rgb2i(r1, g1, b1) - rgb2i(r2, g2, b2)
+	q := rgb2q(r1, g1, b1) - rgb2q(r2, g2, b2)
+	delta := 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+	if y1 > y2 {
+		return -delta
+	}
+	return delta
+}
+
+// antialiased tells whether the pixel at x1,y1 of img sits on an anti-aliased
+// edge: its neighbours both get darker and lighter, and the darkest or lightest
+// of them lies in a flat area of both images (Vyšniauskas, 2009)
+func antialiased(img *image.NRGBA, x1, y1 int, other *image.NRGBA) bool {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	x0, y0, x2, y2 := x1-1, y1-1, x1+1, y1+1
+	if x0 < 0 {
+		x0 = 0
+	}
+	if y0 < 0 {
+		y0 = 0
+	}
+	if x2 > w-1 {
+		x2 = w - 1
+	}
+	if y2 > h-1 {
+		y2 = h - 1
+	}
+	pos := img.PixOffset(x1, y1)
+	zeroes := 0
+	if x1 == x0 || x1 == x2 || y1 == y0 || y1 == y2 {
+		zeroes = 1
+	}
+	var min, max float64
+	var minX, minY, maxX, maxY int
+	for x := x0; x <= x2; x++ {
+		for y := y0; y <= y2; y++ {
+			if x == x1 && y == y1 {
+				continue
+			}
+			delta := colorDelta(img.Pix, img.Pix, pos, img.PixOffset(x, y), true)
+			switch {
+			case delta == 0:
+				zeroes++
+				if zeroes > 2 {
+					return false
+				}
+			case delta < min:
+				min, minX, minY = delta, x, y
+			case delta > max:
+				max, maxX, maxY = delta, x, y
+			}
+		}
+	}
+	if min == 0 || max == 0 {
+		return false
+	}
+	return (hasManySiblings(img, minX, minY) && hasManySiblings(other, minX, minY)) ||
+		(hasManySiblings(img, maxX, maxY) && hasManySiblings(other, maxX, maxY))
+}
+
+// hasManySiblings tells whether at least three neighbours have the pixel's color
+func hasManySiblings(img *image.NRGBA, x1, y1 int) bool {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	x0, y0, x2, y2 := x1-1, y1-1, x1+1, y1+1
+	if x0 < 0 {
+		x0 = 0
+	}
+	if y0 < 0 {
+		y0 = 0
+	}
+	if x2 > w-1 {
+		x2 = w - 1
+	}
+	if y2 > h-1 {
+		y2 = h - 1
+	}
+	pos := img.PixOffset(x1, y1)
+	zeroes := 0
+	if x1 == x0 || x1 == x2 || y1 == y0 || y1 == y2 {
+		zeroes = 1
+	}
+	for x := x0; x <= x2; x++ {
+		for y := y0; y <= y2; y++ {
+			if x == x1 && y == y1 {
+				continue
+			}
+			k := img.PixOffset(x, y)
+			if bytes.Equal(img.Pix[pos:pos+4], img.Pix[k:k+4]) {
+				zeroes++
+			}
+			if zeroes > 2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// diffImages counts the pixels that differ between two images of the same size
+// and draws them over a faded copy of the baseline
+func diffImages(base, cur *image.NRGBA, opts diffOptions) (int, *image.NRGBA) {
+	w, h := base.Rect.Dx(), base.Rect.Dy()
+	out := image.NewNRGBA(image.Rect(0, 0, w, h))
+	maxDelta := maxYIQDelta * opts.threshold * opts.threshold
+	diff := 0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			k := base.PixOffset(x, y)
+			m := cur.PixOffset(x, y)
+			delta := colorDelta(base.Pix, cur.Pix, k, m, false)
+			if delta < 0 {
+				delta = -delta
+			}
+			if delta > maxDelta {
+				if !opts.includeAA && (antialiased(base, x, y, cur) || antialiased(cur, x, y, base)) {
+					out.SetNRGBA(x, y, aaColor)
+					continue
+				}
+				out.SetNRGBA(x, y, diffColor)
+				diff++
+				continue
+			}
+			p := base.Pix[k : k+4]
+			v := uint8(blend(rgb2y(float64(p[0]), float64(p[1]), float64(p[2])), fadeAlpha*float64(p[3])/255))
+			out.SetNRGBA(x, y, color.NRGBA{v, v, v, 255})
+		}
+	}
+	return diff, out
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ============================================================
+// Comparison
+// ============================================================
+
+// listShots returns the screenshots under a folder by key; the folder may not exist
+func listShots(root string) (map[string]shot, error) {
+	shots := make(map[string]shot)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() || !isImageFile(info.Name()) {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		if !strings.Contains(rel, "/") {
+			return nil // not in a device folder
+		}
+		key := strings.TrimSuffix(rel, filepath.Ext(rel))
+		shots[key] = shot{key: key, path: path}
+		return nil
+	})
+	return shots, err
+}
+
+// compareShot diffs one pair and writes its diff image when it changed
+func compareShot(c *comparison, diffPath string, opts diffOptions) {
+	base, err := loadImage(c.baselinePath)
+	if err != nil {
+		c.Status, c.Error = statusError, fmt.Sprintf("baseline: %v", err)
+		return
+	}
+	cur, err := loadImage(c.currentPath)
+	if err != nil {
+		c.Status, c.Error = statusError, fmt.Sprintf("current: %v", err)
+		return
+	}
+	bw, bh, cw, ch := base.Rect.Dx(), base.Rect.Dy(), cur.Rect.Dx(), cur.Rect.Dy()
+	c.Size = fmt.Sprintf("%dx%d", bw, bh)
+	if bw != cw || bh != ch {
+		c.Status = statusSize
+		c.Size += fmt.Sprintf(" -> %dx%d", cw, ch)
+		return
+	}
+	n, img := diffImages(base, cur, opts)
+	c.DiffPixels = n
+	c.Percent = 100 * float64(n) / float64(bw*bh)
+	c.Status = statusSame
+	if n == 0 {
+		return
+	}
+	if c.Percent > opts.maxDiff {
+		c.Status = statusChanged
+	}
+	if err := writePNG(diffPath, img); err != nil {
+		c.Status, c.Error = statusError, fmt.Sprintf("cannot write the diff image: %v", err)
+		return
+	}
+	c.diffFile = diffPath
+}
+
+// compareAll pairs the current screenshots with the baselines and compares them
+// on every CPU. A device without current screenshots was not collected and its
+// baselines are left out.
+func compareAll(baseline, current map[string]shot, reportDir string, opts diffOptions) []*comparison {
+	devices := make(map[string]bool)
+	for key := range current {
+		devices[strings.SplitN(key, "/", 2)[0]] = true
+	}
+	var results, pairs []*comparison
+	add := func(key string) *comparison {
+		parts := strings.SplitN(key, "/", 2)
+		c := &comparison{Key: key, Device: parts[0], Name: parts[1]}
+		results = append(results, c)
+		return c
+	}
+	for key, cur := range current {
+		c := add(key)
+		c.currentPath = cur.path
+		if base, ok := baseline[key]; ok {
+			c.baselinePath = base.path
+			pairs = append(pairs, c)
+		} else {
+			c.Status = statusNew
+		}
+	}
+	for key, base := range baseline {
+		if _, ok := current[key]; !ok && devices[strings.SplitN(key, "/", 2)[0]] {
+			c := add(key)
+			c.baselinePath, c.Status = base.path, statusMissing
+		}
+	}
+
+	jobs := make(chan *comparison)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				compareShot(c, filepath.Join(reportDir, diffFolderName, filepath.FromSlash(c.Key)+".png"), opts)
+				mu.Lock()
+				done++
+				printProgressBar(done, len(pairs))
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, c := range pairs {
+		jobs <- c
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Regressions first, then new screenshots, then the unchanged ones
+	order := map[string]int{statusError: 0, statusSize: 1, statusChanged: 2, statusMissing: 3, statusNew: 4, statusSame: 5}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if order[a.Status] != order[b.Status] {
+			return order[a.Status] < order[b.Status]
+		}
+		if a.Percent != b.Percent {
+			return a.Percent > b.Percent
+		}
+		return a.Key < b.Key
+	})
+	return results
+}
+
+// isRegression reports whether a result fails the comparison
+func isRegression(c *comparison) bool {
+	return c.Status == statusChanged || c.Status == statusSize || c.Status == statusMissing || c.Status == statusError
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+// reportData is what the HTML report shows
+type reportData struct {
+	Project     string
+	GeneratedAt string
+	Baseline    string
+	Current     string
+	MaxDiff     float64
+	Counts      map[string]int
+	Results     []*comparison
+}
+
+var htmlReport = template.Must(template.New("screenshots").Funcs(template.FuncMap{
+	"percent":    func(p float64) string { return fmt.Sprintf("%.3f%%", p) },
+	"regression": isRegression,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Screenshot Comparison — {{.Project}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; font-size: 14px; vertical-align: top; }
+th { background: #f0f0f0; }
+.ok { color: #1a7f37; font-weight: bold; }
+.changed, .size, .missing, .error { color: #cf222e; font-weight: bold; }
+.new { color: #9a6700; font-weight: bold; }
+.shots { display: flex; gap: 8px; }
+.shots figure { margin: 0; flex: 1; }
+.shots img { width: 100%; border: 1px solid #ccc; }
+figcaption { font-size: 12px; color: #555; }
+</style>
+</head>
+<body>
+<h1>Screenshot Comparison — {{.Project}}</h1>
+<p>{{.GeneratedAt}} · baseline <code>{{.Baseline}}</code>, current <code>{{.Current}}</code>, up to {{percent .MaxDiff}} of the pixels may differ</p>
+<p><span class="ok">{{index .Counts "ok"}} unchanged</span>, <span class="changed">{{index .Counts "changed"}} changed</span>, <span class="size">{{index .Counts "size"}} resized</span>, <span class="missing">{{index .Counts "missing"}} missing</span>, <span class="new">{{index .Counts "new"}} new</span>{{if index .Counts "error"}}, <span class="error">{{index .Counts "error"}} unreadable</span>{{end}}</p>
+<table>
+<tr><th>Device</th><th>Screenshot</th><th>Status</th><th>Differing pixels</th><th>Size</th><th>Images</th></tr>
+{{range .Results}}<tr>
+<td>{{.Device}}</td><td>{{.Name}}</td><td class="{{.Status}}">{{.Status}}</td>
+<td>{{if or (eq .Status "ok") (eq .Status "changed")}}{{.DiffPixels}} ({{percent .Percent}}){{end}}</td><td>{{.Size}}</td>
+<td>{{if or (regression .) (eq .Status "new")}}<div class="shots">
+{{if .Baseline}}<figure><a href="{{.Baseline}}"><img src="{{.Baseline}}" loading="lazy"></a><figcaption>baseline</figcaption></figure>{{end}}
+{{if .Current}}<figure><a href="{{.Current}}"><img src="{{.Current}}" loading="lazy"></a><figcaption>current</figcaption></figure>{{end}}
+{{if .Diff}}<figure><a href="{{.Diff}}"><img src="{{.Diff}}" loading="lazy"></a><figcaption>diff</figcaption></figure>{{end}}
+</div>{{if .Error}}<p class="error">{{.Error}}</p>{{end}}{{else}}{{if .Diff}}<a href="{{.Diff}}">diff</a>{{end}}{{end}}</td>
+</tr>{{end}}
+</table>
+</body>
+</html>
+`))
+
+// writeReport writes report.html with image paths relative to it
+func writeReport(p string, data *reportData) error {
+	rel := func(target string) string {
+		if target == "" {
+			return ""
+		}
+		r, err := filepath.Rel(filepath.Dir(p), target)
+		if err != nil {
+			return filepath.ToSlash(target)
+		}
+		return filepath.ToSlash(r)
+	}
+	for _, c := range data.Results {
+		c.Baseline, c.Current, c.Diff = rel(c.baselinePath), rel(c.currentPath), rel(c.diffFile)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if err := htmlReport.Execute(f, data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ============================================================
+// Approve
+// ============================================================
+
+// approveShots copies the current screenshots that differ from their baseline
+// byte for byte (or have none) over it. Returns the keys copied.
+func approveShots(baselineDir string, baseline, current map[string]shot, only []*regexp.Regexp, dryRun bool) ([]string, error) {
+	var keys []string
+	for key := range current {
+		if len(only) == 0 || matchesAny(only, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var copied []string
+	for _, key := range keys {
+		cur := current[key]
+		want, err := os.ReadFile(cur.path)
+		if err != nil {
+			return copied, err
+		}
+		target := filepath.Join(baselineDir, filepath.FromSlash(key)+strings.ToLower(filepath.Ext(cur.path)))
+		if base, ok := baseline[key]; ok {
+			if have, err := os.ReadFile(base.path); err == nil && bytes.Equal(have, want) {
+				continue
+			}
+			// A baseline saved as JPG and taken as PNG now is replaced, not kept beside it
+			if base.path != target && !dryRun {
+				os.Remove(base.path)
+			}
+		}
+		copied = append(copied, key)
+		if dryRun {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return copied, err
+		}
+		if err := os.WriteFile(target, want, 0644); err != nil {
+			return copied, err
+		}
+	}
+	return copied, nil
+}
+
+// pruneShots deletes the baselines of collected devices that took no such
+// screenshot this time. Returns the keys deleted.
+func pruneShots(baseline, current map[string]shot, only []*regexp.Regexp, dryRun bool) ([]string, error) {
+	devices := make(map[string]bool)
+	for key := range current {
+		devices[strings.SplitN(key, "/", 2)[0]] = true
+	}
+	var keys []string
+	for key := range baseline {
+		_, taken := current[key]
+		if !taken && devices[strings.SplitN(key, "/", 2)[0]] && (len(only) == 0 || matchesAny(only, key)) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if dryRun {
+		return keys, nil
+	}
+	for i, key := range keys {
+		if err := os.Remove(baseline[key].path); err != nil {
+			return keys[:i], err
+		}
+	}
+	return keys, nil
+}
+
+// ============================================================
+// Path Filters
+// ============================================================
+
+// globToRegexp converts a glob (*, ?, **) on slash-separated paths. A pattern
+// without a slash matches the name in any folder.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compileGlobs parses a comma-separated glob list
+func compileGlobs(list string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := globToRegexp(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, rel string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+func printProgressBar(current, total int) {
+	percent := float64(current) / float64(total)
+	if plainMode || jsonMode {
+		// One line per 10% step instead of redrawing the bar with \r
+		step := int(percent * 10)
+		if current == total || step > int(float64(current-1)/float64(total)*10) {
+			fmt.Printf(tr("Progress: %d/%d (%.0f%%)\n"), current, total, percent*100)
+		}
+		return
+	}
+	barLength := 40
+	filledLength := int(float64(barLength) * percent)
+
+	bar := strings.Repeat("█", filledLength) + strings.Repeat("-", barLength-filledLength)
+	fmt.Printf("\r[%s] %.0f%% (%d/%d)", bar, percent*100, current, total)
+	if current == total {
+		fmt.Println()
+	}
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...": "\n按回车键继续...",
+	"Progress: %d/%d (%.0f%%)\n":   "进度: %d/%d (%.0f%%)\n",
+
+	"Usage: unity_screenshot_compare [collect|compare|approve] [flags]": "用法: unity_screenshot_compare [collect|compare|approve] [参数]",
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  UNITY SCREENSHOT COMPARE":                                       "  Unity 截图对比",
+	"  Project:  %s\n":                                                 "  项目:     %s\n",
+	"  Baseline: %s\n":                                                 "  基准:     %s\n",
+	"  Current:  %s\n":                                                 "  当前:     %s\n",
+	"[OK] %s: %d screenshot(s)\n":                                      "[OK] %s: %d 张截图\n",
+	"\n[TIP] Run 'unity_screenshot_compare' to compare them with the baselines.":  "\n[TIP] 运行 'unity_screenshot_compare' 将其与基准图对比。",
+	"Pulling %s from %d device(s)...\n":                                           "正在从 %[2]d 台设备拉取 %[1]s...\n",
+	"[FAIL] %s (%s): %v\n":                                                        "[FAIL] %s (%s): %v\n",
+	"[FAIL] %s (%s): no screenshots in %s\n":                                      "[FAIL] %s (%s): %s 中没有截图\n",
+	"[OK] %s (%s): %d screenshot(s)\n":                                            "[OK] %s (%s): %d 张截图\n",
+	"  [UPDATE] %s\n":                                                             "  [UPDATE] %s\n",
+	"  [NEW]    %s\n":                                                             "  [NEW]    %s\n",
+	"  [DELETE] %s\n":                                                             "  [DELETE] %s\n",
+	"[--] The baselines already match the current screenshots.":                   "[--] 基准图已与当前截图一致。",
+	"\n[DRY-RUN] Would copy %d screenshot(s) to the baseline and delete %d.\n":    "\n[DRY-RUN] 将复制 %d 张截图到基准目录，并删除 %d 张。\n",
+	"\n[OK] Copied %d screenshot(s) to the baseline and deleted %d; commit %s.\n": "\n[OK] 已复制 %d 张截图到基准目录并删除 %d 张；请提交 %s。\n",
+	"Comparing %d screenshot(s) with %d baseline(s)...\n":                         "正在将 %d 张截图与 %d 张基准图对比...\n",
+	"  [CHANGED] %s: %d pixel(s) differ (%.3f%%)\n":                               "  [CHANGED] %s: %d 个像素不同 (%.3f%%)\n",
+	"  [SIZE]    %s: %s\n":                                                        "  [SIZE]    %s: %s\n",
+	"  [MISSING] %s: the device took no such screenshot\n":                        "  [MISSING] %s: 设备未拍摄该截图\n",
+	"  [ERROR]   %s: %s\n":                                                        "  [ERROR]   %s: %s\n",
+	"  [NEW]     %s: no baseline\n":                                               "  [NEW]     %s: 没有基准图\n",
+	"  SUMMARY":                                                                   "  汇总",
+	"  Unchanged:  %d\n":                                                          "  未变化:   %d\n",
+	"  Changed:    %d\n":                                                          "  有变化:   %d\n",
+	"  Resized:    %d\n":                                                          "  尺寸变化: %d\n",
+	"  Missing:    %d\n":                                                          "  缺失:     %d\n",
+	"  New:        %d\n":                                                          "  新增:     %d\n",
+	"  Unreadable: %d\n":                                                          "  无法读取: %d\n",
+	"  Report:     %s\n":                                                          "  报告:     %s\n",
+	"  Took:       %v\n":                                                          "  耗时:     %v\n",
+	"\n[TIP] Review the report; run 'unity_screenshot_compare approve' to accept the current screenshots.": "\n[TIP] 请查看报告；运行 'unity_screenshot_compare approve' 接受当前截图。",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode     bool
+		dryRun     bool
+		clear      bool
+		prune      bool
+		strict     bool
+		includeAA  bool
+		threshold  float64
+		maxDiff    float64
+		baselineFl string
+		currentFl  string
+		outFl      string
+		fromFl     string
+		deviceFl   string
+		packageFl  string
+		remoteFl   string
+		deviceName string
+		onlyFl     string
+	)
+
+	flag.StringVar(&baselineFl, "baseline", defaultBaselineDir, "Baseline screenshots, one folder per device (relative to the project root or absolute)")
+	flag.StringVar(&currentFl, "current", defaultCurrentDir, "Screenshots collect writes and compare reads")
+	flag.StringVar(&outFl, "out", defaultReportDir, "compare: folder for report.html and the diff images")
+	flag.StringVar(&fromFl, "from", "", "collect: copy from this folder instead of pulling from Android devices")
+	flag.StringVar(&deviceName, "device-name", defaultDeviceName, "collect -from: device folder for screenshots that are not in one")
+	flag.StringVar(&deviceFl, "device", "", "collect: comma-separated adb serials (default: every connected device)")
+	flag.StringVar(&packageFl, "package", "", "collect: Android package name (default: applicationIdentifier of the project)")
+	flag.StringVar(&remoteFl, "device-dir", "", "collect: screenshot folder on the device (default: <persistentDataPath>/Screenshots)")
+	flag.BoolVar(&clear, "clear", false, "collect: delete the screenshots on the device once they are pulled")
+	flag.Float64Var(&threshold, "threshold", 0.1, "compare: color distance at which a pixel counts as different, 0 (exact) to 1")
+	flag.Float64Var(&maxDiff, "max-diff", 0.1, "compare: percent of the pixels that may differ before a screenshot counts as changed")
+	flag.BoolVar(&includeAA, "include-aa", false, "compare: count anti-aliased pixels that differ as well")
+	flag.BoolVar(&strict, "strict", false, "compare: also exit with 1 when a screenshot has no baseline")
+	flag.StringVar(&onlyFl, "only", "", "approve: comma-separated globs of <device>/<name> to approve (default: all)")
+	flag.BoolVar(&prune, "prune", false, "approve: also delete the baselines a collected device took no screenshot for")
+	flag.BoolVar(&dryRun, "dry-run", false, "approve: list the baselines that would be replaced without writing")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the command ("collect -clear")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_screenshot_compare")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	command := "compare"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	if len(args) > 1 || (command != "collect" && command != "compare" && command != "approve") {
+		fmt.Println(tr("Usage: unity_screenshot_compare [collect|compare|approve] [flags]"))
+		recordError("unknown command '%s'", strings.Join(args, " "))
+		exit(2)
+	}
+	if threshold < 0 || threshold > 1 {
+		fail(2, "-threshold must be between 0 and 1")
+	}
+	if maxDiff < 0 || maxDiff > 100 {
+		fail(2, "-max-diff is a percentage between 0 and 100")
+	}
+	only, err := compileGlobs(onlyFl)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if err := setupRunner(basePath); err != nil {
+		fail(1, "failed to load the command fixture: %v", err)
+	}
+	abs := func(p string) string {
+		if filepath.IsAbs(p) {
+			return filepath.Clean(p)
+		}
+		return filepath.Join(basePath, filepath.FromSlash(p))
+	}
+	baselineDir, currentDir, reportDir := abs(baselineFl), abs(currentFl), abs(outFl)
+
+	printRule("=============================================")
+	fmt.Println(tr("  UNITY SCREENSHOT COMPARE"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:  %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Baseline: %s\n"), baselineDir)
+	fmt.Printf(tr("  Current:  %s\n"), currentDir)
+	fmt.Println()
+
+	switch command {
+	case "collect":
+		// Leftovers of the previous run must not pass for this run's screenshots
+		if err := os.RemoveAll(currentDir); err != nil {
+			fail(1, "cannot clear %s: %v", currentDir, err)
+		}
+		if fromFl != "" {
+			from := abs(fromFl)
+			if info, err := os.Stat(from); err != nil || !info.IsDir() {
+				fail(1, "-from folder not found: %s", from)
+			}
+			counts, err := copyFolder(from, currentDir, deviceName)
+			if err != nil {
+				fail(1, "cannot copy the screenshots: %v", err)
+			}
+			var devices []string
+			for d := range counts {
+				devices = append(devices, d)
+			}
+			sort.Strings(devices)
+			for _, d := range devices {
+				fmt.Printf(tr("[OK] %s: %d screenshot(s)\n"), d, counts[d])
+				recordAction("collect", d, "ok", fmt.Sprintf("%d screenshot(s)", counts[d]), 0)
+			}
+			if len(devices) == 0 {
+				fail(1, "no PNG or JPG screenshots in %s", from)
+			}
+			recordArtifact(currentDir)
+			fmt.Println(tr("\n[TIP] Run 'unity_screenshot_compare' to compare them with the baselines."))
+			exit(0)
+		}
+
+		if err := lookPath("adb"); err != nil {
+			fail(1, "adb not found in PATH; install the Android SDK platform-tools, or pass -from")
+		}
+		remote := remoteFl
+		if remote == "" {
+			pkg := packageFl
+			if pkg == "" {
+				if pkg, err = readAndroidPackageName(basePath); err != nil {
+					fail(1, "%v; pass -package or -device-dir", err)
+				}
+			}
+			remote = persistentDataPath(pkg) + "/" + remoteFolderName
+		}
+		var serials []string
+		if deviceFl != "" {
+			for _, s := range strings.Split(deviceFl, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					serials = append(serials, s)
+				}
+			}
+		} else if serials, err = listDevices(); err != nil {
+			fail(1, "adb devices: %v", err)
+		}
+		if len(serials) == 0 {
+			fail(1, "no Android device connected")
+		}
+		fmt.Printf(tr("Pulling %s from %d device(s)...\n"), remote, len(serials))
+		names := deviceFolderNames(serials)
+		failed := 0
+		for _, serial := range serials {
+			start := time.Now()
+			n, err := pullDevice(serial, remote, filepath.Join(currentDir, names[serial]), clear)
+			if err != nil {
+				failed++
+				fmt.Printf(tr("[FAIL] %s (%s): %v\n"), names[serial], serial, err)
+				recordAction("collect", names[serial], "failed", err.Error(), time.Since(start))
+				recordError("%s: %v", serial, err)
+				continue
+			}
+			if n == 0 {
+				failed++
+				fmt.Printf(tr("[FAIL] %s (%s): no screenshots in %s\n"), names[serial], serial, remote)
+				recordAction("collect", names[serial], "failed", "no screenshots", time.Since(start))
+				recordError("%s: no screenshots in %s", serial, remote)
+				continue
+			}
+			fmt.Printf(tr("[OK] %s (%s): %d screenshot(s)\n"), names[serial], serial, n)
+			recordAction("collect", names[serial], "ok", fmt.Sprintf("%d screenshot(s) from %s", n, serial), time.Since(start))
+		}
+		recordArtifact(currentDir)
+		if failed > 0 {
+			exit(1)
+		}
+		fmt.Println(tr("\n[TIP] Run 'unity_screenshot_compare' to compare them with the baselines."))
+		exit(0)
+
+	case "approve":
+		current, err := listShots(currentDir)
+		if err != nil {
+			fail(1, "cannot read %s: %v", currentDir, err)
+		}
+		if len(current) == 0 {
+			fail(1, "no screenshots in %s; run 'unity_screenshot_compare collect' first", currentDir)
+		}
+		baseline, err := listShots(baselineDir)
+		if err != nil {
+			fail(1, "cannot read %s: %v", baselineDir, err)
+		}
+		copied, err := approveShots(baselineDir, baseline, current, only, dryRun)
+		for _, key := range copied {
+			status := "ok"
+			if dryRun {
+				status = "planned"
+			}
+			if _, ok := baseline[key]; ok {
+				fmt.Printf(tr("  [UPDATE] %s\n"), key)
+				recordAction("approve", key, status, "replaced", 0)
+			} else {
+				fmt.Printf(tr("  [NEW]    %s\n"), key)
+				recordAction("approve", key, status, "added", 0)
+			}
+		}
+		if err != nil {
+			fail(1, "cannot write the baseline: %v", err)
+		}
+		var pruned []string
+		if prune {
+			pruned, err = pruneShots(baseline, current, only, dryRun)
+			for _, key := range pruned {
+				status := "ok"
+				if dryRun {
+					status = "planned"
+				}
+				fmt.Printf(tr("  [DELETE] %s\n"), key)
+				recordAction("prune", key, status, "deleted", 0)
+			}
+			if err != nil {
+				fail(1, "cannot delete the baseline: %v", err)
+			}
+		}
+		switch {
+		case len(copied) == 0 && len(pruned) == 0:
+			fmt.Println(tr("[--] The baselines already match the current screenshots."))
+		case dryRun:
+			fmt.Printf(tr("\n[DRY-RUN] Would copy %d screenshot(s) to the baseline and delete %d.\n"), len(copied), len(pruned))
+		default:
+			fmt.Printf(tr("\n[OK] Copied %d screenshot(s) to the baseline and deleted %d; commit %s.\n"), len(copied), len(pruned), baselineFl)
+		}
+		exit(0)
+	}
+
+	current, err := listShots(currentDir)
+	if err != nil {
+		fail(1, "cannot read %s: %v", currentDir, err)
+	}
+	if len(current) == 0 {
+		fail(1, "no screenshots in %s; run 'unity_screenshot_compare collect' first", currentDir)
+	}
+	baseline, err := listShots(baselineDir)
+	if err != nil {
+		fail(1, "cannot read %s: %v", baselineDir, err)
+	}
+	// Diff images of the previous run must not show up in this report
+	os.RemoveAll(filepath.Join(reportDir, diffFolderName))
+
+	start := time.Now()
+	fmt.Printf(tr("Comparing %d screenshot(s) with %d baseline(s)...\n"), len(current), len(baseline))
+	opts := diffOptions{threshold: threshold, maxDiff: maxDiff, includeAA: includeAA}
+	results := compareAll(baseline, current, reportDir, opts)
+
+	counts := make(map[string]int)
+	regressions := 0
+	fmt.Println()
+	for _, c := range results {
+		counts[c.Status]++
+		detail := c.Status
+		switch c.Status {
+		case statusChanged, statusSame:
+			detail = fmt.Sprintf("%d pixel(s), %.3f%%", c.DiffPixels, c.Percent)
+		case statusSize:
+			detail = "resolution " + c.Size
+		case statusError:
+			detail = c.Error
+		}
+		status := "ok"
+		if isRegression(c) {
+			regressions++
+			status = "failed"
+		} else if c.Status == statusNew {
+			status = "warning"
+		}
+		recordAction("compare", c.Key, status, detail, 0)
+
+		switch c.Status {
+		case statusChanged:
+			fmt.Printf(tr("  [CHANGED] %s: %d pixel(s) differ (%.3f%%)\n"), c.Key, c.DiffPixels, c.Percent)
+		case statusSize:
+			fmt.Printf(tr("  [SIZE]    %s: %s\n"), c.Key, c.Size)
+		case statusMissing:
+			fmt.Printf(tr("  [MISSING] %s: the device took no such screenshot\n"), c.Key)
+		case statusError:
+			fmt.Printf(tr("  [ERROR]   %s: %s\n"), c.Key, c.Error)
+		case statusNew:
+			fmt.Printf(tr("  [NEW]     %s: no baseline\n"), c.Key)
+		}
+		if isRegression(c) {
+			recordError("%s: %s", c.Key, detail)
+		}
+	}
+
+	reportPath := filepath.Join(reportDir, reportFileName)
+	err = writeReport(reportPath, &reportData{
+		Project:     filepath.Base(basePath),
+		GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
+		Baseline:    baselineFl,
+		Current:     currentFl,
+		MaxDiff:     maxDiff,
+		Counts:      counts,
+		Results:     results,
+	})
+	if err != nil {
+		fail(1, "cannot write %s: %v", reportPath, err)
+	}
+	recordArtifact(reportPath)
+
+	fmt.Println()
+	printRule("=============================================")
+	fmt.Println(tr("  SUMMARY"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Unchanged:  %d\n"), counts[statusSame])
+	fmt.Printf(tr("  Changed:    %d\n"), counts[statusChanged])
+	fmt.Printf(tr("  Resized:    %d\n"), counts[statusSize])
+	fmt.Printf(tr("  Missing:    %d\n"), counts[statusMissing])
+	fmt.Printf(tr("  New:        %d\n"), counts[statusNew])
+	if counts[statusError] > 0 {
+		fmt.Printf(tr("  Unreadable: %d\n"), counts[statusError])
+	}
+	fmt.Printf(tr("  Report:     %s\n"), reportPath)
+	fmt.Printf(tr("  Took:       %v\n"), time.Since(start).Round(time.Millisecond))
+
+	if regressions > 0 || counts[statusNew] > 0 {
+		fmt.Println(tr("\n[TIP] Review the report; run 'unity_screenshot_compare approve' to accept the current screenshots."))
+	}
+	if regressions > 0 || (strict && counts[statusNew] > 0) {
+		exit(1)
+	}
+	exit(0)
+}
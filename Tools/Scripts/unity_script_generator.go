@@ -0,0 +1,2337 @@
+// Unity Script Generator — Create C# scripts from templates, with their .meta files.
+// The namespace follows the nearest .asmdef (its rootNamespace, else its name) plus
+// the folders below it, the header is filled from ProjectSettings (company, product)
+// and .unitystarter.json (author, license), and each script gets a .meta with a fresh
+// GUID so it can be committed before Unity has imported it.
+//
+// Build: go build unity_script_generator.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_script_generator mono PlayerController -dir Assets/Game/Scripts
+//	unity_script_generator so WeaponConfig EnemyConfig -dir Assets/Game/Data
+//	unity_script_generator -dry-run class SaveSystem -namespace Game.Persistence
+//	unity_script_generator -templates Tools/ScriptTemplates mono Door -dir Assets/Game
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	// Shared, company-wide values (author, license, year); the env var points at an
+	// explicit file, otherwise the nearest one above the project root wins
+	sharedConfigFileName = ".unitystarter.json"
+	sharedConfigEnvVar   = "UNITYSTARTER_CONFIG"
+
+	// Optional file in the templates folder that replaces the default header
+	headerTemplateName = "header.txt"
+)
+
+// Placeholders. #SCRIPTNAME#, #NOTRIM# and the #ROOTNAMESPACE...# markers behave
+// as in Unity's own ScriptTemplates, so those files can be copied over as they are.
+const (
+	placeholderScriptName     = "#SCRIPTNAME#"
+	placeholderNamespace      = "#NAMESPACE#"
+	placeholderNamespaceBegin = "#ROOTNAMESPACEBEGIN#"
+	placeholderNamespaceEnd   = "#ROOTNAMESPACEEND#"
+	placeholderNoTrim         = "#NOTRIM#"
+	placeholderHeader         = "#HEADER#"
+	placeholderAuthor         = "#AUTHOR#"
+	placeholderYear           = "#YEAR#"
+	placeholderDate           = "#DATE#"
+	placeholderLicense        = "#LICENSE#"
+	placeholderCompany        = "#COMPANY#"
+	placeholderProduct        = "#PRODUCT#"
+)
+
+// Script kinds and their built-in templates. A "<kind>.cs.txt" file in the
+// templates folder replaces the built-in one.
+var builtinTemplates = map[string]string{
+	"mono": `using UnityEngine;
+
+#ROOTNAMESPACEBEGIN#
+public class #SCRIPTNAME# : MonoBehaviour
+{
+    private void Start()
+    {
+        #NOTRIM#
+    }
+
+    private void Update()
+    {
+        #NOTRIM#
+    }
+}
+#ROOTNAMESPACEEND#
+`,
+	"so": `using UnityEngine;
+
+#ROOTNAMESPACEBEGIN#
+[CreateAssetMenu(fileName = "#SCRIPTNAME#", menuName = "#PRODUCT#/#SCRIPTNAME#")]
+public class #SCRIPTNAME# : ScriptableObject
+{
+}
+#ROOTNAMESPACEEND#
+`,
+	"class": `#ROOTNAMESPACEBEGIN#
+public class #SCRIPTNAME#
+{
+}
+#ROOTNAMESPACEEND#
+`,
+	"interface": `#ROOTNAMESPACEBEGIN#
+public interface #SCRIPTNAME#
+{
+}
+#ROOTNAMESPACEEND#
+`,
+	"enum": `#ROOTNAMESPACEBEGIN#
+public enum #SCRIPTNAME#
+{
+}
+#ROOTNAMESPACEEND#
+`,
+}
+
+// Longer spellings accepted on the command line
+var kindAliases = map[string]string{
+	"monobehaviour":    "mono",
+	"behaviour":        "mono",
+	"scriptableobject": "so",
+}
+
+// Lines whose placeholders are all unknown are dropped, so an empty license
+// does not leave "// SPDX-License-Identifier: " behind
+const defaultHeader = `// Copyright (c) #YEAR# #COMPANY#
+// SPDX-License-Identifier: #LICENSE#
+// Author: #AUTHOR#
+`
+
+const scriptMetaTemplate = `fileFormatVersion: 2
+guid: %s
+MonoImporter:
+  externalObjects: {}
+  serializedVersion: 2
+  defaultReferences: []
+  executionOrder: 0
+  icon: {instanceID: 0}
+  userData:
+  assetBundleName:
+  assetBundleVariant:
+`
+
+// Folder .meta written for output folders that do not exist yet
+const folderMetaTemplate = `fileFormatVersion: 2
+guid: %s
+folderAsset: yes
+DefaultImporter:
+  externalObjects: {}
+  userData:
+  assetBundleName:
+  assetBundleVariant:
+`
+
+// Conventional folders that group scripts but are not part of the namespace
+var namespaceSkipDirs = map[string]bool{
+	"Scripts": true,
+	"Script":  true,
+	"Source":  true,
+}
+
+var identifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// C# keywords that cannot be used as a type name
+var csharpKeywords = map[string]bool{
+	"abstract": true, "bool": true, "break": true, "byte": true, "case": true, "catch": true,
+	"char": true, "checked": true, "class": true, "const": true, "continue": true, "decimal": true,
+	"default": true, "delegate": true, "do": true, "double": true, "else": true, "enum": true,
+	"event": true, "explicit": true, "extern": true, "false": true, "finally": true, "fixed": true,
+	"float": true, "for": true, "foreach": true, "goto": true, "if": true, "implicit": true,
+	"in": true, "int": true, "interface": true, "internal": true, "is": true, "lock": true,
+	"long": true, "namespace": true, "new": true, "null": true, "object": true, "operator": true,
+	"out": true, "override": true, "params": true, "private": true, "protected": true, "public": true,
+	"readonly": true, "ref": true, "return": true, "sbyte": true, "sealed": true, "short": true,
+	"sizeof": true, "stackalloc": true, "static": true, "string": true, "struct": true, "switch": true,
+	"this": true, "throw": true, "true": true, "try": true, "typeof": true, "uint": true,
+	"ulong": true, "unchecked": true, "unsafe": true, "ushort": true, "using": true, "virtual": true,
+	"void": true, "volatile": true, "while": true,
+}
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// SharedConfig is the company-wide .unitystarter.json, shared with rename_project.
+// ScriptTemplates is resolved relative to the file.
+type SharedConfig struct {
+	Author          string `json:"author"`
+	License         string `json:"license"`       // SPDX identifier, e.g. "MIT"
+	CopyrightYear   string `json:"copyrightYear"` // defaults to the current year
+	ScriptTemplates string `json:"scriptTemplates"`
+}
+
+// projectInfo is what the templates need from ProjectSettings
+type projectInfo struct {
+	company        string
+	product        string
+	rootNamespace  string // EditorSettings "Root namespace", used outside any asmdef
+	crlf           bool   // EditorSettings "Line Endings For New Scripts"
+	hasLineSetting bool
+}
+
+// script is one file about to be generated
+type script struct {
+	name      string
+	kind      string
+	path      string // project-relative, forward slashes
+	namespace string
+	source    string
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Project Settings
+// ============================================================
+
+// readProjectInfo reads company/product from ProjectSettings.asset and the script
+// settings from EditorSettings.asset. Missing files leave the fields empty.
+func readProjectInfo(basePath string) projectInfo {
+	var info projectInfo
+	field := func(text, key string) (string, bool) {
+		m := regexp.MustCompile(`(?m)^\s*` + key + `:[ \t]*(.*?)\s*$`).FindStringSubmatch(text)
+		if m == nil {
+			return "", false
+		}
+		return m[1], true
+	}
+	if data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectSettings.asset")); err == nil {
+		info.company, _ = field(string(data), "companyName")
+		info.product, _ = field(string(data), "productName")
+	}
+	if data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "EditorSettings.asset")); err == nil {
+		info.rootNamespace, _ = field(string(data), "m_ProjectGenerationRootNamespace")
+		// 0 = OS native, 1 = Unix (LF), 2 = Windows (CRLF)
+		if mode, ok := field(string(data), "m_LineEndingsForNewScripts"); ok {
+			info.hasLineSetting = true
+			info.crlf = mode == "2" || (mode == "0" && runtime.GOOS == "windows")
+		}
+	}
+	if !info.hasLineSetting {
+		info.crlf = runtime.GOOS == "windows"
+	}
+	return info
+}
+
+// loadSharedConfig reads UNITYSTARTER_CONFIG, or else the first .unitystarter.json
+// found from the project root upward. Returns an empty config when none exists.
+func loadSharedConfig(projectRoot string) (*SharedConfig, string, error) {
+	path := os.Getenv(sharedConfigEnvVar)
+	if path == "" {
+		abs, err := filepath.Abs(projectRoot)
+		if err != nil {
+			return &SharedConfig{}, "", nil
+		}
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			candidate := filepath.Join(dir, sharedConfigFileName)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				return &SharedConfig{}, "", nil
+			}
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, path, err
+	}
+	var cfg SharedConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, path, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	if cfg.ScriptTemplates != "" && !filepath.IsAbs(cfg.ScriptTemplates) {
+		cfg.ScriptTemplates = filepath.Join(filepath.Dir(path), filepath.FromSlash(cfg.ScriptTemplates))
+	}
+	return &cfg, path, nil
+}
+
+// ============================================================
+// Shared Policy
+// ============================================================
+
+// A studio keeps its policy (clean lists, package categories, naming rules) in one
+// JSON document that "policy" in .unitystarter.json points at: an HTTP(S) URL, a
+// file in a git repository at a pinned revision, or a local path. Fetched copies
+// are cached in the user cache folder; a pinned commit or content hash is fetched
+// once, anything else again after "refresh". When a fetch fails the cached copy is
+// used with a warning, so tools keep working offline.
+const (
+	policyConfigFileName = ".unitystarter.json"
+	policyConfigEnvVar   = "UNITYSTARTER_CONFIG"
+	policyOfflineEnvVar  = "UNITYSTARTER_POLICY_OFFLINE" // "1": only use the cached copy
+	policyDefaultFile    = "unitystarter-policy.json"    // git: path in the repository
+	policyDefaultRefresh = time.Hour
+	policyTimeout        = 30 * time.Second
+)
+
+// policySource is the "policy" object of .unitystarter.json
+type policySource struct {
+	Source   string `json:"source"`   // https://..., git+https://....git, git@host:repo.git or a path
+	Revision string `json:"revision"` // git: commit, tag or branch (default: HEAD)
+	File     string `json:"file"`     // git: path of the document in the repository
+	SHA256   string `json:"sha256"`   // http: expected hash of the document; pins it
+	Refresh  string `json:"refresh"`  // how long an unpinned copy is used, e.g. "30m" (default: 1h)
+}
+
+// sharedPolicy is the policy document. The schema is shared by all tools in this
+// folder; each one reads the parts it applies and ignores the rest.
+type sharedPolicy struct {
+	Clean *struct {
+		Directories []string `json:"directories"` // replaces the built-in top-level folders
+		Extensions  []string `json:"extensions"`  // replaces the built-in top-level file extensions
+	} `json:"clean"`
+	Packages *struct {
+		Categories []struct {
+			Name     string   `json:"name"`
+			Packages []string `json:"packages"`
+		} `json:"categories"` // replaces the built-in removal categories
+	} `json:"packages"`
+	Naming *struct {
+		Scripts  string `json:"scripts"`  // regex new script names must match
+		Packages string `json:"packages"` // regex new package names must match
+	} `json:"naming"`
+}
+
+var gitCommitRegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// loadPolicy reads "policy" from .unitystarter.json (found like loadSharedConfig)
+// and returns the document with a description of where it came from. Returns nil
+// when no policy is configured.
+func loadPolicy(projectRoot string) (*sharedPolicy, string, error) {
+	path := os.Getenv(policyConfigEnvVar)
+	if path == "" {
+		abs, err := filepath.Abs(projectRoot)
+		if err != nil {
+			return nil, "", nil
+		}
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			candidate := filepath.Join(dir, policyConfigFileName)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				return nil, "", nil
+			}
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	var file struct {
+		Policy *policySource `json:"policy"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, "", fmt.Errorf("invalid %s: %v", path, err)
+	}
+	if file.Policy == nil || strings.TrimSpace(file.Policy.Source) == "" {
+		return nil, "", nil
+	}
+
+	doc, origin, err := fetchPolicy(file.Policy, filepath.Dir(path))
+	if err != nil {
+		return nil, origin, fmt.Errorf("policy %s: %v", origin, err)
+	}
+	var policy sharedPolicy
+	if err := json.Unmarshal(doc, &policy); err != nil {
+		return nil, origin, fmt.Errorf("invalid policy %s: %v", origin, err)
+	}
+	return &policy, origin, nil
+}
+
+// isGitSource reports whether a policy source names a git repository
+func isGitSource(source string) bool {
+	return strings.HasPrefix(source, "git+") || strings.HasPrefix(source, "git@") ||
+		strings.HasPrefix(source, "ssh://") || strings.HasSuffix(source, ".git")
+}
+
+// fetchPolicy returns the policy document, from the cache when it is pinned,
+// fresh enough or offline, and from the source otherwise
+func fetchPolicy(src *policySource, configDir string) ([]byte, string, error) {
+	source := strings.TrimSpace(src.Source)
+	isGit := isGitSource(source)
+	isHTTP := !isGit && (strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://"))
+	if !isGit && !isHTTP {
+		p := source
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(configDir, filepath.FromSlash(p))
+		}
+		data, err := os.ReadFile(p)
+		return data, p, err
+	}
+
+	revision, file := src.Revision, src.File
+	if isGit {
+		if revision == "" {
+			revision = "HEAD"
+		}
+		if file == "" {
+			file = policyDefaultFile
+		}
+	}
+	origin := source
+	if isGit {
+		origin = source + "@" + revision + ":" + file
+	}
+	refresh := policyDefaultRefresh
+	if src.Refresh != "" {
+		d, err := time.ParseDuration(src.Refresh)
+		if err != nil {
+			return nil, origin, fmt.Errorf("invalid refresh '%s': %v", src.Refresh, err)
+		}
+		refresh = d
+	}
+	pinned := (isGit && gitCommitRegex.MatchString(strings.ToLower(revision))) || (isHTTP && src.SHA256 != "")
+	matchesPin := func(data []byte) bool {
+		if src.SHA256 == "" {
+			return true
+		}
+		sum := sha256.Sum256(data)
+		return strings.EqualFold(hex.EncodeToString(sum[:]), strings.TrimSpace(src.SHA256))
+	}
+
+	// One cache file per source, revision and path
+	key := sha256.Sum256([]byte(source + "\n" + revision + "\n" + file))
+	cachePath := ""
+	if dir, err := os.UserCacheDir(); err == nil {
+		cachePath = filepath.Join(dir, "UnityStarter", "policy", hex.EncodeToString(key[:8])+".json")
+	}
+	var cached []byte
+	var cachedAt time.Time
+	if cachePath != "" {
+		if info, err := os.Stat(cachePath); err == nil {
+			if data, err := os.ReadFile(cachePath); err == nil && matchesPin(data) {
+				cached, cachedAt = data, info.ModTime()
+			}
+		}
+	}
+	if cached != nil && (pinned || time.Since(cachedAt) < refresh) {
+		return cached, origin, nil
+	}
+	if os.Getenv(policyOfflineEnvVar) == "1" {
+		if cached != nil {
+			return cached, origin, nil
+		}
+		return nil, origin, fmt.Errorf("%s is set and there is no cached copy", policyOfflineEnvVar)
+	}
+
+	var data []byte
+	var err error
+	if isGit {
+		data, err = fetchGitPolicy(strings.TrimPrefix(source, "git+"), revision, file)
+	} else {
+		data, err = fetchHTTPPolicy(source)
+	}
+	if err == nil && !matchesPin(data) {
+		err = fmt.Errorf("the document does not match the pinned sha256")
+	}
+	if err != nil {
+		if cached != nil {
+			fmt.Printf(tr("[WARNING] Cannot refresh the policy, using the copy cached %s: %v\n"), cachedAt.Format("2006-01-02 15:04"), err)
+			return cached, origin, nil
+		}
+		return nil, origin, err
+	}
+	if cachePath != "" {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err == nil {
+			tmp := cachePath + ".tmp"
+			if os.WriteFile(tmp, data, 0644) == nil {
+				os.Rename(tmp, cachePath)
+			}
+		}
+	}
+	return data, origin, nil
+}
+
+func fetchHTTPPolicy(source string) ([]byte, error) {
+	client := &http.Client{Timeout: policyTimeout}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+}
+
+// fetchGitPolicy reads one file at a revision without a full clone: a shallow
+// fetch of just that revision into a scratch repository
+func fetchGitPolicy(repo, revision, file string) ([]byte, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git is not installed")
+	}
+	tmp, err := os.MkdirTemp("", "unitystarter-policy-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	run := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("git", append([]string{"-C", tmp}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("git %s: %v %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return out, nil
+	}
+	if _, err := run("init", "-q"); err != nil {
+		return nil, err
+	}
+	if _, err := run("fetch", "-q", "--depth", "1", repo, revision); err != nil {
+		return nil, err
+	}
+	return run("show", "FETCH_HEAD:"+strings.TrimPrefix(filepath.ToSlash(file), "/"))
+}
+
+// namingRule compiles a naming regex of the policy; nil when there is none
+func namingRule(pattern string) (*regexp.Regexp, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid naming rule '%s' in the policy: %v", pattern, err)
+	}
+	return re, nil
+}
+
+// ============================================================
+// Namespace
+// ============================================================
+
+// findAsmdef returns the nearest .asmdef at or above dir (project-relative), stopping
+// at Assets/ or the package root
+func findAsmdef(basePath, dir string) (string, error) {
+	for d := dir; d != "." && d != "/" && d != "Packages"; d = path.Dir(d) {
+		entries, err := fsys.ReadDir(filepath.Join(basePath, filepath.FromSlash(d)))
+		if err == nil {
+			for _, e := range entries {
+				if !e.IsDir() && strings.HasSuffix(e.Name(), ".asmdef") {
+					return path.Join(d, e.Name()), nil
+				}
+			}
+		}
+		if d == "Assets" {
+			break
+		}
+	}
+	return "", nil
+}
+
+// sanitizeNamespacePart turns a folder name into a namespace segment:
+// "My Game" -> "MyGame", "2D" -> "_2D"
+func sanitizeNamespacePart(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	part := b.String()
+	if part != "" && part[0] >= '0' && part[0] <= '9' {
+		part = "_" + part
+	}
+	return part
+}
+
+// deriveNamespace builds the namespace for scripts in dir: the asmdef's rootNamespace
+// (or its name) plus the folders below the asmdef; outside any asmdef, the project's
+// root namespace plus the folders below Assets/. Empty when neither is set.
+func deriveNamespace(basePath, dir string, info projectInfo) (string, string, error) {
+	asmdef, err := findAsmdef(basePath, dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	root, base, source := info.rootNamespace, "Assets", "EditorSettings root namespace"
+	if asmdef != "" {
+		data, err := fsys.ReadFile(filepath.Join(basePath, filepath.FromSlash(asmdef)))
+		if err != nil {
+			return "", "", err
+		}
+		var def struct {
+			Name          string `json:"name"`
+			RootNamespace string `json:"rootNamespace"`
+		}
+		if err := json.Unmarshal(bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF}), &def); err != nil {
+			return "", "", fmt.Errorf("invalid %s: %v", asmdef, err)
+		}
+		root, base, source = def.RootNamespace, path.Dir(asmdef), asmdef
+		if root == "" {
+			root = def.Name
+		}
+	}
+	if root == "" {
+		return "", "", nil
+	}
+
+	parts := []string{root}
+	if rel := strings.TrimPrefix(strings.TrimPrefix(dir, base), "/"); rel != "" && rel != dir {
+		for _, seg := range strings.Split(rel, "/") {
+			if namespaceSkipDirs[seg] {
+				continue
+			}
+			if part := sanitizeNamespacePart(seg); part != "" {
+				parts = append(parts, part)
+			}
+		}
+	}
+	return strings.Join(parts, "."), source, nil
+}
+
+// ============================================================
+// Templates
+// ============================================================
+
+// loadTemplate returns the template for kind: "<kind>.cs.txt" from templatesDir when
+// present, else the built-in one
+func loadTemplate(templatesDir, kind string) (string, string, error) {
+	if templatesDir != "" {
+		file := filepath.Join(templatesDir, kind+".cs.txt")
+		data, err := os.ReadFile(file)
+		if err == nil {
+			return normalizeTemplate(data), file, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", file, err
+		}
+	}
+	tmpl, ok := builtinTemplates[kind]
+	if !ok {
+		return "", "", fmt.Errorf("unknown script kind '%s'", kind)
+	}
+	return tmpl, "built-in", nil
+}
+
+// loadHeader returns header.txt from templatesDir, else the default header
+func loadHeader(templatesDir string) (string, error) {
+	if templatesDir != "" {
+		data, err := os.ReadFile(filepath.Join(templatesDir, headerTemplateName))
+		if err == nil {
+			return normalizeTemplate(data), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+	return defaultHeader, nil
+}
+
+// normalizeTemplate strips a BOM and converts line endings to LF
+func normalizeTemplate(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})
+	return strings.ReplaceAll(string(data), "\r\n", "\n")
+}
+
+// fillHeader replaces the header placeholders, dropping lines that only had
+// unknown values so optional fields (license, author) leave no empty labels
+func fillHeader(header string, vars map[string]string) string {
+	var out []string
+	for _, line := range strings.Split(strings.TrimRight(header, "\n"), "\n") {
+		known, missing := 0, 0
+		for key, value := range vars {
+			if !strings.Contains(line, key) {
+				continue
+			}
+			if value == "" {
+				missing++
+			} else {
+				known++
+			}
+			line = strings.ReplaceAll(line, key, value)
+		}
+		if missing > 0 && known == 0 {
+			continue
+		}
+		out = append(out, line)
+	}
+	if len(out) == 0 {
+		return ""
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// renderScript fills a template. Lines between #ROOTNAMESPACEBEGIN# and
+// #ROOTNAMESPACEEND# are indented into the namespace block, or left as they are
+// when there is no namespace; trailing whitespace is trimmed except on #NOTRIM#
+// lines, which keep their indentation as an empty line.
+func renderScript(tmpl, header string, vars map[string]string, namespace string) string {
+	if header != "" && !strings.Contains(tmpl, placeholderHeader) {
+		tmpl = placeholderHeader + "\n\n" + tmpl
+	}
+
+	var out []string
+	inNamespace := false
+	for _, line := range strings.Split(tmpl, "\n") {
+		switch strings.TrimSpace(line) {
+		case placeholderNamespaceBegin:
+			if namespace != "" {
+				out = append(out, "namespace "+namespace, "{")
+			}
+			inNamespace = true
+			continue
+		case placeholderNamespaceEnd:
+			if namespace != "" {
+				out = append(out, "}")
+			}
+			inNamespace = false
+			continue
+		case placeholderHeader:
+			if header == "" {
+				continue
+			}
+			out = append(out, strings.Split(strings.TrimRight(header, "\n"), "\n")...)
+			continue
+		}
+
+		keep := strings.Contains(line, placeholderNoTrim)
+		line = strings.ReplaceAll(line, placeholderNoTrim, "")
+		for key, value := range vars {
+			line = strings.ReplaceAll(line, key, value)
+		}
+		if inNamespace && namespace != "" && line != "" {
+			line = "    " + line
+		}
+		if !keep {
+			line = strings.TrimRight(line, " \t")
+		}
+		out = append(out, line)
+	}
+
+	// Collapse what the removed markers leave at the ends
+	text := strings.Join(out, "\n")
+	text = strings.TrimLeft(text, "\n")
+	return strings.TrimRight(text, "\n") + "\n"
+}
+
+// ============================================================
+// Generation
+// ============================================================
+
+// validateScriptName rejects names that are not usable as a C# type and file name
+func validateScriptName(name string) error {
+	if !identifierRegex.MatchString(name) {
+		return fmt.Errorf("'%s' is not a valid C# type name", name)
+	}
+	if csharpKeywords[name] {
+		return fmt.Errorf("'%s' is a C# keyword", name)
+	}
+	return nil
+}
+
+// findSameName lists other scripts with the same file name. Unity matches
+// MonoBehaviour and ScriptableObject classes to their file, so a second
+// "Player.cs" in the same namespace is a compile error and in another one an
+// easy source of confusion.
+func findSameName(basePath, name string) []string {
+	var found []string
+	for _, root := range []string{"Assets", "Packages"} {
+		fsys.Walk(filepath.Join(basePath, root), func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !info.IsDir() && info.Name() == name+".cs" {
+				rel, _ := filepath.Rel(basePath, p)
+				found = append(found, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+	}
+	sort.Strings(found)
+	return found
+}
+
+// newGUID returns a random 32-digit hex GUID in Unity's .meta format
+func newGUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ensureFolders creates dir and its missing parents, each with a .meta so the new
+// folders get stable GUIDs instead of ones Unity invents on the next import
+func ensureFolders(basePath, dir string) ([]string, error) {
+	var created []string
+	var missing []string
+	for d := dir; d != "Assets" && d != "." && d != "/"; d = path.Dir(d) {
+		if _, err := fsys.Stat(filepath.Join(basePath, filepath.FromSlash(d))); err == nil {
+			break
+		}
+		missing = append(missing, d)
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		abs := filepath.Join(basePath, filepath.FromSlash(missing[i]))
+		if err := fsys.MkdirAll(abs, 0755); err != nil {
+			return created, err
+		}
+		meta := fmt.Sprintf(folderMetaTemplate, newGUID())
+		if err := writeFileAtomic(abs+".meta", []byte(meta)); err != nil {
+			return created, err
+		}
+		activeVCS.add(abs + ".meta")
+		created = append(created, missing[i])
+	}
+	return created, nil
+}
+
+// writeScript writes the script and its .meta and marks both for add
+func writeScript(basePath string, s *script, format textFormat) error {
+	abs := filepath.Join(basePath, filepath.FromSlash(s.path))
+	if err := writeTextFileAtomic(abs, s.source, format); err != nil {
+		return err
+	}
+	meta := fmt.Sprintf(scriptMetaTemplate, newGUID())
+	if err := writeTextFileAtomic(abs+".meta", meta, textFormat{}); err != nil {
+		return fmt.Errorf("wrote %s but not its .meta: %v", s.path, err)
+	}
+	activeVCS.add(abs)
+	activeVCS.add(abs + ".meta")
+	return nil
+}
+
+// normalizeAssetPath turns user input into a clean "Assets/..." path
+func normalizeAssetPath(p string) string {
+	p = strings.TrimSpace(strings.Trim(strings.TrimSpace(p), `"'`))
+	p = path.Clean(filepath.ToSlash(p))
+	return strings.TrimSuffix(strings.TrimPrefix(p, "./"), "/")
+}
+
+// templateVars maps the header and template placeholders to their values
+func templateVars(cfg *SharedConfig, info projectInfo, year string) map[string]string {
+	if year == "" {
+		year = cfg.CopyrightYear
+	}
+	if year == "" {
+		year = strconv.Itoa(time.Now().Year())
+	}
+	return map[string]string{
+		placeholderYear:    year,
+		placeholderDate:    time.Now().Format("2006-01-02"),
+		placeholderCompany: info.company,
+		placeholderProduct: info.product,
+		placeholderAuthor:  cfg.Author,
+		placeholderLicense: cfg.License,
+	}
+}
+
+// ============================================================
+// Version Control
+// ============================================================
+
+// New scripts and their .meta files are marked for add (p4 add, cm add) so they
+// land in the next changelist / changeset; existing files are checked out before
+// -force overwrites them. Git needs nothing: new files show up as untracked.
+const (
+	vcsNone     = "none"
+	vcsPerforce = "p4"
+	vcsPlastic  = "plastic"
+)
+
+// vcsEnvVar overrides detection for every tool (e.g. on build agents)
+const vcsEnvVar = "UNITYSTARTER_VCS"
+
+// vcsClient runs checkouts and adds for files under version control
+type vcsClient struct {
+	kind       string
+	source     string // how kind was chosen, for the startup message
+	checkedOut map[string]bool
+}
+
+// activeVCS is set up in main; nil means no version control commands
+var activeVCS *vcsClient
+
+// detectVCS picks the provider. Priority: -vcs flag > UNITYSTARTER_VCS >
+// Unity's VersionControlSettings.asset mode > workspace markers (.plastic, P4CONFIG).
+func detectVCS(projectRoot, override string) (*vcsClient, error) {
+	normalize := func(v string) (string, bool) {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "p4", "perforce":
+			return vcsPerforce, true
+		case "plastic", "plasticscm", "cm", "unityvcs":
+			return vcsPlastic, true
+		case "none", "git", "off":
+			return vcsNone, true
+		}
+		return "", false
+	}
+	newClient := func(kind, source string) *vcsClient {
+		return &vcsClient{kind: kind, source: source, checkedOut: make(map[string]bool)}
+	}
+
+	if override != "" && override != "auto" {
+		kind, ok := normalize(override)
+		if !ok {
+			return nil, fmt.Errorf("unknown -vcs value '%s' (use auto, none, p4 or plastic)", override)
+		}
+		return newClient(kind, "-vcs flag"), nil
+	}
+	if env := os.Getenv(vcsEnvVar); env != "" {
+		if kind, ok := normalize(env); ok {
+			return newClient(kind, vcsEnvVar), nil
+		}
+	}
+
+	// Unity stores the project's integrated VCS in m_Mode ("Perforce", "PlasticSCM", ...)
+	if data, err := os.ReadFile(filepath.Join(projectRoot, "ProjectSettings", "VersionControlSettings.asset")); err == nil {
+		if m := regexp.MustCompile(`(?m)^\s*m_Mode:\s*(.+?)\s*$`).FindSubmatch(data); m != nil {
+			switch strings.ToLower(string(m[1])) {
+			case "perforce":
+				return newClient(vcsPerforce, "VersionControlSettings.asset"), nil
+			case "plasticscm", "plastic scm", "unity version control":
+				return newClient(vcsPlastic, "VersionControlSettings.asset"), nil
+			}
+		}
+	}
+
+	// Workspace markers, searched upward from the project root
+	p4config := os.Getenv("P4CONFIG")
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info != nil {
+				return newClient(vcsNone, "git repository"), nil
+			}
+			if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+				return newClient(vcsPlastic, ".plastic workspace"), nil
+			}
+			if p4config != "" {
+				if _, err := os.Stat(filepath.Join(dir, p4config)); err == nil {
+					return newClient(vcsPerforce, "P4CONFIG"), nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if os.Getenv("P4CLIENT") != "" {
+		return newClient(vcsPerforce, "P4CLIENT"), nil
+	}
+	return newClient(vcsNone, "no Perforce/Plastic workspace found"), nil
+}
+
+// run executes a VCS command from dir; a dry run only lists it
+func (v *vcsClient) run(dir string, args ...string) error {
+	if overlay, ok := fsys.(*overlayFS); ok {
+		overlay.noteCommand(args...)
+		return nil
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	// Run from the file's folder so P4CONFIG / the Plastic workspace is resolved
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %v %s", args[0], args[1], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// checkout opens an existing file for edit. Files outside the depot/workspace only
+// produce a warning: the write still goes ahead.
+func (v *vcsClient) checkout(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || v.checkedOut[abs] {
+		return
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return
+	}
+	v.checkedOut[abs] = true
+
+	var err2 error
+	switch v.kind {
+	case vcsPerforce:
+		err2 = v.run(filepath.Dir(abs), "p4", "edit", abs)
+	case vcsPlastic:
+		err2 = v.run(filepath.Dir(abs), "cm", "checkout", abs)
+	}
+	if err2 != nil {
+		fmt.Printf(tr("[WARNING] %s checkout failed for %s: %v\n"), v.kind, filepath.Base(abs), err2)
+		return
+	}
+	fmt.Printf(tr("[VCS] Checked out (%s): %s\n"), v.kind, filepath.Base(abs))
+}
+
+// add marks a newly created file for add
+func (v *vcsClient) add(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	var err2 error
+	switch v.kind {
+	case vcsPerforce:
+		err2 = v.run(filepath.Dir(abs), "p4", "add", abs)
+	case vcsPlastic:
+		err2 = v.run(filepath.Dir(abs), "cm", "add", abs)
+	}
+	if err2 != nil {
+		fmt.Printf(tr("[WARNING] %s add failed for %s: %v\n"), v.kind, filepath.Base(abs), err2)
+	}
+}
+
+// ============================================================
+// Safe File Writes
+// ============================================================
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// writeFileAtomic replaces path with data without ever leaving a half-written file:
+// data goes to a temp file in the same directory which is then renamed over the target.
+// The target's permission bits are kept, and a read-only flag (files under Perforce or
+// Plastic SCM that were not checked out) is cleared so the write does not fail.
+func writeFileAtomic(path string, data []byte) error {
+	// A dry run writes straight into the overlay: no checkout, no temp file
+	if isDryRun() {
+		return fsys.WriteFile(path, data, 0644)
+	}
+	activeVCS.checkout(path)
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if mode&0200 == 0 {
+			mode |= 0200
+			if err := os.Chmod(path, mode); err != nil {
+				return fmt.Errorf("cannot clear read-only flag on %s: %v", path, err)
+			}
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Retry briefly: editors, indexers and antivirus can hold the target open on Windows
+	for attempt := 0; ; attempt++ {
+		err = os.Rename(tmpPath, path)
+		if err == nil || attempt == 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// textFormat records the BOM and line-ending style of a text file so rewrites keep it
+type textFormat struct {
+	bom  bool
+	crlf bool // ending of most lines, and of the lines a rewrite adds
+	// Mixed files only: the text as read (LF) and the ending of each of its line
+	// breaks, 'r' (CRLF) or 'n' (LF). Strings keep textFormat comparable.
+	orig string
+	eols string
+}
+
+// decodeText strips a UTF-8 BOM and normalizes line endings to LF. A file counts as
+// CRLF when most of its line breaks are CRLF; a file with both keeps the ending of
+// every line, so a rewrite only changes the endings of the lines it edits.
+func decodeText(data []byte) (string, textFormat) {
+	var format textFormat
+	if bytes.HasPrefix(data, utf8BOM) {
+		format.bom = true
+		data = data[len(utf8BOM):]
+	}
+	crlfCount := bytes.Count(data, []byte("\r\n"))
+	lfCount := bytes.Count(data, []byte("\n")) - crlfCount
+	format.crlf = crlfCount > 0 && crlfCount >= lfCount
+	if crlfCount == 0 {
+		return string(data), format
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if lfCount > 0 {
+		eols := make([]byte, 0, crlfCount+lfCount)
+		for i, c := range data {
+			if c == '\n' {
+				if i > 0 && data[i-1] == '\r' {
+					eols = append(eols, 'r')
+				} else {
+					eols = append(eols, 'n')
+				}
+			}
+		}
+		format.orig, format.eols = text, string(eols)
+	}
+	return text, format
+}
+
+// encode converts LF text back to the recorded format
+func (f textFormat) encode(text string) []byte {
+	switch {
+	case f.eols != "":
+		text = f.mixedEndings(text)
+	case f.crlf:
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	if f.bom {
+		return append(append([]byte{}, utf8BOM...), text...)
+	}
+	return []byte(text)
+}
+
+// mixedEndings gives the lines of a rewritten mixed file the endings they had. The
+// unchanged lines before and after the edited region keep theirs; edited lines keep
+// the ending of the line they replace when the line count did not change, and get
+// the majority ending otherwise.
+func (f textFormat) mixedEndings(text string) string {
+	old := strings.Split(f.orig, "\n")
+	lines := strings.Split(text, "\n")
+	prefix := 0
+	for prefix < len(old) && prefix < len(lines) && old[prefix] == lines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(lines)-prefix && old[len(old)-1-suffix] == lines[len(lines)-1-suffix] {
+		suffix++
+	}
+	crlfAt := func(j int) bool {
+		if j >= 0 && j < len(f.eols) {
+			return f.eols[j] == 'r'
+		}
+		return f.crlf
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		b.WriteString(line)
+		if i == len(lines)-1 {
+			break
+		}
+		crlf := f.crlf
+		switch {
+		case i < prefix || len(lines) == len(old):
+			crlf = crlfAt(i)
+		case i >= len(lines)-suffix:
+			crlf = crlfAt(i - len(lines) + len(old))
+		}
+		if crlf {
+			b.WriteByte('\r')
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// writeTextFileAtomic writes LF text in the given format via writeFileAtomic
+func writeTextFileAtomic(path, text string, format textFormat) error {
+	return writeFileAtomic(path, format.encode(text))
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Filesystem
+// ============================================================
+
+// fileSystem is every filesystem call that can change the project. The real
+// run uses osFS; -dry-run swaps in an overlayFS so the same code path runs
+// and the overlay reports what it would have changed.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Walk(root string, fn filepath.WalkFunc) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Chmod(name string, mode os.FileMode) error
+}
+
+var fsys fileSystem = osFS{}
+
+// isDryRun reports whether changes are going to the in-memory overlay
+func isDryRun() bool {
+	_, ok := fsys.(*overlayFS)
+	return ok
+}
+
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)         { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) Walk(root string, fn filepath.WalkFunc) error { return filepath.Walk(root, fn) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// fsChange is one change the overlay absorbed instead of the disk
+type fsChange struct {
+	op     string // "write", "create", "mkdir", "rename", "delete"
+	path   string
+	detail string
+}
+
+// overlayFS reads through to the disk and keeps every write, rename and
+// delete in memory. Later reads see those changes, so multi-step operations
+// (rename a folder, then rewrite files inside it) behave as they would for real.
+type overlayFS struct {
+	mu      sync.Mutex
+	files   map[string][]byte // files written during the run
+	dirs    map[string]bool   // directories created during the run
+	moved   map[string]string // renamed path -> where its content is on disk
+	removed map[string]bool   // deleted paths; hides everything below them
+	changes []fsChange
+}
+
+func newOverlayFS() *overlayFS {
+	return &overlayFS{
+		files:   map[string][]byte{},
+		dirs:    map[string]bool{},
+		moved:   map[string]string{},
+		removed: map[string]bool{},
+	}
+}
+
+func overlayKey(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// isUnder reports whether p is base or inside it
+func isUnder(p, base string) bool {
+	return p == base || strings.HasPrefix(p, strings.TrimSuffix(base, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// locate finds where the content of p lives: in memory (data / memDir) or
+// on disk (disk, after following renames). ok is false if p was deleted.
+func (o *overlayFS) locate(p string) (data []byte, memFile, memDir bool, disk string, ok bool) {
+	if d, found := o.files[p]; found {
+		return d, true, false, "", true
+	}
+	if o.dirs[p] {
+		return nil, false, true, "", true
+	}
+	for q := p; ; {
+		if src, found := o.moved[q]; found {
+			rel, _ := filepath.Rel(q, p)
+			return nil, false, false, filepath.Join(src, rel), true
+		}
+		if o.removed[q] {
+			return nil, false, false, "", false
+		}
+		parent := filepath.Dir(q)
+		if parent == q {
+			break
+		}
+		q = parent
+	}
+	return nil, false, false, p, true
+}
+
+func (o *overlayFS) stat(p string) (os.FileInfo, error) {
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	case memFile:
+		return memFileInfo{name: filepath.Base(p), size: int64(len(data))}, nil
+	case memDir:
+		return memFileInfo{name: filepath.Base(p), dir: true}, nil
+	}
+	info, err := os.Stat(disk)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	if disk != p {
+		return renamedFileInfo{info, filepath.Base(p)}, nil
+	}
+	return info, nil
+}
+
+func (o *overlayFS) Stat(name string) (os.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stat(overlayKey(name))
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case memDir:
+		return nil, &os.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	case memFile:
+		return append([]byte(nil), data...), nil
+	}
+	return os.ReadFile(disk)
+}
+
+func (o *overlayFS) readDir(p string) ([]os.DirEntry, error) {
+	_, memFile, memDir, disk, ok := o.locate(p)
+	if !ok || memFile {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+	names := map[string]bool{}
+	if !memDir {
+		entries, err := os.ReadDir(disk)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			names[e.Name()] = true
+		}
+	}
+	for k := range o.dirs {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.files {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.moved {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	var result []os.DirEntry
+	for name := range names {
+		if info, err := o.stat(filepath.Join(p, name)); err == nil {
+			result = append(result, fs.FileInfoToDirEntry(info))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (o *overlayFS) ReadDir(name string) ([]os.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readDir(overlayKey(name))
+}
+
+// Walk follows filepath.Walk: lexical order, SkipDir and SkipAll honored.
+// The lock is released while fn runs so the callback can change the overlay.
+func (o *overlayFS) Walk(root string, fn filepath.WalkFunc) error {
+	info, err := o.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = o.walk(root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (o *overlayFS) walk(path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	entries, err := o.ReadDir(path)
+	if err1 := fn(path, info, err); err != nil || err1 != nil {
+		return err1
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		childInfo, err := o.Stat(child)
+		if err != nil {
+			if err := fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := o.walk(child, childInfo, fn); err != nil {
+			if err == filepath.SkipDir && childInfo.IsDir() {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *overlayFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	op := "create"
+	if info, err := o.stat(p); err == nil {
+		if info.IsDir() {
+			return &os.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+		}
+		op = "write"
+	}
+	if info, err := o.stat(filepath.Dir(p)); err != nil || !info.IsDir() {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	o.files[p] = append([]byte(nil), data...)
+	o.changes = append(o.changes, fsChange{op, p, fmt.Sprintf("%d bytes", len(data))})
+	return nil
+}
+
+func (o *overlayFS) MkdirAll(path string, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	created := false
+	for q := p; ; q = filepath.Dir(q) {
+		if info, err := o.stat(q); err == nil {
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: q, Err: errors.New("not a directory")}
+			}
+			break
+		}
+		o.dirs[q] = true
+		created = true
+		if filepath.Dir(q) == q {
+			break
+		}
+	}
+	if created {
+		o.changes = append(o.changes, fsChange{"mkdir", p, ""})
+	}
+	return nil
+}
+
+// shift moves every overlay entry under from to the same place under to
+func (o *overlayFS) shift(from, to string) {
+	for k, v := range o.files {
+		if isUnder(k, from) {
+			delete(o.files, k)
+			o.files[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, from) {
+			delete(o.dirs, k)
+			o.dirs[to+strings.TrimPrefix(k, from)] = true
+		}
+	}
+	for k, v := range o.moved {
+		if isUnder(k, from) {
+			delete(o.moved, k)
+			o.moved[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+}
+
+func (o *overlayFS) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	from, to := overlayKey(oldpath), overlayKey(newpath)
+	_, _, _, disk, ok := o.locate(from)
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if from == to {
+		return nil
+	}
+	if info, err := o.stat(filepath.Dir(to)); err != nil || !info.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	o.drop(to)
+	o.shift(from, to)
+	if disk != "" {
+		if _, err := os.Stat(disk); err == nil {
+			o.moved[to] = disk
+		}
+	}
+	o.removed[from] = true
+	o.changes = append(o.changes, fsChange{"rename", from, to})
+	return nil
+}
+
+// drop forgets p and everything below it and hides it from the disk
+func (o *overlayFS) drop(p string) {
+	for k := range o.files {
+		if isUnder(k, p) {
+			delete(o.files, k)
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, p) {
+			delete(o.dirs, k)
+		}
+	}
+	for k := range o.moved {
+		if isUnder(k, p) {
+			delete(o.moved, k)
+		}
+	}
+	o.removed[p] = true
+}
+
+// treeSize is the byte size of p as the overlay currently sees it
+func (o *overlayFS) treeSize(p string) int64 {
+	info, err := o.stat(p)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var size int64
+	entries, _ := o.readDir(p)
+	for _, e := range entries {
+		size += o.treeSize(filepath.Join(p, e.Name()))
+	}
+	return size
+}
+
+func (o *overlayFS) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	info, err := o.stat(p)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	if info.IsDir() {
+		if entries, _ := o.readDir(p); len(entries) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", info.Size())})
+	return nil
+}
+
+func (o *overlayFS) RemoveAll(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	if _, err := o.stat(p); err != nil {
+		return nil
+	}
+	size := o.treeSize(p)
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", size)})
+	return nil
+}
+
+func (o *overlayFS) Chmod(name string, mode os.FileMode) error {
+	if _, err := o.Stat(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+// noteCommand lists a step the overlay cannot simulate, such as a p4 or cm
+// command
+func (o *overlayFS) noteCommand(args ...string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.changes = append(o.changes, fsChange{"run", strings.Join(args, " "), ""})
+}
+
+// printDryRunReport lists what the overlay absorbed, relative to base
+func (o *overlayFS) printDryRunReport(base string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if abs, err := filepath.Abs(base); err == nil {
+		base = abs
+	}
+	if len(o.changes) == 0 {
+		fmt.Println(tr("\n[Dry Run] No changes would be made."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d change(s) would be made; nothing was written:\n"), len(o.changes))
+	rel := func(p string) string {
+		if r, err := filepath.Rel(base, p); err == nil && !strings.HasPrefix(r, "..") {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	for _, c := range o.changes {
+		switch c.op {
+		case "rename":
+			fmt.Printf("  %-7s %s -> %s\n", c.op, rel(c.path), rel(c.detail))
+		case "mkdir", "run":
+			fmt.Printf("  %-7s %s\n", c.op, rel(c.path))
+		default:
+			fmt.Printf("  %-7s %s (%s)\n", c.op, rel(c.path), c.detail)
+		}
+	}
+}
+
+// memFileInfo describes a file or directory that only exists in the overlay
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (m memFileInfo) Name() string       { return m.name }
+func (m memFileInfo) Size() int64        { return m.size }
+func (m memFileInfo) ModTime() time.Time { return time.Time{} }
+func (m memFileInfo) IsDir() bool        { return m.dir }
+func (m memFileInfo) Sys() interface{}   { return nil }
+func (m memFileInfo) Mode() os.FileMode {
+	if m.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// renamedFileInfo is a disk entry seen under the name it was renamed to
+type renamedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (r renamedFileInfo) Name() string { return r.name }
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"[ERROR] Cannot read %s: %v\n":                                     "[ERROR] 无法读取 %s: %v\n",
+	"[ERROR] Templates folder not found: %s\n":                         "[ERROR] 未找到模板文件夹: %s\n",
+	"Script kinds:": "脚本类型:",
+	"Usage: unity_script_generator [flags] <kind> <Name> [Name...]": "用法: unity_script_generator [参数] <类型> <名称> [名称...]",
+	"Kinds: %s\n": "类型: %s\n",
+	"[ERROR] Unknown script kind '%s' (use %s)\n": "[ERROR] 未知的脚本类型 '%s' (可用 %s)\n",
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"[ERROR] -dir must be inside Assets/ or Packages/: %s\n":   "[ERROR] -dir 必须位于 Assets/ 或 Packages/ 内: %s\n",
+	"Version control: %s (%s), new files are marked for add\n": "版本控制: %s (%s)，新文件会被标记为添加\n",
+	"[ERROR] Cannot read template: %v\n":                       "[ERROR] 无法读取模板: %v\n",
+	"[ERROR] Cannot read header template: %v\n":                "[ERROR] 无法读取文件头模板: %v\n",
+	"Template:   %s\n":                                      "模板:       %s\n",
+	"Config:     %s\n":                                      "配置:       %s\n",
+	"Namespace:  %s (%s)\n":                                 "命名空间:   %s (%s)\n",
+	"Namespace:  (none)":                                    "命名空间:   (无)",
+	"[OK] Created folder: %s (with .meta)\n":                "[OK] 已创建文件夹: %s (含 .meta)\n",
+	"[ERROR] Cannot create %s: %v\n":                        "[ERROR] 无法创建 %s: %v\n",
+	"[ERROR] %s already exists (use -force to overwrite)\n": "[ERROR] %s 已存在 (使用 -force 覆盖)\n",
+	"[ERROR] Cannot write %s: %v\n":                         "[ERROR] 无法写入 %s: %v\n",
+	"[OK] Overwrote: %s\n":                                  "[OK] 已覆盖: %s\n",
+	"[WARNING] Another %s.cs exists: %s\n":                  "[WARNING] 已存在另一个 %s.cs: %s\n",
+	"[OK] Created: %s (+ .meta)\n":                          "[OK] 已创建: %s (+ .meta)\n",
+	"\n[Dry Run] No files were written.":                    "\n[Dry Run] 未写入任何文件。",
+
+	"[WARNING] %s checkout failed for %s: %v\n": "[WARNING] %s 签出 %s 失败: %v\n",
+	"[VCS] Checked out (%s): %s\n":              "[VCS] 已签出 (%s): %s\n",
+	"[WARNING] %s add failed for %s: %v\n":      "[WARNING] %s 添加 %s 失败: %v\n",
+	"\nPress Enter to continue...":              "\n按回车键继续...",
+
+	"\n[Dry Run] No changes would be made.":                          "\n[Dry Run] 不会做任何更改。",
+	"\n[Dry Run] %d change(s) would be made; nothing was written:\n": "\n[Dry Run] 将会进行 %d 项更改，未写入任何内容:\n",
+
+	"[ERROR] '%s' does not match the naming rule %s of the policy %s\n":   "[ERROR] '%s' 不符合策略 %[3]s 的命名规则 %[2]s\n",
+	"[WARNING] Cannot refresh the policy, using the copy cached %s: %v\n": "[WARNING] 无法刷新策略，使用 %s 缓存的副本: %v\n",
+	"[WARNING] Removing a stale %s (%s)\n":                                "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n":           "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	if status == "ok" && isDryRun() {
+		status = "planned"
+	}
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode, dryRun, noHeader, force, list bool
+	var dir, namespaceFlag, templatesDir, vcsMode string
+
+	flag.StringVar(&dir, "dir", "Assets", "Folder for the new scripts, created with .meta files when missing")
+	flag.StringVar(&namespaceFlag, "namespace", "", "Namespace to use instead of the derived one ('none' for no namespace)")
+	flag.StringVar(&templatesDir, "templates", "", "Folder with <kind>.cs.txt / header.txt templates (default: scriptTemplates in .unitystarter.json)")
+	flag.BoolVar(&noHeader, "no-header", false, "Do not add the copyright header")
+	flag.BoolVar(&force, "force", false, "Overwrite scripts that already exist (their .meta and GUID are kept)")
+	flag.BoolVar(&list, "list", false, "List the script kinds and where their templates come from")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Print the scripts that would be generated without writing them")
+	flag.StringVar(&vcsMode, "vcs", "auto", "Mark new files for add: auto, none, p4, plastic")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the kind and names ("mono Player -dir Assets/Game")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_script_generator")
+		ciMode = true
+	}
+	if dryRun {
+		fsys = newOverlayFS()
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+
+	cfg, cfgPath, err := loadSharedConfig(basePath)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot read %s: %v\n"), cfgPath, err)
+		recordError("cannot read %s: %v", cfgPath, err)
+		exit(1)
+	}
+	if templatesDir == "" {
+		templatesDir = cfg.ScriptTemplates
+	}
+	if templatesDir != "" {
+		if info, err := os.Stat(templatesDir); err != nil || !info.IsDir() {
+			fmt.Printf(tr("[ERROR] Templates folder not found: %s\n"), templatesDir)
+			recordError("templates folder not found: %s", templatesDir)
+			exit(1)
+		}
+	}
+
+	kinds := make([]string, 0, len(builtinTemplates))
+	for kind := range builtinTemplates {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	if list {
+		fmt.Println(tr("Script kinds:"))
+		for _, kind := range kinds {
+			_, source, err := loadTemplate(templatesDir, kind)
+			if err != nil {
+				source = err.Error()
+			}
+			fmt.Printf("  %-10s %s\n", kind, source)
+		}
+		exit(0)
+	}
+
+	if len(args) < 2 {
+		fmt.Println(tr("Usage: unity_script_generator [flags] <kind> <Name> [Name...]"))
+		fmt.Printf(tr("Kinds: %s\n"), strings.Join(kinds, ", "))
+		recordError("expected <kind> <Name>")
+		exit(2)
+	}
+	kind := strings.ToLower(args[0])
+	if alias, ok := kindAliases[kind]; ok {
+		kind = alias
+	}
+	if _, ok := builtinTemplates[kind]; !ok {
+		fmt.Printf(tr("[ERROR] Unknown script kind '%s' (use %s)\n"), args[0], strings.Join(kinds, ", "))
+		recordError("unknown script kind '%s'", args[0])
+		exit(2)
+	}
+	names := args[1:]
+	for _, name := range names {
+		if err := validateScriptName(name); err != nil {
+			fmt.Printf(tr("[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exit(2)
+		}
+	}
+
+	// The studio policy can require a naming convention
+	policy, policyOrigin, err := loadPolicy(basePath)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(1)
+	}
+	if policy != nil && policy.Naming != nil {
+		rule, err := namingRule(policy.Naming.Scripts)
+		if err != nil {
+			fmt.Printf(tr("[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exit(1)
+		}
+		for _, name := range names {
+			if rule != nil && !rule.MatchString(name) {
+				fmt.Printf(tr("[ERROR] '%s' does not match the naming rule %s of the policy %s\n"), name, rule, policyOrigin)
+				recordError("'%s' does not match the naming rule %s", name, rule)
+				exit(2)
+			}
+		}
+	}
+
+	dir = normalizeAssetPath(dir)
+	if dir != "Assets" && !strings.HasPrefix(dir, "Assets/") && !strings.HasPrefix(dir, "Packages/") {
+		fmt.Printf(tr("[ERROR] -dir must be inside Assets/ or Packages/: %s\n"), dir)
+		recordError("-dir must be inside Assets/ or Packages/: %s", dir)
+		exit(2)
+	}
+
+	activeVCS, err = detectVCS(basePath, vcsMode)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(1)
+	}
+	if activeVCS.kind != vcsNone {
+		fmt.Printf(tr("Version control: %s (%s), new files are marked for add\n"), activeVCS.kind, activeVCS.source)
+	}
+
+	// Resolve everything shared by the batch before writing anything
+	info := readProjectInfo(basePath)
+	tmpl, tmplSource, err := loadTemplate(templatesDir, kind)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot read template: %v\n"), err)
+		recordError("cannot read template: %v", err)
+		exit(1)
+	}
+	header := ""
+	if !noHeader {
+		if header, err = loadHeader(templatesDir); err != nil {
+			fmt.Printf(tr("[ERROR] Cannot read header template: %v\n"), err)
+			recordError("cannot read header template: %v", err)
+			exit(1)
+		}
+	}
+	namespace, nsSource := namespaceFlag, "-namespace"
+	if namespaceFlag == "none" {
+		namespace = ""
+	} else if namespaceFlag == "" {
+		if namespace, nsSource, err = deriveNamespace(basePath, dir, info); err != nil {
+			fmt.Printf(tr("[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exit(1)
+		}
+	}
+
+	vars := templateVars(cfg, info, "")
+	header = fillHeader(header, vars)
+	format := textFormat{crlf: info.crlf}
+
+	fmt.Printf(tr("Template:   %s\n"), tmplSource)
+	if cfgPath != "" {
+		fmt.Printf(tr("Config:     %s\n"), cfgPath)
+	}
+	if namespace != "" {
+		fmt.Printf(tr("Namespace:  %s (%s)\n"), namespace, nsSource)
+	} else {
+		fmt.Println(tr("Namespace:  (none)"))
+	}
+
+	var scripts []*script
+	for _, name := range names {
+		s := &script{name: name, kind: kind, path: dir + "/" + name + ".cs", namespace: namespace}
+		scriptVars := map[string]string{placeholderScriptName: name, placeholderNamespace: namespace}
+		for k, v := range vars {
+			scriptVars[k] = v
+		}
+		s.source = renderScript(tmpl, header, scriptVars, namespace)
+		scripts = append(scripts, s)
+	}
+	// -force is about existing scripts here, so it does not take over the lock
+	if !dryRun {
+		if err := acquireProjectLock(basePath, "unity_script_generator", "generate "+kind, false); err != nil {
+			fmt.Printf(tr("[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exit(1)
+		}
+		defer releaseProjectLock()
+	}
+
+	fmt.Println()
+	created, err := ensureFolders(basePath, dir)
+	for _, d := range created {
+		fmt.Printf(tr("[OK] Created folder: %s (with .meta)\n"), d)
+		recordAction("create", d, "ok", "folder + .meta", 0)
+	}
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot create %s: %v\n"), dir, err)
+		recordError("cannot create %s: %v", dir, err)
+		exit(1)
+	}
+
+	failed := 0
+	for _, s := range scripts {
+		abs := filepath.Join(basePath, filepath.FromSlash(s.path))
+		if _, err := fsys.Stat(abs); err == nil {
+			if !force {
+				fmt.Printf(tr("[ERROR] %s already exists (use -force to overwrite)\n"), s.path)
+				recordAction("create", s.path, "failed", "already exists", 0)
+				recordError("%s already exists", s.path)
+				failed++
+				continue
+			}
+			// Keep the existing .meta so references to the script survive
+			if err := writeTextFileAtomic(abs, s.source, format); err != nil {
+				fmt.Printf(tr("[ERROR] Cannot write %s: %v\n"), s.path, err)
+				recordError("cannot write %s: %v", s.path, err)
+				failed++
+				continue
+			}
+			fmt.Printf(tr("[OK] Overwrote: %s\n"), s.path)
+			recordAction("modify", s.path, "ok", s.kind, 0)
+		} else {
+			for _, other := range findSameName(basePath, s.name) {
+				fmt.Printf(tr("[WARNING] Another %s.cs exists: %s\n"), s.name, other)
+			}
+			if err := writeScript(basePath, s, format); err != nil {
+				fmt.Printf(tr("[ERROR] Cannot write %s: %v\n"), s.path, err)
+				recordError("cannot write %s: %v", s.path, err)
+				failed++
+				continue
+			}
+			fmt.Printf(tr("[OK] Created: %s (+ .meta)\n"), s.path)
+			recordAction("create", s.path, "ok", s.kind, 0)
+		}
+		if dryRun {
+			printRule("---------------------------------------------")
+			fmt.Print(s.source)
+			printRule("---------------------------------------------")
+		} else {
+			recordArtifact(abs)
+		}
+	}
+
+	if overlay, ok := fsys.(*overlayFS); ok {
+		overlay.printDryRunReport(basePath)
+		fmt.Println(tr("\n[Dry Run] No files were written."))
+	}
+	if failed > 0 {
+		exit(1)
+	}
+	exit(0)
+}
@@ -0,0 +1,1873 @@
+// Unity Search Replace — Bulk text replacement that knows Unity file types.
+// Every file is classified as Unity YAML, C#, JSON, plain text or binary, and each
+// class only exposes the parts that are safe to edit: YAML values but never keys,
+// GUIDs or fileIDs; JSON string values but never keys; C# code, strings or comments
+// as selected. Binary files are never touched. A context diff is shown before
+// anything is written.
+//
+// Build: go build unity_search_replace.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_search_replace -find OldStudio -replace NewStudio
+//	unity_search_replace -regex -find "Legacy(\w+)View" -replace "${1}Panel" -types cs
+//	unity_search_replace -find "Hero" -replace "Knight" -word -include "Assets/Game/**" -dry-run
+//	unity_search_replace -find "v1/" -replace "v2/" -cs-scope strings -ci -json
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// File classes
+const (
+	kindYAML   = "yaml"
+	kindCS     = "cs"
+	kindJSON   = "json"
+	kindText   = "text"
+	kindBinary = "binary"
+)
+
+// Extensions Unity serializes as YAML (with Asset Serialization: Force Text)
+var unityYAMLExts = map[string]bool{
+	".unity": true, ".prefab": true, ".asset": true, ".mat": true, ".anim": true,
+	".controller": true, ".overridecontroller": true, ".mask": true, ".mixer": true,
+	".physicmaterial": true, ".physicsmaterial2d": true, ".guiskin": true,
+	".fontsettings": true, ".spriteatlas": true, ".spriteatlasv2": true, ".lighting": true,
+	".playable": true, ".signal": true, ".preset": true, ".terrainlayer": true,
+	".brush": true, ".flare": true, ".rendertexture": true, ".cubemap": true,
+	".giparams": true, ".shadervariants": true, ".meta": true,
+}
+
+var jsonExts = map[string]bool{
+	".json": true, ".asmdef": true, ".asmref": true, ".inputactions": true,
+}
+
+var textExts = map[string]bool{
+	".txt": true, ".md": true, ".csv": true, ".tsv": true, ".xml": true, ".yaml": true,
+	".yml": true, ".shader": true, ".hlsl": true, ".cginc": true, ".compute": true,
+	".uss": true, ".uxml": true, ".html": true, ".js": true, ".rsp": true,
+}
+
+// Folders searched, relative to the project root
+var searchRoots = []string{"Assets", "ProjectSettings", "Packages"}
+
+// Unity YAML spans that must never change, whatever the pattern: document headers,
+// object references (fileID/guid/type), the value of every key ending in GUID/Guid/guid
+// (m_SceneGUID, Addressables m_GUID, m_AssetGUID) and every mapping key
+var yamlProtectedPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?m)^%(?:YAML|TAG).*$`),
+	regexp.MustCompile(`(?m)^--- !u!\d+ &-?\d+(?: stripped)?`),
+	regexp.MustCompile(`\{fileID: [^}]*\}`),
+	regexp.MustCompile(`\b(?:\w*(?:GUID|Guid|guid)|fileID|fileFormatVersion): [-0-9a-fA-F]+`),
+	regexp.MustCompile(`(?m)^[ \t]*(?:- )*[^\s:#'"{}\[\],&*!|>%@` + "`" + `][^:\n]*:(?:[ \t]|$)`),
+}
+
+// JSON string values that must never change: assembly references by GUID in
+// .asmdef/.asmref files ("GUID:0123...")
+var jsonProtectedValue = regexp.MustCompile(`^GUID:[0-9a-fA-F]{32}$`)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// span is a [start, end) byte range of a file that replacements may touch
+type span struct {
+	start, end int
+}
+
+// replacer applies the compiled pattern
+type replacer struct {
+	re      *regexp.Regexp
+	repl    string
+	literal bool // replacement is inserted as is ($1 is not expanded)
+}
+
+// lineChange is one changed line, for the diff preview
+type lineChange struct {
+	line     int // 0-based
+	old, new string
+	count    int
+}
+
+// fileResult is everything known about one file that has matches
+type fileResult struct {
+	path    string // project-relative, forward slashes
+	abs     string
+	kind    string
+	old     string // original text (LF)
+	text    string // new text (LF)
+	format  textFormat
+	changes []lineChange
+	count   int
+	skipped string // reason when the file will not be written
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// File Classification
+// ============================================================
+
+// classify decides how a file may be edited. Content wins over extension: a
+// binary-serialized .asset has no %YAML header and is treated as binary.
+func classify(path string, data []byte) string {
+	head := data
+	if len(head) > 8000 {
+		head = head[:8000]
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return kindBinary
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case ext == ".cs":
+		return kindCS
+	case jsonExts[ext]:
+		return kindJSON
+	case ext == ".meta":
+		return kindYAML
+	case unityYAMLExts[ext]:
+		if bytes.HasPrefix(bytes.TrimPrefix(data, utf8BOM), []byte("%YAML")) {
+			return kindYAML
+		}
+		return kindBinary
+	case textExts[ext]:
+		return kindText
+	}
+	return ""
+}
+
+// ============================================================
+// Editable Spans
+// ============================================================
+
+// yamlSpans is everything outside the protected YAML patterns
+func yamlSpans(text string) []span {
+	var protected []span
+	for _, re := range yamlProtectedPatterns {
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			protected = append(protected, span{loc[0], loc[1]})
+		}
+	}
+	return complement(len(text), protected)
+}
+
+// complement returns the gaps between (possibly overlapping) protected spans
+func complement(n int, protected []span) []span {
+	sort.Slice(protected, func(i, j int) bool { return protected[i].start < protected[j].start })
+	var spans []span
+	pos := 0
+	for _, p := range protected {
+		if p.start > pos {
+			spans = append(spans, span{pos, p.start})
+		}
+		if p.end > pos {
+			pos = p.end
+		}
+	}
+	if pos < n {
+		spans = append(spans, span{pos, n})
+	}
+	return spans
+}
+
+// jsonSpans returns the contents of JSON string values (not keys or GUID references).
+// The quotes are excluded, so a replacement can never end a string early without
+// json.Valid noticing.
+func jsonSpans(text string) []span {
+	var spans []span
+	for i := 0; i < len(text); i++ {
+		if text[i] != '"' {
+			continue
+		}
+		start := i + 1
+		j := start
+		for j < len(text) && text[j] != '"' {
+			if text[j] == '\\' {
+				j++
+			}
+			j++
+		}
+		end := j
+		// A string followed by ':' is a key
+		k := j + 1
+		for k < len(text) && (text[k] == ' ' || text[k] == '\t' || text[k] == '\r' || text[k] == '\n') {
+			k++
+		}
+		if (k >= len(text) || text[k] != ':') && !jsonProtectedValue.MatchString(text[start:end]) {
+			spans = append(spans, span{start, end})
+		}
+		i = j
+	}
+	return spans
+}
+
+// csSpans splits C# source into code, string and comment ranges and returns the
+// ones in scope. Interpolation holes count as part of their string.
+func csSpans(text, scope string) []span {
+	var spans []span
+	add := func(class string, start, end int) {
+		if end > start && (scope == "all" || scope == class) {
+			spans = append(spans, span{start, end})
+		}
+	}
+
+	codeStart := 0
+	i := 0
+	for i < len(text) {
+		rest := text[i:]
+		var class string
+		var end int
+		switch {
+		case strings.HasPrefix(rest, "//"):
+			class = "comments"
+			end = indexFrom(text, "\n", i, len(text))
+		case strings.HasPrefix(rest, "/*"):
+			class = "comments"
+			end = indexFrom(text, "*/", i+2, len(text)-2) + 2
+		case strings.HasPrefix(rest, `"""`), strings.HasPrefix(rest, `$"""`):
+			class = "strings"
+			open := strings.Index(rest, `"""`)
+			end = indexFrom(text, `"""`, i+open+3, len(text)-3) + 3
+		case strings.HasPrefix(rest, `@"`), strings.HasPrefix(rest, `$@"`), strings.HasPrefix(rest, `@$"`):
+			class = "strings"
+			j := i + strings.Index(rest, `"`) + 1
+			for j < len(text) {
+				if text[j] == '"' {
+					if j+1 < len(text) && text[j+1] == '"' {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			end = min(j+1, len(text))
+		case rest[0] == '"', strings.HasPrefix(rest, `$"`), rest[0] == '\'':
+			class = "strings"
+			quote := rest[0]
+			j := i + 1
+			if quote == '$' {
+				quote = '"'
+				j++
+			}
+			for j < len(text) && text[j] != quote && text[j] != '\n' {
+				if text[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			end = min(j+1, len(text))
+		default:
+			i++
+			continue
+		}
+		add("code", codeStart, i)
+		add(class, i, end)
+		i, codeStart = end, end
+	}
+	add("code", codeStart, len(text))
+	return spans
+}
+
+// indexFrom finds sub in text at or after from, returning fallback when absent
+func indexFrom(text, sub string, from, fallback int) int {
+	if from > len(text) {
+		return fallback
+	}
+	if k := strings.Index(text[from:], sub); k >= 0 {
+		return from + k
+	}
+	return fallback
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// ============================================================
+// Replacement
+// ============================================================
+
+// replaceInSpans applies r to the editable spans, one line at a time, and returns
+// the new text with the per-line changes. Working per line keeps ^ and $ meaningful
+// and makes the diff exact.
+func replaceInSpans(text string, spans []span, r *replacer) (string, []lineChange) {
+	var b strings.Builder
+	var changes []lineChange
+	si := 0
+	lineStart := 0
+	for lineNo := 0; lineStart <= len(text); lineNo++ {
+		lineEnd := indexFrom(text, "\n", lineStart, len(text))
+		var nb strings.Builder
+		pos := lineStart
+		count := 0
+		for si < len(spans) && spans[si].end <= lineStart {
+			si++
+		}
+		for k := si; k < len(spans) && spans[k].start < lineEnd; k++ {
+			s, e := spans[k].start, spans[k].end
+			if s < lineStart {
+				s = lineStart
+			}
+			if e > lineEnd {
+				e = lineEnd
+			}
+			if s >= e {
+				continue
+			}
+			nb.WriteString(text[pos:s])
+			seg := text[s:e]
+			if n := len(r.re.FindAllStringIndex(seg, -1)); n > 0 {
+				count += n
+				if r.literal {
+					seg = r.re.ReplaceAllLiteralString(seg, r.repl)
+				} else {
+					seg = r.re.ReplaceAllString(seg, r.repl)
+				}
+			}
+			nb.WriteString(seg)
+			pos = e
+		}
+		nb.WriteString(text[pos:lineEnd])
+		newLine := nb.String()
+		if count > 0 && newLine != text[lineStart:lineEnd] {
+			changes = append(changes, lineChange{line: lineNo, old: text[lineStart:lineEnd], new: newLine, count: count})
+		}
+		b.WriteString(newLine)
+		if lineEnd < len(text) {
+			b.WriteByte('\n')
+		}
+		lineStart = lineEnd + 1
+	}
+	return b.String(), changes
+}
+
+// processFile classifies one file and computes its replacement; nil when it has
+// no matches or its class is not selected
+func processFile(basePath, path string, data []byte, r *replacer, types map[string]bool, csScope string) *fileResult {
+	kind := classify(path, data)
+	if kind == "" || kind == kindBinary || !types[kind] {
+		return nil
+	}
+	text, format := decodeText(data)
+	if !r.re.MatchString(text) {
+		return nil
+	}
+
+	var spans []span
+	switch kind {
+	case kindYAML:
+		spans = yamlSpans(text)
+	case kindJSON:
+		spans = jsonSpans(text)
+	case kindCS:
+		spans = csSpans(text, csScope)
+	default:
+		spans = []span{{0, len(text)}}
+	}
+	newText, changes := replaceInSpans(text, spans, r)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	rel, _ := filepath.Rel(basePath, path)
+	res := &fileResult{path: filepath.ToSlash(rel), abs: path, kind: kind, old: text, text: newText, format: format, changes: changes}
+	for _, c := range changes {
+		res.count += c.count
+	}
+	if kind == kindJSON && json.Valid([]byte(text)) && !json.Valid([]byte(newText)) {
+		res.skipped = tr("the result would not be valid JSON")
+	}
+	return res
+}
+
+// ============================================================
+// Path Filters
+// ============================================================
+
+// globToRegexp converts a glob to a regexp: ** spans folders, * and ? stay within
+// one path segment. Patterns without a slash match the file name alone.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compileGlobs parses a comma-separated glob list
+func compileGlobs(list string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := globToRegexp(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, rel string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Preview
+// ============================================================
+
+// printDiff shows each changed line with `context` unchanged lines around it
+func printDiff(res *fileResult, context int) {
+	lines := strings.Split(strings.TrimSuffix(res.old, "\n"), "\n")
+	fmt.Printf("\n--- %s (%s, %d)\n", res.path, res.kind, res.count)
+	if res.skipped != "" {
+		fmt.Printf(tr("[WARNING] Skipped: %s\n"), res.skipped)
+	}
+	last := -1
+	for idx, c := range res.changes {
+		from := c.line - context
+		if from <= last {
+			from = last + 1
+		}
+		if from < 0 {
+			from = 0
+		}
+		if last >= 0 && from > last+1 {
+			fmt.Println("    ...")
+		}
+		for l := from; l < c.line; l++ {
+			fmt.Printf("%6d   %s\n", l+1, lines[l])
+		}
+		fmt.Printf("%6d - %s\n", c.line+1, c.old)
+		fmt.Printf("%6d + %s\n", c.line+1, c.new)
+		last = c.line
+		// Trailing context, up to the next change
+		to := c.line + context
+		if idx+1 < len(res.changes) && to >= res.changes[idx+1].line {
+			to = res.changes[idx+1].line - 1
+		}
+		for l := c.line + 1; l <= to && l < len(lines); l++ {
+			fmt.Printf("%6d   %s\n", l+1, lines[l])
+			last = l
+		}
+	}
+}
+
+// ============================================================
+// Version Control Checkout
+// ============================================================
+
+// Perforce and Plastic SCM keep files read-only until they are checked out. Writing
+// them directly (even after clearing the flag) leaves changes the server does not know
+// about, so files are opened for edit first. Git and plain folders need nothing.
+const (
+	vcsNone     = "none"
+	vcsPerforce = "p4"
+	vcsPlastic  = "plastic"
+)
+
+// vcsEnvVar overrides detection for every tool (e.g. on build agents)
+const vcsEnvVar = "UNITYSTARTER_VCS"
+
+// vcsClient issues checkouts for files about to be modified
+type vcsClient struct {
+	kind       string
+	source     string // how kind was chosen, for the startup message
+	checkedOut map[string]bool
+}
+
+// activeVCS is set up in main; nil means no checkouts
+var activeVCS *vcsClient
+
+// detectVCS picks the checkout provider. Priority: -vcs flag > UNITYSTARTER_VCS >
+// Unity's VersionControlSettings.asset mode > workspace markers (.plastic, P4CONFIG).
+func detectVCS(projectRoot, override string) (*vcsClient, error) {
+	normalize := func(v string) (string, bool) {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "p4", "perforce":
+			return vcsPerforce, true
+		case "plastic", "plasticscm", "cm", "unityvcs":
+			return vcsPlastic, true
+		case "none", "git", "off":
+			return vcsNone, true
+		}
+		return "", false
+	}
+	newClient := func(kind, source string) *vcsClient {
+		return &vcsClient{kind: kind, source: source, checkedOut: make(map[string]bool)}
+	}
+
+	if override != "" && override != "auto" {
+		kind, ok := normalize(override)
+		if !ok {
+			return nil, fmt.Errorf("unknown -vcs value '%s' (use auto, none, p4 or plastic)", override)
+		}
+		return newClient(kind, "-vcs flag"), nil
+	}
+	if env := os.Getenv(vcsEnvVar); env != "" {
+		if kind, ok := normalize(env); ok {
+			return newClient(kind, vcsEnvVar), nil
+		}
+	}
+
+	// Unity stores the project's integrated VCS in m_Mode ("Perforce", "PlasticSCM", ...)
+	if data, err := os.ReadFile(filepath.Join(projectRoot, "ProjectSettings", "VersionControlSettings.asset")); err == nil {
+		if m := regexp.MustCompile(`(?m)^\s*m_Mode:\s*(.+?)\s*$`).FindSubmatch(data); m != nil {
+			switch strings.ToLower(string(m[1])) {
+			case "perforce":
+				return newClient(vcsPerforce, "VersionControlSettings.asset"), nil
+			case "plasticscm", "plastic scm", "unity version control":
+				return newClient(vcsPlastic, "VersionControlSettings.asset"), nil
+			}
+		}
+	}
+
+	// Workspace markers, searched upward from the project root
+	p4config := os.Getenv("P4CONFIG")
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info != nil {
+				return newClient(vcsNone, "git repository"), nil
+			}
+			if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+				return newClient(vcsPlastic, ".plastic workspace"), nil
+			}
+			if p4config != "" {
+				if _, err := os.Stat(filepath.Join(dir, p4config)); err == nil {
+					return newClient(vcsPerforce, "P4CONFIG"), nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if os.Getenv("P4CLIENT") != "" {
+		return newClient(vcsPerforce, "P4CLIENT"), nil
+	}
+	return newClient(vcsNone, "no Perforce/Plastic workspace found"), nil
+}
+
+// checkout opens an existing file for edit. New files need no checkout, and files
+// outside the depot/workspace only produce a warning: the write still goes ahead.
+func (v *vcsClient) checkout(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || v.checkedOut[abs] {
+		return
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return
+	}
+	v.checkedOut[abs] = true
+
+	var cmd *exec.Cmd
+	switch v.kind {
+	case vcsPerforce:
+		cmd = exec.Command("p4", "edit", abs)
+	case vcsPlastic:
+		cmd = exec.Command("cm", "checkout", abs)
+	default:
+		return
+	}
+	// Run from the file's folder so P4CONFIG / the Plastic workspace is resolved
+	cmd.Dir = filepath.Dir(abs)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		fmt.Printf(tr("[WARNING] %s checkout failed for %s: %v %s\n"), v.kind, filepath.Base(abs), err, strings.TrimSpace(string(out)))
+		return
+	}
+	fmt.Printf(tr("[VCS] Checked out (%s): %s\n"), v.kind, filepath.Base(abs))
+}
+
+// ============================================================
+// Safe File Writes
+// ============================================================
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// writeFileAtomic replaces path with data without ever leaving a half-written file:
+// data goes to a temp file in the same directory which is then renamed over the target.
+// The target's permission bits are kept, and a read-only flag (files under Perforce or
+// Plastic SCM that were not checked out) is cleared so the write does not fail.
+func writeFileAtomic(path string, data []byte) error {
+	// A dry run writes straight into the overlay: no checkout, no temp file
+	if isDryRun() {
+		return fsys.WriteFile(path, data, 0644)
+	}
+	activeVCS.checkout(path)
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if mode&0200 == 0 {
+			mode |= 0200
+			if err := os.Chmod(path, mode); err != nil {
+				return fmt.Errorf("cannot clear read-only flag on %s: %v", path, err)
+			}
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Retry briefly: editors, indexers and antivirus can hold the target open on Windows
+	for attempt := 0; ; attempt++ {
+		err = os.Rename(tmpPath, path)
+		if err == nil || attempt == 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// textFormat records the BOM and line-ending style of a text file. Rewrites work on
+// BOM-less, LF-only text; encode restores the original format when writing back so
+// CRLF files (common for files edited on Windows) and UTF-8 BOM files stay intact.
+type textFormat struct {
+	bom  bool
+	crlf bool // ending of most lines, and of the lines a rewrite adds
+	// Mixed files only: the text as read (LF) and the ending of each of its line
+	// breaks, 'r' (CRLF) or 'n' (LF). Strings keep textFormat comparable.
+	orig string
+	eols string
+}
+
+// decodeText strips a UTF-8 BOM and normalizes line endings to LF. A file counts as
+// CRLF when most of its line breaks are CRLF; a file with both keeps the ending of
+// every line, so a rewrite only changes the endings of the lines it edits.
+func decodeText(data []byte) (string, textFormat) {
+	var format textFormat
+	if bytes.HasPrefix(data, utf8BOM) {
+		format.bom = true
+		data = data[len(utf8BOM):]
+	}
+	crlfCount := bytes.Count(data, []byte("\r\n"))
+	lfCount := bytes.Count(data, []byte("\n")) - crlfCount
+	format.crlf = crlfCount > 0 && crlfCount >= lfCount
+	if crlfCount == 0 {
+		return string(data), format
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if lfCount > 0 {
+		eols := make([]byte, 0, crlfCount+lfCount)
+		for i, c := range data {
+			if c == '\n' {
+				if i > 0 && data[i-1] == '\r' {
+					eols = append(eols, 'r')
+				} else {
+					eols = append(eols, 'n')
+				}
+			}
+		}
+		format.orig, format.eols = text, string(eols)
+	}
+	return text, format
+}
+
+// encode converts LF text back to the recorded format
+func (f textFormat) encode(text string) []byte {
+	switch {
+	case f.eols != "":
+		text = f.mixedEndings(text)
+	case f.crlf:
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+	if f.bom {
+		return append(append([]byte{}, utf8BOM...), text...)
+	}
+	return []byte(text)
+}
+
+// mixedEndings gives the lines of a rewritten mixed file the endings they had. The
+// unchanged lines before and after the edited region keep theirs; edited lines keep
+// the ending of the line they replace when the line count did not change, and get
+// the majority ending otherwise.
+func (f textFormat) mixedEndings(text string) string {
+	old := strings.Split(f.orig, "\n")
+	lines := strings.Split(text, "\n")
+	prefix := 0
+	for prefix < len(old) && prefix < len(lines) && old[prefix] == lines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(lines)-prefix && old[len(old)-1-suffix] == lines[len(lines)-1-suffix] {
+		suffix++
+	}
+	crlfAt := func(j int) bool {
+		if j >= 0 && j < len(f.eols) {
+			return f.eols[j] == 'r'
+		}
+		return f.crlf
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		b.WriteString(line)
+		if i == len(lines)-1 {
+			break
+		}
+		crlf := f.crlf
+		switch {
+		case i < prefix || len(lines) == len(old):
+			crlf = crlfAt(i)
+		case i >= len(lines)-suffix:
+			crlf = crlfAt(i - len(lines) + len(old))
+		}
+		if crlf {
+			b.WriteByte('\r')
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// writeTextFileAtomic writes LF text in the given format via writeFileAtomic
+func writeTextFileAtomic(path, text string, format textFormat) error {
+	return writeFileAtomic(path, format.encode(text))
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Filesystem
+// ============================================================
+
+// fileSystem is every filesystem call that can change the project. The real
+// run uses osFS; -dry-run swaps in an overlayFS so the same code path runs
+// and the overlay reports what it would have changed.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Walk(root string, fn filepath.WalkFunc) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Chmod(name string, mode os.FileMode) error
+}
+
+var fsys fileSystem = osFS{}
+
+// isDryRun reports whether changes are going to the in-memory overlay
+func isDryRun() bool {
+	_, ok := fsys.(*overlayFS)
+	return ok
+}
+
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)         { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) Walk(root string, fn filepath.WalkFunc) error { return filepath.Walk(root, fn) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// fsChange is one change the overlay absorbed instead of the disk
+type fsChange struct {
+	op     string // "write", "create", "mkdir", "rename", "delete"
+	path   string
+	detail string
+}
+
+// overlayFS reads through to the disk and keeps every write, rename and
+// delete in memory. Later reads see those changes, so multi-step operations
+// (rename a folder, then rewrite files inside it) behave as they would for real.
+type overlayFS struct {
+	mu      sync.Mutex
+	files   map[string][]byte // files written during the run
+	dirs    map[string]bool   // directories created during the run
+	moved   map[string]string // renamed path -> where its content is on disk
+	removed map[string]bool   // deleted paths; hides everything below them
+	changes []fsChange
+}
+
+func newOverlayFS() *overlayFS {
+	return &overlayFS{
+		files:   map[string][]byte{},
+		dirs:    map[string]bool{},
+		moved:   map[string]string{},
+		removed: map[string]bool{},
+	}
+}
+
+func overlayKey(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// isUnder reports whether p is base or inside it
+func isUnder(p, base string) bool {
+	return p == base || strings.HasPrefix(p, strings.TrimSuffix(base, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// locate finds where the content of p lives: in memory (data / memDir) or
+// on disk (disk, after following renames). ok is false if p was deleted.
+func (o *overlayFS) locate(p string) (data []byte, memFile, memDir bool, disk string, ok bool) {
+	if d, found := o.files[p]; found {
+		return d, true, false, "", true
+	}
+	if o.dirs[p] {
+		return nil, false, true, "", true
+	}
+	for q := p; ; {
+		if src, found := o.moved[q]; found {
+			rel, _ := filepath.Rel(q, p)
+			return nil, false, false, filepath.Join(src, rel), true
+		}
+		if o.removed[q] {
+			return nil, false, false, "", false
+		}
+		parent := filepath.Dir(q)
+		if parent == q {
+			break
+		}
+		q = parent
+	}
+	return nil, false, false, p, true
+}
+
+func (o *overlayFS) stat(p string) (os.FileInfo, error) {
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	case memFile:
+		return memFileInfo{name: filepath.Base(p), size: int64(len(data))}, nil
+	case memDir:
+		return memFileInfo{name: filepath.Base(p), dir: true}, nil
+	}
+	info, err := os.Stat(disk)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	if disk != p {
+		return renamedFileInfo{info, filepath.Base(p)}, nil
+	}
+	return info, nil
+}
+
+func (o *overlayFS) Stat(name string) (os.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stat(overlayKey(name))
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case memDir:
+		return nil, &os.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	case memFile:
+		return append([]byte(nil), data...), nil
+	}
+	return os.ReadFile(disk)
+}
+
+func (o *overlayFS) readDir(p string) ([]os.DirEntry, error) {
+	_, memFile, memDir, disk, ok := o.locate(p)
+	if !ok || memFile {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+	names := map[string]bool{}
+	if !memDir {
+		entries, err := os.ReadDir(disk)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			names[e.Name()] = true
+		}
+	}
+	for k := range o.dirs {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.files {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.moved {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	var result []os.DirEntry
+	for name := range names {
+		if info, err := o.stat(filepath.Join(p, name)); err == nil {
+			result = append(result, fs.FileInfoToDirEntry(info))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (o *overlayFS) ReadDir(name string) ([]os.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readDir(overlayKey(name))
+}
+
+// Walk follows filepath.Walk: lexical order, SkipDir and SkipAll honored.
+// The lock is released while fn runs so the callback can change the overlay.
+func (o *overlayFS) Walk(root string, fn filepath.WalkFunc) error {
+	info, err := o.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = o.walk(root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (o *overlayFS) walk(path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	entries, err := o.ReadDir(path)
+	if err1 := fn(path, info, err); err != nil || err1 != nil {
+		return err1
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		childInfo, err := o.Stat(child)
+		if err != nil {
+			if err := fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := o.walk(child, childInfo, fn); err != nil {
+			if err == filepath.SkipDir && childInfo.IsDir() {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *overlayFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	op := "create"
+	if info, err := o.stat(p); err == nil {
+		if info.IsDir() {
+			return &os.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+		}
+		op = "write"
+	}
+	if info, err := o.stat(filepath.Dir(p)); err != nil || !info.IsDir() {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	o.files[p] = append([]byte(nil), data...)
+	o.changes = append(o.changes, fsChange{op, p, fmt.Sprintf("%d bytes", len(data))})
+	return nil
+}
+
+func (o *overlayFS) MkdirAll(path string, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	created := false
+	for q := p; ; q = filepath.Dir(q) {
+		if info, err := o.stat(q); err == nil {
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: q, Err: errors.New("not a directory")}
+			}
+			break
+		}
+		o.dirs[q] = true
+		created = true
+		if filepath.Dir(q) == q {
+			break
+		}
+	}
+	if created {
+		o.changes = append(o.changes, fsChange{"mkdir", p, ""})
+	}
+	return nil
+}
+
+// shift moves every overlay entry under from to the same place under to
+func (o *overlayFS) shift(from, to string) {
+	for k, v := range o.files {
+		if isUnder(k, from) {
+			delete(o.files, k)
+			o.files[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, from) {
+			delete(o.dirs, k)
+			o.dirs[to+strings.TrimPrefix(k, from)] = true
+		}
+	}
+	for k, v := range o.moved {
+		if isUnder(k, from) {
+			delete(o.moved, k)
+			o.moved[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+}
+
+func (o *overlayFS) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	from, to := overlayKey(oldpath), overlayKey(newpath)
+	_, _, _, disk, ok := o.locate(from)
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if from == to {
+		return nil
+	}
+	if info, err := o.stat(filepath.Dir(to)); err != nil || !info.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	o.drop(to)
+	o.shift(from, to)
+	if disk != "" {
+		if _, err := os.Stat(disk); err == nil {
+			o.moved[to] = disk
+		}
+	}
+	o.removed[from] = true
+	o.changes = append(o.changes, fsChange{"rename", from, to})
+	return nil
+}
+
+// drop forgets p and everything below it and hides it from the disk
+func (o *overlayFS) drop(p string) {
+	for k := range o.files {
+		if isUnder(k, p) {
+			delete(o.files, k)
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, p) {
+			delete(o.dirs, k)
+		}
+	}
+	for k := range o.moved {
+		if isUnder(k, p) {
+			delete(o.moved, k)
+		}
+	}
+	o.removed[p] = true
+}
+
+// treeSize is the byte size of p as the overlay currently sees it
+func (o *overlayFS) treeSize(p string) int64 {
+	info, err := o.stat(p)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var size int64
+	entries, _ := o.readDir(p)
+	for _, e := range entries {
+		size += o.treeSize(filepath.Join(p, e.Name()))
+	}
+	return size
+}
+
+func (o *overlayFS) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	info, err := o.stat(p)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	if info.IsDir() {
+		if entries, _ := o.readDir(p); len(entries) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", info.Size())})
+	return nil
+}
+
+func (o *overlayFS) RemoveAll(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	if _, err := o.stat(p); err != nil {
+		return nil
+	}
+	size := o.treeSize(p)
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", size)})
+	return nil
+}
+
+func (o *overlayFS) Chmod(name string, mode os.FileMode) error {
+	if _, err := o.Stat(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+// printDryRunReport lists what the overlay absorbed, relative to base
+func (o *overlayFS) printDryRunReport(base string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if abs, err := filepath.Abs(base); err == nil {
+		base = abs
+	}
+	if len(o.changes) == 0 {
+		fmt.Println(tr("\n[Dry Run] No changes would be made."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d change(s) would be made; nothing was written:\n"), len(o.changes))
+	rel := func(p string) string {
+		if r, err := filepath.Rel(base, p); err == nil && !strings.HasPrefix(r, "..") {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	for _, c := range o.changes {
+		switch c.op {
+		case "rename":
+			fmt.Printf("  %-7s %s -> %s\n", c.op, rel(c.path), rel(c.detail))
+		case "mkdir":
+			fmt.Printf("  %-7s %s\n", c.op, rel(c.path))
+		default:
+			fmt.Printf("  %-7s %s (%s)\n", c.op, rel(c.path), c.detail)
+		}
+	}
+}
+
+// memFileInfo describes a file or directory that only exists in the overlay
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (m memFileInfo) Name() string       { return m.name }
+func (m memFileInfo) Size() int64        { return m.size }
+func (m memFileInfo) ModTime() time.Time { return time.Time{} }
+func (m memFileInfo) IsDir() bool        { return m.dir }
+func (m memFileInfo) Sys() interface{}   { return nil }
+func (m memFileInfo) Mode() os.FileMode {
+	if m.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// renamedFileInfo is a disk entry seen under the name it was renamed to
+type renamedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (r renamedFileInfo) Name() string { return r.name }
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"the result would not be valid JSON": "替换后将不再是有效的 JSON",
+	"[WARNING] Skipped: %s\n":            "[WARNING] 已跳过: %s\n",
+
+	"[ERROR] -find is required\n":                                         "[ERROR] 必须指定 -find\n",
+	"[ERROR] unknown file class '%s' (use yaml, cs, json, text)\n":        "[ERROR] 未知的文件类别 '%s' (可用 yaml, cs, json, text)\n",
+	"[ERROR] unknown -cs-scope '%s' (use all, code, strings, comments)\n": "[ERROR] 未知的 -cs-scope '%s' (可用 all, code, strings, comments)\n",
+	"[ERROR] invalid pattern: %v\n":                                       "[ERROR] 无效的匹配模式: %v\n",
+	"[ERROR] %v\n":                                                        "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                          "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.":    "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":              "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"Searching for %s ...\n":                                              "正在搜索 %s ...\n",
+	"  Summary":                                                           "  摘要",
+	"  Files scanned:        %d\n":                                        "  扫描的文件:          %d\n",
+	"  Binary files skipped: %d\n":                                        "  跳过的二进制文件:    %d\n",
+	"  Files to change:      %d\n":                                        "  将修改的文件:        %d\n",
+	"  Replacements:         %d\n":                                        "  替换次数:            %d\n",
+	"  Files skipped:        %d\n":                                        "  跳过的文件:          %d\n",
+	"\n[--] Nothing to replace.":                                          "\n[--] 没有可替换的内容。",
+	"\nApply these replacements? (y/N): ":                                 "\n是否应用这些替换？(y/N): ",
+	"Operation cancelled.":                                                "操作已取消。",
+	"[ERROR] Cannot write %s: %v\n":                                       "[ERROR] 无法写入 %s: %v\n",
+	"[OK] Updated: %s (%d)\n":                                             "[OK] 已更新: %s (%d)\n",
+	"\n[Dry Run] No files were modified.":                                 "\n[Dry Run] 未修改任何文件。",
+
+	"[WARNING] %s checkout failed for %s: %v %s\n": "[WARNING] %s 签出失败 %s: %v %s\n",
+	"[VCS] Checked out (%s): %s\n":                 "[VCS] 已签出 (%s): %s\n",
+	"\nPress Enter to continue...":                 "\n按回车键继续...",
+
+	"\n[Dry Run] No changes would be made.":                          "\n[Dry Run] 不会做任何更改。",
+	"\n[Dry Run] %d change(s) would be made; nothing was written:\n": "\n[Dry Run] 将会进行 %d 项更改，未写入任何内容:\n",
+
+	"[WARNING] Removing a stale %s (%s)\n":                      "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n": "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	if status == "ok" && isDryRun() {
+		status = "planned"
+	}
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode, dryRun, force bool
+	var find, replace string
+	var useRegex, ignoreCase, wholeWord, includeMeta bool
+	var typesFlag, csScope, includeFlag, excludeFlag, vcsMode string
+	var context int
+
+	flag.StringVar(&find, "find", "", "Text (or with -regex, an RE2 pattern) to search for")
+	flag.StringVar(&replace, "replace", "", "Replacement; with -regex, $1 / ${name} insert capture groups")
+	flag.BoolVar(&useRegex, "regex", false, "Treat -find as a regular expression")
+	flag.BoolVar(&ignoreCase, "i", false, "Case-insensitive match")
+	flag.BoolVar(&wholeWord, "word", false, "Match whole words only")
+	flag.StringVar(&typesFlag, "types", "yaml,cs,json,text", "File classes to edit: yaml, cs, json, text")
+	flag.StringVar(&csScope, "cs-scope", "all", "Parts of C# files to edit: all, code, strings, comments")
+	flag.StringVar(&includeFlag, "include", "", "Comma-separated globs; only matching paths are edited (e.g. \"Assets/Game/**\")")
+	flag.StringVar(&excludeFlag, "exclude", "", "Comma-separated globs to skip (e.g. \"**/ThirdParty/**,*.unity\")")
+	flag.BoolVar(&includeMeta, "meta", false, "Also edit .meta files (GUIDs stay protected)")
+	flag.IntVar(&context, "context", 2, "Unchanged lines shown around each change in the preview")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode: apply without asking")
+	flag.BoolVar(&dryRun, "dry-run", false, "Show the preview and list the writes without touching disk")
+	flag.BoolVar(&force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
+	flag.StringVar(&vcsMode, "vcs", "auto", "Checkout before writing: auto, none, p4, plastic")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_search_replace")
+		ciMode = true
+	}
+	if dryRun {
+		fsys = newOverlayFS()
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	usageError := func(format string, args ...interface{}) {
+		fmt.Printf(tr("[ERROR] "+format+"\n"), args...)
+		recordError(format, args...)
+		exit(2)
+	}
+
+	if find == "" {
+		usageError("-find is required")
+	}
+	types := make(map[string]bool)
+	for _, t := range strings.Split(typesFlag, ",") {
+		switch t = strings.TrimSpace(strings.ToLower(t)); t {
+		case kindYAML, kindCS, kindJSON, kindText:
+			types[t] = true
+		case "":
+		default:
+			usageError("unknown file class '%s' (use yaml, cs, json, text)", t)
+		}
+	}
+	switch csScope {
+	case "all", "code", "strings", "comments":
+	default:
+		usageError("unknown -cs-scope '%s' (use all, code, strings, comments)", csScope)
+	}
+	includes, err := compileGlobs(includeFlag)
+	if err != nil {
+		usageError("%v", err)
+	}
+	excludes, err := compileGlobs(excludeFlag)
+	if err != nil {
+		usageError("%v", err)
+	}
+
+	expr := find
+	if !useRegex {
+		expr = regexp.QuoteMeta(find)
+	}
+	if wholeWord {
+		expr = `\b(?:` + expr + `)\b`
+	}
+	if ignoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		usageError("invalid pattern: %v", err)
+	}
+	r := &replacer{re: re, repl: replace, literal: !useRegex}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	activeVCS, err = detectVCS(basePath, vcsMode)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(1)
+	}
+	if !dryRun {
+		if err := acquireProjectLock(basePath, "unity_search_replace", "replace", force); err != nil {
+			fmt.Printf(tr("[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exit(1)
+		}
+		defer releaseProjectLock()
+	}
+
+	// Scan
+	fmt.Printf(tr("Searching for %s ...\n"), re.String())
+	var results []*fileResult
+	scanned, binaries := 0, 0
+	for _, root := range searchRoots {
+		fsys.Walk(filepath.Join(basePath, root), func(p string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			if !includeMeta && strings.HasSuffix(p, ".meta") {
+				return nil
+			}
+			rel, _ := filepath.Rel(basePath, p)
+			rel = filepath.ToSlash(rel)
+			if (len(includes) > 0 && !matchesAny(includes, rel)) || matchesAny(excludes, rel) {
+				return nil
+			}
+			data, rErr := fsys.ReadFile(p)
+			if rErr != nil {
+				return nil
+			}
+			scanned++
+			if classify(p, data) == kindBinary {
+				binaries++
+				return nil
+			}
+			if res := processFile(basePath, p, data, r, types, csScope); res != nil {
+				results = append(results, res)
+			}
+			return nil
+		})
+	}
+
+	total := 0
+	writable := 0
+	for _, res := range results {
+		printDiff(res, context)
+		if res.skipped == "" {
+			total += res.count
+			writable++
+		}
+	}
+
+	printRule("\n=============================================")
+	fmt.Println(tr("  Summary"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Files scanned:        %d\n"), scanned)
+	fmt.Printf(tr("  Binary files skipped: %d\n"), binaries)
+	fmt.Printf(tr("  Files to change:      %d\n"), writable)
+	fmt.Printf(tr("  Replacements:         %d\n"), total)
+	if len(results) > writable {
+		fmt.Printf(tr("  Files skipped:        %d\n"), len(results)-writable)
+	}
+
+	if writable == 0 {
+		fmt.Println(tr("\n[--] Nothing to replace."))
+		exit(0)
+	}
+
+	if !ciMode && !dryRun {
+		fmt.Print(tr("\nApply these replacements? (y/N): "))
+		confirm, _ := stdinReader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+			fmt.Println(tr("Operation cancelled."))
+			exit(0)
+		}
+	}
+
+	fmt.Println()
+	failed := 0
+	for _, res := range results {
+		if res.skipped != "" {
+			recordAction("modify", res.path, "skipped", res.skipped, 0)
+			continue
+		}
+		if err := writeTextFileAtomic(res.abs, res.text, res.format); err != nil {
+			fmt.Printf(tr("[ERROR] Cannot write %s: %v\n"), res.path, err)
+			recordAction("modify", res.path, "failed", err.Error(), 0)
+			recordError("cannot write %s: %v", res.path, err)
+			failed++
+			continue
+		}
+		fmt.Printf(tr("[OK] Updated: %s (%d)\n"), res.path, res.count)
+		recordAction("modify", res.path, "ok", fmt.Sprintf("%d replacements", res.count), 0)
+	}
+
+	if overlay, ok := fsys.(*overlayFS); ok {
+		overlay.printDryRunReport(basePath)
+		fmt.Println(tr("\n[Dry Run] No files were modified."))
+	}
+	if failed > 0 {
+		exit(1)
+	}
+	exit(0)
+}
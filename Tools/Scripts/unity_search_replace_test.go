@@ -0,0 +1,82 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+// TestYAMLProtectedGUIDs replaces every run of zeros in a scene and checks that
+// only the plain values changed: references and GUID-valued keys stay intact
+func TestYAMLProtectedGUIDs(t *testing.T) {
+	const scene = `%YAML 1.1
+%TAG !u! tag:unity3d.com,2011:
+--- !u!1 &1000000
+GameObject:
+  m_Name: Spawn000000
+  m_Script: {fileID: 11500000, guid: 00000000aaaa00000000bbbb00000000, type: 3}
+--- !u!114 &2000000
+MonoBehaviour:
+  m_SceneGUID: 000000001111222233334444000000ff
+  m_GUID: 000000aa000000bb000000cc000000dd
+  m_AssetGUID: 0000000012345678000000009abcdef0
+  m_PrefabGuid: 00000000ffffeeee00000000dddd0000
+  guid: 00000000ccccdddd00000000eeee0000
+  m_Label: level000000
+`
+	const want = `%YAML 1.1
+%TAG !u! tag:unity3d.com,2011:
+--- !u!1 &1000000
+GameObject:
+  m_Name: Spawn111111
+  m_Script: {fileID: 11500000, guid: 00000000aaaa00000000bbbb00000000, type: 3}
+--- !u!114 &2000000
+MonoBehaviour:
+  m_SceneGUID: 000000001111222233334444000000ff
+  m_GUID: 000000aa000000bb000000cc000000dd
+  m_AssetGUID: 0000000012345678000000009abcdef0
+  m_PrefabGuid: 00000000ffffeeee00000000dddd0000
+  guid: 00000000ccccdddd00000000eeee0000
+  m_Label: level111111
+`
+	r := &replacer{re: regexp.MustCompile("000000"), repl: "111111", literal: true}
+	got, changes := replaceInSpans(scene, yamlSpans(scene), r)
+	if got != want {
+		t.Errorf("replacement touched a protected span:\n%s", got)
+	}
+	if len(changes) != 2 {
+		t.Errorf("got %d changed lines, want 2", len(changes))
+	}
+}
+
+// TestJSONProtectedGUIDs replaces in an .asmdef and checks that the assembly
+// references by GUID keep their value while the names change
+func TestJSONProtectedGUIDs(t *testing.T) {
+	const asmdef = `{
+    "name": "Game.Core",
+    "rootNamespace": "Game.Core",
+    "references": [
+        "GUID:00000000aaaa00000000bbbb00000000",
+        "Game.Core.Utils"
+    ],
+    "versionDefines": []
+}
+`
+	const want = `{
+    "name": "Game.Runtime",
+    "rootNamespace": "Game.Runtime",
+    "references": [
+        "GUID:00000000aaaa00000000bbbb00000000",
+        "Game.Runtime.Utils"
+    ],
+    "versionDefines": []
+}
+`
+	r := &replacer{re: regexp.MustCompile("Core|0000"), repl: "Runtime", literal: true}
+	got, changes := replaceInSpans(asmdef, jsonSpans(asmdef), r)
+	if got != want {
+		t.Errorf("replacement touched a protected value:\n%s", got)
+	}
+	if len(changes) != 3 {
+		t.Errorf("got %d changed lines, want 3", len(changes))
+	}
+}
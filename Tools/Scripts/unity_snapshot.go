@@ -0,0 +1,1209 @@
+// Unity Snapshot — Restore points for a project before risky operations.
+// A snapshot is a copy of Assets/, Packages/ and ProjectSettings/ (plus the rename
+// state and .unitystarter.json) under .unitystarter_snapshots/<id>/. Files that have
+// not changed since the previous snapshot are hard-linked to it instead of copied,
+// so after the first one a snapshot only costs the files that changed. Snapshots are
+// linked to each other, never to the project, so a file saved in place by the editor
+// cannot change one. Where the file system has no hard links, files are copied.
+//
+// restore puts the covered folders back as they were: changed files are copied back
+// and files added since are deleted. The current state is snapshotted first, so a
+// restore can be undone in turn. rename_project, unity_guid_checker -fix and
+// unity_asset_mover take a snapshot before they write when run with -snapshot.
+//
+// Build: go build unity_snapshot.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_snapshot -label "before GUID fix"     # create (the default command)
+//	unity_snapshot list
+//	unity_snapshot restore -dry-run latest      # list what a restore would change
+//	unity_snapshot restore 2026-10-15_011903
+//	unity_snapshot delete 2026-10-15_011903
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// What a snapshot covers, relative to the project root. Library/ and the other
+// caches are rebuilt by Unity from these.
+var snapshotRoots = []string{"Assets", "Packages", "ProjectSettings", ".rename_project.json", ".unitystarter.json"}
+
+const (
+	snapshotDirName  = ".unitystarter_snapshots"
+	manifestFileName = "snapshot.json"
+	// A snapshot is written under <id>.partial and renamed when complete
+	partialSuffix = ".partial"
+	// Snapshots kept by create; older ones are deleted
+	defaultKeep = 10
+	// Paths listed per kind of change by restore -dry-run
+	maxListedPaths = 20
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// snapshotManifest is the snapshot.json of a snapshot. Keep field names stable:
+// snapshots outlive the tool version that wrote them.
+type snapshotManifest struct {
+	ID          string         `json:"id"`
+	CreatedAt   string         `json:"createdAt"`
+	Label       string         `json:"label,omitempty"`
+	Roots       []string       `json:"roots"`
+	Dirs        []string       `json:"dirs"`
+	Files       []snapshotFile `json:"files"`
+	Linked      int            `json:"linked"`      // files shared with the previous snapshot
+	CopiedBytes int64          `json:"copiedBytes"` // disk space the snapshot took when it was created
+}
+
+// snapshotFile is one file of a snapshot; size and time decide whether the
+// project's copy has changed since
+type snapshotFile struct {
+	Path    string      `json:"path"` // slash-separated, relative to the project root
+	Size    int64       `json:"size"`
+	ModTime int64       `json:"modTime"` // Unix nanoseconds
+	Mode    os.FileMode `json:"mode"`
+}
+
+func (f snapshotFile) sameAs(other snapshotFile) bool {
+	return f.Size == other.Size && f.ModTime == other.ModTime && f.Mode == other.Mode
+}
+
+// restorePlan is what a restore changes, with slash-separated project paths
+type restorePlan struct {
+	restore []snapshotFile
+	remove  []string
+	dirs    []string // folders that are not in the snapshot, deepest first
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// isProjectOpen reports whether Unity has the project open: it holds
+// Temp/UnityLockfile while it does
+func isProjectOpen(basePath string) bool {
+	_, err := os.Stat(filepath.Join(basePath, "Temp", "UnityLockfile"))
+	return err == nil
+}
+
+// ============================================================
+// Scanning
+// ============================================================
+
+// scanProject lists the folders and files the snapshot roots hold now. Symbolic
+// links are neither followed nor captured; they are returned as skipped.
+func scanProject(basePath string) (dirs []string, files map[string]snapshotFile, skipped []string, err error) {
+	files = make(map[string]snapshotFile)
+	for _, root := range snapshotRoots {
+		rootPath := filepath.Join(basePath, root)
+		if _, statErr := os.Lstat(rootPath); os.IsNotExist(statErr) {
+			continue
+		}
+		walkErr := filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(basePath, path)
+			rel = filepath.ToSlash(rel)
+			switch {
+			case d.Type()&fs.ModeSymlink != 0:
+				skipped = append(skipped, rel)
+			case d.IsDir():
+				dirs = append(dirs, rel)
+			case d.Type().IsRegular():
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+				files[rel] = snapshotFile{Path: rel, Size: info.Size(), ModTime: info.ModTime().UnixNano(), Mode: info.Mode().Perm()}
+			default:
+				skipped = append(skipped, rel)
+			}
+			return nil
+		})
+		if walkErr != nil {
+			return nil, nil, nil, walkErr
+		}
+	}
+	return dirs, files, skipped, nil
+}
+
+// ============================================================
+// Snapshot Store
+// ============================================================
+
+// loadSnapshots returns the complete snapshots of the project, oldest first
+func loadSnapshots(basePath string) ([]*snapshotManifest, error) {
+	entries, err := os.ReadDir(filepath.Join(basePath, snapshotDirName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []*snapshotManifest
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasSuffix(entry.Name(), partialSuffix) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(basePath, snapshotDirName, entry.Name(), manifestFileName))
+		if err != nil {
+			continue
+		}
+		var m snapshotManifest
+		if err := json.Unmarshal(data, &m); err != nil || m.ID != entry.Name() {
+			continue
+		}
+		snapshots = append(snapshots, &m)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].CreatedAt != snapshots[j].CreatedAt {
+			return snapshots[i].CreatedAt < snapshots[j].CreatedAt
+		}
+		return snapshots[i].ID < snapshots[j].ID
+	})
+	return snapshots, nil
+}
+
+// findSnapshot looks a snapshot up by ID; "latest" is the newest one
+func findSnapshot(snapshots []*snapshotManifest, id string) (*snapshotManifest, error) {
+	if id == "latest" && len(snapshots) > 0 {
+		return snapshots[len(snapshots)-1], nil
+	}
+	for _, m := range snapshots {
+		if m.ID == id {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("no snapshot '%s' (run 'unity_snapshot list' to see them)", id)
+}
+
+// snapshotFilesDir is where a snapshot keeps its copy of the project
+func snapshotFilesDir(basePath, id string) string {
+	return filepath.Join(basePath, snapshotDirName, id, "files")
+}
+
+// newSnapshotID names a snapshot after the time it was taken
+func newSnapshotID(basePath string) string {
+	base := time.Now().Format("2006-01-02_150405")
+	id := base
+	for n := 2; ; n++ {
+		_, err := os.Stat(filepath.Join(basePath, snapshotDirName, id))
+		_, partialErr := os.Stat(filepath.Join(basePath, snapshotDirName, id+partialSuffix))
+		if os.IsNotExist(err) && os.IsNotExist(partialErr) {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// ============================================================
+// Create
+// ============================================================
+
+// createSnapshot copies the snapshot roots into a new snapshot, hard-linking
+// every file that is unchanged since the previous one
+func createSnapshot(basePath, label string, previous *snapshotManifest) (*snapshotManifest, []string, error) {
+	dirs, files, skipped, err := scanProject(basePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot scan the project: %v", err)
+	}
+
+	m := &snapshotManifest{
+		ID:        newSnapshotID(basePath),
+		CreatedAt: time.Now().Format(time.RFC3339),
+		Label:     label,
+		Roots:     snapshotRoots,
+		Dirs:      dirs,
+	}
+	partialDir := filepath.Join(basePath, snapshotDirName, m.ID+partialSuffix)
+	filesDir := filepath.Join(partialDir, "files")
+	fail := func(err error) (*snapshotManifest, []string, error) {
+		os.RemoveAll(partialDir)
+		return nil, nil, err
+	}
+
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(filesDir, filepath.FromSlash(dir)), 0755); err != nil {
+			return fail(err)
+		}
+	}
+
+	previousFiles := make(map[string]snapshotFile)
+	if previous != nil {
+		for _, f := range previous.Files {
+			previousFiles[f.Path] = f
+		}
+	}
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		f := files[p]
+		dst := filepath.Join(filesDir, filepath.FromSlash(p))
+		if old, ok := previousFiles[p]; ok && old.sameAs(f) {
+			// Fails on file systems without hard links, and once a file has as many
+			// links as the file system allows; the file is copied then
+			if os.Link(filepath.Join(snapshotFilesDir(basePath, previous.ID), filepath.FromSlash(p)), dst) == nil {
+				m.Linked++
+				m.Files = append(m.Files, f)
+				continue
+			}
+		}
+		copied, err := copySnapshotFile(filepath.Join(basePath, filepath.FromSlash(p)), dst, f)
+		if err != nil {
+			return fail(fmt.Errorf("cannot copy %s: %v", p, err))
+		}
+		m.CopiedBytes += f.Size
+		m.Files = append(m.Files, copied)
+	}
+
+	data, _ := json.MarshalIndent(m, "", "  ")
+	if err := os.WriteFile(filepath.Join(partialDir, manifestFileName), append(data, '\n'), 0644); err != nil {
+		return fail(err)
+	}
+	if err := os.Rename(partialDir, filepath.Join(basePath, snapshotDirName, m.ID)); err != nil {
+		return fail(err)
+	}
+	return m, skipped, nil
+}
+
+// copySnapshotFile copies a project file into a snapshot with its time and mode.
+// A file that changes while it is copied is recorded as it was copied, so the
+// next snapshot does not link to a copy that matches neither version.
+func copySnapshotFile(src, dst string, f snapshotFile) (snapshotFile, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return f, err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, f.Mode|0200)
+	if err != nil {
+		return f, err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return f, err
+	}
+	if err := out.Close(); err != nil {
+		return f, err
+	}
+	if info, err := in.Stat(); err == nil && (info.Size() != f.Size || info.ModTime().UnixNano() != f.ModTime) {
+		f.ModTime = 0
+		if dstInfo, err := os.Stat(dst); err == nil {
+			f.Size = dstInfo.Size()
+		}
+	}
+	mtime := time.Unix(0, f.ModTime)
+	return f, os.Chtimes(dst, mtime, mtime)
+}
+
+// pruneSnapshots deletes the oldest snapshots beyond keep (0 keeps all). Every
+// snapshot is complete on its own; deleting one never breaks a later one.
+func pruneSnapshots(basePath string, keep int) {
+	if keep <= 0 {
+		return
+	}
+	snapshots, err := loadSnapshots(basePath)
+	if err != nil || len(snapshots) <= keep {
+		return
+	}
+	for _, m := range snapshots[:len(snapshots)-keep] {
+		if err := os.RemoveAll(filepath.Join(basePath, snapshotDirName, m.ID)); err != nil {
+			fmt.Printf(tr("[WARNING] Cannot delete old snapshot %s: %v\n"), m.ID, err)
+			continue
+		}
+		fmt.Printf(tr("[OK] Deleted old snapshot: %s\n"), m.ID)
+		recordAction("delete", m.ID, "ok", "older than the last "+strconv.Itoa(keep), 0)
+	}
+}
+
+// removePartialSnapshots deletes what an interrupted create left behind
+func removePartialSnapshots(basePath string) {
+	entries, _ := os.ReadDir(filepath.Join(basePath, snapshotDirName))
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasSuffix(entry.Name(), partialSuffix) {
+			os.RemoveAll(filepath.Join(basePath, snapshotDirName, entry.Name()))
+		}
+	}
+}
+
+// ============================================================
+// Restore
+// ============================================================
+
+// planRestore compares the project with a snapshot
+func planRestore(basePath string, m *snapshotManifest) (*restorePlan, error) {
+	dirs, files, _, err := scanProject(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot scan the project: %v", err)
+	}
+	plan := &restorePlan{}
+	filesDir := snapshotFilesDir(basePath, m.ID)
+	wanted := make(map[string]bool, len(m.Files))
+	for _, f := range m.Files {
+		wanted[f.Path] = true
+		current, ok := files[f.Path]
+		if ok && current.sameAs(f) {
+			continue
+		}
+		// A file restored earlier has a newer time but the same content
+		if ok && current.Size == f.Size && current.Mode == f.Mode &&
+			sameContent(filepath.Join(basePath, filepath.FromSlash(f.Path)), filepath.Join(filesDir, filepath.FromSlash(f.Path))) {
+			continue
+		}
+		plan.restore = append(plan.restore, f)
+	}
+	for p := range files {
+		if !wanted[p] {
+			plan.remove = append(plan.remove, p)
+		}
+	}
+	sort.Strings(plan.remove)
+
+	wantedDirs := make(map[string]bool, len(m.Dirs))
+	for _, dir := range m.Dirs {
+		wantedDirs[dir] = true
+	}
+	for _, dir := range dirs {
+		if !wantedDirs[dir] {
+			plan.dirs = append(plan.dirs, dir)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(plan.dirs)))
+	return plan, nil
+}
+
+// applyRestore deletes what the snapshot does not have and copies back what
+// changed. Restored files get the current time, so Unity sees that they changed
+// and imports them again.
+func applyRestore(basePath string, m *snapshotManifest, plan *restorePlan) error {
+	var errs []string
+	for _, p := range plan.remove {
+		if err := os.Remove(filepath.Join(basePath, filepath.FromSlash(p))); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, fmt.Sprintf("cannot delete %s: %v", p, err))
+			continue
+		}
+		recordAction("delete", p, "ok", "", 0)
+	}
+	// Deepest first, so a folder is empty by the time it is removed; one that is
+	// not (a symbolic link the snapshot skipped) stays with a warning
+	for _, dir := range plan.dirs {
+		if err := os.Remove(filepath.Join(basePath, filepath.FromSlash(dir))); err != nil && !os.IsNotExist(err) {
+			fmt.Printf(tr("[WARNING] Folder not removed: %s: %v\n"), dir, err)
+		}
+	}
+	for _, dir := range m.Dirs {
+		if err := os.MkdirAll(filepath.Join(basePath, filepath.FromSlash(dir)), 0755); err != nil {
+			errs = append(errs, fmt.Sprintf("cannot create %s: %v", dir, err))
+		}
+	}
+	filesDir := snapshotFilesDir(basePath, m.ID)
+	for _, f := range plan.restore {
+		src := filepath.Join(filesDir, filepath.FromSlash(f.Path))
+		if err := restoreFile(src, filepath.Join(basePath, filepath.FromSlash(f.Path)), f.Mode); err != nil {
+			errs = append(errs, fmt.Sprintf("cannot restore %s: %v", f.Path, err))
+			recordAction("restore", f.Path, "failed", err.Error(), 0)
+			continue
+		}
+		recordAction("restore", f.Path, "ok", "", 0)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d error(s): %s", len(errs), strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// sameContent compares two files byte by byte; unreadable files differ
+func sameContent(a, b string) bool {
+	fa, err := os.Open(a)
+	if err != nil {
+		return false
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return false
+	}
+	defer fb.Close()
+	bufA, bufB := make([]byte, 64*1024), make([]byte, 64*1024)
+	for {
+		na, errA := io.ReadFull(fa, bufA)
+		nb, errB := io.ReadFull(fb, bufB)
+		if na != nb || !bytes.Equal(bufA[:na], bufB[:nb]) {
+			return false
+		}
+		if errA == io.EOF || errA == io.ErrUnexpectedEOF || errB == io.EOF || errB == io.ErrUnexpectedEOF {
+			return (errA == io.EOF || errA == io.ErrUnexpectedEOF) && (errB == io.EOF || errB == io.ErrUnexpectedEOF)
+		}
+		if errA != nil || errB != nil {
+			return false
+		}
+	}
+}
+
+// restoreFile copies a file out of a snapshot through a temporary file, so the
+// project's copy is replaced whole. It is never linked: the project must not
+// share a file with a snapshot.
+func restoreFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := dst + ".snapshot-restore"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode|0200)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// printPaths lists up to maxListedPaths paths
+func printPaths(paths []string) {
+	for i, p := range paths {
+		if i == maxListedPaths {
+			fmt.Printf(tr("    ... and %d more\n"), len(paths)-maxListedPaths)
+			break
+		}
+		fmt.Printf("    %s\n", p)
+	}
+}
+
+// ============================================================
+// Commands
+// ============================================================
+
+// createCommand takes a snapshot and deletes the ones beyond keep
+func createCommand(basePath, label string, keep int) int {
+	snapshots, err := loadSnapshots(basePath)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot read %s: %v\n"), snapshotDirName, err)
+		recordError("cannot read %s: %v", snapshotDirName, err)
+		return 1
+	}
+	var previous *snapshotManifest
+	if len(snapshots) > 0 {
+		previous = snapshots[len(snapshots)-1]
+	}
+	if err := os.MkdirAll(filepath.Join(basePath, snapshotDirName), 0755); err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		return 1
+	}
+	removePartialSnapshots(basePath)
+
+	fmt.Println(tr("Taking snapshot of Assets/, Packages/ and ProjectSettings/..."))
+	start := time.Now()
+	m, skipped, err := createSnapshot(basePath, label, previous)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Snapshot failed: %v\n"), err)
+		recordAction("create", "", "failed", err.Error(), time.Since(start))
+		recordError("snapshot failed: %v", err)
+		return 1
+	}
+	for _, s := range skipped {
+		fmt.Printf(tr("[WARNING] Skipped (not a regular file): %s\n"), s)
+	}
+	fmt.Printf(tr("[OK] Snapshot %s: %d files, %d unchanged since the previous one, %s copied (%.1fs)\n"),
+		m.ID, len(m.Files), m.Linked, formatSize(m.CopiedBytes), time.Since(start).Seconds())
+	fmt.Printf(tr("     Restore with: unity_snapshot restore %s\n"), m.ID)
+	recordAction("create", m.ID, "ok", fmt.Sprintf("%d files, %d linked, %d bytes copied", len(m.Files), m.Linked, m.CopiedBytes), time.Since(start))
+	recordArtifact(filepath.Join(basePath, snapshotDirName, m.ID))
+
+	pruneSnapshots(basePath, keep)
+	return 0
+}
+
+// listCommand prints the snapshots, newest first
+func listCommand(basePath string) int {
+	snapshots, err := loadSnapshots(basePath)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot read %s: %v\n"), snapshotDirName, err)
+		recordError("cannot read %s: %v", snapshotDirName, err)
+		return 1
+	}
+	if len(snapshots) == 0 {
+		fmt.Println(tr("[--] No snapshots yet. Take one with: unity_snapshot create"))
+		return 0
+	}
+	fmt.Printf("%-20s  %-19s  %8s  %10s  %s\n", "ID", tr("Created"), tr("Files"), tr("Added"), tr("Label"))
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		m := snapshots[i]
+		created := m.CreatedAt
+		if t, err := time.Parse(time.RFC3339, m.CreatedAt); err == nil {
+			created = t.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%-20s  %-19s  %8d  %10s  %s\n", m.ID, created, len(m.Files), formatSize(m.CopiedBytes), m.Label)
+		recordAction("snapshot", m.ID, "ok", m.Label, 0)
+	}
+	fmt.Println(tr("\n\"Added\" is the disk space a snapshot took when it was created; unchanged files are shared."))
+	return 0
+}
+
+// restoreCommand puts the project back to a snapshot, taking a snapshot of the
+// current state first. Nothing is pruned, so the snapshot restored from stays.
+func restoreCommand(basePath, id string, ciMode, dryRun bool) int {
+	snapshots, err := loadSnapshots(basePath)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot read %s: %v\n"), snapshotDirName, err)
+		recordError("cannot read %s: %v", snapshotDirName, err)
+		return 1
+	}
+	m, err := findSnapshot(snapshots, id)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		return 1
+	}
+	plan, err := planRestore(basePath, m)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		return 1
+	}
+
+	label := m.ID
+	if m.Label != "" {
+		label += " (" + m.Label + ")"
+	}
+	printRule("=============================================")
+	fmt.Printf(tr("  RESTORE %s\n"), label)
+	printRule("=============================================")
+	if len(plan.restore) == 0 && len(plan.remove) == 0 && len(plan.dirs) == 0 {
+		fmt.Println(tr("[--] The project already matches this snapshot."))
+		return 0
+	}
+	restorePaths := make([]string, len(plan.restore))
+	for i, f := range plan.restore {
+		restorePaths[i] = f.Path
+	}
+	fmt.Printf(tr("  Files to restore: %d\n"), len(plan.restore))
+	printPaths(restorePaths)
+	fmt.Printf(tr("  Files to delete:  %d\n"), len(plan.remove))
+	printPaths(plan.remove)
+	if len(plan.dirs) > 0 {
+		fmt.Printf(tr("  Folders to delete: %d\n"), len(plan.dirs))
+	}
+
+	if dryRun {
+		for _, p := range restorePaths {
+			recordAction("restore", p, "planned", "", 0)
+		}
+		for _, p := range plan.remove {
+			recordAction("delete", p, "planned", "", 0)
+		}
+		fmt.Println(tr("\n[Dry Run] Nothing was changed."))
+		return 0
+	}
+	if !ciMode {
+		fmt.Print(tr("\nRestore the project to this snapshot? The current state is snapshotted first. (y/N): "))
+		confirm, _ := stdinReader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+			fmt.Println(tr("Operation cancelled."))
+			return 0
+		}
+	}
+
+	fmt.Println()
+	if code := createCommand(basePath, "before restore "+m.ID, 0); code != 0 {
+		fmt.Println(tr("[ERROR] The project was not changed."))
+		return code
+	}
+	fmt.Println(tr("\nRestoring..."))
+	if err := applyRestore(basePath, m, plan); err != nil {
+		fmt.Printf(tr("[ERROR] Restore incomplete: %v\n"), err)
+		recordError("restore incomplete: %v", err)
+		return 1
+	}
+	fmt.Printf(tr("[OK] Restored %s: %d file(s) copied back, %d deleted\n"), m.ID, len(plan.restore), len(plan.remove))
+	fmt.Println(tr("     Unity imports the restored files when the project is opened next."))
+	return 0
+}
+
+// deleteCommand deletes one snapshot
+func deleteCommand(basePath, id string) int {
+	snapshots, err := loadSnapshots(basePath)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot read %s: %v\n"), snapshotDirName, err)
+		recordError("cannot read %s: %v", snapshotDirName, err)
+		return 1
+	}
+	m, err := findSnapshot(snapshots, id)
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		return 1
+	}
+	if err := os.RemoveAll(filepath.Join(basePath, snapshotDirName, m.ID)); err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordAction("delete", m.ID, "failed", err.Error(), 0)
+		recordError("%v", err)
+		return 1
+	}
+	fmt.Printf(tr("[OK] Deleted snapshot: %s\n"), m.ID)
+	recordAction("delete", m.ID, "ok", "", 0)
+	return 0
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"[ERROR] Current directory does not appear to be a Unity project.":                                  "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                                            "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"[ERROR] Cannot get current directory: %v\n":                                                        "[ERROR] 无法获取当前目录: %v\n",
+	"Usage: unity_snapshot [flags] [create]":                                                            "用法: unity_snapshot [参数] [create]",
+	"       unity_snapshot [flags] list":                                                                "      unity_snapshot [参数] list",
+	"       unity_snapshot [flags] restore <id|latest>":                                                 "      unity_snapshot [参数] restore <快照ID|latest>",
+	"       unity_snapshot [flags] delete <id|latest>":                                                  "      unity_snapshot [参数] delete <快照ID|latest>",
+	"[ERROR] The project is open in Unity; close it before restoring, or pass -force.":                  "[ERROR] 项目正在 Unity 中打开；请先关闭再恢复，或使用 -force。",
+	"[WARNING] The project seems to be open in Unity; files it is saving may be captured half-written.": "[WARNING] 项目似乎正在 Unity 中打开；正在保存的文件可能只被捕获了一半。",
+	"[ERROR] Cannot read %s: %v\n":                                                                      "[ERROR] 无法读取 %s: %v\n",
+	"Taking snapshot of Assets/, Packages/ and ProjectSettings/...":                                     "正在为 Assets/、Packages/ 和 ProjectSettings/ 创建快照...",
+	"[ERROR] Snapshot failed: %v\n":                                                                     "[ERROR] 创建快照失败: %v\n",
+	"[WARNING] Skipped (not a regular file): %s\n":                                                      "[WARNING] 已跳过（不是普通文件）: %s\n",
+	"[OK] Snapshot %s: %d files, %d unchanged since the previous one, %s copied (%.1fs)\n":              "[OK] 快照 %s: %d 个文件，其中 %d 个与上一个快照相同，复制了 %s（%.1f 秒）\n",
+	"     Restore with: unity_snapshot restore %s\n":                                                    "     恢复命令: unity_snapshot restore %s\n",
+	"[WARNING] Cannot delete old snapshot %s: %v\n":                                                     "[WARNING] 无法删除旧快照 %s: %v\n",
+	"[OK] Deleted old snapshot: %s\n":                                                                   "[OK] 已删除旧快照: %s\n",
+	"[--] No snapshots yet. Take one with: unity_snapshot create":                                       "[--] 还没有快照。创建命令: unity_snapshot create",
+	"Created": "创建时间",
+	"Files":   "文件数",
+	"Added":   "新增占用",
+	"Label":   "备注",
+	"\n\"Added\" is the disk space a snapshot took when it was created; unchanged files are shared.": "\n“新增占用”是快照创建时占用的磁盘空间；未变化的文件在快照之间共享。",
+	"  RESTORE %s\n": "  恢复 %s\n",
+	"[--] The project already matches this snapshot.": "[--] 项目已与该快照一致。",
+	"  Files to restore: %d\n":                        "  要恢复的文件: %d\n",
+	"  Files to delete:  %d\n":                        "  要删除的文件: %d\n",
+	"  Folders to delete: %d\n":                       "  要删除的文件夹: %d\n",
+	"    ... and %d more\n":                           "    ... 以及另外 %d 个\n",
+	"\n[Dry Run] Nothing was changed.":                "\n[Dry Run] 未做任何更改。",
+	"\nRestore the project to this snapshot? The current state is snapshotted first. (y/N): ": "\n将项目恢复到此快照？会先为当前状态创建快照。(y/N): ",
+	"Operation cancelled.":                                                   "操作已取消。",
+	"[ERROR] The project was not changed.":                                   "[ERROR] 项目未被修改。",
+	"\nRestoring...":                                                         "\n正在恢复...",
+	"[ERROR] Restore incomplete: %v\n":                                       "[ERROR] 恢复未完成: %v\n",
+	"[OK] Restored %s: %d file(s) copied back, %d deleted\n":                 "[OK] 已恢复 %s: 复制回 %d 个文件，删除 %d 个\n",
+	"     Unity imports the restored files when the project is opened next.": "     下次打开项目时 Unity 会导入恢复的文件。",
+	"[WARNING] Folder not removed: %s: %v\n":                                 "[WARNING] 未删除文件夹: %s: %v\n",
+	"[OK] Deleted snapshot: %s\n":                                            "[OK] 已删除快照: %s\n",
+	"[WARNING] Removing a stale %s (%s)\n":                                   "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n":              "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+	"\nPress Enter to continue...":                                           "\n按回车键继续...",
+	"[ERROR] -dry-run is a restore flag.":                                    "[ERROR] -dry-run 仅适用于 restore。",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// lockProject takes the lock, unless the tool that started this one holds it:
+// rename_project -snapshot and the like run create while they have the project
+func lockProject(basePath, operation string, force bool) error {
+	host, _ := os.Hostname()
+	if held, err := readProjectLock(filepath.Join(basePath, projectLockFile)); err == nil &&
+		held.PID == os.Getppid() && strings.EqualFold(held.Host, host) {
+		return nil
+	}
+	return acquireProjectLock(basePath, "unity_snapshot", operation, force)
+}
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode, dryRun, force bool
+	var label string
+	var keep int
+
+	flag.StringVar(&label, "label", "", "create: a note shown by list, e.g. \"before GUID fix\"")
+	flag.IntVar(&keep, "keep", defaultKeep, "create: snapshots to keep; the oldest beyond it are deleted (0 keeps all)")
+	flag.BoolVar(&dryRun, "dry-run", false, "restore: list what would be restored and deleted without changing anything")
+	flag.BoolVar(&force, "force", false, "Run even if the project is open in Unity or '.unitystarter.lock' says another tool is working on it")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no confirmation prompts)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the command ("restore latest -dry-run")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_snapshot")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+
+	command := "create"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	needsID := command == "restore" || command == "delete"
+	switch {
+	case command != "create" && command != "list" && !needsID,
+		needsID && len(args) != 1,
+		!needsID && len(args) != 0:
+		fmt.Println(tr("Usage: unity_snapshot [flags] [create]"))
+		fmt.Println(tr("       unity_snapshot [flags] list"))
+		fmt.Println(tr("       unity_snapshot [flags] restore <id|latest>"))
+		fmt.Println(tr("       unity_snapshot [flags] delete <id|latest>"))
+		recordError("expected create, list, restore <id> or delete <id>")
+		exit(2)
+	case dryRun && command != "restore":
+		fmt.Println(tr("[ERROR] -dry-run is a restore flag."))
+		recordError("-dry-run is a restore flag")
+		exit(2)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+
+	if command == "list" {
+		exit(listCommand(basePath))
+	}
+
+	// create and restore read or write the whole project: no other tool may
+	// change it meanwhile, and neither may the editor. delete only needs the
+	// lock, so a create does not link against a snapshot being removed.
+	if command != "delete" && isProjectOpen(basePath) {
+		if command == "restore" && !dryRun && !force {
+			fmt.Println(tr("[ERROR] The project is open in Unity; close it before restoring, or pass -force."))
+			recordError("the project is open in Unity")
+			exit(1)
+		}
+		fmt.Println(tr("[WARNING] The project seems to be open in Unity; files it is saving may be captured half-written."))
+	}
+	if !dryRun {
+		if err := lockProject(basePath, command, force); err != nil {
+			fmt.Printf(tr("[ERROR] %v\n"), err)
+			recordError("%v", err)
+			exit(1)
+		}
+		defer releaseProjectLock()
+	}
+
+	switch command {
+	case "restore":
+		exit(restoreCommand(basePath, args[0], ciMode, dryRun))
+	case "delete":
+		exit(deleteCommand(basePath, args[0]))
+	}
+	exit(createCommand(basePath, label, keep))
+}
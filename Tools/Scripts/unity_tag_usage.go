@@ -0,0 +1,1114 @@
+// Unity Tag Usage — Check the tags, layers and sorting layers code and assets use against the TagManager.
+// Reads ProjectSettings/TagManager.asset and finds every tag, layer and sorting
+// layer the project names: string literals in C# (CompareTag, FindWithTag,
+// .tag == "...", LayerMask.NameToLayer / GetMask, sortingLayerName,
+// SortingLayer.NameToID) and the serialized m_TagString, m_Layer, layer mask and
+// m_SortingLayerID of scenes, prefabs and assets, prefab overrides included.
+// Unity does not complain about most of these at build time: CompareTag throws
+// only when it runs, NameToLayer returns -1, and a renderer on a deleted sorting
+// layer silently draws on Default. It reports every name and ID that is not
+// defined with its file and line, and the custom tags, layers and sorting layers
+// nothing uses. -out writes the findings as a Markdown or CSV report.
+//
+// Build: go build unity_tag_usage.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_tag_usage                                     # Assets/ and Packages/
+//	unity_tag_usage Assets/Game                         # report undefined names in one folder
+//	unity_tag_usage -out Docs/TagUsage.md               # report (.md or .csv)
+//	unity_tag_usage -strict -ci                         # CI: unused entries fail too
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const tagManagerFile = "TagManager.asset" // in ProjectSettings/
+
+const layerCount = 32
+
+// Tags every project has; they cannot be removed and are not reported as unused
+var builtinTags = map[string]bool{
+	"Untagged": true, "Respawn": true, "Finish": true, "EditorOnly": true,
+	"MainCamera": true, "Player": true, "GameController": true,
+}
+
+// Layers Unity names itself; they are not reported as unused
+var builtinLayers = map[int]bool{0: true, 1: true, 2: true, 4: true, 5: true}
+
+// Files read for usages
+var scanExtensions = map[string]bool{".cs": true, ".unity": true, ".prefab": true, ".asset": true}
+
+// Components with m_SortingLayerID, to name them in findings
+var rendererClasses = map[string]string{
+	"23": "MeshRenderer", "96": "TrailRenderer", "120": "LineRenderer",
+	"137": "SkinnedMeshRenderer", "199": "ParticleSystemRenderer", "210": "SortingGroup",
+	"212": "SpriteRenderer", "223": "Canvas", "1971053207": "SpriteShapeRenderer",
+	"483693784": "TilemapRenderer",
+}
+
+// Finding kinds, in report order
+const (
+	findTag           = "tag"
+	findLayer         = "layer"
+	findSorting       = "sorting"
+	findUnusedTag     = "unused-tag"
+	findUnusedLayer   = "unused-layer"
+	findUnusedSorting = "unused-sorting"
+)
+
+var findingSections = []struct {
+	kind  string
+	title string
+	tag   string
+}{
+	{findTag, "Undefined tags", "[UNDEFINED]"},
+	{findLayer, "Undefined layers", "[UNDEFINED]"},
+	{findSorting, "Undefined sorting layers", "[UNDEFINED]"},
+	{findUnusedTag, "Unused tags", "[UNUSED]   "},
+	{findUnusedLayer, "Unused layers", "[UNUSED]   "},
+	{findUnusedSorting, "Unused sorting layers", "[UNUSED]   "},
+}
+
+const literal = `"((?:[^"\\\n]|\\.)*)"`
+
+var (
+	docRegex = regexp.MustCompile(`(?m)^--- !u!(\d+) &(-?\d+)`)
+	// CompareTag("Enemy"), GameObject.FindWithTag("Player"), FindGameObjectsWithTag("Pickup")
+	tagCallRegex = regexp.MustCompile(`\b(CompareTag|FindWithTag|FindGameObjectWithTag|FindGameObjectsWithTag)\s*\(\s*` + literal)
+	// other.tag == "Enemy", tag = "Player", "Enemy" != hit.collider.tag
+	tagCompareRegex  = regexp.MustCompile(`(?:\.tag|^\s*tag)\s*(?:==|!=|=)\s*` + literal)
+	tagReversedRegex = regexp.MustCompile(literal + `\s*(?:==|!=)\s*[\w.]*\.tag\b`)
+	// LayerMask.NameToLayer("Water"), LayerMask.GetMask("Default", "Ground")
+	layerCallRegex = regexp.MustCompile(`\bLayerMask\.(NameToLayer|GetMask)\s*\(([^)]*)\)`)
+	// renderer.sortingLayerName = "UI", SortingLayer.NameToID("Background")
+	sortingRegex  = regexp.MustCompile(`\b(sortingLayerName\s*=|SortingLayer\.NameToID\s*\(|SortingLayer\.GetLayerValueFromName\s*\()\s*` + literal)
+	literalRegex  = regexp.MustCompile(literal)
+	propertyRegex = regexp.MustCompile(`^\s*propertyPath: (.+)$`)
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// yamlDoc is one object of a Unity YAML file
+type yamlDoc struct {
+	class string
+	id    string
+	line  int
+	body  string
+}
+
+// entry is one name the TagManager defines, with its line there
+type entry struct {
+	name string
+	id   string // uniqueID of a sorting layer
+	line int
+	used bool
+}
+
+// tagManager is what ProjectSettings/TagManager.asset defines
+type tagManager struct {
+	tags    []*entry
+	layers  [layerCount]*entry // nil for a layer without a name
+	sorting []*entry
+}
+
+// finding is one problem, kept as an English format so the report and JSON stay English
+type finding struct {
+	kind   string
+	file   string
+	line   int
+	format string
+	args   []interface{}
+}
+
+func (f finding) message() string {
+	return fmt.Sprintf(f.format, f.args...)
+}
+
+// scan is everything the scan found
+type scan struct {
+	basePath string
+	roots    []string
+	tm       *tagManager
+	literals map[string]bool // every string literal of the scripts and string value of the assets
+	usages   int
+	seen     map[string]bool // findings already reported, by file, line and message
+	findings []finding
+}
+
+// ============================================================
+// Tag Manager
+// ============================================================
+
+// readTagManager reads the tags, layers and sorting layers with their lines
+func readTagManager(basePath string) (*tagManager, error) {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", tagManagerFile))
+	if err != nil {
+		return nil, err
+	}
+	tm := &tagManager{}
+	section := ""
+	layer := 0
+	for i, l := range strings.Split(string(data), "\n") {
+		l = strings.TrimRight(l, "\r")
+		line := i + 1
+		if strings.HasPrefix(l, "  ") && !strings.HasPrefix(l, "   ") && !strings.HasPrefix(l, "  -") {
+			section = strings.TrimSpace(strings.SplitN(l, ":", 2)[0])
+			continue
+		}
+		item := ""
+		switch {
+		case l == "  -":
+		case strings.HasPrefix(l, "  - "):
+			item = yamlUnquote(strings.TrimSpace(l[4:]))
+		case strings.HasPrefix(l, "    uniqueID: ") && section == "m_SortingLayers" && len(tm.sorting) > 0:
+			tm.sorting[len(tm.sorting)-1].id = strings.TrimSpace(l[len("    uniqueID: "):])
+			continue
+		default:
+			continue
+		}
+		switch section {
+		case "tags":
+			if item != "" {
+				tm.tags = append(tm.tags, &entry{name: item, line: line})
+			}
+		case "layers":
+			if layer < layerCount && item != "" {
+				tm.layers[layer] = &entry{name: item, line: line}
+			}
+			layer++
+		case "m_SortingLayers":
+			if strings.HasPrefix(item, "name:") {
+				tm.sorting = append(tm.sorting, &entry{name: yamlUnquote(strings.TrimSpace(item[len("name:"):])), line: line})
+			}
+		}
+	}
+	return tm, nil
+}
+
+func (tm *tagManager) tag(name string) *entry {
+	for _, t := range tm.tags {
+		if t.name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+func (tm *tagManager) layer(name string) *entry {
+	for _, l := range tm.layers {
+		if l != nil && l.name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// sortingLayer finds a sorting layer by name, or by uniqueID when byID is set
+func (tm *tagManager) sortingLayer(key string, byID bool) *entry {
+	for _, s := range tm.sorting {
+		if (byID && s.id == key) || (!byID && s.name == key) {
+			return s
+		}
+	}
+	return nil
+}
+
+func yamlUnquote(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// ============================================================
+// Project Files
+// ============================================================
+
+// projectFiles lists the scripts, scenes, prefabs and assets of Assets/ and Packages/
+func projectFiles(basePath string, excludes []*regexp.Regexp) []string {
+	var files []string
+	for _, root := range []string{"Assets", "Packages"} {
+		filepath.Walk(filepath.Join(basePath, root), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if strings.HasPrefix(info.Name(), ".") || strings.HasSuffix(info.Name(), "~") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			rel := relPath(basePath, path)
+			if scanExtensions[filepath.Ext(path)] && !matchesAny(excludes, rel) {
+				files = append(files, rel)
+			}
+			return nil
+		})
+	}
+	sort.Strings(files)
+	return files
+}
+
+// inRoots reports whether a project file is under one of the checked folders
+func (s *scan) inRoots(rel string) bool {
+	for _, r := range s.roots {
+		if rel == r || strings.HasPrefix(rel, r+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// add records a finding once per file, line and message
+func (s *scan) add(kind, file string, line int, format string, args ...interface{}) {
+	key := fmt.Sprintf("%s:%d:%s", file, line, fmt.Sprintf(format, args...))
+	if s.seen[key] {
+		return
+	}
+	s.seen[key] = true
+	s.findings = append(s.findings, finding{kind: kind, file: file, line: line, format: format, args: args})
+}
+
+// ============================================================
+// Usages
+// ============================================================
+
+// useTag marks a tag as used, or reports it when it is not defined
+func (s *scan) useTag(name, context, file string, line int, report bool) {
+	s.usages++
+	if t := s.tm.tag(name); t != nil {
+		t.used = true
+	} else if report && !builtinTags[name] && name != "" {
+		s.add(findTag, file, line, "tag \"%s\" is not defined (%s)", name, context)
+	}
+}
+
+// useLayerName marks a layer as used, or reports it when no layer has the name
+func (s *scan) useLayerName(name, context, file string, line int, report bool) {
+	s.usages++
+	if l := s.tm.layer(name); l != nil {
+		l.used = true
+	} else if report {
+		s.add(findLayer, file, line, "layer \"%s\" is not defined (%s)", name, context)
+	}
+}
+
+// useLayerIndex marks a layer as used, or reports it when the layer has no name
+func (s *scan) useLayerIndex(value, context, file string, line int, report bool) {
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 || i >= layerCount {
+		return
+	}
+	s.usages++
+	if l := s.tm.layers[i]; l != nil {
+		l.used = true
+	} else if report {
+		s.add(findLayer, file, line, "layer %d has no name (%s)", i, context)
+	}
+}
+
+// useMask marks the layers of a layer mask as used, and reports the layers
+// without a name it includes. A mask including every layer without a name is
+// "Everything" or "Everything but some": it names no layer in particular.
+func (s *scan) useMask(value, field, file string, line int, report bool) {
+	bits, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || bits == 0 {
+		return
+	}
+	mask := uint32(bits)
+	var unnamed []string
+	all := true
+	for i := 0; i < layerCount; i++ {
+		if s.tm.layers[i] != nil {
+			continue
+		}
+		if mask&(1<<uint(i)) != 0 {
+			unnamed = append(unnamed, strconv.Itoa(i))
+		} else {
+			all = false
+		}
+	}
+	if all {
+		return
+	}
+	s.usages++
+	for i := 0; i < layerCount; i++ {
+		if l := s.tm.layers[i]; l != nil && mask&(1<<uint(i)) != 0 {
+			l.used = true
+		}
+	}
+	if report && len(unnamed) > 0 {
+		s.add(findLayer, file, line, "layer mask %s includes layer(s) %s, which have no name", field, strings.Join(unnamed, ", "))
+	}
+}
+
+// useSortingName marks a sorting layer as used, or reports it when no sorting layer has the name
+func (s *scan) useSortingName(name, context, file string, line int, report bool) {
+	s.usages++
+	if l := s.tm.sortingLayer(name, false); l != nil {
+		l.used = true
+	} else if report {
+		s.add(findSorting, file, line, "sorting layer \"%s\" is not defined (%s)", name, context)
+	}
+}
+
+// useSortingID marks a sorting layer as used, or reports an ID no sorting layer has
+func (s *scan) useSortingID(id, context, file string, line int, report bool) {
+	s.usages++
+	if l := s.tm.sortingLayer(id, true); l != nil {
+		l.used = true
+	} else if report && id != "0" {
+		s.add(findSorting, file, line, "sorting layer ID %s does not exist (%s)", id, context)
+	}
+}
+
+// scanCode finds the tags, layers and sorting layers a script names, and keeps
+// all its string literals
+func (s *scan) scanCode(rel string, data []byte, report bool) {
+	for i, l := range strings.Split(string(data), "\n") {
+		line := i + 1
+		if t := strings.TrimSpace(l); strings.HasPrefix(t, "//") {
+			continue
+		}
+		for _, m := range literalRegex.FindAllStringSubmatch(l, -1) {
+			s.literals[m[1]] = true
+		}
+		for _, m := range tagCallRegex.FindAllStringSubmatch(l, -1) {
+			s.useTag(m[2], m[1], rel, line, report)
+		}
+		for _, m := range tagCompareRegex.FindAllStringSubmatch(l, -1) {
+			s.useTag(m[1], ".tag", rel, line, report)
+		}
+		for _, m := range tagReversedRegex.FindAllStringSubmatch(l, -1) {
+			s.useTag(m[1], ".tag", rel, line, report)
+		}
+		for _, m := range layerCallRegex.FindAllStringSubmatch(l, -1) {
+			for _, arg := range literalRegex.FindAllStringSubmatch(m[2], -1) {
+				s.useLayerName(arg[1], "LayerMask."+m[1], rel, line, report)
+			}
+		}
+		for _, m := range sortingRegex.FindAllStringSubmatch(l, -1) {
+			context := strings.TrimSpace(strings.TrimRight(m[1], "(="))
+			s.useSortingName(m[2], context, rel, line, report)
+		}
+	}
+}
+
+// scanAsset reads the tags, layers, layer masks and sorting layer IDs a scene,
+// prefab or asset serializes, and the ones its prefab overrides set
+func (s *scan) scanAsset(rel string, data []byte, report bool) {
+	for _, d := range splitDocs(data) {
+		lines := strings.Split(d.body, "\n")
+		for i := range lines {
+			lines[i] = strings.TrimRight(lines[i], "\r")
+		}
+		context := "!u!" + d.class + " &" + d.id
+		if name, ok := rendererClasses[d.class]; ok {
+			context = name
+		} else if d.class == "1" {
+			context = "GameObject \"" + docName(lines) + "\""
+		}
+		for i, l := range lines {
+			line := d.line + i
+			t := strings.TrimSpace(l)
+			switch {
+			case strings.HasPrefix(t, "m_TagString: ") && d.class == "1":
+				s.useTag(yamlUnquote(t[len("m_TagString: "):]), context, rel, line, report)
+			case strings.HasPrefix(t, "m_Layer: ") && d.class == "1":
+				s.useLayerIndex(t[len("m_Layer: "):], context, rel, line, report)
+			case strings.HasPrefix(t, "m_SortingLayerID: "):
+				s.useSortingID(t[len("m_SortingLayerID: "):], context, rel, line, report)
+			case strings.HasPrefix(t, "m_Bits: "):
+				s.useMask(t[len("m_Bits: "):], parentKey(lines, i), rel, line, report)
+			case propertyRegex.MatchString(l):
+				prop := propertyRegex.FindStringSubmatch(l)[1]
+				value, ok := overrideValue(lines, i)
+				if !ok {
+					continue
+				}
+				switch {
+				case prop == "m_TagString":
+					s.useTag(value, "prefab override", rel, line, report)
+				case prop == "m_Layer":
+					s.useLayerIndex(value, "prefab override", rel, line, report)
+				case prop == "m_SortingLayerID":
+					s.useSortingID(value, "prefab override", rel, line, report)
+				case strings.HasSuffix(prop, ".m_Bits"):
+					s.useMask(value, strings.TrimSuffix(prop, ".m_Bits"), rel, line, report)
+				}
+			case d.class == "114" && strings.HasPrefix(l, "  ") && strings.Contains(t, ": "):
+				// String fields of scripts may hold a tag or layer name
+				s.literals[yamlUnquote(strings.SplitN(t, ": ", 2)[1])] = true
+			}
+		}
+	}
+}
+
+// docName is the m_Name of an object, "" when it has none
+func docName(lines []string) string {
+	for _, l := range lines {
+		if strings.HasPrefix(l, "  m_Name: ") {
+			return yamlUnquote(strings.TrimSpace(l[len("  m_Name: "):]))
+		}
+	}
+	return ""
+}
+
+// parentKey is the field a nested line belongs to: the closest line above it
+// that is indented less and opens a map
+func parentKey(lines []string, i int) string {
+	indent := len(lines[i]) - len(strings.TrimLeft(lines[i], " "))
+	for j := i - 1; j >= 0; j-- {
+		l := lines[j]
+		if n := len(l) - len(strings.TrimLeft(l, " ")); n < indent && strings.HasSuffix(l, ":") {
+			return strings.TrimSpace(strings.TrimSuffix(strings.TrimLeft(strings.TrimSpace(l), "- "), ":"))
+		}
+	}
+	return "?"
+}
+
+// overrideValue reads the value: line that follows a propertyPath: line
+func overrideValue(lines []string, i int) (string, bool) {
+	for j := i + 1; j < len(lines) && j <= i+2; j++ {
+		t := strings.TrimSpace(lines[j])
+		if strings.HasPrefix(t, "value: ") {
+			return yamlUnquote(t[len("value: "):]), true
+		}
+	}
+	return "", false
+}
+
+// ============================================================
+// Unity YAML
+// ============================================================
+
+// splitDocs splits a Unity YAML file into its objects
+func splitDocs(data []byte) []yamlDoc {
+	locs := docRegex.FindAllSubmatchIndex(data, -1)
+	docs := make([]yamlDoc, 0, len(locs))
+	line := 1
+	prev := 0
+	for i, m := range locs {
+		end := len(data)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		line += bytes.Count(data[prev:m[0]], []byte("\n"))
+		prev = m[0]
+		docs = append(docs, yamlDoc{
+			class: string(data[m[2]:m[3]]),
+			id:    string(data[m[4]:m[5]]),
+			line:  line,
+			body:  string(data[m[1]:end]),
+		})
+	}
+	return docs
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+// mdEscape keeps "|" from splitting a Markdown table cell
+func mdEscape(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// writeReport writes the findings as CSV for a .csv path, else as Markdown
+func writeReport(path string, s *scan) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		buf.WriteString("\xEF\xBB\xBF")
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"kind", "file", "line", "finding"})
+		for _, f := range s.findings {
+			w.Write([]string{f.kind, f.file, strconv.Itoa(f.line), f.message()})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		return os.WriteFile(path, buf.Bytes(), 0644)
+	}
+
+	fmt.Fprintf(&buf, "# Tag and Layer Usage\n\n%s, %s: %d usage(s) of %d tag(s), %d layer(s) and %d sorting layer(s).\n",
+		filepath.Base(s.basePath), strings.Join(s.roots, ", "), s.usages, len(s.tm.tags), len(s.tm.named()), len(s.tm.sorting))
+	if len(s.findings) == 0 {
+		buf.WriteString("\nNo findings.\n")
+	}
+	for _, sec := range findingSections {
+		var rows []finding
+		for _, f := range s.findings {
+			if f.kind == sec.kind {
+				rows = append(rows, f)
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "\n## %s (%d)\n\n| File | Line | Finding |\n| --- | --- | --- |\n", sec.title, len(rows))
+		for _, f := range rows {
+			fmt.Fprintf(&buf, "| `%s` | %d | %s |\n", f.file, f.line, mdEscape(f.message()))
+		}
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// named lists the layers that have a name
+func (tm *tagManager) named() []int {
+	var list []int
+	for i, l := range tm.layers {
+		if l != nil {
+			list = append(list, i)
+		}
+	}
+	return list
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode      bool
+		strict      bool
+		outFlag     string
+		excludeFlag string
+	)
+
+	flag.StringVar(&outFlag, "out", "", "Also write the findings to this file: Markdown, or CSV for a .csv path")
+	flag.StringVar(&excludeFlag, "exclude", "", "Comma-separated globs of files left out")
+	flag.BoolVar(&strict, "strict", false, "Unused tags, layers and sorting layers fail the check too")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the folders ("Assets/Game -ci")
+	flag.Parse()
+	var roots []string
+	for flag.NArg() > 0 {
+		roots = append(roots, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_tag_usage")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	excludes, err := compileGlobs(excludeFlag)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if len(roots) == 0 {
+		roots = []string{"Assets"}
+		if info, err := os.Stat(filepath.Join(basePath, "Packages")); err == nil && info.IsDir() {
+			roots = append(roots, "Packages")
+		}
+	}
+	for i, r := range roots {
+		abs := r
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(basePath, filepath.FromSlash(r))
+		}
+		if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+			fail(2, "folder %s does not exist", r)
+		}
+		roots[i] = relPath(basePath, abs)
+	}
+
+	tm, err := readTagManager(basePath)
+	if err != nil {
+		fail(2, "cannot read ProjectSettings/%s: %v", tagManagerFile, err)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  TAG AND LAYER USAGE"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:  %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Scanning: %s\n"), strings.Join(roots, ", "))
+	fmt.Printf(tr("  Defined:  %d custom tag(s), %d named layer(s), %d sorting layer(s)\n"), len(tm.tags), len(tm.named()), len(tm.sorting))
+
+	s := &scan{
+		basePath: basePath,
+		roots:    roots,
+		tm:       tm,
+		literals: make(map[string]bool),
+		seen:     make(map[string]bool),
+	}
+	scripts, assets := 0, 0
+	for _, rel := range projectFiles(basePath, excludes) {
+		data, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(rel)))
+		if err != nil {
+			fmt.Printf(tr("[WARNING] %s: %v\n"), rel, err)
+			continue
+		}
+		// Files outside the folders still count as usages, so nothing shows as unused
+		// only because the folder that uses it was not checked
+		report := s.inRoots(rel)
+		if filepath.Ext(rel) == ".cs" {
+			scripts++
+			s.scanCode(rel, data, report)
+		} else if bytes.HasPrefix(data, []byte("%YAML")) {
+			assets++
+			s.scanAsset(rel, data, report)
+		}
+	}
+	fmt.Printf(tr("  Read:     %d script(s), %d scene(s), prefab(s) and asset(s); %d usage(s)\n"), scripts, assets, s.usages)
+
+	manager := "ProjectSettings/" + tagManagerFile
+	for _, t := range tm.tags {
+		if !t.used && !s.literals[t.name] {
+			s.add(findUnusedTag, manager, t.line, "tag \"%s\" is used by no GameObject or script", t.name)
+		}
+	}
+	for i, l := range tm.layers {
+		if l != nil && !l.used && !builtinLayers[i] && !s.literals[l.name] {
+			s.add(findUnusedLayer, manager, l.line, "layer %d \"%s\" is used by no GameObject, layer mask or script", i, l.name)
+		}
+	}
+	for _, l := range tm.sorting {
+		if !l.used && l.id != "0" && !s.literals[l.name] {
+			s.add(findUnusedSorting, manager, l.line, "sorting layer \"%s\" is used by no renderer or script", l.name)
+		}
+	}
+
+	order := make(map[string]int)
+	for i, sec := range findingSections {
+		order[sec.kind] = i
+	}
+	sort.SliceStable(s.findings, func(i, j int) bool {
+		fi, fj := s.findings[i], s.findings[j]
+		if fi.kind != fj.kind {
+			return order[fi.kind] < order[fj.kind]
+		}
+		if fi.file != fj.file {
+			return fi.file < fj.file
+		}
+		return fi.line < fj.line
+	})
+
+	errors, warnings := 0, 0
+	for _, sec := range findingSections {
+		count := 0
+		for _, f := range s.findings {
+			if f.kind != sec.kind {
+				continue
+			}
+			if count == 0 {
+				fmt.Printf("\n%s:\n", tr(sec.title))
+			}
+			count++
+			where := fmt.Sprintf("%s:%d", f.file, f.line)
+			fmt.Printf("%s %s: %s\n", sec.tag, where, fmt.Sprintf(tr(f.format), f.args...))
+			unused := strings.HasPrefix(f.kind, "unused-")
+			status := "warning"
+			if !unused || strict {
+				status = "failed"
+				recordError("%s: %s", where, f.message())
+			}
+			recordAction(f.kind, where, status, f.message(), 0)
+			if unused {
+				warnings++
+			} else {
+				errors++
+			}
+		}
+	}
+
+	fmt.Println()
+	switch {
+	case errors > 0:
+		fmt.Printf(tr("[ERROR] %d undefined tag, layer or sorting layer usage(s), %d unused.\n"), errors, warnings)
+	case warnings > 0 && strict:
+		fmt.Printf(tr("[ERROR] %d unused tag(s), layer(s) or sorting layer(s) (-strict).\n"), warnings)
+	case warnings > 0:
+		fmt.Printf(tr("[WARNING] %d unused tag(s), layer(s) or sorting layer(s); every usage is defined.\n"), warnings)
+	default:
+		fmt.Println(tr("[OK] Every tag, layer and sorting layer used is defined, and every one defined is used."))
+	}
+
+	if outFlag != "" {
+		out := outFlag
+		if !filepath.IsAbs(out) {
+			out = filepath.Join(basePath, filepath.FromSlash(out))
+		}
+		if err := writeReport(out, s); err != nil {
+			fail(1, "cannot write %s: %v", outFlag, err)
+		}
+		recordArtifact(out)
+		fmt.Printf(tr("[OK] Wrote %s\n"), relPath(basePath, out))
+	}
+	if errors > 0 {
+		fmt.Println(tr("[TIP] Add the missing names in Project Settings > Tags and Layers, or fix the spelling; tags and layers are case-sensitive."))
+	}
+	if warnings > 0 {
+		fmt.Println(tr("[TIP] Names built at runtime (string concatenation, data files) are not seen; check before removing an unused entry."))
+	}
+	if errors > 0 || (strict && warnings > 0) {
+		exit(1)
+	}
+	exit(0)
+}
+
+// ============================================================
+// Path Filters
+// ============================================================
+
+// globToRegexp converts a glob (*, ?, **) on slash-separated project paths. A
+// pattern without a slash matches the file name in any folder.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compileGlobs parses a comma-separated glob list
+func compileGlobs(list string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := globToRegexp(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, rel string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+// relPath shows a path relative to the project root, with forward slashes
+func relPath(basePath, p string) string {
+	if rel, err := filepath.Rel(basePath, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return p
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...":                                                 "\n按回车键继续...",
+	"[ERROR] %v\n":                                                                 "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                                   "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.":             "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                       "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  TAG AND LAYER USAGE":                                                        "  标签与层使用检查",
+	"  Project:  %s\n":                                                             "  项目:     %s\n",
+	"  Scanning: %s\n":                                                             "  扫描:     %s\n",
+	"  Defined:  %d custom tag(s), %d named layer(s), %d sorting layer(s)\n":       "  已定义:   %d 个自定义标签，%d 个命名层，%d 个排序层\n",
+	"  Read:     %d script(s), %d scene(s), prefab(s) and asset(s); %d usage(s)\n": "  已读取:   %d 个脚本，%d 个场景、预制体和资源；%d 处使用\n",
+	"[WARNING] %s: %v\n":                                                           "[WARNING] %s: %v\n",
+	"Undefined tags":                                                               "未定义的标签",
+	"Undefined layers":                                                             "未定义的层",
+	"Undefined sorting layers":                                                     "未定义的排序层",
+	"Unused tags":                                                                  "未使用的标签",
+	"Unused layers":                                                                "未使用的层",
+	"Unused sorting layers":                                                        "未使用的排序层",
+	"tag \"%s\" is not defined (%s)":                                               "标签 \"%s\" 未定义 (%s)",
+	"layer \"%s\" is not defined (%s)":                                             "层 \"%s\" 未定义 (%s)",
+	"layer %d has no name (%s)":                                                    "层 %d 没有名称 (%s)",
+	"layer mask %s includes layer(s) %s, which have no name":                       "层遮罩 %s 包含没有名称的层 %s",
+	"sorting layer \"%s\" is not defined (%s)":                                     "排序层 \"%s\" 未定义 (%s)",
+	"sorting layer ID %s does not exist (%s)":                                      "排序层 ID %s 不存在 (%s)",
+	"tag \"%s\" is used by no GameObject or script":                                "标签 \"%s\" 未被任何 GameObject 或脚本使用",
+	"layer %d \"%s\" is used by no GameObject, layer mask or script":               "层 %d \"%s\" 未被任何 GameObject、层遮罩或脚本使用",
+	"sorting layer \"%s\" is used by no renderer or script":                        "排序层 \"%s\" 未被任何渲染器或脚本使用",
+	"[ERROR] %d undefined tag, layer or sorting layer usage(s), %d unused.\n":      "[ERROR] %d 处使用了未定义的标签、层或排序层，%d 个未使用。\n",
+	"[ERROR] %d unused tag(s), layer(s) or sorting layer(s) (-strict).\n":          "[ERROR] %d 个未使用的标签、层或排序层 (-strict)。\n",
+	"[WARNING] %d unused tag(s), layer(s) or sorting layer(s); every usage is defined.\n":     "[WARNING] %d 个未使用的标签、层或排序层；所有使用都已定义。\n",
+	"[OK] Every tag, layer and sorting layer used is defined, and every one defined is used.": "[OK] 所有使用的标签、层和排序层都已定义，所有已定义的都在使用。",
+	"[OK] Wrote %s\n": "[OK] 已写入 %s\n",
+	"[TIP] Add the missing names in Project Settings > Tags and Layers, or fix the spelling; tags and layers are case-sensitive.": "[TIP] 在 Project Settings > Tags and Layers 中添加缺失的名称，或修正拼写；标签和层区分大小写。",
+	"[TIP] Names built at runtime (string concatenation, data files) are not seen; check before removing an unused entry.":        "[TIP] 运行时拼接的名称（字符串拼接、数据文件）无法识别，删除未使用的条目前请确认。",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
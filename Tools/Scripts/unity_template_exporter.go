@@ -0,0 +1,1205 @@
+// Unity Template Exporter — Turn a project back into a reusable starter template.
+// The inverse of rename_project: the company and product names in copyright
+// notices become #COMPANY#, #PRODUCT#, #YEAR# and #AUTHOR# placeholders, the
+// player settings and BuildScript constants get neutral template names, and a
+// .rename_project.json tells rename_project what to replace when the template is
+// used. Caches, build output, UserSettings and the rename backups are left out,
+// as in unity_project_archive. The result is a folder, or a zip in the archive
+// format that "unity_project_archive verify/extract" can check and unpack.
+//
+// Build: go build unity_template_exporter.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_template_exporter ../StudioStarter.zip
+//	unity_template_exporter ../StudioStarter                     # write a folder instead of a zip
+//	unity_template_exporter -company Studio -product Starter ../StudioStarter.zip
+//	unity_template_exporter -exclude "Assets/Game/Levels/**" ../StudioStarter.zip
+//	unity_template_exporter -dry-run ../StudioStarter.zip        # list what would be replaced
+
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Top-level directories left out of the template: what unity_project_full_clean
+// deletes, per-user editor state and generated captures. Keep in sync with
+// excludedDirectories in unity_project_archive.go.
+var excludedDirectories = []string{
+	".git",
+	".vs",
+	".idea",
+	".vscode",
+	".gradle",
+	".utmp",
+	"obj",
+	"Logs",
+	"Temp",
+	"Library",
+	"SceneBackups",
+	"MemoryCaptures",
+	"Recordings",
+	"Build",
+	"Builds",
+	"ExportedObj",
+	"HybridCLRData",
+	"Bundles",
+	"yoo",
+	"HotUpdateAssetsPreUpload",
+	"UserSettings",
+	// Backups of earlier renames of this project
+	".rename_backup",
+	// Restore points of unity_snapshot
+	".unitystarter_snapshots",
+}
+
+// Top-level file extensions left out, as fileExtensionsToDelete in the cleaner;
+// .log covers the logs the tools in this folder write next to the project
+var excludedFileExtensions = []string{
+	".csproj",
+	".sln",
+	".slnx",
+	".user",
+	".vsconfig",
+	".log",
+}
+
+// Top-level files left out: the lock of a running tool and the rename state,
+// which is written again with the template names
+var excludedFiles = []string{
+	".unitystarter.lock",
+	renameStateName,
+}
+
+const (
+	renameStateName  = ".rename_project.json"
+	sharedConfigName = ".unitystarter.json"
+	sharedConfigEnv  = "UNITYSTARTER_CONFIG"
+
+	// Unity's own default for new projects
+	defaultTemplateCompany = "DefaultCompany"
+)
+
+// Placeholders rename_project fills in (see templateVars in rename_project.go)
+const (
+	placeholderAuthor  = "#AUTHOR#"
+	placeholderYear    = "#YEAR#"
+	placeholderCompany = "#COMPANY#"
+	placeholderProduct = "#PRODUCT#"
+)
+
+// Text files whose copyright notices get placeholders; the same list
+// rename_project fills in
+var templateFileExts = map[string]bool{
+	".cs":     true,
+	".md":     true,
+	".txt":    true,
+	".shader": true,
+	".hlsl":   true,
+	".cginc":  true,
+	".uss":    true,
+	".uxml":   true,
+}
+
+// Vendored code keeps its own headers and license
+var templateSkipDirs = map[string]bool{
+	"ThirdParty": true,
+	"Plugins":    true,
+}
+
+// Folders that hold scenes directly under Assets/ but are never the project folder
+var genericSceneDirs = map[string]bool{
+	"Scenes": true,
+	"Levels": true,
+	"Maps":   true,
+}
+
+// Already-compressed formats are stored instead of deflated
+var storedExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".ktx2": true,
+	".mp3": true, ".ogg": true, ".m4a": true, ".aac": true,
+	".mp4": true, ".webm": true, ".mov": true,
+	".zip": true, ".gz": true, ".tgz": true, ".7z": true, ".rar": true, ".unitypackage": true,
+	".bundle": true, ".br": true,
+}
+
+// The zip carries the manifest of unity_project_archive, so its verify and
+// extract commands work on templates too
+const (
+	archiveManifestName  = "unitystarter-archive.json"
+	archiveFormatVersion = 1
+)
+
+var (
+	namePattern          = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+	projectVersionRegex  = regexp.MustCompile(`(?m)^m_EditorVersion:\s*(\S+)`)
+	companyNameRegex     = regexp.MustCompile(`(?m)^  companyName: (.*?)\r?$`)
+	productNameRegex     = regexp.MustCompile(`(?m)^  productName: (.*?)\r?$`)
+	buildSettingsScene   = regexp.MustCompile(`path: Assets/([^/\r\n]+)/`)
+	copyrightLineRegex   = regexp.MustCompile(`(?i)copyright|\(c\)|©|\bauthor\b`)
+	copyrightYearPattern = regexp.MustCompile(`\b(?:19|20)\d{2}(?:\s*[-–]\s*(?:19|20)\d{2})?\b`)
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// renameState mirrors RenameState in rename_project.go; the template ships one
+// so rename_project detects the template names without guessing
+type renameState struct {
+	ProjectFolder string `json:"projectFolder"`
+	CompanyName   string `json:"companyName"`
+	AppName       string `json:"appName"`
+	RenamedAt     string `json:"renamedAt"`
+
+	// Passed through unchanged; rename_project reads it
+	DisplayNameAssets json.RawMessage `json:"displayNameAssets,omitempty"`
+}
+
+// identity is the set of names replaced in the project
+type identity struct {
+	folder  string
+	company string
+	product string
+	author  string // from .unitystarter.json; empty when not configured
+}
+
+// archiveManifest matches the manifest in unity_project_archive.go
+type archiveManifest struct {
+	Format       int            `json:"format"`
+	Project      string         `json:"project"`
+	UnityVersion string         `json:"unityVersion"`
+	CreatedAt    string         `json:"createdAt"`
+	Host         string         `json:"host,omitempty"`
+	IncludesGit  bool           `json:"includesGit"`
+	TotalBytes   int64          `json:"totalBytes"`
+	Files        []archivedFile `json:"files"`
+}
+
+type archivedFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// sourceFile is a file picked for the template; data is set for files whose
+// content is rewritten
+type sourceFile struct {
+	abs     string
+	rel     string // slash-separated, relative to the project root
+	info    os.FileInfo
+	data    []byte
+	details []string
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks for Assets/ and ProjectSettings/ in the given directory
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// readUnityVersion returns the editor version from ProjectSettings/ProjectVersion.txt
+func readUnityVersion(basePath string) string {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectVersion.txt"))
+	if err != nil {
+		return ""
+	}
+	if m := projectVersionRegex.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// ============================================================
+// Project Identity
+// ============================================================
+
+// readIdentity finds the names to replace. The project folder comes from
+// -assets-folder, the rename state, a folder named like the product, then the
+// first Build Settings scene folder; company and product from the player settings.
+func readIdentity(basePath, folderFlag string) (*identity, error) {
+	settingsPath := filepath.Join(basePath, "ProjectSettings", "ProjectSettings.asset")
+	data, err := os.ReadFile(settingsPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read ProjectSettings/ProjectSettings.asset: %v", err)
+	}
+	id := &identity{}
+	if m := companyNameRegex.FindSubmatch(data); m != nil {
+		id.company = strings.TrimSpace(string(m[1]))
+	}
+	if m := productNameRegex.FindSubmatch(data); m != nil {
+		id.product = strings.TrimSpace(string(m[1]))
+	}
+	if id.company == "" || id.product == "" {
+		return nil, fmt.Errorf("companyName or productName not found in ProjectSettings/ProjectSettings.asset")
+	}
+	id.author = sharedAuthor(basePath)
+
+	isFolder := func(name string) bool {
+		info, err := os.Stat(filepath.Join(basePath, "Assets", name))
+		return name != "" && err == nil && info.IsDir()
+	}
+	if folderFlag != "" {
+		if !isFolder(folderFlag) {
+			return nil, fmt.Errorf("Assets/%s does not exist", folderFlag)
+		}
+		id.folder = folderFlag
+		return id, nil
+	}
+	if raw, err := os.ReadFile(filepath.Join(basePath, renameStateName)); err == nil {
+		var state renameState
+		if json.Unmarshal(bytes.TrimPrefix(raw, []byte("\xEF\xBB\xBF")), &state) == nil && isFolder(state.ProjectFolder) {
+			id.folder = state.ProjectFolder
+			return id, nil
+		}
+	}
+	if isFolder(id.product) {
+		id.folder = id.product
+		return id, nil
+	}
+	if raw, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "EditorBuildSettings.asset")); err == nil {
+		for _, m := range buildSettingsScene.FindAllSubmatch(raw, -1) {
+			if name := string(m[1]); !genericSceneDirs[name] && !templateSkipDirs[name] && isFolder(name) {
+				id.folder = name
+				return id, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("could not detect the project folder under Assets/ (use -assets-folder)")
+}
+
+// sharedAuthor reads the author from UNITYSTARTER_CONFIG or the nearest
+// .unitystarter.json above the project, like rename_project
+func sharedAuthor(basePath string) string {
+	path := os.Getenv(sharedConfigEnv)
+	if path == "" {
+		for dir := basePath; ; dir = filepath.Dir(dir) {
+			if _, err := os.Stat(filepath.Join(dir, sharedConfigName)); err == nil {
+				path = filepath.Join(dir, sharedConfigName)
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				return ""
+			}
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var cfg struct {
+		Author string `json:"author"`
+	}
+	json.Unmarshal(bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF")), &cfg)
+	return strings.TrimSpace(cfg.Author)
+}
+
+// ============================================================
+// File Selection
+// ============================================================
+
+// globToRegexp converts a glob to a regexp: ** spans folders, * and ? stay within
+// one path segment. Patterns without a slash match the file name alone.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compileGlobs parses a comma-separated glob list
+func compileGlobs(list string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := globToRegexp(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, rel string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExcludedTopLevel applies the lists above; like the cleaner's, they only
+// apply directly under the project root
+func isExcludedTopLevel(name string, isDir bool) bool {
+	if isDir {
+		return containsFold(excludedDirectories, name)
+	}
+	return containsFold(excludedFiles, name) || containsFold(excludedFileExtensions, filepath.Ext(name))
+}
+
+// collectFiles walks the project and returns the template files in path order.
+// skip is the output zip or folder when it is written inside the project.
+func collectFiles(basePath string, excludes []*regexp.Regexp, skip string) ([]*sourceFile, []string, error) {
+	var files []*sourceFile
+	var skipped []string
+	err := filepath.Walk(basePath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", p, err))
+			return nil
+		}
+		if p == basePath {
+			return nil
+		}
+		if p == skip {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(basePath, p)
+		rel = filepath.ToSlash(rel)
+		if (!strings.Contains(rel, "/") && isExcludedTopLevel(info.Name(), info.IsDir())) || matchesAny(excludes, rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			skipped = append(skipped, rel+" (not a regular file)")
+			return nil
+		}
+		files = append(files, &sourceFile{abs: p, rel: rel, info: info})
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].rel < files[j].rel })
+	return files, skipped, err
+}
+
+// ============================================================
+// Placeholders
+// ============================================================
+
+// templater replaces the project's names with the template's
+type templater struct {
+	from          *identity
+	company       string // template names for settings that must stay valid identifiers
+	product       string
+	companyInText *regexp.Regexp
+	productInText *regexp.Regexp
+	authorInText  *regexp.Regexp
+}
+
+func newTemplater(from *identity, company, product string) *templater {
+	word := func(s string) *regexp.Regexp {
+		if s == "" {
+			return nil
+		}
+		return regexp.MustCompile(`\b` + regexp.QuoteMeta(s) + `\b`)
+	}
+	return &templater{
+		from:          from,
+		company:       company,
+		product:       product,
+		companyInText: word(from.company),
+		productInText: word(from.product),
+		authorInText:  word(from.author),
+	}
+}
+
+// isNoticeFile reports whether rename_project fills placeholders in the file:
+// text files under Assets/ and Packages/ outside vendored folders, and the
+// license files at the project root
+func isNoticeFile(rel string) bool {
+	parts := strings.Split(rel, "/")
+	if len(parts) == 1 {
+		return isLicenseFile(rel)
+	}
+	if parts[0] != "Assets" && parts[0] != "Packages" {
+		return false
+	}
+	for _, dir := range parts[1 : len(parts)-1] {
+		if templateSkipDirs[dir] {
+			return false
+		}
+	}
+	name := parts[len(parts)-1]
+	return templateFileExts[strings.ToLower(filepath.Ext(name))] || isLicenseFile(name)
+}
+
+// isLicenseFile matches LICENSE, LICENSE.md, COPYING.txt, NOTICE and the like
+func isLicenseFile(name string) bool {
+	base := strings.ToUpper(strings.TrimSuffix(name, filepath.Ext(name)))
+	return base == "LICENSE" || base == "LICENCE" || base == "COPYING" || base == "NOTICE"
+}
+
+// rewriteNotices puts placeholders into copyright and author lines only: elsewhere the
+// company name is as likely to be a namespace (CycloneGames.Logger) as the owner
+func (t *templater) rewriteNotices(text string) (string, int) {
+	lines := strings.Split(text, "\n")
+	count := 0
+	for i, line := range lines {
+		if !copyrightLineRegex.MatchString(line) {
+			continue
+		}
+		orig := line
+		for _, r := range []struct {
+			re          *regexp.Regexp
+			placeholder string
+		}{
+			{t.authorInText, placeholderAuthor},
+			{t.companyInText, placeholderCompany},
+			{t.productInText, placeholderProduct},
+			{copyrightYearPattern, placeholderYear},
+		} {
+			if r.re != nil {
+				line = r.re.ReplaceAllLiteralString(line, r.placeholder)
+			}
+		}
+		if line != orig {
+			lines[i] = line
+			count++
+		}
+	}
+	return strings.Join(lines, "\n"), count
+}
+
+// rewriteSettings gives the player settings the template names: the same
+// fields updateProjectSettings in rename_project.go replaces
+func (t *templater) rewriteSettings(text string) (string, []string) {
+	var details []string
+	replace := func(key, from, to string) {
+		old := key + ": " + from
+		if from != to && strings.Contains(text, old) {
+			text = strings.Replace(text, old, key+": "+to, 1)
+			details = append(details, fmt.Sprintf("%s: %s -> %s", key, from, to))
+		}
+	}
+	replace("companyName", t.from.company, t.company)
+	replace("productName", t.from.product, t.product)
+	replace("metroPackageName", t.from.product, t.product)
+	replace("metroApplicationDescription", t.from.product, t.product)
+	oldID := "com." + t.from.company + "." + t.from.product
+	newID := "com." + t.company + "." + t.product
+	if oldID != newID && strings.Contains(text, oldID) {
+		text = strings.ReplaceAll(text, oldID, newID)
+		details = append(details, fmt.Sprintf("applicationIdentifier: %s -> %s", oldID, newID))
+	}
+	return text, details
+}
+
+// rewriteBuildScript replaces the CompanyName and ApplicationName constants
+// rename_project looks for
+func (t *templater) rewriteBuildScript(text string) (string, []string) {
+	var details []string
+	for _, c := range []struct{ name, from, to string }{
+		{"CompanyName", t.from.company, t.company},
+		{"ApplicationName", t.from.product, t.product},
+	} {
+		if c.from == c.to {
+			continue
+		}
+		re := regexp.MustCompile(`(const\s+string\s+` + c.name + `\s*=\s*")` + regexp.QuoteMeta(c.from) + `(")`)
+		if newText := re.ReplaceAllString(text, "${1}"+c.to+"${2}"); newText != text {
+			text = newText
+			details = append(details, fmt.Sprintf("%s: %s -> %s", c.name, c.from, c.to))
+		}
+	}
+	return text, details
+}
+
+// prepare reads the files that change and keeps their new content; line
+// endings and a BOM survive because only whole lines are touched. Files that
+// are not UTF-8 are left alone.
+func (t *templater) prepare(files []*sourceFile) []error {
+	var errs []error
+	for _, f := range files {
+		isSettings := f.rel == "ProjectSettings/ProjectSettings.asset"
+		isBuildScript := f.rel == "Assets/Build/Editor/BuildPipeline/BuildScript.cs"
+		if !isSettings && !isBuildScript && !isNoticeFile(f.rel) {
+			continue
+		}
+		data, err := os.ReadFile(f.abs)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot read %s: %v", f.rel, err))
+			continue
+		}
+		if !utf8.Valid(data) {
+			continue
+		}
+		text := string(data)
+		var details []string
+		if isSettings {
+			text, details = t.rewriteSettings(text)
+		}
+		if isBuildScript {
+			text, details = t.rewriteBuildScript(text)
+		}
+		if isNoticeFile(f.rel) {
+			var n int
+			if text, n = t.rewriteNotices(text); n > 0 {
+				details = append(details, fmt.Sprintf(tr("%d notice line(s)"), n))
+			}
+		}
+		if len(details) > 0 {
+			f.data = []byte(text)
+			f.details = details
+		}
+	}
+	return errs
+}
+
+// stateFile is the rename state the template ships with; the project's list of
+// display name assets is kept, so a rename of the template still updates them
+func stateFile(basePath, folder, company, product string) *sourceFile {
+	state := &renameState{
+		ProjectFolder: folder,
+		CompanyName:   company,
+		AppName:       product,
+		RenamedAt:     time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if raw, err := os.ReadFile(filepath.Join(basePath, renameStateName)); err == nil {
+		var source renameState
+		if json.Unmarshal(bytes.TrimPrefix(raw, []byte("\xEF\xBB\xBF")), &source) == nil {
+			state.DisplayNameAssets = source.DisplayNameAssets
+		}
+	}
+	data, _ := json.MarshalIndent(state, "", "    ")
+	return &sourceFile{rel: renameStateName, data: data, details: []string{"template names for rename_project"}}
+}
+
+// ============================================================
+// Output
+// ============================================================
+
+// open returns the rewritten content, or the file on disk
+func (f *sourceFile) open() (io.ReadCloser, error) {
+	if f.data != nil {
+		return io.NopCloser(bytes.NewReader(f.data)), nil
+	}
+	return os.Open(f.abs)
+}
+
+// writeZip packs the files into outPath with an archive manifest. The zip is
+// written to a temp file next to it and renamed at the end, so an interrupted
+// run never leaves a truncated template with the final name.
+func writeZip(outPath string, files []*sourceFile, manifest *archiveManifest) error {
+	tmp, err := os.CreateTemp(filepath.Dir(outPath), "."+filepath.Base(outPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	fail := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	zw := zip.NewWriter(tmp)
+	for i, f := range files {
+		header := &zip.FileHeader{Name: f.rel, Method: zip.Deflate, Modified: time.Now()}
+		if f.info != nil {
+			if header, err = zip.FileInfoHeader(f.info); err != nil {
+				return fail(err)
+			}
+			header.Name = f.rel
+			header.Method = zip.Deflate
+		}
+		if storedExtensions[strings.ToLower(filepath.Ext(f.rel))] {
+			header.Method = zip.Store
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return fail(err)
+		}
+		src, err := f.open()
+		if err != nil {
+			return fail(fmt.Errorf("cannot read %s: %v", f.rel, err))
+		}
+		h := sha256.New()
+		n, err := io.Copy(io.MultiWriter(w, h), src)
+		src.Close()
+		if err != nil {
+			return fail(fmt.Errorf("cannot read %s: %v", f.rel, err))
+		}
+		manifest.Files = append(manifest.Files, archivedFile{Path: f.rel, Size: n, SHA256: hex.EncodeToString(h.Sum(nil))})
+		manifest.TotalBytes += n
+		printProgressBar(i+1, len(files))
+	}
+
+	data, _ := json.MarshalIndent(manifest, "", "  ")
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: archiveManifestName, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return fail(err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fail(err)
+	}
+	if err := zw.Close(); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, outPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// writeFolder copies the files into an empty folder, keeping modification
+// times so Unity does not see every asset as changed
+func writeFolder(outDir string, files []*sourceFile) error {
+	for i, f := range files {
+		dst := filepath.Join(outDir, filepath.FromSlash(f.rel))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		src, err := f.open()
+		if err != nil {
+			return fmt.Errorf("cannot read %s: %v", f.rel, err)
+		}
+		mode := os.FileMode(0644)
+		if f.info != nil {
+			mode = f.info.Mode().Perm()
+		}
+		out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+		if err != nil {
+			src.Close()
+			return err
+		}
+		_, err = io.Copy(out, src)
+		src.Close()
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("cannot write %s: %v", f.rel, err)
+		}
+		if f.info != nil && f.data == nil {
+			os.Chtimes(dst, f.info.ModTime(), f.info.ModTime())
+		}
+		printProgressBar(i+1, len(files))
+	}
+	return nil
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmptyDir reports whether dir is missing or has no entries
+func isEmptyDir(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	return err != nil || len(entries) == 0
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+func printProgressBar(current, total int) {
+	percent := float64(current) / float64(total)
+	if plainMode || jsonMode {
+		// One line per 10% step instead of redrawing the bar with \r
+		step := int(percent * 10)
+		if current == total || step > int(float64(current-1)/float64(total)*10) {
+			fmt.Printf(tr("Progress: %d/%d (%.0f%%)\n"), current, total, percent*100)
+		}
+		return
+	}
+	barLength := 40
+	filledLength := int(float64(barLength) * percent)
+
+	bar := strings.Repeat("█", filledLength) + strings.Repeat("-", barLength-filledLength)
+	fmt.Printf("\r[%s] %.0f%% (%d/%d)", bar, percent*100, current, total)
+	if current == total {
+		fmt.Println()
+	}
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...": "\n按回车键继续...",
+	"Progress: %d/%d (%.0f%%)\n":   "进度: %d/%d (%.0f%%)\n",
+
+	"Usage: unity_template_exporter [flags] <out.zip|folder>": "用法: unity_template_exporter [参数] <输出.zip|文件夹>",
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                                                    "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.":                              "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                                        "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"[WARNING] The project seems to be open in Unity; save and close it for a consistent template.": "[WARNING] 项目似乎正在 Unity 中打开；请保存并关闭，以得到一致的模板。",
+	"[WARNING] Skipped: %s\n":                                                                       "[WARNING] 已跳过: %s\n",
+	"[WARNING] %v\n":                                                                                "[WARNING] %v\n",
+	"%d notice line(s)":                                                                             "%d 行版权或作者声明",
+	"  TEMPLATE EXPORT":                                                                             "  导出模板",
+	"  Project:  %s (Assets/%s)\n":                                                                  "  项目:     %s (Assets/%s)\n",
+	"  Unity:    %s\n":                                                                              "  Unity:    %s\n",
+	"  Output:   %s\n":                                                                              "  输出:     %s\n",
+	"  Company:  %s -> %s\n":                                                                        "  公司:     %s -> %s\n",
+	"  Product:  %s -> %s\n":                                                                        "  产品:     %s -> %s\n",
+	"  Author:   %s -> %s\n":                                                                        "  作者:     %s -> %s\n",
+	"\n  %d file(s), %s\n":                                                                          "\n  %d 个文件，%s\n",
+	"\nREPLACED IN THE TEMPLATE":                                                                    "\n模板中替换的内容",
+	"\n[Dry Run] No template was written.":                                                          "\n[Dry Run] 未写入模板。",
+	"\n%s exists. Overwrite? (y/N): ":                                                               "\n%s 已存在。是否覆盖？(y/N): ",
+	"Operation cancelled.":                                                                          "操作已取消。",
+	"\n[OK] Exported %d file(s) to %s (%.1fs)\n":                                                    "\n[OK] 已导出 %d 个文件到 %s (%.1fs)\n",
+	"[TIP] Run rename_project in a copy of the template to start a new project from it.": "[TIP] 在模板的副本中运行 rename_project，即可基于它创建新项目。",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode, dryRun bool
+	var excludeFlag, folderFlag, companyFlag, productFlag string
+
+	flag.StringVar(&companyFlag, "company", defaultTemplateCompany, "Company name the template ships with (player settings, BuildScript)")
+	flag.StringVar(&productFlag, "product", "", "Product name the template ships with (default: the project folder name)")
+	flag.StringVar(&folderFlag, "assets-folder", "", "Main project folder under Assets/ (default: rename state, then auto-detection)")
+	flag.StringVar(&excludeFlag, "exclude", "", "Comma-separated globs to leave out as well (e.g. \"Assets/Game/Levels/**\")")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no confirmation prompts)")
+	flag.BoolVar(&dryRun, "dry-run", false, "List the files and replacements without writing anything")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the output path ("../StudioStarter.zip -dry-run")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_template_exporter")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	if len(args) != 1 {
+		fmt.Println(tr("Usage: unity_template_exporter [flags] <out.zip|folder>"))
+		recordError("expected an output path")
+		exit(2)
+	}
+	outPath, err := filepath.Abs(args[0])
+	if err != nil {
+		fail(2, "%v", err)
+	}
+	asZip := strings.EqualFold(filepath.Ext(outPath), ".zip")
+	excludes, err := compileGlobs(excludeFlag)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if outPath == basePath || strings.HasPrefix(basePath, outPath+string(os.PathSeparator)) {
+		fail(2, "the output cannot be the project folder or contain it")
+	}
+	if !asZip && !isEmptyDir(outPath) {
+		fail(1, "%s is not empty; choose a new folder", outPath)
+	}
+
+	from, err := readIdentity(basePath, folderFlag)
+	if err != nil {
+		fail(1, "%v", err)
+	}
+	if productFlag == "" {
+		productFlag = from.folder
+	}
+	for _, v := range []struct{ flag, value string }{{"-company", companyFlag}, {"-product", productFlag}} {
+		if !namePattern.MatchString(v.value) {
+			fail(2, "%s '%s' may only contain letters, numbers, '_' and '-', and cannot start with a number or '-'", v.flag, v.value)
+		}
+	}
+	// Unity holds this file while the project is open; assets may be half-saved
+	if _, err := os.Stat(filepath.Join(basePath, "Temp", "UnityLockfile")); err == nil {
+		fmt.Println(tr("[WARNING] The project seems to be open in Unity; save and close it for a consistent template."))
+	}
+
+	files, skipped, err := collectFiles(basePath, excludes, outPath)
+	if err != nil {
+		fail(1, "cannot scan the project: %v", err)
+	}
+	for _, s := range skipped {
+		fmt.Printf(tr("[WARNING] Skipped: %s\n"), s)
+	}
+	t := newTemplater(from, companyFlag, productFlag)
+	for _, err := range t.prepare(files) {
+		fmt.Printf(tr("[WARNING] %v\n"), err)
+		recordError("%v", err)
+	}
+	files = append(files, stateFile(basePath, from.folder, companyFlag, productFlag))
+	sort.Slice(files, func(i, j int) bool { return files[i].rel < files[j].rel })
+
+	var total int64
+	var rewritten []*sourceFile
+	for _, f := range files {
+		if f.info != nil {
+			total += f.info.Size()
+		}
+		if f.details != nil {
+			rewritten = append(rewritten, f)
+		}
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  TEMPLATE EXPORT"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:  %s (Assets/%s)\n"), filepath.Base(basePath), from.folder)
+	fmt.Printf(tr("  Unity:    %s\n"), readUnityVersion(basePath))
+	fmt.Printf(tr("  Output:   %s\n"), outPath)
+	fmt.Printf(tr("  Company:  %s -> %s\n"), from.company, companyFlag)
+	fmt.Printf(tr("  Product:  %s -> %s\n"), from.product, productFlag)
+	if from.author != "" {
+		fmt.Printf(tr("  Author:   %s -> %s\n"), from.author, placeholderAuthor)
+	}
+	fmt.Printf(tr("\n  %d file(s), %s\n"), len(files), formatSize(total))
+
+	fmt.Println(tr("\nREPLACED IN THE TEMPLATE"))
+	for _, f := range rewritten {
+		fmt.Printf("  %s\n", f.rel)
+		for _, d := range f.details {
+			fmt.Printf("      %s\n", d)
+		}
+	}
+
+	if dryRun {
+		for _, f := range rewritten {
+			recordAction("template", f.rel, "planned", strings.Join(f.details, "; "), 0)
+		}
+		fmt.Println(tr("\n[Dry Run] No template was written."))
+		exit(0)
+	}
+	if _, err := os.Stat(outPath); err == nil && asZip && !ciMode {
+		fmt.Printf(tr("\n%s exists. Overwrite? (y/N): "), filepath.Base(outPath))
+		confirm, _ := stdinReader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(confirm)) != "y" {
+			fmt.Println(tr("Operation cancelled."))
+			exit(0)
+		}
+	}
+	parent := outPath
+	if asZip {
+		parent = filepath.Dir(outPath)
+	}
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		fail(1, "cannot create %s: %v", parent, err)
+	}
+
+	fmt.Println()
+	start := time.Now()
+	if asZip {
+		host, _ := os.Hostname()
+		manifest := &archiveManifest{
+			Format:       archiveFormatVersion,
+			Project:      productFlag,
+			UnityVersion: readUnityVersion(basePath),
+			CreatedAt:    time.Now().Format(time.RFC3339),
+			Host:         host,
+			Files:        []archivedFile{},
+		}
+		err = writeZip(outPath, files, manifest)
+	} else {
+		err = writeFolder(outPath, files)
+	}
+	if err != nil {
+		fail(1, "cannot write the template: %v", err)
+	}
+	for _, f := range rewritten {
+		recordAction("template", f.rel, "ok", strings.Join(f.details, "; "), 0)
+	}
+	fmt.Printf(tr("\n[OK] Exported %d file(s) to %s (%.1fs)\n"), len(files), outPath, time.Since(start).Seconds())
+	recordAction("export", outPath, "ok", fmt.Sprintf("%d files", len(files)), time.Since(start))
+	recordArtifact(outPath)
+	fmt.Println(tr("[TIP] Run rename_project in a copy of the template to start a new project from it."))
+	exit(0)
+}
@@ -0,0 +1,1653 @@
+// Unity Test Runner — Run the Unity Test Framework suites in batch mode and write JUnit results.
+// Starts the installed editor once per test platform (EditMode, PlayMode) with
+// -runTests, reads the NUnit XML Unity writes and converts it into one JUnit
+// report that Jenkins, GitLab, TeamCity and GitHub test reporters read. A project
+// that had com.unity.test-framework removed (remove_unity_packages "Testing") gets
+// it back in Packages/manifest.json for the run; the manifest and packages-lock.json
+// are restored afterwards, and by the next run if this one was killed. Exits with 1
+// when a test failed and with 3 when Unity wrote no results (compile errors, a
+// crash or a timeout), so CI can tell a red test from a broken project.
+//
+// Build: go build unity_test_runner.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_test_runner                                  # EditMode, then PlayMode tests
+//	unity_test_runner -platforms editmode              # only the EditMode suites
+//	unity_test_runner -filter "Game.Tests.Player.*"    # tests matching a name filter
+//	unity_test_runner -category Smoke -ci -annotate github
+//	unity_test_runner -dry-run                         # show the Unity command lines
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	defaultOutDir = "Build/TestResults" // NUnit results, logs and junit.xml
+	junitFileName = "junit.xml"
+
+	// Original manifest and lock file while the test framework is added; a run
+	// that finds it left behind restores them first
+	packagesBackupRel = "Library/UnityStarter/TestRunnerPackages.json"
+
+	// Overrides the editor picked from ProjectVersion.txt and the Unity Hub folders
+	envUnityPath = "UNITYSTARTER_UNITY"
+)
+
+// Needed for -runTests; the version added when the manifest has none. Unity 6
+// ships the framework as a core package, and the editor pins its own version.
+const (
+	testFrameworkPackage = "com.unity.test-framework"
+	testFrameworkLegacy  = "1.1.33" // 2019.4 - 2022.3
+	testFrameworkUnity6  = "1.4.5"
+)
+
+// Test platforms, as passed to -testPlatform
+var testPlatforms = []string{"EditMode", "PlayMode"}
+
+// Unity -runTests exit codes (Unity Test Framework RunnerReturnCodes)
+const (
+	unityTestsPassed = 0
+	unityTestsFailed = 2
+)
+
+// Messages of a failed test listed per test in the report before they are cut
+const maxMessageLines = 3
+
+var (
+	projectVersionRegex = regexp.MustCompile(`(?m)^m_EditorVersion:\s*(\S+)`)
+
+	// The opening of the manifest's "dependencies" object and the indent of its first line
+	dependenciesRegex = regexp.MustCompile(`"dependencies"\s*:\s*\{(\r?\n([ \t]*))?`)
+
+	// "at Game.Tests.PlayerTests.Jumps () [0x00001] in /Project/Assets/Tests/PlayerTests.cs:12"
+	stackLocationRegex = regexp.MustCompile(`\sin (.+?):(\d+)\s*$`)
+
+	// compilerMessageRegex matches "Assets/Foo.cs(12,5): error CS0103: ..." lines
+	compilerMessageRegex = regexp.MustCompile(`^\s*(.+?)\((\d+),(\d+)\):\s*(error)\s+(\w+):\s*(.*?)\s*$`)
+
+	// Log lines worth showing when Unity wrote no results
+	logErrorRegex = regexp.MustCompile(`(?i)(error CS\d+|\[Error\]|Exception:|error:|Aborting batchmode)`)
+)
+
+// Compiler errors annotated per platform run with -annotate
+const maxCompilerAnnotations = 50
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// EditorInstance represents the structure of Library/EditorInstance.json
+type EditorInstance struct {
+	ProcessID int `json:"process_id"`
+}
+
+// nunitRun is the root of the NUnit 3 XML Unity writes with -testResults
+type nunitRun struct {
+	Total    int          `xml:"total,attr"`
+	Passed   int          `xml:"passed,attr"`
+	Failed   int          `xml:"failed,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Duration float64      `xml:"duration,attr"`
+	Start    string       `xml:"start-time,attr"`
+	Suites   []nunitSuite `xml:"test-suite"`
+}
+
+// nunitSuite is a test assembly, namespace, fixture or parameterized method
+type nunitSuite struct {
+	Type     string        `xml:"type,attr"` // Assembly, TestSuite, TestFixture, ParameterizedMethod...
+	Name     string        `xml:"name,attr"`
+	FullName string        `xml:"fullname,attr"`
+	Result   string        `xml:"result,attr"`
+	Site     string        `xml:"site,attr"` // where a failure happened: Test, SetUp, TearDown, Parent, Child
+	Duration float64       `xml:"duration,attr"`
+	Failure  *nunitFailure `xml:"failure"`
+	Suites   []nunitSuite  `xml:"test-suite"`
+	Cases    []nunitCase   `xml:"test-case"`
+}
+
+type nunitCase struct {
+	Name      string        `xml:"name,attr"`
+	FullName  string        `xml:"fullname,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Result    string        `xml:"result,attr"` // Passed, Failed, Skipped, Inconclusive
+	Label     string        `xml:"label,attr"`  // Error, Invalid, Cancelled, Ignored, Explicit
+	Duration  float64       `xml:"duration,attr"`
+	Failure   *nunitFailure `xml:"failure"`
+	Reason    *nunitFailure `xml:"reason"`
+	Output    string        `xml:"output"`
+}
+
+type nunitFailure struct {
+	Message    string `xml:"message"`
+	StackTrace string `xml:"stack-trace"`
+}
+
+// Outcomes of a test, as written to the JUnit report
+const (
+	outcomePassed  = "passed"
+	outcomeFailed  = "failed"  // an assertion failed
+	outcomeError   = "error"   // an exception, an invalid test or a cancelled run
+	outcomeSkipped = "skipped" // ignored, explicit or inconclusive
+)
+
+// testCase is one test of one platform run
+type testCase struct {
+	platform string
+	fixture  string // class, e.g. Game.Tests.PlayerTests
+	name     string // method, with its arguments for parameterized tests
+	outcome  string
+	duration float64
+	message  string
+	stack    string
+	output   string
+}
+
+// platformRun is what one Unity run produced
+type platformRun struct {
+	platform   string
+	resultPath string
+	logPath    string
+	exitCode   int
+	duration   time.Duration
+	start      string // ISO 8601, for the JUnit timestamps
+	cases      []*testCase
+	err        error // Unity wrote no usable results
+}
+
+// JUnit report, in the form the common CI test reporters read
+type junitSuites struct {
+	XMLName  xml.Name      `xml:"testsuites"`
+	Name     string        `xml:"name,attr"`
+	Tests    int           `xml:"tests,attr"`
+	Failures int           `xml:"failures,attr"`
+	Errors   int           `xml:"errors,attr"`
+	Skipped  int           `xml:"skipped,attr"`
+	Time     string        `xml:"time,attr"`
+	Suites   []*junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []*junitCase    `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	Skipped   *junitFailure `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// packagesBackup holds the package files as they were before the test framework
+// was added
+type packagesBackup struct {
+	Manifest   string `json:"manifest"`
+	Lock       string `json:"lock"`
+	LockExists bool   `json:"lockExists"`
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// checkUnityRunning checks if Unity Editor is running for this project
+// via Library/EditorInstance.json and a liveness check on the recorded PID.
+func checkUnityRunning(basePath string) (bool, int) {
+	data, err := os.ReadFile(filepath.Join(basePath, "Library", "EditorInstance.json"))
+	if err != nil {
+		return false, 0
+	}
+	var instance EditorInstance
+	if err := json.Unmarshal(data, &instance); err != nil || instance.ProcessID <= 0 {
+		return false, 0
+	}
+	if isProcessRunning(instance.ProcessID) {
+		return true, instance.ProcessID
+	}
+	return false, 0
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// readUnityVersion returns the editor version from ProjectSettings/ProjectVersion.txt
+func readUnityVersion(basePath string) string {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectVersion.txt"))
+	if err != nil {
+		return ""
+	}
+	if m := projectVersionRegex.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// ============================================================
+// Installed Editors
+// ============================================================
+
+// hubEditorFolders returns the folders Unity Hub installs editors into: the
+// default location plus the custom one from secondaryInstallPath.json
+func hubEditorFolders() []string {
+	home, _ := os.UserHomeDir()
+	var folders []string
+	var hubConfig string
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramW6432"} {
+			if pf := os.Getenv(env); pf != "" {
+				folders = append(folders, filepath.Join(pf, "Unity", "Hub", "Editor"))
+			}
+		}
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			hubConfig = filepath.Join(appData, "UnityHub")
+		}
+	case "darwin":
+		folders = append(folders, "/Applications/Unity/Hub/Editor")
+		hubConfig = filepath.Join(home, "Library", "Application Support", "UnityHub")
+	default:
+		folders = append(folders, filepath.Join(home, "Unity", "Hub", "Editor"))
+		hubConfig = filepath.Join(home, ".config", "UnityHub")
+	}
+	if hubConfig != "" {
+		// The file holds a single JSON string; empty when no custom location is set
+		if data, err := os.ReadFile(filepath.Join(hubConfig, "secondaryInstallPath.json")); err == nil {
+			var custom string
+			if json.Unmarshal(data, &custom) == nil && custom != "" {
+				folders = append(folders, custom)
+			}
+		}
+	}
+	return folders
+}
+
+// editorExecutable is the Unity binary inside an editor version folder
+func editorExecutable(dir string) string {
+	switch runtime.GOOS {
+	case "windows":
+		return filepath.Join(dir, "Editor", "Unity.exe")
+	case "darwin":
+		return filepath.Join(dir, "Unity.app", "Contents", "MacOS", "Unity")
+	default:
+		return filepath.Join(dir, "Editor", "Unity")
+	}
+}
+
+// findUnity picks the editor: -unity, UNITYSTARTER_UNITY, then the Hub install
+// of the project's exact version. Another patch version is never picked
+// silently; it would upgrade the project.
+func findUnity(basePath, override string) (string, error) {
+	for _, candidate := range []string{override, os.Getenv(envUnityPath)} {
+		if candidate == "" {
+			continue
+		}
+		// A version folder works as well as the binary itself
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			candidate = editorExecutable(candidate)
+		}
+		if _, err := os.Stat(candidate); err != nil {
+			return "", fmt.Errorf("Unity editor not found: %s", candidate)
+		}
+		return candidate, nil
+	}
+	version := readUnityVersion(basePath)
+	if version == "" {
+		return "", errors.New("cannot read the Unity version from ProjectSettings/ProjectVersion.txt; pass -unity")
+	}
+	for _, folder := range hubEditorFolders() {
+		exe := editorExecutable(filepath.Join(folder, version))
+		if _, err := os.Stat(exe); err == nil {
+			return exe, nil
+		}
+	}
+	return "", fmt.Errorf("Unity %s is not installed in the Unity Hub folders; install it or pass -unity", version)
+}
+
+// ============================================================
+// Test Framework Package
+// ============================================================
+
+// hasTestFramework reports whether Packages/manifest.json depends on the test framework
+func hasTestFramework(basePath string) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(basePath, "Packages", "manifest.json"))
+	if err != nil {
+		return false, err
+	}
+	var manifest struct {
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return false, fmt.Errorf("invalid Packages/manifest.json: %v", err)
+	}
+	_, ok := manifest.Dependencies[testFrameworkPackage]
+	return ok, nil
+}
+
+// testFrameworkVersion is the version added for the project's editor
+func testFrameworkVersion(unityVersion string) string {
+	if major, err := strconv.Atoi(strings.SplitN(unityVersion, ".", 2)[0]); err == nil && major >= 6000 {
+		return testFrameworkUnity6
+	}
+	return testFrameworkLegacy
+}
+
+// addDependency inserts the package as the first dependency, in the indent of
+// the manifest, so the rest of the file keeps its order and formatting
+func addDependency(content, name, version string) (string, error) {
+	loc := dependenciesRegex.FindStringSubmatchIndex(content)
+	if loc == nil {
+		return "", errors.New("Packages/manifest.json has no \"dependencies\" object")
+	}
+	newline, indent := "\n", "    "
+	if loc[2] >= 0 {
+		newline = strings.TrimRight(content[loc[2]:loc[3]], " \t")
+		indent = content[loc[4]:loc[5]]
+	}
+	entry := fmt.Sprintf("%q: %q", name, version)
+	rest := content[loc[1]:]
+	if strings.HasPrefix(strings.TrimSpace(rest), "}") {
+		// An empty object: the entry is its only line, indented below the key
+		keyIndent := content[strings.LastIndex(content[:loc[0]], "\n")+1 : loc[0]]
+		closing := strings.TrimLeft(rest, " \t\r\n")
+		return content[:loc[0]] + "\"dependencies\": {" + newline + keyIndent + "  " + entry + newline + keyIndent + closing, nil
+	}
+	if loc[2] < 0 {
+		return content[:loc[1]] + entry + ", " + rest, nil
+	}
+	return content[:loc[1]] + entry + "," + newline + indent + rest, nil
+}
+
+// addTestFramework saves the package files and adds the test framework to the
+// manifest. Unity rewrites packages-lock.json when it resolves the package.
+func addTestFramework(basePath, version string) error {
+	manifestPath := filepath.Join(basePath, "Packages", "manifest.json")
+	lockPath := filepath.Join(basePath, "Packages", "packages-lock.json")
+	manifest, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return err
+	}
+	backup := packagesBackup{Manifest: string(manifest)}
+	if lock, err := os.ReadFile(lockPath); err == nil {
+		backup.Lock, backup.LockExists = string(lock), true
+	}
+	updated, err := addDependency(string(manifest), testFrameworkPackage, version)
+	if err != nil {
+		return err
+	}
+
+	backupPath := filepath.Join(basePath, filepath.FromSlash(packagesBackupRel))
+	data, _ := json.MarshalIndent(backup, "", "  ")
+	if err := os.MkdirAll(filepath.Dir(backupPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(backupPath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("cannot save the package files: %v", err)
+	}
+	if err := os.WriteFile(manifestPath, []byte(updated), 0644); err != nil {
+		os.Remove(backupPath)
+		return err
+	}
+	return nil
+}
+
+// restorePackages writes back the package files saved by addTestFramework, if
+// there is a backup. Returns false when there was nothing to restore.
+func restorePackages(basePath string) (bool, error) {
+	backupPath := filepath.Join(basePath, filepath.FromSlash(packagesBackupRel))
+	data, err := os.ReadFile(backupPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var backup packagesBackup
+	if err := json.Unmarshal(data, &backup); err != nil || backup.Manifest == "" {
+		return false, fmt.Errorf("unreadable %s; remove it once Packages/manifest.json is right", packagesBackupRel)
+	}
+	if err := os.WriteFile(filepath.Join(basePath, "Packages", "manifest.json"), []byte(backup.Manifest), 0644); err != nil {
+		return false, err
+	}
+	lockPath := filepath.Join(basePath, "Packages", "packages-lock.json")
+	if backup.LockExists {
+		err = os.WriteFile(lockPath, []byte(backup.Lock), 0644)
+	} else if err = os.Remove(lockPath); os.IsNotExist(err) {
+		err = nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, os.Remove(backupPath)
+}
+
+// restoreBase is set while the manifest holds the added test framework, so that
+// exitTool restores it on every way out
+var restoreBase string
+
+func restoreAddedPackages() {
+	if restoreBase == "" {
+		return
+	}
+	if _, err := restorePackages(restoreBase); err != nil {
+		fmt.Printf(tr("[ERROR] Cannot restore Packages/manifest.json: %v\n"), err)
+		fmt.Printf(tr("        The original is kept in %s.\n"), packagesBackupRel)
+		recordError("cannot restore Packages/manifest.json: %v", err)
+	} else {
+		fmt.Printf(tr("[OK] Removed %s from Packages/manifest.json again\n"), testFrameworkPackage)
+		recordAction("restore", "Packages/manifest.json", "ok", testFrameworkPackage, 0)
+	}
+	restoreBase = ""
+}
+
+// ============================================================
+// Test Run
+// ============================================================
+
+// testOptions are the settings shared by every platform run
+type testOptions struct {
+	basePath   string
+	unity      string
+	outDir     string
+	filter     string
+	category   string
+	assemblies string
+	noGraphics bool
+	timeout    time.Duration
+}
+
+// testCommand returns the Unity arguments for one platform run. -runTests quits
+// the editor by itself; -quit would end it before the tests ran.
+func testCommand(opts *testOptions, platform string) []string {
+	args := []string{
+		"-batchmode",
+		"-projectPath", opts.basePath,
+		"-runTests",
+		"-testPlatform", platform,
+		"-testResults", filepath.Join(opts.outDir, platform+".xml"),
+		"-logFile", filepath.Join(opts.outDir, platform+".log"),
+	}
+	if opts.noGraphics {
+		args = append(args, "-nographics")
+	}
+	if opts.filter != "" {
+		args = append(args, "-testFilter", opts.filter)
+	}
+	if opts.category != "" {
+		args = append(args, "-testCategory", opts.category)
+	}
+	if opts.assemblies != "" {
+		args = append(args, "-assemblyNames", opts.assemblies)
+	}
+	return args
+}
+
+// runPlatform starts Unity for one test platform and reads the results it
+// wrote. Ctrl+C stops Unity, so the manifest is still restored.
+func runPlatform(opts *testOptions, platform string) *platformRun {
+	run := &platformRun{
+		platform:   platform,
+		resultPath: filepath.Join(opts.outDir, platform+".xml"),
+		logPath:    filepath.Join(opts.outDir, platform+".log"),
+		exitCode:   -1,
+	}
+	// Leftovers of the previous run must not pass for this run's results
+	os.Remove(run.resultPath)
+	os.Remove(run.logPath)
+	if err := os.MkdirAll(opts.outDir, 0755); err != nil {
+		run.err = err
+		return run
+	}
+
+	ctx := context.Background()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, opts.unity, testCommand(opts, platform)...)
+	cmd.Dir = opts.basePath
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		run.err = fmt.Errorf("cannot start Unity: %v", err)
+		return run
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	interrupted := false
+	select {
+	case <-interrupt:
+		interrupted = true
+		cmd.Process.Kill()
+		<-done
+	case <-done:
+	}
+	run.duration = time.Since(start)
+	if cmd.ProcessState != nil {
+		run.exitCode = cmd.ProcessState.ExitCode()
+	}
+
+	switch {
+	case interrupted:
+		run.err = errors.New("interrupted")
+		return run
+	case ctx.Err() == context.DeadlineExceeded:
+		run.err = fmt.Errorf("timed out after %s", opts.timeout)
+		return run
+	}
+	results, err := os.ReadFile(run.resultPath)
+	if err != nil {
+		run.err = fmt.Errorf("Unity exited with code %d and wrote no test results (compile errors, or the test framework is missing)", run.exitCode)
+		return run
+	}
+	if err := parseResults(results, run); err != nil {
+		run.err = fmt.Errorf("cannot read %s: %v", filepath.Base(run.resultPath), err)
+		return run
+	}
+	// Results with a code that is neither "passed" nor "failed" are a run error
+	// (an unknown -testPlatform, a build failure of a player run...)
+	if run.exitCode != unityTestsPassed && run.exitCode != unityTestsFailed {
+		run.err = fmt.Errorf("Unity exited with code %d", run.exitCode)
+	}
+	return run
+}
+
+// logExcerpt returns the last error lines of a log, or its last lines when no
+// line looks like an error
+func logExcerpt(logPath string, max int) []string {
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	var errorLines []string
+	for _, line := range lines {
+		if logErrorRegex.MatchString(line) {
+			errorLines = append(errorLines, strings.TrimSpace(line))
+		}
+	}
+	if len(errorLines) == 0 {
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		errorLines = lines
+	}
+	if len(errorLines) > max {
+		errorLines = errorLines[len(errorLines)-max:]
+	}
+	return errorLines
+}
+
+// annotateCompilerErrors turns the compiler errors of a run's log into CI
+// annotations. Unity prints every compiler message several times, so repeats
+// are dropped, and at most max are printed per run.
+func annotateCompilerErrors(platform, logPath string, max int) {
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		return
+	}
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		m := compilerMessageRegex.FindStringSubmatch(line)
+		if m == nil || seen[m[0]] {
+			continue
+		}
+		seen[m[0]] = true
+		if len(seen) > max {
+			return
+		}
+		lineNo, _ := strconv.Atoi(m[2])
+		col, _ := strconv.Atoi(m[3])
+		annotate(m[4], m[1], lineNo, col, m[5], platform+": "+m[6])
+	}
+}
+
+// ============================================================
+// Results
+// ============================================================
+
+// parseResults reads the NUnit XML of a run into its test cases
+func parseResults(data []byte, run *platformRun) error {
+	var doc nunitRun
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	// "2026-10-14 10:00:00Z"; JUnit timestamps are ISO 8601 without a zone
+	if t, err := time.Parse("2006-01-02 15:04:05Z", doc.Start); err == nil {
+		run.start = t.Format("2006-01-02T15:04:05")
+	}
+	for i := range doc.Suites {
+		collectCases(&doc.Suites[i], "", run)
+	}
+	return nil
+}
+
+// collectCases walks a suite tree; fixture is the class of the nearest fixture
+func collectCases(s *nunitSuite, fixture string, run *platformRun) {
+	if s.Type == "TestFixture" || s.Type == "SetUpFixture" {
+		fixture = s.FullName
+	}
+	for i := range s.Cases {
+		c := &s.Cases[i]
+		tc := &testCase{
+			platform: run.platform,
+			fixture:  c.ClassName,
+			name:     c.Name,
+			outcome:  caseOutcome(c.Result, c.Label),
+			duration: c.Duration,
+			output:   strings.TrimSpace(c.Output),
+		}
+		if tc.fixture == "" {
+			tc.fixture = fixture
+		}
+		// NUnit indents the lines of an assertion message; only blank lines go
+		if c.Failure != nil {
+			tc.message, tc.stack = strings.Trim(c.Failure.Message, "\r\n"), strings.TrimSpace(c.Failure.StackTrace)
+		} else if c.Reason != nil {
+			tc.message = strings.Trim(c.Reason.Message, "\r\n")
+		}
+		run.cases = append(run.cases, tc)
+	}
+	for i := range s.Suites {
+		collectCases(&s.Suites[i], fixture, run)
+	}
+	// A failing [OneTimeTearDown] leaves every test of the fixture passed; the
+	// failure only shows on the suite, so it becomes a test of its own
+	if s.Failure != nil && s.Site == "TearDown" {
+		run.cases = append(run.cases, &testCase{
+			platform: run.platform,
+			fixture:  s.FullName,
+			name:     "(OneTimeTearDown)",
+			outcome:  outcomeError,
+			message:  strings.Trim(s.Failure.Message, "\r\n"),
+			stack:    strings.TrimSpace(s.Failure.StackTrace),
+		})
+	}
+}
+
+// caseOutcome maps an NUnit result and label to a JUnit outcome
+func caseOutcome(result, label string) string {
+	switch result {
+	case "Passed":
+		return outcomePassed
+	case "Failed":
+		if label == "Error" || label == "Invalid" || label == "Cancelled" {
+			return outcomeError
+		}
+		return outcomeFailed
+	default:
+		return outcomeSkipped
+	}
+}
+
+// countOutcomes returns passed, failed (failures and errors) and skipped
+func countOutcomes(cases []*testCase) (int, int, int) {
+	var passed, failed, skipped int
+	for _, c := range cases {
+		switch c.outcome {
+		case outcomePassed:
+			passed++
+		case outcomeSkipped:
+			skipped++
+		default:
+			failed++
+		}
+	}
+	return passed, failed, skipped
+}
+
+// stackLocation returns the first frame of a stack trace that points into the
+// project, relative to it, or the first frame with a file at all
+func stackLocation(basePath, stack string) (string, int) {
+	first, firstLine := "", 0
+	for _, frame := range strings.Split(stack, "\n") {
+		m := stackLocationRegex.FindStringSubmatch(strings.TrimRight(frame, "\r"))
+		if m == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(m[2])
+		file := m[1]
+		if rel, err := filepath.Rel(basePath, filepath.FromSlash(file)); err == nil && !strings.HasPrefix(rel, "..") && !filepath.IsAbs(rel) {
+			return filepath.ToSlash(rel), line
+		}
+		if strings.HasPrefix(file, "Assets/") || strings.HasPrefix(file, "Packages/") {
+			return file, line
+		}
+		if first == "" {
+			first, firstLine = file, line
+		}
+	}
+	return first, firstLine
+}
+
+// formatSeconds renders a test duration the way JUnit reports carry it
+func formatSeconds(s float64) string {
+	return strconv.FormatFloat(s, 'f', 3, 64)
+}
+
+// buildJUnit groups the test cases of all runs into one suite per platform and
+// fixture, in the order the runs listed them
+func buildJUnit(project string, runs []*platformRun) *junitSuites {
+	doc := &junitSuites{Name: project}
+	var total float64
+	for _, run := range runs {
+		index := make(map[string]*junitSuite)
+		seconds := make(map[*junitSuite]float64)
+		for _, c := range run.cases {
+			suite := index[c.fixture]
+			if suite == nil {
+				suite = &junitSuite{
+					Name:       c.fixture,
+					Timestamp:  run.start,
+					Properties: []junitProperty{{Name: "platform", Value: run.platform}},
+				}
+				index[c.fixture] = suite
+				doc.Suites = append(doc.Suites, suite)
+			}
+			jc := &junitCase{Name: c.name, ClassName: c.fixture, Time: formatSeconds(c.duration), SystemOut: c.output}
+			detail := &junitFailure{Message: firstLine(c.message), Text: strings.TrimRight(c.message+"\n"+c.stack, "\r\n")}
+			switch c.outcome {
+			case outcomeFailed:
+				jc.Failure = detail
+				suite.Failures++
+			case outcomeError:
+				jc.Error = detail
+				suite.Errors++
+			case outcomeSkipped:
+				jc.Skipped = &junitFailure{Message: firstLine(c.message)}
+				suite.Skipped++
+			}
+			suite.Tests++
+			suite.Cases = append(suite.Cases, jc)
+			seconds[suite] += c.duration
+		}
+		for suite, s := range seconds {
+			suite.Time = formatSeconds(s)
+			doc.Tests += suite.Tests
+			doc.Failures += suite.Failures
+			doc.Errors += suite.Errors
+			doc.Skipped += suite.Skipped
+			total += s
+		}
+	}
+	doc.Time = formatSeconds(total)
+	return doc
+}
+
+// writeJUnit writes the report; the folder is created when needed
+func writeJUnit(p string, doc *junitSuites) error {
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, append([]byte(xml.Header), append(data, '\n')...), 0644)
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexAny(s, "\r\n"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// printFailure lists a failed test with the first lines of its message
+func printFailure(basePath string, c *testCase) {
+	label := "[FAIL]"
+	if c.outcome == outcomeError {
+		label = "[ERROR]"
+	}
+	fmt.Printf("  %-7s %s.%s\n", label, c.fixture, c.name)
+	lines := strings.Split(strings.ReplaceAll(c.message, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if i == maxMessageLines {
+			fmt.Printf(tr("          ... %d more line(s)\n"), len(lines)-i)
+			break
+		}
+		fmt.Printf("          %s\n", line)
+	}
+	if file, line := stackLocation(basePath, c.stack); file != "" {
+		fmt.Printf("          at %s:%d\n", file, line)
+	}
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...": "\n按回车键继续...",
+
+	"[WARNING] Removing a stale %s (%s)\n":                      "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n": "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+
+	"Usage: unity_test_runner [flags]":                                 "用法: unity_test_runner [参数]",
+	"[ERROR] %v\n":                                                     "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"[WARNING] %v\n":                                                   "[WARNING] %v\n",
+	"  UNITY TEST RUNNER":                                              "  Unity 测试运行",
+	"  Project:   %s\n":                                                "  项目:     %s\n",
+	"  Editor:    %s\n":                                                "  编辑器:   %s\n",
+	"  Platforms: %s\n":                                                "  测试平台: %s\n",
+	"  Only:      %s\n":                                                "  仅限:     %s\n",
+	"\n[WARNING] %s is left from an interrupted run; the run restores Packages/manifest.json from it first.\n":                                 "\n[WARNING] %s 是中断的运行留下的；运行时会先用它恢复 Packages/manifest.json。\n",
+	"\n[DRY-RUN] Would add %s %s to Packages/manifest.json for the run\n":                                                                      "\n[DRY-RUN] 将为本次运行在 Packages/manifest.json 中加入 %s %s\n",
+	"\n[Dry Run] Unity was not started.":                                                                                                       "\n[Dry Run] 未启动 Unity。",
+	"\n[ERROR] Unity Editor is running (PID: %d). Close it before running the tests; batch mode cannot open a project that is already open.\n": "\n[ERROR] Unity 编辑器正在运行 (PID: %d)。请先关闭编辑器再运行测试；批处理模式无法打开已打开的项目。\n",
+	"\n[ERROR] %v\n": "\n[ERROR] %v\n",
+	"[WARNING] Restored Packages/manifest.json left changed by an interrupted run":         "[WARNING] 已恢复被中断的运行改动过的 Packages/manifest.json",
+	"[OK] Added %s %s to Packages/manifest.json for the run; Unity resolves it on start\n": "[OK] 已为本次运行在 Packages/manifest.json 中加入 %s %s；Unity 启动时会解析该包\n",
+	"[ERROR] Cannot restore Packages/manifest.json: %v\n":                                  "[ERROR] 无法恢复 Packages/manifest.json: %v\n",
+	"        The original is kept in %s.\n":                                                "        原文件保存在 %s。\n",
+	"[OK] Removed %s from Packages/manifest.json again\n":                                  "[OK] 已从 Packages/manifest.json 中移除 %s\n",
+	"\nRunning %s tests with %s ...\n":                                                     "\n正在用 %[2]s 运行 %[1]s 测试...\n",
+	"[ERROR] %s: %v\n":                                                                     "[ERROR] %s: %v\n",
+	"       Log: %s\n":                                                                     "       日志: %s\n",
+	"%s %s: %d passed, %d failed, %d skipped (%s)\n":                                       "%s %s: %d 个通过，%d 个失败，%d 个跳过 (%s)\n",
+	"          ... %d more line(s)\n":                                                      "          ... 还有 %d 行\n",
+	"  SUMMARY":                                                                            "  汇总",
+	"  Tests:     %d\n":                                                                    "  测试:     %d\n",
+	"  Passed:    %d\n":                                                                    "  通过:     %d\n",
+	"  Failed:    %d\n":                                                                    "  失败:     %d\n",
+	"  Skipped:   %d\n":                                                                    "  跳过:     %d\n",
+	"  Not run:   %d platform(s)\n":                                                        "  未运行:   %d 个平台\n",
+	"  JUnit:     %s\n":                                                                    "  JUnit:    %s\n",
+	"\n[WARNING] No tests ran. Check -filter and -category, or add test assemblies for these platforms.": "\n[WARNING] 没有运行任何测试。请检查 -filter 和 -category，或为这些平台添加测试程序集。",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed, the package files
+// restored and the project lock released
+func exitTool(code int) {
+	restoreAddedPackages()
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// CI Annotations
+// ============================================================
+
+// With -annotate, errors and warnings are printed once more as GitHub Actions
+// workflow commands or TeamCity service messages, so the CI lists them on the run
+// page and, when they point at a file, next to that file in the diff.
+var (
+	annotateMode  string              // "", "github" or "teamcity"
+	annotateTool  string              // TeamCity inspection category
+	annotateBase  string              // project root; relative files start here
+	annotateRoot  string              // repository root; CI file paths are relative to it
+	annotateTypes = map[string]bool{} // TeamCity inspection types already declared
+)
+
+// setAnnotateMode checks the -annotate value; "auto" picks the CI the tool runs in
+func setAnnotateMode(mode, basePath, tool string) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "none":
+		annotateMode = ""
+	case "github", "teamcity":
+		annotateMode = strings.ToLower(strings.TrimSpace(mode))
+	case "auto":
+		annotateMode = ""
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			annotateMode = "github"
+		} else if os.Getenv("TEAMCITY_VERSION") != "" {
+			annotateMode = "teamcity"
+		}
+	default:
+		return fmt.Errorf("unknown -annotate mode '%s' (use github, teamcity or auto)", mode)
+	}
+	annotateTool = tool
+	annotateBase, _ = filepath.Abs(basePath)
+	annotateRoot = repositoryRoot(annotateBase)
+	return nil
+}
+
+// repositoryRoot is GITHUB_WORKSPACE, else the nearest folder with a .git entry
+// (a folder, or a file in worktrees and submodules), else the project itself
+func repositoryRoot(basePath string) string {
+	if ws := os.Getenv("GITHUB_WORKSPACE"); ws != "" && annotateMode == "github" {
+		return ws
+	}
+	for dir := basePath; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return basePath
+		}
+	}
+}
+
+// annotate prints one annotation. level is "error" or "warning"; file is absolute
+// or relative to the project root, and may be empty like line and col.
+func annotate(level, file string, line, col int, title, message string) {
+	if annotateMode == "" {
+		return
+	}
+	if file != "" {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(annotateBase, filepath.FromSlash(file))
+		}
+		if rel, err := filepath.Rel(annotateRoot, file); err == nil {
+			file = rel
+		}
+		file = filepath.ToSlash(file)
+	}
+
+	if annotateMode == "github" {
+		// Property values also escape ':' and ','; the message only '%' and line breaks
+		data := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+		prop := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+		var props []string
+		if file != "" {
+			props = append(props, "file="+prop.Replace(file))
+			if line > 0 {
+				props = append(props, "line="+strconv.Itoa(line))
+			}
+			if col > 0 {
+				props = append(props, "col="+strconv.Itoa(col))
+			}
+		}
+		if title != "" {
+			props = append(props, "title="+prop.Replace(title))
+		}
+		fmt.Printf("::%s %s::%s\n", level, strings.Join(props, ","), data.Replace(message))
+		return
+	}
+
+	esc := strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]").Replace
+	status := "WARNING"
+	if level == "error" {
+		status = "ERROR"
+	}
+	if file == "" {
+		text := message
+		if title != "" {
+			text = title + ": " + message
+		}
+		fmt.Printf("##teamcity[message text='%s' status='%s']\n", esc(text), status)
+		return
+	}
+	typeID := annotateTool + "." + strings.ReplaceAll(strings.ToLower(title), " ", "-")
+	if !annotateTypes[typeID] {
+		annotateTypes[typeID] = true
+		fmt.Printf("##teamcity[inspectionType id='%s' name='%s' category='%s' description='%s']\n", esc(typeID), esc(title), esc(annotateTool), esc(title))
+	}
+	lineAttr := ""
+	if line > 0 {
+		lineAttr = fmt.Sprintf(" line='%d'", line)
+	}
+	fmt.Printf("##teamcity[inspection typeId='%s' message='%s' file='%s'%s SEVERITY='%s']\n", esc(typeID), esc(message), esc(file), lineAttr, status)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode        bool
+		dryRun        bool
+		force         bool
+		noGraphics    bool
+		platformsFlag string
+		filterFlag    string
+		categoryFlag  string
+		assemblyFlag  string
+		unityFlag     string
+		outFlag       string
+		junitFlag     string
+		frameworkFlag string
+		annotateFl    string
+		timeout       time.Duration
+	)
+
+	flag.StringVar(&platformsFlag, "platforms", "editmode,playmode", "Test platforms to run, in order: editmode, playmode")
+	flag.StringVar(&filterFlag, "filter", "", "Only tests whose full name matches: names or regexes separated by ';' (Unity -testFilter)")
+	flag.StringVar(&categoryFlag, "category", "", "Only tests in these [Category] names, separated by ';' (Unity -testCategory)")
+	flag.StringVar(&assemblyFlag, "assemblies", "", "Only tests in these assemblies, separated by ';' (Unity -assemblyNames)")
+	flag.StringVar(&unityFlag, "unity", "", "Unity editor binary or version folder (default: UNITYSTARTER_UNITY, then the Hub install of the project's version)")
+	flag.DurationVar(&timeout, "timeout", 0, "Abort Unity after this long per platform (e.g. 30m; default: none)")
+	flag.StringVar(&outFlag, "out", defaultOutDir, "Folder for the NUnit results and logs of each platform, relative to the project root or absolute")
+	flag.StringVar(&junitFlag, "junit", "", "JUnit report to write (default: junit.xml in -out)")
+	flag.StringVar(&frameworkFlag, "test-framework", "", "Version of "+testFrameworkPackage+" to add when the manifest has none (default: by editor version)")
+	flag.BoolVar(&noGraphics, "nographics", false, "Start Unity without a graphics device; PlayMode tests that render need one")
+	flag.StringVar(&annotateFl, "annotate", "", "Also print failed tests and compiler errors as CI annotations: github, teamcity, auto")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Print the Unity command lines without running them")
+	flag.BoolVar(&force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_test_runner")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	if flag.NArg() > 0 {
+		fmt.Println(tr("Usage: unity_test_runner [flags]"))
+		recordError("unexpected argument '%s'", flag.Arg(0))
+		exit(2)
+	}
+	var platforms []string
+	for _, p := range strings.Split(platformsFlag, ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		known := ""
+		for _, tp := range testPlatforms {
+			if strings.EqualFold(p, tp) {
+				known = tp
+			}
+		}
+		if known == "" {
+			fail(2, "unknown test platform '%s' (use editmode, playmode)", p)
+		}
+		if !containsString(platforms, known) {
+			platforms = append(platforms, known)
+		}
+	}
+	if len(platforms) == 0 {
+		fail(2, "-platforms is empty")
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if err := setAnnotateMode(annotateFl, basePath, "unity_test_runner"); err != nil {
+		fail(2, "%v", err)
+	}
+
+	outDir := outFlag
+	if !filepath.IsAbs(outDir) {
+		outDir = filepath.Join(basePath, filepath.FromSlash(outDir))
+	}
+	junitPath := junitFlag
+	if junitPath == "" {
+		junitPath = filepath.Join(outDir, junitFileName)
+	} else if !filepath.IsAbs(junitPath) {
+		junitPath = filepath.Join(basePath, filepath.FromSlash(junitPath))
+	}
+
+	// A run that was killed left the manifest with the added package
+	_, leftoverErr := os.Stat(filepath.Join(basePath, filepath.FromSlash(packagesBackupRel)))
+	leftover := leftoverErr == nil
+	hasFramework, err := hasTestFramework(basePath)
+	if err != nil {
+		fail(1, "%v", err)
+	}
+	unityVersion := readUnityVersion(basePath)
+	version := frameworkFlag
+	if version == "" {
+		version = testFrameworkVersion(unityVersion)
+	}
+
+	unity, err := findUnity(basePath, unityFlag)
+	if err != nil {
+		if !dryRun {
+			fail(1, "%v", err)
+		}
+		// The dry run still shows the command lines
+		fmt.Printf(tr("[WARNING] %v\n"), err)
+		unity = "Unity"
+	}
+	opts := &testOptions{
+		basePath:   basePath,
+		unity:      unity,
+		outDir:     outDir,
+		filter:     filterFlag,
+		category:   categoryFlag,
+		assemblies: assemblyFlag,
+		noGraphics: noGraphics,
+		timeout:    timeout,
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  UNITY TEST RUNNER"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:   %s\n"), filepath.Base(basePath))
+	if unityVersion != "" {
+		fmt.Printf(tr("  Editor:    %s\n"), unityVersion)
+	}
+	fmt.Printf(tr("  Platforms: %s\n"), strings.Join(platforms, ", "))
+	if filterFlag != "" || categoryFlag != "" || assemblyFlag != "" {
+		var parts []string
+		for _, f := range [][2]string{{"filter", filterFlag}, {"category", categoryFlag}, {"assemblies", assemblyFlag}} {
+			if f[1] != "" {
+				parts = append(parts, f[0]+" "+f[1])
+			}
+		}
+		fmt.Printf(tr("  Only:      %s\n"), strings.Join(parts, "; "))
+	}
+
+	if dryRun {
+		if leftover {
+			fmt.Printf(tr("\n[WARNING] %s is left from an interrupted run; the run restores Packages/manifest.json from it first.\n"), packagesBackupRel)
+		}
+		if !hasFramework && !leftover {
+			fmt.Printf(tr("\n[DRY-RUN] Would add %s %s to Packages/manifest.json for the run\n"), testFrameworkPackage, version)
+		}
+		for _, platform := range platforms {
+			fmt.Printf("\n  %s %s\n", unity, strings.Join(testCommand(opts, platform), " "))
+			recordAction("test", platform, "planned", "", 0)
+		}
+		fmt.Println(tr("\n[Dry Run] Unity was not started."))
+		exit(0)
+	}
+
+	if isRunning, pid := checkUnityRunning(basePath); isRunning {
+		fmt.Printf(tr("\n[ERROR] Unity Editor is running (PID: %d). Close it before running the tests; batch mode cannot open a project that is already open.\n"), pid)
+		recordError("Unity Editor is running (PID: %d). Close it before running the tests", pid)
+		exit(1)
+	}
+	if err := acquireProjectLock(basePath, "unity_test_runner", "run", force); err != nil {
+		fmt.Printf(tr("\n[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exit(1)
+	}
+	defer releaseProjectLock()
+
+	if leftover {
+		if _, err := restorePackages(basePath); err != nil {
+			fail(1, "cannot restore the package files of an interrupted run: %v", err)
+		}
+		fmt.Println(tr("[WARNING] Restored Packages/manifest.json left changed by an interrupted run"))
+		recordAction("restore", "Packages/manifest.json", "ok", "left by an interrupted run", 0)
+		if hasFramework, err = hasTestFramework(basePath); err != nil {
+			fail(1, "%v", err)
+		}
+	}
+	if !hasFramework {
+		if err := addTestFramework(basePath, version); err != nil {
+			fail(1, "cannot add %s: %v", testFrameworkPackage, err)
+		}
+		restoreBase = basePath
+		fmt.Printf(tr("[OK] Added %s %s to Packages/manifest.json for the run; Unity resolves it on start\n"), testFrameworkPackage, version)
+		recordAction("add", "Packages/manifest.json", "ok", testFrameworkPackage+" "+version, 0)
+	}
+
+	var runs []*platformRun
+	runErrors := 0
+	for _, platform := range platforms {
+		fmt.Printf(tr("\nRunning %s tests with %s ...\n"), platform, unity)
+		run := runPlatform(opts, platform)
+		runs = append(runs, run)
+		passed, failed, skipped := countOutcomes(run.cases)
+
+		if run.err != nil {
+			runErrors++
+			fmt.Printf(tr("[ERROR] %s: %v\n"), platform, run.err)
+			for _, line := range logExcerpt(run.logPath, 20) {
+				fmt.Printf("       %s\n", line)
+			}
+			fmt.Printf(tr("       Log: %s\n"), run.logPath)
+			recordAction("test", platform, "failed", run.err.Error(), run.duration)
+			recordError("%s: %v", platform, run.err)
+			annotate("error", "", 0, 0, platform+" tests did not run", run.err.Error())
+			annotateCompilerErrors(platform, run.logPath, maxCompilerAnnotations)
+			if len(run.cases) == 0 {
+				continue
+			}
+		}
+		status, label := "ok", "[OK]  "
+		if failed > 0 {
+			status, label = "failed", "[FAIL]"
+		}
+		fmt.Printf(tr("%s %s: %d passed, %d failed, %d skipped (%s)\n"), label, platform, passed, failed, skipped, run.duration.Round(time.Second))
+		for _, c := range run.cases {
+			if c.outcome != outcomeFailed && c.outcome != outcomeError {
+				continue
+			}
+			printFailure(basePath, c)
+			file, line := stackLocation(basePath, c.stack)
+			annotate("error", file, line, 0, "Test failed", fmt.Sprintf("%s %s.%s: %s", platform, c.fixture, c.name, c.message))
+		}
+		recordAction("test", platform, status, fmt.Sprintf("%d passed, %d failed, %d skipped", passed, failed, skipped), run.duration)
+		recordArtifact(run.resultPath)
+		if failed > 0 {
+			recordError("%s: %d test(s) failed", platform, failed)
+		}
+	}
+
+	restoreAddedPackages()
+
+	report := buildJUnit(filepath.Base(basePath), runs)
+	if err := writeJUnit(junitPath, report); err != nil {
+		fail(1, "cannot write %s: %v", junitPath, err)
+	}
+	recordArtifact(junitPath)
+
+	fmt.Println()
+	printRule("=============================================")
+	fmt.Println(tr("  SUMMARY"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Tests:     %d\n"), report.Tests)
+	fmt.Printf(tr("  Passed:    %d\n"), report.Tests-report.Failures-report.Errors-report.Skipped)
+	fmt.Printf(tr("  Failed:    %d\n"), report.Failures+report.Errors)
+	fmt.Printf(tr("  Skipped:   %d\n"), report.Skipped)
+	if runErrors > 0 {
+		fmt.Printf(tr("  Not run:   %d platform(s)\n"), runErrors)
+	}
+	fmt.Printf(tr("  JUnit:     %s\n"), junitPath)
+
+	switch {
+	case runErrors > 0:
+		exit(3)
+	case report.Failures+report.Errors > 0:
+		exit(1)
+	case report.Tests == 0:
+		fmt.Println(tr("\n[WARNING] No tests ran. Check -filter and -category, or add test assemblies for these platforms."))
+	}
+	exit(0)
+}
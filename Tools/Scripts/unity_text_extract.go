@@ -0,0 +1,1179 @@
+// Unity Text Extract — Find hardcoded UI text to move into string tables.
+// Scans C# for text assigned to UI (label.text = "...", SetText("..."), also
+// through string.Format and concatenation), and scenes and prefabs for the text
+// typed into Text and TextMeshPro components and into prefab instance overrides.
+// Components on a GameObject that already has a localization binding are left
+// out, and so are log messages, exceptions and strings without any letters.
+// Every candidate gets its file and line and a suggested table key; -out writes
+// the list as CSV to hand to whoever does the extraction.
+//
+// Build: go build unity_text_extract.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_text_extract                                  # list the candidates
+//	unity_text_extract -out Docs/Localization/text.csv  # write them as CSV
+//	unity_text_extract -only code Assets/Game/UI        # C# under one folder
+//	unity_text_extract -exclude "**/Debug/**" -json
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Editor code, tests and third-party code do not show text to players
+const defaultExclude = "**/Editor/**,**/Tests/**,**/ThirdParty/**,**/Plugins/**"
+
+// Candidates printed before "...and N more"; -out always has all of them
+const maxPrinted = 200
+
+// What -only accepts
+const (
+	onlyCode   = "code"
+	onlyAssets = "assets"
+)
+
+// Serialized text fields: UGUI Text and InputField use m_Text, TextMeshPro m_text
+var textFields = map[string]string{
+	"m_Text": "Text",
+	"m_text": "TextMeshPro",
+}
+
+// A suggested key built from a name this generic uses the text instead
+var genericNames = map[string]bool{
+	"text": true, "label": true, "labels": true, "txt": true, "tmp": true, "ui": true,
+	"legacy": true, "content": true, "value": true, "temp": true,
+}
+
+var (
+	// label.text = "...", label.text += "...", ui.title.SetText("...")
+	textAssignRegex = regexp.MustCompile(`\.(?:text\s*\+?=|SetText\s*\()`)
+	// What comes before the literal on the right-hand side
+	formatCallRegex = regexp.MustCompile(`^\s*(?:[Ss]tring\.Format\s*\(\s*)?`)
+	// Lines that log or throw
+	logLineRegex = regexp.MustCompile(`\bDebug\.\w*Log|\bLogger\.|\bLog(?:Info|Warning|Error|Debug)\s*\(|\bthrow\s+new\b`)
+
+	// "--- !u!1 &1234567 stripped": class ID, file ID, stripped
+	yamlDocRegex = regexp.MustCompile(`^--- !u!(\d+) &(-?\d+)( stripped)?`)
+	// "  m_Name: Player": a top-level field of a document
+	yamlFieldRegex = regexp.MustCompile(`^  ([^\s-][^:]*):(?: (.*))?$`)
+	// {fileID: 123} or {fileID: 11500000, guid: 0123abcd..., type: 3}
+	objectRefRegex = regexp.MustCompile(`^\{fileID: (-?\d+)(?:, guid: ([0-9a-fA-F]{32}))?(?:, type: \d+)?\}$`)
+
+	slugWordRegex = regexp.MustCompile(`[A-Za-z0-9]+`)
+	placeholderRe = regexp.MustCompile(`\{[^{}]*\}|<[^<>]*>`)
+	// " (1)" and " (TMP)" that Unity adds to GameObject names; m_ and s_ field prefixes
+	nameNoiseRegex = regexp.MustCompile(`\s*\([^()]*\)|^(?:[ms]_|_)`)
+)
+
+// Unity class IDs read from scenes and prefabs
+const (
+	classGameObject     = "1"
+	classMonoBehaviour  = "114"
+	classPrefabInstance = "1001"
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// candidate is one piece of text that looks like it should be localized
+type candidate struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Source  string `json:"source"`  // "code", "scene" or "prefab"
+	Context string `json:"context"` // titleLabel.text, or "Title (TextMeshPro)"
+	Text    string `json:"text"`
+	Note    string `json:"note,omitempty"` // "interpolated", "concatenated", "format", "override"
+	Key     string `json:"key"`            // suggested table key
+
+	name string // what the key is built from when the text is not usable
+}
+
+// yamlField is one top-level field of a document: the value on its line, and the
+// lines of a nested map, a list or a long string below it
+type yamlField struct {
+	key   string
+	value string
+	lines []string
+	line  int
+}
+
+// yamlDoc is one object of a scene or prefab file
+type yamlDoc struct {
+	class    string
+	fileID   string
+	stripped bool
+	typeName string
+	line     int
+	fields   []*yamlField
+	byKey    map[string]*yamlField
+}
+
+func (d *yamlDoc) get(key string) string {
+	if f := d.byKey[key]; f != nil {
+		return f.value
+	}
+	return ""
+}
+
+// ref returns the file ID a field like m_GameObject: {fileID: 123} points at
+func (d *yamlDoc) ref(key string) string {
+	if m := objectRefRegex.FindStringSubmatch(d.get(key)); m != nil {
+		return m[1]
+	}
+	return "0"
+}
+
+// unityFile is a parsed scene or prefab
+type unityFile struct {
+	docs []*yamlDoc
+	byID map[string]*yamlDoc
+}
+
+// ============================================================
+// C# Scanning
+// ============================================================
+
+// stripComments blanks out // and /* */ comments, keeping strings and line breaks
+func stripComments(src []byte) []byte {
+	out := make([]byte, len(src))
+	copy(out, src)
+	for i := 0; i < len(out); i++ {
+		switch {
+		case out[i] == '"':
+			verbatim := i > 0 && (out[i-1] == '@' || (out[i-1] == '$' && i > 1 && out[i-2] == '@'))
+			for i++; i < len(out); i++ {
+				if out[i] == '\n' && !verbatim {
+					break
+				}
+				if out[i] == '\\' && !verbatim {
+					i++
+					continue
+				}
+				if out[i] == '"' {
+					if verbatim && i+1 < len(out) && out[i+1] == '"' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+		case out[i] == '\'':
+			// A char literal such as '"' must not open a string
+			for i++; i < len(out) && out[i] != '\'' && out[i] != '\n'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '*':
+			for ; i < len(out) && !(out[i] == '*' && i+1 < len(out) && out[i+1] == '/'); i++ {
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+			if i+1 < len(out) {
+				out[i], out[i+1] = ' ', ' '
+				i++
+			}
+		}
+	}
+	return out
+}
+
+// readLiteral reads the C# string literal at src[i:], if there is one: its text,
+// whether it is interpolated, and where it ends
+func readLiteral(src []byte, i int) (string, bool, int, bool) {
+	interpolated, verbatim := false, false
+	for i < len(src) && (src[i] == '$' || src[i] == '@') {
+		interpolated = interpolated || src[i] == '$'
+		verbatim = verbatim || src[i] == '@'
+		i++
+	}
+	if i >= len(src) || src[i] != '"' {
+		return "", false, i, false
+	}
+	// Raw string literals ("""...""") are left alone
+	if i+2 < len(src) && src[i+1] == '"' && src[i+2] == '"' {
+		return "", false, i, false
+	}
+	start := i + 1
+	for j := start; j < len(src); j++ {
+		switch {
+		case src[j] == '\n' && !verbatim:
+			return "", false, j, false
+		case src[j] == '\\' && !verbatim:
+			j++
+		case src[j] == '"' && verbatim && j+1 < len(src) && src[j+1] == '"':
+			j++
+		case src[j] == '"':
+			raw := string(src[start:j])
+			if verbatim {
+				return strings.ReplaceAll(raw, `""`, `"`), interpolated, j + 1, true
+			}
+			return csharpUnquote(raw), interpolated, j + 1, true
+		}
+	}
+	return "", false, len(src), false
+}
+
+func csharpUnquote(s string) string {
+	if u, err := strconv.Unquote(`"` + s + `"`); err == nil {
+		return u
+	}
+	return s
+}
+
+// receiverBefore returns the expression in front of ".text", such as titleLabel or
+// GetComponent<TMP_Text>()
+func receiverBefore(src []byte, end int) string {
+	start, depth := end, 0
+	for start > 0 {
+		c := src[start-1]
+		switch {
+		case c == ')' || c == ']' || c == '>':
+			depth++
+		case (c == '(' || c == '[' || c == '<') && depth > 0:
+			depth--
+		case depth > 0 || c == '_' || c == '.' || c == '?' || c == '!' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)):
+		default:
+			return strings.TrimLeft(string(src[start:end]), ".?!")
+		}
+		start--
+	}
+	return string(src[start:end])
+}
+
+// lastIdentifier is the name a key can be built from: titleLabel in
+// panel.titleLabel, and labels in labels[i]; empty for calls
+func lastIdentifier(expr string) string {
+	parts := strings.Split(expr, ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		p := strings.TrimRight(parts[i], "?!")
+		if j := strings.Index(p, "["); j > 0 && strings.HasSuffix(p, "]") {
+			p = p[:j] // labels[i]
+		}
+		if p != "" && !strings.ContainsAny(p, "()[]<>") {
+			return p
+		}
+	}
+	return ""
+}
+
+// scanCode finds text assigned to UI in one C# file
+func scanCode(data []byte, rel string) []candidate {
+	src := stripComments(data)
+	lines := bytes.Split(src, []byte("\n"))
+	var res []candidate
+	for _, m := range textAssignRegex.FindAllIndex(src, -1) {
+		if m[1] < len(src) && src[m[1]-1] == '=' && src[m[1]] == '=' {
+			continue // a comparison
+		}
+		line := bytes.Count(src[:m[0]], []byte("\n")) + 1
+		if logLineRegex.Match(lines[line-1]) {
+			continue
+		}
+		rest := src[m[1]:]
+		skip := formatCallRegex.Find(rest)
+		text, interpolated, end, ok := readLiteral(src, m[1]+len(skip))
+		if !ok || !hasWords(text) {
+			continue
+		}
+		receiver := receiverBefore(src, m[0])
+		context := receiver + ".text"
+		if bytes.HasPrefix(src[m[0]:], []byte(".SetText")) {
+			context = receiver + ".SetText"
+		}
+		c := candidate{File: rel, Line: line, Source: "code", Context: context, Text: text, name: lastIdentifier(receiver)}
+		switch {
+		case interpolated:
+			c.Note = "interpolated"
+		case strings.Contains(string(skip), "Format"):
+			c.Note = "format"
+		case bytes.HasPrefix(bytes.TrimLeft(src[end:], " \t"), []byte("+")):
+			c.Note = "concatenated"
+		}
+		res = append(res, c)
+	}
+	return res
+}
+
+// hasWords reports text a player would read: it has letters once placeholders
+// ({0}, {score}) and rich text tags are taken out
+func hasWords(s string) bool {
+	for _, r := range placeholderRe.ReplaceAllString(s, "") {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Scene and Prefab Scanning
+// ============================================================
+
+// parseUnityFile splits a scene or prefab into its documents and their top-level
+// fields. Files saved with Force Binary serialization cannot be read.
+func parseUnityFile(data []byte) (*unityFile, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(text, "%YAML") {
+		return nil, fmt.Errorf("not a text asset (set Asset Serialization to Force Text)")
+	}
+	uf := &unityFile{byID: make(map[string]*yamlDoc)}
+	var doc *yamlDoc
+	var last *yamlField
+	for n, line := range strings.Split(text, "\n") {
+		if m := yamlDocRegex.FindStringSubmatch(line); m != nil {
+			doc = &yamlDoc{class: m[1], fileID: m[2], stripped: m[3] != "", line: n + 1, byKey: make(map[string]*yamlField)}
+			uf.docs = append(uf.docs, doc)
+			uf.byID[doc.fileID] = doc
+			last = nil
+			continue
+		}
+		if doc == nil {
+			continue
+		}
+		if doc.typeName == "" {
+			doc.typeName = strings.TrimSuffix(strings.TrimSpace(line), ":")
+			continue
+		}
+		if m := yamlFieldRegex.FindStringSubmatch(line); m != nil {
+			last = &yamlField{key: m[1], value: strings.TrimSpace(m[2]), line: n + 1}
+			doc.fields = append(doc.fields, last)
+			if _, dup := doc.byKey[last.key]; !dup {
+				doc.byKey[last.key] = last
+			}
+			continue
+		}
+		if last != nil && strings.HasPrefix(line, "  ") {
+			last.lines = append(last.lines, line)
+		}
+	}
+	return uf, nil
+}
+
+// yamlUnquote turns a YAML scalar into its text
+func yamlUnquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// scalarText is the text of a field whose string may go on over several lines:
+// Unity folds long strings, so line breaks are spaces and empty lines are breaks
+func scalarText(f *yamlField) string {
+	if len(f.lines) == 0 {
+		return yamlUnquote(f.value)
+	}
+	var b strings.Builder
+	b.WriteString(f.value)
+	newline := false
+	for _, l := range f.lines {
+		t := strings.TrimSpace(l)
+		switch {
+		case t == "":
+			b.WriteString(`\n`)
+			newline = true
+			continue
+		case strings.HasSuffix(b.String(), `\`) && !strings.HasSuffix(b.String(), `\\`):
+			// An escaped line break joins without a space
+			s := b.String()
+			b.Reset()
+			b.WriteString(s[:len(s)-1])
+		case !newline:
+			b.WriteString(" ")
+		}
+		b.WriteString(t)
+		newline = false
+	}
+	s := b.String()
+	if strings.HasPrefix(s, `"`) {
+		return yamlUnquote(s)
+	}
+	return strings.ReplaceAll(yamlUnquote(s), `\n`, "\n")
+}
+
+// hasBinding reports a component that already gets its text from a string table:
+// a LocalizedString field with a key set
+func hasBinding(d *yamlDoc) bool {
+	for _, f := range d.fields {
+		for _, l := range f.lines {
+			t := strings.TrimSpace(l)
+			if strings.HasPrefix(t, "m_EntryKey:") && yamlUnquote(strings.TrimPrefix(t, "m_EntryKey:")) != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scanAsset finds the text typed into components and prefab overrides of one scene
+// or prefab; the second result counts the text components that are already bound
+func scanAsset(data []byte, rel string) ([]candidate, int, error) {
+	uf, err := parseUnityFile(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	source := "prefab"
+	if strings.HasSuffix(rel, ".unity") {
+		source = "scene"
+	}
+
+	bound := make(map[string]bool) // GameObject IDs
+	for _, d := range uf.docs {
+		if d.class == classMonoBehaviour && hasBinding(d) {
+			bound[d.ref("m_GameObject")] = true
+		}
+	}
+
+	var res []candidate
+	skipped := 0
+	for _, d := range uf.docs {
+		switch d.class {
+		case classMonoBehaviour:
+			for field, kind := range textFields {
+				f := d.byKey[field]
+				if f == nil {
+					continue
+				}
+				text := scalarText(f)
+				if !hasWords(text) {
+					continue
+				}
+				goID := d.ref("m_GameObject")
+				if bound[goID] {
+					skipped++
+					continue
+				}
+				name := ""
+				if g := uf.byID[goID]; g != nil && g.class == classGameObject {
+					name = yamlUnquote(g.get("m_Name"))
+				}
+				res = append(res, candidate{File: rel, Line: f.line, Source: source, Context: fmt.Sprintf("%s (%s)", name, kind), Text: text, name: name})
+			}
+		case classPrefabInstance:
+			for _, mod := range modifications(d) {
+				if _, ok := textFields[mod.property]; ok && hasWords(mod.value) {
+					res = append(res, candidate{File: rel, Line: d.line, Source: source, Context: "prefab instance " + d.fileID, Text: mod.value, Note: "override"})
+				}
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Line < res[j].Line })
+	return res, skipped, nil
+}
+
+// modification is one entry of a prefab instance's m_Modifications
+type modification struct {
+	property, value string
+}
+
+// modifications reads m_Modification.m_Modifications of a prefab instance
+func modifications(d *yamlDoc) []modification {
+	f := d.byKey["m_Modification"]
+	if f == nil {
+		return nil
+	}
+	var mods []modification
+	var cur *modification
+	inList := false
+	for _, line := range f.lines {
+		item := strings.TrimSpace(line)
+		// Keys of m_Modification are indented by four spaces, list items by six
+		if strings.HasPrefix(line, "    ") && !strings.HasPrefix(line, "     ") && !strings.HasPrefix(item, "- ") {
+			inList = item == "m_Modifications:"
+			continue
+		}
+		if !inList {
+			continue
+		}
+		if strings.HasPrefix(item, "- target: ") {
+			mods = append(mods, modification{})
+			cur = &mods[len(mods)-1]
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(item, "propertyPath: "):
+			cur.property = strings.TrimPrefix(item, "propertyPath: ")
+		case strings.HasPrefix(item, "value: "):
+			cur.value = yamlUnquote(strings.TrimPrefix(item, "value: "))
+		case item == "value:":
+			cur.value = ""
+		}
+	}
+	return mods
+}
+
+// ============================================================
+// Suggested Keys
+// ============================================================
+
+// slug turns a name or a text into a key part: TitleLabel -> title, "Start game" -> start_game
+func slug(s string, maxWords int) string {
+	var words []string
+	for _, w := range slugWordRegex.FindAllString(s, -1) {
+		words = append(words, splitCamel(w)...)
+	}
+	// Trailing "Label" or "Text" says what kind of object it is, not what it shows
+	for len(words) > 1 && genericNames[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	if len(words) == 1 && genericNames[words[0]] {
+		return ""
+	}
+	if len(words) > maxWords {
+		words = words[:maxWords]
+	}
+	return strings.Join(words, "_")
+}
+
+// splitCamel splits UIWindowTitle into ui, window, title
+func splitCamel(w string) []string {
+	var words []string
+	r := []rune(w)
+	start := 0
+	for i := 1; i < len(r); i++ {
+		lowerBefore := unicode.IsLower(r[i-1]) || unicode.IsDigit(r[i-1])
+		acronymEnd := unicode.IsUpper(r[i-1]) && i+1 < len(r) && unicode.IsLower(r[i+1])
+		if unicode.IsUpper(r[i]) && (lowerBefore || acronymEnd) {
+			words = append(words, strings.ToLower(string(r[start:i])))
+			start = i
+		}
+	}
+	return append(words, strings.ToLower(string(r[start:])))
+}
+
+// table is the string table a candidate goes to: its script, scene or prefab
+func (c *candidate) table() string {
+	base := strings.TrimSuffix(filepath.Base(c.File), filepath.Ext(c.File))
+	if t := slug(base, 6); t != "" {
+		return t
+	}
+	return "ui"
+}
+
+// assignKeys suggests a key for every candidate: from the object's name, else from
+// the text. The same text in the same table gets the same key.
+func assignKeys(cands []candidate) {
+	byText := make(map[string]string) // table + text -> key
+	used := make(map[string]string)   // table + key -> text
+	for i := range cands {
+		c := &cands[i]
+		table := c.table()
+		if k, ok := byText[table+"\x00"+c.Text]; ok {
+			c.Key = table + "." + k
+			continue
+		}
+		base := slug(nameNoiseRegex.ReplaceAllString(c.name, ""), 4)
+		if base == "" {
+			base = slug(placeholderRe.ReplaceAllString(c.Text, " "), 4)
+		}
+		if base == "" {
+			base = "text"
+		}
+		key := base
+		for n := 2; ; n++ {
+			if _, taken := used[table+"\x00"+key]; !taken {
+				break
+			}
+			key = fmt.Sprintf("%s_%d", base, n)
+		}
+		used[table+"\x00"+key] = c.Text
+		byText[table+"\x00"+c.Text] = key
+		c.Key = table + "." + key
+	}
+}
+
+// writeCSV writes the candidates with a BOM, so spreadsheet apps read them as UTF-8
+func writeCSV(path string, cands []candidate) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("\xEF\xBB\xBF")
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"file", "line", "source", "context", "text", "note", "table", "key"})
+	for _, c := range cands {
+		i := strings.Index(c.Key, ".")
+		w.Write([]string{c.File, strconv.Itoa(c.Line), c.Source, c.Context, c.Text, c.Note, c.Key[:i], c.Key[i+1:]})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// ============================================================
+// Files
+// ============================================================
+
+// collectFiles lists the C# files, scenes and prefabs under the given paths
+func collectFiles(basePath string, roots []string, only string, excludes []*regexp.Regexp) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	wanted := func(path string) bool {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".cs":
+			return only != onlyAssets
+		case ".prefab", ".unity":
+			return only != onlyCode
+		}
+		return false
+	}
+	for _, root := range roots {
+		abs := root
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(basePath, filepath.FromSlash(root))
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, fmt.Errorf("%s does not exist", root)
+		}
+		if !info.IsDir() {
+			if wanted(abs) && !seen[abs] {
+				seen[abs] = true
+				files = append(files, abs)
+			}
+			continue
+		}
+		filepath.Walk(abs, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			rel := relPath(basePath, path)
+			if info.IsDir() {
+				if path != abs && (strings.HasPrefix(info.Name(), ".") || strings.HasSuffix(info.Name(), "~")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if wanted(path) && !matchesAny(excludes, rel) && !seen[path] {
+				seen[path] = true
+				files = append(files, path)
+			}
+			return nil
+		})
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		ciMode      bool
+		outFlag     string
+		onlyFlag    string
+		excludeFlag string
+	)
+
+	flag.StringVar(&outFlag, "out", "", "Write the candidates to this CSV file")
+	flag.StringVar(&onlyFlag, "only", "", "Scan only C# (code) or only scenes and prefabs (assets)")
+	flag.StringVar(&excludeFlag, "exclude", defaultExclude, "Comma-separated globs of files not scanned")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the paths ("Assets/UI -ci")
+	flag.Parse()
+	var roots []string
+	for flag.NArg() > 0 {
+		roots = append(roots, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_text_extract")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	if onlyFlag != "" && onlyFlag != onlyCode && onlyFlag != onlyAssets {
+		fail(2, "-only must be %s or %s", onlyCode, onlyAssets)
+	}
+	excludes, err := compileGlobs(excludeFlag)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if len(roots) == 0 {
+		roots = []string{"Assets"}
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  HARDCODED TEXT"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project: %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Scanning: %s\n"), strings.Join(roots, ", "))
+	if excludeFlag != "" {
+		fmt.Printf(tr("  Excluded: %s\n"), excludeFlag)
+	}
+
+	files, err := collectFiles(basePath, roots, onlyFlag, excludes)
+	if err != nil {
+		fail(2, "%v", err)
+	}
+
+	var cands []candidate
+	bound, inCode := 0, 0
+	for _, path := range files {
+		rel := relPath(basePath, path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf(tr("[WARNING] %s: %v\n"), rel, err)
+			continue
+		}
+		if strings.HasSuffix(path, ".cs") {
+			found := scanCode(data, rel)
+			inCode += len(found)
+			cands = append(cands, found...)
+			continue
+		}
+		found, skipped, err := scanAsset(data, rel)
+		if err != nil {
+			fmt.Printf(tr("[WARNING] %s: %v\n"), rel, err)
+			continue
+		}
+		bound += skipped
+		cands = append(cands, found...)
+	}
+	assignKeys(cands)
+
+	fmt.Println()
+	lastFile := ""
+	for i, c := range cands {
+		recordAction("candidate", fmt.Sprintf("%s:%d", c.File, c.Line), "ok", c.Key+" "+strconv.Quote(c.Text), 0)
+		if i == maxPrinted {
+			fmt.Printf(tr("...and %d more (-out writes all of them)\n"), len(cands)-maxPrinted)
+			continue
+		}
+		if i > maxPrinted {
+			continue
+		}
+		if c.File != lastFile {
+			if lastFile != "" {
+				fmt.Println()
+			}
+			fmt.Println(c.File)
+			lastFile = c.File
+		}
+		note := ""
+		if c.Note != "" {
+			note = " (" + tr(c.Note) + ")"
+		}
+		fmt.Printf("  %5d  %s: %s -> %s%s\n", c.Line, c.Context, shortText(c.Text), c.Key, note)
+	}
+
+	files = uniqueFiles(cands)
+	if len(cands) == 0 {
+		fmt.Println(tr("[OK] No hardcoded text found."))
+	} else {
+		fmt.Printf(tr("\n[OK] %d candidate(s) in %d file(s): %d in code, %d in scenes and prefabs.\n"), len(cands), len(files), inCode, len(cands)-inCode)
+	}
+	if bound > 0 {
+		fmt.Printf(tr("     %d text component(s) with a localization binding were skipped.\n"), bound)
+	}
+
+	if outFlag != "" {
+		out := outFlag
+		if !filepath.IsAbs(out) {
+			out = filepath.Join(basePath, filepath.FromSlash(out))
+		}
+		if err := writeCSV(out, cands); err != nil {
+			fail(1, "cannot write %s: %v", outFlag, err)
+		}
+		recordArtifact(out)
+		fmt.Printf(tr("[OK] Wrote %s\n"), relPath(basePath, out))
+	}
+	if len(cands) > 0 {
+		fmt.Println(tr("[TIP] Move the texts into string tables, then unity_loc_gate checks every locale has them."))
+	}
+	exit(0)
+}
+
+// shortText quotes a text for the console, cut to one line
+func shortText(s string) string {
+	r := []rune(s)
+	if i := strings.IndexAny(s, "\r\n"); i >= 0 || len(r) > 60 {
+		if i >= 0 && len([]rune(s[:i])) < 60 {
+			r = []rune(s[:i])
+		} else if len(r) > 60 {
+			r = r[:60]
+		}
+		return strconv.Quote(string(r)) + "…"
+	}
+	return strconv.Quote(s)
+}
+
+func uniqueFiles(cands []candidate) []string {
+	var files []string
+	for _, c := range cands {
+		if len(files) == 0 || files[len(files)-1] != c.File {
+			files = append(files, c.File)
+		}
+	}
+	return files
+}
+
+// ============================================================
+// Path Filters
+// ============================================================
+
+// globToRegexp converts a glob (*, ?, **) on slash-separated project paths. A
+// pattern without a slash matches the file name in any folder.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(strings.TrimSpace(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	if !strings.Contains(pattern, "/") {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// compileGlobs parses a comma-separated glob list
+func compileGlobs(list string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range strings.Split(list, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := globToRegexp(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchesAny(globs []*regexp.Regexp, rel string) bool {
+	for _, re := range globs {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+// relPath shows a path relative to the project root, with forward slashes
+func relPath(basePath, p string) string {
+	if rel, err := filepath.Rel(basePath, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return p
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...":                                     "\n按回车键继续...",
+	"[ERROR] %v\n":                                                     "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "应包含 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  HARDCODED TEXT":                                                 "  硬编码文本",
+	"  Project: %s\n":                                                  "  项目: %s\n",
+	"  Scanning: %s\n":                                                 "  扫描: %s\n",
+	"  Excluded: %s\n":                                                 "  排除: %s\n",
+	"[WARNING] %s: %v\n":                                               "[WARNING] %s: %v\n",
+	"...and %d more (-out writes all of them)\n":                       "...另有 %d 条（-out 会写出全部）\n",
+	"[OK] No hardcoded text found.":                                    "[OK] 未发现硬编码文本。",
+	"\n[OK] %d candidate(s) in %d file(s): %d in code, %d in scenes and prefabs.\n": "\n[OK] %[2]d 个文件中有 %[1]d 条候选: 代码中 %[3]d 条，场景和预制体中 %[4]d 条。\n",
+	"     %d text component(s) with a localization binding were skipped.\n":         "     已跳过 %d 个已绑定本地化的文本组件。\n",
+	"[OK] Wrote %s\n": "[OK] 已写入 %s\n",
+	"[TIP] Move the texts into string tables, then unity_loc_gate checks every locale has them.": "[TIP] 将这些文本移入字符串表后，可用 unity_loc_gate 检查每种语言是否都已翻译。",
+	"interpolated": "插值字符串",
+	"concatenated": "字符串拼接",
+	"format":       "string.Format",
+	"override":     "预制体覆盖",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
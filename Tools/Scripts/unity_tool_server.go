@@ -0,0 +1,870 @@
+// Unity Tool Server — Local REST API that runs the project tools for dashboards and editor scripts.
+// Exposes the core operations of this folder (full clean, build size analysis,
+// file tree, manifest edit) over HTTP, so an internal web dashboard or an
+// editor C# script can start them and follow their progress. Each operation
+// runs the matching tool with -json in the project root, one job at a time;
+// its console output is kept as the job log and its result document becomes
+// the job result. Every request except /v1/health needs the bearer token, which
+// is written with the URL to UserSettings/UnityStarter/ToolServer.json so
+// editor scripts of the same project can find the server.
+//
+// Build: go build unity_tool_server.go (the other tools are looked up next to it, then on PATH)
+//
+// Usage: run from the Unity project root.
+//
+//	unity_tool_server                               # http://127.0.0.1:8765, generated token
+//	unity_tool_server -addr 127.0.0.1:9000 -token s3cret
+//	unity_tool_server -cors https://dash.example.com
+//	UNITYSTARTER_SERVER_TOKEN=s3cret unity_tool_server
+//
+// API (Authorization: Bearer <token>):
+//
+//	GET  /v1/health                                 # no token needed
+//	GET  /v1/operations
+//	POST /v1/operations/clean     {"args": ["-dry-run"]}   -> 202 with the job
+//	GET  /v1/jobs                                   # newest first
+//	GET  /v1/jobs/{id}                              # status, result document, log tail
+//	GET  /v1/jobs/{id}/log?from=0                   # raw log from a byte offset
+//	POST /v1/jobs/{id}/cancel
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const defaultAddr = "127.0.0.1:8765"
+
+// Environment variable read when -token is not given
+const tokenEnv = "UNITYSTARTER_SERVER_TOKEN"
+
+// Where editor scripts look for the URL and token of a running server
+const discoveryFile = "UserSettings/UnityStarter/ToolServer.json"
+
+const defaultJobTimeout = 2 * time.Hour
+
+// Finished jobs kept in memory for GET /v1/jobs
+const maxJobs = 50
+
+// A job log stops growing past this size; the result document is kept apart
+const maxLogBytes = 4 << 20
+
+// Last lines of the log included in GET /v1/jobs/{id}
+const logTailLines = 40
+
+// Upper bound of a POST body
+const maxRequestBytes = 64 << 10
+
+// operations maps the API names to the tools they run. Client arguments are
+// passed to the tool as given, followed by -json; no shell is involved.
+var operations = map[string]operation{
+	"clean":    {Tool: "unity_project_full_clean", Description: "Deep clean of Library, Temp, build output and caches"},
+	"analyze":  {Tool: "build_size_analyzer", Description: "Build size breakdown from the Editor log or a build folder"},
+	"tree":     {Tool: "generate_file_tree", Description: "Markdown file tree of the project"},
+	"manifest": {Tool: "remove_unity_packages", Description: "Remove packages from Packages/manifest.json"},
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+type operation struct {
+	Tool        string `json:"tool"`
+	Description string `json:"description"`
+}
+
+// job is one run of an operation. Fields are guarded by the server mutex,
+// except the log, which has its own lock because the tool writes it directly.
+type job struct {
+	ID         string      `json:"id"`
+	Operation  string      `json:"operation"`
+	Args       []string    `json:"args"`
+	Status     string      `json:"status"` // "running", "succeeded", "failed", "canceled"
+	CreatedAt  string      `json:"createdAt"`
+	FinishedAt string      `json:"finishedAt,omitempty"`
+	DurationMs int64       `json:"durationMs,omitempty"`
+	ExitCode   *int        `json:"exitCode,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	Result     *jsonResult `json:"result,omitempty"`
+	LogTail    []string    `json:"logTail,omitempty"`
+
+	log    *jobLog
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// jobLog collects the tool's console output up to maxLogBytes
+type jobLog struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (l *jobLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if room := maxLogBytes - l.buf.Len(); room < len(p) {
+		if room > 0 {
+			l.buf.Write(p[:room])
+		}
+		if !l.truncated {
+			l.truncated = true
+			l.buf.WriteString("\n[log truncated]\n")
+		}
+		return len(p), nil
+	}
+	return l.buf.Write(p)
+}
+
+// from returns the log after a byte offset and the offset to poll from next
+func (l *jobLog) from(offset int) ([]byte, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.buf.Bytes()
+	if offset < 0 || offset > len(b) {
+		offset = len(b)
+	}
+	return append([]byte(nil), b[offset:]...), len(b)
+}
+
+func (l *jobLog) tail(n int) []string {
+	data, _ := l.from(0)
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return nil
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// discovery is the content of ToolServer.json
+type discovery struct {
+	URL        string   `json:"url"`
+	Token      string   `json:"token"`
+	PID        int      `json:"pid"`
+	StartedAt  string   `json:"startedAt"`
+	Operations []string `json:"operations"`
+}
+
+// ============================================================
+// Server
+// ============================================================
+
+type toolServer struct {
+	basePath string
+	token    string
+	cors     string
+	timeout  time.Duration
+	verbose  bool
+
+	mu      sync.Mutex
+	jobs    []*job // oldest first
+	nextID  int
+	current *job
+	closing bool
+}
+
+func (s *toolServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/health", s.handleHealth)
+	mux.HandleFunc("GET /v1/operations", s.auth(s.handleOperations))
+	mux.HandleFunc("POST /v1/operations/{op}", s.auth(s.handleStart))
+	mux.HandleFunc("GET /v1/jobs", s.auth(s.handleJobs))
+	mux.HandleFunc("GET /v1/jobs/{id}", s.auth(s.handleJob))
+	mux.HandleFunc("GET /v1/jobs/{id}/log", s.auth(s.handleLog))
+	mux.HandleFunc("POST /v1/jobs/{id}/cancel", s.auth(s.handleCancel))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.verbose {
+			fmt.Printf("[%s] %s %s %s\n", time.Now().Format("15:04:05"), r.RemoteAddr, r.Method, r.URL.Path)
+		}
+		if s.cors != "" {
+			w.Header().Set("Access-Control-Allow-Origin", s.cors)
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Log-Offset")
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// auth rejects requests without the bearer token; the comparison is constant-time
+func (s *toolServer) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(given)), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="unity_tool_server"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *toolServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	busy := s.current != nil
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "busy": busy})
+}
+
+func (s *toolServer) handleOperations(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, operations)
+}
+
+func (s *toolServer) handleStart(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("op")
+	op, ok := operations[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown operation '%s'", name))
+		return
+	}
+	var body struct {
+		Args []string `json:"args"`
+	}
+	if r.ContentLength != 0 {
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+			return
+		}
+	}
+	for _, a := range body.Args {
+		if a == "-json" || strings.HasPrefix(a, "-json=") || strings.ContainsRune(a, 0) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("argument '%s' is not allowed", a))
+			return
+		}
+	}
+	exe, err := resolveTool(op.Tool)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, "server is shutting down")
+		return
+	}
+	if s.current != nil {
+		running := s.current.ID
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, fmt.Sprintf("job %s is still running", running))
+		return
+	}
+	s.nextID++
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	j := &job{
+		ID:        strconv.Itoa(s.nextID),
+		Operation: name,
+		Args:      append([]string{}, body.Args...),
+		Status:    "running",
+		CreatedAt: time.Now().Format(time.RFC3339),
+		log:       &jobLog{},
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	s.current = j
+	s.jobs = append(s.jobs, j)
+	if len(s.jobs) > maxJobs {
+		s.jobs = s.jobs[len(s.jobs)-maxJobs:]
+	}
+	snapshot := s.view(j, false)
+	s.mu.Unlock()
+
+	fmt.Printf(tr("[JOB %s] %s\n"), j.ID, strings.TrimSpace(name+" "+strings.Join(j.Args, " ")))
+	go s.run(ctx, j, exe)
+
+	w.Header().Set("Location", "/v1/jobs/"+j.ID)
+	writeJSON(w, http.StatusAccepted, snapshot)
+}
+
+func (s *toolServer) handleJobs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	list := make([]job, 0, len(s.jobs))
+	for i := len(s.jobs) - 1; i >= 0; i-- {
+		list = append(list, s.view(s.jobs[i], false))
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *toolServer) handleJob(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j := s.find(r.PathValue("id"))
+	if j == nil {
+		writeError(w, http.StatusNotFound, "no such job")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.view(j, true))
+}
+
+// handleLog returns the log from ?from=<offset>; X-Log-Offset is where the next poll starts
+func (s *toolServer) handleLog(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	j := s.find(r.PathValue("id"))
+	s.mu.Unlock()
+	if j == nil {
+		writeError(w, http.StatusNotFound, "no such job")
+		return
+	}
+	offset := 0
+	if v := r.URL.Query().Get("from"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid offset '%s'", v))
+			return
+		}
+		offset = n
+	}
+	data, next := j.log.from(offset)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Log-Offset", strconv.Itoa(next))
+	w.Write(data)
+}
+
+func (s *toolServer) handleCancel(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	j := s.find(r.PathValue("id"))
+	if j == nil {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "no such job")
+		return
+	}
+	if j.Status != "running" {
+		snapshot := s.view(j, false)
+		s.mu.Unlock()
+		writeJSON(w, http.StatusConflict, snapshot)
+		return
+	}
+	j.Status = "canceled"
+	j.cancel()
+	s.mu.Unlock()
+
+	// The tool is killed; wait briefly so the response shows the final state
+	select {
+	case <-j.done:
+	case <-time.After(5 * time.Second):
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, s.view(j, false))
+}
+
+// find looks a job up by ID; the caller holds s.mu
+func (s *toolServer) find(id string) *job {
+	for _, j := range s.jobs {
+		if j.ID == id {
+			return j
+		}
+	}
+	return nil
+}
+
+// view copies a job for encoding; the caller holds s.mu
+func (s *toolServer) view(j *job, withLog bool) job {
+	v := *j
+	if withLog {
+		v.LogTail = j.log.tail(logTailLines)
+	} else {
+		v.Result = nil
+	}
+	return v
+}
+
+// ============================================================
+// Task Runner
+// ============================================================
+
+// resolveTool finds a sibling tool: next to this executable first, then on PATH
+func resolveTool(name string) (string, error) {
+	file := name
+	if runtime.GOOS == "windows" && !strings.HasSuffix(strings.ToLower(file), ".exe") {
+		file += ".exe"
+	}
+	if exe, err := os.Executable(); err == nil {
+		candidate := filepath.Join(filepath.Dir(exe), file)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	p, err := exec.LookPath(file)
+	if err != nil {
+		return "", fmt.Errorf("%s not found next to unity_tool_server or on PATH", file)
+	}
+	return p, nil
+}
+
+// run executes one job. The tool's console output (stderr under -json) goes to
+// the job log; its result document is read from stdout.
+func (s *toolServer) run(ctx context.Context, j *job, exe string) {
+	defer close(j.done)
+	args := append(append([]string{}, j.Args...), "-json")
+	fmt.Fprintf(j.log, "$ %s %s\n\n", filepath.Base(exe), strings.Join(args, " "))
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, exe, args...)
+	cmd.Dir = s.basePath
+	cmd.Stdout = &stdout
+	cmd.Stderr = j.log
+	// Progress bars would fill the log with carriage returns
+	cmd.Env = append(os.Environ(), "UNITYSTARTER_PLAIN=1")
+	// A child left behind by a killed tool must not keep the job running
+	cmd.WaitDelay = 2 * time.Second
+
+	start := time.Now()
+	runErr := cmd.Run()
+	duration := time.Since(start)
+	j.cancel()
+
+	var doc jsonResult
+	parsed := json.Unmarshal(stdout.Bytes(), &doc) == nil && doc.Tool != ""
+
+	s.mu.Lock()
+	j.FinishedAt = time.Now().Format(time.RFC3339)
+	j.DurationMs = duration.Milliseconds()
+	if parsed {
+		j.Result = &doc
+	}
+	if cmd.ProcessState != nil && cmd.ProcessState.ExitCode() >= 0 {
+		code := cmd.ProcessState.ExitCode()
+		j.ExitCode = &code
+	}
+	switch {
+	case j.Status == "canceled":
+		j.Error = "canceled"
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		j.Status, j.Error = "failed", fmt.Sprintf("timed out after %s", s.timeout)
+	case runErr != nil:
+		j.Status, j.Error = "failed", runErr.Error()
+	case parsed && !doc.Success:
+		j.Status = "failed"
+		j.Error = strings.Join(doc.Errors, "; ")
+	default:
+		j.Status = "succeeded"
+	}
+	s.current = nil
+	status, detail := j.Status, j.Error
+	s.mu.Unlock()
+
+	fmt.Printf(tr("[JOB %s] %s: %s (%s)\n"), j.ID, j.Operation, status, duration.Round(time.Millisecond))
+	actionStatus := map[string]string{"succeeded": "ok", "canceled": "skipped"}[status]
+	if actionStatus == "" {
+		actionStatus = "failed"
+	}
+	recordAction(j.Operation, "job "+j.ID, actionStatus, detail, duration)
+}
+
+// shutdown refuses new jobs and cancels the running one
+func (s *toolServer) shutdown() {
+	s.mu.Lock()
+	s.closing = true
+	j := s.current
+	if j != nil {
+		j.Status = "canceled"
+		j.cancel()
+	}
+	s.mu.Unlock()
+	if j != nil {
+		<-j.done
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
+
+// ============================================================
+// Discovery File
+// ============================================================
+
+// writeDiscovery stores the URL and token for editor scripts; only the user can read it
+func writeDiscovery(basePath, url, token string) (string, error) {
+	p := filepath.Join(basePath, filepath.FromSlash(discoveryFile))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(operations))
+	for name := range operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	data, err := json.MarshalIndent(discovery{
+		URL: url, Token: token, PID: os.Getpid(), StartedAt: time.Now().Format(time.RFC3339), Operations: names,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return p, os.WriteFile(p, append(data, '\n'), 0600)
+}
+
+// newToken returns 32 random bytes as hex
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// isLoopback reports whether the listen host only accepts local connections
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"  Unity Tool Server": "  Unity 工具服务器",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "需要 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  Project:    %s\n": "  项目:       %s\n",
+	"  URL:        %s\n": "  地址:       %s\n",
+	"  Token:      %s\n": "  令牌:       %s\n",
+	"  Operations: %s\n": "  操作:       %s\n",
+	"(saved in %s)":      "(已保存到 %s)",
+	"[WARNING] Listening on %s: other machines can reach this server.\n":    "[WARNING] 正在监听 %s：其他机器也可以访问此服务器。\n",
+	"          Anyone with the token can run the tools with any arguments.": "          持有令牌的任何人都可以用任意参数运行这些工具。",
+	"[WARNING] Could not write %s: %v\n":                                    "[WARNING] 无法写入 %s: %v\n",
+	"\nPress Ctrl+C to stop.":                                               "\n按 Ctrl+C 停止。",
+	"[JOB %s] %s\n":                                                         "[任务 %s] %s\n",
+	"[JOB %s] %s: %s (%s)\n":                                                "[任务 %s] %s: %s (%s)\n",
+	"\nStopping...":                                                         "\n正在停止...",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		addr    string
+		token   string
+		cors    string
+		timeout time.Duration
+		verbose bool
+	)
+
+	flag.StringVar(&addr, "addr", defaultAddr, "Listen address (host:port); keep it on 127.0.0.1 unless the dashboard runs elsewhere")
+	flag.StringVar(&token, "token", "", "Bearer token clients must send (default: "+tokenEnv+" or a generated one)")
+	flag.StringVar(&cors, "cors", "", "Origin allowed to call the API from a browser, e.g. https://dash.example.com")
+	flag.DurationVar(&timeout, "timeout", defaultJobTimeout, "Time limit of one job")
+	flag.BoolVar(&verbose, "v", false, "Log every request")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout when the server stops")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_tool_server")
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exitTool(code)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fail(1, "cannot get current directory: %v", err)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exitTool(1)
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		fail(2, "invalid -addr '%s': %v", addr, err)
+	}
+	if timeout <= 0 {
+		fail(2, "-timeout must be positive")
+	}
+
+	tokenSource := "-token"
+	if token == "" {
+		token, tokenSource = strings.TrimSpace(os.Getenv(tokenEnv)), tokenEnv
+	}
+	if token == "" {
+		if token, err = newToken(); err != nil {
+			fail(1, "cannot generate a token: %v", err)
+		}
+		tokenSource = "generated"
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		fail(1, "%v", err)
+	}
+	// With port 0 the system picks one; show the URL editor scripts will use
+	urlHost := host
+	if urlHost == "" || urlHost == "0.0.0.0" || urlHost == "::" {
+		urlHost = "127.0.0.1"
+	}
+	url := fmt.Sprintf("http://%s/v1/", net.JoinHostPort(urlHost, fmt.Sprint(listener.Addr().(*net.TCPAddr).Port)))
+
+	srv := &toolServer{basePath: basePath, token: token, cors: cors, timeout: timeout, verbose: verbose}
+	httpServer := &http.Server{Handler: srv.routes(), ReadHeaderTimeout: 10 * time.Second}
+
+	printRule("=============================================")
+	fmt.Println(tr("  Unity Tool Server"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:    %s\n"), basePath)
+	fmt.Printf(tr("  URL:        %s\n"), url)
+	discoveryPath, err := writeDiscovery(basePath, url, token)
+	if err != nil {
+		fmt.Printf(tr("[WARNING] Could not write %s: %v\n"), discoveryFile, err)
+		fmt.Printf(tr("  Token:      %s\n"), tokenSource)
+	} else {
+		defer os.Remove(discoveryPath)
+		fmt.Printf(tr("  Token:      %s\n"), tokenSource+" "+fmt.Sprintf(tr("(saved in %s)"), discoveryFile))
+	}
+	names := make([]string, 0, len(operations))
+	for name := range operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Printf(tr("  Operations: %s\n"), strings.Join(names, ", "))
+	if !isLoopback(host) {
+		fmt.Printf(tr("[WARNING] Listening on %s: other machines can reach this server.\n"), addr)
+		fmt.Println(tr("          Anyone with the token can run the tools with any arguments."))
+	}
+	fmt.Println(tr("\nPress Ctrl+C to stop."))
+	recordAction("serve", basePath, "ok", url, 0)
+
+	// Stop cleanly so the running job is killed and the discovery file removed
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		fmt.Println(tr("\nStopping..."))
+		srv.shutdown()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(ctx)
+	}()
+
+	if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+		os.Remove(discoveryPath)
+		fail(1, "%v", err)
+	}
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,1260 @@
+// Unity Toolset — Build a reproducible, checksummed release of every tool in this folder.
+// The release command cross-compiles each tool of Tools/Scripts for Windows,
+// macOS and Linux on amd64 and arm64, with the version, commit and build date
+// compiled in ("<tool> -version" prints them). Each operating system gets one
+// zip with a folder per architecture and a SHA256SUMS manifest; next to the
+// zips go a SHA256SUMS of the zips, release.json, and install scripts
+// (install.sh, install.ps1) that check both manifests before copying the tools.
+// Builds use -trimpath, no cgo and no build ID, and the zip entries are dated
+// with the commit time (or SOURCE_DATE_EPOCH), so the same commit and Go
+// version give the same bytes. The verify command checks a release folder, or
+// one zip, against its manifests.
+//
+// Build: go build unity_toolset.go (release needs the Go toolchain on PATH)
+//
+// Usage: run from Tools/Scripts, the repository root or the Unity project root.
+//
+//	unity_toolset release                                # version from git describe, into Tools/Release/<version>
+//	unity_toolset release -version 1.4.0 -out /srv/tools/1.4.0
+//	unity_toolset release -targets windows/amd64,darwin/arm64 -tools rename_project,unity_project_full_clean
+//	unity_toolset verify Tools/Release/1.4.0             # or one zip
+
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// Prefix of the zips and of the folder inside each of them
+const releaseName = "UnityStarterTools"
+
+const defaultTargets = "windows/amd64,windows/arm64,darwin/amd64,darwin/arm64,linux/amd64,linux/arm64"
+
+// Manifest format of sha256sum / shasum -a 256, so a release can be checked without this tool
+const sumsFileName = "SHA256SUMS"
+
+const releaseManifestName = "release.json"
+
+// Added to each tool's sources for the build only; never written to Tools/Scripts
+const versionFileName = "zz_toolset_version.go"
+
+// A tool is a .go file of package main with its own main function
+var (
+	mainPackagePattern = regexp.MustCompile(`(?m)^package main\s*$`)
+	mainFuncPattern    = regexp.MustCompile(`(?m)^func main\(\) \{`)
+)
+
+// Versions end up in file names
+var versionPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+-]*$`)
+
+// Folders a release is started from: Tools/Scripts itself, the repository root
+// and the Unity project root next to Tools/
+var sourceDirCandidates = []string{".", filepath.Join("Tools", "Scripts"), filepath.Join("..", "Tools", "Scripts")}
+
+// ============================================================
+// Types
+// ============================================================
+
+// target is one GOOS/GOARCH pair
+type target struct {
+	goos, goarch string
+}
+
+func (t target) String() string { return t.goos + "/" + t.goarch }
+
+// osLabel is the operating system as named in zip names and the install scripts
+func osLabel(goos string) string {
+	if goos == "darwin" {
+		return "macos"
+	}
+	return goos
+}
+
+// buildInfo is what gets compiled into every tool
+type buildInfo struct {
+	Version string
+	Commit  string
+	Date    time.Time
+	Dirty   bool
+}
+
+// releaseManifest is written as release.json. Keep field names stable: install
+// automation reads it.
+type releaseManifest struct {
+	Name      string        `json:"name"`
+	Version   string        `json:"version"`
+	Commit    string        `json:"commit"`
+	Date      string        `json:"date"`
+	GoVersion string        `json:"goVersion"`
+	Targets   []string      `json:"targets"`
+	Tools     []string      `json:"tools"`
+	Files     []releaseFile `json:"files"`
+}
+
+type releaseFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ============================================================
+// Sources
+// ============================================================
+
+// findSourceDir returns the folder holding the tool sources
+func findSourceDir(flagValue string) (string, error) {
+	if flagValue != "" {
+		if tools, _ := listTools(flagValue); len(tools) == 0 {
+			return "", fmt.Errorf("no tool sources (package main with func main) in %s", flagValue)
+		}
+		return flagValue, nil
+	}
+	for _, dir := range sourceDirCandidates {
+		if tools, _ := listTools(dir); len(tools) > 0 {
+			return dir, nil
+		}
+	}
+	return "", errors.New("cannot find the tool sources; run from Tools/Scripts or pass -src")
+}
+
+// listTools returns the names of the standalone tools in dir: every .go file
+// that is a main package with its own main function
+func listTools(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	var tools []string
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		if mainPackagePattern.Match(data) && mainFuncPattern.Match(data) {
+			tools = append(tools, strings.TrimSuffix(filepath.Base(file), ".go"))
+		}
+	}
+	sort.Strings(tools)
+	return tools, nil
+}
+
+// selectTools narrows tools to a comma-separated list; unknown names are errors
+func selectTools(tools []string, list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return tools, nil
+	}
+	known := make(map[string]bool, len(tools))
+	for _, t := range tools {
+		known[t] = true
+	}
+	var selected []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSuffix(strings.TrimSpace(name), ".go")
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			return nil, fmt.Errorf("unknown tool '%s'", name)
+		}
+		selected = append(selected, name)
+	}
+	sort.Strings(selected)
+	return selected, nil
+}
+
+// parseTargets reads "goos/goarch" pairs
+func parseTargets(list string) ([]target, error) {
+	var targets []target
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" || seen[item] {
+			continue
+		}
+		parts := strings.Split(item, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid target '%s', expected goos/goarch such as windows/amd64", item)
+		}
+		seen[item] = true
+		targets = append(targets, target{goos: parts[0], goarch: parts[1]})
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("no targets")
+	}
+	return targets, nil
+}
+
+// ============================================================
+// Version Info
+// ============================================================
+
+func gitOutput(dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// resolveBuildInfo takes the version from -version or git describe, and the
+// build date from SOURCE_DATE_EPOCH or the commit time, so rebuilding a commit
+// reproduces its release
+func resolveBuildInfo(src, version string) (buildInfo, error) {
+	info := buildInfo{Version: version, Commit: gitOutput(src, "rev-parse", "HEAD")}
+	info.Dirty = info.Commit != "" && gitOutput(src, "status", "--porcelain", "--", ".") != ""
+	if info.Version == "" {
+		info.Version = strings.TrimPrefix(gitOutput(src, "describe", "--tags", "--always", "--dirty"), "v")
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if !versionPattern.MatchString(info.Version) {
+		return info, fmt.Errorf("invalid version '%s': use letters, digits, '.', '_', '+' and '-'", info.Version)
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+
+	epoch := strings.TrimSpace(os.Getenv("SOURCE_DATE_EPOCH"))
+	if epoch == "" {
+		epoch = gitOutput(src, "log", "-1", "--format=%ct")
+	}
+	if epoch == "" {
+		info.Date = time.Now().UTC().Truncate(time.Second)
+		return info, nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return info, fmt.Errorf("invalid SOURCE_DATE_EPOCH '%s'", epoch)
+	}
+	info.Date = time.Unix(seconds, 0).UTC()
+	return info, nil
+}
+
+// versionSource is compiled into each tool. The flag is only taken as the sole
+// argument, so the tools that have a -version option of their own keep it.
+func versionSource(tool string, info buildInfo) string {
+	return fmt.Sprintf(`// Code generated by unity_toolset release. DO NOT EDIT.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func init() {
+	if len(os.Args) == 2 && (os.Args[1] == "-version" || os.Args[1] == "--version") {
+		fmt.Printf("%%s %%s (%%s, %%s, %%s/%%s)\n", %q, %q, %q, %q, runtime.GOOS, runtime.GOARCH)
+		os.Exit(0)
+	}
+}
+`, tool, info.Version, info.Commit, info.Date.Format(time.RFC3339))
+}
+
+// ============================================================
+// Build
+// ============================================================
+
+func exeName(tool, goos string) string {
+	if goos == "windows" {
+		return tool + ".exe"
+	}
+	return tool
+}
+
+// prepareSources copies each tool with its version file into its own folder
+// under work, so no file is ever added to Tools/Scripts
+func prepareSources(src, work string, tools []string, info buildInfo) error {
+	for _, tool := range tools {
+		dir := filepath.Join(work, "src", tool)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(filepath.Join(src, tool+".go"))
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, tool+".go"), data, 0644); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, versionFileName), []byte(versionSource(tool, info)), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildTool compiles one tool for one target. Paths, the build ID and cgo are
+// left out so the binary depends only on the sources and the Go version.
+func buildTool(goBin, work, tool string, t target, out string) error {
+	cmd := exec.Command(goBin, "build", "-trimpath", "-buildvcs=false", "-ldflags", "-s -w -buildid=",
+		"-o", out, tool+".go", versionFileName)
+	cmd.Dir = filepath.Join(work, "src", tool)
+	cmd.Env = append(os.Environ(), "GOOS="+t.goos, "GOARCH="+t.goarch, "CGO_ENABLED=0", "GO111MODULE=on")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(output))
+		if lines := strings.Split(msg, "\n"); len(lines) > 5 {
+			msg = strings.Join(lines[:5], "\n")
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return errors.New(msg)
+	}
+	return nil
+}
+
+type buildJob struct {
+	tool string
+	t    target
+}
+
+type buildFailure struct {
+	job buildJob
+	err error
+}
+
+// buildAll builds every tool for every target into work/stage/<os>/<arch>/
+// with jobs builds at a time and returns the failed ones
+func buildAll(goBin, work string, tools []string, targets []target, jobs int) []buildFailure {
+	queue := make(chan buildJob)
+	var (
+		mu       sync.Mutex
+		failures []buildFailure
+		done     int
+		wg       sync.WaitGroup
+	)
+	total := len(tools) * len(targets)
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				start := time.Now()
+				out := filepath.Join(work, "stage", osLabel(job.t.goos), job.t.goarch, exeName(job.tool, job.t.goos))
+				err := buildTool(goBin, work, job.tool, job.t, out)
+				mu.Lock()
+				done++
+				if err != nil {
+					failures = append(failures, buildFailure{job: job, err: err})
+					recordAction("build", job.t.String()+"/"+job.tool, "failed", err.Error(), time.Since(start))
+				} else {
+					recordAction("build", job.t.String()+"/"+job.tool, "ok", "", time.Since(start))
+				}
+				if !plainMode {
+					fmt.Printf(tr("\r  Building %d/%d..."), done, total)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, t := range targets {
+		for _, tool := range tools {
+			queue <- buildJob{tool: tool, t: t}
+		}
+	}
+	close(queue)
+	wg.Wait()
+	if !plainMode {
+		fmt.Print("\r" + strings.Repeat(" ", 40) + "\r")
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		a, b := failures[i].job, failures[j].job
+		if a.t.String() != b.t.String() {
+			return a.t.String() < b.t.String()
+		}
+		return a.tool < b.tool
+	})
+	return failures
+}
+
+// ============================================================
+// Packaging
+// ============================================================
+
+func sha256File(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// writeSums writes name -> hash pairs in sha256sum's format, sorted by name
+func writeSums(path string, sums map[string]string) error {
+	names := make([]string, 0, len(sums))
+	for name := range sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s  %s\n", sums[name], name)
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// parseSums reads a sha256sum manifest into name -> hash
+func parseSums(data []byte) (map[string]string, error) {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 || len(fields[0]) != sha256.Size*2 {
+			return nil, fmt.Errorf("line %d is not '<sha256>  <file>'", line)
+		}
+		sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return sums, scanner.Err()
+}
+
+// packageOS writes the manifest and VERSION into stage/<os>/ and zips the
+// folder as <releaseName>-<version>-<os>.zip. Entries are sorted and dated with
+// the build date so the zip is reproducible.
+func packageOS(stageDir, zipPath, rootName string, info buildInfo) error {
+	sums := make(map[string]string)
+	err := filepath.WalkDir(stageDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		hash, _, err := sha256File(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(stageDir, p)
+		sums[filepath.ToSlash(rel)] = hash
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeSums(filepath.Join(stageDir, sumsFileName), sums); err != nil {
+		return err
+	}
+	version := fmt.Sprintf("%s %s\ncommit %s\ndate %s\n", releaseName, info.Version, info.Commit, info.Date.Format(time.RFC3339))
+	if err := os.WriteFile(filepath.Join(stageDir, "VERSION"), []byte(version), 0644); err != nil {
+		return err
+	}
+
+	var names []string
+	filepath.WalkDir(stageDir, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, _ := filepath.Rel(stageDir, p)
+			names = append(names, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(names)
+
+	f, err := os.Create(zipPath)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(f)
+	for _, name := range names {
+		header := &zip.FileHeader{Name: rootName + "/" + name, Method: zip.Deflate, Modified: info.Date}
+		mode := os.FileMode(0644)
+		if strings.Contains(name, "/") {
+			mode = 0755 // the binaries in the architecture folders
+		}
+		header.SetMode(mode)
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			zw.Close()
+			f.Close()
+			return err
+		}
+		data, err := os.ReadFile(filepath.Join(stageDir, filepath.FromSlash(name)))
+		if err == nil {
+			_, err = w.Write(data)
+		}
+		if err != nil {
+			zw.Close()
+			f.Close()
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ============================================================
+// Install Scripts
+// ============================================================
+
+// The scripts find the zip for this machine next to them, check it against
+// SHA256SUMS, then check every binary against the manifest inside the zip
+// before copying it, so a damaged or altered download never gets installed
+const installShTemplate = `#!/bin/sh
+# Installs UnityStarter tools {{VERSION}} from the zip next to this script.
+# Usage: sh install.sh [folder]    (default: $HOME/.unitystarter/tools)
+set -eu
+
+VERSION='{{VERSION}}'
+HERE=$(cd "$(dirname "$0")" && pwd)
+DEST=${1:-"$HOME/.unitystarter/tools"}
+
+case "$(uname -s)" in
+    Darwin) OS=macos ;;
+    Linux) OS=linux ;;
+    *) echo "Unsupported system: $(uname -s); use install.ps1 on Windows" >&2; exit 1 ;;
+esac
+case "$(uname -m)" in
+    x86_64 | amd64) ARCH=amd64 ;;
+    arm64 | aarch64) ARCH=arm64 ;;
+    *) echo "Unsupported architecture: $(uname -m)" >&2; exit 1 ;;
+esac
+
+NAME="{{NAME}}-$VERSION-$OS"
+ZIP="$HERE/$NAME.zip"
+[ -f "$ZIP" ] || { echo "Missing $NAME.zip next to this script" >&2; exit 1; }
+
+sha256() {
+    if command -v sha256sum >/dev/null 2>&1; then
+        sha256sum "$1" | cut -d ' ' -f 1
+    else
+        shasum -a 256 "$1" | cut -d ' ' -f 1
+    fi
+}
+
+WANT=$(awk -v f="$NAME.zip" '$2 == f { print $1 }' "$HERE/{{SUMS}}")
+if [ -z "$WANT" ] || [ "$WANT" != "$(sha256 "$ZIP")" ]; then
+    echo "Checksum mismatch: $NAME.zip" >&2
+    exit 1
+fi
+
+TMP=$(mktemp -d)
+trap 'rm -rf "$TMP"' EXIT
+if command -v unzip >/dev/null 2>&1; then
+    unzip -q "$ZIP" -d "$TMP"
+else
+    tar -xf "$ZIP" -C "$TMP"
+fi
+
+mkdir -p "$DEST"
+COUNT=0
+while read -r SUM FILE; do
+    case "$FILE" in "$ARCH"/*) ;; *) continue ;; esac
+    if [ "$SUM" != "$(sha256 "$TMP/$NAME/$FILE")" ]; then
+        echo "Checksum mismatch: $FILE" >&2
+        exit 1
+    fi
+    cp "$TMP/$NAME/$FILE" "$DEST/"
+    chmod 755 "$DEST/${FILE#*/}"
+    COUNT=$((COUNT + 1))
+done < "$TMP/$NAME/{{SUMS}}"
+
+echo "Installed $COUNT tools ($VERSION, $OS/$ARCH) to $DEST"
+case ":$PATH:" in
+    *":$DEST:"*) ;;
+    *) echo "Add it to PATH: export PATH=\"$DEST:\$PATH\"" ;;
+esac
+`
+
+const installPs1Template = `# Installs UnityStarter tools {{VERSION}} from the zip next to this script.
+# Usage: powershell -ExecutionPolicy Bypass -File install.ps1 [-Destination <folder>]
+#        (default: %LOCALAPPDATA%\UnityStarter\Tools)
+param([string]$Destination = (Join-Path $env:LOCALAPPDATA 'UnityStarter\Tools'))
+$ErrorActionPreference = 'Stop'
+
+$Version = '{{VERSION}}'
+$Name = "{{NAME}}-$Version-windows"
+$Zip = Join-Path $PSScriptRoot "$Name.zip"
+$Arch = if ($env:PROCESSOR_ARCHITECTURE -eq 'ARM64' -or $env:PROCESSOR_ARCHITEW6432 -eq 'ARM64') { 'arm64' } else { 'amd64' }
+
+function Get-Sha256([string]$Path) {
+    (Get-FileHash -Algorithm SHA256 -LiteralPath $Path).Hash.ToLowerInvariant()
+}
+
+function Read-Sums([string]$Path) {
+    $sums = [ordered]@{}
+    foreach ($line in Get-Content -LiteralPath $Path) {
+        $parts = $line.Trim() -split '\s+', 2
+        if ($parts.Count -eq 2) { $sums[$parts[1]] = $parts[0].ToLowerInvariant() }
+    }
+    $sums
+}
+
+if (-not (Test-Path -LiteralPath $Zip)) { throw "Missing $Name.zip next to this script" }
+$want = (Read-Sums (Join-Path $PSScriptRoot '{{SUMS}}'))["$Name.zip"]
+if (-not $want -or $want -ne (Get-Sha256 $Zip)) { throw "Checksum mismatch: $Name.zip" }
+
+$tmp = Join-Path ([IO.Path]::GetTempPath()) ([Guid]::NewGuid().ToString())
+try {
+    Expand-Archive -LiteralPath $Zip -DestinationPath $tmp
+    $root = Join-Path $tmp $Name
+    New-Item -ItemType Directory -Force -Path $Destination | Out-Null
+    $count = 0
+    $sums = Read-Sums (Join-Path $root '{{SUMS}}')
+    foreach ($file in $sums.Keys) {
+        if (-not $file.StartsWith("$Arch/")) { continue }
+        $path = Join-Path $root $file
+        if ($sums[$file] -ne (Get-Sha256 $path)) { throw "Checksum mismatch: $file" }
+        Copy-Item -LiteralPath $path -Destination $Destination -Force
+        $count++
+    }
+}
+finally {
+    Remove-Item -LiteralPath $tmp -Recurse -Force -ErrorAction SilentlyContinue
+}
+
+Write-Host "Installed $count tools ($Version, windows/$Arch) to $Destination"
+if (-not (($env:Path -split ';') -contains $Destination)) {
+    Write-Host "Add it to PATH: System Properties > Environment Variables > Path > New > $Destination"
+}
+`
+
+func installScript(template string, info buildInfo) string {
+	return strings.NewReplacer("{{VERSION}}", info.Version, "{{NAME}}", releaseName, "{{SUMS}}", sumsFileName).Replace(template)
+}
+
+// ============================================================
+// Verify
+// ============================================================
+
+// verifyZip checks every file in a release zip against the SHA256SUMS inside
+// it. Files the manifest does not list are problems too. Returns the number of
+// files checked.
+func verifyZip(zipPath string) (int, []string, error) {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer zr.Close()
+
+	var root string
+	var sums map[string]string
+	for _, f := range zr.File {
+		if path.Base(f.Name) == sumsFileName && strings.Count(f.Name, "/") == 1 {
+			rc, err := f.Open()
+			if err != nil {
+				return 0, nil, err
+			}
+			data, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return 0, nil, err
+			}
+			if sums, err = parseSums(data); err != nil {
+				return 0, nil, fmt.Errorf("%s: %v", f.Name, err)
+			}
+			root = path.Dir(f.Name) + "/"
+			break
+		}
+	}
+	if sums == nil {
+		return 0, nil, fmt.Errorf("no %s in the zip", sumsFileName)
+	}
+
+	var problems []string
+	seen := make(map[string]bool)
+	checked := 0
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name := strings.TrimPrefix(f.Name, root)
+		if name == sumsFileName || name == "VERSION" {
+			continue
+		}
+		want, ok := sums[name]
+		if !strings.HasPrefix(f.Name, root) || !ok {
+			problems = append(problems, fmt.Sprintf(tr("not in the manifest: %s"), f.Name))
+			continue
+		}
+		seen[name] = true
+		rc, err := f.Open()
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", f.Name, err))
+			continue
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, rc)
+		rc.Close()
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", f.Name, err))
+			continue
+		}
+		checked++
+		if hex.EncodeToString(h.Sum(nil)) != want {
+			problems = append(problems, fmt.Sprintf(tr("checksum mismatch: %s"), f.Name))
+		}
+	}
+	for name := range sums {
+		if !seen[name] {
+			problems = append(problems, fmt.Sprintf(tr("missing: %s"), root+name))
+		}
+	}
+	sort.Strings(problems)
+	return checked, problems, nil
+}
+
+// verifyRelease checks the files listed in a release folder's SHA256SUMS, then
+// the contents of each zip among them
+func verifyRelease(dir string) (int, []string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, sumsFileName))
+	if err != nil {
+		return 0, nil, err
+	}
+	sums, err := parseSums(data)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %v", sumsFileName, err)
+	}
+	names := make([]string, 0, len(sums))
+	for name := range sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	checked := 0
+	for _, name := range names {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		hash, _, err := sha256File(p)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf(tr("missing: %s"), name))
+			continue
+		}
+		checked++
+		if hash != sums[name] {
+			problems = append(problems, fmt.Sprintf(tr("checksum mismatch: %s"), name))
+			continue
+		}
+		if strings.HasSuffix(name, ".zip") {
+			n, zipProblems, err := verifyZip(p)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+				continue
+			}
+			checked += n
+			for _, problem := range zipProblems {
+				problems = append(problems, name+": "+problem)
+			}
+		}
+	}
+	return checked, problems, nil
+}
+
+// ============================================================
+// Commands
+// ============================================================
+
+type releaseOptions struct {
+	src, out, version, targets, tools string
+	jobs                              int
+	force                             bool
+}
+
+// checkOutDir makes sure out can take a new release. An existing folder is only
+// replaced with -force, and only when it holds a release (or nothing).
+func checkOutDir(out string, force bool) error {
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	if !force {
+		return fmt.Errorf("%s already exists; pass -force to replace it", out)
+	}
+	if _, err := os.Stat(filepath.Join(out, releaseManifestName)); err != nil {
+		return fmt.Errorf("%s is not empty and holds no %s; refusing to replace it", out, releaseManifestName)
+	}
+	return nil
+}
+
+func runRelease(opts releaseOptions) error {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		return errors.New("the Go toolchain is not on PATH (https://go.dev/dl/)")
+	}
+	src, err := findSourceDir(opts.src)
+	if err != nil {
+		return err
+	}
+	allTools, err := listTools(src)
+	if err != nil {
+		return err
+	}
+	tools, err := selectTools(allTools, opts.tools)
+	if err != nil {
+		return err
+	}
+	targets, err := parseTargets(opts.targets)
+	if err != nil {
+		return err
+	}
+	info, err := resolveBuildInfo(src, opts.version)
+	if err != nil {
+		return err
+	}
+	goVersion := "unknown"
+	if out, err := exec.Command(goBin, "env", "GOVERSION").Output(); err == nil {
+		goVersion = strings.TrimSpace(string(out))
+	}
+	out := opts.out
+	if out == "" {
+		out = filepath.Join(src, "..", "Release", info.Version)
+	}
+
+	printRule("=============================================")
+	fmt.Println(tr("  Unity Toolset Release"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Version:  %s\n"), info.Version)
+	fmt.Printf(tr("  Commit:   %s\n"), info.Commit)
+	fmt.Printf(tr("  Date:     %s\n"), info.Date.Format(time.RFC3339))
+	fmt.Printf(tr("  Go:       %s\n"), goVersion)
+	fmt.Printf(tr("  Sources:  %s (%d tools)\n"), src, len(tools))
+	var targetNames []string
+	for _, t := range targets {
+		targetNames = append(targetNames, t.String())
+	}
+	fmt.Printf(tr("  Targets:  %s\n"), strings.Join(targetNames, ", "))
+	fmt.Printf(tr("  Output:   %s\n"), out)
+	if info.Dirty {
+		fmt.Println(tr("[WARNING] The sources have uncommitted changes; this release cannot be rebuilt from the commit."))
+	}
+
+	if err := checkOutDir(out, opts.force); err != nil {
+		return err
+	}
+	work, err := os.MkdirTemp("", "unity_toolset-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(work)
+	if err := prepareSources(src, work, tools, info); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	start := time.Now()
+	failures := buildAll(goBin, work, tools, targets, opts.jobs)
+	if len(failures) > 0 {
+		for _, f := range failures {
+			fmt.Printf("[ERROR] %s %s:\n", f.job.t, f.job.tool)
+			for _, line := range strings.Split(f.err.Error(), "\n") {
+				fmt.Println("        " + line)
+			}
+			recordError("%s %s: %v", f.job.t, f.job.tool, f.err)
+		}
+		return fmt.Errorf("%d of %d builds failed; no release was written", len(failures), len(tools)*len(targets))
+	}
+	fmt.Printf(tr("[OK] Built %d tool(s) for %d target(s) in %s\n"), len(tools), len(targets), time.Since(start).Round(time.Second))
+
+	// The previous release is only replaced once the new one has built
+	if err := os.RemoveAll(out); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return err
+	}
+
+	// One zip per operating system, holding every architecture built for it
+	sums := make(map[string]string)
+	manifest := releaseManifest{
+		Name: releaseName, Version: info.Version, Commit: info.Commit, Date: info.Date.Format(time.RFC3339),
+		GoVersion: goVersion, Targets: targetNames, Tools: tools, Files: []releaseFile{},
+	}
+	var osNames []string
+	seenOS := make(map[string]bool)
+	for _, t := range targets {
+		if label := osLabel(t.goos); !seenOS[label] {
+			seenOS[label] = true
+			osNames = append(osNames, label)
+		}
+	}
+	sort.Strings(osNames)
+	for _, label := range osNames {
+		rootName := fmt.Sprintf("%s-%s-%s", releaseName, info.Version, label)
+		zipPath := filepath.Join(out, rootName+".zip")
+		if err := packageOS(filepath.Join(work, "stage", label), zipPath, rootName, info); err != nil {
+			recordAction("package", zipPath, "failed", err.Error(), 0)
+			return fmt.Errorf("cannot write %s: %v", zipPath, err)
+		}
+		hash, size, err := sha256File(zipPath)
+		if err != nil {
+			return err
+		}
+		sums[filepath.Base(zipPath)] = hash
+		manifest.Files = append(manifest.Files, releaseFile{Name: filepath.Base(zipPath), Size: size, SHA256: hash})
+		fmt.Printf("[OK] %s (%s)\n", filepath.Base(zipPath), formatSize(size))
+		recordAction("package", zipPath, "ok", hash, 0)
+		recordArtifact(zipPath)
+	}
+
+	scripts := []struct{ name, text string }{
+		{"install.sh", installScript(installShTemplate, info)},
+		{"install.ps1", installScript(installPs1Template, info)},
+	}
+	for _, s := range scripts {
+		p := filepath.Join(out, s.name)
+		if err := os.WriteFile(p, []byte(s.text), 0755); err != nil {
+			return err
+		}
+		hash, size, err := sha256File(p)
+		if err != nil {
+			return err
+		}
+		sums[s.name] = hash
+		manifest.Files = append(manifest.Files, releaseFile{Name: s.name, Size: size, SHA256: hash})
+		recordArtifact(p)
+	}
+	if err := writeSums(filepath.Join(out, sumsFileName), sums); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(out, releaseManifestName), append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	recordArtifact(filepath.Join(out, sumsFileName))
+	recordArtifact(filepath.Join(out, releaseManifestName))
+
+	// Read back what was written, as an installer would
+	checked, problems, err := verifyRelease(out)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("the release does not match its manifests: %s", strings.Join(problems, "; "))
+	}
+	fmt.Printf(tr("[OK] Verified %d files against %s\n"), checked, sumsFileName)
+	fmt.Printf(tr("\nRelease written to %s\n"), out)
+	fmt.Println(tr("Install: sh install.sh (macOS, Linux) or install.ps1 (Windows) from that folder."))
+	return nil
+}
+
+func runVerify(target string) error {
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+	var checked int
+	var problems []string
+	if info.IsDir() {
+		checked, problems, err = verifyRelease(target)
+	} else {
+		checked, problems, err = verifyZip(target)
+	}
+	if err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		fmt.Println("[FAIL] " + problem)
+		recordAction("verify", target, "failed", problem, 0)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problem(s) in %s", len(problems), target)
+	}
+	fmt.Printf(tr("[OK] %d files match their checksums\n"), checked)
+	recordAction("verify", target, "ok", fmt.Sprintf("%d files", checked), 0)
+	return nil
+}
+
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"Usage: unity_toolset [flags] release | verify <release folder or zip>": "用法: unity_toolset [参数] release | verify <发布文件夹或 zip>",
+	"  Unity Toolset Release":     "  Unity 工具集发布",
+	"  Version:  %s\n":            "  版本:     %s\n",
+	"  Commit:   %s\n":            "  提交:     %s\n",
+	"  Date:     %s\n":            "  日期:     %s\n",
+	"  Go:       %s\n":            "  Go 版本:  %s\n",
+	"  Sources:  %s (%d tools)\n": "  源码:     %s（%d 个工具）\n",
+	"  Targets:  %s\n":            "  目标平台: %s\n",
+	"  Output:   %s\n":            "  输出:     %s\n",
+	"[WARNING] The sources have uncommitted changes; this release cannot be rebuilt from the commit.": "[WARNING] 源码有未提交的更改；无法从该提交重新构建此发布。",
+	"\r  Building %d/%d...":                          "\r  正在构建 %d/%d...",
+	"[OK] Built %d tool(s) for %d target(s) in %s\n": "[OK] 已为 %[2]d 个目标平台构建 %[1]d 个工具，用时 %[3]s\n",
+	"[OK] Verified %d files against %s\n":            "[OK] 已按 %[2]s 校验 %[1]d 个文件\n",
+	"\nRelease written to %s\n":                      "\n发布已写入 %s\n",
+	"Install: sh install.sh (macOS, Linux) or install.ps1 (Windows) from that folder.": "安装：在该文件夹中运行 sh install.sh（macOS、Linux）或 install.ps1（Windows）。",
+	"[OK] %d files match their checksums\n":                                            "[OK] %d 个文件与其校验和一致\n",
+	"checksum mismatch: %s":                                                            "校验和不一致: %s",
+	"not in the manifest: %s":                                                          "不在清单中: %s",
+	"missing: %s":                                                                      "缺失: %s",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var opts releaseOptions
+
+	flag.StringVar(&opts.version, "version", "", "Release version (default: git describe of the sources, else \"dev\")")
+	flag.StringVar(&opts.out, "out", "", "Release folder (default: Tools/Release/<version>)")
+	flag.StringVar(&opts.targets, "targets", defaultTargets, "Comma-separated goos/goarch pairs to build")
+	flag.StringVar(&opts.tools, "tools", "", "Comma-separated tools to include (default: every tool in the sources)")
+	flag.StringVar(&opts.src, "src", "", "Folder with the tool sources (default: Tools/Scripts, found from the current folder)")
+	flag.IntVar(&opts.jobs, "jobs", runtime.NumCPU(), "Builds to run at the same time")
+	flag.BoolVar(&opts.force, "force", false, "Replace an existing release folder")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the command ("release -version 1.4.0")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_toolset")
+	}
+
+	command := ""
+	if len(args) > 0 {
+		command = strings.ToLower(args[0])
+	}
+	var err error
+	switch {
+	case command == "release" && len(args) == 1:
+		if opts.jobs < 1 {
+			opts.jobs = 1
+		}
+		err = runRelease(opts)
+	case command == "verify" && len(args) == 2:
+		err = runVerify(args[1])
+	default:
+		fmt.Println(tr("Usage: unity_toolset [flags] release | verify <release folder or zip>"))
+		recordError("expected release or verify <path>")
+		exitTool(2)
+	}
+	if err != nil {
+		fmt.Printf(tr("[ERROR] %v\n"), err)
+		recordError("%v", err)
+		exitTool(1)
+	}
+}
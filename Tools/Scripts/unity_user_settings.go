@@ -0,0 +1,1306 @@
+// Unity UserSettings Manager — Keep per-developer editor state out of the repository.
+// UserSettings/ holds what each developer sets up for themselves: window layouts,
+// scene view and search settings, the editor's per-project preferences. It belongs
+// to one person and must never be committed, yet is lost with every fresh clone.
+// This tool saves it to a store outside the project and restores it, checks that
+// Git, Plastic SCM or Perforce ignores the folder and that nothing in it is tracked,
+// and adds the missing ignore rule with "fix".
+//
+// Build: go build unity_user_settings.go
+//
+// Usage: run from the Unity project root.
+//
+//	unity_user_settings                          # status: ignore rule, tracked files, snapshots
+//	unity_user_settings save                     # save UserSettings/ as the "default" snapshot
+//	unity_user_settings save art-layout          # save a named snapshot
+//	unity_user_settings restore art-layout       # replace UserSettings/ with a snapshot
+//	unity_user_settings clean                    # save, then delete UserSettings/
+//	unity_user_settings fix                      # add the ignore rule, untrack committed files
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+const (
+	userSettingsDir = "UserSettings"
+
+	// Overrides the snapshot store (default: <user config dir>/UnityStarter/UserSettings)
+	storeEnvVar = "UNITYSTARTER_USERSETTINGS_DIR"
+
+	// Snapshot used when save/restore get no name
+	defaultSnapshot = "default"
+
+	// restore and clean keep what they replace under these names
+	beforeRestoreSnapshot = "before-restore"
+	beforeCleanSnapshot   = "before-clean"
+
+	snapshotInfoFile = "snapshot.json"
+)
+
+// Ignore rules written by fix. Git's is the one from the Unity .gitignore template.
+const (
+	gitIgnoreRule     = "/[Uu]ser[Ss]ettings/"
+	perforceIgnoreDir = "UserSettings/"
+)
+
+var (
+	snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	projectVersionRegex = regexp.MustCompile(`(?m)^m_EditorVersion:\s*(\S+)`)
+	// [Uu]-style character classes, folded before rules are compared
+	caseClassPattern = regexp.MustCompile(`\[([A-Za-z])([A-Za-z])\]`)
+)
+
+// Global stdin reader to avoid multiple buffered readers competing for stdin
+var stdinReader *bufio.Reader
+
+func init() {
+	stdinReader = bufio.NewReader(os.Stdin)
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+// EditorInstance represents the structure of Library/EditorInstance.json
+type EditorInstance struct {
+	ProcessID int `json:"process_id"`
+}
+
+// snapshotInfo is stored next to each saved copy of UserSettings/
+type snapshotInfo struct {
+	Name         string `json:"name"`
+	Project      string `json:"project"`
+	UnityVersion string `json:"unityVersion"`
+	Host         string `json:"host"`
+	SavedAt      string `json:"savedAt"`
+	Files        int    `json:"files"`
+	Bytes        int64  `json:"bytes"`
+}
+
+// ignoreCheck is what the version control setup says about UserSettings/
+type ignoreCheck struct {
+	system   string // "git", "plastic", "p4" or "" without version control
+	file     string // ignore file the rule belongs in
+	ignored  bool
+	tracked  []string // files under UserSettings/ under version control
+	checked  bool     // false when tracked files could not be listed
+	problems []string
+}
+
+// ============================================================
+// Unity Project Validation
+// ============================================================
+
+// isUnityProject checks if the given path contains a Unity project structure
+func isUnityProject(basePath string) bool {
+	markers := []string{"Assets", "ProjectSettings"}
+	for _, marker := range markers {
+		info, err := os.Stat(filepath.Join(basePath, marker))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// checkUnityRunning checks if Unity Editor is running for this project
+// via Library/EditorInstance.json and a liveness check on the recorded PID.
+func checkUnityRunning(basePath string) (bool, int) {
+	data, err := os.ReadFile(filepath.Join(basePath, "Library", "EditorInstance.json"))
+	if err != nil {
+		return false, 0
+	}
+	var instance EditorInstance
+	if err := json.Unmarshal(data, &instance); err != nil || instance.ProcessID <= 0 {
+		return false, 0
+	}
+	if isProcessRunning(instance.ProcessID) {
+		return true, instance.ProcessID
+	}
+	return false, 0
+}
+
+// isProcessRunning checks if a process with the given PID is alive.
+func isProcessRunning(pid int) bool {
+	if runtime.GOOS == "windows" {
+		output, err := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV").Output()
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(output), strconv.Itoa(pid))
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// readUnityVersion returns the editor version from ProjectSettings/ProjectVersion.txt
+func readUnityVersion(basePath string) string {
+	data, err := os.ReadFile(filepath.Join(basePath, "ProjectSettings", "ProjectVersion.txt"))
+	if err != nil {
+		return ""
+	}
+	if m := projectVersionRegex.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// ============================================================
+// Snapshots
+// ============================================================
+
+// storeDir is the folder holding this project's snapshots. The key defaults to the
+// project folder name, so a fresh clone in a folder of the same name finds them.
+func storeDir(key string) (string, error) {
+	root := os.Getenv(storeEnvVar)
+	if root == "" {
+		config, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot find the user config folder (set %s): %v", storeEnvVar, err)
+		}
+		root = filepath.Join(config, "UnityStarter", "UserSettings")
+	}
+	return filepath.Join(root, key), nil
+}
+
+// listSnapshots returns the saved snapshots, newest first
+func listSnapshots(store string) []*snapshotInfo {
+	entries, err := os.ReadDir(store)
+	if err != nil {
+		return nil
+	}
+	var list []*snapshotInfo
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info := &snapshotInfo{Name: e.Name()}
+		if data, err := os.ReadFile(filepath.Join(store, e.Name(), snapshotInfoFile)); err == nil {
+			json.Unmarshal(data, info)
+			info.Name = e.Name()
+		}
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].SavedAt > list[j].SavedAt })
+	return list
+}
+
+// saveSnapshot copies UserSettings/ into the store. The copy is made next to the
+// snapshot and swapped in at the end, so a failed save keeps the previous one.
+func saveSnapshot(basePath, store, name string) (*snapshotInfo, error) {
+	if err := os.MkdirAll(store, 0755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp(store, "."+name+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	files, size, err := copyTree(filepath.Join(basePath, userSettingsDir), filepath.Join(tmp, userSettingsDir))
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	info := &snapshotInfo{
+		Name:         name,
+		Project:      basePath,
+		UnityVersion: readUnityVersion(basePath),
+		Host:         host,
+		SavedAt:      time.Now().Format(time.RFC3339),
+		Files:        files,
+		Bytes:        size,
+	}
+	data, _ := json.MarshalIndent(info, "", "  ")
+	if err := os.WriteFile(filepath.Join(tmp, snapshotInfoFile), append(data, '\n'), 0644); err != nil {
+		return nil, err
+	}
+
+	dest := filepath.Join(store, name)
+	old := dest + ".old"
+	os.RemoveAll(old)
+	if _, err := os.Stat(dest); err == nil {
+		if err := os.Rename(dest, old); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Rename(old, dest)
+		return nil, err
+	}
+	os.RemoveAll(old)
+	return info, nil
+}
+
+// restoreSnapshot replaces UserSettings/ with the snapshot; the snapshot is copied
+// into the project first so a failed copy leaves the current folder in place
+func restoreSnapshot(basePath, snapshotDir string) (int, error) {
+	target := filepath.Join(basePath, userSettingsDir)
+	tmp := target + ".restore-tmp"
+	os.RemoveAll(tmp)
+	files, _, err := copyTree(filepath.Join(snapshotDir, userSettingsDir), tmp)
+	if err != nil {
+		os.RemoveAll(tmp)
+		return 0, err
+	}
+	if err := os.RemoveAll(target); err != nil {
+		os.RemoveAll(tmp)
+		return 0, err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		return 0, err
+	}
+	return files, nil
+}
+
+// copyTree copies a folder, keeping modification times; a missing source copies nothing
+func copyTree(src, dst string) (int, int64, error) {
+	files := 0
+	var size int64
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return 0, 0, os.MkdirAll(dst, 0755)
+	}
+	err := filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, p)
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if err := copyFile(p, target, info); err != nil {
+			return err
+		}
+		files++
+		size += info.Size()
+		return nil
+	})
+	return files, size, err
+}
+
+func copyFile(src, dst string, info os.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// measureDir counts the files under dir and their total size
+func measureDir(dir string) (int, int64) {
+	files := 0
+	var size int64
+	filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			files++
+			size += info.Size()
+		}
+		return nil
+	})
+	return files, size
+}
+
+// ============================================================
+// Ignore Rules
+// ============================================================
+
+// gitRoot returns the work tree the project is in, or "" outside Git
+func gitRoot(basePath string) string {
+	out, err := exec.Command("git", "-C", basePath, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return ""
+	}
+	return filepath.Clean(strings.TrimSpace(string(out)))
+}
+
+// plasticRoot returns the Plastic SCM workspace root (the folder holding .plastic)
+func plasticRoot(basePath string) string {
+	for dir := basePath; ; dir = filepath.Dir(dir) {
+		if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return ""
+		}
+	}
+}
+
+// ruleCovers reports whether an ignore file has a rule for UserSettings/ at the
+// project root. Rules are compared after folding [Uu]-style classes and case; a
+// negated rule for the folder counts as not covered.
+func ruleCovers(path string, accepted []string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	covered := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		negated := strings.HasPrefix(line, "!")
+		line = caseClassPattern.ReplaceAllStringFunc(strings.TrimPrefix(line, "!"), func(m string) string {
+			sub := caseClassPattern.FindStringSubmatch(m)
+			if strings.EqualFold(sub[1], sub[2]) {
+				return strings.ToLower(sub[1])
+			}
+			return m
+		})
+		line = strings.ToLower(strings.TrimSuffix(strings.TrimSuffix(line, "/..."), "/"))
+		for _, a := range accepted {
+			if line == a {
+				covered = !negated
+			}
+		}
+	}
+	return covered
+}
+
+// checkIgnore looks at the version control the project is in. Git answers itself
+// (check-ignore, ls-files); for Plastic SCM and Perforce the ignore file is read.
+func checkIgnore(basePath string, vcs *vcsClient) *ignoreCheck {
+	if root := gitRoot(basePath); root != "" {
+		c := &ignoreCheck{system: "git", file: filepath.Join(basePath, ".gitignore"), checked: true}
+		probe := userSettingsDir + "/EditorUserSettings.asset"
+		c.ignored = exec.Command("git", "-C", basePath, "check-ignore", "-q", "--no-index", probe).Run() == nil
+		if out, err := exec.Command("git", "-C", basePath, "ls-files", "--", userSettingsDir).Output(); err == nil {
+			for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+				if line != "" {
+					c.tracked = append(c.tracked, line)
+				}
+			}
+		} else {
+			c.checked = false
+		}
+		return c
+	}
+
+	rel := func(root string) string {
+		r, _ := filepath.Rel(root, basePath)
+		if r == "." {
+			return ""
+		}
+		return strings.ToLower(filepath.ToSlash(r)) + "/"
+	}
+	switch vcs.kind {
+	case vcsPlastic:
+		root := plasticRoot(basePath)
+		if root == "" {
+			root = basePath
+		}
+		c := &ignoreCheck{system: "plastic", file: filepath.Join(root, "ignore.conf")}
+		c.ignored = ruleCovers(c.file, []string{"/" + rel(root) + "usersettings"})
+		return c
+	case vcsPerforce:
+		name := os.Getenv("P4IGNORE")
+		if name == "" {
+			c := &ignoreCheck{system: "p4", file: filepath.Join(basePath, ".p4ignore")}
+			c.problems = append(c.problems, "P4IGNORE is not set, so Perforce reads no ignore file (p4 set P4IGNORE=.p4ignore)")
+			return c
+		}
+		c := &ignoreCheck{system: "p4", file: filepath.Join(basePath, filepath.Base(name))}
+		c.ignored = ruleCovers(c.file, []string{"usersettings", "/usersettings"})
+		return c
+	}
+	return &ignoreCheck{}
+}
+
+// ignoreRule is the line fix adds to the ignore file
+func (c *ignoreCheck) ignoreRule(basePath string) string {
+	switch c.system {
+	case "plastic":
+		r, _ := filepath.Rel(filepath.Dir(c.file), basePath)
+		if r == "." {
+			return "/" + userSettingsDir
+		}
+		return "/" + filepath.ToSlash(r) + "/" + userSettingsDir
+	case "p4":
+		return perforceIgnoreDir
+	}
+	return gitIgnoreRule
+}
+
+// appendRule adds the rule at the end of the ignore file, keeping its line endings
+func appendRule(path, rule string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	eol := "\n"
+	if strings.Contains(string(data), "\r\n") {
+		eol = "\r\n"
+	}
+	text := string(data)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += eol
+	}
+	text += "# Per-developer editor settings (unity_user_settings save/restore)" + eol + rule + eol
+	activeVCS.checkout(path)
+	return os.WriteFile(path, []byte(text), 0644)
+}
+
+// untrack removes UserSettings/ from Git's index and leaves the files on disk;
+// the removal is staged for the next commit
+func untrack(basePath string) error {
+	out, err := exec.Command("git", "-C", basePath, "rm", "-r", "-q", "--cached", "--", userSettingsDir).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git rm --cached: %v %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// ============================================================
+// Report
+// ============================================================
+
+// printCheck reports the ignore rule and tracked files and returns the number of problems
+func printCheck(c *ignoreCheck) int {
+	problems := 0
+	fmt.Printf(tr("  Version control: %s (%s)\n"), c.system, c.file)
+	for _, p := range c.problems {
+		fmt.Printf(tr("  [FAIL] %s\n"), p)
+		recordAction("check", userSettingsDir, "failed", p, 0)
+		recordError("%s", p)
+		problems++
+	}
+	if len(c.problems) == 0 {
+		if c.ignored {
+			fmt.Println(tr("  [OK]   UserSettings/ is ignored"))
+			recordAction("check", c.file, "ok", "UserSettings/ is ignored", 0)
+		} else {
+			fmt.Println(tr("  [FAIL] UserSettings/ is not ignored"))
+			recordAction("check", c.file, "failed", "UserSettings/ is not ignored", 0)
+			recordError("UserSettings/ is not ignored by %s", c.file)
+			problems++
+		}
+	}
+	switch {
+	case !c.checked:
+		fmt.Printf(tr("  [--]   Tracked files are not checked for %s\n"), c.system)
+	case len(c.tracked) > 0:
+		fmt.Printf(tr("  [FAIL] %d file(s) under UserSettings/ are committed:\n"), len(c.tracked))
+		for i, f := range c.tracked {
+			if i == 10 {
+				fmt.Printf(tr("         ... and %d more\n"), len(c.tracked)-10)
+				break
+			}
+			fmt.Printf("         %s\n", f)
+		}
+		recordAction("check", userSettingsDir, "failed", fmt.Sprintf("%d tracked files", len(c.tracked)), 0)
+		recordError("%d file(s) under UserSettings/ are committed", len(c.tracked))
+		problems++
+	default:
+		fmt.Println(tr("  [OK]   No files under UserSettings/ are committed"))
+	}
+	return problems
+}
+
+// printSnapshots lists the saved snapshots of this project
+func printSnapshots(store string) {
+	list := listSnapshots(store)
+	fmt.Println(tr("\nSNAPSHOTS"))
+	if len(list) == 0 {
+		fmt.Println(tr("  None yet; \"unity_user_settings save\" creates one."))
+		return
+	}
+	for _, s := range list {
+		saved := s.SavedAt
+		if t, err := time.Parse(time.RFC3339, s.SavedAt); err == nil {
+			saved = t.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Printf("  %-20s %-16s %6d  %10s  %s\n", s.Name, saved, s.Files, formatSize(s.Bytes), s.Host)
+	}
+}
+
+// ============================================================
+// Version Control Checkout
+// ============================================================
+
+// Perforce and Plastic SCM keep files read-only until they are checked out. Writing
+// them directly (even after clearing the flag) leaves changes the server does not know
+// about, so files are opened for edit first. Git and plain folders need nothing.
+const (
+	vcsNone     = "none"
+	vcsPerforce = "p4"
+	vcsPlastic  = "plastic"
+)
+
+// vcsEnvVar overrides detection for every tool (e.g. on build agents)
+const vcsEnvVar = "UNITYSTARTER_VCS"
+
+// vcsClient issues checkouts for files about to be modified
+type vcsClient struct {
+	kind       string
+	source     string // how kind was chosen, for the startup message
+	checkedOut map[string]bool
+}
+
+// activeVCS is set up in main; nil means no checkouts
+var activeVCS *vcsClient
+
+// detectVCS picks the checkout provider. Priority: -vcs flag > UNITYSTARTER_VCS >
+// Unity's VersionControlSettings.asset mode > workspace markers (.plastic, P4CONFIG).
+func detectVCS(projectRoot, override string) (*vcsClient, error) {
+	normalize := func(v string) (string, bool) {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "p4", "perforce":
+			return vcsPerforce, true
+		case "plastic", "plasticscm", "cm", "unityvcs":
+			return vcsPlastic, true
+		case "none", "git", "off":
+			return vcsNone, true
+		}
+		return "", false
+	}
+	newClient := func(kind, source string) *vcsClient {
+		return &vcsClient{kind: kind, source: source, checkedOut: make(map[string]bool)}
+	}
+
+	if override != "" && override != "auto" {
+		kind, ok := normalize(override)
+		if !ok {
+			return nil, fmt.Errorf("unknown -vcs value '%s' (use auto, none, p4 or plastic)", override)
+		}
+		return newClient(kind, "-vcs flag"), nil
+	}
+	if env := os.Getenv(vcsEnvVar); env != "" {
+		if kind, ok := normalize(env); ok {
+			return newClient(kind, vcsEnvVar), nil
+		}
+	}
+
+	// Unity stores the project's integrated VCS in m_Mode ("Perforce", "PlasticSCM", ...)
+	if data, err := os.ReadFile(filepath.Join(projectRoot, "ProjectSettings", "VersionControlSettings.asset")); err == nil {
+		if m := regexp.MustCompile(`(?m)^\s*m_Mode:\s*(.+?)\s*$`).FindSubmatch(data); m != nil {
+			switch strings.ToLower(string(m[1])) {
+			case "perforce":
+				return newClient(vcsPerforce, "VersionControlSettings.asset"), nil
+			case "plasticscm", "plastic scm", "unity version control":
+				return newClient(vcsPlastic, "VersionControlSettings.asset"), nil
+			}
+		}
+	}
+
+	// Workspace markers, searched upward from the project root
+	p4config := os.Getenv("P4CONFIG")
+	if abs, err := filepath.Abs(projectRoot); err == nil {
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info != nil {
+				return newClient(vcsNone, "git repository"), nil
+			}
+			if info, err := os.Stat(filepath.Join(dir, ".plastic")); err == nil && info.IsDir() {
+				return newClient(vcsPlastic, ".plastic workspace"), nil
+			}
+			if p4config != "" {
+				if _, err := os.Stat(filepath.Join(dir, p4config)); err == nil {
+					return newClient(vcsPerforce, "P4CONFIG"), nil
+				}
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+	if os.Getenv("P4CLIENT") != "" {
+		return newClient(vcsPerforce, "P4CLIENT"), nil
+	}
+	return newClient(vcsNone, "no Perforce/Plastic workspace found"), nil
+}
+
+// checkout opens an existing file for edit. New files need no checkout, and files
+// outside the depot/workspace only produce a warning: the write still goes ahead.
+func (v *vcsClient) checkout(path string) {
+	if v == nil || v.kind == vcsNone {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || v.checkedOut[abs] {
+		return
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return
+	}
+	v.checkedOut[abs] = true
+
+	var cmd *exec.Cmd
+	switch v.kind {
+	case vcsPerforce:
+		cmd = exec.Command("p4", "edit", abs)
+	case vcsPlastic:
+		cmd = exec.Command("cm", "checkout", abs)
+	default:
+		return
+	}
+	// Run from the file's folder so P4CONFIG / the Plastic workspace is resolved
+	cmd.Dir = filepath.Dir(abs)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		fmt.Printf(tr("[WARNING] %s checkout failed for %s: %v %s\n"), v.kind, filepath.Base(abs), err, strings.TrimSpace(string(out)))
+		return
+	}
+	fmt.Printf(tr("[VCS] Checked out (%s): %s\n"), v.kind, filepath.Base(abs))
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nPress Enter to continue...":                 "\n按回车键继续...",
+	"[WARNING] %s checkout failed for %s: %v %s\n": "[WARNING] %s 签出失败 %s: %v %s\n",
+	"[VCS] Checked out (%s): %s\n":                 "[VCS] 已签出 (%s): %s\n",
+
+	"Usage: unity_user_settings [flags] [status|save [name]|restore [name]|clean|fix]": "用法: unity_user_settings [参数] [status|save [名称]|restore [名称]|clean|fix]",
+	"[ERROR] %v\n": "[ERROR] %v\n",
+	"[ERROR] Cannot get current directory: %v\n":                       "[ERROR] 无法获取当前目录: %v\n",
+	"[ERROR] Current directory does not appear to be a Unity project.": "[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":           "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"  USERSETTINGS MANAGER":                                           "  UserSettings 管理",
+	"  Project:      %s\n":                                             "  项目:         %s\n",
+	"  Store:        %s\n":                                             "  存储位置:     %s\n",
+	"  UserSettings: %d file(s), %s\n":                                 "  UserSettings: %d 个文件，%s\n",
+	"[ERROR] Unity Editor is running (PID: %d). Close it first; the editor writes UserSettings/ again when it quits.\n": "[ERROR] Unity 编辑器正在运行 (PID: %d)。请先关闭编辑器；编辑器退出时会重新写入 UserSettings/。\n",
+	"  Would save the current UserSettings/ as '%s'\n":                                                                  "  将把当前的 UserSettings/ 保存为 '%s'\n",
+	"[OK]   Saved the current UserSettings/ as '%s'\n":                                                                  "[OK]   已将当前的 UserSettings/ 保存为 '%s'\n",
+	"[--] UserSettings/ is empty or missing; nothing to save.":                                                          "[--] UserSettings/ 为空或不存在；没有需要保存的内容。",
+	"  Would save %d file(s) as '%s'\n":                                                                                 "  将把 %d 个文件保存为 '%s'\n",
+	"\n[Dry Run] Nothing was saved.":                                                                                    "\n[Dry Run] 未保存任何内容。",
+	"[OK]   Saved %d file(s) (%s) as '%s'\n":                                                                            "[OK]   已将 %d 个文件 (%s) 保存为 '%s'\n",
+	"  Restoring '%s': %d file(s), %s\n":                                                                                "  正在恢复 '%s': %d 个文件，%s\n",
+	"\n[Dry Run] UserSettings/ was not changed.":                                                                        "\n[Dry Run] 未修改 UserSettings/。",
+	"[OK]   Restored %d file(s) from '%s'\n":                                                                            "[OK]   已从 '%[2]s' 恢复 %[1]d 个文件\n",
+	"[--] There is no UserSettings/ folder.":                                                                            "[--] 没有 UserSettings/ 文件夹。",
+	"\n[Dry Run] UserSettings/ was not deleted.":                                                                        "\n[Dry Run] 未删除 UserSettings/。",
+	"[OK]   Deleted UserSettings/; Unity creates it again with default settings.":                                       "[OK]   已删除 UserSettings/；Unity 会以默认设置重新创建。",
+	"[--] The project is not under Git, Plastic SCM or Perforce; nothing to check.":                                     "[--] 项目不在 Git、Plastic SCM 或 Perforce 的管理下；无需检查。",
+	"  Would add '%s' to %s\n":                                                                                          "  将在 %[2]s 中添加 '%[1]s'\n",
+	"[OK]   Added '%s' to %s\n":                                                                                         "[OK]   已在 %[2]s 中添加 '%[1]s'\n",
+	"  Would stop tracking %d file(s) under UserSettings/ (git rm --cached)\n":                                          "  将停止跟踪 UserSettings/ 下的 %d 个文件 (git rm --cached)\n",
+	"[OK]   Stopped tracking %d file(s) under UserSettings/; commit the removal, the files stay on disk\n":              "[OK]   已停止跟踪 UserSettings/ 下的 %d 个文件；请提交此删除，文件仍保留在磁盘上\n",
+	"\n[Dry Run] Nothing was changed.":                                                                                  "\n[Dry Run] 未做任何修改。",
+	"\n[TIP] Run \"unity_user_settings fix\" to add the ignore rule and untrack committed files.":                       "\n[TIP] 运行 \"unity_user_settings fix\" 添加忽略规则并取消跟踪已提交的文件。",
+	"  Version control: %s (%s)\n":                                                                                      "  版本控制: %s (%s)\n",
+	"  [FAIL] %s\n":                                                                                                     "  [FAIL] %s\n",
+	"  [OK]   UserSettings/ is ignored":                                                                                 "  [OK]   UserSettings/ 已被忽略",
+	"  [FAIL] UserSettings/ is not ignored":                                                                             "  [FAIL] UserSettings/ 未被忽略",
+	"  [--]   Tracked files are not checked for %s\n":                                                                   "  [--]   未检查 %s 中已跟踪的文件\n",
+	"  [FAIL] %d file(s) under UserSettings/ are committed:\n":                                                          "  [FAIL] UserSettings/ 下有 %d 个文件已被提交:\n",
+	"         ... and %d more\n":                                                                                        "         ... 还有 %d 个\n",
+	"  [OK]   No files under UserSettings/ are committed":                                                               "  [OK]   UserSettings/ 下没有已提交的文件",
+	"\nSNAPSHOTS": "\n快照",
+	"  None yet; \"unity_user_settings save\" creates one.": "  暂无；\"unity_user_settings save\" 可创建快照。",
+
+	"[WARNING] Removing a stale %s (%s)\n":                      "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n": "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var ciMode, dryRun, force bool
+	var keyFlag, vcsMode string
+
+	flag.StringVar(&keyFlag, "key", "", "Store key of this project (default: the project folder name)")
+	flag.StringVar(&vcsMode, "vcs", "auto", "Version control to check when the project is not in Git: auto, none, p4, plastic")
+	flag.BoolVar(&ciMode, "ci", false, "Non-interactive mode (no key press at the end)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Show what save, restore, clean or fix would do without doing it")
+	flag.BoolVar(&force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+
+	// Flags may also follow the command ("restore art-layout -dry-run")
+	flag.Parse()
+	var args []string
+	for flag.NArg() > 0 {
+		args = append(args, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_user_settings")
+		ciMode = true
+	}
+
+	exit := func(code int) {
+		if !ciMode {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
+	fail := func(code int, format string, a ...interface{}) {
+		fmt.Printf(tr("[ERROR] %v\n"), fmt.Sprintf(format, a...))
+		recordError(format, a...)
+		exit(code)
+	}
+
+	command := "status"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	name := defaultSnapshot
+	valid := false
+	switch command {
+	case "status", "clean", "fix":
+		valid = len(args) <= 1
+	case "save", "restore":
+		valid = len(args) <= 2
+		if len(args) == 2 {
+			name = args[1]
+		}
+	}
+	if !valid {
+		fmt.Println(tr("Usage: unity_user_settings [flags] [status|save [name]|restore [name]|clean|fix]"))
+		recordError("unknown command")
+		exit(2)
+	}
+	if !snapshotNamePattern.MatchString(name) {
+		fail(2, "invalid snapshot name '%s' (letters, numbers, '.', '_' and '-')", name)
+	}
+
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("[ERROR] Cannot get current directory: %v\n"), err)
+		recordError("cannot get current directory: %v", err)
+		exit(1)
+	}
+	if !isUnityProject(basePath) {
+		fmt.Println(tr("[ERROR] Current directory does not appear to be a Unity project."))
+		recordError("current directory does not appear to be a Unity project")
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		exit(1)
+	}
+	if keyFlag == "" {
+		keyFlag = filepath.Base(basePath)
+	}
+	store, err := storeDir(keyFlag)
+	if err != nil {
+		fail(1, "%v", err)
+	}
+	if activeVCS, err = detectVCS(basePath, vcsMode); err != nil {
+		fail(2, "%v", err)
+	}
+	settingsPath := filepath.Join(basePath, userSettingsDir)
+	files, size := measureDir(settingsPath)
+
+	printRule("=============================================")
+	fmt.Println(tr("  USERSETTINGS MANAGER"))
+	printRule("=============================================")
+	fmt.Printf(tr("  Project:      %s\n"), filepath.Base(basePath))
+	fmt.Printf(tr("  Store:        %s\n"), store)
+	fmt.Printf(tr("  UserSettings: %d file(s), %s\n"), files, formatSize(size))
+	fmt.Println()
+
+	// restore and clean replace the folder; fix edits the ignore file
+	if command == "restore" || command == "clean" || command == "fix" {
+		if command != "fix" {
+			if isRunning, pid := checkUnityRunning(basePath); isRunning {
+				fmt.Printf(tr("[ERROR] Unity Editor is running (PID: %d). Close it first; the editor writes UserSettings/ again when it quits.\n"), pid)
+				recordError("Unity Editor is running (PID: %d)", pid)
+				exit(1)
+			}
+		}
+		if !dryRun {
+			if err := acquireProjectLock(basePath, "unity_user_settings", command, force); err != nil {
+				fail(1, "%v", err)
+			}
+			defer releaseProjectLock()
+		}
+	}
+
+	// keepCurrent saves UserSettings/ before restore or clean replaces it
+	keepCurrent := func(snapshot string) {
+		if files == 0 {
+			return
+		}
+		if dryRun {
+			fmt.Printf(tr("  Would save the current UserSettings/ as '%s'\n"), snapshot)
+			return
+		}
+		if _, err := saveSnapshot(basePath, store, snapshot); err != nil {
+			fail(1, "cannot save the current UserSettings/: %v", err)
+		}
+		fmt.Printf(tr("[OK]   Saved the current UserSettings/ as '%s'\n"), snapshot)
+		recordAction("save", snapshot, "ok", fmt.Sprintf("%d files", files), 0)
+	}
+
+	switch command {
+	case "save":
+		if files == 0 {
+			fmt.Println(tr("[--] UserSettings/ is empty or missing; nothing to save."))
+			exit(0)
+		}
+		if dryRun {
+			fmt.Printf(tr("  Would save %d file(s) as '%s'\n"), files, name)
+			recordAction("save", name, "planned", fmt.Sprintf("%d files", files), 0)
+			fmt.Println(tr("\n[Dry Run] Nothing was saved."))
+			exit(0)
+		}
+		info, err := saveSnapshot(basePath, store, name)
+		if err != nil {
+			fail(1, "cannot save snapshot '%s': %v", name, err)
+		}
+		fmt.Printf(tr("[OK]   Saved %d file(s) (%s) as '%s'\n"), info.Files, formatSize(info.Bytes), name)
+		recordAction("save", name, "ok", fmt.Sprintf("%d files", info.Files), 0)
+		recordArtifact(filepath.Join(store, name))
+		exit(0)
+
+	case "restore":
+		snapshotDir := filepath.Join(store, name)
+		if _, err := os.Stat(filepath.Join(snapshotDir, userSettingsDir)); err != nil {
+			fail(1, "no snapshot '%s' in %s", name, store)
+		}
+		snapFiles, snapSize := measureDir(filepath.Join(snapshotDir, userSettingsDir))
+		fmt.Printf(tr("  Restoring '%s': %d file(s), %s\n"), name, snapFiles, formatSize(snapSize))
+		// Restoring before-restore must not overwrite it with the folder it replaces
+		if name != beforeRestoreSnapshot {
+			keepCurrent(beforeRestoreSnapshot)
+		}
+		if dryRun {
+			recordAction("restore", name, "planned", fmt.Sprintf("%d files", snapFiles), 0)
+			fmt.Println(tr("\n[Dry Run] UserSettings/ was not changed."))
+			exit(0)
+		}
+		n, err := restoreSnapshot(basePath, snapshotDir)
+		if err != nil {
+			fail(1, "cannot restore snapshot '%s': %v", name, err)
+		}
+		fmt.Printf(tr("[OK]   Restored %d file(s) from '%s'\n"), n, name)
+		recordAction("restore", name, "ok", fmt.Sprintf("%d files", n), 0)
+		exit(0)
+
+	case "clean":
+		if _, err := os.Stat(settingsPath); os.IsNotExist(err) {
+			fmt.Println(tr("[--] There is no UserSettings/ folder."))
+			exit(0)
+		}
+		keepCurrent(beforeCleanSnapshot)
+		if dryRun {
+			recordAction("delete", userSettingsDir, "planned", fmt.Sprintf("%d files", files), 0)
+			fmt.Println(tr("\n[Dry Run] UserSettings/ was not deleted."))
+			exit(0)
+		}
+		if err := os.RemoveAll(settingsPath); err != nil {
+			recordAction("delete", userSettingsDir, "failed", err.Error(), 0)
+			fail(1, "cannot delete UserSettings/: %v", err)
+		}
+		fmt.Println(tr("[OK]   Deleted UserSettings/; Unity creates it again with default settings."))
+		recordAction("delete", userSettingsDir, "ok", fmt.Sprintf("%d files", files), 0)
+		exit(0)
+	}
+
+	// status and fix
+	check := checkIgnore(basePath, activeVCS)
+	if check.system == "" {
+		fmt.Println(tr("[--] The project is not under Git, Plastic SCM or Perforce; nothing to check."))
+		printSnapshots(store)
+		exit(0)
+	}
+	if command == "fix" {
+		if !check.ignored && len(check.problems) == 0 {
+			rule := check.ignoreRule(basePath)
+			if dryRun {
+				fmt.Printf(tr("  Would add '%s' to %s\n"), rule, check.file)
+				recordAction("modify", check.file, "planned", rule, 0)
+			} else if err := appendRule(check.file, rule); err != nil {
+				recordAction("modify", check.file, "failed", err.Error(), 0)
+				fail(1, "cannot update %s: %v", check.file, err)
+			} else {
+				fmt.Printf(tr("[OK]   Added '%s' to %s\n"), rule, check.file)
+				recordAction("modify", check.file, "ok", rule, 0)
+			}
+		}
+		if len(check.tracked) > 0 {
+			if dryRun {
+				fmt.Printf(tr("  Would stop tracking %d file(s) under UserSettings/ (git rm --cached)\n"), len(check.tracked))
+				recordAction("untrack", userSettingsDir, "planned", fmt.Sprintf("%d files", len(check.tracked)), 0)
+			} else if err := untrack(basePath); err != nil {
+				recordAction("untrack", userSettingsDir, "failed", err.Error(), 0)
+				fail(1, "%v", err)
+			} else {
+				fmt.Printf(tr("[OK]   Stopped tracking %d file(s) under UserSettings/; commit the removal, the files stay on disk\n"), len(check.tracked))
+				recordAction("untrack", userSettingsDir, "ok", fmt.Sprintf("%d files", len(check.tracked)), 0)
+			}
+		}
+		if dryRun {
+			fmt.Println(tr("\n[Dry Run] Nothing was changed."))
+			exit(0)
+		}
+		check = checkIgnore(basePath, activeVCS)
+		fmt.Println()
+	}
+
+	problems := printCheck(check)
+	printSnapshots(store)
+	if problems > 0 {
+		if command == "status" {
+			fmt.Println(tr("\n[TIP] Run \"unity_user_settings fix\" to add the ignore rule and untrack committed files."))
+		}
+		exit(1)
+	}
+	exit(0)
+}
@@ -5,6 +5,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"io/fs"
@@ -16,6 +18,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -28,6 +31,9 @@ const (
 	shortAudioThreshold = 3.0
 )
 
+// dryRun runs the media analysis but only lists the encodes (-dry-run)
+var dryRun bool
+
 type preset struct {
 	Key          string
 	Name         string
@@ -197,23 +203,522 @@ var (
 	audioStreamRegex = regexp.MustCompile(`Stream\s+#\d+:\d+(?:\[[^\]]+\])?(?:\([^)]+\))?:\s+Audio:`)
 )
 
+// ============================================================
+// Commands
+// ============================================================
+
+// commandRunner starts every external program the tool uses. Output is for
+// commands that only read (an analysis pass, a device query) and always runs;
+// Run is for commands that change something and is only noted under -dry-run.
+//
+// Two environment variables swap the runner for tests and bug reports:
+//   - UNITYSTARTER_EXEC_RECORD=<file.json> runs commands as usual and saves each
+//     one with its output and exit code
+//   - UNITYSTARTER_EXEC_REPLAY=<file.json> starts nothing and answers every
+//     command from such a file, so a run can be repeated without the programs
+//
+// The project root and the temp folder are written as $ROOT and $TMP in fixtures
+// so they replay on any machine.
+type commandRunner interface {
+	Output(ctx context.Context, name string, args ...string) (stdout, stderr []byte, err error)
+	Run(ctx context.Context, name string, args ...string) (stdout, stderr []byte, err error)
+}
+
+var runner commandRunner = execRunner{}
+
+// replayingCommands is set when commands are answered from a fixture; lookPath
+// then accepts programs that are not installed
+var replayingCommands bool
+
+// setupRunner picks the runner for this run; the dry runner it returns (nil
+// without -dry-run) lists the skipped commands with printDryRunReport
+func setupRunner(root string, dryRun bool) (*dryRunner, error) {
+	var r commandRunner = execRunner{}
+	if path := os.Getenv("UNITYSTARTER_EXEC_REPLAY"); path != "" {
+		replay, err := loadReplayRunner(path, root)
+		if err != nil {
+			return nil, err
+		}
+		r = replay
+		replayingCommands = true
+	}
+	if path := os.Getenv("UNITYSTARTER_EXEC_RECORD"); path != "" {
+		r = &recordingRunner{inner: r, path: path, hide: fixturePaths(root, false)}
+	}
+	var dry *dryRunner
+	if dryRun {
+		dry = &dryRunner{inner: r}
+		r = dry
+	}
+	runner = r
+	return dry, nil
+}
+
+// lookPath reports whether a program can be started
+func lookPath(name string) error {
+	if replayingCommands {
+		return nil
+	}
+	_, err := exec.LookPath(name)
+	return err
+}
+
+// commandLine formats a command the way it could be pasted into a shell
+func commandLine(name string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, a := range append([]string{name}, args...) {
+		if a == "" || strings.ContainsAny(a, " \t\"'") {
+			a = strconv.Quote(a)
+		}
+		parts = append(parts, a)
+	}
+	return strings.Join(parts, " ")
+}
+
+// fixturePaths swaps the machine's paths for $ROOT and $TMP, or back with restore.
+// The longer path goes first so a project inside the temp folder stays $ROOT.
+func fixturePaths(root string, restore bool) *strings.Replacer {
+	paths := [][2]string{{root, "$ROOT"}, {filepath.Clean(os.TempDir()), "$TMP"}}
+	if len(paths[1][0]) > len(paths[0][0]) {
+		paths[0], paths[1] = paths[1], paths[0]
+	}
+	var pairs []string
+	for _, p := range paths {
+		if p[0] == "" || p[0] == "." {
+			continue
+		}
+		if restore {
+			pairs = append(pairs, p[1], p[0])
+		} else {
+			pairs = append(pairs, p[0], p[1])
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// execRunner starts the real programs
+type execRunner struct{}
+
+func (execRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+func (e execRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	return e.Output(ctx, name, args...)
+}
+
+// dryRunner passes queries through and keeps the commands that would change something
+type dryRunner struct {
+	inner   commandRunner
+	mu      sync.Mutex
+	planned []string
+}
+
+func (d *dryRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	return d.inner.Output(ctx, name, args...)
+}
+
+func (d *dryRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.planned = append(d.planned, commandLine(name, args))
+	return nil, nil, nil
+}
+
+// printDryRunReport lists the commands that were skipped, in the order they came
+func (d *dryRunner) printDryRunReport() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.planned) == 0 {
+		fmt.Println(tr("\n[Dry Run] No commands would be run."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d command(s) would be run; nothing was executed:\n"), len(d.planned))
+	for _, line := range d.planned {
+		fmt.Printf("  run     %s\n", line)
+		recordAction("run", line, "dry-run", "", 0)
+	}
+}
+
+// commandRecord is one command in a fixture; Exit is -1 when it did not start
+// or was killed, with the reason in Error
+type commandRecord struct {
+	Args   []string `json:"args"`
+	Stdout string   `json:"stdout,omitempty"`
+	Stderr string   `json:"stderr,omitempty"`
+	Exit   int      `json:"exit"`
+	Error  string   `json:"error,omitempty"`
+}
+
+type commandFixture struct {
+	Commands []commandRecord `json:"commands"`
+}
+
+// recordingRunner saves every command to path, rewriting the file after each
+// one so an interrupted run still leaves a usable fixture
+type recordingRunner struct {
+	inner   commandRunner
+	path    string
+	hide    *strings.Replacer
+	mu      sync.Mutex
+	fixture commandFixture
+}
+
+func (r *recordingRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	stdout, stderr, err := r.inner.Output(ctx, name, args...)
+	r.save(name, args, stdout, stderr, err)
+	return stdout, stderr, err
+}
+
+func (r *recordingRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	stdout, stderr, err := r.inner.Run(ctx, name, args...)
+	r.save(name, args, stdout, stderr, err)
+	return stdout, stderr, err
+}
+
+func (r *recordingRunner) save(name string, args []string, stdout, stderr []byte, err error) {
+	rec := commandRecord{Stdout: r.hide.Replace(string(stdout)), Stderr: r.hide.Replace(string(stderr))}
+	for _, a := range append([]string{name}, args...) {
+		rec.Args = append(rec.Args, r.hide.Replace(a))
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		rec.Exit = exitErr.ExitCode()
+	default:
+		rec.Exit = -1
+		rec.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Commands = append(r.fixture.Commands, rec)
+	data, _ := json.MarshalIndent(r.fixture, "", "  ")
+	os.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// replayRunner answers commands from a fixture. A command that appears several
+// times (the same device query, a retried step) gets its recordings in order,
+// the last one repeating once they run out.
+type replayRunner struct {
+	hide    *strings.Replacer
+	show    *strings.Replacer
+	mu      sync.Mutex
+	records map[string][]commandRecord
+}
+
+func loadReplayRunner(path, root string) (*replayRunner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture commandFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r := &replayRunner{hide: fixturePaths(root, false), show: fixturePaths(root, true), records: make(map[string][]commandRecord)}
+	for _, rec := range fixture.Commands {
+		key := strings.Join(rec.Args, "\x00")
+		r.records[key] = append(r.records[key], rec)
+	}
+	return r, nil
+}
+
+func (r *replayRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	var keyArgs []string
+	for _, a := range append([]string{name}, args...) {
+		keyArgs = append(keyArgs, r.hide.Replace(a))
+	}
+	key := strings.Join(keyArgs, "\x00")
+
+	r.mu.Lock()
+	queue := r.records[key]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, nil, fmt.Errorf("no recorded output for: %s", commandLine(name, args))
+	}
+	rec := queue[0]
+	if len(queue) > 1 {
+		r.records[key] = queue[1:]
+	}
+	r.mu.Unlock()
+
+	stdout, stderr := []byte(r.show.Replace(rec.Stdout)), []byte(r.show.Replace(rec.Stderr))
+	switch {
+	case rec.Error != "":
+		return stdout, stderr, errors.New(rec.Error)
+	case rec.Exit != 0:
+		return stdout, stderr, fmt.Errorf("exit status %d", rec.Exit)
+	}
+	return stdout, stderr, nil
+}
+
+func (r *replayRunner) Run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	return r.Output(ctx, name, args...)
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"Entry preset with a 3M video bitrate floor for cleaner mobile and lightweight runtime playback.":                 "入门预设，视频码率下限 3M，适合移动端与轻量运行时播放。",
+	"Default preset with a 9M target for noticeably cleaner playback across Android, iOS, WebGL, Windows, and macOS.": "默认预设，目标码率 9M，在 Android、iOS、WebGL、Windows 和 macOS 上播放明显更清晰。",
+	"Highest quality preset with an 18M target for showcase clips and maximum preservation within VP8/WebM.":          "最高质量预设，目标码率 18M，用于展示片段，在 VP8/WebM 范围内最大程度保留画质。",
+	"Default. Keep the source resolution unchanged.":                                                                  "默认。保持源分辨率不变。",
+	"Downscale only when the source is larger than 1920x1080.":                                                        "仅当源大于 1920x1080 时缩小。",
+	"Downscale only when the source is larger than 1280x720.":                                                         "仅当源大于 1280x720 时缩小。",
+	"Downscale only when the source is larger than 960x540.":                                                          "仅当源大于 960x540 时缩小。",
+
+	"Error: ffmpeg was not found in PATH. Please install ffmpeg and make sure the command is available in your system environment.": "错误: PATH 中未找到 ffmpeg。请安装 ffmpeg 并确认可在系统环境中调用该命令。",
+	"Cancelled: %v":                                   "已取消: %v",
+	"Failed to read input path: %v":                   "读取输入路径失败: %v",
+	"Failed to build conversion list: %v":             "生成转换列表失败: %v",
+	"No supported video files were found to process.": "未找到可处理的视频文件。",
+	"Summary":               "摘要",
+	"  Source:   %s\n":      "  来源:     %s\n",
+	"  Preset:   %s\n":      "  预设:     %s\n",
+	"  Resolution: %s\n":    "  分辨率:   %s\n",
+	"  Video bitrate: %s\n": "  视频码率: %s\n",
+	"  Output:   %s\n":      "  输出:     %s\n",
+	"  Files:    %d\n":      "  文件数:   %d\n",
+	"  Overwrite:%t\n":      "  覆盖:     %t\n",
+	"Audio note: WebM does not support AAC in the standard container, so this tool uses Vorbis audio plus loudness normalization for broad Unity/WebM compatibility.": "音频说明: 标准 WebM 容器不支持 AAC，因此本工具使用 Vorbis 音频并进行响度标准化，以获得广泛的 Unity/WebM 兼容性。",
+	"Start conversion now? (Y/N) [default: Y]: ":     "现在开始转换？(Y/N) [默认: Y]: ",
+	"Operation cancelled by user.":                   "用户已取消操作。",
+	"  Skipped: output already exists -> %s\n":       "  已跳过: 输出已存在 -> %s\n",
+	"  Failed: create output directory failed: %v\n": "  失败: 创建输出目录失败: %v\n",
+	"  Failed: %v\n":    "  失败: %v\n",
+	"  Done: %s\n":      "  完成: %s\n",
+	"Finished":          "已结束",
+	"  Succeeded: %d\n": "  成功:   %d\n",
+	"  Skipped:   %d\n": "  跳过:   %d\n",
+	"  Failed:    %d\n": "  失败:   %d\n",
+
+	"--- Unity Video WebM Converter ---":                                            "--- Unity 视频 WebM 转换工具 ---",
+	"This tool calls the system ffmpeg to convert video files into Unity-friendly":  "本工具调用系统 ffmpeg，将视频文件转换为适合 Unity 的",
+	"VP8 WebM outputs suitable for Android, iOS, WebGL, Windows, and macOS builds.": "VP8 WebM 文件，适用于 Android、iOS、WebGL、Windows 和 macOS 构建。",
+	"Defaults": "默认设置",
+	"  Video: VP8 / yuv420p / default target bitrate 9M":                           "  视频: VP8 / yuv420p / 默认目标码率 9M",
+	"  Audio: Vorbis / stereo / 44.1 kHz / loudness-normalized":                    "  音频: Vorbis / 立体声 / 44.1 kHz / 响度标准化",
+	"  Preset: Balanced / Universal":                                               "  预设: Balanced / Universal",
+	"  Resolution: Original":                                                       "  分辨率: Original",
+	"  Bitrate: adjustable after preset selection":                                 "  码率: 选择预设后可调整",
+	"Tip: you can paste or drag a file/folder path directly into this console.":    "提示: 可以直接将文件/文件夹路径粘贴或拖入此控制台。",
+	"Audio normalization":                                                          "音频标准化",
+	"  Long audio (>= 3s): two-pass LUFS loudness normalization":                   "  长音频 (>= 3s): 两遍 LUFS 响度标准化",
+	"  Short audio (< 3s): peak normalization for safer short clips":               "  短音频 (< 3s): 峰值标准化，对短片段更安全",
+	"Enter a video file or folder path. Press Enter to open a dialog on Windows: ": "输入视频文件或文件夹路径。在 Windows 上直接回车可打开选择对话框: ",
+	"Dialog error: %v\n":                                                           "对话框错误: %v\n",
+	"Please enter a valid path.":                                                   "请输入有效的路径。",
+	"Path not found: %s\n":                                                         "路径不存在: %s\n",
+	"Open file dialog or folder dialog? (F/D) [default: F]: ":                      "打开文件对话框还是文件夹对话框？(F/D) [默认: F]: ",
+	"Quality presets":                                                              "质量预设",
+	"     Output suffix: %s.webm\n":                                                "     输出后缀: %s.webm\n",
+	"Select preset (1/2/3) [default: 2]: ":                                         "选择预设 (1/2/3) [默认: 2]: ",
+	"Unknown preset selection, using Balanced / Universal.":                        "无效的预设选择，使用 Balanced / Universal。",
+	"Resolution options":                                                           "分辨率选项",
+	"Select resolution option (1/2/3/4) [default: 1]: ":                            "选择分辨率选项 (1/2/3/4) [默认: 1]: ",
+	"Unknown resolution selection, using Original.":                                "无效的分辨率选择，使用 Original。",
+	"Video bitrate [default: %s, examples: 12M / 8500k]: ":                         "视频码率 [默认: %s，示例: 12M / 8500k]: ",
+	"Unknown bitrate format '%s', using default %s.\n":                             "无法识别的码率格式 '%s'，使用默认值 %s。\n",
+	"Output folder [default: %s]: ":                                                "输出文件夹 [默认: %s]: ",
+	"Overwrite existing outputs? (y/N) [default: N]: ":                             "覆盖已存在的输出？(y/N) [默认: N]: ",
+	"Press Enter to exit...":                                                       "按回车键退出...",
+	"Failed to load the command fixture: %v":                                       "加载命令录制文件失败: %v",
+	"  [Dry Run] Would write: %s\n":                                                "  [Dry Run] 将写入: %s\n",
+	"\n[Dry Run] No commands would be run.":                                        "\n[Dry Run] 不会运行任何命令。",
+	"\n[Dry Run] %d command(s) would be run; nothing was executed:\n":              "\n[Dry Run] 将会运行 %d 条命令，未执行任何命令:\n",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
 func main() {
+	flag.BoolVar(&dryRun, "dry-run", false, "Analyze the videos and list the ffmpeg commands that would encode them, without running them")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (prompts go to stderr)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("unity_video_webm_converter")
+	}
+
 	printIntro()
 
+	root, _ := os.Getwd()
+	dry, err := setupRunner(root, dryRun)
+	if err != nil {
+		exitWithMessage(fmt.Sprintf(tr("Failed to load the command fixture: %v"), err))
+	}
 	if !commandExists("ffmpeg") {
-		exitWithMessage("Error: ffmpeg was not found in PATH. Please install ffmpeg and make sure the command is available in your system environment.")
+		exitWithMessage(tr("Error: ffmpeg was not found in PATH. Please install ffmpeg and make sure the command is available in your system environment."))
 	}
 
 	reader := bufio.NewReader(os.Stdin)
 
 	sourcePath, err := chooseSourcePath(reader)
 	if err != nil {
-		exitWithMessage(fmt.Sprintf("Cancelled: %v", err))
+		exitWithMessage(fmt.Sprintf(tr("Cancelled: %v"), err))
 	}
 
 	info, err := os.Stat(sourcePath)
 	if err != nil {
-		exitWithMessage(fmt.Sprintf("Failed to read input path: %v", err))
+		exitWithMessage(fmt.Sprintf(tr("Failed to read input path: %v"), err))
 	}
 
 	selectedPreset := choosePreset(reader)
@@ -221,31 +726,31 @@ func main() {
 	settings := chooseVideoBitrate(reader, selectedPreset, selectedResolution)
 	outputRoot, overwrite, err := chooseOutputOptions(reader, sourcePath, info, settings.Preset)
 	if err != nil {
-		exitWithMessage(fmt.Sprintf("Cancelled: %v", err))
+		exitWithMessage(fmt.Sprintf(tr("Cancelled: %v"), err))
 	}
 
 	jobs, err := buildJobs(sourcePath, info, outputRoot, settings.Preset)
 	if err != nil {
-		exitWithMessage(fmt.Sprintf("Failed to build conversion list: %v", err))
+		exitWithMessage(fmt.Sprintf(tr("Failed to build conversion list: %v"), err))
 	}
 	if len(jobs) == 0 {
-		exitWithMessage("No supported video files were found to process.")
+		exitWithMessage(tr("No supported video files were found to process."))
 	}
 
 	fmt.Println()
-	fmt.Println("Summary")
-	fmt.Printf("  Source:   %s\n", sourcePath)
-	fmt.Printf("  Preset:   %s\n", settings.Preset.Name)
-	fmt.Printf("  Resolution: %s\n", settings.SelectedResolution.Name)
-	fmt.Printf("  Video bitrate: %s\n", settings.VideoBitrate)
-	fmt.Printf("  Output:   %s\n", outputRoot)
-	fmt.Printf("  Files:    %d\n", len(jobs))
-	fmt.Printf("  Overwrite:%t\n", overwrite)
+	fmt.Println(tr("Summary"))
+	fmt.Printf(tr("  Source:   %s\n"), sourcePath)
+	fmt.Printf(tr("  Preset:   %s\n"), settings.Preset.Name)
+	fmt.Printf(tr("  Resolution: %s\n"), settings.SelectedResolution.Name)
+	fmt.Printf(tr("  Video bitrate: %s\n"), settings.VideoBitrate)
+	fmt.Printf(tr("  Output:   %s\n"), outputRoot)
+	fmt.Printf(tr("  Files:    %d\n"), len(jobs))
+	fmt.Printf(tr("  Overwrite:%t\n"), overwrite)
 	fmt.Println()
-	fmt.Println("Audio note: WebM does not support AAC in the standard container, so this tool uses Vorbis audio plus loudness normalization for broad Unity/WebM compatibility.")
+	fmt.Println(tr("Audio note: WebM does not support AAC in the standard container, so this tool uses Vorbis audio plus loudness normalization for broad Unity/WebM compatibility."))
 
-	if !confirm(reader, "Start conversion now? (Y/N) [default: Y]: ", true) {
-		exitWithMessage("Operation cancelled by user.")
+	if !confirm(reader, tr("Start conversion now? (Y/N) [default: Y]: "), true) {
+		exitWithMessage(tr("Operation cancelled by user."))
 	}
 
 	successCount := 0
@@ -258,60 +763,79 @@ func main() {
 
 		if !overwrite {
 			if _, statErr := os.Stat(item.OutputPath); statErr == nil {
-				fmt.Printf("  Skipped: output already exists -> %s\n", item.OutputPath)
+				fmt.Printf(tr("  Skipped: output already exists -> %s\n"), item.OutputPath)
+				recordAction("convert", item.InputPath, "skipped", "output already exists", 0)
 				skipCount++
 				continue
 			}
 		}
 
-		if err := os.MkdirAll(filepath.Dir(item.OutputPath), 0o755); err != nil {
-			fmt.Printf("  Failed: create output directory failed: %v\n", err)
-			failCount++
-			continue
+		if !dryRun {
+			if err := os.MkdirAll(filepath.Dir(item.OutputPath), 0o755); err != nil {
+				fmt.Printf(tr("  Failed: create output directory failed: %v\n"), err)
+				recordAction("convert", item.InputPath, "failed", err.Error(), 0)
+				recordError("%s: %v", item.InputPath, err)
+				failCount++
+				continue
+			}
 		}
 
+		started := time.Now()
 		if err := convertVideo(item.InputPath, item.OutputPath, settings); err != nil {
-			fmt.Printf("  Failed: %v\n", err)
+			fmt.Printf(tr("  Failed: %v\n"), err)
+			recordAction("convert", item.InputPath, "failed", err.Error(), time.Since(started))
+			recordError("%s: %v", item.InputPath, err)
 			failCount++
 			continue
 		}
 
-		fmt.Printf("  Done: %s\n", item.OutputPath)
+		if dryRun {
+			fmt.Printf(tr("  [Dry Run] Would write: %s\n"), item.OutputPath)
+			recordAction("convert", item.InputPath, "dry-run", item.OutputPath, time.Since(started))
+			successCount++
+			continue
+		}
+		fmt.Printf(tr("  Done: %s\n"), item.OutputPath)
+		recordAction("convert", item.InputPath, "ok", item.OutputPath, time.Since(started))
+		recordArtifact(item.OutputPath)
 		successCount++
 	}
+	if dry != nil {
+		dry.printDryRunReport()
+	}
 
 	fmt.Println()
-	fmt.Println("Finished")
-	fmt.Printf("  Succeeded: %d\n", successCount)
-	fmt.Printf("  Skipped:   %d\n", skipCount)
-	fmt.Printf("  Failed:    %d\n", failCount)
+	fmt.Println(tr("Finished"))
+	fmt.Printf(tr("  Succeeded: %d\n"), successCount)
+	fmt.Printf(tr("  Skipped:   %d\n"), skipCount)
+	fmt.Printf(tr("  Failed:    %d\n"), failCount)
 	waitForExit()
 }
 
 func printIntro() {
-	fmt.Println("--- Unity Video WebM Converter ---")
+	fmt.Println(tr("--- Unity Video WebM Converter ---"))
 	fmt.Println()
-	fmt.Println("This tool calls the system ffmpeg to convert video files into Unity-friendly")
-	fmt.Println("VP8 WebM outputs suitable for Android, iOS, WebGL, Windows, and macOS builds.")
+	fmt.Println(tr("This tool calls the system ffmpeg to convert video files into Unity-friendly"))
+	fmt.Println(tr("VP8 WebM outputs suitable for Android, iOS, WebGL, Windows, and macOS builds."))
 	fmt.Println()
-	fmt.Println("Defaults")
-	fmt.Println("  Video: VP8 / yuv420p / default target bitrate 9M")
-	fmt.Println("  Audio: Vorbis / stereo / 44.1 kHz / loudness-normalized")
-	fmt.Println("  Preset: Balanced / Universal")
-	fmt.Println("  Resolution: Original")
-	fmt.Println("  Bitrate: adjustable after preset selection")
+	fmt.Println(tr("Defaults"))
+	fmt.Println(tr("  Video: VP8 / yuv420p / default target bitrate 9M"))
+	fmt.Println(tr("  Audio: Vorbis / stereo / 44.1 kHz / loudness-normalized"))
+	fmt.Println(tr("  Preset: Balanced / Universal"))
+	fmt.Println(tr("  Resolution: Original"))
+	fmt.Println(tr("  Bitrate: adjustable after preset selection"))
 	fmt.Println()
-	fmt.Println("Tip: you can paste or drag a file/folder path directly into this console.")
+	fmt.Println(tr("Tip: you can paste or drag a file/folder path directly into this console."))
 	fmt.Println()
-	fmt.Println("Audio normalization")
-	fmt.Println("  Long audio (>= 3s): two-pass LUFS loudness normalization")
-	fmt.Println("  Short audio (< 3s): peak normalization for safer short clips")
+	fmt.Println(tr("Audio normalization"))
+	fmt.Println(tr("  Long audio (>= 3s): two-pass LUFS loudness normalization"))
+	fmt.Println(tr("  Short audio (< 3s): peak normalization for safer short clips"))
 	fmt.Println()
 }
 
 func chooseSourcePath(reader *bufio.Reader) (string, error) {
 	for {
-		fmt.Print("Enter a video file or folder path. Press Enter to open a dialog on Windows: ")
+		fmt.Print(tr("Enter a video file or folder path. Press Enter to open a dialog on Windows: "))
 		text, err := readLine(reader)
 		if err != nil {
 			return "", err
@@ -320,7 +844,7 @@ func chooseSourcePath(reader *bufio.Reader) (string, error) {
 		if text == "" && runtime.GOOS == "windows" {
 			path, dialogErr := choosePathWithWindowsDialog(reader)
 			if dialogErr != nil {
-				fmt.Printf("Dialog error: %v\n", dialogErr)
+				fmt.Printf(tr("Dialog error: %v\n"), dialogErr)
 				continue
 			}
 			if path == "" {
@@ -330,18 +854,18 @@ func chooseSourcePath(reader *bufio.Reader) (string, error) {
 		}
 
 		if text == "" {
-			fmt.Println("Please enter a valid path.")
+			fmt.Println(tr("Please enter a valid path."))
 			continue
 		}
 
 		cleaned := normalizePath(text)
 		if cleaned == "" {
-			fmt.Println("Please enter a valid path.")
+			fmt.Println(tr("Please enter a valid path."))
 			continue
 		}
 
 		if _, err := os.Stat(cleaned); err != nil {
-			fmt.Printf("Path not found: %s\n", cleaned)
+			fmt.Printf(tr("Path not found: %s\n"), cleaned)
 			continue
 		}
 
@@ -350,7 +874,7 @@ func chooseSourcePath(reader *bufio.Reader) (string, error) {
 }
 
 func choosePathWithWindowsDialog(reader *bufio.Reader) (string, error) {
-	fmt.Print("Open file dialog or folder dialog? (F/D) [default: F]: ")
+	fmt.Print(tr("Open file dialog or folder dialog? (F/D) [default: F]: "))
 	choice, err := readLine(reader)
 	if err != nil {
 		return "", err
@@ -363,14 +887,14 @@ func choosePathWithWindowsDialog(reader *bufio.Reader) (string, error) {
 }
 
 func choosePreset(reader *bufio.Reader) preset {
-	fmt.Println("Quality presets")
+	fmt.Println(tr("Quality presets"))
 	for _, p := range presets {
 		fmt.Printf("  %s. %s\n", p.Key, p.Name)
-		fmt.Printf("     %s\n", p.Description)
-		fmt.Printf("     Output suffix: %s.webm\n", p.Suffix)
+		fmt.Printf("     %s\n", tr(p.Description))
+		fmt.Printf(tr("     Output suffix: %s.webm\n"), p.Suffix)
 	}
 
-	fmt.Print("Select preset (1/2/3) [default: 2]: ")
+	fmt.Print(tr("Select preset (1/2/3) [default: 2]: "))
 	input, err := readLine(reader)
 	if err != nil {
 		return presets[1]
@@ -386,18 +910,18 @@ func choosePreset(reader *bufio.Reader) preset {
 		}
 	}
 
-	fmt.Println("Unknown preset selection, using Balanced / Universal.")
+	fmt.Println(tr("Unknown preset selection, using Balanced / Universal."))
 	return presets[1]
 }
 
 func chooseResolution(reader *bufio.Reader) resolutionOption {
-	fmt.Println("Resolution options")
+	fmt.Println(tr("Resolution options"))
 	for _, option := range resolutionOptions {
 		fmt.Printf("  %s. %s\n", option.Key, option.Name)
-		fmt.Printf("     %s\n", option.Description)
+		fmt.Printf("     %s\n", tr(option.Description))
 	}
 
-	fmt.Print("Select resolution option (1/2/3/4) [default: 1]: ")
+	fmt.Print(tr("Select resolution option (1/2/3/4) [default: 1]: "))
 	input, err := readLine(reader)
 	if err != nil {
 		return resolutionOptions[0]
@@ -413,12 +937,12 @@ func chooseResolution(reader *bufio.Reader) resolutionOption {
 		}
 	}
 
-	fmt.Println("Unknown resolution selection, using Original.")
+	fmt.Println(tr("Unknown resolution selection, using Original."))
 	return resolutionOptions[0]
 }
 
 func chooseVideoBitrate(reader *bufio.Reader, selectedPreset preset, selectedResolution resolutionOption) encodeSettings {
-	fmt.Printf("Video bitrate [default: %s, examples: 12M / 8500k]: ", selectedPreset.VideoBitrate)
+	fmt.Printf(tr("Video bitrate [default: %s, examples: 12M / 8500k]: "), selectedPreset.VideoBitrate)
 	input, err := readLine(reader)
 	if err != nil {
 		return buildEncodeSettings(selectedPreset, selectedResolution, selectedPreset.VideoBitrate)
@@ -431,7 +955,7 @@ func chooseVideoBitrate(reader *bufio.Reader, selectedPreset preset, selectedRes
 
 	normalized, ok := normalizeBitrateInput(input)
 	if !ok {
-		fmt.Printf("Unknown bitrate format '%s', using default %s.\n", input, selectedPreset.VideoBitrate)
+		fmt.Printf(tr("Unknown bitrate format '%s', using default %s.\n"), input, selectedPreset.VideoBitrate)
 		return buildEncodeSettings(selectedPreset, selectedResolution, selectedPreset.VideoBitrate)
 	}
 
@@ -451,7 +975,7 @@ func buildEncodeSettings(selectedPreset preset, selectedResolution resolutionOpt
 func chooseOutputOptions(reader *bufio.Reader, sourcePath string, info os.FileInfo, selectedPreset preset) (string, bool, error) {
 	defaultOutputRoot := defaultOutputPath(sourcePath, info, selectedPreset)
 
-	fmt.Printf("Output folder [default: %s]: ", defaultOutputRoot)
+	fmt.Printf(tr("Output folder [default: %s]: "), defaultOutputRoot)
 	outputText, err := readLine(reader)
 	if err != nil {
 		return "", false, err
@@ -462,7 +986,7 @@ func chooseOutputOptions(reader *bufio.Reader, sourcePath string, info os.FileIn
 		outputRoot = normalizePath(outputText)
 	}
 
-	overwrite := confirm(reader, "Overwrite existing outputs? (y/N) [default: N]: ", false)
+	overwrite := confirm(reader, tr("Overwrite existing outputs? (y/N) [default: N]: "), false)
 	return outputRoot, overwrite, nil
 }
 
@@ -563,15 +1087,17 @@ func convertVideo(inputPath string, outputPath string, settings encodeSettings)
 	ctx, cancel := context.WithTimeout(context.Background(), ffmpegTimeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
-	output, err := cmd.CombinedOutput()
+	stdout, stderr, err := runner.Run(ctx, "ffmpeg", args...)
 	if ctx.Err() == context.DeadlineExceeded {
 		_ = os.Remove(tempOutput)
 		return fmt.Errorf("ffmpeg timed out after %s", ffmpegTimeout)
 	}
 	if err != nil {
 		_ = os.Remove(tempOutput)
-		return fmt.Errorf("ffmpeg error: %w\n%s", err, trimCommandOutput(output))
+		return fmt.Errorf("ffmpeg error: %w\n%s", err, trimCommandOutput(append(stdout, stderr...)))
+	}
+	if dryRun {
+		return nil
 	}
 
 	if err := os.Rename(tempOutput, outputPath); err != nil {
@@ -627,17 +1153,13 @@ func inspectMedia(inputPath string) (mediaInfo, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), ffmpegTimeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
-	var stderr bytes.Buffer
-	cmd.Stdout = io.Discard
-	cmd.Stderr = &stderr
-	_ = cmd.Run()
+	_, stderr, _ := runner.Output(ctx, "ffmpeg", args...)
 
 	if ctx.Err() == context.DeadlineExceeded {
 		return mediaInfo{}, fmt.Errorf("ffmpeg media inspection timed out after %s", ffmpegTimeout)
 	}
 
-	output := stderr.String()
+	output := string(stderr)
 	return mediaInfo{
 		DurationSec:      parseDuration(output),
 		HasAudio:         audioStreamRegex.MatchString(output),
@@ -675,17 +1197,13 @@ func buildPeakNormalizationFilter(inputPath string, category audioCategory) (str
 	ctx, cancel := context.WithTimeout(context.Background(), ffmpegTimeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "ffmpeg", "-hide_banner", "-i", inputPath, "-vn", "-map", "0:a:0", "-af", "volumedetect", "-f", "null", "-")
-	var stderr bytes.Buffer
-	cmd.Stdout = io.Discard
-	cmd.Stderr = &stderr
-	_ = cmd.Run()
+	_, stderr, _ := runner.Output(ctx, "ffmpeg", "-hide_banner", "-i", inputPath, "-vn", "-map", "0:a:0", "-af", "volumedetect", "-f", "null", "-")
 
 	if ctx.Err() == context.DeadlineExceeded {
 		return "", fmt.Errorf("ffmpeg volumedetect timed out after %s", ffmpegTimeout)
 	}
 
-	match := maxVolRegex.FindStringSubmatch(stderr.String())
+	match := maxVolRegex.FindStringSubmatch(string(stderr))
 	if len(match) < 2 {
 		return "", fmt.Errorf("could not detect peak volume for audio track")
 	}
@@ -713,17 +1231,13 @@ func buildLoudnormFilter(inputPath string, category audioCategory) (string, erro
 	ctx, cancel := context.WithTimeout(context.Background(), ffmpegTimeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "ffmpeg", "-hide_banner", "-i", inputPath, "-vn", "-map", "0:a:0", "-af", pass1Filter, "-f", "null", "-")
-	var stderr bytes.Buffer
-	cmd.Stdout = io.Discard
-	cmd.Stderr = &stderr
-	_ = cmd.Run()
+	_, stderr, _ := runner.Output(ctx, "ffmpeg", "-hide_banner", "-i", inputPath, "-vn", "-map", "0:a:0", "-af", pass1Filter, "-f", "null", "-")
 
 	if ctx.Err() == context.DeadlineExceeded {
 		return "", fmt.Errorf("ffmpeg loudnorm analysis timed out after %s", ffmpegTimeout)
 	}
 
-	lnInfo, err := extractLoudnormInfo(stderr.String())
+	lnInfo, err := extractLoudnormInfo(string(stderr))
 	if err != nil {
 		return "", fmt.Errorf("failed to extract loudness info: %w", err)
 	}
@@ -863,8 +1377,7 @@ func normalizePath(text string) string {
 }
 
 func commandExists(name string) bool {
-	_, err := exec.LookPath(name)
-	return err == nil
+	return lookPath(name) == nil
 }
 
 func confirm(reader *bufio.Reader, prompt string, defaultYes bool) bool {
@@ -890,15 +1403,19 @@ func readLine(reader *bufio.Reader) (string, error) {
 }
 
 func waitForExit() {
+	if jsonMode {
+		return
+	}
 	fmt.Println()
-	fmt.Print("Press Enter to exit...")
+	fmt.Print(tr("Press Enter to exit..."))
 	_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
 }
 
 func exitWithMessage(message string) {
 	fmt.Println(message)
+	recordError("%s", message)
 	waitForExit()
-	os.Exit(1)
+	exitTool(1)
 }
 
 func runWindowsDialog(script string) (string, error) {
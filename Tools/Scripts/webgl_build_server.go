@@ -0,0 +1,686 @@
+// WebGL Build Server — Serve a Unity WebGL build locally with correct headers.
+// Handles pre-compressed .br/.gz files (Content-Encoding), WASM MIME types,
+// cross-origin isolation headers required for WebGL threads (SharedArrayBuffer),
+// and optional HTTPS with an in-memory self-signed certificate.
+//
+// Build: go build webgl_build_server.go
+//
+// Usage:
+//
+//	webgl_build_server Build/WebGL
+//	webgl_build_server -port 8443 -https Build/WebGL
+//	webgl_build_server -https -cert dev.pem -key dev-key.pem Build/WebGL
+//	webgl_build_server -no-coi -open Build/WebGL
+//	webgl_build_server -json Build/WebGL      # result document on stdout after Ctrl+C
+
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ============================================================
+// Configuration
+// ============================================================
+
+// MIME types for Unity WebGL output, keyed by the extension left after stripping .br/.gz
+var mimeTypes = map[string]string{
+	".html":     "text/html; charset=utf-8",
+	".js":       "application/javascript",
+	".mjs":      "application/javascript",
+	".wasm":     "application/wasm",
+	".data":     "application/octet-stream",
+	".json":     "application/json",
+	".css":      "text/css",
+	".png":      "image/png",
+	".jpg":      "image/jpeg",
+	".ico":      "image/x-icon",
+	".svg":      "image/svg+xml",
+	".unityweb": "application/octet-stream",
+	".bundle":   "application/octet-stream",
+}
+
+// Content-Encoding values for pre-compressed file suffixes
+var encodings = map[string]string{
+	".br": "br",
+	".gz": "gzip",
+}
+
+// ============================================================
+// Types
+// ============================================================
+
+type server struct {
+	root        string
+	coi         bool
+	verbose     bool
+	warnedBrMu  sync.Mutex
+	warnedBrFor map[string]bool
+}
+
+// loggingWriter captures the status code and bytes for the access log
+type loggingWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *loggingWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *loggingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// ============================================================
+// Request Handling
+// ============================================================
+
+func acceptsEncoding(r *http.Request, enc string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if name == enc || name == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveFile maps a request path to a file on disk. When the exact file is
+// missing, an available pre-compressed variant is chosen based on Accept-Encoding.
+func (s *server) resolveFile(r *http.Request) (string, string, bool) {
+	clean := path.Clean("/" + r.URL.Path)
+	if strings.HasSuffix(r.URL.Path, "/") {
+		clean = path.Join(clean, "index.html")
+	}
+	full := filepath.Join(s.root, filepath.FromSlash(clean))
+
+	if info, err := os.Stat(full); err == nil {
+		if info.IsDir() {
+			full = filepath.Join(full, "index.html")
+			clean = path.Join(clean, "index.html")
+			if _, err := os.Stat(full); err != nil {
+				return "", "", false
+			}
+		}
+		return full, clean, true
+	}
+
+	// Negotiate a compressed sibling, e.g. Build.wasm -> Build.wasm.br
+	for _, suffix := range []string{".br", ".gz"} {
+		if !acceptsEncoding(r, encodings[suffix]) {
+			continue
+		}
+		if _, err := os.Stat(full + suffix); err == nil {
+			return full + suffix, clean + suffix, true
+		}
+	}
+	return "", "", false
+}
+
+func (s *server) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w := &loggingWriter{ResponseWriter: rw}
+	defer func() {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		if s.verbose || w.status >= 400 {
+			fmt.Printf("%s %3d %-6s %s (%s, %s)\n", time.Now().Format("15:04:05"), w.status, r.Method, r.URL.Path, formatSize(w.bytes), time.Since(start).Round(time.Millisecond))
+		}
+	}()
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Development server: never let the browser cache stale builds
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	if s.coi {
+		// Required for SharedArrayBuffer (WebGL multithreading)
+		w.Header().Set("Cross-Origin-Opener-Policy", "same-origin")
+		w.Header().Set("Cross-Origin-Embedder-Policy", "require-corp")
+		w.Header().Set("Cross-Origin-Resource-Policy", "cross-origin")
+	}
+
+	full, urlPath, ok := s.resolveFile(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	ext := strings.ToLower(path.Ext(urlPath))
+	encoding := encodings[ext]
+	baseExt := ext
+	if encoding != "" {
+		baseExt = strings.ToLower(path.Ext(strings.TrimSuffix(urlPath, path.Ext(urlPath))))
+	}
+
+	contentType := mimeTypes[baseExt]
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+
+	f, err := os.Open(full)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	info, _ := f.Stat()
+
+	if encoding != "" {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsEncoding(r, encoding) {
+			if encoding == "gzip" {
+				// Transparent fallback: decompress on the fly
+				gz, err := gzip.NewReader(f)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				defer gz.Close()
+				if r.Method == http.MethodHead {
+					return
+				}
+				io.Copy(w, gz)
+				return
+			}
+			// Brotli has no stdlib decoder; most browsers only advertise br over HTTPS
+			s.warnBrotli(r)
+		}
+		w.Header().Set("Content-Encoding", encoding)
+	}
+
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
+
+func (s *server) warnBrotli(r *http.Request) {
+	host := r.RemoteAddr
+	s.warnedBrMu.Lock()
+	defer s.warnedBrMu.Unlock()
+	if s.warnedBrFor[host] {
+		return
+	}
+	s.warnedBrFor[host] = true
+	fmt.Printf(tr("[WARNING] Client %s does not accept 'br' but the build is Brotli-compressed.\n"), host)
+	fmt.Println(tr("          Browsers only enable Brotli over HTTPS: restart with -https,"))
+	fmt.Println(tr("          or rebuild with Gzip / Decompression Fallback enabled."))
+}
+
+// ============================================================
+// TLS
+// ============================================================
+
+// selfSignedCert creates an ECDSA certificate valid for localhost and all local IPs.
+// It lives only in memory; browsers will show a warning that must be accepted once.
+func selfSignedCert(hosts []string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Unity Starter WebGL Dev Server"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(30 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// localAddresses lists non-loopback IPv4 addresses for LAN testing (e.g. phones)
+func localAddresses() []string {
+	var addrs []string
+	ifaces, err := net.InterfaceAddrs()
+	if err != nil {
+		return addrs
+	}
+	for _, a := range ifaces {
+		if ipNet, ok := a.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			addrs = append(addrs, ipNet.IP.String())
+		}
+	}
+	return addrs
+}
+
+// ============================================================
+// Build Inspection
+// ============================================================
+
+// describeBuild reports which compression the build uses, so header problems are obvious
+func describeBuild(root string) (string, error) {
+	buildDir := filepath.Join(root, "Build")
+	entries, err := os.ReadDir(buildDir)
+	if err != nil {
+		return "", fmt.Errorf("no Build/ folder found in %s (is this a Unity WebGL output folder?)", root)
+	}
+	var br, gz, fallback, plain int
+	for _, e := range entries {
+		name := strings.ToLower(e.Name())
+		switch {
+		case strings.HasSuffix(name, ".br"):
+			br++
+		case strings.HasSuffix(name, ".gz"):
+			gz++
+		case strings.HasSuffix(name, ".unityweb"):
+			fallback++
+		case strings.HasSuffix(name, ".wasm") || strings.HasSuffix(name, ".data"):
+			plain++
+		}
+	}
+	switch {
+	case br > 0:
+		return "Brotli (.br)", nil
+	case gz > 0:
+		return "Gzip (.gz)", nil
+	case fallback > 0:
+		return "Decompression Fallback (.unityweb)", nil
+	case plain > 0:
+		return "Uncompressed", nil
+	}
+	return "Unknown", nil
+}
+
+func openBrowser(url string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	case "darwin":
+		cmd = exec.Command("open", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	cmd.Start()
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func formatSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"[WARNING] Client %s does not accept 'br' but the build is Brotli-compressed.\n": "[WARNING] 客户端 %s 不接受 'br'，但构建使用了 Brotli 压缩。\n",
+	"          Browsers only enable Brotli over HTTPS: restart with -https,":         "          浏览器仅在 HTTPS 下启用 Brotli：请使用 -https 重新启动，",
+	"          or rebuild with Gzip / Decompression Fallback enabled.":               "          或改用 Gzip / 启用 Decompression Fallback 重新构建。",
+	"  WebGL Build Server":                                                                "  WebGL 构建服务器",
+	"[ERROR] No index.html found in %s\n":                                                 "[ERROR] 在 %s 中未找到 index.html\n",
+	"Usage: webgl_build_server [flags] <WebGL build folder>":                              "用法: webgl_build_server [参数] <WebGL 构建目录>",
+	"[ERROR] -cert and -key must be provided together.":                                   "[ERROR] -cert 与 -key 必须同时提供。",
+	"  Root:        %s\n":                                                                 "  根目录:      %s\n",
+	"  Compression: %s\n":                                                                 "  压缩方式:    %s\n",
+	"  Isolation:   %v (COOP/COEP for WebGL threads)\n":                                   "  跨源隔离:    %v (用于 WebGL 多线程的 COOP/COEP)\n",
+	"  URL:         %s://localhost:%d/\n":                                                 "  地址:        %s://localhost:%d/\n",
+	"\n[TIP] Brotli builds need HTTPS in most browsers. Consider -https.":                 "\n[TIP] 大多数浏览器需要 HTTPS 才能加载 Brotli 构建，建议使用 -https。",
+	"\nPress Ctrl+C to stop.":                                                             "\n按 Ctrl+C 停止。",
+	"[ERROR] Failed to create self-signed certificate: %v\n":                              "[ERROR] 创建自签名证书失败: %v\n",
+	"[INFO] Using an in-memory self-signed certificate; accept the browser warning once.": "[INFO] 正在使用内存中的自签名证书；请在浏览器中接受一次安全警告。",
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed
+func exitTool(code int) {
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var (
+		host     string
+		port     int
+		useHTTPS bool
+		certFile string
+		keyFile  string
+		noCOI    bool
+		open     bool
+		verbose  bool
+	)
+
+	flag.StringVar(&host, "host", "0.0.0.0", "Listen address")
+	flag.IntVar(&port, "port", 8080, "Listen port")
+	flag.BoolVar(&useHTTPS, "https", false, "Serve over HTTPS (self-signed unless -cert/-key given)")
+	flag.StringVar(&certFile, "cert", "", "TLS certificate file (PEM)")
+	flag.StringVar(&keyFile, "key", "", "TLS private key file (PEM)")
+	flag.BoolVar(&noCOI, "no-coi", false, "Disable cross-origin isolation headers (COOP/COEP)")
+	flag.BoolVar(&open, "open", false, "Open the build in the default browser")
+	flag.BoolVar(&verbose, "v", false, "Log every request (default: errors only)")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout when the server stops")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	defer finishJSON(0)
+	if jsonMode {
+		enableJSONOutput("webgl_build_server")
+	}
+
+	root := filepath.Join("Build", "WebGL")
+	if flag.NArg() > 0 {
+		root = flag.Arg(0)
+	}
+	root, _ = filepath.Abs(root)
+
+	printRule("=============================================")
+	fmt.Println(tr("  WebGL Build Server"))
+	printRule("=============================================")
+
+	if info, err := os.Stat(filepath.Join(root, "index.html")); err != nil || info.IsDir() {
+		fmt.Printf(tr("[ERROR] No index.html found in %s\n"), root)
+		recordError("no index.html found in %s", root)
+		fmt.Println(tr("Usage: webgl_build_server [flags] <WebGL build folder>"))
+		exitTool(1)
+	}
+	compression, err := describeBuild(root)
+	if err != nil {
+		fmt.Printf("[WARNING] %v\n", err)
+	}
+	if (certFile == "") != (keyFile == "") {
+		fmt.Println(tr("[ERROR] -cert and -key must be provided together."))
+		recordError("-cert and -key must be provided together")
+		exitTool(1)
+	}
+	if certFile != "" {
+		useHTTPS = true
+	}
+
+	srv := &server{root: root, coi: !noCOI, verbose: verbose, warnedBrFor: make(map[string]bool)}
+	addr := net.JoinHostPort(host, fmt.Sprint(port))
+	httpServer := &http.Server{Addr: addr, Handler: srv, ReadHeaderTimeout: 10 * time.Second}
+
+	scheme := "http"
+	if useHTTPS {
+		scheme = "https"
+	}
+
+	fmt.Printf(tr("  Root:        %s\n"), root)
+	if compression != "" {
+		fmt.Printf(tr("  Compression: %s\n"), compression)
+	}
+	fmt.Printf(tr("  Isolation:   %v (COOP/COEP for WebGL threads)\n"), !noCOI)
+	fmt.Printf(tr("  URL:         %s://localhost:%d/\n"), scheme, port)
+	if host == "0.0.0.0" || host == "" {
+		for _, ip := range localAddresses() {
+			fmt.Printf("               %s://%s:%d/\n", scheme, ip, port)
+		}
+	}
+	if compression == "Brotli (.br)" && !useHTTPS {
+		fmt.Println(tr("\n[TIP] Brotli builds need HTTPS in most browsers. Consider -https."))
+	}
+	fmt.Println(tr("\nPress Ctrl+C to stop."))
+	recordAction("serve", root, "ok", fmt.Sprintf("%s://localhost:%d/", scheme, port), 0)
+
+	// Ctrl+C normally just kills the process; in JSON mode shut down cleanly
+	// so the result document still gets written
+	if jsonMode {
+		go func() {
+			sig := make(chan os.Signal, 1)
+			signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+			<-sig
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			httpServer.Shutdown(ctx)
+		}()
+	}
+
+	if open {
+		go func() {
+			time.Sleep(300 * time.Millisecond)
+			openBrowser(fmt.Sprintf("%s://localhost:%d/", scheme, port))
+		}()
+	}
+
+	if useHTTPS {
+		if certFile != "" {
+			err = httpServer.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			hosts := append([]string{"localhost", "127.0.0.1", "::1"}, localAddresses()...)
+			cert, certErr := selfSignedCert(hosts)
+			if certErr != nil {
+				fmt.Printf(tr("[ERROR] Failed to create self-signed certificate: %v\n"), certErr)
+				recordError("failed to create self-signed certificate: %v", certErr)
+				exitTool(1)
+			}
+			httpServer.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+			fmt.Println(tr("[INFO] Using an in-memory self-signed certificate; accept the browser warning once."))
+			err = httpServer.ListenAndServeTLS("", "")
+		}
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		fmt.Printf("[ERROR] %v\n", err)
+		recordError("%v", err)
+		exitTool(1)
+	}
+}
@@ -2,18 +2,33 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
+	"unicode/utf8"
 )
 
 // ============================================================
@@ -48,6 +63,52 @@ var fileExtensionsToDelete = []string{
 	".vsconfig",
 }
 
+// Clean history, relative to the project root. UserSettings/ is per-developer
+// and never cleaned, unlike Library/ which every clean empties.
+const cleanHistoryFile = "UserSettings/UnityStarter/CleanHistory.json"
+
+// Runs kept in the history file, runs listed by -history, and runs drawn in
+// each sparkline
+const (
+	maxCleanRuns  = 200
+	historyRows   = 10
+	sparklineRuns = 20
+	filesCategory = "files" // history key for the root-level files
+)
+
+// Marker files that keep the folder holding them, and everything below it, out of
+// a clean even when the folder is on the delete list (a committed Build/Tools, a
+// local cache a team wants to survive scheduled cleans)
+var cleanMarkerFiles = []string{".keep", ".noclean"}
+
+// Audio middleware profiles, cleaned only with -middleware. projectDirs are
+// generated folders next to the FMOD Studio / Wwise project file; unityDirs are
+// generated folders inside the Unity project.
+type middlewareProfile struct {
+	name        string
+	projectExt  string   // .fspro / .wproj
+	projectDirs []string // relative to the folder holding the project file
+	unityDirs   []string // relative to the Unity project root
+	markers     []string // integration folders; any of them means the middleware is used
+}
+
+var middlewareProfiles = []middlewareProfile{
+	{
+		name:        "fmod",
+		projectExt:  ".fspro",
+		projectDirs: []string{".cache"},
+		unityDirs:   []string{"Assets/Plugins/FMOD/Cache"},
+		markers:     []string{"Assets/Plugins/FMOD"},
+	},
+	{
+		name:        "wwise",
+		projectExt:  ".wproj",
+		projectDirs: []string{".cache", "GeneratedSoundBanks"},
+		unityDirs:   []string{"Assets/StreamingAssets/Audio/GeneratedSoundBanks"},
+		markers:     []string{"Assets/Wwise"},
+	},
+}
+
 // Global stdin reader to avoid multiple buffered readers competing for stdin
 var stdinReader *bufio.Reader
 
@@ -66,10 +127,33 @@ type EditorInstance struct {
 
 // deleteResult tracks the outcome of a single delete operation
 type deleteResult struct {
-	path    string
-	kind    string // "directory" or "file"
-	size    int64  // size in bytes (0 if unknown)
-	err     error
+	path string
+	kind string // "directory" or "file"
+	size int64  // size in bytes (0 if unknown)
+	err  error
+}
+
+// cleanRun is one entry in the clean history. Keep field names stable: the
+// file accumulates across tool versions.
+type cleanRun struct {
+	RecordedAt string           `json:"recordedAt"`
+	DurationMs int64            `json:"durationMs"`
+	Deleted    int              `json:"deleted"`
+	Failed     int              `json:"failed"`
+	Freed      int64            `json:"freedBytes"`
+	Categories map[string]int64 `json:"categories"` // bytes freed per deleted folder, "files" for the root files
+}
+
+type cleanHistory struct {
+	Runs []cleanRun `json:"runs"`
+}
+
+// bankIssue is a middleware reference in a scene the generated banks do not contain
+type bankIssue struct {
+	scene  string // relative to project root, slash-separated
+	line   int
+	ref    string // what the scene references
+	detail string // why it counts as missing
 }
 
 // ============================================================
@@ -88,6 +172,198 @@ func isUnityProject(basePath string) bool {
 	return true
 }
 
+// ============================================================
+// Commands
+// ============================================================
+
+// commandRunner starts every external program the cleaner uses. The cleaner
+// only asks (is a process running, is the project on a network drive), so there
+// is no Run for commands that change something.
+//
+// Two environment variables swap the runner for tests and bug reports:
+//   - UNITYSTARTER_EXEC_RECORD=<file.json> runs commands as usual and saves each
+//     one with its output and exit code
+//   - UNITYSTARTER_EXEC_REPLAY=<file.json> starts nothing and answers every
+//     command from such a file, so a run can be repeated without the programs
+//
+// The project root and the temp folder are written as $ROOT and $TMP in fixtures
+// so they replay on any machine.
+type commandRunner interface {
+	Output(ctx context.Context, name string, args ...string) (stdout, stderr []byte, err error)
+}
+
+var runner commandRunner = execRunner{}
+
+// setupRunner picks the runner for this run
+func setupRunner(root string) error {
+	var r commandRunner = execRunner{}
+	if path := os.Getenv("UNITYSTARTER_EXEC_REPLAY"); path != "" {
+		replay, err := loadReplayRunner(path, root)
+		if err != nil {
+			return err
+		}
+		r = replay
+	}
+	if path := os.Getenv("UNITYSTARTER_EXEC_RECORD"); path != "" {
+		r = &recordingRunner{inner: r, path: path, hide: fixturePaths(root, false)}
+	}
+	runner = r
+	return nil
+}
+
+// commandLine formats a command the way it could be pasted into a shell
+func commandLine(name string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, a := range append([]string{name}, args...) {
+		if a == "" || strings.ContainsAny(a, " \t\"'") {
+			a = strconv.Quote(a)
+		}
+		parts = append(parts, a)
+	}
+	return strings.Join(parts, " ")
+}
+
+// fixturePaths swaps the machine's paths for $ROOT and $TMP, or back with restore.
+// The longer path goes first so a project inside the temp folder stays $ROOT.
+func fixturePaths(root string, restore bool) *strings.Replacer {
+	paths := [][2]string{{root, "$ROOT"}, {filepath.Clean(os.TempDir()), "$TMP"}}
+	if len(paths[1][0]) > len(paths[0][0]) {
+		paths[0], paths[1] = paths[1], paths[0]
+	}
+	var pairs []string
+	for _, p := range paths {
+		if p[0] == "" || p[0] == "." {
+			continue
+		}
+		if restore {
+			pairs = append(pairs, p[1], p[0])
+		} else {
+			pairs = append(pairs, p[0], p[1])
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// execRunner starts the real programs
+type execRunner struct{}
+
+func (execRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+// commandRecord is one command in a fixture; Exit is -1 when it did not start
+// or was killed, with the reason in Error
+type commandRecord struct {
+	Args   []string `json:"args"`
+	Stdout string   `json:"stdout,omitempty"`
+	Stderr string   `json:"stderr,omitempty"`
+	Exit   int      `json:"exit"`
+	Error  string   `json:"error,omitempty"`
+}
+
+type commandFixture struct {
+	Commands []commandRecord `json:"commands"`
+}
+
+// recordingRunner saves every command to path, rewriting the file after each
+// one so an interrupted run still leaves a usable fixture
+type recordingRunner struct {
+	inner   commandRunner
+	path    string
+	hide    *strings.Replacer
+	mu      sync.Mutex
+	fixture commandFixture
+}
+
+func (r *recordingRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	stdout, stderr, err := r.inner.Output(ctx, name, args...)
+	r.save(name, args, stdout, stderr, err)
+	return stdout, stderr, err
+}
+
+func (r *recordingRunner) save(name string, args []string, stdout, stderr []byte, err error) {
+	rec := commandRecord{Stdout: r.hide.Replace(string(stdout)), Stderr: r.hide.Replace(string(stderr))}
+	for _, a := range append([]string{name}, args...) {
+		rec.Args = append(rec.Args, r.hide.Replace(a))
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		rec.Exit = exitErr.ExitCode()
+	default:
+		rec.Exit = -1
+		rec.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Commands = append(r.fixture.Commands, rec)
+	data, _ := json.MarshalIndent(r.fixture, "", "  ")
+	os.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// replayRunner answers commands from a fixture. A command that appears several
+// times (the same device query, a retried step) gets its recordings in order,
+// the last one repeating once they run out.
+type replayRunner struct {
+	hide    *strings.Replacer
+	show    *strings.Replacer
+	mu      sync.Mutex
+	records map[string][]commandRecord
+}
+
+func loadReplayRunner(path, root string) (*replayRunner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture commandFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r := &replayRunner{hide: fixturePaths(root, false), show: fixturePaths(root, true), records: make(map[string][]commandRecord)}
+	for _, rec := range fixture.Commands {
+		key := strings.Join(rec.Args, "\x00")
+		r.records[key] = append(r.records[key], rec)
+	}
+	return r, nil
+}
+
+func (r *replayRunner) Output(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	var keyArgs []string
+	for _, a := range append([]string{name}, args...) {
+		keyArgs = append(keyArgs, r.hide.Replace(a))
+	}
+	key := strings.Join(keyArgs, "\x00")
+
+	r.mu.Lock()
+	queue := r.records[key]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, nil, fmt.Errorf("no recorded output for: %s", commandLine(name, args))
+	}
+	rec := queue[0]
+	if len(queue) > 1 {
+		r.records[key] = queue[1:]
+	}
+	r.mu.Unlock()
+
+	stdout, stderr := []byte(r.show.Replace(rec.Stdout)), []byte(r.show.Replace(rec.Stderr))
+	switch {
+	case rec.Error != "":
+		return stdout, stderr, errors.New(rec.Error)
+	case rec.Exit != 0:
+		return stdout, stderr, fmt.Errorf("exit status %d", rec.Exit)
+	}
+	return stdout, stderr, nil
+}
+
 // ============================================================
 // Unity Running Detection
 // ============================================================
@@ -118,6 +394,21 @@ func checkUnityRunning(basePath string) (bool, int) {
 	return false, 0
 }
 
+// Longest -wait-pid waits for the editor to save and quit
+const editorExitTimeout = 5 * time.Minute
+
+// waitForProcessExit polls until pid is gone; false when the timeout ran out first
+func waitForProcessExit(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for isProcessRunning(pid) {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Second)
+	}
+	return true
+}
+
 // isProcessRunning checks if a process with the given PID is alive.
 func isProcessRunning(pid int) bool {
 	if runtime.GOOS == "windows" {
@@ -128,8 +419,7 @@ func isProcessRunning(pid int) bool {
 
 // isProcessRunningWindows uses tasklist to check if a PID exists.
 func isProcessRunningWindows(pid int) bool {
-	cmd := exec.Command("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV")
-	output, err := cmd.Output()
+	output, _, err := runner.Output(context.Background(), "tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/NH", "/FO", "CSV")
 	if err != nil {
 		return false
 	}
@@ -153,19 +443,36 @@ func isProcessRunningUnix(pid int) bool {
 // Size Calculation
 // ============================================================
 
-// getDirSize calculates the total size of a directory
-func getDirSize(path string) int64 {
-	var size int64
-	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+// getDirSize calculates the total size of a directory that a clean would free;
+// progress, when set, counts the bytes as they are found. Folders holding a marker
+// file are not measured and come back in kept, path included.
+func getDirSize(path string, progress *progressTask) (size int64, kept []string) {
+	filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil
 		}
-		if !info.IsDir() {
-			size += info.Size()
+		if info.IsDir() {
+			if hasCleanMarker(p) {
+				kept = append(kept, p)
+				return filepath.SkipDir
+			}
+			return nil
 		}
+		size += info.Size()
+		progress.advance(info.Size())
 		return nil
 	})
-	return size
+	return size, kept
+}
+
+// hasCleanMarker reports whether dir holds one of cleanMarkerFiles
+func hasCleanMarker(dir string) bool {
+	for _, name := range cleanMarkerFiles {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
 }
 
 // formatSize formats bytes into human-readable string
@@ -188,317 +495,3227 @@ func formatSize(bytes int64) string {
 }
 
 // ============================================================
-// Preview / Dry-Run
+// Audio Middleware
 // ============================================================
 
-// previewItem represents a file or directory to be deleted
-type previewItem struct {
-	path string
-	kind string // "directory" or "file"
-	size int64
-}
+const fmodSettingsAsset = "Assets/Plugins/FMOD/Resources/FMODStudioSettings.asset"
 
-// collectPreview scans for all items that will be deleted and their sizes
-func collectPreview(basePath string) []previewItem {
-	var items []previewItem
+// Wwise keeps its settings in ProjectSettings since 2021.1, in Assets before
+var wwiseSettingsFiles = []string{"ProjectSettings/WwiseSettings.xml", "Assets/WwiseSettings.xml"}
 
-	// Directories
-	for _, dir := range directoriesToDelete {
-		path := filepath.Join(basePath, dir)
-		info, err := os.Stat(path)
-		if err != nil || !info.IsDir() {
+var (
+	// FMODStudioSettings.asset paths are relative to the project root
+	fmodProjectPathRegex = regexp.MustCompile(`(?m)^\s*SourceProjectPath:\s*(.+?)\s*$`)
+	fmodBankPathRegex    = regexp.MustCompile(`(?m)^\s*SourceBankPath:\s*(.+?)\s*$`)
+	// WwiseSettings.xml paths are relative to Assets/
+	wwiseProjectPathRegex = regexp.MustCompile(`<WwiseProjectPath>\s*(.*?)\s*</WwiseProjectPath>`)
+)
+
+// parseMiddleware resolves -middleware: comma-separated profile names, "auto" for
+// the integrations found in the project, or "none"
+func parseMiddleware(value, basePath string) ([]middlewareProfile, error) {
+	var res []middlewareProfile
+	add := func(p middlewareProfile) {
+		for _, have := range res {
+			if have.name == p.name {
+				return
+			}
+		}
+		res = append(res, p)
+	}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || name == "none" {
 			continue
 		}
-		size := getDirSize(path)
-		items = append(items, previewItem{path: dir, kind: "directory", size: size})
+		found := false
+		for _, p := range middlewareProfiles {
+			if name == p.name || (name == "auto" && middlewareInstalled(basePath, p)) {
+				add(p)
+			}
+			found = found || name == p.name
+		}
+		if !found && name != "auto" {
+			return nil, fmt.Errorf("unknown middleware '%s' (use fmod, wwise, auto or none)", name)
+		}
 	}
+	return res, nil
+}
 
-	// Files
-	extSet := make(map[string]struct{}, len(fileExtensionsToDelete))
-	for _, ext := range fileExtensionsToDelete {
-		extSet[ext] = struct{}{}
+// middlewareInstalled reports whether the project uses the middleware: its Unity
+// integration is installed or its project file is in the project folder
+func middlewareInstalled(basePath string, p middlewareProfile) bool {
+	for _, m := range p.markers {
+		if info, err := os.Stat(filepath.Join(basePath, filepath.FromSlash(m))); err == nil && info.IsDir() {
+			return true
+		}
 	}
+	return len(middlewareProjects(basePath, p)) > 0
+}
 
-	entries, err := os.ReadDir(basePath)
-	if err == nil {
-		for _, entry := range entries {
-			if entry.IsDir() {
-				continue
+// middlewareProjects returns the folders holding the middleware's project file:
+// the one the integration settings name, plus any found up to two levels below
+// the project root
+func middlewareProjects(basePath string, p middlewareProfile) []string {
+	var dirs []string
+	add := func(dir string) {
+		dir = filepath.Clean(dir)
+		for _, d := range dirs {
+			if d == dir {
+				return
 			}
-			ext := filepath.Ext(entry.Name())
-			if _, ok := extSet[ext]; !ok {
+		}
+		dirs = append(dirs, dir)
+	}
+	if ref := middlewareSettingsProject(basePath, p.name); ref != "" {
+		if _, err := os.Stat(ref); err == nil {
+			add(filepath.Dir(ref))
+		}
+	}
+
+	// Unity's own folders never hold a middleware project
+	skip := append([]string{"Assets", "Packages", "ProjectSettings", "UserSettings"}, directoriesToDelete...)
+	var scan func(dir string, depth int)
+	scan = func(dir string, depth int) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if !e.IsDir() {
+				if strings.EqualFold(filepath.Ext(name), p.projectExt) {
+					add(dir)
+				}
 				continue
 			}
-			info, err := entry.Info()
-			if err != nil {
+			if depth >= 2 || strings.HasPrefix(name, ".") || (depth == 0 && containsFold(skip, name)) {
 				continue
 			}
-			items = append(items, previewItem{path: entry.Name(), kind: "file", size: info.Size()})
+			scan(filepath.Join(dir, name), depth+1)
 		}
 	}
-
-	return items
+	scan(basePath, 0)
+	return dirs
 }
 
-// printPreview displays all items that will be deleted
-func printPreview(items []previewItem) {
-	if len(items) == 0 {
-		fmt.Println("\nNothing to clean. Project is already clean.")
-		return
+// middlewareSettingsProject returns the project file the Unity integration is
+// set up with, or "" when its settings name none
+func middlewareSettingsProject(basePath, name string) string {
+	switch name {
+	case "fmod":
+		if data, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(fmodSettingsAsset))); err == nil {
+			if m := fmodProjectPathRegex.FindSubmatch(data); m != nil {
+				return resolveSettingsPath(basePath, string(m[1]))
+			}
+		}
+	case "wwise":
+		for _, f := range wwiseSettingsFiles {
+			if data, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(f))); err == nil {
+				if m := wwiseProjectPathRegex.FindSubmatch(data); m != nil {
+					return resolveSettingsPath(filepath.Join(basePath, "Assets"), string(m[1]))
+				}
+			}
+		}
 	}
+	return ""
+}
 
-	var totalSize int64
-	var dirCount, fileCount int
-
-	fmt.Println("\n=============================================")
-	fmt.Println("  ITEMS TO DELETE")
-	fmt.Println("=============================================")
-
-	fmt.Println("\nDirectories:")
-	for _, item := range items {
-		if item.kind == "directory" {
-			fmt.Printf("  [DIR]  %-30s  %s\n", item.path+"/", formatSize(item.size))
-			totalSize += item.size
-			dirCount++
-		}
+// resolveSettingsPath makes a path from middleware settings absolute; the
+// settings may use either slash
+func resolveSettingsPath(base, p string) string {
+	p = strings.Trim(strings.TrimSpace(p), `"'`)
+	if p == "" {
+		return ""
 	}
-	if dirCount == 0 {
-		fmt.Println("  (none)")
+	p = filepath.FromSlash(strings.ReplaceAll(p, `\`, "/"))
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
 	}
+	return filepath.Join(base, p)
+}
 
-	fmt.Println("\nFiles:")
-	for _, item := range items {
-		if item.kind == "file" {
-			fmt.Printf("  [FILE] %-30s  %s\n", item.path, formatSize(item.size))
-			totalSize += item.size
-			fileCount++
+// middlewareTargets returns the generated middleware folders that exist, relative
+// to the project root. Folders outside the project are reported and left alone,
+// and folders inside one the cleaner deletes anyway are not listed twice.
+func middlewareTargets(basePath string, profiles []middlewareProfile) []string {
+	var targets []string
+	for _, p := range profiles {
+		var candidates []string
+		for _, d := range p.unityDirs {
+			candidates = append(candidates, filepath.Join(basePath, filepath.FromSlash(d)))
+		}
+		for _, project := range middlewareProjects(basePath, p) {
+			for _, d := range p.projectDirs {
+				candidates = append(candidates, filepath.Join(project, d))
+			}
+		}
+		for _, c := range candidates {
+			info, err := os.Stat(c)
+			if err != nil || !info.IsDir() {
+				continue
+			}
+			rel, err := filepath.Rel(basePath, c)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				fmt.Printf(tr("[--] %s: %s is outside the project; not cleaned\n"), p.name, c)
+				continue
+			}
+			rel = filepath.ToSlash(rel)
+			if containsFold(directoriesToDelete, strings.SplitN(rel, "/", 2)[0]) || containsFold(targets, rel) {
+				continue
+			}
+			targets = append(targets, rel)
 		}
 	}
-	if fileCount == 0 {
-		fmt.Println("  (none)")
-	}
-
-	fmt.Printf("\nTotal: %d directories, %d files, %s\n", dirCount, fileCount, formatSize(totalSize))
+	return targets
 }
 
 // ============================================================
-// Delete Operations
+// Bank Validation
 // ============================================================
 
-// tryDelete attempts to delete a path with retries.
-// For permission errors, walks the tree to remove read-only attributes on all files.
-func tryDelete(path string) error {
-	var lastErr error
-	for attempt := 0; attempt < 3; attempt++ {
-		lastErr = os.RemoveAll(path)
-		if lastErr == nil {
-			return nil
-		}
+const wwiseGUIDPattern = `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`
+
+var (
+	// FMOD for Unity EventReference: "Guid:" with Data1..Data4, then "Path:"
+	fmodGUIDDataRegex  = regexp.MustCompile(`^\s*Data([1-4]):\s*(-?\d+)\s*$`)
+	fmodEventPathRegex = regexp.MustCompile(`^\s*Path:\s*(event:/.*?)\s*$`)
+	// GUIDs.txt, written by FMOD Studio's "Export GUIDs": "{guid} event:/path"
+	fmodGUIDsTxtRegex = regexp.MustCompile(`(?m)^\{(` + wwiseGUIDPattern + `)\}`)
+
+	// AK.Wwise.* fields serialize a reference to a WwiseObjectReference asset
+	wwiseRefRegex        = regexp.MustCompile(`WwiseObjectReference:\s*\{fileID:\s*-?\d+,\s*guid:\s*([0-9a-fA-F]{32})`)
+	wwiseObjectNameRegex = regexp.MustCompile(`(?m)^\s*objectName:\s*(.*?)\s*$`)
+	wwiseObjectIDRegex   = regexp.MustCompile(`(?m)^\s*id:\s*(\d+)\s*$`)
+	wwiseObjectGUIDRegex = regexp.MustCompile(`(?m)^\s*guid:\s*\{?(` + wwiseGUIDPattern + `)\}?\s*$`)
+	// SoundbanksInfo.xml attributes and SoundbanksInfo.json keys
+	wwiseInfoGUIDRegex = regexp.MustCompile(`[\s"]GUID"?\s*[=:]\s*"\{?(` + wwiseGUIDPattern + `)\}?"`)
+	wwiseInfoIDRegex   = regexp.MustCompile(`[\s"]Id"?\s*[=:]\s*"(\d+)"`)
+	wwiseInfoNameRegex = regexp.MustCompile(`[\s"]Name"?\s*[=:]\s*"([^"]+)"`)
+
+	metaGUIDRegex = regexp.MustCompile(`(?m)^guid:\s*([0-9a-fA-F]{32})`)
+)
 
-		// On permission error, walk the entire tree and chmod all entries
-		if os.IsPermission(lastErr) {
-			filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
-				if err != nil {
-					return nil
-				}
-				os.Chmod(p, 0777)
-				return nil
-			})
+// validateBanks checks the middleware references of every scene against the
+// generated banks and prints what is missing. Returns the number of issues.
+func validateBanks(basePath string, profiles []middlewareProfile) int {
+	printRule("\n=============================================")
+	fmt.Println(tr("  BANK VALIDATION"))
+	printRule("=============================================")
+	if len(profiles) == 0 {
+		fmt.Println(tr("\nNo FMOD or Wwise integration found; nothing to validate."))
+		return 0
+	}
+
+	var scenes []string
+	filepath.Walk(filepath.Join(basePath, "Assets"), func(p string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && strings.HasSuffix(info.Name(), ".unity") {
+			rel, _ := filepath.Rel(basePath, p)
+			scenes = append(scenes, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(scenes)
+
+	total := 0
+	for _, p := range profiles {
+		var issues []bankIssue
+		var checked int
+		switch p.name {
+		case "fmod":
+			issues, checked = validateFMOD(basePath, p, scenes)
+		case "wwise":
+			issues, checked = validateWwise(basePath, p, scenes)
 		}
+		for _, is := range issues {
+			target := fmt.Sprintf("%s:%d", is.scene, is.line)
+			fmt.Printf(tr("[MISSING] %s  %s (%s)\n"), target, is.ref, is.detail)
+			recordAction("validate", target, "failed", p.name+": "+is.ref+" ("+is.detail+")", 0)
+		}
+		fmt.Printf(tr("[%s] %d reference(s) in %d scene(s) checked, %d missing\n"), p.name, checked, len(scenes), len(issues))
+		total += len(issues)
+	}
+	return total
+}
 
-		// Brief wait for transient file locks (e.g., antivirus, indexer)
-		time.Sleep(100 * time.Millisecond)
+// collectFiles returns the files under the given folders whose name matches
+func collectFiles(dirs []string, match func(name string) bool) []string {
+	var files []string
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && match(info.Name()) && !seen[p] {
+				seen[p] = true
+				files = append(files, p)
+			}
+			return nil
+		})
 	}
-	return lastErr
+	return files
 }
 
-// deleteItems concurrently deletes directories and files, returning results
-// via a channel. Output is collected and printed in order after completion.
-func deleteItems(basePath string, items []previewItem) (deleted int, failed int, freedBytes int64) {
-	if len(items) == 0 {
-		return 0, 0, 0
+// fmodGUID converts the four ints FMOD for Unity serializes into the 16 bytes of
+// an FMOD_GUID (as stored in banks) and its text form
+func fmodGUID(data [4]int64) ([]byte, string) {
+	b := make([]byte, 16)
+	for i, v := range data {
+		binary.LittleEndian.PutUint32(b[i*4:], uint32(int32(v)))
 	}
+	s := fmt.Sprintf("%08x-%04x-%04x-%x-%x", binary.LittleEndian.Uint32(b[0:]), binary.LittleEndian.Uint16(b[4:]), binary.LittleEndian.Uint16(b[6:]), b[8:10], b[10:16])
+	return b, s
+}
 
-	workerCount := runtime.NumCPU() * 2
-	if workerCount < 4 {
-		workerCount = 4
+// validateFMOD checks EventReference GUIDs and StudioBankLoader bank names against
+// the built banks: Build/ of the FMOD Studio project, SourceBankPath from the
+// settings, and StreamingAssets. Event GUIDs are looked up in GUIDs.txt or the
+// strings banks; without either only bank names are checked.
+func validateFMOD(basePath string, p middlewareProfile, scenes []string) ([]bankIssue, int) {
+	dirs := []string{filepath.Join(basePath, "Assets", "StreamingAssets")}
+	for _, project := range middlewareProjects(basePath, p) {
+		dirs = append(dirs, filepath.Join(project, "Build"))
 	}
-	if workerCount > len(items) {
-		workerCount = len(items)
+	if data, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(fmodSettingsAsset))); err == nil {
+		if m := fmodBankPathRegex.FindSubmatch(data); m != nil {
+			if dir := resolveSettingsPath(basePath, string(m[1])); dir != "" {
+				dirs = append(dirs, dir)
+			}
+		}
 	}
 
-	type job struct {
-		item previewItem
+	banks := make(map[string]bool)
+	var stringsBanks [][]byte
+	guids := make(map[string]bool)
+	for _, f := range collectFiles(dirs, func(name string) bool {
+		return strings.HasSuffix(strings.ToLower(name), ".bank") || strings.EqualFold(name, "GUIDs.txt")
+	}) {
+		name := filepath.Base(f)
+		if strings.EqualFold(name, "GUIDs.txt") {
+			if data, err := os.ReadFile(f); err == nil {
+				for _, m := range fmodGUIDsTxtRegex.FindAllSubmatch(data, -1) {
+					guids[strings.ToLower(string(m[1]))] = true
+				}
+			}
+			continue
+		}
+		banks[strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))] = true
+		if strings.HasSuffix(strings.ToLower(name), ".strings.bank") {
+			if data, err := os.ReadFile(f); err == nil {
+				stringsBanks = append(stringsBanks, data)
+			}
+		}
+	}
+	if len(banks) == 0 {
+		fmt.Println(tr("[WARNING] fmod: no built banks found; build them in FMOD Studio first."))
+		return nil, 0
+	}
+	checkEvents := len(guids) > 0 || len(stringsBanks) > 0
+	fmt.Printf(tr("[fmod] %d bank(s) found\n"), len(banks))
+	if !checkEvents {
+		fmt.Println(tr("[WARNING] fmod: no strings bank or GUIDs.txt; event GUIDs are not checked."))
+	}
+	hasEvent := func(raw []byte, text string) bool {
+		if guids[text] {
+			return true
+		}
+		for _, data := range stringsBanks {
+			if bytes.Contains(data, raw) {
+				return true
+			}
+		}
+		return false
 	}
 
-	jobs := make(chan job, len(items))
-	results := make(chan deleteResult, len(items))
-	var wg sync.WaitGroup
-
-	// Start workers
-	for i := 0; i < workerCount; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for j := range jobs {
-				fullPath := filepath.Join(basePath, j.item.path)
-				err := tryDelete(fullPath)
-				results <- deleteResult{
-					path: j.item.path,
-					kind: j.item.kind,
-					size: j.item.size,
-					err:  err,
+	var issues []bankIssue
+	checked := 0
+	for _, scene := range scenes {
+		content, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(scene)))
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+		for i := 0; i < len(lines); i++ {
+			switch strings.TrimSpace(lines[i]) {
+			case "Guid:":
+				if !checkEvents || i+4 >= len(lines) {
+					continue
+				}
+				var data [4]int64
+				ok := true
+				for k := 0; k < 4 && ok; k++ {
+					m := fmodGUIDDataRegex.FindStringSubmatch(lines[i+1+k])
+					ok = m != nil && m[1] == strconv.Itoa(k+1)
+					if ok {
+						data[k], _ = strconv.ParseInt(m[2], 10, 64)
+					}
+				}
+				if !ok || data == [4]int64{} {
+					continue
+				}
+				raw, text := fmodGUID(data)
+				eventPath := ""
+				if i+5 < len(lines) {
+					if m := fmodEventPathRegex.FindStringSubmatch(lines[i+5]); m != nil {
+						eventPath = m[1]
+					}
+				}
+				checked++
+				if !hasEvent(raw, text) {
+					detail := "event not in the built banks"
+					if eventPath != "" {
+						detail = eventPath
+					}
+					issues = append(issues, bankIssue{scene: scene, line: i + 1, ref: "event {" + text + "}", detail: detail})
+				}
+			case "Banks:":
+				// StudioBankLoader: a list of bank names, one "- Name" line each
+				for j := i + 1; j < len(lines); j++ {
+					item := strings.TrimSpace(lines[j])
+					if !strings.HasPrefix(item, "- ") {
+						break
+					}
+					name := strings.Trim(strings.TrimSpace(item[2:]), `"'`)
+					checked++
+					if !banks[strings.ToLower(path.Base(strings.TrimSuffix(name, ".bank")))] {
+						issues = append(issues, bankIssue{scene: scene, line: j + 1, ref: "bank " + name, detail: "no " + path.Base(name) + ".bank was built"})
+					}
 				}
 			}
-		}()
+		}
 	}
+	return issues, checked
+}
 
-	// Enqueue all jobs
-	for _, item := range items {
-		jobs <- job{item: item}
+// validateWwise resolves the WwiseObjectReference assets the scenes use and checks
+// their events and banks against SoundbanksInfo.xml / .json of the generated
+// banks, by GUID, else ID, else name
+func validateWwise(basePath string, p middlewareProfile, scenes []string) ([]bankIssue, int) {
+	var dirs []string
+	for _, d := range p.unityDirs {
+		dirs = append(dirs, filepath.Join(basePath, filepath.FromSlash(d)))
+	}
+	for _, project := range middlewareProjects(basePath, p) {
+		dirs = append(dirs, filepath.Join(project, "GeneratedSoundBanks"))
+	}
+	known := make(map[string]bool) // "guid:", "id:" and "name:" keys
+	infos := collectFiles(dirs, func(name string) bool {
+		return strings.EqualFold(name, "SoundbanksInfo.xml") || strings.EqualFold(name, "SoundbanksInfo.json")
+	})
+	for _, f := range infos {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		for _, m := range wwiseInfoGUIDRegex.FindAllSubmatch(data, -1) {
+			known["guid:"+strings.ToLower(string(m[1]))] = true
+		}
+		for _, m := range wwiseInfoIDRegex.FindAllSubmatch(data, -1) {
+			known["id:"+string(m[1])] = true
+		}
+		for _, m := range wwiseInfoNameRegex.FindAllSubmatch(data, -1) {
+			known["name:"+strings.ToLower(string(m[1]))] = true
+		}
+	}
+	if len(infos) == 0 {
+		fmt.Println(tr("[WARNING] wwise: no SoundbanksInfo.xml or .json found; generate the SoundBanks in Wwise first."))
+		return nil, 0
+	}
+	fmt.Printf(tr("[wwise] %d SoundbanksInfo file(s) found\n"), len(infos))
+
+	// Scene references first, so only the referenced .meta files are read
+	type wwiseRef struct {
+		scene string
+		line  int
+	}
+	refs := make(map[string][]wwiseRef)
+	for _, scene := range scenes {
+		content, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(scene)))
+		if err != nil {
+			continue
+		}
+		for i, line := range strings.Split(string(content), "\n") {
+			if m := wwiseRefRegex.FindStringSubmatch(line); m != nil {
+				guid := strings.ToLower(m[1])
+				refs[guid] = append(refs[guid], wwiseRef{scene, i + 1})
+			}
+		}
+	}
+	assets := make(map[string]string)
+	filepath.Walk(filepath.Join(basePath, "Assets"), func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(info.Name(), ".asset.meta") {
+			return nil
+		}
+		if data, err := os.ReadFile(p); err == nil {
+			if m := metaGUIDRegex.FindSubmatch(data); m != nil && refs[strings.ToLower(string(m[1]))] != nil {
+				assets[strings.ToLower(string(m[1]))] = strings.TrimSuffix(p, ".meta")
+			}
+		}
+		return nil
+	})
+
+	var issues []bankIssue
+	checked := 0
+	for guid, uses := range refs {
+		ref, detail := "object "+guid, ""
+		if asset, ok := assets[guid]; !ok {
+			detail = "WwiseObjectReference asset not found"
+		} else {
+			// The integration keeps one folder per object type: Event, Soundbank, State, ...
+			kind := filepath.Base(filepath.Dir(asset))
+			if !strings.EqualFold(kind, "Event") && !strings.EqualFold(kind, "Soundbank") {
+				continue
+			}
+			data, _ := os.ReadFile(asset)
+			name, id, wguid := "", "", ""
+			if m := wwiseObjectNameRegex.FindSubmatch(data); m != nil {
+				name = string(m[1])
+			}
+			if m := wwiseObjectIDRegex.FindSubmatch(data); m != nil {
+				id = string(m[1])
+			}
+			if m := wwiseObjectGUIDRegex.FindSubmatch(data); m != nil {
+				wguid = strings.ToLower(string(m[1]))
+			}
+			ref = strings.ToLower(kind) + " " + name
+			switch {
+			case wguid != "" && known["guid:"+wguid]:
+			case wguid == "" && id != "" && known["id:"+id]:
+			case wguid == "" && id == "" && name != "" && known["name:"+strings.ToLower(name)]:
+			default:
+				detail = "not in the generated SoundBanks"
+			}
+		}
+		for _, use := range uses {
+			checked++
+			if detail != "" {
+				issues = append(issues, bankIssue{scene: use.scene, line: use.line, ref: ref, detail: detail})
+			}
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].scene != issues[j].scene {
+			return issues[i].scene < issues[j].scene
+		}
+		return issues[i].line < issues[j].line
+	})
+	return issues, checked
+}
+
+// ============================================================
+// Preview / Dry-Run
+// ============================================================
+
+// previewItem represents a file or directory to be deleted
+type previewItem struct {
+	path string
+	kind string   // "directory" or "file"
+	size int64    // bytes freed, without the kept folders
+	keep []string // folders inside it protected by a marker file, relative to project root
+}
+
+// collectPreview scans for all items that will be deleted and their sizes.
+// extraDirs are further folders relative to the project root (middleware output).
+// protected lists the folders marker files keep, relative to the project root.
+func collectPreview(basePath string, extraDirs []string) (items []previewItem, protected []string) {
+
+	// Directories, measured in parallel: Library alone can take a while
+	var dirs []string
+	for _, dir := range append(append([]string{}, directoriesToDelete...), extraDirs...) {
+		info, err := os.Stat(filepath.Join(basePath, dir))
+		if err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	sizes := make([]int64, len(dirs))
+	kept := make([][]string, len(dirs))
+	board := newProgressBoard()
+	scan := board.add(tr("Measuring folders"), int64(len(dirs)))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, runtime.NumCPU())
+	for i, dir := range dirs {
+		wg.Add(1)
+		go func(i int, dir string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			step := board.addSizeStep(dir)
+			sizes[i], kept[i] = getDirSize(filepath.Join(basePath, dir), step)
+			step.finish()
+			scan.advance(1)
+		}(i, dir)
+	}
+	wg.Wait()
+	board.close()
+	for i, dir := range dirs {
+		var keep []string
+		for _, k := range kept[i] {
+			rel, err := filepath.Rel(basePath, k)
+			if err != nil {
+				continue
+			}
+			protected = append(protected, rel)
+			keep = append(keep, rel)
+		}
+		// A marker in the folder itself keeps all of it
+		if len(keep) == 1 && keep[0] == filepath.Clean(dir) {
+			continue
+		}
+		items = append(items, previewItem{path: dir, kind: "directory", size: sizes[i], keep: keep})
+	}
+
+	// Files
+	extSet := make(map[string]struct{}, len(fileExtensionsToDelete))
+	for _, ext := range fileExtensionsToDelete {
+		extSet[ext] = struct{}{}
+	}
+
+	entries, err := os.ReadDir(basePath)
+	if err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			ext := filepath.Ext(entry.Name())
+			if _, ok := extSet[ext]; !ok {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			items = append(items, previewItem{path: entry.Name(), kind: "file", size: info.Size()})
+		}
+	}
+
+	return items, protected
+}
+
+// printPreview displays all items that will be deleted and the folders kept by markers
+func printPreview(items []previewItem, protected []string) {
+	if len(protected) > 0 {
+		fmt.Printf(tr("\nKept by %s marker files:\n"), strings.Join(cleanMarkerFiles, "/"))
+		for _, p := range protected {
+			fmt.Printf("  [KEEP] %s\n", filepath.ToSlash(p)+"/")
+			recordAction("delete", filepath.ToSlash(p), "skipped", "marker file", 0)
+		}
+	}
+	if len(items) == 0 {
+		fmt.Println(tr("\nNothing to clean. Project is already clean."))
+		return
+	}
+
+	var totalSize int64
+	var dirCount, fileCount int
+
+	printRule("\n=============================================")
+	fmt.Println(tr("  ITEMS TO DELETE"))
+	printRule("=============================================")
+
+	fmt.Println(tr("\nDirectories:"))
+	for _, item := range items {
+		if item.kind == "directory" {
+			fmt.Printf("  [DIR]  %-30s  %s\n", item.path+"/", formatSize(item.size))
+			if len(item.keep) > 0 {
+				fmt.Printf(tr("         (except %d kept folder(s) inside)\n"), len(item.keep))
+			}
+			totalSize += item.size
+			dirCount++
+		}
+	}
+	if dirCount == 0 {
+		fmt.Println(tr("  (none)"))
+	}
+
+	fmt.Println(tr("\nFiles:"))
+	for _, item := range items {
+		if item.kind == "file" {
+			fmt.Printf("  [FILE] %-30s  %s\n", item.path, formatSize(item.size))
+			totalSize += item.size
+			fileCount++
+		}
+	}
+	if fileCount == 0 {
+		fmt.Println(tr("  (none)"))
+	}
+
+	fmt.Printf(tr("\nTotal: %d directories, %d files, %s\n"), dirCount, fileCount, formatSize(totalSize))
+}
+
+// ============================================================
+// Delete Operations
+// ============================================================
+
+// tryDelete attempts to delete a path with retries.
+// For permission errors, walks the tree to remove read-only attributes on all files.
+func tryDelete(path string) error {
+	removeAll := fsys.RemoveAll
+	if throttled && !isDryRun() {
+		removeAll = removeAllPaced
+	}
+	var lastErr error
+	for attempt := 0; attempt < 3; attempt++ {
+		lastErr = removeAll(path)
+		if lastErr == nil {
+			return nil
+		}
+
+		// On permission error, walk the entire tree and chmod all entries
+		if os.IsPermission(lastErr) {
+			fsys.Walk(path, func(p string, info os.FileInfo, err error) error {
+				if err != nil {
+					return nil
+				}
+				fsys.Chmod(p, 0777)
+				return nil
+			})
+		}
+
+		// Brief wait for transient file locks (e.g., antivirus, indexer)
+		time.Sleep(100 * time.Millisecond)
+	}
+	return lastErr
+}
+
+// deleteAround deletes everything in dir except the kept folders (absolute paths)
+// and the folders on the way to them
+func deleteAround(dir string, keep []string) error {
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	for _, entry := range entries {
+		p := filepath.Join(dir, entry.Name())
+		kept, onTheWay := false, false
+		for _, k := range keep {
+			if k == p {
+				kept = true
+			} else if isUnder(k, p) {
+				onTheWay = true
+			}
+		}
+		switch {
+		case kept:
+			continue
+		case onTheWay:
+			err = deleteAround(p, keep)
+		default:
+			err = tryDelete(p)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// deleteItems concurrently deletes directories and files, returning results
+// via a channel. Output is collected and printed in order after completion.
+// freedBy splits the freed bytes by folder, with the root files as one entry.
+func deleteItems(basePath string, items []previewItem) (deleted int, failed int, freedBytes int64, freedBy map[string]int64) {
+	freedBy = make(map[string]int64)
+	if len(items) == 0 {
+		return 0, 0, 0, freedBy
+	}
+
+	workerCount := runtime.NumCPU() * 2
+	if workerCount < 4 {
+		workerCount = 4
+	}
+	if throttled {
+		workerCount = throttleWorkers
+	}
+	if workerCount > len(items) {
+		workerCount = len(items)
+	}
+
+	type job struct {
+		item previewItem
+	}
+
+	jobs := make(chan job, len(items))
+	results := make(chan deleteResult, len(items))
+	var wg sync.WaitGroup
+
+	// One bar for the bytes to free, plus a line per item being deleted
+	var totalSize int64
+	for _, item := range items {
+		totalSize += item.size
+	}
+	board := newProgressBoard()
+	overall := board.addSize(tr("Deleting"), totalSize)
+
+	// Start workers
+	for i := 0; i < workerCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				fullPath := filepath.Join(basePath, j.item.path)
+				step := board.addStep(j.item.path)
+				var err error
+				if len(j.item.keep) > 0 {
+					var keep []string
+					for _, k := range j.item.keep {
+						keep = append(keep, filepath.Join(basePath, k))
+					}
+					err = deleteAround(fullPath, keep)
+				} else {
+					err = tryDelete(fullPath)
+				}
+				step.finish()
+				results <- deleteResult{
+					path: j.item.path,
+					kind: j.item.kind,
+					size: j.item.size,
+					err:  err,
+				}
+			}
+		}()
+	}
+
+	// Enqueue all jobs
+	for _, item := range items {
+		jobs <- job{item: item}
 	}
 	close(jobs)
 
-	// Wait for all workers, then close results
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+	// Wait for all workers, then close results
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Collect and print results in arrival order; lines go through the board
+	// so they land above the progress bars
+	var deletedCount, failedCount int
+	var totalFreed int64
+	for r := range results {
+		if r.err != nil {
+			overall.fail()
+			board.printf("[FAIL] %s: %v\n", r.path, r.err)
+			recordAction("delete", r.path, "failed", r.err.Error(), 0)
+			recordError("%s: %v", r.path, r.err)
+			failedCount++
+		} else {
+			overall.advance(r.size)
+			board.printf(tr("[OK]   Deleted %s: %s (%s)\n"), tr(r.kind), r.path, formatSize(r.size))
+			recordAction("delete", r.path, "ok", fmt.Sprintf("%s, %d bytes", r.kind, r.size), 0)
+			deletedCount++
+			totalFreed += r.size
+			if r.kind == "file" {
+				freedBy[filesCategory] += r.size
+			} else {
+				freedBy[filepath.ToSlash(r.path)] += r.size
+			}
+		}
+	}
+	board.close()
+
+	return deletedCount, failedCount, totalFreed, freedBy
+}
+
+// ============================================================
+// Network Drives (-throttle)
+// ============================================================
+
+// File systems served by another machine. Deleting a Library folder on one of
+// them at full speed can saturate the share for everyone else using it.
+var networkFSTypes = map[string]bool{
+	"nfs":            true,
+	"nfs4":           true,
+	"cifs":           true,
+	"smb":            true,
+	"smb2":           true,
+	"smb3":           true,
+	"smbfs":          true,
+	"afpfs":          true,
+	"webdav":         true,
+	"davfs":          true,
+	"afs":            true,
+	"ceph":           true,
+	"glusterfs":      true,
+	"fuse.glusterfs": true,
+	"fuse.sshfs":     true,
+	"fuse.rclone":    true,
+}
+
+const (
+	throttleWorkers = 1                     // items deleted at the same time
+	throttleBatch   = 200                   // entries removed between pauses
+	throttlePause   = 50 * time.Millisecond // rest after each batch
+)
+
+// throttled paces deletion, one item at a time with pauses; set from -throttle
+var throttled bool
+
+var mountLineRegex = regexp.MustCompile(`^.+ on (.+) \(([^,)]+)`)
+
+// resolveThrottle turns the -throttle value into a decision: on, off, or auto
+// (on only for a project on a network file system, which is returned)
+func resolveThrottle(mode, basePath string) (bool, string, error) {
+	switch mode {
+	case "on":
+		return true, "", nil
+	case "off":
+		return false, "", nil
+	case "auto":
+		share := networkFileSystem(basePath)
+		return share != "", share, nil
+	}
+	return false, "", fmt.Errorf("-throttle must be auto, on or off, not '%s'", mode)
+}
+
+// networkFileSystem describes the network share holding dir, or returns "" for
+// a local disk and whenever it cannot be told
+func networkFileSystem(dir string) string {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+
+	if runtime.GOOS == "windows" {
+		vol := filepath.VolumeName(dir)
+		if strings.HasPrefix(vol, `\\`) {
+			return vol
+		}
+		if vol == "" {
+			return ""
+		}
+		out, _, err := runner.Output(context.Background(), "powershell", "-NoProfile", "-NonInteractive", "-Command", "([System.IO.DriveInfo]'"+vol+`\').DriveType`)
+		if err == nil && strings.TrimSpace(string(out)) == "Network" {
+			return fmt.Sprintf(tr("mapped drive %s"), vol)
+		}
+		return ""
+	}
+
+	// Mount points and their types: /proc on Linux, the mount command elsewhere
+	type mount struct{ dir, fsType string }
+	var mounts []mount
+	if data, err := os.ReadFile("/proc/self/mounts"); err == nil {
+		unescape := strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`)
+		for _, line := range strings.Split(string(data), "\n") {
+			if fields := strings.Fields(line); len(fields) >= 3 {
+				mounts = append(mounts, mount{unescape.Replace(fields[1]), fields[2]})
+			}
+		}
+	} else if out, _, err := runner.Output(context.Background(), "mount"); err == nil {
+		for _, line := range strings.Split(string(out), "\n") {
+			if m := mountLineRegex.FindStringSubmatch(line); m != nil {
+				mounts = append(mounts, mount{m[1], m[2]})
+			}
+		}
+	}
+
+	// The deepest mount point containing dir is the one it lives on
+	var best mount
+	for _, m := range mounts {
+		if isUnder(dir, m.dir) && len(m.dir) >= len(best.dir) {
+			best = m
+		}
+	}
+	if !networkFSTypes[best.fsType] {
+		return ""
+	}
+	return fmt.Sprintf("%s %s", best.fsType, best.dir)
+}
+
+// removeAllPaced removes path bottom-up, resting after every throttleBatch
+// entries so other users of the share are not starved
+func removeAllPaced(path string) error {
+	var paths []string
+	err := fsys.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		paths = append(paths, p)
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// Walk lists a folder before its contents, so going backwards empties folders first
+	for i := len(paths) - 1; i >= 0; i-- {
+		if err := fsys.Remove(paths[i]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if (len(paths)-i)%throttleBatch == 0 {
+			time.Sleep(throttlePause)
+		}
+	}
+	return nil
+}
+
+// ============================================================
+// Clean History (-history)
+// ============================================================
+
+func loadCleanHistory(path string) (*cleanHistory, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &cleanHistory{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var h cleanHistory
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("invalid history file %s: %v", path, err)
+	}
+	return &h, nil
+}
+
+// recordCleanRun appends a finished clean to the history, dropping the oldest
+// runs beyond maxCleanRuns
+func recordCleanRun(basePath string, run cleanRun) error {
+	path := filepath.Join(basePath, filepath.FromSlash(cleanHistoryFile))
+	h, err := loadCleanHistory(path)
+	if err != nil {
+		return err
+	}
+	h.Runs = append(h.Runs, run)
+	if len(h.Runs) > maxCleanRuns {
+		h.Runs = h.Runs[len(h.Runs)-maxCleanRuns:]
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, _ := json.MarshalIndent(h, "", "  ")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// sparkline draws values as block characters scaled to the largest one
+func sparkline(values []int64) string {
+	levels := []rune("▁▂▃▄▅▆▇█")
+	var top int64
+	for _, v := range values {
+		if v > top {
+			top = v
+		}
+	}
+	var sb strings.Builder
+	for _, v := range values {
+		i := 0
+		if top > 0 {
+			i = int(v * int64(len(levels)-1) / top)
+		}
+		sb.WriteRune(levels[i])
+	}
+	return sb.String()
+}
+
+// printCleanHistory shows what earlier cleans freed per folder, so it is clear
+// whether Library/ growth or build output is what fills the disk
+func printCleanHistory(basePath string) error {
+	h, err := loadCleanHistory(filepath.Join(basePath, filepath.FromSlash(cleanHistoryFile)))
+	if err != nil {
+		return err
+	}
+	if len(h.Runs) == 0 {
+		fmt.Printf(tr("\nNo cleans recorded yet in %s.\n"), cleanHistoryFile)
+		return nil
+	}
+	runs := h.Runs
+	fmt.Printf(tr("\nClean history: %d run(s) since %s (%s)\n"), len(runs), runs[0].RecordedAt, cleanHistoryFile)
+
+	recent := runs
+	if len(recent) > sparklineRuns {
+		recent = recent[len(recent)-sparklineRuns:]
+	}
+	type categoryTrend struct {
+		name        string
+		last, total int64
+		series      []int64
+	}
+	totals := &categoryTrend{name: tr("Total")}
+	byName := make(map[string]*categoryTrend)
+	var trends []*categoryTrend
+	for i, r := range runs {
+		totals.total += r.Freed
+		for name, size := range r.Categories {
+			t := byName[name]
+			if t == nil {
+				t = &categoryTrend{name: name}
+				byName[name] = t
+				trends = append(trends, t)
+			}
+			t.total += size
+		}
+		if i == len(runs)-1 {
+			totals.last = r.Freed
+			for name, size := range r.Categories {
+				byName[name].last = size
+			}
+		}
+	}
+	for _, r := range recent {
+		totals.series = append(totals.series, r.Freed)
+		for _, t := range trends {
+			t.series = append(t.series, r.Categories[t.name])
+		}
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].total != trends[j].total {
+			return trends[i].total > trends[j].total
+		}
+		return trends[i].name < trends[j].name
+	})
+	if t, ok := byName[filesCategory]; ok {
+		t.name = tr("Root files")
+	}
+
+	fmt.Printf("\n  %-30s %10s %10s %10s", tr("FOLDER"), tr("LAST"), tr("AVERAGE"), tr("TOTAL"))
+	if !plainMode {
+		fmt.Printf("  %s", fmt.Sprintf(tr("LAST %d"), len(recent)))
+	}
+	fmt.Println()
+	for _, t := range append(trends, totals) {
+		fmt.Printf("  %-30s %10s %10s %10s", t.name, formatSize(t.last), formatSize(t.total/int64(len(runs))), formatSize(t.total))
+		if !plainMode {
+			fmt.Printf("  %s", sparkline(t.series))
+		}
+		fmt.Println()
+	}
+
+	tail := runs
+	if len(tail) > historyRows {
+		tail = tail[len(tail)-historyRows:]
+	}
+	fmt.Printf("\n  %-20s %10s %8s %8s %10s\n", tr("RECORDED"), tr("FREED"), tr("ITEMS"), tr("FAILED"), tr("TIME"))
+	var totalMs int64
+	for _, r := range runs {
+		totalMs += r.DurationMs
+	}
+	for _, r := range tail {
+		fmt.Printf("  %-20s %10s %8d %8d %10s\n", r.RecordedAt, formatSize(r.Freed), r.Deleted, r.Failed, (time.Duration(r.DurationMs) * time.Millisecond).Round(100*time.Millisecond))
+	}
+	fmt.Printf(tr("\nAverage clean: %s freed in %s\n"), formatSize(totals.total/int64(len(runs))), (time.Duration(totalMs/int64(len(runs))) * time.Millisecond).Round(100*time.Millisecond))
+	return nil
+}
+
+// ============================================================
+// Utilities
+// ============================================================
+
+func waitForKeyPress() {
+	if jsonMode {
+		return
+	}
+	fmt.Println(tr("\nPress Enter to continue..."))
+	stdinReader.ReadBytes('\n')
+}
+
+// ============================================================
+// Filesystem
+// ============================================================
+
+// fileSystem is every filesystem call that can change the project. The real
+// run uses osFS; -dry-run swaps in an overlayFS so the same code path runs
+// and the overlay reports what it would have changed.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Walk(root string, fn filepath.WalkFunc) error
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Chmod(name string, mode os.FileMode) error
+}
+
+var fsys fileSystem = osFS{}
+
+// isDryRun reports whether changes are going to the in-memory overlay
+func isDryRun() bool {
+	_, ok := fsys.(*overlayFS)
+	return ok
+}
+
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error)        { return os.Stat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)         { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]os.DirEntry, error)   { return os.ReadDir(name) }
+func (osFS) Walk(root string, fn filepath.WalkFunc) error { return filepath.Walk(root, fn) }
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+func (osFS) Rename(oldpath, newpath string) error         { return os.Rename(oldpath, newpath) }
+func (osFS) Remove(name string) error                     { return os.Remove(name) }
+func (osFS) RemoveAll(path string) error                  { return os.RemoveAll(path) }
+func (osFS) Chmod(name string, mode os.FileMode) error    { return os.Chmod(name, mode) }
+func (osFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// fsChange is one change the overlay absorbed instead of the disk
+type fsChange struct {
+	op     string // "write", "create", "mkdir", "rename", "delete"
+	path   string
+	detail string
+}
+
+// overlayFS reads through to the disk and keeps every write, rename and
+// delete in memory. Later reads see those changes, so multi-step operations
+// (rename a folder, then rewrite files inside it) behave as they would for real.
+type overlayFS struct {
+	mu      sync.Mutex
+	files   map[string][]byte // files written during the run
+	dirs    map[string]bool   // directories created during the run
+	moved   map[string]string // renamed path -> where its content is on disk
+	removed map[string]bool   // deleted paths; hides everything below them
+	changes []fsChange
+}
+
+func newOverlayFS() *overlayFS {
+	return &overlayFS{
+		files:   map[string][]byte{},
+		dirs:    map[string]bool{},
+		moved:   map[string]string{},
+		removed: map[string]bool{},
+	}
+}
+
+func overlayKey(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// isUnder reports whether p is base or inside it
+func isUnder(p, base string) bool {
+	return p == base || strings.HasPrefix(p, strings.TrimSuffix(base, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// locate finds where the content of p lives: in memory (data / memDir) or
+// on disk (disk, after following renames). ok is false if p was deleted.
+func (o *overlayFS) locate(p string) (data []byte, memFile, memDir bool, disk string, ok bool) {
+	if d, found := o.files[p]; found {
+		return d, true, false, "", true
+	}
+	if o.dirs[p] {
+		return nil, false, true, "", true
+	}
+	for q := p; ; {
+		if src, found := o.moved[q]; found {
+			rel, _ := filepath.Rel(q, p)
+			return nil, false, false, filepath.Join(src, rel), true
+		}
+		if o.removed[q] {
+			return nil, false, false, "", false
+		}
+		parent := filepath.Dir(q)
+		if parent == q {
+			break
+		}
+		q = parent
+	}
+	return nil, false, false, p, true
+}
+
+func (o *overlayFS) stat(p string) (os.FileInfo, error) {
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	case memFile:
+		return memFileInfo{name: filepath.Base(p), size: int64(len(data))}, nil
+	case memDir:
+		return memFileInfo{name: filepath.Base(p), dir: true}, nil
+	}
+	info, err := os.Stat(disk)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+	}
+	if disk != p {
+		return renamedFileInfo{info, filepath.Base(p)}, nil
+	}
+	return info, nil
+}
+
+func (o *overlayFS) Stat(name string) (os.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stat(overlayKey(name))
+}
+
+func (o *overlayFS) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	data, memFile, memDir, disk, ok := o.locate(p)
+	switch {
+	case !ok:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case memDir:
+		return nil, &os.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	case memFile:
+		return append([]byte(nil), data...), nil
+	}
+	return os.ReadFile(disk)
+}
+
+func (o *overlayFS) readDir(p string) ([]os.DirEntry, error) {
+	_, memFile, memDir, disk, ok := o.locate(p)
+	if !ok || memFile {
+		return nil, &os.PathError{Op: "readdir", Path: p, Err: os.ErrNotExist}
+	}
+	names := map[string]bool{}
+	if !memDir {
+		entries, err := os.ReadDir(disk)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			names[e.Name()] = true
+		}
+	}
+	for k := range o.dirs {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.files {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	for k := range o.moved {
+		if filepath.Dir(k) == p {
+			names[filepath.Base(k)] = true
+		}
+	}
+	var result []os.DirEntry
+	for name := range names {
+		if info, err := o.stat(filepath.Join(p, name)); err == nil {
+			result = append(result, fs.FileInfoToDirEntry(info))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (o *overlayFS) ReadDir(name string) ([]os.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.readDir(overlayKey(name))
+}
+
+// Walk follows filepath.Walk: lexical order, SkipDir and SkipAll honored.
+// The lock is released while fn runs so the callback can change the overlay.
+func (o *overlayFS) Walk(root string, fn filepath.WalkFunc) error {
+	info, err := o.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = o.walk(root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (o *overlayFS) walk(path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+	entries, err := o.ReadDir(path)
+	if err1 := fn(path, info, err); err != nil || err1 != nil {
+		return err1
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		childInfo, err := o.Stat(child)
+		if err != nil {
+			if err := fn(child, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := o.walk(child, childInfo, fn); err != nil {
+			if err == filepath.SkipDir && childInfo.IsDir() {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *overlayFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	op := "create"
+	if info, err := o.stat(p); err == nil {
+		if info.IsDir() {
+			return &os.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+		}
+		op = "write"
+	}
+	if info, err := o.stat(filepath.Dir(p)); err != nil || !info.IsDir() {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	o.files[p] = append([]byte(nil), data...)
+	o.changes = append(o.changes, fsChange{op, p, fmt.Sprintf("%d bytes", len(data))})
+	return nil
+}
+
+func (o *overlayFS) MkdirAll(path string, perm os.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	created := false
+	for q := p; ; q = filepath.Dir(q) {
+		if info, err := o.stat(q); err == nil {
+			if !info.IsDir() {
+				return &os.PathError{Op: "mkdir", Path: q, Err: errors.New("not a directory")}
+			}
+			break
+		}
+		o.dirs[q] = true
+		created = true
+		if filepath.Dir(q) == q {
+			break
+		}
+	}
+	if created {
+		o.changes = append(o.changes, fsChange{"mkdir", p, ""})
+	}
+	return nil
+}
+
+// shift moves every overlay entry under from to the same place under to
+func (o *overlayFS) shift(from, to string) {
+	for k, v := range o.files {
+		if isUnder(k, from) {
+			delete(o.files, k)
+			o.files[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, from) {
+			delete(o.dirs, k)
+			o.dirs[to+strings.TrimPrefix(k, from)] = true
+		}
+	}
+	for k, v := range o.moved {
+		if isUnder(k, from) {
+			delete(o.moved, k)
+			o.moved[to+strings.TrimPrefix(k, from)] = v
+		}
+	}
+}
+
+func (o *overlayFS) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	from, to := overlayKey(oldpath), overlayKey(newpath)
+	_, _, _, disk, ok := o.locate(from)
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if from == to {
+		return nil
+	}
+	if info, err := o.stat(filepath.Dir(to)); err != nil || !info.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	o.drop(to)
+	o.shift(from, to)
+	if disk != "" {
+		if _, err := os.Stat(disk); err == nil {
+			o.moved[to] = disk
+		}
+	}
+	o.removed[from] = true
+	o.changes = append(o.changes, fsChange{"rename", from, to})
+	return nil
+}
+
+// drop forgets p and everything below it and hides it from the disk
+func (o *overlayFS) drop(p string) {
+	for k := range o.files {
+		if isUnder(k, p) {
+			delete(o.files, k)
+		}
+	}
+	for k := range o.dirs {
+		if isUnder(k, p) {
+			delete(o.dirs, k)
+		}
+	}
+	for k := range o.moved {
+		if isUnder(k, p) {
+			delete(o.moved, k)
+		}
+	}
+	o.removed[p] = true
+}
+
+// treeSize is the byte size of p as the overlay currently sees it
+func (o *overlayFS) treeSize(p string) int64 {
+	info, err := o.stat(p)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		return info.Size()
+	}
+	var size int64
+	entries, _ := o.readDir(p)
+	for _, e := range entries {
+		size += o.treeSize(filepath.Join(p, e.Name()))
+	}
+	return size
+}
+
+func (o *overlayFS) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(name)
+	info, err := o.stat(p)
+	if err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	if info.IsDir() {
+		if entries, _ := o.readDir(p); len(entries) > 0 {
+			return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", info.Size())})
+	return nil
+}
+
+func (o *overlayFS) RemoveAll(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	p := overlayKey(path)
+	if _, err := o.stat(p); err != nil {
+		return nil
+	}
+	size := o.treeSize(p)
+	o.drop(p)
+	o.changes = append(o.changes, fsChange{"delete", p, fmt.Sprintf("%d bytes", size)})
+	return nil
+}
+
+func (o *overlayFS) Chmod(name string, mode os.FileMode) error {
+	if _, err := o.Stat(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+// printDryRunReport lists what the overlay absorbed, relative to base
+func (o *overlayFS) printDryRunReport(base string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if abs, err := filepath.Abs(base); err == nil {
+		base = abs
+	}
+	if len(o.changes) == 0 {
+		fmt.Println(tr("\n[Dry Run] No changes would be made."))
+		return
+	}
+	fmt.Printf(tr("\n[Dry Run] %d change(s) would be made; nothing was written:\n"), len(o.changes))
+	rel := func(p string) string {
+		if r, err := filepath.Rel(base, p); err == nil && !strings.HasPrefix(r, "..") {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	for _, c := range o.changes {
+		switch c.op {
+		case "rename":
+			fmt.Printf("  %-7s %s -> %s\n", c.op, rel(c.path), rel(c.detail))
+		case "mkdir":
+			fmt.Printf("  %-7s %s\n", c.op, rel(c.path))
+		default:
+			fmt.Printf("  %-7s %s (%s)\n", c.op, rel(c.path), c.detail)
+		}
+	}
+}
+
+// memFileInfo describes a file or directory that only exists in the overlay
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (m memFileInfo) Name() string       { return m.name }
+func (m memFileInfo) Size() int64        { return m.size }
+func (m memFileInfo) ModTime() time.Time { return time.Time{} }
+func (m memFileInfo) IsDir() bool        { return m.dir }
+func (m memFileInfo) Sys() interface{}   { return nil }
+func (m memFileInfo) Mode() os.FileMode {
+	if m.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+// renamedFileInfo is a disk entry seen under the name it was renamed to
+type renamedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (r renamedFileInfo) Name() string { return r.name }
+
+// ============================================================
+// Progress
+// ============================================================
+
+// progressBoard shows the progress of work spread over goroutines: one line
+// per task, a bar when the task's total is known and a spinner when it is not.
+// Every method is safe to call from any goroutine; log lines printed through
+// printf appear above the bars instead of tearing them.
+//
+// How it draws depends on where stdout goes:
+//   - a terminal with ANSI support: every task on its own line, redrawn in place
+//   - the classic Windows console: one line redrawn with \r, showing the first
+//     running task and the item the first step is working on
+//   - a file, a pipe or -plain: nothing is redrawn; each bar prints a line per
+//     10%, and a task without a total prints a "still running" line every 15
+//     seconds in place of the spinner
+//
+// close stops drawing and leaves one line per task as the summary.
+type progressBoard struct {
+	mu    sync.Mutex
+	tasks []*progressTask
+	mode  int
+	drawn int // progressLines: lines drawn; progressLine: width of the line
+	frame int
+	stop  chan struct{}
+	wg    sync.WaitGroup
+	done  sync.Once // guards close
+}
+
+// progressTask is one line of the board. Steps are short-lived items (a file
+// being converted, a folder being deleted) shown while they run and left out
+// of the summary.
+type progressTask struct {
+	board   *progressBoard
+	name    string
+	current int64
+	total   int64 // 0 when unknown
+	bytes   bool  // current and total are sizes
+	failed  int
+	step    bool
+	started time.Time
+	ended   time.Time
+	logged  int       // progressLog: last 10% step printed
+	beat    time.Time // progressLog: last "still running" line
+}
+
+const (
+	progressLines = iota // ANSI terminal
+	progressLine         // terminal without cursor movement
+	progressLog          // no terminal, or plain mode
+)
+
+const (
+	progressRedraw    = 100 * time.Millisecond
+	progressHeartbeat = 15 * time.Second
+	progressBarWidth  = 30
+	progressNameWidth = 24
+	progressMaxSteps  = 8  // step lines shown at once; the rest are counted
+	progressLineWidth = 79 // progressLine: longer lines wrap and break \r
+)
+
+var progressSpinner = []string{"|", "/", "-", `\`}
+
+// detectProgressMode picks how the board draws on the current stdout
+func detectProgressMode() int {
+	if plainMode {
+		return progressLog
+	}
+	info, err := os.Stdout.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return progressLog
+	}
+	// Windows Terminal, ConEmu and terminals inside editors handle escapes;
+	// the classic console only does when a program switches it on
+	if runtime.GOOS != "windows" || os.Getenv("WT_SESSION") != "" || os.Getenv("TERM_PROGRAM") != "" || os.Getenv("ConEmuANSI") == "ON" {
+		return progressLines
+	}
+	return progressLine
+}
+
+func newProgressBoard() *progressBoard {
+	b := &progressBoard{mode: detectProgressMode(), stop: make(chan struct{})}
+	interval := progressRedraw
+	if b.mode == progressLog {
+		interval = time.Second
+	}
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-b.stop:
+				return
+			case <-ticker.C:
+				b.mu.Lock()
+				b.frame++
+				b.render()
+				b.mu.Unlock()
+			}
+		}
+	}()
+	return b
+}
+
+// add starts a task of total items; 0 draws a spinner until setTotal
+func (b *progressBoard) add(name string, total int64) *progressTask {
+	return b.addTask(&progressTask{name: name, total: total})
+}
+
+// addSize starts a task measured in bytes
+func (b *progressBoard) addSize(name string, total int64) *progressTask {
+	return b.addTask(&progressTask{name: name, total: total, bytes: true})
+}
+
+// addStep shows one item while it is being worked on; call finish when done
+func (b *progressBoard) addStep(name string) *progressTask {
+	return b.addTask(&progressTask{name: name, step: true})
+}
+
+// addSizeStep is a step that counts bytes, such as a folder being measured
+func (b *progressBoard) addSizeStep(name string) *progressTask {
+	return b.addTask(&progressTask{name: name, step: true, bytes: true})
+}
+
+func (b *progressBoard) addTask(t *progressTask) *progressTask {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t.board = b
+	t.started = time.Now()
+	t.beat = t.started
+	b.tasks = append(b.tasks, t)
+	return t
+}
+
+// printf prints a log line above the board
+func (b *progressBoard) printf(format string, a ...interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.erase()
+	fmt.Printf(format, a...)
+	b.render()
+}
+
+// close stops drawing and prints the final line of every task that is not a step.
+// Only the first call does anything; concurrent callers wait for it to finish.
+func (b *progressBoard) close() {
+	b.done.Do(func() {
+		close(b.stop)
+		b.wg.Wait()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.erase()
+		for _, t := range b.tasks {
+			if !t.step {
+				fmt.Println(t.line(b.frame, true))
+			}
+		}
+	})
+}
+
+func (t *progressTask) advance(n int64) {
+	if t == nil {
+		return
+	}
+	t.board.mu.Lock()
+	t.current += n
+	t.board.mu.Unlock()
+}
+
+func (t *progressTask) setTotal(total int64) {
+	if t == nil {
+		return
+	}
+	t.board.mu.Lock()
+	t.total = total
+	t.board.mu.Unlock()
+}
+
+// fail counts a failed item; failures show on the task's line
+func (t *progressTask) fail() {
+	if t == nil {
+		return
+	}
+	t.board.mu.Lock()
+	t.failed++
+	t.board.mu.Unlock()
+}
+
+func (t *progressTask) finish() {
+	if t == nil {
+		return
+	}
+	t.board.mu.Lock()
+	defer t.board.mu.Unlock()
+	if t.ended.IsZero() {
+		t.ended = time.Now()
+	}
+	if t.step {
+		b := t.board
+		for i, other := range b.tasks {
+			if other == t {
+				b.tasks = append(b.tasks[:i], b.tasks[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// amount is "12/40", "1.20 GB/3.00 GB" or, without a total, the count so far
+func (t *progressTask) amount() string {
+	format := func(n int64) string {
+		if t.bytes {
+			return formatSize(n)
+		}
+		return strconv.FormatInt(n, 10)
+	}
+	if t.total > 0 {
+		return format(t.current) + "/" + format(t.total)
+	}
+	return format(t.current)
+}
+
+// line renders the task; final is the summary form after close
+func (t *progressTask) line(frame int, final bool) string {
+	name := t.name
+	if r := []rune(name); len(r) > progressNameWidth {
+		name = "..." + string(r[len(r)-progressNameWidth+3:])
+	}
+	var sb strings.Builder
+	if t.board.mode == progressLog {
+		sb.WriteString(name + ": ")
+	} else {
+		fmt.Fprintf(&sb, "%-*s ", progressNameWidth, name)
+	}
+	end := t.ended
+	if end.IsZero() {
+		end = time.Now()
+	}
+	elapsed := end.Sub(t.started).Round(100 * time.Millisecond)
+	switch {
+	case t.step:
+		sb.WriteString(progressSpinner[frame%len(progressSpinner)])
+		if t.current > 0 {
+			sb.WriteString(" " + t.amount())
+		}
+		sb.WriteString(" " + elapsed.Round(time.Second).String())
+		return sb.String()
+	case t.total > 0 && t.board.mode == progressLog:
+		// Screen readers and log viewers get the numbers without the bar
+		fmt.Fprintf(&sb, "%s (%.0f%%)", t.amount(), math.Min(float64(t.current)/float64(t.total), 1)*100)
+	case t.total > 0:
+		percent := math.Min(float64(t.current)/float64(t.total), 1)
+		filled := int(percent * progressBarWidth)
+		fmt.Fprintf(&sb, "[%s%s] %3.0f%% (%s)", strings.Repeat("█", filled), strings.Repeat("-", progressBarWidth-filled), percent*100, t.amount())
+	case final || !t.ended.IsZero():
+		fmt.Fprintf(&sb, tr("done, %s"), t.amount())
+	default:
+		fmt.Fprintf(&sb, "%s %s", progressSpinner[frame%len(progressSpinner)], t.amount())
+	}
+	if t.failed > 0 {
+		fmt.Fprintf(&sb, tr(", %d failed"), t.failed)
+	}
+	if final || !t.ended.IsZero() {
+		fmt.Fprintf(&sb, tr(" in %s"), elapsed)
+	}
+	return sb.String()
+}
+
+// erase removes what the last render drew; called with mu held
+func (b *progressBoard) erase() {
+	switch b.mode {
+	case progressLines:
+		if b.drawn > 0 {
+			fmt.Printf("\x1b[%dA\x1b[J", b.drawn)
+		}
+	case progressLine:
+		if b.drawn > 0 {
+			fmt.Printf("\r%s\r", strings.Repeat(" ", b.drawn))
+		}
+	}
+	b.drawn = 0
+}
+
+// render draws the board; called with mu held
+func (b *progressBoard) render() {
+	select {
+	case <-b.stop:
+		return
+	default:
+	}
+	switch b.mode {
+	case progressLines:
+		var sb strings.Builder
+		if b.drawn > 0 {
+			fmt.Fprintf(&sb, "\x1b[%dA", b.drawn)
+		}
+		lines, steps := 0, 0
+		for _, t := range b.tasks {
+			if t.step {
+				if steps++; steps > progressMaxSteps {
+					continue
+				}
+			}
+			sb.WriteString("\r\x1b[2K" + t.line(b.frame, false) + "\n")
+			lines++
+		}
+		if steps > progressMaxSteps {
+			sb.WriteString("\r\x1b[2K" + fmt.Sprintf(tr("  ... and %d more"), steps-progressMaxSteps) + "\n")
+			lines++
+		}
+		sb.WriteString("\x1b[J")
+		fmt.Print(sb.String())
+		b.drawn = lines
+	case progressLine:
+		var main, step *progressTask
+		for _, t := range b.tasks {
+			if t.step && step == nil {
+				step = t
+			} else if !t.step && main == nil && t.ended.IsZero() {
+				main = t
+			}
+		}
+		var text string
+		if main != nil {
+			text = main.line(b.frame, false)
+		}
+		if step != nil {
+			text += "  " + progressSpinner[b.frame%len(progressSpinner)] + " " + step.name
+		}
+		if r := []rune(text); len(r) > progressLineWidth {
+			text = string(r[:progressLineWidth])
+		}
+		width := len([]rune(text))
+		pad := ""
+		if b.drawn > width {
+			pad = strings.Repeat(" ", b.drawn-width)
+		}
+		fmt.Printf("\r%s%s", text, pad)
+		b.drawn = width
+	case progressLog:
+		for _, t := range b.tasks {
+			switch {
+			case t.total > 0 && !t.step:
+				// 100% is left to the summary line of close
+				if s := int(t.current * 10 / t.total); s > t.logged && s < 10 {
+					t.logged = s
+					fmt.Println(t.line(b.frame, false))
+				}
+			case time.Since(t.beat) >= progressHeartbeat && t.ended.IsZero():
+				t.beat = time.Now()
+				detail := time.Since(t.started).Round(time.Second).String()
+				if t.current > 0 {
+					detail = t.amount() + ", " + detail
+				}
+				fmt.Printf(tr("%s: still running (%s)\n"), t.name, detail)
+			}
+		}
+	}
+}
+
+// ============================================================
+// Plain Output
+// ============================================================
+
+// plainMode turns off output that screen readers and CI log viewers handle
+// badly: carriage-return progress bars, box drawing and screen clearing.
+var plainMode bool
+
+// detectPlainMode enables plain mode for TERM=dumb or UNITYSTARTER_PLAIN, on
+// top of the -plain / -no-ansi flags
+func detectPlainMode() {
+	if os.Getenv("TERM") == "dumb" || os.Getenv("UNITYSTARTER_PLAIN") != "" {
+		plainMode = true
+	}
+}
+
+// printRule prints a separator line; plain mode keeps only its leading blank line
+func printRule(line string) {
+	if plainMode {
+		if strings.HasPrefix(line, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	fmt.Println(line)
+}
+
+// ============================================================
+// Localization
+// ============================================================
+
+// Messages are looked up by their English text, so anything without a
+// translation falls back to English. Format verbs are kept in the
+// translations, which makes tr() safe to pass to Printf.
+var (
+	uiLang   = "en"
+	langFlag string
+)
+
+// setLanguage picks the UI language: -lang, UNITYSTARTER_LANG, then the
+// system locale (LC_ALL / LC_MESSAGES / LANG, or the Windows user locale)
+func setLanguage(flagValue string) {
+	for _, v := range []string{flagValue, os.Getenv("UNITYSTARTER_LANG"), os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if v = strings.TrimSpace(v); v != "" {
+			uiLang = normalizeLang(v)
+			return
+		}
+	}
+	if runtime.GOOS == "windows" {
+		out, err := exec.Command("reg", "query", `HKCU\Control Panel\International`, "/v", "LocaleName").Output()
+		if fields := strings.Fields(string(out)); err == nil && len(fields) > 0 {
+			uiLang = normalizeLang(fields[len(fields)-1])
+		}
+	}
+}
+
+// normalizeLang maps a locale such as zh_CN.UTF-8, zh-Hans or en-US to "zh" or "en"
+func normalizeLang(locale string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return "zh"
+	}
+	return "en"
+}
+
+func tr(msg string) string {
+	if uiLang == "zh" {
+		if s, ok := zhMessages[msg]; ok {
+			return s
+		}
+	}
+	return msg
+}
+
+var zhMessages = map[string]string{
+	"\nNothing to clean. Project is already clean.": "\n没有需要清理的内容，项目已经是干净的。",
+	"  ITEMS TO DELETE":                             "  待删除项目",
+	"\nDirectories:":                                "\n目录:",
+	"  (none)":                                      "  (无)",
+	"\nFiles:":                                      "\n文件:",
+	"\nTotal: %d directories, %d files, %s\n":       "\n合计: %d 个目录，%d 个文件，%s\n",
+	"[OK]   Deleted %s: %s (%s)\n":                  "[OK]   已删除%s: %s (%s)\n",
+	"directory":                                     "目录",
+	"file":                                          "文件",
+	"\nPress Enter to continue...":                  "\n按回车键继续...",
+
+	"Unable to get current directory: %s\n":                                     "无法获取当前目录: %s\n",
+	"Target Directory: %s\n":                                                    "目标目录: %s\n",
+	"[CI Mode] Running in non-interactive mode":                                 "[CI Mode] 以非交互模式运行",
+	"[Dry Run] Preview mode — no files will be deleted":                         "[Dry Run] 预览模式 — 不会删除任何文件",
+	"\n[ERROR] Current directory does not appear to be a Unity project.":        "\n[ERROR] 当前目录似乎不是 Unity 项目。",
+	"Expected 'Assets/' and 'ProjectSettings/' directories.":                    "需要存在 'Assets/' 和 'ProjectSettings/' 目录。",
+	"Please run this tool from the Unity project root directory.":               "请在 Unity 项目根目录中运行此工具。",
+	"\n[WARNING] Unity Editor appears to be running (PID: %d).\n":               "\n[WARNING] Unity 编辑器似乎正在运行 (PID: %d)。\n",
+	"Cleaning while Unity is open WILL cause errors and file locks.":            "在 Unity 打开时清理必定会导致错误和文件锁定。",
+	"Please close Unity and try again.":                                         "请关闭 Unity 后重试。",
+	"\n[CI Mode] Aborting due to Unity running. Exit code: 1":                   "\n[CI Mode] Unity 正在运行，已中止。退出码: 1",
+	"\nPress Enter to FORCE continue (not recommended), or Ctrl+C to cancel...": "\n按回车键强制继续 (不推荐)，或按 Ctrl+C 取消...",
+	"\nScanning project...":                                                     "\n正在扫描项目...",
+	"\n[Dry Run] No files were deleted.":                                        "\n[Dry Run] 未删除任何文件。",
+	"\nProceed with deletion? (y/N): ":                                          "\n是否继续删除？(y/N): ",
+	"Operation cancelled.":                                                      "操作已取消。",
+	"\nDeleting...":                                                             "\n正在删除...",
+	"Measuring folders":                                                         "正在统计文件夹大小",
+	"Deleting":                                                                  "删除",
+	"done, %s":                                                                  "完成，%s",
+	", %d failed":                                                               "，%d 个失败",
+	" in %s":                                                                    "，用时 %s",
+	"  ... and %d more":                                                         "  ... 另有 %d 项",
+	"%s: still running (%s)\n":                                                  "%s: 仍在运行 (%s)\n",
+	"\nDeleting (dry run, in memory only)...":                                   "\n正在删除 (试运行，仅在内存中)...",
+	"  CLEAN COMPLETE":                                                          "  清理完成",
+	"  Deleted: %d items\n":                                                     "  已删除: %d 项\n",
+	"  Failed:  %d items\n":                                                     "  失败:   %d 项\n",
+	"  Freed:   %s\n":                                                           "  释放:   %s\n",
+	"  Time:    %s\n":                                                           "  耗时:   %s\n",
+
+	"\n[Dry Run] No changes would be made.":                          "\n[Dry Run] 不会做任何更改。",
+	"\n[Dry Run] %d change(s) would be made; nothing was written:\n": "\n[Dry Run] 将会进行 %d 项更改，未写入任何内容:\n",
+
+	"[WARNING] Notifications are off: %v\n":                                                 "[WARNING] 通知已关闭: %v\n",
+	"[WARNING] Skipping webhook in %s: the URL is empty or not http(s) (unset variable?)\n": "[WARNING] 跳过 %s 中的 webhook: URL 为空或不是 http(s)（变量未设置？）\n",
+	"[WARNING] Skipping webhook %s: unknown format '%s' (use slack, discord or json)\n":     "[WARNING] 跳过 webhook %s: 未知格式 '%s'（可用 slack、discord 或 json）\n",
+	"[WARNING] Webhook %s failed: %v\n":                                                     "[WARNING] Webhook %s 发送失败: %v\n",
+	"[OK] Notified %s\n":                                                                    "[OK] 已通知 %s\n",
+
+	"[WARNING] Removing a stale %s (%s)\n":                      "[WARNING] 删除过期的 %s（%s）\n",
+	"[WARNING] -force: taking over %s from %s (PID %d on %s)\n": "[WARNING] -force: 接管 %[2]s（PID %[3]d，%[4]s）持有的 %[1]s\n",
+
+	"[--] %s: %s is outside the project; not cleaned\n": "[--] %s: %s 位于项目之外，不清理\n",
+	"  BANK VALIDATION": "  音频库校验",
+	"\nNo FMOD or Wwise integration found; nothing to validate.":                                     "\n未找到 FMOD 或 Wwise 集成，无需校验。",
+	"[MISSING] %s  %s (%s)\n":                                                                        "[MISSING] %s  %s (%s)\n",
+	"[%s] %d reference(s) in %d scene(s) checked, %d missing\n":                                      "[%s] 已检查 %[3]d 个场景中的 %[2]d 处引用，缺失 %[4]d 处\n",
+	"[WARNING] fmod: no built banks found; build them in FMOD Studio first.":                         "[WARNING] fmod: 未找到已构建的 bank；请先在 FMOD Studio 中构建。",
+	"[fmod] %d bank(s) found\n":                                                                      "[fmod] 找到 %d 个 bank\n",
+	"[WARNING] fmod: no strings bank or GUIDs.txt; event GUIDs are not checked.":                     "[WARNING] fmod: 没有 strings bank 或 GUIDs.txt；不检查事件 GUID。",
+	"[WARNING] wwise: no SoundbanksInfo.xml or .json found; generate the SoundBanks in Wwise first.": "[WARNING] wwise: 未找到 SoundbanksInfo.xml 或 .json；请先在 Wwise 中生成 SoundBank。",
+	"[wwise] %d SoundbanksInfo file(s) found\n":                                                      "[wwise] 找到 %d 个 SoundbanksInfo 文件\n",
+
+	"Clean list: %s\n": "清理列表: %s\n",
+	"[WARNING] Cannot refresh the policy, using the copy cached %s: %v\n": "[WARNING] 无法刷新策略，使用 %s 缓存的副本: %v\n",
+	"\nKept by %s marker files:\n":                                        "\n由 %s 标记文件保留:\n",
+	"         (except %d kept folder(s) inside)\n":                        "         (其中 %d 个保留的文件夹除外)\n",
+	"mapped drive %s": "映射的网络驱动器 %s",
+	"Network share detected (%s): deleting one item at a time with pauses (-throttle off for full speed)\n": "检测到网络共享 (%s): 逐项删除并间歇暂停 (使用 -throttle off 全速删除)\n",
+	"Throttled: deleting one item at a time with pauses":                                                    "限速模式: 逐项删除并间歇暂停",
+	"\nNo cleans recorded yet in %s.\n":                                                                     "\n%s 中尚无清理记录。\n",
+	"\nClean history: %d run(s) since %s (%s)\n":                                                            "\n清理历史: 自 %[2]s 起共 %[1]d 次 (%[3]s)\n",
+	"Total":                             "合计",
+	"Root files":                        "根目录文件",
+	"FOLDER":                            "文件夹",
+	"LAST":                              "最近一次",
+	"AVERAGE":                           "平均",
+	"TOTAL":                             "总计",
+	"LAST %d":                           "最近 %d 次",
+	"RECORDED":                          "记录时间",
+	"FREED":                             "释放",
+	"ITEMS":                             "项数",
+	"FAILED":                            "失败",
+	"TIME":                              "耗时",
+	"\nAverage clean: %s freed in %s\n": "\n平均每次清理: 释放 %s, 耗时 %s\n",
+	"[WARNING] Could not record the clean history: %v\n": "[WARNING] 无法记录清理历史: %v\n",
+	"\nWaiting for process %d to exit...\n":              "\n正在等待进程 %d 退出...\n",
+	"[WARNING] Process %d is still running after %s.\n":  "[WARNING] 进程 %d 在 %s 后仍在运行。\n",
+	"\n[ERROR] Failed to load the command fixture: %v\n": "\n[ERROR] 加载命令录制文件失败: %v\n",
+}
+
+// ============================================================
+// Project Lock
+// ============================================================
+
+// Tools that change a project hold .unitystarter.lock in its root while they run,
+// so a clean and a rename cannot work on the same project at once. The lock names
+// its holder; it is stale once that process is gone, or, for a lock taken on
+// another machine (shared or network drive), once it is older than projectLockMaxAge.
+const (
+	projectLockFile   = ".unitystarter.lock"
+	projectLockMaxAge = 24 * time.Hour
+
+	// How long a new lock file may be empty: it is created, then written
+	projectLockWriteGrace = 5 * time.Second
+)
+
+// projectLock is the content of .unitystarter.lock
+type projectLock struct {
+	Tool      string `json:"tool"`
+	Operation string `json:"operation"`
+	PID       int    `json:"pid"`
+	Host      string `json:"host"`
+	StartedAt string `json:"startedAt"`
+}
+
+var heldLockPath string
+
+// acquireProjectLock creates the lock file, which fails when it exists. A stale
+// lock is replaced with a warning; a live one is an error unless force is set.
+func acquireProjectLock(basePath, tool, operation string, force bool) error {
+	lockPath := filepath.Join(basePath, projectLockFile)
+	host, _ := os.Hostname()
+	data, _ := json.MarshalIndent(projectLock{
+		Tool: tool, Operation: operation, PID: os.Getpid(), Host: host, StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}, "", "  ")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return fmt.Errorf("cannot write %s: %v", projectLockFile, err)
+			}
+			heldLockPath = lockPath
+			return nil
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("cannot create %s: %v", projectLockFile, err)
+		}
+
+		held, err := readProjectLock(lockPath)
+		// The holder creates the file before writing it: give a fresh, unreadable
+		// lock a moment, and treat it as held if it stays that way
+		for wait := 0; err != nil && wait < 10 && lockIsFresh(lockPath); wait++ {
+			time.Sleep(100 * time.Millisecond)
+			held, err = readProjectLock(lockPath)
+		}
+		if err != nil && !force && lockIsFresh(lockPath) {
+			return fmt.Errorf("%s was just created by another tool that has not finished writing it; try again in a moment", projectLockFile)
+		}
+		if reason := staleLockReason(held, err, host); reason != "" {
+			fmt.Printf(tr("[WARNING] Removing a stale %s (%s)\n"), projectLockFile, reason)
+		} else if force {
+			fmt.Printf(tr("[WARNING] -force: taking over %s from %s (PID %d on %s)\n"), projectLockFile, held.Tool, held.PID, held.Host)
+		} else {
+			return fmt.Errorf("%s is already running '%s' on this project (PID %d on %s, since %s); wait for it to finish, or pass -force if it is not running",
+				held.Tool, held.Operation, held.PID, held.Host, held.StartedAt)
+		}
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot remove %s: %v", projectLockFile, err)
+		}
+	}
+	return fmt.Errorf("cannot take %s: another tool took it at the same moment", projectLockFile)
+}
+
+func readProjectLock(lockPath string) (*projectLock, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	var lock projectLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// lockIsFresh reports whether the lock file was created too recently for an
+// empty or half-written content to mean its holder is gone
+func lockIsFresh(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	return err == nil && time.Since(info.ModTime()) < projectLockWriteGrace
+}
+
+// staleLockReason explains why a lock no longer protects anything, or returns ""
+// while its holder may still be running
+func staleLockReason(held *projectLock, readErr error, host string) string {
+	if readErr != nil || held.PID <= 0 {
+		return "unreadable"
+	}
+	if strings.EqualFold(held.Host, host) {
+		if held.PID == os.Getpid() || !isProcessRunning(held.PID) {
+			return fmt.Sprintf("%s, PID %d, is no longer running", held.Tool, held.PID)
+		}
+		return ""
+	}
+	if started, err := time.Parse(time.RFC3339, held.StartedAt); err != nil || time.Since(started) > projectLockMaxAge {
+		return fmt.Sprintf("taken on %s more than %s ago", held.Host, projectLockMaxAge)
+	}
+	return ""
+}
+
+// releaseProjectLock removes the lock if it is still this process's; another
+// tool may have taken it over with -force
+func releaseProjectLock() {
+	if heldLockPath == "" {
+		return
+	}
+	if held, err := readProjectLock(heldLockPath); err == nil && held.PID == os.Getpid() {
+		os.Remove(heldLockPath)
+	}
+	heldLockPath = ""
+}
+
+// ============================================================
+// JSON Output
+// ============================================================
+
+// With -json, human-readable output moves to stderr and one result document is
+// written to stdout when the tool exits, so scripts can parse stdout directly.
+// The schema is shared by all tools in this folder.
+type jsonResult struct {
+	Tool       string       `json:"tool"`
+	Success    bool         `json:"success"`
+	ExitCode   int          `json:"exitCode"`
+	StartedAt  string       `json:"startedAt"`
+	DurationMs int64        `json:"durationMs"`
+	Actions    []jsonAction `json:"actions"`
+	Errors     []string     `json:"errors"`
+	Artifacts  []string     `json:"artifacts"`
+}
+
+// jsonAction is one unit of work: a deleted folder, a rewritten file, a converted asset...
+type jsonAction struct {
+	Action     string `json:"action"`
+	Target     string `json:"target"`
+	Status     string `json:"status"` // "ok", "failed", "skipped", "planned"
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs,omitempty"`
+}
+
+var (
+	jsonMode    bool
+	jsonStdout  *os.File
+	jsonStart   = time.Now()
+	jsonWritten bool
+	jsonMu      sync.Mutex
+	jsonDoc     = jsonResult{Actions: []jsonAction{}, Errors: []string{}, Artifacts: []string{}}
+)
+
+// enableJSONOutput redirects human output to stderr; call right after flag.Parse
+func enableJSONOutput(tool string) {
+	jsonMode = true
+	jsonDoc.Tool = tool
+	jsonStdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+func recordAction(action, target, status, detail string, duration time.Duration) {
+	if status == "ok" && isDryRun() {
+		status = "planned"
+	}
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Actions = append(jsonDoc.Actions, jsonAction{
+		Action: action, Target: target, Status: status, Detail: detail, DurationMs: duration.Milliseconds(),
+	})
+}
+
+func recordError(format string, args ...interface{}) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Errors = append(jsonDoc.Errors, fmt.Sprintf(format, args...))
+}
+
+func recordArtifact(path string) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	jsonDoc.Artifacts = append(jsonDoc.Artifacts, filepath.ToSlash(path))
+}
+
+// finishJSON writes the result document once; exitCode 0 with recorded errors
+// still reports success=false.
+func finishJSON(exitCode int) {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	if !jsonMode || jsonWritten {
+		return
+	}
+	jsonWritten = true
+	jsonDoc.ExitCode = exitCode
+	jsonDoc.Success = exitCode == 0 && len(jsonDoc.Errors) == 0
+	jsonDoc.StartedAt = jsonStart.Format(time.RFC3339)
+	jsonDoc.DurationMs = time.Since(jsonStart).Milliseconds()
+	enc := json.NewEncoder(jsonStdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonDoc)
+}
+
+// exitTool ends the process with the JSON document flushed and the project
+// lock released
+func exitTool(code int) {
+	releaseProjectLock()
+	finishJSON(code)
+	os.Exit(code)
+}
+
+// ============================================================
+// Shared Policy
+// ============================================================
+
+// A studio keeps its policy (clean lists, package categories, naming rules) in one
+// JSON document that "policy" in .unitystarter.json points at: an HTTP(S) URL, a
+// file in a git repository at a pinned revision, or a local path. Fetched copies
+// are cached in the user cache folder; a pinned commit or content hash is fetched
+// once, anything else again after "refresh". When a fetch fails the cached copy is
+// used with a warning, so tools keep working offline.
+const (
+	policyConfigFileName = ".unitystarter.json"
+	policyConfigEnvVar   = "UNITYSTARTER_CONFIG"
+	policyOfflineEnvVar  = "UNITYSTARTER_POLICY_OFFLINE" // "1": only use the cached copy
+	policyDefaultFile    = "unitystarter-policy.json"    // git: path in the repository
+	policyDefaultRefresh = time.Hour
+	policyTimeout        = 30 * time.Second
+)
+
+// policySource is the "policy" object of .unitystarter.json
+type policySource struct {
+	Source   string `json:"source"`   // https://..., git+https://....git, git@host:repo.git or a path
+	Revision string `json:"revision"` // git: commit, tag or branch (default: HEAD)
+	File     string `json:"file"`     // git: path of the document in the repository
+	SHA256   string `json:"sha256"`   // http: expected hash of the document; pins it
+	Refresh  string `json:"refresh"`  // how long an unpinned copy is used, e.g. "30m" (default: 1h)
+}
+
+// sharedPolicy is the policy document. The schema is shared by all tools in this
+// folder; each one reads the parts it applies and ignores the rest.
+type sharedPolicy struct {
+	Clean *struct {
+		Directories []string `json:"directories"` // replaces the built-in top-level folders
+		Extensions  []string `json:"extensions"`  // replaces the built-in top-level file extensions
+	} `json:"clean"`
+	Packages *struct {
+		Categories []struct {
+			Name     string   `json:"name"`
+			Packages []string `json:"packages"`
+		} `json:"categories"` // replaces the built-in removal categories
+	} `json:"packages"`
+	Naming *struct {
+		Scripts  string `json:"scripts"`  // regex new script names must match
+		Packages string `json:"packages"` // regex new package names must match
+	} `json:"naming"`
+}
+
+var gitCommitRegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// loadPolicy reads "policy" from .unitystarter.json (found like loadSharedConfig)
+// and returns the document with a description of where it came from. Returns nil
+// when no policy is configured.
+func loadPolicy(projectRoot string) (*sharedPolicy, string, error) {
+	path := os.Getenv(policyConfigEnvVar)
+	if path == "" {
+		abs, err := filepath.Abs(projectRoot)
+		if err != nil {
+			return nil, "", nil
+		}
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			candidate := filepath.Join(dir, policyConfigFileName)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				return nil, "", nil
+			}
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	var file struct {
+		Policy *policySource `json:"policy"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, "", fmt.Errorf("invalid %s: %v", path, err)
+	}
+	if file.Policy == nil || strings.TrimSpace(file.Policy.Source) == "" {
+		return nil, "", nil
+	}
+
+	doc, origin, err := fetchPolicy(file.Policy, filepath.Dir(path))
+	if err != nil {
+		return nil, origin, fmt.Errorf("policy %s: %v", origin, err)
+	}
+	var policy sharedPolicy
+	if err := json.Unmarshal(doc, &policy); err != nil {
+		return nil, origin, fmt.Errorf("invalid policy %s: %v", origin, err)
+	}
+	return &policy, origin, nil
+}
 
-	// Collect and print results in arrival order (safe — only main goroutine prints)
-	var deletedCount, failedCount int
-	var totalFreed int64
-	for r := range results {
-		if r.err != nil {
-			fmt.Printf("[FAIL] %s: %v\n", r.path, r.err)
-			failedCount++
-		} else {
-			fmt.Printf("[OK]   Deleted %s: %s (%s)\n", r.kind, r.path, formatSize(r.size))
-			deletedCount++
-			totalFreed += r.size
+// isGitSource reports whether a policy source names a git repository
+func isGitSource(source string) bool {
+	return strings.HasPrefix(source, "git+") || strings.HasPrefix(source, "git@") ||
+		strings.HasPrefix(source, "ssh://") || strings.HasSuffix(source, ".git")
+}
+
+// fetchPolicy returns the policy document, from the cache when it is pinned,
+// fresh enough or offline, and from the source otherwise
+func fetchPolicy(src *policySource, configDir string) ([]byte, string, error) {
+	source := strings.TrimSpace(src.Source)
+	isGit := isGitSource(source)
+	isHTTP := !isGit && (strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://"))
+	if !isGit && !isHTTP {
+		p := source
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(configDir, filepath.FromSlash(p))
+		}
+		data, err := os.ReadFile(p)
+		return data, p, err
+	}
+
+	revision, file := src.Revision, src.File
+	if isGit {
+		if revision == "" {
+			revision = "HEAD"
+		}
+		if file == "" {
+			file = policyDefaultFile
+		}
+	}
+	origin := source
+	if isGit {
+		origin = source + "@" + revision + ":" + file
+	}
+	refresh := policyDefaultRefresh
+	if src.Refresh != "" {
+		d, err := time.ParseDuration(src.Refresh)
+		if err != nil {
+			return nil, origin, fmt.Errorf("invalid refresh '%s': %v", src.Refresh, err)
+		}
+		refresh = d
+	}
+	pinned := (isGit && gitCommitRegex.MatchString(strings.ToLower(revision))) || (isHTTP && src.SHA256 != "")
+	matchesPin := func(data []byte) bool {
+		if src.SHA256 == "" {
+			return true
+		}
+		sum := sha256.Sum256(data)
+		return strings.EqualFold(hex.EncodeToString(sum[:]), strings.TrimSpace(src.SHA256))
+	}
+
+	// One cache file per source, revision and path
+	key := sha256.Sum256([]byte(source + "\n" + revision + "\n" + file))
+	cachePath := ""
+	if dir, err := os.UserCacheDir(); err == nil {
+		cachePath = filepath.Join(dir, "UnityStarter", "policy", hex.EncodeToString(key[:8])+".json")
+	}
+	var cached []byte
+	var cachedAt time.Time
+	if cachePath != "" {
+		if info, err := os.Stat(cachePath); err == nil {
+			if data, err := os.ReadFile(cachePath); err == nil && matchesPin(data) {
+				cached, cachedAt = data, info.ModTime()
+			}
+		}
+	}
+	if cached != nil && (pinned || time.Since(cachedAt) < refresh) {
+		return cached, origin, nil
+	}
+	if os.Getenv(policyOfflineEnvVar) == "1" {
+		if cached != nil {
+			return cached, origin, nil
 		}
+		return nil, origin, fmt.Errorf("%s is set and there is no cached copy", policyOfflineEnvVar)
 	}
 
-	return deletedCount, failedCount, totalFreed
+	var data []byte
+	var err error
+	if isGit {
+		data, err = fetchGitPolicy(strings.TrimPrefix(source, "git+"), revision, file)
+	} else {
+		data, err = fetchHTTPPolicy(source)
+	}
+	if err == nil && !matchesPin(data) {
+		err = fmt.Errorf("the document does not match the pinned sha256")
+	}
+	if err != nil {
+		if cached != nil {
+			fmt.Printf(tr("[WARNING] Cannot refresh the policy, using the copy cached %s: %v\n"), cachedAt.Format("2006-01-02 15:04"), err)
+			return cached, origin, nil
+		}
+		return nil, origin, err
+	}
+	if cachePath != "" {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err == nil {
+			tmp := cachePath + ".tmp"
+			if os.WriteFile(tmp, data, 0644) == nil {
+				os.Rename(tmp, cachePath)
+			}
+		}
+	}
+	return data, origin, nil
 }
 
-// ============================================================
-// Utilities
-// ============================================================
+func fetchHTTPPolicy(source string) ([]byte, error) {
+	client := &http.Client{Timeout: policyTimeout}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+}
 
-func waitForKeyPress() {
-	fmt.Println("\nPress Enter to continue...")
-	stdinReader.ReadBytes('\n')
+// fetchGitPolicy reads one file at a revision without a full clone: a shallow
+// fetch of just that revision into a scratch repository
+func fetchGitPolicy(repo, revision, file string) ([]byte, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git is not installed")
+	}
+	tmp, err := os.MkdirTemp("", "unitystarter-policy-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	run := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("git", append([]string{"-C", tmp}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("git %s: %v %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return out, nil
+	}
+	if _, err := run("init", "-q"); err != nil {
+		return nil, err
+	}
+	if _, err := run("fetch", "-q", "--depth", "1", repo, revision); err != nil {
+		return nil, err
+	}
+	return run("show", "FETCH_HEAD:"+strings.TrimPrefix(filepath.ToSlash(file), "/"))
+}
+
+// protectedDirs are never deleted, whatever a policy lists
+var protectedDirs = []string{"Assets", "Packages", "ProjectSettings", "UserSettings", ".git", ".plastic", ".svn"}
+
+// applyCleanPolicy replaces the built-in clean lists with the policy's. Entries
+// must be top-level folder names and file extensions; Unity's own folders and the
+// version control folders are refused.
+func applyCleanPolicy(p *sharedPolicy) error {
+	if p == nil || p.Clean == nil {
+		return nil
+	}
+	if len(p.Clean.Directories) > 0 {
+		for _, d := range p.Clean.Directories {
+			if d == "" || d == "." || d == ".." || strings.ContainsAny(d, `/\`) {
+				return fmt.Errorf("policy clean directory '%s' is not a top-level folder name", d)
+			}
+			if containsFold(protectedDirs, d) {
+				return fmt.Errorf("policy clean directory '%s' is protected", d)
+			}
+		}
+		directoriesToDelete = p.Clean.Directories
+	}
+	if len(p.Clean.Extensions) > 0 {
+		for _, ext := range p.Clean.Extensions {
+			if !strings.HasPrefix(ext, ".") || len(ext) < 2 || strings.ContainsAny(ext, `/\*`) {
+				return fmt.Errorf("policy clean extension '%s' is not an extension like \".csproj\"", ext)
+			}
+		}
+		fileExtensionsToDelete = p.Clean.Extensions
+	}
+	return nil
 }
 
 // ============================================================
-// Entry Point
+// Notifications
 // ============================================================
 
-func main() {
-	var ciMode bool
-	var dryRun bool
+// Webhooks come from "notifications" in .unitystarter.json (UNITYSTARTER_CONFIG, or
+// the first file from the project root upward) and from UNITYSTARTER_WEBHOOK_URL.
+// The message is built from the recorded actions, errors and artifacts, so it says
+// the same as the -json document. A webhook that fails only prints a warning.
+const (
+	notifyConfigFileName = ".unitystarter.json"
+	notifyConfigEnvVar   = "UNITYSTARTER_CONFIG"
+	notifyWebhookEnvVar  = "UNITYSTARTER_WEBHOOK_URL"
+	notifyTimeout        = 15 * time.Second
+	notifyMaxLines       = 20 // errors, log lines and artifacts per message
+)
 
-	flag.BoolVar(&ciMode, "ci", false, "Run in CI mode (non-interactive, no confirmation prompts)")
-	flag.BoolVar(&dryRun, "dry-run", false, "Preview what would be deleted without actually deleting")
-	flag.Parse()
+// notifyConfig is the "notifications" object of .unitystarter.json
+type notifyConfig struct {
+	Webhooks        []webhookConfig `json:"webhooks"`
+	ArtifactBaseURL string          `json:"artifactBaseUrl"` // project-relative artifact paths are appended
+}
 
-	basePath, err := os.Getwd()
+// webhookConfig is one target. The URL may use ${VAR} so the secret stays in the
+// CI environment instead of the repository.
+type webhookConfig struct {
+	URL    string   `json:"url"`
+	Format string   `json:"format"` // slack, discord, json (default: from the URL host)
+	On     []string `json:"on"`     // success, failure (default: both)
+	Tools  []string `json:"tools"`  // tool names (default: every tool)
+}
+
+// notifyExcerpt is a titled block of log lines shown under the errors
+type notifyExcerpt struct {
+	Title string   `json:"title"`
+	Lines []string `json:"lines"`
+}
+
+// notification is the body posted to "json" webhooks
+type notification struct {
+	Tool       string          `json:"tool"`
+	Project    string          `json:"project"`
+	Host       string          `json:"host"`
+	Success    bool            `json:"success"`
+	ExitCode   int             `json:"exitCode"`
+	DurationMs int64           `json:"durationMs"`
+	Summary    string          `json:"summary"`
+	Counts     map[string]int  `json:"counts"` // actions per status
+	Errors     []string        `json:"errors"`
+	Logs       []notifyExcerpt `json:"logs"`
+	Artifacts  []string        `json:"artifacts"` // links with artifactBaseUrl, else paths
+	RunURL     string          `json:"runUrl,omitempty"`
+}
+
+var (
+	notifyHooks       []webhookConfig
+	notifyTool        string
+	notifyBasePath    string
+	notifyArtifactURL string
+	notifySummary     string
+	notifyExcerpts    []notifyExcerpt
+	notifySent        bool
+)
+
+// loadNotifyConfig reads the "notifications" object the same way loadSharedConfig
+// finds .unitystarter.json. Returns an empty config when no file exists.
+func loadNotifyConfig(projectRoot string) (*notifyConfig, string, error) {
+	path := os.Getenv(notifyConfigEnvVar)
+	if path == "" {
+		abs, err := filepath.Abs(projectRoot)
+		if err != nil {
+			return &notifyConfig{}, "", nil
+		}
+		for dir := abs; ; dir = filepath.Dir(dir) {
+			candidate := filepath.Join(dir, notifyConfigFileName)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				return &notifyConfig{}, "", nil
+			}
+		}
+	}
+
+	data, err := os.ReadFile(path)
 	if err != nil {
-		fmt.Printf("Unable to get current directory: %s\n", err)
-		if !ciMode {
-			waitForKeyPress()
+		return nil, path, err
+	}
+	var file struct {
+		Notifications notifyConfig `json:"notifications"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, path, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	return &file.Notifications, path, nil
+}
+
+// enableNotifications loads the webhooks of this tool. Call it once the real work
+// starts, so listings, dry runs and cancelled prompts never post.
+func enableNotifications(basePath, tool string) {
+	cfg, path, err := loadNotifyConfig(basePath)
+	if err != nil {
+		fmt.Printf(tr("[WARNING] Notifications are off: %v\n"), err)
+		return
+	}
+	hooks := cfg.Webhooks
+	if u := os.Getenv(notifyWebhookEnvVar); u != "" {
+		hooks = append(hooks, webhookConfig{URL: u})
+	}
+	notifyBasePath = basePath
+	notifyArtifactURL = cfg.ArtifactBaseURL
+	for _, h := range hooks {
+		if len(h.Tools) > 0 && !containsFold(h.Tools, tool) {
+			continue
+		}
+		h.URL = strings.TrimSpace(os.ExpandEnv(h.URL))
+		if u, err := url.Parse(h.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			fmt.Printf(tr("[WARNING] Skipping webhook in %s: the URL is empty or not http(s) (unset variable?)\n"), path)
+			continue
+		}
+		h.Format = webhookFormat(h)
+		if h.Format != "slack" && h.Format != "discord" && h.Format != "json" {
+			fmt.Printf(tr("[WARNING] Skipping webhook %s: unknown format '%s' (use slack, discord or json)\n"), webhookHost(h.URL), h.Format)
+			continue
+		}
+		notifyHooks = append(notifyHooks, h)
+	}
+	notifyTool = tool
+}
+
+// setNotifySummary replaces the default "3 ok, 1 failed" line of the message
+func setNotifySummary(format string, args ...interface{}) {
+	notifySummary = fmt.Sprintf(format, args...)
+}
+
+// addNotifyExcerpt attaches log lines of a failure to the message
+func addNotifyExcerpt(title string, lines []string) {
+	if len(lines) > 0 {
+		notifyExcerpts = append(notifyExcerpts, notifyExcerpt{Title: title, Lines: lines})
+	}
+}
+
+// sendNotifications posts the result to every webhook that wants it. Call it
+// before waiting for a key press, so the message does not wait for the user.
+func sendNotifications(exitCode int) {
+	if notifySent || len(notifyHooks) == 0 {
+		return
+	}
+	notifySent = true
+	n := buildNotification(exitCode)
+	event := "success"
+	if !n.Success {
+		event = "failure"
+	}
+	for _, h := range notifyHooks {
+		if len(h.On) > 0 && !containsFold(h.On, event) {
+			continue
+		}
+		if err := postWebhook(h, n); err != nil {
+			fmt.Printf(tr("[WARNING] Webhook %s failed: %v\n"), webhookHost(h.URL), err)
+			continue
+		}
+		fmt.Printf(tr("[OK] Notified %s\n"), webhookHost(h.URL))
+	}
+}
+
+func buildNotification(exitCode int) *notification {
+	jsonMu.Lock()
+	defer jsonMu.Unlock()
+	host, _ := os.Hostname()
+	n := &notification{
+		Tool:       notifyTool,
+		Project:    filepath.Base(notifyBasePath),
+		Host:       host,
+		Success:    exitCode == 0 && len(jsonDoc.Errors) == 0,
+		ExitCode:   exitCode,
+		DurationMs: time.Since(jsonStart).Milliseconds(),
+		Summary:    notifySummary,
+		Counts:     make(map[string]int),
+		Errors:     append([]string{}, jsonDoc.Errors...),
+		Logs:       append([]notifyExcerpt{}, notifyExcerpts...),
+		Artifacts:  []string{},
+		RunURL:     ciRunURL(),
+	}
+	for _, a := range jsonDoc.Actions {
+		n.Counts[a.Status]++
+	}
+	if n.Summary == "" {
+		var parts []string
+		for _, status := range []string{"ok", "failed", "skipped"} {
+			if n.Counts[status] > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", n.Counts[status], status))
+			}
+		}
+		n.Summary = strings.Join(parts, ", ")
+	}
+	for _, a := range jsonDoc.Artifacts {
+		n.Artifacts = append(n.Artifacts, artifactLink(a))
+	}
+	return n
+}
+
+// artifactLink turns a path into a link below artifactBaseUrl; paths outside the
+// project, or without a base URL, are kept as they are
+func artifactLink(p string) string {
+	rel := filepath.ToSlash(p)
+	if filepath.IsAbs(p) {
+		r, err := filepath.Rel(notifyBasePath, p)
+		if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+			return rel
+		}
+		rel = filepath.ToSlash(r)
+	}
+	if notifyArtifactURL == "" {
+		return rel
+	}
+	parts := strings.Split(rel, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.TrimSuffix(notifyArtifactURL, "/") + "/" + strings.Join(parts, "/")
+}
+
+// ciRunURL links the CI job the tool runs in, when the CI exposes one
+func ciRunURL() string {
+	if server, repo, run := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"); server != "" && repo != "" && run != "" {
+		return server + "/" + repo + "/actions/runs/" + run
+	}
+	for _, name := range []string{"CI_JOB_URL", "BUILD_URL"} { // GitLab, Jenkins
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// webhookFormat returns the configured format, else guesses it from the host.
+// Mattermost and Rocket.Chat accept "slack".
+func webhookFormat(h webhookConfig) string {
+	if h.Format != "" {
+		return strings.ToLower(h.Format)
+	}
+	host := webhookHost(h.URL)
+	switch {
+	case host == "hooks.slack.com":
+		return "slack"
+	case host == "discord.com" || host == "discordapp.com" || strings.HasSuffix(host, ".discord.com"):
+		return "discord"
+	}
+	return "json"
+}
+
+// webhookHost is what the output shows of a webhook: the path holds the secret
+func webhookHost(raw string) string {
+	if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		return strings.ToLower(u.Hostname())
+	}
+	return "webhook"
+}
+
+// notificationText renders the message as Slack mrkdwn or Discord markdown,
+// cut to limit characters
+func notificationText(n *notification, slack bool, limit int) string {
+	esc := func(s string) string { return s }
+	bold := func(s string) string { return "**" + s + "**" }
+	link := func(u, text string) string { return text + ": " + u }
+	if slack {
+		esc = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+		bold = func(s string) string { return "*" + s + "*" }
+		link = func(u, text string) string { return "<" + u + "|" + esc(text) + ">" }
+	}
+
+	status := "✅ " + bold(esc(n.Tool)) + " succeeded"
+	if !n.Success {
+		status = "❌ " + bold(esc(n.Tool)) + fmt.Sprintf(" failed (exit code %d)", n.ExitCode)
+	}
+	lines := []string{fmt.Sprintf("%s: %s on %s, %s", status, esc(n.Project), esc(n.Host), (time.Duration(n.DurationMs) * time.Millisecond).Round(time.Second))}
+	if n.Summary != "" {
+		lines = append(lines, esc(n.Summary))
+	}
+	if n.RunURL != "" {
+		lines = append(lines, link(n.RunURL, "CI run"))
+	}
+	if len(n.Errors) > 0 {
+		lines = append(lines, "", bold("Errors"))
+		for i, e := range n.Errors {
+			if i == notifyMaxLines {
+				lines = append(lines, fmt.Sprintf("… %d more", len(n.Errors)-i))
+				break
+			}
+			lines = append(lines, "• "+esc(e))
+		}
+	}
+	for _, x := range n.Logs {
+		excerpt := x.Lines
+		if len(excerpt) > notifyMaxLines {
+			excerpt = excerpt[len(excerpt)-notifyMaxLines:]
+		}
+		// A stray fence inside the log would end the code block early
+		block := strings.ReplaceAll(strings.Join(excerpt, "\n"), "```", "'''")
+		lines = append(lines, "", bold(esc(x.Title)), "```", esc(block), "```")
+	}
+	if len(n.Artifacts) > 0 {
+		lines = append(lines, "", bold("Artifacts"))
+		for i, a := range n.Artifacts {
+			if i == notifyMaxLines {
+				lines = append(lines, fmt.Sprintf("… %d more", len(n.Artifacts)-i))
+				break
+			}
+			if strings.HasPrefix(a, "http://") || strings.HasPrefix(a, "https://") {
+				lines = append(lines, "• "+link(a, path.Base(a)))
+			} else {
+				lines = append(lines, "• "+esc(a))
+			}
+		}
+	}
+
+	text := strings.Join(lines, "\n")
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	text = string(runes[:limit-8])
+	if strings.Count(text, "```")%2 == 1 {
+		text += "\n```"
+	}
+	return text + "\n…"
+}
+
+// postWebhook sends one message; a 429 or 5xx answer is retried once
+func postWebhook(h webhookConfig, n *notification) error {
+	var payload interface{} = n
+	switch h.Format {
+	case "slack":
+		payload = map[string]string{"text": notificationText(n, true, 3900)}
+	case "discord":
+		payload = map[string]string{"content": notificationText(n, false, 2000)}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: notifyTimeout}
+	for attempt := 0; ; attempt++ {
+		resp, err := client.Post(h.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			// The error text repeats the URL, secret included
+			if ue, ok := err.(*url.Error); ok {
+				err = ue.Err
+			}
+			return err
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+		if resp.StatusCode < 300 {
+			return nil
+		}
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !retry || attempt > 0 {
+			return fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		wait := 2 * time.Second
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 && s <= 30 {
+			wait = time.Duration(s) * time.Second
+		}
+		time.Sleep(wait)
+	}
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), s) {
+			return true
 		}
-		os.Exit(1)
 	}
+	return false
+}
+
+// ============================================================
+// Clean
+// ============================================================
+
+// cleanOptions are the command-line flags of a clean
+type cleanOptions struct {
+	ci         bool
+	dryRun     bool
+	force      bool
+	noNotify   bool
+	validate   bool // -validate-banks
+	history    bool
+	middleware string
+	throttle   string
+	waitPID    int
+}
 
-	fmt.Printf("Target Directory: %s\n", basePath)
-	if ciMode {
-		fmt.Println("[CI Mode] Running in non-interactive mode")
+// runClean cleans the Unity project at basePath, or runs -history or
+// -validate-banks, and returns the exit code: 1 when something failed, 2 for
+// invalid options. Files go through fsys and the process and network drive
+// checks through runner, so tests can run it on a fixture without the executable.
+func runClean(basePath string, opts cleanOptions) int {
+	fmt.Printf(tr("Target Directory: %s\n"), basePath)
+	if opts.ci {
+		fmt.Println(tr("[CI Mode] Running in non-interactive mode"))
 	}
-	if dryRun {
-		fmt.Println("[Dry Run] Preview mode — no files will be deleted")
+	if opts.dryRun {
+		fmt.Println(tr("[Dry Run] Preview mode — no files will be deleted"))
+		fsys = newOverlayFS()
 	}
 
 	// Validate this is a Unity project
 	if !isUnityProject(basePath) {
-		fmt.Println("\n[ERROR] Current directory does not appear to be a Unity project.")
-		fmt.Println("Expected 'Assets/' and 'ProjectSettings/' directories.")
-		fmt.Println("Please run this tool from the Unity project root directory.")
-		if !ciMode {
-			waitForKeyPress()
+		fmt.Println(tr("\n[ERROR] Current directory does not appear to be a Unity project."))
+		fmt.Println(tr("Expected 'Assets/' and 'ProjectSettings/' directories."))
+		fmt.Println(tr("Please run this tool from the Unity project root directory."))
+		recordError("not a Unity project: %s", basePath)
+		return 1
+	}
+
+	// The history only reads a file, so it needs neither a closed editor nor the lock
+	if opts.history {
+		if err := printCleanHistory(basePath); err != nil {
+			fmt.Printf(tr("\n[ERROR] %v\n"), err)
+			recordError("%v", err)
+			return 1
+		}
+		return 0
+	}
+
+	// A studio policy can replace the clean lists
+	policy, policyOrigin, err := loadPolicy(basePath)
+	if err == nil {
+		err = applyCleanPolicy(policy)
+	}
+	if err != nil {
+		fmt.Printf(tr("\n[ERROR] %v\n"), err)
+		recordError("%v", err)
+		return 1
+	}
+	if policy != nil && policy.Clean != nil {
+		fmt.Printf(tr("Clean list: %s\n"), policyOrigin)
+	}
+
+	if opts.validate && opts.middleware == "" {
+		opts.middleware = "auto"
+	}
+	profiles, err := parseMiddleware(opts.middleware, basePath)
+	if err != nil {
+		fmt.Printf(tr("\n[ERROR] %v\n"), err)
+		recordError("%v", err)
+		return 2
+	}
+
+	// Validation only reads, so it needs neither a closed editor nor the lock
+	if opts.validate {
+		missing := validateBanks(basePath, profiles)
+		if missing > 0 {
+			return 1
+		}
+		return 0
+	}
+
+	// A clean at full speed would saturate a network share for everyone on it
+	throttle, share, err := resolveThrottle(opts.throttle, basePath)
+	if err != nil {
+		fmt.Printf(tr("\n[ERROR] %v\n"), err)
+		recordError("%v", err)
+		return 2
+	}
+	throttled = throttle
+	if share != "" {
+		fmt.Printf(tr("Network share detected (%s): deleting one item at a time with pauses (-throttle off for full speed)\n"), share)
+	} else if throttled {
+		fmt.Println(tr("Throttled: deleting one item at a time with pauses"))
+	}
+
+	// The editor menu starts the clean and then quits; give it time to close
+	if opts.waitPID > 0 && isProcessRunning(opts.waitPID) {
+		fmt.Printf(tr("\nWaiting for process %d to exit...\n"), opts.waitPID)
+		if !waitForProcessExit(opts.waitPID, editorExitTimeout) {
+			fmt.Printf(tr("[WARNING] Process %d is still running after %s.\n"), opts.waitPID, editorExitTimeout)
 		}
-		os.Exit(1)
 	}
 
 	// Check if Unity is running
 	if isRunning, pid := checkUnityRunning(basePath); isRunning {
-		fmt.Printf("\n[WARNING] Unity Editor appears to be running (PID: %d).\n", pid)
-		fmt.Println("Cleaning while Unity is open WILL cause errors and file locks.")
-		fmt.Println("Please close Unity and try again.")
-		if ciMode {
-			fmt.Println("\n[CI Mode] Aborting due to Unity running. Exit code: 1")
-			os.Exit(1)
-		}
-		fmt.Println("\nPress Enter to FORCE continue (not recommended), or Ctrl+C to cancel...")
+		fmt.Printf(tr("\n[WARNING] Unity Editor appears to be running (PID: %d).\n"), pid)
+		fmt.Println(tr("Cleaning while Unity is open WILL cause errors and file locks."))
+		fmt.Println(tr("Please close Unity and try again."))
+		if opts.ci {
+			fmt.Println(tr("\n[CI Mode] Aborting due to Unity running. Exit code: 1"))
+			recordError("Unity Editor is running (PID %d)", pid)
+			return 1
+		}
+		fmt.Println(tr("\nPress Enter to FORCE continue (not recommended), or Ctrl+C to cancel..."))
 		stdinReader.ReadBytes('\n')
 	}
 
-	// Collect and preview items
-	fmt.Println("\nScanning project...")
-	items := collectPreview(basePath)
-	printPreview(items)
-
-	if len(items) == 0 {
-		if !ciMode {
-			waitForKeyPress()
+	// Another tool working on the project would see folders vanish mid-run
+	if !opts.dryRun {
+		if err := acquireProjectLock(basePath, "unity_project_full_clean", "clean", opts.force); err != nil {
+			fmt.Printf(tr("\n[ERROR] %v\n"), err)
+			recordError("%v", err)
+			return 1
 		}
-		return
+		defer releaseProjectLock()
 	}
 
-	// Dry-run stops here
-	if dryRun {
-		fmt.Println("\n[Dry Run] No files were deleted.")
-		if !ciMode {
-			waitForKeyPress()
-		}
-		return
+	// Collect and preview items
+	fmt.Println(tr("\nScanning project..."))
+	items, protected := collectPreview(basePath, middlewareTargets(basePath, profiles))
+	printPreview(items, protected)
+
+	if len(items) == 0 {
+		return 0
 	}
 
-	// Confirm before deletion
-	if !ciMode {
-		fmt.Print("\nProceed with deletion? (y/N): ")
+	// Confirm before deletion; a dry run goes straight to the overlay
+	if !opts.ci && !opts.dryRun {
+		fmt.Print(tr("\nProceed with deletion? (y/N): "))
 		confirm, _ := stdinReader.ReadString('\n')
 		confirm = strings.TrimSpace(strings.ToLower(confirm))
 		if confirm != "y" {
-			fmt.Println("Operation cancelled.")
-			waitForKeyPress()
-			return
+			fmt.Println(tr("Operation cancelled."))
+			return 0
 		}
 	}
 
 	// Execute deletion
-	fmt.Println("\nDeleting...")
+	if opts.dryRun {
+		fmt.Println(tr("\nDeleting (dry run, in memory only)..."))
+	} else {
+		fmt.Println(tr("\nDeleting..."))
+		if !opts.noNotify {
+			enableNotifications(basePath, "unity_project_full_clean")
+		}
+	}
 	startTime := time.Now()
 
-	deletedCount, failedCount, freedBytes := deleteItems(basePath, items)
+	deletedCount, failedCount, freedBytes, freedBy := deleteItems(basePath, items)
 
 	duration := time.Since(startTime)
+	if !opts.dryRun {
+		run := cleanRun{
+			RecordedAt: startTime.Format("2006-01-02 15:04:05"),
+			DurationMs: duration.Milliseconds(),
+			Deleted:    deletedCount,
+			Failed:     failedCount,
+			Freed:      freedBytes,
+			Categories: freedBy,
+		}
+		if err := recordCleanRun(basePath, run); err != nil {
+			fmt.Printf(tr("[WARNING] Could not record the clean history: %v\n"), err)
+		}
+	}
 
 	// Summary
-	fmt.Println("\n===========================================")
-	fmt.Println("  CLEAN COMPLETE")
-	fmt.Println("===========================================")
-	fmt.Printf("  Deleted: %d items\n", deletedCount)
+	printRule("\n===========================================")
+	fmt.Println(tr("  CLEAN COMPLETE"))
+	printRule("===========================================")
+	fmt.Printf(tr("  Deleted: %d items\n"), deletedCount)
 	if failedCount > 0 {
-		fmt.Printf("  Failed:  %d items\n", failedCount)
+		fmt.Printf(tr("  Failed:  %d items\n"), failedCount)
 	}
-	fmt.Printf("  Freed:   %s\n", formatSize(freedBytes))
-	fmt.Printf("  Time:    %s\n", duration)
+	fmt.Printf(tr("  Freed:   %s\n"), formatSize(freedBytes))
+	fmt.Printf(tr("  Time:    %s\n"), duration)
 
-	if !ciMode {
-		waitForKeyPress()
+	if overlay, ok := fsys.(*overlayFS); ok {
+		overlay.printDryRunReport(basePath)
+		fmt.Println(tr("\n[Dry Run] No files were deleted."))
 	}
+
+	setNotifySummary("Deleted %d item(s), %d failed, freed %s in %s", deletedCount, failedCount, formatSize(freedBytes), duration.Round(time.Second))
+	sendNotifications(0)
+
+	return 0
 }
 
+// ============================================================
+// Entry Point
+// ============================================================
+
+func main() {
+	var opts cleanOptions
+	flag.BoolVar(&opts.ci, "ci", false, "Run in CI mode (non-interactive, no confirmation prompts)")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "Preview what would be deleted without actually deleting")
+	flag.BoolVar(&opts.force, "force", false, "Run even if '.unitystarter.lock' says another tool is working on this project")
+	flag.StringVar(&opts.middleware, "middleware", "", "Also clean generated audio middleware folders: fmod, wwise, auto, none (comma-separated)")
+	flag.BoolVar(&opts.validate, "validate-banks", false, "Check the FMOD/Wwise events and banks scenes reference against the generated banks, then exit (deletes nothing)")
+	flag.StringVar(&opts.throttle, "throttle", "auto", "Delete one item at a time with pauses, for projects on a network share: auto, on, off")
+	flag.BoolVar(&opts.history, "history", false, "Show what earlier cleans freed per folder and how long they took, then exit (deletes nothing)")
+	flag.IntVar(&opts.waitPID, "wait-pid", 0, "Wait for this process to exit before cleaning, e.g. the Unity editor that started the clean and is closing")
+	flag.BoolVar(&opts.noNotify, "no-notify", false, "Do not post the result to the webhooks in .unitystarter.json")
+	flag.BoolVar(&jsonMode, "json", false, "Print a JSON result document on stdout (implies -ci)")
+	flag.StringVar(&langFlag, "lang", "", "UI language: en, zh (default: UNITYSTARTER_LANG or system locale)")
+	flag.BoolVar(&plainMode, "plain", false, "Plain line-based output: no progress bars, box drawing or screen clearing")
+	flag.BoolVar(&plainMode, "no-ansi", false, "Same as -plain")
+	flag.Parse()
+	setLanguage(langFlag)
+	detectPlainMode()
+
+	if jsonMode {
+		enableJSONOutput("unity_project_full_clean")
+		opts.ci = true
+	}
+	exit := func(code int) {
+		if !opts.ci {
+			waitForKeyPress()
+		}
+		exitTool(code)
+	}
 
+	basePath, err := os.Getwd()
+	if err != nil {
+		fmt.Printf(tr("Unable to get current directory: %s\n"), err)
+		recordError("unable to get current directory: %v", err)
+		exit(1)
+	}
+	if err := setupRunner(basePath); err != nil {
+		fmt.Printf(tr("\n[ERROR] Failed to load the command fixture: %v\n"), err)
+		recordError("failed to load the command fixture: %v", err)
+		exit(1)
+	}
+	exit(runClean(basePath, opts))
+}